@@ -0,0 +1,286 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// slackAccount holds the credentials of one Slack app. The default account
+// comes from the SLACK_* variables; further accounts from SLACK_ACCOUNTS,
+// keyed by the account_id kafclaw uses in cfg.Channels.Slack.Accounts.
+type slackAccount struct {
+	ID            string `json:"-"`
+	BotToken      string `json:"bot_token"`
+	AppToken      string `json:"app_token"`
+	SigningSecret string `json:"signing_secret"`
+	BotUserID     string `json:"bot_user_id"`
+	// InboundToken is the kafclaw inbound token of the account; empty falls
+	// back to KAFCLAW_SLACK_INBOUND_TOKEN.
+	InboundToken string `json:"inbound_token"`
+	// WorkspaceTokens are bot tokens by team ID for an app installed into
+	// several workspaces; BotToken serves the others.
+	WorkspaceTokens map[string]string `json:"workspace_tokens"`
+	// WorkspaceID is the workspace whose token BotToken is, once switched
+	// with forWorkspace.
+	WorkspaceID string `json:"-"`
+}
+
+// teamsAccount holds the credentials of one Teams bot app. The default
+// account comes from the MSTEAMS_* variables; further accounts from
+// MSTEAMS_ACCOUNTS, keyed by account_id.
+type teamsAccount struct {
+	ID          string `json:"-"`
+	AppID       string `json:"app_id"`
+	AppPassword string `json:"app_password"`
+	// TenantID falls back to MSTEAMS_TENANT_ID.
+	TenantID string `json:"tenant_id"`
+	// InboundToken falls back to KAFCLAW_MSTEAMS_INBOUND_TOKEN.
+	InboundToken string `json:"inbound_token"`
+}
+
+// parseSlackAccounts reads SLACK_ACCOUNTS, a JSON object of account_id to
+// credentials. Invalid JSON is logged and ignored.
+func parseSlackAccounts(raw string) map[string]slackAccount {
+	var in map[string]slackAccount
+	if !decodeAccounts("SLACK_ACCOUNTS", raw, &in) {
+		return nil
+	}
+	out := make(map[string]slackAccount, len(in))
+	for id, acct := range in {
+		id = normalizeAccountID(id)
+		if id == "" || strings.TrimSpace(acct.BotToken) == "" {
+			slog.Warn("SLACK_ACCOUNTS: skipping account without bot_token", "account", id)
+			continue
+		}
+		acct.ID = id
+		out[id] = acct
+	}
+	return out
+}
+
+// parseTeamsAccounts reads MSTEAMS_ACCOUNTS, a JSON object of account_id to
+// credentials. Invalid JSON is logged and ignored.
+func parseTeamsAccounts(raw string) map[string]teamsAccount {
+	var in map[string]teamsAccount
+	if !decodeAccounts("MSTEAMS_ACCOUNTS", raw, &in) {
+		return nil
+	}
+	out := make(map[string]teamsAccount, len(in))
+	for id, acct := range in {
+		id = normalizeAccountID(id)
+		if id == "" || strings.TrimSpace(acct.AppID) == "" || strings.TrimSpace(acct.AppPassword) == "" {
+			slog.Warn("MSTEAMS_ACCOUNTS: skipping account without app_id/app_password", "account", id)
+			continue
+		}
+		acct.ID = id
+		out[id] = acct
+	}
+	return out
+}
+
+func decodeAccounts(name, raw string, out any) bool {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return false
+	}
+	if err := json.Unmarshal([]byte(raw), out); err != nil {
+		slog.Warn(name+" ignored", "error", err)
+		return false
+	}
+	return true
+}
+
+func normalizeAccountID(id string) string {
+	return strings.ToLower(strings.TrimSpace(id))
+}
+
+// slackDefaultAccount is the account configured by the SLACK_* variables.
+func (b *bridge) slackDefaultAccount() slackAccount {
+	return slackAccount{
+		ID:            bridgeAccountIDOrDefault(b.cfg.SlackAccountID),
+		BotToken:      strings.TrimSpace(b.cfg.SlackBotToken),
+		AppToken:      strings.TrimSpace(b.cfg.SlackAppToken),
+		SigningSecret: strings.TrimSpace(b.cfg.SlackSigningSecret),
+		BotUserID:     strings.TrimSpace(b.cfg.SlackBotUserID),
+		InboundToken:  strings.TrimSpace(b.cfg.KafclawSlackInboundToken),
+
+		WorkspaceTokens: b.cfg.SlackWorkspaceTokens,
+	}
+}
+
+// slackAccount returns the account for accountID. Unknown IDs use the
+// default account, so a bridge with one Slack app serves every account.
+func (b *bridge) slackAccount(accountID string) slackAccount {
+	id := normalizeAccountID(accountID)
+	def := b.slackDefaultAccount()
+	if id == "" || id == normalizeAccountID(def.ID) {
+		return def
+	}
+	acct, ok := b.cfg.SlackAccounts[id]
+	if !ok {
+		return def
+	}
+	if strings.TrimSpace(acct.InboundToken) == "" {
+		acct.InboundToken = def.InboundToken
+	}
+	return acct
+}
+
+// slackAccounts lists the default account followed by the SLACK_ACCOUNTS
+// entries in ID order.
+func (b *bridge) slackAccounts() []slackAccount {
+	out := []slackAccount{b.slackDefaultAccount()}
+	for _, id := range sortedAccountIDs(b.cfg.SlackAccounts) {
+		if id != normalizeAccountID(out[0].ID) {
+			out = append(out, b.slackAccount(id))
+		}
+	}
+	return out
+}
+
+// slackAccountFromQuery reads the account_id and workspace_id query
+// parameters of the resolve and probe endpoints.
+func (b *bridge) slackAccountFromQuery(r *http.Request) slackAccount {
+	return b.slackAccount(accountIDFromQuery(r)).forWorkspace(r.URL.Query().Get("workspace_id"))
+}
+
+// slackAccountForRequest finds the account whose signing secret signed a
+// Slack request. Without a default signing secret, requests no other
+// account signed are taken unverified by the default account, as with a
+// single app.
+func (b *bridge) slackAccountForRequest(body []byte, r *http.Request) (slackAccount, error) {
+	accounts := b.slackAccounts()
+	for _, acct := range accounts {
+		if acct.SigningSecret != "" && verifySlackSignature(body, r, acct.SigningSecret) == nil {
+			return acct, nil
+		}
+	}
+	def := accounts[0]
+	if err := verifySlackSignature(body, r, def.SigningSecret); err != nil {
+		return slackAccount{}, err
+	}
+	return def, nil
+}
+
+// teamsDefaultAccount is the account configured by the MSTEAMS_* variables.
+func (b *bridge) teamsDefaultAccount() teamsAccount {
+	return teamsAccount{
+		ID:           bridgeAccountIDOrDefault(b.cfg.MSTeamsAccountID),
+		AppID:        strings.TrimSpace(b.cfg.MSTeamsAppID),
+		AppPassword:  strings.TrimSpace(b.cfg.MSTeamsAppPassword),
+		TenantID:     strings.TrimSpace(b.cfg.MSTeamsTenantID),
+		InboundToken: strings.TrimSpace(b.cfg.KafclawMSTeamsInboundToken),
+	}
+}
+
+// teamsAccount returns the account for accountID; unknown IDs use the
+// default account.
+func (b *bridge) teamsAccount(accountID string) teamsAccount {
+	id := normalizeAccountID(accountID)
+	def := b.teamsDefaultAccount()
+	if id == "" || id == normalizeAccountID(def.ID) {
+		return def
+	}
+	acct, ok := b.cfg.MSTeamsAccounts[id]
+	if !ok {
+		return def
+	}
+	if strings.TrimSpace(acct.TenantID) == "" {
+		acct.TenantID = def.TenantID
+	}
+	if strings.TrimSpace(acct.InboundToken) == "" {
+		acct.InboundToken = def.InboundToken
+	}
+	return acct
+}
+
+// teamsAccounts lists the default account followed by the MSTEAMS_ACCOUNTS
+// entries in ID order.
+func (b *bridge) teamsAccounts() []teamsAccount {
+	out := []teamsAccount{b.teamsDefaultAccount()}
+	for _, id := range sortedAccountIDs(b.cfg.MSTeamsAccounts) {
+		if id != normalizeAccountID(out[0].ID) {
+			out = append(out, b.teamsAccount(id))
+		}
+	}
+	return out
+}
+
+// teamsAccountForActivity picks the account of the bot an activity is
+// addressed to. Bot Framework sets recipient.id to "28:<app id>".
+func (b *bridge) teamsAccountForActivity(activity map[string]any) teamsAccount {
+	recipient, _ := activity["recipient"].(map[string]any)
+	appID := strings.TrimSpace(asString(recipient["id"]))
+	if _, rest, ok := strings.Cut(appID, ":"); ok {
+		appID = rest
+	}
+	for _, acct := range b.teamsAccounts()[1:] {
+		if appID != "" && strings.EqualFold(acct.AppID, appID) {
+			return acct
+		}
+	}
+	return b.teamsDefaultAccount()
+}
+
+// teamsAccountForRef picks the account to reply with: the requested one, or
+// the account the conversation came in on when kafclaw sends none.
+func (b *bridge) teamsAccountForRef(accountID string, ref teamsConversationRef) teamsAccount {
+	id := normalizeAccountID(accountID)
+	if id == "" || id == "default" {
+		id = ref.AccountID
+	}
+	return b.teamsAccount(id)
+}
+
+// teamsJWTFor returns the token verifier of an account. The default account
+// uses b.jwt; others get a verifier for their app ID on first use.
+func (b *bridge) teamsJWTFor(acct teamsAccount) *teamsJWTVerifier {
+	if acct.ID == b.teamsDefaultAccount().ID {
+		return b.jwt
+	}
+	b.teamsMu.Lock()
+	defer b.teamsMu.Unlock()
+	if v, ok := b.teamsJWTs[acct.ID]; ok {
+		return v
+	}
+	if b.teamsJWTs == nil {
+		b.teamsJWTs = map[string]*teamsJWTVerifier{}
+	}
+	v := newTeamsJWTVerifier(b.client, b.cfg.MSTeamsOpenIDConfig, acct.AppID)
+	b.teamsJWTs[acct.ID] = v
+	return v
+}
+
+// accountIDFromQuery reads the account_id query parameter of the resolve and
+// probe endpoints.
+func accountIDFromQuery(r *http.Request) string {
+	return strings.TrimSpace(r.URL.Query().Get("account_id"))
+}
+
+func slackAccountIDs(accounts []slackAccount) []string {
+	ids := make([]string, len(accounts))
+	for i, acct := range accounts {
+		ids[i] = acct.ID
+	}
+	return ids
+}
+
+func teamsAccountIDs(accounts []teamsAccount) []string {
+	ids := make([]string, len(accounts))
+	for i, acct := range accounts {
+		ids[i] = acct.ID
+	}
+	return ids
+}
+
+func sortedAccountIDs[T any](m map[string]T) []string {
+	ids := make([]string, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func signSlackRequest(req *http.Request, body []byte, secret string) {
+	ts := fmt.Sprintf("%d", time.Now().Unix())
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte("v0:" + ts + ":" + string(body)))
+	req.Header.Set("X-Slack-Request-Timestamp", ts)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+}
+
+func TestSlackEventsRouteToSigningAccount(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		auth string
+		got  map[string]any
+	)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/channels/slack/inbound" {
+			mu.Lock()
+			auth = r.Header.Get("X-Channel-Token")
+			_ = json.NewDecoder(r.Body).Decode(&got)
+			mu.Unlock()
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer api.Close()
+
+	b := newTestBridge(api.URL)
+	b.cfg.SlackSigningSecret = "default-secret"
+	b.cfg.KafclawSlackInboundToken = "default-inbound"
+	b.cfg.SlackAccounts = parseSlackAccounts(`{"Work":{"bot_token":"xoxb-work","signing_secret":"work-secret","inbound_token":"work-inbound"},"broken":{}}`)
+	if len(b.cfg.SlackAccounts) != 1 {
+		t.Fatalf("expected one valid account, got %v", b.cfg.SlackAccounts)
+	}
+
+	body := []byte(`{"type":"event_callback","event_id":"Ev1","event":{"type":"message","channel":"C1","user":"U1","text":"hi","channel_type":"channel","ts":"1.1"}}`)
+	req := httptest.NewRequest(http.MethodPost, "/slack/events", bytes.NewReader(body))
+	signSlackRequest(req, body, "work-secret")
+	rec := httptest.NewRecorder()
+	b.handleSlackEvents(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	mu.Lock()
+	if got["account_id"] != "work" || auth != "work-inbound" {
+		t.Fatalf("expected forward for account work, got account=%v auth=%q", got["account_id"], auth)
+	}
+	mu.Unlock()
+
+	bad := httptest.NewRequest(http.MethodPost, "/slack/events", bytes.NewReader(body))
+	signSlackRequest(bad, body, "unknown-secret")
+	rec = httptest.NewRecorder()
+	b.handleSlackEvents(rec, bad)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected request signed by no account to be rejected, got %d", rec.Code)
+	}
+}
+
+func TestSlackOutboundUsesAccountBotToken(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		tokens []string
+	)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/chat.postMessage" {
+			_ = r.ParseForm()
+			mu.Lock()
+			tokens = append(tokens, r.FormValue("token"))
+			mu.Unlock()
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "channel": "C1", "ts": "1.2"})
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer api.Close()
+
+	b := newTestBridge(api.URL)
+	b.cfg.SlackAPIBase = api.URL
+	b.cfg.SlackBotToken = "xoxb-default"
+	b.cfg.SlackAccounts = parseSlackAccounts(`{"work":{"bot_token":"xoxb-work"}}`)
+
+	for _, accountID := range []string{"work", "", "unknown"} {
+		body := `{"account_id":"` + accountID + `","chat_id":"C1","content":"hello"}`
+		rec := httptest.NewRecorder()
+		b.handleSlackOutbound(rec, httptest.NewRequest(http.MethodPost, "/slack/outbound", strings.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("account %q: expected 200, got %d: %s", accountID, rec.Code, rec.Body.String())
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"xoxb-work", "xoxb-default", "xoxb-default"}
+	if strings.Join(tokens, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected bot tokens %v, got %v", want, tokens)
+	}
+}
+
+func TestTeamsAccountForActivityAndReply(t *testing.T) {
+	b := newTestBridge("http://kafclaw")
+	b.cfg.MSTeamsAppID = "default-app"
+	b.cfg.MSTeamsTenantID = "tenant-default"
+	b.cfg.MSTeamsAccounts = parseTeamsAccounts(`{"ops":{"app_id":"ops-app","app_password":"pw"}}`)
+
+	acct := b.teamsAccountForActivity(map[string]any{"recipient": map[string]any{"id": "28:OPS-APP"}})
+	if acct.ID != "ops" || acct.TenantID != "tenant-default" {
+		t.Fatalf("expected ops account with default tenant, got %+v", acct)
+	}
+	if got := b.teamsAccountForActivity(map[string]any{"recipient": map[string]any{"id": "28:other"}}); got.ID != "default" {
+		t.Fatalf("expected default account for unknown bot, got %+v", got)
+	}
+	ref := teamsConversationRef{ConversationID: "conv-1", AccountID: "ops"}
+	if got := b.teamsAccountForRef("default", ref); got.ID != "ops" {
+		t.Fatalf("expected reply on the conversation account, got %+v", got)
+	}
+	if got := b.teamsAccountForRef("", teamsConversationRef{}); got.ID != "default" {
+		t.Fatalf("expected default account, got %+v", got)
+	}
+}
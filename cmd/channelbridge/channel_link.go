@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path"
+	"strings"
+	"time"
+)
+
+// linkedThreadTTL is how long the thread mapping of a mirrored message is
+// kept after its last use.
+const linkedThreadTTL = 30 * 24 * time.Hour
+
+// channelLink mirrors messages between a Slack channel and a Teams channel
+// conversation ("19:...@thread.tacv2").
+type channelLink struct {
+	SlackChannel      string
+	TeamsConversation string
+}
+
+// linkedThread is the peer of a mirrored thread root: the Slack ts of a
+// Teams thread, or the Teams message ID of a Slack thread.
+type linkedThread struct {
+	Peer  string    `json:"peer"`
+	Until time.Time `json:"until"`
+}
+
+// parseChannelLinks reads CHANNEL_BRIDGE_CHANNEL_LINKS, a comma-separated
+// list of slack_channel=teams_conversation pairs. Thread suffixes of the
+// Teams conversation (";messageid=...") are dropped.
+func parseChannelLinks(raw string) []channelLink {
+	var out []channelLink
+	for _, pair := range strings.Split(raw, ",") {
+		slackChannel, teamsConv, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		slackChannel, teamsConv = strings.ToUpper(strings.TrimSpace(slackChannel)), teamsLinkBase(teamsConv)
+		if !ok || slackChannel == "" || teamsConv == "" {
+			continue
+		}
+		out = append(out, channelLink{SlackChannel: slackChannel, TeamsConversation: teamsConv})
+	}
+	return out
+}
+
+// teamsLinkBase strips the thread of a Teams channel conversation ID.
+func teamsLinkBase(conversationID string) string {
+	base, _, _ := strings.Cut(strings.TrimSpace(conversationID), ";messageid=")
+	return strings.TrimSpace(base)
+}
+
+func (b *bridge) slackChannelLink(channelID string) (channelLink, bool) {
+	channelID = strings.ToUpper(strings.TrimSpace(channelID))
+	for _, l := range b.cfg.ChannelLinks {
+		if l.SlackChannel == channelID {
+			return l, true
+		}
+	}
+	return channelLink{}, false
+}
+
+func (b *bridge) teamsChannelLink(conversationID string) (channelLink, bool) {
+	base := teamsLinkBase(conversationID)
+	for _, l := range b.cfg.ChannelLinks {
+		if l.TeamsConversation == base {
+			return l, true
+		}
+	}
+	return channelLink{}, false
+}
+
+// linkedThreadPeer returns the mirrored root of a thread and refreshes it.
+func (b *bridge) linkedThreadPeer(key string) string {
+	b.linkMu.Lock()
+	defer b.linkMu.Unlock()
+	t, ok := b.linkThreads[key]
+	if !ok || time.Now().After(t.Until) {
+		return ""
+	}
+	t.Until = time.Now().Add(linkedThreadTTL)
+	b.linkThreads[key] = t
+	return t.Peer
+}
+
+// recordLinkedThread records a Slack thread root and its mirrored Teams
+// root in both directions.
+func (b *bridge) recordLinkedThread(slackChannel, slackTS, teamsConv, teamsID string) {
+	if slackTS == "" || teamsID == "" {
+		return
+	}
+	until := time.Now().Add(linkedThreadTTL)
+	b.linkMu.Lock()
+	if b.linkThreads == nil {
+		b.linkThreads = map[string]linkedThread{}
+	}
+	b.linkThreads[slackThreadKey(slackChannel, slackTS)] = linkedThread{Peer: teamsID, Until: until}
+	b.linkThreads[teamsThreadKey(teamsConv, teamsID)] = linkedThread{Peer: slackTS, Until: until}
+	b.linkMu.Unlock()
+	if err := b.saveState(); err != nil {
+		slog.Warn("channelbridge state save warning", "error", err)
+	}
+}
+
+func (b *bridge) pruneLinkThreadsLocked(now time.Time) {
+	for k, t := range b.linkThreads {
+		if now.After(t.Until) {
+			delete(b.linkThreads, k)
+		}
+	}
+}
+
+func slackThreadKey(channelID, ts string) string {
+	return "slack:" + strings.ToUpper(channelID) + ":" + ts
+}
+
+func teamsThreadKey(conversationID, rootID string) string {
+	return "teams:" + teamsLinkBase(conversationID) + ":" + rootID
+}
+
+// mirrorSlackToTeams posts a Slack message into the linked Teams channel,
+// attributed to its author. Replies land in the Teams thread mirroring the
+// Slack thread; a reply in an unknown thread starts a new one. The posted
+// activity is marked seen so it is never mirrored back.
+func (b *bridge) mirrorSlackToTeams(acct slackAccount, in slackInbound) {
+	link, ok := b.slackChannelLink(in.channelID)
+	if !ok || in.messageID == "" {
+		return
+	}
+	if err := b.doMirrorSlackToTeams(acct, link, in); err != nil {
+		b.noteChannelLink(false)
+		slog.Warn("channel link: slack to teams failed", "slack_channel", link.SlackChannel, "error", err)
+		return
+	}
+	b.noteChannelLink(true)
+}
+
+func (b *bridge) doMirrorSlackToTeams(acct slackAccount, link channelLink, in slackInbound) error {
+	ref, err := b.teamsLinkRef(link.TeamsConversation)
+	if err != nil {
+		return err
+	}
+	token, err := b.getTeamsAccessToken(b.teamsAccountForRef(ref.AccountID, ref))
+	if err != nil {
+		return err
+	}
+	slackRoot := firstNonEmpty(in.threadID, in.messageID)
+	teamsRoot := ""
+	if in.threadID != "" {
+		teamsRoot = b.linkedThreadPeer(slackThreadKey(in.channelID, in.threadID))
+	}
+	if teamsRoot != "" {
+		ref.ConversationID = link.TeamsConversation + ";messageid=" + teamsRoot
+	}
+	names := make([]string, 0, len(in.files))
+	for _, f := range in.files {
+		names = append(names, f.Name)
+	}
+	author := b.slackLinkAuthor(acct.forWorkspace(in.workspace.ID), in.senderID)
+	id, err := b.teamsSend(ref, token, "", linkMessageText("**"+author+"** (Slack)", in.text, names), nil, nil)
+	if err != nil {
+		return err
+	}
+	if id == "" {
+		return nil
+	}
+	if teamsRoot == "" {
+		teamsRoot = id
+		b.recordLinkedThread(link.SlackChannel, slackRoot, link.TeamsConversation, teamsRoot)
+	}
+	b.seenInboundEvent("teams:msg:"+link.TeamsConversation+";messageid="+teamsRoot+":"+id, time.Now())
+	return nil
+}
+
+// mirrorTeamsToSlack posts a Teams channel message into the linked Slack
+// channel, threading replies like mirrorSlackToTeams.
+func (b *bridge) mirrorTeamsToSlack(in teamsInbound) {
+	link, ok := b.teamsChannelLink(in.chatID)
+	if !ok || in.messageID == "" {
+		return
+	}
+	if err := b.doMirrorTeamsToSlack(link, in); err != nil {
+		b.noteChannelLink(false)
+		slog.Warn("channel link: teams to slack failed", "teams_conversation", link.TeamsConversation, "error", err)
+		return
+	}
+	b.noteChannelLink(true)
+}
+
+func (b *bridge) doMirrorTeamsToSlack(link channelLink, in teamsInbound) error {
+	teamsRoot := firstNonEmpty(teamsRootMessageID(in.chatID), in.messageID)
+	slackRoot := ""
+	if teamsRoot != in.messageID {
+		slackRoot = b.linkedThreadPeer(teamsThreadKey(link.TeamsConversation, teamsRoot))
+	}
+	names := make([]string, 0, len(in.mediaURLs))
+	for _, u := range in.mediaURLs {
+		names = append(names, path.Base(u))
+	}
+	acct := b.slackChannelAccount(b.slackDefaultAccount(), link.SlackChannel)
+	author := firstNonEmpty(in.senderName, in.senderID)
+	ts, err := b.slackPostCard(acct, link.SlackChannel, slackRoot, linkMessageText("*"+author+"* (Teams)", in.text, names), nil)
+	if err != nil {
+		return err
+	}
+	if ts == "" {
+		return nil
+	}
+	if slackRoot == "" {
+		b.recordLinkedThread(link.SlackChannel, ts, link.TeamsConversation, teamsRoot)
+	}
+	b.seenInboundEvent("slack:msg:"+link.SlackChannel+":"+ts, time.Now())
+	return nil
+}
+
+// teamsLinkRef returns the reference of a linked Teams channel: the stored
+// one, one of its threads, or the configured service URL.
+func (b *bridge) teamsLinkRef(conversationID string) (teamsConversationRef, error) {
+	if ref, err := b.resolveTeamsConversation(conversationID); err == nil {
+		return ref, nil
+	}
+	b.teamsMu.RLock()
+	for id, ref := range b.teamsConvByID {
+		if teamsLinkBase(id) == conversationID && ref.ServiceURL != "" {
+			b.teamsMu.RUnlock()
+			ref.ConversationID = conversationID
+			return ref, nil
+		}
+	}
+	b.teamsMu.RUnlock()
+	if serviceURL := strings.TrimSpace(b.cfg.MSTeamsServiceURL); serviceURL != "" {
+		return teamsConversationRef{ServiceURL: serviceURL, ConversationID: conversationID, AccountID: b.cfg.MSTeamsAccountID}, nil
+	}
+	return teamsConversationRef{}, errors.New("no teams conversation reference for " + conversationID)
+}
+
+// slackLinkAuthor returns the display name of a Slack user, or the ID when
+// it cannot be looked up.
+func (b *bridge) slackLinkAuthor(acct slackAccount, userID string) string {
+	api, err := b.slackClient(acct)
+	if err != nil {
+		return userID
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	msgs := []threadMessage{{SenderID: userID}}
+	b.slackFillSenderNames(ctx, acct, api, msgs)
+	return firstNonEmpty(msgs[0].SenderName, userID)
+}
+
+// linkMessageText prefixes a mirrored message with its author and lists the
+// names of its attachments, which stay on the source platform.
+func linkMessageText(author, text string, attachments []string) string {
+	out := author + ": " + strings.TrimSpace(text)
+	for _, name := range attachments {
+		if name = strings.TrimSpace(name); name != "" && name != "." && name != "/" {
+			out += "\n📎 " + name
+		}
+	}
+	return out
+}
+
+func (b *bridge) noteChannelLink(ok bool) {
+	b.metricsMu.Lock()
+	defer b.metricsMu.Unlock()
+	if ok {
+		b.metrics.ChannelLinkMirrored++
+	} else {
+		b.metrics.ChannelLinkFailed++
+	}
+}
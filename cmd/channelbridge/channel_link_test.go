@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+func TestChannelLinkMirrorsBothWaysWithThreads(t *testing.T) {
+	var mu sync.Mutex
+	var teamsPosts []map[string]any
+	var teamsPaths, slackPosts []string
+	var slackThreads []string
+	forwards := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/api/v1/channels/"):
+			forwards++
+		case r.URL.Path == "/users.info":
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "users": []map[string]any{{"id": "U1", "profile": map[string]any{"display_name": "Ada"}}}})
+		case r.URL.Path == "/chat.postMessage":
+			_ = r.ParseForm()
+			slackPosts = append(slackPosts, r.FormValue("text"))
+			slackThreads = append(slackThreads, r.FormValue("thread_ts"))
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "ts": "300.1"})
+		case strings.HasPrefix(r.URL.Path, "/v3/conversations/"):
+			var body map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			teamsPosts = append(teamsPosts, body)
+			teamsPaths = append(teamsPaths, r.URL.Path)
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "T1"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	b := newTestBridge(srv.URL)
+	b.cfg.SlackAPIBase = srv.URL
+	b.cfg.SlackBotToken = "xoxb-test"
+	b.cfg.MSTeamsAPIBase = srv.URL
+	b.cfg.MSTeamsServiceURL = srv.URL
+	b.cfg.ChannelLinks = parseChannelLinks("c1=19:ch@thread.tacv2")
+	b.teamsToken = tokenCache{accessToken: "token", expiresAt: time.Now().Add(30 * time.Minute)}
+
+	slackEvent := func(eventID, ts, threadTS, text string) {
+		event := map[string]any{"type": "message", "channel": "C1", "channel_type": "channel", "user": "U1", "ts": ts, "text": text}
+		if threadTS != "" {
+			event["thread_ts"] = threadTS
+		}
+		body, _ := json.Marshal(map[string]any{"type": "event_callback", "event_id": eventID, "event": event})
+		w := httptest.NewRecorder()
+		b.handleSlackEvents(w, httptest.NewRequest(http.MethodPost, "/slack/events", bytes.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("slack status=%d body=%s", w.Code, w.Body.String())
+		}
+	}
+	teamsActivity := func(id, conv, text string) map[string]any {
+		body, _ := json.Marshal(map[string]any{
+			"type": "message", "id": id, "text": text, "serviceUrl": srv.URL,
+			"from":         map[string]any{"id": "29:bob", "name": "Bob"},
+			"conversation": map[string]any{"id": conv, "conversationType": "channel"},
+		})
+		w := httptest.NewRecorder()
+		b.handleTeamsMessages(w, httptest.NewRequest(http.MethodPost, "/teams/messages", bytes.NewReader(body)))
+		var out map[string]any
+		_ = json.Unmarshal(w.Body.Bytes(), &out)
+		return out
+	}
+
+	slackEvent("Ev1", "100.1", "", "hello teams")
+	slackEvent("Ev2", "100.2", "100.1", "in the thread")
+	// Slash commands reach the agent but are not channel messages.
+	if err := b.forwardSlackSlashCommand(b.slackAccount(""), slack.SlashCommand{Command: "/ask", Text: "status", UserID: "U1", ChannelID: "C1", TriggerID: "trig-1"}); err != nil {
+		t.Fatalf("slash command: %v", err)
+	}
+	if len(teamsPosts) != 2 {
+		t.Fatalf("expected two mirrored Teams posts, got %d", len(teamsPosts))
+	}
+	if teamsPosts[0]["text"] != "**Ada** (Slack): hello teams" {
+		t.Fatalf("expected author attribution, got %#v", teamsPosts[0]["text"])
+	}
+	if !strings.HasSuffix(teamsPaths[0], "/19:ch@thread.tacv2/activities") || !strings.HasSuffix(teamsPaths[1], "/19:ch@thread.tacv2;messageid=T1/activities") {
+		t.Fatalf("expected the reply in the mirrored thread, got %v", teamsPaths)
+	}
+
+	if out := teamsActivity("T1", "19:ch@thread.tacv2;messageid=T1", "**Ada** (Slack): hello teams"); out["deduped"] != true {
+		t.Fatalf("expected the mirrored post deduped, got %#v", out)
+	}
+	teamsActivity("T2", "19:ch@thread.tacv2;messageid=T1", "hi from teams")
+	if len(slackPosts) != 1 || slackPosts[0] != "*Bob* (Teams): hi from teams" || slackThreads[0] != "100.1" {
+		t.Fatalf("expected the Teams reply in the Slack thread, got %v %v", slackPosts, slackThreads)
+	}
+	if forwards != 4 {
+		t.Fatalf("expected the agent to see the four human messages, got %d", forwards)
+	}
+	if b.metrics.ChannelLinkMirrored != 3 || b.metrics.ChannelLinkFailed != 0 {
+		t.Fatalf("unexpected link metrics %+v", b.metrics)
+	}
+}
+
+func TestParseChannelLinks(t *testing.T) {
+	links := parseChannelLinks(" c1 = 19:a@thread.tacv2;messageid=9 , bad, =19:b@thread.tacv2")
+	if len(links) != 1 || links[0] != (channelLink{SlackChannel: "C1", TeamsConversation: "19:a@thread.tacv2"}) {
+		t.Fatalf("unexpected links %#v", links)
+	}
+}
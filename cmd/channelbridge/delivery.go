@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// outboundDelivery identifies the platform messages created by one outbound
+// request. It is the outbound response and, with DeliveryReceipts enabled,
+// the receipt posted to kafclaw so later edits and reactions can target the
+// message.
+type outboundDelivery struct {
+	Channel   string `json:"channel"`
+	AccountID string `json:"account_id"`
+	ChatID    string `json:"chat_id"`
+	// ChannelID is the resolved platform conversation (Slack channel ID,
+	// Teams conversation ID).
+	ChannelID string `json:"channel_id,omitempty"`
+	ThreadID  string `json:"thread_id,omitempty"`
+	// MessageID is the first created message (Slack ts, Teams activity ID);
+	// MessageIDs lists all of them when a long reply was split.
+	MessageID  string   `json:"message_id,omitempty"`
+	MessageIDs []string `json:"message_ids,omitempty"`
+	FileIDs    []string `json:"file_ids,omitempty"`
+	// Visibility is set when the reply went to one user only ("ephemeral"
+	// or "dm"); ephemeral Slack replies have no message IDs.
+	Visibility string `json:"visibility,omitempty"`
+	TaskID     string `json:"task_id,omitempty"`
+	TraceID    string `json:"trace_id,omitempty"`
+}
+
+// sent reports whether the delivery already created a message or file, so a
+// failed request is no longer safe to replay from the start.
+func (d *outboundDelivery) sent() bool {
+	return len(d.MessageIDs) > 0 || len(d.FileIDs) > 0
+}
+
+func (d *outboundDelivery) addMessageIDs(ids ...string) {
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		if d.MessageID == "" {
+			d.MessageID = id
+		}
+		d.MessageIDs = append(d.MessageIDs, id)
+	}
+}
+
+func writeOutboundDelivery(w http.ResponseWriter, d outboundDelivery) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(struct {
+		OK bool `json:"ok"`
+		outboundDelivery
+	}{true, d})
+}
+
+// postDeliveryReceipt reports d to kafclaw in the background, in the trace of
+// the reply. Receipts are best effort: a failure is logged and never fails
+// the send.
+func (b *bridge) postDeliveryReceipt(d outboundDelivery, token string) {
+	if !b.cfg.DeliveryReceipts || (d.MessageID == "" && len(d.FileIDs) == 0) {
+		return
+	}
+	data, _ := json.Marshal(d)
+	var payload map[string]any
+	_ = json.Unmarshal(data, &payload)
+	done := b.trackInflight()
+	go func() {
+		defer done()
+		if err := b.postInboundTrace("/api/v1/channels/"+d.Channel+"/delivery", token, traceparentFor(d.TraceID), payload); err != nil {
+			slog.Warn("delivery receipt failed", "channel", d.Channel, "chat", d.ChatID, "message", d.MessageID, "error", err)
+		}
+	}()
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSlackOutboundReturnsMessageIDsAndPostsReceipt(t *testing.T) {
+	var posts int32
+	slackAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat.postMessage" {
+			http.NotFound(w, r)
+			return
+		}
+		n := atomic.AddInt32(&posts, 1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "channel": "C111", "ts": fmt.Sprintf("171.%d", n)})
+	}))
+	defer slackAPI.Close()
+
+	receipts := make(chan map[string]any, 1)
+	kafclaw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/channels/slack/delivery" || r.Header.Get("X-Channel-Token") != "in-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		receipts <- body
+	}))
+	defer kafclaw.Close()
+
+	b := newTestBridge(kafclaw.URL)
+	b.cfg.SlackAPIBase = slackAPI.URL
+	b.cfg.SlackBotToken = "xoxb-test"
+	b.cfg.KafclawSlackInboundToken = "in-token"
+	b.cfg.DeliveryReceipts = true
+
+	body, _ := json.Marshal(map[string]any{
+		"chat_id":   "C111",
+		"thread_id": "170.1",
+		"content":   strings.Repeat("word ", 1000),
+		"task_id":   "task-1",
+		"trace_id":  "trace-1",
+	})
+	w := httptest.NewRecorder()
+	b.handleSlackOutbound(w, httptest.NewRequest(http.MethodPost, "/slack/outbound", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		OK         bool     `json:"ok"`
+		ChannelID  string   `json:"channel_id"`
+		ThreadID   string   `json:"thread_id"`
+		MessageID  string   `json:"message_id"`
+		MessageIDs []string `json:"message_ids"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.OK || resp.ChannelID != "C111" || resp.ThreadID != "170.1" || resp.MessageID != "171.1" ||
+		strings.Join(resp.MessageIDs, ",") != "171.1,171.2" {
+		t.Fatalf("unexpected outbound response: %s", w.Body.String())
+	}
+
+	select {
+	case got := <-receipts:
+		if got["chat_id"] != "C111" || got["thread_id"] != "170.1" || got["message_id"] != "171.1" ||
+			got["task_id"] != "task-1" || got["trace_id"] != "trace-1" || got["account_id"] != "default" {
+			t.Fatalf("unexpected receipt: %+v", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no delivery receipt posted")
+	}
+}
+
+func TestTeamsOutboundReturnsActivityID(t *testing.T) {
+	teamsAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": "1700000000001"})
+	}))
+	defer teamsAPI.Close()
+
+	b := newTestBridge("http://example.invalid")
+	b.cfg.MSTeamsAPIBase = teamsAPI.URL
+	b.teamsMu.Lock()
+	b.teamsConvByID["conv-1"] = teamsConversationRef{ServiceURL: teamsAPI.URL, ConversationID: "conv-1", UserID: "u1"}
+	b.teamsToken = tokenCache{accessToken: "token", expiresAt: time.Now().Add(30 * time.Minute)}
+	b.teamsMu.Unlock()
+
+	body, _ := json.Marshal(map[string]any{"chat_id": "conv-1", "content": "hello"})
+	w := httptest.NewRecorder()
+	b.handleTeamsOutbound(w, httptest.NewRequest(http.MethodPost, "/teams/outbound", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	var resp map[string]any
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp["ok"] != true || resp["message_id"] != "1700000000001" || resp["channel_id"] != "conv-1" {
+		t.Fatalf("unexpected outbound response: %s", w.Body.String())
+	}
+}
@@ -0,0 +1,980 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Discord gateway intents the bridge asks for by default: guilds, guild and
+// direct messages, and message content (a privileged intent that must be
+// enabled for the bot in the developer portal).
+const (
+	discordIntentGuilds         = 1 << 0
+	discordIntentGuildMessages  = 1 << 9
+	discordIntentDirectMessages = 1 << 12
+	discordIntentMessageContent = 1 << 15
+
+	defaultDiscordIntents = discordIntentGuilds | discordIntentGuildMessages | discordIntentDirectMessages | discordIntentMessageContent
+)
+
+// Discord gateway opcodes.
+const (
+	discordOpDispatch       = 0
+	discordOpHeartbeat      = 1
+	discordOpIdentify       = 2
+	discordOpResume         = 6
+	discordOpReconnect      = 7
+	discordOpInvalidSession = 9
+	discordOpHello          = 10
+	discordOpHeartbeatACK   = 11
+)
+
+const (
+	// discordChunkChars keeps chunks, with a closed code fence, under the
+	// 2000-character message limit.
+	discordChunkChars        = 1990
+	discordGatewayMaxBackoff = time.Minute
+)
+
+var discordSnowflakePattern = regexp.MustCompile(`^[0-9]{15,21}$`)
+
+// discordSession is the gateway session the bridge resumes after a
+// reconnect, so no dispatched events are lost.
+type discordSession struct {
+	id        string
+	resumeURL string
+	seq       int64
+}
+
+type discordGatewayPayload struct {
+	Op int             `json:"op"`
+	D  json.RawMessage `json:"d"`
+	S  *int64          `json:"s,omitempty"`
+	T  string          `json:"t,omitempty"`
+}
+
+// discordFatalCloseError is a gateway close the bridge must not retry, e.g.
+// a wrong token or disallowed intents.
+type discordFatalCloseError struct {
+	code   int
+	reason string
+}
+
+func (e *discordFatalCloseError) Error() string {
+	return fmt.Sprintf("discord gateway closed: %d %s", e.code, e.reason)
+}
+
+// discordAPIError is a non-2xx answer of the Discord REST API.
+type discordAPIError struct {
+	status     int
+	message    string
+	retryAfter time.Duration
+}
+
+func (e *discordAPIError) Error() string {
+	return fmt.Sprintf("discord api status=%d: %s", e.status, e.message)
+}
+
+// discordInbound is a Discord message normalized to the inbound contract.
+type discordInbound struct {
+	senderID     string
+	channelID    string
+	guildID      string
+	threadID     string
+	messageID    string
+	text         string
+	isGroup      bool
+	wasMentioned bool
+}
+
+type discordMessageEvent struct {
+	ID        string `json:"id"`
+	ChannelID string `json:"channel_id"`
+	GuildID   string `json:"guild_id"`
+	Content   string `json:"content"`
+	Author    struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+		Bot      bool   `json:"bot"`
+	} `json:"author"`
+	Mentions []struct {
+		ID string `json:"id"`
+	} `json:"mentions"`
+	MessageReference *struct {
+		MessageID string `json:"message_id"`
+	} `json:"message_reference"`
+	Attachments []struct {
+		Filename string `json:"filename"`
+		URL      string `json:"url"`
+	} `json:"attachments"`
+}
+
+// normalizeDiscordMessage maps a MESSAGE_CREATE event to an inbound message.
+// Messages of bots (including the bridge's own) are dropped. A reply to a
+// message keeps the referenced message as its thread, so the answer replies
+// to the same message.
+func normalizeDiscordMessage(raw json.RawMessage, botUserID string) (discordInbound, bool) {
+	var ev discordMessageEvent
+	if err := json.Unmarshal(raw, &ev); err != nil {
+		return discordInbound{}, false
+	}
+	if ev.Author.Bot || ev.Author.ID == "" || ev.ChannelID == "" || (botUserID != "" && ev.Author.ID == botUserID) {
+		return discordInbound{}, false
+	}
+	text := strings.TrimSpace(ev.Content)
+	for _, a := range ev.Attachments {
+		text = strings.TrimSpace(text + "\n[attachment: " + firstNonEmpty(a.Filename, "file") + " " + a.URL + "]")
+	}
+	if text == "" {
+		return discordInbound{}, false
+	}
+	in := discordInbound{
+		senderID:  ev.Author.ID,
+		channelID: ev.ChannelID,
+		guildID:   ev.GuildID,
+		messageID: ev.ID,
+		text:      text,
+		isGroup:   ev.GuildID != "",
+	}
+	if ev.MessageReference != nil {
+		in.threadID = strings.TrimSpace(ev.MessageReference.MessageID)
+	}
+	if botUserID != "" {
+		for _, m := range ev.Mentions {
+			if m.ID == botUserID {
+				in.wasMentioned = true
+			}
+		}
+		if strings.Contains(ev.Content, "<@"+botUserID+">") || strings.Contains(ev.Content, "<@!"+botUserID+">") {
+			in.wasMentioned = true
+		}
+	}
+	return in, true
+}
+
+func (b *bridge) forwardDiscordInbound(in discordInbound) error {
+	seenKey := ""
+	if in.messageID != "" {
+		seenKey = "discord:msg:" + in.channelID + ":" + in.messageID
+	}
+	if b.seenInboundEvent(seenKey, time.Now()) {
+		b.noteInboundDeduped("discord")
+		return nil
+	}
+	queued, err := b.forwardInbound("discord", "/api/v1/channels/discord/inbound", in.channelID, map[string]any{
+		"account_id":       strings.TrimSpace(b.cfg.DiscordAccountID),
+		"sender_id":        in.senderID,
+		"chat_id":          in.channelID,
+		"group_id":         in.guildID,
+		"thread_id":        in.threadID,
+		"message_id":       in.messageID,
+		"text":             in.text,
+		"is_group":         in.isGroup,
+		"was_mentioned":    in.wasMentioned,
+		"history_limit":    b.cfg.DiscordHistoryLimit,
+		"dm_history_limit": b.cfg.DiscordDMHistoryLimit,
+	})
+	if err != nil {
+		b.releaseRateLimited(err, seenKey)
+		b.noteInboundForward(false, err)
+		slog.Warn("discord inbound forward failed", "error", err)
+		return err
+	}
+	if queued {
+		return nil
+	}
+	b.metricsMu.Lock()
+	b.metrics.DiscordInboundForwarded++
+	b.metricsMu.Unlock()
+	return nil
+}
+
+// startDiscordGateway connects to the Discord gateway in the background and
+// reconnects, resuming the session where possible, until Discord rejects the
+// bot for good.
+func (b *bridge) startDiscordGateway() {
+	if strings.TrimSpace(b.cfg.DiscordBotToken) == "" || !b.cfg.DiscordGateway {
+		return
+	}
+	go func() {
+		backoff := time.Second
+		for {
+			start := time.Now()
+			err := b.runDiscordGateway(context.Background())
+			var fatal *discordFatalCloseError
+			if errors.As(err, &fatal) {
+				slog.Warn("discord gateway stopped", "error", err)
+				b.noteDiscordSocket("stopped", err)
+				return
+			}
+			slog.Warn("discord gateway disconnected", "error", err)
+			b.noteDiscordSocket("disconnected", err)
+			if time.Since(start) > discordGatewayMaxBackoff {
+				backoff = time.Second
+			}
+			time.Sleep(backoff)
+			backoff = min(backoff*2, discordGatewayMaxBackoff)
+		}
+	}()
+}
+
+// runDiscordGateway runs one gateway connection until it drops.
+func (b *bridge) runDiscordGateway(ctx context.Context) error {
+	b.noteDiscordSocket("connecting", nil)
+	b.discordMu.Lock()
+	session := b.discordSession
+	b.discordMu.Unlock()
+	gatewayURL := session.resumeURL
+	if session.id == "" || gatewayURL == "" {
+		var err error
+		if gatewayURL, err = b.discordGatewayURL(ctx); err != nil {
+			return err
+		}
+	}
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, discordGatewayEndpoint(gatewayURL), nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var hello discordGatewayPayload
+	if err := conn.ReadJSON(&hello); err != nil {
+		return err
+	}
+	if hello.Op != discordOpHello {
+		return fmt.Errorf("discord gateway: expected hello, got op %d", hello.Op)
+	}
+	var helloData struct {
+		HeartbeatInterval int64 `json:"heartbeat_interval"`
+	}
+	_ = json.Unmarshal(hello.D, &helloData)
+	if helloData.HeartbeatInterval <= 0 {
+		return errors.New("discord gateway: hello without heartbeat interval")
+	}
+
+	var writeMu sync.Mutex
+	send := func(op int, d any) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return conn.WriteJSON(map[string]any{"op": op, "d": d})
+	}
+	token := strings.TrimSpace(b.cfg.DiscordBotToken)
+	if session.id != "" {
+		err = send(discordOpResume, map[string]any{"token": token, "session_id": session.id, "seq": session.seq})
+	} else {
+		err = send(discordOpIdentify, map[string]any{
+			"token":   token,
+			"intents": b.cfg.DiscordIntents,
+			"properties": map[string]string{
+				"os":      "linux",
+				"browser": "kafclaw-channelbridge",
+				"device":  "kafclaw-channelbridge",
+			},
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	// Heartbeats carry the last sequence number. A heartbeat that is not
+	// acknowledged before the next one marks a zombie connection.
+	var acked sync.Mutex
+	ackPending := false
+	heartbeat := func() error {
+		acked.Lock()
+		ackPending = true
+		acked.Unlock()
+		b.discordMu.Lock()
+		seq := b.discordSession.seq
+		b.discordMu.Unlock()
+		if seq == 0 {
+			return send(discordOpHeartbeat, nil)
+		}
+		return send(discordOpHeartbeat, seq)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		interval := time.Duration(helloData.HeartbeatInterval) * time.Millisecond
+		timer := time.NewTimer(time.Duration(rand.Int64N(int64(interval))))
+		defer timer.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-timer.C:
+			}
+			acked.Lock()
+			zombie := ackPending
+			acked.Unlock()
+			if zombie {
+				slog.Warn("discord gateway heartbeat not acknowledged; reconnecting")
+				_ = conn.Close()
+				return
+			}
+			if err := heartbeat(); err != nil {
+				_ = conn.Close()
+				return
+			}
+			timer.Reset(interval)
+		}
+	}()
+
+	for {
+		var p discordGatewayPayload
+		if err := conn.ReadJSON(&p); err != nil {
+			return b.discordCloseError(err)
+		}
+		switch p.Op {
+		case discordOpDispatch:
+			if p.S != nil {
+				b.discordMu.Lock()
+				b.discordSession.seq = *p.S
+				b.discordMu.Unlock()
+			}
+			b.handleDiscordDispatch(p.T, p.D)
+		case discordOpHeartbeat:
+			if err := heartbeat(); err != nil {
+				return err
+			}
+		case discordOpHeartbeatACK:
+			acked.Lock()
+			ackPending = false
+			acked.Unlock()
+		case discordOpReconnect:
+			return errors.New("discord gateway requested reconnect")
+		case discordOpInvalidSession:
+			var resumable bool
+			_ = json.Unmarshal(p.D, &resumable)
+			if !resumable {
+				b.resetDiscordSession()
+			}
+			// Discord asks for a random 1-5s wait before identifying again.
+			time.Sleep(time.Second + time.Duration(rand.Int64N(int64(4*time.Second))))
+			return errors.New("discord gateway session invalidated")
+		}
+	}
+}
+
+func (b *bridge) handleDiscordDispatch(eventType string, data json.RawMessage) {
+	switch eventType {
+	case "READY":
+		var ready struct {
+			SessionID        string `json:"session_id"`
+			ResumeGatewayURL string `json:"resume_gateway_url"`
+			User             struct {
+				ID       string `json:"id"`
+				Username string `json:"username"`
+			} `json:"user"`
+		}
+		_ = json.Unmarshal(data, &ready)
+		b.discordMu.Lock()
+		b.discordSession.id = ready.SessionID
+		b.discordSession.resumeURL = ready.ResumeGatewayURL
+		if ready.User.ID != "" {
+			b.discordBotUserID = ready.User.ID
+		}
+		b.discordMu.Unlock()
+		slog.Info("discord gateway ready", "user", ready.User.Username)
+		b.noteDiscordSocket("connected", nil)
+	case "RESUMED":
+		b.noteDiscordSocket("connected", nil)
+	case "MESSAGE_CREATE":
+		if in, ok := normalizeDiscordMessage(data, b.discordBotID()); ok {
+			_ = b.forwardDiscordInbound(in)
+		}
+	}
+}
+
+// discordCloseError classifies a gateway read error. Closes that require a
+// new session reset it; closes that retrying cannot fix are fatal.
+func (b *bridge) discordCloseError(err error) error {
+	var ce *websocket.CloseError
+	if !errors.As(err, &ce) {
+		return err
+	}
+	switch ce.Code {
+	case 4004, 4010, 4011, 4012, 4013, 4014:
+		// authentication failed, invalid shard, sharding required,
+		// invalid API version, invalid or disallowed intents
+		return &discordFatalCloseError{code: ce.Code, reason: ce.Text}
+	case 4007, 4009:
+		// invalid sequence, session timed out
+		b.resetDiscordSession()
+	}
+	return err
+}
+
+func (b *bridge) resetDiscordSession() {
+	b.discordMu.Lock()
+	b.discordSession = discordSession{}
+	b.discordMu.Unlock()
+}
+
+// discordGatewayEndpoint adds the API version and encoding to a gateway URL.
+func discordGatewayEndpoint(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return raw
+	}
+	q := u.Query()
+	q.Set("v", "10")
+	q.Set("encoding", "json")
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// discordGatewayURL returns the configured gateway URL or asks Discord for it.
+func (b *bridge) discordGatewayURL(ctx context.Context) (string, error) {
+	if u := strings.TrimSpace(b.cfg.DiscordGatewayURL); u != "" {
+		return u, nil
+	}
+	var out struct {
+		URL string `json:"url"`
+	}
+	if err := b.discordAPI(ctx, http.MethodGet, "/gateway/bot", "", nil, &out); err != nil {
+		return "", err
+	}
+	if out.URL == "" {
+		return "", errors.New("discord /gateway/bot returned no url")
+	}
+	return out.URL, nil
+}
+
+// discordBotID returns the bot user ID from READY or the configuration.
+func (b *bridge) discordBotID() string {
+	b.discordMu.Lock()
+	defer b.discordMu.Unlock()
+	return firstNonEmpty(b.discordBotUserID, b.cfg.DiscordBotUserID)
+}
+
+// noteDiscordSocket records a gateway connection state change.
+func (b *bridge) noteDiscordSocket(state string, err error) {
+	b.socketMu.Lock()
+	defer b.socketMu.Unlock()
+	b.discordSocket.set(state, err)
+}
+
+// discordAPI calls the Discord REST API. Rate limits and server errors are
+// retried; out, when set, receives the decoded JSON answer.
+func (b *bridge) discordAPI(ctx context.Context, method, apiPath, traceparent string, body, out any) error {
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	return b.discordDo(ctx, method, apiPath, traceparent, "application/json", data, out)
+}
+
+func (b *bridge) discordDo(ctx context.Context, method, apiPath, traceparent, contentType string, data []byte, out any) error {
+	token := strings.TrimSpace(b.cfg.DiscordBotToken)
+	if token == "" {
+		return errors.New("DISCORD_BOT_TOKEN not configured")
+	}
+	endpoint := strings.TrimRight(b.cfg.DiscordAPIBase, "/") + apiPath
+	return withRetry(3, 250*time.Millisecond, func() (bool, error) {
+		var reader io.Reader
+		if data != nil {
+			reader = bytes.NewReader(data)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+		if err != nil {
+			return false, err
+		}
+		req.Header.Set("Authorization", "Bot "+token)
+		if data != nil {
+			req.Header.Set("Content-Type", contentType)
+		}
+		if traceparent != "" {
+			req.Header.Set(traceparentHeader, traceparent)
+		}
+		resp, err := b.client.Do(req)
+		if err != nil {
+			return true, err
+		}
+		defer resp.Body.Close()
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+		if resp.StatusCode >= 300 {
+			apiErr := &discordAPIError{status: resp.StatusCode, message: strings.TrimSpace(string(raw))}
+			var body struct {
+				Message    string  `json:"message"`
+				RetryAfter float64 `json:"retry_after"`
+			}
+			if json.Unmarshal(raw, &body) == nil {
+				apiErr.message = firstNonEmpty(body.Message, apiErr.message)
+				apiErr.retryAfter = time.Duration(body.RetryAfter * float64(time.Second))
+			}
+			if resp.StatusCode == http.StatusTooManyRequests {
+				time.Sleep(min(apiErr.retryAfter, 10*time.Second))
+				return true, apiErr
+			}
+			return resp.StatusCode >= 500, apiErr
+		}
+		if out != nil && len(bytes.TrimSpace(raw)) > 0 {
+			return false, json.Unmarshal(raw, out)
+		}
+		return false, nil
+	})
+}
+
+// normalizeDiscordTarget strips the "discord:" prefix of a chat ID.
+func normalizeDiscordTarget(v string) string {
+	s := strings.TrimSpace(v)
+	if strings.HasPrefix(strings.ToLower(s), "discord:") {
+		s = strings.TrimSpace(s[len("discord:"):])
+	}
+	return s
+}
+
+// resolveDiscordChannelID maps a chat ID to the channel to post in: a
+// channel ID ("channel:<id>" or bare) as is, "user:<id>" to the DM channel
+// with that user.
+func (b *bridge) resolveDiscordChannelID(ctx context.Context, chatID string) (string, error) {
+	target := normalizeDiscordTarget(chatID)
+	lower := strings.ToLower(target)
+	switch {
+	case target == "":
+		return "", errors.New("empty chat id")
+	case strings.HasPrefix(lower, "channel:"):
+		return strings.TrimSpace(target[len("channel:"):]), nil
+	case !strings.HasPrefix(lower, "user:"):
+		return target, nil
+	}
+	userID := strings.TrimSpace(target[len("user:"):])
+	b.discordMu.Lock()
+	channelID, ok := b.discordDMs[userID]
+	b.discordMu.Unlock()
+	if ok {
+		return channelID, nil
+	}
+	var dm struct {
+		ID string `json:"id"`
+	}
+	if err := b.discordAPI(ctx, http.MethodPost, "/users/@me/channels", "", map[string]any{"recipient_id": userID}, &dm); err != nil {
+		return "", fmt.Errorf("open discord dm with %s: %w", userID, err)
+	}
+	b.discordMu.Lock()
+	if b.discordDMs == nil {
+		b.discordDMs = map[string]string{}
+	}
+	b.discordDMs[userID] = dm.ID
+	b.discordMu.Unlock()
+	return dm.ID, nil
+}
+
+// discordPostMessage posts text, split into chunks under the message limit.
+// The first chunk replies to replyTo when set.
+func (b *bridge) discordPostMessage(ctx context.Context, channelID, replyTo, text, traceparent string) ([]string, error) {
+	var ids []string
+	for i, chunk := range splitSlackMarkdownChunks(text, discordChunkChars) {
+		body := map[string]any{
+			"content":          chunk,
+			"allowed_mentions": map[string]any{"parse": []string{"users", "roles"}},
+		}
+		if i == 0 && replyTo != "" {
+			body["message_reference"] = map[string]any{"message_id": replyTo, "fail_if_not_exists": false}
+		}
+		var msg struct {
+			ID string `json:"id"`
+		}
+		if err := b.discordAPI(ctx, http.MethodPost, "/channels/"+url.PathEscape(channelID)+"/messages", traceparent, body, &msg); err != nil {
+			return ids, err
+		}
+		ids = append(ids, msg.ID)
+	}
+	return ids, nil
+}
+
+// discordUploadMedia uploads a generated file ("data:" URL) as a message
+// attachment.
+func (b *bridge) discordUploadMedia(ctx context.Context, channelID, replyTo, mediaURL, traceparent string) (string, error) {
+	data, mimeType, _, err := decodeDataURL(mediaURL)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	payload := map[string]any{"attachments": []map[string]any{{"id": 0, "filename": "upload" + mediaExtForType(mimeType)}}}
+	if replyTo != "" {
+		payload["message_reference"] = map[string]any{"message_id": replyTo, "fail_if_not_exists": false}
+	}
+	payloadJSON, _ := json.Marshal(payload)
+	_ = mw.WriteField("payload_json", string(payloadJSON))
+	part, err := mw.CreateFormFile("files[0]", "upload"+mediaExtForType(mimeType))
+	if err != nil {
+		return "", err
+	}
+	_, _ = part.Write(data)
+	_ = mw.Close()
+	var msg struct {
+		ID string `json:"id"`
+	}
+	err = b.discordDo(ctx, http.MethodPost, "/channels/"+url.PathEscape(channelID)+"/messages", traceparent, mw.FormDataContentType(), buf.Bytes(), &msg)
+	return msg.ID, err
+}
+
+func (b *bridge) handleDiscordOutbound(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		AccountID string   `json:"account_id"`
+		ChatID    string   `json:"chat_id"`
+		ThreadID  string   `json:"thread_id"`
+		ReplyMode string   `json:"reply_mode"`
+		Content   string   `json:"content"`
+		MediaURLs []string `json:"media_urls"`
+		Action    string   `json:"action"`
+		TaskID    string   `json:"task_id"`
+		TraceID   string   `json:"trace_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.ChatID) == "" {
+		http.Error(w, "chat_id required", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	traceparent := childTraceparent(r.Header.Get(traceparentHeader))
+	action := strings.ToLower(strings.TrimSpace(req.Action))
+	if action != "" && action != "typing" {
+		http.Error(w, "unsupported discord action: "+action, http.StatusBadRequest)
+		return
+	}
+	if action == "" && strings.TrimSpace(req.Content) == "" && len(req.MediaURLs) == 0 {
+		http.Error(w, "content or media_urls required", http.StatusBadRequest)
+		return
+	}
+	channelID, err := b.resolveDiscordChannelID(ctx, req.ChatID)
+	if err != nil {
+		b.noteOutbound(false, "discord", err)
+		writeOutboundError(w, err, false)
+		return
+	}
+	if action == "typing" {
+		// Discord shows typing for about ten seconds; KafClaw refreshes it.
+		if err := b.discordAPI(ctx, http.MethodPost, "/channels/"+url.PathEscape(channelID)+"/typing", traceparent, nil, nil); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
+		return
+	}
+	accountID := bridgeAccountIDOrDefault(req.AccountID)
+	replyTo := b.resolveReplyThread("discord", accountID, req.ChatID, req.ThreadID, req.ReplyMode, b.cfg.DiscordReplyMode)
+	delivery := outboundDelivery{
+		Channel:   "discord",
+		AccountID: accountID,
+		ChatID:    strings.TrimSpace(req.ChatID),
+		ChannelID: channelID,
+		ThreadID:  strings.TrimSpace(replyTo),
+		TaskID:    strings.TrimSpace(req.TaskID),
+		TraceID:   strings.TrimSpace(req.TraceID),
+	}
+	// Remote media is linked so Discord unfurls it; generated files are
+	// uploaded as attachments.
+	text := req.Content
+	var uploads []string
+	for _, m := range req.MediaURLs {
+		m = strings.TrimSpace(m)
+		switch {
+		case m == "":
+		case strings.HasPrefix(strings.ToLower(m), "data:"):
+			uploads = append(uploads, m)
+		default:
+			text = strings.TrimSpace(text + "\n" + m)
+		}
+	}
+	if strings.TrimSpace(text) != "" {
+		ids, err := b.discordPostMessage(ctx, channelID, replyTo, text, traceparent)
+		delivery.addMessageIDs(ids...)
+		if err != nil {
+			b.noteOutbound(false, "discord", err)
+			writeOutboundError(w, err, delivery.sent())
+			return
+		}
+		replyTo = ""
+	}
+	for _, m := range uploads {
+		id, err := b.discordUploadMedia(ctx, channelID, replyTo, m, traceparent)
+		if err != nil {
+			b.noteOutbound(false, "discord", err)
+			writeOutboundError(w, err, delivery.sent())
+			return
+		}
+		delivery.FileIDs = append(delivery.FileIDs, id)
+		delivery.addMessageIDs(id)
+		replyTo = ""
+	}
+	b.noteOutbound(true, "discord", nil)
+	b.postDeliveryReceipt(delivery, b.cfg.KafclawDiscordInboundToken)
+	writeOutboundDelivery(w, delivery)
+}
+
+func (b *bridge) handleDiscordResolveUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Entries []string `json:"entries"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	out, err := b.discordResolveUsers(r.Context(), req.Entries)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"results": out})
+}
+
+func (b *bridge) handleDiscordResolveChannels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Entries []string `json:"entries"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	out, err := b.discordResolveChannels(r.Context(), req.Entries)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"results": out})
+}
+
+// discordResolveUsers resolves user IDs and mentions as is and names by a
+// member search in the bot's guilds.
+func (b *bridge) discordResolveUsers(ctx context.Context, entries []string) ([]map[string]any, error) {
+	out := make([]map[string]any, 0, len(entries))
+	var guilds []string
+	for _, raw := range entries {
+		q := strings.TrimSpace(raw)
+		if q == "" {
+			out = append(out, map[string]any{"input": raw, "resolved": false, "note": "empty input"})
+			continue
+		}
+		qNorm := strings.TrimPrefix(strings.TrimPrefix(normalizeDiscordTarget(q), "user:"), "@")
+		qNorm = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(qNorm, "<@!"), "<@"), ">")
+		if discordSnowflakePattern.MatchString(qNorm) {
+			out = append(out, map[string]any{"input": raw, "resolved": true, "type": "user", "id": qNorm, "mention": "<@" + qNorm + ">"})
+			continue
+		}
+		if guilds == nil {
+			var err error
+			if guilds, err = b.discordGuildIDs(ctx); err != nil {
+				return nil, err
+			}
+		}
+		entry := map[string]any{"input": raw, "resolved": false}
+		for _, guildID := range guilds {
+			var members []struct {
+				Nick string `json:"nick"`
+				User struct {
+					ID         string `json:"id"`
+					Username   string `json:"username"`
+					GlobalName string `json:"global_name"`
+				} `json:"user"`
+			}
+			query := url.Values{"query": {qNorm}, "limit": {"10"}}
+			if err := b.discordAPI(ctx, http.MethodGet, "/guilds/"+url.PathEscape(guildID)+"/members/search?"+query.Encode(), "", nil, &members); err != nil {
+				return nil, err
+			}
+			for _, m := range members {
+				if strings.EqualFold(qNorm, m.User.Username) || strings.EqualFold(qNorm, m.User.GlobalName) || strings.EqualFold(qNorm, m.Nick) {
+					entry = map[string]any{"input": raw, "resolved": true, "type": "user", "id": m.User.ID, "mention": "<@" + m.User.ID + ">", "name": m.User.Username}
+					break
+				}
+			}
+			if entry["resolved"] == true {
+				break
+			}
+		}
+		out = append(out, entry)
+	}
+	return out, nil
+}
+
+// discordResolveChannels resolves channel IDs as is and names against the
+// channels of the bot's guilds.
+func (b *bridge) discordResolveChannels(ctx context.Context, entries []string) ([]map[string]any, error) {
+	out := make([]map[string]any, 0, len(entries))
+	var channels []map[string]any
+	loaded := false
+	for _, raw := range entries {
+		q := strings.TrimSpace(raw)
+		if q == "" {
+			out = append(out, map[string]any{"input": raw, "resolved": false, "note": "empty input"})
+			continue
+		}
+		qNorm := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(normalizeDiscordTarget(q)), "channel:"), "#")
+		qNorm = strings.TrimSuffix(strings.TrimPrefix(qNorm, "<#"), ">")
+		if discordSnowflakePattern.MatchString(qNorm) {
+			out = append(out, map[string]any{"input": raw, "resolved": true, "id": qNorm})
+			continue
+		}
+		if !loaded {
+			var err error
+			if channels, err = b.discordListChannels(ctx); err != nil {
+				return nil, err
+			}
+			loaded = true
+		}
+		entry := map[string]any{"input": raw, "resolved": false}
+		for _, c := range channels {
+			if strings.EqualFold(qNorm, asString(c["name"])) {
+				entry = map[string]any{"input": raw, "resolved": true, "id": asString(c["id"]), "name": asString(c["name"]), "guild_id": asString(c["guild_id"])}
+				break
+			}
+		}
+		out = append(out, entry)
+	}
+	return out, nil
+}
+
+// discordGuildIDs returns DISCORD_GUILD_ID or the guilds the bot is in.
+func (b *bridge) discordGuildIDs(ctx context.Context) ([]string, error) {
+	if id := strings.TrimSpace(b.cfg.DiscordGuildID); id != "" {
+		return []string{id}, nil
+	}
+	var guilds []struct {
+		ID string `json:"id"`
+	}
+	if err := b.discordAPI(ctx, http.MethodGet, "/users/@me/guilds", "", nil, &guilds); err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(guilds))
+	for _, g := range guilds {
+		ids = append(ids, g.ID)
+	}
+	return ids, nil
+}
+
+// discordListChannels lists the text, announcement and forum channels of the
+// bot's guilds.
+func (b *bridge) discordListChannels(ctx context.Context) ([]map[string]any, error) {
+	guilds, err := b.discordGuildIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out []map[string]any
+	for _, guildID := range guilds {
+		var chs []map[string]any
+		if err := b.discordAPI(ctx, http.MethodGet, "/guilds/"+url.PathEscape(guildID)+"/channels", "", nil, &chs); err != nil {
+			return nil, err
+		}
+		for _, c := range chs {
+			switch intFromAny(c["type"], -1) {
+			case 0, 5, 15: // text, announcement, forum
+				out = append(out, c)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (b *bridge) handleDiscordProbe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if strings.TrimSpace(b.cfg.DiscordBotToken) == "" {
+		http.Error(w, "DISCORD_BOT_TOKEN not configured", http.StatusBadRequest)
+		return
+	}
+	var user struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	}
+	if err := b.discordAPI(r.Context(), http.MethodGet, "/users/@me", "", nil, &user); err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	var gw struct {
+		Shards            int            `json:"shards"`
+		SessionStartLimit map[string]any `json:"session_start_limit"`
+	}
+	gateway := map[string]any{"ok": true}
+	if err := b.discordAPI(r.Context(), http.MethodGet, "/gateway/bot", "", nil, &gw); err != nil {
+		gateway = map[string]any{"ok": false, "error": err.Error()}
+	} else {
+		gateway["shards"] = gw.Shards
+		gateway["session_start_limit"] = gw.SessionStartLimit
+	}
+	b.socketMu.Lock()
+	gateway["state"] = firstNonEmpty(b.discordSocket.state, "disabled")
+	b.socketMu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"ok":      true,
+		"user":    user.Username,
+		"user_id": user.ID,
+		"intents": b.cfg.DiscordIntents,
+		"gateway": gateway,
+	})
+}
+
+// discordStatus summarizes the Discord side for /status.
+func (b *bridge) discordStatus() map[string]any {
+	b.discordMu.Lock()
+	st := map[string]any{
+		"configured": strings.TrimSpace(b.cfg.DiscordBotToken) != "",
+		"bot_user":   firstNonEmpty(b.discordBotUserID, b.cfg.DiscordBotUserID),
+		"session":    b.discordSession.id != "",
+		"dm_refs":    len(b.discordDMs),
+	}
+	b.discordMu.Unlock()
+	b.socketMu.Lock()
+	st["gateway"] = firstNonEmpty(b.discordSocket.state, "disabled")
+	b.socketMu.Unlock()
+	return st
+}
+
+// checkDiscordAuth verifies the bot token with /users/@me.
+func (b *bridge) checkDiscordAuth(ctx context.Context) (string, error) {
+	var user struct {
+		Username string `json:"username"`
+	}
+	if err := b.discordAPI(ctx, http.MethodGet, "/users/@me", "", nil, &user); err != nil {
+		return "", fmt.Errorf("users/@me: %w", err)
+	}
+	return "user " + user.Username, nil
+}
+
+// parseDiscordIntents reads DISCORD_INTENTS as a gateway intents bitmask.
+func parseDiscordIntents(raw string) int {
+	v, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || v <= 0 {
+		return defaultDiscordIntents
+	}
+	return v
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestNormalizeDiscordMessage(t *testing.T) {
+	raw := json.RawMessage(`{
+		"id": "m1", "channel_id": "c1", "guild_id": "g1", "content": "<@bot> hi",
+		"author": {"id": "u1", "username": "ana"},
+		"mentions": [{"id": "bot"}],
+		"message_reference": {"message_id": "m0"}
+	}`)
+	in, ok := normalizeDiscordMessage(raw, "bot")
+	if !ok {
+		t.Fatal("expected message to be accepted")
+	}
+	if in.senderID != "u1" || in.channelID != "c1" || in.threadID != "m0" || !in.isGroup || !in.wasMentioned {
+		t.Fatalf("unexpected inbound: %+v", in)
+	}
+
+	dm, ok := normalizeDiscordMessage(json.RawMessage(`{"id":"m2","channel_id":"d1","content":"hello","author":{"id":"u1"}}`), "bot")
+	if !ok || dm.isGroup || dm.wasMentioned {
+		t.Fatalf("expected a plain DM, got %+v ok=%v", dm, ok)
+	}
+
+	for _, skipped := range []string{
+		`{"id":"m3","channel_id":"c1","content":"beep","author":{"id":"b2","bot":true}}`,
+		`{"id":"m4","channel_id":"c1","content":"echo","author":{"id":"bot"}}`,
+		`{"id":"m5","channel_id":"c1","content":"  ","author":{"id":"u1"}}`,
+	} {
+		if in, ok := normalizeDiscordMessage(json.RawMessage(skipped), "bot"); ok {
+			t.Fatalf("expected %s to be skipped, got %+v", skipped, in)
+		}
+	}
+}
+
+func TestDiscordGatewayForwardsMessages(t *testing.T) {
+	inbound := make(chan map[string]any, 4)
+	kafclaw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/channels/discord/inbound" || r.Header.Get("X-Channel-Token") != "in-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		inbound <- body
+	}))
+	defer kafclaw.Close()
+
+	identified := make(chan map[string]any, 1)
+	upgrader := websocket.Upgrader{}
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_ = conn.WriteJSON(map[string]any{"op": discordOpHello, "d": map[string]any{"heartbeat_interval": 60000}})
+		var identify struct {
+			Op int            `json:"op"`
+			D  map[string]any `json:"d"`
+		}
+		if err := conn.ReadJSON(&identify); err != nil || identify.Op != discordOpIdentify {
+			return
+		}
+		identified <- identify.D
+		_ = conn.WriteJSON(map[string]any{"op": 0, "s": 1, "t": "READY", "d": map[string]any{
+			"session_id": "sess-1", "resume_gateway_url": "ws://resume.invalid", "user": map[string]any{"id": "bot", "username": "kafclaw"},
+		}})
+		_ = conn.WriteJSON(map[string]any{"op": 0, "s": 2, "t": "MESSAGE_CREATE", "d": map[string]any{
+			"id": "m1", "channel_id": "c1", "guild_id": "g1", "content": "<@bot> status?",
+			"author": map[string]any{"id": "u1"}, "mentions": []map[string]any{{"id": "bot"}},
+		}})
+		_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(4004, "authentication failed"))
+		_, _, _ = conn.ReadMessage()
+	}))
+	defer gateway.Close()
+
+	b := newTestBridge(kafclaw.URL)
+	b.cfg.KafclawDiscordInboundToken = "in-token"
+	b.cfg.DiscordBotToken = "bot-token"
+	b.cfg.DiscordGatewayURL = "ws" + strings.TrimPrefix(gateway.URL, "http")
+	b.cfg.DiscordIntents = defaultDiscordIntents
+
+	err := b.runDiscordGateway(context.Background())
+	var fatal *discordFatalCloseError
+	if !errors.As(err, &fatal) || fatal.code != 4004 {
+		t.Fatalf("expected fatal 4004 close, got %v", err)
+	}
+	id := <-identified
+	if id["token"] != "bot-token" || int(id["intents"].(float64)) != defaultDiscordIntents {
+		t.Fatalf("unexpected identify: %+v", id)
+	}
+	select {
+	case body := <-inbound:
+		if body["chat_id"] != "c1" || body["sender_id"] != "u1" || body["was_mentioned"] != true || body["is_group"] != true {
+			t.Fatalf("unexpected inbound payload: %+v", body)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected MESSAGE_CREATE to be forwarded")
+	}
+	if b.discordSession.id != "sess-1" || b.discordSession.seq != 2 || b.discordBotID() != "bot" {
+		t.Fatalf("expected session state from READY, got %+v bot=%q", b.discordSession, b.discordBotID())
+	}
+	if b.metrics.DiscordInboundForwarded != 1 {
+		t.Fatalf("expected forwarded metric, got %+v", b.metrics)
+	}
+}
+
+func TestDiscordOutboundOpensDMAndSplitsLongReplies(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		posts []map[string]any
+	)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bot bot-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/users/@me/channels":
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "dm-1"})
+		case r.Method == http.MethodPost && r.URL.Path == "/channels/dm-1/messages":
+			var body map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			mu.Lock()
+			posts = append(posts, body)
+			n := len(posts)
+			mu.Unlock()
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "msg-" + string(rune('0'+n))})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer api.Close()
+
+	b := newTestBridge(api.URL)
+	b.cfg.DiscordBotToken = "bot-token"
+	b.cfg.DiscordAPIBase = api.URL
+	b.cfg.DiscordReplyMode = "all"
+
+	body, _ := json.Marshal(map[string]any{
+		"chat_id":   "discord:user:u1",
+		"thread_id": "m0",
+		"content":   strings.Repeat("word ", 500),
+	})
+	rec := httptest.NewRecorder()
+	b.handleDiscordOutbound(rec, httptest.NewRequest(http.MethodPost, "/discord/outbound", strings.NewReader(string(body))))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var delivery outboundDelivery
+	_ = json.Unmarshal(rec.Body.Bytes(), &delivery)
+	if delivery.ChannelID != "dm-1" || len(delivery.MessageIDs) != 2 || delivery.MessageID != "msg-1" {
+		t.Fatalf("unexpected delivery: %+v", delivery)
+	}
+	if len(posts) != 2 {
+		t.Fatalf("expected the reply split in two messages, got %d", len(posts))
+	}
+	for _, p := range posts {
+		if n := len([]rune(p["content"].(string))); n > 2000 {
+			t.Fatalf("chunk over the message limit: %d", n)
+		}
+	}
+	if posts[0]["message_reference"] == nil || posts[1]["message_reference"] != nil {
+		t.Fatalf("expected only the first chunk to reply to the thread: %+v", posts)
+	}
+	if b.discordDMs["u1"] != "dm-1" || b.metrics.DiscordOutboundSent != 1 {
+		t.Fatalf("expected cached DM and sent metric, dms=%v metrics=%+v", b.discordDMs, b.metrics)
+	}
+}
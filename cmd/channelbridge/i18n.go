@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Message keys for user-facing bridge strings (ephemeral acks, error replies
+// and card labels).
+const (
+	msgCommandAccepted = "command.accepted"
+	msgCommandFailed   = "command.failed"
+	msgPollVote        = "poll.vote"
+	msgPollClosed      = "poll.closed"
+	msgPollVoters      = "poll.voters"
+	msgTyping          = "typing"
+)
+
+// bridgeMessages is the translation catalog, keyed by base language. English
+// is the fallback for missing languages and keys.
+var bridgeMessages = map[string]map[string]string{
+	"en": {
+		msgCommandAccepted: "accepted",
+		msgCommandFailed:   "Sorry, your command could not be delivered. Please try again.",
+		msgPollVote:        "Vote",
+		msgPollClosed:      "Poll closed: %s",
+		msgPollVoters:      "%d voters",
+		msgTyping:          "is typing...",
+	},
+	"de": {
+		msgCommandAccepted: "angenommen",
+		msgCommandFailed:   "Der Befehl konnte leider nicht zugestellt werden. Bitte versuche es erneut.",
+		msgPollVote:        "Abstimmen",
+		msgPollClosed:      "Umfrage beendet: %s",
+		msgPollVoters:      "%d Teilnehmende",
+		msgTyping:          "schreibt...",
+	},
+	"fr": {
+		msgCommandAccepted: "accepté",
+		msgCommandFailed:   "Désolé, votre commande n'a pas pu être transmise. Veuillez réessayer.",
+		msgPollVote:        "Voter",
+		msgPollClosed:      "Sondage clos : %s",
+		msgPollVoters:      "%d votants",
+		msgTyping:          "écrit...",
+	},
+	"es": {
+		msgCommandAccepted: "aceptado",
+		msgCommandFailed:   "Lo sentimos, no se pudo entregar tu comando. Inténtalo de nuevo.",
+		msgPollVote:        "Votar",
+		msgPollClosed:      "Encuesta cerrada: %s",
+		msgPollVoters:      "%d votantes",
+		msgTyping:          "está escribiendo...",
+	},
+}
+
+// normalizeLanguage reduces a language tag ("de-CH", "pt_BR") to its
+// lower-case base language.
+func normalizeLanguage(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i > 0 {
+		tag = tag[:i]
+	}
+	return tag
+}
+
+// localize renders key in lang, falling back to English and finally to the
+// key itself. Extra args are applied with fmt.Sprintf.
+func localize(lang, key string, args ...any) string {
+	msg, ok := bridgeMessages[normalizeLanguage(lang)][key]
+	if !ok {
+		msg, ok = bridgeMessages["en"][key]
+	}
+	if !ok {
+		msg = key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// languageFor returns the configured language for a Slack workspace (team ID)
+// or Teams tenant ID, falling back to the bridge default.
+func (b *bridge) languageFor(workspace string) string {
+	if lang := b.cfg.LanguageByWorkspace[strings.ToLower(strings.TrimSpace(workspace))]; lang != "" {
+		return lang
+	}
+	if lang := strings.TrimSpace(b.cfg.DefaultLanguage); lang != "" {
+		return lang
+	}
+	return "en"
+}
+
+// text renders a user-facing message for workspace.
+func (b *bridge) text(workspace, key string, args ...any) string {
+	return localize(b.languageFor(workspace), key, args...)
+}
+
+// parseLanguageByWorkspace parses "T0123=de,<tenant-id>=fr" into a lookup map
+// keyed by lower-case workspace or tenant ID.
+func parseLanguageByWorkspace(raw string) map[string]string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	out := map[string]string{}
+	for _, pair := range strings.Split(raw, ",") {
+		kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(kv[0]))
+		lang := normalizeLanguage(kv[1])
+		if key == "" || lang == "" {
+			continue
+		}
+		out[key] = lang
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestLocalizeFallsBackToEnglish(t *testing.T) {
+	if got := localize("de-CH", msgPollVote); got != "Abstimmen" {
+		t.Fatalf("expected German label for de-CH, got %q", got)
+	}
+	if got := localize("xx", msgCommandAccepted); got != "accepted" {
+		t.Fatalf("expected English fallback, got %q", got)
+	}
+	if got := localize("de", "missing.key"); got != "missing.key" {
+		t.Fatalf("expected key fallback, got %q", got)
+	}
+}
+
+func TestParseLanguageByWorkspace(t *testing.T) {
+	got := parseLanguageByWorkspace("T0123=de-DE, tenant-a=FR, broken, =es")
+	if len(got) != 2 || got["t0123"] != "de" || got["tenant-a"] != "fr" {
+		t.Fatalf("unexpected map: %#v", got)
+	}
+	b := newTestBridge("")
+	b.cfg.DefaultLanguage = "es"
+	b.cfg.LanguageByWorkspace = got
+	if b.languageFor("T0123") != "de" || b.languageFor("T9999") != "es" {
+		t.Fatalf("unexpected language resolution")
+	}
+}
+
+func TestSlackCommandsLocalizedAck(t *testing.T) {
+	status := http.StatusOK
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer api.Close()
+
+	b := newTestBridge(api.URL)
+	b.cfg.LanguageByWorkspace = map[string]string{"t0123": "de"}
+	send := func() map[string]any {
+		form := url.Values{}
+		form.Set("team_id", "T0123")
+		form.Set("channel_id", "C111")
+		form.Set("user_id", "U111")
+		form.Set("command", "/ask")
+		form.Set("text", "status")
+		req := httptest.NewRequest(http.MethodPost, "/slack/commands", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		b.handleSlackCommands(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+		}
+		var resp map[string]any
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp
+	}
+
+	if resp := send(); resp["text"] != "angenommen" || resp["response_type"] != "ephemeral" {
+		t.Fatalf("unexpected ack: %#v", resp)
+	}
+	status = http.StatusBadGateway
+	if resp := send(); !strings.Contains(asString(resp["text"]), "nicht zugestellt") {
+		t.Fatalf("expected localized failure reply, got %#v", resp)
+	}
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultInboundQueueMaxAgeSec = 600
+	defaultInboundQueueMax       = 1000
+	inboundQueueTick             = time.Second
+	inboundQueueMaxBackoff       = 30 * time.Second
+)
+
+// queuedInbound is an inbound event kafclaw could not take, e.g. during a
+// gateway restart. It is kept in the state file and retried until it is
+// delivered or older than the configured maximum age.
+type queuedInbound struct {
+	ID      string         `json:"id"`
+	Channel string         `json:"channel"` // slack|msteams|discord|telegram; selects the inbound token
+	Path    string         `json:"path"`
+	ChatID  string         `json:"chat_id"`
+	Payload map[string]any `json:"payload"`
+	// Traceparent is kept so every retry continues the same trace.
+	Traceparent string    `json:"traceparent"`
+	QueuedAt    time.Time `json:"queued_at"`
+	Attempts    int       `json:"attempts"`
+	NextAt      time.Time `json:"next_at"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// inboundStatusError is a non-2xx answer of the kafclaw inbound API.
+type inboundStatusError struct {
+	status     int
+	body       string
+	retryAfter time.Duration
+}
+
+func (e *inboundStatusError) Error() string {
+	return fmt.Sprintf("kafclaw inbound rejected: status=%d body=%s", e.status, e.body)
+}
+
+// retryableInbound reports whether a failed inbound post may succeed later:
+// kafclaw was unreachable, overloaded or failing.
+func retryableInbound(err error) bool {
+	var se *inboundStatusError
+	if errors.As(err, &se) {
+		return se.status == http.StatusTooManyRequests || se.status >= 500
+	}
+	return err != nil
+}
+
+// inboundQueueMaxAge reads CHANNEL_BRIDGE_INBOUND_QUEUE (default on) and
+// CHANNEL_BRIDGE_INBOUND_QUEUE_MAX_AGE_SEC; it returns 0 when the queue is off.
+func inboundQueueMaxAge() time.Duration {
+	if !parseBoolDefault("CHANNEL_BRIDGE_INBOUND_QUEUE", true) {
+		return 0
+	}
+	return time.Duration(parseIntDefault("CHANNEL_BRIDGE_INBOUND_QUEUE_MAX_AGE_SEC", defaultInboundQueueMaxAgeSec)) * time.Second
+}
+
+func (b *bridge) inboundQueueEnabled() bool {
+	return b.cfg.InboundQueueMaxAge > 0
+}
+
+// inboundToken returns the kafclaw inbound token of a channel account.
+func (b *bridge) inboundToken(channel, accountID string) string {
+	switch channel {
+	case "msteams":
+		return b.teamsAccount(accountID).InboundToken
+	case "discord":
+		return b.cfg.KafclawDiscordInboundToken
+	case "telegram":
+		return b.cfg.KafclawTelegramInboundToken
+	}
+	return b.slackAccount(accountID).InboundToken
+}
+
+// forwardInbound posts an inbound event of a chat to kafclaw. When kafclaw
+// cannot take it, the event is queued for a delayed retry instead of being
+// dropped. Events of a chat that already has queued events queue behind
+// them, so each chat keeps its order. It reports whether the event was
+// queued; err is set only when the event was neither posted nor queued.
+func (b *bridge) forwardInbound(channel, path, chatID string, payload map[string]any) (bool, error) {
+	defer b.trackInflight()()
+	if rl := b.allowInbound(channel, chatID, asString(payload["sender_id"]), time.Now()); rl != nil {
+		b.noteInboundRateLimited(rl)
+		return false, rl
+	}
+	traceparent := newTraceparent()
+	payload["trace_id"] = traceIDOf(traceparent)
+	if !b.inboundQueueEnabled() {
+		return false, b.postInboundTrace(path, b.inboundToken(channel, asString(payload["account_id"])), traceparent, payload)
+	}
+	item := queuedInbound{
+		ID:          randomHex(8),
+		Channel:     channel,
+		Path:        path,
+		ChatID:      chatID,
+		Payload:     payload,
+		Traceparent: traceparent,
+	}
+	if b.chatQueued(channel, chatID) {
+		return b.enqueueInbound(item, "chat has queued events")
+	}
+	err := b.postInboundTrace(path, b.inboundToken(channel, asString(payload["account_id"])), traceparent, payload)
+	if err == nil || !retryableInbound(err) {
+		return false, err
+	}
+	return b.enqueueInbound(item, err.Error())
+}
+
+func (b *bridge) chatQueued(channel, chatID string) bool {
+	b.queueMu.Lock()
+	defer b.queueMu.Unlock()
+	for _, q := range b.inboundQueue {
+		if q.Channel == channel && q.ChatID == chatID {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *bridge) enqueueInbound(item queuedInbound, reason string) (bool, error) {
+	now := time.Now().UTC()
+	item.QueuedAt, item.NextAt, item.LastError = now, now, reason
+	max := b.cfg.InboundQueueMax
+	if max <= 0 {
+		max = defaultInboundQueueMax
+	}
+	b.queueMu.Lock()
+	if len(b.inboundQueue) >= max {
+		b.queueMu.Unlock()
+		b.noteInboundQueue(func(m *bridgeMetrics) { m.InboundQueueDropped++ })
+		return false, fmt.Errorf("inbound queue full (%d events): %s", max, reason)
+	}
+	b.inboundQueue = append(b.inboundQueue, item)
+	b.queueMu.Unlock()
+	b.noteInboundQueue(func(m *bridgeMetrics) { m.InboundQueued++ })
+	slog.Warn("inbound queued for retry", "channel", item.Channel, "chat", item.ChatID, "trace_id", traceIDOf(item.Traceparent), "reason", reason)
+	_ = b.saveState()
+	return true, nil
+}
+
+// startInboundQueue retries queued inbound events in the background.
+func (b *bridge) startInboundQueue() {
+	if !b.inboundQueueEnabled() {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(inboundQueueTick)
+		defer ticker.Stop()
+		for range ticker.C {
+			done := b.trackInflight()
+			if b.closing.Load() {
+				done()
+				return
+			}
+			b.drainInboundQueue(time.Now())
+			done()
+		}
+	}()
+}
+
+// drainInboundQueue expires events older than the maximum age and retries
+// the due head event of every chat. A delivered head is followed by the
+// next event of its chat right away; a failed one backs off and holds its
+// chat. Events kafclaw rejects for good are dropped.
+func (b *bridge) drainInboundQueue(now time.Time) {
+	b.expireInboundQueue(now)
+	if b.inboundQueueDepth() == 0 {
+		return
+	}
+	changed := false
+	done := map[string]bool{}
+	for {
+		item, ok := b.nextQueuedInbound(now, done)
+		if !ok {
+			break
+		}
+		changed = true
+		key := item.Channel + "\x00" + item.ChatID
+		err := b.postInboundOnce(item.Path, b.inboundToken(item.Channel, asString(item.Payload["account_id"])), item.Traceparent, item.Payload)
+		switch {
+		case err == nil:
+			b.removeQueuedInbound(item.ID)
+			b.noteInboundQueue(func(m *bridgeMetrics) { m.InboundQueueDelivered++ })
+			slog.Info("inbound delivered from queue", "channel", item.Channel, "chat", item.ChatID, "trace_id", traceIDOf(item.Traceparent), "attempts", item.Attempts+1)
+		case retryableInbound(err):
+			b.backoffQueuedInbound(item.ID, now, err)
+			done[key] = true
+		default:
+			b.removeQueuedInbound(item.ID)
+			b.noteInboundForward(false, err)
+			b.noteInboundQueue(func(m *bridgeMetrics) { m.InboundQueueDropped++ })
+			slog.Warn("inbound dropped from queue", "channel", item.Channel, "chat", item.ChatID, "trace_id", traceIDOf(item.Traceparent), "error", err)
+		}
+	}
+	if changed {
+		_ = b.saveState()
+	}
+}
+
+// expireInboundQueue drops events queued longer than the maximum age.
+func (b *bridge) expireInboundQueue(now time.Time) {
+	b.queueMu.Lock()
+	kept := b.inboundQueue[:0]
+	var expired []queuedInbound
+	for _, q := range b.inboundQueue {
+		if now.Sub(q.QueuedAt) > b.cfg.InboundQueueMaxAge {
+			expired = append(expired, q)
+			continue
+		}
+		kept = append(kept, q)
+	}
+	b.inboundQueue = kept
+	b.queueMu.Unlock()
+	for _, q := range expired {
+		slog.Warn("inbound expired in queue", "channel", q.Channel, "chat", q.ChatID, "trace_id", traceIDOf(q.Traceparent), "attempts", q.Attempts, "last_error", q.LastError)
+	}
+	if len(expired) > 0 {
+		b.noteInboundQueue(func(m *bridgeMetrics) { m.InboundQueueExpired += len(expired) })
+		_ = b.saveState()
+	}
+}
+
+// nextQueuedInbound returns the first due event whose chat has no earlier
+// event and is not in done.
+func (b *bridge) nextQueuedInbound(now time.Time, done map[string]bool) (queuedInbound, bool) {
+	b.queueMu.Lock()
+	defer b.queueMu.Unlock()
+	seen := map[string]bool{}
+	for _, q := range b.inboundQueue {
+		key := q.Channel + "\x00" + q.ChatID
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if !done[key] && !q.NextAt.After(now) {
+			return q, true
+		}
+	}
+	return queuedInbound{}, false
+}
+
+func (b *bridge) removeQueuedInbound(id string) {
+	b.queueMu.Lock()
+	defer b.queueMu.Unlock()
+	for i, q := range b.inboundQueue {
+		if q.ID == id {
+			b.inboundQueue = append(b.inboundQueue[:i], b.inboundQueue[i+1:]...)
+			return
+		}
+	}
+}
+
+func (b *bridge) backoffQueuedInbound(id string, now time.Time, err error) {
+	b.queueMu.Lock()
+	defer b.queueMu.Unlock()
+	for i := range b.inboundQueue {
+		q := &b.inboundQueue[i]
+		if q.ID != id {
+			continue
+		}
+		q.Attempts++
+		delay := inboundQueueTick << min(q.Attempts, 5)
+		if delay > inboundQueueMaxBackoff {
+			delay = inboundQueueMaxBackoff
+		}
+		q.NextAt = now.Add(delay)
+		q.LastError = err.Error()
+		return
+	}
+}
+
+func (b *bridge) inboundQueueDepth() int {
+	b.queueMu.Lock()
+	defer b.queueMu.Unlock()
+	return len(b.inboundQueue)
+}
+
+// inboundQueueStatus summarizes the queue for /status.
+func (b *bridge) inboundQueueStatus() map[string]any {
+	b.queueMu.Lock()
+	defer b.queueMu.Unlock()
+	st := map[string]any{
+		"enabled":     b.inboundQueueEnabled(),
+		"depth":       len(b.inboundQueue),
+		"max_age_sec": int(b.cfg.InboundQueueMaxAge / time.Second),
+	}
+	if len(b.inboundQueue) > 0 {
+		oldest := b.inboundQueue[0].QueuedAt
+		for _, q := range b.inboundQueue[1:] {
+			if q.QueuedAt.Before(oldest) {
+				oldest = q.QueuedAt
+			}
+		}
+		st["oldest_queued_at"] = oldest.Format(time.RFC3339)
+	}
+	return st
+}
+
+func (b *bridge) noteInboundQueue(update func(*bridgeMetrics)) {
+	b.metricsMu.Lock()
+	defer b.metricsMu.Unlock()
+	update(&b.metrics)
+}
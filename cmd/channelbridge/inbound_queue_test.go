@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestInboundQueueRetriesInChatOrder(t *testing.T) {
+	var (
+		down     atomic.Bool
+		mu       sync.Mutex
+		received []string
+	)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["text"] == "bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		received = append(received, body["chat_id"].(string)+":"+body["text"].(string))
+		mu.Unlock()
+	}))
+	defer api.Close()
+
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	b := newTestBridge(api.URL)
+	b.cfg.StatePath = statePath
+	b.cfg.InboundQueueMaxAge = time.Minute
+	forward := func(chatID, text string) bool {
+		t.Helper()
+		queued, err := b.forwardInbound("slack", "/api/v1/channels/slack/inbound", chatID, map[string]any{"chat_id": chatID, "text": text})
+		if err != nil {
+			t.Fatalf("forward %s: %v", text, err)
+		}
+		return queued
+	}
+
+	down.Store(true)
+	if !forward("C1", "first") {
+		t.Fatal("expected event to be queued while kafclaw is down")
+	}
+	down.Store(false)
+	if !forward("C1", "second") {
+		t.Fatal("expected event to queue behind the queued event of its chat")
+	}
+	if forward("C2", "other") {
+		t.Fatal("expected event of another chat to be posted directly")
+	}
+	if _, err := b.forwardInbound("slack", "/api/v1/channels/slack/inbound", "C3", map[string]any{"chat_id": "C3", "text": "bad"}); err == nil {
+		t.Fatal("expected a rejected event to fail instead of queueing")
+	}
+
+	// The queue survives a restart through the state file.
+	restarted := newTestBridge(api.URL)
+	restarted.cfg.StatePath = statePath
+	restarted.cfg.InboundQueueMaxAge = time.Minute
+	if err := restarted.loadState(); err != nil {
+		t.Fatal(err)
+	}
+	if restarted.inboundQueueDepth() != 2 {
+		t.Fatalf("expected 2 queued events after restart, got %d", restarted.inboundQueueDepth())
+	}
+
+	restarted.drainInboundQueue(time.Now())
+	mu.Lock()
+	got := append([]string(nil), received...)
+	mu.Unlock()
+	if len(got) != 3 || got[0] != "C2:other" || got[1] != "C1:first" || got[2] != "C1:second" {
+		t.Fatalf("unexpected delivery order: %v", got)
+	}
+	if restarted.inboundQueueDepth() != 0 || restarted.metrics.InboundQueueDelivered != 2 {
+		t.Fatalf("expected queue drained, depth=%d metrics=%+v", restarted.inboundQueueDepth(), restarted.metrics)
+	}
+	if b.metrics.InboundQueued != 2 {
+		t.Fatalf("expected 2 queued events counted, got %+v", b.metrics)
+	}
+}
+
+func TestInboundQueueBackoffAndExpiry(t *testing.T) {
+	var calls atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer api.Close()
+
+	b := newTestBridge(api.URL)
+	b.cfg.InboundQueueMaxAge = time.Minute
+	now := time.Now()
+	b.inboundQueue = []queuedInbound{
+		{ID: "a", Channel: "msteams", Path: "/api/v1/channels/msteams/inbound", ChatID: "T1", QueuedAt: now, NextAt: now},
+		{ID: "b", Channel: "msteams", Path: "/api/v1/channels/msteams/inbound", ChatID: "T1", QueuedAt: now, NextAt: now},
+	}
+
+	b.drainInboundQueue(now)
+	if calls.Load() != 1 {
+		t.Fatalf("expected only the head of the chat to be tried, got %d calls", calls.Load())
+	}
+	if q := b.inboundQueue[0]; q.Attempts != 1 || !q.NextAt.After(now) || q.LastError == "" {
+		t.Fatalf("expected head to back off, got %+v", q)
+	}
+	b.drainInboundQueue(now)
+	if calls.Load() != 1 {
+		t.Fatal("expected no retry before the backoff elapsed")
+	}
+
+	b.drainInboundQueue(now.Add(2 * time.Minute))
+	if b.inboundQueueDepth() != 0 || b.metrics.InboundQueueExpired != 2 {
+		t.Fatalf("expected both events expired, depth=%d metrics=%+v", b.inboundQueueDepth(), b.metrics)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// inboundRateLimitError reports an inbound event dropped by the per-chat or
+// per-sender budget.
+type inboundRateLimitError struct {
+	scope      string // "chat" or "sender"
+	retryAfter time.Duration
+}
+
+func (e *inboundRateLimitError) Error() string {
+	return fmt.Sprintf("inbound rate limited per %s, retry after %s", e.scope, e.retryAfter.Round(time.Second))
+}
+
+// rateBucket is a token bucket refilled continuously at the configured rate.
+type rateBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// parseInboundRateAllow reads CHANNEL_BRIDGE_INBOUND_RATE_ALLOW: chat IDs,
+// optionally prefixed with the channel ("slack:C123"), that are never
+// limited.
+func parseInboundRateAllow(raw string) map[string]bool {
+	out := map[string]bool{}
+	for _, part := range strings.Split(raw, ",") {
+		if v := strings.ToLower(strings.TrimSpace(part)); v != "" {
+			out[v] = true
+		}
+	}
+	return out
+}
+
+// allowInbound takes one token from the chat and the sender bucket of an
+// inbound event. Allowlisted chats and disabled limits always pass. When a
+// bucket is empty it returns the error to answer with.
+func (b *bridge) allowInbound(channel, chatID, senderID string, now time.Time) *inboundRateLimitError {
+	chatID, senderID = strings.TrimSpace(chatID), strings.TrimSpace(senderID)
+	if b.cfg.InboundRatePerChat <= 0 && b.cfg.InboundRatePerSender <= 0 {
+		return nil
+	}
+	if allow := b.cfg.InboundRateAllow; allow[strings.ToLower(chatID)] || allow[strings.ToLower(channel+":"+chatID)] {
+		return nil
+	}
+
+	b.rateMu.Lock()
+	defer b.rateMu.Unlock()
+	if b.rateBuckets == nil {
+		b.rateBuckets = map[string]*rateBucket{}
+	}
+	if len(b.rateBuckets) > 10000 {
+		for k, rb := range b.rateBuckets {
+			if now.Sub(rb.last) > 10*time.Minute {
+				delete(b.rateBuckets, k)
+			}
+		}
+	}
+	// Check both budgets before taking from either, so a rejected event
+	// does not use up the other budget.
+	chatKey := "chat|" + channel + "|" + chatID
+	senderKey := "sender|" + channel + "|" + senderID
+	if wait := b.rateWait(chatKey, b.cfg.InboundRatePerChat, now); wait > 0 {
+		return &inboundRateLimitError{scope: "chat", retryAfter: wait}
+	}
+	if senderID != "" {
+		if wait := b.rateWait(senderKey, b.cfg.InboundRatePerSender, now); wait > 0 {
+			return &inboundRateLimitError{scope: "sender", retryAfter: wait}
+		}
+	}
+	b.rateTake(chatKey, b.cfg.InboundRatePerChat)
+	if senderID != "" {
+		b.rateTake(senderKey, b.cfg.InboundRatePerSender)
+	}
+	return nil
+}
+
+// rateWait refills the bucket key and returns how long until it holds a
+// token; 0 means a token is available. Callers hold rateMu.
+func (b *bridge) rateWait(key string, perMinute int, now time.Time) time.Duration {
+	if perMinute <= 0 {
+		return 0
+	}
+	burst := float64(b.cfg.InboundRateBurst)
+	if burst <= 0 {
+		burst = math.Max(1, float64(perMinute)/4)
+	}
+	rate := float64(perMinute) / 60 // tokens per second
+	rb := b.rateBuckets[key]
+	if rb == nil {
+		rb = &rateBucket{tokens: burst, last: now}
+		b.rateBuckets[key] = rb
+	}
+	rb.tokens = math.Min(burst, rb.tokens+now.Sub(rb.last).Seconds()*rate)
+	rb.last = now
+	if rb.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - rb.tokens) / rate * float64(time.Second))
+}
+
+func (b *bridge) rateTake(key string, perMinute int) {
+	if rb := b.rateBuckets[key]; rb != nil && perMinute > 0 {
+		rb.tokens--
+	}
+}
+
+// releaseRateLimited forgets the dedupe keys of an event dropped by the rate
+// limit, so the platform's redelivery is checked against the budget again
+// instead of being deduped.
+func (b *bridge) releaseRateLimited(err error, keys ...string) {
+	var rl *inboundRateLimitError
+	if !errors.As(err, &rl) {
+		return
+	}
+	for _, key := range keys {
+		b.releaseInboundEvent(key)
+	}
+}
+
+func (b *bridge) noteInboundRateLimited(err *inboundRateLimitError) {
+	b.metricsMu.Lock()
+	defer b.metricsMu.Unlock()
+	if err.scope == "chat" {
+		b.metrics.InboundRateLimitedChat++
+	} else {
+		b.metrics.InboundRateLimitedSender++
+	}
+}
+
+// writeInboundError answers a failed inbound forward: 429 with Retry-After
+// for rate-limited events, 502 otherwise.
+func writeInboundError(w http.ResponseWriter, err error) {
+	var rl *inboundRateLimitError
+	if errors.As(err, &rl) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(rl.retryAfter.Seconds()))))
+		http.Error(w, "rate limited", http.StatusTooManyRequests)
+		return
+	}
+	http.Error(w, "forward failed", http.StatusBadGateway)
+}
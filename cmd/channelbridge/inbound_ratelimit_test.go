@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSlackEventsRateLimitedPerChatAndSender(t *testing.T) {
+	var forwards int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&forwards, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer api.Close()
+
+	b := newTestBridge(api.URL)
+	// Bursts of a quarter of the rate: 2 events per chat, 3 per sender.
+	b.cfg.InboundRatePerChat = 8
+	b.cfg.InboundRatePerSender = 12
+	b.cfg.InboundRateAllow = parseInboundRateAllow("slack:C-TRUSTED")
+
+	n := 0
+	post := func(channel, user string) *httptest.ResponseRecorder {
+		n++
+		body, _ := json.Marshal(map[string]any{
+			"type":     "event_callback",
+			"event_id": fmt.Sprintf("Ev%d", n),
+			"event": map[string]any{
+				"type":         "message",
+				"channel":      channel,
+				"user":         user,
+				"text":         "spam",
+				"channel_type": "channel",
+				"ts":           fmt.Sprintf("1700000.%03d", n),
+			},
+		})
+		w := httptest.NewRecorder()
+		b.handleSlackEvents(w, httptest.NewRequest(http.MethodPost, "/slack/events", bytes.NewReader(body)))
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := post("C1", "U1"); w.Code != http.StatusOK {
+			t.Fatalf("event %d within burst: status=%d", i, w.Code)
+		}
+	}
+	w := post("C1", "U2")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "8" {
+		t.Fatalf("expected chat limit 429 with Retry-After, got %d %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	// U1 has one sender token left after the rejected event took none.
+	if w := post("C2", "U1"); w.Code != http.StatusOK {
+		t.Fatalf("expected another chat to pass, got %d", w.Code)
+	}
+	if w := post("C3", "U1"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected sender limit 429, got %d", w.Code)
+	}
+	for i := 0; i < 5; i++ {
+		if w := post("C-TRUSTED", "U1"); w.Code != http.StatusOK {
+			t.Fatalf("expected allowlisted chat to pass, got %d", w.Code)
+		}
+	}
+
+	if got := atomic.LoadInt32(&forwards); got != 8 {
+		t.Fatalf("expected 8 forwarded events, got %d", got)
+	}
+	if b.metrics.InboundRateLimitedChat != 1 || b.metrics.InboundRateLimitedSender != 1 || b.metrics.InboundForwardErrors != 0 {
+		t.Fatalf("unexpected metrics: %+v", b.metrics)
+	}
+
+	// Buckets refill over time.
+	if err := b.allowInbound("slack", "C1", "U9", time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("expected refilled chat bucket, got %v", err)
+	}
+}
+
+func TestSlackEventsRateLimitedRedeliveryIsForwarded(t *testing.T) {
+	var forwards int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&forwards, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer api.Close()
+
+	b := newTestBridge(api.URL)
+	b.cfg.InboundRatePerChat = 4 // burst of 1
+
+	post := func(eventID, ts string) int {
+		body, _ := json.Marshal(map[string]any{
+			"type":     "event_callback",
+			"event_id": eventID,
+			"event": map[string]any{
+				"type":         "message",
+				"channel":      "C1",
+				"user":         "U1",
+				"text":         "hi",
+				"channel_type": "channel",
+				"ts":           ts,
+			},
+		})
+		w := httptest.NewRecorder()
+		b.handleSlackEvents(w, httptest.NewRequest(http.MethodPost, "/slack/events", bytes.NewReader(body)))
+		return w.Code
+	}
+
+	if code := post("Ev1", "1700000.001"); code != http.StatusOK {
+		t.Fatalf("first event: status=%d", code)
+	}
+	if code := post("Ev2", "1700000.002"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", code)
+	}
+	// Slack redelivers the dropped event once the budget allows it again.
+	b.cfg.InboundRatePerChat = 0
+	if code := post("Ev2", "1700000.002"); code != http.StatusOK {
+		t.Fatalf("redelivery: status=%d", code)
+	}
+	if got := atomic.LoadInt32(&forwards); got != 2 || b.metrics.SlackInboundDeduped != 0 {
+		t.Fatalf("expected the redelivery forwarded, forwards=%d metrics=%+v", got, b.metrics)
+	}
+}
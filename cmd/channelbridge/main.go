@@ -13,19 +13,24 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"math/big"
 	"mime/multipart"
 	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
 	"path"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
+	"github.com/KafClaw/KafClaw/internal/logging"
 	"github.com/slack-go/slack"
 	"github.com/slack-go/slack/slackevents"
 	"github.com/slack-go/slack/socketmode"
@@ -36,8 +41,46 @@ type config struct {
 
 	KafclawBase string
 
-	KafclawSlackInboundToken   string
-	KafclawMSTeamsInboundToken string
+	KafclawSlackInboundToken    string
+	KafclawMSTeamsInboundToken  string
+	KafclawDiscordInboundToken  string
+	KafclawTelegramInboundToken string
+	// DeliveryReceipts posts the platform message IDs of every outbound
+	// send back to kafclaw (/api/v1/channels/<channel>/delivery).
+	DeliveryReceipts bool
+	// InboundQueueMaxAge is how long inbound events kafclaw could not take
+	// are retried (see inbound_queue.go); 0 disables the queue.
+	InboundQueueMaxAge time.Duration
+	InboundQueueMax    int
+	// OutboundQueueMaxAge is how long transiently failed sends are retried
+	// before they become dead letters (see outbound_queue.go); 0 disables
+	// the queue.
+	OutboundQueueMaxAge time.Duration
+	OutboundQueueMax    int
+	OutboundMaxAttempts int
+	OutboundRetryBase   time.Duration
+	OutboundRetryMax    time.Duration
+	// ShutdownTimeout bounds the drain of in-flight requests and forwards
+	// on SIGTERM/SIGINT before the final state save.
+	ShutdownTimeout time.Duration
+	// LogLevel (debug|info|warn|error) and LogFormat (text|json) configure
+	// the bridge logger.
+	LogLevel  string
+	LogFormat string
+	// InboundRatePerChat and InboundRatePerSender are token-bucket budgets
+	// in events per minute for forwarding inbound events (see
+	// inbound_ratelimit.go); 0 disables a limit. InboundRateBurst is the
+	// bucket size, 0 for a quarter of the per-minute rate.
+	InboundRatePerChat   int
+	InboundRatePerSender int
+	InboundRateBurst     int
+	// InboundRateAllow lists chat IDs, optionally "channel:chat", that are
+	// never limited.
+	InboundRateAllow map[string]bool
+	// MediaDownload downloads inbound Slack files and Teams attachments and
+	// forwards them inline (see media.go); MediaMaxBytes caps one file.
+	MediaDownload bool
+	MediaMaxBytes int64
 
 	SlackBotToken            string
 	SlackAppToken            string
@@ -52,6 +95,22 @@ type config struct {
 	SlackBotUserID           string
 	SlackSigningSecret       string
 	SlackAPIBase             string
+	// SlackAutoJoin joins public channels when a post fails with
+	// not_in_channel.
+	SlackAutoJoin bool
+	// SlackAllowBroadcast lets outbound text ping the whole channel with
+	// "!here"/"!channel"/"!everyone". Off by default.
+	SlackAllowBroadcast bool
+	// SlackAccounts are further Slack apps by account_id (SLACK_ACCOUNTS);
+	// the SLACK_* variables above are the SlackAccountID account.
+	SlackAccounts map[string]slackAccount
+	// SlackWorkspaceTokens are bot tokens of the default account by team ID
+	// (SLACK_WORKSPACE_TOKENS), for an app installed into several
+	// workspaces.
+	SlackWorkspaceTokens map[string]string
+	// ChannelLinks mirror messages between Slack and Teams channels
+	// (CHANNEL_BRIDGE_CHANNEL_LINKS, see channel_link.go).
+	ChannelLinks []channelLink
 
 	MSTeamsAppID           string
 	MSTeamsAppPassword     string
@@ -64,9 +123,65 @@ type config struct {
 	MSTeamsInboundBearer   string
 	MSTeamsOpenIDConfig    string
 	MSTeamsAPIBase         string
-	MSTeamsGraphBase       string
+	// MSTeamsServiceURL is the Bot Framework service URL used to create
+	// conversations with users who have not messaged the bot yet.
+	MSTeamsServiceURL     string
+	MSTeamsGraphBase      string
+	MSTeamsOutboundFormat string
+	// MSTeamsAccounts are further Teams bot apps by account_id
+	// (MSTEAMS_ACCOUNTS); the MSTEAMS_* variables above are the
+	// MSTeamsAccountID account.
+	MSTeamsAccounts map[string]teamsAccount
+
+	DiscordBotToken       string
+	DiscordAPIBase        string
+	DiscordAccountID      string
+	DiscordReplyMode      string
+	DiscordHistoryLimit   int
+	DiscordDMHistoryLimit int
+	DiscordBotUserID      string
+	// DiscordGateway connects to the Discord gateway for inbound messages;
+	// without it the bridge only sends.
+	DiscordGateway    bool
+	DiscordGatewayURL string
+	DiscordIntents    int
+	// DiscordGuildID limits name resolution to one guild instead of every
+	// guild the bot is in.
+	DiscordGuildID string
+
+	TelegramBotToken       string
+	TelegramAPIBase        string
+	TelegramAccountID      string
+	TelegramReplyMode      string
+	TelegramHistoryLimit   int
+	TelegramDMHistoryLimit int
+	TelegramBotUsername    string
+	// TelegramMode is "polling" (getUpdates) or "webhook" (/telegram/inbound).
+	TelegramMode string
+	// TelegramWebhookURL is registered with setWebhook in webhook mode;
+	// TelegramWebhookSecret is the secret_token Telegram sends back.
+	TelegramWebhookURL    string
+	TelegramWebhookSecret string
+
+	// DefaultLanguage and LanguageByWorkspace (Slack team ID or Teams tenant
+	// ID -> language) select the language of user-facing acks and labels.
+	DefaultLanguage     string
+	LanguageByWorkspace map[string]string
+
+	// ReadyRequire names the dependencies /readyz requires to report ready;
+	// nil requires every configured one.
+	ReadyRequire  map[string]bool
+	ReadyCacheTTL time.Duration
 
 	StatePath string
+	// StateBackend selects where state lives: file (StatePath), sqlite or
+	// redis (StateURL). The shared backends let replicas share Teams
+	// conversations, inbound dedupe keys and polls; InstanceID keys this
+	// replica's retry queues and StatePrefix namespaces the Redis keys.
+	StateBackend string
+	StateURL     string
+	StatePrefix  string
+	InstanceID   string
 }
 
 type bridge struct {
@@ -79,6 +194,10 @@ type bridge struct {
 	teamsConvByUserID map[string]teamsConversationRef
 	teamsToken        tokenCache
 	teamsGraphToken   tokenCache
+	// teamsAccountTokens and teamsJWTs serve the MSTeamsAccounts; the
+	// default account uses teamsToken, teamsGraphToken and jwt.
+	teamsAccountTokens map[string]tokenCache
+	teamsJWTs          map[string]*teamsJWTVerifier
 
 	inboundMu   sync.Mutex
 	inboundSeen map[string]time.Time
@@ -91,6 +210,71 @@ type bridge struct {
 
 	metricsMu sync.RWMutex
 	metrics   bridgeMetrics
+
+	// slackNames caches user display names for history reads, keyed by
+	// account ID and user ID.
+	slackNameMu sync.Mutex
+	slackNames  map[string]string
+
+	// slackModals maps view IDs of modals opened through /slack/outbound to
+	// their conversation.
+	slackModalMu sync.Mutex
+	slackModals  map[string]slackModalOrigin
+
+	// slackChannelWorkspaces maps account ID and channel ID to the team ID
+	// the channel was last seen in.
+	slackWorkspaceMu       sync.Mutex
+	slackChannelWorkspaces map[string]string
+
+	rateMu      sync.Mutex
+	rateBuckets map[string]*rateBucket // inbound budgets by scope, channel and ID
+
+	// linkThreads maps mirrored thread roots of channel links
+	// ("slack:<channel>:<ts>", "teams:<conversation>:<id>") to their peer.
+	linkMu      sync.Mutex
+	linkThreads map[string]linkedThread
+
+	usergroupMu sync.Mutex
+	usergroups  map[string]slackUsergroupCache // by account ID
+
+	queueMu      sync.Mutex
+	inboundQueue []queuedInbound
+
+	outboundMu    sync.Mutex
+	outboundQueue []queuedOutbound
+	outboundDead  []queuedOutbound
+
+	scheduledMu sync.Mutex
+	scheduled   []scheduledMessage
+
+	readyMu    sync.Mutex
+	readyCache map[string]cachedDependency
+	socketMu   sync.Mutex
+	socket     socketState
+	// slackSockets tracks Socket Mode of the SlackAccounts.
+	slackSockets map[string]socketState
+
+	discordMu        sync.Mutex
+	discordSession   discordSession
+	discordBotUserID string
+	discordDMs       map[string]string // user ID -> DM channel ID
+	discordSocket    socketState
+
+	telegramMu      sync.Mutex
+	telegramBotID   int64
+	telegramBotUser string
+	telegramOffset  int64
+	telegramPollAt  time.Time
+	telegramPollErr string
+
+	// state is the state backend; nil is the file at cfg.StatePath.
+	state stateStore
+	// stateMu serializes state writes.
+	stateMu sync.Mutex
+	// inflight counts background work shutdown waits for; closing stops
+	// the retry queues once shutdown began.
+	inflight atomic.Int64
+	closing  atomic.Bool
 }
 
 type bridgeMetrics struct {
@@ -99,14 +283,44 @@ type bridgeMetrics struct {
 	SlackInboundForwarded int `json:"slack_inbound_forwarded"`
 	SlackOutboundSent     int `json:"slack_outbound_sent"`
 
-	TeamsInboundForwarded int `json:"teams_inbound_forwarded"`
-	TeamsOutboundSent     int `json:"teams_outbound_sent"`
+	TeamsInboundForwarded     int `json:"teams_inbound_forwarded"`
+	TeamsOutboundSent         int `json:"teams_outbound_sent"`
+	TeamsConversationsCreated int `json:"teams_conversations_created"`
+
+	DiscordInboundForwarded int `json:"discord_inbound_forwarded"`
+	DiscordOutboundSent     int `json:"discord_outbound_sent"`
+
+	TelegramInboundForwarded int `json:"telegram_inbound_forwarded"`
+	TelegramOutboundSent     int `json:"telegram_outbound_sent"`
+
+	InboundForwardErrors   int `json:"inbound_forward_errors"`
+	OutboundErrors         int `json:"outbound_errors"`
+	SlackInboundDeduped    int `json:"slack_inbound_deduped"`
+	TeamsInboundDeduped    int `json:"teams_inbound_deduped"`
+	DiscordInboundDeduped  int `json:"discord_inbound_deduped"`
+	TelegramInboundDeduped int `json:"telegram_inbound_deduped"`
+	InboundAuthRejected    int `json:"inbound_auth_rejected"`
+
+	InboundRateLimitedChat   int `json:"inbound_rate_limited_chat"`
+	InboundRateLimitedSender int `json:"inbound_rate_limited_sender"`
+
+	InboundQueued         int `json:"inbound_queued"`
+	InboundQueueDelivered int `json:"inbound_queue_delivered"`
+	InboundQueueExpired   int `json:"inbound_queue_expired"`
+	InboundQueueDropped   int `json:"inbound_queue_dropped"`
+
+	OutboundQueued         int `json:"outbound_queued"`
+	OutboundQueueDelivered int `json:"outbound_queue_delivered"`
+	OutboundDeadLettered   int `json:"outbound_dead_lettered"`
 
-	InboundForwardErrors int `json:"inbound_forward_errors"`
-	OutboundErrors       int `json:"outbound_errors"`
-	SlackInboundDeduped  int `json:"slack_inbound_deduped"`
-	TeamsInboundDeduped  int `json:"teams_inbound_deduped"`
-	InboundAuthRejected  int `json:"inbound_auth_rejected"`
+	StateStoreErrors int `json:"state_store_errors"`
+
+	ChannelLinkMirrored int `json:"channel_link_mirrored"`
+	ChannelLinkFailed   int `json:"channel_link_failed"`
+
+	ScheduledMessages int `json:"scheduled_messages"`
+	ScheduledSent     int `json:"scheduled_sent"`
+	ScheduledFailed   int `json:"scheduled_failed"`
 
 	LastError   string `json:"last_error,omitempty"`
 	LastErrorAt string `json:"last_error_at,omitempty"`
@@ -138,6 +352,13 @@ type teamsConversationRef struct {
 	ServiceURL     string `json:"service_url"`
 	ConversationID string `json:"conversation_id"`
 	UserID         string `json:"user_id"`
+	TenantID       string `json:"tenant_id,omitempty"`
+	// TeamGroupID and ChannelID locate channel conversations in Graph for
+	// thread history reads.
+	TeamGroupID string `json:"team_group_id,omitempty"`
+	ChannelID   string `json:"channel_id,omitempty"`
+	// AccountID is the Teams account the conversation came in on.
+	AccountID string `json:"account_id,omitempty"`
 }
 
 type bridgeState struct {
@@ -145,10 +366,18 @@ type bridgeState struct {
 	TeamsConvByUserID map[string]teamsConversationRef `json:"teams_conv_by_user_id"`
 	InboundSeen       map[string]time.Time            `json:"inbound_seen,omitempty"`
 	TeamsPolls        map[string]map[string]any       `json:"teams_polls,omitempty"`
+	InboundQueue      []queuedInbound                 `json:"inbound_queue,omitempty"`
+	OutboundQueue     []queuedOutbound                `json:"outbound_queue,omitempty"`
+	OutboundDead      []queuedOutbound                `json:"outbound_dead,omitempty"`
+	LinkThreads       map[string]linkedThread         `json:"link_threads,omitempty"`
+	Scheduled         []scheduledMessage              `json:"scheduled,omitempty"`
 }
 
 func main() {
 	cfg := loadConfig()
+	if err := logging.Setup(logging.Options{Level: cfg.LogLevel, Format: cfg.LogFormat, Service: "channelbridge"}); err != nil {
+		slog.Warn("invalid CHANNEL_BRIDGE_LOG_* settings, using defaults", "error", err)
+	}
 	httpClient := &http.Client{Timeout: 20 * time.Second}
 	b := &bridge{
 		cfg:               cfg,
@@ -160,37 +389,85 @@ func main() {
 		inboundTTL:        10 * time.Minute,
 		teamsPolls:        map[string]map[string]any{},
 		replySeen:         map[string]bool{},
+		discordDMs:        map[string]string{},
 		metrics: bridgeMetrics{
 			StartedAt: time.Now().UTC(),
 		},
 	}
+	if cfg.TelegramBotToken != "" && cfg.TelegramMode == "webhook" && cfg.TelegramWebhookSecret == "" {
+		slog.Error("channelbridge: TELEGRAM_MODE=webhook requires TELEGRAM_WEBHOOK_SECRET")
+		os.Exit(1)
+	}
+	store, err := openStateStore(cfg)
+	if err != nil {
+		slog.Error("channelbridge state store", "backend", cfg.StateBackend, "error", err)
+		os.Exit(1)
+	}
+	b.state = store
+	defer store.Close()
 	if err := b.loadState(); err != nil {
-		log.Printf("channelbridge state load warning: %v", err)
+		slog.Warn("channelbridge state load warning", "backend", cfg.StateBackend, "path", cfg.StatePath, "error", err)
 	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
 		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
 	})
+	mux.HandleFunc("/readyz", b.handleReadyz)
 	mux.HandleFunc("/status", b.handleStatus)
 	mux.HandleFunc("/slack/events", b.handleSlackEvents)
 	mux.HandleFunc("/slack/commands", b.handleSlackCommands)
 	mux.HandleFunc("/slack/interactions", b.handleSlackInteractions)
-	mux.HandleFunc("/slack/outbound", b.handleSlackOutbound)
+	mux.HandleFunc("/slack/outbound", b.queueOutbound("slack"))
 	mux.HandleFunc("/slack/resolve/users", b.handleSlackResolveUsers)
 	mux.HandleFunc("/slack/resolve/channels", b.handleSlackResolveChannels)
 	mux.HandleFunc("/slack/probe", b.handleSlackProbe)
+	mux.HandleFunc("/slack/history", b.handleSlackHistory)
 	mux.HandleFunc("/teams/messages", b.handleTeamsMessages)
-	mux.HandleFunc("/teams/outbound", b.handleTeamsOutbound)
+	mux.HandleFunc("/teams/outbound", b.queueOutbound("msteams"))
 	mux.HandleFunc("/teams/resolve/users", b.handleTeamsResolveUsers)
 	mux.HandleFunc("/teams/resolve/channels", b.handleTeamsResolveChannels)
 	mux.HandleFunc("/teams/probe", b.handleTeamsProbe)
+	mux.HandleFunc("/discord/outbound", b.queueOutbound("discord"))
+	mux.HandleFunc("/discord/resolve/users", b.handleDiscordResolveUsers)
+	mux.HandleFunc("/discord/resolve/channels", b.handleDiscordResolveChannels)
+	mux.HandleFunc("/discord/probe", b.handleDiscordProbe)
+	if cfg.TelegramMode == "webhook" {
+		mux.HandleFunc("/telegram/inbound", b.handleTelegramInbound)
+	}
+	mux.HandleFunc("/telegram/outbound", b.queueOutbound("telegram"))
+	mux.HandleFunc("/telegram/resolve/users", b.handleTelegramResolveUsers)
+	mux.HandleFunc("/telegram/resolve/channels", b.handleTelegramResolveChannels)
+	mux.HandleFunc("/telegram/probe", b.handleTelegramProbe)
+	mux.HandleFunc("/outbound/queue", b.handleOutboundQueue)
+	mux.HandleFunc("/polls", b.handlePolls)
+	mux.HandleFunc("/polls/", b.handlePoll)
+	mux.HandleFunc("/scheduled", b.handleScheduled)
+	mux.HandleFunc("/scheduled/", b.handleScheduledItem)
+	mux.HandleFunc("/typing", b.handleTyping)
 	b.startSlackSocketMode()
-
-	log.Printf("channelbridge listening on %s", cfg.ListenAddr)
-	if err := http.ListenAndServe(cfg.ListenAddr, mux); err != nil {
-		log.Fatalf("channelbridge failed: %v", err)
-	}
+	b.startDiscordGateway()
+	b.startTelegram()
+	b.startInboundQueue()
+	b.startOutboundQueue()
+	b.startScheduledSends()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	srv := &http.Server{Addr: cfg.ListenAddr, Handler: mux}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	slog.Info("channelbridge listening", "addr", cfg.ListenAddr)
+	select {
+	case err := <-errc:
+		slog.Error("channelbridge failed", "error", err)
+		os.Exit(1)
+	case sig := <-sigChan:
+		slog.Info("channelbridge shutting down", "signal", sig.String())
+	}
+	b.shutdown(srv)
 }
 
 func loadConfig() config {
@@ -203,8 +480,27 @@ func loadConfig() config {
 
 		KafclawBase: strings.TrimSpace(getEnvDefault("KAFCLAW_BASE_URL", "http://127.0.0.1:18791")),
 
-		KafclawSlackInboundToken:   strings.TrimSpace(os.Getenv("KAFCLAW_SLACK_INBOUND_TOKEN")),
-		KafclawMSTeamsInboundToken: strings.TrimSpace(os.Getenv("KAFCLAW_MSTEAMS_INBOUND_TOKEN")),
+		KafclawSlackInboundToken:    strings.TrimSpace(os.Getenv("KAFCLAW_SLACK_INBOUND_TOKEN")),
+		KafclawMSTeamsInboundToken:  strings.TrimSpace(os.Getenv("KAFCLAW_MSTEAMS_INBOUND_TOKEN")),
+		KafclawDiscordInboundToken:  strings.TrimSpace(os.Getenv("KAFCLAW_DISCORD_INBOUND_TOKEN")),
+		KafclawTelegramInboundToken: strings.TrimSpace(os.Getenv("KAFCLAW_TELEGRAM_INBOUND_TOKEN")),
+		DeliveryReceipts:            parseBoolDefault("CHANNEL_BRIDGE_DELIVERY_RECEIPTS", true),
+		InboundQueueMaxAge:          inboundQueueMaxAge(),
+		InboundQueueMax:             parseIntDefault("CHANNEL_BRIDGE_INBOUND_QUEUE_MAX", defaultInboundQueueMax),
+		OutboundQueueMaxAge:         outboundQueueMaxAge(),
+		OutboundQueueMax:            parseIntDefault("CHANNEL_BRIDGE_OUTBOUND_QUEUE_MAX", defaultOutboundQueueMax),
+		OutboundMaxAttempts:         parseIntDefault("CHANNEL_BRIDGE_OUTBOUND_MAX_ATTEMPTS", defaultOutboundMaxAttempts),
+		OutboundRetryBase:           time.Duration(parseIntDefault("CHANNEL_BRIDGE_OUTBOUND_RETRY_BASE_SEC", defaultOutboundRetryBaseSec)) * time.Second,
+		OutboundRetryMax:            time.Duration(parseIntDefault("CHANNEL_BRIDGE_OUTBOUND_RETRY_MAX_SEC", defaultOutboundRetryMaxSec)) * time.Second,
+		ShutdownTimeout:             time.Duration(parseIntDefault("CHANNEL_BRIDGE_SHUTDOWN_TIMEOUT_SEC", defaultShutdownTimeoutSec)) * time.Second,
+		LogLevel:                    getEnvDefault("CHANNEL_BRIDGE_LOG_LEVEL", "info"),
+		LogFormat:                   getEnvDefault("CHANNEL_BRIDGE_LOG_FORMAT", logging.FormatText),
+		InboundRatePerChat:          parseIntDefault("CHANNEL_BRIDGE_INBOUND_RATE_PER_CHAT", 0),
+		InboundRatePerSender:        parseIntDefault("CHANNEL_BRIDGE_INBOUND_RATE_PER_SENDER", 0),
+		InboundRateBurst:            parseIntDefault("CHANNEL_BRIDGE_INBOUND_RATE_BURST", 0),
+		InboundRateAllow:            parseInboundRateAllow(os.Getenv("CHANNEL_BRIDGE_INBOUND_RATE_ALLOW")),
+		MediaDownload:               parseBoolDefault("CHANNEL_BRIDGE_MEDIA_DOWNLOAD", true),
+		MediaMaxBytes:               int64(parseIntDefault("CHANNEL_BRIDGE_MEDIA_MAX_BYTES", defaultMediaMaxBytes)),
 
 		SlackBotToken:            strings.TrimSpace(os.Getenv("SLACK_BOT_TOKEN")),
 		SlackAppToken:            strings.TrimSpace(os.Getenv("SLACK_APP_TOKEN")),
@@ -219,6 +515,11 @@ func loadConfig() config {
 		SlackBotUserID:           strings.TrimSpace(os.Getenv("SLACK_BOT_USER_ID")),
 		SlackSigningSecret:       strings.TrimSpace(os.Getenv("SLACK_SIGNING_SECRET")),
 		SlackAPIBase:             strings.TrimSpace(getEnvDefault("SLACK_API_BASE", "https://slack.com/api")),
+		SlackAutoJoin:            parseBoolDefault("SLACK_AUTO_JOIN", true),
+		SlackAllowBroadcast:      parseBoolDefault("SLACK_ALLOW_BROADCAST", false),
+		SlackAccounts:            parseSlackAccounts(os.Getenv("SLACK_ACCOUNTS")),
+		SlackWorkspaceTokens:     parseSlackWorkspaceTokens(os.Getenv("SLACK_WORKSPACE_TOKENS")),
+		ChannelLinks:             parseChannelLinks(os.Getenv("CHANNEL_BRIDGE_CHANNEL_LINKS")),
 
 		MSTeamsAppID:          strings.TrimSpace(os.Getenv("MSTEAMS_APP_ID")),
 		MSTeamsAppPassword:    strings.TrimSpace(os.Getenv("MSTEAMS_APP_PASSWORD")),
@@ -234,13 +535,49 @@ func loadConfig() config {
 			"*.sharepoint.com",
 			"*.onedrive.com",
 		}),
-		MSTeamsTenantID:      strings.TrimSpace(getEnvDefault("MSTEAMS_TENANT_ID", "botframework.com")),
-		MSTeamsInboundBearer: strings.TrimSpace(os.Getenv("MSTEAMS_INBOUND_BEARER")),
-		MSTeamsOpenIDConfig:  strings.TrimSpace(getEnvDefault("MSTEAMS_OPENID_CONFIG", "https://login.botframework.com/v1/.well-known/openidconfiguration")),
-		MSTeamsAPIBase:       strings.TrimSpace(getEnvDefault("MSTEAMS_API_BASE", "")),
-		MSTeamsGraphBase:     strings.TrimSpace(getEnvDefault("MSTEAMS_GRAPH_BASE", "https://graph.microsoft.com/v1.0")),
-
-		StatePath: strings.TrimSpace(getEnvDefault("CHANNEL_BRIDGE_STATE", defaultState)),
+		MSTeamsTenantID:       strings.TrimSpace(getEnvDefault("MSTEAMS_TENANT_ID", "botframework.com")),
+		MSTeamsInboundBearer:  strings.TrimSpace(os.Getenv("MSTEAMS_INBOUND_BEARER")),
+		MSTeamsOpenIDConfig:   strings.TrimSpace(getEnvDefault("MSTEAMS_OPENID_CONFIG", "https://login.botframework.com/v1/.well-known/openidconfiguration")),
+		MSTeamsAPIBase:        strings.TrimSpace(getEnvDefault("MSTEAMS_API_BASE", "")),
+		MSTeamsServiceURL:     strings.TrimSpace(getEnvDefault("MSTEAMS_SERVICE_URL", "https://smba.trafficmanager.net/teams/")),
+		MSTeamsGraphBase:      strings.TrimSpace(getEnvDefault("MSTEAMS_GRAPH_BASE", "https://graph.microsoft.com/v1.0")),
+		MSTeamsOutboundFormat: strings.ToLower(strings.TrimSpace(getEnvDefault("MSTEAMS_OUTBOUND_FORMAT", "text"))),
+		MSTeamsAccounts:       parseTeamsAccounts(os.Getenv("MSTEAMS_ACCOUNTS")),
+
+		DiscordBotToken:       strings.TrimSpace(os.Getenv("DISCORD_BOT_TOKEN")),
+		DiscordAPIBase:        strings.TrimSpace(getEnvDefault("DISCORD_API_BASE", "https://discord.com/api/v10")),
+		DiscordAccountID:      strings.TrimSpace(getEnvDefault("DISCORD_ACCOUNT_ID", "default")),
+		DiscordReplyMode:      strings.TrimSpace(getEnvDefault("DISCORD_REPLY_MODE", "all")),
+		DiscordHistoryLimit:   parseIntDefault("DISCORD_HISTORY_LIMIT", 50),
+		DiscordDMHistoryLimit: parseIntDefault("DISCORD_DM_HISTORY_LIMIT", 20),
+		DiscordBotUserID:      strings.TrimSpace(os.Getenv("DISCORD_BOT_USER_ID")),
+		DiscordGateway:        parseBoolDefault("DISCORD_GATEWAY", true),
+		DiscordGatewayURL:     strings.TrimSpace(os.Getenv("DISCORD_GATEWAY_URL")),
+		DiscordIntents:        parseDiscordIntents(os.Getenv("DISCORD_INTENTS")),
+		DiscordGuildID:        strings.TrimSpace(os.Getenv("DISCORD_GUILD_ID")),
+
+		TelegramBotToken:       strings.TrimSpace(os.Getenv("TELEGRAM_BOT_TOKEN")),
+		TelegramAPIBase:        strings.TrimSpace(getEnvDefault("TELEGRAM_API_BASE", "https://api.telegram.org")),
+		TelegramAccountID:      strings.TrimSpace(getEnvDefault("TELEGRAM_ACCOUNT_ID", "default")),
+		TelegramReplyMode:      strings.TrimSpace(getEnvDefault("TELEGRAM_REPLY_MODE", "all")),
+		TelegramHistoryLimit:   parseIntDefault("TELEGRAM_HISTORY_LIMIT", 50),
+		TelegramDMHistoryLimit: parseIntDefault("TELEGRAM_DM_HISTORY_LIMIT", 20),
+		TelegramBotUsername:    strings.TrimSpace(os.Getenv("TELEGRAM_BOT_USERNAME")),
+		TelegramMode:           parseTelegramMode(os.Getenv("TELEGRAM_MODE")),
+		TelegramWebhookURL:     strings.TrimSpace(os.Getenv("TELEGRAM_WEBHOOK_URL")),
+		TelegramWebhookSecret:  strings.TrimSpace(os.Getenv("TELEGRAM_WEBHOOK_SECRET")),
+
+		DefaultLanguage:     normalizeLanguage(getEnvDefault("CHANNEL_BRIDGE_LANGUAGE", "en")),
+		LanguageByWorkspace: parseLanguageByWorkspace(os.Getenv("CHANNEL_BRIDGE_LANGUAGE_BY_WORKSPACE")),
+
+		ReadyRequire:  parseReadyRequire(os.Getenv("CHANNEL_BRIDGE_READY_REQUIRE")),
+		ReadyCacheTTL: time.Duration(parseIntDefault("CHANNEL_BRIDGE_READY_CACHE_SEC", defaultReadyCacheSec)) * time.Second,
+
+		StatePath:    strings.TrimSpace(getEnvDefault("CHANNEL_BRIDGE_STATE", defaultState)),
+		StateBackend: strings.ToLower(strings.TrimSpace(getEnvDefault("CHANNEL_BRIDGE_STATE_BACKEND", stateBackendFile))),
+		StateURL:     strings.TrimSpace(os.Getenv("CHANNEL_BRIDGE_STATE_URL")),
+		StatePrefix:  strings.TrimSpace(getEnvDefault("CHANNEL_BRIDGE_STATE_PREFIX", "channelbridge:")),
+		InstanceID:   strings.TrimSpace(getEnvDefault("CHANNEL_BRIDGE_INSTANCE_ID", defaultInstanceID())),
 	}
 }
 
@@ -338,8 +675,16 @@ func (b *bridge) handleStatus(w http.ResponseWriter, _ *http.Request) {
 			"user_refs":               userCount,
 			"token_cached":            hasToken,
 			"inbound_bearer_required": strings.TrimSpace(b.cfg.MSTeamsInboundBearer) != "",
+			"accounts":                teamsAccountIDs(b.teamsAccounts()),
 		},
+		"slack": map[string]any{
+			"accounts": slackAccountIDs(b.slackAccounts()),
+		},
+		"discord":              b.discordStatus(),
+		"telegram":             b.telegramStatus(),
 		"inbound_dedupe_cache": b.inboundCacheSize(),
+		"inbound_queue":        b.inboundQueueStatus(),
+		"outbound_queue":       b.outboundQueueStatus(),
 	})
 }
 
@@ -356,6 +701,11 @@ func (b *bridge) noteInboundForward(success bool, err error) {
 	if success {
 		return
 	}
+	var rl *inboundRateLimitError
+	if errors.As(err, &rl) {
+		// Counted by noteInboundRateLimited.
+		return
+	}
 	b.metrics.InboundForwardErrors++
 	if err != nil {
 		b.metrics.LastError = err.Error()
@@ -363,13 +713,18 @@ func (b *bridge) noteInboundForward(success bool, err error) {
 	}
 }
 
-func (b *bridge) noteOutbound(success bool, isSlack bool, err error) {
+func (b *bridge) noteOutbound(success bool, channel string, err error) {
 	b.metricsMu.Lock()
 	defer b.metricsMu.Unlock()
 	if success {
-		if isSlack {
+		switch channel {
+		case "slack":
 			b.metrics.SlackOutboundSent++
-		} else {
+		case "discord":
+			b.metrics.DiscordOutboundSent++
+		case "telegram":
+			b.metrics.TelegramOutboundSent++
+		default:
 			b.metrics.TeamsOutboundSent++
 		}
 		return
@@ -381,14 +736,19 @@ func (b *bridge) noteOutbound(success bool, isSlack bool, err error) {
 	}
 }
 
-func (b *bridge) noteInboundDeduped(isSlack bool) {
+func (b *bridge) noteInboundDeduped(channel string) {
 	b.metricsMu.Lock()
 	defer b.metricsMu.Unlock()
-	if isSlack {
+	switch channel {
+	case "slack":
 		b.metrics.SlackInboundDeduped++
-		return
+	case "discord":
+		b.metrics.DiscordInboundDeduped++
+	case "telegram":
+		b.metrics.TelegramInboundDeduped++
+	default:
+		b.metrics.TeamsInboundDeduped++
 	}
-	b.metrics.TeamsInboundDeduped++
 }
 
 func (b *bridge) noteInboundAuthRejected() {
@@ -407,7 +767,8 @@ func (b *bridge) handleSlackEvents(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "bad body", http.StatusBadRequest)
 		return
 	}
-	if err := verifySlackSignature(body, r, b.cfg.SlackSigningSecret); err != nil {
+	acct, err := b.slackAccountForRequest(body, r)
+	if err != nil {
 		http.Error(w, "invalid slack signature", http.StatusUnauthorized)
 		return
 	}
@@ -417,9 +778,9 @@ func (b *bridge) handleSlackEvents(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid json", http.StatusBadRequest)
 		return
 	}
-	resp, err := b.processSlackEventsPayload(payload)
+	resp, err := b.processSlackEventsPayload(acct, payload)
 	if err != nil {
-		http.Error(w, "forward failed", http.StatusBadGateway)
+		writeInboundError(w, err)
 		return
 	}
 	_ = json.NewEncoder(w).Encode(resp)
@@ -435,7 +796,8 @@ func (b *bridge) handleSlackCommands(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "bad body", http.StatusBadRequest)
 		return
 	}
-	if err := verifySlackSignature(body, r, b.cfg.SlackSigningSecret); err != nil {
+	acct, err := b.slackAccountForRequest(body, r)
+	if err != nil {
 		http.Error(w, "invalid slack signature", http.StatusUnauthorized)
 		return
 	}
@@ -445,12 +807,14 @@ func (b *bridge) handleSlackCommands(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid slash command", http.StatusBadRequest)
 		return
 	}
-	if err := b.forwardSlackSlashCommand(cmd); err != nil {
-		http.Error(w, "forward failed", http.StatusBadGateway)
+	w.Header().Set("Content-Type", "application/json")
+	if err := b.forwardSlackSlashCommand(acct, cmd); err != nil {
+		// Slack shows non-2xx replies as a generic dispatch failure; answer
+		// with a localized ephemeral message instead.
+		_ = json.NewEncoder(w).Encode(map[string]any{"response_type": "ephemeral", "text": b.text(cmd.TeamID, msgCommandFailed)})
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(map[string]any{"response_type": "ephemeral", "text": "accepted"})
+	_ = json.NewEncoder(w).Encode(map[string]any{"response_type": "ephemeral", "text": b.text(cmd.TeamID, msgCommandAccepted)})
 }
 
 func (b *bridge) handleSlackInteractions(w http.ResponseWriter, r *http.Request) {
@@ -463,7 +827,8 @@ func (b *bridge) handleSlackInteractions(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "bad body", http.StatusBadRequest)
 		return
 	}
-	if err := verifySlackSignature(body, r, b.cfg.SlackSigningSecret); err != nil {
+	acct, err := b.slackAccountForRequest(body, r)
+	if err != nil {
 		http.Error(w, "invalid slack signature", http.StatusUnauthorized)
 		return
 	}
@@ -473,8 +838,19 @@ func (b *bridge) handleSlackInteractions(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "invalid interaction payload", http.StatusBadRequest)
 		return
 	}
-	if err := b.forwardSlackInteraction(cb); err != nil {
-		http.Error(w, "forward failed", http.StatusBadGateway)
+	if handled, details := b.handleSlackPollVote(cb); handled {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "poll_vote_recorded": details != nil && details["closed"] != true, "poll": details})
+		return
+	}
+	if err := b.forwardSlackInteraction(acct, cb); err != nil {
+		writeInboundError(w, err)
+		return
+	}
+	if cb.Type == slack.InteractionTypeViewSubmission || cb.Type == slack.InteractionTypeViewClosed {
+		// An empty 200 closes the modal; a JSON body would be read as a
+		// response_action.
+		w.WriteHeader(http.StatusOK)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -508,26 +884,39 @@ func verifySlackSignature(body []byte, r *http.Request, secret string) error {
 	return nil
 }
 
-func (b *bridge) processSlackEventsPayload(payload map[string]any) (map[string]any, error) {
+func (b *bridge) processSlackEventsPayload(acct slackAccount, payload map[string]any) (map[string]any, error) {
 	switch strings.TrimSpace(asString(payload["type"])) {
 	case "url_verification":
 		return map[string]any{"challenge": asString(payload["challenge"])}, nil
 	case "event_callback":
+		eventKey := ""
 		if eventID := strings.TrimSpace(asString(payload["event_id"])); eventID != "" {
-			if b.seenInboundEvent("slack:event:"+eventID, time.Now()) {
-				b.noteInboundDeduped(true)
-				return map[string]any{"ok": true, "deduped": true}, nil
-			}
+			eventKey = "slack:event:" + eventID
+		}
+		if b.seenInboundEvent(eventKey, time.Now()) {
+			b.noteInboundDeduped("slack")
+			return map[string]any{"ok": true, "deduped": true}, nil
 		}
 		event, _ := payload["event"].(map[string]any)
 		if event == nil {
 			return map[string]any{"ok": true}, nil
 		}
-		in, ok := normalizeSlackInboundEvent(event, strings.TrimSpace(b.cfg.SlackBotUserID))
+		ws := slackEventWorkspace(payload, event)
+		if in, ok := normalizeSlackActivityEvent(event, acct.BotUserID); ok {
+			in.workspace = ws
+			if err := b.forwardSlackActivity(acct, in); err != nil {
+				b.releaseRateLimited(err, eventKey)
+				return nil, err
+			}
+			return map[string]any{"ok": true}, nil
+		}
+		in, ok := normalizeSlackInboundEvent(event, acct.BotUserID)
 		if !ok {
 			return map[string]any{"ok": true}, nil
 		}
-		if err := b.forwardSlackInbound(in.senderID, in.channelID, in.threadID, in.messageID, in.text, in.isGroup, in.wasMentioned); err != nil {
+		in.workspace = ws
+		if err := b.forwardSlackInbound(acct, in); err != nil {
+			b.releaseRateLimited(err, eventKey)
 			return nil, err
 		}
 		return map[string]any{"ok": true}, nil
@@ -544,6 +933,133 @@ type slackInbound struct {
 	text         string
 	isGroup      bool
 	wasMentioned bool
+	workspace    slackWorkspace
+	// files are the files attached to the message, downloaded and
+	// forwarded as media.
+	files []slackFile
+	// eventType and event describe non-message activity (reactions, file
+	// shares, channel membership); empty for regular messages.
+	eventType string
+	event     map[string]any
+	// mirror marks a channel message event, which is mirrored to a linked
+	// Teams channel. Slash commands and interactions are never mirrored.
+	mirror bool
+}
+
+// Slack reactions mapped to feedback sentiment; other reactions are neutral.
+var (
+	slackPositiveReactions = map[string]bool{
+		"+1": true, "thumbsup": true, "white_check_mark": true, "heavy_check_mark": true,
+		"heart": true, "tada": true, "raised_hands": true, "clap": true, "100": true, "star": true,
+	}
+	slackNegativeReactions = map[string]bool{
+		"-1": true, "thumbsdown": true, "x": true, "no_entry": true, "no_entry_sign": true,
+		"confused": true, "disappointed": true, "rage": true,
+	}
+)
+
+func slackReactionSentiment(reaction string) string {
+	// Skin tone variants arrive as "thumbsup::skin-tone-2".
+	name, _, _ := strings.Cut(reaction, "::")
+	switch {
+	case slackPositiveReactions[name]:
+		return "positive"
+	case slackNegativeReactions[name]:
+		return "negative"
+	default:
+		return "neutral"
+	}
+}
+
+// normalizeSlackActivityEvent maps reaction_added/removed, file_shared and
+// member_joined/left_channel events to an inbound with a distinct event type.
+// The bot's own activity is dropped.
+func normalizeSlackActivityEvent(event map[string]any, botUserID string) (slackInbound, bool) {
+	eventType := strings.TrimSpace(asString(event["type"]))
+	botUserID = strings.TrimSpace(botUserID)
+	eventTS := strings.TrimSpace(asString(event["event_ts"]))
+	switch eventType {
+	case "reaction_added", "reaction_removed":
+		item, _ := event["item"].(map[string]any)
+		if strings.TrimSpace(asString(item["type"])) != "message" {
+			return slackInbound{}, false
+		}
+		senderID := strings.TrimSpace(asString(event["user"]))
+		channelID := strings.TrimSpace(asString(item["channel"]))
+		reaction := strings.TrimSpace(asString(event["reaction"]))
+		if senderID == "" || channelID == "" || reaction == "" || senderID == botUserID {
+			return slackInbound{}, false
+		}
+		itemTS := strings.TrimSpace(asString(item["ts"]))
+		itemUser := strings.TrimSpace(asString(event["item_user"]))
+		onBotMessage := botUserID != "" && itemUser == botUserID
+		action := strings.TrimPrefix(eventType, "reaction_")
+		return slackInbound{
+			senderID:     senderID,
+			channelID:    channelID,
+			messageID:    firstNonEmpty(eventTS, action+":"+itemTS+":"+reaction),
+			text:         fmt.Sprintf("[reaction %s: :%s: on message %s]", action, reaction, itemTS),
+			isGroup:      !strings.HasPrefix(strings.ToUpper(channelID), "D"),
+			wasMentioned: onBotMessage,
+			eventType:    eventType,
+			event: map[string]any{
+				"feedback":       true,
+				"action":         action,
+				"reaction":       reaction,
+				"sentiment":      slackReactionSentiment(reaction),
+				"item_ts":        itemTS,
+				"item_user":      itemUser,
+				"on_bot_message": onBotMessage,
+			},
+		}, true
+	case "file_shared":
+		senderID := strings.TrimSpace(firstNonEmpty(asString(event["user_id"]), asString(event["user"])))
+		channelID := strings.TrimSpace(firstNonEmpty(asString(event["channel_id"]), asString(event["channel"])))
+		fileID := strings.TrimSpace(asString(event["file_id"]))
+		if fileID == "" {
+			file, _ := event["file"].(map[string]any)
+			fileID = strings.TrimSpace(asString(file["id"]))
+		}
+		if senderID == "" || channelID == "" || fileID == "" || senderID == botUserID {
+			return slackInbound{}, false
+		}
+		return slackInbound{
+			senderID:  senderID,
+			channelID: channelID,
+			messageID: firstNonEmpty(eventTS, "file:"+fileID),
+			text:      "[file shared: " + fileID + "]",
+			isGroup:   !strings.HasPrefix(strings.ToUpper(channelID), "D"),
+			eventType: eventType,
+			event:     map[string]any{"file_id": fileID},
+		}, true
+	case "member_joined_channel", "member_left_channel":
+		senderID := strings.TrimSpace(asString(event["user"]))
+		channelID := strings.TrimSpace(asString(event["channel"]))
+		if senderID == "" || channelID == "" || senderID == botUserID {
+			return slackInbound{}, false
+		}
+		verb := "joined"
+		if eventType == "member_left_channel" {
+			verb = "left"
+		}
+		ev := map[string]any{
+			"user":         senderID,
+			"channel_type": strings.TrimSpace(asString(event["channel_type"])),
+		}
+		if inviter := strings.TrimSpace(asString(event["inviter"])); inviter != "" {
+			ev["inviter"] = inviter
+		}
+		return slackInbound{
+			senderID:  senderID,
+			channelID: channelID,
+			messageID: firstNonEmpty(eventTS, verb+":"+senderID),
+			text:      fmt.Sprintf("[member %s channel: <@%s>]", verb, senderID),
+			isGroup:   true,
+			eventType: eventType,
+			event:     ev,
+		}, true
+	}
+	return slackInbound{}, false
 }
 
 func normalizeSlackInboundEvent(event map[string]any, botUserID string) (slackInbound, bool) {
@@ -642,49 +1158,169 @@ func normalizeSlackInboundEvent(event map[string]any, botUserID string) (slackIn
 		text:         text,
 		isGroup:      isGroup,
 		wasMentioned: wasMentioned,
+		files:        slackEventFiles(msg["files"]),
+		mirror:       true,
 	}, true
 }
 
-func (b *bridge) forwardSlackInbound(senderID, channelID, threadID, messageID, text string, isGroup, wasMentioned bool) error {
-	channelID = strings.TrimSpace(channelID)
-	senderID = strings.TrimSpace(senderID)
+func (b *bridge) forwardSlackInbound(acct slackAccount, in slackInbound) error {
+	channelID := strings.TrimSpace(in.channelID)
+	senderID := strings.TrimSpace(in.senderID)
 	if channelID == "" || senderID == "" {
 		return nil
 	}
-	if messageID != "" && b.seenInboundEvent("slack:msg:"+channelID+":"+messageID, time.Now()) {
-		b.noteInboundDeduped(true)
+	messageID := strings.TrimSpace(in.messageID)
+	seenKey := ""
+	if messageID != "" {
+		seenKey = "slack:msg:" + channelID + ":" + messageID
+	}
+	if b.seenInboundEvent(seenKey, time.Now()) {
+		b.noteInboundDeduped("slack")
 		return nil
 	}
-	err := b.postInbound("/api/v1/channels/slack/inbound", b.cfg.KafclawSlackInboundToken, map[string]any{
-		"account_id":       strings.TrimSpace(b.cfg.SlackAccountID),
+	ws := in.workspace
+	b.noteSlackWorkspace(acct.ID, channelID, ws.ID)
+	payload := map[string]any{
+		"account_id":       acct.ID,
+		"workspace_id":     ws.ID,
+		"enterprise_id":    ws.EnterpriseID,
 		"sender_id":        senderID,
 		"chat_id":          channelID,
-		"thread_id":        strings.TrimSpace(threadID),
-		"message_id":       strings.TrimSpace(messageID),
-		"text":             text,
-		"is_group":         isGroup,
-		"was_mentioned":    wasMentioned,
+		"thread_id":        strings.TrimSpace(in.threadID),
+		"message_id":       messageID,
+		"text":             in.text,
+		"is_group":         in.isGroup,
+		"was_mentioned":    in.wasMentioned,
 		"history_limit":    b.cfg.SlackHistoryLimit,
 		"dm_history_limit": b.cfg.SlackDMHistoryLimit,
-	})
+	}
+	setInboundMedia(payload, b.slackInboundMedia(acct.forWorkspace(ws.ID), in.files))
+	queued, err := b.forwardInbound("slack", "/api/v1/channels/slack/inbound", channelID, payload)
+	var rl *inboundRateLimitError
+	if in.mirror && !errors.As(err, &rl) {
+		// A rate-limited event is mirrored when Slack redelivers it.
+		b.mirrorSlackToTeams(acct, in)
+	}
+	if err != nil {
+		b.releaseRateLimited(err, seenKey)
+		b.noteInboundForward(false, err)
+		slog.Warn("slack inbound forward failed", "error", err)
+		return err
+	}
+	if queued {
+		return nil
+	}
+	b.metricsMu.Lock()
+	b.metrics.SlackInboundForwarded++
+	b.metricsMu.Unlock()
+	return nil
+}
+
+// forwardSlackActivity forwards a non-message event with its event type and
+// payload. File shares are enriched via files.info when a bot token is set.
+func (b *bridge) forwardSlackActivity(acct slackAccount, in slackInbound) error {
+	seenKey := ""
+	if in.messageID != "" {
+		seenKey = "slack:" + in.eventType + ":" + in.channelID + ":" + in.messageID
+	}
+	if b.seenInboundEvent(seenKey, time.Now()) {
+		b.noteInboundDeduped("slack")
+		return nil
+	}
+	b.noteSlackWorkspace(acct.ID, in.channelID, in.workspace.ID)
+	wsAcct := acct.forWorkspace(in.workspace.ID)
+	if in.eventType == "file_shared" {
+		b.slackEnrichFileShared(wsAcct, &in)
+	}
+	payload := map[string]any{
+		"account_id":    acct.ID,
+		"workspace_id":  in.workspace.ID,
+		"enterprise_id": in.workspace.EnterpriseID,
+		"sender_id":     in.senderID,
+		"chat_id":       in.channelID,
+		"thread_id":     in.threadID,
+		"message_id":    in.messageID,
+		"text":          in.text,
+		"is_group":      in.isGroup,
+		"was_mentioned": in.wasMentioned,
+		"event_type":    in.eventType,
+		"event":         in.event,
+	}
+	setInboundMedia(payload, b.slackInboundMedia(wsAcct, in.files))
+	queued, err := b.forwardInbound("slack", "/api/v1/channels/slack/inbound", in.channelID, payload)
 	if err != nil {
+		b.releaseRateLimited(err, seenKey)
 		b.noteInboundForward(false, err)
-		log.Printf("slack inbound forward failed: %v", err)
+		slog.Warn("slack activity forward failed", "event", in.eventType, "error", err)
 		return err
 	}
+	if queued {
+		return nil
+	}
 	b.metricsMu.Lock()
 	b.metrics.SlackInboundForwarded++
 	b.metricsMu.Unlock()
 	return nil
 }
 
-func (b *bridge) forwardSlackSlashCommand(cmd slack.SlashCommand) error {
+// slackEnrichFileShared adds name, type, size and download URL of the shared
+// file. Lookup failures keep the bare file id.
+func (b *bridge) slackEnrichFileShared(acct slackAccount, in *slackInbound) {
+	fileID := asString(in.event["file_id"])
+	api, err := b.slackClient(acct)
+	if err != nil {
+		return
+	}
+	file, _, _, err := api.GetFileInfo(fileID, 0, 0)
+	if err != nil || file == nil {
+		slog.Warn("slack files.info failed", "file", fileID, "error", err)
+		return
+	}
+	in.event["name"] = file.Name
+	in.event["title"] = file.Title
+	in.event["mimetype"] = file.Mimetype
+	in.event["size"] = file.Size
+	in.event["url_private_download"] = file.URLPrivateDownload
+	in.event["permalink"] = file.Permalink
+	in.files = []slackFile{{ID: file.ID, Name: file.Name, MimeType: file.Mimetype, Size: file.Size, URL: firstNonEmpty(file.URLPrivateDownload, file.URLPrivate)}}
+	in.text = fmt.Sprintf("[file shared: %s (%s, %d bytes)]", firstNonEmpty(file.Name, fileID), file.Mimetype, file.Size)
+}
+
+func (b *bridge) forwardSlackSlashCommand(acct slackAccount, cmd slack.SlashCommand) error {
 	content := strings.TrimSpace(strings.TrimSpace(cmd.Command) + " " + strings.TrimSpace(cmd.Text))
 	isGroup := !strings.HasPrefix(strings.ToUpper(strings.TrimSpace(cmd.ChannelID)), "D")
-	return b.forwardSlackInbound(cmd.UserID, cmd.ChannelID, "", cmd.TriggerID, content, isGroup, true)
+	return b.forwardSlackInbound(acct, slackInbound{
+		senderID:     cmd.UserID,
+		channelID:    cmd.ChannelID,
+		messageID:    cmd.TriggerID,
+		text:         content,
+		isGroup:      isGroup,
+		wasMentioned: true,
+		workspace:    slackWorkspace{ID: normalizeSlackTeamID(cmd.TeamID), EnterpriseID: strings.TrimSpace(cmd.EnterpriseID)},
+	})
 }
 
-func (b *bridge) forwardSlackInteraction(cb slack.InteractionCallback) error {
+// slackApprovalActionPrefix starts the action_id of the Approve and Deny
+// buttons KafClaw adds to approval prompts.
+const slackApprovalActionPrefix = "kafclaw_approval_"
+
+// slackApprovalReply returns the approve:<id> or deny:<id> reply of a click
+// on an approval button, so KafClaw handles it like a typed reply.
+func slackApprovalReply(actionID, value string) (string, bool) {
+	if !strings.HasPrefix(actionID, slackApprovalActionPrefix) {
+		return "", false
+	}
+	verb, id, ok := strings.Cut(value, ":")
+	if !ok || (verb != "approve" && verb != "deny") || strings.TrimSpace(id) == "" {
+		return "", false
+	}
+	return value, true
+}
+
+func (b *bridge) forwardSlackInteraction(acct slackAccount, cb slack.InteractionCallback) error {
+	if cb.Type == slack.InteractionTypeViewSubmission || cb.Type == slack.InteractionTypeViewClosed {
+		return b.forwardSlackViewEvent(acct, cb)
+	}
 	channelID := strings.TrimSpace(cb.Channel.ID)
 	if channelID == "" {
 		channelID = strings.TrimSpace(cb.Container.ChannelID)
@@ -704,31 +1340,48 @@ func (b *bridge) forwardSlackInteraction(cb slack.InteractionCallback) error {
 	if content == "interactive" {
 		content = "interactive " + strings.TrimSpace(string(cb.Type))
 	}
+	if reply, ok := slackApprovalReply(actionID, actionVal); ok {
+		content = reply
+	}
 	isGroup := !strings.HasPrefix(strings.ToUpper(channelID), "D")
 	messageID := strings.TrimSpace(cb.ActionTs)
 	if messageID == "" {
 		messageID = strings.TrimSpace(cb.TriggerID)
 	}
-	return b.forwardSlackInbound(cb.User.ID, channelID, threadID, messageID, content, isGroup, true)
+	return b.forwardSlackInbound(acct, slackInbound{
+		senderID:     cb.User.ID,
+		channelID:    channelID,
+		threadID:     threadID,
+		messageID:    messageID,
+		text:         content,
+		isGroup:      isGroup,
+		wasMentioned: true,
+		workspace:    slackInteractionWorkspace(cb),
+	})
 }
 
+// startSlackSocketMode opens a Socket Mode connection for every account
+// with an app token.
 func (b *bridge) startSlackSocketMode() {
-	appToken := strings.TrimSpace(b.cfg.SlackAppToken)
-	if appToken == "" {
-		return
-	}
-	api, err := b.slackClientWithAppToken(appToken)
-	if err != nil {
-		log.Printf("slack socket mode disabled: %v", err)
-		return
+	for _, acct := range b.slackAccounts() {
+		if acct.AppToken == "" {
+			continue
+		}
+		api, err := b.slackClientWithAppToken(acct)
+		if err != nil {
+			slog.Warn("slack socket mode disabled", "account", acct.ID, "error", err)
+			b.noteSlackSocket(acct.ID, "stopped", err)
+			continue
+		}
+		client := socketmode.New(api)
+		go b.runSlackSocketMode(acct, client)
 	}
-	client := socketmode.New(api)
-	go b.runSlackSocketMode(client)
 }
 
-func (b *bridge) runSlackSocketMode(client *socketmode.Client) {
+func (b *bridge) runSlackSocketMode(acct slackAccount, client *socketmode.Client) {
 	go func() {
 		for evt := range client.Events {
+			b.noteSlackSocketEvent(acct.ID, evt)
 			switch evt.Type {
 			case socketmode.EventTypeEventsAPI:
 				if evt.Request != nil {
@@ -738,42 +1391,91 @@ func (b *bridge) runSlackSocketMode(client *socketmode.Client) {
 				if !ok || ev.Type != slackevents.CallbackEvent {
 					continue
 				}
+				ws := slackWorkspace{ID: normalizeSlackTeamID(ev.TeamID), EnterpriseID: strings.TrimSpace(ev.EnterpriseID)}
 				switch in := ev.InnerEvent.Data.(type) {
 				case *slackevents.MessageEvent:
 					if in == nil {
 						continue
 					}
 					wasMentioned := false
-					if botID := strings.TrimSpace(b.cfg.SlackBotUserID); botID != "" {
+					if botID := acct.BotUserID; botID != "" {
 						wasMentioned = strings.Contains(in.Text, "<@"+botID+">")
 					}
-					_ = b.forwardSlackInbound(in.User, in.Channel, in.ThreadTimeStamp, in.TimeStamp, in.Text, in.ChannelType != "im", wasMentioned)
+					var files []slackFile
+					if in.Message != nil {
+						files = slackMsgFiles(in.Message.Files)
+					}
+					_ = b.forwardSlackInbound(acct, slackInbound{
+						senderID:     in.User,
+						channelID:    in.Channel,
+						threadID:     in.ThreadTimeStamp,
+						messageID:    in.TimeStamp,
+						text:         in.Text,
+						isGroup:      in.ChannelType != "im",
+						wasMentioned: wasMentioned,
+						workspace:    ws,
+						files:        files,
+						mirror:       true,
+					})
 				case *slackevents.AppMentionEvent:
 					if in == nil {
 						continue
 					}
-					_ = b.forwardSlackInbound(in.User, in.Channel, in.ThreadTimeStamp, in.TimeStamp, in.Text, true, true)
+					_ = b.forwardSlackInbound(acct, slackInbound{
+						senderID:     in.User,
+						channelID:    in.Channel,
+						threadID:     in.ThreadTimeStamp,
+						messageID:    in.TimeStamp,
+						text:         in.Text,
+						isGroup:      true,
+						wasMentioned: true,
+						workspace:    ws,
+						mirror:       true,
+					})
+				case *slackevents.ReactionAddedEvent, *slackevents.ReactionRemovedEvent, *slackevents.FileSharedEvent,
+					*slackevents.MemberJoinedChannelEvent, *slackevents.MemberLeftChannelEvent:
+					// The typed events keep Slack's field names; reuse the HTTP normalization.
+					raw, err := json.Marshal(in)
+					if err != nil {
+						continue
+					}
+					var event map[string]any
+					if json.Unmarshal(raw, &event) != nil {
+						continue
+					}
+					if act, ok := normalizeSlackActivityEvent(event, acct.BotUserID); ok {
+						act.workspace = ws
+						_ = b.forwardSlackActivity(acct, act)
+					}
 				}
 			case socketmode.EventTypeSlashCommand:
+				cmd, ok := evt.Data.(slack.SlashCommand)
 				if evt.Request != nil {
-					client.Ack(*evt.Request, map[string]any{"response_type": "ephemeral", "text": "accepted"})
+					client.Ack(*evt.Request, map[string]any{"response_type": "ephemeral", "text": b.text(cmd.TeamID, msgCommandAccepted)})
 				}
-				cmd, ok := evt.Data.(slack.SlashCommand)
 				if ok {
-					_ = b.forwardSlackSlashCommand(cmd)
+					_ = b.forwardSlackSlashCommand(acct, cmd)
 				}
 			case socketmode.EventTypeInteractive:
 				if evt.Request != nil {
 					client.Ack(*evt.Request)
 				}
 				cb, ok := evt.Data.(slack.InteractionCallback)
-				if ok {
-					_ = b.forwardSlackInteraction(cb)
+				if !ok {
+					continue
+				}
+				if handled, _ := b.handleSlackPollVote(cb); !handled {
+					_ = b.forwardSlackInteraction(acct, cb)
 				}
 			}
 		}
 	}()
-	client.Run()
+	err := client.Run()
+	if err == nil {
+		err = errors.New("socket mode client stopped")
+	}
+	slog.Warn("slack socket mode stopped", "account", acct.ID, "error", err)
+	b.noteSlackSocket(acct.ID, "stopped", err)
 }
 
 func (b *bridge) handleSlackOutbound(w http.ResponseWriter, r *http.Request) {
@@ -783,6 +1485,7 @@ func (b *bridge) handleSlackOutbound(w http.ResponseWriter, r *http.Request) {
 	}
 	var req struct {
 		AccountID         string         `json:"account_id"`
+		WorkspaceID       string         `json:"workspace_id"`
 		ChatID            string         `json:"chat_id"`
 		ThreadID          string         `json:"thread_id"`
 		ReplyMode         string         `json:"reply_mode"`
@@ -797,6 +1500,11 @@ func (b *bridge) handleSlackOutbound(w http.ResponseWriter, r *http.Request) {
 		PollQuestion      string         `json:"poll_question"`
 		PollOptions       []string       `json:"poll_options"`
 		PollMaxSelections int            `json:"poll_max_selections"`
+		SendAt            string         `json:"send_at"`
+		Visibility        string         `json:"visibility"`
+		UserID            string         `json:"user_id"`
+		TaskID            string         `json:"task_id"`
+		TraceID           string         `json:"trace_id"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "invalid json", http.StatusBadRequest)
@@ -806,20 +1514,40 @@ func (b *bridge) handleSlackOutbound(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "chat_id required", http.StatusBadRequest)
 		return
 	}
-	if strings.TrimSpace(req.Content) == "" && len(req.MediaURLs) == 0 && len(req.Card) == 0 && strings.TrimSpace(req.Action) == "" {
-		http.Error(w, "content, media_urls, card or action required", http.StatusBadRequest)
+	if strings.TrimSpace(req.Content) == "" && len(req.MediaURLs) == 0 && len(req.Card) == 0 && strings.TrimSpace(req.Action) == "" && strings.TrimSpace(req.PollQuestion) == "" {
+		http.Error(w, "content, media_urls, card, poll or action required", http.StatusBadRequest)
+		return
+	}
+	sendAt, err := parseSendAt(req.SendAt, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !sendAt.IsZero() && (strings.TrimSpace(req.Action) != "" || len(req.MediaURLs) > 0) {
+		http.Error(w, "send_at does not support actions or media_urls", http.StatusBadRequest)
+		return
+	}
+	visibility, err := parseVisibility(req.Visibility, req.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if visibility == visibilityEphemeral && (len(req.MediaURLs) > 0 || strings.TrimSpace(req.PollQuestion) != "" || !sendAt.IsZero()) {
+		http.Error(w, "ephemeral replies support content and card only", http.StatusBadRequest)
 		return
 	}
 	accountID := strings.TrimSpace(req.AccountID)
 	if accountID == "" {
 		accountID = "default"
 	}
-	channelID, err := b.resolveSlackChannelID(req.ChatID)
+	acct := b.slackAccount(accountID).forWorkspace(req.WorkspaceID)
+	channelID, err := b.resolveSlackChannelID(acct, req.ChatID)
 	if err != nil {
-		b.noteOutbound(false, true, err)
-		http.Error(w, err.Error(), http.StatusBadGateway)
+		b.noteOutbound(false, "slack", err)
+		writeOutboundError(w, err, false)
 		return
 	}
+	acct = b.slackChannelAccount(acct, channelID)
 	defaultReplyMode := b.cfg.SlackReplyMode
 	if strings.TrimSpace(req.ReplyMode) == "" {
 		if override := resolveSlackReplyModeByChatType(channelID, b.cfg.SlackReplyModeByChatType); override != "" {
@@ -828,22 +1556,89 @@ func (b *bridge) handleSlackOutbound(w http.ResponseWriter, r *http.Request) {
 	}
 	threadID := b.resolveReplyThread("slack", accountID, req.ChatID, req.ThreadID, req.ReplyMode, defaultReplyMode)
 	if act := strings.TrimSpace(strings.ToLower(req.Action)); act != "" {
-		result, err := b.slackHandleAction(act, channelID, strings.TrimSpace(threadID), req.Content, req.ActionParams)
+		if act == "thread" {
+			// History reads target the requested thread regardless of reply mode.
+			threadID = req.ThreadID
+		}
+		result, err := b.slackHandleAction(acct, act, channelID, strings.TrimSpace(threadID), req.Content, req.ActionParams)
 		if err != nil {
-			b.noteOutbound(false, true, err)
+			b.noteOutbound(false, "slack", err)
 			http.Error(w, err.Error(), http.StatusBadGateway)
 			return
 		}
-		b.noteOutbound(true, true, nil)
+		b.noteOutbound(true, "slack", nil)
 		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": result})
 		return
 	}
+	req.Content = b.slackExpandMentions(acct, req.Content)
+	if visibility == visibilityEphemeral {
+		err := b.slackPostEphemeral(acct, channelID, threadID, req.UserID, req.Content, req.Card)
+		if err == nil {
+			b.noteOutbound(true, "slack", nil)
+			writeOutboundDelivery(w, outboundDelivery{
+				Channel:    "slack",
+				AccountID:  accountID,
+				ChatID:     strings.TrimSpace(req.ChatID),
+				ChannelID:  channelID,
+				ThreadID:   strings.TrimSpace(threadID),
+				Visibility: visibilityEphemeral,
+				TaskID:     strings.TrimSpace(req.TaskID),
+				TraceID:    strings.TrimSpace(req.TraceID),
+			})
+			return
+		}
+		var te *transientError
+		if errors.As(err, &te) {
+			b.noteOutbound(false, "slack", err)
+			writeOutboundError(w, err, false)
+			return
+		}
+		// E.g. user_not_in_channel: deliver it privately instead.
+		slog.Info("slack ephemeral reply failed, sending a DM", "user", req.UserID, "error", err)
+		visibility = visibilityDM
+	}
+	if visibility == visibilityDM {
+		dmID, err := b.resolveSlackChannelID(acct, "user:"+normalizeSlackTarget(req.UserID))
+		if err != nil {
+			b.noteOutbound(false, "slack", err)
+			writeOutboundError(w, err, false)
+			return
+		}
+		channelID, threadID = dmID, ""
+	}
+	if question := strings.TrimSpace(req.PollQuestion); question != "" {
+		pollID := b.recordPoll("slack", accountID, channelID, question, req.PollOptions, req.PollMaxSelections)
+		req.Card = buildSlackPollCard(question, req.PollOptions, req.PollMaxSelections, pollID)
+	}
+	if !sendAt.IsZero() {
+		b.scheduleSlackOutbound(w, acct, scheduledMessage{
+			AccountID:      accountID,
+			ChatID:         strings.TrimSpace(req.ChatID),
+			ThreadID:       strings.TrimSpace(threadID),
+			SendAt:         sendAt,
+			Preview:        firstNonEmpty(req.Content, req.PollQuestion, asString(req.Card["text"]), asString(req.Card["title"])),
+			SlackChannelID: channelID,
+		}, req.Content, req.Card)
+		return
+	}
+	delivery := outboundDelivery{
+		Channel:    "slack",
+		AccountID:  accountID,
+		ChatID:     strings.TrimSpace(req.ChatID),
+		ChannelID:  channelID,
+		ThreadID:   strings.TrimSpace(threadID),
+		Visibility: visibility,
+		TaskID:     strings.TrimSpace(req.TaskID),
+		TraceID:    strings.TrimSpace(req.TraceID),
+	}
 	if len(req.MediaURLs) > 0 {
-		if err := b.slackUploadMedia(channelID, threadID, req.MediaURLs[0], req.Content); err != nil {
-			b.noteOutbound(false, true, err)
-			http.Error(w, err.Error(), http.StatusBadGateway)
+		fileIDs, err := b.slackUploadMedia(acct, channelID, threadID, req.MediaURLs[0], req.Content)
+		if err != nil {
+			b.noteOutbound(false, "slack", err)
+			writeOutboundError(w, err, false)
 			return
 		}
+		delivery.FileIDs = fileIDs
 	}
 	streamMode := normalizeSlackStreamMode(firstNonEmpty(req.StreamMode, b.cfg.SlackStreamMode))
 	nativeStreaming := b.cfg.SlackNativeStreaming
@@ -860,29 +1655,36 @@ func (b *bridge) handleSlackOutbound(w http.ResponseWriter, r *http.Request) {
 		len(req.Card) == 0 &&
 		strings.TrimSpace(req.Content) != ""
 	if canStream {
-		if err := b.slackPostStreamedMessage(channelID, threadID, req.Content, streamChunkChars); err != nil {
-			log.Printf("slack native streaming failed, falling back to postMessage: %v", err)
-			if err := b.slackPostMessage(channelID, threadID, req.Content); err != nil {
-				b.noteOutbound(false, true, err)
-				http.Error(w, err.Error(), http.StatusBadGateway)
+		ts, err := b.slackPostStreamedMessage(acct, channelID, threadID, req.Content, streamChunkChars)
+		if err != nil {
+			slog.Warn("slack native streaming failed, falling back to postMessage", "error", err)
+			if ts, err = b.slackPostMessage(acct, channelID, threadID, req.Content); err != nil {
+				b.noteOutbound(false, "slack", err)
+				writeOutboundError(w, err, false)
 				return
 			}
 		}
+		delivery.addMessageIDs(ts)
 	} else if len(req.Card) > 0 {
-		if err := b.slackPostCard(channelID, threadID, req.Content, req.Card); err != nil {
-			b.noteOutbound(false, true, err)
-			http.Error(w, err.Error(), http.StatusBadGateway)
+		ts, err := b.slackPostCard(acct, channelID, threadID, req.Content, req.Card)
+		if err != nil {
+			b.noteOutbound(false, "slack", err)
+			writeOutboundError(w, err, delivery.sent())
 			return
 		}
+		delivery.addMessageIDs(ts)
 	} else if strings.TrimSpace(req.Content) != "" {
-		if err := b.slackPostMessageChunked(channelID, threadID, req.Content); err != nil {
-			b.noteOutbound(false, true, err)
-			http.Error(w, err.Error(), http.StatusBadGateway)
+		ts, err := b.slackPostMessageChunked(acct, channelID, threadID, req.Content)
+		delivery.addMessageIDs(ts...)
+		if err != nil {
+			b.noteOutbound(false, "slack", err)
+			writeOutboundError(w, err, delivery.sent())
 			return
 		}
 	}
-	b.noteOutbound(true, true, nil)
-	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
+	b.noteOutbound(true, "slack", nil)
+	b.postDeliveryReceipt(delivery, acct.InboundToken)
+	writeOutboundDelivery(w, delivery)
 }
 
 func (b *bridge) handleSlackResolveUsers(w http.ResponseWriter, r *http.Request) {
@@ -897,7 +1699,7 @@ func (b *bridge) handleSlackResolveUsers(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "invalid json", http.StatusBadRequest)
 		return
 	}
-	out, err := b.slackResolveUsers(req.Entries)
+	out, err := b.slackResolveUsers(b.slackAccountFromQuery(r), req.Entries)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadGateway)
 		return
@@ -918,7 +1720,7 @@ func (b *bridge) handleSlackResolveChannels(w http.ResponseWriter, r *http.Reque
 		http.Error(w, "invalid json", http.StatusBadRequest)
 		return
 	}
-	out, err := b.slackResolveChannels(req.Entries)
+	out, err := b.slackResolveChannels(b.slackAccountFromQuery(r), req.Entries)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadGateway)
 		return
@@ -932,7 +1734,8 @@ func (b *bridge) handleSlackProbe(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	api, err := b.slackClient()
+	acct := b.slackAccountFromQuery(r)
+	api, err := b.slackClient(acct)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -944,13 +1747,17 @@ func (b *bridge) handleSlackProbe(w http.ResponseWriter, r *http.Request) {
 	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]any{
-		"ok":   true,
-		"team": auth.Team,
-		"user": auth.User,
+		"ok":         true,
+		"account_id": acct.ID,
+		"team":       auth.Team,
+		"team_id":    auth.TeamID,
+		// enterprise_id is set for apps installed on an Enterprise Grid org.
+		"enterprise_id": auth.EnterpriseID,
+		"user":          auth.User,
 	})
 }
 
-func (b *bridge) resolveSlackChannelID(chatID string) (string, error) {
+func (b *bridge) resolveSlackChannelID(acct slackAccount, chatID string) (string, error) {
 	chatID = normalizeSlackTarget(chatID)
 	if chatID == "" {
 		return "", errors.New("empty chat id")
@@ -961,7 +1768,7 @@ func (b *bridge) resolveSlackChannelID(chatID string) (string, error) {
 	if !strings.HasPrefix(chatID, "U") {
 		return chatID, nil
 	}
-	api, err := b.slackClient()
+	api, err := b.slackClient(acct)
 	if err != nil {
 		return "", err
 	}
@@ -999,8 +1806,8 @@ func normalizeSlackTarget(v string) string {
 	}
 }
 
-func (b *bridge) slackResolveUsers(entries []string) ([]map[string]any, error) {
-	users, err := b.slackListUsers()
+func (b *bridge) slackResolveUsers(acct slackAccount, entries []string) ([]map[string]any, error) {
+	users, err := b.slackListUsers(acct)
 	if err != nil {
 		return nil, err
 	}
@@ -1011,9 +1818,19 @@ func (b *bridge) slackResolveUsers(entries []string) ([]map[string]any, error) {
 			out = append(out, map[string]any{"input": raw, "resolved": false, "note": "empty input"})
 			continue
 		}
-		qNorm := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(q), "user:"), "@")
+		if broadcast := slackBroadcastMention(q); broadcast != "" {
+			out = append(out, map[string]any{"input": raw, "resolved": true, "type": "broadcast", "id": broadcast, "mention": "<!" + broadcast + ">"})
+			continue
+		}
+		lower := strings.ToLower(q)
+		if strings.HasPrefix(lower, "usergroup:") || strings.HasPrefix(lower, "subteam:") || slackUsergroupIDPattern.MatchString(q) {
+			out = append(out, b.slackResolveUsergroup(acct, raw, q))
+			continue
+		}
+		qNorm := strings.TrimPrefix(strings.TrimPrefix(lower, "user:"), "@")
 		if strings.HasPrefix(strings.ToUpper(q), "U") {
-			out = append(out, map[string]any{"input": raw, "resolved": true, "id": strings.ToUpper(q)})
+			id := strings.ToUpper(q)
+			out = append(out, map[string]any{"input": raw, "resolved": true, "type": "user", "id": id, "mention": "<@" + id + ">"})
 			continue
 		}
 		resolved := false
@@ -1034,9 +1851,16 @@ func (b *bridge) slackResolveUsers(entries []string) ([]map[string]any, error) {
 				break
 			}
 		}
+		if !resolved && strings.HasPrefix(q, "@") {
+			// "@devops" that is not a user may be a usergroup handle.
+			out = append(out, b.slackResolveUsergroup(acct, raw, q))
+			continue
+		}
 		entry := map[string]any{"input": raw, "resolved": resolved}
 		if resolved {
+			entry["type"] = "user"
 			entry["id"] = id
+			entry["mention"] = "<@" + id + ">"
 			if name != "" {
 				entry["name"] = name
 			}
@@ -1046,8 +1870,155 @@ func (b *bridge) slackResolveUsers(entries []string) ([]map[string]any, error) {
 	return out, nil
 }
 
-func (b *bridge) slackResolveChannels(entries []string) ([]map[string]any, error) {
-	chs, err := b.slackListChannels()
+// slackUsergroup is the subset of a Slack usergroup used for mention resolution.
+type slackUsergroup struct {
+	ID        string
+	Handle    string
+	Name      string
+	UserCount int
+	Disabled  bool
+}
+
+var (
+	slackUsergroupIDPattern    = regexp.MustCompile(`^S[A-Z0-9]{6,}$`)
+	slackBroadcastInText       = regexp.MustCompile(`(^|[\s(])!(here|channel|everyone)\b`)
+	slackUsergroupHandleInText = regexp.MustCompile(`(^|[\s(])@([A-Za-z0-9](?:[A-Za-z0-9._-]*[A-Za-z0-9])?)`)
+)
+
+// slackBroadcastMention maps "!here", "@channel", ... to the Slack special
+// mention keyword, or "" when q is not a broadcast mention.
+func slackBroadcastMention(q string) string {
+	if len(q) < 2 || (q[0] != '!' && q[0] != '@') {
+		return ""
+	}
+	switch kw := strings.ToLower(q[1:]); kw {
+	case "here", "channel", "everyone":
+		return kw
+	}
+	return ""
+}
+
+// slackResolveUsergroup resolves a usergroup handle, name or ID against
+// usergroups.list and reports whether it can be mentioned.
+func (b *bridge) slackResolveUsergroup(acct slackAccount, raw, q string) map[string]any {
+	entry := map[string]any{"input": raw, "resolved": false}
+	groups, err := b.slackListUsergroups(acct)
+	if err != nil {
+		entry["note"] = "usergroups.list failed: " + err.Error()
+		return entry
+	}
+	qNorm := strings.ToLower(strings.TrimSpace(q))
+	for _, prefix := range []string{"usergroup:", "subteam:", "@"} {
+		qNorm = strings.TrimPrefix(qNorm, prefix)
+	}
+	for _, g := range groups {
+		if qNorm != strings.ToLower(g.ID) && qNorm != strings.ToLower(g.Handle) && qNorm != strings.ToLower(g.Name) {
+			continue
+		}
+		entry["type"] = "usergroup"
+		entry["id"] = g.ID
+		entry["handle"] = g.Handle
+		entry["members"] = g.UserCount
+		if g.Disabled {
+			entry["note"] = "usergroup is disabled"
+			return entry
+		}
+		entry["resolved"] = true
+		entry["mention"] = "<!subteam^" + g.ID + ">"
+		if g.UserCount == 0 {
+			entry["note"] = "usergroup has no members"
+		}
+		return entry
+	}
+	entry["note"] = "no matching user or usergroup"
+	return entry
+}
+
+// slackUsergroupCache holds the usergroups of one account's workspace, or
+// the error of the last failed lookup.
+type slackUsergroupCache struct {
+	groups []slackUsergroup
+	err    error
+	at     time.Time
+}
+
+// slackListUsergroups returns the workspace usergroups, cached for five
+// minutes. A failed lookup (e.g. missing usergroups:read) is cached for one
+// minute so every outbound message does not repeat it.
+func (b *bridge) slackListUsergroups(acct slackAccount) ([]slackUsergroup, error) {
+	b.usergroupMu.Lock()
+	defer b.usergroupMu.Unlock()
+	if c, ok := b.usergroups[acct.ID]; ok {
+		if c.err != nil && time.Since(c.at) < time.Minute {
+			return nil, c.err
+		}
+		if c.err == nil && time.Since(c.at) < 5*time.Minute {
+			return c.groups, nil
+		}
+	}
+	if b.usergroups == nil {
+		b.usergroups = map[string]slackUsergroupCache{}
+	}
+	api, err := b.slackClient(acct)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := api.GetUserGroupsContext(context.Background(),
+		slack.GetUserGroupsOptionIncludeCount(true),
+		slack.GetUserGroupsOptionIncludeDisabled(true))
+	if err != nil {
+		b.usergroups[acct.ID] = slackUsergroupCache{err: err, at: time.Now()}
+		return nil, err
+	}
+	out := make([]slackUsergroup, 0, len(groups))
+	for _, g := range groups {
+		out = append(out, slackUsergroup{
+			ID:        g.ID,
+			Handle:    g.Handle,
+			Name:      g.Name,
+			UserCount: g.UserCount,
+			Disabled:  g.DateDelete != 0,
+		})
+	}
+	b.usergroups[acct.ID] = slackUsergroupCache{groups: out, at: time.Now()}
+	return out, nil
+}
+
+// slackExpandMentions rewrites usergroup handles such as "@devops" into
+// Slack mention syntax. "!here"/"!channel"/"!everyone" are only rewritten
+// when SlackAllowBroadcast is set. Handles that do not match an enabled
+// usergroup are left unchanged.
+func (b *bridge) slackExpandMentions(acct slackAccount, text string) string {
+	if !strings.ContainsAny(text, "!@") {
+		return text
+	}
+	if b.cfg.SlackAllowBroadcast {
+		text = slackBroadcastInText.ReplaceAllString(text, "$1<!$2>")
+	}
+	if !strings.Contains(text, "@") {
+		return text
+	}
+	groups, err := b.slackListUsergroups(acct)
+	if err != nil || len(groups) == 0 {
+		return text
+	}
+	byHandle := make(map[string]string, len(groups))
+	for _, g := range groups {
+		if !g.Disabled && g.Handle != "" {
+			byHandle[strings.ToLower(g.Handle)] = g.ID
+		}
+	}
+	return slackUsergroupHandleInText.ReplaceAllStringFunc(text, func(m string) string {
+		sub := slackUsergroupHandleInText.FindStringSubmatch(m)
+		if id, ok := byHandle[strings.ToLower(sub[2])]; ok {
+			return sub[1] + "<!subteam^" + id + ">"
+		}
+		return m
+	})
+}
+
+func (b *bridge) slackResolveChannels(acct slackAccount, entries []string) ([]map[string]any, error) {
+	chs, err := b.slackListChannels(acct)
 	if err != nil {
 		return nil, err
 	}
@@ -1088,8 +2059,8 @@ func (b *bridge) slackResolveChannels(entries []string) ([]map[string]any, error
 	return out, nil
 }
 
-func (b *bridge) slackListUsers() ([]map[string]any, error) {
-	api, err := b.slackClient()
+func (b *bridge) slackListUsers(acct slackAccount) ([]map[string]any, error) {
+	api, err := b.slackClient(acct)
 	if err != nil {
 		return nil, err
 	}
@@ -1111,8 +2082,8 @@ func (b *bridge) slackListUsers() ([]map[string]any, error) {
 	return out, nil
 }
 
-func (b *bridge) slackListChannels() ([]map[string]any, error) {
-	api, err := b.slackClient()
+func (b *bridge) slackListChannels(acct slackAccount) ([]map[string]any, error) {
+	api, err := b.slackClient(acct)
 	if err != nil {
 		return nil, err
 	}
@@ -1141,53 +2112,96 @@ func (b *bridge) slackListChannels() ([]map[string]any, error) {
 	return all, nil
 }
 
-func (b *bridge) slackPostMessage(channelID, threadID, text string) error {
-	api, err := b.slackClient()
+// slackPostMessage posts text and returns the ts of the new message.
+func (b *bridge) slackPostMessage(acct slackAccount, channelID, threadID, text string) (string, error) {
+	api, err := b.slackClient(acct)
 	if err != nil {
+		return "", err
+	}
+	var msgTS string
+	err = b.slackPostWithJoin(api, channelID, func() error {
+		return withRetry(3, 200*time.Millisecond, func() (bool, error) {
+			opts := []slack.MsgOption{slack.MsgOptionText(text, false)}
+			if ts := strings.TrimSpace(threadID); ts != "" {
+				opts = append(opts, slack.MsgOptionTS(ts))
+			}
+			var err error
+			_, msgTS, err = api.PostMessageContext(context.Background(), channelID, opts...)
+			return b.slackRetryDecision(err)
+		})
+	})
+	return msgTS, err
+}
+
+// slackPostWithJoin runs post and, if it fails with not_in_channel, joins the
+// channel and runs it once more. Private channels cannot be joined by the bot,
+// so they (and public channels with auto-join disabled) get an error naming
+// the channel instead of the bare Slack error code.
+func (b *bridge) slackPostWithJoin(api *slack.Client, channelID string, post func() error) error {
+	err := post()
+	if err == nil || !strings.Contains(err.Error(), "not_in_channel") {
 		return err
 	}
-	return withRetry(3, 200*time.Millisecond, func() (bool, error) {
-		opts := []slack.MsgOption{slack.MsgOptionText(text, false)}
-		if ts := strings.TrimSpace(threadID); ts != "" {
-			opts = append(opts, slack.MsgOptionTS(ts))
+	ctx := context.Background()
+	name, private := channelID, false
+	if info, infoErr := api.GetConversationInfoContext(ctx, &slack.GetConversationInfoInput{ChannelID: channelID}); infoErr == nil && info != nil {
+		if info.Name != "" {
+			name = fmt.Sprintf("#%s (%s)", info.Name, channelID)
 		}
-		_, _, err := api.PostMessageContext(context.Background(), channelID, opts...)
-		return b.slackRetryDecision(err)
-	})
+		private = info.IsPrivate
+	}
+	if private {
+		return fmt.Errorf("slack bot is not a member of private channel %s; invite the bot to the channel", name)
+	}
+	if !b.cfg.SlackAutoJoin {
+		return fmt.Errorf("slack bot is not a member of channel %s and auto-join is disabled (SLACK_AUTO_JOIN=false); invite the bot to the channel", name)
+	}
+	if _, _, _, err := api.JoinConversationContext(ctx, channelID); err != nil {
+		return fmt.Errorf("slack bot is not a member of channel %s and joining failed: %w", name, err)
+	}
+	slog.Info("slack: joined channel to deliver outbound message", "channel", name)
+	return post()
 }
 
-func (b *bridge) slackPostMessageChunked(channelID, threadID, text string) error {
+// slackPostMessageChunked posts text in chunks and returns the ts of every
+// posted chunk, including those posted before a failure.
+func (b *bridge) slackPostMessageChunked(acct slackAccount, channelID, threadID, text string) ([]string, error) {
 	chunks := splitSlackMarkdownChunks(text, 3500)
-	if len(chunks) == 0 {
-		return nil
-	}
+	var posted []string
 	for _, chunk := range chunks {
-		if err := b.slackPostMessage(channelID, threadID, chunk); err != nil {
-			return err
+		ts, err := b.slackPostMessage(acct, channelID, threadID, chunk)
+		if err != nil {
+			return posted, err
 		}
+		posted = append(posted, ts)
 	}
-	return nil
+	return posted, nil
 }
 
-func (b *bridge) slackPostStreamedMessage(channelID, threadID, text string, chunkChars int) error {
+// slackPostStreamedMessage streams text and returns the ts of the streamed
+// message.
+func (b *bridge) slackPostStreamedMessage(acct slackAccount, channelID, threadID, text string, chunkChars int) (string, error) {
 	threadID = strings.TrimSpace(threadID)
 	if threadID == "" {
-		return errors.New("missing thread id for slack native streaming")
+		return "", errors.New("missing thread id for slack native streaming")
 	}
 	chunks := splitSlackStreamChunks(text, chunkChars)
 	if len(chunks) == 0 {
-		return errors.New("empty stream chunks")
+		return "", errors.New("empty stream chunks")
 	}
-	streamTS, err := b.slackStartStream(channelID, threadID, chunks[0])
+	streamTS, err := b.slackStartStream(acct, channelID, threadID, chunks[0])
 	if err != nil {
-		return err
+		return "", err
 	}
 	for i := 1; i < len(chunks); i++ {
-		if err := b.slackAppendStream(channelID, streamTS, chunks[i]); err != nil {
-			return err
+		if err := b.slackAppendStream(acct, channelID, streamTS, chunks[i]); err != nil {
+			return "", err
 		}
 	}
-	return b.slackStopStream(channelID, streamTS)
+	if err := b.slackStopStream(acct, channelID, streamTS); err != nil {
+		return "", err
+	}
+	return streamTS, nil
 }
 
 func splitSlackStreamChunks(text string, chunkChars int) []string {
@@ -1227,13 +2241,13 @@ func splitSlackStreamChunks(text string, chunkChars int) []string {
 	return chunks
 }
 
-func (b *bridge) slackStartStream(channelID, threadID, text string) (string, error) {
+func (b *bridge) slackStartStream(acct slackAccount, channelID, threadID, text string) (string, error) {
 	var out struct {
 		OK    bool   `json:"ok"`
 		Error string `json:"error"`
 		TS    string `json:"ts"`
 	}
-	err := b.slackAPIPostForm("chat.startStream", url.Values{
+	err := b.slackAPIPostForm(acct, "chat.startStream", url.Values{
 		"channel":       {strings.TrimSpace(channelID)},
 		"thread_ts":     {strings.TrimSpace(threadID)},
 		"markdown_text": {strings.TrimSpace(text)},
@@ -1248,31 +2262,31 @@ func (b *bridge) slackStartStream(channelID, threadID, text string) (string, err
 	return ts, nil
 }
 
-func (b *bridge) slackAppendStream(channelID, ts, text string) error {
+func (b *bridge) slackAppendStream(acct slackAccount, channelID, ts, text string) error {
 	var out struct {
 		OK    bool   `json:"ok"`
 		Error string `json:"error"`
 	}
-	return b.slackAPIPostForm("chat.appendStream", url.Values{
+	return b.slackAPIPostForm(acct, "chat.appendStream", url.Values{
 		"channel":       {strings.TrimSpace(channelID)},
 		"ts":            {strings.TrimSpace(ts)},
 		"markdown_text": {strings.TrimSpace(text)},
 	}, &out)
 }
 
-func (b *bridge) slackStopStream(channelID, ts string) error {
+func (b *bridge) slackStopStream(acct slackAccount, channelID, ts string) error {
 	var out struct {
 		OK    bool   `json:"ok"`
 		Error string `json:"error"`
 	}
-	return b.slackAPIPostForm("chat.stopStream", url.Values{
+	return b.slackAPIPostForm(acct, "chat.stopStream", url.Values{
 		"channel": {strings.TrimSpace(channelID)},
 		"ts":      {strings.TrimSpace(ts)},
 	}, &out)
 }
 
-func (b *bridge) slackAPIPostForm(method string, form url.Values, out any) error {
-	token := strings.TrimSpace(b.cfg.SlackBotToken)
+func (b *bridge) slackAPIPostForm(acct slackAccount, method string, form url.Values, out any) error {
+	token := acct.BotToken
 	if token == "" {
 		return errors.New("missing SLACK_BOT_TOKEN")
 	}
@@ -1321,10 +2335,11 @@ func (b *bridge) slackAPIPostForm(method string, form url.Values, out any) error
 	})
 }
 
-func (b *bridge) slackPostCard(channelID, threadID, text string, card map[string]any) error {
-	api, err := b.slackClient()
+// slackPostCard posts a block/attachment message and returns its ts.
+func (b *bridge) slackPostCard(acct slackAccount, channelID, threadID, text string, card map[string]any) (string, error) {
+	api, err := b.slackClient(acct)
 	if err != nil {
-		return err
+		return "", err
 	}
 	var blocks slack.Blocks
 	if rawBlocks, ok := card["blocks"]; ok && rawBlocks != nil {
@@ -1354,24 +2369,29 @@ func (b *bridge) slackPostCard(channelID, threadID, text string, card map[string
 	if strings.TrimSpace(text) == "" {
 		text = strings.TrimSpace(firstNonEmpty(asString(card["text"]), asString(card["title"]), asString(card["body"])))
 	}
-	return withRetry(3, 200*time.Millisecond, func() (bool, error) {
-		opts := []slack.MsgOption{slack.MsgOptionText(strings.TrimSpace(text), false)}
-		if len(blocks.BlockSet) > 0 {
-			opts = append(opts, slack.MsgOptionBlocks(blocks.BlockSet...))
-		}
-		if len(attachments) > 0 {
-			opts = append(opts, slack.MsgOptionAttachments(attachments...))
-		}
-		if ts := strings.TrimSpace(threadID); ts != "" {
-			opts = append(opts, slack.MsgOptionTS(ts))
-		}
-		_, _, err := api.PostMessageContext(context.Background(), channelID, opts...)
-		return b.slackRetryDecision(err)
+	var msgTS string
+	err = b.slackPostWithJoin(api, channelID, func() error {
+		return withRetry(3, 200*time.Millisecond, func() (bool, error) {
+			opts := []slack.MsgOption{slack.MsgOptionText(strings.TrimSpace(text), false)}
+			if len(blocks.BlockSet) > 0 {
+				opts = append(opts, slack.MsgOptionBlocks(blocks.BlockSet...))
+			}
+			if len(attachments) > 0 {
+				opts = append(opts, slack.MsgOptionAttachments(attachments...))
+			}
+			if ts := strings.TrimSpace(threadID); ts != "" {
+				opts = append(opts, slack.MsgOptionTS(ts))
+			}
+			var err error
+			_, msgTS, err = api.PostMessageContext(context.Background(), channelID, opts...)
+			return b.slackRetryDecision(err)
+		})
 	})
+	return msgTS, err
 }
 
-func (b *bridge) slackHandleAction(action, channelID, threadID, content string, params map[string]any) (map[string]any, error) {
-	api, err := b.slackClient()
+func (b *bridge) slackHandleAction(acct slackAccount, action, channelID, threadID, content string, params map[string]any) (map[string]any, error) {
+	api, err := b.slackClient(acct)
 	if err != nil {
 		return nil, err
 	}
@@ -1450,13 +2470,31 @@ func (b *bridge) slackHandleAction(action, channelID, threadID, content string,
 			"has_more":   resp.HasMore,
 			"nextCursor": strings.TrimSpace(resp.ResponseMetaData.NextCursor),
 		}, nil
+	case "modal_open", "modal_push", "modal_update":
+		return b.slackModalAction(api, action, channelID, threadID, params)
+	case "thread":
+		ctx := context.Background()
+		if threadID == "" {
+			// Without a thread, the newest channel messages.
+			page, err := b.slackHistory(ctx, acct, api, channelID, slackHistoryRequest{Limit: threadHistoryLimit(params)})
+			if err != nil {
+				return nil, err
+			}
+			return map[string]any{"ok": true, "messages": page.Messages}, nil
+		}
+		msgs, err := slackThreadHistory(ctx, api, channelID, threadID, threadHistoryLimit(params))
+		if err != nil {
+			return nil, err
+		}
+		b.slackFillSenderNames(ctx, acct, api, msgs)
+		return map[string]any{"ok": true, "messages": msgs}, nil
 	default:
 		return nil, fmt.Errorf("unsupported slack action: %s", action)
 	}
 }
 
-func (b *bridge) slackClient() (*slack.Client, error) {
-	token := strings.TrimSpace(b.cfg.SlackBotToken)
+func (b *bridge) slackClient(acct slackAccount) (*slack.Client, error) {
+	token := acct.BotToken
 	if token == "" {
 		return nil, errors.New("missing SLACK_BOT_TOKEN")
 	}
@@ -1468,12 +2506,12 @@ func (b *bridge) slackClient() (*slack.Client, error) {
 	return slack.New(token, slack.OptionHTTPClient(b.client), slack.OptionAPIURL(base)), nil
 }
 
-func (b *bridge) slackClientWithAppToken(appToken string) (*slack.Client, error) {
-	token := strings.TrimSpace(b.cfg.SlackBotToken)
+func (b *bridge) slackClientWithAppToken(acct slackAccount) (*slack.Client, error) {
+	token := acct.BotToken
 	if token == "" {
 		return nil, errors.New("missing SLACK_BOT_TOKEN")
 	}
-	appToken = strings.TrimSpace(appToken)
+	appToken := acct.AppToken
 	if appToken == "" {
 		return nil, errors.New("missing SLACK_APP_TOKEN")
 	}
@@ -1508,16 +2546,19 @@ func (b *bridge) slackRetryDecision(err error) (bool, error) {
 	return false, err
 }
 
-func (b *bridge) slackUploadMedia(channelID, threadID, mediaURL, caption string) error {
-	token := strings.TrimSpace(b.cfg.SlackBotToken)
+// slackUploadMedia uploads mediaURL to the channel and returns the Slack
+// file IDs of the upload.
+func (b *bridge) slackUploadMedia(acct slackAccount, channelID, threadID, mediaURL, caption string) ([]string, error) {
+	token := acct.BotToken
 	if token == "" {
-		return errors.New("missing SLACK_BOT_TOKEN")
+		return nil, errors.New("missing SLACK_BOT_TOKEN")
 	}
 	data, filename, err := b.downloadMedia(mediaURL)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return withRetry(3, 200*time.Millisecond, func() (bool, error) {
+	var fileIDs []string
+	err = withRetry(3, 200*time.Millisecond, func() (bool, error) {
 		var body bytes.Buffer
 		w := multipart.NewWriter(&body)
 		_ = w.WriteField("channel_id", channelID)
@@ -1554,9 +2595,17 @@ func (b *bridge) slackUploadMedia(channelID, threadID, mediaURL, caption string)
 		var out struct {
 			OK    bool   `json:"ok"`
 			Error string `json:"error"`
+			Files []struct {
+				ID string `json:"id"`
+			} `json:"files"`
 		}
 		_ = json.NewDecoder(resp.Body).Decode(&out)
 		if out.OK {
+			for _, f := range out.Files {
+				if f.ID != "" {
+					fileIDs = append(fileIDs, f.ID)
+				}
+			}
 			return false, nil
 		}
 		if d := parseRetryAfter(resp.Header.Get("Retry-After")); d > 0 {
@@ -1568,6 +2617,7 @@ func (b *bridge) slackUploadMedia(channelID, threadID, mediaURL, caption string)
 		}
 		return retryable, errors.New(out.Error)
 	})
+	return fileIDs, err
 }
 
 func verifyBearer(r *http.Request, expected string) bool {
@@ -1579,15 +2629,16 @@ func verifyBearer(r *http.Request, expected string) bool {
 	return got == expected
 }
 
-func (b *bridge) verifyTeamsJWTRequest(r *http.Request, serviceURL, channelID string) error {
-	if b.jwt == nil || strings.TrimSpace(b.cfg.MSTeamsAppID) == "" {
+func (b *bridge) verifyTeamsJWTRequest(acct teamsAccount, r *http.Request, serviceURL, channelID string) error {
+	verifier := b.teamsJWTFor(acct)
+	if verifier == nil || acct.AppID == "" {
 		return nil
 	}
 	auth := strings.TrimSpace(r.Header.Get("Authorization"))
 	if auth == "" {
 		return errors.New("missing authorization header")
 	}
-	return b.jwt.Verify(auth, time.Now(), strings.TrimSpace(serviceURL), strings.TrimSpace(channelID))
+	return verifier.Verify(auth, time.Now(), strings.TrimSpace(serviceURL), strings.TrimSpace(channelID))
 }
 
 func (b *bridge) handleTeamsMessages(w http.ResponseWriter, r *http.Request) {
@@ -1610,7 +2661,8 @@ func (b *bridge) handleTeamsMessages(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid json", http.StatusBadRequest)
 		return
 	}
-	if err := b.verifyTeamsJWTRequest(r, strings.TrimSpace(asString(activity["serviceUrl"])), strings.TrimSpace(asString(activity["channelId"]))); err != nil {
+	acct := b.teamsAccountForActivity(activity)
+	if err := b.verifyTeamsJWTRequest(acct, r, strings.TrimSpace(asString(activity["serviceUrl"])), strings.TrimSpace(asString(activity["channelId"]))); err != nil {
 		b.noteInboundAuthRejected()
 		http.Error(w, "invalid teams jwt", http.StatusUnauthorized)
 		return
@@ -1624,27 +2676,26 @@ func (b *bridge) handleTeamsMessages(w http.ResponseWriter, r *http.Request) {
 		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
 		return
 	}
-	if recorded, details := b.handleTeamsPollVote(inbound.chatID, inbound.senderID, activity); recorded {
-		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "poll_vote_recorded": true, "poll": details})
+	if handled, details := b.handleTeamsPollVote(inbound.chatID, inbound.senderID, activity); handled {
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "poll_vote_recorded": details["closed"] != true, "poll": details})
 		return
 	}
-	if inbound.messageID != "" && b.seenInboundEvent("teams:msg:"+inbound.chatID+":"+inbound.messageID, time.Now()) {
-		b.noteInboundDeduped(false)
+	seenKey := ""
+	if inbound.messageID != "" {
+		seenKey = "teams:msg:" + inbound.chatID + ":" + inbound.messageID
+	}
+	if b.seenInboundEvent(seenKey, time.Now()) {
+		b.noteInboundDeduped("teams")
 		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "deduped": true})
 		return
 	}
 
-	ref := teamsConversationRef{ServiceURL: inbound.serviceURL, ConversationID: inbound.chatID, UserID: inbound.userID}
-	b.teamsMu.Lock()
-	b.teamsConvByID[inbound.chatID] = ref
-	if inbound.userID != "" {
-		b.teamsConvByUserID[inbound.userID] = ref
-	}
-	b.teamsMu.Unlock()
-	_ = b.saveState()
+	ref := teamsConversationRef{ServiceURL: inbound.serviceURL, ConversationID: inbound.chatID, UserID: inbound.userID, TenantID: inbound.tenantID, TeamGroupID: inbound.teamGroupID, ChannelID: inbound.channelID, AccountID: acct.ID}
+	b.rememberTeamsConversation(ref, inbound.userID)
+	b.mirrorTeamsToSlack(inbound)
 
-	err = b.postInbound("/api/v1/channels/msteams/inbound", b.cfg.KafclawMSTeamsInboundToken, map[string]any{
-		"account_id":         strings.TrimSpace(b.cfg.MSTeamsAccountID),
+	payload := map[string]any{
+		"account_id":         acct.ID,
 		"sender_id":          inbound.senderID,
 		"user_id":            inbound.userID,
 		"chat_id":            inbound.chatID,
@@ -1662,11 +2713,18 @@ func (b *bridge) handleTeamsMessages(w http.ResponseWriter, r *http.Request) {
 		"tenant_id":          inbound.tenantID,
 		"service_url":        inbound.serviceURL,
 		"service_url_domain": inbound.serviceDomain,
-	})
+	}
+	setInboundMedia(payload, b.teamsInboundMedia(acct, inbound.serviceURL, inbound.mediaURLs))
+	queued, err := b.forwardInbound("msteams", "/api/v1/channels/msteams/inbound", inbound.chatID, payload)
 	if err != nil {
+		b.releaseRateLimited(err, seenKey)
 		b.noteInboundForward(false, err)
-		log.Printf("teams inbound forward failed: %v", err)
-		http.Error(w, "forward failed", http.StatusBadGateway)
+		slog.Warn("teams inbound forward failed", "error", err)
+		writeInboundError(w, err)
+		return
+	}
+	if queued {
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "queued": true})
 		return
 	}
 	b.metricsMu.Lock()
@@ -1677,6 +2735,7 @@ func (b *bridge) handleTeamsMessages(w http.ResponseWriter, r *http.Request) {
 
 type teamsInbound struct {
 	senderID         string
+	senderName       string
 	userID           string
 	chatID           string
 	threadID         string
@@ -1686,6 +2745,7 @@ type teamsInbound struct {
 	serviceDomain    string
 	conversationType string
 	teamID           string
+	teamGroupID      string
 	channelID        string
 	tenantID         string
 	mediaURLs        []string
@@ -1706,6 +2766,7 @@ func normalizeTeamsInbound(activity map[string]any, mediaAllowHosts []string) te
 	mediaURLs := extractTeamsInboundMediaURLs(activity, mediaAllowHosts)
 	out := teamsInbound{
 		senderID:         strings.TrimSpace(asString(from["id"])),
+		senderName:       strings.TrimSpace(asString(from["name"])),
 		userID:           strings.TrimSpace(asString(from["aadObjectId"])),
 		chatID:           strings.TrimSpace(asString(conv["id"])),
 		threadID:         strings.TrimSpace(asString(activity["replyToId"])),
@@ -1714,6 +2775,7 @@ func normalizeTeamsInbound(activity map[string]any, mediaAllowHosts []string) te
 		serviceURL:       strings.TrimSpace(asString(activity["serviceUrl"])),
 		conversationType: strings.ToLower(strings.TrimSpace(asString(conv["conversationType"]))),
 		teamID:           strings.TrimSpace(asString(team["id"])),
+		teamGroupID:      strings.TrimSpace(asString(team["aadGroupId"])),
 		channelID:        strings.TrimSpace(asString(channel["id"])),
 		tenantID:         strings.TrimSpace(asString(tenant["id"])),
 		mediaURLs:        mediaURLs,
@@ -1865,6 +2927,11 @@ func isURLAllowed(rawURL string, allowHosts []string) bool {
 }
 
 func teamsWasMentioned(activity map[string]any, botID, botName string) bool {
+	// A card action (e.g. an approval button) is addressed to the bot, like
+	// a Slack interaction.
+	if val, _ := activity["value"].(map[string]any); len(val) > 0 && strings.TrimSpace(asString(activity["text"])) == "" {
+		return true
+	}
 	botID = strings.TrimSpace(botID)
 	botName = strings.TrimSpace(strings.ToLower(botName))
 	if ents, ok := activity["entities"].([]any); ok {
@@ -1929,17 +2996,50 @@ func (b *bridge) seenInboundEvent(key string, now time.Time) bool {
 		b.inboundMu.Unlock()
 		return true
 	}
-	b.inboundSeen[key] = now.Add(b.inboundTTL)
+	until := now.Add(b.inboundTTL)
+	b.inboundSeen[key] = until
 	shouldPersist = true
 	b.inboundMu.Unlock()
+	// Another replica may have taken the event; without a shared backend
+	// the claim always succeeds. A failing backend falls back to the local
+	// check.
+	ctx, cancel := stateContext()
+	claimed, err := b.stateStore().ClaimInbound(ctx, key, until)
+	cancel()
+	if err != nil {
+		b.noteStateError("claim inbound", err)
+	} else if !claimed {
+		return true
+	}
 	if shouldPersist {
 		if err := b.saveState(); err != nil {
-			log.Printf("channelbridge state save warning: %v", err)
+			slog.Warn("channelbridge state save warning", "error", err)
 		}
 	}
 	return false
 }
 
+// releaseInboundEvent forgets a key marked by seenInboundEvent, so a
+// redelivery of the event is forwarded.
+func (b *bridge) releaseInboundEvent(key string) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return
+	}
+	b.inboundMu.Lock()
+	delete(b.inboundSeen, key)
+	b.inboundMu.Unlock()
+	ctx, cancel := stateContext()
+	err := b.stateStore().ReleaseInbound(ctx, key)
+	cancel()
+	if err != nil {
+		b.noteStateError("release inbound", err)
+	}
+	if err := b.saveState(); err != nil {
+		slog.Warn("channelbridge state save warning", "error", err)
+	}
+}
+
 func (b *bridge) pruneInboundSeenLocked(now time.Time) {
 	ttl := b.inboundTTL
 	if ttl <= 0 {
@@ -1960,9 +3060,11 @@ func (b *bridge) handleTeamsOutbound(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		AccountID         string         `json:"account_id"`
 		ChatID            string         `json:"chat_id"`
+		TenantID          string         `json:"tenant_id"`
 		ThreadID          string         `json:"thread_id"`
 		ReplyMode         string         `json:"reply_mode"`
 		Content           string         `json:"content"`
+		Format            string         `json:"format"`
 		MediaURLs         []string       `json:"media_urls"`
 		Card              map[string]any `json:"card"`
 		Action            string         `json:"action"`
@@ -1970,6 +3072,11 @@ func (b *bridge) handleTeamsOutbound(w http.ResponseWriter, r *http.Request) {
 		PollQuestion      string         `json:"poll_question"`
 		PollOptions       []string       `json:"poll_options"`
 		PollMaxSelections int            `json:"poll_max_selections"`
+		SendAt            string         `json:"send_at,omitempty"`
+		Visibility        string         `json:"visibility,omitempty"`
+		UserID            string         `json:"user_id,omitempty"`
+		TaskID            string         `json:"task_id"`
+		TraceID           string         `json:"trace_id"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "invalid json", http.StatusBadRequest)
@@ -1979,38 +3086,120 @@ func (b *bridge) handleTeamsOutbound(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "chat_id required", http.StatusBadRequest)
 		return
 	}
+	sendAt, err := parseSendAt(req.SendAt, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !sendAt.IsZero() && strings.TrimSpace(req.Action) != "" {
+		http.Error(w, "send_at does not support actions", http.StatusBadRequest)
+		return
+	}
+	if strings.EqualFold(strings.TrimSpace(req.Action), "typing") {
+		b.handleTeamsTyping(w, req.AccountID, req.ChatID)
+		return
+	}
+	if strings.EqualFold(strings.TrimSpace(req.Action), "thread") {
+		b.handleTeamsThread(w, childTraceparent(r.Header.Get(traceparentHeader)), req.ChatID, req.ThreadID, req.ActionParams)
+		return
+	}
+	if act := strings.ToLower(strings.TrimSpace(req.Action)); act != "" {
+		b.handleTeamsAction(w, req.AccountID, req.ChatID, act, req.Content, req.ActionParams)
+		return
+	}
 	if strings.TrimSpace(req.Content) == "" && len(req.MediaURLs) == 0 && len(req.Card) == 0 && strings.TrimSpace(req.PollQuestion) == "" {
 		http.Error(w, "content, media_urls, card or poll required", http.StatusBadRequest)
 		return
 	}
+	visibility, err := parseVisibility(req.Visibility, req.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if visibility != "" {
+		req.ChatID, req.ThreadID = teamsPersonalChat(req.UserID), ""
+	}
+	if !sendAt.IsZero() {
+		req.SendAt = ""
+		body, _ := json.Marshal(req)
+		b.scheduleTeamsOutbound(w, r, scheduledMessage{
+			AccountID: strings.TrimSpace(req.AccountID),
+			ChatID:    strings.TrimSpace(req.ChatID),
+			ThreadID:  strings.TrimSpace(req.ThreadID),
+			SendAt:    sendAt,
+			Preview:   firstNonEmpty(req.Content, req.PollQuestion),
+			Body:      body,
+		})
+		return
+	}
 	accountID := strings.TrimSpace(req.AccountID)
 	if accountID == "" {
 		accountID = "default"
 	}
 	threadID := b.resolveReplyThread("msteams", accountID, req.ChatID, req.ThreadID, req.ReplyMode, b.cfg.MSTeamsReplyMode)
-	ref, err := b.resolveTeamsConversation(req.ChatID)
+	ref, err := b.resolveOrCreateTeamsConversation(req.AccountID, req.ChatID, req.TenantID)
 	if err != nil {
-		b.noteOutbound(false, false, err)
-		http.Error(w, err.Error(), http.StatusBadGateway)
+		b.noteOutbound(false, "teams", err)
+		writeOutboundError(w, err, false)
 		return
 	}
-	token, err := b.getTeamsAccessToken()
+	acct := b.teamsAccountForRef(req.AccountID, ref)
+	token, err := b.getTeamsAccessToken(acct)
 	if err != nil {
-		b.noteOutbound(false, false, err)
-		http.Error(w, err.Error(), http.StatusBadGateway)
+		b.noteOutbound(false, "teams", err)
+		writeOutboundError(w, err, false)
 		return
 	}
 	pollCard := req.Card
+	text := req.Content
 	if strings.TrimSpace(req.PollQuestion) != "" {
-		pollID := b.recordTeamsPoll(strings.TrimSpace(req.ChatID), strings.TrimSpace(req.PollQuestion), req.PollOptions, req.PollMaxSelections)
-		pollCard = buildTeamsPollCard(strings.TrimSpace(req.PollQuestion), req.PollOptions, req.PollMaxSelections, pollID)
+		pollID := b.recordPoll("msteams", accountID, strings.TrimSpace(req.ChatID), strings.TrimSpace(req.PollQuestion), req.PollOptions, req.PollMaxSelections)
+		pollCard = buildTeamsPollCard(strings.TrimSpace(req.PollQuestion), req.PollOptions, req.PollMaxSelections, pollID, b.text(ref.TenantID, msgPollVote))
+	} else if len(pollCard) == 0 && strings.TrimSpace(text) != "" && wantsTeamsCardFormat(req.Format, b.cfg.MSTeamsOutboundFormat) {
+		if card := markdownToAdaptiveCard(text); card != nil {
+			pollCard = card
+			text = ""
+		}
+	}
+	activityID, err := b.teamsSend(ref, token, threadID, text, req.MediaURLs, pollCard)
+	if err != nil {
+		b.noteOutbound(false, "teams", err)
+		writeOutboundError(w, err, false)
+		return
 	}
-	if err := b.teamsSend(ref, token, threadID, req.Content, req.MediaURLs, pollCard); err != nil {
-		b.noteOutbound(false, false, err)
+	b.noteOutbound(true, "teams", nil)
+	delivery := outboundDelivery{
+		Channel:    "msteams",
+		AccountID:  accountID,
+		ChatID:     strings.TrimSpace(req.ChatID),
+		ChannelID:  ref.ConversationID,
+		ThreadID:   strings.TrimSpace(threadID),
+		Visibility: visibility,
+		TaskID:     strings.TrimSpace(req.TaskID),
+		TraceID:    strings.TrimSpace(req.TraceID),
+	}
+	delivery.addMessageIDs(activityID)
+	b.postDeliveryReceipt(delivery, acct.InboundToken)
+	writeOutboundDelivery(w, delivery)
+}
+
+// handleTeamsTyping posts a typing activity. Teams shows it for a few
+// seconds, so KafClaw refreshes it while a task runs.
+func (b *bridge) handleTeamsTyping(w http.ResponseWriter, accountID, chatID string) {
+	ref, err := b.resolveTeamsConversation(chatID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	token, err := b.getTeamsAccessToken(b.teamsAccountForRef(accountID, ref))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	if err := b.teamsSendTyping(ref, token); err != nil {
 		http.Error(w, err.Error(), http.StatusBadGateway)
 		return
 	}
-	b.noteOutbound(true, false, nil)
 	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
 }
 
@@ -2026,7 +3215,7 @@ func (b *bridge) handleTeamsResolveUsers(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "invalid json", http.StatusBadRequest)
 		return
 	}
-	results, err := b.teamsResolveUsers(req.Entries)
+	results, err := b.teamsResolveUsers(b.teamsAccount(accountIDFromQuery(r)), req.Entries)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadGateway)
 		return
@@ -2047,7 +3236,7 @@ func (b *bridge) handleTeamsResolveChannels(w http.ResponseWriter, r *http.Reque
 		http.Error(w, "invalid json", http.StatusBadRequest)
 		return
 	}
-	results, err := b.teamsResolveChannels(req.Entries)
+	results, err := b.teamsResolveChannels(b.teamsAccount(accountIDFromQuery(r)), req.Entries)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadGateway)
 		return
@@ -2056,8 +3245,8 @@ func (b *bridge) handleTeamsResolveChannels(w http.ResponseWriter, r *http.Reque
 	_ = json.NewEncoder(w).Encode(map[string]any{"results": results})
 }
 
-func (b *bridge) teamsResolveUsers(entries []string) ([]map[string]any, error) {
-	users, err := b.teamsGraphUsers()
+func (b *bridge) teamsResolveUsers(acct teamsAccount, entries []string) ([]map[string]any, error) {
+	users, err := b.teamsGraphUsers(acct)
 	if err != nil {
 		return nil, err
 	}
@@ -2096,8 +3285,8 @@ func (b *bridge) teamsResolveUsers(entries []string) ([]map[string]any, error) {
 	return out, nil
 }
 
-func (b *bridge) teamsResolveChannels(entries []string) ([]map[string]any, error) {
-	teams, err := b.teamsGraphTeams()
+func (b *bridge) teamsResolveChannels(acct teamsAccount, entries []string) ([]map[string]any, error) {
+	teams, err := b.teamsGraphTeams(acct)
 	if err != nil {
 		return nil, err
 	}
@@ -2127,7 +3316,7 @@ func (b *bridge) teamsResolveChannels(entries []string) ([]map[string]any, error
 				if teamName != tdn {
 					continue
 				}
-				chs, _ := b.teamsGraphTeamChannels(tid)
+				chs, _ := b.teamsGraphTeamChannels(acct, tid)
 				for _, ch := range chs {
 					cid := strings.TrimSpace(asString(ch["id"]))
 					cdn := strings.ToLower(strings.TrimSpace(asString(ch["displayName"])))
@@ -2166,13 +3355,14 @@ func (b *bridge) handleTeamsProbe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	now := time.Now()
-	botToken, err := b.getTeamsAccessToken()
+	acct := b.teamsAccount(accountIDFromQuery(r))
+	botToken, err := b.getTeamsAccessToken(acct)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadGateway)
 		return
 	}
 	botClaims := decodeJWTPayloadMap(botToken)
-	graphToken, gErr := b.getTeamsGraphToken()
+	graphToken, gErr := b.getTeamsGraphToken(acct)
 	graph := map[string]any{"ok": gErr == nil}
 	if gErr == nil {
 		graphClaims := decodeJWTPayloadMap(graphToken)
@@ -2183,7 +3373,8 @@ func (b *bridge) handleTeamsProbe(w http.ResponseWriter, r *http.Request) {
 	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]any{
-		"ok": true,
+		"ok":         true,
+		"account_id": acct.ID,
 		"bot": map[string]any{
 			"claims":      botClaims,
 			"diagnostics": tokenDiagnostics(botClaims, now, "api.botframework.com"),
@@ -2308,12 +3499,20 @@ func graphCapabilityResult(statusCode int, err error, requiredPerms string) map[
 }
 
 func (b *bridge) graphProbeGET(token, pathSuffix string) (int, []byte, error) {
+	return b.graphGET(token, pathSuffix, "")
+}
+
+// graphGET reads a Graph resource; a non-empty traceparent is sent along.
+func (b *bridge) graphGET(token, pathSuffix, traceparent string) (int, []byte, error) {
 	u := strings.TrimRight(b.cfg.MSTeamsGraphBase, "/") + "/" + strings.TrimLeft(pathSuffix, "/")
 	req, err := http.NewRequest(http.MethodGet, u, nil)
 	if err != nil {
 		return 0, nil, err
 	}
 	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(token))
+	if traceparent != "" {
+		req.Header.Set(traceparentHeader, traceparent)
+	}
 	resp, err := b.client.Do(req)
 	if err != nil {
 		return 0, nil, err
@@ -2342,8 +3541,8 @@ func graphFirstValueID(body []byte) string {
 	return strings.TrimSpace(asString(out.Value[0]["id"]))
 }
 
-func (b *bridge) teamsGraphUsers() ([]map[string]any, error) {
-	token, err := b.getTeamsGraphToken()
+func (b *bridge) teamsGraphUsers(acct teamsAccount) ([]map[string]any, error) {
+	token, err := b.getTeamsGraphToken(acct)
 	if err != nil {
 		return nil, err
 	}
@@ -2371,8 +3570,8 @@ func (b *bridge) teamsGraphUsers() ([]map[string]any, error) {
 	return out.Value, nil
 }
 
-func (b *bridge) teamsGraphTeams() ([]map[string]any, error) {
-	token, err := b.getTeamsGraphToken()
+func (b *bridge) teamsGraphTeams(acct teamsAccount) ([]map[string]any, error) {
+	token, err := b.getTeamsGraphToken(acct)
 	if err != nil {
 		return nil, err
 	}
@@ -2400,8 +3599,8 @@ func (b *bridge) teamsGraphTeams() ([]map[string]any, error) {
 	return out.Value, nil
 }
 
-func (b *bridge) teamsGraphTeamChannels(teamID string) ([]map[string]any, error) {
-	token, err := b.getTeamsGraphToken()
+func (b *bridge) teamsGraphTeamChannels(acct teamsAccount, teamID string) ([]map[string]any, error) {
+	token, err := b.getTeamsGraphToken(acct)
 	if err != nil {
 		return nil, err
 	}
@@ -2446,11 +3645,16 @@ func looksLikeGUID(v string) bool {
 func (b *bridge) resolveTeamsConversation(chatID string) (teamsConversationRef, error) {
 	id := normalizeTeamsTarget(chatID)
 	b.teamsMu.RLock()
-	defer b.teamsMu.RUnlock()
 	if ref, ok := b.teamsConvByID[id]; ok && ref.ServiceURL != "" {
+		b.teamsMu.RUnlock()
 		return ref, nil
 	}
 	if ref, ok := b.teamsConvByUserID[id]; ok && ref.ServiceURL != "" {
+		b.teamsMu.RUnlock()
+		return ref, nil
+	}
+	b.teamsMu.RUnlock()
+	if ref, ok := b.sharedTeamsConversation(id); ok {
 		return ref, nil
 	}
 	return teamsConversationRef{}, fmt.Errorf("no teams conversation reference for %s", id)
@@ -2471,7 +3675,7 @@ func normalizeTeamsTarget(v string) string {
 	}
 }
 
-func buildTeamsPollCard(question string, options []string, maxSel int, pollID string) map[string]any {
+func buildTeamsPollCard(question string, options []string, maxSel int, pollID, voteLabel string) map[string]any {
 	if maxSel <= 0 {
 		maxSel = 1
 	}
@@ -2491,12 +3695,15 @@ func buildTeamsPollCard(question string, options []string, maxSel int, pollID st
 			{"type": "Input.ChoiceSet", "id": "poll_choice", "isMultiSelect": maxSel > 1, "choices": choices},
 		},
 		"actions": []map[string]any{
-			{"type": "Action.Submit", "title": "Vote", "data": map[string]any{"poll_id": strings.TrimSpace(pollID)}},
+			{"type": "Action.Submit", "title": voteLabel, "data": map[string]any{"poll_id": strings.TrimSpace(pollID)}},
 		},
 	}
 }
 
-func (b *bridge) recordTeamsPoll(chatID, question string, options []string, maxSel int) string {
+// recordPoll stores a poll sent to a Slack or Teams chat and returns its ID.
+// Polls of both platforms share the teamsPolls store, so votes are
+// persisted and shared between replicas the same way.
+func (b *bridge) recordPoll(channel, accountID, chatID, question string, options []string, maxSel int) string {
 	if strings.TrimSpace(chatID) == "" || strings.TrimSpace(question) == "" {
 		return ""
 	}
@@ -2512,7 +3719,9 @@ func (b *bridge) recordTeamsPoll(chatID, question string, options []string, maxS
 		b.teamsPolls = map[string]map[string]any{}
 	}
 	key := fmt.Sprintf("%s:%d", strings.TrimSpace(chatID), time.Now().UnixNano())
-	b.teamsPolls[key] = map[string]any{
+	poll := map[string]any{
+		"channel":            channel,
+		"account_id":         accountID,
 		"chat_id":            strings.TrimSpace(chatID),
 		"question":           question,
 		"options":            options,
@@ -2520,7 +3729,9 @@ func (b *bridge) recordTeamsPoll(chatID, question string, options []string, maxS
 		"max_selections":     maxSel,
 		"created_at_rfc3339": time.Now().UTC().Format(time.RFC3339),
 	}
+	b.teamsPolls[key] = poll
 	b.pollMu.Unlock()
+	b.storeTeamsPoll(key, cloneTeamsPoll(poll))
 	_ = b.saveState()
 	return key
 }
@@ -2538,13 +3749,32 @@ func (b *bridge) handleTeamsPollVote(chatID, senderID string, activity map[strin
 	if len(selections) == 0 {
 		return false, nil
 	}
+	return b.recordPollVote(pollID, chatID, senderID, selections)
+}
+
+// recordPollVote records the selections of a voter on a poll of chatID. An
+// empty pollID matches any poll of the chat. A new vote replaces the voter's
+// previous one. A vote on a closed poll is handled but not counted; its
+// details carry "closed".
+func (b *bridge) recordPollVote(pollID, chatID, senderID string, selections []string) (bool, map[string]any) {
 	chatID = strings.TrimSpace(chatID)
 	senderID = strings.TrimSpace(senderID)
 	if chatID == "" || senderID == "" {
 		return false, nil
 	}
+	// The shared copy carries the votes recorded on other replicas.
+	var shared map[string]any
+	if pollID != "" {
+		shared, _ = b.sharedTeamsPoll(pollID)
+	}
 	b.pollMu.Lock()
 	defer b.pollMu.Unlock()
+	if shared != nil {
+		if b.teamsPolls == nil {
+			b.teamsPolls = map[string]map[string]any{}
+		}
+		b.teamsPolls[pollID] = shared
+	}
 	keys := make([]string, 0, len(b.teamsPolls))
 	if pollID != "" {
 		keys = append(keys, pollID)
@@ -2561,6 +3791,9 @@ func (b *bridge) handleTeamsPollVote(chatID, senderID string, activity map[strin
 		if strings.TrimSpace(c) != chatID {
 			continue
 		}
+		if closed, _ := p["closed"].(bool); closed {
+			return true, map[string]any{"poll_id": k, "closed": true}
+		}
 		maxSel := intFromAny(p["max_selections"], 1)
 		allowedSet := make(map[string]struct{})
 		if allowed, ok := p["allowed_values"].([]string); ok {
@@ -2613,7 +3846,9 @@ func (b *bridge) handleTeamsPollVote(chatID, senderID string, activity map[strin
 		p["total_votes"] = totalVotes
 		p["updated_at_rfc3339"] = time.Now().UTC().Format(time.RFC3339)
 		b.teamsPolls[k] = p
+		stored := cloneTeamsPoll(p)
 		go func() {
+			b.storeTeamsPoll(k, stored)
 			_ = b.saveState()
 		}()
 		return true, map[string]any{
@@ -2627,28 +3862,36 @@ func (b *bridge) handleTeamsPollVote(chatID, senderID string, activity map[strin
 	return false, nil
 }
 
-func (b *bridge) getTeamsAccessToken() (string, error) {
-	return b.getTeamsTokenForScope("https://api.botframework.com/.default", false)
+func (b *bridge) getTeamsAccessToken(acct teamsAccount) (string, error) {
+	return b.getTeamsTokenForScope(acct, "https://api.botframework.com/.default", false)
 }
 
-func (b *bridge) getTeamsGraphToken() (string, error) {
-	return b.getTeamsTokenForScope("https://graph.microsoft.com/.default", true)
+func (b *bridge) getTeamsGraphToken(acct teamsAccount) (string, error) {
+	return b.getTeamsTokenForScope(acct, "https://graph.microsoft.com/.default", true)
 }
 
-func (b *bridge) getTeamsTokenForScope(scope string, graph bool) (string, error) {
-	b.teamsMu.RLock()
-	cache := b.teamsToken
+func (b *bridge) getTeamsTokenForScope(acct teamsAccount, scope string, graph bool) (string, error) {
+	isDefault := acct.ID == b.teamsDefaultAccount().ID
+	cacheKey := acct.ID
 	if graph {
-		cache = b.teamsGraphToken
+		cacheKey += "\x00graph"
+	}
+	b.teamsMu.RLock()
+	cache := b.teamsAccountTokens[cacheKey]
+	if isDefault {
+		cache = b.teamsToken
+		if graph {
+			cache = b.teamsGraphToken
+		}
 	}
 	b.teamsMu.RUnlock()
 	if cache.accessToken != "" && time.Until(cache.expiresAt) > 2*time.Minute {
 		return cache.accessToken, nil
 	}
 
-	appID := strings.TrimSpace(b.cfg.MSTeamsAppID)
-	secret := strings.TrimSpace(b.cfg.MSTeamsAppPassword)
-	tenant := strings.TrimSpace(b.cfg.MSTeamsTenantID)
+	appID := acct.AppID
+	secret := acct.AppPassword
+	tenant := strings.TrimSpace(acct.TenantID)
 	if appID == "" || secret == "" || tenant == "" {
 		return "", errors.New("missing teams app credentials")
 	}
@@ -2696,17 +3939,26 @@ func (b *bridge) getTeamsTokenForScope(scope string, graph bool) (string, error)
 		return "", err
 	}
 	b.teamsMu.Lock()
-	if graph {
+	switch {
+	case !isDefault:
+		if b.teamsAccountTokens == nil {
+			b.teamsAccountTokens = map[string]tokenCache{}
+		}
+		b.teamsAccountTokens[cacheKey] = tokenCache{accessToken: token, expiresAt: exp}
+	case graph:
 		b.teamsGraphToken = tokenCache{accessToken: token, expiresAt: exp}
-	} else {
+	default:
 		b.teamsToken = tokenCache{accessToken: token, expiresAt: exp}
 	}
 	b.teamsMu.Unlock()
 	return token, nil
 }
 
-func (b *bridge) teamsSend(ref teamsConversationRef, accessToken, replyToID, text string, mediaURLs []string, card map[string]any) error {
-	return withRetry(3, 300*time.Millisecond, func() (bool, error) {
+// teamsSend posts a message activity and returns the activity ID Teams
+// assigned to it.
+func (b *bridge) teamsSend(ref teamsConversationRef, accessToken, replyToID, text string, mediaURLs []string, card map[string]any) (string, error) {
+	var activityID string
+	err := withRetry(3, 300*time.Millisecond, func() (bool, error) {
 		payload := map[string]any{"type": "message", "text": text}
 		if rid := strings.TrimSpace(replyToID); rid != "" {
 			payload["replyToId"] = rid
@@ -2721,8 +3973,16 @@ func (b *bridge) teamsSend(ref teamsConversationRef, accessToken, replyToID, tex
 			if name == "." || name == "/" || name == "" {
 				name = "attachment"
 			}
+			contentType := "application/octet-stream"
+			if _, mimeType, ok, err := decodeDataURL(mediaURL); ok {
+				if err != nil {
+					return false, err
+				}
+				contentType = mimeType
+				name = "attachment" + mediaExtForType(mimeType)
+			}
 			attachments = append(attachments, map[string]any{
-				"contentType": "application/octet-stream",
+				"contentType": contentType,
 				"contentUrl":  mediaURL,
 				"name":        name,
 			})
@@ -2754,47 +4014,95 @@ func (b *bridge) teamsSend(ref teamsConversationRef, accessToken, replyToID, tex
 			return true, err
 		}
 		defer resp.Body.Close()
+		bb, _ := io.ReadAll(resp.Body)
 		if resp.StatusCode < 300 {
+			var out struct {
+				ID string `json:"id"`
+			}
+			_ = json.Unmarshal(bb, &out)
+			activityID = strings.TrimSpace(out.ID)
 			return false, nil
 		}
-		bb, _ := io.ReadAll(resp.Body)
 		if d := parseRetryAfter(resp.Header.Get("Retry-After")); d > 0 {
 			time.Sleep(d)
 		}
 		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
 		return retryable, fmt.Errorf("teams send failed: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(bb)))
 	})
+	return activityID, err
+}
+
+// teamsSendTyping posts a typing activity. It is not retried: a missed
+// indicator is harmless and the next refresh follows shortly.
+func (b *bridge) teamsSendTyping(ref teamsConversationRef, accessToken string) error {
+	body, _ := json.Marshal(map[string]any{"type": "typing"})
+	base := strings.TrimRight(ref.ServiceURL, "/")
+	if apiBase := strings.TrimSpace(b.cfg.MSTeamsAPIBase); apiBase != "" {
+		base = strings.TrimRight(apiBase, "/")
+	}
+	u := fmt.Sprintf("%s/v3/conversations/%s/activities", base, url.PathEscape(ref.ConversationID))
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		bb, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("teams typing failed: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(bb)))
+	}
+	return nil
 }
 
 func (b *bridge) postInbound(path, token string, payload map[string]any) error {
+	return b.postInboundTrace(path, token, newTraceparent(), payload)
+}
+
+func (b *bridge) postInboundTrace(path, token, traceparent string, payload map[string]any) error {
 	return withRetry(3, 200*time.Millisecond, func() (bool, error) {
-		data, _ := json.Marshal(payload)
-		u := strings.TrimRight(b.cfg.KafclawBase, "/") + path
-		req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(data))
-		if err != nil {
-			return false, err
-		}
-		req.Header.Set("Content-Type", "application/json")
-		if strings.TrimSpace(token) != "" {
-			req.Header.Set("X-Channel-Token", strings.TrimSpace(token))
-		}
-		resp, err := b.client.Do(req)
-		if err != nil {
-			return true, err
+		err := b.postInboundOnce(path, token, traceparent, payload)
+		var se *inboundStatusError
+		if errors.As(err, &se) && se.retryAfter > 0 {
+			time.Sleep(se.retryAfter)
 		}
-		defer resp.Body.Close()
-		if resp.StatusCode < 300 {
-			return false, nil
-		}
-		body, _ := io.ReadAll(resp.Body)
-		if d := parseRetryAfter(resp.Header.Get("Retry-After")); d > 0 {
-			time.Sleep(d)
-		}
-		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
-		return retryable, fmt.Errorf("kafclaw inbound rejected: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(body)))
+		return retryableInbound(err), err
 	})
 }
 
+// postInboundOnce makes a single inbound post to kafclaw.
+func (b *bridge) postInboundOnce(path, token, traceparent string, payload map[string]any) error {
+	data, _ := json.Marshal(payload)
+	u := strings.TrimRight(b.cfg.KafclawBase, "/") + path
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(traceparentHeader, traceparent)
+	if strings.TrimSpace(token) != "" {
+		req.Header.Set("X-Channel-Token", strings.TrimSpace(token))
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(resp.Body)
+	return &inboundStatusError{
+		status:     resp.StatusCode,
+		body:       strings.TrimSpace(string(body)),
+		retryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+}
+
 func withRetry(attempts int, baseDelay time.Duration, fn func() (retryable bool, err error)) error {
 	if attempts <= 0 {
 		attempts = 1
@@ -2809,28 +4117,22 @@ func withRetry(attempts int, baseDelay time.Duration, fn func() (retryable bool,
 			return nil
 		}
 		lastErr = err
-		if !retryable || i == attempts-1 {
+		if !retryable {
+			return err
+		}
+		if i == attempts-1 {
 			break
 		}
 		time.Sleep(baseDelay * time.Duration(1<<i))
 	}
-	return lastErr
+	return &transientError{err: lastErr}
 }
 
 func (b *bridge) loadState() error {
-	path := strings.TrimSpace(b.cfg.StatePath)
-	if path == "" {
-		return nil
-	}
-	data, err := os.ReadFile(path)
+	ctx, cancel := stateContext()
+	defer cancel()
+	st, err := b.stateStore().Load(ctx)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
-		}
-		return err
-	}
-	var st bridgeState
-	if err := json.Unmarshal(data, &st); err != nil {
 		return err
 	}
 	b.teamsMu.Lock()
@@ -2859,17 +4161,32 @@ func (b *bridge) loadState() error {
 		b.teamsPolls[k] = v
 	}
 	b.pollMu.Unlock()
+	b.queueMu.Lock()
+	b.inboundQueue = append(b.inboundQueue, st.InboundQueue...)
+	b.queueMu.Unlock()
+	b.outboundMu.Lock()
+	b.outboundQueue = append(b.outboundQueue, st.OutboundQueue...)
+	b.outboundDead = append(b.outboundDead, st.OutboundDead...)
+	b.outboundMu.Unlock()
+	b.scheduledMu.Lock()
+	b.scheduled = append(b.scheduled, st.Scheduled...)
+	b.scheduledMu.Unlock()
+	b.linkMu.Lock()
+	if b.linkThreads == nil {
+		b.linkThreads = map[string]linkedThread{}
+	}
+	for k, v := range st.LinkThreads {
+		b.linkThreads[k] = v
+	}
+	b.linkMu.Unlock()
 	return nil
 }
 
 func (b *bridge) saveState() error {
-	path := strings.TrimSpace(b.cfg.StatePath)
-	if path == "" {
+	store := b.stateStore()
+	if fs, ok := store.(fileStateStore); ok && strings.TrimSpace(fs.path) == "" {
 		return nil
 	}
-	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
-		return err
-	}
 	b.teamsMu.RLock()
 	convByID := make(map[string]teamsConversationRef, len(b.teamsConvByID))
 	for k, v := range b.teamsConvByID {
@@ -2893,18 +4210,40 @@ func (b *bridge) saveState() error {
 		teamsPolls[k] = v
 	}
 	b.pollMu.Unlock()
+	b.queueMu.Lock()
+	inboundQueue := append([]queuedInbound(nil), b.inboundQueue...)
+	b.queueMu.Unlock()
+	b.outboundMu.Lock()
+	outboundQueue := append([]queuedOutbound(nil), b.outboundQueue...)
+	outboundDead := append([]queuedOutbound(nil), b.outboundDead...)
+	b.outboundMu.Unlock()
+	b.scheduledMu.Lock()
+	scheduled := append([]scheduledMessage(nil), b.scheduled...)
+	b.scheduledMu.Unlock()
+	b.linkMu.Lock()
+	b.pruneLinkThreadsLocked(time.Now())
+	linkThreads := make(map[string]linkedThread, len(b.linkThreads))
+	for k, v := range b.linkThreads {
+		linkThreads[k] = v
+	}
+	b.linkMu.Unlock()
 
 	st := bridgeState{
 		TeamsConvByID:     convByID,
 		TeamsConvByUserID: convByUserID,
 		InboundSeen:       inboundSeen,
 		TeamsPolls:        teamsPolls,
+		InboundQueue:      inboundQueue,
+		OutboundQueue:     outboundQueue,
+		OutboundDead:      outboundDead,
+		LinkThreads:       linkThreads,
+		Scheduled:         scheduled,
 	}
-	data, err := json.MarshalIndent(st, "", "  ")
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(path, data, 0o600)
+	b.stateMu.Lock()
+	defer b.stateMu.Unlock()
+	ctx, cancel := stateContext()
+	defer cancel()
+	return store.Save(ctx, st)
 }
 
 func newTeamsJWTVerifier(client *http.Client, cfgURL, appID string) *teamsJWTVerifier {
@@ -3271,6 +4610,12 @@ func (b *bridge) downloadMedia(mediaURL string) ([]byte, string, error) {
 	if mediaURL == "" {
 		return nil, "", errors.New("empty media url")
 	}
+	if data, mimeType, ok, err := decodeDataURL(mediaURL); ok {
+		if err != nil {
+			return nil, "", err
+		}
+		return data, "upload" + mediaExtForType(mimeType), nil
+	}
 	parsed, err := validateMediaDownloadURL(mediaURL)
 	if err != nil {
 		return nil, "", err
@@ -3300,6 +4645,40 @@ func (b *bridge) downloadMedia(mediaURL string) ([]byte, string, error) {
 	return data, name, nil
 }
 
+// decodeDataURL decodes a base64 "data:" URL as sent by the gateway for
+// locally generated media. ok is false when raw is not a data URL.
+func decodeDataURL(raw string) (data []byte, mimeType string, ok bool, err error) {
+	if !strings.HasPrefix(strings.ToLower(raw), "data:") {
+		return nil, "", false, nil
+	}
+	meta, payload, found := strings.Cut(raw[len("data:"):], ",")
+	if !found || !strings.HasSuffix(strings.ToLower(meta), ";base64") {
+		return nil, "", true, errors.New("media data url must be base64 encoded")
+	}
+	data, err = base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, "", true, fmt.Errorf("invalid media data url: %w", err)
+	}
+	return data, strings.TrimSuffix(strings.ToLower(meta), ";base64"), true, nil
+}
+
+func mediaExtForType(mimeType string) string {
+	switch mimeType {
+	case "image/png":
+		return ".png"
+	case "image/jpeg":
+		return ".jpg"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	case "application/pdf":
+		return ".pdf"
+	default:
+		return ".bin"
+	}
+}
+
 func validateMediaDownloadURL(raw string) (*url.URL, error) {
 	u, err := url.Parse(strings.TrimSpace(raw))
 	if err != nil {
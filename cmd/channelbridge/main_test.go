@@ -126,6 +126,80 @@ func TestSlackEventsAppMentionForwards(t *testing.T) {
 	}
 }
 
+func TestSlackEventsForwardActivityWithEventType(t *testing.T) {
+	var got []map[string]any
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/channels/slack/inbound":
+			defer r.Body.Close()
+			var body map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			got = append(got, body)
+			w.WriteHeader(http.StatusOK)
+		case "/files.info":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"ok": true,
+				"file": map[string]any{
+					"id": "F1", "name": "report.pdf", "mimetype": "application/pdf", "size": 2048,
+					"url_private_download": "https://files.slack.com/F1/download/report.pdf",
+				},
+			})
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer api.Close()
+
+	b := newTestBridge(api.URL)
+	b.cfg.SlackBotToken = "xoxb-test"
+	b.cfg.SlackAPIBase = api.URL
+	b.cfg.SlackBotUserID = "Ubot"
+	events := []map[string]any{
+		{"type": "reaction_added", "user": "U1", "reaction": "thumbsup::skin-tone-2", "item_user": "Ubot", "event_ts": "1.1",
+			"item": map[string]any{"type": "message", "channel": "C1", "ts": "0.9"}},
+		{"type": "reaction_removed", "user": "Ubot", "reaction": "x", "event_ts": "1.2",
+			"item": map[string]any{"type": "message", "channel": "C1", "ts": "0.9"}},
+		{"type": "file_shared", "user_id": "U1", "channel_id": "C1", "file_id": "F1", "event_ts": "1.3"},
+		{"type": "member_joined_channel", "user": "U2", "channel": "C1", "channel_type": "C", "inviter": "U1", "event_ts": "1.4"},
+		{"type": "member_left_channel", "user": "U2", "channel": "C1", "channel_type": "C", "event_ts": "1.5"},
+	}
+	for i, ev := range events {
+		body, _ := json.Marshal(map[string]any{"type": "event_callback", "event_id": fmt.Sprintf("EvAct%d", i), "event": ev})
+		w := httptest.NewRecorder()
+		b.handleSlackEvents(w, httptest.NewRequest(http.MethodPost, "/slack/events", bytes.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("event %d status=%d body=%s", i, w.Code, w.Body.String())
+		}
+	}
+
+	// The bot's own reaction is dropped.
+	if len(got) != 4 {
+		t.Fatalf("expected 4 forwards, got %d: %#v", len(got), got)
+	}
+	reaction := got[0]
+	feedback, _ := reaction["event"].(map[string]any)
+	if reaction["event_type"] != "reaction_added" || feedback["sentiment"] != "positive" || feedback["item_ts"] != "0.9" {
+		t.Fatalf("unexpected reaction forward: %#v", reaction)
+	}
+	if was, _ := reaction["was_mentioned"].(bool); !was {
+		t.Fatalf("reaction on bot message should count as mention: %#v", reaction)
+	}
+	file, _ := got[1]["event"].(map[string]any)
+	if got[1]["event_type"] != "file_shared" || file["name"] != "report.pdf" || file["url_private_download"] == "" {
+		t.Fatalf("unexpected file forward: %#v", got[1])
+	}
+	if !strings.Contains(asString(got[1]["text"]), "report.pdf") {
+		t.Fatalf("expected file name in text, got %q", got[1]["text"])
+	}
+	member, _ := got[2]["event"].(map[string]any)
+	if got[2]["event_type"] != "member_joined_channel" || got[2]["sender_id"] != "U2" || member["inviter"] != "U1" {
+		t.Fatalf("unexpected member forward: %#v", got[2])
+	}
+	if got[3]["event_type"] != "member_left_channel" {
+		t.Fatalf("unexpected member left forward: %#v", got[3])
+	}
+}
+
 func TestSlackEventsMessageChangedForwardsNormalized(t *testing.T) {
 	var got map[string]any
 	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -463,6 +537,45 @@ func TestSlackInteractionsForwardInbound(t *testing.T) {
 	}
 }
 
+func TestSlackApprovalButtonForwardsReply(t *testing.T) {
+	var got map[string]any
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/channels/slack/inbound" {
+			defer r.Body.Close()
+			_ = json.NewDecoder(r.Body).Decode(&got)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer api.Close()
+
+	b := newTestBridge(api.URL)
+	payload, _ := json.Marshal(map[string]any{
+		"type":      "block_actions",
+		"user":      map[string]any{"id": "U22"},
+		"channel":   map[string]any{"id": "C22"},
+		"action_ts": "171.224",
+		"actions": []map[string]any{
+			{"type": "button", "block_id": "b1", "action_id": "kafclaw_approval_approve", "value": "approve:9f2c"},
+		},
+	})
+	form := url.Values{}
+	form.Set("payload", string(payload))
+	req := httptest.NewRequest(http.MethodPost, "/slack/interactions", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	b.handleSlackInteractions(w, req)
+	if w.Code != http.StatusOK || got["text"] != "approve:9f2c" {
+		t.Fatalf("expected the button value as reply, status=%d payload=%#v", w.Code, got)
+	}
+
+	if _, ok := slackApprovalReply("kafclaw_approval_deny", "drop:9f2c"); ok {
+		t.Fatal("expected values other than approve:/deny: to be ignored")
+	}
+	if _, ok := slackApprovalReply("approve", "approve:9f2c"); ok {
+		t.Fatal("expected other action IDs to be ignored")
+	}
+}
+
 func TestTeamsInboundRequiresBearerWhenConfigured(t *testing.T) {
 	var forwards int32
 	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -1107,6 +1220,34 @@ func TestTeamsOutboundCardAndAttachment(t *testing.T) {
 	}
 }
 
+func TestTeamsOutboundTypingActivity(t *testing.T) {
+	var payload map[string]any
+	teamsAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer teamsAPI.Close()
+
+	b := newTestBridge("http://example.invalid")
+	b.cfg.MSTeamsAPIBase = teamsAPI.URL
+	b.teamsMu.Lock()
+	b.teamsConvByID["conv-1"] = teamsConversationRef{ServiceURL: teamsAPI.URL, ConversationID: "conv-1", UserID: "u1"}
+	b.teamsToken = tokenCache{accessToken: "token", expiresAt: time.Now().Add(30 * time.Minute)}
+	b.teamsMu.Unlock()
+
+	reqBody, _ := json.Marshal(map[string]any{"chat_id": "conv-1", "action": "typing"})
+	req := httptest.NewRequest(http.MethodPost, "/teams/outbound", bytes.NewReader(reqBody))
+	w := httptest.NewRecorder()
+	b.handleTeamsOutbound(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	if payload["type"] != "typing" || payload["text"] != nil {
+		t.Fatalf("expected bare typing activity, got %#v", payload)
+	}
+}
+
 func TestTeamsOutboundMultipleMediaAttachments(t *testing.T) {
 	var payload map[string]any
 	teamsAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -2045,14 +2186,14 @@ func TestVerifyBearer(t *testing.T) {
 func TestVerifyTeamsJWTRequestBranches(t *testing.T) {
 	b := newTestBridge("http://example.invalid")
 
-	if err := b.verifyTeamsJWTRequest(httptest.NewRequest(http.MethodPost, "/teams/messages", nil), "https://service", "msteams"); err != nil {
+	if err := b.verifyTeamsJWTRequest(b.teamsDefaultAccount(), httptest.NewRequest(http.MethodPost, "/teams/messages", nil), "https://service", "msteams"); err != nil {
 		t.Fatalf("expected bypass when jwt/app id unset: %v", err)
 	}
 
 	b.cfg.MSTeamsAppID = "app-id"
 	b.jwt = &teamsJWTVerifier{appID: "app-id"}
 	req := httptest.NewRequest(http.MethodPost, "/teams/messages", nil)
-	if err := b.verifyTeamsJWTRequest(req, "https://service", "msteams"); err == nil {
+	if err := b.verifyTeamsJWTRequest(b.teamsDefaultAccount(), req, "https://service", "msteams"); err == nil {
 		t.Fatal("expected missing authorization error")
 	}
 }
@@ -2321,9 +2462,218 @@ func TestValidateMediaDownloadURL(t *testing.T) {
 	}
 }
 
+func TestDownloadMediaDataURL(t *testing.T) {
+	b := &bridge{}
+	data, name, err := b.downloadMedia("data:image/png;base64," + base64.StdEncoding.EncodeToString([]byte("png-bytes")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "png-bytes" || name != "upload.png" {
+		t.Fatalf("unexpected decode: data=%q name=%q", data, name)
+	}
+	if _, _, err := b.downloadMedia("data:text/plain,hello"); err == nil {
+		t.Fatal("expected non-base64 data url to be rejected")
+	}
+}
+
 func buildUnsignedTestJWT(claims map[string]any) string {
 	header := map[string]any{"alg": "none", "typ": "JWT"}
 	hb, _ := json.Marshal(header)
 	cb, _ := json.Marshal(claims)
 	return base64.RawURLEncoding.EncodeToString(hb) + "." + base64.RawURLEncoding.EncodeToString(cb) + "."
 }
+
+func TestSlackUsergroupAndBroadcastMentions(t *testing.T) {
+	var usergroupCalls int32
+	slackAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users.list":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"ok":      true,
+				"members": []map[string]any{{"id": "U123", "name": "alice"}},
+			})
+		case "/usergroups.list":
+			atomic.AddInt32(&usergroupCalls, 1)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"ok": true,
+				"usergroups": []map[string]any{
+					{"id": "S0DEVOPS1", "handle": "devops", "name": "DevOps", "user_count": 4},
+					{"id": "S0OLDTEAM", "handle": "oldteam", "name": "Old Team", "date_delete": 1700000000},
+				},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer slackAPI.Close()
+
+	b := newTestBridge("http://example.invalid")
+	b.cfg.SlackAPIBase = slackAPI.URL
+	b.cfg.SlackBotToken = "xoxb-test"
+
+	results, err := b.slackResolveUsers(b.slackDefaultAccount(), []string{"!here", "@channel", "@devops", "usergroup:DevOps", "S0DEVOPS1", "@oldteam", "@alice", "@nobody"})
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	want := []struct {
+		resolved bool
+		mention  string
+	}{
+		{true, "<!here>"},
+		{true, "<!channel>"},
+		{true, "<!subteam^S0DEVOPS1>"},
+		{true, "<!subteam^S0DEVOPS1>"},
+		{true, "<!subteam^S0DEVOPS1>"},
+		{false, ""},
+		{true, "<@U123>"},
+		{false, ""},
+	}
+	for i, w := range want {
+		got := results[i]
+		if got["resolved"] != w.resolved || asString(got["mention"]) != w.mention {
+			t.Fatalf("entry %d (%v): got %#v", i, got["input"], got)
+		}
+	}
+	if results[2]["members"] != 4 {
+		t.Fatalf("expected member count from usergroups.list, got %#v", results[2])
+	}
+
+	got := b.slackExpandMentions(b.slackDefaultAccount(), "!here deploy is done, @devops please check (and @oldteam, a@b.com, @devops.)")
+	wantText := "!here deploy is done, <!subteam^S0DEVOPS1> please check (and @oldteam, a@b.com, <!subteam^S0DEVOPS1>.)"
+	if got != wantText {
+		t.Fatalf("broadcast must not expand by default:\n got %q\nwant %q", got, wantText)
+	}
+	b.cfg.SlackAllowBroadcast = true
+	got = b.slackExpandMentions(b.slackDefaultAccount(), "!here deploy is done, @devops please check (and @oldteam, a@b.com, @devops.)")
+	wantText = "<!here> deploy is done, <!subteam^S0DEVOPS1> please check (and @oldteam, a@b.com, <!subteam^S0DEVOPS1>.)"
+	if got != wantText {
+		t.Fatalf("unexpected expansion:\n got %q\nwant %q", got, wantText)
+	}
+	if n := atomic.LoadInt32(&usergroupCalls); n != 1 {
+		t.Fatalf("expected usergroups.list to be cached, got %d calls", n)
+	}
+}
+
+func TestSlackUsergroupLookupFailureIsCached(t *testing.T) {
+	var usergroupCalls int32
+	slackAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/usergroups.list" {
+			atomic.AddInt32(&usergroupCalls, 1)
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "missing_scope"})
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer slackAPI.Close()
+
+	b := newTestBridge("http://example.invalid")
+	b.cfg.SlackAPIBase = slackAPI.URL
+	b.cfg.SlackBotToken = "xoxb-test"
+
+	for i := 0; i < 3; i++ {
+		if got := b.slackExpandMentions(b.slackDefaultAccount(), "ping @devops"); got != "ping @devops" {
+			t.Fatalf("unexpected expansion: %q", got)
+		}
+	}
+	if n := atomic.LoadInt32(&usergroupCalls); n != 1 {
+		t.Fatalf("expected failed usergroups.list to be cached, got %d calls", n)
+	}
+}
+
+func TestSlackOutboundAutoJoinsPublicChannel(t *testing.T) {
+	var posts, joins int32
+	slackAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/chat.postMessage":
+			if atomic.AddInt32(&posts, 1) == 1 {
+				_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "not_in_channel"})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "ts": "1"})
+		case "/conversations.info":
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "channel": map[string]any{"id": "C111", "name": "ops"}})
+		case "/conversations.join":
+			atomic.AddInt32(&joins, 1)
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "channel": map[string]any{"id": "C111"}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer slackAPI.Close()
+
+	b := newTestBridge("http://example.invalid")
+	b.cfg.SlackAPIBase = slackAPI.URL
+	b.cfg.SlackBotToken = "xoxb-test"
+	b.cfg.SlackAutoJoin = true
+
+	body, _ := json.Marshal(map[string]any{"chat_id": "C111", "content": "hello"})
+	w := httptest.NewRecorder()
+	b.handleSlackOutbound(w, httptest.NewRequest(http.MethodPost, "/slack/outbound", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	if atomic.LoadInt32(&joins) != 1 || atomic.LoadInt32(&posts) != 2 {
+		t.Fatalf("expected join and retried post, joins=%d posts=%d", joins, posts)
+	}
+}
+
+func TestSlackOutboundNotInChannelErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		private  bool
+		autoJoin bool
+		want     string
+	}{
+		{name: "private", private: true, autoJoin: true, want: "private channel #secret (C222)"},
+		{name: "auto-join disabled", autoJoin: false, want: "auto-join is disabled"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var joins int32
+			slackAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/chat.postMessage":
+					_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "not_in_channel"})
+				case "/conversations.info":
+					_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "channel": map[string]any{"id": "C222", "name": "secret", "is_private": tc.private}})
+				case "/conversations.join":
+					atomic.AddInt32(&joins, 1)
+					_ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer slackAPI.Close()
+
+			b := newTestBridge("http://example.invalid")
+			b.cfg.SlackAPIBase = slackAPI.URL
+			b.cfg.SlackBotToken = "xoxb-test"
+			b.cfg.SlackAutoJoin = tc.autoJoin
+
+			body, _ := json.Marshal(map[string]any{"chat_id": "C222", "content": "hello"})
+			w := httptest.NewRecorder()
+			b.handleSlackOutbound(w, httptest.NewRequest(http.MethodPost, "/slack/outbound", bytes.NewReader(body)))
+			if w.Code != http.StatusBadGateway || !strings.Contains(w.Body.String(), tc.want) {
+				t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+			}
+			if atomic.LoadInt32(&joins) != 0 {
+				t.Fatal("expected no join attempt")
+			}
+		})
+	}
+}
+
+func TestTeamsApprovalActionIsAReply(t *testing.T) {
+	activity := map[string]any{
+		"type":  "message",
+		"value": map[string]any{"text": "deny:9f2c"},
+	}
+	if got := extractTeamsInboundText(activity); got != "deny:9f2c" {
+		t.Fatalf("expected the action data as text, got %q", got)
+	}
+	if !teamsWasMentioned(activity, "bot-1", "KafClaw") {
+		t.Fatal("expected a card action to count as addressed to the bot")
+	}
+	if teamsWasMentioned(map[string]any{"text": "hello"}, "bot-1", "KafClaw") {
+		t.Fatal("expected plain messages without a mention to stay unmentioned")
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// defaultMediaMaxBytes caps one downloaded attachment; larger files are
+// left out (Slack) or forwarded by URL (Teams).
+const defaultMediaMaxBytes = 8 << 20
+
+// inboundMedia is one attachment forwarded to KafClaw: a data: URL when the
+// bridge downloaded it, else the original URL.
+type inboundMedia struct {
+	URL      string
+	MimeType string
+	Name     string
+}
+
+// slackFile is a file attached to a Slack message.
+type slackFile struct {
+	ID       string
+	Name     string
+	MimeType string
+	Size     int
+	URL      string
+}
+
+// slackEventFiles reads the files of an Events API message.
+func slackEventFiles(raw any) []slackFile {
+	items, _ := raw.([]any)
+	var out []slackFile
+	for _, item := range items {
+		f, _ := item.(map[string]any)
+		if f == nil {
+			continue
+		}
+		size, _ := f["size"].(float64)
+		file := slackFile{
+			ID:       strings.TrimSpace(asString(f["id"])),
+			Name:     strings.TrimSpace(asString(f["name"])),
+			MimeType: strings.TrimSpace(asString(f["mimetype"])),
+			Size:     int(size),
+			URL:      strings.TrimSpace(firstNonEmpty(asString(f["url_private_download"]), asString(f["url_private"]))),
+		}
+		if file.URL != "" {
+			out = append(out, file)
+		}
+	}
+	return out
+}
+
+// slackMsgFiles reads the files of a socket-mode message.
+func slackMsgFiles(files []slack.File) []slackFile {
+	var out []slackFile
+	for _, f := range files {
+		u := strings.TrimSpace(firstNonEmpty(f.URLPrivateDownload, f.URLPrivate))
+		if u == "" {
+			continue
+		}
+		out = append(out, slackFile{ID: f.ID, Name: f.Name, MimeType: f.Mimetype, Size: f.Size, URL: u})
+	}
+	return out
+}
+
+// setInboundMedia adds media_urls with the parallel media_types and
+// media_names to an inbound payload.
+func setInboundMedia(payload map[string]any, media []inboundMedia) {
+	if len(media) == 0 {
+		return
+	}
+	urls := make([]string, 0, len(media))
+	types := make([]string, 0, len(media))
+	names := make([]string, 0, len(media))
+	for _, m := range media {
+		urls = append(urls, m.URL)
+		types = append(types, m.MimeType)
+		names = append(names, m.Name)
+	}
+	payload["media_urls"] = urls
+	payload["media_types"] = types
+	payload["media_names"] = names
+}
+
+// slackInboundMedia downloads the files of a Slack message with the bot
+// token of its workspace. Files that cannot be downloaded are left out:
+// their URLs need the token, so KafClaw could not fetch them either.
+func (b *bridge) slackInboundMedia(acct slackAccount, files []slackFile) []inboundMedia {
+	if !b.cfg.MediaDownload || len(files) == 0 {
+		return nil
+	}
+	var out []inboundMedia
+	for _, f := range files {
+		if max := b.mediaMaxBytes(); f.Size > 0 && int64(f.Size) > max {
+			slog.Warn("slack file too large to forward", "file_id", f.ID, "size", f.Size, "max_bytes", max)
+			continue
+		}
+		data, mimeType, err := b.fetchInboundMedia(f.URL, acct.BotToken, f.MimeType)
+		if err != nil {
+			slog.Warn("slack file download failed", "file_id", f.ID, "error", err)
+			continue
+		}
+		out = append(out, inboundMedia{URL: mediaDataURL(mimeType, data), MimeType: mimeType, Name: f.Name})
+	}
+	return out
+}
+
+// teamsInboundMedia downloads Teams attachments. The bot token is only sent
+// to the conversation's service URL and Bot Framework hosts; SharePoint and
+// OneDrive download URLs are pre-authenticated. Attachments that cannot be
+// downloaded are forwarded by URL as before.
+func (b *bridge) teamsInboundMedia(acct teamsAccount, serviceURL string, urls []string) []inboundMedia {
+	out := make([]inboundMedia, 0, len(urls))
+	for _, u := range urls {
+		m := inboundMedia{URL: u, Name: mediaNameFromURL(u)}
+		if b.cfg.MediaDownload {
+			token := ""
+			if teamsMediaNeedsToken(u, serviceURL) {
+				if t, err := b.getTeamsAccessToken(acct); err == nil {
+					token = t
+				}
+			}
+			if data, mimeType, err := b.fetchInboundMedia(u, token, ""); err != nil {
+				slog.Warn("teams attachment download failed", "host", hostOnly(u), "error", err)
+			} else {
+				m.URL, m.MimeType = mediaDataURL(mimeType, data), mimeType
+			}
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+func teamsMediaNeedsToken(rawURL, serviceURL string) bool {
+	host := hostOnly(rawURL)
+	if host == "" {
+		return false
+	}
+	if host == hostOnly(serviceURL) {
+		return true
+	}
+	return host == "botframework.com" || strings.HasSuffix(host, ".botframework.com")
+}
+
+func (b *bridge) mediaMaxBytes() int64 {
+	if b.cfg.MediaMaxBytes > 0 {
+		return b.cfg.MediaMaxBytes
+	}
+	return defaultMediaMaxBytes
+}
+
+// fetchInboundMedia fetches an attachment, with the token as bearer auth when
+// set. An HTML page in place of a non-HTML file is an error: Slack answers
+// with its login page when the app lacks the files:read scope.
+func (b *bridge) fetchInboundMedia(rawURL, token, wantType string) ([]byte, string, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, "", fmt.Errorf("download status %d", resp.StatusCode)
+	}
+	max := b.mediaMaxBytes()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, max+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(data)) > max {
+		return nil, "", fmt.Errorf("attachment exceeds %d bytes", max)
+	}
+	gotType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if gotType == "text/html" && !strings.HasPrefix(strings.ToLower(wantType), "text/html") {
+		return nil, "", errors.New("got an HTML page instead of the file; check the files:read scope")
+	}
+	mimeType := strings.TrimSpace(wantType)
+	if mimeType == "" {
+		mimeType = gotType
+	}
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	return data, mimeType, nil
+}
+
+func mediaDataURL(mimeType string, data []byte) string {
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+func mediaNameFromURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	name := path.Base(u.Path)
+	if name == "." || name == "/" {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSlackFileShareForwardsDownloadedMedia(t *testing.T) {
+	var got map[string]any
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/channels/slack/inbound" {
+			defer r.Body.Close()
+			_ = json.NewDecoder(r.Body).Decode(&got)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer api.Close()
+	files := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer xoxb-test" {
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<html>sign in</html>"))
+			return
+		}
+		_, _ = w.Write([]byte("voice-bytes"))
+	}))
+	defer files.Close()
+
+	b := newTestBridge(api.URL)
+	b.cfg.SlackBotToken = "xoxb-test"
+	b.cfg.MediaDownload = true
+	body, _ := json.Marshal(map[string]any{
+		"type":     "event_callback",
+		"event_id": "EvFile",
+		"event": map[string]any{
+			"type":         "message",
+			"subtype":      "file_share",
+			"channel":      "C777",
+			"channel_type": "channel",
+			"user":         "U777",
+			"ts":           "171.400",
+			"files": []map[string]any{
+				{"id": "F1", "name": "memo.ogg", "mimetype": "audio/ogg", "size": 11, "url_private_download": files.URL + "/F1/memo.ogg"},
+				{"id": "F2", "name": "huge.mov", "mimetype": "video/quicktime", "size": 1 << 30, "url_private_download": files.URL + "/F2/huge.mov"},
+			},
+		},
+	})
+	w := httptest.NewRecorder()
+	b.handleSlackEvents(w, httptest.NewRequest(http.MethodPost, "/slack/events", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	urls, _ := got["media_urls"].([]any)
+	types, _ := got["media_types"].([]any)
+	names, _ := got["media_names"].([]any)
+	want := "data:audio/ogg;base64," + base64.StdEncoding.EncodeToString([]byte("voice-bytes"))
+	if len(urls) != 1 || urls[0] != want {
+		t.Fatalf("expected the downloaded file inline and the oversized one left out, got %#v", got["media_urls"])
+	}
+	if len(types) != 1 || types[0] != "audio/ogg" || len(names) != 1 || names[0] != "memo.ogg" {
+		t.Fatalf("expected the MIME type and name, got %#v %#v", got["media_types"], got["media_names"])
+	}
+}
+
+func TestSlackFileDownloadRejectsLoginPage(t *testing.T) {
+	files := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte("<html>sign in</html>"))
+	}))
+	defer files.Close()
+
+	b := newTestBridge("http://example.invalid")
+	b.cfg.MediaDownload = true
+	media := b.slackInboundMedia(slackAccount{BotToken: "xoxb-test"}, []slackFile{{ID: "F1", MimeType: "application/pdf", URL: files.URL + "/F1/doc.pdf"}})
+	if len(media) != 0 {
+		t.Fatalf("expected the login page dropped, got %#v", media)
+	}
+}
+
+func TestTeamsAttachmentFallsBackToURLWhenDownloadFails(t *testing.T) {
+	files := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing.pdf" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("expected no bot token sent to a file host, got %q", r.Header.Get("Authorization"))
+		}
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte("png"))
+	}))
+	defer files.Close()
+
+	b := newTestBridge("http://example.invalid")
+	b.cfg.MediaDownload = true
+	media := b.teamsInboundMedia(teamsAccount{}, "https://smba.trafficmanager.net/emea", []string{files.URL + "/image.png", files.URL + "/missing.pdf"})
+	if len(media) != 2 {
+		t.Fatalf("expected both attachments, got %#v", media)
+	}
+	if media[0].MimeType != "image/png" || media[0].Name != "image.png" || media[0].URL != "data:image/png;base64,"+base64.StdEncoding.EncodeToString([]byte("png")) {
+		t.Fatalf("expected the image inline, got %#v", media[0])
+	}
+	if media[1].URL != files.URL+"/missing.pdf" {
+		t.Fatalf("expected the original URL of a failed download, got %#v", media[1])
+	}
+	if !teamsMediaNeedsToken("https://smba.trafficmanager.net/emea/v3/attachments/a1", "https://smba.trafficmanager.net/emea") || teamsMediaNeedsToken("https://contoso.sharepoint.com/x", "https://smba.trafficmanager.net/emea") {
+		t.Fatal("expected the bot token only for the service URL host")
+	}
+}
@@ -0,0 +1,488 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultOutboundQueueMaxAgeSec = 1800
+	defaultOutboundQueueMax       = 1000
+	defaultOutboundMaxAttempts    = 10
+	defaultOutboundRetryBaseSec   = 2
+	defaultOutboundRetryMaxSec    = 300
+	outboundQueueTick             = time.Second
+	outboundDeadLetterMax         = 200
+
+	// outboundRetryableHeader marks a 502 of an outbound handler whose send
+	// failed transiently before anything reached the platform. It never
+	// leaves the bridge.
+	outboundRetryableHeader = "X-Channelbridge-Retryable"
+)
+
+// transientError is a platform failure that outlasted the inline retries of
+// withRetry (rate limit, 5xx, network error).
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string { return e.err.Error() }
+func (e *transientError) Unwrap() error { return e.err }
+
+// writeOutboundError answers a failed send with 502. A transient failure is
+// flagged for the outbound queue unless part of the reply was already sent,
+// since a replay starts from the beginning.
+func writeOutboundError(w http.ResponseWriter, err error, sent bool) {
+	var te *transientError
+	if !sent && errors.As(err, &te) {
+		w.Header().Set(outboundRetryableHeader, "true")
+	}
+	http.Error(w, err.Error(), http.StatusBadGateway)
+}
+
+// queuedOutbound is an outbound request that failed transiently. It is kept
+// in the state file and replayed through the channel's outbound handler
+// until it is delivered or moved to the dead letters.
+type queuedOutbound struct {
+	ID        string          `json:"id"`
+	Channel   string          `json:"channel"` // slack|msteams|discord|telegram
+	AccountID string          `json:"account_id,omitempty"`
+	ChatID    string          `json:"chat_id"`
+	Body      json.RawMessage `json:"body,omitempty"`
+	// TaskID and TraceID name the gateway task of the reply, so a dead
+	// letter can be reported back as a failed delivery.
+	TaskID  string `json:"task_id,omitempty"`
+	TraceID string `json:"trace_id,omitempty"`
+	// Traceparent is the caller's header, so every replay joins its trace.
+	Traceparent string    `json:"traceparent,omitempty"`
+	QueuedAt    time.Time `json:"queued_at"`
+	Attempts    int       `json:"attempts"`
+	NextAt      time.Time `json:"next_at"`
+	LastError   string    `json:"last_error,omitempty"`
+	// DeadAt is set once the request is given up and kept as a dead letter.
+	DeadAt *time.Time `json:"dead_at,omitempty"`
+}
+
+// outboundQueueMaxAge reads CHANNEL_BRIDGE_OUTBOUND_QUEUE (default on) and
+// CHANNEL_BRIDGE_OUTBOUND_QUEUE_MAX_AGE_SEC; it returns 0 when the queue is
+// off.
+func outboundQueueMaxAge() time.Duration {
+	if !parseBoolDefault("CHANNEL_BRIDGE_OUTBOUND_QUEUE", true) {
+		return 0
+	}
+	return time.Duration(parseIntDefault("CHANNEL_BRIDGE_OUTBOUND_QUEUE_MAX_AGE_SEC", defaultOutboundQueueMaxAgeSec)) * time.Second
+}
+
+func (b *bridge) outboundQueueEnabled() bool {
+	return b.cfg.OutboundQueueMaxAge > 0
+}
+
+func (b *bridge) outboundHandler(channel string) http.HandlerFunc {
+	switch channel {
+	case "msteams":
+		return b.handleTeamsOutbound
+	case "discord":
+		return b.handleDiscordOutbound
+	case "telegram":
+		return b.handleTelegramOutbound
+	}
+	return b.handleSlackOutbound
+}
+
+// queueOutbound wraps the outbound handler of a channel. A plain send that
+// fails transiently is queued and answered with 202 instead of 502; sends
+// to a chat that already has queued requests queue behind them, so each
+// chat keeps its order. Actions (typing, reactions, edits, history reads)
+// are never queued.
+func (b *bridge) queueOutbound(channel string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		next := b.outboundHandler(channel)
+		if !b.outboundQueueEnabled() || r.Method != http.MethodPost {
+			next(w, r)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "read body failed", http.StatusBadRequest)
+			return
+		}
+		var meta struct {
+			AccountID string `json:"account_id"`
+			ChatID    string `json:"chat_id"`
+			Action    string `json:"action"`
+			TaskID    string `json:"task_id"`
+			TraceID   string `json:"trace_id"`
+		}
+		queueable := json.Unmarshal(body, &meta) == nil &&
+			strings.TrimSpace(meta.ChatID) != "" && strings.TrimSpace(meta.Action) == ""
+		item := queuedOutbound{
+			ID:          randomHex(8),
+			Channel:     channel,
+			AccountID:   strings.TrimSpace(meta.AccountID),
+			ChatID:      strings.TrimSpace(meta.ChatID),
+			Body:        body,
+			TaskID:      strings.TrimSpace(meta.TaskID),
+			TraceID:     strings.TrimSpace(meta.TraceID),
+			Traceparent: r.Header.Get(traceparentHeader),
+		}
+		if queueable && b.outboundChatQueued(channel, item.ChatID) {
+			b.answerOutboundQueued(w, item, "chat has queued sends")
+			return
+		}
+		rec := &outboundRecorder{header: http.Header{}}
+		replay := r.Clone(r.Context())
+		replay.Body = io.NopCloser(bytes.NewReader(body))
+		next(rec, replay)
+		if queueable && rec.retryable() {
+			b.answerOutboundQueued(w, item, rec.errorText())
+			return
+		}
+		rec.header.Del(outboundRetryableHeader)
+		for k, v := range rec.header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(rec.status())
+		_, _ = w.Write(rec.body.Bytes())
+	}
+}
+
+func (b *bridge) answerOutboundQueued(w http.ResponseWriter, item queuedOutbound, reason string) {
+	if err := b.enqueueOutbound(item, reason); err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "queued": true, "queue_id": item.ID})
+}
+
+// outboundRecorder captures a handler response so the wrapper can decide
+// whether to pass it on or queue the request.
+type outboundRecorder struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func (r *outboundRecorder) Header() http.Header { return r.header }
+
+func (r *outboundRecorder) WriteHeader(code int) {
+	if r.code == 0 {
+		r.code = code
+	}
+}
+
+func (r *outboundRecorder) Write(p []byte) (int, error) {
+	if r.code == 0 {
+		r.code = http.StatusOK
+	}
+	return r.body.Write(p)
+}
+
+func (r *outboundRecorder) status() int {
+	if r.code == 0 {
+		return http.StatusOK
+	}
+	return r.code
+}
+
+func (r *outboundRecorder) retryable() bool {
+	return r.status() == http.StatusBadGateway && r.header.Get(outboundRetryableHeader) != ""
+}
+
+func (r *outboundRecorder) errorText() string {
+	return strings.TrimSpace(r.body.String())
+}
+
+func (b *bridge) outboundChatQueued(channel, chatID string) bool {
+	b.outboundMu.Lock()
+	defer b.outboundMu.Unlock()
+	for _, q := range b.outboundQueue {
+		if q.Channel == channel && q.ChatID == chatID {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *bridge) enqueueOutbound(item queuedOutbound, reason string) error {
+	now := time.Now().UTC()
+	item.QueuedAt, item.LastError = now, reason
+	item.NextAt = now.Add(b.outboundBackoff(1))
+	max := b.cfg.OutboundQueueMax
+	if max <= 0 {
+		max = defaultOutboundQueueMax
+	}
+	b.outboundMu.Lock()
+	if len(b.outboundQueue) >= max {
+		b.outboundMu.Unlock()
+		return fmt.Errorf("outbound queue full (%d sends): %s", max, reason)
+	}
+	b.outboundQueue = append(b.outboundQueue, item)
+	b.outboundMu.Unlock()
+	b.noteOutboundQueue(func(m *bridgeMetrics) { m.OutboundQueued++ })
+	slog.Warn("outbound queued for retry", "channel", item.Channel, "chat", item.ChatID, "reason", reason)
+	_ = b.saveState()
+	return nil
+}
+
+// outboundBackoff is the delay before the given attempt: the base delay
+// doubled per earlier attempt, capped at the maximum.
+func (b *bridge) outboundBackoff(attempt int) time.Duration {
+	base, limit := b.cfg.OutboundRetryBase, b.cfg.OutboundRetryMax
+	if base <= 0 {
+		base = defaultOutboundRetryBaseSec * time.Second
+	}
+	if limit <= 0 {
+		limit = defaultOutboundRetryMaxSec * time.Second
+	}
+	delay := base << min(max(attempt-1, 0), 16)
+	if delay > limit || delay <= 0 {
+		delay = limit
+	}
+	return delay
+}
+
+// startOutboundQueue replays queued outbound requests in the background.
+func (b *bridge) startOutboundQueue() {
+	if !b.outboundQueueEnabled() {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(outboundQueueTick)
+		defer ticker.Stop()
+		for range ticker.C {
+			done := b.trackInflight()
+			if b.closing.Load() {
+				done()
+				return
+			}
+			b.drainOutboundQueue(time.Now())
+			done()
+		}
+	}()
+}
+
+// drainOutboundQueue replays the due head request of every chat. A
+// delivered head is followed by the next request of its chat right away; a
+// transient failure backs off and holds its chat. Requests that fail for
+// good, run out of attempts or are older than the maximum age become dead
+// letters.
+func (b *bridge) drainOutboundQueue(now time.Time) {
+	if b.outboundQueueDepth() == 0 {
+		return
+	}
+	changed := false
+	done := map[string]bool{}
+	for {
+		item, ok := b.nextQueuedOutbound(now, done)
+		if !ok {
+			break
+		}
+		changed = true
+		key := item.Channel + "\x00" + item.ChatID
+		if now.Sub(item.QueuedAt) > b.cfg.OutboundQueueMaxAge {
+			b.deadLetterOutbound(item.ID, now, "expired: "+item.LastError)
+			continue
+		}
+		rec := b.replayOutbound(item)
+		attempts := item.Attempts + 1
+		switch {
+		case rec.status() < 300:
+			b.removeQueuedOutbound(item.ID)
+			b.noteOutboundQueue(func(m *bridgeMetrics) { m.OutboundQueueDelivered++ })
+			slog.Info("outbound delivered from queue", "channel", item.Channel, "chat", item.ChatID, "attempts", attempts)
+		case rec.retryable() && attempts < b.outboundMaxAttempts():
+			b.backoffQueuedOutbound(item.ID, now, rec.errorText())
+			done[key] = true
+		default:
+			b.deadLetterOutbound(item.ID, now, fmt.Sprintf("status=%d: %s", rec.status(), rec.errorText()))
+			done[key] = true
+		}
+	}
+	if changed {
+		_ = b.saveState()
+	}
+}
+
+func (b *bridge) replayOutbound(item queuedOutbound) *outboundRecorder {
+	rec := &outboundRecorder{header: http.Header{}}
+	req, err := http.NewRequest(http.MethodPost, "/"+item.Channel+"/outbound", bytes.NewReader(item.Body))
+	if err != nil {
+		rec.code = http.StatusBadRequest
+		rec.body.WriteString(err.Error())
+		return rec
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if item.Traceparent != "" {
+		req.Header.Set(traceparentHeader, item.Traceparent)
+	}
+	b.outboundHandler(item.Channel)(rec, req)
+	return rec
+}
+
+func (b *bridge) outboundMaxAttempts() int {
+	if b.cfg.OutboundMaxAttempts <= 0 {
+		return defaultOutboundMaxAttempts
+	}
+	return b.cfg.OutboundMaxAttempts
+}
+
+// nextQueuedOutbound returns the first due request whose chat has no
+// earlier request and is not in done.
+func (b *bridge) nextQueuedOutbound(now time.Time, done map[string]bool) (queuedOutbound, bool) {
+	b.outboundMu.Lock()
+	defer b.outboundMu.Unlock()
+	seen := map[string]bool{}
+	for _, q := range b.outboundQueue {
+		key := q.Channel + "\x00" + q.ChatID
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if !done[key] && !q.NextAt.After(now) {
+			return q, true
+		}
+	}
+	return queuedOutbound{}, false
+}
+
+func (b *bridge) removeQueuedOutbound(id string) (queuedOutbound, bool) {
+	b.outboundMu.Lock()
+	defer b.outboundMu.Unlock()
+	for i, q := range b.outboundQueue {
+		if q.ID == id {
+			b.outboundQueue = append(b.outboundQueue[:i], b.outboundQueue[i+1:]...)
+			return q, true
+		}
+	}
+	return queuedOutbound{}, false
+}
+
+func (b *bridge) backoffQueuedOutbound(id string, now time.Time, reason string) {
+	b.outboundMu.Lock()
+	defer b.outboundMu.Unlock()
+	for i := range b.outboundQueue {
+		q := &b.outboundQueue[i]
+		if q.ID != id {
+			continue
+		}
+		q.Attempts++
+		q.NextAt = now.Add(b.outboundBackoff(q.Attempts + 1))
+		q.LastError = reason
+		return
+	}
+}
+
+// deadLetterOutbound moves a queued request to the dead letters, which keep
+// the newest outboundDeadLetterMax entries for inspection. The sender was
+// answered 202 when the request was queued, so a request of a gateway task
+// is reported back as a failed delivery.
+func (b *bridge) deadLetterOutbound(id string, now time.Time, reason string) {
+	q, ok := b.removeQueuedOutbound(id)
+	if !ok {
+		return
+	}
+	deadAt := now.UTC()
+	q.Attempts++
+	q.LastError = reason
+	q.DeadAt = &deadAt
+	b.outboundMu.Lock()
+	b.outboundDead = append(b.outboundDead, q)
+	if over := len(b.outboundDead) - outboundDeadLetterMax; over > 0 {
+		b.outboundDead = append([]queuedOutbound(nil), b.outboundDead[over:]...)
+	}
+	b.outboundMu.Unlock()
+	b.noteOutboundQueue(func(m *bridgeMetrics) { m.OutboundDeadLettered++ })
+	slog.Error("outbound dead-lettered", "channel", q.Channel, "chat", q.ChatID, "attempts", q.Attempts, "reason", reason)
+	b.postDeliveryFailure(q)
+}
+
+// postDeliveryFailure reports a dead-lettered request to the gateway's
+// delivery endpoint with status "failed", which marks its task's delivery
+// as failed.
+func (b *bridge) postDeliveryFailure(q queuedOutbound) {
+	if q.TaskID == "" {
+		return
+	}
+	payload := map[string]any{
+		"status":     "failed",
+		"account_id": q.AccountID,
+		"chat_id":    q.ChatID,
+		"task_id":    q.TaskID,
+		"trace_id":   q.TraceID,
+		"error":      q.LastError,
+	}
+	token := b.inboundToken(q.Channel, q.AccountID)
+	done := b.trackInflight()
+	go func() {
+		defer done()
+		if err := b.postInboundTrace("/api/v1/channels/"+q.Channel+"/delivery", token, traceparentFor(q.TraceID), payload); err != nil {
+			slog.Warn("delivery failure report failed", "channel", q.Channel, "chat", q.ChatID, "task", q.TaskID, "error", err)
+		}
+	}()
+}
+
+func (b *bridge) outboundQueueDepth() int {
+	b.outboundMu.Lock()
+	defer b.outboundMu.Unlock()
+	return len(b.outboundQueue)
+}
+
+// outboundQueueStatus summarizes the queue for /status.
+func (b *bridge) outboundQueueStatus() map[string]any {
+	b.outboundMu.Lock()
+	defer b.outboundMu.Unlock()
+	st := map[string]any{
+		"enabled":      b.outboundQueueEnabled(),
+		"depth":        len(b.outboundQueue),
+		"dead_letters": len(b.outboundDead),
+		"max_age_sec":  int(b.cfg.OutboundQueueMaxAge / time.Second),
+		"max_attempts": b.outboundMaxAttempts(),
+	}
+	if len(b.outboundQueue) > 0 {
+		st["oldest_queued_at"] = b.outboundQueue[0].QueuedAt.Format(time.RFC3339)
+	}
+	return st
+}
+
+// handleOutboundQueue lists pending and dead-lettered outbound requests.
+// Request bodies are left out; they can carry whole replies and media.
+func (b *bridge) handleOutboundQueue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	b.outboundMu.Lock()
+	pending := outboundQueueView(b.outboundQueue)
+	dead := outboundQueueView(b.outboundDead)
+	b.outboundMu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"ok":           true,
+		"pending":      pending,
+		"dead_letters": dead,
+	})
+}
+
+func outboundQueueView(items []queuedOutbound) []queuedOutbound {
+	out := make([]queuedOutbound, 0, len(items))
+	for _, q := range items {
+		q.Body = nil
+		out = append(out, q)
+	}
+	return out
+}
+
+func (b *bridge) noteOutboundQueue(update func(*bridgeMetrics)) {
+	b.metricsMu.Lock()
+	defer b.metricsMu.Unlock()
+	update(&b.metrics)
+}
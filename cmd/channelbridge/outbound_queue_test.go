@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOutboundQueueRetriesSendsInOrder(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		down  = true
+		posts []string
+	)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		posts = append(posts, body["content"].(string))
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": "msg-1"})
+	}))
+	defer api.Close()
+
+	b := newTestBridge(api.URL)
+	b.cfg.DiscordBotToken = "bot-token"
+	b.cfg.DiscordAPIBase = api.URL
+	b.cfg.OutboundQueueMaxAge = time.Hour
+	b.cfg.StatePath = filepath.Join(t.TempDir(), "state.json")
+	send := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		b.queueOutbound("discord")(rec, httptest.NewRequest(http.MethodPost, "/discord/outbound", strings.NewReader(body)))
+		return rec
+	}
+
+	for _, content := range []string{"first", "second"} {
+		rec := send(`{"chat_id":"c1","content":"` + content + `"}`)
+		if rec.Code != http.StatusAccepted || !strings.Contains(rec.Body.String(), `"queued":true`) {
+			t.Fatalf("expected %s to be queued, got %d: %s", content, rec.Code, rec.Body.String())
+		}
+	}
+	// Actions are answered directly, never queued.
+	if rec := send(`{"chat_id":"c1","action":"typing"}`); rec.Code != http.StatusBadGateway || rec.Header().Get(outboundRetryableHeader) != "" {
+		t.Fatalf("expected typing to fail directly, got %d", rec.Code)
+	}
+	if b.outboundQueueDepth() != 2 {
+		t.Fatalf("expected two queued sends, got %d", b.outboundQueueDepth())
+	}
+
+	// The queue survives a restart.
+	restored := newTestBridge(api.URL)
+	restored.cfg = b.cfg
+	if err := restored.loadState(); err != nil || restored.outboundQueueDepth() != 2 {
+		t.Fatalf("expected queue restored from state, depth=%d err=%v", restored.outboundQueueDepth(), err)
+	}
+
+	mu.Lock()
+	down = false
+	mu.Unlock()
+	b.drainOutboundQueue(time.Now().Add(time.Minute))
+	if b.outboundQueueDepth() != 0 || len(posts) != 2 || posts[0] != "first" || posts[1] != "second" {
+		t.Fatalf("expected both sends delivered in order, depth=%d posts=%v", b.outboundQueueDepth(), posts)
+	}
+	if b.metrics.OutboundQueued != 2 || b.metrics.OutboundQueueDelivered != 2 {
+		t.Fatalf("unexpected queue metrics: %+v", b.metrics)
+	}
+}
+
+func TestOutboundQueueDeadLettersAfterMaxAttempts(t *testing.T) {
+	var mu sync.Mutex
+	var report map[string]any
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/channels/discord/delivery" {
+			mu.Lock()
+			_ = json.NewDecoder(r.Body).Decode(&report)
+			mu.Unlock()
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer api.Close()
+
+	b := newTestBridge(api.URL)
+	b.cfg.DiscordBotToken = "bot-token"
+	b.cfg.DiscordAPIBase = api.URL
+	b.cfg.OutboundQueueMaxAge = time.Hour
+	b.cfg.OutboundMaxAttempts = 2
+
+	rec := httptest.NewRecorder()
+	b.queueOutbound("discord")(rec, httptest.NewRequest(http.MethodPost, "/discord/outbound", strings.NewReader(`{"chat_id":"c1","content":"hi","task_id":"t1","trace_id":"tr1"}`)))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	b.drainOutboundQueue(time.Now().Add(time.Minute))
+	if b.outboundQueueDepth() != 1 || b.outboundQueue[0].Attempts != 1 {
+		t.Fatalf("expected the send to back off after one retry, queue=%v", b.outboundQueue)
+	}
+	b.drainOutboundQueue(time.Now().Add(2 * time.Minute))
+	if b.outboundQueueDepth() != 0 || len(b.outboundDead) != 1 || b.outboundDead[0].Attempts != 2 {
+		t.Fatalf("expected one dead letter after two attempts, queue=%v dead=%+v", b.outboundQueue, b.outboundDead)
+	}
+
+	list := httptest.NewRecorder()
+	b.handleOutboundQueue(list, httptest.NewRequest(http.MethodGet, "/outbound/queue", nil))
+	var view struct {
+		Pending []queuedOutbound `json:"pending"`
+		Dead    []queuedOutbound `json:"dead_letters"`
+	}
+	_ = json.Unmarshal(list.Body.Bytes(), &view)
+	if len(view.Pending) != 0 || len(view.Dead) != 1 || view.Dead[0].ChatID != "c1" || view.Dead[0].Body != nil || view.Dead[0].DeadAt == nil {
+		t.Fatalf("unexpected queue listing: %s", list.Body.String())
+	}
+	if b.metrics.OutboundDeadLettered != 1 {
+		t.Fatalf("expected dead-letter metric, got %+v", b.metrics)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	b.waitInflight(ctx)
+	mu.Lock()
+	defer mu.Unlock()
+	if report["status"] != "failed" || report["task_id"] != "t1" || report["chat_id"] != "c1" || report["error"] == "" {
+		t.Fatalf("expected a failed delivery report, got %v", report)
+	}
+}
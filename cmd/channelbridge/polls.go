@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// errPollClosed is returned when closing a poll that is already closed.
+var errPollClosed = errors.New("poll already closed")
+
+// pollView is a poll as returned by the /polls endpoints. Percent is the
+// share of voters that picked an option.
+type pollView struct {
+	ID            string       `json:"id"`
+	Channel       string       `json:"channel"`
+	AccountID     string       `json:"account_id,omitempty"`
+	ChatID        string       `json:"chat_id"`
+	Question      string       `json:"question"`
+	Options       []pollOption `json:"options"`
+	MaxSelections int          `json:"max_selections"`
+	Voters        int          `json:"voters"`
+	TotalVotes    int          `json:"total_votes"`
+	Closed        bool         `json:"closed"`
+	CreatedAt     string       `json:"created_at,omitempty"`
+	ClosedAt      string       `json:"closed_at,omitempty"`
+}
+
+type pollOption struct {
+	Value   string `json:"value"`
+	Label   string `json:"label"`
+	Votes   int    `json:"votes"`
+	Percent int    `json:"percent"`
+}
+
+// newPollView tallies a stored poll. Polls recorded before Slack polls
+// existed have no channel and are Teams polls.
+func newPollView(id string, p map[string]any) pollView {
+	v := pollView{
+		ID:            id,
+		Channel:       firstNonEmpty(asString(p["channel"]), "msteams"),
+		AccountID:     strings.TrimSpace(asString(p["account_id"])),
+		ChatID:        strings.TrimSpace(asString(p["chat_id"])),
+		Question:      asString(p["question"]),
+		MaxSelections: intFromAny(p["max_selections"], 1),
+		CreatedAt:     asString(p["created_at_rfc3339"]),
+		ClosedAt:      asString(p["closed_at_rfc3339"]),
+	}
+	v.Closed, _ = p["closed"].(bool)
+	var labels []string
+	switch opts := p["options"].(type) {
+	case []string:
+		labels = opts
+	case []any:
+		for _, o := range opts {
+			labels = append(labels, asString(o))
+		}
+	}
+	counts := map[string]int{}
+	votes, _ := p["votes"].(map[string]any)
+	for _, raw := range votes {
+		picked := normalizePollSelections(raw)
+		if len(picked) == 0 {
+			continue
+		}
+		v.Voters++
+		for _, one := range picked {
+			counts[one]++
+			v.TotalVotes++
+		}
+	}
+	for i, label := range labels {
+		if strings.TrimSpace(label) == "" {
+			continue
+		}
+		value := fmt.Sprintf("opt_%d", i+1)
+		opt := pollOption{Value: value, Label: strings.TrimSpace(label), Votes: counts[value]}
+		if v.Voters > 0 {
+			opt.Percent = opt.Votes * 100 / v.Voters
+		}
+		v.Options = append(v.Options, opt)
+	}
+	return v
+}
+
+// pollSnapshot returns a copy of a poll, merged with the shared state so
+// votes taken on other replicas count.
+func (b *bridge) pollSnapshot(id string) (map[string]any, bool) {
+	shared, _ := b.sharedTeamsPoll(id)
+	b.pollMu.Lock()
+	defer b.pollMu.Unlock()
+	if shared != nil {
+		if b.teamsPolls == nil {
+			b.teamsPolls = map[string]map[string]any{}
+		}
+		b.teamsPolls[id] = shared
+	}
+	p, ok := b.teamsPolls[id]
+	if !ok {
+		return nil, false
+	}
+	return cloneTeamsPoll(p), true
+}
+
+// handlePolls answers GET /polls: the polls of the bridge, newest first,
+// optionally filtered by channel and chat_id.
+func (b *bridge) handlePolls(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	channel := strings.TrimSpace(r.URL.Query().Get("channel"))
+	chatID := strings.TrimSpace(r.URL.Query().Get("chat_id"))
+	b.pollMu.Lock()
+	polls := make([]pollView, 0, len(b.teamsPolls))
+	for id, p := range b.teamsPolls {
+		v := newPollView(id, p)
+		if (channel != "" && v.Channel != channel) || (chatID != "" && v.ChatID != chatID) {
+			continue
+		}
+		polls = append(polls, v)
+	}
+	b.pollMu.Unlock()
+	sort.Slice(polls, func(i, j int) bool {
+		if polls[i].CreatedAt != polls[j].CreatedAt {
+			return polls[i].CreatedAt > polls[j].CreatedAt
+		}
+		return polls[i].ID > polls[j].ID
+	})
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "polls": polls})
+}
+
+// handlePoll answers GET /polls/<id>/results and POST /polls/<id>/close.
+// The ID is path-escaped; a chat_id query parameter must match the poll's
+// chat.
+func (b *bridge) handlePoll(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.EscapedPath(), "/polls/")
+	escapedID, op, ok := cutLast(rest, "/")
+	id, err := url.PathUnescape(escapedID)
+	if !ok || err != nil || strings.TrimSpace(id) == "" {
+		http.NotFound(w, r)
+		return
+	}
+	wantMethod := map[string]string{"results": http.MethodGet, "close": http.MethodPost}[op]
+	if wantMethod == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != wantMethod {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	p, found := b.pollSnapshot(id)
+	chatID := strings.TrimSpace(r.URL.Query().Get("chat_id"))
+	if !found || (chatID != "" && strings.TrimSpace(asString(p["chat_id"])) != chatID) {
+		http.Error(w, "poll not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if op == "results" {
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "poll": newPollView(id, p)})
+		return
+	}
+	view, err := b.closePoll(id)
+	if errors.Is(err, errPollClosed) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	messageID, err := b.postPollResults(view)
+	if err != nil {
+		// The poll stays closed; only the results message is missing.
+		w.WriteHeader(http.StatusBadGateway)
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": err.Error(), "poll": view})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "poll": view, "message_id": messageID})
+}
+
+// closePoll marks a poll closed, so later votes are not counted.
+func (b *bridge) closePoll(id string) (pollView, error) {
+	b.pollMu.Lock()
+	p, ok := b.teamsPolls[id]
+	if !ok {
+		b.pollMu.Unlock()
+		return pollView{}, errors.New("poll not found")
+	}
+	if closed, _ := p["closed"].(bool); closed {
+		b.pollMu.Unlock()
+		return pollView{}, errPollClosed
+	}
+	p["closed"] = true
+	p["closed_at_rfc3339"] = time.Now().UTC().Format(time.RFC3339)
+	stored := cloneTeamsPoll(p)
+	b.pollMu.Unlock()
+	b.storeTeamsPoll(id, stored)
+	_ = b.saveState()
+	return newPollView(id, stored), nil
+}
+
+// postPollResults posts the final tallies of a poll to its chat and returns
+// the message ID.
+func (b *bridge) postPollResults(v pollView) (string, error) {
+	switch v.Channel {
+	case "slack":
+		acct := b.slackChannelAccount(b.slackAccount(v.AccountID), v.ChatID)
+		return b.slackPostCard(acct, v.ChatID, "", "", buildSlackPollResultsCard(v, b.text(acct.WorkspaceID, msgPollClosed, v.Question), b.text(acct.WorkspaceID, msgPollVoters, v.Voters)))
+	default:
+		ref, err := b.resolveTeamsConversation(v.ChatID)
+		if err != nil {
+			return "", err
+		}
+		token, err := b.getTeamsAccessToken(b.teamsAccountForRef(v.AccountID, ref))
+		if err != nil {
+			return "", err
+		}
+		card := buildTeamsPollResultsCard(v, b.text(ref.TenantID, msgPollClosed, v.Question), b.text(ref.TenantID, msgPollVoters, v.Voters))
+		return b.teamsSend(ref, token, "", "", nil, card)
+	}
+}
+
+func pollResultLine(o pollOption) string {
+	return fmt.Sprintf("%d (%d%%)", o.Votes, o.Percent)
+}
+
+func buildSlackPollResultsCard(v pollView, title, voters string) map[string]any {
+	lines := make([]string, 0, len(v.Options))
+	for _, o := range v.Options {
+		lines = append(lines, "• "+o.Label+": "+pollResultLine(o))
+	}
+	blocks := []map[string]any{
+		{"type": "section", "text": map[string]any{"type": "mrkdwn", "text": "*" + title + "*"}},
+	}
+	if len(lines) > 0 {
+		blocks = append(blocks, map[string]any{"type": "section", "text": map[string]any{"type": "mrkdwn", "text": strings.Join(lines, "\n")}})
+	}
+	blocks = append(blocks, map[string]any{"type": "context", "elements": []map[string]any{{"type": "mrkdwn", "text": voters}}})
+	return map[string]any{"text": title, "blocks": blocks}
+}
+
+func buildTeamsPollResultsCard(v pollView, title, voters string) map[string]any {
+	facts := make([]map[string]any, 0, len(v.Options))
+	for _, o := range v.Options {
+		facts = append(facts, map[string]any{"title": o.Label, "value": pollResultLine(o)})
+	}
+	return map[string]any{
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body": []map[string]any{
+			{"type": "TextBlock", "text": title, "weight": "Bolder", "wrap": true},
+			{"type": "FactSet", "facts": facts},
+			{"type": "TextBlock", "text": voters, "isSubtle": true, "wrap": true},
+		},
+	}
+}
+
+// cutLast slices s around the last instance of sep.
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
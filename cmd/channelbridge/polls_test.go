@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestPollResultsAndClose(t *testing.T) {
+	var posted string
+	slackAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/chat.postMessage" {
+			_ = r.ParseForm()
+			posted = r.FormValue("blocks")
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "ts": "500.1"})
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer slackAPI.Close()
+
+	b := newTestBridge("http://example.invalid")
+	b.cfg.SlackAPIBase = slackAPI.URL
+	b.cfg.SlackBotToken = "xoxb-test"
+	id := b.recordPoll("slack", "default", "C1", "Lunch?", []string{"Sushi", "Pizza"}, 1)
+	b.recordPoll("msteams", "default", "19:x@thread.tacv2", "Other?", []string{"A", "B"}, 1)
+	b.recordPollVote(id, "C1", "U1", []string{"opt_1"})
+	b.recordPollVote(id, "C1", "U2", []string{"opt_1"})
+	b.recordPollVote(id, "C1", "U3", []string{"opt_2"})
+
+	do := func(method, target string) (*httptest.ResponseRecorder, map[string]any) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, target, nil)
+		if strings.HasPrefix(r.URL.Path, "/polls/") {
+			b.handlePoll(w, r)
+		} else {
+			b.handlePolls(w, r)
+		}
+		var out map[string]any
+		_ = json.Unmarshal(w.Body.Bytes(), &out)
+		return w, out
+	}
+
+	_, out := do(http.MethodGet, "/polls?channel=slack")
+	if polls, _ := out["polls"].([]any); len(polls) != 1 {
+		t.Fatalf("expected the Slack poll only, got %#v", out["polls"])
+	}
+	pollPath := "/polls/" + url.PathEscape(id)
+	if w, _ := do(http.MethodGet, pollPath+"/results?chat_id=C2"); w.Code != http.StatusNotFound {
+		t.Fatalf("expected another chat's poll hidden, got %d", w.Code)
+	}
+	_, out = do(http.MethodGet, pollPath+"/results")
+	poll, _ := out["poll"].(map[string]any)
+	options, _ := poll["options"].([]any)
+	first, _ := options[0].(map[string]any)
+	if poll["voters"] != float64(3) || first["label"] != "Sushi" || first["votes"] != float64(2) || first["percent"] != float64(66) {
+		t.Fatalf("unexpected results %#v", poll)
+	}
+
+	w, out := do(http.MethodPost, pollPath+"/close")
+	if w.Code != http.StatusOK || out["message_id"] != "500.1" {
+		t.Fatalf("close status=%d body=%s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(posted, "Poll closed: Lunch?") || !strings.Contains(posted, "Sushi: 2 (66%)") {
+		t.Fatalf("expected the results posted, got %s", posted)
+	}
+	if ok, details := b.recordPollVote(id, "C1", "U4", []string{"opt_2"}); !ok || details["closed"] != true {
+		t.Fatalf("expected a late vote refused, got %v %#v", ok, details)
+	}
+	if _, out = do(http.MethodGet, pollPath+"/results"); out["poll"].(map[string]any)["voters"] != float64(3) {
+		t.Fatalf("expected the late vote not counted, got %#v", out["poll"])
+	}
+	if w, _ := do(http.MethodPost, pollPath+"/close"); w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 on a second close, got %d", w.Code)
+	}
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack/socketmode"
+)
+
+// Dependencies reported by /readyz.
+const (
+	readyDepSlack       = "slack"
+	readyDepSlackSocket = "slack_socket"
+	readyDepTeams       = "teams"
+	readyDepDiscord     = "discord"
+	readyDepDiscordGW   = "discord_gateway"
+	readyDepTelegram    = "telegram"
+	readyDepKafclaw     = "kafclaw"
+)
+
+const (
+	defaultReadyCacheSec = 30
+	readyProbeTimeout    = 5 * time.Second
+)
+
+// dependencyHealth is the /readyz result of one dependency.
+type dependencyHealth struct {
+	Name      string `json:"name"`
+	OK        bool   `json:"ok"`
+	Required  bool   `json:"required"`
+	Detail    string `json:"detail,omitempty"`
+	Error     string `json:"error,omitempty"`
+	LatencyMS int64  `json:"latency_ms,omitempty"`
+	CheckedAt string `json:"checked_at"`
+	Cached    bool   `json:"cached,omitempty"`
+}
+
+type cachedDependency struct {
+	health dependencyHealth
+	at     time.Time
+}
+
+// socketState tracks a long-lived platform connection: Slack Socket Mode
+// from its client events, the Discord gateway from its session events.
+type socketState struct {
+	state string // connecting, connected, disconnected, stopped
+	err   string
+	since time.Time
+}
+
+// set records a state change; repeats of the current state keep its since.
+func (s *socketState) set(state string, err error) {
+	msg := ""
+	if err != nil {
+		msg = err.Error()
+	}
+	if s.state == state && s.err == msg {
+		return
+	}
+	*s = socketState{state: state, err: msg, since: time.Now().UTC()}
+}
+
+// parseReadyRequire reads CHANNEL_BRIDGE_READY_REQUIRE: "all" (every
+// configured dependency), "none" (report only) or a comma-separated list of
+// dependency names. It returns nil for "all".
+func parseReadyRequire(raw string) map[string]bool {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	if raw == "" || raw == "all" {
+		return nil
+	}
+	out := map[string]bool{}
+	if raw == "none" {
+		return out
+	}
+	for _, part := range strings.Split(raw, ",") {
+		switch name := strings.TrimSpace(part); name {
+		case readyDepSlack, readyDepSlackSocket, readyDepTeams, readyDepDiscord, readyDepDiscordGW, readyDepTelegram, readyDepKafclaw:
+			out[name] = true
+		}
+	}
+	return out
+}
+
+// readyStrictness describes the configured requirement for the response.
+func readyStrictness(required map[string]bool) string {
+	if required == nil {
+		return "all"
+	}
+	if len(required) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(required))
+	for _, name := range []string{readyDepSlack, readyDepSlackSocket, readyDepTeams, readyDepDiscord, readyDepDiscordGW, readyDepTelegram, readyDepKafclaw} {
+		if required[name] {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// noteSlackSocket records a Socket Mode connection state change of an
+// account. The default account's connection is b.socket.
+func (b *bridge) noteSlackSocket(accountID, state string, err error) {
+	b.socketMu.Lock()
+	defer b.socketMu.Unlock()
+	id := normalizeAccountID(accountID)
+	if id == "" || id == normalizeAccountID(b.slackDefaultAccount().ID) {
+		b.socket.set(state, err)
+		return
+	}
+	if b.slackSockets == nil {
+		b.slackSockets = map[string]socketState{}
+	}
+	s := b.slackSockets[id]
+	s.set(state, err)
+	b.slackSockets[id] = s
+}
+
+// noteSlackSocketEvent updates the Socket Mode state from a client event.
+func (b *bridge) noteSlackSocketEvent(accountID string, evt socketmode.Event) {
+	switch evt.Type {
+	case socketmode.EventTypeConnecting:
+		b.noteSlackSocket(accountID, "connecting", nil)
+	case socketmode.EventTypeConnected, socketmode.EventTypeHello:
+		b.noteSlackSocket(accountID, "connected", nil)
+	case socketmode.EventTypeConnectionError, socketmode.EventTypeInvalidAuth, socketmode.EventTypeDisconnect:
+		err := errors.New(string(evt.Type))
+		if e, ok := evt.Data.(error); ok && e != nil {
+			err = e
+		}
+		b.noteSlackSocket(accountID, "disconnected", err)
+	}
+}
+
+// handleReadyz reports whether the bridge can serve traffic: 200 when every
+// required dependency is healthy, 503 otherwise. /healthz stays a plain
+// liveness check.
+func (b *bridge) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	deps := b.checkReadiness(r.Context(), time.Now())
+	ready := true
+	for _, d := range deps {
+		if d.Required && !d.OK {
+			ready = false
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if !ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"ok":           ready,
+		"strictness":   readyStrictness(b.cfg.ReadyRequire),
+		"dependencies": deps,
+	})
+}
+
+// checkReadiness checks every configured dependency. Remote checks are
+// cached for the configured TTL, so frequent probes do not hit Slack,
+// Microsoft login, Discord, Telegram or kafclaw on every call.
+func (b *bridge) checkReadiness(ctx context.Context, now time.Time) []dependencyHealth {
+	var deps []dependencyHealth
+	if strings.TrimSpace(b.cfg.SlackBotToken) != "" {
+		deps = append(deps, b.cachedDependency(ctx, readyDepSlack, now, b.checkSlackAuth))
+	}
+	if strings.TrimSpace(b.cfg.SlackAppToken) != "" {
+		deps = append(deps, b.checkSlackSocket(now))
+	}
+	for _, acct := range b.slackAccounts()[1:] {
+		deps = append(deps, b.cachedDependency(ctx, readyDepSlack+":"+acct.ID, now, func(ctx context.Context) (string, error) {
+			return b.checkSlackAccountAuth(ctx, acct)
+		}))
+		if acct.AppToken != "" {
+			b.socketMu.Lock()
+			s := b.slackSockets[acct.ID]
+			b.socketMu.Unlock()
+			deps = append(deps, socketHealth(readyDepSlackSocket+":"+acct.ID, s, now))
+		}
+	}
+	if strings.TrimSpace(b.cfg.MSTeamsAppID) != "" && strings.TrimSpace(b.cfg.MSTeamsAppPassword) != "" {
+		deps = append(deps, b.cachedDependency(ctx, readyDepTeams, now, b.checkTeamsToken))
+	}
+	if strings.TrimSpace(b.cfg.DiscordBotToken) != "" {
+		deps = append(deps, b.cachedDependency(ctx, readyDepDiscord, now, b.checkDiscordAuth))
+		if b.cfg.DiscordGateway {
+			b.socketMu.Lock()
+			s := b.discordSocket
+			b.socketMu.Unlock()
+			deps = append(deps, socketHealth(readyDepDiscordGW, s, now))
+		}
+	}
+	if strings.TrimSpace(b.cfg.TelegramBotToken) != "" {
+		deps = append(deps, b.cachedDependency(ctx, readyDepTelegram, now, b.checkTelegramAuth))
+	}
+	deps = append(deps, b.cachedDependency(ctx, readyDepKafclaw, now, b.checkKafclaw))
+	for i := range deps {
+		// Extra accounts ("slack:<account_id>") follow their base dependency.
+		base, _, _ := strings.Cut(deps[i].Name, ":")
+		deps[i].Required = b.cfg.ReadyRequire == nil || b.cfg.ReadyRequire[base]
+	}
+	return deps
+}
+
+// cachedDependency returns the last result of check for name while it is
+// younger than the cache TTL, and runs check otherwise.
+func (b *bridge) cachedDependency(ctx context.Context, name string, now time.Time, check func(ctx context.Context) (string, error)) dependencyHealth {
+	ttl := b.cfg.ReadyCacheTTL
+	if ttl <= 0 {
+		ttl = defaultReadyCacheSec * time.Second
+	}
+	b.readyMu.Lock()
+	defer b.readyMu.Unlock()
+	if c, ok := b.readyCache[name]; ok && now.Sub(c.at) < ttl {
+		h := c.health
+		h.Cached = true
+		return h
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, readyProbeTimeout)
+	defer cancel()
+	start := time.Now()
+	detail, err := check(checkCtx)
+	h := dependencyHealth{
+		Name:      name,
+		OK:        err == nil,
+		Detail:    detail,
+		LatencyMS: time.Since(start).Milliseconds(),
+		CheckedAt: now.UTC().Format(time.RFC3339),
+	}
+	if err != nil {
+		h.Error = err.Error()
+	}
+	if b.readyCache == nil {
+		b.readyCache = map[string]cachedDependency{}
+	}
+	b.readyCache[name] = cachedDependency{health: h, at: now}
+	return h
+}
+
+func (b *bridge) checkSlackAuth(ctx context.Context) (string, error) {
+	return b.checkSlackAccountAuth(ctx, b.slackDefaultAccount())
+}
+
+func (b *bridge) checkSlackAccountAuth(ctx context.Context, acct slackAccount) (string, error) {
+	api, err := b.slackClient(acct)
+	if err != nil {
+		return "", err
+	}
+	auth, err := api.AuthTestContext(ctx)
+	if err != nil {
+		return "", fmt.Errorf("auth.test: %w", err)
+	}
+	return fmt.Sprintf("team %s, user %s", auth.Team, auth.User), nil
+}
+
+func (b *bridge) checkSlackSocket(now time.Time) dependencyHealth {
+	b.socketMu.Lock()
+	s := b.socket
+	b.socketMu.Unlock()
+	return socketHealth(readyDepSlackSocket, s, now)
+}
+
+// socketHealth reports a connection as healthy while it is connected.
+func socketHealth(name string, s socketState, now time.Time) dependencyHealth {
+	if s.state == "" {
+		s.state = "connecting"
+	}
+	h := dependencyHealth{
+		Name:      name,
+		OK:        s.state == "connected",
+		Detail:    s.state,
+		Error:     s.err,
+		CheckedAt: now.UTC().Format(time.RFC3339),
+	}
+	if !s.since.IsZero() {
+		h.Detail += " since " + s.since.Format(time.RFC3339)
+	}
+	return h
+}
+
+// checkTeamsToken acquires (or reuses) the Bot Framework token the bridge
+// sends with.
+func (b *bridge) checkTeamsToken(_ context.Context) (string, error) {
+	if _, err := b.getTeamsAccessToken(b.teamsDefaultAccount()); err != nil {
+		return "", fmt.Errorf("token acquisition: %w", err)
+	}
+	b.teamsMu.RLock()
+	exp := b.teamsToken.expiresAt
+	b.teamsMu.RUnlock()
+	return "token valid until " + exp.UTC().Format(time.RFC3339), nil
+}
+
+// checkKafclaw calls the unauthenticated kafclaw status endpoint.
+func (b *bridge) checkKafclaw(ctx context.Context) (string, error) {
+	endpoint := strings.TrimRight(strings.TrimSpace(b.cfg.KafclawBase), "/") + "/api/v1/status"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("%s returned HTTP %d", endpoint, resp.StatusCode)
+	}
+	return fmt.Sprintf("HTTP %d", resp.StatusCode), nil
+}
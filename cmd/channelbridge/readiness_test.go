@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack/socketmode"
+)
+
+type readyzResponse struct {
+	OK           bool               `json:"ok"`
+	Strictness   string             `json:"strictness"`
+	Dependencies []dependencyHealth `json:"dependencies"`
+}
+
+func getReadyz(t *testing.T, b *bridge) (int, readyzResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	b.handleReadyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var out readyzResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatalf("decode readyz: %v body=%s", err, w.Body.String())
+	}
+	return w.Code, out
+}
+
+func readyDep(out readyzResponse, name string) (dependencyHealth, bool) {
+	for _, d := range out.Dependencies {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return dependencyHealth{}, false
+}
+
+func TestReadyzReflectsDependencies(t *testing.T) {
+	var authCalls atomic.Int32
+	var authOK atomic.Bool
+	authOK.Store(true)
+	slackAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authCalls.Add(1)
+		if !authOK.Load() {
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "invalid_auth"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "team": "T", "user": "bot"})
+	}))
+	defer slackAPI.Close()
+	kafclaw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/status" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"status": "ok"})
+	}))
+	defer kafclaw.Close()
+
+	b := newTestBridge(kafclaw.URL)
+	b.cfg.SlackAPIBase = slackAPI.URL
+	b.cfg.SlackBotToken = "xoxb-test"
+	b.cfg.SlackAppToken = "xapp-test"
+	b.cfg.MSTeamsAppID = "app"
+	b.cfg.MSTeamsAppPassword = "secret"
+	b.cfg.MSTeamsTenantID = "tenant"
+	b.teamsToken = tokenCache{accessToken: "tok", expiresAt: time.Now().Add(time.Hour)}
+	b.cfg.ReadyCacheTTL = time.Hour
+
+	// Socket Mode has not connected yet: not ready.
+	code, out := getReadyz(t, b)
+	if code != http.StatusServiceUnavailable || out.OK {
+		t.Fatalf("expected 503 before socket mode connects, got %d %+v", code, out)
+	}
+	if d, _ := readyDep(out, readyDepSlackSocket); d.OK || d.Detail != "connecting" {
+		t.Fatalf("unexpected socket state: %+v", d)
+	}
+
+	b.noteSlackSocketEvent("", socketmode.Event{Type: socketmode.EventTypeConnected})
+	code, out = getReadyz(t, b)
+	if code != http.StatusOK || !out.OK || out.Strictness != "all" {
+		t.Fatalf("expected ready, got %d %+v", code, out)
+	}
+	for _, name := range []string{readyDepSlack, readyDepSlackSocket, readyDepTeams, readyDepKafclaw} {
+		if d, ok := readyDep(out, name); !ok || !d.OK || !d.Required {
+			t.Fatalf("expected %s healthy and required, got %+v", name, d)
+		}
+	}
+	if d, _ := readyDep(out, readyDepSlack); !d.Cached || d.Detail != "team T, user bot" {
+		t.Fatalf("expected the cached auth test result, got %+v", d)
+	}
+	if n := authCalls.Load(); n != 1 {
+		t.Fatalf("expected one auth.test call within the cache TTL, got %d", n)
+	}
+
+	// A dead socket fails readiness with the reason.
+	b.noteSlackSocketEvent("", socketmode.Event{Type: socketmode.EventTypeConnectionError, Data: errors.New("dial tcp: refused")})
+	code, out = getReadyz(t, b)
+	if code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 after socket disconnect, got %d", code)
+	}
+	if d, _ := readyDep(out, readyDepSlackSocket); d.OK || d.Error != "dial tcp: refused" {
+		t.Fatalf("unexpected socket detail: %+v", d)
+	}
+
+	// Slack auth failures show once the cached result expires.
+	b.noteSlackSocketEvent("", socketmode.Event{Type: socketmode.EventTypeConnected})
+	authOK.Store(false)
+	b.cfg.ReadyCacheTTL = time.Nanosecond
+	code, out = getReadyz(t, b)
+	if code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 after auth failure, got %d", code)
+	}
+	if d, _ := readyDep(out, readyDepSlack); d.OK || d.Error == "" {
+		t.Fatalf("expected slack auth error, got %+v", d)
+	}
+}
+
+func TestReadyzStrictness(t *testing.T) {
+	b := newTestBridge("http://127.0.0.1:1")
+	b.cfg.MSTeamsAppID = "app"
+	b.cfg.MSTeamsAppPassword = "secret"
+	// No tenant: token acquisition fails without a network call.
+
+	code, out := getReadyz(t, b)
+	if code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d %+v", code, out)
+	}
+	if d, _ := readyDep(out, readyDepTeams); d.OK || d.Error == "" {
+		t.Fatalf("expected teams token failure, got %+v", d)
+	}
+	if _, ok := readyDep(out, readyDepSlack); ok {
+		t.Fatal("unconfigured slack must not be reported")
+	}
+
+	b.cfg.ReadyRequire = parseReadyRequire("none")
+	if code, out := getReadyz(t, b); code != http.StatusOK || out.Strictness != "none" {
+		t.Fatalf("expected report-only readiness, got %d %+v", code, out)
+	}
+
+	b.cfg.ReadyRequire = parseReadyRequire("teams, bogus")
+	code, out = getReadyz(t, b)
+	if code != http.StatusServiceUnavailable || out.Strictness != "teams" {
+		t.Fatalf("expected teams to be required, got %d %+v", code, out)
+	}
+	if d, _ := readyDep(out, readyDepKafclaw); d.Required || d.OK {
+		t.Fatalf("expected kafclaw reported as optional and down, got %+v", d)
+	}
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	scheduledTick = time.Second
+	// scheduledMaxAhead is how far ahead a send may be scheduled; Slack's
+	// chat.scheduleMessage allows 120 days.
+	scheduledMaxAhead = 120 * 24 * time.Hour
+	scheduledMax      = 1000
+)
+
+// scheduledMessage is an outbound send scheduled with send_at. Slack sends
+// are scheduled natively with chat.scheduleMessage and kept here for listing
+// and cancelling; Teams sends keep their request body and are replayed
+// through the outbound handler once due.
+type scheduledMessage struct {
+	ID        string    `json:"id"`
+	Channel   string    `json:"channel"` // slack|msteams
+	AccountID string    `json:"account_id,omitempty"`
+	ChatID    string    `json:"chat_id"`
+	ThreadID  string    `json:"thread_id,omitempty"`
+	SendAt    time.Time `json:"send_at"`
+	CreatedAt time.Time `json:"created_at"`
+	Preview   string    `json:"preview,omitempty"`
+	// SlackChannelID and SlackMessageID identify the message at Slack.
+	SlackChannelID string `json:"slack_channel_id,omitempty"`
+	SlackMessageID string `json:"slack_scheduled_message_id,omitempty"`
+	// Body and Traceparent are the Teams outbound request.
+	Body        json.RawMessage `json:"body,omitempty"`
+	Traceparent string          `json:"traceparent,omitempty"`
+}
+
+// parseSendAt reads the send_at of an outbound request (RFC 3339). An empty
+// value is the zero time; anything else must lie in the future and within
+// scheduledMaxAhead.
+func parseSendAt(raw string, now time.Time) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	at, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, errors.New("send_at must be an RFC 3339 time")
+	}
+	if !at.After(now) {
+		return time.Time{}, errors.New("send_at must be in the future")
+	}
+	if at.Sub(now) > scheduledMaxAhead {
+		return time.Time{}, fmt.Errorf("send_at must be within %d days", int(scheduledMaxAhead/(24*time.Hour)))
+	}
+	return at.UTC(), nil
+}
+
+// addScheduled records a scheduled send and answers the outbound request.
+func (b *bridge) addScheduled(w http.ResponseWriter, item scheduledMessage) {
+	item.CreatedAt = time.Now().UTC()
+	item.Preview = truncateRunes(strings.TrimSpace(item.Preview), 100)
+	b.scheduledMu.Lock()
+	b.scheduled = append(b.scheduled, item)
+	b.scheduledMu.Unlock()
+	b.noteScheduled(func(m *bridgeMetrics) { m.ScheduledMessages++ })
+	_ = b.saveState()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"ok":          true,
+		"scheduled":   true,
+		"schedule_id": item.ID,
+		"send_at":     item.SendAt.Format(time.RFC3339),
+	})
+}
+
+// scheduledFull reports whether no further sends can be scheduled.
+func (b *bridge) scheduledFull() bool {
+	b.scheduledMu.Lock()
+	defer b.scheduledMu.Unlock()
+	return len(b.scheduled) >= scheduledMax
+}
+
+// scheduleTeamsOutbound keeps a Teams outbound request until its send_at.
+// The stored body has no send_at, so the replay sends right away.
+func (b *bridge) scheduleTeamsOutbound(w http.ResponseWriter, r *http.Request, item scheduledMessage) {
+	if b.scheduledFull() {
+		http.Error(w, fmt.Sprintf("too many scheduled sends (%d)", scheduledMax), http.StatusServiceUnavailable)
+		return
+	}
+	item.ID = randomHex(8)
+	item.Channel = "msteams"
+	item.Traceparent = r.Header.Get(traceparentHeader)
+	b.addScheduled(w, item)
+}
+
+// scheduleSlackOutbound schedules a Slack send with chat.scheduleMessage.
+// Media uploads cannot be scheduled.
+func (b *bridge) scheduleSlackOutbound(w http.ResponseWriter, acct slackAccount, item scheduledMessage, text string, card map[string]any) {
+	if b.scheduledFull() {
+		http.Error(w, fmt.Sprintf("too many scheduled sends (%d)", scheduledMax), http.StatusServiceUnavailable)
+		return
+	}
+	id, err := b.slackScheduleMessage(acct, item.SlackChannelID, item.ThreadID, text, card, item.SendAt)
+	if err != nil {
+		b.noteOutbound(false, "slack", err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	item.ID = randomHex(8)
+	item.Channel = "slack"
+	item.SlackMessageID = id
+	b.addScheduled(w, item)
+}
+
+func (b *bridge) slackScheduleMessage(acct slackAccount, channelID, threadID, text string, card map[string]any, at time.Time) (string, error) {
+	form := url.Values{
+		"channel": {strings.TrimSpace(channelID)},
+		"post_at": {strconv.FormatInt(at.Unix(), 10)},
+	}
+	if ts := strings.TrimSpace(threadID); ts != "" {
+		form.Set("thread_ts", ts)
+	}
+	setSlackMessageForm(form, text, card)
+	var out struct {
+		ScheduledMessageID string `json:"scheduled_message_id"`
+	}
+	if err := b.slackAPIPostForm(acct, "chat.scheduleMessage", form, &out); err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(out.ScheduledMessageID) == "" {
+		return "", errors.New("chat.scheduleMessage missing scheduled_message_id")
+	}
+	return strings.TrimSpace(out.ScheduledMessageID), nil
+}
+
+// setSlackMessageForm sets text, blocks and attachments of a form-encoded
+// Slack message from a reply and its card, as slackPostCard renders them.
+func setSlackMessageForm(form url.Values, text string, card map[string]any) {
+	if len(card) > 0 {
+		blocks := card["blocks"]
+		if blocks == nil {
+			var sections []map[string]any
+			if raw, ok := card["sections"].([]any); ok {
+				for _, sec := range raw {
+					if line := strings.TrimSpace(asString(sec)); line != "" {
+						sections = append(sections, map[string]any{"type": "section", "text": map[string]any{"type": "mrkdwn", "text": line}})
+					}
+				}
+			}
+			if len(sections) > 0 {
+				blocks = sections
+			}
+		}
+		if blocks != nil {
+			blob, _ := json.Marshal(blocks)
+			form.Set("blocks", string(blob))
+		}
+		if attachments := card["attachments"]; attachments != nil {
+			blob, _ := json.Marshal(attachments)
+			form.Set("attachments", string(blob))
+		}
+		if strings.TrimSpace(text) == "" {
+			text = firstNonEmpty(asString(card["text"]), asString(card["title"]), asString(card["body"]))
+		}
+	}
+	form.Set("text", strings.TrimSpace(text))
+}
+
+// startScheduledSends sends due Teams messages in the background.
+func (b *bridge) startScheduledSends() {
+	go func() {
+		ticker := time.NewTicker(scheduledTick)
+		defer ticker.Stop()
+		for range ticker.C {
+			done := b.trackInflight()
+			if b.closing.Load() {
+				done()
+				return
+			}
+			b.sendDueScheduled(time.Now())
+			done()
+		}
+	}()
+}
+
+// sendDueScheduled replays the due Teams sends and forgets the Slack sends
+// that Slack has posted by now. A Teams send that fails transiently goes to
+// the outbound retry queue when it is on.
+func (b *bridge) sendDueScheduled(now time.Time) {
+	b.scheduledMu.Lock()
+	var due []scheduledMessage
+	keep := b.scheduled[:0]
+	for _, s := range b.scheduled {
+		if s.SendAt.After(now) {
+			keep = append(keep, s)
+		} else {
+			due = append(due, s)
+		}
+	}
+	b.scheduled = keep
+	b.scheduledMu.Unlock()
+	if len(due) == 0 {
+		return
+	}
+	for _, s := range due {
+		if s.Channel != "msteams" {
+			continue
+		}
+		item := queuedOutbound{ID: s.ID, Channel: s.Channel, ChatID: s.ChatID, Body: s.Body, Traceparent: s.Traceparent}
+		if b.outboundQueueEnabled() && b.outboundChatQueued(item.Channel, item.ChatID) {
+			_ = b.enqueueOutbound(item, "chat has queued sends")
+			continue
+		}
+		rec := b.replayOutbound(item)
+		switch {
+		case rec.status() < 300:
+			b.noteScheduled(func(m *bridgeMetrics) { m.ScheduledSent++ })
+		case rec.retryable() && b.outboundQueueEnabled() && b.enqueueOutbound(item, rec.errorText()) == nil:
+		default:
+			b.noteScheduled(func(m *bridgeMetrics) { m.ScheduledFailed++ })
+			slog.Error("scheduled send failed", "channel", s.Channel, "chat", s.ChatID, "status", rec.status(), "error", rec.errorText())
+		}
+	}
+	_ = b.saveState()
+}
+
+// handleScheduled answers GET /scheduled: the pending scheduled sends,
+// soonest first, optionally filtered by channel and chat_id. Request bodies
+// are left out.
+func (b *bridge) handleScheduled(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	channel := strings.TrimSpace(r.URL.Query().Get("channel"))
+	chatID := strings.TrimSpace(r.URL.Query().Get("chat_id"))
+	now := time.Now()
+	b.scheduledMu.Lock()
+	items := make([]scheduledMessage, 0, len(b.scheduled))
+	for _, s := range b.scheduled {
+		if !s.SendAt.After(now) || (channel != "" && s.Channel != channel) || (chatID != "" && s.ChatID != chatID) {
+			continue
+		}
+		s.Body, s.Traceparent = nil, ""
+		items = append(items, s)
+	}
+	b.scheduledMu.Unlock()
+	sort.SliceStable(items, func(i, j int) bool { return items[i].SendAt.Before(items[j].SendAt) })
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "scheduled": items})
+}
+
+// handleScheduledItem answers POST /scheduled/<id>/cancel. A chat_id query
+// parameter must match the send's chat.
+func (b *bridge) handleScheduledItem(w http.ResponseWriter, r *http.Request) {
+	id, op, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/scheduled/"), "/")
+	if !ok || op != "cancel" || strings.TrimSpace(id) == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	chatID := strings.TrimSpace(r.URL.Query().Get("chat_id"))
+	item, found := b.scheduledByID(id)
+	if !found || !item.SendAt.After(time.Now()) || (chatID != "" && item.ChatID != chatID) {
+		http.Error(w, "scheduled send not found", http.StatusNotFound)
+		return
+	}
+	if item.Channel == "slack" {
+		acct := b.slackChannelAccount(b.slackAccount(firstNonEmpty(item.AccountID, "default")), item.SlackChannelID)
+		err := b.slackAPIPostForm(acct, "chat.deleteScheduledMessage", url.Values{
+			"channel":              {item.SlackChannelID},
+			"scheduled_message_id": {item.SlackMessageID},
+		}, nil)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+	}
+	if _, ok := b.removeScheduled(id); !ok {
+		// Sent between the lookup and now.
+		http.Error(w, "scheduled send not found", http.StatusNotFound)
+		return
+	}
+	_ = b.saveState()
+	item.Body, item.Traceparent = nil, ""
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "cancelled": item})
+}
+
+func (b *bridge) scheduledByID(id string) (scheduledMessage, bool) {
+	b.scheduledMu.Lock()
+	defer b.scheduledMu.Unlock()
+	for _, s := range b.scheduled {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	return scheduledMessage{}, false
+}
+
+func (b *bridge) removeScheduled(id string) (scheduledMessage, bool) {
+	b.scheduledMu.Lock()
+	defer b.scheduledMu.Unlock()
+	for i, s := range b.scheduled {
+		if s.ID == id {
+			b.scheduled = append(b.scheduled[:i], b.scheduled[i+1:]...)
+			return s, true
+		}
+	}
+	return scheduledMessage{}, false
+}
+
+func (b *bridge) noteScheduled(update func(*bridgeMetrics)) {
+	b.metricsMu.Lock()
+	defer b.metricsMu.Unlock()
+	update(&b.metrics)
+}
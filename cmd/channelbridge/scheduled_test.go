@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSlackScheduledSendListAndCancel(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls = map[string]string{}
+	)
+	slackAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		mu.Lock()
+		calls[r.URL.Path] = r.Form.Encode()
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/chat.scheduleMessage":
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "scheduled_message_id": "Q1", "post_at": r.FormValue("post_at")})
+		case "/chat.deleteScheduledMessage":
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": r.FormValue("scheduled_message_id") == "Q1"})
+		default:
+			t.Errorf("unexpected slack call %s", r.URL.Path)
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "unexpected"})
+		}
+	}))
+	defer slackAPI.Close()
+
+	b := newTestBridge("http://example.invalid")
+	b.cfg.SlackAPIBase = slackAPI.URL
+	b.cfg.SlackBotToken = "xoxb-test"
+
+	sendAt := time.Now().Add(2 * time.Hour).UTC().Truncate(time.Second)
+	body, _ := json.Marshal(map[string]any{"chat_id": "C1", "content": "standup in 5", "send_at": sendAt.Format(time.RFC3339)})
+	w := httptest.NewRecorder()
+	b.handleSlackOutbound(w, httptest.NewRequest(http.MethodPost, "/slack/outbound", bytes.NewReader(body)))
+	var resp map[string]any
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	id, _ := resp["schedule_id"].(string)
+	if w.Code != http.StatusOK || resp["scheduled"] != true || id == "" {
+		t.Fatalf("schedule status=%d body=%s", w.Code, w.Body.String())
+	}
+	mu.Lock()
+	form := calls["/chat.scheduleMessage"]
+	mu.Unlock()
+	if form != "channel=C1&post_at="+strconv.FormatInt(sendAt.Unix(), 10)+"&text=standup+in+5" {
+		t.Fatalf("unexpected chat.scheduleMessage form %q", form)
+	}
+
+	w = httptest.NewRecorder()
+	b.handleScheduled(w, httptest.NewRequest(http.MethodGet, "/scheduled?chat_id=C1", nil))
+	var list struct {
+		Scheduled []scheduledMessage `json:"scheduled"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list.Scheduled) != 1 || list.Scheduled[0].Preview != "standup in 5" || !list.Scheduled[0].SendAt.Equal(sendAt) {
+		t.Fatalf("unexpected list %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	b.handleScheduledItem(w, httptest.NewRequest(http.MethodPost, "/scheduled/"+id+"/cancel?chat_id=C2", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected another chat's send hidden, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	b.handleScheduledItem(w, httptest.NewRequest(http.MethodPost, "/scheduled/"+id+"/cancel", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("cancel status=%d body=%s", w.Code, w.Body.String())
+	}
+	mu.Lock()
+	deleted := calls["/chat.deleteScheduledMessage"]
+	mu.Unlock()
+	if deleted != "channel=C1&scheduled_message_id=Q1" {
+		t.Fatalf("unexpected chat.deleteScheduledMessage form %q", deleted)
+	}
+	if _, ok := b.scheduledByID(id); ok {
+		t.Fatal("expected the cancelled send forgotten")
+	}
+}
+
+func TestTeamsScheduledSendIsSentWhenDue(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		sent []string
+	)
+	teamsAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var activity map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&activity)
+		mu.Lock()
+		sent = append(sent, asString(activity["text"]))
+		mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": "1700000000001"})
+	}))
+	defer teamsAPI.Close()
+
+	b := newTestBridge("http://example.invalid")
+	b.cfg.MSTeamsAPIBase = teamsAPI.URL
+	b.teamsMu.Lock()
+	b.teamsConvByID["conv-1"] = teamsConversationRef{ServiceURL: teamsAPI.URL, ConversationID: "conv-1", UserID: "u1"}
+	b.teamsToken = tokenCache{accessToken: "token", expiresAt: time.Now().Add(30 * time.Minute)}
+	b.teamsMu.Unlock()
+
+	post := func(payload map[string]any) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(payload)
+		w := httptest.NewRecorder()
+		b.handleTeamsOutbound(w, httptest.NewRequest(http.MethodPost, "/teams/outbound", bytes.NewReader(body)))
+		return w
+	}
+	if w := post(map[string]any{"chat_id": "conv-1", "content": "late", "send_at": time.Now().Add(-time.Minute).Format(time.RFC3339)}); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected a past send_at refused, got %d", w.Code)
+	}
+	sendAt := time.Now().Add(time.Hour)
+	if w := post(map[string]any{"chat_id": "conv-1", "content": "reminder: retro", "send_at": sendAt.Format(time.RFC3339)}); w.Code != http.StatusOK {
+		t.Fatalf("schedule status=%d body=%s", w.Code, w.Body.String())
+	}
+
+	b.sendDueScheduled(time.Now())
+	mu.Lock()
+	early := len(sent)
+	mu.Unlock()
+	if early != 0 {
+		t.Fatalf("expected nothing sent before send_at, got %d", early)
+	}
+	b.sendDueScheduled(sendAt.Add(time.Second))
+	mu.Lock()
+	defer mu.Unlock()
+	if len(sent) != 1 || sent[0] != "reminder: retro" {
+		t.Fatalf("expected the reminder sent once, got %#v", sent)
+	}
+	if len(b.scheduled) != 0 || b.metrics.ScheduledSent != 1 {
+		t.Fatalf("expected the send done, scheduled=%d sent=%d", len(b.scheduled), b.metrics.ScheduledSent)
+	}
+}
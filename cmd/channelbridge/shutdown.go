@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const defaultShutdownTimeoutSec = 25
+
+// trackInflight marks background work kafclaw would lose if the process
+// exited now (an inbound forward, a delivery receipt, a queue pass). Call
+// the returned func when it is done. Work started after shutdown began is
+// still tracked.
+func (b *bridge) trackInflight() func() {
+	b.inflight.Add(1)
+	return func() { b.inflight.Add(-1) }
+}
+
+// waitInflight waits until no tracked work is running or ctx is done. It
+// reports whether everything finished.
+func (b *bridge) waitInflight(ctx context.Context) bool {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for b.inflight.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+	return true
+}
+
+// shutdown stops the bridge within the shutdown timeout: it stops the
+// retry queues, lets srv finish in-flight requests, waits for background
+// forwards and receipts, and saves the state one last time.
+func (b *bridge) shutdown(srv *http.Server) {
+	timeout := b.cfg.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeoutSec * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	b.closing.Store(true)
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Warn("channelbridge shutdown: http server", "error", err)
+	}
+	if !b.waitInflight(ctx) {
+		slog.Warn("channelbridge shutdown: forwards still running", "inflight", b.inflight.Load(), "timeout", timeout)
+	}
+	if err := b.saveState(); err != nil {
+		slog.Error("channelbridge shutdown: state save failed", "error", err)
+		return
+	}
+	slog.Info("channelbridge stopped")
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestShutdownWaitsForForwardsAndSavesState(t *testing.T) {
+	b := newTestBridge("http://example.invalid")
+	b.cfg.StatePath = filepath.Join(t.TempDir(), "state.json")
+	b.cfg.ShutdownTimeout = 5 * time.Second
+	b.teamsConvByID["conv-1"] = teamsConversationRef{ConversationID: "conv-1", ServiceURL: "https://smba.example.test"}
+
+	var finished atomic.Bool
+	done := b.trackInflight()
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		finished.Store(true)
+		done()
+	}()
+
+	b.shutdown(&http.Server{})
+	if !finished.Load() {
+		t.Fatal("shutdown returned before the in-flight forward finished")
+	}
+	if !b.closing.Load() {
+		t.Fatal("expected queues to be stopped")
+	}
+	data, err := os.ReadFile(b.cfg.StatePath)
+	if err != nil {
+		t.Fatalf("expected state file: %v", err)
+	}
+	loaded := newTestBridge("http://example.invalid")
+	loaded.cfg.StatePath = b.cfg.StatePath
+	if err := loaded.loadState(); err != nil {
+		t.Fatalf("load state: %v (%s)", err, data)
+	}
+	if loaded.teamsConvByID["conv-1"].ServiceURL != "https://smba.example.test" {
+		t.Fatalf("expected saved conversation reference, got %+v", loaded.teamsConvByID)
+	}
+}
+
+func TestShutdownGivesUpAfterTimeout(t *testing.T) {
+	b := newTestBridge("http://example.invalid")
+	b.cfg.StatePath = filepath.Join(t.TempDir(), "state.json")
+	b.cfg.ShutdownTimeout = 100 * time.Millisecond
+	defer b.trackInflight()()
+
+	start := time.Now()
+	b.shutdown(&http.Server{})
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("shutdown took %s despite the timeout", elapsed)
+	}
+	if _, err := os.Stat(b.cfg.StatePath); err != nil {
+		t.Fatalf("expected state saved after the timeout: %v", err)
+	}
+}
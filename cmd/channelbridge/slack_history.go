@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	defaultSlackHistoryLimit = 50
+	maxSlackHistoryLimit     = 200
+)
+
+// errSlackHistoryRange marks an unparseable oldest/latest bound.
+var errSlackHistoryRange = errors.New("invalid history range")
+
+// slackHistoryRequest is the body of POST /slack/history.
+type slackHistoryRequest struct {
+	AccountID string `json:"account_id"`
+	// WorkspaceID selects the bot token of that workspace (team ID).
+	WorkspaceID string `json:"workspace_id"`
+	ChatID      string `json:"chat_id"`
+	// ThreadID reads the replies of that thread (conversations.replies)
+	// instead of the channel history (conversations.history).
+	ThreadID string `json:"thread_id"`
+	// Oldest and Latest bound the time range; each is a Slack ts or RFC3339.
+	Oldest    string `json:"oldest"`
+	Latest    string `json:"latest"`
+	Inclusive bool   `json:"inclusive"`
+	Limit     int    `json:"limit"`
+	// Cursor continues a previous read from its next_cursor.
+	Cursor string `json:"cursor"`
+}
+
+// slackHistoryPage is one page of a history read, oldest message first.
+type slackHistoryPage struct {
+	Messages   []threadMessage `json:"messages"`
+	HasMore    bool            `json:"has_more"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// handleSlackHistory reads one page of channel or thread history with sender
+// names filled in. Channel pages run newest to oldest across cursors, thread
+// pages oldest to newest, as in the Slack API; messages within a page are
+// always oldest first.
+func (b *bridge) handleSlackHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req slackHistoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.ChatID) == "" {
+		http.Error(w, "chat_id required", http.StatusBadRequest)
+		return
+	}
+	acct := b.slackAccount(req.AccountID).forWorkspace(req.WorkspaceID)
+	channelID, err := b.resolveSlackChannelID(acct, req.ChatID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	acct = b.slackChannelAccount(acct, channelID)
+	api, err := b.slackClient(acct)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, err := b.slackHistory(r.Context(), acct, api, channelID, req)
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, errSlackHistoryRange) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"ok":          true,
+		"messages":    page.Messages,
+		"has_more":    page.HasMore,
+		"next_cursor": page.NextCursor,
+	})
+}
+
+// slackHistory reads one page of history of channelID, or of the thread
+// req.ThreadID in it.
+func (b *bridge) slackHistory(ctx context.Context, acct slackAccount, api *slack.Client, channelID string, req slackHistoryRequest) (slackHistoryPage, error) {
+	oldest, err := slackTSParam(req.Oldest)
+	if err != nil {
+		return slackHistoryPage{}, fmt.Errorf("%w: oldest: %v", errSlackHistoryRange, err)
+	}
+	latest, err := slackTSParam(req.Latest)
+	if err != nil {
+		return slackHistoryPage{}, fmt.Errorf("%w: latest: %v", errSlackHistoryRange, err)
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultSlackHistoryLimit
+	}
+	if limit > maxSlackHistoryLimit {
+		limit = maxSlackHistoryLimit
+	}
+
+	var (
+		msgs     []slack.Message
+		page     slackHistoryPage
+		threaded = strings.TrimSpace(req.ThreadID) != ""
+	)
+	if threaded {
+		msgs, page.HasMore, page.NextCursor, err = api.GetConversationRepliesContext(ctx, &slack.GetConversationRepliesParameters{
+			ChannelID: channelID,
+			Timestamp: strings.TrimSpace(req.ThreadID),
+			Cursor:    strings.TrimSpace(req.Cursor),
+			Inclusive: req.Inclusive,
+			Oldest:    oldest,
+			Latest:    latest,
+			Limit:     limit,
+		})
+	} else {
+		var resp *slack.GetConversationHistoryResponse
+		resp, err = api.GetConversationHistoryContext(ctx, &slack.GetConversationHistoryParameters{
+			ChannelID: channelID,
+			Cursor:    strings.TrimSpace(req.Cursor),
+			Inclusive: req.Inclusive,
+			Oldest:    oldest,
+			Latest:    latest,
+			Limit:     limit,
+		})
+		if resp != nil {
+			msgs = resp.Messages
+			page.HasMore = resp.HasMore
+			page.NextCursor = resp.ResponseMetaData.NextCursor
+		}
+	}
+	if err != nil {
+		return slackHistoryPage{}, err
+	}
+	page.NextCursor = strings.TrimSpace(page.NextCursor)
+	page.Messages = make([]threadMessage, 0, len(msgs))
+	for _, m := range msgs {
+		page.Messages = append(page.Messages, slackThreadMessage(m))
+	}
+	if !threaded {
+		// conversations.history is newest first.
+		for i, j := 0, len(page.Messages)-1; i < j; i, j = i+1, j-1 {
+			page.Messages[i], page.Messages[j] = page.Messages[j], page.Messages[i]
+		}
+	}
+	b.slackFillSenderNames(ctx, acct, api, page.Messages)
+	return page, nil
+}
+
+// slackThreadMessage normalizes a Slack message.
+func slackThreadMessage(m slack.Message) threadMessage {
+	sender := strings.TrimSpace(m.User)
+	if sender == "" {
+		sender = strings.TrimSpace(m.BotID)
+	}
+	name := strings.TrimSpace(m.Username)
+	if name == "" && m.BotProfile != nil {
+		name = strings.TrimSpace(m.BotProfile.Name)
+	}
+	return threadMessage{
+		ID:         m.Timestamp,
+		SenderID:   sender,
+		SenderName: name,
+		IsBot:      m.BotID != "",
+		Text:       m.Text,
+		Timestamp:  slackTSTime(m.Timestamp),
+	}
+}
+
+// slackFillSenderNames sets the display name of messages from users, looked
+// up with users.info and cached per account. Lookup failures leave the name
+// empty.
+func (b *bridge) slackFillSenderNames(ctx context.Context, acct slackAccount, api *slack.Client, msgs []threadMessage) {
+	key := func(userID string) string { return acct.ID + "\x00" + userID }
+	var missing []string
+	seen := map[string]bool{}
+	b.slackNameMu.Lock()
+	for _, m := range msgs {
+		if m.SenderName != "" || m.IsBot || m.SenderID == "" || seen[m.SenderID] {
+			continue
+		}
+		seen[m.SenderID] = true
+		if _, ok := b.slackNames[key(m.SenderID)]; !ok {
+			missing = append(missing, m.SenderID)
+		}
+	}
+	b.slackNameMu.Unlock()
+
+	if len(missing) > 0 {
+		users, err := api.GetUsersInfoContext(ctx, missing...)
+		if err == nil && users != nil {
+			b.slackNameMu.Lock()
+			if b.slackNames == nil {
+				b.slackNames = map[string]string{}
+			}
+			for _, u := range *users {
+				b.slackNames[key(u.ID)] = slackUserDisplayName(u)
+			}
+			b.slackNameMu.Unlock()
+		}
+	}
+
+	b.slackNameMu.Lock()
+	defer b.slackNameMu.Unlock()
+	for i := range msgs {
+		if msgs[i].SenderName == "" && !msgs[i].IsBot {
+			msgs[i].SenderName = b.slackNames[key(msgs[i].SenderID)]
+		}
+	}
+}
+
+func slackUserDisplayName(u slack.User) string {
+	for _, name := range []string{u.Profile.DisplayName, u.RealName, u.Profile.RealName, u.Name} {
+		if s := strings.TrimSpace(name); s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// slackTSParam converts a time bound to a Slack ts. It accepts a Slack ts
+// ("1700000000.123456"), Unix seconds or RFC3339; empty stays empty.
+func slackTSParam(v string) (string, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return "", nil
+	}
+	if _, err := strconv.ParseFloat(v, 64); err == nil {
+		return v, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return "", fmt.Errorf("want a Slack ts or RFC3339 time, got %q", v)
+	}
+	return fmt.Sprintf("%d.%06d", t.Unix(), t.Nanosecond()/1000), nil
+}
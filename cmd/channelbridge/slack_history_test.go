@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestSlackHistoryReadsPageWithSenderNames(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		form      map[string]string
+		userReads int
+	)
+	slackAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/conversations.history":
+			form = map[string]string{"oldest": r.Form.Get("oldest"), "latest": r.Form.Get("latest"), "cursor": r.Form.Get("cursor"), "limit": r.Form.Get("limit")}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"ok":       true,
+				"has_more": true,
+				"messages": []map[string]any{
+					{"ts": "1700000020.000300", "bot_id": "B1", "bot_profile": map[string]any{"name": "kafclaw"}, "text": "bot reply"},
+					{"ts": "1700000010.000200", "user": "U2", "text": "second"},
+					{"ts": "1700000000.000100", "user": "U1", "text": "first"},
+				},
+				"response_metadata": map[string]any{"next_cursor": "bmV4dA=="},
+			})
+		case "/users.info":
+			userReads++
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"ok": true,
+				"users": []map[string]any{
+					{"id": "U1", "name": "alex", "profile": map[string]any{"display_name": "Alex"}},
+					{"id": "U2", "name": "sam", "real_name": "Sam Doe"},
+				},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer slackAPI.Close()
+
+	b := newTestBridge("http://example.invalid")
+	b.cfg.SlackAPIBase = slackAPI.URL
+	b.cfg.SlackBotToken = "xoxb-test"
+
+	read := func(req map[string]any) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		b.handleSlackHistory(w, httptest.NewRequest(http.MethodPost, "/slack/history", bytes.NewReader(body)))
+		return w
+	}
+	var page slackHistoryPage
+	for i := 0; i < 2; i++ {
+		w := read(map[string]any{"chat_id": "C1", "oldest": "2023-11-14T22:13:20Z", "latest": "1700000100", "cursor": "abc", "limit": 500})
+		if w.Code != http.StatusOK {
+			t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+		}
+		page = slackHistoryPage{}
+		_ = json.Unmarshal(w.Body.Bytes(), &page)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if form["oldest"] != "1700000000.000000" || form["latest"] != "1700000100" || form["cursor"] != "abc" || form["limit"] != "200" {
+		t.Fatalf("unexpected conversations.history params: %v", form)
+	}
+	if len(page.Messages) != 3 || page.Messages[0].Text != "first" || page.Messages[2].Text != "bot reply" {
+		t.Fatalf("expected messages oldest first, got %+v", page.Messages)
+	}
+	if page.Messages[0].SenderName != "Alex" || page.Messages[1].SenderName != "Sam Doe" || page.Messages[2].SenderName != "kafclaw" {
+		t.Fatalf("expected enriched sender names, got %+v", page.Messages)
+	}
+	if !page.HasMore || page.NextCursor != "bmV4dA==" {
+		t.Fatalf("expected pagination cursor, got %+v", page)
+	}
+	if userReads != 1 {
+		t.Fatalf("expected user names to be cached, got %d users.info calls", userReads)
+	}
+
+	if w := read(map[string]any{"chat_id": "C1", "oldest": "yesterday"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid time bound, got %d", w.Code)
+	}
+}
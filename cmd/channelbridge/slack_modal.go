@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// slackModalOriginTTL bounds how long the bridge remembers where a modal was
+// opened.
+const slackModalOriginTTL = 24 * time.Hour
+
+// slackModalOrigin is the conversation a modal was opened from. Submissions
+// of the modal are forwarded to it.
+type slackModalOrigin struct {
+	channelID string
+	threadID  string
+	at        time.Time
+}
+
+// slackModalAction handles the modal_open, modal_push and modal_update
+// actions of /slack/outbound. action_params.view is a Block Kit modal view;
+// open and push need the trigger_id of a recent interaction, update needs
+// view_id or external_id.
+func (b *bridge) slackModalAction(api *slack.Client, action, channelID, threadID string, params map[string]any) (map[string]any, error) {
+	raw, ok := params["view"].(map[string]any)
+	if !ok || len(raw) == 0 {
+		return nil, fmt.Errorf("%s requires action_params.view", action)
+	}
+	if strings.TrimSpace(asString(raw["type"])) == "" {
+		raw["type"] = string(slack.VTModal)
+	}
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var view slack.ModalViewRequest
+	if err := json.Unmarshal(encoded, &view); err != nil {
+		return nil, fmt.Errorf("invalid action_params.view: %w", err)
+	}
+
+	ctx := context.Background()
+	triggerID := strings.TrimSpace(asString(params["trigger_id"]))
+	var resp *slack.ViewResponse
+	switch action {
+	case "modal_open", "modal_push":
+		if triggerID == "" {
+			return nil, fmt.Errorf("%s requires action_params.trigger_id", action)
+		}
+		if action == "modal_open" {
+			resp, err = api.OpenViewContext(ctx, triggerID, view)
+		} else {
+			resp, err = api.PushViewContext(ctx, triggerID, view)
+		}
+	default:
+		viewID := strings.TrimSpace(asString(params["view_id"]))
+		externalID := strings.TrimSpace(asString(params["external_id"]))
+		if viewID == "" && externalID == "" {
+			return nil, errors.New("modal_update requires action_params.view_id or external_id")
+		}
+		resp, err = api.UpdateViewContext(ctx, view, externalID, strings.TrimSpace(asString(params["hash"])), viewID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if action != "modal_update" {
+		b.noteSlackModalOrigin(resp.ID, channelID, threadID, time.Now())
+	}
+	return map[string]any{
+		"ok":           true,
+		"view_id":      resp.ID,
+		"root_view_id": resp.RootViewID,
+		"external_id":  resp.ExternalID,
+		"hash":         resp.Hash,
+	}, nil
+}
+
+// noteSlackModalOrigin remembers the conversation of an opened view and
+// drops expired entries.
+func (b *bridge) noteSlackModalOrigin(viewID, channelID, threadID string, now time.Time) {
+	viewID = strings.TrimSpace(viewID)
+	if viewID == "" {
+		return
+	}
+	b.slackModalMu.Lock()
+	defer b.slackModalMu.Unlock()
+	if b.slackModals == nil {
+		b.slackModals = map[string]slackModalOrigin{}
+	}
+	for id, o := range b.slackModals {
+		if now.Sub(o.at) > slackModalOriginTTL {
+			delete(b.slackModals, id)
+		}
+	}
+	b.slackModals[viewID] = slackModalOrigin{channelID: channelID, threadID: threadID, at: now}
+}
+
+// slackModalOriginFor finds the conversation of a view, its root view or the
+// view it was pushed on.
+func (b *bridge) slackModalOriginFor(view slack.View) (slackModalOrigin, bool) {
+	b.slackModalMu.Lock()
+	defer b.slackModalMu.Unlock()
+	for _, id := range []string{view.ID, view.RootViewID, view.PreviousViewID} {
+		if o, ok := b.slackModals[strings.TrimSpace(id)]; ok && id != "" {
+			return o, true
+		}
+	}
+	return slackModalOrigin{}, false
+}
+
+// forwardSlackViewEvent forwards a view_submission or view_closed interaction
+// as structured activity. It goes to the conversation the bridge opened the
+// modal from; modals opened elsewhere go to the user's DM.
+func (b *bridge) forwardSlackViewEvent(acct slackAccount, cb slack.InteractionCallback) error {
+	eventType := string(cb.Type)
+	origin, known := b.slackModalOriginFor(cb.View)
+	if !known {
+		origin = slackModalOrigin{channelID: strings.TrimSpace(cb.User.ID)}
+	}
+	values := slackViewStateValues(cb.View.State)
+	verb := "submitted"
+	if cb.Type == slack.InteractionTypeViewClosed {
+		verb = "closed"
+	}
+	text := fmt.Sprintf("[modal %s: %s]", verb, firstNonEmpty(cb.View.CallbackID, cb.View.ID))
+	if summary := slackViewValuesSummary(values); summary != "" {
+		text += " " + summary
+	}
+	return b.forwardSlackActivity(acct, slackInbound{
+		senderID:     strings.TrimSpace(cb.User.ID),
+		channelID:    origin.channelID,
+		threadID:     origin.threadID,
+		messageID:    "view:" + cb.View.ID + ":" + cb.View.Hash,
+		text:         text,
+		isGroup:      known && !strings.HasPrefix(strings.ToUpper(origin.channelID), "D"),
+		wasMentioned: true,
+		workspace:    slackInteractionWorkspace(cb),
+		eventType:    eventType,
+		event: map[string]any{
+			"view_id":          cb.View.ID,
+			"root_view_id":     cb.View.RootViewID,
+			"external_id":      cb.View.ExternalID,
+			"callback_id":      cb.View.CallbackID,
+			"private_metadata": cb.View.PrivateMetadata,
+			"hash":             cb.View.Hash,
+			"trigger_id":       cb.TriggerID,
+			"values":           values,
+		},
+	})
+}
+
+// slackViewStateValues maps the input state of a view to
+// block_id -> action_id -> value. Multi-selects become string lists.
+func slackViewStateValues(state *slack.ViewState) map[string]map[string]any {
+	out := map[string]map[string]any{}
+	if state == nil {
+		return out
+	}
+	for blockID, actions := range state.Values {
+		for actionID, a := range actions {
+			if out[blockID] == nil {
+				out[blockID] = map[string]any{}
+			}
+			out[blockID][actionID] = slackBlockActionValue(a)
+		}
+	}
+	return out
+}
+
+func slackBlockActionValue(a slack.BlockAction) any {
+	switch {
+	case a.Value != "":
+		return a.Value
+	case a.SelectedOption.Value != "":
+		return a.SelectedOption.Value
+	case len(a.SelectedOptions) > 0:
+		vals := make([]string, 0, len(a.SelectedOptions))
+		for _, o := range a.SelectedOptions {
+			vals = append(vals, o.Value)
+		}
+		return vals
+	case a.SelectedUser != "":
+		return a.SelectedUser
+	case len(a.SelectedUsers) > 0:
+		return a.SelectedUsers
+	case a.SelectedChannel != "":
+		return a.SelectedChannel
+	case len(a.SelectedChannels) > 0:
+		return a.SelectedChannels
+	case a.SelectedConversation != "":
+		return a.SelectedConversation
+	case len(a.SelectedConversations) > 0:
+		return a.SelectedConversations
+	case a.SelectedDate != "":
+		return a.SelectedDate
+	case a.SelectedTime != "":
+		return a.SelectedTime
+	case a.SelectedDateTime != 0:
+		return time.Unix(a.SelectedDateTime, 0).UTC().Format(time.RFC3339)
+	}
+	return ""
+}
+
+// slackViewValuesSummary renders values as "action_id=value" pairs in a
+// stable order, for the inbound text.
+func slackViewValuesSummary(values map[string]map[string]any) string {
+	var parts []string
+	for _, actions := range values {
+		for actionID, v := range actions {
+			s, ok := v.(string)
+			if !ok {
+				list, _ := v.([]string)
+				s = strings.Join(list, ",")
+			}
+			parts = append(parts, actionID+"="+strconv.Quote(s))
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, " ")
+}
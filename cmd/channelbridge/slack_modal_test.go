@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSlackModalOpenAndSubmissionForwarding(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		triggerID string
+		view      map[string]any
+		got       map[string]any
+	)
+	slackAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/views.open":
+			var body map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			triggerID = asString(body["trigger_id"])
+			view, _ = body["view"].(map[string]any)
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "view": map[string]any{"id": "V1", "root_view_id": "V1", "hash": "h1"}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer slackAPI.Close()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/channels/slack/inbound" {
+			mu.Lock()
+			_ = json.NewDecoder(r.Body).Decode(&got)
+			mu.Unlock()
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer api.Close()
+
+	b := newTestBridge(api.URL)
+	b.cfg.SlackAPIBase = slackAPI.URL
+	b.cfg.SlackBotToken = "xoxb-test"
+
+	body, _ := json.Marshal(map[string]any{
+		"chat_id":   "C1",
+		"thread_id": "171.100",
+		"action":    "modal_open",
+		"action_params": map[string]any{
+			"trigger_id": "T-123",
+			"view": map[string]any{
+				"callback_id": "deploy_form",
+				"title":       map[string]any{"type": "plain_text", "text": "Deploy"},
+				"submit":      map[string]any{"type": "plain_text", "text": "Go"},
+				"blocks":      []any{},
+			},
+		},
+	})
+	w := httptest.NewRecorder()
+	b.handleSlackOutbound(w, httptest.NewRequest(http.MethodPost, "/slack/outbound", bytes.NewReader(body)))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"view_id":"V1"`) {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	mu.Lock()
+	if triggerID != "T-123" || view["type"] != "modal" || view["callback_id"] != "deploy_form" {
+		t.Fatalf("unexpected views.open call: trigger=%q view=%#v", triggerID, view)
+	}
+	mu.Unlock()
+	if _, err := b.slackModalAction(nil, "modal_update", "C1", "", map[string]any{"view": map[string]any{"blocks": []any{}}}); err == nil {
+		t.Fatal("expected modal_update without view_id or external_id to fail")
+	}
+
+	payload, _ := json.Marshal(map[string]any{
+		"type":       "view_submission",
+		"trigger_id": "T-456",
+		"user":       map[string]any{"id": "U9"},
+		"view": map[string]any{
+			"id":          "V1",
+			"callback_id": "deploy_form",
+			"hash":        "h2",
+			"state": map[string]any{"values": map[string]any{
+				"env_block":  map[string]any{"env": map[string]any{"type": "static_select", "selected_option": map[string]any{"value": "prod"}}},
+				"note_block": map[string]any{"note": map[string]any{"type": "plain_text_input", "value": "ship it"}},
+			}},
+		},
+	})
+	form := url.Values{}
+	form.Set("payload", string(payload))
+	req := httptest.NewRequest(http.MethodPost, "/slack/interactions", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	b.handleSlackInteractions(w, req)
+	if w.Code != http.StatusOK || w.Body.Len() != 0 {
+		t.Fatalf("expected empty 200 to close the modal, got %d %q", w.Code, w.Body.String())
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if got == nil {
+		t.Fatal("expected forwarded view_submission")
+	}
+	if got["event_type"] != "view_submission" || got["chat_id"] != "C1" || got["thread_id"] != "171.100" || got["sender_id"] != "U9" {
+		t.Fatalf("unexpected forwarded payload: %#v", got)
+	}
+	if got["text"] != `[modal submitted: deploy_form] env="prod" note="ship it"` {
+		t.Fatalf("unexpected text: %#v", got["text"])
+	}
+	event, _ := got["event"].(map[string]any)
+	values, _ := event["values"].(map[string]any)
+	env, _ := values["env_block"].(map[string]any)
+	if event["callback_id"] != "deploy_form" || event["trigger_id"] != "T-456" || env["env"] != "prod" {
+		t.Fatalf("unexpected event: %#v", event)
+	}
+}
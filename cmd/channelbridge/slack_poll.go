@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// Slack polls carry the poll ID in the block ID of their choice element;
+// every change of a voter's selection is a block_actions interaction with
+// the slackPollActionID action.
+const (
+	slackPollBlockPrefix = "kafclaw_poll:"
+	slackPollActionID    = "poll_choice"
+	// slackPollInlineMax is the most options radio buttons and checkboxes
+	// take; longer polls use a select menu.
+	slackPollInlineMax = 10
+)
+
+// buildSlackPollCard renders a poll as Block Kit: the question and radio
+// buttons, or checkboxes when more than one option may be picked. The
+// option values are opt_<n> like the Teams poll card, so votes record the
+// same way.
+func buildSlackPollCard(question string, options []string, maxSel int, pollID string) map[string]any {
+	if maxSel <= 0 {
+		maxSel = 1
+	}
+	choices := make([]map[string]any, 0, len(options))
+	for i, o := range options {
+		opt := strings.TrimSpace(o)
+		if opt == "" {
+			continue
+		}
+		choices = append(choices, map[string]any{
+			"text":  map[string]any{"type": "plain_text", "text": truncateRunes(opt, 75)},
+			"value": fmt.Sprintf("opt_%d", i+1),
+		})
+	}
+	element := map[string]any{"action_id": slackPollActionID, "options": choices}
+	switch {
+	case len(choices) <= slackPollInlineMax && maxSel > 1:
+		element["type"] = "checkboxes"
+	case len(choices) <= slackPollInlineMax:
+		element["type"] = "radio_buttons"
+	case maxSel > 1:
+		element["type"] = "multi_static_select"
+		element["max_selected_items"] = maxSel
+		element["placeholder"] = map[string]any{"type": "plain_text", "text": truncateRunes(question, 150)}
+	default:
+		element["type"] = "static_select"
+		element["placeholder"] = map[string]any{"type": "plain_text", "text": truncateRunes(question, 150)}
+	}
+	return map[string]any{
+		"text": question,
+		"blocks": []map[string]any{
+			{"type": "section", "text": map[string]any{"type": "mrkdwn", "text": "*" + question + "*"}},
+			{"type": "actions", "block_id": slackPollBlockPrefix + strings.TrimSpace(pollID), "elements": []map[string]any{element}},
+		},
+	}
+}
+
+// handleSlackPollVote records a block_actions interaction on a poll choice
+// element. It reports false for any other interaction, which is forwarded
+// as usual.
+func (b *bridge) handleSlackPollVote(cb slack.InteractionCallback) (bool, map[string]any) {
+	if cb.Type != slack.InteractionTypeBlockActions {
+		return false, nil
+	}
+	for _, action := range cb.ActionCallback.BlockActions {
+		if action == nil || action.ActionID != slackPollActionID || !strings.HasPrefix(action.BlockID, slackPollBlockPrefix) {
+			continue
+		}
+		pollID := strings.TrimPrefix(action.BlockID, slackPollBlockPrefix)
+		selections := make([]string, 0, len(action.SelectedOptions)+1)
+		if v := strings.TrimSpace(action.SelectedOption.Value); v != "" {
+			selections = append(selections, v)
+		}
+		for _, opt := range action.SelectedOptions {
+			selections = append(selections, opt.Value)
+		}
+		selections = normalizePollSelections(selections)
+		if len(selections) == 0 {
+			// All checkboxes cleared; the previous vote stands.
+			return true, nil
+		}
+		chatID := firstNonEmpty(cb.Channel.ID, cb.Container.ChannelID)
+		return b.recordPollVote(pollID, chatID, cb.User.ID, selections)
+	}
+	return false, nil
+}
+
+func truncateRunes(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max-1]) + "…"
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestSlackPollOutboundAndVotes(t *testing.T) {
+	var blocks []map[string]any
+	forwarded := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/chat.postMessage":
+			_ = r.ParseForm()
+			_ = json.Unmarshal([]byte(r.FormValue("blocks")), &blocks)
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "ts": "1"})
+		case "/api/v1/channels/slack/inbound":
+			forwarded++
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	b := newTestBridge(srv.URL)
+	b.cfg.SlackAPIBase = srv.URL
+	b.cfg.SlackBotToken = "xoxb-test"
+
+	body, _ := json.Marshal(map[string]any{
+		"chat_id":             "C111",
+		"poll_question":       "Lunch?",
+		"poll_options":        []string{"Sushi", "Pizza", "Tacos"},
+		"poll_max_selections": 2,
+	})
+	w := httptest.NewRecorder()
+	b.handleSlackOutbound(w, httptest.NewRequest(http.MethodPost, "/slack/outbound", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	if len(blocks) != 2 {
+		t.Fatalf("expected question and choice blocks, got %#v", blocks)
+	}
+	blockID := asString(blocks[1]["block_id"])
+	elements, _ := blocks[1]["elements"].([]any)
+	element, _ := elements[0].(map[string]any)
+	if !strings.HasPrefix(blockID, slackPollBlockPrefix+"C111:") || element["type"] != "checkboxes" || element["action_id"] != slackPollActionID {
+		t.Fatalf("expected a checkbox poll, got block_id=%q element=%#v", blockID, element)
+	}
+
+	vote := func(user string, selected ...string) map[string]any {
+		opts := make([]map[string]any, 0, len(selected))
+		for _, v := range selected {
+			opts = append(opts, map[string]any{"value": v})
+		}
+		payload, _ := json.Marshal(map[string]any{
+			"type":    "block_actions",
+			"user":    map[string]any{"id": user},
+			"channel": map[string]any{"id": "C111"},
+			"actions": []map[string]any{{"action_id": slackPollActionID, "block_id": blockID, "type": "checkboxes", "selected_options": opts}},
+		})
+		form := url.Values{}
+		form.Set("payload", string(payload))
+		req := httptest.NewRequest(http.MethodPost, "/slack/interactions", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		b.handleSlackInteractions(w, req)
+		var out map[string]any
+		_ = json.Unmarshal(w.Body.Bytes(), &out)
+		return out
+	}
+	vote("U1", "opt_1", "opt_2", "opt_3")
+	out := vote("U2", "opt_2")
+	if out["poll_vote_recorded"] != true {
+		t.Fatalf("expected the vote recorded, got %#v", out)
+	}
+	poll, _ := out["poll"].(map[string]any)
+	results, _ := poll["results"].(map[string]any)
+	if poll["total_votes"] != float64(3) || results["opt_2"] != float64(2) || results["opt_3"] != nil {
+		t.Fatalf("expected U1 capped at two selections and U2 counted, got %#v", poll)
+	}
+	if forwarded != 0 {
+		t.Fatalf("expected votes not forwarded to kafclaw, got %d", forwarded)
+	}
+	pollID := strings.TrimPrefix(blockID, slackPollBlockPrefix)
+	b.pollMu.Lock()
+	channel := b.teamsPolls[pollID]["channel"]
+	b.pollMu.Unlock()
+	if channel != "slack" {
+		t.Fatalf("expected the poll stored with its channel, got %#v", channel)
+	}
+}
+
+func TestBuildSlackPollCardElements(t *testing.T) {
+	elementType := func(options int, maxSel int) string {
+		opts := make([]string, options)
+		for i := range opts {
+			opts[i] = "option"
+		}
+		card := buildSlackPollCard("Q", opts, maxSel, "p1")
+		blocks := card["blocks"].([]map[string]any)
+		return blocks[1]["elements"].([]map[string]any)[0]["type"].(string)
+	}
+	if got := elementType(3, 1); got != "radio_buttons" {
+		t.Fatalf("single choice: %s", got)
+	}
+	if got := elementType(12, 1); got != "static_select" {
+		t.Fatalf("long single choice: %s", got)
+	}
+	if got := elementType(12, 3); got != "multi_static_select" {
+		t.Fatalf("long multiple choice: %s", got)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// slackWorkspace identifies the Slack workspace (team) an event came from
+// and, on Enterprise Grid, its organization. User and channel IDs are only
+// unique within a workspace.
+type slackWorkspace struct {
+	ID           string
+	EnterpriseID string
+}
+
+// parseSlackWorkspaceTokens reads SLACK_WORKSPACE_TOKENS, a comma-separated
+// list of team_id=bot_token pairs.
+func parseSlackWorkspaceTokens(raw string) map[string]string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	out := map[string]string{}
+	for _, pair := range strings.Split(raw, ",") {
+		teamID, token, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		teamID, token = normalizeSlackTeamID(teamID), strings.TrimSpace(token)
+		if !ok || teamID == "" || token == "" {
+			continue
+		}
+		out[teamID] = token
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func normalizeSlackTeamID(id string) string {
+	return strings.ToUpper(strings.TrimSpace(id))
+}
+
+// forWorkspace returns the account with the bot token of a workspace. An app
+// distributed to several workspaces gets one token per installation; an
+// org-wide Enterprise Grid install has one token for all of them, which is
+// the account's own bot token. Workspaces without a token of their own keep
+// it.
+func (a slackAccount) forWorkspace(teamID string) slackAccount {
+	teamID = normalizeSlackTeamID(teamID)
+	if teamID == "" {
+		return a
+	}
+	for id, token := range a.WorkspaceTokens {
+		if normalizeSlackTeamID(id) == teamID && strings.TrimSpace(token) != "" {
+			a.BotToken = strings.TrimSpace(token)
+			a.WorkspaceID = teamID
+			return a
+		}
+	}
+	return a
+}
+
+// slackEventWorkspace reads the workspace of an Events API payload: the
+// event's team, which on Enterprise Grid is the workspace the event
+// happened in, else the payload's team_id. The organization comes from
+// enterprise_id or, for org-wide installs, the authorizations.
+func slackEventWorkspace(payload, event map[string]any) slackWorkspace {
+	ws := slackWorkspace{
+		ID:           normalizeSlackTeamID(firstNonEmpty(asString(event["team"]), asString(payload["team_id"]))),
+		EnterpriseID: strings.TrimSpace(asString(payload["enterprise_id"])),
+	}
+	if auths, ok := payload["authorizations"].([]any); ok && len(auths) > 0 {
+		auth, _ := auths[0].(map[string]any)
+		if ws.ID == "" {
+			ws.ID = normalizeSlackTeamID(asString(auth["team_id"]))
+		}
+		if ws.EnterpriseID == "" {
+			ws.EnterpriseID = strings.TrimSpace(asString(auth["enterprise_id"]))
+		}
+	}
+	return ws
+}
+
+// slackInteractionWorkspace reads the workspace of an interaction payload.
+func slackInteractionWorkspace(cb slack.InteractionCallback) slackWorkspace {
+	return slackWorkspace{ID: normalizeSlackTeamID(cb.Team.ID), EnterpriseID: strings.TrimSpace(cb.Enterprise.ID)}
+}
+
+// noteSlackWorkspace remembers the workspace of a channel, so replies to it
+// use that workspace's bot token.
+func (b *bridge) noteSlackWorkspace(accountID, channelID, teamID string) {
+	channelID, teamID = strings.TrimSpace(channelID), normalizeSlackTeamID(teamID)
+	if channelID == "" || teamID == "" {
+		return
+	}
+	b.slackWorkspaceMu.Lock()
+	defer b.slackWorkspaceMu.Unlock()
+	if b.slackChannelWorkspaces == nil {
+		b.slackChannelWorkspaces = map[string]string{}
+	}
+	b.slackChannelWorkspaces[normalizeAccountID(accountID)+"\x00"+channelID] = teamID
+}
+
+// slackChannelAccount picks the bot token for a channel: the workspace the
+// account was already switched to, else the workspace the channel was last
+// seen in.
+func (b *bridge) slackChannelAccount(acct slackAccount, channelID string) slackAccount {
+	if acct.WorkspaceID != "" || len(acct.WorkspaceTokens) == 0 {
+		return acct
+	}
+	b.slackWorkspaceMu.Lock()
+	teamID := b.slackChannelWorkspaces[normalizeAccountID(acct.ID)+"\x00"+strings.TrimSpace(channelID)]
+	b.slackWorkspaceMu.Unlock()
+	return acct.forWorkspace(teamID)
+}
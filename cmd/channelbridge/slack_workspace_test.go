@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSlackWorkspaceForwardedAndRepliedWithItsToken(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		inbound map[string]any
+		tokens  []string
+	)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/channels/slack/inbound" {
+			mu.Lock()
+			_ = json.NewDecoder(r.Body).Decode(&inbound)
+			mu.Unlock()
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer api.Close()
+	slackAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/chat.postMessage" {
+			_ = r.ParseForm()
+			mu.Lock()
+			tokens = append(tokens, firstNonEmpty(r.FormValue("token"), strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")))
+			mu.Unlock()
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "ts": "1"})
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer slackAPI.Close()
+
+	b := newTestBridge(api.URL)
+	b.cfg.SlackAPIBase = slackAPI.URL
+	b.cfg.SlackBotToken = "xoxb-org"
+	b.cfg.SlackWorkspaceTokens = parseSlackWorkspaceTokens("T2=xoxb-t2, bad, T3=")
+
+	body, _ := json.Marshal(map[string]any{
+		"type":          "event_callback",
+		"event_id":      "EvGrid",
+		"team_id":       "T1",
+		"enterprise_id": "E1",
+		"event": map[string]any{
+			"type":         "message",
+			"team":         "t2",
+			"channel":      "C200",
+			"user":         "U1",
+			"text":         "hello",
+			"channel_type": "channel",
+			"ts":           "1700000.002",
+		},
+	})
+	w := httptest.NewRecorder()
+	b.handleSlackEvents(w, httptest.NewRequest(http.MethodPost, "/slack/events", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	mu.Lock()
+	if inbound["workspace_id"] != "T2" || inbound["enterprise_id"] != "E1" {
+		t.Fatalf("expected the event's workspace in the inbound payload, got %#v", inbound)
+	}
+	mu.Unlock()
+
+	send := func(req map[string]any) {
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		b.handleSlackOutbound(w, httptest.NewRequest(http.MethodPost, "/slack/outbound", bytes.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+		}
+	}
+	send(map[string]any{"chat_id": "C200", "content": "reply"})
+	send(map[string]any{"chat_id": "C300", "content": "elsewhere"})
+	send(map[string]any{"chat_id": "C300", "workspace_id": "T2", "content": "explicit"})
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"xoxb-t2", "xoxb-org", "xoxb-t2"}
+	if strings.Join(tokens, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected tokens %v, got %v", want, tokens)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisStateStore shares state through Redis. Conversations and polls are
+// hashes, inbound claims are keys expiring with their dedupe TTL, and each
+// replica's queues are one key per instance ID. All keys start with the
+// configured prefix.
+type redisStateStore struct {
+	client     *redis.Client
+	prefix     string
+	instanceID string
+}
+
+func openRedisStateStore(rawURL, prefix, instanceID string) (*redisStateStore, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return nil, errors.New("CHANNEL_BRIDGE_STATE_URL must be a redis:// URL")
+	}
+	opts, err := redis.ParseURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	client := redis.NewClient(opts)
+	ctx, cancel := stateContext()
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	return &redisStateStore{client: client, prefix: prefix, instanceID: instanceID}, nil
+}
+
+func (s *redisStateStore) key(parts ...string) string {
+	return s.prefix + strings.Join(parts, ":")
+}
+
+func (s *redisStateStore) Load(ctx context.Context) (bridgeState, error) {
+	st := bridgeState{
+		TeamsConvByID:     map[string]teamsConversationRef{},
+		TeamsConvByUserID: map[string]teamsConversationRef{},
+		TeamsPolls:        map[string]map[string]any{},
+	}
+	convs, err := s.client.HGetAll(ctx, s.key("teams_conversations")).Result()
+	if err != nil {
+		return st, err
+	}
+	for key, raw := range convs {
+		var ref teamsConversationRef
+		if json.Unmarshal([]byte(raw), &ref) == nil {
+			splitTeamsConversationKey(&st, key, ref)
+		}
+	}
+	polls, err := s.client.HGetAll(ctx, s.key("teams_polls")).Result()
+	if err != nil {
+		return st, err
+	}
+	for key, raw := range polls {
+		var poll map[string]any
+		if json.Unmarshal([]byte(raw), &poll) == nil {
+			st.TeamsPolls[key] = poll
+		}
+	}
+	raw, err := s.client.Get(ctx, s.key("instance", s.instanceID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return st, nil
+	}
+	if err != nil {
+		return st, err
+	}
+	var q instanceQueues
+	if err := json.Unmarshal([]byte(raw), &q); err != nil {
+		return st, err
+	}
+	st.InboundQueue, st.OutboundQueue, st.OutboundDead = q.InboundQueue, q.OutboundQueue, q.OutboundDead
+	st.Scheduled = q.Scheduled
+	return st, nil
+}
+
+func (s *redisStateStore) Save(ctx context.Context, st bridgeState) error {
+	data, err := json.Marshal(queuesOf(st))
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, s.key("instance", s.instanceID), data, 0).Err()
+}
+
+func (s *redisStateStore) PutTeamsConversation(ctx context.Context, key string, ref teamsConversationRef) error {
+	data, err := json.Marshal(ref)
+	if err != nil {
+		return err
+	}
+	return s.client.HSet(ctx, s.key("teams_conversations"), key, data).Err()
+}
+
+func (s *redisStateStore) TeamsConversation(ctx context.Context, key string) (teamsConversationRef, bool, error) {
+	raw, err := s.client.HGet(ctx, s.key("teams_conversations"), key).Result()
+	if errors.Is(err, redis.Nil) {
+		return teamsConversationRef{}, false, nil
+	}
+	if err != nil {
+		return teamsConversationRef{}, false, err
+	}
+	var ref teamsConversationRef
+	if err := json.Unmarshal([]byte(raw), &ref); err != nil {
+		return teamsConversationRef{}, false, err
+	}
+	return ref, true, nil
+}
+
+func (s *redisStateStore) ClaimInbound(ctx context.Context, key string, until time.Time) (bool, error) {
+	ttl := time.Until(until)
+	if ttl <= 0 {
+		return true, nil
+	}
+	return s.client.SetNX(ctx, s.key("inbound_seen", key), 1, ttl).Result()
+}
+
+func (s *redisStateStore) ReleaseInbound(ctx context.Context, key string) error {
+	return s.client.Del(ctx, s.key("inbound_seen", key)).Err()
+}
+
+func (s *redisStateStore) PutTeamsPoll(ctx context.Context, key string, poll map[string]any) error {
+	data, err := json.Marshal(poll)
+	if err != nil {
+		return err
+	}
+	return s.client.HSet(ctx, s.key("teams_polls"), key, data).Err()
+}
+
+func (s *redisStateStore) TeamsPoll(ctx context.Context, key string) (map[string]any, bool, error) {
+	raw, err := s.client.HGet(ctx, s.key("teams_polls"), key).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var poll map[string]any
+	if err := json.Unmarshal([]byte(raw), &poll); err != nil {
+		return nil, false, err
+	}
+	return poll, true, nil
+}
+
+func (s *redisStateStore) Close() error { return s.client.Close() }
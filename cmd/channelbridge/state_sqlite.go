@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const sqliteStateSchema = `
+CREATE TABLE IF NOT EXISTS teams_conversations (
+	key TEXT PRIMARY KEY,
+	ref TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS inbound_seen (
+	key TEXT PRIMARY KEY,
+	expires_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_inbound_seen_expires ON inbound_seen(expires_at);
+CREATE TABLE IF NOT EXISTS teams_polls (
+	key TEXT PRIMARY KEY,
+	poll TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS instance_state (
+	instance_id TEXT PRIMARY KEY,
+	queues TEXT NOT NULL,
+	updated_at INTEGER NOT NULL
+);
+`
+
+// sqliteStateStore shares state through one SQLite database, for replicas on
+// one host or on a shared volume.
+type sqliteStateStore struct {
+	db         *sql.DB
+	instanceID string
+}
+
+func openSQLiteStateStore(path, instanceID string) (*sqliteStateStore, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, errors.New("CHANNEL_BRIDGE_STATE_URL must name the SQLite database file")
+	}
+	path = strings.TrimPrefix(path, "sqlite://")
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(sqliteStateSchema); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &sqliteStateStore{db: db, instanceID: instanceID}, nil
+}
+
+func (s *sqliteStateStore) Load(ctx context.Context) (bridgeState, error) {
+	st := bridgeState{
+		TeamsConvByID:     map[string]teamsConversationRef{},
+		TeamsConvByUserID: map[string]teamsConversationRef{},
+		TeamsPolls:        map[string]map[string]any{},
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT key, ref FROM teams_conversations`)
+	if err != nil {
+		return st, err
+	}
+	for rows.Next() {
+		var key, raw string
+		var ref teamsConversationRef
+		if err := rows.Scan(&key, &raw); err != nil {
+			rows.Close()
+			return st, err
+		}
+		if json.Unmarshal([]byte(raw), &ref) == nil {
+			splitTeamsConversationKey(&st, key, ref)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return st, err
+	}
+
+	rows, err = s.db.QueryContext(ctx, `SELECT key, poll FROM teams_polls`)
+	if err != nil {
+		return st, err
+	}
+	for rows.Next() {
+		var key, raw string
+		var poll map[string]any
+		if err := rows.Scan(&key, &raw); err != nil {
+			rows.Close()
+			return st, err
+		}
+		if json.Unmarshal([]byte(raw), &poll) == nil {
+			st.TeamsPolls[key] = poll
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return st, err
+	}
+
+	var raw string
+	err = s.db.QueryRowContext(ctx, `SELECT queues FROM instance_state WHERE instance_id = ?`, s.instanceID).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return st, nil
+	}
+	if err != nil {
+		return st, err
+	}
+	var q instanceQueues
+	if err := json.Unmarshal([]byte(raw), &q); err != nil {
+		return st, err
+	}
+	st.InboundQueue, st.OutboundQueue, st.OutboundDead = q.InboundQueue, q.OutboundQueue, q.OutboundDead
+	st.Scheduled = q.Scheduled
+	return st, nil
+}
+
+func (s *sqliteStateStore) Save(ctx context.Context, st bridgeState) error {
+	data, err := json.Marshal(queuesOf(st))
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `INSERT INTO instance_state (instance_id, queues, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(instance_id) DO UPDATE SET queues = excluded.queues, updated_at = excluded.updated_at`,
+		s.instanceID, string(data), time.Now().Unix())
+	return err
+}
+
+func (s *sqliteStateStore) PutTeamsConversation(ctx context.Context, key string, ref teamsConversationRef) error {
+	data, err := json.Marshal(ref)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `INSERT INTO teams_conversations (key, ref) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET ref = excluded.ref`, key, string(data))
+	return err
+}
+
+func (s *sqliteStateStore) TeamsConversation(ctx context.Context, key string) (teamsConversationRef, bool, error) {
+	var raw string
+	err := s.db.QueryRowContext(ctx, `SELECT ref FROM teams_conversations WHERE key = ?`, key).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return teamsConversationRef{}, false, nil
+	}
+	if err != nil {
+		return teamsConversationRef{}, false, err
+	}
+	var ref teamsConversationRef
+	if err := json.Unmarshal([]byte(raw), &ref); err != nil {
+		return teamsConversationRef{}, false, err
+	}
+	return ref, true, nil
+}
+
+// ClaimInbound drops an expired claim of the key, then inserts it; the
+// insert only takes effect when no replica holds the key.
+func (s *sqliteStateStore) ClaimInbound(ctx context.Context, key string, until time.Time) (bool, error) {
+	now := time.Now().UnixMilli()
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM inbound_seen WHERE expires_at <= ?`, now); err != nil {
+		return false, err
+	}
+	res, err := s.db.ExecContext(ctx, `INSERT OR IGNORE INTO inbound_seen (key, expires_at) VALUES (?, ?)`, key, until.UnixMilli())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (s *sqliteStateStore) ReleaseInbound(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM inbound_seen WHERE key = ?`, key)
+	return err
+}
+
+func (s *sqliteStateStore) PutTeamsPoll(ctx context.Context, key string, poll map[string]any) error {
+	data, err := json.Marshal(poll)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `INSERT INTO teams_polls (key, poll) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET poll = excluded.poll`, key, string(data))
+	return err
+}
+
+func (s *sqliteStateStore) TeamsPoll(ctx context.Context, key string) (map[string]any, bool, error) {
+	var raw string
+	err := s.db.QueryRowContext(ctx, `SELECT poll FROM teams_polls WHERE key = ?`, key).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var poll map[string]any
+	if err := json.Unmarshal([]byte(raw), &poll); err != nil {
+		return nil, false, err
+	}
+	return poll, true, nil
+}
+
+func (s *sqliteStateStore) Close() error { return s.db.Close() }
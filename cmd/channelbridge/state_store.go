@@ -0,0 +1,278 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// State backends (CHANNEL_BRIDGE_STATE_BACKEND).
+const (
+	stateBackendFile   = "file"
+	stateBackendSQLite = "sqlite"
+	stateBackendRedis  = "redis"
+)
+
+// stateStoreTimeout bounds one call to a shared state backend.
+const stateStoreTimeout = 3 * time.Second
+
+// stateStore persists the bridge state. The file backend keeps everything in
+// one JSON file for a single bridge. The shared backends (SQLite, Redis) let
+// horizontally scaled replicas share Teams conversation references, inbound
+// dedupe keys and polls: those are written through as they change and read
+// on a local miss. Retry queues stay per replica (keyed by instance ID),
+// since only the replica that queued an item retries it.
+type stateStore interface {
+	// Load returns the stored state: the shared entries and this instance's
+	// queues. A missing state is empty.
+	Load(ctx context.Context) (bridgeState, error)
+	// Save stores a snapshot. The file backend writes all of it; shared
+	// backends write the instance's queues only.
+	Save(ctx context.Context, st bridgeState) error
+	// PutTeamsConversation stores a conversation reference under a
+	// "id:<conversation>" or "user:<user>" key.
+	PutTeamsConversation(ctx context.Context, key string, ref teamsConversationRef) error
+	TeamsConversation(ctx context.Context, key string) (teamsConversationRef, bool, error)
+	// ClaimInbound records an inbound dedupe key until the given time. It
+	// reports false when another replica claimed the key and it has not
+	// expired.
+	ClaimInbound(ctx context.Context, key string, until time.Time) (bool, error)
+	// ReleaseInbound drops a claim, so a redelivery of an event that was
+	// not forwarded is taken again.
+	ReleaseInbound(ctx context.Context, key string) error
+	PutTeamsPoll(ctx context.Context, key string, poll map[string]any) error
+	TeamsPoll(ctx context.Context, key string) (map[string]any, bool, error)
+	Close() error
+}
+
+// openStateStore opens the backend selected by the config.
+func openStateStore(cfg config) (stateStore, error) {
+	switch backend := strings.ToLower(strings.TrimSpace(cfg.StateBackend)); backend {
+	case "", stateBackendFile:
+		return fileStateStore{path: cfg.StatePath}, nil
+	case stateBackendSQLite:
+		s, err := openSQLiteStateStore(cfg.StateURL, cfg.InstanceID)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	case stateBackendRedis:
+		s, err := openRedisStateStore(cfg.StateURL, cfg.StatePrefix, cfg.InstanceID)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unknown CHANNEL_BRIDGE_STATE_BACKEND %q (want file, sqlite or redis)", backend)
+	}
+}
+
+// stateStore returns the configured backend, or the file backend of
+// cfg.StatePath when none is set.
+func (b *bridge) stateStore() stateStore {
+	if b.state != nil {
+		return b.state
+	}
+	return fileStateStore{path: b.cfg.StatePath}
+}
+
+func stateContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), stateStoreTimeout)
+}
+
+// defaultInstanceID names this replica in shared state: the hostname, which
+// is the pod name on Kubernetes.
+func defaultInstanceID() string {
+	if h, err := os.Hostname(); err == nil && strings.TrimSpace(h) != "" {
+		return strings.TrimSpace(h)
+	}
+	return "channelbridge"
+}
+
+// fileStateStore keeps the whole state in one JSON file. It shares nothing,
+// so the shared-entry methods are no-ops.
+type fileStateStore struct {
+	path string
+}
+
+func (s fileStateStore) Load(context.Context) (bridgeState, error) {
+	var st bridgeState
+	path := strings.TrimSpace(s.path)
+	if path == "" {
+		return st, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return st, nil
+		}
+		return st, err
+	}
+	err = json.Unmarshal(data, &st)
+	return st, err
+}
+
+// Save writes a temporary file and renames it, so a kill mid-write never
+// leaves a truncated state file. Callers serialize saves.
+func (s fileStateStore) Save(_ context.Context, st bridgeState) error {
+	path := strings.TrimSpace(s.path)
+	if path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (fileStateStore) PutTeamsConversation(context.Context, string, teamsConversationRef) error {
+	return nil
+}
+
+func (fileStateStore) TeamsConversation(context.Context, string) (teamsConversationRef, bool, error) {
+	return teamsConversationRef{}, false, nil
+}
+
+func (fileStateStore) ClaimInbound(context.Context, string, time.Time) (bool, error) {
+	return true, nil
+}
+
+func (fileStateStore) ReleaseInbound(context.Context, string) error { return nil }
+
+func (fileStateStore) PutTeamsPoll(context.Context, string, map[string]any) error { return nil }
+
+func (fileStateStore) TeamsPoll(context.Context, string) (map[string]any, bool, error) {
+	return nil, false, nil
+}
+
+func (fileStateStore) Close() error { return nil }
+
+// instanceQueues is the per-replica part of the state in shared backends.
+type instanceQueues struct {
+	InboundQueue  []queuedInbound  `json:"inbound_queue,omitempty"`
+	OutboundQueue []queuedOutbound `json:"outbound_queue,omitempty"`
+	OutboundDead  []queuedOutbound `json:"outbound_dead,omitempty"`
+	// Scheduled sends are per replica too: each replica sends its own.
+	Scheduled []scheduledMessage `json:"scheduled,omitempty"`
+}
+
+func queuesOf(st bridgeState) instanceQueues {
+	return instanceQueues{InboundQueue: st.InboundQueue, OutboundQueue: st.OutboundQueue, OutboundDead: st.OutboundDead, Scheduled: st.Scheduled}
+}
+
+// splitTeamsConversationKey maps a stored key to the state map it belongs to.
+func splitTeamsConversationKey(st *bridgeState, key string, ref teamsConversationRef) {
+	if id, ok := strings.CutPrefix(key, "id:"); ok {
+		st.TeamsConvByID[id] = ref
+	} else if id, ok := strings.CutPrefix(key, "user:"); ok {
+		st.TeamsConvByUserID[id] = ref
+	}
+}
+
+// rememberTeamsConversation records the conversation reference of a chat
+// (and of its user) locally and in the shared state.
+func (b *bridge) rememberTeamsConversation(ref teamsConversationRef, userID string) {
+	b.teamsMu.Lock()
+	b.teamsConvByID[ref.ConversationID] = ref
+	if userID != "" {
+		b.teamsConvByUserID[userID] = ref
+	}
+	b.teamsMu.Unlock()
+
+	store := b.stateStore()
+	ctx, cancel := stateContext()
+	defer cancel()
+	if err := store.PutTeamsConversation(ctx, "id:"+ref.ConversationID, ref); err != nil {
+		b.noteStateError("put teams conversation", err)
+	}
+	if userID != "" {
+		if err := store.PutTeamsConversation(ctx, "user:"+userID, ref); err != nil {
+			b.noteStateError("put teams conversation", err)
+		}
+	}
+	_ = b.saveState()
+}
+
+// sharedTeamsConversation looks up a conversation another replica learned
+// and caches it.
+func (b *bridge) sharedTeamsConversation(id string) (teamsConversationRef, bool) {
+	ctx, cancel := stateContext()
+	defer cancel()
+	for _, key := range []string{"id:" + id, "user:" + id} {
+		ref, ok, err := b.stateStore().TeamsConversation(ctx, key)
+		if err != nil {
+			b.noteStateError("get teams conversation", err)
+			return teamsConversationRef{}, false
+		}
+		if ok && ref.ServiceURL != "" {
+			b.teamsMu.Lock()
+			if strings.HasPrefix(key, "id:") {
+				b.teamsConvByID[id] = ref
+			} else {
+				b.teamsConvByUserID[id] = ref
+			}
+			b.teamsMu.Unlock()
+			return ref, true
+		}
+	}
+	return teamsConversationRef{}, false
+}
+
+// cloneTeamsPoll deep-copies a poll, so it can be stored while later votes
+// change the original.
+func cloneTeamsPoll(p map[string]any) map[string]any {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return p
+	}
+	var out map[string]any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return p
+	}
+	return out
+}
+
+// storeTeamsPoll writes a poll through to the shared state.
+func (b *bridge) storeTeamsPoll(key string, poll map[string]any) {
+	ctx, cancel := stateContext()
+	defer cancel()
+	if err := b.stateStore().PutTeamsPoll(ctx, key, poll); err != nil {
+		b.noteStateError("put teams poll", err)
+	}
+}
+
+// sharedTeamsPoll reads a poll from the shared state, so a vote landing on
+// another replica than the one that sent the poll still counts, on top of
+// the votes already recorded there.
+func (b *bridge) sharedTeamsPoll(key string) (map[string]any, bool) {
+	ctx, cancel := stateContext()
+	defer cancel()
+	poll, ok, err := b.stateStore().TeamsPoll(ctx, key)
+	if err != nil {
+		b.noteStateError("get teams poll", err)
+		return nil, false
+	}
+	return poll, ok
+}
+
+// noteStateError records a failed shared state call. The bridge keeps
+// working on its local state.
+func (b *bridge) noteStateError(op string, err error) {
+	b.metricsMu.Lock()
+	b.metrics.StateStoreErrors++
+	b.metricsMu.Unlock()
+	slog.Warn("channelbridge state store error", "op", op, "backend", b.cfg.StateBackend, "error", err)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// sharedStateBackends opens, per shared backend, a store for a replica with
+// the given instance ID; all replicas of one backend share its state.
+func sharedStateBackends(t *testing.T) map[string]func(instanceID string) stateStore {
+	t.Helper()
+	dbPath := filepath.Join(t.TempDir(), "state.db")
+	mr := miniredis.RunT(t)
+	open := func(cfg config) stateStore {
+		s, err := openStateStore(cfg)
+		if err != nil {
+			t.Fatalf("open %s store: %v", cfg.StateBackend, err)
+		}
+		t.Cleanup(func() { _ = s.Close() })
+		return s
+	}
+	return map[string]func(string) stateStore{
+		stateBackendSQLite: func(id string) stateStore {
+			return open(config{StateBackend: stateBackendSQLite, StateURL: dbPath, InstanceID: id})
+		},
+		stateBackendRedis: func(id string) stateStore {
+			return open(config{StateBackend: stateBackendRedis, StateURL: "redis://" + mr.Addr(), StatePrefix: "test:", InstanceID: id})
+		},
+	}
+}
+
+func newReplica(store stateStore) *bridge {
+	b := newTestBridge("http://example.invalid")
+	b.state = store
+	return b
+}
+
+func TestSharedStateStoreSharesTeamsStateAcrossReplicas(t *testing.T) {
+	for name, open := range sharedStateBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			a, b := newReplica(open("a")), newReplica(open("b"))
+
+			a.rememberTeamsConversation(teamsConversationRef{ServiceURL: "https://smba.example", ConversationID: "conv-1", UserID: "user-1"}, "user-1")
+			ref, err := b.resolveTeamsConversation("user:user-1")
+			if err != nil || ref.ConversationID != "conv-1" {
+				t.Fatalf("expected replica b to resolve the conversation of a, got %+v err=%v", ref, err)
+			}
+
+			now := time.Now()
+			if a.seenInboundEvent("teams:msg:conv-1:m1", now) {
+				t.Fatal("expected the first delivery to be new")
+			}
+			if !b.seenInboundEvent("teams:msg:conv-1:m1", now) {
+				t.Fatal("expected the redelivery to another replica to be deduped")
+			}
+
+			pollID := a.recordPoll("msteams", "", "conv-1", "Lunch?", []string{"Sushi", "Pizza"}, 1)
+			vote := func(r *bridge, user, choice string) map[string]any {
+				ok, details := r.handleTeamsPollVote("conv-1", user, map[string]any{
+					"value": map[string]any{"poll_id": pollID, "poll_choice": choice},
+				})
+				if !ok {
+					t.Fatalf("expected vote of %s recorded", user)
+				}
+				// The vote is written through in the background.
+				deadline := time.Now().Add(2 * time.Second)
+				for time.Now().Before(deadline) {
+					p, _ := r.sharedTeamsPoll(pollID)
+					if votes, _ := p["votes"].(map[string]any); votes[user] != nil {
+						break
+					}
+					time.Sleep(10 * time.Millisecond)
+				}
+				return details
+			}
+			vote(b, "user-2", "opt_1")
+			if details := vote(a, "user-3", "opt_2"); details["total_votes"] != 2 {
+				t.Fatalf("expected votes of both replicas counted, got %#v", details)
+			}
+		})
+	}
+}
+
+func TestSharedStateStoreKeepsQueuesPerInstance(t *testing.T) {
+	for name, open := range sharedStateBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			a := newReplica(open("a"))
+			a.outboundQueue = []queuedOutbound{{ID: "out-1", Channel: "slack", ChatID: "C1"}}
+			if err := a.saveState(); err != nil {
+				t.Fatalf("save: %v", err)
+			}
+
+			other := newReplica(open("b"))
+			if err := other.loadState(); err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			if len(other.outboundQueue) != 0 {
+				t.Fatalf("expected no queue of replica a on replica b, got %+v", other.outboundQueue)
+			}
+
+			restarted := newReplica(open("a"))
+			if err := restarted.loadState(); err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			if len(restarted.outboundQueue) != 1 || restarted.outboundQueue[0].ID != "out-1" {
+				t.Fatalf("expected the queue back after a restart, got %+v", restarted.outboundQueue)
+			}
+		})
+	}
+}
+
+func TestOpenStateStoreRejectsUnknownBackend(t *testing.T) {
+	if _, err := openStateStore(config{StateBackend: "etcd"}); err == nil {
+		t.Fatal("expected an error for an unknown backend")
+	}
+	if _, err := openStateStore(config{StateBackend: stateBackendSQLite}); err == nil {
+		t.Fatal("expected an error for sqlite without CHANNEL_BRIDGE_STATE_URL")
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// teamsReactionEmoji maps the Slack-style reaction names agents use to the
+// emoji posted in Teams.
+var teamsReactionEmoji = map[string]string{
+	"+1":               "👍",
+	"thumbsup":         "👍",
+	"-1":               "👎",
+	"thumbsdown":       "👎",
+	"heart":            "❤️",
+	"joy":              "😂",
+	"laughing":         "😆",
+	"open_mouth":       "😮",
+	"cry":              "😢",
+	"angry":            "😠",
+	"eyes":             "👀",
+	"white_check_mark": "✅",
+	"heavy_check_mark": "✔️",
+	"x":                "❌",
+	"tada":             "🎉",
+	"rocket":           "🚀",
+	"hourglass":        "⌛",
+	"warning":          "⚠️",
+}
+
+// handleTeamsAction runs the message actions of /teams/outbound against an
+// existing conversation and writes the result as {"ok":true,"result":...}.
+func (b *bridge) handleTeamsAction(w http.ResponseWriter, accountID, chatID, action, content string, params map[string]any) {
+	ref, err := b.resolveTeamsConversation(chatID)
+	if err != nil {
+		b.noteOutbound(false, "teams", err)
+		writeOutboundError(w, err, false)
+		return
+	}
+	token, err := b.getTeamsAccessToken(b.teamsAccountForRef(accountID, ref))
+	if err != nil {
+		b.noteOutbound(false, "teams", err)
+		writeOutboundError(w, err, false)
+		return
+	}
+	result, err := b.teamsHandleAction(ref, token, action, content, params)
+	if err != nil {
+		b.noteOutbound(false, "teams", err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	b.noteOutbound(true, "teams", nil)
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": result})
+}
+
+// teamsHandleAction is the Teams counterpart of slackHandleAction. Edit and
+// delete use the Bot Framework updateActivity and deleteActivity calls, which
+// only work on the bot's own messages. Bots cannot add reactions, so react
+// replies to the message with the emoji instead.
+func (b *bridge) teamsHandleAction(ref teamsConversationRef, accessToken, action, content string, params map[string]any) (map[string]any, error) {
+	msgID := strings.TrimSpace(asString(params["message_id"]))
+	switch action {
+	case "edit":
+		text := strings.TrimSpace(content)
+		if text == "" {
+			text = strings.TrimSpace(asString(params["text"]))
+		}
+		card, _ := params["card"].(map[string]any)
+		if msgID == "" || (text == "" && len(card) == 0) {
+			return nil, errors.New("edit requires action_params.message_id and content/text or card")
+		}
+		activity := map[string]any{"type": "message", "id": msgID, "text": text}
+		if len(card) > 0 {
+			activity["attachments"] = []map[string]any{{
+				"contentType": "application/vnd.microsoft.card.adaptive",
+				"content":     card,
+			}}
+		}
+		body, _ := json.Marshal(activity)
+		if err := b.teamsActivityRequest(http.MethodPut, ref, accessToken, msgID, body); err != nil {
+			return nil, err
+		}
+		return map[string]any{"ok": true, "conversation_id": ref.ConversationID, "message_id": msgID}, nil
+	case "delete":
+		if msgID == "" {
+			return nil, errors.New("delete requires action_params.message_id")
+		}
+		if err := b.teamsActivityRequest(http.MethodDelete, ref, accessToken, msgID, nil); err != nil {
+			return nil, err
+		}
+		return map[string]any{"ok": true, "conversation_id": ref.ConversationID, "message_id": msgID}, nil
+	case "react":
+		emoji := strings.Trim(strings.TrimSpace(asString(params["emoji"])), ":")
+		if emoji == "" || msgID == "" {
+			return nil, errors.New("react requires action_params.emoji and action_params.message_id")
+		}
+		if mapped, ok := teamsReactionEmoji[strings.ToLower(emoji)]; ok {
+			emoji = mapped
+		}
+		activityID, err := b.teamsSend(ref, accessToken, msgID, emoji, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"ok": true, "conversation_id": ref.ConversationID, "message_id": activityID, "reply_to_id": msgID}, nil
+	default:
+		return nil, fmt.Errorf("unsupported teams action: %s", action)
+	}
+}
+
+// teamsActivityRequest sends method to an existing activity of the
+// conversation, retrying throttling and server errors.
+func (b *bridge) teamsActivityRequest(method string, ref teamsConversationRef, accessToken, activityID string, body []byte) error {
+	base := strings.TrimRight(ref.ServiceURL, "/")
+	if apiBase := strings.TrimSpace(b.cfg.MSTeamsAPIBase); apiBase != "" {
+		base = strings.TrimRight(apiBase, "/")
+	}
+	u := fmt.Sprintf("%s/v3/conversations/%s/activities/%s", base, url.PathEscape(ref.ConversationID), url.PathEscape(activityID))
+	return withRetry(3, 300*time.Millisecond, func() (bool, error) {
+		req, err := http.NewRequest(method, u, bytes.NewReader(body))
+		if err != nil {
+			return false, err
+		}
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := b.client.Do(req)
+		if err != nil {
+			return true, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 300 {
+			return false, nil
+		}
+		bb, _ := io.ReadAll(resp.Body)
+		if d := parseRetryAfter(resp.Header.Get("Retry-After")); d > 0 {
+			time.Sleep(d)
+		}
+		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retryable, fmt.Errorf("teams %s activity failed: status=%d body=%s", strings.ToLower(method), resp.StatusCode, strings.TrimSpace(string(bb)))
+	})
+}
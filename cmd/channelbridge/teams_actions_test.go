@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTeamsOutboundMessageActions(t *testing.T) {
+	type call struct {
+		method, path string
+		body         map[string]any
+	}
+	var (
+		mu    sync.Mutex
+		calls []call
+	)
+	teamsAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		calls = append(calls, call{r.Method, r.URL.Path, body})
+		mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": "act-9"})
+	}))
+	defer teamsAPI.Close()
+
+	b := newTestBridge("http://example.invalid")
+	b.cfg.MSTeamsAPIBase = teamsAPI.URL
+	b.teamsMu.Lock()
+	b.teamsConvByID["conv-1"] = teamsConversationRef{ServiceURL: teamsAPI.URL, ConversationID: "conv-1", UserID: "u1"}
+	b.teamsToken = tokenCache{accessToken: "token", expiresAt: time.Now().Add(30 * time.Minute)}
+	b.teamsMu.Unlock()
+
+	send := func(body map[string]any) *httptest.ResponseRecorder {
+		raw, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		b.handleTeamsOutbound(w, httptest.NewRequest(http.MethodPost, "/teams/outbound", bytes.NewReader(raw)))
+		return w
+	}
+	for _, body := range []map[string]any{
+		{"chat_id": "conv-1", "action": "edit", "content": "updated", "action_params": map[string]any{"message_id": "act-1"}},
+		{"chat_id": "conv-1", "action": "delete", "action_params": map[string]any{"message_id": "act-2"}},
+		{"chat_id": "conv-1", "action": "react", "action_params": map[string]any{"message_id": "act-3", "emoji": ":thumbsup:"}},
+	} {
+		if w := send(body); w.Code != http.StatusOK {
+			t.Fatalf("%v: status=%d body=%s", body["action"], w.Code, w.Body.String())
+		}
+	}
+	if w := send(map[string]any{"chat_id": "conv-1", "action": "edit", "action_params": map[string]any{"message_id": "act-1"}}); w.Code != http.StatusBadGateway {
+		t.Fatalf("expected edit without text to fail, got %d", w.Code)
+	}
+	if w := send(map[string]any{"chat_id": "conv-1", "action": "pin", "action_params": map[string]any{"message_id": "act-1"}}); w.Code != http.StatusBadGateway {
+		t.Fatalf("expected unsupported action to fail, got %d", w.Code)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(calls) != 3 {
+		t.Fatalf("expected 3 Bot Framework calls, got %+v", calls)
+	}
+	if c := calls[0]; c.method != http.MethodPut || c.path != "/v3/conversations/conv-1/activities/act-1" || c.body["text"] != "updated" || c.body["id"] != "act-1" {
+		t.Fatalf("unexpected update call: %+v", c)
+	}
+	if c := calls[1]; c.method != http.MethodDelete || c.path != "/v3/conversations/conv-1/activities/act-2" {
+		t.Fatalf("unexpected delete call: %+v", c)
+	}
+	if c := calls[2]; c.method != http.MethodPost || c.path != "/v3/conversations/conv-1/activities" || c.body["text"] != "👍" || c.body["replyToId"] != "act-3" {
+		t.Fatalf("unexpected reaction reply: %+v", c)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	mdHeadingRe     = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	mdBulletRe      = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	mdOrderedRe     = regexp.MustCompile(`^\s*(\d+)[.)]\s+(.*)$`)
+	mdRuleRe        = regexp.MustCompile(`^\s*([-*_])(\s*[-*_]){2,}\s*$`)
+	mdBareLinkRe    = regexp.MustCompile(`(^|[\s(])(https?://[^\s)<>]+)`)
+	mdInlineCodeRe  = regexp.MustCompile("`([^`]+)`")
+	mdMarkdownLinks = regexp.MustCompile(`\[[^\]]+\]\([^)]+\)`)
+)
+
+// wantsTeamsCardFormat reports whether outbound content should be rendered as
+// an Adaptive Card. An explicit request format wins over the bridge default.
+func wantsTeamsCardFormat(requested, fallback string) bool {
+	f := strings.ToLower(strings.TrimSpace(requested))
+	if f == "" {
+		f = strings.ToLower(strings.TrimSpace(fallback))
+	}
+	return f == "card"
+}
+
+// markdownToAdaptiveCard converts simple markdown (headings, paragraphs, bullet
+// and numbered lists, fenced code blocks, horizontal rules and links) into an
+// Adaptive Card. TextBlocks support a markdown subset (bold, italic, lists and
+// links) natively, so inline formatting is passed through; block structure is
+// mapped onto card elements. Returns nil for empty content.
+func markdownToAdaptiveCard(content string) map[string]any {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	body := make([]map[string]any, 0, 8)
+	separatorNext := false
+
+	add := func(block map[string]any) {
+		if separatorNext {
+			block["separator"] = true
+			separatorNext = false
+		}
+		body = append(body, block)
+	}
+
+	var para []string
+	flushPara := func() {
+		if len(para) == 0 {
+			return
+		}
+		add(map[string]any{"type": "TextBlock", "text": linkifyBareURLs(strings.Join(para, " ")), "wrap": true})
+		para = nil
+	}
+
+	var list []string
+	flushList := func() {
+		if len(list) == 0 {
+			return
+		}
+		// TextBlock markdown requires list items to be separated by \r.
+		add(map[string]any{"type": "TextBlock", "text": strings.Join(list, "\r"), "wrap": true})
+		list = nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") {
+			flushPara()
+			flushList()
+			lang := strings.TrimSpace(strings.TrimPrefix(trimmed, "```"))
+			var code []string
+			for i++; i < len(lines); i++ {
+				if strings.HasPrefix(strings.TrimSpace(lines[i]), "```") {
+					break
+				}
+				code = append(code, lines[i])
+			}
+			add(codeBlockContainer(strings.Join(code, "\n"), lang))
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			flushPara()
+			flushList()
+		case mdRuleRe.MatchString(trimmed):
+			flushPara()
+			flushList()
+			separatorNext = true
+		case mdHeadingRe.MatchString(trimmed):
+			flushPara()
+			flushList()
+			m := mdHeadingRe.FindStringSubmatch(trimmed)
+			add(map[string]any{
+				"type":   "TextBlock",
+				"text":   stripInlineCode(m[2]),
+				"weight": "Bolder",
+				"size":   headingSize(len(m[1])),
+				"wrap":   true,
+			})
+		case mdBulletRe.MatchString(line):
+			flushPara()
+			m := mdBulletRe.FindStringSubmatch(line)
+			list = append(list, "- "+linkifyBareURLs(stripInlineCode(m[1])))
+		case mdOrderedRe.MatchString(line):
+			flushPara()
+			m := mdOrderedRe.FindStringSubmatch(line)
+			list = append(list, m[1]+". "+linkifyBareURLs(stripInlineCode(m[2])))
+		default:
+			flushList()
+			para = append(para, stripInlineCode(trimmed))
+		}
+	}
+	flushPara()
+	flushList()
+
+	if len(body) == 0 {
+		return nil
+	}
+	return map[string]any{
+		"type":    "AdaptiveCard",
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"version": "1.4",
+		"body":    body,
+		"msteams": map[string]any{"width": "Full"},
+	}
+}
+
+func headingSize(level int) string {
+	switch level {
+	case 1:
+		return "Large"
+	case 2:
+		return "Medium"
+	default:
+		return "Default"
+	}
+}
+
+func codeBlockContainer(code, lang string) map[string]any {
+	items := make([]map[string]any, 0, 2)
+	if lang != "" {
+		items = append(items, map[string]any{"type": "TextBlock", "text": lang, "size": "Small", "isSubtle": true})
+	}
+	items = append(items, map[string]any{
+		"type":     "TextBlock",
+		"text":     code,
+		"fontType": "Monospace",
+		"wrap":     true,
+	})
+	return map[string]any{
+		"type":  "Container",
+		"style": "emphasis",
+		"items": items,
+	}
+}
+
+// stripInlineCode drops inline code backticks, which TextBlock markdown does
+// not support and would otherwise render literally.
+func stripInlineCode(s string) string {
+	return mdInlineCodeRe.ReplaceAllString(s, "$1")
+}
+
+// linkifyBareURLs wraps bare http(s) URLs in markdown link syntax so they are
+// clickable in the card. Existing [text](url) links are left untouched.
+func linkifyBareURLs(s string) string {
+	if !strings.Contains(s, "http") {
+		return s
+	}
+	spans := mdMarkdownLinks.FindAllStringIndex(s, -1)
+	var out strings.Builder
+	last := 0
+	for _, sp := range spans {
+		out.WriteString(mdBareLinkRe.ReplaceAllString(s[last:sp[0]], "$1[$2]($2)"))
+		out.WriteString(s[sp[0]:sp[1]])
+		last = sp[1]
+	}
+	out.WriteString(mdBareLinkRe.ReplaceAllString(s[last:], "$1[$2]($2)"))
+	return out.String()
+}
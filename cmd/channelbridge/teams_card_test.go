@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarkdownToAdaptiveCard(t *testing.T) {
+	md := strings.Join([]string{
+		"# Deploy report",
+		"",
+		"Rollout finished, see https://status.example.com for details.",
+		"",
+		"## Steps",
+		"- build `api`",
+		"- push [image](https://registry.example.com/api)",
+		"1. migrate",
+		"2. restart",
+		"",
+		"---",
+		"```bash",
+		"kubectl rollout status deploy/api",
+		"```",
+	}, "\n")
+	card := markdownToAdaptiveCard(md)
+	if card == nil {
+		t.Fatal("expected card")
+	}
+	if card["type"] != "AdaptiveCard" || card["version"] != "1.4" {
+		t.Fatalf("unexpected card header: %#v", card)
+	}
+	body, _ := card["body"].([]map[string]any)
+	if len(body) != 5 {
+		t.Fatalf("expected 5 body elements, got %d: %#v", len(body), body)
+	}
+	if body[0]["text"] != "Deploy report" || body[0]["size"] != "Large" || body[0]["weight"] != "Bolder" {
+		t.Fatalf("unexpected h1: %#v", body[0])
+	}
+	if got := body[1]["text"].(string); !strings.Contains(got, "[https://status.example.com](https://status.example.com)") {
+		t.Fatalf("expected bare url linkified, got %q", got)
+	}
+	if body[2]["size"] != "Medium" {
+		t.Fatalf("unexpected h2: %#v", body[2])
+	}
+	list := body[3]["text"].(string)
+	if list != "- build api\r- push [image](https://registry.example.com/api)\r1. migrate\r2. restart" {
+		t.Fatalf("unexpected list: %q", list)
+	}
+	code := body[4]
+	if code["type"] != "Container" || code["separator"] != true {
+		t.Fatalf("expected separated code container, got %#v", code)
+	}
+	items := code["items"].([]map[string]any)
+	if len(items) != 2 || items[1]["fontType"] != "Monospace" || items[1]["text"] != "kubectl rollout status deploy/api" {
+		t.Fatalf("unexpected code items: %#v", items)
+	}
+	if _, err := json.Marshal(card); err != nil {
+		t.Fatalf("card not serializable: %v", err)
+	}
+	if markdownToAdaptiveCard("  \n\n") != nil {
+		t.Fatal("expected nil card for blank content")
+	}
+}
+
+func TestTeamsOutboundFormatCard(t *testing.T) {
+	var payload map[string]any
+	teamsAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer teamsAPI.Close()
+
+	b := newTestBridge("http://example.invalid")
+	b.cfg.MSTeamsAPIBase = teamsAPI.URL
+	b.teamsMu.Lock()
+	b.teamsConvByID["conv-1"] = teamsConversationRef{ServiceURL: teamsAPI.URL, ConversationID: "conv-1", UserID: "u1"}
+	b.teamsToken = tokenCache{accessToken: "token", expiresAt: time.Now().Add(30 * time.Minute)}
+	b.teamsMu.Unlock()
+
+	send := func(body map[string]any) {
+		t.Helper()
+		payload = nil
+		raw, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		b.handleTeamsOutbound(w, httptest.NewRequest(http.MethodPost, "/teams/outbound", bytes.NewReader(raw)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+		}
+	}
+
+	send(map[string]any{"chat_id": "conv-1", "content": "# Title\n\nbody", "format": "card"})
+	if payload["text"] != "" {
+		t.Fatalf("expected empty text with card format, got %#v", payload["text"])
+	}
+	atts, _ := payload["attachments"].([]any)
+	if len(atts) != 1 {
+		t.Fatalf("expected card attachment, got %#v", payload["attachments"])
+	}
+	if att := atts[0].(map[string]any); att["contentType"] != "application/vnd.microsoft.card.adaptive" {
+		t.Fatalf("unexpected attachment: %#v", att)
+	}
+
+	b.cfg.MSTeamsOutboundFormat = "card"
+	send(map[string]any{"chat_id": "conv-1", "content": "plain", "format": "text"})
+	if payload["text"] != "plain" || payload["attachments"] != nil {
+		t.Fatalf("expected explicit text format to override default, got %#v", payload)
+	}
+}
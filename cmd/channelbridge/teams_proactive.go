@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// teamsAADObjectIDPattern matches an Entra ID (AAD) object ID.
+var teamsAADObjectIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// teamsProactiveUser reports the user a chat ID addresses when the bridge may
+// open a conversation with it: "user:<id>" targets, AAD object IDs and
+// Bot Framework user IDs ("29:..."). Conversation IDs never qualify.
+func teamsProactiveUser(chatID string) (string, bool) {
+	s := strings.TrimSpace(chatID)
+	l := strings.ToLower(s)
+	explicit := strings.HasPrefix(l, "user:") || strings.HasPrefix(l, "msteams:user:")
+	id := normalizeTeamsTarget(s)
+	if id == "" {
+		return "", false
+	}
+	if explicit || teamsAADObjectIDPattern.MatchString(id) || strings.HasPrefix(id, "29:") {
+		return id, true
+	}
+	return "", false
+}
+
+// resolveOrCreateTeamsConversation returns the stored conversation for
+// chatID. Without one, a user target gets a new 1:1 conversation through the
+// Bot Framework createConversation API, so the agent can message users who
+// never wrote to the bot. The bot must be installed for the user.
+func (b *bridge) resolveOrCreateTeamsConversation(accountID, chatID, tenantID string) (teamsConversationRef, error) {
+	ref, err := b.resolveTeamsConversation(chatID)
+	if err == nil {
+		return ref, nil
+	}
+	userID, ok := teamsProactiveUser(chatID)
+	if !ok {
+		return ref, err
+	}
+	acct := b.teamsAccount(accountID)
+	tenant := strings.TrimSpace(tenantID)
+	if tenant == "" && !strings.EqualFold(acct.TenantID, "botframework.com") {
+		tenant = acct.TenantID
+	}
+	if tenant == "" {
+		return teamsConversationRef{}, fmt.Errorf("no teams conversation reference for %s and no tenant_id to create one", userID)
+	}
+	token, err := b.getTeamsAccessToken(acct)
+	if err != nil {
+		return teamsConversationRef{}, err
+	}
+	ref, err = b.teamsCreateConversation(acct, token, userID, tenant)
+	if err != nil {
+		return teamsConversationRef{}, err
+	}
+	b.rememberTeamsConversation(ref, userID)
+	b.metricsMu.Lock()
+	b.metrics.TeamsConversationsCreated++
+	b.metricsMu.Unlock()
+	slog.Info("teams: created conversation", "conversation", ref.ConversationID, "user", userID)
+	return ref, nil
+}
+
+// teamsCreateConversation calls POST /v3/conversations for a 1:1 chat
+// between the account's bot and userID.
+func (b *bridge) teamsCreateConversation(acct teamsAccount, accessToken, userID, tenantID string) (teamsConversationRef, error) {
+	if acct.AppID == "" {
+		return teamsConversationRef{}, errors.New("missing teams app credentials")
+	}
+	member := map[string]any{"id": userID}
+	if teamsAADObjectIDPattern.MatchString(userID) {
+		member = map[string]any{"id": "8:orgid:" + userID, "aadObjectId": userID}
+	}
+	body, _ := json.Marshal(map[string]any{
+		"bot":         map[string]any{"id": "28:" + acct.AppID},
+		"members":     []map[string]any{member},
+		"isGroup":     false,
+		"tenantId":    tenantID,
+		"channelData": map[string]any{"tenant": map[string]any{"id": tenantID}},
+	})
+	serviceURL := strings.TrimRight(strings.TrimSpace(b.cfg.MSTeamsServiceURL), "/")
+	base := serviceURL
+	if apiBase := strings.TrimSpace(b.cfg.MSTeamsAPIBase); apiBase != "" {
+		base = strings.TrimRight(apiBase, "/")
+	}
+	var ref teamsConversationRef
+	err := withRetry(3, 300*time.Millisecond, func() (bool, error) {
+		req, err := http.NewRequest(http.MethodPost, base+"/v3/conversations", bytes.NewReader(body))
+		if err != nil {
+			return false, err
+		}
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := b.client.Do(req)
+		if err != nil {
+			return true, err
+		}
+		defer resp.Body.Close()
+		bb, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode >= 300 {
+			if d := parseRetryAfter(resp.Header.Get("Retry-After")); d > 0 {
+				time.Sleep(d)
+			}
+			retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+			return retryable, fmt.Errorf("teams create conversation failed: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(bb)))
+		}
+		var out struct {
+			ID         string `json:"id"`
+			ServiceURL string `json:"serviceUrl"`
+		}
+		_ = json.Unmarshal(bb, &out)
+		if strings.TrimSpace(out.ID) == "" {
+			return false, errors.New("teams create conversation: response missing id")
+		}
+		ref = teamsConversationRef{
+			ServiceURL:     serviceURL,
+			ConversationID: strings.TrimSpace(out.ID),
+			UserID:         userID,
+			TenantID:       tenantID,
+			AccountID:      acct.ID,
+		}
+		if s := strings.TrimSpace(out.ServiceURL); s != "" {
+			ref.ServiceURL = s
+		}
+		return false, nil
+	})
+	return ref, err
+}
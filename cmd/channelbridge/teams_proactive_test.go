@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTeamsOutboundCreatesConversationForNewUser(t *testing.T) {
+	const aadID = "6f1c2d3e-4a5b-4c6d-8e9f-0a1b2c3d4e5f"
+	var (
+		mu      sync.Mutex
+		created map[string]any
+		creates int
+		sentTo  []string
+	)
+	teamsAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/v3/conversations":
+			creates++
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "a:new-conv"})
+		case "/v3/conversations/a:new-conv/activities":
+			sentTo = append(sentTo, r.URL.Path)
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "act-1"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer teamsAPI.Close()
+
+	b := newTestBridge("http://example.invalid")
+	b.cfg.MSTeamsAppID = "bot-app"
+	b.cfg.MSTeamsAPIBase = teamsAPI.URL
+	b.cfg.MSTeamsServiceURL = "https://smba.example.test/teams/"
+	b.cfg.MSTeamsTenantID = "botframework.com"
+	b.cfg.StatePath = filepath.Join(t.TempDir(), "state.json")
+	b.teamsToken = tokenCache{accessToken: "token", expiresAt: time.Now().Add(30 * time.Minute)}
+
+	send := func(body map[string]any) *httptest.ResponseRecorder {
+		raw, _ := json.Marshal(body)
+		rec := httptest.NewRecorder()
+		b.handleTeamsOutbound(rec, httptest.NewRequest(http.MethodPost, "/teams/outbound", bytes.NewReader(raw)))
+		return rec
+	}
+
+	// The multi-tenant default tenant cannot address a user.
+	if rec := send(map[string]any{"chat_id": "user:" + aadID, "content": "hi"}); rec.Code != http.StatusBadGateway {
+		t.Fatalf("expected failure without tenant, got %d: %s", rec.Code, rec.Body.String())
+	}
+	// Conversation IDs are never created.
+	if rec := send(map[string]any{"chat_id": "19:unknown@thread.tacv2", "tenant_id": "tenant-1", "content": "hi"}); rec.Code != http.StatusBadGateway {
+		t.Fatalf("expected unknown conversation to fail, got %d", rec.Code)
+	}
+
+	for i := 0; i < 2; i++ {
+		rec := send(map[string]any{"chat_id": "user:" + aadID, "tenant_id": "tenant-1", "content": "hello"})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("send %d: status=%d body=%s", i, rec.Code, rec.Body.String())
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if creates != 1 || len(sentTo) != 2 {
+		t.Fatalf("expected one created conversation reused for two sends, creates=%d sends=%d", creates, len(sentTo))
+	}
+	members, _ := created["members"].([]any)
+	member, _ := members[0].(map[string]any)
+	bot, _ := created["bot"].(map[string]any)
+	if member["aadObjectId"] != aadID || bot["id"] != "28:bot-app" || created["tenantId"] != "tenant-1" {
+		t.Fatalf("unexpected createConversation payload: %#v", created)
+	}
+	ref := b.teamsConvByUserID[aadID]
+	if ref.ConversationID != "a:new-conv" || ref.ServiceURL != "https://smba.example.test/teams" || ref.TenantID != "tenant-1" {
+		t.Fatalf("expected stored conversation reference, got %+v", ref)
+	}
+	if b.metrics.TeamsConversationsCreated != 1 {
+		t.Fatalf("expected created metric, got %+v", b.metrics)
+	}
+}
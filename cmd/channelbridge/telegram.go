@@ -0,0 +1,771 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// telegramChunkChars keeps chunks, with a closed code fence, under the
+	// 4096-character message limit.
+	telegramChunkChars     = 4000
+	telegramPollTimeoutSec = 30
+	telegramPollMaxBackoff = time.Minute
+	telegramSecretHeader   = "X-Telegram-Bot-Api-Secret-Token"
+)
+
+var telegramChatIDPattern = regexp.MustCompile(`^-?[0-9]+$`)
+
+// telegramAPIError is a failed Bot API call (ok=false or a transport error).
+type telegramAPIError struct {
+	status     int
+	message    string
+	retryAfter time.Duration
+}
+
+func (e *telegramAPIError) Error() string {
+	return fmt.Sprintf("telegram api status=%d: %s", e.status, e.message)
+}
+
+type telegramUser struct {
+	ID        int64  `json:"id"`
+	IsBot     bool   `json:"is_bot"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Username  string `json:"username"`
+	// CanReadAllGroupMessages is set on getMe when privacy mode is off, i.e.
+	// the bot sees every group message and not only mentions and commands.
+	CanReadAllGroupMessages bool `json:"can_read_all_group_messages"`
+}
+
+type telegramChat struct {
+	ID       int64  `json:"id"`
+	Type     string `json:"type"` // private, group, supergroup, channel
+	Title    string `json:"title"`
+	Username string `json:"username"`
+	IsForum  bool   `json:"is_forum"`
+}
+
+type telegramMessage struct {
+	MessageID       int64            `json:"message_id"`
+	MessageThreadID int64            `json:"message_thread_id"`
+	IsTopicMessage  bool             `json:"is_topic_message"`
+	From            *telegramUser    `json:"from"`
+	Chat            telegramChat     `json:"chat"`
+	Text            string           `json:"text"`
+	Caption         string           `json:"caption"`
+	Entities        []telegramEntity `json:"entities"`
+	CaptionEntities []telegramEntity `json:"caption_entities"`
+	ReplyToMessage  *telegramMessage `json:"reply_to_message"`
+	Photo           []struct {
+		FileID string `json:"file_id"`
+	} `json:"photo"`
+	Document *struct {
+		FileID   string `json:"file_id"`
+		FileName string `json:"file_name"`
+	} `json:"document"`
+	Voice *struct {
+		FileID string `json:"file_id"`
+	} `json:"voice"`
+}
+
+type telegramEntity struct {
+	Type   string        `json:"type"` // mention, text_mention, bot_command, ...
+	Offset int           `json:"offset"`
+	Length int           `json:"length"`
+	User   *telegramUser `json:"user"`
+}
+
+type telegramUpdate struct {
+	UpdateID      int64            `json:"update_id"`
+	Message       *telegramMessage `json:"message"`
+	EditedMessage *telegramMessage `json:"edited_message"`
+}
+
+// telegramInbound is a Telegram message normalized to the inbound contract.
+type telegramInbound struct {
+	senderID     string
+	chatID       string
+	threadID     string
+	messageID    string
+	text         string
+	isGroup      bool
+	wasMentioned bool
+}
+
+// normalizeTelegramUpdate maps a Bot API update to an inbound message.
+// Messages of bots are dropped. A reply keeps the replied-to message as its
+// thread, and a forum topic message its topic, so the answer lands there.
+// Files are forwarded as placeholders: their download URLs carry the bot
+// token.
+func normalizeTelegramUpdate(u telegramUpdate, botID int64, botUsername string) (telegramInbound, bool) {
+	m := u.Message
+	if m == nil {
+		m = u.EditedMessage
+	}
+	if m == nil || m.From == nil || m.From.IsBot || m.Chat.ID == 0 {
+		return telegramInbound{}, false
+	}
+	text := strings.TrimSpace(firstNonEmpty(m.Text, m.Caption))
+	switch {
+	case len(m.Photo) > 0:
+		text = strings.TrimSpace(text + "\n[photo]")
+	case m.Document != nil:
+		text = strings.TrimSpace(text + "\n[document: " + firstNonEmpty(m.Document.FileName, "file") + "]")
+	case m.Voice != nil:
+		text = strings.TrimSpace(text + "\n[voice message]")
+	}
+	if text == "" {
+		return telegramInbound{}, false
+	}
+	in := telegramInbound{
+		senderID:  strconv.FormatInt(m.From.ID, 10),
+		chatID:    strconv.FormatInt(m.Chat.ID, 10),
+		messageID: strconv.FormatInt(m.MessageID, 10),
+		text:      text,
+		isGroup:   m.Chat.Type != "private",
+	}
+	switch {
+	case m.ReplyToMessage != nil && (!m.IsTopicMessage || m.ReplyToMessage.MessageID != m.MessageThreadID):
+		in.threadID = strconv.FormatInt(m.ReplyToMessage.MessageID, 10)
+	case m.IsTopicMessage && m.MessageThreadID != 0:
+		in.threadID = strconv.FormatInt(m.MessageThreadID, 10)
+	}
+	in.wasMentioned = telegramMentionsBot(m, botID, botUsername)
+	return in, true
+}
+
+// telegramMentionsBot reports whether a message addresses the bot: an
+// @username mention, a text mention, a /command@bot or a reply to the bot.
+func telegramMentionsBot(m *telegramMessage, botID int64, botUsername string) bool {
+	if botID != 0 && m.ReplyToMessage != nil && m.ReplyToMessage.From != nil && m.ReplyToMessage.From.ID == botID {
+		return true
+	}
+	handle := "@" + strings.ToLower(strings.TrimPrefix(botUsername, "@"))
+	text, entities := m.Text, m.Entities
+	if text == "" {
+		text, entities = m.Caption, m.CaptionEntities
+	}
+	units := utf16Units(text)
+	for _, e := range entities {
+		switch e.Type {
+		case "text_mention":
+			if e.User != nil && botID != 0 && e.User.ID == botID {
+				return true
+			}
+		case "mention", "bot_command":
+			if botUsername == "" || e.Offset < 0 || e.Offset+e.Length > len(units) {
+				continue
+			}
+			v := strings.ToLower(utf16String(units[e.Offset : e.Offset+e.Length]))
+			if v == handle || strings.HasSuffix(v, handle) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Telegram entity offsets count UTF-16 code units.
+func utf16Units(s string) []uint16 {
+	out := make([]uint16, 0, len(s))
+	for _, r := range s {
+		if r >= 0x10000 {
+			r -= 0x10000
+			out = append(out, uint16(0xD800+(r>>10)), uint16(0xDC00+(r&0x3FF)))
+			continue
+		}
+		out = append(out, uint16(r))
+	}
+	return out
+}
+
+func utf16String(units []uint16) string {
+	var sb strings.Builder
+	for i := 0; i < len(units); i++ {
+		u := units[i]
+		if u >= 0xD800 && u < 0xDC00 && i+1 < len(units) {
+			sb.WriteRune(rune(u-0xD800)<<10 + rune(units[i+1]-0xDC00) + 0x10000)
+			i++
+			continue
+		}
+		sb.WriteRune(rune(u))
+	}
+	return sb.String()
+}
+
+func (b *bridge) processTelegramUpdate(u telegramUpdate) error {
+	botID, botUsername := b.telegramBot()
+	in, ok := normalizeTelegramUpdate(u, botID, botUsername)
+	if !ok {
+		return nil
+	}
+	key := "telegram:msg:" + in.chatID + ":" + in.messageID
+	if u.EditedMessage != nil {
+		key = "telegram:update:" + strconv.FormatInt(u.UpdateID, 10)
+	}
+	if b.seenInboundEvent(key, time.Now()) {
+		b.noteInboundDeduped("telegram")
+		return nil
+	}
+	queued, err := b.forwardInbound("telegram", "/api/v1/channels/telegram/inbound", in.chatID, map[string]any{
+		"account_id":       strings.TrimSpace(b.cfg.TelegramAccountID),
+		"sender_id":        in.senderID,
+		"chat_id":          in.chatID,
+		"thread_id":        in.threadID,
+		"message_id":       in.messageID,
+		"text":             in.text,
+		"is_group":         in.isGroup,
+		"was_mentioned":    in.wasMentioned,
+		"history_limit":    b.cfg.TelegramHistoryLimit,
+		"dm_history_limit": b.cfg.TelegramDMHistoryLimit,
+	})
+	if err != nil {
+		b.releaseRateLimited(err, key)
+		b.noteInboundForward(false, err)
+		slog.Warn("telegram inbound forward failed", "error", err)
+		return err
+	}
+	if queued {
+		return nil
+	}
+	b.metricsMu.Lock()
+	b.metrics.TelegramInboundForwarded++
+	b.metricsMu.Unlock()
+	return nil
+}
+
+// handleTelegramInbound is the webhook endpoint, served in webhook mode
+// only and only with TELEGRAM_WEBHOOK_SECRET set. Telegram redelivers
+// updates not answered with 2xx, so an update that was neither forwarded nor
+// queued is answered 502, or 429 when rate limited.
+func (b *bridge) handleTelegramInbound(w http.ResponseWriter, r *http.Request) {
+	if b.cfg.TelegramMode != "webhook" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	secret := strings.TrimSpace(b.cfg.TelegramWebhookSecret)
+	if secret == "" {
+		http.Error(w, "TELEGRAM_WEBHOOK_SECRET not configured", http.StatusServiceUnavailable)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get(telegramSecretHeader)), []byte(secret)) != 1 {
+		b.noteInboundAuthRejected()
+		http.Error(w, "invalid telegram secret token", http.StatusUnauthorized)
+		return
+	}
+	var u telegramUpdate
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&u); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if err := b.processTelegramUpdate(u); err != nil {
+		writeInboundError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
+}
+
+// startTelegram sets up update delivery: in webhook mode it registers
+// TELEGRAM_WEBHOOK_URL (when set) with the webhook secret, otherwise it
+// long-polls getUpdates in the background.
+func (b *bridge) startTelegram() {
+	if strings.TrimSpace(b.cfg.TelegramBotToken) == "" {
+		return
+	}
+	go func() {
+		ctx := context.Background()
+		if _, err := b.telegramMe(ctx); err != nil {
+			slog.Warn("telegram getMe failed", "error", err)
+		}
+		if b.cfg.TelegramMode == "webhook" {
+			if hook := strings.TrimSpace(b.cfg.TelegramWebhookURL); hook != "" {
+				params := map[string]any{
+					"url":             hook,
+					"allowed_updates": []string{"message", "edited_message"},
+					"secret_token":    strings.TrimSpace(b.cfg.TelegramWebhookSecret),
+				}
+				if err := b.telegramAPI(ctx, b.client, "setWebhook", params, nil); err != nil {
+					slog.Warn("telegram setWebhook failed", "error", err)
+				}
+			}
+			return
+		}
+		b.runTelegramPolling(ctx)
+	}()
+}
+
+// runTelegramPolling long-polls getUpdates. A webhook, if any, is removed
+// first: Telegram delivers updates through one of the two only.
+func (b *bridge) runTelegramPolling(ctx context.Context) {
+	client := &http.Client{Timeout: (telegramPollTimeoutSec + 15) * time.Second}
+	if err := b.telegramAPI(ctx, b.client, "deleteWebhook", map[string]any{"drop_pending_updates": false}, nil); err != nil {
+		slog.Warn("telegram deleteWebhook failed", "error", err)
+	}
+	backoff := time.Second
+	for {
+		b.telegramMu.Lock()
+		offset := b.telegramOffset
+		b.telegramMu.Unlock()
+		var updates []telegramUpdate
+		err := b.telegramAPI(ctx, client, "getUpdates", map[string]any{
+			"offset":          offset,
+			"timeout":         telegramPollTimeoutSec,
+			"allowed_updates": []string{"message", "edited_message"},
+		}, &updates)
+		if err != nil {
+			b.noteTelegramPolling(err)
+			var apiErr *telegramAPIError
+			if errors.As(err, &apiErr) && apiErr.status == http.StatusUnauthorized {
+				slog.Warn("telegram polling stopped", "error", err)
+				return
+			}
+			slog.Warn("telegram getUpdates failed", "error", err)
+			time.Sleep(backoff)
+			backoff = min(backoff*2, telegramPollMaxBackoff)
+			continue
+		}
+		backoff = time.Second
+		b.noteTelegramPolling(nil)
+		for _, u := range updates {
+			// Updates are confirmed by the next offset even when the forward
+			// fails; the inbound queue keeps those for a retry.
+			_ = b.processTelegramUpdate(u)
+			b.telegramMu.Lock()
+			if u.UpdateID >= b.telegramOffset {
+				b.telegramOffset = u.UpdateID + 1
+			}
+			b.telegramMu.Unlock()
+		}
+	}
+}
+
+func (b *bridge) noteTelegramPolling(err error) {
+	b.telegramMu.Lock()
+	defer b.telegramMu.Unlock()
+	b.telegramPollAt = time.Now().UTC()
+	b.telegramPollErr = ""
+	if err != nil {
+		b.telegramPollErr = err.Error()
+	}
+}
+
+// telegramBot returns the bot's user ID and username, from getMe or the
+// configuration.
+func (b *bridge) telegramBot() (int64, string) {
+	b.telegramMu.Lock()
+	defer b.telegramMu.Unlock()
+	return b.telegramBotID, firstNonEmpty(b.telegramBotUser, strings.TrimPrefix(b.cfg.TelegramBotUsername, "@"))
+}
+
+func (b *bridge) telegramMe(ctx context.Context) (telegramUser, error) {
+	var me telegramUser
+	if err := b.telegramAPI(ctx, b.client, "getMe", nil, &me); err != nil {
+		return me, err
+	}
+	b.telegramMu.Lock()
+	b.telegramBotID, b.telegramBotUser = me.ID, me.Username
+	b.telegramMu.Unlock()
+	return me, nil
+}
+
+// telegramAPI calls a Bot API method with JSON parameters. Rate limits and
+// server errors are retried; out, when set, receives the result.
+func (b *bridge) telegramAPI(ctx context.Context, client *http.Client, method string, params, out any) error {
+	var data []byte
+	if params != nil {
+		data, _ = json.Marshal(params)
+	}
+	return b.telegramDo(ctx, client, method, "application/json", data, out)
+}
+
+func (b *bridge) telegramDo(ctx context.Context, client *http.Client, method, contentType string, data []byte, out any) error {
+	token := strings.TrimSpace(b.cfg.TelegramBotToken)
+	if token == "" {
+		return errors.New("TELEGRAM_BOT_TOKEN not configured")
+	}
+	endpoint := strings.TrimRight(b.cfg.TelegramAPIBase, "/") + "/bot" + token + "/" + method
+	return withRetry(3, 250*time.Millisecond, func() (bool, error) {
+		var reader io.Reader
+		if data != nil {
+			reader = bytes.NewReader(data)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, reader)
+		if err != nil {
+			return false, fmt.Errorf("telegram %s: invalid request", method)
+		}
+		if data != nil {
+			req.Header.Set("Content-Type", contentType)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			// The request URL carries the bot token; keep it out of errors.
+			var ue *url.Error
+			if errors.As(err, &ue) {
+				err = ue.Err
+			}
+			return true, fmt.Errorf("telegram %s: %w", method, err)
+		}
+		defer resp.Body.Close()
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+		var body struct {
+			OK          bool            `json:"ok"`
+			Result      json.RawMessage `json:"result"`
+			Description string          `json:"description"`
+			ErrorCode   int             `json:"error_code"`
+			Parameters  struct {
+				RetryAfter int `json:"retry_after"`
+			} `json:"parameters"`
+		}
+		if err := json.Unmarshal(raw, &body); err != nil || !body.OK {
+			apiErr := &telegramAPIError{status: resp.StatusCode, message: firstNonEmpty(body.Description, strings.TrimSpace(string(raw)))}
+			if body.ErrorCode != 0 {
+				apiErr.status = body.ErrorCode
+			}
+			apiErr.retryAfter = time.Duration(body.Parameters.RetryAfter) * time.Second
+			if apiErr.status == http.StatusTooManyRequests {
+				time.Sleep(min(apiErr.retryAfter, 10*time.Second))
+				return true, apiErr
+			}
+			return apiErr.status >= 500, apiErr
+		}
+		if out != nil && len(body.Result) > 0 {
+			return false, json.Unmarshal(body.Result, out)
+		}
+		return false, nil
+	})
+}
+
+// normalizeTelegramTarget maps a chat ID ("telegram:", "user:" or "chat:"
+// prefixed, or bare) to the Bot API chat_id. Public channels and groups may
+// also be addressed as @username.
+func normalizeTelegramTarget(v string) string {
+	s := strings.TrimSpace(v)
+	for _, prefix := range []string{"telegram:", "user:", "chat:", "channel:"} {
+		if strings.HasPrefix(strings.ToLower(s), prefix) {
+			s = strings.TrimSpace(s[len(prefix):])
+		}
+	}
+	return s
+}
+
+// telegramReplyParams returns the reply_parameters of a reply to messageID.
+func telegramReplyParams(messageID string) map[string]any {
+	id, err := strconv.ParseInt(strings.TrimSpace(messageID), 10, 64)
+	if err != nil || id == 0 {
+		return nil
+	}
+	return map[string]any{"message_id": id, "allow_sending_without_reply": true}
+}
+
+// telegramSendMessage posts text, split into chunks under the message limit.
+// The first chunk replies to replyTo when set.
+func (b *bridge) telegramSendMessage(ctx context.Context, chatID, replyTo, text string) ([]string, error) {
+	var ids []string
+	for i, chunk := range splitSlackMarkdownChunks(text, telegramChunkChars) {
+		params := map[string]any{"chat_id": chatID, "text": chunk}
+		if rp := telegramReplyParams(replyTo); i == 0 && rp != nil {
+			params["reply_parameters"] = rp
+		}
+		var msg telegramMessage
+		if err := b.telegramAPI(ctx, b.client, "sendMessage", params, &msg); err != nil {
+			return ids, err
+		}
+		ids = append(ids, strconv.FormatInt(msg.MessageID, 10))
+	}
+	return ids, nil
+}
+
+// telegramSendMedia sends one media URL: images with sendPhoto, anything
+// else with sendDocument. Remote URLs are fetched by Telegram; generated
+// files ("data:" URLs) are uploaded.
+func (b *bridge) telegramSendMedia(ctx context.Context, chatID, replyTo, mediaURL string) (string, error) {
+	data, mimeType, isData, err := decodeDataURL(mediaURL)
+	if err != nil {
+		return "", err
+	}
+	method, field := "sendDocument", "document"
+	if (isData && strings.HasPrefix(mimeType, "image/") && mimeType != "image/gif") || (!isData && telegramLooksLikePhoto(mediaURL)) {
+		method, field = "sendPhoto", "photo"
+	}
+	var msg telegramMessage
+	if !isData {
+		params := map[string]any{"chat_id": chatID, field: mediaURL}
+		if rp := telegramReplyParams(replyTo); rp != nil {
+			params["reply_parameters"] = rp
+		}
+		if err := b.telegramAPI(ctx, b.client, method, params, &msg); err != nil {
+			return "", err
+		}
+		return strconv.FormatInt(msg.MessageID, 10), nil
+	}
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	_ = mw.WriteField("chat_id", chatID)
+	if rp := telegramReplyParams(replyTo); rp != nil {
+		rpJSON, _ := json.Marshal(rp)
+		_ = mw.WriteField("reply_parameters", string(rpJSON))
+	}
+	part, err := mw.CreateFormFile(field, "upload"+mediaExtForType(mimeType))
+	if err != nil {
+		return "", err
+	}
+	_, _ = part.Write(data)
+	_ = mw.Close()
+	if err := b.telegramDo(ctx, b.client, method, mw.FormDataContentType(), buf.Bytes(), &msg); err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(msg.MessageID, 10), nil
+}
+
+func telegramLooksLikePhoto(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	p := strings.ToLower(u.Path)
+	return strings.HasSuffix(p, ".jpg") || strings.HasSuffix(p, ".jpeg") || strings.HasSuffix(p, ".png") || strings.HasSuffix(p, ".webp")
+}
+
+func (b *bridge) handleTelegramOutbound(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		AccountID string   `json:"account_id"`
+		ChatID    string   `json:"chat_id"`
+		ThreadID  string   `json:"thread_id"`
+		ReplyMode string   `json:"reply_mode"`
+		Content   string   `json:"content"`
+		MediaURLs []string `json:"media_urls"`
+		Action    string   `json:"action"`
+		TaskID    string   `json:"task_id"`
+		TraceID   string   `json:"trace_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	chatID := normalizeTelegramTarget(req.ChatID)
+	if chatID == "" {
+		http.Error(w, "chat_id required", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	action := strings.ToLower(strings.TrimSpace(req.Action))
+	if action != "" && action != "typing" {
+		http.Error(w, "unsupported telegram action: "+action, http.StatusBadRequest)
+		return
+	}
+	if action == "typing" {
+		// The chat action shows for about five seconds; KafClaw refreshes it.
+		if err := b.telegramAPI(ctx, b.client, "sendChatAction", map[string]any{"chat_id": chatID, "action": "typing"}, nil); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
+		return
+	}
+	if strings.TrimSpace(req.Content) == "" && len(req.MediaURLs) == 0 {
+		http.Error(w, "content or media_urls required", http.StatusBadRequest)
+		return
+	}
+	accountID := bridgeAccountIDOrDefault(req.AccountID)
+	replyTo := b.resolveReplyThread("telegram", accountID, req.ChatID, req.ThreadID, req.ReplyMode, b.cfg.TelegramReplyMode)
+	delivery := outboundDelivery{
+		Channel:   "telegram",
+		AccountID: accountID,
+		ChatID:    strings.TrimSpace(req.ChatID),
+		ChannelID: chatID,
+		ThreadID:  strings.TrimSpace(replyTo),
+		TaskID:    strings.TrimSpace(req.TaskID),
+		TraceID:   strings.TrimSpace(req.TraceID),
+	}
+	if strings.TrimSpace(req.Content) != "" {
+		ids, err := b.telegramSendMessage(ctx, chatID, replyTo, req.Content)
+		delivery.addMessageIDs(ids...)
+		if err != nil {
+			b.noteOutbound(false, "telegram", err)
+			writeOutboundError(w, err, delivery.sent())
+			return
+		}
+		replyTo = ""
+	}
+	for _, m := range req.MediaURLs {
+		if m = strings.TrimSpace(m); m == "" {
+			continue
+		}
+		id, err := b.telegramSendMedia(ctx, chatID, replyTo, m)
+		if err != nil {
+			b.noteOutbound(false, "telegram", err)
+			writeOutboundError(w, err, delivery.sent())
+			return
+		}
+		delivery.FileIDs = append(delivery.FileIDs, id)
+		delivery.addMessageIDs(id)
+		replyTo = ""
+	}
+	b.noteOutbound(true, "telegram", nil)
+	b.postDeliveryReceipt(delivery, b.cfg.KafclawTelegramInboundToken)
+	writeOutboundDelivery(w, delivery)
+}
+
+func (b *bridge) handleTelegramResolveUsers(w http.ResponseWriter, r *http.Request) {
+	b.handleTelegramResolve(w, r, "user")
+}
+
+func (b *bridge) handleTelegramResolveChannels(w http.ResponseWriter, r *http.Request) {
+	b.handleTelegramResolve(w, r, "channel")
+}
+
+func (b *bridge) handleTelegramResolve(w http.ResponseWriter, r *http.Request, kind string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Entries []string `json:"entries"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"results": b.telegramResolve(r.Context(), req.Entries, kind)})
+}
+
+// telegramResolve resolves chat IDs and @usernames with getChat. The Bot API
+// cannot look users up by name, so users resolve by numeric ID only (a
+// private chat ID is the user ID); @username works for public groups and
+// channels.
+func (b *bridge) telegramResolve(ctx context.Context, entries []string, kind string) []map[string]any {
+	out := make([]map[string]any, 0, len(entries))
+	for _, raw := range entries {
+		q := normalizeTelegramTarget(raw)
+		if q == "" {
+			out = append(out, map[string]any{"input": raw, "resolved": false, "note": "empty input"})
+			continue
+		}
+		if !telegramChatIDPattern.MatchString(q) && !strings.HasPrefix(q, "@") {
+			if kind == "user" {
+				out = append(out, map[string]any{"input": raw, "resolved": false, "note": "telegram bots can only resolve users by numeric id"})
+				continue
+			}
+			q = "@" + q
+		}
+		var chat telegramChat
+		if err := b.telegramAPI(ctx, b.client, "getChat", map[string]any{"chat_id": q}, &chat); err != nil {
+			out = append(out, map[string]any{"input": raw, "resolved": false, "note": err.Error()})
+			continue
+		}
+		entry := map[string]any{
+			"input":    raw,
+			"resolved": true,
+			"id":       strconv.FormatInt(chat.ID, 10),
+			"type":     chat.Type,
+			"name":     firstNonEmpty(chat.Title, chat.Username),
+		}
+		if chat.Username != "" {
+			entry["mention"] = "@" + chat.Username
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+func (b *bridge) handleTelegramProbe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if strings.TrimSpace(b.cfg.TelegramBotToken) == "" {
+		http.Error(w, "TELEGRAM_BOT_TOKEN not configured", http.StatusBadRequest)
+		return
+	}
+	me, err := b.telegramMe(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	var hook struct {
+		URL                  string `json:"url"`
+		PendingUpdateCount   int    `json:"pending_update_count"`
+		LastErrorMessage     string `json:"last_error_message"`
+		LastErrorDate        int64  `json:"last_error_date"`
+		HasCustomCertificate bool   `json:"has_custom_certificate"`
+	}
+	webhook := map[string]any{"ok": true}
+	if err := b.telegramAPI(r.Context(), b.client, "getWebhookInfo", nil, &hook); err != nil {
+		webhook = map[string]any{"ok": false, "error": err.Error()}
+	} else {
+		webhook["url"] = hook.URL
+		webhook["pending_update_count"] = hook.PendingUpdateCount
+		webhook["last_error"] = hook.LastErrorMessage
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"ok":                  true,
+		"user":                me.Username,
+		"user_id":             strconv.FormatInt(me.ID, 10),
+		"mode":                b.cfg.TelegramMode,
+		"webhook":             webhook,
+		"secret_token_set":    strings.TrimSpace(b.cfg.TelegramWebhookSecret) != "",
+		"can_read_all_groups": me.CanReadAllGroupMessages,
+	})
+}
+
+// telegramStatus summarizes the Telegram side for /status.
+func (b *bridge) telegramStatus() map[string]any {
+	b.telegramMu.Lock()
+	defer b.telegramMu.Unlock()
+	st := map[string]any{
+		"configured": strings.TrimSpace(b.cfg.TelegramBotToken) != "",
+		"mode":       b.cfg.TelegramMode,
+		"bot_user":   firstNonEmpty(b.telegramBotUser, b.cfg.TelegramBotUsername),
+		"offset":     b.telegramOffset,
+	}
+	if !b.telegramPollAt.IsZero() {
+		st["last_poll_at"] = b.telegramPollAt.Format(time.RFC3339)
+	}
+	if b.telegramPollErr != "" {
+		st["last_poll_error"] = b.telegramPollErr
+	}
+	return st
+}
+
+// checkTelegramAuth verifies the bot token with getMe.
+func (b *bridge) checkTelegramAuth(ctx context.Context) (string, error) {
+	me, err := b.telegramMe(ctx)
+	if err != nil {
+		return "", fmt.Errorf("getMe: %w", err)
+	}
+	return "user " + me.Username, nil
+}
+
+// parseTelegramMode reads TELEGRAM_MODE: "webhook" or "polling" (default).
+func parseTelegramMode(raw string) string {
+	if strings.EqualFold(strings.TrimSpace(raw), "webhook") {
+		return "webhook"
+	}
+	return "polling"
+}
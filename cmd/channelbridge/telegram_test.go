@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func parseTelegramUpdate(t *testing.T, raw string) telegramUpdate {
+	t.Helper()
+	var u telegramUpdate
+	if err := json.Unmarshal([]byte(raw), &u); err != nil {
+		t.Fatalf("decode update: %v", err)
+	}
+	return u
+}
+
+func TestNormalizeTelegramUpdate(t *testing.T) {
+	u := parseTelegramUpdate(t, `{"update_id": 7, "message": {
+		"message_id": 12, "from": {"id": 42, "username": "ana"},
+		"chat": {"id": -1001, "type": "supergroup"},
+		"text": "hey @KafBot 👋 status?",
+		"entities": [{"type": "mention", "offset": 4, "length": 7}],
+		"reply_to_message": {"message_id": 10, "from": {"id": 99}, "chat": {"id": -1001}}
+	}}`)
+	in, ok := normalizeTelegramUpdate(u, 1, "kafbot")
+	if !ok {
+		t.Fatal("expected message to be accepted")
+	}
+	if in.senderID != "42" || in.chatID != "-1001" || in.messageID != "12" || in.threadID != "10" || !in.isGroup || !in.wasMentioned {
+		t.Fatalf("unexpected inbound: %+v", in)
+	}
+
+	// A forum topic message keeps its topic; a reply to the bot counts as a
+	// mention.
+	u = parseTelegramUpdate(t, `{"update_id": 8, "message": {
+		"message_id": 30, "message_thread_id": 25, "is_topic_message": true,
+		"from": {"id": 42}, "chat": {"id": -1002, "type": "supergroup", "is_forum": true},
+		"text": "more",
+		"reply_to_message": {"message_id": 25, "from": {"id": 1}, "chat": {"id": -1002}}
+	}}`)
+	if in, ok := normalizeTelegramUpdate(u, 1, "kafbot"); !ok || in.threadID != "25" || !in.wasMentioned {
+		t.Fatalf("unexpected topic message: %+v ok=%v", in, ok)
+	}
+
+	u = parseTelegramUpdate(t, `{"update_id": 9, "message": {"message_id": 2, "from": {"id": 42}, "chat": {"id": 42, "type": "private"}, "caption": "look", "photo": [{"file_id": "f1"}]}}`)
+	if in, ok := normalizeTelegramUpdate(u, 1, "kafbot"); !ok || in.isGroup || in.wasMentioned || in.text != "look\n[photo]" {
+		t.Fatalf("unexpected DM: %+v ok=%v", in, ok)
+	}
+
+	u = parseTelegramUpdate(t, `{"update_id": 10, "message": {"message_id": 3, "from": {"id": 5, "is_bot": true}, "chat": {"id": 42, "type": "private"}, "text": "beep"}}`)
+	if in, ok := normalizeTelegramUpdate(u, 1, "kafbot"); ok {
+		t.Fatalf("expected bot message to be skipped, got %+v", in)
+	}
+}
+
+func TestTelegramWebhookVerifiesSecretAndForwards(t *testing.T) {
+	inbound := make(chan map[string]any, 2)
+	kafclaw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/channels/telegram/inbound" || r.Header.Get("X-Channel-Token") != "in-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		inbound <- body
+	}))
+	defer kafclaw.Close()
+
+	b := newTestBridge(kafclaw.URL)
+	b.cfg.KafclawTelegramInboundToken = "in-token"
+	b.cfg.TelegramMode = "polling"
+	update := `{"update_id": 1, "message": {"message_id": 5, "from": {"id": 42}, "chat": {"id": 42, "type": "private"}, "text": "hi"}}`
+	post := func(secret string) int {
+		req := httptest.NewRequest(http.MethodPost, "/telegram/inbound", strings.NewReader(update))
+		req.Header.Set(telegramSecretHeader, secret)
+		rec := httptest.NewRecorder()
+		b.handleTelegramInbound(rec, req)
+		return rec.Code
+	}
+
+	if code := post(""); code != http.StatusNotFound {
+		t.Fatalf("expected no webhook in polling mode, got %d", code)
+	}
+	b.cfg.TelegramMode = "webhook"
+	if code := post(""); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected webhook without a secret to be refused, got %d", code)
+	}
+	b.cfg.TelegramWebhookSecret = "s3cret"
+
+	if code := post("wrong"); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for a wrong secret, got %d", code)
+	}
+	if code := post("s3cret"); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	body := <-inbound
+	if body["chat_id"] != "42" || body["sender_id"] != "42" || body["text"] != "hi" || body["is_group"] != false {
+		t.Fatalf("unexpected inbound payload: %+v", body)
+	}
+	if code := post("s3cret"); code != http.StatusOK || len(inbound) != 0 || b.metrics.TelegramInboundDeduped != 1 {
+		t.Fatalf("expected redelivery to be deduped, code=%d metrics=%+v", code, b.metrics)
+	}
+}
+
+func TestTelegramOutboundRepliesAndSplitsLongText(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls []map[string]any
+	)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/botbot-token/sendMessage" {
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error_code": 404, "description": "Not Found"})
+			return
+		}
+		var params map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&params)
+		mu.Lock()
+		calls = append(calls, params)
+		id := len(calls) + 100
+		mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": map[string]any{"message_id": id, "chat": map[string]any{"id": 42}}})
+	}))
+	defer api.Close()
+
+	b := newTestBridge(api.URL)
+	b.cfg.TelegramBotToken = "bot-token"
+	b.cfg.TelegramAPIBase = api.URL
+	b.cfg.TelegramReplyMode = "all"
+
+	body, _ := json.Marshal(map[string]any{
+		"chat_id":   "telegram:42",
+		"thread_id": "5",
+		"content":   strings.Repeat("word ", 1000),
+	})
+	rec := httptest.NewRecorder()
+	b.handleTelegramOutbound(rec, httptest.NewRequest(http.MethodPost, "/telegram/outbound", strings.NewReader(string(body))))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var delivery outboundDelivery
+	_ = json.Unmarshal(rec.Body.Bytes(), &delivery)
+	if delivery.ChannelID != "42" || delivery.MessageID != "101" || len(delivery.MessageIDs) != 2 {
+		t.Fatalf("unexpected delivery: %+v", delivery)
+	}
+	if len(calls) != 2 || calls[0]["chat_id"] != "42" {
+		t.Fatalf("expected two sendMessage calls to chat 42, got %+v", calls)
+	}
+	if rp, _ := calls[0]["reply_parameters"].(map[string]any); rp["message_id"] != float64(5) || calls[1]["reply_parameters"] != nil {
+		t.Fatalf("expected only the first chunk to reply to message 5: %+v", calls)
+	}
+	if b.metrics.TelegramOutboundSent != 1 {
+		t.Fatalf("expected sent metric, got %+v", b.metrics)
+	}
+
+	// API failures are reported without the bot token.
+	rec = httptest.NewRecorder()
+	b.cfg.TelegramAPIBase = "http://127.0.0.1:1"
+	b.handleTelegramOutbound(rec, httptest.NewRequest(http.MethodPost, "/telegram/outbound", strings.NewReader(`{"chat_id":"42","content":"hi"}`)))
+	if rec.Code != http.StatusBadGateway || strings.Contains(rec.Body.String(), "bot-token") {
+		t.Fatalf("expected 502 without the token, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
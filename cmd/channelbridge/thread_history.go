@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	defaultThreadHistoryLimit = 20
+	maxThreadHistoryLimit     = 100
+	// slackThreadMaxPages bounds the replies pagination of one thread read.
+	slackThreadMaxPages = 10
+)
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]+>`)
+
+// threadMessage is one message of a thread history read (action "thread"),
+// normalized across platforms. Messages are returned oldest first.
+type threadMessage struct {
+	ID         string `json:"id"`
+	SenderID   string `json:"sender_id,omitempty"`
+	SenderName string `json:"sender_name,omitempty"`
+	IsBot      bool   `json:"is_bot,omitempty"`
+	Text       string `json:"text"`
+	Timestamp  string `json:"ts,omitempty"` // RFC3339
+}
+
+// threadHistoryLimit reads action_params.limit, clamped to
+// [1, maxThreadHistoryLimit].
+func threadHistoryLimit(params map[string]any) int {
+	limit := defaultThreadHistoryLimit
+	if n, ok := params["limit"].(float64); ok && int(n) > 0 {
+		limit = int(n)
+	}
+	if limit > maxThreadHistoryLimit {
+		limit = maxThreadHistoryLimit
+	}
+	return limit
+}
+
+// lastThreadMessages sorts msgs oldest first and keeps the newest limit.
+func lastThreadMessages(msgs []threadMessage, limit int) []threadMessage {
+	at := func(m threadMessage) time.Time {
+		t, _ := time.Parse(time.RFC3339Nano, m.Timestamp)
+		return t
+	}
+	sort.SliceStable(msgs, func(i, j int) bool { return at(msgs[i]).Before(at(msgs[j])) })
+	if limit > 0 && len(msgs) > limit {
+		msgs = msgs[len(msgs)-limit:]
+	}
+	return msgs
+}
+
+// slackTSTime converts a Slack message ts ("1700000000.123456") to RFC3339.
+func slackTSTime(ts string) string {
+	f, err := strconv.ParseFloat(strings.TrimSpace(ts), 64)
+	if err != nil || f <= 0 {
+		return ""
+	}
+	sec := int64(f)
+	return time.Unix(sec, int64((f-float64(sec))*1e9)).UTC().Format(time.RFC3339Nano)
+}
+
+// slackThreadHistory reads the newest limit messages of the thread rooted at
+// threadTS, including the root message.
+func slackThreadHistory(ctx context.Context, api *slack.Client, channelID, threadTS string, limit int) ([]threadMessage, error) {
+	if strings.TrimSpace(threadTS) == "" {
+		return nil, errors.New("thread requires thread_id")
+	}
+	var out []threadMessage
+	cursor := ""
+	for page := 0; page < slackThreadMaxPages; page++ {
+		msgs, hasMore, next, err := api.GetConversationRepliesContext(ctx, &slack.GetConversationRepliesParameters{
+			ChannelID: channelID,
+			Timestamp: threadTS,
+			Cursor:    cursor,
+			Limit:     200,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range msgs {
+			out = append(out, slackThreadMessage(m))
+		}
+		if !hasMore || strings.TrimSpace(next) == "" {
+			break
+		}
+		cursor = next
+	}
+	return lastThreadMessages(out, limit), nil
+}
+
+// teamsRootMessageID extracts the root message of a Teams channel thread from
+// its conversation ID ("19:...@thread.tacv2;messageid=1700000000000").
+func teamsRootMessageID(conversationID string) string {
+	_, after, ok := strings.Cut(conversationID, ";messageid=")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(after)
+}
+
+// handleTeamsThread answers action "thread" on /teams/outbound. Channel
+// threads are read from the channel message replies, other conversations
+// from the chat messages; both need the Graph ChannelMessage.Read.All or
+// Chat.Read.All application permission.
+func (b *bridge) handleTeamsThread(w http.ResponseWriter, traceparent, chatID, threadID string, params map[string]any) {
+	ref, err := b.resolveTeamsConversation(chatID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	token, err := b.getTeamsGraphToken(b.teamsAccount(ref.AccountID))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	msgs, err := b.teamsThreadHistory(token, ref, threadID, threadHistoryLimit(params), traceparent)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": map[string]any{"ok": true, "messages": msgs}})
+}
+
+func (b *bridge) teamsThreadHistory(token string, ref teamsConversationRef, threadID string, limit int, traceparent string) ([]threadMessage, error) {
+	var paths []string
+	root := teamsRootMessageID(ref.ConversationID)
+	if root == "" {
+		root = strings.TrimSpace(threadID)
+	}
+	if ref.TeamGroupID != "" && ref.ChannelID != "" && root != "" {
+		base := "/teams/" + url.PathEscape(ref.TeamGroupID) + "/channels/" + url.PathEscape(ref.ChannelID) + "/messages/" + url.PathEscape(root)
+		paths = []string{base, base + fmt.Sprintf("/replies?$top=%d", limit)}
+	} else {
+		chat, _, _ := strings.Cut(ref.ConversationID, ";")
+		paths = []string{"/chats/" + url.PathEscape(chat) + fmt.Sprintf("/messages?$top=%d", limit)}
+	}
+
+	var out []threadMessage
+	for _, p := range paths {
+		_, body, err := b.graphGET(token, p, traceparent)
+		if err != nil {
+			return nil, fmt.Errorf("graph thread read %s: %w", p, err)
+		}
+		var page struct {
+			Value []map[string]any `json:"value"`
+		}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, err
+		}
+		if page.Value == nil {
+			// A single message (the channel thread root).
+			var one map[string]any
+			if err := json.Unmarshal(body, &one); err == nil && one["id"] != nil {
+				page.Value = []map[string]any{one}
+			}
+		}
+		for _, m := range page.Value {
+			if msg, ok := graphThreadMessage(m); ok {
+				out = append(out, msg)
+			}
+		}
+	}
+	return lastThreadMessages(out, limit), nil
+}
+
+// graphThreadMessage normalizes a Graph chatMessage; system event messages
+// and deleted messages are skipped.
+func graphThreadMessage(m map[string]any) (threadMessage, bool) {
+	if t := asString(m["messageType"]); t != "" && t != "message" {
+		return threadMessage{}, false
+	}
+	if m["deletedDateTime"] != nil {
+		return threadMessage{}, false
+	}
+	body, _ := m["body"].(map[string]any)
+	text := asString(body["content"])
+	if strings.EqualFold(asString(body["contentType"]), "html") {
+		text = html.UnescapeString(htmlTagPattern.ReplaceAllString(text, ""))
+	}
+	msg := threadMessage{
+		ID:        asString(m["id"]),
+		Text:      strings.TrimSpace(text),
+		Timestamp: asString(m["createdDateTime"]),
+	}
+	from, _ := m["from"].(map[string]any)
+	if user, ok := from["user"].(map[string]any); ok {
+		msg.SenderID = asString(user["id"])
+		msg.SenderName = asString(user["displayName"])
+	} else if app, ok := from["application"].(map[string]any); ok {
+		msg.SenderID = asString(app["id"])
+		msg.SenderName = asString(app["displayName"])
+		msg.IsBot = true
+	}
+	return msg, true
+}
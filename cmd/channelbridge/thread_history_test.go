@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func decodeThreadResult(t *testing.T, w *httptest.ResponseRecorder) []threadMessage {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Result struct {
+			Messages []threadMessage `json:"messages"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return resp.Result.Messages
+}
+
+func TestSlackOutboundActionThreadKeepsNewest(t *testing.T) {
+	var gotTS string
+	slackAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/conversations.replies" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = r.ParseForm()
+		gotTS = r.Form.Get("ts")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"ok": true,
+			"messages": []map[string]any{
+				{"ts": "1700000000.000100", "user": "U1", "text": "root question"},
+				{"ts": "1700000010.000200", "user": "U2", "text": "first answer"},
+				{"ts": "1700000020.000300", "bot_id": "B1", "username": "kafclaw", "text": "bot reply"},
+			},
+		})
+	}))
+	defer slackAPI.Close()
+
+	b := newTestBridge("http://example.invalid")
+	b.cfg.SlackAPIBase = slackAPI.URL
+	b.cfg.SlackBotToken = "xoxb-test"
+	b.cfg.SlackReplyMode = "off"
+
+	body, _ := json.Marshal(map[string]any{
+		"chat_id":       "channel:C111",
+		"thread_id":     "1700000000.000100",
+		"action":        "thread",
+		"action_params": map[string]any{"limit": 2},
+	})
+	w := httptest.NewRecorder()
+	b.handleSlackOutbound(w, httptest.NewRequest(http.MethodPost, "/slack/outbound", bytes.NewReader(body)))
+	msgs := decodeThreadResult(t, w)
+	if gotTS != "1700000000.000100" {
+		t.Fatalf("thread read must ignore reply mode, got ts=%q", gotTS)
+	}
+	if len(msgs) != 2 || msgs[0].Text != "first answer" || !msgs[1].IsBot || msgs[1].SenderName != "kafclaw" {
+		t.Fatalf("unexpected thread messages: %+v", msgs)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, msgs[0].Timestamp); err != nil {
+		t.Fatalf("expected RFC3339 timestamp, got %q", msgs[0].Timestamp)
+	}
+}
+
+func TestTeamsOutboundActionThreadReadsChannelReplies(t *testing.T) {
+	var paths, traceparents []string
+	graph := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		traceparents = append(traceparents, r.Header.Get(traceparentHeader))
+		switch r.URL.Path {
+		case "/teams/gid-1/channels/19:ch@thread.tacv2/messages/1700":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"id": "1700", "messageType": "message", "createdDateTime": "2026-01-01T10:00:00Z",
+				"from": map[string]any{"user": map[string]any{"id": "u1", "displayName": "Alex"}},
+				"body": map[string]any{"contentType": "html", "content": "<p>deploy &amp; verify?</p>"},
+			})
+		case "/teams/gid-1/channels/19:ch@thread.tacv2/messages/1700/replies":
+			_ = json.NewEncoder(w).Encode(map[string]any{"value": []map[string]any{
+				{"id": "1702", "messageType": "message", "createdDateTime": "2026-01-01T10:02:00Z",
+					"from": map[string]any{"application": map[string]any{"id": "app", "displayName": "KafClaw"}},
+					"body": map[string]any{"contentType": "text", "content": "on it"}},
+				{"id": "1701", "messageType": "systemEventMessage", "createdDateTime": "2026-01-01T10:01:00Z"},
+			}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer graph.Close()
+
+	b := newTestBridge("http://example.invalid")
+	b.cfg.MSTeamsGraphBase = graph.URL
+	b.teamsMu.Lock()
+	b.teamsConvByID["19:ch@thread.tacv2;messageid=1700"] = teamsConversationRef{
+		ServiceURL: "http://example.invalid", ConversationID: "19:ch@thread.tacv2;messageid=1700",
+		TeamGroupID: "gid-1", ChannelID: "19:ch@thread.tacv2",
+	}
+	b.teamsGraphToken = tokenCache{accessToken: "graph-token", expiresAt: time.Now().Add(10 * time.Minute)}
+	b.teamsMu.Unlock()
+
+	body, _ := json.Marshal(map[string]any{"chat_id": "19:ch@thread.tacv2;messageid=1700", "thread_id": "1700", "action": "thread"})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/teams/outbound", bytes.NewReader(body))
+	req.Header.Set(traceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	b.handleTeamsOutbound(w, req)
+	msgs := decodeThreadResult(t, w)
+	if len(msgs) != 2 || msgs[0].Text != "deploy & verify?" || msgs[0].SenderName != "Alex" || !msgs[1].IsBot {
+		t.Fatalf("unexpected thread messages: %+v (paths %v)", msgs, paths)
+	}
+	for _, tp := range traceparents {
+		if !strings.HasPrefix(tp, "00-4bf92f3577b34da6a3ce929d0e0e4736-") || strings.Contains(tp, "00f067aa0ba902b7") {
+			t.Fatalf("expected Graph reads to continue the caller trace, got %v", traceparents)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// traceparentHeader is the W3C trace context header. The bridge starts a
+// trace for every inbound message it forwards and continues the trace of
+// KafClaw requests on the platform API calls they cause.
+const traceparentHeader = "traceparent"
+
+// newTraceparent returns a sampled traceparent for a new trace.
+func newTraceparent() string {
+	return "00-" + randomHex(16) + "-" + randomHex(8) + "-01"
+}
+
+// traceparentFor returns a traceparent in the KafClaw trace traceID when it is
+// a W3C trace ID, as for messages the bridge forwarded, and a new trace
+// otherwise.
+func traceparentFor(traceID string) string {
+	id := strings.ToLower(strings.TrimSpace(traceID))
+	if !isLowerHex(id, 32) || strings.Trim(id, "0") == "" {
+		return newTraceparent()
+	}
+	return "00-" + id + "-" + randomHex(8) + "-01"
+}
+
+// childTraceparent returns a traceparent continuing the trace of parent with
+// a fresh span, or "" when parent is not a valid version 00 traceparent.
+func childTraceparent(parent string) string {
+	parts := strings.Split(strings.TrimSpace(parent), "-")
+	if len(parts) != 4 || parts[0] != "00" || !isLowerHex(parts[1], 32) || !isLowerHex(parts[2], 16) || !isLowerHex(parts[3], 2) {
+		return ""
+	}
+	if strings.Trim(parts[1], "0") == "" {
+		return ""
+	}
+	return "00-" + parts[1] + "-" + randomHex(8) + "-" + parts[3]
+}
+
+// traceIDOf returns the trace ID of a traceparent, the trace_id of log
+// records, or "" when it does not parse.
+func traceIDOf(traceparent string) string {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) != 4 || !isLowerHex(parts[1], 32) {
+		return ""
+	}
+	return parts[1]
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	b[n-1] |= 1 // never all zero
+	return hex.EncodeToString(b)
+}
+
+func isLowerHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
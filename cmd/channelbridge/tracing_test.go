@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestForwardInboundCarriesTraceID(t *testing.T) {
+	var header, traceID string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		header = r.Header.Get(traceparentHeader)
+		traceID, _ = body["trace_id"].(string)
+	}))
+	defer api.Close()
+
+	b := newTestBridge(api.URL)
+	if _, err := b.forwardInbound("slack", "/api/v1/channels/slack/inbound", "C1", map[string]any{"chat_id": "C1", "text": "hi"}); err != nil {
+		t.Fatal(err)
+	}
+	if traceID == "" || traceID != traceIDOf(header) {
+		t.Fatalf("expected the body trace_id of traceparent %q, got %q", header, traceID)
+	}
+}
+
+func TestTraceparentFor(t *testing.T) {
+	const id = "4bf92f3577b34da6a3ce929d0e0e4736"
+	tp := traceparentFor(id)
+	if !strings.HasPrefix(tp, "00-"+id+"-") || childTraceparent(tp) == "" {
+		t.Fatalf("expected a traceparent in trace %s, got %q", id, tp)
+	}
+	if other := traceparentFor("trace-1"); traceIDOf(other) == "" || traceIDOf(other) == id {
+		t.Fatalf("expected a new trace for a non-W3C trace ID, got %q", other)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// handleTyping answers POST /typing: a typing indicator in a Slack or Teams
+// chat. Teams gets a typing activity, which it shows for a few seconds.
+// Slack has no typing API for Events API and Socket Mode apps, so the
+// bridge sets the assistant thread status instead; that needs a thread and
+// the assistant:write scope, and Slack clears it with the next reply.
+func (b *bridge) handleTyping(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Channel     string `json:"channel"`
+		AccountID   string `json:"account_id"`
+		WorkspaceID string `json:"workspace_id"`
+		ChatID      string `json:"chat_id"`
+		ThreadID    string `json:"thread_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.ChatID) == "" {
+		http.Error(w, "chat_id required", http.StatusBadRequest)
+		return
+	}
+	switch strings.ToLower(strings.TrimSpace(req.Channel)) {
+	case "msteams", "teams":
+		b.handleTeamsTyping(w, req.AccountID, req.ChatID)
+	case "slack":
+		b.handleSlackTyping(w, req.AccountID, req.WorkspaceID, req.ChatID, req.ThreadID)
+	default:
+		http.Error(w, "channel must be slack or msteams", http.StatusBadRequest)
+	}
+}
+
+func (b *bridge) handleSlackTyping(w http.ResponseWriter, accountID, workspaceID, chatID, threadID string) {
+	w.Header().Set("Content-Type", "application/json")
+	threadID = strings.TrimSpace(threadID)
+	if threadID == "" {
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "typing": false, "reason": "slack shows typing in threads only"})
+		return
+	}
+	acct := b.slackAccount(firstNonEmpty(strings.TrimSpace(accountID), "default")).forWorkspace(workspaceID)
+	channelID, err := b.resolveSlackChannelID(acct, chatID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	acct = b.slackChannelAccount(acct, channelID)
+	err = b.slackAPIPostForm(acct, "assistant.threads.setStatus", url.Values{
+		"channel_id": {channelID},
+		"thread_ts":  {threadID},
+		"status":     {b.text(acct.WorkspaceID, msgTyping)},
+	}, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "typing": true})
+}
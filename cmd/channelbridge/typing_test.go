@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTypingEndpoint(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls []string
+	)
+	record := func(call string) {
+		mu.Lock()
+		calls = append(calls, call)
+		mu.Unlock()
+	}
+	slackAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		record(r.URL.Path + "?" + r.Form.Encode())
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
+	}))
+	defer slackAPI.Close()
+	teamsAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var activity map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&activity)
+		record("teams:" + asString(activity["type"]))
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": "1"})
+	}))
+	defer teamsAPI.Close()
+
+	b := newTestBridge("http://example.invalid")
+	b.cfg.SlackAPIBase = slackAPI.URL
+	b.cfg.SlackBotToken = "xoxb-test"
+	b.cfg.MSTeamsAPIBase = teamsAPI.URL
+	b.teamsMu.Lock()
+	b.teamsConvByID["conv-1"] = teamsConversationRef{ServiceURL: teamsAPI.URL, ConversationID: "conv-1", UserID: "u1"}
+	b.teamsToken = tokenCache{accessToken: "token", expiresAt: time.Now().Add(30 * time.Minute)}
+	b.teamsMu.Unlock()
+
+	post := func(payload map[string]any) map[string]any {
+		body, _ := json.Marshal(payload)
+		w := httptest.NewRecorder()
+		b.handleTyping(w, httptest.NewRequest(http.MethodPost, "/typing", bytes.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("typing %v: status=%d body=%s", payload, w.Code, w.Body.String())
+		}
+		var out map[string]any
+		_ = json.Unmarshal(w.Body.Bytes(), &out)
+		return out
+	}
+	if out := post(map[string]any{"channel": "slack", "chat_id": "C1"}); out["typing"] != false {
+		t.Fatalf("expected no Slack typing outside a thread, got %#v", out)
+	}
+	if out := post(map[string]any{"channel": "slack", "chat_id": "C1", "thread_id": "100.1"}); out["typing"] != true {
+		t.Fatalf("expected Slack typing in a thread, got %#v", out)
+	}
+	post(map[string]any{"channel": "msteams", "chat_id": "conv-1"})
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"/assistant.threads.setStatus?channel_id=C1&status=is+typing...&thread_ts=100.1", "teams:typing"}
+	if len(calls) != 2 || calls[0] != want[0] || calls[1] != want[1] {
+		t.Fatalf("unexpected calls %#v", calls)
+	}
+}
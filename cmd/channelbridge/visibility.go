@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Outbound visibility modes. The default posts to the chat for everyone.
+const (
+	visibilityEphemeral = "ephemeral"
+	visibilityDM        = "dm"
+)
+
+// parseVisibility validates the visibility of an outbound request: empty or
+// "channel" for everyone, "ephemeral" or "dm" for user_id only.
+func parseVisibility(raw, userID string) (string, error) {
+	v := strings.ToLower(strings.TrimSpace(raw))
+	switch v {
+	case "", "channel":
+		return "", nil
+	case visibilityEphemeral, visibilityDM:
+		if strings.TrimSpace(userID) == "" {
+			return "", fmt.Errorf("user_id required for visibility %q", v)
+		}
+		return v, nil
+	}
+	return "", errors.New("visibility must be channel, ephemeral or dm")
+}
+
+// slackPostEphemeral posts a reply only userID can see, as one message per
+// chunk of text. Slack does not keep ephemeral messages, so their IDs are
+// not returned.
+func (b *bridge) slackPostEphemeral(acct slackAccount, channelID, threadID, userID, text string, card map[string]any) error {
+	chunks := []string{text}
+	if len(card) == 0 {
+		chunks = splitSlackMarkdownChunks(text, 3500)
+	}
+	for _, chunk := range chunks {
+		form := url.Values{
+			"channel": {channelID},
+			"user":    {normalizeSlackTarget(userID)},
+		}
+		if ts := strings.TrimSpace(threadID); ts != "" {
+			form.Set("thread_ts", ts)
+		}
+		setSlackMessageForm(form, chunk, card)
+		if err := b.slackAPIPostForm(acct, "chat.postEphemeral", form, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// teamsPersonalChat is the chat ID of the 1:1 chat with a Teams user. Teams
+// has no ephemeral messages, so both private modes deliver there.
+func teamsPersonalChat(userID string) string {
+	id := strings.TrimSpace(userID)
+	if _, ok := teamsProactiveUser(id); ok {
+		return id
+	}
+	return "user:" + id
+}
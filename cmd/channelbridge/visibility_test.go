@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSlackOutboundVisibility(t *testing.T) {
+	var (
+		mu            sync.Mutex
+		calls         []string
+		notInChannel  bool
+		postedChannel string
+	)
+	slackAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, r.URL.Path)
+		switch r.URL.Path {
+		case "/chat.postEphemeral":
+			if notInChannel {
+				_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "user_not_in_channel"})
+				return
+			}
+			if r.FormValue("user") != "U1" || r.FormValue("channel") != "C1" {
+				t.Errorf("unexpected ephemeral form %v", r.Form)
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "message_ts": "1.1"})
+		case "/conversations.open":
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "channel": map[string]any{"id": "D1"}})
+		case "/chat.postMessage":
+			postedChannel = r.FormValue("channel")
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "channel": postedChannel, "ts": "2.2"})
+		default:
+			t.Errorf("unexpected slack call %s", r.URL.Path)
+		}
+	}))
+	defer slackAPI.Close()
+
+	b := newTestBridge("http://example.invalid")
+	b.cfg.SlackAPIBase = slackAPI.URL + "/"
+	b.cfg.SlackBotToken = "xoxb-test"
+	b.cfg.SlackNativeStreaming = false
+
+	post := func(payload map[string]any) (*httptest.ResponseRecorder, map[string]any) {
+		body, _ := json.Marshal(payload)
+		w := httptest.NewRecorder()
+		b.handleSlackOutbound(w, httptest.NewRequest(http.MethodPost, "/slack/outbound", bytes.NewReader(body)))
+		var out map[string]any
+		_ = json.Unmarshal(w.Body.Bytes(), &out)
+		return w, out
+	}
+	if w, _ := post(map[string]any{"chat_id": "C1", "content": "denied", "visibility": "ephemeral"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected user_id required, got %d", w.Code)
+	}
+	w, out := post(map[string]any{"chat_id": "C1", "content": "denied", "visibility": "ephemeral", "user_id": "U1"})
+	if w.Code != http.StatusOK || out["visibility"] != "ephemeral" || out["message_id"] != nil {
+		t.Fatalf("ephemeral status=%d body=%s", w.Code, w.Body.String())
+	}
+
+	mu.Lock()
+	notInChannel = true
+	mu.Unlock()
+	w, out = post(map[string]any{"chat_id": "C1", "content": "denied", "visibility": "ephemeral", "user_id": "U1"})
+	if w.Code != http.StatusOK || out["visibility"] != "dm" || out["channel_id"] != "D1" || out["message_id"] != "2.2" {
+		t.Fatalf("fallback status=%d body=%s", w.Code, w.Body.String())
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if postedChannel != "D1" {
+		t.Fatalf("expected the fallback posted to the DM, got %q (calls %v)", postedChannel, calls)
+	}
+}
+
+func TestTeamsOutboundDMVisibility(t *testing.T) {
+	var paths []string
+	teamsAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": "1700000000001"})
+	}))
+	defer teamsAPI.Close()
+
+	b := newTestBridge("http://example.invalid")
+	b.cfg.MSTeamsAPIBase = teamsAPI.URL
+	b.teamsMu.Lock()
+	b.teamsConvByID["conv-1"] = teamsConversationRef{ServiceURL: teamsAPI.URL, ConversationID: "conv-1", UserID: "u1"}
+	b.teamsConvByUserID["29:user-1"] = teamsConversationRef{ServiceURL: teamsAPI.URL, ConversationID: "dm-1", UserID: "29:user-1"}
+	b.teamsToken = tokenCache{accessToken: "token", expiresAt: time.Now().Add(30 * time.Minute)}
+	b.teamsMu.Unlock()
+
+	body, _ := json.Marshal(map[string]any{"chat_id": "conv-1", "thread_id": "a1", "content": "only you", "visibility": "ephemeral", "user_id": "29:user-1"})
+	w := httptest.NewRecorder()
+	b.handleTeamsOutbound(w, httptest.NewRequest(http.MethodPost, "/teams/outbound", bytes.NewReader(body)))
+	var out map[string]any
+	_ = json.Unmarshal(w.Body.Bytes(), &out)
+	if w.Code != http.StatusOK || out["channel_id"] != "dm-1" || out["visibility"] != "ephemeral" {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	if len(paths) != 1 || paths[0] != "/v3/conversations/dm-1/activities" {
+		t.Fatalf("expected one post to the personal chat, got %v", paths)
+	}
+}
@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// activityWithoutReply reports whether msg is platform activity (a reaction,
+// a file share, a channel join) that the agent does not answer. Activity
+// carries bus.MetaKeyEventType; only types listed in channels.replyEvents
+// reach the model. Other activity is recorded on the timeline and dropped.
+func (l *Loop) activityWithoutReply(msg *bus.InboundMessage) bool {
+	eventType, _ := msg.Metadata[bus.MetaKeyEventType].(string)
+	if eventType = strings.TrimSpace(eventType); eventType == "" {
+		return false
+	}
+	if l.cfg != nil {
+		for _, t := range l.cfg.Channels.ReplyEvents {
+			if strings.EqualFold(strings.TrimSpace(t), eventType) {
+				return false
+			}
+		}
+	}
+	if l.timeline != nil {
+		meta, _ := json.Marshal(map[string]any{
+			"channel":    msg.Channel,
+			"chat_id":    msg.ChatID,
+			"event_type": eventType,
+			"event":      msg.Metadata[bus.MetaKeyEvent],
+		})
+		_ = l.timeline.AddEvent(&timeline.TimelineEvent{
+			EventID:        fmt.Sprintf("ACTIVITY_%s_%d", msg.TraceID, time.Now().UnixNano()),
+			TraceID:        msg.TraceID,
+			Timestamp:      time.Now(),
+			SenderID:       msg.SenderID,
+			SenderName:     msg.Channel,
+			EventType:      "SYSTEM",
+			ContentText:    msg.Content,
+			Classification: "ACTIVITY",
+			Metadata:       string(meta),
+		})
+	}
+	return true
+}
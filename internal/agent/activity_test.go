@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/provider"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestActivityEventsAreNotAnsweredByDefault(t *testing.T) {
+	tl := newTestTimeline(t)
+	msgBus := bus.NewMessageBus()
+	prov := &mockProvider{responses: []provider.ChatResponse{{Content: "Thanks for the details."}}}
+	cfg := config.DefaultConfig()
+	cfg.Channels.ReplyEvents = []string{"view_submission"}
+	loop := NewLoop(LoopOptions{
+		Bus:           msgBus,
+		Provider:      prov,
+		Timeline:      tl,
+		Workspace:     t.TempDir(),
+		Model:         "mock-model",
+		MaxIterations: 3,
+		Config:        cfg,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	outCh := make(chan *bus.OutboundMessage, 4)
+	msgBus.Subscribe("slack", func(msg *bus.OutboundMessage) { outCh <- msg })
+	go func() { _ = msgBus.DispatchOutbound(ctx) }()
+	go func() { _ = loop.Run(ctx) }()
+	defer loop.Stop()
+
+	event := func(traceID, eventType, content string) *bus.InboundMessage {
+		return &bus.InboundMessage{
+			Channel:   "slack",
+			SenderID:  "U1",
+			ChatID:    "C1",
+			TraceID:   traceID,
+			Content:   content,
+			Timestamp: time.Now(),
+			Metadata: map[string]any{
+				bus.MetaKeyMessageType: bus.MessageTypeExternal,
+				bus.MetaKeyEventType:   eventType,
+				bus.MetaKeyEvent:       map[string]any{"reaction": "thumbsup"},
+			},
+		}
+	}
+	msgBus.PublishInbound(event("trace-reaction", "reaction_added", "[reaction added: thumbsup]"))
+	msgBus.PublishInbound(event("trace-join", "member_joined_channel", "[joined channel]"))
+	msgBus.PublishInbound(event("trace-modal", "view_submission", "[modal submitted: feedback] rating=\"5\""))
+
+	select {
+	case out := <-outCh:
+		if out.TraceID != "trace-modal" || out.Content != "Thanks for the details." {
+			t.Fatalf("expected only the listed event type to be answered, got %+v", out)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for reply")
+	}
+	select {
+	case out := <-outCh:
+		t.Fatalf("unexpected second reply: %+v", out)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if prov.calls != 1 {
+		t.Fatalf("activity must not reach the model, got %d calls", prov.calls)
+	}
+	events, err := tl.GetEvents(timeline.FilterArgs{TraceID: "trace-reaction"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Classification != "ACTIVITY" {
+		t.Fatalf("expected the reaction on the timeline, got %+v", events)
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/KafClaw/KafClaw/internal/approval"
 	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
 	"github.com/KafClaw/KafClaw/internal/policy"
 	"github.com/KafClaw/KafClaw/internal/provider"
 )
@@ -485,3 +486,214 @@ func TestApprovalRunInterception(t *testing.T) {
 	}
 	t.Logf("Run() interception test passed for approval ID=%s", id)
 }
+
+// TestApprovalRunRejectsOtherSenders verifies that only the requesting
+// sender (or the owner) can decide a pending approval.
+func TestApprovalRunRejectsOtherSenders(t *testing.T) {
+	tl := newTestTimeline(t)
+	msgBus := bus.NewMessageBus()
+	tmpDir := t.TempDir()
+
+	loop := NewLoop(LoopOptions{
+		Bus:           msgBus,
+		Provider:      &mockProvider{},
+		Timeline:      tl,
+		Policy:        policy.NewDefaultEngine(),
+		Workspace:     tmpDir,
+		WorkRepo:      tmpDir,
+		Model:         "mock-model",
+		MaxIterations: 5,
+	})
+	id := loop.approvalMgr.Create(&approval.ApprovalRequest{Tool: "exec", Tier: 2, Sender: "owner@s.whatsapp.net"})
+
+	var outbound outboundCapture
+	msgBus.Subscribe("whatsapp", func(msg *bus.OutboundMessage) {
+		outbound.add(msg)
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go msgBus.DispatchOutbound(ctx)
+	go func() { _ = loop.Run(ctx) }()
+
+	msgBus.PublishInbound(&bus.InboundMessage{
+		Channel:   "whatsapp",
+		SenderID:  "other@s.whatsapp.net",
+		ChatID:    "group@g.us",
+		Content:   fmt.Sprintf("approve:%s", id),
+		Timestamp: time.Now(),
+	})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, o := range outbound.snapshot() {
+			if strings.Contains(o.Content, "not allowed") && strings.Contains(o.Content, id) {
+				if err := loop.approvalMgr.Respond(id, false); err != nil {
+					t.Fatalf("expected approval still pending: %v", err)
+				}
+				return
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("expected the other sender to be rejected")
+}
+
+func TestApprovalNoticesRouting(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	cfg := config.DefaultConfig()
+	cfg.Tools.Approvals.ExpirySec = 300
+	cfg.Tools.Approvals.EscalationChannel = "slack"
+	cfg.Tools.Approvals.EscalationChatID = "C-oncall"
+	loop := NewLoop(LoopOptions{
+		Bus:       msgBus,
+		Provider:  &mockProvider{},
+		Config:    cfg,
+		Workspace: t.TempDir(),
+		Model:     "mock-model",
+	})
+	if got := loop.approvalExpiry(); got != 5*time.Minute {
+		t.Fatalf("expected configured expiry, got %s", got)
+	}
+
+	var outbound outboundCapture
+	msgBus.Subscribe("whatsapp", outbound.add)
+	msgBus.Subscribe("slack", outbound.add)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go msgBus.DispatchOutbound(ctx)
+
+	req := approval.ApprovalRequest{ApprovalID: "a1", Channel: "whatsapp", ChatID: "owner", ThreadID: "t1"}
+	loop.publishApprovalNotice(req, approval.NoticeReminder, "reminder")
+	loop.publishApprovalNotice(req, approval.NoticeEscalated, "escalated")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(outbound.snapshot()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	got := map[string]bus.OutboundMessage{}
+	for _, o := range outbound.snapshot() {
+		got[o.Content] = o
+	}
+	if r := got["reminder"]; r.Channel != "whatsapp" || r.ChatID != "owner" || r.ThreadID != "t1" || r.Card != nil || r.Visibility != "" {
+		t.Fatalf("expected a plain reminder in the originating thread, got %+v", r)
+	}
+	if e := got["escalated"]; e.Channel != "slack" || e.ChatID != "C-oncall" || e.Card == nil {
+		t.Fatalf("expected escalation with buttons to the escalation target, got %+v", e)
+	}
+}
+
+// TestApprovalPromptAndPolicyDenialArePrivate checks that on Slack the
+// approval prompt and a policy denial reach the requester only.
+func TestApprovalPromptAndPolicyDenialArePrivate(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	tmpDir := t.TempDir()
+	mock := &mockProvider{
+		responses: []provider.ChatResponse{
+			{ToolCalls: []provider.ToolCall{{ID: "call_exec", Name: "exec", Arguments: map[string]any{"command": "echo hello"}}}},
+			{ToolCalls: []provider.ToolCall{{ID: "call_read", Name: "read_file", Arguments: map[string]any{"path": "notes.txt"}}}},
+			{Content: "done"},
+		},
+	}
+	policyEngine := policy.NewDefaultEngine()
+	policyEngine.ToolLists = map[string]policy.ToolListPolicy{
+		"slack": {Deny: map[string]bool{"read_file": true}},
+	}
+	loop := NewLoop(LoopOptions{
+		Bus:           msgBus,
+		Provider:      mock,
+		Policy:        policyEngine,
+		Workspace:     tmpDir,
+		WorkRepo:      tmpDir,
+		Model:         "mock-model",
+		MaxIterations: 5,
+	})
+
+	var outbound outboundCapture
+	msgBus.Subscribe("slack", outbound.add)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go msgBus.DispatchOutbound(ctx)
+
+	msg := &bus.InboundMessage{
+		Channel:   "slack",
+		SenderID:  "U123",
+		ChatID:    "C1",
+		TraceID:   "trace-private-001",
+		Content:   "Run echo hello and read notes",
+		Timestamp: time.Now(),
+		Metadata: map[string]any{
+			bus.MetaKeyMessageType: bus.MessageTypeInternal,
+		},
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := loop.processMessage(ctx, msg)
+		done <- err
+	}()
+
+	approvalID := waitForApprovalPrompt(t, &outbound, 5*time.Second)
+	if err := loop.approvalMgr.Respond(approvalID, true); err != nil {
+		t.Fatalf("respond failed: %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("processMessage error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("processMessage did not complete after approval")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	var prompt, denial *bus.OutboundMessage
+	for (prompt == nil || denial == nil) && time.Now().Before(deadline) {
+		for _, o := range outbound.snapshot() {
+			o := o
+			switch {
+			case strings.Contains(o.Content, "requires approval"):
+				prompt = &o
+			case strings.Contains(o.Content, "denied by policy"):
+				denial = &o
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for name, o := range map[string]*bus.OutboundMessage{"approval prompt": prompt, "policy denial": denial} {
+		if o == nil {
+			t.Fatalf("expected a %s", name)
+		}
+		if o.ChatID != "C1" || o.Visibility != "ephemeral" || o.RecipientID != "U123" {
+			t.Fatalf("expected a private %s to the requester, got %+v", name, *o)
+		}
+	}
+	if !strings.Contains(denial.Content, `"read_file"`) {
+		t.Fatalf("expected the denied tool in the notice, got %q", denial.Content)
+	}
+}
+
+func TestApprovalCard(t *testing.T) {
+	if approvalCard("whatsapp", "prompt", "a1") != nil {
+		t.Fatal("expected no card outside Slack and Teams")
+	}
+	slack := approvalCard("slack", "prompt", "a1")
+	blocks := slack["blocks"].([]any)
+	buttons := blocks[1].(map[string]any)["elements"].([]any)
+	if slack["text"] != "prompt" || len(buttons) != 2 {
+		t.Fatalf("unexpected slack card %#v", slack)
+	}
+	for i, want := range []string{"approve:a1", "deny:a1"} {
+		b := buttons[i].(map[string]any)
+		if id, _, _ := parseApprovalResponse(b["value"].(string)); id != "a1" || b["value"] != want ||
+			!strings.HasPrefix(b["action_id"].(string), approvalActionPrefix) {
+			t.Fatalf("unexpected slack button %#v", b)
+		}
+	}
+	teams := approvalCard("msteams", "prompt", "a1")
+	actions := teams["actions"].([]any)
+	if teams["type"] != "AdaptiveCard" || len(actions) != 2 {
+		t.Fatalf("unexpected teams card %#v", teams)
+	}
+	if data := actions[1].(map[string]any)["data"].(map[string]any); data["text"] != "deny:a1" {
+		t.Fatalf("unexpected teams deny action %#v", data)
+	}
+}
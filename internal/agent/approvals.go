@@ -0,0 +1,176 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/approval"
+	"github.com/KafClaw/KafClaw/internal/bus"
+)
+
+// setupApprovals applies the configured approval lifecycle (reminders,
+// delegation, expiry) and routes its notices over the bus.
+func (l *Loop) setupApprovals() {
+	if l.approvalMgr == nil || l.cfg == nil {
+		return
+	}
+	cfg := l.cfg.Tools.Approvals
+	var delegates []string
+	for _, d := range cfg.Delegates {
+		if d = strings.TrimSpace(d); d != "" {
+			delegates = append(delegates, d)
+		}
+	}
+	action := approval.ExpiryDeny
+	if strings.EqualFold(strings.TrimSpace(cfg.ExpiryAction), approval.ExpiryEscalate) {
+		action = approval.ExpiryEscalate
+	}
+	l.approvalMgr.SetPolicy(approval.Policy{
+		ExpiryAction:     action,
+		ReminderInterval: time.Duration(cfg.ReminderIntervalSec) * time.Second,
+		DelegateAfter:    time.Duration(cfg.DelegateAfterSec) * time.Second,
+		Delegates:        delegates,
+	})
+	l.approvalMgr.SetNotifier(l.publishApprovalNotice)
+}
+
+// publishApprovalNotice sends a reminder or notice of a pending approval to
+// the chat it was requested in; escalations go to the escalation target
+// when one is configured. Reminders reach the requester only, like the
+// prompt.
+func (l *Loop) publishApprovalNotice(req approval.ApprovalRequest, kind, text string) {
+	if l.bus == nil {
+		return
+	}
+	out := &bus.OutboundMessage{
+		Channel:  req.Channel,
+		ChatID:   req.ChatID,
+		ThreadID: req.ThreadID,
+		TraceID:  req.TraceID,
+		TaskID:   req.TaskID,
+		Content:  text,
+	}
+	if l.cfg != nil && kind == approval.NoticeEscalated {
+		if c := l.cfg.Tools.Approvals; c.EscalationChannel != "" && c.EscalationChatID != "" {
+			out.Channel, out.ChatID, out.ThreadID = c.EscalationChannel, c.EscalationChatID, ""
+		}
+	}
+	if out.Channel == "" || out.ChatID == "" {
+		return
+	}
+	if kind != approval.NoticeExpired {
+		out.Card = approvalCard(out.Channel, text, req.ApprovalID)
+	}
+	if kind == approval.NoticeReminder {
+		out.Visibility, out.RecipientID = privateTo(out.Channel, req.Sender)
+	}
+	l.bus.PublishOutbound(out)
+}
+
+// createApproval registers an approval for the active chat; it expires
+// after the configured approval expiry.
+func (l *Loop) createApproval(req *approval.ApprovalRequest) string {
+	req.ChatID = l.activeChatID
+	req.ThreadID = l.activeThreadID
+	req.ExpiresAt = time.Now().Add(l.approvalExpiry())
+	return l.approvalMgr.Create(req)
+}
+
+// approvalExpiry returns tools.approvals.expirySec, or the
+// approval_timeout_seconds setting when it is not set.
+func (l *Loop) approvalExpiry() time.Duration {
+	if l.cfg != nil && l.cfg.Tools.Approvals.ExpirySec > 0 {
+		return time.Duration(l.cfg.Tools.Approvals.ExpirySec) * time.Second
+	}
+	return l.approvalTimeout()
+}
+
+// approvalActionPrefix starts the Slack action_id of approval buttons. The
+// channel bridge forwards a click as the button value, approve:<id> or
+// deny:<id>, so it takes the same path as a typed reply.
+const approvalActionPrefix = "kafclaw_approval_"
+
+// publishApprovalPrompt asks the requester of the active message to decide
+// approvalID; Slack and Teams show the prompt to the requester only.
+func (l *Loop) publishApprovalPrompt(text, approvalID string) {
+	visibility, recipient := privateTo(l.activeChannel, l.activeSender)
+	l.bus.PublishOutbound(&bus.OutboundMessage{
+		Channel:     l.activeChannel,
+		ChatID:      l.activeChatID,
+		ThreadID:    l.activeThreadID,
+		TraceID:     l.activeTraceID,
+		TaskID:      l.activeTaskID,
+		Content:     text,
+		Card:        approvalCard(l.activeChannel, text, approvalID),
+		Visibility:  visibility,
+		RecipientID: recipient,
+	})
+}
+
+// publishPolicyDenial tells the requester of the active message that the
+// policy denied a tool call. Only Slack and Teams can deliver it privately,
+// so other channels get no notice.
+func (l *Loop) publishPolicyDenial(toolName, reason string) {
+	visibility, recipient := privateTo(l.activeChannel, l.activeSender)
+	if l.bus == nil || visibility == "" || l.activeChatID == "" {
+		return
+	}
+	l.bus.PublishOutbound(&bus.OutboundMessage{
+		Channel:     l.activeChannel,
+		ChatID:      l.activeChatID,
+		ThreadID:    l.activeThreadID,
+		TraceID:     l.activeTraceID,
+		TaskID:      l.activeTaskID,
+		Content:     fmt.Sprintf("Tool %q was denied by policy: %s", toolName, reason),
+		Visibility:  visibility,
+		RecipientID: recipient,
+	})
+}
+
+// privateTo returns the visibility and recipient that show a message to
+// sender only, or empty values on channels without private replies.
+func privateTo(channel, sender string) (string, string) {
+	switch channel {
+	case "slack", "msteams":
+		if sender != "" {
+			return "ephemeral", sender
+		}
+	}
+	return "", ""
+}
+
+// approvalCard adds Approve and Deny buttons to an approval prompt: Block
+// Kit buttons on Slack, Adaptive Card actions on Teams. Other channels keep
+// the approve:<id> / deny:<id> text.
+func approvalCard(channel, text, approvalID string) map[string]any {
+	approve, deny := "approve:"+approvalID, "deny:"+approvalID
+	switch channel {
+	case "slack":
+		return map[string]any{
+			"text": text,
+			"blocks": []any{
+				map[string]any{"type": "section", "text": map[string]any{"type": "mrkdwn", "text": truncateStr(text, 2900)}},
+				map[string]any{"type": "actions", "elements": []any{
+					map[string]any{"type": "button", "action_id": approvalActionPrefix + "approve", "style": "primary", "value": approve,
+						"text": map[string]any{"type": "plain_text", "text": "Approve"}},
+					map[string]any{"type": "button", "action_id": approvalActionPrefix + "deny", "style": "danger", "value": deny,
+						"text": map[string]any{"type": "plain_text", "text": "Deny"}},
+				}},
+			},
+		}
+	case "msteams":
+		// The prompt goes out as the message text; the card only holds
+		// the actions. Teams forwards the submitted data.text as a reply.
+		return map[string]any{
+			"type":    "AdaptiveCard",
+			"version": "1.4",
+			"body":    []any{},
+			"actions": []any{
+				map[string]any{"type": "Action.Submit", "title": "Approve", "style": "positive", "data": map[string]any{"text": approve}},
+				map[string]any{"type": "Action.Submit", "title": "Deny", "style": "destructive", "data": map[string]any{"text": deny}},
+			},
+		}
+	}
+	return nil
+}
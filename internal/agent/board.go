@@ -0,0 +1,222 @@
+package agent
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+	"github.com/KafClaw/KafClaw/internal/tools"
+)
+
+// boardChatLimit bounds the items shown by the board chat command and the
+// task_board list action.
+const boardChatLimit = 50
+
+// activeBoardChat returns the chat whose board the task_board tool works on.
+func (l *Loop) activeBoardChat() (string, string, error) {
+	if l.timeline == nil {
+		return "", "", fmt.Errorf("task board unavailable: no timeline")
+	}
+	if l.activeChannel == "" || l.activeChatID == "" {
+		return "", "", fmt.Errorf("task board unavailable outside a chat")
+	}
+	return l.activeChannel, l.activeChatID, nil
+}
+
+func (l *Loop) listBoardItemsForTool(includeClosed bool) ([]tools.BoardItemView, error) {
+	channel, chatID, err := l.activeBoardChat()
+	if err != nil {
+		return nil, err
+	}
+	recs, err := l.timeline.ListBoardItems(timeline.BoardItemFilter{
+		Channel: channel, ChatID: chatID, IncludeClosed: includeClosed, Limit: boardChatLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]tools.BoardItemView, 0, len(recs))
+	for _, r := range recs {
+		out = append(out, boardItemView(r))
+	}
+	return out, nil
+}
+
+func (l *Loop) createBoardItemForTool(item tools.BoardItemView) (tools.BoardItemView, error) {
+	channel, chatID, err := l.activeBoardChat()
+	if err != nil {
+		return tools.BoardItemView{}, err
+	}
+	rec := timeline.BoardItemRecord{
+		Channel:   channel,
+		ChatID:    chatID,
+		Title:     item.Title,
+		Notes:     item.Notes,
+		Status:    item.Status,
+		Assignee:  item.Assignee,
+		CreatedBy: "agent",
+		TraceID:   l.activeTraceID,
+	}
+	if item.Due != "" {
+		due, ok := parseCommitmentDue(item.Due, time.Now())
+		if !ok {
+			return tools.BoardItemView{}, fmt.Errorf("invalid due date %q: use RFC3339 or YYYY-MM-DD", item.Due)
+		}
+		rec.DueAt = &due
+	}
+	if err := l.timeline.CreateBoardItem(&rec); err != nil {
+		return tools.BoardItemView{}, err
+	}
+	return boardItemView(rec), nil
+}
+
+func (l *Loop) updateBoardItemForTool(id int64, change tools.BoardItemChange) (tools.BoardItemView, error) {
+	channel, chatID, err := l.activeBoardChat()
+	if err != nil {
+		return tools.BoardItemView{}, err
+	}
+	rec, err := l.timeline.GetBoardItem(id)
+	if err != nil {
+		return tools.BoardItemView{}, err
+	}
+	if rec == nil || rec.Channel != channel || rec.ChatID != chatID {
+		return tools.BoardItemView{}, fmt.Errorf("board item %d not found in this chat", id)
+	}
+	if err := applyBoardItemChange(rec, change, time.Now()); err != nil {
+		return tools.BoardItemView{}, err
+	}
+	if err := l.timeline.UpdateBoardItem(rec); err != nil {
+		return tools.BoardItemView{}, err
+	}
+	return boardItemView(*rec), nil
+}
+
+// applyBoardItemChange copies the set fields of change onto rec.
+func applyBoardItemChange(rec *timeline.BoardItemRecord, change tools.BoardItemChange, now time.Time) error {
+	if change.Title != nil {
+		if *change.Title == "" {
+			return fmt.Errorf("title must not be empty")
+		}
+		rec.Title = *change.Title
+	}
+	if change.Notes != nil {
+		rec.Notes = *change.Notes
+	}
+	if change.Status != nil {
+		if !timeline.ValidBoardItemStatus(*change.Status) {
+			return fmt.Errorf("invalid status %q", *change.Status)
+		}
+		rec.Status = *change.Status
+	}
+	if change.Assignee != nil {
+		rec.Assignee = *change.Assignee
+	}
+	if change.Due != nil {
+		rec.DueAt = nil
+		if *change.Due != "" {
+			due, ok := parseCommitmentDue(*change.Due, now)
+			if !ok {
+				return fmt.Errorf("invalid due date %q: use RFC3339 or YYYY-MM-DD", *change.Due)
+			}
+			rec.DueAt = &due
+		}
+	}
+	return nil
+}
+
+func boardItemView(r timeline.BoardItemRecord) tools.BoardItemView {
+	v := tools.BoardItemView{
+		ID:       r.ID,
+		Title:    r.Title,
+		Notes:    r.Notes,
+		Status:   r.Status,
+		Assignee: r.Assignee,
+	}
+	if r.DueAt != nil {
+		v.Due = r.DueAt.UTC().Format(time.RFC3339)
+	}
+	return v
+}
+
+// handleBoardCommand serves the chat surface of the task board:
+//
+//	board                 list active items of this chat
+//	board all             include done and cancelled items
+//	board done <id>       mark an item as done
+//	board cancel <id>     cancel an item
+func (l *Loop) handleBoardCommand(msg *bus.InboundMessage) (string, bool) {
+	if l.timeline == nil {
+		return "", false
+	}
+	fields := strings.Fields(strings.ToLower(strings.TrimSpace(msg.Content)))
+	if len(fields) == 0 || fields[0] != "board" {
+		return "", false
+	}
+	switch {
+	case len(fields) == 1:
+		return l.listBoardText(msg.Channel, msg.ChatID, false), true
+	case len(fields) == 2 && fields[1] == "all":
+		return l.listBoardText(msg.Channel, msg.ChatID, true), true
+	case len(fields) == 3 && (fields[1] == "done" || fields[1] == "cancel"):
+		id, err := strconv.ParseInt(strings.TrimPrefix(fields[2], "#"), 10, 64)
+		if err != nil {
+			return "Usage: board done|cancel <id>", true
+		}
+		return l.closeBoardItemText(msg.Channel, msg.ChatID, id, fields[1]), true
+	default:
+		return "", false
+	}
+}
+
+func (l *Loop) listBoardText(channel, chatID string, includeClosed bool) string {
+	recs, err := l.timeline.ListBoardItems(timeline.BoardItemFilter{
+		Channel: channel, ChatID: chatID, IncludeClosed: includeClosed, Limit: boardChatLimit,
+	})
+	if err != nil {
+		return fmt.Sprintf("Board: lookup failed: %v", err)
+	}
+	if len(recs) == 0 {
+		return "The task board of this chat is empty."
+	}
+	var b strings.Builder
+	b.WriteString("Task board:\n")
+	for _, r := range recs {
+		fmt.Fprintf(&b, "#%d [%s] %s", r.ID, r.Status, r.Title)
+		var details []string
+		if r.Assignee != "" {
+			details = append(details, "@"+strings.TrimPrefix(r.Assignee, "@"))
+		}
+		if r.DueAt != nil {
+			details = append(details, "due "+r.DueAt.Local().Format("2006-01-02 15:04"))
+		}
+		if len(details) > 0 {
+			b.WriteString(" (" + strings.Join(details, ", ") + ")")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("Close with: board done <id> or board cancel <id>")
+	return b.String()
+}
+
+func (l *Loop) closeBoardItemText(channel, chatID string, id int64, action string) string {
+	rec, err := l.timeline.GetBoardItem(id)
+	if err != nil {
+		return fmt.Sprintf("Board: lookup failed: %v", err)
+	}
+	if rec == nil || rec.Channel != channel || rec.ChatID != chatID {
+		return fmt.Sprintf("Board item #%d not found in this chat.", id)
+	}
+	if rec.Status == timeline.BoardItemDone || rec.Status == timeline.BoardItemCancelled {
+		return fmt.Sprintf("Board item #%d is already %s.", id, rec.Status)
+	}
+	rec.Status = timeline.BoardItemDone
+	if action == "cancel" {
+		rec.Status = timeline.BoardItemCancelled
+	}
+	if err := l.timeline.UpdateBoardItem(rec); err != nil {
+		return fmt.Sprintf("Board: update failed: %v", err)
+	}
+	return fmt.Sprintf("Board item #%d marked %s: %s", id, rec.Status, rec.Title)
+}
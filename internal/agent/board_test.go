@@ -0,0 +1,108 @@
+package agent
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+	"github.com/KafClaw/KafClaw/internal/tools"
+)
+
+func TestTaskBoardToolScopedToActiveChat(t *testing.T) {
+	tl := newTestTimeline(t)
+	loop := NewLoop(LoopOptions{Timeline: tl, Config: config.DefaultConfig(), Workspace: t.TempDir(), WorkRepo: t.TempDir()})
+	if _, ok := loop.registry.Get(tools.TaskBoardToolName); !ok {
+		t.Fatal("expected task_board tool registered with a timeline")
+	}
+
+	other := &timeline.BoardItemRecord{Channel: "slack", ChatID: "C2", Title: "other chat"}
+	_ = tl.CreateBoardItem(other)
+
+	loop.activeChannel, loop.activeChatID, loop.activeTraceID = "slack", "C1", "trace-1"
+	ctx := context.Background()
+	if _, err := loop.registry.Execute(ctx, tools.TaskBoardToolName, map[string]any{
+		"action": "create", "title": "rotate keys", "assignee": "alice", "due": "2026-03-06",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	items, _ := tl.ListBoardItems(timeline.BoardItemFilter{Channel: "slack", ChatID: "C1"})
+	if len(items) != 1 || items[0].Assignee != "alice" || items[0].DueAt == nil || items[0].CreatedBy != "agent" || items[0].TraceID != "trace-1" {
+		t.Fatalf("unexpected created item: %+v", items)
+	}
+	id := float64(items[0].ID)
+
+	if _, err := loop.registry.Execute(ctx, tools.TaskBoardToolName, map[string]any{
+		"action": "update", "id": id, "status": "in_progress", "due": "",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := tl.GetBoardItem(items[0].ID); got.Status != timeline.BoardItemInProgress || got.DueAt != nil || got.Title != "rotate keys" {
+		t.Fatalf("unexpected updated item: %+v", got)
+	}
+	if _, err := loop.registry.Execute(ctx, tools.TaskBoardToolName, map[string]any{
+		"action": "update", "id": float64(other.ID), "status": "done",
+	}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected other chat's item to be hidden, got %v", err)
+	}
+	if _, err := loop.registry.Execute(ctx, tools.TaskBoardToolName, map[string]any{
+		"action": "update", "id": id, "status": "blocked",
+	}); err == nil {
+		t.Fatal("expected error for invalid status")
+	}
+
+	out, err := loop.registry.Execute(ctx, tools.TaskBoardToolName, map[string]any{"action": "list"})
+	if err != nil || !strings.Contains(out, "rotate keys") || strings.Contains(out, "other chat") {
+		t.Fatalf("unexpected list: %s err=%v", out, err)
+	}
+
+	loop.activeChatID = ""
+	if _, err := loop.registry.Execute(ctx, tools.TaskBoardToolName, map[string]any{"action": "list"}); err == nil {
+		t.Fatal("expected error outside a chat")
+	}
+}
+
+func TestBoardChatCommands(t *testing.T) {
+	tl := newTestTimeline(t)
+	loop := NewLoop(LoopOptions{Timeline: tl, Config: config.DefaultConfig(), Workspace: t.TempDir(), WorkRepo: t.TempDir()})
+
+	mine := &timeline.BoardItemRecord{Channel: "slack", ChatID: "C1", Title: "write release notes", Assignee: "bob"}
+	other := &timeline.BoardItemRecord{Channel: "slack", ChatID: "C2", Title: "other chat"}
+	_ = tl.CreateBoardItem(mine)
+	_ = tl.CreateBoardItem(other)
+
+	msg := &bus.InboundMessage{Channel: "slack", ChatID: "C1", Content: "Board"}
+	out, ok := loop.handleBoardCommand(msg)
+	if !ok || !strings.Contains(out, "write release notes (@bob)") || strings.Contains(out, "other chat") {
+		t.Fatalf("unexpected list: ok=%v %q", ok, out)
+	}
+
+	msg.Content = "board done " + strconv.FormatInt(other.ID, 10)
+	if out, _ := loop.handleBoardCommand(msg); !strings.Contains(out, "not found") {
+		t.Fatalf("expected other chat's item to be hidden, got %q", out)
+	}
+	msg.Content = "board done #" + strconv.FormatInt(mine.ID, 10)
+	if out, _ := loop.handleBoardCommand(msg); !strings.Contains(out, "marked done") {
+		t.Fatalf("unexpected close reply: %q", out)
+	}
+	if out, _ := loop.handleBoardCommand(msg); !strings.Contains(out, "already done") {
+		t.Fatalf("unexpected second close reply: %q", out)
+	}
+
+	msg.Content = "board"
+	if out, _ := loop.handleBoardCommand(msg); !strings.Contains(out, "empty") {
+		t.Fatalf("expected empty active board, got %q", out)
+	}
+	msg.Content = "board all"
+	if out, _ := loop.handleBoardCommand(msg); !strings.Contains(out, "[done] write release notes") {
+		t.Fatalf("expected closed item in full list, got %q", out)
+	}
+
+	msg.Content = "board meeting moved to friday"
+	if _, ok := loop.handleBoardCommand(msg); ok {
+		t.Fatal("free text must not be treated as a command")
+	}
+}
@@ -0,0 +1,53 @@
+package agent
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrTaskCancelled is the error of a task an operator cancelled.
+var ErrTaskCancelled = errors.New("task cancelled by operator")
+
+// trackTaskCancel derives a context CancelTask can cancel for taskID. The
+// returned func stops tracking; call it when the task ends.
+func (l *Loop) trackTaskCancel(ctx context.Context, taskID string) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	if taskID == "" {
+		return ctx, func() { cancel(nil) }
+	}
+	l.taskCancelMu.Lock()
+	if l.taskCancels == nil {
+		l.taskCancels = map[string]context.CancelCauseFunc{}
+	}
+	l.taskCancels[taskID] = cancel
+	l.taskCancelMu.Unlock()
+	return ctx, func() {
+		l.taskCancelMu.Lock()
+		delete(l.taskCancels, taskID)
+		l.taskCancelMu.Unlock()
+		cancel(nil)
+	}
+}
+
+// CancelTask cancels the in-flight task taskID: the running LLM call and
+// tool are aborted through their context and no further step starts. It
+// reports false when the task is not running on this loop.
+func (l *Loop) CancelTask(taskID string) bool {
+	l.taskCancelMu.Lock()
+	cancel, ok := l.taskCancels[taskID]
+	l.taskCancelMu.Unlock()
+	if ok {
+		cancel(ErrTaskCancelled)
+	}
+	return ok
+}
+
+// taskCancelled returns ErrTaskCancelled once CancelTask cancelled ctx.
+// Other context ends (deadlines, shutdown) are left to the code that
+// waits on ctx.
+func taskCancelled(ctx context.Context) error {
+	if ctx.Err() != nil && errors.Is(context.Cause(ctx), ErrTaskCancelled) {
+		return ErrTaskCancelled
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/provider"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// blockingProvider blocks every call until its context is done.
+type blockingProvider struct {
+	mockProvider
+	started chan struct{}
+}
+
+func (p *blockingProvider) Chat(ctx context.Context, _ *provider.ChatRequest) (*provider.ChatResponse, error) {
+	p.started <- struct{}{}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestCancelTaskAbortsRunningTask(t *testing.T) {
+	tl := newTestTimeline(t)
+	dir := t.TempDir()
+	prov := &blockingProvider{started: make(chan struct{}, 1)}
+	loop := NewLoop(LoopOptions{
+		Bus:           bus.NewMessageBus(),
+		Provider:      prov,
+		Timeline:      tl,
+		Workspace:     dir,
+		WorkRepo:      dir,
+		Model:         "mock-model",
+		MaxIterations: 3,
+	})
+
+	go func() {
+		<-prov.started
+		tasks, _ := tl.ListTasks(timeline.TaskStatusProcessing, "", 10, 0)
+		if len(tasks) != 1 || !loop.CancelTask(tasks[0].TaskID) {
+			t.Errorf("expected one running task to cancel, got %+v", tasks)
+		}
+	}()
+	_, taskID, err := loop.processMessage(context.Background(), &bus.InboundMessage{
+		Channel: "cli", ChatID: "default", SenderID: "owner", Content: "loop forever", TraceID: "trace-cancel",
+	})
+	if !errors.Is(err, ErrTaskCancelled) {
+		t.Fatalf("expected ErrTaskCancelled, got %v", err)
+	}
+	task, err := tl.GetTask(taskID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Status != timeline.TaskStatusCancelled || task.CompletedAt == nil {
+		t.Fatalf("expected cancelled task, got %+v", task)
+	}
+	if loop.CancelTask(taskID) {
+		t.Fatal("finished task must no longer be cancellable")
+	}
+}
@@ -0,0 +1,226 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+)
+
+// chatSettings are per-chat conversation preferences changed with the
+// /settings command. Empty fields mean the default.
+type chatSettings struct {
+	Verbosity  string `json:"verbosity,omitempty"`   // short | normal | detailed
+	Language   string `json:"language,omitempty"`    // reply language; "" matches the user
+	ToolTraces string `json:"tool_traces,omitempty"` // on | off
+	Threading  string `json:"threading,omitempty"`   // on | off
+}
+
+// chatSettingKeys lists the settings in display order with their defaults.
+var chatSettingKeys = []struct{ key, def, help string }{
+	{"verbosity", "normal", "short | normal | detailed"},
+	{"language", "auto", "reply language, e.g. de or Spanish; auto matches the user"},
+	{"tool_traces", "off", "on | off, append the tools used to replies"},
+	{"threading", "on", "on | off, reply in threads where the channel supports it"},
+}
+
+var chatSettingAliases = map[string]string{
+	"lang":    "language",
+	"tools":   "tool_traces",
+	"traces":  "tool_traces",
+	"threads": "threading",
+	"thread":  "threading",
+}
+
+// chatSettingsKey is the timeline setting holding the settings of a chat.
+func chatSettingsKey(channel, chatID string) string {
+	return fmt.Sprintf("chat_settings:%s:%s", channel, chatID)
+}
+
+// loadChatSettings returns the stored settings of a chat, or the defaults.
+func (l *Loop) loadChatSettings(channel, chatID string) chatSettings {
+	var s chatSettings
+	if l.timeline == nil {
+		return s
+	}
+	raw, err := l.timeline.GetSetting(chatSettingsKey(channel, chatID))
+	if err != nil || strings.TrimSpace(raw) == "" {
+		return s
+	}
+	_ = json.Unmarshal([]byte(raw), &s)
+	return s
+}
+
+func (l *Loop) saveChatSettings(channel, chatID string, s chatSettings) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if string(data) == "{}" {
+		data = nil
+	}
+	return l.timeline.SetSetting(chatSettingsKey(channel, chatID), string(data))
+}
+
+func (s chatSettings) get(key string) string {
+	var v string
+	switch key {
+	case "verbosity":
+		v = s.Verbosity
+	case "language":
+		v = s.Language
+	case "tool_traces":
+		v = s.ToolTraces
+	case "threading":
+		v = s.Threading
+	}
+	if v == "" {
+		return chatSettingDefault(key)
+	}
+	return v
+}
+
+// set validates and stores value under key; the default value clears it.
+func (s *chatSettings) set(key, value string) error {
+	value = strings.TrimSpace(value)
+	switch key {
+	case "verbosity":
+		v := strings.ToLower(value)
+		if v != "short" && v != "normal" && v != "detailed" {
+			return fmt.Errorf("verbosity must be short, normal or detailed")
+		}
+		if v == "normal" {
+			v = ""
+		}
+		s.Verbosity = v
+	case "language":
+		if len([]rune(value)) > 40 {
+			return fmt.Errorf("language must be at most 40 characters")
+		}
+		if strings.EqualFold(value, "auto") {
+			value = ""
+		}
+		s.Language = value
+	case "tool_traces", "threading":
+		on, ok := parseOnOff(value)
+		if !ok {
+			return fmt.Errorf("%s must be on or off", key)
+		}
+		if key == "tool_traces" {
+			s.ToolTraces = ""
+			if on {
+				s.ToolTraces = "on"
+			}
+		} else {
+			s.Threading = ""
+			if !on {
+				s.Threading = "off"
+			}
+		}
+	default:
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	return nil
+}
+
+func parseOnOff(v string) (bool, bool) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "on", "true", "yes", "1":
+		return true, true
+	case "off", "false", "no", "0":
+		return false, true
+	}
+	return false, false
+}
+
+// promptHint is the system prompt section for non-default settings.
+func (s chatSettings) promptHint() string {
+	var lines []string
+	switch s.Verbosity {
+	case "short":
+		lines = append(lines, "Keep replies short: a few sentences, no preamble, lists only when essential.")
+	case "detailed":
+		lines = append(lines, "Give detailed replies: explain reasoning, include relevant context and examples.")
+	}
+	if s.Language != "" {
+		lines = append(lines, fmt.Sprintf("Reply in %s regardless of the language of the message.", s.Language))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "\n\n## Chat Settings\n" + strings.Join(lines, "\n")
+}
+
+// handleSettingsCommand answers the per-chat settings command:
+//
+//	/settings [show]         list current values
+//	/settings <key> <value>  change a setting
+//	/settings reset [key]    restore defaults
+func (l *Loop) handleSettingsCommand(msg *bus.InboundMessage) (string, bool) {
+	if l.timeline == nil {
+		return "", false
+	}
+	fields := strings.Fields(strings.TrimSpace(msg.Content))
+	if len(fields) == 0 || !strings.EqualFold(fields[0], "/settings") {
+		return "", false
+	}
+	s := l.loadChatSettings(msg.Channel, msg.ChatID)
+	if len(fields) == 1 || (len(fields) == 2 && strings.EqualFold(fields[1], "show")) {
+		return s.describe(), true
+	}
+
+	key := strings.ToLower(fields[1])
+	if alias, ok := chatSettingAliases[key]; ok {
+		key = alias
+	}
+	switch {
+	case key == "reset" && len(fields) == 2:
+		s = chatSettings{}
+	case key == "reset" && len(fields) == 3:
+		reset := strings.ToLower(fields[2])
+		if alias, ok := chatSettingAliases[reset]; ok {
+			reset = alias
+		}
+		if err := s.set(reset, chatSettingDefault(reset)); err != nil {
+			return "Settings: " + err.Error(), true
+		}
+	case len(fields) >= 3:
+		if err := s.set(key, strings.Join(fields[2:], " ")); err != nil {
+			return "Settings: " + err.Error() + "\n\n" + s.describe(), true
+		}
+	default:
+		return fmt.Sprintf("Settings: missing value for %q. Usage: /settings <key> <value>", key), true
+	}
+	if err := l.saveChatSettings(msg.Channel, msg.ChatID, s); err != nil {
+		return fmt.Sprintf("Settings: update failed: %v", err), true
+	}
+	return "Updated.\n\n" + s.describe(), true
+}
+
+func chatSettingDefault(key string) string {
+	for _, k := range chatSettingKeys {
+		if k.key == key {
+			return k.def
+		}
+	}
+	return ""
+}
+
+func (s chatSettings) describe() string {
+	var b strings.Builder
+	b.WriteString("Chat settings:\n")
+	for _, k := range chatSettingKeys {
+		fmt.Fprintf(&b, "- %s: %s (%s)\n", k.key, s.get(k.key), k.help)
+	}
+	b.WriteString("Change with: /settings <key> <value>; restore with: /settings reset [key]")
+	return b.String()
+}
+
+// formatToolTrace renders the tools used for a reply when tool_traces is on.
+func formatToolTrace(trace []string) string {
+	if len(trace) == 0 {
+		return ""
+	}
+	return "\n\n_Tools: " + strings.Join(trace, ", ") + "_"
+}
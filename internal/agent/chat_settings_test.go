@@ -0,0 +1,128 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/provider"
+)
+
+func TestSettingsChatCommands(t *testing.T) {
+	tl := newTestTimeline(t)
+	loop := NewLoop(LoopOptions{Timeline: tl, Workspace: t.TempDir(), WorkRepo: t.TempDir()})
+	msg := &bus.InboundMessage{Channel: "slack", ChatID: "C1", Content: "/settings show"}
+
+	out, ok := loop.handleSettingsCommand(msg)
+	if !ok || !strings.Contains(out, "- verbosity: normal") || !strings.Contains(out, "- threading: on") {
+		t.Fatalf("unexpected defaults: ok=%v %q", ok, out)
+	}
+	msg.Content = "/settings verbosity chatty"
+	if out, _ := loop.handleSettingsCommand(msg); !strings.Contains(out, "verbosity must be") {
+		t.Fatalf("expected validation error, got %q", out)
+	}
+	for _, cmd := range []string{"/settings verbosity short", "/Settings lang Brazilian Portuguese", "/settings tools on", "/settings threads off"} {
+		msg.Content = cmd
+		if out, _ := loop.handleSettingsCommand(msg); !strings.HasPrefix(out, "Updated.") {
+			t.Fatalf("%s: unexpected reply %q", cmd, out)
+		}
+	}
+	s := loop.loadChatSettings("slack", "C1")
+	if s.Verbosity != "short" || s.Language != "Brazilian Portuguese" || s.ToolTraces != "on" || s.Threading != "off" {
+		t.Fatalf("unexpected stored settings: %+v", s)
+	}
+	if got := loop.loadChatSettings("slack", "C2"); got != (chatSettings{}) {
+		t.Fatalf("settings must be per chat, got %+v for C2", got)
+	}
+	if hint := s.promptHint(); !strings.Contains(hint, "Keep replies short") || !strings.Contains(hint, "Reply in Brazilian Portuguese") {
+		t.Fatalf("unexpected prompt hint: %q", hint)
+	}
+
+	msg.Content = "/settings reset language"
+	loop.handleSettingsCommand(msg)
+	if s := loop.loadChatSettings("slack", "C1"); s.Language != "" || s.Verbosity != "short" {
+		t.Fatalf("expected only language reset, got %+v", s)
+	}
+	msg.Content = "/settings reset"
+	loop.handleSettingsCommand(msg)
+	if s := loop.loadChatSettings("slack", "C1"); s != (chatSettings{}) {
+		t.Fatalf("expected defaults after reset, got %+v", s)
+	}
+	msg.Content = "what are the settings of the VPN?"
+	if _, ok := loop.handleSettingsCommand(msg); ok {
+		t.Fatal("free text must not be treated as a command")
+	}
+}
+
+// promptRecordingProvider records the system prompt and the last message of
+// each request.
+type promptRecordingProvider struct {
+	mockProvider
+	systemPrompts []string
+	lastMessages  []string
+}
+
+func (p *promptRecordingProvider) Chat(ctx context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+	if len(req.Messages) > 0 {
+		p.systemPrompts = append(p.systemPrompts, req.Messages[0].Content)
+		p.lastMessages = append(p.lastMessages, req.Messages[len(req.Messages)-1].Content)
+	}
+	return p.mockProvider.Chat(ctx, req)
+}
+
+func TestSettingsAppliedToPromptAndReplies(t *testing.T) {
+	tl := newTestTimeline(t)
+	msgBus := bus.NewMessageBus()
+	tmpDir := t.TempDir()
+	prov := &promptRecordingProvider{mockProvider: mockProvider{responses: []provider.ChatResponse{
+		{ToolCalls: []provider.ToolCall{{ID: "call_1", Name: "list_dir", Arguments: map[string]any{"path": tmpDir}}}},
+		{Content: "Fertig."},
+	}}}
+	loop := NewLoop(LoopOptions{
+		Bus:           msgBus,
+		Provider:      prov,
+		Timeline:      tl,
+		Workspace:     tmpDir,
+		WorkRepo:      tmpDir,
+		Model:         "mock-model",
+		MaxIterations: 5,
+	})
+	if err := loop.saveChatSettings("cli", "owner", chatSettings{Language: "German", ToolTraces: "on", Threading: "off"}); err != nil {
+		t.Fatalf("save settings: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	outCh := make(chan *bus.OutboundMessage, 1)
+	msgBus.Subscribe("cli", func(msg *bus.OutboundMessage) { outCh <- msg })
+	go func() { _ = msgBus.DispatchOutbound(ctx) }()
+	go func() { _ = loop.Run(ctx) }()
+	defer loop.Stop()
+	msgBus.PublishInbound(&bus.InboundMessage{
+		Channel:   "cli",
+		SenderID:  "owner",
+		ChatID:    "owner",
+		ThreadID:  "t1",
+		TraceID:   "trace-settings",
+		Content:   "What is in the workspace?",
+		Timestamp: time.Now(),
+		Metadata:  map[string]any{bus.MetaKeyMessageType: bus.MessageTypeInternal},
+	})
+	var out *bus.OutboundMessage
+	select {
+	case out = <-outCh:
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for reply")
+	}
+	if out.Content != "Fertig.\n\n_Tools: list_dir_" {
+		t.Fatalf("expected tool trace appended, got %q", out.Content)
+	}
+	if out.ThreadID != "" {
+		t.Fatalf("threading off must clear the thread, got %q", out.ThreadID)
+	}
+	if len(prov.systemPrompts) == 0 || !strings.Contains(prov.systemPrompts[0], "Reply in German") {
+		t.Fatalf("expected language in system prompt, got %q", prov.systemPrompts)
+	}
+}
@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/metrics"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// CommitmentJobName is the scheduled_jobs entry updated by the commitment worker.
+const CommitmentJobName = "commitments-followup"
+
+var commitmentFollowUpsTotal = metrics.NewCounter("kafclaw_commitment_followups_total", "Follow-ups dispatched for due commitments, by owner.", "owner")
+
+// CommitmentWorker polls for due commitments and follows up on them. User
+// commitments become a reminder in the originating chat; agent commitments
+// are fed back to the agent as an internal message so it can act on them.
+type CommitmentWorker struct {
+	timeline *timeline.TimelineService
+	bus      *bus.MessageBus
+	interval time.Duration
+	now      func() time.Time
+}
+
+// NewCommitmentWorker creates a commitment worker. A non-positive interval
+// defaults to one minute.
+func NewCommitmentWorker(tl *timeline.TimelineService, b *bus.MessageBus, interval time.Duration) *CommitmentWorker {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &CommitmentWorker{
+		timeline: tl,
+		bus:      b,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Run starts the polling loop. Blocks until context is cancelled.
+func (w *CommitmentWorker) Run(ctx context.Context) error {
+	slog.Info("Commitment worker started", "interval", w.interval)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Commitment worker stopped")
+			return ctx.Err()
+		case <-ticker.C:
+			w.poll()
+		}
+	}
+}
+
+func (w *CommitmentWorker) poll() {
+	now := w.now()
+	due, err := w.timeline.ListDueCommitments(now, 20)
+	if err != nil {
+		slog.Error("Commitment worker poll failed", "error", err)
+		_ = w.timeline.UpsertScheduledJob(CommitmentJobName, "failed", now)
+		return
+	}
+	if len(due) == 0 {
+		return
+	}
+	for _, rec := range due {
+		if err := w.timeline.MarkCommitmentFollowedUp(rec.ID); err != nil {
+			slog.Error("Commitment worker failed to mark follow-up", "id", rec.ID, "error", err)
+			continue
+		}
+		w.followUp(rec, now)
+		commitmentFollowUpsTotal.Inc(rec.Owner)
+		slog.Info("Commitment follow-up dispatched", "id", rec.ID, "owner", rec.Owner, "channel", rec.Channel)
+	}
+	_ = w.timeline.UpsertScheduledJob(CommitmentJobName, "dispatched", now)
+}
+
+func (w *CommitmentWorker) followUp(rec timeline.CommitmentRecord, now time.Time) {
+	if rec.Owner == timeline.CommitmentOwnerUser {
+		w.bus.PublishOutbound(&bus.OutboundMessage{
+			Channel:  rec.Channel,
+			ChatID:   rec.ChatID,
+			ThreadID: rec.ThreadID,
+			TraceID:  rec.TraceID,
+			TaskID:   fmt.Sprintf("commitment:%d", rec.ID),
+			Content:  fmt.Sprintf("⏰ Reminder: %s\n(commitment #%d — reply \"commitment done %d\" to close it)", rec.Description, rec.ID, rec.ID),
+		})
+		return
+	}
+	meta := map[string]any{
+		bus.MetaKeyMessageType: bus.MessageTypeInternal,
+		"commitment_id":        rec.ID,
+		"scheduler_job":        CommitmentJobName,
+		"scheduler_tick":       now.Format(time.RFC3339),
+	}
+	if rec.SessionKey != "" {
+		meta[bus.MetaKeySessionScope] = rec.SessionKey
+	}
+	w.bus.PublishInbound(&bus.InboundMessage{
+		Channel:  rec.Channel,
+		SenderID: commitmentSenderID,
+		ChatID:   rec.ChatID,
+		ThreadID: rec.ThreadID,
+		Content: fmt.Sprintf("Follow-up due for your commitment #%d: %s\n"+
+			"Do it now and report the result to the user. If it cannot be done, explain why.", rec.ID, rec.Description),
+		Metadata:  meta,
+		Timestamp: now,
+	})
+}
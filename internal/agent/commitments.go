@@ -0,0 +1,225 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/provider"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// commitmentSenderID marks inbound follow-ups published by the commitment
+// worker. Turns from this sender are not mined for new commitments.
+const commitmentSenderID = "commitments"
+
+// commitmentCueRe is a cheap pre-filter so the extraction model only runs on
+// turns that plausibly contain a promise or a reminder request.
+var commitmentCueRe = regexp.MustCompile(`(?i)\b(i'll|i will|i'm going to|let me get back|remind me|don't let me forget|follow up|get back to you|tomorrow|tonight|next (week|month|monday|tuesday|wednesday|thursday|friday)|by (monday|tuesday|wednesday|thursday|friday|end of)|ich werde|ich melde mich|erinnere mich|morgen|nächste woche)\b`)
+
+const commitmentExtractionPrompt = `You extract commitments from one conversation turn between a user and an assistant.
+
+A commitment is a concrete promise to do something later:
+- owner "agent": the assistant promised to act later ("I'll check the build tomorrow").
+- owner "user": the user asked to be reminded, or announced a task for themselves ("remind me to send the report on Friday").
+
+Ignore things that were already done in this turn, vague intentions, and questions.
+
+Current time: %s
+
+Return ONLY a JSON array, no prose. Each element:
+{"owner": "agent"|"user", "description": "<short imperative description>", "due": "<RFC3339 timestamp or empty when no time was given>"}
+Resolve relative dates ("tomorrow", "next week") against the current time; use 09:00 local time when only a day is given.
+Return [] when there are no commitments.`
+
+type extractedCommitment struct {
+	Owner       string `json:"owner"`
+	Description string `json:"description"`
+	Due         string `json:"due"`
+}
+
+// commitmentsEnabled reports whether commitment tracking is configured.
+func (l *Loop) commitmentsEnabled() bool {
+	return l.timeline != nil && l.cfg != nil && l.cfg.Commitments.Enabled
+}
+
+// trackCommitments starts commitment extraction for a processed inbound
+// message. A follow-up sent by the commitment worker closes its agent
+// commitment instead, so a reminder does not turn into a new commitment.
+func (l *Loop) trackCommitments(msg *bus.InboundMessage, sessionKey, response string) {
+	if !l.commitmentsEnabled() || strings.TrimSpace(response) == "" {
+		return
+	}
+	if msg.SenderID == commitmentSenderID {
+		if id, ok := msg.Metadata["commitment_id"].(int64); ok {
+			_, _ = l.timeline.CloseCommitment(id, timeline.CommitmentDone)
+		}
+		return
+	}
+	base := timeline.CommitmentRecord{
+		Channel:    msg.Channel,
+		ChatID:     msg.ChatID,
+		ThreadID:   msg.ThreadID,
+		SessionKey: sessionKey,
+		TraceID:    msg.TraceID,
+		SourceText: msg.Content,
+	}
+	go l.extractCommitments(context.Background(), base, msg.Content, response)
+}
+
+// extractCommitments asks the model for commitments made in a turn and stores
+// them. It is called asynchronously after the response has been produced.
+func (l *Loop) extractCommitments(ctx context.Context, base timeline.CommitmentRecord, userText, response string) {
+	if !commitmentCueRe.MatchString(userText) && !commitmentCueRe.MatchString(response) {
+		return
+	}
+	model := l.cfg.Commitments.Model
+	if model == "" {
+		model = l.model
+	}
+	now := time.Now()
+	resp, err := l.provider.Chat(ctx, &provider.ChatRequest{
+		Model: model,
+		Messages: []provider.Message{
+			{Role: "system", Content: fmt.Sprintf(commitmentExtractionPrompt, now.Format(time.RFC3339+" (Monday)"))},
+			{Role: "user", Content: fmt.Sprintf("User: %s\n\nAssistant: %s", userText, response)},
+		},
+		MaxTokens: 600,
+	})
+	if err != nil {
+		slog.Warn("Commitment extraction failed", "error", err)
+		return
+	}
+	for _, c := range parseExtractedCommitments(resp.Content, now) {
+		rec := base
+		rec.Owner = c.Owner
+		rec.Description = c.Description
+		rec.DueAt = c.DueAt
+		if err := l.timeline.CreateCommitment(&rec); err != nil {
+			slog.Warn("Commitment store failed", "error", err)
+			continue
+		}
+		slog.Info("Commitment recorded", "id", rec.ID, "owner", rec.Owner, "channel", rec.Channel, "chat_id", rec.ChatID)
+	}
+}
+
+// parseExtractedCommitments decodes the model's JSON array. Code fences and
+// surrounding prose are tolerated; entries without a description are dropped.
+func parseExtractedCommitments(raw string, now time.Time) []timeline.CommitmentRecord {
+	start := strings.Index(raw, "[")
+	end := strings.LastIndex(raw, "]")
+	if start < 0 || end <= start {
+		return nil
+	}
+	var items []extractedCommitment
+	if err := json.Unmarshal([]byte(raw[start:end+1]), &items); err != nil {
+		return nil
+	}
+	var out []timeline.CommitmentRecord
+	for _, it := range items {
+		desc := strings.TrimSpace(it.Description)
+		if desc == "" {
+			continue
+		}
+		owner := strings.ToLower(strings.TrimSpace(it.Owner))
+		if owner != timeline.CommitmentOwnerUser {
+			owner = timeline.CommitmentOwnerAgent
+		}
+		rec := timeline.CommitmentRecord{Owner: owner, Description: desc}
+		if due, ok := parseCommitmentDue(it.Due, now); ok {
+			rec.DueAt = &due
+		}
+		out = append(out, rec)
+	}
+	return out
+}
+
+func parseCommitmentDue(raw string, now time.Time) (time.Time, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, false
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, true
+	}
+	if d, err := time.ParseInLocation("2006-01-02", raw, now.Location()); err == nil {
+		return d.Add(9 * time.Hour), true
+	}
+	return time.Time{}, false
+}
+
+// handleCommitmentCommand serves the chat surface:
+//
+//	commitments               list open commitments of this chat
+//	commitment done <id>      mark a commitment as done
+//	commitment cancel <id>    cancel a commitment
+func (l *Loop) handleCommitmentCommand(msg *bus.InboundMessage) (string, bool) {
+	if !l.commitmentsEnabled() {
+		return "", false
+	}
+	fields := strings.Fields(strings.ToLower(strings.TrimSpace(msg.Content)))
+	if len(fields) == 0 {
+		return "", false
+	}
+	switch {
+	case len(fields) == 1 && fields[0] == "commitments":
+		return l.listCommitmentsText(msg.Channel, msg.ChatID), true
+	case len(fields) == 3 && fields[0] == "commitment" && (fields[1] == "done" || fields[1] == "cancel"):
+		id, err := strconv.ParseInt(strings.TrimPrefix(fields[2], "#"), 10, 64)
+		if err != nil {
+			return "Usage: commitment done|cancel <id>", true
+		}
+		return l.closeCommitmentText(msg.Channel, msg.ChatID, id, fields[1]), true
+	default:
+		return "", false
+	}
+}
+
+func (l *Loop) listCommitmentsText(channel, chatID string) string {
+	recs, err := l.timeline.ListCommitments(timeline.CommitmentOpen, channel, chatID, 20)
+	if err != nil {
+		return fmt.Sprintf("Commitments: lookup failed: %v", err)
+	}
+	if len(recs) == 0 {
+		return "No open commitments."
+	}
+	var b strings.Builder
+	b.WriteString("Open commitments:\n")
+	for _, r := range recs {
+		due := "no due date"
+		if r.DueAt != nil {
+			due = "due " + r.DueAt.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(&b, "#%d [%s] %s (%s)\n", r.ID, r.Owner, r.Description, due)
+	}
+	b.WriteString("Close with: commitment done <id> or commitment cancel <id>")
+	return b.String()
+}
+
+func (l *Loop) closeCommitmentText(channel, chatID string, id int64, action string) string {
+	rec, err := l.timeline.GetCommitment(id)
+	if err != nil {
+		return fmt.Sprintf("Commitments: lookup failed: %v", err)
+	}
+	if rec == nil || rec.Channel != channel || rec.ChatID != chatID {
+		return fmt.Sprintf("Commitment #%d not found in this chat.", id)
+	}
+	status := timeline.CommitmentDone
+	if action == "cancel" {
+		status = timeline.CommitmentCancelled
+	}
+	ok, err := l.timeline.CloseCommitment(id, status)
+	if err != nil {
+		return fmt.Sprintf("Commitments: update failed: %v", err)
+	}
+	if !ok {
+		return fmt.Sprintf("Commitment #%d is already %s.", id, rec.Status)
+	}
+	return fmt.Sprintf("Commitment #%d marked %s: %s", id, status, rec.Description)
+}
@@ -0,0 +1,122 @@
+package agent
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestParseExtractedCommitments(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	raw := "```json\n[" +
+		`{"owner":"user","description":"send the report","due":"2026-03-06T09:00:00Z"},` +
+		`{"owner":"bot","description":"check the build","due":"2026-03-03"},` +
+		`{"owner":"agent","description":"  ","due":""}` +
+		"]\n```"
+	got := parseExtractedCommitments(raw, now)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 commitments, got %+v", got)
+	}
+	if got[0].Owner != timeline.CommitmentOwnerUser || got[0].DueAt == nil || !got[0].DueAt.Equal(time.Date(2026, 3, 6, 9, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected user commitment: %+v", got[0])
+	}
+	if got[1].Owner != timeline.CommitmentOwnerAgent || got[1].DueAt == nil || got[1].DueAt.Hour() != 9 {
+		t.Fatalf("unexpected agent commitment: %+v", got[1])
+	}
+	if parseExtractedCommitments("no commitments here", now) != nil {
+		t.Fatal("expected nil for non-JSON output")
+	}
+}
+
+func TestCommitmentChatCommands(t *testing.T) {
+	tl := newTestTimeline(t)
+	cfg := config.DefaultConfig()
+	cfg.Commitments.Enabled = true
+	loop := NewLoop(LoopOptions{Timeline: tl, Config: cfg, Workspace: t.TempDir(), WorkRepo: t.TempDir()})
+
+	mine := &timeline.CommitmentRecord{Description: "check the build", Channel: "slack", ChatID: "C1"}
+	other := &timeline.CommitmentRecord{Description: "other chat", Channel: "slack", ChatID: "C2"}
+	_ = tl.CreateCommitment(mine)
+	_ = tl.CreateCommitment(other)
+
+	msg := &bus.InboundMessage{Channel: "slack", ChatID: "C1", Content: "commitments"}
+	out, ok := loop.handleCommitmentCommand(msg)
+	if !ok || !strings.Contains(out, "check the build") || strings.Contains(out, "other chat") {
+		t.Fatalf("unexpected list: ok=%v %q", ok, out)
+	}
+
+	msg.Content = "commitment done " + strconv.FormatInt(other.ID, 10)
+	if out, _ := loop.handleCommitmentCommand(msg); !strings.Contains(out, "not found") {
+		t.Fatalf("expected other chat's commitment to be hidden, got %q", out)
+	}
+	msg.Content = "Commitment done #" + strconv.FormatInt(mine.ID, 10)
+	if out, _ := loop.handleCommitmentCommand(msg); !strings.Contains(out, "marked done") {
+		t.Fatalf("unexpected close reply: %q", out)
+	}
+	if rec, _ := tl.GetCommitment(mine.ID); rec == nil || rec.Status != timeline.CommitmentDone {
+		t.Fatalf("expected commitment closed, got %+v", rec)
+	}
+
+	msg.Content = "what are my commitments to the team?"
+	if _, ok := loop.handleCommitmentCommand(msg); ok {
+		t.Fatal("free text must not be treated as a command")
+	}
+}
+
+func TestCommitmentWorkerFollowsUpDueCommitments(t *testing.T) {
+	tl := newTestTimeline(t)
+	msgBus := bus.NewMessageBus()
+	worker := NewCommitmentWorker(tl, msgBus, time.Minute)
+
+	past := time.Now().Add(-time.Minute)
+	user := &timeline.CommitmentRecord{Owner: timeline.CommitmentOwnerUser, Description: "send the report", DueAt: &past, Channel: "slack", ChatID: "C1", ThreadID: "T1"}
+	agentRec := &timeline.CommitmentRecord{Description: "check the build", DueAt: &past, Channel: "slack", ChatID: "C1", SessionKey: "slack:default:C1"}
+	_ = tl.CreateCommitment(user)
+	_ = tl.CreateCommitment(agentRec)
+
+	reminders := make(chan *bus.OutboundMessage, 2)
+	msgBus.Subscribe("slack", func(m *bus.OutboundMessage) { reminders <- m })
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go func() { _ = msgBus.DispatchOutbound(ctx) }()
+
+	worker.poll()
+
+	select {
+	case out := <-reminders:
+		if out.ChatID != "C1" || out.ThreadID != "T1" || !strings.Contains(out.Content, "send the report") {
+			t.Fatalf("unexpected reminder: %+v", out)
+		}
+	case <-ctx.Done():
+		t.Fatal("expected reminder for user commitment")
+	}
+
+	in, err := msgBus.ConsumeInbound(ctx)
+	if err != nil {
+		t.Fatalf("expected follow-up for agent commitment: %v", err)
+	}
+	if in.SenderID != commitmentSenderID || in.MessageType() != bus.MessageTypeInternal ||
+		in.Metadata[bus.MetaKeySessionScope] != "slack:default:C1" || !strings.Contains(in.Content, "check the build") {
+		t.Fatalf("unexpected follow-up: %+v", in)
+	}
+
+	if due, _ := tl.ListDueCommitments(time.Now(), 0); len(due) != 0 {
+		t.Fatalf("expected commitments marked as followed up, got %+v", due)
+	}
+	if job, _ := tl.GetScheduledJob(CommitmentJobName); job == nil || job.LastStatus != "dispatched" {
+		t.Fatalf("expected scheduled job recorded, got %+v", job)
+	}
+
+	// Completing the follow-up turn closes the agent commitment.
+	loop := NewLoop(LoopOptions{Timeline: tl, Config: &config.Config{Commitments: config.CommitmentsConfig{Enabled: true}}, Workspace: t.TempDir(), WorkRepo: t.TempDir()})
+	loop.trackCommitments(in, "slack:default:C1", "Build is green.")
+	if rec, _ := tl.GetCommitment(agentRec.ID); rec == nil || rec.Status != timeline.CommitmentDone {
+		t.Fatalf("expected agent commitment closed after follow-up, got %+v", rec)
+	}
+}
@@ -23,6 +23,7 @@ type ContextBuilder struct {
 	workRepo   string
 	systemRepo string
 	registry   *tools.Registry
+	templates  PromptTemplateStore
 }
 
 // NewContextBuilder creates a new ContextBuilder.
@@ -38,9 +39,14 @@ func NewContextBuilder(workspace string, workRepo string, systemRepo string, reg
 // BuildSystemPrompt constructs the full system prompt from files and runtime info.
 func (b *ContextBuilder) BuildSystemPrompt() string {
 	var parts []string
+	vars := b.promptVariables()
 
-	// 1. Core Identity & Runtime Info
-	parts = append(parts, b.getIdentity())
+	// 1. Core Identity & Runtime Info (replaced by the "system" template if stored)
+	identity := b.loadTemplate(SystemPromptTemplate, 0, vars, 0)
+	if identity == "" {
+		identity = b.getIdentity(vars)
+	}
+	parts = append(parts, identity)
 
 	// 2. Bootstrap Files
 	if bootstrap := b.loadBootstrapFiles(); bootstrap != "" {
@@ -57,10 +63,11 @@ func (b *ContextBuilder) BuildSystemPrompt() string {
 		parts = append(parts, "# Skills\n\n"+skills)
 	}
 
-	return strings.Join(parts, "\n\n---\n\n")
+	return b.expandTemplateRefs(strings.Join(parts, "\n\n---\n\n"), vars, 0)
 }
 
-func (b *ContextBuilder) getIdentity() string {
+// promptVariables returns the built-in variables available to prompt templates.
+func (b *ContextBuilder) promptVariables() map[string]string {
 	t := time.Now()
 	now := t.Format("2006-01-02 15:04 (Monday)")
 
@@ -89,6 +96,16 @@ func (b *ContextBuilder) getIdentity() string {
 
 	runtimeInfo := fmt.Sprintf("%s %s, Go %s", runtime.GOOS, runtime.GOARCH, runtime.Version())
 
+	return map[string]string{
+		"now":            now,
+		"date_reference": dateRef,
+		"runtime":        runtimeInfo,
+		"workspace":      wsPath,
+		"work_repo":      b.workRepo,
+	}
+}
+
+func (b *ContextBuilder) getIdentity(vars map[string]string) string {
 	return fmt.Sprintf(`# KafClaw 🤖
 
 You are KafClaw, a helpful, efficient AI assistant.
@@ -129,7 +146,7 @@ Your workspace is at: %s
 IMPORTANT: When responding to direct questions, reply directly with text.
 Only use the 'message' tool when explicitly asked to send a message to a channel.
 Always be helpful, accurate, and concise.
-`, now, dateRef, runtimeInfo, wsPath, b.workRepo, b.workRepo, b.workRepo, wsPath)
+`, vars["now"], vars["date_reference"], vars["runtime"], vars["workspace"], b.workRepo, b.workRepo, b.workRepo, vars["workspace"])
 }
 
 func (b *ContextBuilder) loadBootstrapFiles() string {
@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KafClaw/KafClaw/internal/provider"
+	"github.com/KafClaw/KafClaw/internal/session"
+)
+
+// providerOverrideKey carries the provider of one turn in its context.
+type providerOverrideKey struct{}
+
+// withProviderOverride runs the turn of ctx on prov instead of the chain's
+// provider, without touching the chain other turns use.
+func withProviderOverride(ctx context.Context, prov provider.LLMProvider) context.Context {
+	return context.WithValue(ctx, providerOverrideKey{}, prov)
+}
+
+// providerOverride returns the provider set by withProviderOverride, or nil.
+func providerOverride(ctx context.Context) provider.LLMProvider {
+	prov, _ := ctx.Value(providerOverrideKey{}).(provider.LLMProvider)
+	return prov
+}
+
+// ProcessConversation answers content, the last user turn of a conversation
+// the client keeps itself, as OpenAI-compatible clients do. The session
+// history is replaced by history (user and assistant turns only), so the
+// session mirrors what the client sent. An empty sessionKey runs the turn on
+// a throwaway session named after traceID, deleted afterwards. A non-empty
+// agentID other than the loop's own runs the turn on that agent's configured
+// model (agents.list).
+func (l *Loop) ProcessConversation(ctx context.Context, history []session.Message, content, sessionKey, traceID, agentID string) (string, error) {
+	if agentID != "" && agentID != l.agentID {
+		if l.cfg == nil {
+			return "", fmt.Errorf("agent %s: no config to resolve its model", agentID)
+		}
+		prov, err := provider.Resolve(l.cfg, agentID)
+		if err != nil {
+			return "", fmt.Errorf("agent %s: %w", agentID, err)
+		}
+		ctx = withProviderOverride(ctx, prov)
+	}
+
+	// Replacing the history and answering is one turn, so concurrent
+	// clients of the same session each see their own history.
+	l.turnMu.Lock()
+	defer l.turnMu.Unlock()
+	if sessionKey == "" {
+		sessionKey = "conversation:" + traceID
+		defer l.sessions.Delete(sessionKey)
+	}
+	sess := l.sessions.GetOrCreate(sessionKey)
+	sess.Clear()
+	for _, m := range history {
+		if m.Role == "user" || m.Role == "assistant" {
+			sess.AddMessage(m.Role, m.Content)
+		}
+	}
+	_ = l.sessions.Save(sess)
+	return l.processDirect(ctx, content, sessionKey, traceID)
+}
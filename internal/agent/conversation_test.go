@@ -0,0 +1,175 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/provider"
+	"github.com/KafClaw/KafClaw/internal/session"
+)
+
+// messagesRecordingProvider records the messages of each request.
+type messagesRecordingProvider struct {
+	mockProvider
+	requests [][]provider.Message
+}
+
+func (p *messagesRecordingProvider) Chat(ctx context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+	p.requests = append(p.requests, append([]provider.Message(nil), req.Messages...))
+	return p.mockProvider.Chat(ctx, req)
+}
+
+func TestProcessConversationReplacesSessionHistory(t *testing.T) {
+	dir := t.TempDir()
+	prov := &messagesRecordingProvider{mockProvider: mockProvider{responses: []provider.ChatResponse{
+		{Content: "first answer"},
+		{Content: "Paris."},
+	}}}
+	loop := NewLoop(LoopOptions{
+		Bus:           bus.NewMessageBus(),
+		Provider:      prov,
+		Timeline:      newTestTimeline(t),
+		Workspace:     dir,
+		WorkRepo:      dir,
+		Model:         "mock-model",
+		MaxIterations: 3,
+	})
+	ctx := context.Background()
+	if _, err := loop.ProcessDirectWithTrace(ctx, "stale question", "openai:u1", "trace-1"); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	history := []session.Message{
+		{Role: "system", Content: "ignored"},
+		{Role: "user", Content: "What is the capital of France?"},
+		{Role: "assistant", Content: "Which France do you mean?"},
+	}
+	resp, err := loop.ProcessConversation(ctx, history, "The country.", "openai:u1", "trace-2", "")
+	if err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	if resp != "Paris." {
+		t.Fatalf("unexpected response %q", resp)
+	}
+
+	var got []string
+	for _, m := range prov.requests[len(prov.requests)-1] {
+		if m.Role != "system" {
+			got = append(got, m.Role+":"+m.Content)
+		}
+	}
+	want := []string{"user:What is the capital of France?", "assistant:Which France do you mean?", "user:The country."}
+	if len(got) != len(want) {
+		t.Fatalf("expected history %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected history %v, got %v", want, got)
+		}
+	}
+}
+
+// historyEchoProvider answers with the first and the last user message it
+// was sent.
+type historyEchoProvider struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (p *historyEchoProvider) Chat(_ context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+	p.mu.Lock()
+	p.calls++
+	p.mu.Unlock()
+	var users []string
+	for _, m := range req.Messages {
+		if m.Role == "user" {
+			users = append(users, m.Content)
+		}
+	}
+	if len(users) == 0 {
+		return &provider.ChatResponse{Content: "none"}, nil
+	}
+	return &provider.ChatResponse{Content: users[0] + " / " + users[len(users)-1]}, nil
+}
+
+func (p *historyEchoProvider) Transcribe(context.Context, *provider.AudioRequest) (*provider.AudioResponse, error) {
+	return &provider.AudioResponse{}, nil
+}
+
+func (p *historyEchoProvider) Speak(context.Context, *provider.TTSRequest) (*provider.TTSResponse, error) {
+	return &provider.TTSResponse{}, nil
+}
+
+func (p *historyEchoProvider) DefaultModel() string { return "echo" }
+
+func TestProcessConversationConcurrentClients(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	prov := &historyEchoProvider{}
+	loop := NewLoop(LoopOptions{
+		Bus:           bus.NewMessageBus(),
+		Provider:      prov,
+		Timeline:      newTestTimeline(t),
+		Workspace:     dir,
+		WorkRepo:      dir,
+		Model:         "mock-model",
+		MaxIterations: 3,
+	})
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 24)
+	for i := 0; i < 8; i++ {
+		for _, key := range []string{"openai:shared", ""} {
+			wg.Add(1)
+			go func(i int, key string) {
+				defer wg.Done()
+				first := fmt.Sprintf("question %d", i)
+				last := fmt.Sprintf("follow-up %d", i)
+				history := []session.Message{{Role: "user", Content: first}, {Role: "assistant", Content: "answer"}}
+				resp, err := loop.ProcessConversation(ctx, history, last, key, fmt.Sprintf("trace-%d-%t", i, key == ""), "")
+				if err != nil {
+					errs <- err
+				} else if want := first + " / " + last; resp != want {
+					errs <- fmt.Errorf("session %q: expected %q, got %q", key, want, resp)
+				}
+			}(i, key)
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := loop.ProcessDirectWithTrace(ctx, "hello", fmt.Sprintf("cli:%d", i), ""); err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if loop.chain.Provider != prov {
+		t.Fatal("expected the chain's provider to be left alone")
+	}
+	for _, info := range loop.sessions.List() {
+		if strings.HasPrefix(info.Key, "conversation:") {
+			t.Fatalf("expected throwaway sessions to be deleted, found %s", info.Key)
+		}
+	}
+}
+
+func TestProviderOverrideIsPerCall(t *testing.T) {
+	prov := &historyEchoProvider{}
+	ctx := withProviderOverride(context.Background(), prov)
+	if providerOverride(ctx) != prov {
+		t.Fatal("expected the override from the context")
+	}
+	if providerOverride(context.Background()) != nil {
+		t.Fatal("expected no override without one set")
+	}
+}
@@ -0,0 +1,226 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/metrics"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// CostAnomalyJobName is the scheduled_jobs entry updated by the cost anomaly
+// worker.
+const CostAnomalyJobName = "finops-anomaly"
+
+const costAnomalyTopTraces = 5
+
+var costAnomaliesTotal = metrics.NewCounter("kafclaw_finops_cost_anomalies_total", "Token spend anomalies detected, by channel, model and window.", "channel", "model", "window")
+
+// CostAnomalyWorker learns the normal hourly and daily token spend of every
+// channel and model from the task table and raises an alert when the current
+// hour or day reaches a multiple of it, so runaway loops surface within
+// minutes instead of on the invoice. Each anomaly is recorded once, logged
+// to the timeline of its costliest trace and posted to the alert chat.
+type CostAnomalyWorker struct {
+	timeline *timeline.TimelineService
+	bus      *bus.MessageBus
+	cfg      config.CostAnomalyConfig
+	now      func() time.Time
+}
+
+// NewCostAnomalyWorker creates a cost anomaly worker. Unset settings fall
+// back to the defaults.
+func NewCostAnomalyWorker(tl *timeline.TimelineService, b *bus.MessageBus, cfg config.CostAnomalyConfig) *CostAnomalyWorker {
+	def := config.DefaultConfig().FinOps.Anomaly
+	if cfg.IntervalSec <= 0 {
+		cfg.IntervalSec = def.IntervalSec
+	}
+	if cfg.BaselineDays <= 0 {
+		cfg.BaselineDays = def.BaselineDays
+	}
+	if cfg.Factor <= 1 {
+		cfg.Factor = def.Factor
+	}
+	if cfg.MinTokens < 0 {
+		cfg.MinTokens = 0
+	}
+	return &CostAnomalyWorker{timeline: tl, bus: b, cfg: cfg, now: time.Now}
+}
+
+// Run starts the polling loop. Blocks until context is cancelled.
+func (w *CostAnomalyWorker) Run(ctx context.Context) error {
+	interval := time.Duration(w.cfg.IntervalSec) * time.Second
+	slog.Info("Cost anomaly worker started", "interval", interval, "factor", w.cfg.Factor, "baseline_days", w.cfg.BaselineDays)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Cost anomaly worker stopped")
+			return ctx.Err()
+		case <-ticker.C:
+			w.poll()
+		}
+	}
+}
+
+func (w *CostAnomalyWorker) poll() {
+	now := w.now().UTC()
+	anomalies, err := w.Detect(now)
+	if err != nil {
+		slog.Error("Cost anomaly detection failed", "error", err)
+		_ = w.timeline.UpsertScheduledJob(CostAnomalyJobName, "failed", now)
+		return
+	}
+	for i := range anomalies {
+		a := &anomalies[i]
+		inserted, err := w.timeline.RecordCostAnomaly(a)
+		if err != nil {
+			slog.Error("Cost anomaly record failed", "error", err)
+			continue
+		}
+		if inserted {
+			w.alert(a, now)
+		}
+	}
+	_ = w.timeline.UpsertScheduledJob(CostAnomalyJobName, "checked", now)
+}
+
+// Detect compares the current and the previous hour, and today, against the
+// channel/model baseline: the average spend per hour (per day) over the
+// BaselineDays before the window. Hours and days without usage count as
+// zero. Channels and models without any baseline usage are skipped, as are
+// windows below MinTokens.
+func (w *CostAnomalyWorker) Detect(now time.Time) ([]timeline.CostAnomalyRecord, error) {
+	now = now.UTC()
+	hour := now.Truncate(time.Hour)
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	baseline := time.Duration(w.cfg.BaselineDays) * 24 * time.Hour
+
+	var out []timeline.CostAnomalyRecord
+
+	// Hourly: the previous hour is checked too, so a spike is still caught
+	// when the hour rolls over between two polls.
+	hourly, err := w.timeline.GetTokenUsageBuckets(hour.Add(-time.Hour).Add(-baseline), true)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range []time.Time{hour.Add(-time.Hour), hour} {
+		from := h.Add(-baseline)
+		out = append(out, w.detectWindow("hour", hourly, h.Format("2006-01-02 15:00"), from.Format("2006-01-02 15:00"),
+			float64(w.cfg.BaselineDays*24), h, h.Add(time.Hour))...)
+	}
+
+	daily, err := w.timeline.GetTokenUsageBuckets(day.Add(-baseline), false)
+	if err != nil {
+		return nil, err
+	}
+	out = append(out, w.detectWindow("day", daily, day.Format("2006-01-02"), day.Add(-baseline).Format("2006-01-02"),
+		float64(w.cfg.BaselineDays), day, day.Add(24*time.Hour))...)
+	return out, nil
+}
+
+// detectWindow checks the buckets labelled current against the average of
+// the buckets in [baselineFrom, current) over slots windows.
+func (w *CostAnomalyWorker) detectWindow(window string, buckets []timeline.UsageBucket, current, baselineFrom string, slots float64, from, to time.Time) []timeline.CostAnomalyRecord {
+	type key struct{ channel, model string }
+	history := map[key]int{}
+	spend := map[key]timeline.UsageBucket{}
+	for _, b := range buckets {
+		k := key{b.Channel, b.Model}
+		switch {
+		case b.Bucket == current:
+			spend[k] = b
+		case b.Bucket >= baselineFrom && b.Bucket < current:
+			history[k] += b.Tokens
+		}
+	}
+
+	var out []timeline.CostAnomalyRecord
+	for k, b := range spend {
+		if history[k] == 0 || b.Tokens < w.cfg.MinTokens {
+			continue
+		}
+		avg := float64(history[k]) / slots
+		if float64(b.Tokens) < w.cfg.Factor*avg {
+			continue
+		}
+		traces, err := w.timeline.ListTopTokenTraces(k.channel, k.model, from, to, costAnomalyTopTraces)
+		if err != nil {
+			slog.Warn("Cost anomaly trace lookup failed", "channel", k.channel, "model", k.model, "error", err)
+		}
+		out = append(out, timeline.CostAnomalyRecord{
+			Window:   window,
+			Bucket:   current,
+			Channel:  k.channel,
+			Model:    k.model,
+			Tokens:   b.Tokens,
+			Baseline: avg,
+			CostUSD:  b.CostUSD,
+			Traces:   traces,
+		})
+	}
+	return out
+}
+
+// alert logs the anomaly to the timeline and posts it to the alert chat.
+func (w *CostAnomalyWorker) alert(a *timeline.CostAnomalyRecord, now time.Time) {
+	costAnomaliesTotal.Inc(a.Channel, a.Model, a.Window)
+	text := FormatCostAnomaly(a)
+	slog.Warn("Cost anomaly detected", "window", a.Window, "bucket", a.Bucket, "channel", a.Channel, "model", a.Model, "tokens", a.Tokens, "baseline", a.Baseline)
+
+	traceID := ""
+	if len(a.Traces) > 0 {
+		traceID = a.Traces[0].TraceID
+	}
+	_ = w.timeline.AddEvent(&timeline.TimelineEvent{
+		EventID:        fmt.Sprintf("COST_ANOMALY_%d", now.UnixNano()),
+		TraceID:        traceID,
+		Timestamp:      now,
+		SenderID:       "AGENT",
+		SenderName:     "FinOps",
+		EventType:      "SYSTEM",
+		ContentText:    text,
+		Classification: "COST_ANOMALY",
+		Authorized:     true,
+	})
+
+	channel, chatID := strings.TrimSpace(w.cfg.Channel), strings.TrimSpace(w.cfg.ChatID)
+	if w.bus == nil || channel == "" || chatID == "" {
+		return
+	}
+	w.bus.PublishOutbound(&bus.OutboundMessage{
+		Channel: channel,
+		ChatID:  chatID,
+		TraceID: traceID,
+		Content: text,
+	})
+}
+
+// FormatCostAnomaly renders an anomaly alert with the costliest traces.
+func FormatCostAnomaly(a *timeline.CostAnomalyRecord) string {
+	var sb strings.Builder
+	ratio := 0.0
+	if a.Baseline > 0 {
+		ratio = float64(a.Tokens) / a.Baseline
+	}
+	fmt.Fprintf(&sb, "⚠️ Cost anomaly: %s / %s used %d tokens in %s %s UTC, %.1fx the baseline of %.0f tokens per %s",
+		a.Channel, a.Model, a.Tokens, a.Window, a.Bucket, ratio, a.Baseline, a.Window)
+	if a.CostUSD > 0 {
+		fmt.Fprintf(&sb, " (~$%.2f)", a.CostUSD)
+	}
+	sb.WriteString(".")
+	if len(a.Traces) > 0 {
+		sb.WriteString("\nTop traces:")
+		for _, t := range a.Traces {
+			fmt.Fprintf(&sb, "\n- %s: %d tokens (/api/v1/trace/%s)", t.TraceID, t.Tokens, t.TraceID)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestCostAnomalyWorkerAlertsOnceOnSpike(t *testing.T) {
+	tl := newTestTimeline(t)
+	now := time.Date(2026, 3, 10, 14, 30, 0, 0, time.UTC)
+	n := 0
+	addTask := func(at time.Time, channel, model, trace string, tokens int) {
+		t.Helper()
+		n++
+		_, err := tl.DB().Exec(`INSERT INTO tasks (task_id, trace_id, channel, chat_id, total_tokens, model_name, cost_usd, created_at)
+			VALUES (?, ?, ?, 'c1', ?, ?, ?, ?)`, fmt.Sprintf("task-%d", n), trace, channel, tokens, model, float64(tokens)/1e6, at.Format("2006-01-02 15:04:05"))
+		if err != nil {
+			t.Fatalf("insert task: %v", err)
+		}
+	}
+	// Baseline: 24,000 tokens per day on slack/gpt (1,000 per hour on average).
+	for d := 1; d <= 7; d++ {
+		for h := 0; h < 24; h += 6 {
+			addTask(now.Add(-time.Duration(d)*24*time.Hour).Truncate(24*time.Hour).Add(time.Duration(h)*time.Hour), "slack", "gpt", "", 6000)
+		}
+	}
+	// Normal hour on whatsapp without a learned baseline, and a runaway trace on slack.
+	addTask(now.Add(-10*time.Minute), "whatsapp", "gpt", "trace-wa", 90000)
+	addTask(now.Add(-20*time.Minute), "slack", "gpt", "trace-runaway", 150000)
+	addTask(now.Add(-5*time.Minute), "slack", "gpt", "trace-small", 2000)
+
+	cfg := config.DefaultConfig().FinOps.Anomaly
+	cfg.Channel, cfg.ChatID = "slack", "C-ops"
+	msgBus := bus.NewMessageBus()
+	w := NewCostAnomalyWorker(tl, msgBus, cfg)
+	w.now = func() time.Time { return now }
+
+	w.poll()
+	got, err := tl.ListCostAnomalies(10)
+	if err != nil {
+		t.Fatalf("list anomalies: %v", err)
+	}
+	windows := map[string]bool{}
+	for _, a := range got {
+		if a.Channel != "slack" || a.Model != "gpt" || a.Tokens != 152000 {
+			t.Fatalf("unexpected anomaly %+v", a)
+		}
+		if len(a.Traces) != 2 || a.Traces[0].TraceID != "trace-runaway" {
+			t.Fatalf("expected the runaway trace first, got %+v", a.Traces)
+		}
+		windows[a.Window] = true
+	}
+	if len(got) != 2 || !windows["hour"] || !windows["day"] {
+		t.Fatalf("expected an hourly and a daily anomaly, got %+v", got)
+	}
+	if size := msgBus.OutboundSize(); size != 2 {
+		t.Fatalf("expected 2 alerts, got %d", size)
+	}
+
+	w.poll()
+	if size := msgBus.OutboundSize(); size != 2 {
+		t.Fatalf("anomalies must alert once, got %d alerts", size)
+	}
+}
+
+func TestFormatCostAnomalyLinksTraces(t *testing.T) {
+	a := &timeline.CostAnomalyRecord{
+		Window: "hour", Bucket: "2026-03-10 14:00", Channel: "slack", Model: "gpt",
+		Tokens: 82000, Baseline: 1000, Traces: []timeline.TraceTokens{{TraceID: "trace-runaway", Tokens: 80000}},
+	}
+	text := FormatCostAnomaly(a)
+	for _, want := range []string{"slack / gpt used 82000 tokens in hour 2026-03-10 14:00 UTC", "82.0x", "/api/v1/trace/trace-runaway"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("expected %q in %q", want, text)
+		}
+	}
+}
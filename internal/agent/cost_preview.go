@@ -0,0 +1,216 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/approval"
+	"github.com/KafClaw/KafClaw/internal/provider"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+const (
+	// costPreviewCharsPerToken is the rough chars→tokens ratio used for estimates.
+	costPreviewCharsPerToken = 4
+	// costPreviewCompletionReserve is the completion budget assumed per LLM call.
+	costPreviewCompletionReserve = 4096
+)
+
+// costEstimate is an up-front projection of what a request will consume.
+type costEstimate struct {
+	PromptTokens     int      `json:"prompt_tokens"`
+	CompletionTokens int      `json:"completion_tokens"`
+	TotalTokens      int      `json:"total_tokens"`
+	ExpectedCalls    int      `json:"expected_calls"`
+	CostUSD          float64  `json:"cost_usd"`
+	Reasons          []string `json:"reasons,omitempty"`
+}
+
+// expectedLLMCalls maps a task category to the number of LLM round-trips a
+// request of that kind typically needs (tool calls, follow-ups, subagents).
+var expectedLLMCalls = map[string]int{
+	"quick-answer": 1,
+	"creative":     1,
+	"security":     2,
+	"tool-heavy":   3,
+	"multi-step":   3,
+}
+
+// estimateRequestCost projects token usage for the built message list.
+func (l *Loop) estimateRequestCost(messages []provider.Message, content string) costEstimate {
+	chars := 0
+	for _, m := range messages {
+		chars += len(m.Content)
+	}
+	promptTokens := chars / costPreviewCharsPerToken
+
+	assessment := AssessTask(content)
+	calls := expectedLLMCalls[assessment.Category]
+	if calls <= 0 {
+		calls = 1
+	}
+	var reasons []string
+	if promptTokens >= 8000 {
+		reasons = append(reasons, "large_context")
+	}
+	lower := strings.ToLower(content)
+	for _, kw := range []string{"subagent", "spawn", "in parallel"} {
+		if strings.Contains(lower, kw) {
+			calls *= 2
+			reasons = append(reasons, "subagents")
+			break
+		}
+	}
+	for _, kw := range []string{"index", "whole repo", "entire repo", "all files"} {
+		if strings.Contains(lower, kw) {
+			calls *= 2
+			reasons = append(reasons, "repo_scan")
+			break
+		}
+	}
+	if max := l.maxIterations; max > 0 && calls > max {
+		calls = max
+	}
+
+	est := costEstimate{
+		PromptTokens:     promptTokens * calls,
+		CompletionTokens: costPreviewCompletionReserve * calls,
+		ExpectedCalls:    calls,
+		Reasons:          reasons,
+	}
+	est.TotalTokens = est.PromptTokens + est.CompletionTokens
+	est.CostUSD = l.estimateCostUSD(est)
+	return est
+}
+
+// estimateCostUSD prices an estimate using FinOps pricing for the model's provider.
+// Returns 0 when FinOps is disabled or no pricing is configured.
+func (l *Loop) estimateCostUSD(est costEstimate) float64 {
+	if l.cfg == nil || !l.cfg.FinOps.Enabled {
+		return 0
+	}
+	providerID := l.model
+	if idx := strings.Index(providerID, "/"); idx > 0 {
+		providerID = providerID[:idx]
+	}
+	pricing, ok := l.cfg.FinOps.Pricing[providerID]
+	if !ok {
+		return 0
+	}
+	return (float64(est.PromptTokens)*pricing.PromptPer1kTokens +
+		float64(est.CompletionTokens)*pricing.CompletionPer1kTokens) / 1000.0
+}
+
+// costPreviewThresholds reads the confirmation thresholds from settings.
+// "cost_preview_threshold_tokens" and "cost_preview_threshold_usd" are
+// independent; a value of 0 or an empty setting disables that threshold.
+func (l *Loop) costPreviewThresholds() (int, float64) {
+	if l.timeline == nil {
+		return 0, 0
+	}
+	var tokens int
+	if v, err := l.timeline.GetSetting("cost_preview_threshold_tokens"); err == nil {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && n > 0 {
+			tokens = n
+		}
+	}
+	var usd float64
+	if v, err := l.timeline.GetSetting("cost_preview_threshold_usd"); err == nil {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil && f > 0 {
+			usd = f
+		}
+	}
+	return tokens, usd
+}
+
+// confirmEstimatedCost asks the user to confirm a request whose estimated cost
+// exceeds the configured threshold. Returns false with a user-facing notice
+// when the request should not proceed. The estimate and decision are recorded
+// in the active trace.
+func (l *Loop) confirmEstimatedCost(ctx context.Context, messages []provider.Message, content string) (bool, string) {
+	tokenLimit, usdLimit := l.costPreviewThresholds()
+	if tokenLimit == 0 && usdLimit == 0 {
+		return true, ""
+	}
+	est := l.estimateRequestCost(messages, content)
+	overTokens := tokenLimit > 0 && est.TotalTokens >= tokenLimit
+	overUSD := usdLimit > 0 && est.CostUSD >= usdLimit
+	if !overTokens && !overUSD {
+		return true, ""
+	}
+
+	// Without an interactive channel there is nobody to ask; proceed and record it.
+	if l.bus == nil || l.approvalMgr == nil {
+		l.recordCostPreview(est, "unattended")
+		return true, ""
+	}
+
+	args := map[string]any{
+		"estimated_tokens":   est.TotalTokens,
+		"estimated_cost_usd": est.CostUSD,
+		"expected_calls":     est.ExpectedCalls,
+	}
+	if len(est.Reasons) > 0 {
+		args["reasons"] = est.Reasons
+	}
+	approvalID := l.createApproval(&approval.ApprovalRequest{
+		Tool:      "cost_preview",
+		Arguments: args,
+		Sender:    l.activeSender,
+		Channel:   l.activeChannel,
+		TraceID:   l.activeTraceID,
+		TaskID:    l.activeTaskID,
+	})
+
+	prompt := fmt.Sprintf("This request looks expensive: ~%d tokens over ~%d LLM calls", est.TotalTokens, est.ExpectedCalls)
+	if est.CostUSD > 0 {
+		prompt += fmt.Sprintf(" (~$%.4f)", est.CostUSD)
+	}
+	if len(est.Reasons) > 0 {
+		prompt += fmt.Sprintf(" [%s]", strings.Join(est.Reasons, ", "))
+	}
+	prompt += fmt.Sprintf(".\nIt requires approval before proceeding.\nReply approve:%s or deny:%s", approvalID, approvalID)
+	l.publishApprovalPrompt(prompt, approvalID)
+
+	approved, err := l.approvalMgr.Wait(ctx, approvalID)
+	switch {
+	case err != nil:
+		slog.Warn("Cost preview approval wait failed", "id", approvalID, "error", err)
+		l.recordCostPreview(est, "timeout")
+		return false, "Request cancelled: cost confirmation timed out."
+	case !approved:
+		l.recordCostPreview(est, "denied")
+		return false, "Request cancelled: estimated cost was not approved."
+	}
+	l.recordCostPreview(est, "approved")
+	return true, ""
+}
+
+// recordCostPreview writes the estimate and decision to the active trace.
+func (l *Loop) recordCostPreview(est costEstimate, decision string) {
+	if l.timeline == nil || l.activeTraceID == "" {
+		return
+	}
+	meta, _ := json.Marshal(map[string]any{
+		"estimate": est,
+		"decision": decision,
+		"model":    l.model,
+	})
+	_ = l.timeline.AddEvent(&timeline.TimelineEvent{
+		EventID:        fmt.Sprintf("COST_%s_%d", l.activeTraceID, time.Now().UnixNano()),
+		TraceID:        l.activeTraceID,
+		Timestamp:      time.Now(),
+		SenderID:       "AGENT",
+		SenderName:     "CostPreview",
+		EventType:      "SYSTEM",
+		ContentText:    fmt.Sprintf("cost preview: tokens=%d cost_usd=%.4f decision=%s", est.TotalTokens, est.CostUSD, decision),
+		Classification: "COST_PREVIEW",
+		Authorized:     decision != "denied" && decision != "timeout",
+		Metadata:       string(meta),
+	})
+}
@@ -0,0 +1,152 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/provider"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestEstimateRequestCostScalesWithContextAndFanOut(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.FinOps.Enabled = true
+	cfg.FinOps.Pricing = map[string]config.ProviderPricing{
+		"mock": {PromptPer1kTokens: 1, CompletionPer1kTokens: 2},
+	}
+	loop := &Loop{maxIterations: 20, model: "mock/model", cfg: cfg}
+
+	small := loop.estimateRequestCost([]provider.Message{{Role: "user", Content: "hi"}}, "hi")
+	if small.ExpectedCalls != 1 {
+		t.Fatalf("expected 1 call for quick answer, got %d", small.ExpectedCalls)
+	}
+
+	big := strings.Repeat("x", 40000)
+	large := loop.estimateRequestCost([]provider.Message{{Role: "user", Content: big}}, "spawn subagents to index the whole repo and fix the bug")
+	if large.TotalTokens <= small.TotalTokens {
+		t.Fatalf("expected large estimate to exceed small: %d <= %d", large.TotalTokens, small.TotalTokens)
+	}
+	for _, want := range []string{"large_context", "subagents", "repo_scan"} {
+		found := false
+		for _, r := range large.Reasons {
+			if r == want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected reason %q in %v", want, large.Reasons)
+		}
+	}
+	if large.CostUSD <= 0 {
+		t.Fatalf("expected priced estimate, got %v", large.CostUSD)
+	}
+}
+
+func TestCostPreviewDeniedSkipsLLM(t *testing.T) {
+	tl := newTestTimeline(t)
+	if err := tl.SetSetting("cost_preview_threshold_tokens", "10"); err != nil {
+		t.Fatal(err)
+	}
+	msgBus := bus.NewMessageBus()
+	mock := &mockProvider{}
+	loop := NewLoop(LoopOptions{
+		Bus:           msgBus,
+		Provider:      mock,
+		Timeline:      tl,
+		Workspace:     t.TempDir(),
+		WorkRepo:      t.TempDir(),
+		Model:         "mock-model",
+		MaxIterations: 5,
+	})
+
+	var outbound outboundCapture
+	msgBus.Subscribe("whatsapp", func(msg *bus.OutboundMessage) { outbound.add(msg) })
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go msgBus.DispatchOutbound(ctx)
+
+	msg := &bus.InboundMessage{
+		Channel:        "whatsapp",
+		SenderID:       "owner",
+		ChatID:         "owner",
+		TraceID:        "trace-cost-001",
+		IdempotencyKey: "wa:COST001",
+		Content:        "Summarize the project status",
+		Timestamp:      time.Now(),
+		Metadata:       map[string]any{bus.MetaKeyMessageType: bus.MessageTypeInternal},
+	}
+
+	done := make(chan string, 1)
+	go func() {
+		resp, _, _ := loop.processMessage(ctx, msg)
+		done <- resp
+	}()
+
+	id := waitForApprovalPrompt(t, &outbound, 5*time.Second)
+	if err := loop.approvalMgr.Respond(id, false); err != nil {
+		t.Fatalf("respond: %v", err)
+	}
+
+	select {
+	case resp := <-done:
+		if !strings.Contains(resp, "not approved") {
+			t.Fatalf("unexpected response: %q", resp)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("processMessage did not complete after denial")
+	}
+	if mock.calls != 0 {
+		t.Fatalf("expected no LLM calls after denial, got %d", mock.calls)
+	}
+
+	events, err := tl.GetEvents(timeline.FilterArgs{TraceID: "trace-cost-001"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, e := range events {
+		if e.Classification == "COST_PREVIEW" && strings.Contains(e.Metadata, `"decision":"denied"`) {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("expected COST_PREVIEW event with denied decision")
+	}
+}
+
+func TestCostPreviewSkippedForSubagents(t *testing.T) {
+	tl := newTestTimeline(t)
+	if err := tl.SetSetting("cost_preview_threshold_tokens", "10"); err != nil {
+		t.Fatal(err)
+	}
+	mock := &mockProvider{}
+	loop := NewLoop(LoopOptions{
+		Bus:           bus.NewMessageBus(),
+		Provider:      mock,
+		Timeline:      tl,
+		Workspace:     t.TempDir(),
+		WorkRepo:      t.TempDir(),
+		Model:         "mock-model",
+		MaxIterations: 5,
+	})
+
+	for _, key := range []string{"subagent:run-1", "agent:ops:subagent:run-2"} {
+		done := make(chan string, 1)
+		go func() {
+			resp, _ := loop.ProcessDirectWithTrace(context.Background(), "Summarize the project status", key, "trace-"+key)
+			done <- resp
+		}()
+		select {
+		case resp := <-done:
+			if resp != "mock response" {
+				t.Fatalf("%s: unexpected response %q", key, resp)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: subagent run waited for a cost confirmation", key)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
 	"log/slog"
 	"math"
 	"time"
@@ -46,6 +47,8 @@ func (w *DeliveryWorker) Run(ctx context.Context) error {
 }
 
 func (w *DeliveryWorker) poll() {
+	w.releaseDeferred()
+
 	tasks, err := w.timeline.ListPendingDeliveries(10)
 	if err != nil {
 		slog.Error("Delivery worker poll failed", "error", err)
@@ -74,6 +77,30 @@ func (w *DeliveryWorker) poll() {
 	}
 }
 
+// releaseDeferred re-publishes outbound messages held back by quiet hours once
+// their window has opened.
+func (w *DeliveryWorker) releaseDeferred() {
+	due, err := w.timeline.ListDueDeferredOutbound(50)
+	if err != nil {
+		slog.Error("Delivery worker deferred poll failed", "error", err)
+		return
+	}
+	for _, rec := range due {
+		var msg bus.OutboundMessage
+		if err := json.Unmarshal([]byte(rec.Payload), &msg); err != nil {
+			slog.Warn("Dropping unreadable deferred outbound", "id", rec.ID, "error", err)
+			_ = w.timeline.DeleteDeferredOutbound(rec.ID)
+			continue
+		}
+		if err := w.timeline.DeleteDeferredOutbound(rec.ID); err != nil {
+			slog.Error("Delivery worker failed to release deferred outbound", "id", rec.ID, "error", err)
+			continue
+		}
+		w.bus.PublishOutbound(&msg)
+		slog.Info("Delivery worker released deferred outbound", "id", rec.ID, "channel", msg.Channel, "chat_id", msg.ChatID)
+	}
+}
+
 // DeliveryBackoff calculates the next retry time using exponential backoff.
 // Returns min(30s * 2^attempts, 5min).
 func DeliveryBackoff(attempts int) time.Time {
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/provider"
 	"github.com/KafClaw/KafClaw/internal/timeline"
 )
 
@@ -126,3 +127,91 @@ func TestDeliveryWorkerRunStopsOnContextCancel(t *testing.T) {
 		t.Fatalf("expected context canceled, got: %v", err)
 	}
 }
+
+func TestDeliveryWorkerReleasesDeferredOutbound(t *testing.T) {
+	tl := newTestTimeline(t)
+	msgBus := bus.NewMessageBus()
+	worker := NewDeliveryWorker(tl, msgBus)
+
+	if err := tl.DeferOutbound(&timeline.DeferredOutboundRecord{
+		Channel:   "whatsapp",
+		ChatID:    "123@s.whatsapp.net",
+		Payload:   `{"channel":"whatsapp","chat_id":"123@s.whatsapp.net","content":"good morning","media_urls":["https://x/y.png"]}`,
+		DeliverAt: time.Now().Add(-time.Minute),
+	}); err != nil {
+		t.Fatalf("defer: %v", err)
+	}
+	if err := tl.DeferOutbound(&timeline.DeferredOutboundRecord{
+		Channel:   "whatsapp",
+		ChatID:    "123@s.whatsapp.net",
+		Payload:   `{"channel":"whatsapp","chat_id":"123@s.whatsapp.net","content":"later"}`,
+		DeliverAt: time.Now().Add(time.Hour),
+	}); err != nil {
+		t.Fatalf("defer: %v", err)
+	}
+
+	worker.poll()
+
+	got := make(chan *bus.OutboundMessage, 1)
+	msgBus.Subscribe("whatsapp", func(m *bus.OutboundMessage) { got <- m })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go msgBus.DispatchOutbound(ctx)
+
+	var msg *bus.OutboundMessage
+	select {
+	case msg = <-got:
+	case <-time.After(time.Second):
+		t.Fatal("expected released message")
+	}
+	if msg.Content != "good morning" || len(msg.MediaURLs) != 1 {
+		t.Fatalf("unexpected released message: %+v", msg)
+	}
+	if n, _ := tl.CountDeferredOutbound(); n != 1 {
+		t.Fatalf("expected the future message to stay queued, got %d", n)
+	}
+}
+
+func TestProcessMessageAdoptsPendingBridgeTask(t *testing.T) {
+	tl := newTestTimeline(t)
+	loop := NewLoop(LoopOptions{
+		Bus:           bus.NewMessageBus(),
+		Provider:      &mockProvider{responses: []provider.ChatResponse{{Content: "Friday."}}},
+		Timeline:      tl,
+		Workspace:     t.TempDir(),
+		WorkRepo:      t.TempDir(),
+		Model:         "mock-model",
+		MaxIterations: 3,
+	})
+	// The asynchronous inbound endpoint creates the task before publishing.
+	pending, err := tl.CreateTask(&timeline.AgentTask{
+		IdempotencyKey: "bridge:slack|D1|1700.01",
+		TraceID:        "trace-bridge",
+		Channel:        "slack",
+		ChatID:         "D1",
+		ContentIn:      "When do we deploy?",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, taskID, err := loop.processMessage(context.Background(), &bus.InboundMessage{
+		Channel:        "slack",
+		SenderID:       "U1",
+		ChatID:         "D1",
+		MessageID:      "1700.01",
+		TraceID:        "trace-bridge",
+		IdempotencyKey: "bridge:slack|D1|1700.01",
+		Content:        "When do we deploy?",
+		Metadata:       map[string]any{bus.MetaKeyMessageType: bus.MessageTypeInternal},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if taskID != pending.TaskID {
+		t.Fatalf("expected pending task %s to be adopted, got %s", pending.TaskID, taskID)
+	}
+	got, _ := tl.GetTask(taskID)
+	if got.Status != timeline.TaskStatusCompleted || got.ContentOut != "Friday." {
+		t.Fatalf("unexpected task after processing: %+v", got)
+	}
+}
@@ -0,0 +1,209 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/memory"
+	"github.com/KafClaw/KafClaw/internal/provider"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// groundedSourcesCapChars bounds the sources section of a grounded answer.
+const groundedSourcesCapChars = 6000
+
+var groundedCitationPattern = regexp.MustCompile(`\[(\d{1,3})\]`)
+
+// groundedConfig returns the grounded mode settings with defaults filled in.
+func (l *Loop) groundedConfig() config.MemoryGroundedConfig {
+	def := config.DefaultConfig().Memory.Grounded
+	if l.cfg == nil {
+		return def
+	}
+	gc := l.cfg.Memory.Grounded
+	if gc.MinScore <= 0 || gc.MinScore > 1 {
+		gc.MinScore = def.MinScore
+	}
+	if strings.TrimSpace(gc.Refusal) == "" {
+		gc.Refusal = def.Refusal
+	}
+	return gc
+}
+
+// groundedForChat reports whether a chat answers only from memory. The
+// per-chat setting "grounded:<channel>:<chat_id>" wins over the per-channel
+// "grounded:<channel>", which wins over memory.grounded.channels and the
+// global "grounded_mode" setting; settings accept on/off.
+func (l *Loop) groundedForChat(channel, chatID string) bool {
+	if l.timeline != nil && channel != "" {
+		keys := []string{"grounded:" + channel}
+		if chatID != "" {
+			keys = append([]string{fmt.Sprintf("grounded:%s:%s", channel, chatID)}, keys...)
+		}
+		for _, key := range keys {
+			if v, err := l.timeline.GetSetting(key); err == nil && strings.TrimSpace(v) != "" {
+				return isSettingOn(v)
+			}
+		}
+	}
+	if l.cfg != nil && slices.Contains(l.cfg.Memory.Grounded.Channels, channel) {
+		return true
+	}
+	if l.timeline == nil {
+		return false
+	}
+	v, err := l.timeline.GetSetting("grounded_mode")
+	return err == nil && isSettingOn(v)
+}
+
+// answerGrounded answers userQuery from retrieved memory and knowledge only.
+// Without a source scoring at least the grounded MinScore the model is not
+// called at all. Tools are off for the grounded call, and a reply that cites
+// none of the numbered sources is replaced by the refusal.
+func (l *Loop) answerGrounded(ctx context.Context, messages []provider.Message, userQuery string) (string, error) {
+	gc := l.groundedConfig()
+	relevant, facts := l.relevantMemory(ctx, userQuery, float32(gc.MinScore))
+	section, sources := formatGroundedSources(relevant, min(groundedSourcesCapChars, l.memoryInjectionBudgetChars()))
+	if len(sources) == 0 || len(messages) == 0 {
+		l.recordGrounded("refused", "no source above the confidence threshold", sources, nil, gc.MinScore)
+		return gc.Refusal, nil
+	}
+	messages[0].Content += section + fmt.Sprintf(groundedInstructions, gc.Refusal)
+	l.recordFactInjections(facts)
+
+	l.activeGrounded = true
+	response, err := l.runAgentLoop(ctx, messages)
+	l.activeGrounded = false
+	if err != nil {
+		return "", err
+	}
+
+	answer, cited := groundedAnswer(response, sources, gc.Refusal)
+	switch {
+	case len(cited) > 0:
+		l.recordGrounded("answered", "", sources, cited, gc.MinScore)
+	case strings.TrimSpace(response) == gc.Refusal:
+		l.recordGrounded("refused", "sources do not answer the question", sources, nil, gc.MinScore)
+	default:
+		l.recordGrounded("refused", "reply cited no source", sources, nil, gc.MinScore)
+	}
+	return answer, nil
+}
+
+const groundedInstructions = `
+
+# Grounded Answer Mode
+Answer ONLY from the numbered sources above. Do not use prior knowledge, assumptions or the conversation itself as evidence.
+Cite every statement with the number of its source, e.g. [1] or [2][3].
+If the sources do not answer the question, reply exactly: %s`
+
+// formatGroundedSources numbers the chunks, best first, as long as they fit
+// capChars. Chunks that do not fit are dropped so every listed source is
+// complete and citable.
+func formatGroundedSources(chunks []memory.MemoryChunk, capChars int) (string, []memory.MemoryChunk) {
+	var sb strings.Builder
+	var sources []memory.MemoryChunk
+	sb.WriteString("\n\n---\n\n# Sources\n\n")
+	for _, c := range chunks {
+		content := strings.TrimSpace(c.Content)
+		if content == "" {
+			continue
+		}
+		line := fmt.Sprintf("[%d] (%s, relevance %.0f%%) %s\n", len(sources)+1, c.Source, c.Score*100, content)
+		if sb.Len()+len(line) > capChars {
+			break
+		}
+		sb.WriteString(line)
+		sources = append(sources, c)
+	}
+	if len(sources) == 0 {
+		return "", nil
+	}
+	return sb.String(), sources
+}
+
+// groundedAnswer checks the citations of a grounded reply. A reply citing at
+// least one listed source is returned with a source list appended; anything
+// else becomes the refusal. cited holds the 1-based source numbers used.
+func groundedAnswer(response string, sources []memory.MemoryChunk, refusal string) (string, []int) {
+	response = strings.TrimSpace(response)
+	seen := map[int]bool{}
+	var cited []int
+	for _, m := range groundedCitationPattern.FindAllStringSubmatch(response, -1) {
+		n, err := strconv.Atoi(m[1])
+		if err != nil || n < 1 || n > len(sources) || seen[n] {
+			continue
+		}
+		seen[n] = true
+		cited = append(cited, n)
+	}
+	if len(cited) == 0 {
+		return refusal, nil
+	}
+	slices.Sort(cited)
+
+	var sb strings.Builder
+	sb.WriteString(response)
+	sb.WriteString("\n\nSources:")
+	for _, n := range cited {
+		sb.WriteString("\n" + groundedSourceLabel(n, sources[n-1]))
+	}
+	return sb.String(), cited
+}
+
+// groundedSourceLabel names a source for the reader: its kind plus the
+// trace it was indexed from, when known.
+func groundedSourceLabel(n int, c memory.MemoryChunk) string {
+	label := fmt.Sprintf("[%d] %s", n, c.Source)
+	if c.TraceID != "" {
+		label += " (trace " + c.TraceID + ")"
+	} else if c.ID != "" {
+		label += " (" + c.ID + ")"
+	}
+	if !c.CreatedAt.IsZero() {
+		label += ", " + c.CreatedAt.UTC().Format("2006-01-02")
+	}
+	return label
+}
+
+// recordGrounded logs the outcome of a grounded answer to the trace.
+func (l *Loop) recordGrounded(outcome, reason string, sources []memory.MemoryChunk, cited []int, minScore float64) {
+	if l.timeline == nil || l.activeTraceID == "" {
+		return
+	}
+	top := 0.0
+	if len(sources) > 0 {
+		top = float64(sources[0].Score)
+	}
+	meta, _ := json.Marshal(map[string]any{
+		"outcome":   outcome,
+		"reason":    reason,
+		"sources":   len(sources),
+		"cited":     cited,
+		"top_score": top,
+		"min_score": minScore,
+	})
+	text := "grounded answer: " + outcome
+	if reason != "" {
+		text += " (" + reason + ")"
+	}
+	_ = l.timeline.AddEvent(&timeline.TimelineEvent{
+		EventID:        fmt.Sprintf("GROUNDED_%s_%d", l.activeTraceID, time.Now().UnixNano()),
+		TraceID:        l.activeTraceID,
+		Timestamp:      time.Now(),
+		SenderID:       "AGENT",
+		SenderName:     "Grounding",
+		EventType:      "SYSTEM",
+		ContentText:    text,
+		Classification: "GROUNDED",
+		Authorized:     true,
+		Metadata:       string(meta),
+	})
+}
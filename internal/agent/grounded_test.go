@@ -0,0 +1,144 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/memory"
+	"github.com/KafClaw/KafClaw/internal/provider"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func newGroundedLoop(t *testing.T, responses []provider.ChatResponse) (*Loop, *recordingProvider, *timeline.TimelineService) {
+	t.Helper()
+	tl := newTestTimeline(t)
+	cfg := config.DefaultConfig()
+	cfg.Knowledge.Enabled = true
+	cfg.Knowledge.Group = "g"
+	// Facts without votes have a modest confidence.
+	cfg.Memory.Grounded.MinScore = 0.2
+	if err := tl.UpsertKnowledgeFactLatest(&timeline.KnowledgeFactRecord{
+		FactID: "f-owner", GroupName: "g", Subject: "payments", Predicate: "owner", Object: "team-alpha", Version: 1, Source: "decision", Tags: "[]",
+	}); err != nil {
+		t.Fatalf("upsert fact: %v", err)
+	}
+	if err := tl.SetSetting("grounded:slack:C1", "on"); err != nil {
+		t.Fatal(err)
+	}
+	prov := &recordingProvider{mockProvider: mockProvider{responses: responses}}
+	tmpDir := t.TempDir()
+	loop := NewLoop(LoopOptions{
+		Provider:      prov,
+		Timeline:      tl,
+		Config:        cfg,
+		Workspace:     tmpDir,
+		WorkRepo:      tmpDir,
+		Model:         "mock-model",
+		MaxIterations: 3,
+	})
+	return loop, prov, tl
+}
+
+func groundedOutcome(t *testing.T, tl *timeline.TimelineService, traceID string) string {
+	t.Helper()
+	events, err := tl.GetEvents(timeline.FilterArgs{TraceID: traceID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range events {
+		if e.Classification == "GROUNDED" {
+			return e.ContentText
+		}
+	}
+	return ""
+}
+
+func TestGroundedModeAnswersWithCitations(t *testing.T) {
+	loop, prov, tl := newGroundedLoop(t, []provider.ChatResponse{{Content: "Team alpha owns payments [1]."}})
+	resp, err := loop.ProcessDirectWithTrace(context.Background(), "who is the payments owner?", "slack:C1", "trace-grounded-ok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(resp, "Team alpha owns payments [1].") || !strings.Contains(resp, "Sources:\n[1] knowledge (f-owner)") {
+		t.Fatalf("expected cited answer with source list, got %q", resp)
+	}
+	if len(prov.toolsOffered) != 1 || prov.toolsOffered[0] {
+		t.Fatalf("grounded call must run once without tools, got %v", prov.toolsOffered)
+	}
+	if got := groundedOutcome(t, tl, "trace-grounded-ok"); got != "grounded answer: answered" {
+		t.Fatalf("unexpected GROUNDED event %q", got)
+	}
+}
+
+func TestGroundedModeRefuses(t *testing.T) {
+	refusal := config.DefaultConfig().Memory.Grounded.Refusal
+
+	// Nothing retrieved: the model is never called.
+	loop, prov, tl := newGroundedLoop(t, nil)
+	resp, err := loop.ProcessDirectWithTrace(context.Background(), "what is the weather in Berlin?", "slack:C1", "trace-grounded-empty")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp != refusal || prov.calls != 0 {
+		t.Fatalf("expected refusal without LLM call, got %q after %d calls", resp, prov.calls)
+	}
+	if got := groundedOutcome(t, tl, "trace-grounded-empty"); !strings.Contains(got, "refused") {
+		t.Fatalf("unexpected GROUNDED event %q", got)
+	}
+
+	// An uncited reply, or one citing a source that does not exist, is refused.
+	loop, _, _ = newGroundedLoop(t, []provider.ChatResponse{{Content: "Probably team beta, see [7]."}})
+	resp, err = loop.ProcessDirectWithTrace(context.Background(), "who is the payments owner?", "slack:C1", "trace-grounded-uncited")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp != refusal {
+		t.Fatalf("expected refusal for uncited reply, got %q", resp)
+	}
+}
+
+func TestGroundedForChatPrecedence(t *testing.T) {
+	loop, _, tl := newGroundedLoop(t, nil)
+	loop.cfg.Memory.Grounded.Channels = []string{"msteams"}
+
+	cases := []struct {
+		channel, chat string
+		want          bool
+	}{
+		{"slack", "C1", true},   // per-chat setting
+		{"slack", "C2", false},  // nothing set
+		{"msteams", "T1", true}, // config channel list
+		{"msteams", "T2", false},
+	}
+	if err := tl.SetSetting("grounded:msteams:T2", "off"); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range cases {
+		if got := loop.groundedForChat(c.channel, c.chat); got != c.want {
+			t.Fatalf("groundedForChat(%s, %s) = %v, want %v", c.channel, c.chat, got, c.want)
+		}
+	}
+
+	if err := tl.SetSetting("grounded_mode", "on"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tl.SetSetting("grounded:slack", "off"); err != nil {
+		t.Fatal(err)
+	}
+	if !loop.groundedForChat("cli", "default") || loop.groundedForChat("slack", "C2") {
+		t.Fatal("expected grounded_mode for other channels and the channel setting to win over it")
+	}
+}
+
+func TestFormatGroundedSourcesKeepsWholeSources(t *testing.T) {
+	chunks := []memory.MemoryChunk{
+		{ID: "a", Source: "conversation", Content: "short", Score: 0.9},
+		{ID: "b", Source: "user", Content: strings.Repeat("x", 500), Score: 0.8},
+	}
+	section, sources := formatGroundedSources(chunks, 200)
+	if len(sources) != 1 || !strings.Contains(section, "[1] (conversation, relevance 90%) short") || strings.Contains(section, "[2]") {
+		t.Fatalf("expected only the first source to fit, got %q", section)
+	}
+}
@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/KafClaw/KafClaw/internal/knowledge"
+	"github.com/KafClaw/KafClaw/internal/memory"
+)
+
+// knowledgeFactScanLimit bounds how many shared facts are matched per prompt.
+const knowledgeFactScanLimit = 200
+
+// relevantKnowledgeFacts matches shared knowledge facts against the query and
+// returns them as memory chunks. A fact's score is its term overlap with the
+// query weighted by its confidence, so well-supported facts outrank disputed
+// ones and low-confidence facts fall below the relevance threshold.
+func (l *Loop) relevantKnowledgeFacts(query string, minScore float32) []memory.MemoryChunk {
+	if l.timeline == nil || l.cfg == nil || !l.cfg.Knowledge.Enabled {
+		return nil
+	}
+	queryTerms := map[string]bool{}
+	for _, t := range factTerms(query) {
+		queryTerms[t] = true
+	}
+	if len(queryTerms) == 0 {
+		return nil
+	}
+	facts, err := l.timeline.ListKnowledgeFacts(strings.TrimSpace(l.cfg.Knowledge.Group), knowledgeFactScanLimit, 0)
+	if err != nil {
+		slog.Warn("Knowledge fact lookup failed", "error", err)
+		return nil
+	}
+	var out []memory.MemoryChunk
+	for _, f := range facts {
+		terms := factTerms(f.Subject + " " + f.Predicate + " " + f.Object)
+		if len(terms) == 0 {
+			continue
+		}
+		matched := 0
+		for _, t := range terms {
+			if queryTerms[t] {
+				matched++
+			}
+		}
+		if matched == 0 {
+			continue
+		}
+		confidence := knowledge.FactConfidence(knowledge.ConfidenceSignals{
+			YesVotes:         f.YesVotes,
+			NoVotes:          f.NoVotes,
+			ProposerApproved: f.ProposerApproved,
+			ProposerRejected: f.ProposerRejected,
+			Injections:       f.InjectionCount,
+			Contradictions:   f.ContradictionCount,
+		})
+		score := float32(float64(matched) / float64(len(terms)) * confidence)
+		if score < minScore {
+			continue
+		}
+		out = append(out, memory.MemoryChunk{
+			ID:      f.FactID,
+			Source:  "knowledge",
+			Privacy: memory.PrivacyPublic,
+			Content: fmt.Sprintf("%s %s %s (confidence %.0f%%)", f.Subject, f.Predicate, f.Object, confidence*100),
+			Score:   score,
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	if k := l.memoryLaneTopK(); len(out) > k {
+		out = out[:k]
+	}
+	return out
+}
+
+// factTerms splits text into lowercase terms of three or more characters.
+func factTerms(text string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, f := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(f)) >= 3 && !seen[f] {
+			seen[f] = true
+			out = append(out, f)
+		}
+	}
+	return out
+}
@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/provider"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestInjectRAGContextWeightsKnowledgeFactsByConfidence(t *testing.T) {
+	tl := newTestTimeline(t)
+	cfg := config.DefaultConfig()
+	cfg.Knowledge.Enabled = true
+	cfg.Knowledge.Group = "g"
+
+	if err := tl.CreateKnowledgeProposal(&timeline.KnowledgeProposalRecord{
+		ProposalID: "p-good", GroupName: "g", Statement: "s", ProposerClawID: "claw-a",
+	}); err != nil {
+		t.Fatalf("create proposal: %v", err)
+	}
+	if err := tl.UpdateKnowledgeProposalDecision("p-good", "approved", 6, 0, ""); err != nil {
+		t.Fatalf("decide proposal: %v", err)
+	}
+	for _, f := range []timeline.KnowledgeFactRecord{
+		{FactID: "f-good", GroupName: "g", Subject: "payments", Predicate: "owner", Object: "team-alpha", Version: 1, Source: "decision", ProposalID: "p-good", Tags: "[]"},
+		{FactID: "f-disputed", GroupName: "g", Subject: "payments", Predicate: "owner", Object: "team-beta", Version: 1, Source: "direct", Tags: "[]"},
+	} {
+		f := f
+		if err := tl.UpsertKnowledgeFactLatest(&f); err != nil {
+			t.Fatalf("upsert fact: %v", err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		_ = tl.RecordKnowledgeFactContradiction("f-disputed")
+	}
+
+	loop := NewLoop(LoopOptions{Timeline: tl, Config: cfg, Workspace: t.TempDir(), WorkRepo: t.TempDir()})
+	msgs, _ := loop.injectRAGContext(context.Background(), []provider.Message{{Role: "system", Content: "sys"}}, "who is the payments owner team alpha or beta?", 4000)
+	out := msgs[0].Content
+	good := strings.Index(out, "payments owner team-alpha")
+	disputed := strings.Index(out, "payments owner team-beta")
+	if good < 0 || disputed < 0 || good > disputed {
+		t.Fatalf("expected well-supported fact ranked above disputed fact, got %q", out)
+	}
+
+	// Only "payments" matches: the disputed fact falls below the threshold.
+	msgs, _ = loop.injectRAGContext(context.Background(), []provider.Message{{Role: "system", Content: "sys"}}, "payments status", 4000)
+	if strings.Contains(msgs[0].Content, "team-beta") {
+		t.Fatalf("expected low-confidence partial match dropped, got %q", msgs[0].Content)
+	}
+
+	got, err := tl.GetKnowledgeFactLatest("f-good")
+	if err != nil || got == nil || got.InjectionCount < 1 {
+		t.Fatalf("expected injection recorded, got %+v err=%v", got, err)
+	}
+}
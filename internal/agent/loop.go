@@ -12,6 +12,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -22,12 +23,15 @@ import (
 	"github.com/KafClaw/KafClaw/internal/bus"
 	"github.com/KafClaw/KafClaw/internal/config"
 	"github.com/KafClaw/KafClaw/internal/memory"
+	"github.com/KafClaw/KafClaw/internal/metrics"
+	"github.com/KafClaw/KafClaw/internal/msgtemplate"
 	"github.com/KafClaw/KafClaw/internal/policy"
 	"github.com/KafClaw/KafClaw/internal/provider"
 	"github.com/KafClaw/KafClaw/internal/provider/middleware"
 	"github.com/KafClaw/KafClaw/internal/session"
 	"github.com/KafClaw/KafClaw/internal/timeline"
 	"github.com/KafClaw/KafClaw/internal/tools"
+	"github.com/KafClaw/KafClaw/internal/tracing"
 )
 
 // GroupTracePublisher can publish trace and audit data to a group.
@@ -81,6 +85,7 @@ type LoopOptions struct {
 	SubagentMemoryShareMode string
 	SubagentToolsAllow      []string
 	SubagentToolsDeny       []string
+	SubagentProgressSec     int
 	Config                  *config.Config // for middleware chain setup
 }
 
@@ -98,6 +103,7 @@ type Loop struct {
 	groupPublisher   GroupTracePublisher
 	approvalMgr      *approval.Manager
 	registry         *tools.Registry
+	artifacts        *tools.ArtifactStore
 	sessions         *session.Manager
 	contextBuilder   *ContextBuilder
 	workspace        string
@@ -107,15 +113,32 @@ type Loop struct {
 	model            string
 	maxIterations    int
 	running          atomic.Bool
+	// turnMu runs one turn at a time: bus messages and direct calls share
+	// the active* state below.
+	turnMu sync.Mutex
 	// activeTaskID tracks the current task being processed (for token accounting).
 	activeTaskID string
+	// taskCancels holds the cancel funcs of in-flight tasks for CancelTask.
+	taskCancelMu sync.Mutex
+	taskCancels  map[string]context.CancelCauseFunc
 	// activeSender tracks the sender of the current message (for policy checks).
-	activeSender            string
-	activeChannel           string
-	activeChatID            string
-	activeThreadID          string
-	activeTraceID           string
-	activeMessageType       string
+	activeSender      string
+	activeChannel     string
+	activeChatID      string
+	activeThreadID    string
+	activeTraceID     string
+	activeMessageType string
+	// activeMessageID is the channel message id of the inbound message,
+	// recorded as provenance on indexed memory.
+	activeMessageID string
+	// activeAccount is the channel account of the inbound message (for
+	// per-account tool lists).
+	activeAccount string
+	// typing is the typing indicator of the current task, if any.
+	typing *typingIndicator
+	// activePlanApproved is set while running a tool call covered by an
+	// approved plan-first go-ahead.
+	activePlanApproved      bool
 	chain                   *middleware.Chain
 	cfg                     *config.Config
 	subagents               *subagentManager
@@ -125,11 +148,36 @@ type Loop struct {
 	subagentThinking        string
 	subagentMemoryShareMode string
 	subagentTools           subagentToolPolicy
+	subagentProgressSec     int
+	progressHook            func(subagentProgress)
 	announceMu              sync.Mutex
 	announceSent            map[string]time.Time
 	retryWorkerMu           sync.Mutex
 	retryWorkerOn           bool
-}
+	// pendingMedia collects files tools attached to the current reply.
+	pendingMedia []string
+	// pendingTemplate and pendingTemplateVars are the message template the
+	// current reply is rendered with.
+	pendingTemplate     string
+	pendingTemplateVars map[string]any
+	// activeSettings are the /settings of the chat being processed.
+	activeSettings chatSettings
+	// activeThreadContext is the thread history section of the inbound
+	// message being processed (see thread_context.go).
+	activeThreadContext string
+	// activeGrounded turns tools off while a grounded answer is generated
+	// (see grounded.go).
+	activeGrounded bool
+	// toolTrace lists the tools run for the current reply (tool_traces).
+	toolTrace []string
+	// outputHooks post-process replies per channel (see output_hooks.go).
+	outputHooks *outputHooks
+}
+
+var (
+	loopMessagesTotal = metrics.NewCounter("kafclaw_agent_messages_total", "Inbound messages processed by the agent loop, by channel and result.", "channel", "result")
+	loopDuration      = metrics.NewHistogram("kafclaw_agent_message_duration_seconds", "Agent loop processing time per inbound message.", nil, "channel")
+)
 
 // NewLoop creates a new agent loop.
 func NewLoop(opts LoopOptions) *Loop {
@@ -142,6 +190,9 @@ func NewLoop(opts LoopOptions) *Loop {
 
 	// Create context builder
 	ctxBuilder := NewContextBuilder(opts.Workspace, opts.WorkRepo, opts.SystemRepo, registry)
+	if opts.Timeline != nil {
+		ctxBuilder.SetPromptTemplates(opts.Timeline)
+	}
 
 	loop := &Loop{
 		bus:              opts.Bus,
@@ -191,10 +242,12 @@ func NewLoop(opts LoopOptions) *Loop {
 			Allow: append([]string{}, opts.SubagentToolsAllow...),
 			Deny:  append([]string{}, opts.SubagentToolsDeny...),
 		},
-		announceSent: make(map[string]time.Time),
+		subagentProgressSec: opts.SubagentProgressSec,
+		announceSent:        make(map[string]time.Time),
 	}
 
 	loop.cfg = opts.Config
+	loop.setupApprovals()
 
 	// Build middleware chain.
 	loop.chain = middleware.NewChain(opts.Provider)
@@ -211,6 +264,16 @@ func NewLoop(opts LoopOptions) *Loop {
 		if opts.Config.FinOps.Enabled {
 			loop.chain.Use(middleware.NewFinOpsRecorder(opts.Config.FinOps))
 		}
+		if len(opts.Config.OutputHooks.Channels) > 0 {
+			hooks, err := newOutputHooks(opts.Config.OutputHooks)
+			if err != nil {
+				slog.Error("Invalid output hooks block their channels' replies", "error", err)
+			}
+			loop.outputHooks = hooks
+			if loop.bus != nil {
+				loop.bus.SetOutboundFilter(loop.filterOutbound)
+			}
+		}
 	}
 
 	// Register default tools
@@ -230,11 +293,35 @@ func (l *Loop) registerDefaultTools() {
 	l.registry.Register(tools.NewListDirTool())
 	l.registry.Register(tools.NewResolvePathTool(repoGetter))
 	l.registry.Register(tools.NewExecTool(0, true, l.workspace, repoGetter))
+	if strings.TrimSpace(l.workspace) != "" {
+		l.artifacts = tools.NewArtifactStore(filepath.Join(l.workspace, "artifacts", "tool-output"))
+		l.registry.Register(tools.NewReadArtifactTool(l.artifacts))
+	}
 
 	// Register memory tools only when memory service is available.
 	if l.memoryService != nil {
 		l.registry.Register(tools.NewRememberTool(l.memoryService))
-		l.registry.Register(tools.NewRecallTool(l.memoryService))
+		l.registry.Register(tools.NewRecallTool(l.memoryService, func() string { return l.allowedPrivacy(l.activeChannel) }))
+	}
+	if vision, ok := l.provider.(provider.VisionProvider); ok {
+		l.registry.Register(tools.NewAnalyzeImageTool(vision, l.memoryService, tools.DefaultMediaDirs(l.workspace)...))
+	}
+	if gen, err := provider.ResolveImageGenerator(l.cfg); err != nil {
+		slog.Warn("Image generation disabled", "error", err)
+	} else if gen != nil {
+		ic := l.cfg.Tools.ImageGen
+		outDir := ""
+		if strings.TrimSpace(l.workspace) != "" {
+			outDir = filepath.Join(l.workspace, "media", "generated")
+		}
+		l.registry.Register(tools.NewGenerateImageTool(gen, outDir, ic.Size, ic.Model, l.attachMedia))
+	}
+	if l.cfg != nil && len(l.cfg.Tools.SSH.Hosts) > 0 {
+		if sshTool, err := tools.NewSSHTool(l.cfg.Tools.SSH, l.auditRemoteExec); err != nil {
+			slog.Warn("SSH tool disabled", "error", err)
+		} else {
+			l.registry.Register(sshTool)
+		}
 	}
 
 	l.registry.Register(tools.NewSessionsSpawnTool(l.spawnSubagentFromTool))
@@ -242,6 +329,41 @@ func (l *Loop) registerDefaultTools() {
 	l.registry.Register(tools.NewAgentsListTool(l.listSubagentAgentsForTool))
 	l.registry.Register(tools.NewGoogleWorkspaceReadTool())
 	l.registry.Register(tools.NewM365ReadTool())
+	if l.timeline != nil {
+		l.registry.Register(tools.NewTaskBoardTool(l.listBoardItemsForTool, l.createBoardItemForTool, l.updateBoardItemForTool))
+	}
+	if l.bus != nil {
+		l.registry.Register(tools.NewPollTool(l.listPollsForTool, l.pollResultsForTool, l.closePollForTool))
+		l.registry.Register(tools.NewScheduledMessageTool(l.scheduleMessageForTool, l.listScheduledForTool, l.cancelScheduledForTool))
+	}
+	if strings.TrimSpace(l.workspace) != "" {
+		l.registry.Register(tools.NewMessageTemplateTool(msgtemplate.NewRegistry(l.workspace), l.useTemplate))
+	}
+}
+
+// auditRemoteExec records an ssh tool command in the timeline, attributed
+// to the active trace and sender.
+func (l *Loop) auditRemoteExec(rec tools.SSHAuditRecord) {
+	if l.timeline == nil {
+		return
+	}
+	if err := l.timeline.LogRemoteExec(&timeline.RemoteExecRecord{
+		TraceID:     l.activeTraceID,
+		TaskID:      l.activeTaskID,
+		Host:        rec.Host,
+		Address:     rec.Address,
+		User:        rec.User,
+		Command:     rec.Command,
+		Tier:        rec.Tier,
+		ExitCode:    rec.ExitCode,
+		DurationMs:  rec.Duration.Milliseconds(),
+		OutputBytes: rec.OutputBytes,
+		Error:       rec.Error,
+		Sender:      l.activeSender,
+		Channel:     l.activeChannel,
+	}); err != nil {
+		slog.Warn("Failed to audit remote command", "host", rec.Host, "error", err)
+	}
 }
 
 // Run starts the agent loop, processing messages from the bus.
@@ -262,14 +384,19 @@ func (l *Loop) Run(ctx context.Context) error {
 
 		// Intercept approval responses (approve:<id> / deny:<id>)
 		if id, approved, ok := parseApprovalResponse(msg.Content); ok && l.approvalMgr != nil {
-			if err := l.approvalMgr.Respond(id, approved); err != nil {
-				slog.Warn("Approval response failed", "id", id, "error", err)
+			owner := msg.MessageType() == bus.MessageTypeInternal
+			if err := l.approvalMgr.RespondAs(id, approved, msg.SenderID, owner); err != nil {
+				slog.Warn("Approval response failed", "id", id, "sender", msg.SenderID, "error", err)
+				reply := fmt.Sprintf("No pending approval found for ID %s.", id)
+				if errors.Is(err, approval.ErrNotAuthorized) {
+					reply = fmt.Sprintf("You are not allowed to decide approval %s.", id)
+				}
 				l.bus.PublishOutbound(&bus.OutboundMessage{
 					Channel:  msg.Channel,
 					ChatID:   msg.ChatID,
 					ThreadID: msg.ThreadID,
 					TraceID:  msg.TraceID,
-					Content:  fmt.Sprintf("No pending approval found for ID %s.", id),
+					Content:  reply,
 				})
 			} else {
 				action := "denied"
@@ -287,26 +414,46 @@ func (l *Loop) Run(ctx context.Context) error {
 			continue
 		}
 
+		if l.activityWithoutReply(msg) {
+			continue
+		}
+
+		l.turnMu.Lock()
+		l.pendingMedia = nil
+		l.pendingTemplate, l.pendingTemplateVars = "", nil
+		started := time.Now()
 		response, taskID, err := l.processMessage(ctx, msg)
+		loopDuration.Observe(time.Since(started).Seconds(), msg.Channel)
 		if err != nil {
+			loopMessagesTotal.Inc(msg.Channel, "error")
 			slog.Error("Failed to process message", "error", err)
 			response = fmt.Sprintf("Error: %v", err)
+		} else {
+			loopMessagesTotal.Inc(msg.Channel, "ok")
 		}
 
-		if response != "" {
+		if response != "" || l.pendingTemplate != "" {
+			threadID := msg.ThreadID
+			if l.activeSettings.Threading == "off" {
+				threadID = ""
+			}
 			l.bus.PublishOutbound(&bus.OutboundMessage{
-				Channel:  msg.Channel,
-				ChatID:   msg.ChatID,
-				ThreadID: msg.ThreadID,
-				TraceID:  msg.TraceID,
-				TaskID:   taskID,
-				Content:  response,
+				Channel:      msg.Channel,
+				ChatID:       msg.ChatID,
+				ThreadID:     threadID,
+				TraceID:      msg.TraceID,
+				TaskID:       taskID,
+				Content:      response,
+				MediaURLs:    l.pendingMedia,
+				Template:     l.pendingTemplate,
+				TemplateVars: l.pendingTemplateVars,
 			})
 			// Optimistic delivery mark
 			if l.timeline != nil && taskID != "" {
 				_ = l.timeline.UpdateTaskDelivery(taskID, timeline.DeliverySent, nil)
 			}
 		}
+		l.turnMu.Unlock()
 	}
 
 	return nil
@@ -317,6 +464,11 @@ func (l *Loop) Stop() {
 	l.running.Store(false)
 }
 
+// ToolSchemas returns the schemas of the tools registered on this loop.
+func (l *Loop) ToolSchemas() []tools.Schema {
+	return l.registry.Schemas()
+}
+
 // ProcessDirect processes a message directly (for CLI usage).
 func (l *Loop) ProcessDirect(ctx context.Context, content, sessionKey string) (string, error) {
 	return l.ProcessDirectWithTrace(ctx, content, sessionKey, "")
@@ -324,6 +476,13 @@ func (l *Loop) ProcessDirect(ctx context.Context, content, sessionKey string) (s
 
 // ProcessDirectWithTrace processes a message with an explicit trace id.
 func (l *Loop) ProcessDirectWithTrace(ctx context.Context, content, sessionKey, traceID string) (string, error) {
+	l.turnMu.Lock()
+	defer l.turnMu.Unlock()
+	return l.processDirect(ctx, content, sessionKey, traceID)
+}
+
+// processDirect runs one turn on sessionKey; the caller holds turnMu.
+func (l *Loop) processDirect(ctx context.Context, content, sessionKey, traceID string) (string, error) {
 	// Extract channel and chatID from key if possible
 	parts := strings.SplitN(sessionKey, ":", 2)
 	channel, chatID := "cli", "default"
@@ -333,19 +492,29 @@ func (l *Loop) ProcessDirectWithTrace(ctx context.Context, content, sessionKey,
 	if traceID == "" {
 		traceID = fmt.Sprintf("trace-%d", time.Now().UnixNano())
 	}
+	ctx = tracing.WithTraceID(ctx, traceID)
 	prevChannel := l.activeChannel
 	prevChatID := l.activeChatID
 	prevThreadID := l.activeThreadID
 	prevTrace := l.activeTraceID
+	prevMessageID := l.activeMessageID
+	prevAccount := l.activeAccount
 	l.activeChannel = channel
 	l.activeChatID = chatID
 	l.activeThreadID = ""
 	l.activeTraceID = traceID
+	if traceID != prevTrace {
+		// Direct calls (CLI, cron, subagents) have no inbound message.
+		l.activeMessageID = ""
+		l.activeAccount = ""
+	}
 	defer func() {
 		l.activeChannel = prevChannel
 		l.activeChatID = prevChatID
 		l.activeThreadID = prevThreadID
 		l.activeTraceID = prevTrace
+		l.activeMessageID = prevMessageID
+		l.activeAccount = prevAccount
 	}()
 
 	// CLI direct calls are always internal (owner). Bus-routed messages
@@ -403,6 +572,27 @@ func (l *Loop) ProcessDirectWithTrace(ctx context.Context, content, sessionKey,
 
 	// Build messages using the context builder
 	messages := l.contextBuilder.BuildMessages(sess, content, channel, chatID, l.activeMessageType)
+	if hint := l.activeSettings.promptHint(); hint != "" && len(messages) > 0 {
+		messages[0].Content += hint
+	}
+	if l.activeThreadContext != "" && len(messages) > 0 {
+		messages[0].Content += l.activeThreadContext
+	}
+	if pins := l.pinnedNotesContext(channel, chatID); pins != "" && len(messages) > 1 && messages[len(messages)-1].Role == "user" {
+		messages[len(messages)-1].Content = pins + "\n" + messages[len(messages)-1].Content
+	}
+
+	// Grounded chats answer only from retrieved memory and knowledge. Their
+	// replies are not indexed back into memory, so they never become sources.
+	if l.groundedForChat(channel, chatID) {
+		response, err := l.answerGrounded(ctx, messages, content)
+		if err != nil {
+			return "", err
+		}
+		sess.AddMessage("assistant", response)
+		l.sessions.Save(sess)
+		return response, nil
+	}
 
 	remainingMemoryBudget := l.memoryInjectionBudgetChars()
 
@@ -415,6 +605,16 @@ func (l *Loop) ProcessDirectWithTrace(ctx context.Context, content, sessionKey,
 	// Inject RAG context from semantic memory
 	messages, _ = l.injectRAGContext(ctx, messages, content, remainingMemoryBudget)
 
+	// Cost preview: expensive requests need confirmation before the loop runs.
+	// Subagent runs have no user to ask; their parent request was confirmed.
+	if !isSubagentSession(sessionKey) {
+		if proceed, notice := l.confirmEstimatedCost(ctx, messages, content); !proceed {
+			sess.AddMessage("assistant", notice)
+			l.sessions.Save(sess)
+			return notice, nil
+		}
+	}
+
 	// Run the agentic loop
 	response, err := l.runAgentLoop(ctx, messages)
 	if err != nil {
@@ -427,13 +627,18 @@ func (l *Loop) ProcessDirectWithTrace(ctx context.Context, content, sessionKey,
 
 	// Auto-index conversation pair into semantic memory
 	if l.autoIndexer != nil {
-		l.autoIndexer.Enqueue(memory.FormatConversationPair(content, response, channel, chatID))
+		pair := memory.FormatConversationPair(content, response, channel, chatID)
+		pair.Privacy = l.creationPrivacy()
+		pair.TraceID = traceID
+		pair.EventID = l.activeMessageID
+		l.autoIndexer.Enqueue(pair)
 	}
 
 	// Enqueue messages for observational memory and trigger compression if needed
 	if l.observer != nil {
-		l.observer.EnqueueMessage(sessionKey, "user", content)
-		l.observer.EnqueueMessage(sessionKey, "assistant", response)
+		privacy := l.creationPrivacy()
+		l.observer.EnqueueMessageWithPrivacy(sessionKey, "user", content, privacy)
+		l.observer.EnqueueMessageWithPrivacy(sessionKey, "assistant", response, privacy)
 		if l.observer.ShouldObserve(sessionKey) {
 			go func() {
 				if err := l.observer.Observe(context.Background(), sessionKey); err != nil {
@@ -985,29 +1190,15 @@ func (l *Loop) systemRepoPath() string {
 	return ""
 }
 
-// injectRAGContext searches semantic memory for relevant context and appends
-// it to the system prompt. Returns messages unchanged if memoryService is nil
-// or search returns no relevant results.
+// injectRAGContext searches semantic memory and shared knowledge facts for
+// relevant context and appends it to the system prompt. Returns messages
+// unchanged if neither source is available or nothing relevant is found.
 func (l *Loop) injectRAGContext(ctx context.Context, messages []provider.Message, userQuery string, budgetChars int) ([]provider.Message, int) {
-	if l.memoryService == nil || len(messages) == 0 {
+	if len(messages) == 0 {
 		return messages, budgetChars
 	}
 
-	chunks, err := l.memoryService.Search(ctx, userQuery, l.memoryLaneTopK())
-	if err != nil {
-		slog.Warn("RAG search failed", "error", err)
-		return messages, budgetChars
-	}
-
-	// Filter out low-relevance results
-	var relevant []memory.MemoryChunk
-	minScore := l.memoryMinScore()
-	for _, c := range chunks {
-		if c.Score >= minScore {
-			relevant = append(relevant, c)
-		}
-	}
-
+	relevant, facts := l.relevantMemory(ctx, userQuery, l.memoryMinScore())
 	if len(relevant) == 0 {
 		return messages, budgetChars
 	}
@@ -1025,9 +1216,58 @@ func (l *Loop) injectRAGContext(ctx context.Context, messages []provider.Message
 	if truncated {
 		l.recordMemoryOverflow("rag")
 	}
+	l.recordFactInjections(facts)
 	return updated, remaining
 }
 
+// relevantMemory searches semantic memory and shared knowledge facts and
+// returns the chunks visible to the active channel scoring at least
+// minScore, best first. facts is the knowledge fact subset of relevant.
+func (l *Loop) relevantMemory(ctx context.Context, userQuery string, minScore float32) (relevant, facts []memory.MemoryChunk) {
+	if l.memoryService != nil {
+		// Over-fetch when privacy filtering may drop results.
+		allowed := l.allowedPrivacy(l.activeChannel)
+		limit := l.memoryLaneTopK()
+		fetch := limit
+		if allowed != memory.PrivacyOwner {
+			fetch *= 2
+		}
+		chunks, err := l.memoryService.Search(ctx, userQuery, fetch)
+		if err != nil {
+			slog.Warn("RAG search failed", "error", err)
+		}
+		chunks = visibleChunks(chunks, allowed)
+		if len(chunks) > limit {
+			chunks = chunks[:limit]
+		}
+		for _, c := range chunks {
+			if c.Score >= minScore {
+				relevant = append(relevant, c)
+			}
+		}
+	}
+	facts = l.relevantKnowledgeFacts(userQuery, minScore)
+	if len(facts) > 0 {
+		relevant = append(relevant, facts...)
+	}
+	sort.SliceStable(relevant, func(i, j int) bool { return relevant[i].Score > relevant[j].Score })
+	return relevant, facts
+}
+
+// recordFactInjections counts the use of injected knowledge facts.
+func (l *Loop) recordFactInjections(facts []memory.MemoryChunk) {
+	if len(facts) == 0 || l.timeline == nil {
+		return
+	}
+	ids := make([]string, len(facts))
+	for i, f := range facts {
+		ids[i] = f.ID
+	}
+	if err := l.timeline.RecordKnowledgeFactInjections(ids); err != nil {
+		slog.Warn("Knowledge fact usage not recorded", "error", err)
+	}
+}
+
 // injectWorkingMemory loads scoped working memory and appends it to the system prompt.
 func (l *Loop) injectWorkingMemory(messages []provider.Message, resourceID, threadID string, budgetChars int) ([]provider.Message, int) {
 	if l.workingMemory == nil || len(messages) == 0 {
@@ -1078,6 +1318,7 @@ func (l *Loop) injectObservations(messages []provider.Message, sessionID string,
 		slog.Warn("Observations load failed", "error", err)
 		return messages, budgetChars
 	}
+	observations = visibleObservations(observations, l.allowedPrivacy(l.activeChannel))
 	observations = trimTailObservations(observations, l.memoryLaneTopK())
 
 	formatted := memory.FormatObservations(observations)
@@ -1236,6 +1477,21 @@ func (l *Loop) processMessage(ctx context.Context, msg *bus.InboundMessage) (res
 	if msg.TraceID == "" {
 		msg.TraceID = fmt.Sprintf("trace-%d", time.Now().UnixNano())
 	}
+	ctx = tracing.WithTraceID(ctx, msg.TraceID)
+	// A bridged message continues the span of the bridge forward.
+	if raw, _ := msg.Metadata[bus.MetaKeyTraceparent].(string); raw != "" {
+		if p, ok := tracing.Parse(raw); ok && p.TraceID == msg.TraceID {
+			ctx = tracing.WithParent(ctx, p)
+		}
+	}
+	ctx, span := tracing.Start(ctx, "agent.process_message", tracing.KindServer)
+	span.Set("messaging.system", msg.Channel)
+	span.Set("messaging.destination.name", msg.ChatID)
+	span.Set("kafclaw.sender_id", msg.SenderID)
+	defer func() {
+		span.Set("kafclaw.task_id", taskID)
+		span.End(err)
+	}()
 
 	// Ensure IdempotencyKey
 	if msg.IdempotencyKey == "" {
@@ -1255,12 +1511,20 @@ func (l *Loop) processMessage(ctx context.Context, msg *bus.InboundMessage) (res
 			case timeline.TaskStatusProcessing:
 				slog.Info("Dedup hit: task still processing, skipping", "task_id", existing.TaskID)
 				return "", existing.TaskID, nil
+			case timeline.TaskStatusCancelled:
+				slog.Info("Dedup hit: task cancelled before it ran, skipping", "task_id", existing.TaskID)
+				return "", existing.TaskID, nil
+			case timeline.TaskStatusPending:
+				// Created up front by the asynchronous inbound bridge endpoint.
+				taskID = existing.TaskID
+				_ = l.timeline.UpdateTaskStatus(taskID, timeline.TaskStatusProcessing, "", "")
+				l.assignTaskToMission(msg, taskID)
 			}
 		}
 	}
 
 	// CREATE TASK (H-004)
-	if l.timeline != nil {
+	if l.timeline != nil && taskID == "" {
 		task, createErr := l.timeline.CreateTask(&timeline.AgentTask{
 			IdempotencyKey: msg.IdempotencyKey,
 			TraceID:        msg.TraceID,
@@ -1275,9 +1539,17 @@ func (l *Loop) processMessage(ctx context.Context, msg *bus.InboundMessage) (res
 		} else {
 			taskID = task.TaskID
 			_ = l.timeline.UpdateTaskStatus(taskID, timeline.TaskStatusProcessing, "", "")
+			l.assignTaskToMission(msg, taskID)
 		}
 	}
 
+	ctx, untrackCancel := l.trackTaskCancel(ctx, taskID)
+	defer untrackCancel()
+
+	// Typing indicator and read receipt while the task runs
+	stopPresence := l.startPresence(ctx, msg)
+	defer stopPresence()
+
 	// Set active context for policy checks and token tracking
 	l.activeTaskID = taskID
 	l.activeSender = msg.SenderID
@@ -1286,13 +1558,42 @@ func (l *Loop) processMessage(ctx context.Context, msg *bus.InboundMessage) (res
 	l.activeThreadID = msg.ThreadID
 	l.activeTraceID = msg.TraceID
 	l.activeMessageType = msg.MessageType()
+	l.activeMessageID = msg.MessageID
+	l.activeAccount, _ = msg.Metadata[bus.MetaKeyChannelAccount].(string)
+	l.activeSettings = l.loadChatSettings(msg.Channel, msg.ChatID)
+	l.toolTrace = nil
 
 	// PROCESS
-	response, err = l.ProcessDirectWithTrace(ctx, msg.Content, sessionKey, msg.TraceID)
+	if reply, handled := l.handleSettingsCommand(msg); handled {
+		response = reply
+	} else if reply, handled := l.handleCommitmentCommand(msg); handled {
+		response = reply
+	} else if reply, handled := l.handleBoardCommand(msg); handled {
+		response = reply
+	} else if reply, handled := l.handlePinsCommand(msg); handled {
+		response = reply
+	} else if reply, handled := l.handleMissionCommand(msg); handled {
+		response = reply
+	} else {
+		l.activeThreadContext = l.threadContext(ctx, msg, sessionKey)
+		response, err = l.processDirect(ctx, withAttachmentNote(msg.Content, msg.Media), sessionKey, msg.TraceID)
+		l.activeThreadContext = ""
+		if err == nil {
+			l.trackCommitments(msg, sessionKey, response)
+			if l.activeSettings.ToolTraces == "on" {
+				response += formatToolTrace(l.toolTrace)
+			}
+		}
+	}
+	if err != nil && taskCancelled(ctx) != nil {
+		err = ErrTaskCancelled
+	}
 
 	// UPDATE TASK
 	if l.timeline != nil && taskID != "" {
-		if err != nil {
+		if errors.Is(err, ErrTaskCancelled) {
+			_ = l.timeline.UpdateTaskStatus(taskID, timeline.TaskStatusCancelled, "", err.Error())
+		} else if err != nil {
 			_ = l.timeline.UpdateTaskStatus(taskID, timeline.TaskStatusFailed, "", err.Error())
 		} else {
 			_ = l.timeline.UpdateTaskStatus(taskID, timeline.TaskStatusCompleted, response, "")
@@ -1301,15 +1602,19 @@ func (l *Loop) processMessage(ctx context.Context, msg *bus.InboundMessage) (res
 
 	// PUBLISH TRACE to group (if active)
 	if l.groupPublisher != nil && l.groupPublisher.Active() && msg.TraceID != "" {
+		span := map[string]string{
+			"trace_id":  msg.TraceID,
+			"span_type": "TASK",
+			"title":     fmt.Sprintf("Task from %s via %s", msg.SenderID, msg.Channel),
+			"content":   response,
+		}
+		if err != nil {
+			span["error"] = "true"
+		}
 		go func() {
 			pubCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 			defer cancel()
-			_ = l.groupPublisher.PublishTrace(pubCtx, map[string]string{
-				"trace_id":  msg.TraceID,
-				"span_type": "TASK",
-				"title":     fmt.Sprintf("Task from %s via %s", msg.SenderID, msg.Channel),
-				"content":   response,
-			})
+			_ = l.groupPublisher.PublishTrace(pubCtx, span)
 		}()
 	}
 
@@ -1318,8 +1623,19 @@ func (l *Loop) processMessage(ctx context.Context, msg *bus.InboundMessage) (res
 
 func (l *Loop) runAgentLoop(ctx context.Context, messages []provider.Message) (string, error) {
 	toolDefs := l.buildToolDefinitions()
+	if l.activeGrounded {
+		toolDefs = nil
+	}
+	tokensUsed := 0
+	// Safe mode answers from the context as it was before any tool call.
+	baseMessages := messages
+	malformedStreak := 0
 
 	for i := 0; i < l.maxIterations; i++ {
+		if err := taskCancelled(ctx); err != nil {
+			return "", err
+		}
+
 		// QUOTA CHECK (H-014): check daily token limit before LLM call
 		if err := l.checkTokenQuota(); err != nil {
 			return err.Error(), nil
@@ -1338,14 +1654,24 @@ func (l *Loop) runAgentLoop(ctx context.Context, messages []provider.Message) (s
 		meta.SenderID = l.activeSender
 		meta.Channel = l.activeChannel
 		meta.MessageType = l.activeMessageType
-		resp, err := l.chain.Process(ctx, chatReq, meta)
+		meta.ProviderOverride = providerOverride(ctx)
+		llmCtx, llmSpan := tracing.Start(ctx, "chat "+l.model, tracing.KindClient)
+		llmSpan.Set("gen_ai.operation.name", "chat")
+		llmSpan.Set("gen_ai.request.model", l.model)
+		resp, err := l.chain.Process(llmCtx, chatReq, meta)
 		llmDuration := time.Since(llmStart)
 		if err != nil {
+			llmSpan.End(err)
 			return "", fmt.Errorf("LLM call failed: %w", err)
 		}
+		llmSpan.Set("gen_ai.usage.input_tokens", resp.Usage.PromptTokens)
+		llmSpan.Set("gen_ai.usage.output_tokens", resp.Usage.CompletionTokens)
+		llmSpan.Set("gen_ai.response.finish_reasons", resp.FinishReason)
+		llmSpan.End(nil)
 
 		// TOKEN TRACKING (H-013): record usage
 		l.trackTokens(resp.Usage)
+		tokensUsed += resp.Usage.TotalTokens
 
 		// Log middleware security events to timeline
 		l.logMiddlewareEvents(meta, i)
@@ -1375,6 +1701,7 @@ func (l *Loop) runAgentLoop(ctx context.Context, messages []provider.Message) (s
 				"total_tokens":      resp.Usage.TotalTokens,
 				"response_text":     truncateStr(resp.Content, 10240),
 				"message_count":     len(messages),
+				"w3c_trace_id":      tracing.TraceIDFor(l.activeTraceID),
 			}
 			// System prompt preview (first message if role=system)
 			if len(messages) > 0 && messages[0].Role == "system" {
@@ -1431,23 +1758,85 @@ func (l *Loop) runAgentLoop(ctx context.Context, messages []provider.Message) (s
 			}(l.activeTraceID, llmContent, llmDuration)
 		}
 
+		streamTools := make([]string, 0, len(resp.ToolCalls))
+		for _, tc := range resp.ToolCalls {
+			streamTools = append(streamTools, tc.Name)
+		}
+		EmitStreamEvent(ctx, StreamEvent{
+			Type:       StreamEventLLM,
+			Iteration:  i + 1,
+			Content:    resp.Content,
+			Tools:      streamTools,
+			Tokens:     resp.Usage.TotalTokens,
+			DurationMS: llmDuration.Milliseconds(),
+		})
+
 		// Check for tool calls
 		if len(resp.ToolCalls) == 0 {
 			// No tool calls, return the response
 			return resp.Content, nil
 		}
 
+		// SAFE MODE: repeated responses with only malformed tool calls.
+		malformed := 0
+		var malformedReason string
+		for _, tc := range resp.ToolCalls {
+			if reason := l.malformedToolCall(tc); reason != "" {
+				malformed++
+				malformedReason = reason
+			}
+		}
+		if malformed == len(resp.ToolCalls) {
+			malformedStreak++
+			if malformedStreak >= safeModeMalformedLimit {
+				return l.runSafeMode(ctx, baseMessages, safeModeMalformedCalls,
+					fmt.Sprintf("the model returned malformed tool calls %d times in a row, last: %s", malformedStreak, malformedReason))
+			}
+		} else {
+			malformedStreak = 0
+		}
+
 		// Add assistant message with tool calls
 		messages = append(messages, provider.Message{
 			Role:      "assistant",
 			Content:   resp.Content,
 			ToolCalls: resp.ToolCalls,
 		})
+		if l.progressHook != nil {
+			l.progressHook(subagentProgress{
+				Step:          strings.TrimPrefix(toolCallSummary, " → "),
+				Iterations:    i + 1,
+				MaxIterations: l.maxIterations,
+				Tokens:        tokensUsed,
+			})
+		}
+
+		// Plan-first mode: post the plan for high-tier calls and wait for a go-ahead.
+		planApproved, planBlocked := l.confirmToolPlan(ctx, resp.Content, resp.ToolCalls)
 
 		// Execute each tool call
 		for _, tc := range resp.ToolCalls {
+			if err := taskCancelled(ctx); err != nil {
+				return "", err
+			}
+			if reason, ok := planBlocked[tc.ID]; ok {
+				slog.Warn("Tool blocked by plan-first gate", "tool", tc.Name, "reason", reason)
+				messages = append(messages, provider.Message{
+					Role:       "tool",
+					Content:    fmt.Sprintf("Policy denied: %s", reason),
+					ToolCallID: tc.ID,
+				})
+				continue
+			}
+
 			// POLICY CHECK (H-011): evaluate before tool execution
-			if denied, reason := l.checkToolPolicy(ctx, tc.Name, tc.Arguments); denied {
+			l.activePlanApproved = planApproved[tc.ID]
+			denied, reason, policyErr := l.checkToolPolicy(ctx, tc.Name, tc.Arguments)
+			l.activePlanApproved = false
+			if policyErr != nil {
+				return l.runSafeMode(ctx, baseMessages, safeModePolicyError, policyErr.Error())
+			}
+			if denied {
 				slog.Warn("Tool denied by policy", "tool", tc.Name, "reason", reason)
 				messages = append(messages, provider.Message{
 					Role:       "tool",
@@ -1457,15 +1846,34 @@ func (l *Loop) runAgentLoop(ctx context.Context, messages []provider.Message) (s
 				continue
 			}
 
+			EmitStreamEvent(ctx, StreamEvent{Type: StreamEventToolCall, Iteration: i + 1, Tool: tc.Name, ToolCallID: tc.ID, Arguments: tc.Arguments})
 			toolStart := time.Now()
-			result, err := l.registry.Execute(ctx, tc.Name, tc.Arguments)
+			toolTyping := l.typingForTool()
+			toolCtx, toolSpan := tracing.Start(ctx, "execute_tool "+tc.Name, tracing.KindInternal)
+			toolSpan.Set("gen_ai.operation.name", "execute_tool")
+			toolSpan.Set("gen_ai.tool.name", tc.Name)
+			toolSpan.Set("gen_ai.tool.call.id", tc.ID)
+			result, err := l.registry.Execute(toolCtx, tc.Name, tc.Arguments)
+			toolSpan.End(err)
+			toolTyping()
+			l.toolTrace = append(l.toolTrace, tc.Name)
 			toolDuration := time.Since(toolStart)
 			if err != nil {
 				result = fmt.Sprintf("Error: %v", err)
 			}
+			EmitStreamEvent(ctx, StreamEvent{
+				Type:       StreamEventToolResult,
+				Iteration:  i + 1,
+				Tool:       tc.Name,
+				ToolCallID: tc.ID,
+				Result:     truncateStr(result, streamResultPreview),
+				Error:      err != nil || strings.HasPrefix(result, "Error"),
+				DurationMS: toolDuration.Milliseconds(),
+			})
 
 			// Log tool span to timeline for end-to-end trace visibility
 			toolContent := fmt.Sprintf("tool=%s duration=%dms result_len=%d", tc.Name, toolDuration.Milliseconds(), len(result))
+			toolEventID := fmt.Sprintf("TOOL_%s_%s_%d", l.activeTraceID, tc.Name, time.Now().UnixNano())
 			if l.timeline != nil && l.activeTraceID != "" {
 				// Build rich metadata for TOOL span
 				toolMeta := map[string]any{
@@ -1481,7 +1889,7 @@ func (l *Loop) runAgentLoop(ctx context.Context, messages []provider.Message) (s
 				toolMetaJSON, _ := json.Marshal(toolMeta)
 
 				_ = l.timeline.AddEvent(&timeline.TimelineEvent{
-					EventID:        fmt.Sprintf("TOOL_%s_%s_%d", l.activeTraceID, tc.Name, time.Now().UnixNano()),
+					EventID:        toolEventID,
 					TraceID:        l.activeTraceID,
 					Timestamp:      toolStart,
 					SenderID:       "AGENT",
@@ -1495,11 +1903,13 @@ func (l *Loop) runAgentLoop(ctx context.Context, messages []provider.Message) (s
 			}
 			// Publish tool span to group traces topic
 			if l.groupPublisher != nil && l.groupPublisher.Active() && l.activeTraceID != "" {
-				go func(traceID, toolN, content string, dur time.Duration) {
+				// Tools report most failures as "Error: ..." results rather than errors.
+				failed := err != nil || strings.HasPrefix(result, "Error")
+				go func(traceID, toolN, content string, dur time.Duration, failed bool) {
 					pubCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 					defer cancel()
 					now := time.Now()
-					_ = l.groupPublisher.PublishTrace(pubCtx, map[string]string{
+					span := map[string]string{
 						"trace_id":    traceID,
 						"span_type":   "TOOL",
 						"title":       fmt.Sprintf("Tool: %s", toolN),
@@ -1507,8 +1917,12 @@ func (l *Loop) runAgentLoop(ctx context.Context, messages []provider.Message) (s
 						"started_at":  now.Add(-dur).Format(time.RFC3339),
 						"ended_at":    now.Format(time.RFC3339),
 						"duration_ms": fmt.Sprintf("%d", dur.Milliseconds()),
-					})
-				}(l.activeTraceID, tc.Name, toolContent, toolDuration)
+					}
+					if failed {
+						span["error"] = "true"
+					}
+					_ = l.groupPublisher.PublishTrace(pubCtx, span)
+				}(l.activeTraceID, tc.Name, toolContent, toolDuration, failed)
 			}
 
 			if strings.Contains(result, "Ey, du spinnst wohl? Hä?") {
@@ -1518,16 +1932,24 @@ func (l *Loop) runAgentLoop(ctx context.Context, messages []provider.Message) (s
 			// Auto-index substantive tool results
 			if l.autoIndexer != nil && err == nil && len(result) > 200 {
 				item := memory.FormatToolResult(tc.Name, tc.Arguments, result)
+				item.Privacy = l.creationPrivacy()
+				item.Provenance = memory.Provenance{
+					TraceID: l.activeTraceID,
+					EventID: toolEventID,
+					Channel: l.activeChannel,
+					ChatID:  l.activeChatID,
+				}
 				l.autoIndexer.Enqueue(item)
 			}
 
 			// Track tool expertise
 			l.expertiseTracker.RecordToolUse(tc.Name, l.activeTaskID, toolDuration.Milliseconds(), err == nil)
 
-			// Add tool result
+			// Add tool result; oversized output is replaced by a preview and an
+			// artifact reference the model can page through with read_artifact.
 			messages = append(messages, provider.Message{
 				Role:       "tool",
-				Content:    result,
+				Content:    l.compactToolResult(tc.Name, result),
 				ToolCallID: tc.ID,
 			})
 
@@ -1538,6 +1960,26 @@ func (l *Loop) runAgentLoop(ctx context.Context, messages []provider.Message) (s
 	return "Max iterations reached. Please try a simpler request.", nil
 }
 
+// defaultToolOutputMaxChars bounds a tool result in the conversation when
+// tools.output.maxChars is unset.
+const defaultToolOutputMaxChars = 16000
+
+// compactToolResult keeps oversized tool output out of the context. The full
+// output goes to an artifact; read_artifact pages are already bounded.
+func (l *Loop) compactToolResult(toolName, result string) string {
+	maxChars := defaultToolOutputMaxChars
+	if l.cfg != nil && l.cfg.Tools.Output.MaxChars > 0 {
+		maxChars = l.cfg.Tools.Output.MaxChars
+	}
+	if toolName == "read_artifact" || len(result) <= maxChars {
+		return result
+	}
+	if l.artifacts == nil {
+		return truncateWithEllipsis(result, maxChars)
+	}
+	return l.artifacts.Compact(toolName, result, maxChars)
+}
+
 // truncateStr returns s trimmed to maxLen characters.
 func truncateStr(s string, maxLen int) string {
 	if len(s) <= maxLen {
@@ -1547,20 +1989,22 @@ func truncateStr(s string, maxLen int) string {
 }
 
 // checkToolPolicy evaluates whether a tool call should proceed.
-// Returns (denied bool, reason string).
-func (l *Loop) checkToolPolicy(ctx context.Context, toolName string, args map[string]any) (bool, string) {
+// Returns (denied bool, reason string), or an error if the policy engine
+// failed.
+func (l *Loop) checkToolPolicy(ctx context.Context, toolName string, args map[string]any) (bool, string, error) {
 	if l.policy == nil {
-		return false, ""
+		return false, "", nil
 	}
 
 	tier := tools.TierReadOnly
 	if t, ok := l.registry.Get(toolName); ok {
-		tier = tools.ToolTier(t)
+		tier = tools.ToolTierFor(t, args)
 	}
 
 	policyCtx := policy.Context{
 		Sender:      l.activeSender,
 		Channel:     l.activeChannel,
+		Account:     l.activeAccount,
 		Tool:        toolName,
 		Tier:        tier,
 		Arguments:   args,
@@ -1568,7 +2012,10 @@ func (l *Loop) checkToolPolicy(ctx context.Context, toolName string, args map[st
 		MessageType: l.activeMessageType,
 	}
 
-	decision := l.policy.Evaluate(policyCtx)
+	decision, err := l.evaluatePolicy(policyCtx)
+	if err != nil {
+		return true, "policy_error", err
+	}
 
 	// Log policy decision (H-015)
 	if l.timeline != nil {
@@ -1599,6 +2046,10 @@ func (l *Loop) checkToolPolicy(ctx context.Context, toolName string, args map[st
 
 	if !decision.Allow {
 		// Interactive approval gate for tier 2+ internal messages
+		if decision.RequiresApproval && l.activePlanApproved {
+			// The go-ahead for the posted plan already covers this call.
+			return false, "", nil
+		}
 		if decision.RequiresApproval && l.approvalMgr != nil && l.bus != nil {
 			req := &approval.ApprovalRequest{
 				Tool:      toolName,
@@ -1609,40 +2060,62 @@ func (l *Loop) checkToolPolicy(ctx context.Context, toolName string, args map[st
 				TraceID:   l.activeTraceID,
 				TaskID:    l.activeTaskID,
 			}
-			approvalID := l.approvalMgr.Create(req)
+			approvalID := l.createApproval(req)
 
 			// Format and send prompt to user
 			argsPreview := formatArgsPreview(args)
 			prompt := fmt.Sprintf("Tool \"%s\" (tier %d) requires approval.\nArgs: %s\nReply approve:%s or deny:%s",
 				toolName, tier, argsPreview, approvalID, approvalID)
+			l.publishApprovalPrompt(prompt, approvalID)
 
-			l.bus.PublishOutbound(&bus.OutboundMessage{
-				Channel:  l.activeChannel,
-				ChatID:   l.activeChatID,
-				ThreadID: l.activeThreadID,
-				TraceID:  l.activeTraceID,
-				TaskID:   l.activeTaskID,
-				Content:  prompt,
-			})
-
-			// Block with configurable timeout (default 60s)
-			timeout := l.approvalTimeout()
-			waitCtx, waitCancel := context.WithTimeout(ctx, timeout)
-			defer waitCancel()
-
-			approved, err := l.approvalMgr.Wait(waitCtx, approvalID)
+			// Block until decided or expired (configurable, default 60s)
+			approved, err := l.approvalMgr.Wait(ctx, approvalID)
 			if err != nil {
 				slog.Warn("Approval wait failed", "id", approvalID, "error", err)
-				return true, "approval_timeout"
+				return true, "approval_timeout", nil
 			}
 			if approved {
-				return false, "" // Allow execution
+				return false, "", nil // Allow execution
 			}
-			return true, "approval_denied"
+			return true, "approval_denied", nil
 		}
-		return true, decision.Reason
+		l.publishPolicyDenial(toolName, decision.Reason)
+		return true, decision.Reason, nil
 	}
-	return false, ""
+	return false, "", nil
+}
+
+// attachMedia queues a local media file for the reply to the current message.
+func (l *Loop) attachMedia(path string) {
+	l.pendingMedia = append(l.pendingMedia, path)
+}
+
+// useTemplate renders the reply to the current message with a template.
+func (l *Loop) useTemplate(name string, vars map[string]any) {
+	l.pendingTemplate, l.pendingTemplateVars = name, vars
+}
+
+// withAttachmentNote lists inbound media paths after the message text so the
+// model can hand them to tools such as analyze_image.
+func withAttachmentNote(content string, media []string) string {
+	var sb strings.Builder
+	for _, m := range media {
+		if m = strings.TrimSpace(m); m == "" {
+			continue
+		}
+		kind := "file"
+		switch strings.ToLower(filepath.Ext(m)) {
+		case ".jpg", ".jpeg", ".png", ".gif", ".webp":
+			kind = "image"
+		case ".ogg", ".m4a", ".mp3", ".wav":
+			kind = "audio"
+		}
+		fmt.Fprintf(&sb, "\n- %s: %s", kind, m)
+	}
+	if sb.Len() == 0 {
+		return content
+	}
+	return content + "\n\n[Attachments]" + sb.String()
 }
 
 // parseApprovalResponse checks if a message is an approval response.
@@ -1832,13 +2305,22 @@ func (l *Loop) currentSessionKey() string {
 	return SessionKey(channel, chatID)
 }
 
+// subagentPolicy returns the policy of a child spawned from the active
+// message. The child keeps the channel and account of the message that
+// started the chain, so their tool lists still apply.
 func (l *Loop) subagentPolicy() policy.Engine {
+	channel, account := l.activeChannel, l.activeAccount
+	if parent, ok := l.policy.(*subagentPolicy); ok {
+		channel, account = parent.channel, parent.account
+	}
 	return &subagentPolicy{
 		base:      l.policy,
 		session:   l.currentSessionKey(),
 		manager:   l.subagents,
 		allowList: append([]string{}, l.subagentTools.Allow...),
 		denyList:  append([]string{}, l.subagentTools.Deny...),
+		channel:   channel,
+		account:   account,
 	}
 }
 
@@ -1895,7 +2377,9 @@ func (l *Loop) spawnSubagentFromTool(ctx context.Context, req tools.SpawnRequest
 	)
 	parentChannel := l.activeChannel
 	parentChatID := l.activeChatID
+	parentThreadID := l.activeThreadID
 	parentTraceID := l.activeTraceID
+	childPolicy := l.subagentPolicy()
 
 	childTrace := parentTraceID
 	if childTrace == "" {
@@ -1909,7 +2393,7 @@ func (l *Loop) spawnSubagentFromTool(ctx context.Context, req tools.SpawnRequest
 		childLoop := NewLoop(LoopOptions{
 			Provider:                l.provider,
 			Timeline:                l.timeline,
-			Policy:                  l.subagentPolicy(),
+			Policy:                  childPolicy,
 			MemoryService:           l.memoryService,
 			AutoIndexer:             l.autoIndexer,
 			ExpertiseTracker:        l.expertiseTracker,
@@ -1930,7 +2414,9 @@ func (l *Loop) spawnSubagentFromTool(ctx context.Context, req tools.SpawnRequest
 			SubagentMemoryShareMode: l.subagentMemoryShareMode,
 			SubagentToolsAllow:      append([]string{}, l.subagentTools.Allow...),
 			SubagentToolsDeny:       append([]string{}, l.subagentTools.Deny...),
+			SubagentProgressSec:     l.subagentProgressSec,
 		})
+		childLoop.progressHook = l.subagentProgressHook(runID, parentChannel, parentChatID, parentThreadID, parentTraceID)
 		if l.subagentMemoryShareMode == "inherit-readonly" {
 			l.seedChildReadonlyParentContext(childLoop, parentSession, childSessionKey)
 		}
@@ -2111,6 +2597,9 @@ func (l *Loop) listSubagentsForTool() []tools.SubagentRunView {
 			StartedAt:       run.StartedAt,
 			EndedAt:         run.EndedAt,
 			Error:           run.Error,
+			CurrentStep:     run.CurrentStep,
+			Iterations:      run.Iterations,
+			TokensUsed:      run.TokensUsed,
 		})
 	}
 	return out
@@ -2666,6 +3155,15 @@ type subagentPolicy struct {
 	manager   *subagentManager
 	allowList []string
 	denyList  []string
+	// channel and account are those of the message that spawned the
+	// chain; the child itself runs on the subagent channel.
+	channel string
+	account string
+}
+
+// toolListEngine is a policy engine with per-channel tool lists.
+type toolListEngine interface {
+	ToolListDenial(ctx policy.Context) string
 }
 
 type subagentToolPolicy struct {
@@ -2684,6 +3182,16 @@ func (p *subagentPolicy) Evaluate(ctx policy.Context) policy.Decision {
 		}
 	}
 
+	if reason := p.ToolListDenial(ctx); reason != "" {
+		return policy.Decision{
+			Allow:   false,
+			Reason:  reason,
+			Tier:    ctx.Tier,
+			Ts:      time.Now(),
+			TraceID: ctx.TraceID,
+		}
+	}
+
 	if ctx.Tool == "sessions_spawn" && p.manager != nil {
 		depth := p.manager.currentDepth(p.session)
 		if depth >= p.manager.limits.MaxSpawnDepth {
@@ -2709,6 +3217,17 @@ func (p *subagentPolicy) Evaluate(ctx policy.Context) policy.Decision {
 	}
 }
 
+// ToolListDenial evaluates the tool lists of the base policy against the
+// channel and account that spawned the chain.
+func (p *subagentPolicy) ToolListDenial(ctx policy.Context) string {
+	base, ok := p.base.(toolListEngine)
+	if !ok {
+		return ""
+	}
+	ctx.Channel, ctx.Account = p.channel, p.account
+	return base.ToolListDenial(ctx)
+}
+
 func toolDeniedByPolicy(tool string, deny, allow []string) bool {
 	name := strings.TrimSpace(tool)
 	if name == "" {
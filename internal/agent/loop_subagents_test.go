@@ -170,6 +170,61 @@ func TestLoopSubagentPolicy_AllowDenyLists(t *testing.T) {
 	}
 }
 
+func TestLoopSpawnSubagentFromTool_KeepsParentToolLists(t *testing.T) {
+	tl := newTestTimeline(t)
+	mock := &mockProvider{
+		responses: []provider.ChatResponse{
+			{ToolCalls: []provider.ToolCall{{ID: "call_read", Name: "read_file", Arguments: map[string]any{"path": "notes.txt"}}}},
+			{Content: "child done"},
+		},
+	}
+	engine := policy.NewDefaultEngine()
+	engine.ToolLists = map[string]policy.ToolListPolicy{
+		"slack:ops": {Deny: map[string]bool{"read_file": true}},
+	}
+	loop := NewLoop(LoopOptions{
+		Provider:              mock,
+		Timeline:              tl,
+		Policy:                engine,
+		Workspace:             t.TempDir(),
+		WorkRepo:              t.TempDir(),
+		Model:                 "mock-model",
+		MaxIterations:         3,
+		MaxSubagentSpawnDepth: 1,
+		MaxSubagentChildren:   2,
+	})
+	loop.activeChannel = "slack"
+	loop.activeAccount = "ops"
+	loop.activeChatID = "C1"
+	loop.activeTraceID = "trace-parent"
+
+	res, err := loop.spawnSubagentFromTool(context.Background(), tools.SpawnRequest{Task: "read the notes"})
+	if err != nil {
+		t.Fatalf("spawn err: %v", err)
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if list := loop.listSubagentsForTool(); len(list) == 1 && list[0].Status == "completed" {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	decisions, err := tl.ListPolicyDecisions("trace-parent:" + res.RunID)
+	if err != nil {
+		t.Fatalf("list decisions: %v", err)
+	}
+	for _, d := range decisions {
+		if d.Tool == "read_file" {
+			if d.Allowed || d.Reason != "tool_denied_for_channel: slack:ops" {
+				t.Fatalf("expected the parent account's tool list to deny read_file, got %+v", d)
+			}
+			return
+		}
+	}
+	t.Fatalf("expected a policy decision for read_file, got %+v", decisions)
+}
+
 func TestLoopSubagentListAndKill(t *testing.T) {
 	loop := NewLoop(LoopOptions{
 		Workspace:             t.TempDir(),
@@ -977,3 +1032,57 @@ func TestLoopNestedAnnounceDeferredRetry_RoutesToRootRequester(t *testing.T) {
 		t.Fatal("expected deferred nested announce to be delivered")
 	}
 }
+
+func TestLoopSubagentProgressHookThrottlesUpdates(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	outbound := make(chan *bus.OutboundMessage, 8)
+	msgBus.Subscribe("slack", func(msg *bus.OutboundMessage) {
+		outbound <- msg
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = msgBus.DispatchOutbound(ctx) }()
+
+	loop := NewLoop(LoopOptions{
+		Bus:                 msgBus,
+		Workspace:           t.TempDir(),
+		WorkRepo:            t.TempDir(),
+		SubagentProgressSec: 1,
+	})
+	run := loop.subagents.register("slack:C1", "slack:C1", "slack", "C1", "trace-parent", "work", "researcher", "", "", "", "keep", 1, func() {})
+	loop.subagents.markRunning(run.RunID)
+	hook := loop.subagentProgressHook(run.RunID, "slack", "C1", "1700000000.0001", "trace-parent")
+
+	hook(subagentProgress{Step: "tools: web_search", Iterations: 1, MaxIterations: 10, Tokens: 120})
+	select {
+	case msg := <-outbound:
+		t.Fatalf("expected first update to be throttled, got %q", msg.Content)
+	case <-time.After(100 * time.Millisecond):
+	}
+	got, ok := loop.subagents.getRun(run.RunID)
+	if !ok || got.Iterations != 1 || got.TokensUsed != 120 || got.CurrentStep != "tools: web_search" {
+		t.Fatalf("expected progress recorded on run, got %+v", got)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+	hook(subagentProgress{Step: "tools: read_file", Iterations: 2, MaxIterations: 10, Tokens: 300})
+	select {
+	case msg := <-outbound:
+		if msg.ChatID != "C1" || msg.ThreadID != "1700000000.0001" || msg.TraceID != "trace-parent" {
+			t.Fatalf("unexpected progress route: %+v", msg)
+		}
+		want := "⏳ Subagent researcher: tools: read_file (iteration 2/10, 300 tokens)"
+		if msg.Content != want {
+			t.Fatalf("progress content = %q, want %q", msg.Content, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected progress outbound message")
+	}
+
+	hook(subagentProgress{Step: "tools: write_file", Iterations: 3, MaxIterations: 10, Tokens: 400})
+	select {
+	case msg := <-outbound:
+		t.Fatalf("expected update within interval to be throttled, got %q", msg.Content)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
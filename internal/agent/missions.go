@@ -0,0 +1,116 @@
+package agent
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// missionSettingKey is the timeline setting holding the active mission of a
+// chat.
+func missionSettingKey(channel, chatID string) string {
+	return fmt.Sprintf("mission:%s:%s", channel, chatID)
+}
+
+// activeMission returns the mission selected for a chat, or "".
+func (l *Loop) activeMission(channel, chatID string) string {
+	if l.timeline == nil {
+		return ""
+	}
+	v, err := l.timeline.GetSetting(missionSettingKey(channel, chatID))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(v)
+}
+
+// assignTaskToMission files a new task under the chat's active mission.
+func (l *Loop) assignTaskToMission(msg *bus.InboundMessage, taskID string) {
+	mission := l.activeMission(msg.Channel, msg.ChatID)
+	if mission == "" || taskID == "" {
+		return
+	}
+	if err := l.timeline.AssignToMission(mission, timeline.MissionKindTask, taskID); err != nil {
+		slog.Warn("Failed to assign task to mission", "mission", mission, "task_id", taskID, "error", err)
+	}
+}
+
+// handleMissionCommand answers the mission chat commands:
+//
+//	missions              list missions
+//	mission               show the chat's active mission
+//	mission use <name>    switch the chat's active mission
+//	mission clear         stop filing tasks under a mission
+func (l *Loop) handleMissionCommand(msg *bus.InboundMessage) (string, bool) {
+	if l.timeline == nil {
+		return "", false
+	}
+	fields := strings.Fields(strings.TrimSpace(msg.Content))
+	if len(fields) == 0 {
+		return "", false
+	}
+	switch {
+	case len(fields) == 1 && strings.EqualFold(fields[0], "missions"):
+		return l.listMissionsText(msg.Channel, msg.ChatID), true
+	case len(fields) == 1 && strings.EqualFold(fields[0], "mission"):
+		if name := l.activeMission(msg.Channel, msg.ChatID); name != "" {
+			return fmt.Sprintf("Active mission: %s", name), true
+		}
+		return "No active mission. Switch with: mission use <name>", true
+	case len(fields) == 2 && strings.EqualFold(fields[0], "mission") && strings.EqualFold(fields[1], "clear"):
+		if err := l.timeline.SetSetting(missionSettingKey(msg.Channel, msg.ChatID), ""); err != nil {
+			return fmt.Sprintf("Mission: update failed: %v", err), true
+		}
+		return "Active mission cleared.", true
+	case len(fields) == 3 && strings.EqualFold(fields[0], "mission") && strings.EqualFold(fields[1], "use"):
+		return l.useMissionText(msg.Channel, msg.ChatID, fields[2]), true
+	default:
+		return "", false
+	}
+}
+
+func (l *Loop) listMissionsText(channel, chatID string) string {
+	missions, err := l.timeline.ListMissions()
+	if err != nil {
+		return fmt.Sprintf("Missions: lookup failed: %v", err)
+	}
+	if len(missions) == 0 {
+		return "No missions defined."
+	}
+	active := l.activeMission(channel, chatID)
+	var b strings.Builder
+	b.WriteString("Missions:\n")
+	for _, m := range missions {
+		marker := "-"
+		if m.Name == active {
+			marker = "*"
+		}
+		fmt.Fprintf(&b, "%s %s [%s]", marker, m.Name, m.Status)
+		if m.Description != "" {
+			fmt.Fprintf(&b, " %s", m.Description)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("Switch with: mission use <name>")
+	return b.String()
+}
+
+func (l *Loop) useMissionText(channel, chatID, name string) string {
+	m, err := l.timeline.GetMission(name)
+	if err != nil {
+		return fmt.Sprintf("Mission: lookup failed: %v", err)
+	}
+	if m == nil {
+		return fmt.Sprintf("Mission %q not found.", name)
+	}
+	if m.Status == timeline.MissionStatusArchived {
+		return fmt.Sprintf("Mission %q is archived.", name)
+	}
+	if err := l.timeline.SetSetting(missionSettingKey(channel, chatID), m.Name); err != nil {
+		return fmt.Sprintf("Mission: update failed: %v", err)
+	}
+	return fmt.Sprintf("Active mission: %s. New tasks in this chat are filed under it.", m.Name)
+}
@@ -0,0 +1,56 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestMissionChatCommands(t *testing.T) {
+	tl := newTestTimeline(t)
+	loop := NewLoop(LoopOptions{Timeline: tl, Workspace: t.TempDir(), WorkRepo: t.TempDir()})
+	_ = tl.UpsertMission(&timeline.MissionRecord{Name: "launch", Description: "v2 launch"})
+	_ = tl.UpsertMission(&timeline.MissionRecord{Name: "old", Status: timeline.MissionStatusArchived})
+
+	msg := &bus.InboundMessage{Channel: "slack", ChatID: "C1", Content: "mission"}
+	if out, ok := loop.handleMissionCommand(msg); !ok || !strings.Contains(out, "No active mission") {
+		t.Fatalf("unexpected reply: ok=%v %q", ok, out)
+	}
+	msg.Content = "mission use nope"
+	if out, _ := loop.handleMissionCommand(msg); !strings.Contains(out, "not found") {
+		t.Fatalf("expected unknown mission error, got %q", out)
+	}
+	msg.Content = "mission use old"
+	if out, _ := loop.handleMissionCommand(msg); !strings.Contains(out, "archived") {
+		t.Fatalf("expected archived mission error, got %q", out)
+	}
+	msg.Content = "Mission use launch"
+	if out, _ := loop.handleMissionCommand(msg); !strings.Contains(out, "Active mission: launch") {
+		t.Fatalf("unexpected switch reply: %q", out)
+	}
+	if got := loop.activeMission("slack", "C2"); got != "" {
+		t.Fatalf("mission must be per chat, got %q for C2", got)
+	}
+	msg.Content = "missions"
+	if out, _ := loop.handleMissionCommand(msg); !strings.Contains(out, "* launch [active] v2 launch") {
+		t.Fatalf("unexpected list: %q", out)
+	}
+
+	task, _ := tl.CreateTask(&timeline.AgentTask{Channel: "slack", ChatID: "C1"})
+	loop.assignTaskToMission(msg, task.TaskID)
+	if tasks, _ := tl.ListMissionAssignments("launch", timeline.MissionKindTask); len(tasks) != 1 || tasks[0].Ref != task.TaskID {
+		t.Fatalf("expected task filed under launch, got %+v", tasks)
+	}
+
+	msg.Content = "mission clear"
+	loop.handleMissionCommand(msg)
+	if got := loop.activeMission("slack", "C1"); got != "" {
+		t.Fatalf("expected mission cleared, got %q", got)
+	}
+	msg.Content = "what is our mission statement?"
+	if _, ok := loop.handleMissionCommand(msg); ok {
+		t.Fatal("free text must not be treated as a command")
+	}
+}
@@ -0,0 +1,311 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/metrics"
+	"github.com/KafClaw/KafClaw/internal/msgtemplate"
+	"github.com/KafClaw/KafClaw/internal/secrets"
+)
+
+var outputHookActionsTotal = metrics.NewCounter("kafclaw_output_hook_actions_total", "Agent replies changed by output hooks, by hook and action (modified, blocked, error).", "hook", "action")
+
+// defaultBlockedReply replaces a reply blocked by a hook without its own text.
+const defaultBlockedReply = "[Response withheld by output policy]"
+
+// defaultOutputWebhookTimeout bounds a webhook hook call when timeoutMs is unset.
+const defaultOutputWebhookTimeout = 5 * time.Second
+
+// outputTarget is where a reply is going.
+type outputTarget struct {
+	Channel string `json:"channel"`
+	ChatID  string `json:"chat_id"`
+	TraceID string `json:"trace_id"`
+}
+
+// outputHook post-processes an agent reply. It returns the new content, or
+// blocked=true when the reply must not be delivered.
+type outputHook interface {
+	name() string
+	apply(ctx context.Context, t outputTarget, content string) (out string, blocked bool)
+}
+
+// outputHooks holds the hook chains by channel.
+type outputHooks struct {
+	chains map[string][]chainedHook
+}
+
+// chainedHook is a hook with the reply sent instead of one it blocks.
+type chainedHook struct {
+	outputHook
+	blockText string
+}
+
+// newOutputHooks builds the hook chains of cfg. An invalid hook is reported
+// in the returned error and fails closed: it blocks every reply of its
+// channel until the config is fixed.
+func newOutputHooks(cfg config.OutputHooksConfig) (*outputHooks, error) {
+	h := &outputHooks{chains: map[string][]chainedHook{}}
+	var errs []error
+	for channel, chain := range cfg.Channels {
+		channel = strings.ToLower(strings.TrimSpace(channel))
+		for i, hc := range chain {
+			hook, err := newOutputHook(hc)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("output hook %s[%d]: %w", channel, i, err))
+				hook = &invalidHook{hookName: fmt.Sprintf("%s[%d]", channel, i)}
+			}
+			h.chains[channel] = append(h.chains[channel], chainedHook{outputHook: hook, blockText: hc.Text})
+		}
+	}
+	return h, errors.Join(errs...)
+}
+
+func newOutputHook(hc config.OutputHookConfig) (outputHook, error) {
+	kind := strings.ToLower(strings.TrimSpace(hc.Type))
+	name := strings.TrimSpace(hc.Name)
+	if name == "" {
+		name = kind
+	}
+	switch kind {
+	case "redact", "block":
+		if hc.Pattern == "" {
+			return nil, fmt.Errorf("%s needs a pattern", kind)
+		}
+		re, err := regexp.Compile(hc.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		if kind == "block" {
+			return &blockHook{hookName: name, re: re}, nil
+		}
+		replacement := hc.Replacement
+		if replacement == "" {
+			replacement = "[redacted]"
+		}
+		return &redactHook{hookName: name, re: re, replacement: replacement}, nil
+	case "footer":
+		if strings.TrimSpace(hc.Text) == "" {
+			return nil, errors.New("footer needs a text")
+		}
+		return &footerHook{hookName: name, text: strings.TrimSpace(hc.Text)}, nil
+	case "webhook":
+		if !strings.HasPrefix(hc.URL, "http://") && !strings.HasPrefix(hc.URL, "https://") {
+			return nil, fmt.Errorf("webhook needs an http(s) url, got %q", hc.URL)
+		}
+		timeout := time.Duration(hc.TimeoutMs) * time.Millisecond
+		if timeout <= 0 {
+			timeout = defaultOutputWebhookTimeout
+		}
+		return &webhookHook{
+			hookName: name,
+			url:      hc.URL,
+			tokenRef: hc.TokenRef,
+			failOpen: hc.FailOpen,
+			client:   &http.Client{Timeout: timeout},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown type %q", hc.Type)
+	}
+}
+
+// run passes content through the "*" chain, then the chain of t's channel.
+// A blocked reply comes back as the blocking hook's text.
+func (h *outputHooks) run(ctx context.Context, t outputTarget, content string) (string, bool) {
+	if h == nil || content == "" {
+		return content, false
+	}
+	chain := h.chains["*"]
+	if channel := strings.ToLower(t.Channel); channel != "*" {
+		chain = append(chain[:len(chain):len(chain)], h.chains[channel]...)
+	}
+	for _, hook := range chain {
+		out, blocked := hook.apply(ctx, t, content)
+		if blocked {
+			outputHookActionsTotal.Inc(hook.name(), "blocked")
+			slog.Info("Reply blocked by output hook", "hook", hook.name(), "channel", t.Channel, "trace_id", t.TraceID)
+			if hook.blockText != "" {
+				return hook.blockText, true
+			}
+			return defaultBlockedReply, true
+		}
+		if out != content {
+			outputHookActionsTotal.Inc(hook.name(), "modified")
+			content = out
+		}
+	}
+	return content, false
+}
+
+// filterOutbound is the bus outbound filter running the output hooks on
+// every message the agent sends, whichever path published it. Templates
+// without a card are checked as their plain-text rendering; a message the
+// hooks change is sent as plain text, and a blocked one loses its media.
+func (l *Loop) filterOutbound(ctx context.Context, msg *bus.OutboundMessage) *bus.OutboundMessage {
+	text := msg.Content
+	if msg.Template != "" && msg.Card == nil {
+		tpl, err := msgtemplate.NewRegistry(l.workspace).Get(msg.Template)
+		if err == nil {
+			tpl, err = tpl.Render(msg.TemplateVars, false)
+		}
+		if err != nil {
+			slog.Warn("Output hooks dropped unrenderable template", "template", msg.Template, "channel", msg.Channel, "error", err)
+			return nil
+		}
+		text = tpl.PlainText(msg.Content)
+	}
+	out, blocked := l.outputHooks.run(ctx, outputTarget{Channel: msg.Channel, ChatID: msg.ChatID, TraceID: msg.TraceID}, text)
+	if out == text {
+		return msg
+	}
+	filtered := *msg
+	filtered.Content = out
+	filtered.Card, filtered.Template, filtered.TemplateVars = nil, "", nil
+	if blocked {
+		filtered.MediaURLs = nil
+	}
+	return &filtered
+}
+
+// invalidHook stands in for a hook whose config is invalid and blocks
+// every reply.
+type invalidHook struct {
+	hookName string
+}
+
+func (h *invalidHook) name() string { return h.hookName }
+
+func (h *invalidHook) apply(_ context.Context, _ outputTarget, content string) (string, bool) {
+	return content, true
+}
+
+// redactHook replaces every match of a pattern.
+type redactHook struct {
+	hookName    string
+	re          *regexp.Regexp
+	replacement string
+}
+
+func (h *redactHook) name() string { return h.hookName }
+
+func (h *redactHook) apply(_ context.Context, _ outputTarget, content string) (string, bool) {
+	return h.re.ReplaceAllString(content, h.replacement), false
+}
+
+// blockHook withholds replies matching a pattern.
+type blockHook struct {
+	hookName string
+	re       *regexp.Regexp
+}
+
+func (h *blockHook) name() string { return h.hookName }
+
+func (h *blockHook) apply(_ context.Context, _ outputTarget, content string) (string, bool) {
+	return content, h.re.MatchString(content)
+}
+
+// footerHook appends a fixed text, such as a disclaimer, unless the reply
+// already ends with it.
+type footerHook struct {
+	hookName string
+	text     string
+}
+
+func (h *footerHook) name() string { return h.hookName }
+
+func (h *footerHook) apply(_ context.Context, _ outputTarget, content string) (string, bool) {
+	trimmed := strings.TrimRight(content, " \t\n")
+	if strings.HasSuffix(trimmed, h.text) {
+		return content, false
+	}
+	return trimmed + "\n\n" + h.text, false
+}
+
+// webhookHook hands the reply to an external filter. The webhook receives
+// {"channel", "chat_id", "trace_id", "content"} and answers with
+// {"content": "...", "blocked": false}; a missing content keeps the reply.
+type webhookHook struct {
+	hookName string
+	url      string
+	tokenRef string
+	failOpen bool
+	client   *http.Client
+}
+
+type webhookHookResponse struct {
+	Content *string `json:"content"`
+	Blocked bool    `json:"blocked"`
+	Reason  string  `json:"reason"`
+}
+
+func (h *webhookHook) name() string { return h.hookName }
+
+func (h *webhookHook) apply(ctx context.Context, t outputTarget, content string) (string, bool) {
+	res, err := h.call(ctx, t, content)
+	if err != nil {
+		outputHookActionsTotal.Inc(h.hookName, "error")
+		slog.Warn("Output webhook failed", "hook", h.hookName, "fail_open", h.failOpen, "error", err)
+		return content, !h.failOpen
+	}
+	if res.Blocked {
+		if res.Reason != "" {
+			slog.Info("Output webhook blocked reply", "hook", h.hookName, "reason", res.Reason)
+		}
+		return content, true
+	}
+	if res.Content == nil {
+		return content, false
+	}
+	return *res.Content, false
+}
+
+func (h *webhookHook) call(ctx context.Context, t outputTarget, content string) (*webhookHookResponse, error) {
+	body, err := json.Marshal(struct {
+		outputTarget
+		Content string `json:"content"`
+	}{t, content})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.tokenRef != "" {
+		token, err := secrets.Resolve(h.tokenRef)
+		if err != nil {
+			return nil, fmt.Errorf("token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	var res webhookHookResponse
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	return &res, nil
+}
@@ -0,0 +1,203 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/policy"
+	"github.com/KafClaw/KafClaw/internal/provider"
+)
+
+func TestOutputHookChains(t *testing.T) {
+	hooks, err := newOutputHooks(config.OutputHooksConfig{Channels: map[string][]config.OutputHookConfig{
+		"*": {
+			{Type: "redact", Pattern: `\b[\w-]+\.corp\.internal\b`, Replacement: "[host]"},
+		},
+		"Slack": {
+			{Type: "redact", Pattern: `\b[\w-]+\.corp\.internal\b`, Replacement: "[host]"},
+			{Type: "block", Pattern: `(?i)confidential`, Text: "This reply was withheld."},
+			{Type: "footer", Text: "_Not financial advice._"},
+		},
+		"whatsapp": {{Type: "nope"}, {Type: "redact", Pattern: "("}},
+	}})
+	if err == nil || !strings.Contains(err.Error(), `unknown type "nope"`) || !strings.Contains(err.Error(), "invalid pattern") {
+		t.Fatalf("expected errors for invalid hooks, got %v", err)
+	}
+	ctx := context.Background()
+
+	// The "*" chain runs first, so the slack redaction finds nothing left.
+	got, _ := hooks.run(ctx, outputTarget{Channel: "slack"}, "Deployed to db-1.corp.internal.")
+	if got != "Deployed to [host].\n\n_Not financial advice._" {
+		t.Fatalf("slack chain: %q", got)
+	}
+	if again, _ := hooks.run(ctx, outputTarget{Channel: "slack"}, got); again != got {
+		t.Fatalf("footer must not be appended twice: %q", again)
+	}
+	if got, blocked := hooks.run(ctx, outputTarget{Channel: "slack"}, "The Confidential numbers"); !blocked || got != "This reply was withheld." {
+		t.Fatalf("blocked reply: %q %v", got, blocked)
+	}
+	if got, blocked := hooks.run(ctx, outputTarget{Channel: "telegram"}, "see web.corp.internal"); blocked || got != "see [host]" {
+		t.Fatalf("wildcard chain: %q", got)
+	}
+	// Invalid hooks fail closed.
+	if got, blocked := hooks.run(ctx, outputTarget{Channel: "whatsapp"}, "web.corp.internal"); !blocked || got != defaultBlockedReply {
+		t.Fatalf("channel with invalid hooks: %q %v", got, blocked)
+	}
+}
+
+func TestOutputHooksWildcardAlwaysRuns(t *testing.T) {
+	hooks, err := newOutputHooks(config.OutputHooksConfig{Channels: map[string][]config.OutputHookConfig{
+		"*":     {{Type: "block", Pattern: `(?i)password`}},
+		"slack": {{Type: "footer", Text: "-- bot"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if got, blocked := hooks.run(ctx, outputTarget{Channel: "slack"}, "the password is x"); !blocked || got != defaultBlockedReply {
+		t.Fatalf("the * chain must run before the channel chain: %q", got)
+	}
+	if got, _ := hooks.run(ctx, outputTarget{Channel: "slack"}, "hi"); got != "hi\n\n-- bot" {
+		t.Fatalf("channel chain: %q", got)
+	}
+}
+
+func TestOutputWebhookHook(t *testing.T) {
+	t.Setenv("OUTPUT_HOOK_TOKEN", "s3cret")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			Channel string `json:"channel"`
+			ChatID  string `json:"chat_id"`
+			Content string `json:"content"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		switch {
+		case req.Content == "fail":
+			w.WriteHeader(http.StatusBadGateway)
+		case strings.Contains(req.Content, "secret plan"):
+			_, _ = w.Write([]byte(`{"blocked":true,"reason":"policy 7"}`))
+		case req.ChatID == "keep":
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			_, _ = w.Write([]byte(`{"content":"` + strings.ToUpper(req.Content) + ` [` + req.Channel + `]"}`))
+		}
+	}))
+	defer srv.Close()
+
+	build := func(failOpen bool) *outputHooks {
+		hooks, err := newOutputHooks(config.OutputHooksConfig{Channels: map[string][]config.OutputHookConfig{
+			"*": {{Type: "webhook", URL: srv.URL, TokenRef: "env:OUTPUT_HOOK_TOKEN", TimeoutMs: 2000, FailOpen: failOpen}},
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hooks
+	}
+	ctx := context.Background()
+	closed, open := build(false), build(true)
+	if got, _ := closed.run(ctx, outputTarget{Channel: "slack", ChatID: "c1"}, "hello"); got != "HELLO [slack]" {
+		t.Fatalf("rewritten reply: %q", got)
+	}
+	if got, _ := closed.run(ctx, outputTarget{Channel: "slack", ChatID: "keep"}, "hello"); got != "hello" {
+		t.Fatalf("reply without content must be kept: %q", got)
+	}
+	if got, _ := closed.run(ctx, outputTarget{Channel: "slack"}, "the secret plan"); got != defaultBlockedReply {
+		t.Fatalf("blocked reply: %q", got)
+	}
+	if got, _ := closed.run(ctx, outputTarget{Channel: "slack"}, "fail"); got != defaultBlockedReply {
+		t.Fatalf("failing webhook must block by default: %q", got)
+	}
+	if got, _ := open.run(ctx, outputTarget{Channel: "slack"}, "fail"); got != "fail" {
+		t.Fatalf("failOpen webhook must deliver the reply: %q", got)
+	}
+}
+
+func TestLoopAppliesOutputHooks(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.OutputHooks.Channels = map[string][]config.OutputHookConfig{
+		"cli": {{Type: "footer", Text: "-- reviewed"}},
+	}
+	tmpDir := t.TempDir()
+	msgBus := bus.NewMessageBus()
+	loop := NewLoop(LoopOptions{
+		Bus:           msgBus,
+		Provider:      &mockProvider{responses: []provider.ChatResponse{{Content: "All done."}}},
+		Timeline:      newTestTimeline(t),
+		Policy:        policy.NewDefaultEngine(),
+		Config:        cfg,
+		Workspace:     tmpDir,
+		WorkRepo:      tmpDir,
+		Model:         "mock-model",
+		MaxIterations: 3,
+	})
+	out := make(chan *bus.OutboundMessage, 4)
+	msgBus.Subscribe("cli", func(msg *bus.OutboundMessage) { out <- msg })
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go func() { _ = msgBus.DispatchOutbound(ctx) }()
+	go loop.Run(ctx)
+
+	msgBus.PublishInbound(&bus.InboundMessage{
+		Channel:        "cli",
+		SenderID:       "owner",
+		ChatID:         "owner",
+		TraceID:        "trace-hooks",
+		IdempotencyKey: "cli:trace-hooks",
+		Content:        "status?",
+		Timestamp:      time.Now(),
+		Metadata:       map[string]any{bus.MetaKeyMessageType: bus.MessageTypeInternal},
+	})
+	select {
+	case msg := <-out:
+		if msg.Content != "All done.\n\n-- reviewed" {
+			t.Fatalf("reply: %q", msg.Content)
+		}
+	case <-ctx.Done():
+		t.Fatal("no reply delivered")
+	}
+
+	// Messages published outside the reply path, such as approval prompts,
+	// are filtered too and sent as plain text.
+	msgBus.PublishOutbound(&bus.OutboundMessage{
+		Channel: "cli",
+		ChatID:  "owner",
+		Content: "Approve exec?",
+		Card:    map[string]any{"type": "AdaptiveCard"},
+	})
+	select {
+	case msg := <-out:
+		if msg.Content != "Approve exec?\n\n-- reviewed" || msg.Card != nil {
+			t.Fatalf("published message: %+v", msg)
+		}
+	case <-ctx.Done():
+		t.Fatal("published message not delivered")
+	}
+}
+
+func TestFilterOutboundDropsUnrenderableTemplate(t *testing.T) {
+	hooks, err := newOutputHooks(config.OutputHooksConfig{Channels: map[string][]config.OutputHookConfig{
+		"*": {{Type: "footer", Text: "-- bot"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	loop := &Loop{workspace: t.TempDir(), outputHooks: hooks}
+	if got := loop.filterOutbound(context.Background(), &bus.OutboundMessage{Channel: "slack", Template: "missing"}); got != nil {
+		t.Fatalf("unrenderable template must be dropped, got %+v", got)
+	}
+	poll := &bus.OutboundMessage{Channel: "slack", PollQuestion: "Lunch?"}
+	if got := loop.filterOutbound(context.Background(), poll); got != poll {
+		t.Fatalf("message without text must pass unchanged, got %+v", got)
+	}
+}
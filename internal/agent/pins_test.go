@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/provider"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestPinsChatCommands(t *testing.T) {
+	tl := newTestTimeline(t)
+	loop := NewLoop(LoopOptions{Timeline: tl, Workspace: t.TempDir(), WorkRepo: t.TempDir()})
+	run := func(content string) string {
+		t.Helper()
+		out, ok := loop.handlePinsCommand(&bus.InboundMessage{Channel: "slack", ChatID: "C1", SenderID: "U1", Content: content})
+		if !ok {
+			t.Fatalf("%q: expected command to be handled", content)
+		}
+		return out
+	}
+
+	if out := run("/pins"); !strings.Contains(out, "No pinned notes") {
+		t.Fatalf("unexpected empty list: %q", out)
+	}
+	if out := run("/pin Our prod cluster is eu-central-1"); !strings.HasPrefix(out, "Pinned #") {
+		t.Fatalf("unexpected pin reply: %q", out)
+	}
+	pins, _ := tl.ListChatPins("slack", "C1")
+	if len(pins) != 1 || pins[0].Content != "Our prod cluster is eu-central-1" || pins[0].CreatedBy != "U1" {
+		t.Fatalf("unexpected stored pins: %+v", pins)
+	}
+	if out := run("/pins"); !strings.Contains(out, "eu-central-1") || !strings.Contains(out, "of 2000 characters used") {
+		t.Fatalf("unexpected list: %q", out)
+	}
+	if out := run("/pin " + strings.Repeat("x", timeline.ChatPinBudgetChars)); !strings.Contains(out, "exceed") {
+		t.Fatalf("expected budget error, got %q", out)
+	}
+	if out := run("/pins remove 999"); !strings.Contains(out, "not found") {
+		t.Fatalf("expected unknown pin, got %q", out)
+	}
+	if out := run("/unpin #" + strconv.FormatInt(pins[0].ID, 10)); !strings.HasPrefix(out, "Removed pin") {
+		t.Fatalf("unexpected remove reply: %q", out)
+	}
+	if _, ok := loop.handlePinsCommand(&bus.InboundMessage{Channel: "slack", ChatID: "C1", Content: "pinning strategies for npm?"}); ok {
+		t.Fatal("free text must not be treated as a command")
+	}
+}
+
+func TestPinnedNotesInjectedIntoPrompt(t *testing.T) {
+	tl := newTestTimeline(t)
+	prov := &promptRecordingProvider{mockProvider: mockProvider{responses: []provider.ChatResponse{{Content: "ok"}, {Content: "ok"}}}}
+	loop := NewLoop(LoopOptions{
+		Bus:           bus.NewMessageBus(),
+		Provider:      prov,
+		Timeline:      tl,
+		Workspace:     t.TempDir(),
+		WorkRepo:      t.TempDir(),
+		Model:         "mock-model",
+		MaxIterations: 2,
+	})
+	_ = tl.CreateChatPin(&timeline.ChatPinRecord{Channel: "slack", ChatID: "C1", Content: "prod cluster is eu-central-1", CreatedBy: "U1"})
+
+	if _, err := loop.ProcessDirectWithTrace(context.Background(), "which region?", "slack:C1", "trace-pins"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loop.ProcessDirectWithTrace(context.Background(), "which region?", "slack:C2", "trace-pins-2"); err != nil {
+		t.Fatal(err)
+	}
+	if len(prov.systemPrompts) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(prov.systemPrompts))
+	}
+	// Any chat member may pin, so pins are attributed user context rather
+	// than part of the system prompt.
+	if strings.Contains(prov.systemPrompts[0], "eu-central-1") {
+		t.Fatalf("pinned note must not reach the system prompt: %q", prov.systemPrompts[0])
+	}
+	if got := prov.lastMessages[0]; !strings.Contains(got, "- (pinned by U1) prod cluster is eu-central-1") || !strings.HasSuffix(got, "which region?") {
+		t.Fatalf("expected attributed pin before the user message, got %q", got)
+	}
+	if strings.Contains(prov.lastMessages[1], "Pinned notes") {
+		t.Fatal("pins must stay in their chat")
+	}
+}
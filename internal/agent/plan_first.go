@@ -0,0 +1,193 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/approval"
+	"github.com/KafClaw/KafClaw/internal/policy"
+	"github.com/KafClaw/KafClaw/internal/provider"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+	"github.com/KafClaw/KafClaw/internal/tools"
+)
+
+// plannedToolCall is one entry of a plan posted before high-tier execution.
+type plannedToolCall struct {
+	ID     string `json:"tool_call_id"`
+	Tool   string `json:"tool"`
+	Tier   int    `json:"tier"`
+	Args   string `json:"args"`
+	Effect string `json:"effect"`
+}
+
+// planFirstForChat reports whether the active chat opted into plan-first mode.
+// The per-chat setting "plan_first:<channel>:<chat_id>" wins over the global
+// "plan_first_mode" setting; both accept on/off.
+func (l *Loop) planFirstForChat() bool {
+	if l.timeline == nil {
+		return false
+	}
+	if l.activeChannel != "" && l.activeChatID != "" {
+		if v, err := l.timeline.GetSetting(fmt.Sprintf("plan_first:%s:%s", l.activeChannel, l.activeChatID)); err == nil && strings.TrimSpace(v) != "" {
+			return isSettingOn(v)
+		}
+	}
+	v, err := l.timeline.GetSetting("plan_first_mode")
+	return err == nil && isSettingOn(v)
+}
+
+func isSettingOn(v string) bool {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "on", "true", "1", "yes":
+		return true
+	}
+	return false
+}
+
+// toolCallPlan collects the calls in a batch that need a plan go-ahead: tier 2+
+// calls when the chat opted in, plus any call a policy rule marks RequiresPlan.
+// Calls the policy would refuse outright are left to the normal policy check.
+func (l *Loop) toolCallPlan(calls []provider.ToolCall) []plannedToolCall {
+	if l.policy == nil {
+		return nil
+	}
+	chatPlanFirst := l.planFirstForChat()
+	var plan []plannedToolCall
+	for _, tc := range calls {
+		tier := tools.TierReadOnly
+		effect := ""
+		if t, ok := l.registry.Get(tc.Name); ok {
+			tier = tools.ToolTierFor(t, tc.Arguments)
+			effect = t.Description()
+		}
+		decision, err := l.evaluatePolicy(policy.Context{
+			Sender:      l.activeSender,
+			Channel:     l.activeChannel,
+			Tool:        tc.Name,
+			Tier:        tier,
+			Arguments:   tc.Arguments,
+			TraceID:     l.activeTraceID,
+			MessageType: l.activeMessageType,
+		})
+		if err != nil {
+			// Left to checkToolPolicy, which switches to safe mode.
+			continue
+		}
+		if !decision.Allow && !decision.RequiresApproval {
+			continue
+		}
+		if !decision.RequiresPlan && !(chatPlanFirst && tier >= tools.TierHighRisk) {
+			continue
+		}
+		if idx := strings.IndexAny(effect, ".\n"); idx > 0 {
+			effect = effect[:idx]
+		}
+		plan = append(plan, plannedToolCall{
+			ID:     tc.ID,
+			Tool:   tc.Name,
+			Tier:   tier,
+			Args:   formatArgsPreview(tc.Arguments),
+			Effect: truncateStr(effect, 160),
+		})
+	}
+	return plan
+}
+
+// confirmToolPlan posts the intended plan for high-tier tool calls and waits
+// for a go-ahead through the approval manager, so the confirmation is audited
+// like any other approval. It returns the tool call IDs covered by an approved
+// plan and, when the plan is not approved, the IDs to block with a reason.
+func (l *Loop) confirmToolPlan(ctx context.Context, intent string, calls []provider.ToolCall) (map[string]bool, map[string]string) {
+	plan := l.toolCallPlan(calls)
+	if len(plan) == 0 {
+		return nil, nil
+	}
+	blockAll := func(reason string) map[string]string {
+		out := make(map[string]string, len(plan))
+		for _, p := range plan {
+			out[p.ID] = reason
+		}
+		return out
+	}
+	// Plan-first requires an explicit go-ahead; without an interactive channel
+	// the calls cannot proceed.
+	if l.bus == nil || l.approvalMgr == nil {
+		l.recordToolPlan("", plan, "unavailable")
+		return nil, blockAll("plan_not_confirmed")
+	}
+
+	maxTier := 0
+	for _, p := range plan {
+		if p.Tier > maxTier {
+			maxTier = p.Tier
+		}
+	}
+	approvalID := l.createApproval(&approval.ApprovalRequest{
+		Tool:      "plan_preview",
+		Tier:      maxTier,
+		Arguments: map[string]any{"plan": plan},
+		Sender:    l.activeSender,
+		Channel:   l.activeChannel,
+		TraceID:   l.activeTraceID,
+		TaskID:    l.activeTaskID,
+	})
+
+	var b strings.Builder
+	b.WriteString("Planned actions (requires approval before running):\n")
+	if intent = strings.TrimSpace(intent); intent != "" {
+		fmt.Fprintf(&b, "Intent: %s\n", truncateStr(intent, 500))
+	}
+	for i, p := range plan {
+		fmt.Fprintf(&b, "%d. %s (tier %d) args: %s\n", i+1, p.Tool, p.Tier, p.Args)
+		if p.Effect != "" {
+			fmt.Fprintf(&b, "   Effect: %s\n", p.Effect)
+		}
+	}
+	fmt.Fprintf(&b, "Reply approve:%s or deny:%s", approvalID, approvalID)
+	l.publishApprovalPrompt(b.String(), approvalID)
+
+	approved, err := l.approvalMgr.Wait(ctx, approvalID)
+	switch {
+	case err != nil:
+		slog.Warn("Plan approval wait failed", "id", approvalID, "error", err)
+		l.recordToolPlan(approvalID, plan, "timeout")
+		return nil, blockAll("plan_approval_timeout")
+	case !approved:
+		l.recordToolPlan(approvalID, plan, "denied")
+		return nil, blockAll("plan_denied")
+	}
+	l.recordToolPlan(approvalID, plan, "approved")
+	ok := make(map[string]bool, len(plan))
+	for _, p := range plan {
+		ok[p.ID] = true
+	}
+	return ok, nil
+}
+
+// recordToolPlan writes the posted plan and its outcome to the active trace.
+func (l *Loop) recordToolPlan(approvalID string, plan []plannedToolCall, decision string) {
+	if l.timeline == nil || l.activeTraceID == "" {
+		return
+	}
+	meta, _ := json.Marshal(map[string]any{
+		"approval_id": approvalID,
+		"decision":    decision,
+		"plan":        plan,
+	})
+	_ = l.timeline.AddEvent(&timeline.TimelineEvent{
+		EventID:        fmt.Sprintf("PLAN_%s_%d", l.activeTraceID, time.Now().UnixNano()),
+		TraceID:        l.activeTraceID,
+		Timestamp:      time.Now(),
+		SenderID:       "AGENT",
+		SenderName:     "PlanFirst",
+		EventType:      "SYSTEM",
+		ContentText:    fmt.Sprintf("plan: %d tool call(s) decision=%s", len(plan), decision),
+		Classification: "PLAN",
+		Authorized:     decision == "approved",
+		Metadata:       string(meta),
+	})
+}
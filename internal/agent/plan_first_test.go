@@ -0,0 +1,155 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/policy"
+	"github.com/KafClaw/KafClaw/internal/provider"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func newPlanFirstLoop(t *testing.T, tl *timeline.TimelineService, engine *policy.DefaultEngine) (*Loop, *mockProvider, *outboundCapture, context.Context) {
+	t.Helper()
+	msgBus := bus.NewMessageBus()
+	tmpDir := t.TempDir()
+	mock := &mockProvider{
+		responses: []provider.ChatResponse{
+			{
+				Content: "I will print a greeting.",
+				ToolCalls: []provider.ToolCall{{
+					ID:        "call_exec_1",
+					Name:      "exec",
+					Arguments: map[string]any{"command": "echo hello"},
+				}},
+			},
+			{Content: "Done."},
+		},
+	}
+	loop := NewLoop(LoopOptions{
+		Bus:           msgBus,
+		Provider:      mock,
+		Timeline:      tl,
+		Policy:        engine,
+		Workspace:     tmpDir,
+		WorkRepo:      tmpDir,
+		Model:         "mock-model",
+		MaxIterations: 5,
+	})
+	capture := &outboundCapture{}
+	msgBus.Subscribe("whatsapp", func(msg *bus.OutboundMessage) { capture.add(msg) })
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	go msgBus.DispatchOutbound(ctx)
+	return loop, mock, capture, ctx
+}
+
+func planFirstMessage(traceID string) *bus.InboundMessage {
+	return &bus.InboundMessage{
+		Channel:        "whatsapp",
+		SenderID:       "owner",
+		ChatID:         "owner",
+		TraceID:        traceID,
+		IdempotencyKey: "wa:" + traceID,
+		Content:        "Say hello from the shell",
+		Timestamp:      time.Now(),
+		Metadata:       map[string]any{bus.MetaKeyMessageType: bus.MessageTypeInternal},
+	}
+}
+
+func TestPlanFirstPerChatDeniedBlocksTool(t *testing.T) {
+	tl := newTestTimeline(t)
+	if err := tl.SetSetting("plan_first:whatsapp:owner", "on"); err != nil {
+		t.Fatal(err)
+	}
+	engine := policy.NewDefaultEngine()
+	engine.MaxAutoTier = 2 // exec would normally run without asking
+	loop, _, capture, ctx := newPlanFirstLoop(t, tl, engine)
+
+	done := make(chan string, 1)
+	go func() {
+		resp, _, _ := loop.processMessage(ctx, planFirstMessage("trace-plan-deny"))
+		done <- resp
+	}()
+
+	id := waitForApprovalPrompt(t, capture, 5*time.Second)
+	var prompt string
+	for _, o := range capture.snapshot() {
+		if strings.Contains(o.Content, id) {
+			prompt = o.Content
+		}
+	}
+	if !strings.Contains(prompt, "exec (tier 2)") || !strings.Contains(prompt, "echo hello") || !strings.Contains(prompt, "Intent: I will print a greeting.") {
+		t.Fatalf("plan prompt missing details: %q", prompt)
+	}
+	if err := loop.approvalMgr.Respond(id, false); err != nil {
+		t.Fatalf("respond: %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("processMessage did not complete after plan denial")
+	}
+
+	events, err := tl.GetEvents(timeline.FilterArgs{TraceID: "trace-plan-deny"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var planDenied, toolRan bool
+	for _, e := range events {
+		if e.Classification == "PLAN" && strings.Contains(e.Metadata, `"decision":"denied"`) {
+			planDenied = true
+		}
+		if e.Classification == "TOOL" {
+			toolRan = true
+		}
+	}
+	if !planDenied {
+		t.Fatal("expected PLAN event with denied decision")
+	}
+	if toolRan {
+		t.Fatal("tool must not run after the plan was denied")
+	}
+}
+
+func TestPlanFirstPolicyRuleApprovedSkipsSecondPrompt(t *testing.T) {
+	tl := newTestTimeline(t)
+	engine := policy.NewDefaultEngine()
+	engine.MaxAutoTier = 1 // exec would normally need its own approval
+	engine.PlanFirstMinTier = 2
+	loop, mock, capture, ctx := newPlanFirstLoop(t, tl, engine)
+
+	done := make(chan string, 1)
+	go func() {
+		resp, _, _ := loop.processMessage(ctx, planFirstMessage("trace-plan-ok"))
+		done <- resp
+	}()
+
+	id := waitForApprovalPrompt(t, capture, 5*time.Second)
+	if err := loop.approvalMgr.Respond(id, true); err != nil {
+		t.Fatalf("respond: %v", err)
+	}
+	select {
+	case resp := <-done:
+		if resp != "Done." {
+			t.Fatalf("unexpected response: %q", resp)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("processMessage did not complete after plan approval")
+	}
+	if mock.calls != 2 {
+		t.Fatalf("expected 2 LLM calls, got %d", mock.calls)
+	}
+	prompts := 0
+	for _, o := range capture.snapshot() {
+		if strings.Contains(o.Content, "requires approval") {
+			prompts++
+		}
+	}
+	if prompts != 1 {
+		t.Fatalf("expected a single plan prompt, got %d", prompts)
+	}
+}
@@ -0,0 +1,81 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/tools"
+)
+
+// activePolls returns the poll manager of the chat the poll tool works on.
+func (l *Loop) activePolls() (bus.PollManager, string, error) {
+	if l.activeChannel == "" || l.activeChatID == "" {
+		return nil, "", fmt.Errorf("polls unavailable outside a chat")
+	}
+	m, err := l.bus.Polls(l.activeChannel)
+	if errors.Is(err, bus.ErrNoPollManager) {
+		return nil, "", fmt.Errorf("polls unavailable on %s", l.activeChannel)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return m, l.activeChatID, nil
+}
+
+func (l *Loop) listPollsForTool(ctx context.Context) ([]tools.PollView, error) {
+	m, chatID, err := l.activePolls()
+	if err != nil {
+		return nil, err
+	}
+	polls, err := m.ListPolls(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]tools.PollView, 0, len(polls))
+	for _, p := range polls {
+		out = append(out, pollView(p))
+	}
+	return out, nil
+}
+
+func (l *Loop) pollResultsForTool(ctx context.Context, id string) (tools.PollView, error) {
+	m, chatID, err := l.activePolls()
+	if err != nil {
+		return tools.PollView{}, err
+	}
+	p, err := m.PollResults(ctx, chatID, id)
+	if err != nil {
+		return tools.PollView{}, err
+	}
+	return pollView(*p), nil
+}
+
+func (l *Loop) closePollForTool(ctx context.Context, id string) (tools.PollView, error) {
+	m, chatID, err := l.activePolls()
+	if err != nil {
+		return tools.PollView{}, err
+	}
+	p, err := m.ClosePoll(ctx, chatID, id)
+	if err != nil {
+		return tools.PollView{}, err
+	}
+	return pollView(*p), nil
+}
+
+func pollView(p bus.Poll) tools.PollView {
+	v := tools.PollView{
+		ID:        p.ID,
+		Question:  p.Question,
+		Voters:    p.Voters,
+		Closed:    p.Closed,
+		CreatedAt: p.CreatedAt,
+		ClosedAt:  p.ClosedAt,
+		Options:   make([]tools.PollOptionView, 0, len(p.Options)),
+	}
+	for _, o := range p.Options {
+		v.Options = append(v.Options, tools.PollOptionView{Label: o.Label, Votes: o.Votes, Percent: o.Percent})
+	}
+	return v
+}
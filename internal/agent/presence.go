@@ -0,0 +1,132 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+const defaultTypingRefresh = 8 * time.Second
+
+// presenceConfig returns the presence settings, falling back to the defaults
+// when the loop runs without a config.
+func (l *Loop) presenceConfig() config.PresenceConfig {
+	if l.cfg == nil {
+		return config.DefaultConfig().Channels.Presence
+	}
+	return l.cfg.Channels.Presence
+}
+
+// typingIndicator publishes typing events for one task, refreshed so the
+// indicator stays visible. It runs from start until stop.
+type typingIndicator struct {
+	ctx     context.Context
+	send    func()
+	refresh time.Duration
+	// after delays the indicator until a tool call has run this long.
+	after time.Duration
+
+	mu      sync.Mutex
+	started bool
+	stopped bool
+	done    chan struct{}
+}
+
+func (t *typingIndicator) start() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.started || t.stopped {
+		return
+	}
+	t.started = true
+	t.send()
+	go func() {
+		ticker := time.NewTicker(t.refresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-t.done:
+				return
+			case <-t.ctx.Done():
+				return
+			case <-ticker.C:
+				t.send()
+			}
+		}
+	}()
+}
+
+func (t *typingIndicator) stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.stopped {
+		t.stopped = true
+		close(t.done)
+	}
+}
+
+// startPresence marks the inbound message read and shows a typing indicator
+// in its chat until the returned stop function is called. The indicator is
+// refreshed periodically so it stays visible during long tool runs. With
+// typingAfterMs set it starts only once a tool call runs that long.
+func (l *Loop) startPresence(ctx context.Context, msg *bus.InboundMessage) (stop func()) {
+	l.typing = nil
+	if l.bus == nil || msg.SenderID == commitmentSenderID {
+		return func() {}
+	}
+	pc := l.presenceConfig()
+	if pc.ReadReceipts && strings.TrimSpace(msg.MessageID) != "" {
+		l.bus.PublishPresence(&bus.PresenceEvent{
+			Channel:    msg.Channel,
+			ChatID:     msg.ChatID,
+			ThreadID:   msg.ThreadID,
+			Kind:       bus.PresenceRead,
+			SenderID:   msg.SenderID,
+			MessageIDs: []string{msg.MessageID},
+		})
+	}
+	if !pc.Typing {
+		return func() {}
+	}
+
+	t := &typingIndicator{
+		ctx: ctx,
+		send: func() {
+			l.bus.PublishPresence(&bus.PresenceEvent{
+				Channel:  msg.Channel,
+				ChatID:   msg.ChatID,
+				ThreadID: msg.ThreadID,
+				Kind:     bus.PresenceTyping,
+			})
+		},
+		refresh: defaultTypingRefresh,
+		after:   time.Duration(pc.TypingAfterMs) * time.Millisecond,
+		done:    make(chan struct{}),
+	}
+	if pc.TypingRefreshSec > 0 {
+		t.refresh = time.Duration(pc.TypingRefreshSec) * time.Second
+	}
+	if t.after <= 0 {
+		t.start()
+	}
+	l.typing = t
+	return func() {
+		l.typing = nil
+		t.stop()
+	}
+}
+
+// typingForTool arms the delayed typing indicator for a tool call; the
+// returned function disarms it when the call returns in time.
+func (l *Loop) typingForTool() (done func()) {
+	t := l.typing
+	if t == nil || t.after <= 0 {
+		return func() {}
+	}
+	timer := time.AfterFunc(t.after, t.start)
+	return func() { timer.Stop() }
+}
@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+func TestStartPresencePublishesReadAndRefreshesTyping(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Channels.Presence.TypingRefreshSec = 1
+	msgBus := bus.NewMessageBus()
+	loop := NewLoop(LoopOptions{Bus: msgBus, Config: cfg, Workspace: t.TempDir(), WorkRepo: t.TempDir()})
+
+	events := make(chan *bus.PresenceEvent, 10)
+	msgBus.SubscribePresence("whatsapp", func(evt *bus.PresenceEvent) { events <- evt })
+
+	stop := loop.startPresence(context.Background(), &bus.InboundMessage{Channel: "whatsapp", ChatID: "c1", SenderID: "u1", MessageID: "m1"})
+	kinds := map[string]int{}
+	deadline := time.After(3 * time.Second)
+	for kinds[bus.PresenceTyping] < 2 {
+		select {
+		case evt := <-events:
+			kinds[evt.Kind]++
+			if evt.Kind == bus.PresenceRead && (len(evt.MessageIDs) != 1 || evt.MessageIDs[0] != "m1" || evt.SenderID != "u1") {
+				t.Fatalf("unexpected read receipt: %+v", evt)
+			}
+		case <-deadline:
+			t.Fatalf("expected typing refresh, got %v", kinds)
+		}
+	}
+	stop()
+	if kinds[bus.PresenceRead] != 1 {
+		t.Fatalf("expected one read receipt, got %v", kinds)
+	}
+}
+
+func TestStartPresenceDisabled(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Channels.Presence.Typing = false
+	cfg.Channels.Presence.ReadReceipts = false
+	msgBus := bus.NewMessageBus()
+	loop := NewLoop(LoopOptions{Bus: msgBus, Config: cfg, Workspace: t.TempDir(), WorkRepo: t.TempDir()})
+
+	events := make(chan *bus.PresenceEvent, 10)
+	msgBus.SubscribePresence("whatsapp", func(evt *bus.PresenceEvent) { events <- evt })
+	loop.startPresence(context.Background(), &bus.InboundMessage{Channel: "whatsapp", ChatID: "c1", MessageID: "m1"})()
+	select {
+	case evt := <-events:
+		t.Fatalf("expected no presence events, got %+v", evt)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestTypingAfterWaitsForSlowTool(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Channels.Presence.ReadReceipts = false
+	cfg.Channels.Presence.TypingAfterMs = 30
+	msgBus := bus.NewMessageBus()
+	loop := NewLoop(LoopOptions{Bus: msgBus, Config: cfg, Workspace: t.TempDir(), WorkRepo: t.TempDir()})
+
+	events := make(chan *bus.PresenceEvent, 10)
+	msgBus.SubscribePresence("slack", func(evt *bus.PresenceEvent) { events <- evt })
+	stop := loop.startPresence(context.Background(), &bus.InboundMessage{Channel: "slack", ChatID: "C1", ThreadID: "1.1", SenderID: "u1"})
+	defer stop()
+
+	loop.typingForTool()()
+	select {
+	case evt := <-events:
+		t.Fatalf("expected no typing for a fast tool, got %+v", evt)
+	case <-time.After(60 * time.Millisecond):
+	}
+
+	done := loop.typingForTool()
+	select {
+	case evt := <-events:
+		if evt.Kind != bus.PresenceTyping || evt.ThreadID != "1.1" {
+			t.Fatalf("unexpected presence event %+v", evt)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected typing once the tool ran past the threshold")
+	}
+	done()
+}
@@ -0,0 +1,63 @@
+package agent
+
+import (
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/memory"
+)
+
+// creationPrivacy is the level assigned to observations and chunks derived
+// from the active message: owner messages stay owner-only, content from
+// external senders is trusted.
+func (l *Loop) creationPrivacy() string {
+	if l.activeMessageType == bus.MessageTypeExternal {
+		return memory.PrivacyTrusted
+	}
+	return memory.PrivacyOwner
+}
+
+// allowedPrivacy is the most restricted level that may be injected for the
+// active message: owner for the owner, memory.privacy.external for external
+// senders, capped by the memory.privacy.channels entry of the channel.
+func (l *Loop) allowedPrivacy(channel string) string {
+	allowed := memory.PrivacyOwner
+	var external string
+	var channels map[string]string
+	if l.cfg != nil {
+		external = l.cfg.Memory.Privacy.External
+		channels = l.cfg.Memory.Privacy.Channels
+	}
+	if l.activeMessageType == bus.MessageTypeExternal {
+		if allowed = memory.NormalizePrivacy(external); allowed == "" {
+			allowed = memory.PrivacyTrusted
+		}
+	}
+	if limit, ok := channels[channel]; ok && !memory.PrivacyAllows(limit, allowed) {
+		allowed = memory.NormalizePrivacy(limit)
+		if allowed == "" {
+			allowed = memory.PrivacyPublic
+		}
+	}
+	return allowed
+}
+
+// visibleObservations drops observations above the allowed privacy level.
+func visibleObservations(observations []memory.Observation, allowed string) []memory.Observation {
+	out := observations[:0:0]
+	for _, obs := range observations {
+		if memory.PrivacyAllows(allowed, obs.Privacy) {
+			out = append(out, obs)
+		}
+	}
+	return out
+}
+
+// visibleChunks drops memory chunks above the allowed privacy level.
+func visibleChunks(chunks []memory.MemoryChunk, allowed string) []memory.MemoryChunk {
+	out := chunks[:0:0]
+	for _, c := range chunks {
+		if memory.PrivacyAllows(allowed, c.Privacy) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,56 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/memory"
+)
+
+func TestAllowedPrivacy(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Memory.Privacy.Channels = map[string]string{"msteams": "public"}
+	l := &Loop{cfg: cfg}
+
+	l.activeMessageType = bus.MessageTypeInternal
+	if got := l.allowedPrivacy("whatsapp"); got != memory.PrivacyOwner {
+		t.Fatalf("owner on whatsapp: got %q", got)
+	}
+	if got := l.allowedPrivacy("msteams"); got != memory.PrivacyPublic {
+		t.Fatalf("owner on msteams: got %q", got)
+	}
+	if got := l.creationPrivacy(); got != memory.PrivacyOwner {
+		t.Fatalf("owner creation: got %q", got)
+	}
+
+	l.activeMessageType = bus.MessageTypeExternal
+	if got := l.allowedPrivacy("whatsapp"); got != memory.PrivacyTrusted {
+		t.Fatalf("external on whatsapp: got %q", got)
+	}
+	if got := l.creationPrivacy(); got != memory.PrivacyTrusted {
+		t.Fatalf("external creation: got %q", got)
+	}
+}
+
+func TestVisibleObservationsAndChunks(t *testing.T) {
+	obs := []memory.Observation{
+		{Content: "owner secret", Privacy: memory.PrivacyOwner},
+		{Content: "team fact", Privacy: memory.PrivacyTrusted},
+		{Content: "public fact", Privacy: memory.PrivacyPublic},
+	}
+	if got := visibleObservations(obs, memory.PrivacyTrusted); len(got) != 2 || got[0].Content != "team fact" {
+		t.Fatalf("unexpected trusted observations: %+v", got)
+	}
+	if got := visibleObservations(obs, memory.PrivacyOwner); len(got) != 3 {
+		t.Fatalf("owner should see all observations, got %d", len(got))
+	}
+
+	chunks := []memory.MemoryChunk{
+		{Content: "conversation", Privacy: memory.PrivacyOwner},
+		{Content: "soul", Privacy: memory.PrivacyPublic},
+	}
+	if got := visibleChunks(chunks, memory.PrivacyPublic); len(got) != 1 || got[0].Content != "soul" {
+		t.Fatalf("unexpected public chunks: %+v", got)
+	}
+}
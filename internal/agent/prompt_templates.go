@@ -0,0 +1,96 @@
+package agent
+
+import (
+	"encoding/json"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// SystemPromptTemplate is the template name that replaces the built-in
+// identity section of the system prompt when it exists.
+const SystemPromptTemplate = "system"
+
+// maxTemplateDepth bounds nested {{template:...}} references.
+const maxTemplateDepth = 3
+
+// PromptTemplateStore provides named, versioned prompt templates.
+type PromptTemplateStore interface {
+	GetPromptTemplate(name string, version int) (*timeline.PromptTemplateRecord, error)
+}
+
+var (
+	templateNameRe = regexp.MustCompile(`^[A-Za-z0-9_.\-]+$`)
+	templateRefRe  = regexp.MustCompile(`\{\{\s*template:([A-Za-z0-9_.\-]+)(?:@(\d+))?\s*\}\}`)
+	templateVarRe  = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+)
+
+// ValidPromptTemplateName reports whether name can be stored and referenced.
+func ValidPromptTemplateName(name string) bool {
+	return templateNameRe.MatchString(name)
+}
+
+// RenderPromptTemplate substitutes {{variable}} placeholders. Placeholders
+// without a value are left untouched so missing variables stay visible.
+func RenderPromptTemplate(content string, vars map[string]string) string {
+	return templateVarRe.ReplaceAllStringFunc(content, func(m string) string {
+		name := templateVarRe.FindStringSubmatch(m)[1]
+		if v, ok := vars[name]; ok {
+			return v
+		}
+		return m
+	})
+}
+
+// TemplateVariables merges a template's JSON default variables with vars;
+// entries in vars take precedence.
+func TemplateVariables(rec *timeline.PromptTemplateRecord, vars map[string]string) map[string]string {
+	out := map[string]string{}
+	if rec != nil && rec.Variables != "" {
+		_ = json.Unmarshal([]byte(rec.Variables), &out)
+	}
+	for k, v := range vars {
+		out[k] = v
+	}
+	return out
+}
+
+// SetPromptTemplates enables template lookups for the system prompt.
+func (b *ContextBuilder) SetPromptTemplates(store PromptTemplateStore) {
+	b.templates = store
+}
+
+// loadTemplate returns the rendered template, or "" when it is unknown.
+func (b *ContextBuilder) loadTemplate(name string, version int, vars map[string]string, depth int) string {
+	if b.templates == nil {
+		return ""
+	}
+	rec, err := b.templates.GetPromptTemplate(name, version)
+	if err != nil {
+		slog.Warn("Prompt template lookup failed", "name", name, "error", err)
+		return ""
+	}
+	if rec == nil {
+		return ""
+	}
+	return b.expandTemplateRefs(RenderPromptTemplate(rec.Content, TemplateVariables(rec, vars)), vars, depth+1)
+}
+
+// expandTemplateRefs replaces {{template:name}} and {{template:name@version}}
+// references with the rendered template content.
+func (b *ContextBuilder) expandTemplateRefs(text string, vars map[string]string, depth int) string {
+	if b.templates == nil || !strings.Contains(text, "{{") {
+		return text
+	}
+	return templateRefRe.ReplaceAllStringFunc(text, func(m string) string {
+		if depth >= maxTemplateDepth {
+			return ""
+		}
+		sub := templateRefRe.FindStringSubmatch(m)
+		version, _ := strconv.Atoi(sub[2])
+		return b.loadTemplate(sub[1], version, vars, depth)
+	})
+}
@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+	"github.com/KafClaw/KafClaw/internal/tools"
+)
+
+func TestContextBuilderPromptTemplates(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "AGENTS.md"), []byte("Rules: {{template:house-style}}"), 0644)
+
+	timeSvc, err := timeline.NewTimelineService(filepath.Join(tmpDir, "timeline.db"))
+	if err != nil {
+		t.Fatalf("open timeline: %v", err)
+	}
+	defer timeSvc.Close()
+
+	builder := NewContextBuilder(tmpDir, "", "", tools.NewRegistry())
+	builder.SetPromptTemplates(timeSvc)
+
+	if prompt := builder.BuildSystemPrompt(); !strings.Contains(prompt, "You are KafClaw") || strings.Contains(prompt, "{{template:") {
+		t.Fatalf("expected built-in identity and dropped unknown reference, got:\n%s", prompt)
+	}
+
+	for _, rec := range []*timeline.PromptTemplateRecord{
+		{Name: "house-style", Content: "Answer in {{tone}} tone."},
+		{Name: "house-style", Content: "Answer in a {{tone}} tone.", Variables: `{"tone":"friendly"}`},
+		{Name: SystemPromptTemplate, Content: "You are Ops Claw. Now: {{now}}. {{undefined}}\n{{template:house-style@1}}"},
+	} {
+		if err := timeSvc.SavePromptTemplate(rec); err != nil {
+			t.Fatalf("save %s: %v", rec.Name, err)
+		}
+	}
+
+	prompt := builder.BuildSystemPrompt()
+	if strings.Contains(prompt, "You are KafClaw") || !strings.Contains(prompt, "You are Ops Claw. Now: 20") {
+		t.Fatalf("system template not applied:\n%s", prompt)
+	}
+	if !strings.Contains(prompt, "{{undefined}}") {
+		t.Fatal("unknown variables should be left visible")
+	}
+	if !strings.Contains(prompt, "Answer in {{tone}} tone.") {
+		t.Fatalf("pinned version 1 not used:\n%s", prompt)
+	}
+	if !strings.Contains(prompt, "Rules: Answer in a friendly tone.") {
+		t.Fatalf("latest version with default variables not used:\n%s", prompt)
+	}
+}
+
+func TestPromptTemplateRecursionIsBounded(t *testing.T) {
+	timeSvc, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatalf("open timeline: %v", err)
+	}
+	defer timeSvc.Close()
+	_ = timeSvc.SavePromptTemplate(&timeline.PromptTemplateRecord{Name: "loop", Content: "x{{template:loop}}"})
+
+	builder := NewContextBuilder(t.TempDir(), "", "", tools.NewRegistry())
+	builder.SetPromptTemplates(timeSvc)
+	if got := builder.expandTemplateRefs("{{template:loop}}", nil, 0); got != "xxx" {
+		t.Fatalf("expected bounded expansion, got %q", got)
+	}
+}
@@ -0,0 +1,122 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/metrics"
+	"github.com/KafClaw/KafClaw/internal/policy"
+	"github.com/KafClaw/KafClaw/internal/provider"
+	"github.com/KafClaw/KafClaw/internal/provider/middleware"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// safeModeMalformedLimit is the number of consecutive LLM responses with only
+// malformed tool calls after which the loop switches to safe mode.
+const safeModeMalformedLimit = 3
+
+var safeModeTotal = metrics.NewCounter("kafclaw_agent_safe_mode_total", "Replies answered in safe mode (tools disabled), by cause.", "cause")
+
+// Safe mode causes.
+const (
+	safeModePolicyError    = "policy_error"
+	safeModeMalformedCalls = "malformed_tool_calls"
+)
+
+// evaluatePolicy runs the policy engine and turns a panic into an error, so a
+// broken engine degrades the reply instead of crashing the loop.
+func (l *Loop) evaluatePolicy(pctx policy.Context) (decision policy.Decision, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("policy engine panic: %v", r)
+		}
+	}()
+	return l.policy.Evaluate(pctx), nil
+}
+
+// malformedToolCall returns why a tool call cannot be executed as requested,
+// or "" if it looks well-formed.
+func (l *Loop) malformedToolCall(tc provider.ToolCall) string {
+	name := strings.TrimSpace(tc.Name)
+	if name == "" {
+		return "empty tool name"
+	}
+	if _, ok := l.registry.Get(name); !ok {
+		return fmt.Sprintf("unknown tool %q", name)
+	}
+	// Providers keep unparseable argument JSON under "raw".
+	if raw, ok := tc.Arguments["raw"].(string); ok && len(tc.Arguments) == 1 && raw != "" {
+		if !l.toolDeclaresParam(name, "raw") {
+			return fmt.Sprintf("invalid arguments for %s", name)
+		}
+	}
+	return ""
+}
+
+// toolDeclaresParam reports whether tool declares the named parameter.
+func (l *Loop) toolDeclaresParam(tool, param string) bool {
+	t, ok := l.registry.Get(tool)
+	if !ok {
+		return false
+	}
+	props, _ := t.Parameters()["properties"].(map[string]any)
+	_, ok = props[param]
+	return ok
+}
+
+// runSafeMode answers without tools from the conversation context (which
+// already carries the injected memory), records a SAFE_MODE timeline event
+// and appends a generic notice to the reply. The reason can name tools or
+// carry policy errors, so it only goes to the log and the timeline.
+func (l *Loop) runSafeMode(ctx context.Context, messages []provider.Message, cause, reason string) (string, error) {
+	slog.Warn("Agent entering safe mode", "cause", cause, "reason", reason, "trace_id", l.activeTraceID)
+	safeModeTotal.Inc(cause)
+	if l.timeline != nil {
+		meta, _ := json.Marshal(map[string]any{
+			"cause":   cause,
+			"reason":  reason,
+			"task_id": l.activeTaskID,
+			"channel": l.activeChannel,
+			"chat_id": l.activeChatID,
+			"sender":  l.activeSender,
+		})
+		_ = l.timeline.AddEvent(&timeline.TimelineEvent{
+			EventID:        fmt.Sprintf("SAFE_MODE_%s_%d", l.activeTraceID, time.Now().UnixNano()),
+			TraceID:        l.activeTraceID,
+			Timestamp:      time.Now(),
+			SenderID:       "AGENT",
+			SenderName:     "Agent",
+			EventType:      "SYSTEM",
+			ContentText:    fmt.Sprintf("safe mode (%s): %s", cause, reason),
+			Classification: "SAFE_MODE",
+			Authorized:     true,
+			Metadata:       string(meta),
+		})
+	}
+
+	msgs := append([]provider.Message{}, messages...)
+	msgs = append(msgs, provider.Message{
+		Role:    "system",
+		Content: "Tools are unavailable for this reply. Answer from the conversation and the memory context above only. If the request needs tools, say what you could not do.",
+	})
+	meta := middleware.NewRequestMeta("", l.model)
+	meta.SenderID = l.activeSender
+	meta.Channel = l.activeChannel
+	meta.MessageType = l.activeMessageType
+	resp, err := l.chain.Process(ctx, &provider.ChatRequest{
+		Messages:    msgs,
+		Model:       l.model,
+		MaxTokens:   4096,
+		Temperature: 0.7,
+	}, meta)
+	if err != nil {
+		return "", fmt.Errorf("LLM call failed in safe mode: %w", err)
+	}
+	l.trackTokens(resp.Usage)
+	content := strings.TrimSpace(resp.Content)
+	return content + "\n\n⚠️ Safe mode: tools were disabled for this reply.", nil
+}
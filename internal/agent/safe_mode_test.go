@@ -0,0 +1,129 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/policy"
+	"github.com/KafClaw/KafClaw/internal/provider"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// recordingProvider replays responses and records whether tools were offered.
+type recordingProvider struct {
+	mockProvider
+	toolsOffered []bool
+}
+
+func (p *recordingProvider) Chat(ctx context.Context, req *provider.ChatRequest) (*provider.ChatResponse, error) {
+	p.toolsOffered = append(p.toolsOffered, len(req.Tools) > 0)
+	return p.mockProvider.Chat(ctx, req)
+}
+
+type panicEngine struct{}
+
+func (panicEngine) Evaluate(policy.Context) policy.Decision { panic("rules not loaded") }
+
+func runSafeModeLoop(t *testing.T, engine policy.Engine, responses []provider.ChatResponse, traceID string) (string, *recordingProvider, []timeline.TimelineEvent) {
+	t.Helper()
+	tl := newTestTimeline(t)
+	tmpDir := t.TempDir()
+	prov := &recordingProvider{mockProvider: mockProvider{responses: responses}}
+	loop := NewLoop(LoopOptions{
+		Bus:           bus.NewMessageBus(),
+		Provider:      prov,
+		Timeline:      tl,
+		Policy:        engine,
+		Workspace:     tmpDir,
+		WorkRepo:      tmpDir,
+		Model:         "mock-model",
+		MaxIterations: 10,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	resp, _, err := loop.processMessage(ctx, &bus.InboundMessage{
+		Channel:        "cli",
+		SenderID:       "owner",
+		ChatID:         "owner",
+		TraceID:        traceID,
+		IdempotencyKey: "cli:" + traceID,
+		Content:        "What is in my notes?",
+		Timestamp:      time.Now(),
+		Metadata:       map[string]any{bus.MetaKeyMessageType: bus.MessageTypeInternal},
+	})
+	if err != nil {
+		t.Fatalf("processMessage: %v", err)
+	}
+	events, err := tl.GetEvents(timeline.FilterArgs{TraceID: traceID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, prov, events
+}
+
+func hasSafeModeEvent(events []timeline.TimelineEvent, cause string) bool {
+	for _, e := range events {
+		if e.Classification == "SAFE_MODE" && strings.Contains(e.Metadata, `"cause":"`+cause+`"`) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSafeModeAfterRepeatedMalformedToolCalls(t *testing.T) {
+	bad := provider.ChatResponse{ToolCalls: []provider.ToolCall{{ID: "c1", Name: "no_such_tool"}}}
+	resp, prov, events := runSafeModeLoop(t, policy.NewDefaultEngine(), []provider.ChatResponse{
+		bad, bad, bad,
+		{Content: "From your notes: nothing urgent."},
+	}, "trace-safe-malformed")
+
+	if !strings.HasPrefix(resp, "From your notes: nothing urgent.") || !strings.Contains(resp, "Safe mode") {
+		t.Fatalf("unexpected response: %q", resp)
+	}
+	if strings.Contains(resp, "no_such_tool") {
+		t.Fatalf("safe-mode reason must not reach the user: %q", resp)
+	}
+	if n := len(prov.toolsOffered); n != 4 || prov.toolsOffered[n-1] {
+		t.Fatalf("expected a final call without tools, got %v", prov.toolsOffered)
+	}
+	if !hasSafeModeEvent(events, safeModeMalformedCalls) {
+		t.Fatal("expected SAFE_MODE timeline event")
+	}
+}
+
+func TestSafeModeOnPolicyEnginePanic(t *testing.T) {
+	resp, prov, events := runSafeModeLoop(t, panicEngine{}, []provider.ChatResponse{
+		{ToolCalls: []provider.ToolCall{{ID: "c1", Name: "read_file", Arguments: map[string]any{"path": "notes.md"}}}},
+		{Content: "I can't read files right now."},
+	}, "trace-safe-policy")
+
+	if !strings.Contains(resp, "Safe mode") || !strings.HasPrefix(resp, "I can't read files right now.") {
+		t.Fatalf("unexpected response: %q", resp)
+	}
+	if strings.Contains(resp, "rules not loaded") {
+		t.Fatalf("safe-mode reason must not reach the user: %q", resp)
+	}
+	if len(prov.toolsOffered) != 2 || prov.toolsOffered[1] {
+		t.Fatalf("expected safe-mode call without tools, got %v", prov.toolsOffered)
+	}
+	if !hasSafeModeEvent(events, safeModePolicyError) {
+		t.Fatal("expected SAFE_MODE timeline event")
+	}
+	var logged bool
+	for _, e := range events {
+		if e.Classification == "SAFE_MODE" && strings.Contains(e.ContentText, "rules not loaded") {
+			logged = true
+		}
+	}
+	if !logged {
+		t.Fatal("expected the reason in the SAFE_MODE timeline event")
+	}
+	for _, e := range events {
+		if e.Classification == "TOOL" {
+			t.Fatal("tool must not run when the policy engine fails")
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/tools"
+)
+
+// activeSchedules returns the schedule manager of the chat the scheduled
+// message tool works on.
+func (l *Loop) activeSchedules() (bus.ScheduleManager, string, error) {
+	if l.activeChannel == "" || l.activeChatID == "" {
+		return nil, "", fmt.Errorf("scheduled messages unavailable outside a chat")
+	}
+	m, err := l.bus.Scheduled(l.activeChannel)
+	if errors.Is(err, bus.ErrNoScheduleManager) {
+		return nil, "", fmt.Errorf("scheduled messages unavailable on %s", l.activeChannel)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return m, l.activeChatID, nil
+}
+
+// scheduleMessageForTool publishes a message to the current chat and thread
+// with SendAt set; the channel's bridge holds it until then.
+func (l *Loop) scheduleMessageForTool(_ context.Context, content string, sendAt time.Time) error {
+	if _, _, err := l.activeSchedules(); err != nil {
+		return err
+	}
+	threadID := l.activeThreadID
+	if l.activeSettings.Threading == "off" {
+		threadID = ""
+	}
+	l.bus.PublishOutbound(&bus.OutboundMessage{
+		Channel:  l.activeChannel,
+		ChatID:   l.activeChatID,
+		ThreadID: threadID,
+		TraceID:  l.activeTraceID,
+		Content:  content,
+		SendAt:   sendAt.Format(time.RFC3339),
+	})
+	return nil
+}
+
+func (l *Loop) listScheduledForTool(ctx context.Context) ([]tools.ScheduledMessageView, error) {
+	m, chatID, err := l.activeSchedules()
+	if err != nil {
+		return nil, err
+	}
+	items, err := m.ListScheduled(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]tools.ScheduledMessageView, 0, len(items))
+	for _, s := range items {
+		out = append(out, tools.ScheduledMessageView{ID: s.ID, ThreadID: s.ThreadID, SendAt: s.SendAt, Preview: s.Preview})
+	}
+	return out, nil
+}
+
+func (l *Loop) cancelScheduledForTool(ctx context.Context, id string) error {
+	m, chatID, err := l.activeSchedules()
+	if err != nil {
+		return err
+	}
+	return m.CancelScheduled(ctx, chatID, id)
+}
@@ -0,0 +1,54 @@
+package agent
+
+import "context"
+
+// Stream event types reported while an agent run is in progress.
+const (
+	// StreamEventLLM follows every LLM call. Content is the assistant text of
+	// the step; Tools names the tools it called.
+	StreamEventLLM = "llm"
+	// StreamEventToolCall precedes a tool execution.
+	StreamEventToolCall = "tool_call"
+	// StreamEventToolResult follows a tool execution.
+	StreamEventToolResult = "tool_result"
+)
+
+// streamResultPreview caps the tool result carried by a stream event.
+const streamResultPreview = 2048
+
+// StreamEvent is one step of an agent run, as reported to a stream sink.
+type StreamEvent struct {
+	Type       string         `json:"type"`
+	Iteration  int            `json:"iteration"`
+	Content    string         `json:"content,omitempty"`
+	Tools      []string       `json:"tools,omitempty"`
+	Tokens     int            `json:"tokens,omitempty"`
+	Tool       string         `json:"tool,omitempty"`
+	ToolCallID string         `json:"tool_call_id,omitempty"`
+	Arguments  map[string]any `json:"arguments,omitempty"`
+	Result     string         `json:"result,omitempty"`
+	Error      bool           `json:"error,omitempty"`
+	DurationMS int64          `json:"duration_ms,omitempty"`
+}
+
+type streamSinkKey struct{}
+
+// WithStreamSink returns ctx carrying sink. Runs started with the context
+// report their LLM and tool steps to it as they happen. The sink is called
+// from the run's goroutine and must not block for long.
+func WithStreamSink(ctx context.Context, sink func(StreamEvent)) context.Context {
+	if sink == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, streamSinkKey{}, sink)
+}
+
+// EmitStreamEvent reports ev to the sink carried by ctx, if any.
+func EmitStreamEvent(ctx context.Context, ev StreamEvent) {
+	if ctx == nil {
+		return
+	}
+	if sink, ok := ctx.Value(streamSinkKey{}).(func(StreamEvent)); ok {
+		sink(ev)
+	}
+}
@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/provider"
+)
+
+func TestStreamSinkReportsLLMAndToolSteps(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("release on friday"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mock := &mockProvider{responses: []provider.ChatResponse{
+		{
+			Content: "Reading the notes.",
+			ToolCalls: []provider.ToolCall{{
+				ID:        "call_1",
+				Name:      "read_file",
+				Arguments: map[string]any{"path": filepath.Join(dir, "notes.txt")},
+			}},
+			Usage: provider.Usage{TotalTokens: 50},
+		},
+		{Content: "The release is on Friday.", Usage: provider.Usage{TotalTokens: 40}},
+	}}
+	loop := NewLoop(LoopOptions{
+		Bus:           bus.NewMessageBus(),
+		Provider:      mock,
+		Timeline:      newTestTimeline(t),
+		Workspace:     dir,
+		WorkRepo:      dir,
+		Model:         "mock-model",
+		MaxIterations: 5,
+	})
+
+	var events []StreamEvent
+	ctx := WithStreamSink(context.Background(), func(ev StreamEvent) { events = append(events, ev) })
+	resp, err := loop.ProcessDirectWithTrace(ctx, "when is the release?", "local:test", "trace-stream")
+	if err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	if resp != "The release is on Friday." {
+		t.Fatalf("unexpected response %q", resp)
+	}
+
+	var types []string
+	for _, ev := range events {
+		types = append(types, ev.Type)
+	}
+	want := []string{StreamEventLLM, StreamEventToolCall, StreamEventToolResult, StreamEventLLM}
+	if len(types) != len(want) {
+		t.Fatalf("expected events %v, got %v", want, types)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Fatalf("expected events %v, got %v", want, types)
+		}
+	}
+	if first := events[0]; first.Content != "Reading the notes." || len(first.Tools) != 1 || first.Tools[0] != "read_file" || first.Tokens != 50 {
+		t.Fatalf("unexpected llm event: %+v", first)
+	}
+	if call := events[1]; call.Tool != "read_file" || call.ToolCallID != "call_1" || call.Iteration != 1 {
+		t.Fatalf("unexpected tool_call event: %+v", call)
+	}
+	if res := events[2]; res.Error || res.Result != "release on friday" {
+		t.Fatalf("unexpected tool_result event: %+v", res)
+	}
+	if last := events[3]; last.Iteration != 2 || last.Content != "The release is on Friday." {
+		t.Fatalf("unexpected final llm event: %+v", last)
+	}
+}
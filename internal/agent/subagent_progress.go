@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// subagentProgress is reported by a child loop after every LLM iteration
+// that requested tool calls.
+type subagentProgress struct {
+	Step          string
+	Iterations    int
+	MaxIterations int
+	Tokens        int
+}
+
+// subagentProgressHook returns the child loop hook for a spawned run. Every
+// report updates the run state; at most one update per configured interval is
+// posted as a threaded message to the originating chat and recorded on the
+// parent trace.
+func (l *Loop) subagentProgressHook(runID, channel, chatID, threadID, traceID string) func(subagentProgress) {
+	interval := time.Duration(l.subagentProgressSec) * time.Second
+	lastSent := time.Now()
+	return func(p subagentProgress) {
+		l.subagents.markProgress(runID, p.Step, p.Iterations, p.Tokens)
+		if interval <= 0 || time.Since(lastSent) < interval {
+			return
+		}
+		lastSent = time.Now()
+		run, ok := l.subagents.getRun(runID)
+		if !ok {
+			return
+		}
+		l.publishSubagentProgress(run, p, channel, chatID, threadID, traceID)
+	}
+}
+
+func (l *Loop) publishSubagentProgress(run *subagentRun, p subagentProgress, channel, chatID, threadID, traceID string) {
+	content := formatSubagentProgress(run, p)
+	if l.timeline != nil && traceID != "" {
+		meta, _ := json.Marshal(map[string]any{
+			"run_id":         run.RunID,
+			"label":          run.Label,
+			"step":           p.Step,
+			"iterations":     p.Iterations,
+			"max_iterations": p.MaxIterations,
+			"tokens":         p.Tokens,
+		})
+		_ = l.timeline.AddEvent(&timeline.TimelineEvent{
+			EventID:        fmt.Sprintf("SUBAGENT_progress_%s_%d", run.RunID, time.Now().UnixNano()),
+			TraceID:        traceID,
+			Timestamp:      time.Now(),
+			SenderID:       "AGENT",
+			SenderName:     "SubagentController",
+			EventType:      "SYSTEM",
+			ContentText:    content,
+			Classification: "SUBAGENT_PROGRESS",
+			Authorized:     true,
+			Metadata:       string(meta),
+		})
+	}
+	if l.bus == nil || strings.TrimSpace(channel) == "" || strings.TrimSpace(chatID) == "" {
+		return
+	}
+	l.bus.PublishOutbound(&bus.OutboundMessage{
+		Channel:  channel,
+		ChatID:   chatID,
+		ThreadID: threadID,
+		TraceID:  traceID,
+		TaskID:   "subagent-progress:" + run.RunID,
+		Content:  content,
+	})
+}
+
+func formatSubagentProgress(run *subagentRun, p subagentProgress) string {
+	name := strings.TrimSpace(run.Label)
+	if name == "" {
+		name = run.RunID
+	}
+	step := p.Step
+	if step == "" {
+		step = "thinking"
+	}
+	return fmt.Sprintf("⏳ Subagent %s: %s (iteration %d/%d, %d tokens)", name, step, p.Iterations, p.MaxIterations, p.Tokens)
+}
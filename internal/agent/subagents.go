@@ -50,9 +50,18 @@ type subagentRun struct {
 	AnnounceAttempts int
 	CompletionOutput string
 	Error            string
+	CurrentStep      string
+	Iterations       int
+	TokensUsed       int
+	LastProgressAt   *time.Time
 	cancel           context.CancelFunc
 }
 
+// isSubagentSession reports whether a session key belongs to a subagent run.
+func isSubagentSession(sessionKey string) bool {
+	return strings.HasPrefix(sessionKey, "subagent:") || strings.Contains(sessionKey, ":subagent:")
+}
+
 func buildSubagentAnnounceID(childSessionKey, runID string) string {
 	payload := strings.TrimSpace(childSessionKey) + "|" + strings.TrimSpace(runID)
 	sum := sha1.Sum([]byte(payload))
@@ -362,6 +371,20 @@ func (m *subagentManager) markFinished(runID string, status string, err error) {
 	}
 }
 
+// markProgress records the latest progress of a running subagent. It is
+// kept in memory only; the run is persisted on the next state change.
+func (m *subagentManager) markProgress(runID, step string, iterations, tokens int) {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if run, ok := m.runs[runID]; ok {
+		run.CurrentStep = step
+		run.Iterations = iterations
+		run.TokensUsed = tokens
+		run.LastProgressAt = &now
+	}
+}
+
 func (m *subagentManager) markCompletionOutput(runID, output string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -578,5 +601,9 @@ func cloneSubagentRun(in *subagentRun) *subagentRun {
 		archiveAt := *in.ArchiveAt
 		out.ArchiveAt = &archiveAt
 	}
+	if in.LastProgressAt != nil {
+		progressAt := *in.LastProgressAt
+		out.LastProgressAt = &progressAt
+	}
 	return &out
 }
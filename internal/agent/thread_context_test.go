@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+func TestThreadContextFetchesOnceAndAttributes(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Channels.ThreadContext.Limit = 2
+	msgBus := bus.NewMessageBus()
+	loop := NewLoop(LoopOptions{Bus: msgBus, Config: cfg, Workspace: t.TempDir(), WorkRepo: t.TempDir()})
+
+	reads := 0
+	at := time.Date(2026, 1, 2, 9, 30, 0, 0, time.UTC)
+	msgBus.RegisterThreadReader("slack", func(_ context.Context, chatID, threadID string, limit int) ([]bus.ThreadMessage, error) {
+		reads++
+		if chatID != "C1" || threadID != "100.1" || limit != 3 {
+			t.Fatalf("unexpected read %s %s %d", chatID, threadID, limit)
+		}
+		return []bus.ThreadMessage{
+			{ID: "100.1", SenderID: "U1", SenderName: "alex", Text: "root question", Timestamp: at},
+			{ID: "100.2", SenderID: "U2", Text: "an answer"},
+			{ID: "100.3", SenderID: "B1", SenderName: "kafclaw", IsBot: true, Text: "bot   reply"},
+			{ID: "100.4", SenderID: "U1", Text: "@kafclaw what now?"},
+		}, nil
+	})
+
+	msg := &bus.InboundMessage{Channel: "slack", ChatID: "C1", ThreadID: "100.1", MessageID: "100.4", SenderID: "U1"}
+	section := loop.threadContext(context.Background(), msg, "slack:C1")
+	if !strings.Contains(section, "## Thread History") ||
+		!strings.Contains(section, "- U2: an answer") ||
+		!strings.Contains(section, "- kafclaw (B1) [bot]: bot reply") {
+		t.Fatalf("unexpected section:\n%s", section)
+	}
+	if strings.Contains(section, "root question") || strings.Contains(section, "what now") {
+		t.Fatalf("expected the limit to drop the root and the current message to be skipped:\n%s", section)
+	}
+
+	msg.MessageID = "100.5"
+	if again := loop.threadContext(context.Background(), msg, "slack:C1"); again != section || reads != 1 {
+		t.Fatalf("expected cached section, reads=%d", reads)
+	}
+
+	if got := loop.threadContext(context.Background(), &bus.InboundMessage{Channel: "whatsapp", ChatID: "c1", ThreadID: "t1"}, "whatsapp:c1"); got != "" {
+		t.Fatalf("channels without a reader must yield no section, got %q", got)
+	}
+}
+
+func TestThreadContextDisabled(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Channels.ThreadContext.Enabled = false
+	msgBus := bus.NewMessageBus()
+	loop := NewLoop(LoopOptions{Bus: msgBus, Config: cfg, Workspace: t.TempDir(), WorkRepo: t.TempDir()})
+	msgBus.RegisterThreadReader("slack", func(context.Context, string, string, int) ([]bus.ThreadMessage, error) {
+		t.Fatal("reader must not be called when thread context is disabled")
+		return nil, nil
+	})
+	if got := loop.threadContext(context.Background(), &bus.InboundMessage{Channel: "slack", ChatID: "C1", ThreadID: "1.0"}, "slack:C1"); got != "" {
+		t.Fatalf("expected no section, got %q", got)
+	}
+}
+
+func TestChatContextUsesHistoryHint(t *testing.T) {
+	cfg := config.DefaultConfig()
+	msgBus := bus.NewMessageBus()
+	loop := NewLoop(LoopOptions{Bus: msgBus, Config: cfg, Workspace: t.TempDir(), WorkRepo: t.TempDir()})
+	msgBus.RegisterThreadReader("slack", func(_ context.Context, chatID, threadID string, limit int) ([]bus.ThreadMessage, error) {
+		if chatID != "C1" || threadID != "" || limit != 6 {
+			t.Fatalf("unexpected read %s %q %d", chatID, threadID, limit)
+		}
+		return []bus.ThreadMessage{
+			{ID: "1.1", SenderID: "U2", SenderName: "sam", Text: "deploy is red"},
+			{ID: "1.2", SenderID: "U1", Text: "@kafclaw why?"},
+		}, nil
+	})
+
+	msg := &bus.InboundMessage{Channel: "slack", ChatID: "C1", MessageID: "1.2", SenderID: "U1"}
+	if got := loop.threadContext(context.Background(), msg, "slack:C1"); got != "" {
+		t.Fatalf("expected no section without a history hint, got %q", got)
+	}
+	msg.Metadata = map[string]any{bus.MetaKeyChatHistory: 5}
+	section := loop.threadContext(context.Background(), msg, "slack:C1")
+	if !strings.Contains(section, "## Chat History") || !strings.Contains(section, "- sam (U2): deploy is red") || strings.Contains(section, "why?") {
+		t.Fatalf("unexpected section:\n%s", section)
+	}
+}
@@ -0,0 +1,40 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/policy"
+)
+
+func TestToolListsUseChannelAccount(t *testing.T) {
+	for _, tc := range []struct {
+		account string
+		allowed bool
+		reason  string
+	}{
+		{account: "default", allowed: false, reason: "tool_denied_for_channel: whatsapp"},
+		{account: "ops", allowed: true, reason: "tier_2_auto_approved"},
+	} {
+		tl := newTestTimeline(t)
+		engine := policy.NewDefaultEngine()
+		engine.MaxAutoTier = 2
+		engine.ToolLists = map[string]policy.ToolListPolicy{
+			"whatsapp":     {Deny: map[string]bool{"exec": true}},
+			"whatsapp:ops": {},
+		}
+		loop, _, _, ctx := newPlanFirstLoop(t, tl, engine)
+		msg := planFirstMessage("trace-lists-" + tc.account)
+		msg.Metadata[bus.MetaKeyChannelAccount] = tc.account
+		if _, _, err := loop.processMessage(ctx, msg); err != nil {
+			t.Fatalf("%s: %v", tc.account, err)
+		}
+		decisions, err := tl.ListPolicyDecisions(msg.TraceID)
+		if err != nil || len(decisions) != 1 {
+			t.Fatalf("%s: expected one policy decision, got %d %v", tc.account, len(decisions), err)
+		}
+		if d := decisions[0]; d.Tool != "exec" || d.Allowed != tc.allowed || d.Reason != tc.reason {
+			t.Fatalf("%s: unexpected decision %+v", tc.account, d)
+		}
+	}
+}
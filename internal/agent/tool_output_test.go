@@ -0,0 +1,29 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+func TestCompactToolResultArtifacts(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Tools.Output.MaxChars = 200
+	loop := NewLoop(LoopOptions{Config: cfg, Workspace: t.TempDir(), WorkRepo: t.TempDir()})
+	if _, ok := loop.registry.Get("read_artifact"); !ok {
+		t.Fatal("expected read_artifact tool registered")
+	}
+
+	if got := loop.compactToolResult("exec", "ok"); got != "ok" {
+		t.Fatalf("expected small result unchanged, got %q", got)
+	}
+	big := strings.Repeat("build output line\n", 100)
+	got := loop.compactToolResult("exec", big)
+	if len(got) >= len(big) || !strings.Contains(got, "read_artifact") {
+		t.Fatalf("expected artifact reference, got %q", got)
+	}
+	if got := loop.compactToolResult("read_artifact", big); got != big {
+		t.Fatal("read_artifact pages must not be re-artifacted")
+	}
+}
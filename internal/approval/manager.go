@@ -6,14 +6,39 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/KafClaw/KafClaw/internal/timeline"
 )
 
+// Expiry actions of a Policy.
+const (
+	ExpiryDeny     = "deny"
+	ExpiryEscalate = "escalate"
+)
+
+// Notice kinds passed to the notifier.
+const (
+	NoticeReminder  = "reminder"
+	NoticeDelegated = "delegated"
+	NoticeEscalated = "escalated"
+	NoticeExpired   = "expired"
+)
+
+var (
+	// ErrExpired is returned by Wait when an approval expired undecided.
+	ErrExpired = errors.New("approval expired")
+	// ErrNotAuthorized is returned by RespondAs when the sender may not
+	// decide the approval (yet).
+	ErrNotAuthorized = errors.New("not authorized to decide this approval")
+)
+
 // ApprovalRequest represents a pending approval for a tool call.
 type ApprovalRequest struct {
 	ApprovalID string         `json:"approval_id"`
@@ -22,30 +47,72 @@ type ApprovalRequest struct {
 	Arguments  map[string]any `json:"arguments"`
 	Sender     string         `json:"sender"`
 	Channel    string         `json:"channel"`
+	ChatID     string         `json:"chat_id,omitempty"`
+	ThreadID   string         `json:"thread_id,omitempty"`
 	TraceID    string         `json:"trace_id"`
 	TaskID     string         `json:"task_id"`
-	Status     string         `json:"status"` // pending, approved, denied, timeout
+	Status     string         `json:"status"` // pending, approved, denied, timeout, expired
 	CreatedAt  time.Time      `json:"created_at"`
+	// ExpiresAt ends the wait with ErrExpired; zero waits for the context
+	// only.
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// Policy controls reminders, delegation and expiry of pending approvals.
+// The zero value sends no reminders and has no delegates.
+type Policy struct {
+	ExpiryAction     string // ExpiryDeny (default) or ExpiryEscalate
+	ReminderInterval time.Duration
+	DelegateAfter    time.Duration // 0 = delegates decide only once escalated
+	Delegates        []string
 }
 
 // Manager handles approval lifecycle: create, wait, respond.
 type Manager struct {
 	mu       sync.Mutex
-	pending  map[string]chan bool
+	pending  map[string]*pendingApproval
 	timeline *timeline.TimelineService
+	policy   Policy
+	notify   func(req ApprovalRequest, kind, text string)
+}
+
+type pendingApproval struct {
+	req       *ApprovalRequest
+	ch        chan decision
+	delegated bool
+}
+
+type decision struct {
+	approved bool
+	by       string
 }
 
 // NewManager creates an approval manager. Timeline may be nil.
 // On creation, any stale pending approvals in the DB are marked as timeout.
 func NewManager(tl *timeline.TimelineService) *Manager {
 	m := &Manager{
-		pending:  make(map[string]chan bool),
+		pending:  make(map[string]*pendingApproval),
 		timeline: tl,
 	}
 	m.cleanupStale()
 	return m
 }
 
+// SetPolicy sets the reminder, delegation and expiry rules.
+func (m *Manager) SetPolicy(p Policy) {
+	m.mu.Lock()
+	m.policy = p
+	m.mu.Unlock()
+}
+
+// SetNotifier sets the function that delivers reminders and delegation,
+// escalation and expiry notices of a pending approval.
+func (m *Manager) SetNotifier(fn func(req ApprovalRequest, kind, text string)) {
+	m.mu.Lock()
+	m.notify = fn
+	m.mu.Unlock()
+}
+
 // cleanupStale marks any DB-pending approvals as timeout on startup.
 // These are leftovers from a previous process that never resolved them.
 func (m *Manager) cleanupStale() {
@@ -68,9 +135,8 @@ func (m *Manager) Create(req *ApprovalRequest) string {
 	req.Status = "pending"
 	req.CreatedAt = time.Now()
 
-	ch := make(chan bool, 1)
 	m.mu.Lock()
-	m.pending[id] = ch
+	m.pending[id] = &pendingApproval{req: req, ch: make(chan decision, 1)}
 	m.mu.Unlock()
 
 	// Persist to timeline (best-effort)
@@ -81,52 +147,167 @@ func (m *Manager) Create(req *ApprovalRequest) string {
 			req.Tool, req.Tier, string(argsJSON),
 			req.Sender, req.Channel,
 		)
+		if !req.ExpiresAt.IsZero() {
+			_ = m.timeline.SetApprovalExpiry(id, req.ExpiresAt)
+		}
 	}
 
 	return id
 }
 
-// Wait blocks until the approval is responded to or the context expires.
+// Wait blocks until the approval is responded to, expires or the context
+// ends. While it waits it sends reminders and opens the approval to the
+// delegates as the policy says. On expiry the approval is denied with
+// ErrExpired, or with ExpiryEscalate first escalated and extended once.
 func (m *Manager) Wait(ctx context.Context, id string) (bool, error) {
 	m.mu.Lock()
-	ch, ok := m.pending[id]
+	p, ok := m.pending[id]
+	policy := m.policy
 	m.mu.Unlock()
 	if !ok {
 		return false, fmt.Errorf("no pending approval: %s", id)
 	}
+	defer m.cleanup(id)
 
-	select {
-	case approved := <-ch:
-		m.cleanup(id)
-		status := "denied"
-		if approved {
-			status = "approved"
-		}
-		if m.timeline != nil {
-			_ = m.timeline.UpdateApprovalStatus(id, status)
-		}
-		return approved, nil
-	case <-ctx.Done():
-		m.cleanup(id)
-		if m.timeline != nil {
-			_ = m.timeline.UpdateApprovalStatus(id, "timeout")
+	var reminders, delegation, expiry <-chan time.Time
+	if policy.ReminderInterval > 0 {
+		t := time.NewTicker(policy.ReminderInterval)
+		defer t.Stop()
+		reminders = t.C
+	}
+	if policy.DelegateAfter > 0 && len(policy.Delegates) > 0 {
+		t := time.NewTimer(time.Until(p.req.CreatedAt.Add(policy.DelegateAfter)))
+		defer t.Stop()
+		delegation = t.C
+	}
+	var expiryTimer *time.Timer
+	if !p.req.ExpiresAt.IsZero() {
+		expiryTimer = time.NewTimer(time.Until(p.req.ExpiresAt))
+		defer expiryTimer.Stop()
+		expiry = expiryTimer.C
+	}
+	escalated := false
+
+	for {
+		select {
+		case d := <-p.ch:
+			status := "denied"
+			if d.approved {
+				status = "approved"
+			}
+			m.resolve(id, status, d.by)
+			return d.approved, nil
+		case now := <-reminders:
+			m.remind(p, now)
+		case now := <-delegation:
+			delegation = nil
+			m.delegate(p, policy.Delegates, now)
+		case now := <-expiry:
+			if policy.ExpiryAction == ExpiryEscalate && !escalated {
+				escalated = true
+				window := p.req.ExpiresAt.Sub(p.req.CreatedAt)
+				m.escalate(p, policy.Delegates, now, now.Add(window))
+				expiryTimer.Reset(window)
+				continue
+			}
+			m.resolve(id, "expired", "")
+			m.notice(p, NoticeExpired, fmt.Sprintf("Approval %s for %q expired without a decision and was denied.", id, p.req.Tool))
+			return false, ErrExpired
+		case <-ctx.Done():
+			m.resolve(id, "timeout", "")
+			return false, ctx.Err()
 		}
-		return false, ctx.Err()
 	}
 }
 
-// Respond delivers an approval decision for a pending request.
+func (m *Manager) resolve(id, status, decidedBy string) {
+	if m.timeline != nil {
+		_ = m.timeline.ResolveApproval(id, status, decidedBy)
+	}
+}
+
+// remind repeats the prompt of a pending approval.
+func (m *Manager) remind(p *pendingApproval, now time.Time) {
+	if m.timeline != nil {
+		_ = m.timeline.RecordApprovalReminder(p.req.ApprovalID, now)
+	}
+	text := fmt.Sprintf("Reminder: tool %q (tier %d) is still waiting for approval", p.req.Tool, p.req.Tier)
+	if !p.req.ExpiresAt.IsZero() {
+		text += fmt.Sprintf(" (expires in %s)", p.req.ExpiresAt.Sub(now).Round(time.Second))
+	}
+	text += fmt.Sprintf(".\nReply approve:%s or deny:%s", p.req.ApprovalID, p.req.ApprovalID)
+	m.notice(p, NoticeReminder, text)
+}
+
+// delegate lets the delegates decide a pending approval.
+func (m *Manager) delegate(p *pendingApproval, delegates []string, now time.Time) {
+	m.mu.Lock()
+	already := p.delegated
+	p.delegated = true
+	m.mu.Unlock()
+	if already || len(delegates) == 0 {
+		return
+	}
+	if m.timeline != nil {
+		_ = m.timeline.RecordApprovalDelegation(p.req.ApprovalID, delegates, now)
+	}
+	m.notice(p, NoticeDelegated, fmt.Sprintf("Approval %s for %q is still pending; %s may now decide it.\nReply approve:%s or deny:%s",
+		p.req.ApprovalID, p.req.Tool, strings.Join(delegates, ", "), p.req.ApprovalID, p.req.ApprovalID))
+}
+
+// escalate extends an expired approval to expiresAt and opens it to the
+// delegates.
+func (m *Manager) escalate(p *pendingApproval, delegates []string, now, expiresAt time.Time) {
+	p.req.ExpiresAt = expiresAt
+	if m.timeline != nil {
+		_ = m.timeline.RecordApprovalEscalation(p.req.ApprovalID, now, expiresAt)
+	}
+	text := fmt.Sprintf("Escalated: approval %s for tool %q (tier %d) was not decided in time", p.req.ApprovalID, p.req.Tool, p.req.Tier)
+	if p.req.Sender != "" {
+		text += " by " + p.req.Sender
+	}
+	text += fmt.Sprintf(". It is denied at %s UTC unless decided.\nReply approve:%s or deny:%s",
+		expiresAt.UTC().Format("15:04:05"), p.req.ApprovalID, p.req.ApprovalID)
+	m.notice(p, NoticeEscalated, text)
+	m.delegate(p, delegates, now)
+}
+
+func (m *Manager) notice(p *pendingApproval, kind, text string) {
+	m.mu.Lock()
+	fn := m.notify
+	m.mu.Unlock()
+	if fn != nil {
+		fn(*p.req, kind, text)
+	}
+}
+
+// Respond delivers an approval decision of the owner for a pending request.
 func (m *Manager) Respond(id string, approved bool) error {
+	return m.RespondAs(id, approved, "owner", true)
+}
+
+// RespondAs delivers an approval decision made by sender. The requesting
+// sender and the owner may always decide; delegates may once the approval
+// was delegated or escalated. Others get ErrNotAuthorized.
+func (m *Manager) RespondAs(id string, approved bool, sender string, owner bool) error {
 	m.mu.Lock()
-	ch, ok := m.pending[id]
+	p, ok := m.pending[id]
+	var allowed bool
+	if ok {
+		allowed = owner || p.req.Sender == "" || sender == p.req.Sender ||
+			(p.delegated && slices.Contains(m.policy.Delegates, sender))
+	}
 	m.mu.Unlock()
 	if !ok {
 		return fmt.Errorf("no pending approval: %s", id)
 	}
+	if !allowed {
+		return ErrNotAuthorized
+	}
 
 	// Non-blocking send (channel is buffered with size 1)
 	select {
-	case ch <- approved:
+	case p.ch <- decision{approved: approved, by: sender}:
 	default:
 	}
 	return nil
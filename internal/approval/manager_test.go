@@ -3,8 +3,10 @@ package approval
 import (
 	"context"
 	"crypto/rand"
+	"errors"
 	"io"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
@@ -131,7 +133,7 @@ func TestCleanupStaleIgnoresTimelineErrors(t *testing.T) {
 	_ = tl.Close() // force DB errors for GetPendingApprovals
 
 	m := &Manager{
-		pending:  map[string]chan bool{},
+		pending:  map[string]*pendingApproval{},
 		timeline: tl,
 	}
 	// Should not panic even if timeline operations fail.
@@ -230,6 +232,143 @@ func TestApprovalLifecyclePersistsTimelineStatuses(t *testing.T) {
 	}
 }
 
+func newLifecycleManager(t *testing.T, p Policy) (*Manager, *timeline.TimelineService, *noticeLog) {
+	t.Helper()
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatalf("new timeline: %v", err)
+	}
+	t.Cleanup(func() { _ = tl.Close() })
+	m := NewManager(tl)
+	m.SetPolicy(p)
+	notices := &noticeLog{}
+	m.SetNotifier(notices.add)
+	return m, tl, notices
+}
+
+type noticeLog struct {
+	mu    sync.Mutex
+	kinds []string
+	texts []string
+}
+
+func (n *noticeLog) add(req ApprovalRequest, kind, text string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.kinds = append(n.kinds, kind)
+	n.texts = append(n.texts, text)
+}
+
+func (n *noticeLog) count(kind string) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	c := 0
+	for _, k := range n.kinds {
+		if k == kind {
+			c++
+		}
+	}
+	return c
+}
+
+func TestApprovalExpiryDenies(t *testing.T) {
+	m, tl, notices := newLifecycleManager(t, Policy{ReminderInterval: 20 * time.Millisecond})
+	id := m.Create(&ApprovalRequest{Tool: "exec", Tier: 2, Sender: "owner", TraceID: "tr-expiry", ExpiresAt: time.Now().Add(90 * time.Millisecond)})
+
+	ok, err := m.Wait(context.Background(), id)
+	if ok || !errors.Is(err, ErrExpired) {
+		t.Fatalf("expected expiry, got ok=%v err=%v", ok, err)
+	}
+	if notices.count(NoticeReminder) < 2 || notices.count(NoticeExpired) != 1 {
+		t.Fatalf("expected reminders and an expiry notice, got %v", notices.kinds)
+	}
+	rec, err := tl.GetApproval(id)
+	if err != nil {
+		t.Fatalf("get approval: %v", err)
+	}
+	if rec.Status != "expired" || rec.Reminders < 2 || rec.LastReminderAt == nil || rec.ExpiresAt == nil {
+		t.Fatalf("expected expiry and reminders recorded, got %+v", rec)
+	}
+	if err := m.Respond(id, true); err == nil {
+		t.Fatal("expected expired approval to be gone")
+	}
+}
+
+func TestApprovalDelegation(t *testing.T) {
+	m, tl, notices := newLifecycleManager(t, Policy{DelegateAfter: 50 * time.Millisecond, Delegates: []string{"deputy"}})
+	id := m.Create(&ApprovalRequest{Tool: "exec", Tier: 2, Sender: "owner", ExpiresAt: time.Now().Add(5 * time.Second)})
+
+	if err := m.RespondAs(id, true, "deputy", false); !errors.Is(err, ErrNotAuthorized) {
+		t.Fatalf("expected delegate to wait for delegation, got %v", err)
+	}
+	if err := m.RespondAs(id, true, "stranger", false); !errors.Is(err, ErrNotAuthorized) {
+		t.Fatalf("expected stranger rejected, got %v", err)
+	}
+	go func() {
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if err := m.RespondAs(id, false, "deputy", false); err == nil {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	ok, err := m.Wait(context.Background(), id)
+	if err != nil || ok {
+		t.Fatalf("expected denial by delegate, got ok=%v err=%v", ok, err)
+	}
+	if notices.count(NoticeDelegated) != 1 {
+		t.Fatalf("expected a delegation notice, got %v", notices.kinds)
+	}
+	rec, err := tl.GetApproval(id)
+	if err != nil {
+		t.Fatalf("get approval: %v", err)
+	}
+	if rec.Status != "denied" || rec.DecidedBy != "deputy" || rec.DelegatedAt == nil || len(rec.Delegates) != 1 {
+		t.Fatalf("expected delegated decision recorded, got %+v", rec)
+	}
+}
+
+func TestApprovalExpiryEscalates(t *testing.T) {
+	m, tl, notices := newLifecycleManager(t, Policy{ExpiryAction: ExpiryEscalate, Delegates: []string{"deputy"}})
+	id := m.Create(&ApprovalRequest{Tool: "exec", Tier: 2, Sender: "owner", ExpiresAt: time.Now().Add(60 * time.Millisecond)})
+
+	start := time.Now()
+	ok, err := m.Wait(context.Background(), id)
+	if ok || !errors.Is(err, ErrExpired) {
+		t.Fatalf("expected expiry after escalation, got ok=%v err=%v", ok, err)
+	}
+	if time.Since(start) < 100*time.Millisecond {
+		t.Fatal("expected the escalation to extend the expiry")
+	}
+	if notices.count(NoticeEscalated) != 1 || notices.count(NoticeDelegated) != 1 {
+		t.Fatalf("expected escalation and delegation notices, got %v", notices.kinds)
+	}
+	rec, err := tl.GetApproval(id)
+	if err != nil {
+		t.Fatalf("get approval: %v", err)
+	}
+	if rec.Status != "expired" || rec.EscalatedAt == nil || rec.DelegatedAt == nil {
+		t.Fatalf("expected escalation recorded, got %+v", rec)
+	}
+
+	// Escalated approvals are open to the delegates.
+	m2, _, _ := newLifecycleManager(t, Policy{ExpiryAction: ExpiryEscalate, Delegates: []string{"deputy"}})
+	id2 := m2.Create(&ApprovalRequest{Tool: "exec", Tier: 2, Sender: "owner", ExpiresAt: time.Now().Add(30 * time.Millisecond)})
+	go func() {
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if err := m2.RespondAs(id2, true, "deputy", false); err == nil {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+	if ok, err := m2.Wait(context.Background(), id2); err != nil || !ok {
+		t.Fatalf("expected approval by delegate after escalation, got ok=%v err=%v", ok, err)
+	}
+}
+
 type failingReader struct{}
 
 func (failingReader) Read(_ []byte) (int, error) {
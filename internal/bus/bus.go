@@ -3,8 +3,20 @@ package bus
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/KafClaw/KafClaw/internal/metrics"
+)
+
+var (
+	inboundTotal  = metrics.NewCounter("kafclaw_bus_inbound_total", "Inbound messages published to the bus, by channel.", "channel")
+	outboundTotal = metrics.NewCounter("kafclaw_bus_outbound_total", "Outbound messages published to the bus, by channel.", "channel")
+	dupTotal      = metrics.NewCounter("kafclaw_bus_inbound_duplicates_total", "Duplicate inbound messages dropped by the bus, by channel.", "channel")
+
+	outboundSplitTotal = metrics.NewCounter("kafclaw_bus_outbound_split_total", "Outbound messages split to fit the channel's message limit, by channel.", "channel")
 )
 
 // Well-known metadata keys and message type constants.
@@ -13,6 +25,12 @@ const (
 	MetaKeyIsFromMe       = "is_from_me"
 	MetaKeySessionScope   = "session_scope"
 	MetaKeyChannelAccount = "channel_account"
+	MetaKeyEventType      = "event_type"    // non-message activity, e.g. "reaction_added"
+	MetaKeyEvent          = "event"         // structured payload of MetaKeyEventType
+	MetaKeyTraceparent    = "traceparent"   // W3C traceparent the message arrived with
+	MetaKeyChatHistory    = "chat_history"  // newest chat messages to read as context outside threads
+	MetaKeyWorkspace      = "workspace_id"  // Slack team the message came from
+	MetaKeyEnterprise     = "enterprise_id" // Slack Enterprise Grid org of MetaKeyWorkspace
 	MessageTypeInternal   = "internal"
 	MessageTypeExternal   = "external"
 )
@@ -58,31 +76,217 @@ type OutboundMessage struct {
 	PollQuestion      string         `json:"poll_question,omitempty"`
 	PollOptions       []string       `json:"poll_options,omitempty"`
 	PollMaxSelections int            `json:"poll_max_selections,omitempty"`
+	// Template names a message template of the workspace, filled with
+	// TemplateVars and rendered natively by each channel.
+	Template     string         `json:"template,omitempty"`
+	TemplateVars map[string]any `json:"template_vars,omitempty"`
+	// SendAt schedules the message for later delivery (RFC 3339). Only the
+	// Slack and Teams bridges honor it.
+	SendAt string `json:"send_at,omitempty"`
+	// Visibility "ephemeral" or "dm" shows the message to RecipientID only;
+	// empty posts it to the chat. Only the Slack and Teams bridges honor it.
+	Visibility  string `json:"visibility,omitempty"`
+	RecipientID string `json:"recipient_id,omitempty"`
+	// Part and Parts number the pieces of a message the dispatcher split to
+	// fit the channel's message limit (1-based); both are zero when unsplit.
+	Part  int `json:"part,omitempty"`
+	Parts int `json:"parts,omitempty"`
+}
+
+// Presence event kinds.
+const (
+	PresenceTyping = "typing"
+	PresenceRead   = "read"
+)
+
+// PresenceEvent is a transient chat-state signal from the agent to a
+// channel: a typing indicator or a read receipt for inbound messages.
+// Presence bypasses the outbound queue and is never retried.
+type PresenceEvent struct {
+	Channel    string   `json:"channel"`
+	ChatID     string   `json:"chat_id"`
+	ThreadID   string   `json:"thread_id,omitempty"`
+	Kind       string   `json:"kind"`
+	SenderID   string   `json:"sender_id,omitempty"`   // read: author of the messages
+	MessageIDs []string `json:"message_ids,omitempty"` // read: messages to mark read
+}
+
+// ThreadMessage is one platform message of a chat thread, as returned by a
+// channel's ThreadReader.
+type ThreadMessage struct {
+	ID         string    `json:"id"`
+	SenderID   string    `json:"sender_id,omitempty"`
+	SenderName string    `json:"sender_name,omitempty"`
+	IsBot      bool      `json:"is_bot,omitempty"`
+	Text       string    `json:"text"`
+	Timestamp  time.Time `json:"ts,omitempty"`
+}
+
+// ThreadReader fetches up to limit of the newest messages of a thread,
+// oldest first.
+type ThreadReader func(ctx context.Context, chatID, threadID string, limit int) ([]ThreadMessage, error)
+
+// ErrNoThreadReader is returned by ReadThread for channels that cannot read
+// thread history.
+var ErrNoThreadReader = errors.New("channel has no thread reader")
+
+// Poll is a poll a channel sent, with its tallies.
+type Poll struct {
+	ID            string       `json:"id"`
+	ChatID        string       `json:"chat_id"`
+	Question      string       `json:"question"`
+	Options       []PollOption `json:"options"`
+	MaxSelections int          `json:"max_selections"`
+	Voters        int          `json:"voters"`
+	Closed        bool         `json:"closed"`
+	CreatedAt     string       `json:"created_at,omitempty"`
+	ClosedAt      string       `json:"closed_at,omitempty"`
+}
+
+// PollOption is one answer of a poll. Percent is the share of voters that
+// picked it.
+type PollOption struct {
+	Label   string `json:"label"`
+	Votes   int    `json:"votes"`
+	Percent int    `json:"percent"`
+}
+
+// PollManager reads and closes the polls a channel sent to a chat. Closing
+// posts the results to the chat and stops counting votes.
+type PollManager interface {
+	ListPolls(ctx context.Context, chatID string) ([]Poll, error)
+	PollResults(ctx context.Context, chatID, pollID string) (*Poll, error)
+	ClosePoll(ctx context.Context, chatID, pollID string) (*Poll, error)
+}
+
+// ErrNoPollManager is returned by Polls for channels without polls.
+var ErrNoPollManager = errors.New("channel has no polls")
+
+// ScheduledMessage is a message scheduled with SendAt that is not sent yet.
+type ScheduledMessage struct {
+	ID       string `json:"id"`
+	ChatID   string `json:"chat_id"`
+	ThreadID string `json:"thread_id,omitempty"`
+	SendAt   string `json:"send_at"`
+	Preview  string `json:"preview,omitempty"`
 }
 
+// ScheduleManager lists and cancels the scheduled messages of a chat.
+type ScheduleManager interface {
+	ListScheduled(ctx context.Context, chatID string) ([]ScheduledMessage, error)
+	CancelScheduled(ctx context.Context, chatID, id string) error
+}
+
+// ErrNoScheduleManager is returned by Scheduled for channels that cannot
+// schedule messages.
+var ErrNoScheduleManager = errors.New("channel has no scheduled messages")
+
 // MessageBus decouples channels from the agent core.
 type MessageBus struct {
-	inbound  chan *InboundMessage
-	outbound chan *OutboundMessage
-	subs     map[string][]func(*OutboundMessage)
-	running  bool
-	mu       sync.RWMutex
+	inbound      chan *InboundMessage
+	outbound     chan *OutboundMessage
+	subs         map[string][]func(*OutboundMessage)
+	presenceSubs map[string][]func(*PresenceEvent)
+	threadReader map[string]ThreadReader
+	polls        map[string]PollManager
+	schedules    map[string]ScheduleManager
+	limits       map[string]int // channel -> max message chars (see SetMessageLimit)
+	filter       OutboundFilter
+	running      bool
+	mu           sync.RWMutex
+
+	// Inbound dedupe (see EnableDedupe); seen maps dedupe key to first sight.
+	dedupeMu     sync.Mutex
+	dedupeWindow time.Duration
+	seen         map[string]time.Time
+	lastSweep    time.Time
+	onDuplicate  func(msg *InboundMessage, key string)
 }
 
 // NewMessageBus creates a new message bus.
 func NewMessageBus() *MessageBus {
 	return &MessageBus{
-		inbound:  make(chan *InboundMessage, 100),
-		outbound: make(chan *OutboundMessage, 100),
-		subs:     make(map[string][]func(*OutboundMessage)),
+		inbound:      make(chan *InboundMessage, 100),
+		outbound:     make(chan *OutboundMessage, 100),
+		subs:         make(map[string][]func(*OutboundMessage)),
+		presenceSubs: make(map[string][]func(*PresenceEvent)),
+		threadReader: make(map[string]ThreadReader),
+		polls:        make(map[string]PollManager),
+		schedules:    make(map[string]ScheduleManager),
+		limits:       make(map[string]int),
+	}
+}
+
+// EnableDedupe drops inbound messages whose DedupeKey was already published
+// within window. onDuplicate, if set, is called for every dropped message.
+// A window <= 0 disables dedupe.
+func (b *MessageBus) EnableDedupe(window time.Duration, onDuplicate func(msg *InboundMessage, key string)) {
+	b.dedupeMu.Lock()
+	defer b.dedupeMu.Unlock()
+	b.dedupeWindow = window
+	b.onDuplicate = onDuplicate
+	b.seen = make(map[string]time.Time)
+}
+
+// DedupeKey derives the canonical dedupe key of an inbound message: the
+// platform message ID scoped by channel and chat, else the idempotency key
+// scoped by channel. Messages with neither are never deduplicated.
+func DedupeKey(msg *InboundMessage) string {
+	channel := strings.ToLower(strings.TrimSpace(msg.Channel))
+	if id := strings.TrimSpace(msg.MessageID); id != "" {
+		return channel + "|" + strings.TrimSpace(msg.ChatID) + "|" + id
+	}
+	if key := strings.TrimSpace(msg.IdempotencyKey); key != "" {
+		return channel + "|idem|" + key
+	}
+	return ""
+}
+
+// isDuplicate records msg and reports whether its key was seen within the
+// dedupe window.
+func (b *MessageBus) isDuplicate(msg *InboundMessage) (string, bool) {
+	b.dedupeMu.Lock()
+	defer b.dedupeMu.Unlock()
+	if b.dedupeWindow <= 0 {
+		return "", false
+	}
+	key := DedupeKey(msg)
+	if key == "" {
+		return "", false
+	}
+	now := time.Now()
+	if now.Sub(b.lastSweep) >= b.dedupeWindow {
+		for k, at := range b.seen {
+			if now.Sub(at) >= b.dedupeWindow {
+				delete(b.seen, k)
+			}
+		}
+		b.lastSweep = now
+	}
+	if at, ok := b.seen[key]; ok && now.Sub(at) < b.dedupeWindow {
+		return key, true
 	}
+	b.seen[key] = now
+	return key, false
 }
 
-// PublishInbound sends a message from a channel to the agent.
+// PublishInbound sends a message from a channel to the agent. Duplicates are
+// dropped when dedupe is enabled.
 func (b *MessageBus) PublishInbound(msg *InboundMessage) {
 	if msg.Timestamp.IsZero() {
 		msg.Timestamp = time.Now()
 	}
+	if key, dup := b.isDuplicate(msg); dup {
+		dupTotal.Inc(msg.Channel)
+		b.dedupeMu.Lock()
+		onDuplicate := b.onDuplicate
+		b.dedupeMu.Unlock()
+		if onDuplicate != nil {
+			onDuplicate(msg, key)
+		}
+		return
+	}
+	inboundTotal.Inc(msg.Channel)
 	b.inbound <- msg
 }
 
@@ -98,6 +302,7 @@ func (b *MessageBus) ConsumeInbound(ctx context.Context) (*InboundMessage, error
 
 // PublishOutbound sends a message from the agent to channels.
 func (b *MessageBus) PublishOutbound(msg *OutboundMessage) {
+	outboundTotal.Inc(msg.Channel)
 	b.outbound <- msg
 }
 
@@ -109,7 +314,101 @@ func (b *MessageBus) Subscribe(channel string, callback func(*OutboundMessage))
 	b.subs[channel] = append(b.subs[channel], callback)
 }
 
-// DispatchOutbound runs the outbound message dispatcher.
+// SubscribePresence registers a callback for presence events to a channel.
+func (b *MessageBus) SubscribePresence(channel string, callback func(*PresenceEvent)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.presenceSubs[channel] = append(b.presenceSubs[channel], callback)
+}
+
+// PublishPresence hands a presence event to the channel's presence
+// subscribers, each on its own goroutine so a slow platform call never blocks
+// the agent. Events for channels without subscribers are dropped.
+func (b *MessageBus) PublishPresence(evt *PresenceEvent) {
+	b.mu.RLock()
+	callbacks := b.presenceSubs[evt.Channel]
+	b.mu.RUnlock()
+	for _, cb := range callbacks {
+		go cb(evt)
+	}
+}
+
+// RegisterThreadReader sets the thread history reader of a channel.
+func (b *MessageBus) RegisterThreadReader(channel string, reader ThreadReader) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.threadReader[channel] = reader
+}
+
+// ReadThread fetches thread history through the channel's reader. Unlike
+// outbound messages this is a synchronous request to the platform.
+func (b *MessageBus) ReadThread(ctx context.Context, channel, chatID, threadID string, limit int) ([]ThreadMessage, error) {
+	b.mu.RLock()
+	reader := b.threadReader[channel]
+	b.mu.RUnlock()
+	if reader == nil {
+		return nil, ErrNoThreadReader
+	}
+	return reader(ctx, chatID, threadID, limit)
+}
+
+// RegisterPollManager sets the poll manager of a channel.
+func (b *MessageBus) RegisterPollManager(channel string, m PollManager) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.polls[channel] = m
+}
+
+// Polls returns the poll manager of a channel, or ErrNoPollManager.
+func (b *MessageBus) Polls(channel string) (PollManager, error) {
+	b.mu.RLock()
+	m := b.polls[channel]
+	b.mu.RUnlock()
+	if m == nil {
+		return nil, ErrNoPollManager
+	}
+	return m, nil
+}
+
+// RegisterScheduleManager sets the schedule manager of a channel. Channels
+// with one honor OutboundMessage.SendAt.
+func (b *MessageBus) RegisterScheduleManager(channel string, m ScheduleManager) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.schedules[channel] = m
+}
+
+// Scheduled returns the schedule manager of a channel, or
+// ErrNoScheduleManager.
+func (b *MessageBus) Scheduled(channel string) (ScheduleManager, error) {
+	b.mu.RLock()
+	m := b.schedules[channel]
+	b.mu.RUnlock()
+	if m == nil {
+		return nil, ErrNoScheduleManager
+	}
+	return m, nil
+}
+
+// OutboundFilter rewrites an outbound message before it reaches the channel
+// subscribers. It returns the message to deliver, or nil to drop it.
+type OutboundFilter func(ctx context.Context, msg *OutboundMessage) *OutboundMessage
+
+// SetOutboundFilter installs the filter every outbound message passes once,
+// whoever published it.
+func (b *MessageBus) SetOutboundFilter(f OutboundFilter) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.filter = f
+}
+
+// DispatchOutbound runs the outbound message dispatcher. Messages pass the
+// outbound filter; messages longer than the channel's message limit reach
+// subscribers as consecutive parts.
 // This should be run as a goroutine.
 func (b *MessageBus) DispatchOutbound(ctx context.Context) error {
 	b.mu.Lock()
@@ -123,10 +422,19 @@ func (b *MessageBus) DispatchOutbound(ctx context.Context) error {
 		case msg := <-b.outbound:
 			b.mu.RLock()
 			callbacks := b.subs[msg.Channel]
+			filter := b.filter
 			b.mu.RUnlock()
 
+			if filter != nil {
+				if msg = filter(ctx, msg); msg == nil {
+					continue
+				}
+			}
+			parts := b.splitOutbound(msg)
 			for _, cb := range callbacks {
-				cb(msg)
+				for _, part := range parts {
+					cb(part)
+				}
 			}
 		}
 	}
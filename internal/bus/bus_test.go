@@ -2,6 +2,8 @@ package bus
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 )
@@ -81,3 +83,102 @@ func TestConsumeInboundCanceled(t *testing.T) {
 		t.Fatal("expected cancellation error")
 	}
 }
+
+func TestMessageBusDedupe(t *testing.T) {
+	b := NewMessageBus()
+	var dropped []string
+	b.EnableDedupe(50*time.Millisecond, func(msg *InboundMessage, key string) { dropped = append(dropped, key) })
+
+	b.PublishInbound(&InboundMessage{Channel: "slack", ChatID: "C1", MessageID: "1700.01", IdempotencyKey: "a"})
+	// Channel retry with a different idempotency key but the same platform ID.
+	b.PublishInbound(&InboundMessage{Channel: "Slack", ChatID: "C1", MessageID: " 1700.01", IdempotencyKey: "b"})
+	// Same message ID in another chat is not a duplicate.
+	b.PublishInbound(&InboundMessage{Channel: "slack", ChatID: "C2", MessageID: "1700.01"})
+	b.PublishInbound(&InboundMessage{Channel: "whatsapp", IdempotencyKey: "wa:X"})
+	b.PublishInbound(&InboundMessage{Channel: "whatsapp", IdempotencyKey: "wa:X"})
+	// No key at all: always delivered.
+	b.PublishInbound(&InboundMessage{Channel: "cli", Content: "hi"})
+	b.PublishInbound(&InboundMessage{Channel: "cli", Content: "hi"})
+
+	if b.InboundSize() != 5 {
+		t.Fatalf("expected 5 delivered messages, got %d", b.InboundSize())
+	}
+	if len(dropped) != 2 || dropped[0] != "slack|C1|1700.01" || dropped[1] != "whatsapp|idem|wa:X" {
+		t.Fatalf("unexpected dropped keys: %v", dropped)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	b.PublishInbound(&InboundMessage{Channel: "whatsapp", IdempotencyKey: "wa:X"})
+	if b.InboundSize() != 6 {
+		t.Fatalf("expected redelivery after the window, got %d", b.InboundSize())
+	}
+}
+
+func TestMessageBusPresence(t *testing.T) {
+	b := NewMessageBus()
+	got := make(chan *PresenceEvent, 1)
+	b.SubscribePresence("whatsapp", func(evt *PresenceEvent) { got <- evt })
+
+	b.PublishPresence(&PresenceEvent{Channel: "slack", ChatID: "C1", Kind: PresenceTyping})
+	b.PublishPresence(&PresenceEvent{Channel: "whatsapp", ChatID: "c1", Kind: PresenceTyping})
+	select {
+	case evt := <-got:
+		if evt.ChatID != "c1" {
+			t.Fatalf("unexpected event: %+v", evt)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected presence event")
+	}
+	select {
+	case evt := <-got:
+		t.Fatalf("event for unsubscribed channel delivered: %+v", evt)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestMessageBusOutboundFilter(t *testing.T) {
+	b := NewMessageBus()
+	b.SetOutboundFilter(func(_ context.Context, msg *OutboundMessage) *OutboundMessage {
+		if msg.Content == "drop" {
+			return nil
+		}
+		out := *msg
+		out.Content = strings.ToUpper(msg.Content)
+		return &out
+	})
+	got := make(chan *OutboundMessage, 2)
+	b.Subscribe("slack", func(msg *OutboundMessage) { got <- msg })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = b.DispatchOutbound(ctx) }()
+
+	b.PublishOutbound(&OutboundMessage{Channel: "slack", ChatID: "C1", Content: "drop"})
+	b.PublishOutbound(&OutboundMessage{Channel: "slack", ChatID: "C1", Content: "hello"})
+	select {
+	case msg := <-got:
+		if msg.Content != "HELLO" {
+			t.Fatalf("expected the filtered message, got %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected outbound message")
+	}
+	select {
+	case msg := <-got:
+		t.Fatalf("dropped message delivered: %+v", msg)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestMessageBusReadThread(t *testing.T) {
+	b := NewMessageBus()
+	if _, err := b.ReadThread(context.Background(), "slack", "C1", "1.0", 5); !errors.Is(err, ErrNoThreadReader) {
+		t.Fatalf("expected ErrNoThreadReader, got %v", err)
+	}
+	b.RegisterThreadReader("slack", func(_ context.Context, chatID, threadID string, limit int) ([]ThreadMessage, error) {
+		return []ThreadMessage{{ID: threadID, Text: chatID}}, nil
+	})
+	msgs, err := b.ReadThread(context.Background(), "slack", "C1", "1.0", 5)
+	if err != nil || len(msgs) != 1 || msgs[0].ID != "1.0" || msgs[0].Text != "C1" {
+		t.Fatalf("unexpected read: %+v %v", msgs, err)
+	}
+}
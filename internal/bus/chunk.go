@@ -0,0 +1,207 @@
+package bus
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// minMessageLimit is the smallest size limit a channel may set; below it code
+// fences and continuation markers would not fit.
+const minMessageLimit = 64
+
+// partMarkerReserve is the room kept free in every part for its continuation
+// marker, e.g. "\n\n(12/345)".
+const partMarkerReserve = 12
+
+// SetMessageLimit sets the maximum message length of a channel in characters.
+// Longer outbound messages are split by the dispatcher; zero or less disables
+// splitting.
+func (b *MessageBus) SetMessageLimit(channel string, maxChars int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if maxChars > 0 && maxChars < minMessageLimit {
+		maxChars = minMessageLimit
+	}
+	b.limits[channel] = maxChars
+}
+
+// MessageLimit returns the maximum message length of a channel, 0 if unlimited.
+func (b *MessageBus) MessageLimit(channel string) int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.limits[channel]
+}
+
+// splitOutbound splits msg into parts that fit the channel's message limit.
+// Each part carries a numbered continuation marker and Part/Parts; media,
+// cards, templates and polls ride on the last part. Actions are never split.
+func (b *MessageBus) splitOutbound(msg *OutboundMessage) []*OutboundMessage {
+	limit := b.MessageLimit(msg.Channel)
+	if limit <= 0 || msg.Action != "" || utf8.RuneCountInString(msg.Content) <= limit {
+		return []*OutboundMessage{msg}
+	}
+	chunks := chunkText(msg.Content, limit-partMarkerReserve)
+	if len(chunks) < 2 {
+		return []*OutboundMessage{msg}
+	}
+	outboundSplitTotal.Inc(msg.Channel)
+	parts := make([]*OutboundMessage, 0, len(chunks))
+	for i, chunk := range chunks {
+		part := *msg
+		part.Content = fmt.Sprintf("%s\n\n(%d/%d)", chunk, i+1, len(chunks))
+		part.Part = i + 1
+		part.Parts = len(chunks)
+		if i < len(chunks)-1 {
+			part.MediaURLs = nil
+			part.Card = nil
+			part.Template = ""
+			part.TemplateVars = nil
+			part.PollQuestion = ""
+			part.PollOptions = nil
+			part.PollMaxSelections = 0
+		}
+		parts = append(parts, &part)
+	}
+	return parts
+}
+
+// chunkText splits text into chunks of at most maxChars characters. It breaks
+// at paragraphs where possible, otherwise at lines, and splits overlong lines
+// at spaces. A code fence open at a break is closed in the chunk and reopened
+// in the next one, so every chunk renders on its own.
+func chunkText(text string, maxChars int) []string {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	if maxChars <= 0 || utf8.RuneCountInString(text) <= maxChars {
+		return []string{text}
+	}
+
+	var (
+		chunks []string
+		cur    []string // lines of the current chunk
+		size   int      // length of cur joined by newlines
+		fence  string   // opener of the code fence cur ends inside
+		cut    int      // lines of cur before the last paragraph break outside a fence
+		fresh  bool     // cur holds only the fence reopened after a break
+	)
+	emit := func(lines []string, closeFence bool) {
+		out := strings.TrimSpace(strings.Join(lines, "\n"))
+		if out == "" {
+			return
+		}
+		if closeFence {
+			out += "\n```"
+		}
+		chunks = append(chunks, out)
+	}
+	resize := func() {
+		size = 0
+		for i, l := range cur {
+			if i > 0 {
+				size++
+			}
+			size += utf8.RuneCountInString(l)
+		}
+	}
+
+	for _, raw := range strings.Split(text, "\n") {
+		for _, line := range splitLongLine(raw, maxChars/2) {
+			nextFence := fence
+			if togglesFence(line) {
+				if fence == "" {
+					nextFence = fenceOpener(line)
+				} else {
+					nextFence = ""
+				}
+			}
+			need := func() int {
+				n := utf8.RuneCountInString(line)
+				if len(cur) > 0 {
+					n++
+				}
+				if nextFence != "" {
+					n += len("\n```")
+				}
+				return n
+			}
+			// A line that does not fit even after the reopened fence goes
+			// in anyway; breaking again would only repeat the fence.
+			for len(cur) > 0 && !fresh && size+need() > maxChars {
+				if cut > 0 {
+					emit(cur[:cut], false)
+					cur = append([]string(nil), cur[cut:]...)
+					for len(cur) > 0 && strings.TrimSpace(cur[0]) == "" {
+						cur = cur[1:]
+					}
+				} else {
+					emit(cur, fence != "")
+					cur = nil
+					if fence != "" {
+						cur = []string{fence}
+						fresh = true
+					}
+				}
+				cut = 0
+				resize()
+			}
+			if len(cur) > 0 {
+				size++
+			}
+			cur = append(cur, line)
+			size += utf8.RuneCountInString(line)
+			fence = nextFence
+			fresh = false
+			if strings.TrimSpace(line) == "" && fence == "" && len(cur) > 1 {
+				cut = len(cur) - 1
+			}
+		}
+	}
+	emit(cur, fence != "")
+	return chunks
+}
+
+// togglesFence reports whether line opens or closes a code fence. A line
+// that opens and closes a fence, like ```code```, leaves it as it was.
+func togglesFence(line string) bool {
+	t := strings.TrimSpace(line)
+	return strings.HasPrefix(t, "```") && !strings.Contains(t[3:], "```")
+}
+
+// maxFenceOpener is the longest opener reopened after a break; a longer
+// info string is dropped so the reopened fence stays short.
+const maxFenceOpener = 20
+
+// fenceOpener returns the line that reopens the fence opened by line: the
+// backticks and the language, if any.
+func fenceOpener(line string) string {
+	opener := strings.Fields(line)[0]
+	if utf8.RuneCountInString(opener) > maxFenceOpener {
+		return "```"
+	}
+	return opener
+}
+
+// splitLongLine splits a line longer than maxChars at spaces, or hard at
+// maxChars when a word is longer.
+func splitLongLine(line string, maxChars int) []string {
+	if maxChars <= 0 || utf8.RuneCountInString(line) <= maxChars {
+		return []string{line}
+	}
+	var out []string
+	rest := []rune(line)
+	for len(rest) > maxChars {
+		at := maxChars
+		for i := maxChars; i > maxChars/2; i-- {
+			if rest[i] == ' ' {
+				at = i
+				break
+			}
+		}
+		out = append(out, strings.TrimRight(string(rest[:at]), " "))
+		rest = []rune(strings.TrimLeft(string(rest[at:]), " "))
+	}
+	if len(rest) > 0 {
+		out = append(out, string(rest))
+	}
+	return out
+}
@@ -0,0 +1,151 @@
+package bus
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestChunkTextSplitsOnParagraphs(t *testing.T) {
+	paras := []string{
+		strings.Repeat("a", 60),
+		strings.Repeat("b", 60),
+		strings.Repeat("c", 60),
+	}
+	chunks := chunkText(strings.Join(paras, "\n\n"), 130)
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %d: %q", len(chunks), chunks)
+	}
+	if chunks[0] != paras[0]+"\n\n"+paras[1] || chunks[1] != paras[2] {
+		t.Fatalf("expected paragraph boundaries, got %q", chunks)
+	}
+}
+
+func TestChunkTextReopensCodeFence(t *testing.T) {
+	var code []string
+	for i := 0; i < 20; i++ {
+		code = append(code, "fmt.Println(\"line\")")
+	}
+	text := "Example:\n\n```go\n" + strings.Join(code, "\n") + "\n```\n\nDone."
+	chunks := chunkText(text, 150)
+	if len(chunks) < 3 {
+		t.Fatalf("expected the code block to span chunks, got %q", chunks)
+	}
+	for i, c := range chunks {
+		if n := utf8.RuneCountInString(c); n > 150 {
+			t.Fatalf("chunk %d has %d chars", i, n)
+		}
+		if strings.Count(c, "```")%2 != 0 {
+			t.Fatalf("chunk %d has an unbalanced fence: %q", i, c)
+		}
+	}
+	if !strings.HasPrefix(chunks[2], "```go\n") {
+		t.Fatalf("expected continued chunk to reopen the fence, got %q", chunks[2])
+	}
+	if !strings.HasSuffix(chunks[len(chunks)-1], "Done.") {
+		t.Fatalf("expected trailing text in last chunk, got %q", chunks[len(chunks)-1])
+	}
+}
+
+func TestChunkTextSplitsLongLines(t *testing.T) {
+	text := strings.Repeat("word ", 100)
+	chunks := chunkText(text, 80)
+	if len(chunks) < 7 {
+		t.Fatalf("expected the line to be split, got %d chunks", len(chunks))
+	}
+	if got := strings.Join(strings.Fields(strings.Join(chunks, " ")), " "); got != strings.TrimSpace(text) {
+		t.Fatal("expected no words to be lost")
+	}
+	for i, c := range chunks {
+		if utf8.RuneCountInString(c) > 80 {
+			t.Fatalf("chunk %d too long: %d", i, utf8.RuneCountInString(c))
+		}
+	}
+}
+
+func TestDispatchOutboundSplitsLongMessages(t *testing.T) {
+	b := NewMessageBus()
+	b.SetMessageLimit("slack", 100)
+	recv := make(chan *OutboundMessage, 10)
+	b.Subscribe("slack", func(msg *OutboundMessage) { recv <- msg })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go b.DispatchOutbound(ctx)
+
+	content := strings.Repeat("x", 70) + "\n\n" + strings.Repeat("y", 70) + "\n\n" + strings.Repeat("z", 70)
+	b.PublishOutbound(&OutboundMessage{
+		Channel: "slack",
+		ChatID:  "C1",
+		TaskID:  "task-1",
+		Content: content,
+		Card:    map[string]any{"type": "AdaptiveCard"},
+	})
+
+	var parts []*OutboundMessage
+	for len(parts) < 3 {
+		select {
+		case msg := <-recv:
+			parts = append(parts, msg)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("expected 3 parts, got %d", len(parts))
+		}
+	}
+	for i, p := range parts {
+		if p.Part != i+1 || p.Parts != 3 || p.TaskID != "task-1" {
+			t.Fatalf("part %d: unexpected numbering %+v", i, p)
+		}
+		if utf8.RuneCountInString(p.Content) > 100 {
+			t.Fatalf("part %d exceeds the limit: %d", i, utf8.RuneCountInString(p.Content))
+		}
+	}
+	if !strings.HasSuffix(parts[0].Content, "(1/3)") || !strings.HasSuffix(parts[2].Content, "(3/3)") {
+		t.Fatalf("expected continuation markers, got %q / %q", parts[0].Content, parts[2].Content)
+	}
+	if parts[0].Card != nil || parts[2].Card == nil {
+		t.Fatal("expected the card on the last part only")
+	}
+
+	b.PublishOutbound(&OutboundMessage{Channel: "slack", ChatID: "C1", Content: "short"})
+	select {
+	case msg := <-recv:
+		if msg.Content != "short" || msg.Parts != 0 {
+			t.Fatalf("expected short message unchanged, got %+v", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected short message")
+	}
+}
+
+func TestChunkTextLongFenceLineTerminates(t *testing.T) {
+	text := "```" + strings.Repeat("x", 3600) + "\nend"
+	done := make(chan []string, 1)
+	go func() { done <- chunkText(text, 3488) }()
+	var chunks []string
+	select {
+	case chunks = <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("chunkText did not return")
+	}
+	for i, c := range chunks {
+		if n := utf8.RuneCountInString(c); n > 3488 {
+			t.Fatalf("chunk %d has %d chars", i, n)
+		}
+	}
+	if got := strings.Count(strings.Join(chunks, ""), "x"); got != 3600 {
+		t.Fatalf("expected all 3600 characters kept, got %d", got)
+	}
+	if !strings.HasSuffix(chunks[len(chunks)-1], "end\n```") {
+		t.Fatalf("expected the last chunk to end the fenced text, got %q", chunks[len(chunks)-1])
+	}
+}
+
+func TestChunkTextInlineFenceDoesNotOpen(t *testing.T) {
+	text := "```x``` inline\n\n" + strings.Repeat("a", 60) + "\n\n" + strings.Repeat("b", 60)
+	for i, c := range chunkText(text, 90) {
+		if strings.HasSuffix(c, "\n```") {
+			t.Fatalf("chunk %d was closed as a fence: %q", i, c)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package channels
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+	"github.com/KafClaw/KafClaw/internal/tracing"
+)
+
+// BridgeTask identifies the agent task of a bridged inbound message. The
+// gateway answers the bridge with it before the agent picks the message up;
+// the reply is delivered through the normal outbound path.
+type BridgeTask struct {
+	TaskID    string `json:"task_id"`
+	TraceID   string `json:"trace_id"`
+	Status    string `json:"status"`
+	Duplicate bool   `json:"duplicate,omitempty"`
+}
+
+// bridgeIdempotencyKey derives the task idempotency key of a bridged message
+// from its platform message ID, so redeliveries map to the same key. It is
+// "" for a message without an ID, which cannot be told from a new one.
+func bridgeIdempotencyKey(msg *bus.InboundMessage) string {
+	if strings.TrimSpace(msg.MessageID) == "" {
+		return ""
+	}
+	return "bridge:" + bus.DedupeKey(msg)
+}
+
+// applyTraceparent makes a valid W3C traceparent of the bridge request the
+// trace of msg and keeps the header for the timeline.
+func applyTraceparent(msg *bus.InboundMessage, header string) {
+	p, ok := tracing.Parse(header)
+	if !ok {
+		return
+	}
+	msg.TraceID = p.TraceID
+	if msg.Metadata == nil {
+		msg.Metadata = map[string]any{}
+	}
+	msg.Metadata[bus.MetaKeyTraceparent] = p.String()
+}
+
+// BridgeTraceparent returns the traceparent of a bridge inbound request: its
+// header when valid, else a span of the trace_id the bridge also puts in the
+// body, for proxies that drop the header. It is "" when neither is usable.
+func BridgeTraceparent(header, traceID string) string {
+	if _, ok := tracing.Parse(header); ok {
+		return strings.TrimSpace(header)
+	}
+	id := strings.ToLower(strings.TrimSpace(traceID))
+	if id == "" || tracing.TraceIDFor(id) != id {
+		return ""
+	}
+	return tracing.Child(id)
+}
+
+// acceptBridgeMessage creates the pending task of msg and publishes it. A
+// redelivery of a message that already has a task returns that task and is
+// not published again, so bridge retries never duplicate work. Messages
+// without a platform message ID always get a new task.
+func acceptBridgeMessage(tl *timeline.TimelineService, publish func(*bus.InboundMessage), msg *bus.InboundMessage) (*BridgeTask, error) {
+	if msg.TraceID == "" {
+		msg.TraceID = fmt.Sprintf("trace-%d", time.Now().UnixNano())
+	}
+	msg.IdempotencyKey = bridgeIdempotencyKey(msg)
+	deduped := msg.IdempotencyKey != ""
+	if !deduped {
+		// The key only ties the task to the agent's processing of msg.
+		msg.IdempotencyKey = fmt.Sprintf("bridge:%s:once:%d", msg.Channel, time.Now().UnixNano())
+	}
+	if tl == nil {
+		publish(msg)
+		return &BridgeTask{TraceID: msg.TraceID, Status: timeline.TaskStatusPending}, nil
+	}
+	if deduped {
+		if existing, err := tl.GetTaskByIdempotencyKey(msg.IdempotencyKey); err == nil && existing != nil {
+			return &BridgeTask{TaskID: existing.TaskID, TraceID: existing.TraceID, Status: existing.Status, Duplicate: true}, nil
+		}
+	}
+	task, err := tl.CreateTask(&timeline.AgentTask{
+		IdempotencyKey: msg.IdempotencyKey,
+		TraceID:        msg.TraceID,
+		Channel:        msg.Channel,
+		ChatID:         msg.ChatID,
+		SenderID:       msg.SenderID,
+		ContentIn:      msg.Content,
+		MessageType:    msg.MessageType(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	logTraceparent(tl, msg)
+	publish(msg)
+	return &BridgeTask{TaskID: task.TaskID, TraceID: task.TraceID, Status: task.Status}, nil
+}
+
+// logTraceparent records the inbound span of a message that arrived with a
+// traceparent, as a child of the caller's span.
+func logTraceparent(tl *timeline.TimelineService, msg *bus.InboundMessage) {
+	raw, _ := msg.Metadata[bus.MetaKeyTraceparent].(string)
+	p, ok := tracing.Parse(raw)
+	if !ok {
+		return
+	}
+	meta, _ := json.Marshal(map[string]any{
+		"channel":     msg.Channel,
+		"chat_id":     msg.ChatID,
+		"traceparent": raw,
+	})
+	_ = tl.AddEvent(&timeline.TimelineEvent{
+		EventID:        fmt.Sprintf("%s_IN_%d", strings.ToUpper(msg.Channel), time.Now().UnixNano()),
+		TraceID:        msg.TraceID,
+		SpanID:         tracing.NewSpanID(),
+		ParentSpanID:   p.SpanID,
+		Timestamp:      time.Now(),
+		SenderID:       msg.SenderID,
+		SenderName:     "User",
+		EventType:      "TEXT",
+		ContentText:    msg.Content,
+		Classification: strings.ToUpper(msg.Channel) + "_INBOUND",
+		Authorized:     true,
+		Metadata:       string(meta),
+	})
+}
+
+// LogDeliverySpan records the outbound span of a delivery receipt: the
+// platform messages the bridge created for a reply of trace rec.TraceID. The
+// receipt's traceparent, if any, is the parent span. Receipts without a trace
+// are not recorded.
+func LogDeliverySpan(tl *timeline.TimelineService, rec *timeline.DeliveryReceiptRecord, traceparent string) {
+	if tl == nil || rec == nil || strings.TrimSpace(rec.TraceID) == "" {
+		return
+	}
+	fields := map[string]any{
+		"channel":     rec.Channel,
+		"chat_id":     rec.ChatID,
+		"thread_id":   rec.ThreadID,
+		"message_ids": rec.MessageIDs,
+		"task_id":     rec.TaskID,
+	}
+	if len(rec.FileIDs) > 0 {
+		fields["file_ids"] = rec.FileIDs
+	}
+	parent := ""
+	if p, ok := tracing.Parse(traceparent); ok {
+		parent = p.SpanID
+		fields["traceparent"] = p.String()
+	}
+	meta, _ := json.Marshal(fields)
+	_ = tl.AddEvent(&timeline.TimelineEvent{
+		EventID:        fmt.Sprintf("%s_DELIVERED_%d", strings.ToUpper(rec.Channel), time.Now().UnixNano()),
+		TraceID:        strings.TrimSpace(rec.TraceID),
+		SpanID:         tracing.NewSpanID(),
+		ParentSpanID:   parent,
+		Timestamp:      time.Now(),
+		SenderID:       rec.Channel,
+		SenderName:     "Bridge",
+		EventType:      "SYSTEM",
+		ContentText:    rec.MessageID,
+		Classification: strings.ToUpper(rec.Channel) + "_OUTBOUND_DELIVERED",
+		Authorized:     true,
+		Metadata:       string(meta),
+	})
+}
+
+// MarkBridgeDeliveryFailed marks the delivery of task taskID as failed after
+// the bridge gave up on its reply. The bridge answers a send it queues with
+// 202, so the channel recorded the task as sent; the task must belong to
+// channel.
+func MarkBridgeDeliveryFailed(tl *timeline.TimelineService, channel, taskID, reason string) error {
+	task, err := tl.GetTask(strings.TrimSpace(taskID))
+	if err != nil {
+		return err
+	}
+	if task.Channel != channel {
+		return fmt.Errorf("task not found: %s", taskID)
+	}
+	return tl.UpdateTaskDeliveryWithReason(task.TaskID, timeline.DeliveryFailed, nil, "bridge_dead_letter: "+strings.TrimSpace(reason))
+}
@@ -4,8 +4,36 @@ import (
 	"context"
 
 	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/metrics"
+	"github.com/KafClaw/KafClaw/internal/msgtemplate"
+	"github.com/KafClaw/KafClaw/internal/tracing"
 )
 
+var sendsTotal = metrics.NewCounter("kafclaw_channel_sends_total", "Outbound channel deliveries, by channel and result (sent, error).", "channel", "result")
+
+// recordSend counts one outbound delivery attempt.
+func recordSend(channel string, err error) {
+	if err != nil {
+		sendsTotal.Inc(channel, "error")
+		return
+	}
+	sendsTotal.Inc(channel, "sent")
+}
+
+// sendTraced delivers msg with send inside an OpenTelemetry span of the
+// message's trace.
+func sendTraced(ctx context.Context, channel string, msg *bus.OutboundMessage, send func(context.Context, *bus.OutboundMessage) error) error {
+	ctx, span := tracing.Start(tracing.WithTraceID(ctx, msg.TraceID), "deliver "+channel, tracing.KindClient)
+	span.Set("messaging.system", channel)
+	span.Set("messaging.destination.name", msg.ChatID)
+	if msg.TaskID != "" {
+		span.Set("kafclaw.task_id", msg.TaskID)
+	}
+	err := send(ctx, msg)
+	span.End(err)
+	return err
+}
+
 // Channel defines the interface for chat platforms (Telegram, WhatsApp, etc).
 type Channel interface {
 	// Name returns the channel name (e.g. "telegram").
@@ -21,4 +49,7 @@ type Channel interface {
 // BaseChannel provides common functionality for channels.
 type BaseChannel struct {
 	Bus *bus.MessageBus
+	// Templates renders outbound messages that name a template (see
+	// renderTemplate).
+	Templates *msgtemplate.Registry
 }
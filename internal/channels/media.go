@@ -0,0 +1,231 @@
+package channels
+
+import (
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// maxInlineMediaBytes caps local files inlined as data URLs for the bridge.
+const maxInlineMediaBytes = 8 << 20
+
+var (
+	mediaDirMu sync.RWMutex
+	mediaDir   string
+)
+
+// SetMediaDir sets where channels store inbound media and which local files
+// they may send, normally the media folder of the configured workspace.
+func SetMediaDir(dir string) {
+	mediaDirMu.Lock()
+	defer mediaDirMu.Unlock()
+	mediaDir = strings.TrimSpace(dir)
+}
+
+// mediaRoot is where channels and tools keep media files. Without SetMediaDir
+// it is the media folder of the default workspace.
+func mediaRoot() string {
+	mediaDirMu.RLock()
+	dir := mediaDir
+	mediaDirMu.RUnlock()
+	if dir != "" {
+		return dir
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".kafclaw", "workspace", "media")
+}
+
+// bridgeMediaURLs converts local media files (absolute paths or file:// URLs
+// under the media directory) into data URLs the channel bridge can upload.
+// Remote URLs pass through unchanged; unreadable local files are dropped.
+func bridgeMediaURLs(urls []string) []string {
+	if len(urls) == 0 {
+		return urls
+	}
+	root := mediaRoot()
+	out := make([]string, 0, len(urls))
+	for _, u := range urls {
+		u = strings.TrimSpace(u)
+		local := strings.TrimPrefix(u, "file://")
+		if !filepath.IsAbs(local) {
+			if u != "" {
+				out = append(out, u)
+			}
+			continue
+		}
+		dataURL, err := inlineMediaFile(root, local)
+		if err != nil {
+			fmt.Printf("⚠️ Outbound media %s skipped: %v\n", local, err)
+			continue
+		}
+		out = append(out, dataURL)
+	}
+	return out
+}
+
+func inlineMediaFile(root, path string) (string, error) {
+	rel, err := filepath.Rel(root, filepath.Clean(path))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("outside media directory")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.Size() > maxInlineMediaBytes {
+		return "", fmt.Errorf("file too large (%d bytes)", info.Size())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	mimeType := http.DetectContentType(data)
+	if i := strings.Index(mimeType, ";"); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// maxInboundMediaBytes caps one attachment forwarded by the bridge.
+const maxInboundMediaBytes = 25 << 20
+
+// BridgeMedia is an attachment forwarded by the channel bridge: a data: URL
+// of a file the bridge downloaded, or a remote URL.
+type BridgeMedia struct {
+	URL      string
+	MimeType string
+	Name     string
+}
+
+// BridgeMediaFromRequest pairs the parallel media_urls, media_types and
+// media_names arrays of a bridge request.
+func BridgeMediaFromRequest(urls, types, names []string) []BridgeMedia {
+	var out []BridgeMedia
+	for i, u := range urls {
+		u = strings.TrimSpace(u)
+		if u == "" {
+			continue
+		}
+		m := BridgeMedia{URL: u}
+		if i < len(types) {
+			m.MimeType = strings.TrimSpace(types[i])
+		}
+		if i < len(names) {
+			m.Name = strings.TrimSpace(names[i])
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// storeBridgeMedia saves inline attachments under the media directory, in
+// the images, audio or documents folder by MIME type, and returns the paths
+// for the agent. Remote URLs pass through; undecodable attachments are
+// dropped.
+func storeBridgeMedia(channel, messageID string, media []BridgeMedia) []string {
+	if len(media) == 0 {
+		return nil
+	}
+	root := mediaRoot()
+	out := make([]string, 0, len(media))
+	for i, m := range media {
+		if !strings.HasPrefix(m.URL, "data:") {
+			out = append(out, m.URL)
+			continue
+		}
+		path, err := storeDataURL(root, channel, messageID, i, m)
+		if err != nil {
+			fmt.Printf("⚠️ Inbound %s media skipped: %v\n", channel, err)
+			continue
+		}
+		out = append(out, path)
+	}
+	return out
+}
+
+func storeDataURL(root, channel, messageID string, index int, m BridgeMedia) (string, error) {
+	header, payload, ok := strings.Cut(strings.TrimPrefix(m.URL, "data:"), ",")
+	if !ok || !strings.HasSuffix(header, ";base64") {
+		return "", fmt.Errorf("not a base64 data URL")
+	}
+	if base64.StdEncoding.DecodedLen(len(payload)) > maxInboundMediaBytes {
+		return "", fmt.Errorf("attachment too large")
+	}
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", err
+	}
+	mimeType := strings.ToLower(strings.TrimSpace(m.MimeType))
+	if mimeType == "" {
+		mimeType = strings.ToLower(strings.TrimSuffix(header, ";base64"))
+	}
+	dir := filepath.Join(root, mediaFolder(mimeType))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("%s-%s-%d%s", channel, sanitizeMediaPart(messageID), index, mediaExtension(m.Name, mimeType))
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func mediaFolder(mimeType string) string {
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		return "images"
+	case strings.HasPrefix(mimeType, "audio/"):
+		return "audio"
+	default:
+		return "documents"
+	}
+}
+
+var preferredMediaExt = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"audio/ogg":       ".ogg",
+	"audio/mpeg":      ".mp3",
+	"audio/mp4":       ".m4a",
+	"audio/wav":       ".wav",
+	"application/pdf": ".pdf",
+}
+
+// mediaExtension keeps the extension of the original file name, else picks
+// one for the MIME type; the agent classifies attachments by extension.
+func mediaExtension(name, mimeType string) string {
+	if ext := strings.ToLower(filepath.Ext(strings.TrimSpace(name))); ext != "" && len(ext) <= 8 && sanitizeMediaPart(ext[1:]) == ext[1:] {
+		return ext
+	}
+	if ext, ok := preferredMediaExt[mimeType]; ok {
+		return ext
+	}
+	if exts, _ := mime.ExtensionsByType(mimeType); len(exts) > 0 {
+		return exts[0]
+	}
+	return ".bin"
+}
+
+func sanitizeMediaPart(s string) string {
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "media"
+	}
+	return b.String()
+}
@@ -12,13 +12,16 @@ import (
 	"github.com/KafClaw/KafClaw/internal/bus"
 	"github.com/KafClaw/KafClaw/internal/config"
 	"github.com/KafClaw/KafClaw/internal/timeline"
+	"github.com/KafClaw/KafClaw/internal/tracing"
 )
 
 // MSTeamsChannel is a Teams transport scaffold with policy + pairing integration.
 type MSTeamsChannel struct {
 	BaseChannel
-	config   config.MSTeamsConfig
-	timeline *timeline.TimelineService
+	config      config.MSTeamsConfig
+	timeline    *timeline.TimelineService
+	parts       outboundParts
+	transcriber Transcriber
 }
 
 func NewMSTeamsChannel(cfg config.MSTeamsConfig, messageBus *bus.MessageBus, tl *timeline.TimelineService) *MSTeamsChannel {
@@ -31,12 +34,28 @@ func NewMSTeamsChannel(cfg config.MSTeamsConfig, messageBus *bus.MessageBus, tl
 
 func (c *MSTeamsChannel) Name() string { return "msteams" }
 
+// SetTranscriber enables transcription of voice notes forwarded by the
+// bridge.
+func (c *MSTeamsChannel) SetTranscriber(t Transcriber) { c.transcriber = t }
+
 func (c *MSTeamsChannel) Start(ctx context.Context) error {
 	if !c.config.Enabled {
 		return nil
 	}
+	c.Bus.SetMessageLimit(c.Name(), messageLimit(c.config.MaxMessageChars, msteamsMaxMessageChars))
 	c.Bus.Subscribe(c.Name(), func(msg *bus.OutboundMessage) {
-		if err := c.Send(ctx, msg); err != nil {
+		if c.parts.skip(msg) {
+			return
+		}
+		if deferForQuietHours(c.timeline, c.Name(), msg, time.Now()) {
+			return
+		}
+		err := sendTraced(ctx, c.Name(), msg, c.Send)
+		recordSend(c.Name(), err)
+		if !c.parts.finish(msg, err) {
+			return
+		}
+		if err != nil {
 			if c.timeline != nil && strings.TrimSpace(msg.TaskID) != "" {
 				reason, cls := classifyDeliveryError(err)
 				if cls == deliveryTransient {
@@ -52,12 +71,20 @@ func (c *MSTeamsChannel) Start(ctx context.Context) error {
 			_ = c.timeline.UpdateTaskDeliveryWithReason(msg.TaskID, timeline.DeliverySent, nil, "")
 		}
 	})
+	c.Bus.SubscribePresence(c.Name(), c.handlePresence)
+	c.Bus.RegisterThreadReader(c.Name(), c.ReadThread)
+	c.Bus.RegisterPollManager(c.Name(), c)
+	c.Bus.RegisterScheduleManager(c.Name(), c)
 	return nil
 }
 
 func (c *MSTeamsChannel) Stop() error { return nil }
 
 func (c *MSTeamsChannel) Send(ctx context.Context, msg *bus.OutboundMessage) error {
+	msg, err := c.renderTemplate(c.Name(), msg)
+	if err != nil {
+		return err
+	}
 	accountID, chatID := parseAccountChat(strings.TrimSpace(msg.ChatID))
 	ac := c.teamsAccountConfig(accountID)
 	if strings.TrimSpace(ac.OutboundURL) == "" {
@@ -67,22 +94,28 @@ func (c *MSTeamsChannel) Send(ctx context.Context, msg *bus.OutboundMessage) err
 		"channel":             "msteams",
 		"account_id":          accountID,
 		"chat_id":             strings.TrimSpace(chatID),
-		"thread_id":           strings.TrimSpace(msg.ThreadID),
+		"tenant_id":           strings.TrimSpace(ac.TenantID),
+		"thread_id":           strings.TrimSpace(c.parts.thread(msg)),
 		"content":             msg.Content,
-		"media_urls":          msg.MediaURLs,
+		"media_urls":          bridgeMediaURLs(msg.MediaURLs),
 		"card":                msg.Card,
 		"action":              strings.TrimSpace(msg.Action),
 		"action_params":       msg.ActionParams,
 		"poll_question":       strings.TrimSpace(msg.PollQuestion),
 		"poll_options":        msg.PollOptions,
 		"poll_max_selections": msg.PollMaxSelections,
+		"send_at":             strings.TrimSpace(msg.SendAt),
+		"visibility":          strings.TrimSpace(msg.Visibility),
+		"user_id":             strings.TrimSpace(msg.RecipientID),
 		"trace_id":            msg.TraceID,
+		"task_id":             msg.TaskID,
 	})
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ac.OutboundURL, bytes.NewReader(body))
 	if err != nil {
 		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	tracing.Inject(req, msg.TraceID)
 	if tok := strings.TrimSpace(ac.AppPassword); tok != "" {
 		req.Header.Set("Authorization", "Bearer "+tok)
 	}
@@ -94,6 +127,13 @@ func (c *MSTeamsChannel) Send(ctx context.Context, msg *bus.OutboundMessage) err
 	if resp.StatusCode >= 300 {
 		return fmt.Errorf("msteams outbound bridge status: %d", resp.StatusCode)
 	}
+	if msg.Parts > 1 {
+		var delivery struct {
+			MessageID string `json:"message_id"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&delivery)
+		c.parts.sent(msg, strings.TrimSpace(delivery.MessageID))
+	}
 	return nil
 }
 
@@ -110,6 +150,19 @@ func (c *MSTeamsChannel) HandleInboundWithContext(accountID, senderID, chatID, t
 }
 
 func (c *MSTeamsChannel) HandleInboundWithContextAndHints(accountID, senderID, chatID, threadID, messageID, text string, isGroup, wasMentioned bool, groupID, channelID string, historyLimit, dmHistoryLimit int) error {
+	_, err := c.handleInbound(accountID, senderID, chatID, threadID, messageID, text, isGroup, wasMentioned, groupID, channelID, historyLimit, dmHistoryLimit, nil, "", false)
+	return err
+}
+
+// AcceptInbound is HandleInboundWithContextAndHints for the asynchronous
+// bridge endpoint: it creates the agent task up front and returns it. The
+// task is nil when the message was dropped by policy or started pairing. A
+// valid traceparent of the bridge request becomes the trace of the task.
+func (c *MSTeamsChannel) AcceptInbound(accountID, senderID, chatID, threadID, messageID, text string, isGroup, wasMentioned bool, groupID, channelID string, historyLimit, dmHistoryLimit int, media []BridgeMedia, traceparent string) (*BridgeTask, error) {
+	return c.handleInbound(accountID, senderID, chatID, threadID, messageID, text, isGroup, wasMentioned, groupID, channelID, historyLimit, dmHistoryLimit, media, traceparent, true)
+}
+
+func (c *MSTeamsChannel) handleInbound(accountID, senderID, chatID, threadID, messageID, text string, isGroup, wasMentioned bool, groupID, channelID string, historyLimit, dmHistoryLimit int, media []BridgeMedia, traceparent string, accept bool) (*BridgeTask, error) {
 	ac := c.teamsAccountConfig(accountID)
 	targetAllowlistMode := isGroup && (ac.GroupPolicy == config.GroupPolicyAllowlist || strings.TrimSpace(string(ac.GroupPolicy)) == "") && hasTeamsGroupTargetEntries(ac.GroupAllowFrom)
 	groupAllowFrom := ac.GroupAllowFrom
@@ -132,26 +185,26 @@ func (c *MSTeamsChannel) HandleInboundWithContextAndHints(accountID, senderID, c
 	})
 	if decision.RequiresPairing {
 		if c.timeline == nil {
-			return nil
+			return nil, nil
 		}
 		svc := NewPairingService(c.timeline)
 		pending, err := svc.CreateOrGetPending(c.Name(), senderID, 0)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		c.Bus.PublishOutbound(&bus.OutboundMessage{
 			Channel: c.Name(),
 			ChatID:  withAccountChat(accountID, chatID),
 			Content: BuildPairingReply(c.Name(), fmt.Sprintf("MSTeams user: %s", strings.TrimSpace(senderID)), pending.Code),
 		})
-		return nil
+		return nil, nil
 	}
 	if !decision.Allowed {
-		return nil
+		return nil, nil
 	}
 	if isGroup && (ac.GroupPolicy == config.GroupPolicyAllowlist || strings.TrimSpace(string(ac.GroupPolicy)) == "") {
 		if enforced, allowed := matchTeamsGroupTargetAllowlist(ac.GroupAllowFrom, groupID, channelID); enforced && !allowed {
-			return nil
+			return nil, nil
 		}
 	}
 	scopedChatID := withAccountChat(accountID, chatID)
@@ -169,16 +222,23 @@ func (c *MSTeamsChannel) HandleInboundWithContextAndHints(accountID, senderID, c
 	if dmHistoryLimit > 0 {
 		metadata["dm_history_limit"] = dmHistoryLimit
 	}
-	c.Bus.PublishInbound(&bus.InboundMessage{
+	msg := &bus.InboundMessage{
 		Channel:   c.Name(),
 		SenderID:  strings.TrimSpace(senderID),
 		ChatID:    strings.TrimSpace(scopedChatID),
 		ThreadID:  strings.TrimSpace(threadID),
 		MessageID: strings.TrimSpace(messageID),
 		Content:   text,
+		Media:     storeBridgeMedia(c.Name(), messageID, media),
 		Metadata:  metadata,
-	})
-	return nil
+	}
+	applyTraceparent(msg, traceparent)
+	publish := publishTranscribed(c.Bus, c.transcriber)
+	if accept {
+		return acceptBridgeMessage(c.timeline, publish, msg)
+	}
+	publish(msg)
+	return nil, nil
 }
 
 func matchTeamsGroupTargetAllowlist(entries []string, groupID, channelID string) (enforced bool, allowed bool) {
@@ -0,0 +1,113 @@
+package channels
+
+import (
+	"sync"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+)
+
+// Default outbound message limits in characters. Slack matches the bridge's
+// chunk size below the 4000-character block limit, Teams stays under the
+// activity payload limit, Discord and Telegram match their message limits
+// (2000 and 4096 characters) and WhatsApp keeps replies readable on a phone.
+const (
+	slackMaxMessageChars    = 3500
+	msteamsMaxMessageChars  = 24000
+	discordMaxMessageChars  = 2000
+	telegramMaxMessageChars = 4096
+	whatsappMaxMessageChars = 4096
+)
+
+// messageLimit resolves a configured message limit: 0 selects the channel
+// default, a negative value disables splitting.
+func messageLimit(configured, def int) int {
+	switch {
+	case configured < 0:
+		return 0
+	case configured == 0:
+		return def
+	default:
+		return configured
+	}
+}
+
+// outboundParts follows the parts of outbound messages the bus split to fit
+// the channel's message limit. Parts of one message reach a channel
+// consecutively, so one sequence per chat suffices. A failed part stops the
+// rest, and follow-up parts of an unthreaded message reply to the first.
+type outboundParts struct {
+	mu  sync.Mutex
+	seq map[string]*partSequence
+}
+
+type partSequence struct {
+	threadID string
+	failed   bool
+}
+
+// skip reports whether msg is a part of a message whose earlier part failed.
+func (p *outboundParts) skip(msg *bus.OutboundMessage) bool {
+	if msg.Parts < 2 {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.seq == nil {
+		p.seq = map[string]*partSequence{}
+	}
+	if msg.Part <= 1 {
+		p.seq[msg.ChatID] = &partSequence{}
+		return false
+	}
+	s := p.seq[msg.ChatID]
+	return s != nil && s.failed
+}
+
+// thread returns the thread a follow-up part replies to: its own, or the
+// message created by the first part.
+func (p *outboundParts) thread(msg *bus.OutboundMessage) string {
+	if msg.ThreadID != "" || msg.Part < 2 {
+		return msg.ThreadID
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if s := p.seq[msg.ChatID]; s != nil {
+		return s.threadID
+	}
+	return ""
+}
+
+// sent records the platform message created by the first part of an
+// unthreaded message.
+func (p *outboundParts) sent(msg *bus.OutboundMessage, messageID string) {
+	if msg.Parts < 2 || msg.Part != 1 || msg.ThreadID != "" || messageID == "" {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if s := p.seq[msg.ChatID]; s != nil {
+		s.threadID = messageID
+	}
+}
+
+// finish records the outcome of msg and reports whether the delivery of the
+// whole message is settled: on a failure, or after the last part.
+func (p *outboundParts) finish(msg *bus.OutboundMessage, err error) bool {
+	if msg.Parts < 2 {
+		return true
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := p.seq[msg.ChatID]
+	if err != nil {
+		if s != nil {
+			s.failed = true
+		}
+		return true
+	}
+	if msg.Part >= msg.Parts {
+		delete(p.seq, msg.ChatID)
+		return true
+	}
+	return false
+}
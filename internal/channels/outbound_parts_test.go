@@ -0,0 +1,100 @@
+package channels
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+func TestSlackSplitMessageThreadsFollowUps(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		got  []map[string]any
+		fail int // 1-based request number answered with an error
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		got = append(got, body)
+		n := len(got)
+		mu.Unlock()
+		if n == fail {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "message_id": fmt.Sprintf("17000.%d", n)})
+	}))
+	defer srv.Close()
+
+	msgBus := bus.NewMessageBus()
+	ch := NewSlackChannel(config.SlackConfig{Enabled: true, OutboundURL: srv.URL, MaxMessageChars: 100}, msgBus, nil)
+	if err := ch.Start(t.Context()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if msgBus.MessageLimit("slack") != 100 {
+		t.Fatalf("expected configured limit, got %d", msgBus.MessageLimit("slack"))
+	}
+	go msgBus.DispatchOutbound(t.Context())
+
+	long := strings.Repeat("a", 70) + "\n\n" + strings.Repeat("b", 70) + "\n\n" + strings.Repeat("c", 70)
+	waitRequests := func(n int) []map[string]any {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			mu.Lock()
+			out := append([]map[string]any(nil), got...)
+			mu.Unlock()
+			if len(out) >= n {
+				return out
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("expected %d bridge requests, got %d", n, len(out))
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	msgBus.PublishOutbound(&bus.OutboundMessage{Channel: "slack", ChatID: "C1", Content: long})
+	reqs := waitRequests(3)
+	if reqs[0]["thread_id"] != "" {
+		t.Fatalf("expected first part unthreaded, got %v", reqs[0]["thread_id"])
+	}
+	for _, r := range reqs[1:] {
+		if r["thread_id"] != "17000.1" {
+			t.Fatalf("expected follow-up in thread of first part, got %v", r["thread_id"])
+		}
+	}
+
+	// A failed part stops the remaining parts of the message.
+	mu.Lock()
+	fail = 4
+	mu.Unlock()
+	msgBus.PublishOutbound(&bus.OutboundMessage{Channel: "slack", ChatID: "C1", Content: long})
+	msgBus.PublishOutbound(&bus.OutboundMessage{Channel: "slack", ChatID: "C1", Content: "after"})
+	reqs = waitRequests(5)
+	if reqs[4]["content"] != "after" {
+		t.Fatalf("expected remaining parts to be skipped after failure, got %v", reqs[4]["content"])
+	}
+}
+
+func TestMessageLimit(t *testing.T) {
+	if got := messageLimit(0, slackMaxMessageChars); got != slackMaxMessageChars {
+		t.Fatalf("expected default, got %d", got)
+	}
+	if got := messageLimit(-1, slackMaxMessageChars); got != 0 {
+		t.Fatalf("expected disabled, got %d", got)
+	}
+	if got := messageLimit(500, slackMaxMessageChars); got != 500 {
+		t.Fatalf("expected configured, got %d", got)
+	}
+}
@@ -237,6 +237,10 @@ func addChannelAllowFrom(cfg *config.Config, channel, senderID string) error {
 		cfg.Channels.Slack.AllowFrom = appendUnique(cfg.Channels.Slack.AllowFrom, senderID)
 	case "msteams":
 		cfg.Channels.MSTeams.AllowFrom = appendUnique(cfg.Channels.MSTeams.AllowFrom, senderID)
+	case "discord":
+		cfg.Channels.Discord.AllowFrom = appendUnique(cfg.Channels.Discord.AllowFrom, senderID)
+	case "telegram":
+		cfg.Channels.Telegram.AllowFrom = appendUnique(cfg.Channels.Telegram.AllowFrom, senderID)
 	case "whatsapp":
 		cfg.Channels.WhatsApp.AllowFrom = appendUnique(cfg.Channels.WhatsApp.AllowFrom, senderID)
 	default:
@@ -271,6 +275,12 @@ func normalizeAllowEntryForChannel(channel, raw string) string {
 		v = strings.TrimPrefix(strings.ToLower(v), "msteams:")
 		v = strings.TrimPrefix(v, "teams:")
 		v = strings.TrimPrefix(v, "user:")
+	case "discord":
+		v = strings.TrimPrefix(strings.ToLower(v), "discord:")
+		v = strings.TrimPrefix(v, "user:")
+	case "telegram":
+		v = strings.TrimPrefix(strings.ToLower(v), "telegram:")
+		v = strings.TrimPrefix(v, "user:")
 	default:
 		return strings.TrimSpace(raw)
 	}
@@ -31,6 +31,21 @@ func NotifyPairingApproved(ctx context.Context, cfg *config.Config, entry *Pendi
 			ChatID:  strings.TrimSpace(entry.SenderID),
 			Content: PairingApprovedMessage,
 		})
+	case "discord":
+		ch := NewDiscordChannel(cfg.Channels.Discord, bus.NewMessageBus(), nil)
+		return ch.Send(ctx, &bus.OutboundMessage{
+			Channel: "discord",
+			ChatID:  "user:" + strings.TrimSpace(entry.SenderID),
+			Content: PairingApprovedMessage,
+		})
+	case "telegram":
+		// A Telegram private chat has the user's ID.
+		ch := NewTelegramChannel(cfg.Channels.Telegram, bus.NewMessageBus(), nil)
+		return ch.Send(ctx, &bus.OutboundMessage{
+			Channel: "telegram",
+			ChatID:  strings.TrimSpace(entry.SenderID),
+			Content: PairingApprovedMessage,
+		})
 	case "whatsapp":
 		// WhatsApp pairing notifications remain handled by existing channel flow.
 		return nil
@@ -191,6 +191,20 @@ func TestPairingCriticalHelpers(t *testing.T) {
 		t.Fatalf("unexpected teams allowfrom: %#v", cfg.Channels.MSTeams.AllowFrom)
 	}
 
+	if err := addChannelAllowFrom(cfg, "discord", "discord:user:80351110224678912"); err != nil {
+		t.Fatalf("add discord allowfrom: %v", err)
+	}
+	if len(cfg.Channels.Discord.AllowFrom) != 1 || cfg.Channels.Discord.AllowFrom[0] != "80351110224678912" {
+		t.Fatalf("unexpected discord allowfrom: %#v", cfg.Channels.Discord.AllowFrom)
+	}
+
+	if err := addChannelAllowFrom(cfg, "telegram", "telegram:123456789"); err != nil {
+		t.Fatalf("add telegram allowfrom: %v", err)
+	}
+	if len(cfg.Channels.Telegram.AllowFrom) != 1 || cfg.Channels.Telegram.AllowFrom[0] != "123456789" {
+		t.Fatalf("unexpected telegram allowfrom: %#v", cfg.Channels.Telegram.AllowFrom)
+	}
+
 	if err := addChannelAllowFrom(cfg, "whatsapp", " +123@s.whatsapp.net "); err != nil {
 		t.Fatalf("add whatsapp allowfrom: %v", err)
 	}
@@ -201,7 +215,7 @@ func TestPairingCriticalHelpers(t *testing.T) {
 	if err := addChannelAllowFrom(cfg, "slack", ""); err == nil {
 		t.Fatal("expected error for empty sender")
 	}
-	if err := addChannelAllowFrom(cfg, "telegram", "u1"); err == nil {
+	if err := addChannelAllowFrom(cfg, "irc", "u1"); err == nil {
 		t.Fatal("expected error for unsupported channel")
 	}
 
@@ -0,0 +1,127 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+)
+
+// ListPolls lists the Slack polls of a chat through the channelbridge.
+func (c *SlackChannel) ListPolls(ctx context.Context, chatID string) ([]bus.Poll, error) {
+	return c.pollBridge(chatID).list(ctx)
+}
+
+// PollResults returns the tallies of a Slack poll of the chat.
+func (c *SlackChannel) PollResults(ctx context.Context, chatID, pollID string) (*bus.Poll, error) {
+	return c.pollBridge(chatID).poll(ctx, http.MethodGet, pollID, "results")
+}
+
+// ClosePoll closes a Slack poll of the chat and posts its results.
+func (c *SlackChannel) ClosePoll(ctx context.Context, chatID, pollID string) (*bus.Poll, error) {
+	return c.pollBridge(chatID).poll(ctx, http.MethodPost, pollID, "close")
+}
+
+func (c *SlackChannel) pollBridge(chatID string) bridgePolls {
+	accountID, chat := parseAccountChat(strings.TrimSpace(chatID))
+	ac := c.slackAccountConfig(accountID)
+	return bridgePolls{channel: c.Name(), outboundURL: ac.OutboundURL, token: ac.BotToken, chatID: chat}
+}
+
+// ListPolls lists the Teams polls of a chat through the channelbridge.
+func (c *MSTeamsChannel) ListPolls(ctx context.Context, chatID string) ([]bus.Poll, error) {
+	return c.pollBridge(chatID).list(ctx)
+}
+
+// PollResults returns the tallies of a Teams poll of the chat.
+func (c *MSTeamsChannel) PollResults(ctx context.Context, chatID, pollID string) (*bus.Poll, error) {
+	return c.pollBridge(chatID).poll(ctx, http.MethodGet, pollID, "results")
+}
+
+// ClosePoll closes a Teams poll of the chat and posts its results.
+func (c *MSTeamsChannel) ClosePoll(ctx context.Context, chatID, pollID string) (*bus.Poll, error) {
+	return c.pollBridge(chatID).poll(ctx, http.MethodPost, pollID, "close")
+}
+
+func (c *MSTeamsChannel) pollBridge(chatID string) bridgePolls {
+	accountID, chat := parseAccountChat(strings.TrimSpace(chatID))
+	ac := c.teamsAccountConfig(accountID)
+	return bridgePolls{channel: c.Name(), outboundURL: ac.OutboundURL, token: ac.AppPassword, chatID: chat}
+}
+
+// bridgePolls calls the /polls endpoints of the channelbridge serving an
+// outbound URL, scoped to one chat.
+type bridgePolls struct {
+	channel     string
+	outboundURL string
+	token       string
+	chatID      string
+}
+
+func (p bridgePolls) list(ctx context.Context) ([]bus.Poll, error) {
+	var out struct {
+		Polls []bus.Poll `json:"polls"`
+	}
+	q := url.Values{"channel": {p.channel}, "chat_id": {p.chatID}}
+	if err := p.do(ctx, http.MethodGet, "/polls?"+q.Encode(), &out); err != nil {
+		return nil, err
+	}
+	return out.Polls, nil
+}
+
+func (p bridgePolls) poll(ctx context.Context, method, pollID, op string) (*bus.Poll, error) {
+	var out struct {
+		Poll bus.Poll `json:"poll"`
+	}
+	q := url.Values{"chat_id": {p.chatID}}
+	if err := p.do(ctx, method, "/polls/"+url.PathEscape(strings.TrimSpace(pollID))+"/"+op+"?"+q.Encode(), &out); err != nil {
+		return nil, err
+	}
+	return &out.Poll, nil
+}
+
+func (p bridgePolls) do(ctx context.Context, method, path string, out any) error {
+	endpoint, err := bridgeEndpoint(p.channel, p.outboundURL, path)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	if tok := strings.TrimSpace(p.token); tok != "" {
+		req.Header.Set("Authorization", "Bearer "+tok)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("poll not found")
+	case resp.StatusCode == http.StatusConflict:
+		return fmt.Errorf("poll already closed")
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("%s poll bridge status: %d %s", p.channel, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("%s polls: %w", p.channel, err)
+	}
+	return nil
+}
+
+// bridgeEndpoint returns path on the channelbridge that serves outboundURL.
+func bridgeEndpoint(channel, outboundURL, path string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(outboundURL))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("%s has no outbound bridge", channel)
+	}
+	return u.Scheme + "://" + u.Host + path, nil
+}
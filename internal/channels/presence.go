@@ -0,0 +1,121 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// handlePresence shows WhatsApp "composing" state and sends read receipts.
+// Nothing is sent in silent mode, so the account does not reveal activity.
+func (c *WhatsAppChannel) handlePresence(evt *bus.PresenceEvent) {
+	if c.timeline != nil && c.timeline.IsSilentMode() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var err error
+	if c.presenceFn != nil {
+		err = c.presenceFn(ctx, evt)
+	} else {
+		err = c.sendPresence(ctx, evt)
+	}
+	if err != nil {
+		fmt.Printf("⚠️ WhatsApp presence (%s) to %s failed: %v\n", evt.Kind, evt.ChatID, err)
+	}
+}
+
+func (c *WhatsAppChannel) sendPresence(ctx context.Context, evt *bus.PresenceEvent) error {
+	if c.client == nil {
+		return fmt.Errorf("client not initialized")
+	}
+	chat, err := types.ParseJID(evt.ChatID)
+	if err != nil {
+		return fmt.Errorf("invalid JID: %w", err)
+	}
+	switch evt.Kind {
+	case bus.PresenceTyping:
+		return c.client.SendChatPresence(ctx, chat, types.ChatPresenceComposing, types.ChatPresenceMediaText)
+	case bus.PresenceRead:
+		// Group receipts name the author; in direct chats the chat is the author.
+		var sender types.JID
+		if chat.Server == types.GroupServer && strings.TrimSpace(evt.SenderID) != "" {
+			sender = types.NewJID(evt.SenderID, types.DefaultUserServer)
+		}
+		ids := make([]types.MessageID, 0, len(evt.MessageIDs))
+		for _, id := range evt.MessageIDs {
+			ids = append(ids, types.MessageID(id))
+		}
+		return c.client.MarkRead(ctx, ids, time.Now(), chat, sender)
+	}
+	return nil
+}
+
+// handlePresence forwards typing indicators to the Teams bridge, which
+// posts a typing activity. Read receipts are not available to Teams bots.
+func (c *MSTeamsChannel) handlePresence(evt *bus.PresenceEvent) {
+	if evt.Kind != bus.PresenceTyping {
+		return
+	}
+	accountID, chatID := parseAccountChat(strings.TrimSpace(evt.ChatID))
+	ac := c.teamsAccountConfig(accountID)
+	if err := postBridgeTyping(c.Name(), ac.OutboundURL, ac.AppPassword, accountID, chatID, evt.ThreadID); err != nil {
+		fmt.Printf("⚠️ MSTeams typing indicator to %s failed: %v\n", evt.ChatID, err)
+	}
+}
+
+// handlePresence forwards typing indicators to the Slack bridge, which sets
+// the assistant thread status. Slack has no read receipts for bots.
+func (c *SlackChannel) handlePresence(evt *bus.PresenceEvent) {
+	if evt.Kind != bus.PresenceTyping || strings.TrimSpace(evt.ThreadID) == "" {
+		return
+	}
+	accountID, chatID := parseAccountChat(strings.TrimSpace(evt.ChatID))
+	ac := c.slackAccountConfig(accountID)
+	if err := postBridgeTyping(c.Name(), ac.OutboundURL, ac.BotToken, accountID, chatID, evt.ThreadID); err != nil {
+		fmt.Printf("⚠️ Slack typing indicator to %s failed: %v\n", evt.ChatID, err)
+	}
+}
+
+// postBridgeTyping calls /typing on the channelbridge serving outboundURL.
+func postBridgeTyping(channel, outboundURL, token, accountID, chatID, threadID string) error {
+	if strings.TrimSpace(outboundURL) == "" {
+		return nil
+	}
+	endpoint, err := bridgeEndpoint(channel, outboundURL, "/typing")
+	if err != nil {
+		return err
+	}
+	body, _ := json.Marshal(map[string]any{
+		"channel":    channel,
+		"account_id": accountID,
+		"chat_id":    chatID,
+		"thread_id":  strings.TrimSpace(threadID),
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if tok := strings.TrimSpace(token); tok != "" {
+		req.Header.Set("Authorization", "Bearer "+tok)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s typing bridge status: %d", channel, resp.StatusCode)
+	}
+	return nil
+}
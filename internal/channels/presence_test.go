@@ -0,0 +1,73 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+func TestWhatsAppPresenceSuppressedInSilentMode(t *testing.T) {
+	timeSvc := newTestTimeline(t)
+	wa := NewWhatsAppChannel(config.WhatsAppConfig{Enabled: true}, bus.NewMessageBus(), nil, timeSvc)
+	var got []string
+	wa.presenceFn = func(ctx context.Context, evt *bus.PresenceEvent) error {
+		got = append(got, evt.Kind)
+		return nil
+	}
+
+	_ = timeSvc.SetSetting("silent_mode", "true")
+	wa.handlePresence(&bus.PresenceEvent{Channel: "whatsapp", ChatID: "1@s.whatsapp.net", Kind: bus.PresenceTyping})
+	if len(got) != 0 {
+		t.Fatalf("expected no presence in silent mode, got %v", got)
+	}
+	_ = timeSvc.SetSetting("silent_mode", "false")
+	wa.handlePresence(&bus.PresenceEvent{Channel: "whatsapp", ChatID: "1@s.whatsapp.net", Kind: bus.PresenceRead, MessageIDs: []string{"m1"}})
+	if len(got) != 1 || got[0] != bus.PresenceRead {
+		t.Fatalf("expected read receipt, got %v", got)
+	}
+}
+
+func TestMSTeamsPresenceForwardsTypingOnly(t *testing.T) {
+	var got []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		body["path"] = r.URL.Path
+		got = append(got, body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	ch := NewMSTeamsChannel(config.MSTeamsConfig{Enabled: true, OutboundURL: srv.URL + "/teams/outbound"}, bus.NewMessageBus(), nil)
+	ch.handlePresence(&bus.PresenceEvent{Channel: "msteams", ChatID: "conv-1", Kind: bus.PresenceRead, MessageIDs: []string{"a1"}})
+	ch.handlePresence(&bus.PresenceEvent{Channel: "msteams", ChatID: "conv-1", ThreadID: "a1", Kind: bus.PresenceTyping})
+	if len(got) != 1 || got[0]["path"] != "/typing" || got[0]["channel"] != "msteams" || got[0]["chat_id"] != "conv-1" {
+		t.Fatalf("expected one typing call, got %#v", got)
+	}
+}
+
+func TestSlackPresenceForwardsThreadTyping(t *testing.T) {
+	var got []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		body["path"] = r.URL.Path
+		got = append(got, body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	ch := NewSlackChannel(config.SlackConfig{Enabled: true, OutboundURL: srv.URL + "/slack/outbound"}, bus.NewMessageBus(), nil)
+	ch.handlePresence(&bus.PresenceEvent{Channel: "slack", ChatID: "C1", Kind: bus.PresenceTyping})
+	ch.handlePresence(&bus.PresenceEvent{Channel: "slack", ChatID: "C1", ThreadID: "100.1", Kind: bus.PresenceTyping})
+	if len(got) != 1 || got[0]["path"] != "/typing" || got[0]["channel"] != "slack" || got[0]["thread_id"] != "100.1" {
+		t.Fatalf("expected one thread typing call, got %#v", got)
+	}
+}
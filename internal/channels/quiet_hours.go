@@ -0,0 +1,216 @@
+package channels
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// QuietHoursSettingPrefix is the settings key prefix for quiet-hour schedules:
+// "quiet_hours:<channel>" applies to a whole channel and
+// "quiet_hours:<channel>:<chat_id>" overrides it for one chat.
+const QuietHoursSettingPrefix = "quiet_hours:"
+
+// QuietHoursSchedule describes when outbound messages for a channel or chat
+// are held back. Times are interpreted in Timezone (IANA name, default UTC).
+type QuietHoursSchedule struct {
+	Timezone string             `json:"timezone,omitempty"`
+	Windows  []QuietHoursWindow `json:"windows"`
+
+	loc *time.Location
+}
+
+// QuietHoursWindow is one recurring quiet period. Start/End use HH:MM; an End
+// at or before Start wraps past midnight. Omitting both makes the whole day
+// quiet. Days (mon..sun) restrict the day the window starts on; empty means
+// every day.
+type QuietHoursWindow struct {
+	Days  []string `json:"days,omitempty"`
+	Start string   `json:"start,omitempty"`
+	End   string   `json:"end,omitempty"`
+
+	days        map[time.Weekday]bool
+	startMin    int
+	durationMin int
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParseQuietHours parses and validates a schedule setting value. An empty
+// value or "off" yields nil (no quiet hours).
+func ParseQuietHours(raw string) (*QuietHoursSchedule, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.EqualFold(raw, "off") {
+		return nil, nil
+	}
+	var s QuietHoursSchedule
+	if err := json.Unmarshal([]byte(raw), &s); err != nil {
+		return nil, fmt.Errorf("invalid quiet hours schedule: %w", err)
+	}
+	tz := strings.TrimSpace(s.Timezone)
+	if tz == "" {
+		tz = "UTC"
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quiet hours timezone %q: %w", s.Timezone, err)
+	}
+	s.loc = loc
+	if len(s.Windows) == 0 {
+		return nil, fmt.Errorf("quiet hours schedule needs at least one window")
+	}
+	for i := range s.Windows {
+		if err := s.Windows[i].compile(); err != nil {
+			return nil, fmt.Errorf("quiet hours window %d: %w", i+1, err)
+		}
+	}
+	return &s, nil
+}
+
+func (w *QuietHoursWindow) compile() error {
+	w.days = map[time.Weekday]bool{}
+	for _, d := range w.Days {
+		key := strings.ToLower(strings.TrimSpace(d))
+		if len(key) > 3 {
+			key = key[:3]
+		}
+		wd, ok := weekdayNames[key]
+		if !ok {
+			return fmt.Errorf("unknown day %q", d)
+		}
+		w.days[wd] = true
+	}
+	start, end := strings.TrimSpace(w.Start), strings.TrimSpace(w.End)
+	if start == "" && end == "" {
+		w.startMin, w.durationMin = 0, 24*60
+		return nil
+	}
+	s, err := parseClock(start)
+	if err != nil {
+		return fmt.Errorf("start: %w", err)
+	}
+	e, err := parseClock(end)
+	if err != nil {
+		return fmt.Errorf("end: %w", err)
+	}
+	w.startMin = s
+	w.durationMin = e - s
+	if w.durationMin <= 0 {
+		w.durationMin += 24 * 60
+	}
+	return nil
+}
+
+func parseClock(v string) (int, error) {
+	t, err := time.Parse("15:04", v)
+	if err != nil {
+		return 0, fmt.Errorf("expected HH:MM, got %q", v)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// QuietUntil reports whether now falls inside a quiet window and, if so, when
+// the quiet period ends. Back-to-back windows (e.g. Saturday and Sunday) are
+// merged so the returned time is when messages may actually go out.
+func (s *QuietHoursSchedule) QuietUntil(now time.Time) (time.Time, bool) {
+	if s == nil {
+		return time.Time{}, false
+	}
+	until, quiet := s.windowEnd(now)
+	if !quiet {
+		return time.Time{}, false
+	}
+	for i := 0; i < 14; i++ {
+		next, ok := s.windowEnd(until)
+		if !ok || !next.After(until) {
+			break
+		}
+		until = next
+	}
+	return until, true
+}
+
+// windowEnd returns the latest end of any window containing t.
+func (s *QuietHoursSchedule) windowEnd(t time.Time) (time.Time, bool) {
+	local := t.In(s.loc)
+	var end time.Time
+	found := false
+	for _, w := range s.Windows {
+		// A window that started yesterday may still be open today.
+		for back := 0; back <= 1; back++ {
+			day := local.AddDate(0, 0, -back)
+			if len(w.days) > 0 && !w.days[day.Weekday()] {
+				continue
+			}
+			midnight := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, s.loc)
+			start := midnight.Add(time.Duration(w.startMin) * time.Minute)
+			stop := start.Add(time.Duration(w.durationMin) * time.Minute)
+			if !local.Before(start) && local.Before(stop) && stop.After(end) {
+				end, found = stop, true
+			}
+		}
+	}
+	return end, found
+}
+
+// LoadQuietHours resolves the schedule for a chat: the per-chat setting wins
+// over the channel setting, and a per-chat "off" disables channel quiet hours.
+func LoadQuietHours(tl *timeline.TimelineService, channel, chatID string) (*QuietHoursSchedule, error) {
+	if tl == nil || strings.TrimSpace(channel) == "" {
+		return nil, nil
+	}
+	if chatID = strings.TrimSpace(chatID); chatID != "" {
+		if v, err := tl.GetSetting(QuietHoursSettingPrefix + channel + ":" + chatID); err == nil && strings.TrimSpace(v) != "" {
+			return ParseQuietHours(v)
+		}
+	}
+	v, err := tl.GetSetting(QuietHoursSettingPrefix + channel)
+	if err != nil {
+		return nil, nil
+	}
+	return ParseQuietHours(v)
+}
+
+// deferForQuietHours holds msg back when its chat is inside a quiet window.
+// The full message is queued in deferred_outbound and re-published by the
+// delivery worker once the window opens; a task reply is marked deferred
+// meanwhile. Returns true when the message must not be sent now.
+func deferForQuietHours(tl *timeline.TimelineService, channel string, msg *bus.OutboundMessage, now time.Time) bool {
+	if tl == nil || msg == nil {
+		return false
+	}
+	sched, err := LoadQuietHours(tl, channel, msg.ChatID)
+	if err != nil {
+		fmt.Printf("⚠️ Quiet hours: ignoring invalid schedule for %s/%s: %v\n", channel, msg.ChatID, err)
+		return false
+	}
+	until, quiet := sched.QuietUntil(now)
+	if !quiet {
+		return false
+	}
+	payload, _ := json.Marshal(msg)
+	err = tl.DeferOutbound(&timeline.DeferredOutboundRecord{
+		Channel:   channel,
+		ChatID:    msg.ChatID,
+		TraceID:   msg.TraceID,
+		Payload:   string(payload),
+		Reason:    "quiet_hours",
+		DeliverAt: until,
+	})
+	if err != nil {
+		fmt.Printf("⚠️ Quiet hours: failed to queue outbound to %s/%s, sending now: %v\n", channel, msg.ChatID, err)
+		return false
+	}
+	if strings.TrimSpace(msg.TaskID) != "" {
+		_ = tl.DeferTaskDelivery(msg.TaskID, until, "deferred:quiet_hours")
+	}
+	fmt.Printf("🌙 Quiet hours: deferred outbound to %s/%s until %s\n", channel, msg.ChatID, until.Format(time.RFC3339))
+	return true
+}
@@ -0,0 +1,95 @@
+package channels
+
+import (
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestQuietHoursOvernightAndWeekend(t *testing.T) {
+	sched, err := ParseQuietHours(`{"timezone":"Europe/Berlin","windows":[
+		{"start":"22:00","end":"07:00"},
+		{"days":["sat","sunday"]}
+	]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+
+	// Wednesday 23:30 Berlin: quiet until Thursday 07:00.
+	until, quiet := sched.QuietUntil(time.Date(2026, 3, 4, 23, 30, 0, 0, berlin))
+	if !quiet || !until.Equal(time.Date(2026, 3, 5, 7, 0, 0, 0, berlin)) {
+		t.Fatalf("overnight: quiet=%v until=%v", quiet, until)
+	}
+	// Thursday 12:00 Berlin: open.
+	if _, quiet := sched.QuietUntil(time.Date(2026, 3, 5, 12, 0, 0, 0, berlin)); quiet {
+		t.Fatal("expected midday to be open")
+	}
+	// Same instant expressed in UTC is evaluated in the schedule's timezone.
+	if _, quiet := sched.QuietUntil(time.Date(2026, 3, 4, 22, 30, 0, 0, time.UTC)); !quiet {
+		t.Fatal("expected 23:30 Berlin (22:30 UTC) to be quiet")
+	}
+	// Saturday 10:00: weekend and the following night merge into Monday 07:00.
+	until, quiet = sched.QuietUntil(time.Date(2026, 3, 7, 10, 0, 0, 0, berlin))
+	if !quiet || !until.Equal(time.Date(2026, 3, 9, 7, 0, 0, 0, berlin)) {
+		t.Fatalf("weekend: quiet=%v until=%v", quiet, until)
+	}
+}
+
+func TestParseQuietHoursValidation(t *testing.T) {
+	for _, raw := range []string{"", "off", " OFF "} {
+		if s, err := ParseQuietHours(raw); s != nil || err != nil {
+			t.Fatalf("%q: expected no schedule, got %+v err=%v", raw, s, err)
+		}
+	}
+	for _, raw := range []string{
+		`not json`,
+		`{"windows":[]}`,
+		`{"timezone":"Mars/Olympus","windows":[{"start":"22:00","end":"07:00"}]}`,
+		`{"windows":[{"start":"25:00","end":"07:00"}]}`,
+		`{"windows":[{"days":["funday"]}]}`,
+	} {
+		if _, err := ParseQuietHours(raw); err == nil {
+			t.Fatalf("expected error for %s", raw)
+		}
+	}
+}
+
+func TestDeferForQuietHoursQueuesAndPerChatOverride(t *testing.T) {
+	tl := newTestTimeline(t)
+	if err := tl.SetSetting("quiet_hours:msteams", `{"windows":[{}]}`); err != nil {
+		t.Fatal(err)
+	}
+	if err := tl.SetSetting("quiet_hours:msteams:ops", "off"); err != nil {
+		t.Fatal(err)
+	}
+	task, err := tl.CreateTask(&timeline.AgentTask{Channel: "msteams", ChatID: "team-a", ContentIn: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+
+	if deferForQuietHours(tl, "msteams", &bus.OutboundMessage{Channel: "msteams", ChatID: "ops", Content: "page"}, now) {
+		t.Fatal("per-chat off should override channel quiet hours")
+	}
+	msg := &bus.OutboundMessage{Channel: "msteams", ChatID: "team-a", TaskID: task.TaskID, Content: "report", MediaURLs: []string{"https://x/y.png"}}
+	if !deferForQuietHours(tl, "msteams", msg, now) {
+		t.Fatal("expected message to be deferred")
+	}
+
+	got, err := tl.GetTask(task.TaskID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.DeliveryStatus != timeline.DeliveryDeferred || got.DeliveryAttempts != 0 {
+		t.Fatalf("unexpected task delivery: status=%s attempts=%d", got.DeliveryStatus, got.DeliveryAttempts)
+	}
+	if n, _ := tl.CountDeferredOutbound(); n != 1 {
+		t.Fatalf("expected 1 deferred message, got %d", n)
+	}
+	if due, _ := tl.ListDueDeferredOutbound(0); len(due) != 0 {
+		t.Fatalf("message must not be due during the window, got %d", len(due))
+	}
+}
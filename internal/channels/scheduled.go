@@ -0,0 +1,103 @@
+package channels
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+)
+
+// ListScheduled lists the pending scheduled Slack messages of a chat
+// through the channelbridge.
+func (c *SlackChannel) ListScheduled(ctx context.Context, chatID string) ([]bus.ScheduledMessage, error) {
+	return c.scheduleBridge(chatID).list(ctx)
+}
+
+// CancelScheduled cancels a scheduled Slack message of the chat.
+func (c *SlackChannel) CancelScheduled(ctx context.Context, chatID, id string) error {
+	return c.scheduleBridge(chatID).cancel(ctx, id)
+}
+
+func (c *SlackChannel) scheduleBridge(chatID string) bridgeScheduled {
+	accountID, chat := parseAccountChat(strings.TrimSpace(chatID))
+	ac := c.slackAccountConfig(accountID)
+	return bridgeScheduled{channel: c.Name(), outboundURL: ac.OutboundURL, token: ac.BotToken, chatID: chat}
+}
+
+// ListScheduled lists the pending scheduled Teams messages of a chat
+// through the channelbridge.
+func (c *MSTeamsChannel) ListScheduled(ctx context.Context, chatID string) ([]bus.ScheduledMessage, error) {
+	return c.scheduleBridge(chatID).list(ctx)
+}
+
+// CancelScheduled cancels a scheduled Teams message of the chat.
+func (c *MSTeamsChannel) CancelScheduled(ctx context.Context, chatID, id string) error {
+	return c.scheduleBridge(chatID).cancel(ctx, id)
+}
+
+func (c *MSTeamsChannel) scheduleBridge(chatID string) bridgeScheduled {
+	accountID, chat := parseAccountChat(strings.TrimSpace(chatID))
+	ac := c.teamsAccountConfig(accountID)
+	return bridgeScheduled{channel: c.Name(), outboundURL: ac.OutboundURL, token: ac.AppPassword, chatID: chat}
+}
+
+// bridgeScheduled calls the /scheduled endpoints of the channelbridge
+// serving an outbound URL, scoped to one chat.
+type bridgeScheduled struct {
+	channel     string
+	outboundURL string
+	token       string
+	chatID      string
+}
+
+func (s bridgeScheduled) list(ctx context.Context) ([]bus.ScheduledMessage, error) {
+	var out struct {
+		Scheduled []bus.ScheduledMessage `json:"scheduled"`
+	}
+	q := url.Values{"channel": {s.channel}, "chat_id": {s.chatID}}
+	if err := s.do(ctx, http.MethodGet, "/scheduled?"+q.Encode(), &out); err != nil {
+		return nil, err
+	}
+	return out.Scheduled, nil
+}
+
+func (s bridgeScheduled) cancel(ctx context.Context, id string) error {
+	var out struct{}
+	q := url.Values{"chat_id": {s.chatID}}
+	return s.do(ctx, http.MethodPost, "/scheduled/"+url.PathEscape(strings.TrimSpace(id))+"/cancel?"+q.Encode(), &out)
+}
+
+func (s bridgeScheduled) do(ctx context.Context, method, path string, out any) error {
+	endpoint, err := bridgeEndpoint(s.channel, s.outboundURL, path)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	if tok := strings.TrimSpace(s.token); tok != "" {
+		req.Header.Set("Authorization", "Bearer "+tok)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("scheduled message not found")
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("%s schedule bridge status: %d %s", s.channel, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("%s scheduled messages: %w", s.channel, err)
+	}
+	return nil
+}
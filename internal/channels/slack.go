@@ -12,13 +12,16 @@ import (
 	"github.com/KafClaw/KafClaw/internal/bus"
 	"github.com/KafClaw/KafClaw/internal/config"
 	"github.com/KafClaw/KafClaw/internal/timeline"
+	"github.com/KafClaw/KafClaw/internal/tracing"
 )
 
 // SlackChannel is a Slack transport scaffold with policy + pairing integration.
 type SlackChannel struct {
 	BaseChannel
-	config   config.SlackConfig
-	timeline *timeline.TimelineService
+	config      config.SlackConfig
+	timeline    *timeline.TimelineService
+	parts       outboundParts
+	transcriber Transcriber
 }
 
 func NewSlackChannel(cfg config.SlackConfig, messageBus *bus.MessageBus, tl *timeline.TimelineService) *SlackChannel {
@@ -31,12 +34,28 @@ func NewSlackChannel(cfg config.SlackConfig, messageBus *bus.MessageBus, tl *tim
 
 func (c *SlackChannel) Name() string { return "slack" }
 
+// SetTranscriber enables transcription of voice notes forwarded by the
+// bridge.
+func (c *SlackChannel) SetTranscriber(t Transcriber) { c.transcriber = t }
+
 func (c *SlackChannel) Start(ctx context.Context) error {
 	if !c.config.Enabled {
 		return nil
 	}
+	c.Bus.SetMessageLimit(c.Name(), messageLimit(c.config.MaxMessageChars, slackMaxMessageChars))
 	c.Bus.Subscribe(c.Name(), func(msg *bus.OutboundMessage) {
-		if err := c.Send(ctx, msg); err != nil {
+		if c.parts.skip(msg) {
+			return
+		}
+		if deferForQuietHours(c.timeline, c.Name(), msg, time.Now()) {
+			return
+		}
+		err := sendTraced(ctx, c.Name(), msg, c.Send)
+		recordSend(c.Name(), err)
+		if !c.parts.finish(msg, err) {
+			return
+		}
+		if err != nil {
 			if c.timeline != nil && strings.TrimSpace(msg.TaskID) != "" {
 				reason, cls := classifyDeliveryError(err)
 				if cls == deliveryTransient {
@@ -52,12 +71,20 @@ func (c *SlackChannel) Start(ctx context.Context) error {
 			_ = c.timeline.UpdateTaskDeliveryWithReason(msg.TaskID, timeline.DeliverySent, nil, "")
 		}
 	})
+	c.Bus.SubscribePresence(c.Name(), c.handlePresence)
+	c.Bus.RegisterThreadReader(c.Name(), c.ReadThread)
+	c.Bus.RegisterPollManager(c.Name(), c)
+	c.Bus.RegisterScheduleManager(c.Name(), c)
 	return nil
 }
 
 func (c *SlackChannel) Stop() error { return nil }
 
 func (c *SlackChannel) Send(ctx context.Context, msg *bus.OutboundMessage) error {
+	msg, err := c.renderTemplate(c.Name(), msg)
+	if err != nil {
+		return err
+	}
 	accountID, chatID := parseAccountChat(strings.TrimSpace(msg.ChatID))
 	ac := c.slackAccountConfig(accountID)
 	if strings.TrimSpace(ac.OutboundURL) == "" {
@@ -67,25 +94,30 @@ func (c *SlackChannel) Send(ctx context.Context, msg *bus.OutboundMessage) error
 		"channel":             "slack",
 		"account_id":          accountID,
 		"chat_id":             strings.TrimSpace(chatID),
-		"thread_id":           strings.TrimSpace(msg.ThreadID),
+		"thread_id":           strings.TrimSpace(c.parts.thread(msg)),
 		"native_streaming":    slackNativeStreamingOrDefault(ac.NativeStreaming, c.config.NativeStreaming),
 		"stream_mode":         strings.TrimSpace(ac.StreamMode),
 		"stream_chunk_chars":  ac.StreamChunkChars,
 		"content":             msg.Content,
-		"media_urls":          msg.MediaURLs,
+		"media_urls":          bridgeMediaURLs(msg.MediaURLs),
 		"card":                msg.Card,
 		"action":              strings.TrimSpace(msg.Action),
 		"action_params":       msg.ActionParams,
 		"poll_question":       strings.TrimSpace(msg.PollQuestion),
 		"poll_options":        msg.PollOptions,
 		"poll_max_selections": msg.PollMaxSelections,
+		"send_at":             strings.TrimSpace(msg.SendAt),
+		"visibility":          strings.TrimSpace(msg.Visibility),
+		"user_id":             strings.TrimSpace(msg.RecipientID),
 		"trace_id":            msg.TraceID,
+		"task_id":             msg.TaskID,
 	})
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ac.OutboundURL, bytes.NewReader(body))
 	if err != nil {
 		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	tracing.Inject(req, msg.TraceID)
 	if tok := strings.TrimSpace(ac.BotToken); tok != "" {
 		req.Header.Set("Authorization", "Bearer "+tok)
 	}
@@ -97,6 +129,13 @@ func (c *SlackChannel) Send(ctx context.Context, msg *bus.OutboundMessage) error
 	if resp.StatusCode >= 300 {
 		return fmt.Errorf("slack outbound bridge status: %d", resp.StatusCode)
 	}
+	if msg.Parts > 1 {
+		var delivery struct {
+			MessageID string `json:"message_id"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&delivery)
+		c.parts.sent(msg, strings.TrimSpace(delivery.MessageID))
+	}
 	return nil
 }
 
@@ -109,9 +148,51 @@ func (c *SlackChannel) HandleInboundWithAccount(accountID, senderID, chatID, thr
 }
 
 func (c *SlackChannel) HandleInboundWithAccountAndHints(accountID, senderID, chatID, threadID, messageID, text string, isGroup, wasMentioned bool, historyLimit, dmHistoryLimit int) error {
+	_, err := c.handleInbound(accountID, senderID, chatID, threadID, messageID, text, isGroup, wasMentioned, historyLimit, dmHistoryLimit, "", nil, SlackWorkspace{}, nil, "", false)
+	return err
+}
+
+// SlackWorkspace is the Slack workspace (team ID) a bridged message came
+// from and, on Enterprise Grid, its organization. Slack user IDs are only
+// unique within a workspace. The zero value is a single-workspace install.
+type SlackWorkspace struct {
+	ID           string
+	EnterpriseID string
+}
+
+// scoped qualifies a user or channel ID with the workspace ("T123:U456"),
+// so IDs that repeat across workspaces get different access decisions,
+// pairings and sessions.
+func (ws SlackWorkspace) scoped(id string) string {
+	team := strings.TrimSpace(ws.ID)
+	if team == "" || strings.TrimSpace(id) == "" {
+		return id
+	}
+	return team + ":" + strings.TrimSpace(id)
+}
+
+// AcceptInbound is HandleInboundWithAccountAndHints for the asynchronous
+// bridge endpoint: it creates the agent task up front and returns it. The
+// task is nil when the message was dropped by policy or started pairing. A
+// valid traceparent of the bridge request becomes the trace of the task.
+func (c *SlackChannel) AcceptInbound(accountID, senderID, chatID, threadID, messageID, text string, isGroup, wasMentioned bool, historyLimit, dmHistoryLimit int, workspace SlackWorkspace, media []BridgeMedia, traceparent string) (*BridgeTask, error) {
+	return c.handleInbound(accountID, senderID, chatID, threadID, messageID, text, isGroup, wasMentioned, historyLimit, dmHistoryLimit, "", nil, workspace, media, traceparent, true)
+}
+
+// HandleEventWithAccount handles non-message activity forwarded by the bridge
+// (reactions, file shares, channel membership). The event type and payload
+// are passed to the agent as metadata. Activity goes through the same access
+// policy as messages but never starts pairing.
+func (c *SlackChannel) HandleEventWithAccount(accountID, senderID, chatID, threadID, messageID, text string, isGroup, wasMentioned bool, eventType string, event map[string]any, workspace SlackWorkspace, media []BridgeMedia, traceparent string) error {
+	_, err := c.handleInbound(accountID, senderID, chatID, threadID, messageID, text, isGroup, wasMentioned, 0, 0, eventType, event, workspace, media, traceparent, false)
+	return err
+}
+
+func (c *SlackChannel) handleInbound(accountID, senderID, chatID, threadID, messageID, text string, isGroup, wasMentioned bool, historyLimit, dmHistoryLimit int, eventType string, event map[string]any, workspace SlackWorkspace, media []BridgeMedia, traceparent string, accept bool) (*BridgeTask, error) {
 	ac := c.slackAccountConfig(accountID)
+	sender := workspace.scoped(senderID)
 	decision := EvaluateAccess(AccessContext{
-		SenderID:     senderID,
+		SenderID:     sender,
 		IsGroup:      isGroup,
 		WasMentioned: wasMentioned,
 	}, AccessConfig{
@@ -123,47 +204,74 @@ func (c *SlackChannel) HandleInboundWithAccountAndHints(accountID, senderID, cha
 		RequireMention: ac.RequireMention && isGroup,
 	})
 	if decision.RequiresPairing {
-		if c.timeline == nil {
-			return nil
+		if c.timeline == nil || eventType != "" {
+			return nil, nil
 		}
 		svc := NewPairingService(c.timeline)
-		pending, err := svc.CreateOrGetPending(c.Name(), senderID, 0)
+		pending, err := svc.CreateOrGetPending(c.Name(), sender, 0)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		c.Bus.PublishOutbound(&bus.OutboundMessage{
 			Channel: c.Name(),
 			ChatID:  withAccountChat(accountID, chatID),
-			Content: BuildPairingReply(c.Name(), fmt.Sprintf("Slack user: %s", strings.TrimSpace(senderID)), pending.Code),
+			Content: BuildPairingReply(c.Name(), fmt.Sprintf("Slack user: %s", strings.TrimSpace(sender)), pending.Code),
 		})
-		return nil
+		return nil, nil
 	}
 	if !decision.Allowed {
-		return nil
+		return nil, nil
 	}
 	scopedChatID := withAccountChat(accountID, chatID)
 	metadata := map[string]any{
 		bus.MetaKeyMessageType: bus.MessageTypeExternal,
 		// Isolation boundary is channel + account + conversation/chat room.
-		bus.MetaKeySessionScope:   buildSessionScope(c.Name(), accountID, chatID, threadID, senderID, ac.SessionScope),
+		bus.MetaKeySessionScope:   buildSessionScope(c.Name(), accountID, workspace.scoped(chatID), threadID, sender, ac.SessionScope),
 		bus.MetaKeyChannelAccount: accountIDOrDefault(accountID),
 	}
+	if ws := strings.TrimSpace(workspace.ID); ws != "" {
+		metadata[bus.MetaKeyWorkspace] = ws
+	}
+	if ent := strings.TrimSpace(workspace.EnterpriseID); ent != "" {
+		metadata[bus.MetaKeyEnterprise] = ent
+	}
 	if historyLimit > 0 {
 		metadata["history_limit"] = historyLimit
 	}
 	if dmHistoryLimit > 0 {
 		metadata["dm_history_limit"] = dmHistoryLimit
 	}
-	c.Bus.PublishInbound(&bus.InboundMessage{
+	// Outside threads the agent reads the newest chat messages as context.
+	chatHistory := historyLimit
+	if !isGroup {
+		chatHistory = dmHistoryLimit
+	}
+	if strings.TrimSpace(threadID) == "" && chatHistory > 0 {
+		metadata[bus.MetaKeyChatHistory] = chatHistory
+	}
+	if eventType != "" {
+		metadata[bus.MetaKeyEventType] = eventType
+		if event != nil {
+			metadata[bus.MetaKeyEvent] = event
+		}
+	}
+	msg := &bus.InboundMessage{
 		Channel:   c.Name(),
 		SenderID:  strings.TrimSpace(senderID),
 		ChatID:    strings.TrimSpace(scopedChatID),
 		ThreadID:  strings.TrimSpace(threadID),
 		MessageID: strings.TrimSpace(messageID),
 		Content:   text,
+		Media:     storeBridgeMedia(c.Name(), messageID, media),
 		Metadata:  metadata,
-	})
-	return nil
+	}
+	applyTraceparent(msg, traceparent)
+	publish := publishTranscribed(c.Bus, c.transcriber)
+	if accept {
+		return acceptBridgeMessage(c.timeline, publish, msg)
+	}
+	publish(msg)
+	return nil, nil
 }
 
 func (c *SlackChannel) slackAccountConfig(accountID string) config.SlackAccountConfig {
@@ -2,17 +2,24 @@ package channels
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/KafClaw/KafClaw/internal/bus"
 	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/msgtemplate"
+	"github.com/KafClaw/KafClaw/internal/provider"
 	"github.com/KafClaw/KafClaw/internal/timeline"
+	"github.com/KafClaw/KafClaw/internal/tracing"
 )
 
 var pairingCodeRe = regexp.MustCompile(`Pairing code:\s+([A-Z0-9]+)`)
@@ -114,6 +121,227 @@ func TestSlackHandleInboundSetsRoomSessionScope(t *testing.T) {
 	}
 }
 
+func TestSlackHandleEventPublishesEventMetadata(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	db := filepath.Join(t.TempDir(), "timeline.db")
+	timeSvc, err := timeline.NewTimelineService(db)
+	if err != nil {
+		t.Fatalf("timeline: %v", err)
+	}
+	defer timeSvc.Close()
+	ch := NewSlackChannel(config.SlackConfig{
+		Enabled:     true,
+		AllowFrom:   []string{"U123"},
+		DmPolicy:    config.DmPolicyPairing,
+		GroupPolicy: config.GroupPolicyAllowlist,
+	}, msgBus, timeSvc)
+
+	// Activity from unknown senders never triggers a pairing reply.
+	out := make(chan *bus.OutboundMessage, 1)
+	msgBus.Subscribe("slack", func(msg *bus.OutboundMessage) { out <- msg })
+	go msgBus.DispatchOutbound(t.Context())
+	if err := ch.HandleEventWithAccount("", "U999", "D1", "", "e0", "[reaction added]", false, false, "reaction_added", nil, SlackWorkspace{}, nil, ""); err != nil {
+		t.Fatalf("handle event: %v", err)
+	}
+	select {
+	case got := <-out:
+		t.Fatalf("unexpected pairing reply: %+v", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	event := map[string]any{"reaction": "+1", "sentiment": "positive"}
+	if err := ch.HandleEventWithAccount("", "U123", "C100", "", "e1", "[reaction added: :+1:]", true, false, "reaction_added", event, SlackWorkspace{}, nil, ""); err != nil {
+		t.Fatalf("handle event: %v", err)
+	}
+	msg, err := msgBus.ConsumeInbound(t.Context())
+	if err != nil {
+		t.Fatalf("consume inbound: %v", err)
+	}
+	if msg.Metadata[bus.MetaKeyEventType] != "reaction_added" {
+		t.Fatalf("unexpected event type: %#v", msg.Metadata)
+	}
+	got, _ := msg.Metadata[bus.MetaKeyEvent].(map[string]any)
+	if got["sentiment"] != "positive" {
+		t.Fatalf("unexpected event payload: %#v", got)
+	}
+}
+
+func TestSlackAcceptInboundCreatesTaskOnce(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	timeSvc, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatalf("timeline: %v", err)
+	}
+	defer timeSvc.Close()
+	ch := NewSlackChannel(config.SlackConfig{
+		Enabled:     true,
+		AllowFrom:   []string{"U123"},
+		DmPolicy:    config.DmPolicyAllowlist,
+		GroupPolicy: config.GroupPolicyAllowlist,
+	}, msgBus, timeSvc)
+
+	bt, err := ch.AcceptInbound("", "U123", "D1", "", "1700.01", "slow question", false, false, 0, 0, SlackWorkspace{}, nil, "")
+	if err != nil || bt == nil {
+		t.Fatalf("accept inbound: %+v %v", bt, err)
+	}
+	if bt.TaskID == "" || bt.Status != timeline.TaskStatusPending || bt.Duplicate {
+		t.Fatalf("unexpected bridge task: %+v", bt)
+	}
+	msg, err := msgBus.ConsumeInbound(t.Context())
+	if err != nil {
+		t.Fatalf("consume inbound: %v", err)
+	}
+	task, err := timeSvc.GetTaskByIdempotencyKey(msg.IdempotencyKey)
+	if err != nil || task == nil || task.TaskID != bt.TaskID || msg.TraceID != bt.TraceID {
+		t.Fatalf("published message must carry the task keys: %+v task=%+v err=%v", msg, task, err)
+	}
+
+	// A bridge retry of the same message returns the task without requeueing.
+	again, err := ch.AcceptInbound("", "U123", "D1", "", "1700.01", "slow question", false, false, 0, 0, SlackWorkspace{}, nil, "")
+	if err != nil || again.TaskID != bt.TaskID || !again.Duplicate {
+		t.Fatalf("expected duplicate of %s, got %+v %v", bt.TaskID, again, err)
+	}
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+	if dup, err := msgBus.ConsumeInbound(ctx); err == nil {
+		t.Fatalf("retry must not be republished: %+v", dup)
+	}
+
+	// Messages without a platform ID are never taken for redeliveries, even
+	// within one trace.
+	publish := func(*bus.InboundMessage) {}
+	first, err := acceptBridgeMessage(timeSvc, publish, &bus.InboundMessage{Channel: "slack", ChatID: "D1", TraceID: "trace-shared", Content: "one"})
+	if err != nil || first.Duplicate {
+		t.Fatalf("accept without message id: %+v %v", first, err)
+	}
+	second, err := acceptBridgeMessage(timeSvc, publish, &bus.InboundMessage{Channel: "slack", ChatID: "D1", TraceID: "trace-shared", Content: "two"})
+	if err != nil || second.Duplicate || second.TaskID == first.TaskID {
+		t.Fatalf("messages without id must get their own tasks: %+v %+v %v", first, second, err)
+	}
+
+	if bt, err := ch.AcceptInbound("", "U999", "D2", "", "m2", "hi", false, false, 0, 0, SlackWorkspace{}, nil, ""); err != nil || bt != nil {
+		t.Fatalf("denied sender must not get a task: %+v %v", bt, err)
+	}
+}
+
+func TestSlackAcceptInboundJoinsTraceparent(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	timeSvc, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatalf("timeline: %v", err)
+	}
+	defer timeSvc.Close()
+	var header string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	ch := NewSlackChannel(config.SlackConfig{
+		Enabled:     true,
+		AllowFrom:   []string{"U123"},
+		DmPolicy:    config.DmPolicyAllowlist,
+		GroupPolicy: config.GroupPolicyAllowlist,
+		OutboundURL: srv.URL,
+	}, msgBus, timeSvc)
+
+	const tp = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	bt, err := ch.AcceptInbound("", "U123", "D1", "", "1700.01", "traced", false, false, 0, 0, SlackWorkspace{}, nil, tp)
+	if err != nil || bt == nil {
+		t.Fatalf("accept inbound: %+v %v", bt, err)
+	}
+	if bt.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("expected the W3C trace ID as trace, got %q", bt.TraceID)
+	}
+	msg, err := msgBus.ConsumeInbound(t.Context())
+	if err != nil {
+		t.Fatalf("consume inbound: %v", err)
+	}
+	if msg.Metadata[bus.MetaKeyTraceparent] != tp {
+		t.Fatalf("expected traceparent metadata, got %v", msg.Metadata)
+	}
+	events, err := timeSvc.GetEvents(timeline.FilterArgs{TraceID: bt.TraceID})
+	if err != nil || len(events) != 1 {
+		t.Fatalf("expected one inbound event, got %d %v", len(events), err)
+	}
+	if events[0].ParentSpanID != "00f067aa0ba902b7" || !strings.Contains(events[0].Metadata, tp) {
+		t.Fatalf("expected the caller span as parent, got %+v", events[0])
+	}
+
+	if err := ch.Send(t.Context(), &bus.OutboundMessage{Channel: "slack", ChatID: "D1", Content: "ok", TraceID: bt.TraceID}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if !strings.HasPrefix(header, "00-4bf92f3577b34da6a3ce929d0e0e4736-") || header == tp {
+		t.Fatalf("expected a child traceparent on the bridge call, got %q", header)
+	}
+}
+
+func TestBridgeTraceparentFallsBackToBodyTraceID(t *testing.T) {
+	const tp = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	if got := BridgeTraceparent(tp, "ffffffffffffffffffffffffffffffff"); got != tp {
+		t.Fatalf("expected the header to win, got %q", got)
+	}
+	got := BridgeTraceparent("", "4BF92F3577B34DA6A3CE929D0E0E4736")
+	if p, ok := tracing.Parse(got); !ok || p.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("expected a span of the body trace, got %q", got)
+	}
+	for _, bad := range []string{"", "trace-1", "00000000000000000000000000000000"} {
+		if got := BridgeTraceparent("garbage", bad); got != "" {
+			t.Fatalf("expected no traceparent for %q, got %q", bad, got)
+		}
+	}
+}
+
+func TestLogDeliverySpan(t *testing.T) {
+	timeSvc, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatalf("timeline: %v", err)
+	}
+	defer timeSvc.Close()
+
+	LogDeliverySpan(timeSvc, &timeline.DeliveryReceiptRecord{Channel: "slack", ChatID: "C1", MessageID: "1.1"}, "")
+	const tp = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	LogDeliverySpan(timeSvc, &timeline.DeliveryReceiptRecord{
+		Channel: "slack", ChatID: "C1", ThreadID: "1.0", MessageID: "1.1", MessageIDs: []string{"1.1"},
+		TaskID: "task-1", TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+	}, tp)
+
+	events, err := timeSvc.GetEvents(timeline.FilterArgs{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736"})
+	if err != nil || len(events) != 1 {
+		t.Fatalf("expected one delivery span, got %d %v", len(events), err)
+	}
+	e := events[0]
+	if e.Classification != "SLACK_OUTBOUND_DELIVERED" || e.ParentSpanID != "00f067aa0ba902b7" || e.ContentText != "1.1" ||
+		!strings.Contains(e.Metadata, `"task_id":"task-1"`) {
+		t.Fatalf("unexpected delivery span: %+v", e)
+	}
+}
+
+func TestMarkBridgeDeliveryFailed(t *testing.T) {
+	timeSvc, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatalf("timeline: %v", err)
+	}
+	defer timeSvc.Close()
+
+	task, err := timeSvc.CreateTask(&timeline.AgentTask{Channel: "discord", ChatID: "c1"})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	_ = timeSvc.UpdateTaskDeliveryWithReason(task.TaskID, timeline.DeliverySent, nil, "")
+
+	if err := MarkBridgeDeliveryFailed(timeSvc, "slack", task.TaskID, "503"); err == nil {
+		t.Fatal("expected a task of another channel to be refused")
+	}
+	if err := MarkBridgeDeliveryFailed(timeSvc, "discord", task.TaskID, "503 Service Unavailable"); err != nil {
+		t.Fatalf("mark failed: %v", err)
+	}
+	got, _ := timeSvc.GetTask(task.TaskID)
+	if got.DeliveryStatus != timeline.DeliveryFailed || got.ErrorText != "bridge_dead_letter: 503 Service Unavailable" {
+		t.Fatalf("expected a failed delivery, got %q %q", got.DeliveryStatus, got.ErrorText)
+	}
+}
+
 func TestSlackSendUsesOutboundBridge(t *testing.T) {
 	var got map[string]any
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -255,6 +483,174 @@ func TestMSTeamsSendUsesOutboundBridge(t *testing.T) {
 	}
 }
 
+func TestSlackSendInlinesLocalMedia(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	img := filepath.Join(home, ".kafclaw", "workspace", "media", "generated", "img-1.png")
+	if err := os.MkdirAll(filepath.Dir(img), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(img, []byte("\x89PNG\r\n\x1a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	outside := filepath.Join(home, "secret.txt")
+	_ = os.WriteFile(outside, []byte("secret"), 0o644)
+
+	var got struct {
+		MediaURLs []string `json:"media_urls"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	ch := NewSlackChannel(config.SlackConfig{Enabled: true, OutboundURL: srv.URL}, bus.NewMessageBus(), nil)
+	err := ch.Send(context.Background(), &bus.OutboundMessage{
+		Channel:   "slack",
+		ChatID:    "C1",
+		Content:   "here is the diagram",
+		MediaURLs: []string{img, outside, "https://files.slack.com/x.png"},
+	})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if len(got.MediaURLs) != 2 || !strings.HasPrefix(got.MediaURLs[0], "data:image/png;base64,") || got.MediaURLs[1] != "https://files.slack.com/x.png" {
+		t.Fatalf("unexpected media urls: %v", got.MediaURLs)
+	}
+}
+
+func TestSlackAcceptInboundStoresBridgeMedia(t *testing.T) {
+	workspace := t.TempDir()
+	SetMediaDir(filepath.Join(workspace, "media"))
+	t.Cleanup(func() { SetMediaDir("") })
+	msgBus := bus.NewMessageBus()
+	ch := NewSlackChannel(config.SlackConfig{Enabled: true, AllowFrom: []string{"U123"}, DmPolicy: config.DmPolicyAllowlist}, msgBus, nil)
+
+	media := BridgeMediaFromRequest(
+		[]string{"data:audio/ogg;base64," + base64.StdEncoding.EncodeToString([]byte("voice")), "data:text/plain,not-base64", "https://files.example.com/doc.pdf"},
+		[]string{"audio/ogg"},
+		[]string{"memo"},
+	)
+	if err := ch.HandleEventWithAccount("", "U123", "D1", "", "1700.02", "[file shared]", false, false, "file_shared", nil, SlackWorkspace{}, media, ""); err != nil {
+		t.Fatalf("handle event: %v", err)
+	}
+	msg, err := msgBus.ConsumeInbound(t.Context())
+	if err != nil {
+		t.Fatalf("consume inbound: %v", err)
+	}
+	want := filepath.Join(workspace, "media", "audio", "slack-1700_02-0.ogg")
+	if len(msg.Media) != 2 || msg.Media[0] != want || msg.Media[1] != "https://files.example.com/doc.pdf" {
+		t.Fatalf("expected the stored file and the remote URL, got %v", msg.Media)
+	}
+	if data, err := os.ReadFile(want); err != nil || string(data) != "voice" {
+		t.Fatalf("stored media: %q %v", data, err)
+	}
+}
+
+type fakeTranscriber struct {
+	text  string
+	err   error
+	files []string
+}
+
+func (f *fakeTranscriber) Transcribe(_ context.Context, req *provider.AudioRequest) (*provider.AudioResponse, error) {
+	f.files = append(f.files, req.FilePath)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &provider.AudioResponse{Text: f.text}, nil
+}
+
+func TestBridgeVoiceNotesArriveTranscribed(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	voice := BridgeMediaFromRequest([]string{"data:audio/ogg;base64," + base64.StdEncoding.EncodeToString([]byte("voice"))}, []string{"audio/ogg"}, nil)
+
+	msgBus := bus.NewMessageBus()
+	slackTr := &fakeTranscriber{text: " ship it on friday "}
+	slack := NewSlackChannel(config.SlackConfig{Enabled: true, AllowFrom: []string{"U123"}, DmPolicy: config.DmPolicyAllowlist}, msgBus, nil)
+	slack.SetTranscriber(slackTr)
+	if _, err := slack.AcceptInbound("", "U123", "D1", "", "1700.03", "[file shared]", false, false, 0, 0, SlackWorkspace{}, voice, ""); err != nil {
+		t.Fatalf("accept inbound: %v", err)
+	}
+	msg, err := msgBus.ConsumeInbound(t.Context())
+	if err != nil {
+		t.Fatalf("consume inbound: %v", err)
+	}
+	if msg.Content != "[Audio Transcript]: ship it on friday" || len(slackTr.files) != 1 || slackTr.files[0] != msg.Media[0] {
+		t.Fatalf("expected the placeholder replaced by the transcript, got %q (transcribed %v)", msg.Content, slackTr.files)
+	}
+
+	teams := NewMSTeamsChannel(config.MSTeamsConfig{Enabled: true, AllowFrom: []string{"29:u1"}, DmPolicy: config.DmPolicyAllowlist}, msgBus, nil)
+	teams.SetTranscriber(&fakeTranscriber{text: "see notes"})
+	if _, err := teams.AcceptInbound("", "29:u1", "a:conv", "", "m1", "listen to this", false, false, "", "", 0, 0, voice, ""); err != nil {
+		t.Fatalf("accept inbound: %v", err)
+	}
+	if msg, err = msgBus.ConsumeInbound(t.Context()); err != nil || msg.Content != "listen to this\n\n[Audio Transcript]: see notes" {
+		t.Fatalf("expected the transcript after the text, got %q %v", msg.Content, err)
+	}
+
+	teams.SetTranscriber(&fakeTranscriber{err: errors.New("whisper not installed")})
+	if _, err := teams.AcceptInbound("", "29:u1", "a:conv", "", "m2", "[voice]", false, false, "", "", 0, 0, voice, ""); err != nil {
+		t.Fatalf("accept inbound: %v", err)
+	}
+	if msg, err = msgBus.ConsumeInbound(t.Context()); err != nil || msg.Content != "[voice]" || len(msg.Media) != 1 {
+		t.Fatalf("expected the message unchanged when transcription fails, got %+v %v", msg, err)
+	}
+}
+
+func TestOutboundTemplateRendersPerChannel(t *testing.T) {
+	workspace := t.TempDir()
+	templates := msgtemplate.NewRegistry(workspace)
+	if err := templates.Save(&msgtemplate.Template{Name: "deploy", Title: "Deploy of {{.service}}", Fields: []msgtemplate.Field{{Label: "Env", Value: "{{.env}}"}}}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		got = nil
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+	msg := func() *bus.OutboundMessage {
+		return &bus.OutboundMessage{ChatID: "C1", Content: "Shipped.", Template: "deploy", TemplateVars: map[string]any{"service": "api", "env": "prod"}}
+	}
+
+	slack := NewSlackChannel(config.SlackConfig{Enabled: true, OutboundURL: srv.URL}, bus.NewMessageBus(), nil)
+	slack.Templates = templates
+	if err := slack.Send(context.Background(), msg()); err != nil {
+		t.Fatalf("slack send: %v", err)
+	}
+	card, _ := got["card"].(map[string]any)
+	if blocks, _ := card["blocks"].([]any); len(blocks) != 3 || got["content"] != "Shipped." {
+		t.Fatalf("expected Block Kit with the reply text, got %#v", got)
+	}
+
+	teams := NewMSTeamsChannel(config.MSTeamsConfig{Enabled: true, OutboundURL: srv.URL}, bus.NewMessageBus(), nil)
+	teams.Templates = templates
+	if err := teams.Send(context.Background(), msg()); err != nil {
+		t.Fatalf("teams send: %v", err)
+	}
+	if card, _ := got["card"].(map[string]any); card["type"] != "AdaptiveCard" || got["content"] != "" {
+		t.Fatalf("expected an Adaptive Card only, got %#v", got)
+	}
+
+	telegram := NewTelegramChannel(config.TelegramConfig{Enabled: true, OutboundURL: srv.URL}, bus.NewMessageBus(), nil)
+	telegram.Templates = templates
+	if err := telegram.Send(context.Background(), msg()); err != nil {
+		t.Fatalf("telegram send: %v", err)
+	}
+	if got["content"] != "Shipped.\n\n*Deploy of api*\n\nEnv: prod" {
+		t.Fatalf("expected plain text, got %#v", got["content"])
+	}
+
+	missing := msg()
+	missing.TemplateVars = map[string]any{"service": "api"}
+	if err := slack.Send(context.Background(), missing); err == nil {
+		t.Fatal("expected an error for a missing template variable")
+	}
+}
+
 func TestSlackPairingApproveThenAllowedIntegration(t *testing.T) {
 	msgBus := bus.NewMessageBus()
 	db := filepath.Join(t.TempDir(), "timeline.db")
@@ -709,3 +1105,149 @@ func TestMSTeamsSessionScopeUserMode(t *testing.T) {
 		t.Fatalf("unexpected scope: %q", scope)
 	}
 }
+
+func TestSlackReadThreadViaBridge(t *testing.T) {
+	var got map[string]any
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		auth = r.Header.Get("Authorization")
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": map[string]any{"ok": true, "messages": []map[string]any{
+			{"id": "1.1", "sender_id": "U1", "sender_name": "alex", "text": "hello", "ts": "2026-01-02T09:30:00Z"},
+		}}})
+	}))
+	defer srv.Close()
+
+	ch := NewSlackChannel(config.SlackConfig{
+		Enabled:  true,
+		Accounts: []config.SlackAccountConfig{{ID: "acme", OutboundURL: srv.URL, BotToken: "xoxb-acme"}},
+	}, bus.NewMessageBus(), nil)
+	msgs, err := ch.ReadThread(context.Background(), withAccountChat("acme", "C1"), "1.0", 5)
+	if err != nil {
+		t.Fatalf("read thread: %v", err)
+	}
+	if got["action"] != "thread" || got["chat_id"] != "C1" || got["thread_id"] != "1.0" || got["account_id"] != "acme" || auth != "Bearer xoxb-acme" {
+		t.Fatalf("unexpected bridge request %#v auth=%q", got, auth)
+	}
+	if params, _ := got["action_params"].(map[string]any); params["limit"] != float64(5) {
+		t.Fatalf("expected limit 5, got %#v", got["action_params"])
+	}
+	if len(msgs) != 1 || msgs[0].SenderName != "alex" || !msgs[0].Timestamp.Equal(time.Date(2026, 1, 2, 9, 30, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected messages %+v", msgs)
+	}
+
+	if _, err := NewMSTeamsChannel(config.MSTeamsConfig{Enabled: true}, bus.NewMessageBus(), nil).ReadThread(context.Background(), "conv-1", "a1", 5); err == nil {
+		t.Fatal("expected an error without an outbound bridge")
+	}
+}
+
+func TestSlackScheduledMessagesViaBridge(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		switch {
+		case r.URL.Path == "/scheduled":
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "scheduled": []map[string]any{
+				{"id": "s1", "channel": "slack", "chat_id": "C1", "send_at": "2030-01-01T09:00:00Z", "preview": "standup"},
+			}})
+		case r.URL.Path == "/scheduled/s1/cancel":
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
+		default:
+			http.Error(w, "scheduled send not found", http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	msgBus := bus.NewMessageBus()
+	ch := NewSlackChannel(config.SlackConfig{
+		Enabled:  true,
+		Accounts: []config.SlackAccountConfig{{ID: "acme", OutboundURL: srv.URL + "/slack/outbound"}},
+	}, msgBus, nil)
+	if err := ch.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	m, err := msgBus.Scheduled("slack")
+	if err != nil {
+		t.Fatalf("expected a schedule manager: %v", err)
+	}
+	items, err := m.ListScheduled(context.Background(), withAccountChat("acme", "C1"))
+	if err != nil || len(items) != 1 || items[0].ID != "s1" || items[0].SendAt != "2030-01-01T09:00:00Z" {
+		t.Fatalf("unexpected list: %+v %v", items, err)
+	}
+	if err := m.CancelScheduled(context.Background(), withAccountChat("acme", "C1"), "s1"); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if err := m.CancelScheduled(context.Background(), withAccountChat("acme", "C1"), "gone"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if requests[0] != "GET /scheduled?channel=slack&chat_id=C1" || requests[1] != "POST /scheduled/s1/cancel?chat_id=C1" {
+		t.Fatalf("unexpected bridge requests %v", requests)
+	}
+}
+
+func TestSlackWorkspaceSeparatesUsersWithTheSameID(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	ch := NewSlackChannel(config.SlackConfig{
+		Enabled:      true,
+		AllowFrom:    []string{"T1:U123", "T2:U123"},
+		DmPolicy:     config.DmPolicyAllowlist,
+		GroupPolicy:  config.GroupPolicyAllowlist,
+		SessionScope: "user",
+	}, msgBus, nil)
+
+	scopes := map[string]bool{}
+	for _, ws := range []SlackWorkspace{{ID: "T1", EnterpriseID: "E1"}, {ID: "T2", EnterpriseID: "E1"}} {
+		if err := ch.HandleEventWithAccount("", "U123", "D1", "", "e-"+ws.ID, "[file shared]", false, false, "file_shared", nil, ws, nil, ""); err != nil {
+			t.Fatalf("handle event: %v", err)
+		}
+		msg, err := msgBus.ConsumeInbound(t.Context())
+		if err != nil {
+			t.Fatalf("consume inbound: %v", err)
+		}
+		if msg.Metadata[bus.MetaKeyWorkspace] != ws.ID || msg.Metadata[bus.MetaKeyEnterprise] != "E1" {
+			t.Fatalf("expected workspace metadata, got %#v", msg.Metadata)
+		}
+		scopes[msg.Metadata[bus.MetaKeySessionScope].(string)] = true
+	}
+	if !scopes["slack:default:T1:U123"] || !scopes["slack:default:T2:U123"] {
+		t.Fatalf("expected one user session per workspace, got %v", scopes)
+	}
+}
+
+func TestSlackWorkspaceScopesAccessPairingAndRooms(t *testing.T) {
+	timeSvc, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatalf("timeline: %v", err)
+	}
+	defer timeSvc.Close()
+	msgBus := bus.NewMessageBus()
+	ch := NewSlackChannel(config.SlackConfig{
+		Enabled:     true,
+		AllowFrom:   []string{"T1:U123"},
+		DmPolicy:    config.DmPolicyPairing,
+		GroupPolicy: config.GroupPolicyAllowlist,
+	}, msgBus, timeSvc)
+
+	bt, err := ch.AcceptInbound("", "U123", "D1", "", "m1", "hi", false, false, 0, 0, SlackWorkspace{ID: "T1"}, nil, "")
+	if err != nil || bt == nil {
+		t.Fatalf("expected the allowlisted user of T1 to pass: %+v %v", bt, err)
+	}
+	msg, err := msgBus.ConsumeInbound(t.Context())
+	if err != nil {
+		t.Fatalf("consume inbound: %v", err)
+	}
+	if scope := msg.Metadata[bus.MetaKeySessionScope]; scope != "slack:default:T1:D1" {
+		t.Fatalf("expected a workspace-qualified room session, got %v", scope)
+	}
+
+	// The same user ID in another workspace is someone else and pairs.
+	bt, err = ch.AcceptInbound("", "U123", "D1", "", "m2", "hi", false, false, 0, 0, SlackWorkspace{ID: "T2"}, nil, "")
+	if err != nil || bt != nil {
+		t.Fatalf("expected T2:U123 to be refused, got %+v %v", bt, err)
+	}
+	pending, err := NewPairingService(timeSvc).ListPending()
+	if err != nil || len(pending) != 1 || pending[0].SenderID != "T2:U123" {
+		t.Fatalf("expected a workspace-qualified pairing, got %+v %v", pending, err)
+	}
+}
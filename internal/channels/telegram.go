@@ -0,0 +1,174 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+	"github.com/KafClaw/KafClaw/internal/tracing"
+)
+
+// TelegramChannel is the Telegram transport. The channelbridge receives the
+// Bot API updates (webhook or long polling) and forwards messages to the
+// inbound API; replies are posted to the bridge's outbound endpoint.
+type TelegramChannel struct {
+	BaseChannel
+	config   config.TelegramConfig
+	timeline *timeline.TimelineService
+	parts    outboundParts
+}
+
+func NewTelegramChannel(cfg config.TelegramConfig, messageBus *bus.MessageBus, tl *timeline.TimelineService) *TelegramChannel {
+	return &TelegramChannel{
+		BaseChannel: BaseChannel{Bus: messageBus},
+		config:      cfg,
+		timeline:    tl,
+	}
+}
+
+func (c *TelegramChannel) Name() string { return "telegram" }
+
+func (c *TelegramChannel) Start(ctx context.Context) error {
+	if !c.config.Enabled {
+		return nil
+	}
+	c.Bus.SetMessageLimit(c.Name(), messageLimit(c.config.MaxMessageChars, telegramMaxMessageChars))
+	c.Bus.Subscribe(c.Name(), func(msg *bus.OutboundMessage) {
+		if c.parts.skip(msg) {
+			return
+		}
+		if deferForQuietHours(c.timeline, c.Name(), msg, time.Now()) {
+			return
+		}
+		err := sendTraced(ctx, c.Name(), msg, c.Send)
+		recordSend(c.Name(), err)
+		if !c.parts.finish(msg, err) {
+			return
+		}
+		if c.timeline == nil || strings.TrimSpace(msg.TaskID) == "" {
+			return
+		}
+		if err != nil {
+			reason, cls := classifyDeliveryError(err)
+			if cls == deliveryTransient {
+				next := time.Now().Add(30 * time.Second)
+				_ = c.timeline.UpdateTaskDeliveryWithReason(msg.TaskID, timeline.DeliveryPending, &next, reason)
+			} else {
+				_ = c.timeline.UpdateTaskDeliveryWithReason(msg.TaskID, timeline.DeliveryFailed, nil, reason)
+			}
+			return
+		}
+		_ = c.timeline.UpdateTaskDeliveryWithReason(msg.TaskID, timeline.DeliverySent, nil, "")
+	})
+	return nil
+}
+
+func (c *TelegramChannel) Stop() error { return nil }
+
+func (c *TelegramChannel) Send(ctx context.Context, msg *bus.OutboundMessage) error {
+	if strings.TrimSpace(c.config.OutboundURL) == "" {
+		return nil
+	}
+	msg, err := c.renderTemplate(c.Name(), msg)
+	if err != nil {
+		return err
+	}
+	body, _ := json.Marshal(map[string]any{
+		"channel":    "telegram",
+		"account_id": "default",
+		"chat_id":    strings.TrimSpace(msg.ChatID),
+		"thread_id":  strings.TrimSpace(c.parts.thread(msg)),
+		"content":    msg.Content,
+		"media_urls": bridgeMediaURLs(msg.MediaURLs),
+		"action":     strings.TrimSpace(msg.Action),
+		"trace_id":   msg.TraceID,
+		"task_id":    msg.TaskID,
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.config.OutboundURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	tracing.Inject(req, msg.TraceID)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("telegram outbound bridge status: %d", resp.StatusCode)
+	}
+	if msg.Parts > 1 {
+		var delivery struct {
+			MessageID string `json:"message_id"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&delivery)
+		c.parts.sent(msg, strings.TrimSpace(delivery.MessageID))
+	}
+	return nil
+}
+
+// AcceptInbound creates the agent task of a message forwarded by the bridge
+// and returns it; see SlackChannel.AcceptInbound.
+func (c *TelegramChannel) AcceptInbound(senderID, chatID, threadID, messageID, text string, isGroup, wasMentioned bool, historyLimit, dmHistoryLimit int, traceparent string) (*BridgeTask, error) {
+	decision := EvaluateAccess(AccessContext{
+		SenderID:     senderID,
+		IsGroup:      isGroup,
+		WasMentioned: wasMentioned,
+	}, AccessConfig{
+		Channel:        c.Name(),
+		AllowFrom:      c.config.AllowFrom,
+		GroupAllowFrom: c.config.AllowFrom,
+		DmPolicy:       c.config.DmPolicy,
+		GroupPolicy:    c.config.GroupPolicy,
+		RequireMention: c.config.RequireMention && isGroup,
+	})
+	if decision.RequiresPairing {
+		if c.timeline == nil {
+			return nil, nil
+		}
+		svc := NewPairingService(c.timeline)
+		pending, err := svc.CreateOrGetPending(c.Name(), senderID, 0)
+		if err != nil {
+			return nil, err
+		}
+		c.Bus.PublishOutbound(&bus.OutboundMessage{
+			Channel: c.Name(),
+			ChatID:  strings.TrimSpace(chatID),
+			Content: BuildPairingReply(c.Name(), fmt.Sprintf("Telegram user: %s", strings.TrimSpace(senderID)), pending.Code),
+		})
+		return nil, nil
+	}
+	if !decision.Allowed {
+		return nil, nil
+	}
+	metadata := map[string]any{
+		bus.MetaKeyMessageType:    bus.MessageTypeExternal,
+		bus.MetaKeySessionScope:   buildSessionScope(c.Name(), "default", chatID, threadID, senderID, c.config.SessionScope),
+		bus.MetaKeyChannelAccount: "default",
+	}
+	if historyLimit > 0 {
+		metadata["history_limit"] = historyLimit
+	}
+	if dmHistoryLimit > 0 {
+		metadata["dm_history_limit"] = dmHistoryLimit
+	}
+	msg := &bus.InboundMessage{
+		Channel:   c.Name(),
+		SenderID:  strings.TrimSpace(senderID),
+		ChatID:    strings.TrimSpace(chatID),
+		ThreadID:  strings.TrimSpace(threadID),
+		MessageID: strings.TrimSpace(messageID),
+		Content:   text,
+		Metadata:  metadata,
+	}
+	applyTraceparent(msg, traceparent)
+	return acceptBridgeMessage(c.timeline, c.Bus.PublishInbound, msg)
+}
@@ -0,0 +1,41 @@
+package channels
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+)
+
+// renderTemplate fills the outbound template of msg for the channel: a Block
+// Kit card on Slack, an Adaptive Card on Teams and plain text elsewhere.
+// The message text comes first. Messages without a template, or with a card
+// of their own, are returned as they are.
+func (b *BaseChannel) renderTemplate(channel string, msg *bus.OutboundMessage) (*bus.OutboundMessage, error) {
+	name := strings.TrimSpace(msg.Template)
+	if name == "" || len(msg.Card) > 0 {
+		return msg, nil
+	}
+	if b.Templates == nil {
+		return nil, fmt.Errorf("template %s: no template registry", name)
+	}
+	tpl, err := b.Templates.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	filled, err := tpl.Render(msg.TemplateVars, false)
+	if err != nil {
+		return nil, err
+	}
+	out := *msg
+	switch channel {
+	case "slack":
+		out.Card = filled.SlackBlocks(msg.Content)
+	case "msteams":
+		out.Card = filled.AdaptiveCard(msg.Content)
+		out.Content = ""
+	default:
+		out.Content = filled.PlainText(msg.Content)
+	}
+	return &out, nil
+}
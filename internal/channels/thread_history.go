@@ -0,0 +1,90 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/tracing"
+)
+
+// ReadThread fetches Slack thread history through the channelbridge.
+func (c *SlackChannel) ReadThread(ctx context.Context, chatID, threadID string, limit int) ([]bus.ThreadMessage, error) {
+	accountID, chat := parseAccountChat(strings.TrimSpace(chatID))
+	ac := c.slackAccountConfig(accountID)
+	return readBridgeThread(ctx, c.Name(), ac.OutboundURL, ac.BotToken, accountID, chat, threadID, limit)
+}
+
+// ReadThread fetches Teams thread history through the channelbridge.
+func (c *MSTeamsChannel) ReadThread(ctx context.Context, chatID, threadID string, limit int) ([]bus.ThreadMessage, error) {
+	accountID, chat := parseAccountChat(strings.TrimSpace(chatID))
+	ac := c.teamsAccountConfig(accountID)
+	return readBridgeThread(ctx, c.Name(), ac.OutboundURL, ac.AppPassword, accountID, chat, threadID, limit)
+}
+
+// readBridgeThread posts the "thread" action to a channelbridge outbound
+// endpoint and decodes the messages it returns.
+func readBridgeThread(ctx context.Context, channel, outboundURL, token, accountID, chatID, threadID string, limit int) ([]bus.ThreadMessage, error) {
+	if strings.TrimSpace(outboundURL) == "" {
+		return nil, fmt.Errorf("%s has no outbound bridge", channel)
+	}
+	body, _ := json.Marshal(map[string]any{
+		"channel":       channel,
+		"account_id":    accountID,
+		"chat_id":       chatID,
+		"thread_id":     strings.TrimSpace(threadID),
+		"action":        "thread",
+		"action_params": map[string]any{"limit": limit},
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, outboundURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	tracing.Inject(req, tracing.TraceID(ctx))
+	if tok := strings.TrimSpace(token); tok != "" {
+		req.Header.Set("Authorization", "Bearer "+tok)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s thread read bridge status: %d", channel, resp.StatusCode)
+	}
+	var out struct {
+		Result struct {
+			Messages []struct {
+				ID         string `json:"id"`
+				SenderID   string `json:"sender_id"`
+				SenderName string `json:"sender_name"`
+				IsBot      bool   `json:"is_bot"`
+				Text       string `json:"text"`
+				Timestamp  string `json:"ts"`
+			} `json:"messages"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 4<<20)).Decode(&out); err != nil {
+		return nil, fmt.Errorf("%s thread read: %w", channel, err)
+	}
+	msgs := make([]bus.ThreadMessage, 0, len(out.Result.Messages))
+	for _, m := range out.Result.Messages {
+		at, _ := time.Parse(time.RFC3339Nano, m.Timestamp)
+		msgs = append(msgs, bus.ThreadMessage{
+			ID:         m.ID,
+			SenderID:   m.SenderID,
+			SenderName: m.SenderName,
+			IsBot:      m.IsBot,
+			Text:       m.Text,
+			Timestamp:  at,
+		})
+	}
+	return msgs, nil
+}
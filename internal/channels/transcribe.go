@@ -0,0 +1,86 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/provider"
+)
+
+// transcribeTimeout bounds the transcription of one bridged message.
+const transcribeTimeout = 2 * time.Minute
+
+// Transcriber converts audio files to text. Every provider.LLMProvider is
+// one; with LocalWhisper enabled that is the local whisper binary.
+type Transcriber interface {
+	Transcribe(ctx context.Context, req *provider.AudioRequest) (*provider.AudioResponse, error)
+}
+
+// publishTranscribed returns how a bridge channel publishes an inbound
+// message. Messages with voice notes are published once these are
+// transcribed, in the background: transcription can take longer than the
+// bridge waits for an answer.
+func publishTranscribed(b *bus.MessageBus, t Transcriber) func(*bus.InboundMessage) {
+	return func(msg *bus.InboundMessage) {
+		audio := audioMediaPaths(msg.Media)
+		if t == nil || len(audio) == 0 {
+			b.PublishInbound(msg)
+			return
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), transcribeTimeout)
+			defer cancel()
+			transcribeInbound(ctx, t, msg, audio)
+			b.PublishInbound(msg)
+		}()
+	}
+}
+
+// audioMediaPaths returns the stored files in the audio media folder.
+func audioMediaPaths(media []string) []string {
+	dir := filepath.Join(mediaRoot(), "audio")
+	var out []string
+	for _, p := range media {
+		if filepath.IsAbs(p) && filepath.Dir(filepath.Clean(p)) == dir {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// transcribeInbound adds the transcripts of the audio files to the message
+// content as "[Audio Transcript]: ...", like WhatsApp voice notes. A
+// placeholder content such as "[file shared]" is replaced. Audio that fails
+// to transcribe stays an attachment.
+func transcribeInbound(ctx context.Context, t Transcriber, msg *bus.InboundMessage, audio []string) {
+	var transcripts []string
+	for _, p := range audio {
+		resp, err := t.Transcribe(ctx, &provider.AudioRequest{FilePath: p})
+		if err != nil {
+			fmt.Printf("❌ %s transcription error: %v\n", msg.Channel, err)
+			continue
+		}
+		if text := strings.TrimSpace(resp.Text); text != "" {
+			transcripts = append(transcripts, text)
+		}
+	}
+	if len(transcripts) == 0 {
+		return
+	}
+	transcript := "[Audio Transcript]: " + strings.Join(transcripts, "\n")
+	if content := strings.TrimSpace(msg.Content); content == "" || isPlaceholderContent(content) {
+		msg.Content = transcript
+	} else {
+		msg.Content = content + "\n\n" + transcript
+	}
+}
+
+// isPlaceholderContent reports whether the bridge stood in a bracketed
+// note for a message without text, e.g. "[file shared]".
+func isPlaceholderContent(s string) bool {
+	return strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") && !strings.Contains(s, "\n")
+}
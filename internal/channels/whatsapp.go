@@ -42,6 +42,10 @@ type WhatsAppChannel struct {
 	denylist  map[string]bool
 	token     string
 	mu        sync.Mutex
+	parts     outboundParts
+
+	// presenceFn replaces the platform presence call in tests.
+	presenceFn func(ctx context.Context, evt *bus.PresenceEvent) error
 }
 
 // NewWhatsAppChannel creates a new WhatsApp channel.
@@ -66,10 +70,14 @@ func (c *WhatsAppChannel) Start(ctx context.Context) error {
 	clientLog := waLog.Stdout("Client", "INFO", true)
 
 	// Initialize database
-	home, _ := os.UserHomeDir()
-	dbPath := filepath.Join(home, ".kafclaw", "whatsapp.db")
+	dbPath, _ := WhatsAppSessionDBPath()
 
 	os.MkdirAll(filepath.Dir(dbPath), 0755)
+	if applied, err := applyStagedWhatsAppSession(dbPath); err != nil {
+		return fmt.Errorf("failed to apply imported whatsapp session: %w", err)
+	} else if applied {
+		fmt.Println("WhatsApp: imported session applied")
+	}
 
 	// sqlstore.New(ctx, driver, url, log)
 	container, err := sqlstore.New(ctx, "sqlite", "file:"+dbPath+"?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)", dbLog)
@@ -129,12 +137,21 @@ func (c *WhatsAppChannel) Start(ctx context.Context) error {
 		fmt.Println("🔇 WhatsApp: silent mode enabled (default-on at startup)")
 	}
 
-	// Subscribe to outbound messages
+	go c.runSessionBackups(ctx, dbPath)
+
+	// Subscribe to outbound messages. Parts of a split message are sent in
+	// order on the dispatcher instead of concurrently.
+	c.Bus.SetMessageLimit(c.Name(), messageLimit(c.config.MaxMessageChars, whatsappMaxMessageChars))
 	c.Bus.Subscribe(c.Name(), func(msg *bus.OutboundMessage) {
+		if msg.Parts > 1 {
+			c.handleOutbound(msg)
+			return
+		}
 		go func() {
 			c.handleOutbound(msg)
 		}()
 	})
+	c.Bus.SubscribePresence(c.Name(), c.handlePresence)
 
 	return nil
 }
@@ -153,6 +170,10 @@ func (c *WhatsAppChannel) Send(ctx context.Context, msg *bus.OutboundMessage) er
 	if c.client == nil {
 		return fmt.Errorf("client not initialized")
 	}
+	msg, err := c.renderTemplate(c.Name(), msg)
+	if err != nil {
+		return err
+	}
 
 	jid, err := types.ParseJID(msg.ChatID)
 	if err != nil {
@@ -170,6 +191,9 @@ func (c *WhatsAppChannel) Send(ctx context.Context, msg *bus.OutboundMessage) er
 }
 
 func (c *WhatsAppChannel) handleOutbound(msg *bus.OutboundMessage) {
+	if c.parts.skip(msg) {
+		return
+	}
 	// Check silent mode — never send if enabled
 	if c.timeline != nil && c.timeline.IsSilentMode() {
 		fmt.Printf("🔇 Silent Mode: suppressed outbound to %s reason=silent_mode channel=%s\n", msg.ChatID, c.Name())
@@ -179,9 +203,16 @@ func (c *WhatsAppChannel) handleOutbound(msg *bus.OutboundMessage) {
 		}
 		return
 	}
+	if deferForQuietHours(c.timeline, c.Name(), msg, time.Now()) {
+		c.logOutbound("deferred", msg)
+		return
+	}
 	sendCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
-	if err := c.sendOutbound(sendCtx, msg); err != nil {
+	err := sendTraced(sendCtx, c.Name(), msg, c.sendOutbound)
+	recordSend(c.Name(), err)
+	settled := c.parts.finish(msg, err)
+	if err != nil {
 		fmt.Printf("Error sending whatsapp message: %v\n", err)
 		c.logOutbound("error", msg)
 		// Mark delivery as pending for retry with backoff
@@ -197,7 +228,7 @@ func (c *WhatsAppChannel) handleOutbound(msg *bus.OutboundMessage) {
 		return
 	}
 	c.logOutbound("sent", msg)
-	if c.timeline != nil && msg.TaskID != "" {
+	if settled && c.timeline != nil && msg.TaskID != "" {
 		_ = c.timeline.UpdateTaskDeliveryWithReason(msg.TaskID, timeline.DeliverySent, nil, "")
 	}
 }
@@ -277,8 +308,6 @@ func (c *WhatsAppChannel) eventHandler(evt interface{}) {
 
 				mediaPath = filePath
 				fmt.Printf("📸 Image saved to %s\n", filePath)
-
-				// Optional: Describe image using Vision API? (For later)
 			} else {
 				fmt.Printf("❌ Image download error: %v\n", err)
 			}
@@ -414,13 +443,19 @@ func (c *WhatsAppChannel) eventHandler(evt interface{}) {
 			if v.Info.IsFromMe {
 				msgType = bus.MessageTypeInternal
 			}
+			var media []string
+			if mediaPath != "" {
+				media = []string{mediaPath}
+			}
 			c.Bus.PublishInbound(&bus.InboundMessage{
 				Channel:        c.Name(),
 				SenderID:       sender,
 				ChatID:         v.Info.Chat.String(),
+				MessageID:      v.Info.ID,
 				TraceID:        traceID,
 				IdempotencyKey: "wa:" + v.Info.ID,
 				Content:        content,
+				Media:          media,
 				Timestamp:      v.Info.Timestamp,
 				Metadata: map[string]any{
 					bus.MetaKeyMessageType: msgType,
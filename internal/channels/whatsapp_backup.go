@@ -0,0 +1,263 @@
+package channels
+
+import (
+	"context"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/secrets"
+)
+
+const (
+	whatsAppBackupFormat     = "kafclaw-whatsapp-session"
+	whatsAppBackupIterations = 600000
+	// whatsAppImportSuffix marks a session imported while the gateway runs;
+	// it replaces the live store on the next WhatsApp channel start.
+	whatsAppImportSuffix = ".import"
+)
+
+// WhatsAppSessionBackup is the portable, encrypted form of the whatsmeow
+// session store. With a passphrase the key is derived via PBKDF2-SHA256 and
+// the backup can be restored on any host; without one it is sealed with the
+// local master key.
+type WhatsAppSessionBackup struct {
+	Format     string          `json:"format"`
+	Version    int             `json:"version"`
+	KDF        string          `json:"kdf"` // pbkdf2-sha256|master-key
+	Iterations int             `json:"iterations,omitempty"`
+	Salt       string          `json:"salt,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	Blob       json.RawMessage `json:"blob"`
+}
+
+// WhatsAppSessionDBPath returns the whatsmeow session store location.
+func WhatsAppSessionDBPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".kafclaw", "whatsapp.db"), nil
+}
+
+// WhatsAppSessionBackupDir returns the directory for periodic session backups.
+func WhatsAppSessionBackupDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".kafclaw", "backups", "whatsapp"), nil
+}
+
+// ExportWhatsAppSession snapshots the session store and encrypts it. The
+// snapshot uses VACUUM INTO, so it is consistent while the client is running.
+func ExportWhatsAppSession(ctx context.Context, dbPath, passphrase string) ([]byte, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("whatsapp session store: %w", err)
+	}
+	tmpDir, err := os.MkdirTemp("", "kafclaw-wa-export-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+	snapshot := filepath.Join(tmpDir, "whatsapp.db")
+
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	if _, err := db.ExecContext(ctx, `VACUUM INTO ?`, snapshot); err != nil {
+		return nil, fmt.Errorf("snapshot whatsapp session: %w", err)
+	}
+	plain, err := os.ReadFile(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	backup := WhatsAppSessionBackup{
+		Format:    whatsAppBackupFormat,
+		Version:   1,
+		CreatedAt: time.Now().UTC(),
+	}
+	var blob []byte
+	if passphrase != "" {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		key, err := pbkdf2.Key(sha256.New, passphrase, salt, whatsAppBackupIterations, 32)
+		if err != nil {
+			return nil, err
+		}
+		backup.KDF = "pbkdf2-sha256"
+		backup.Iterations = whatsAppBackupIterations
+		backup.Salt = base64.RawStdEncoding.EncodeToString(salt)
+		blob, err = secrets.EncryptBlobWithKey(plain, key)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		backup.KDF = "master-key"
+		blob, err = secrets.EncryptBlob(plain)
+		if err != nil {
+			return nil, err
+		}
+	}
+	backup.Blob = blob
+	return json.MarshalIndent(backup, "", "  ")
+}
+
+// DecryptWhatsAppSession validates and decrypts an exported session.
+func DecryptWhatsAppSession(data []byte, passphrase string) ([]byte, error) {
+	var backup WhatsAppSessionBackup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return nil, fmt.Errorf("parse whatsapp session backup: %w", err)
+	}
+	if backup.Format != whatsAppBackupFormat || backup.Version != 1 {
+		return nil, fmt.Errorf("unsupported whatsapp session backup (format=%q version=%d)", backup.Format, backup.Version)
+	}
+	var (
+		plain []byte
+		err   error
+	)
+	switch backup.KDF {
+	case "pbkdf2-sha256":
+		if passphrase == "" {
+			return nil, fmt.Errorf("passphrase required")
+		}
+		salt, decErr := base64.RawStdEncoding.DecodeString(backup.Salt)
+		if decErr != nil || backup.Iterations <= 0 {
+			return nil, fmt.Errorf("invalid key derivation parameters")
+		}
+		key, keyErr := pbkdf2.Key(sha256.New, passphrase, salt, backup.Iterations, 32)
+		if keyErr != nil {
+			return nil, keyErr
+		}
+		plain, err = secrets.DecryptBlobWithKey(backup.Blob, key)
+	case "master-key":
+		plain, err = secrets.DecryptBlob(backup.Blob)
+	default:
+		return nil, fmt.Errorf("unsupported key derivation %q", backup.KDF)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decrypt whatsapp session backup: %w", err)
+	}
+	if !strings.HasPrefix(string(plain), "SQLite format 3\x00") {
+		return nil, fmt.Errorf("decrypted backup is not a session store")
+	}
+	return plain, nil
+}
+
+// ImportWhatsAppSession decrypts a backup and stages it next to dbPath. The
+// staged store replaces the live one on the next WhatsApp channel start, so
+// the running client is never swapped underneath.
+func ImportWhatsAppSession(data []byte, dbPath, passphrase string) error {
+	plain, err := DecryptWhatsAppSession(data, passphrase)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0o700); err != nil {
+		return err
+	}
+	staged := dbPath + whatsAppImportSuffix
+	tmp := staged + ".tmp"
+	if err := os.WriteFile(tmp, plain, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, staged)
+}
+
+// applyStagedWhatsAppSession moves an imported session into place. Stale WAL
+// and SHM files of the old store are removed so they are not replayed.
+func applyStagedWhatsAppSession(dbPath string) (bool, error) {
+	staged := dbPath + whatsAppImportSuffix
+	if _, err := os.Stat(staged); err != nil {
+		return false, nil
+	}
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !os.IsNotExist(err) {
+			return false, err
+		}
+	}
+	if err := os.Rename(staged, dbPath); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// BackupWhatsAppSession writes a master-key encrypted session backup into dir
+// and keeps the newest keep backups.
+func BackupWhatsAppSession(ctx context.Context, dbPath, dir string, keep int) (string, error) {
+	data, err := ExportWhatsAppSession(ctx, dbPath, "")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "whatsapp-session-"+time.Now().UTC().Format("20060102-150405Z")+".json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", err
+	}
+	if keep > 0 {
+		backups, _ := ListWhatsAppSessionBackups(dir)
+		for i := keep; i < len(backups); i++ {
+			_ = os.Remove(backups[i])
+		}
+	}
+	return path, nil
+}
+
+// ListWhatsAppSessionBackups returns backup files in dir, newest first.
+func ListWhatsAppSessionBackups(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), "whatsapp-session-") && strings.HasSuffix(e.Name(), ".json") {
+			out = append(out, filepath.Join(dir, e.Name()))
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(out)))
+	return out, nil
+}
+
+// runSessionBackups writes periodic session backups until ctx is done.
+func (c *WhatsAppChannel) runSessionBackups(ctx context.Context, dbPath string) {
+	if c.config.SessionBackupHours <= 0 {
+		return
+	}
+	dir, err := WhatsAppSessionBackupDir()
+	if err != nil {
+		return
+	}
+	ticker := time.NewTicker(time.Duration(c.config.SessionBackupHours) * time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if path, err := BackupWhatsAppSession(ctx, dbPath, dir, c.config.SessionBackupKeep); err != nil {
+				fmt.Printf("⚠️ WhatsApp session backup failed: %v\n", err)
+			} else {
+				fmt.Printf("💾 WhatsApp session backed up to %s\n", path)
+			}
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package channels
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestSessionStore(t *testing.T, dir string) string {
+	t.Helper()
+	dbPath := filepath.Join(dir, "whatsapp.db")
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?_pragma=journal_mode(WAL)")
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE whatsmeow_device (jid TEXT PRIMARY KEY)`); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO whatsmeow_device (jid) VALUES ('4912345:1@s.whatsapp.net')`); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	return dbPath
+}
+
+func readDeviceJID(t *testing.T, dbPath string) string {
+	t.Helper()
+	db, err := sql.Open("sqlite", "file:"+dbPath)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer db.Close()
+	var jid string
+	if err := db.QueryRow(`SELECT jid FROM whatsmeow_device`).Scan(&jid); err != nil {
+		t.Fatalf("read device: %v", err)
+	}
+	return jid
+}
+
+func TestWhatsAppSessionExportImportRoundTrip(t *testing.T) {
+	src := newTestSessionStore(t, t.TempDir())
+	data, err := ExportWhatsAppSession(context.Background(), src, "correct horse battery")
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if strings.Contains(string(data), "whatsmeow_device") {
+		t.Fatal("expected export to be encrypted")
+	}
+
+	dst := filepath.Join(t.TempDir(), "whatsapp.db")
+	if err := ImportWhatsAppSession(data, dst, "wrong passphrase"); err == nil {
+		t.Fatal("expected import with wrong passphrase to fail")
+	}
+	if err := ImportWhatsAppSession(data, dst, "correct horse battery"); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Fatal("expected import to be staged, not applied to the live store")
+	}
+	if err := os.WriteFile(dst+"-wal", []byte("stale"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := applyStagedWhatsAppSession(dst)
+	if err != nil || !applied {
+		t.Fatalf("apply staged: applied=%v err=%v", applied, err)
+	}
+	if _, err := os.Stat(dst + "-wal"); !os.IsNotExist(err) {
+		t.Fatal("expected stale WAL removed")
+	}
+	if got := readDeviceJID(t, dst); got != "4912345:1@s.whatsapp.net" {
+		t.Fatalf("unexpected restored device %q", got)
+	}
+	if applied, _ := applyStagedWhatsAppSession(dst); applied {
+		t.Fatal("expected nothing staged after apply")
+	}
+}
+
+func TestBackupWhatsAppSessionKeepsNewest(t *testing.T) {
+	t.Setenv("KAFCLAW_OAUTH_MASTER_KEY", base64.RawStdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))))
+	src := newTestSessionStore(t, t.TempDir())
+	dir := t.TempDir()
+
+	var last string
+	for i := 0; i < 3; i++ {
+		path, err := BackupWhatsAppSession(context.Background(), src, dir, 2)
+		if err != nil {
+			t.Fatalf("backup %d: %v", i, err)
+		}
+		last = path
+		time.Sleep(1100 * time.Millisecond)
+	}
+	backups, err := ListWhatsAppSessionBackups(dir)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(backups) != 2 || backups[0] != last {
+		t.Fatalf("expected 2 newest backups with latest first, got %v", backups)
+	}
+
+	data, err := os.ReadFile(last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := DecryptWhatsAppSession(data, "")
+	if err != nil {
+		t.Fatalf("decrypt master-key backup: %v", err)
+	}
+	if !strings.HasPrefix(string(plain), "SQLite format 3") {
+		t.Fatal("expected sqlite snapshot")
+	}
+}
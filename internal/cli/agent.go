@@ -80,6 +80,7 @@ func runAgent(cmd *cobra.Command, args []string) {
 		SubagentMemoryShareMode: cfg.Tools.Subagents.MemoryShareMode,
 		SubagentToolsAllow:      cfg.Tools.Subagents.Tools.Allow,
 		SubagentToolsDeny:       cfg.Tools.Subagents.Tools.Deny,
+		SubagentProgressSec:     cfg.Tools.Subagents.ProgressIntervalSec,
 		Config:                  cfg,
 	})
 
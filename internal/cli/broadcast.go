@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+const (
+	broadcastSenderID        = "broadcast"
+	defaultBroadcastInterval = 250 * time.Millisecond
+	maxBroadcastTargets      = 500
+)
+
+// broadcastTarget is one chat a broadcast is delivered to.
+type broadcastTarget struct {
+	Channel  string `json:"channel"`
+	ChatID   string `json:"chat_id"`
+	ThreadID string `json:"thread_id,omitempty"`
+}
+
+// broadcastRequest is the body of POST /api/v1/channels/broadcast.
+type broadcastRequest struct {
+	Content   string            `json:"content"`
+	Targets   []broadcastTarget `json:"targets"`
+	Audiences []string          `json:"audiences"`
+	// IntervalMs is the pause between batches; BatchSize targets are sent
+	// back to back before each pause.
+	IntervalMs int `json:"interval_ms"`
+	BatchSize  int `json:"batch_size"`
+}
+
+// resolveBroadcastTargets expands saved audiences, normalizes targets and
+// drops duplicates while keeping the request order.
+func resolveBroadcastTargets(tl *timeline.TimelineService, req broadcastRequest) ([]broadcastTarget, error) {
+	all := append([]broadcastTarget{}, req.Targets...)
+	for _, name := range req.Audiences {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		aud, err := tl.GetBroadcastAudience(name)
+		if err != nil {
+			return nil, err
+		}
+		if aud == nil {
+			return nil, fmt.Errorf("unknown audience %q", name)
+		}
+		var targets []broadcastTarget
+		if err := json.Unmarshal([]byte(aud.Targets), &targets); err != nil {
+			return nil, fmt.Errorf("audience %q: invalid targets: %w", name, err)
+		}
+		all = append(all, targets...)
+	}
+
+	seen := map[string]bool{}
+	out := make([]broadcastTarget, 0, len(all))
+	for _, t := range all {
+		t.Channel = strings.ToLower(strings.TrimSpace(t.Channel))
+		t.ChatID = strings.TrimSpace(t.ChatID)
+		t.ThreadID = strings.TrimSpace(t.ThreadID)
+		if t.Channel == "" || t.ChatID == "" {
+			return nil, fmt.Errorf("every target needs channel and chat_id")
+		}
+		key := t.Channel + "\x00" + t.ChatID + "\x00" + t.ThreadID
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, t)
+	}
+	if len(out) > maxBroadcastTargets {
+		return nil, fmt.Errorf("too many targets (%d > %d)", len(out), maxBroadcastTargets)
+	}
+	return out, nil
+}
+
+// queueBroadcast records one task per target under the broadcast ID (used as
+// trace ID) so delivery status can be aggregated before and after dispatch.
+func queueBroadcast(tl *timeline.TimelineService, broadcastID, content string, targets []broadcastTarget) ([]string, error) {
+	taskIDs := make([]string, 0, len(targets))
+	for _, t := range targets {
+		task, err := tl.CreateTask(&timeline.AgentTask{
+			IdempotencyKey: fmt.Sprintf("%s:%s:%s:%s", broadcastID, t.Channel, t.ChatID, t.ThreadID),
+			TraceID:        broadcastID,
+			Channel:        t.Channel,
+			ChatID:         t.ChatID,
+			SenderID:       broadcastSenderID,
+			MessageType:    bus.MessageTypeInternal,
+			ContentIn:      content,
+		})
+		if err != nil {
+			return nil, err
+		}
+		taskIDs = append(taskIDs, task.TaskID)
+	}
+	return taskIDs, nil
+}
+
+// dispatchBroadcast fans the message out via the bus. Each task is marked
+// sent and completed before publishing, so a failure reported by the channel
+// is not overwritten and retryable failures are picked up by the delivery
+// worker.
+func dispatchBroadcast(ctx context.Context, tl *timeline.TimelineService, msgBus *bus.MessageBus, broadcastID, content string, targets []broadcastTarget, taskIDs []string, interval time.Duration, batchSize int) {
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	for i, t := range targets {
+		if i > 0 && i%batchSize == 0 && interval > 0 {
+			select {
+			case <-ctx.Done():
+				for _, id := range taskIDs[i:] {
+					_ = tl.UpdateTaskStatus(id, timeline.TaskStatusFailed, "", "broadcast cancelled")
+				}
+				return
+			case <-time.After(interval):
+			}
+		}
+		_ = tl.UpdateTaskDeliveryWithReason(taskIDs[i], timeline.DeliverySent, nil, "")
+		_ = tl.UpdateTaskStatus(taskIDs[i], timeline.TaskStatusCompleted, content, "")
+		msgBus.PublishOutbound(&bus.OutboundMessage{
+			Channel:  t.Channel,
+			ChatID:   t.ChatID,
+			ThreadID: t.ThreadID,
+			TraceID:  broadcastID,
+			TaskID:   taskIDs[i],
+			Content:  content,
+		})
+	}
+}
+
+// broadcastStatus aggregates the per-target delivery status of a broadcast.
+func broadcastStatus(tl *timeline.TimelineService, broadcastID string) (map[string]any, error) {
+	tasks, err := tl.ListTasksByTrace(broadcastID)
+	if err != nil {
+		return nil, err
+	}
+	counts := map[string]int{}
+	targets := make([]map[string]any, 0, len(tasks))
+	for _, task := range tasks {
+		if task.SenderID != broadcastSenderID {
+			continue
+		}
+		state := task.DeliveryStatus
+		switch {
+		case task.Status == timeline.TaskStatusFailed:
+			state = timeline.DeliveryFailed
+		case task.Status != timeline.TaskStatusCompleted:
+			state = "queued"
+		}
+		counts[state]++
+		targets = append(targets, map[string]any{
+			"channel":  task.Channel,
+			"chat_id":  task.ChatID,
+			"task_id":  task.TaskID,
+			"status":   state,
+			"attempts": task.DeliveryAttempts,
+			"error":    task.ErrorText,
+		})
+	}
+	if len(targets) == 0 {
+		return nil, nil
+	}
+	return map[string]any{
+		"broadcast_id": broadcastID,
+		"total":        len(targets),
+		"counts":       counts,
+		"done":         counts["queued"] == 0 && counts[timeline.DeliveryPending] == 0,
+		"targets":      targets,
+	}, nil
+}
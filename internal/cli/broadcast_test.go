@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestBroadcastFanOutAndStatus(t *testing.T) {
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatalf("open timeline: %v", err)
+	}
+	defer tl.Close()
+
+	if err := tl.UpsertBroadcastAudience(&timeline.BroadcastAudienceRecord{
+		Name:    "ops",
+		Targets: `[{"channel":"slack","chat_id":"C1"},{"channel":"msteams","chat_id":"conv-1"}]`,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	targets, err := resolveBroadcastTargets(tl, broadcastRequest{
+		Targets:   []broadcastTarget{{Channel: "Slack", ChatID: "C1"}, {Channel: "whatsapp", ChatID: "49123@s.whatsapp.net"}},
+		Audiences: []string{"ops"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 3 {
+		t.Fatalf("expected duplicates dropped, got %+v", targets)
+	}
+	if _, err := resolveBroadcastTargets(tl, broadcastRequest{Audiences: []string{"nope"}}); err == nil {
+		t.Fatal("expected error for unknown audience")
+	}
+
+	const id = "broadcast-test"
+	taskIDs, err := queueBroadcast(tl, id, "maintenance at 18:00", targets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, _ := broadcastStatus(tl, id)
+	if status["total"] != 3 || status["counts"].(map[string]int)["queued"] != 3 || status["done"] != false {
+		t.Fatalf("unexpected queued status: %+v", status)
+	}
+
+	msgBus := bus.NewMessageBus()
+	var got []*bus.OutboundMessage
+	for _, ch := range []string{"slack", "msteams", "whatsapp"} {
+		msgBus.Subscribe(ch, func(m *bus.OutboundMessage) { got = append(got, m) })
+	}
+	dispatchBroadcast(context.Background(), tl, msgBus, id, "maintenance at 18:00", targets, taskIDs, time.Millisecond, 2)
+	// Simulate the WhatsApp channel reporting a terminal failure.
+	_ = tl.UpdateTaskDeliveryWithReason(taskIDs[1], timeline.DeliveryFailed, nil, "terminal:not_registered")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_ = msgBus.DispatchOutbound(ctx)
+	if len(got) != 3 || got[0].TaskID != taskIDs[0] || got[0].TraceID != id {
+		t.Fatalf("unexpected outbound: %+v", got)
+	}
+
+	status, _ = broadcastStatus(tl, id)
+	counts := status["counts"].(map[string]int)
+	if counts[timeline.DeliverySent] != 2 || counts[timeline.DeliveryFailed] != 1 || status["done"] != true {
+		t.Fatalf("unexpected final status: %+v", status)
+	}
+	if missing, err := broadcastStatus(tl, "broadcast-unknown"); err != nil || missing != nil {
+		t.Fatalf("expected nil for unknown broadcast, got %+v err=%v", missing, err)
+	}
+}
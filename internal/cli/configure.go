@@ -364,7 +364,9 @@ func countEmbeddedMemoryChunks() (int64, error) {
 	}
 	defer closeFn()
 	var count int64
-	if err := db.QueryRow(`SELECT COUNT(*) FROM memory_chunks WHERE embedding IS NOT NULL`).Scan(&count); err != nil {
+	if err := db.QueryRow(`SELECT COUNT(*) FROM memory_chunks c
+		WHERE c.embedding IS NOT NULL
+		OR EXISTS (SELECT 1 FROM memory_embeddings e WHERE e.chunk_id = c.id)`).Scan(&count); err != nil {
 		return 0, err
 	}
 	return count, nil
@@ -380,6 +382,9 @@ func wipeAllMemoryChunks() (int64, error) {
 	if err != nil {
 		return 0, err
 	}
+	// Dimensions of the per-model indexes may change with the switch.
+	_, _ = db.Exec(`DELETE FROM memory_embeddings`)
+	_, _ = db.Exec(`DELETE FROM memory_embedding_indexes`)
 	n, err := res.RowsAffected()
 	if err != nil {
 		return 0, nil
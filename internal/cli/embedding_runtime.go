@@ -0,0 +1,443 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+// Lifecycle states of the supervised local embedding runtime. An externally
+// managed runtime reports embeddingRuntimeExternal.
+const (
+	embeddingRuntimeExternal    = "external"
+	embeddingRuntimeStopped     = "stopped"
+	embeddingRuntimeDownloading = "downloading"
+	embeddingRuntimeStarting    = "starting"
+	embeddingRuntimeRunning     = "running"
+	embeddingRuntimeBackoff     = "backoff"
+)
+
+const (
+	embeddingRuntimeMinBackoff   = time.Second
+	embeddingRuntimeMaxBackoff   = time.Minute
+	embeddingRuntimeStableAfter  = time.Minute // a run this long resets the backoff
+	embeddingRuntimePollInterval = 500 * time.Millisecond
+	embeddingRuntimeStopGrace    = 5 * time.Second
+)
+
+// embeddingRuntimeState is the lifecycle of the local embedding runtime as
+// reported by the embedding status endpoint.
+type embeddingRuntimeState struct {
+	Supervised    bool       `json:"supervised"`
+	State         string     `json:"state"`
+	Detail        string     `json:"detail,omitempty"`
+	PID           int        `json:"pid,omitempty"`
+	Restarts      int        `json:"restarts"`
+	LastExit      string     `json:"lastExit,omitempty"`
+	StartedAt     *time.Time `json:"startedAt,omitempty"`
+	NextRestartAt *time.Time `json:"nextRestartAt,omitempty"`
+	Model         string     `json:"model,omitempty"`
+	ModelCached   bool       `json:"modelCached"`
+	Downloading   string     `json:"downloading,omitempty"`
+}
+
+// embeddingSupervisor launches the local-hf runtime, downloads its model into
+// the cache dir when missing, and restarts the process with exponential
+// backoff when it exits or does not become healthy in time.
+type embeddingSupervisor struct {
+	command        []string
+	download       string // unexpanded download command
+	vars           map[string]string
+	env            []string
+	model          string
+	cacheDir       string
+	autoDownload   bool
+	endpoint       string
+	startupTimeout time.Duration
+	probe          func() embeddingRuntimeHealth
+
+	// onChange, when set before Start, observes every state transition.
+	onChange func(embeddingRuntimeState)
+
+	mu          sync.Mutex
+	state       embeddingRuntimeState
+	downloading map[string]bool
+	cancel      context.CancelFunc
+	done        chan struct{}
+}
+
+// newEmbeddingSupervisor returns a supervisor for the configured runtime
+// command, or nil when the provider is not local-hf or no command is set.
+func newEmbeddingSupervisor(cfg *config.Config) *embeddingSupervisor {
+	if cfg == nil {
+		return nil
+	}
+	emb := cfg.Memory.Embedding
+	if !emb.Enabled || !strings.EqualFold(strings.TrimSpace(emb.Provider), "local-hf") {
+		return nil
+	}
+	if strings.TrimSpace(emb.Command) == "" {
+		return nil
+	}
+	vars := embeddingCommandVars(emb)
+	timeout := time.Duration(emb.StartupTimeoutSec) * time.Second
+	if timeout <= 0 {
+		timeout = 45 * time.Second
+	}
+	s := &embeddingSupervisor{
+		command:        expandEmbeddingCommand(emb.Command, vars),
+		download:       strings.TrimSpace(emb.DownloadCommand),
+		vars:           vars,
+		model:          strings.TrimSpace(emb.Model),
+		cacheDir:       vars["cacheDir"],
+		autoDownload:   emb.AutoDownload,
+		endpoint:       strings.TrimSpace(emb.Endpoint),
+		startupTimeout: timeout,
+		probe:          func() embeddingRuntimeHealth { return probeEmbeddingRuntime(cfg) },
+		downloading:    map[string]bool{},
+	}
+	s.env = []string{
+		"KAFCLAW_EMBEDDING_MODEL=" + s.model,
+		"KAFCLAW_EMBEDDING_CACHE_DIR=" + s.cacheDir,
+		"KAFCLAW_EMBEDDING_ENDPOINT=" + s.endpoint,
+		"HF_HUB_CACHE=" + s.cacheDir,
+	}
+	if !emb.AutoDownload {
+		s.env = append(s.env, "HF_HUB_OFFLINE=1")
+	}
+	s.state = embeddingRuntimeState{
+		Supervised:  true,
+		State:       embeddingRuntimeStopped,
+		Model:       s.model,
+		ModelCached: embeddingModelCached(s.cacheDir, s.model),
+	}
+	return s
+}
+
+// embeddingCommandVars returns the placeholder values of the runtime and
+// download commands.
+func embeddingCommandVars(emb config.MemoryEmbeddingConfig) map[string]string {
+	vars := map[string]string{
+		"model":    strings.TrimSpace(emb.Model),
+		"cacheDir": expandEmbeddingCacheDir(emb.CacheDir),
+		"endpoint": strings.TrimSpace(emb.Endpoint),
+	}
+	if u, err := url.Parse(vars["endpoint"]); err == nil {
+		vars["host"] = u.Hostname()
+		vars["port"] = u.Port()
+	}
+	return vars
+}
+
+// expandEmbeddingCommand splits command into arguments and substitutes the
+// {name} placeholders per argument, so expanded paths may contain spaces.
+func expandEmbeddingCommand(command string, vars map[string]string) []string {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return nil
+	}
+	pairs := make([]string, 0, 2*len(vars))
+	for k, v := range vars {
+		pairs = append(pairs, "{"+k+"}", v)
+	}
+	r := strings.NewReplacer(pairs...)
+	for i, f := range fields {
+		fields[i] = r.Replace(f)
+	}
+	return fields
+}
+
+// expandEmbeddingCacheDir resolves a leading ~ in the configured cache dir.
+func expandEmbeddingCacheDir(cacheDir string) string {
+	p := strings.TrimSpace(cacheDir)
+	if strings.HasPrefix(p, "~") {
+		if home, err := os.UserHomeDir(); err == nil {
+			p = filepath.Join(home, strings.TrimPrefix(p, "~"))
+		}
+	}
+	return p
+}
+
+// embeddingModelCached reports whether model is present in cacheDir in the
+// Hugging Face hub layout (models--<org>--<name>).
+func embeddingModelCached(cacheDir, model string) bool {
+	if cacheDir == "" || model == "" {
+		return false
+	}
+	dir := filepath.Join(cacheDir, "models--"+strings.ReplaceAll(model, "/", "--"))
+	entries, err := os.ReadDir(dir)
+	return err == nil && len(entries) > 0
+}
+
+// Status returns the current lifecycle state. A nil supervisor reports an
+// externally managed runtime.
+func (s *embeddingSupervisor) Status() embeddingRuntimeState {
+	if s == nil {
+		return embeddingRuntimeState{State: embeddingRuntimeExternal}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.state
+	for model := range s.downloading {
+		st.Downloading = model
+	}
+	return st
+}
+
+// Start launches the supervision loop. It is a no-op on a nil supervisor.
+func (s *embeddingSupervisor) Start() {
+	if s == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.mu.Lock()
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	s.mu.Unlock()
+	go s.run(ctx)
+}
+
+// Stop terminates the runtime and waits for the supervision loop to exit.
+func (s *embeddingSupervisor) Stop() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	cancel, done := s.cancel, s.done
+	s.mu.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// RequestDownload fetches model into the cache dir in the background. It
+// fails when no download command is configured or the model is already
+// being downloaded.
+func (s *embeddingSupervisor) RequestDownload(model string) error {
+	if s == nil || s.download == "" {
+		return fmt.Errorf("no memory.embedding.downloadCommand configured")
+	}
+	model = strings.TrimSpace(model)
+	if model == "" {
+		return fmt.Errorf("embedding model is required")
+	}
+	s.mu.Lock()
+	busy := s.downloading[model]
+	s.mu.Unlock()
+	if busy {
+		return fmt.Errorf("download of %s already running", model)
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+		defer cancel()
+		if err := s.downloadModel(ctx, model); err != nil {
+			slog.Warn("Embedding model download failed", "model", model, "error", err)
+		}
+	}()
+	return nil
+}
+
+func (s *embeddingSupervisor) run(ctx context.Context) {
+	defer close(s.done)
+	backoff := embeddingRuntimeMinBackoff
+	for ctx.Err() == nil {
+		started := time.Now()
+		err := s.ensureModel(ctx)
+		if err == nil {
+			err = s.runOnce(ctx)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		if time.Since(started) >= embeddingRuntimeStableAfter {
+			backoff = embeddingRuntimeMinBackoff
+		}
+		next := time.Now().Add(backoff).UTC()
+		s.update(func(st *embeddingRuntimeState) {
+			st.State = embeddingRuntimeBackoff
+			st.Detail = fmt.Sprintf("restarting in %s", backoff)
+			st.PID = 0
+			st.Restarts++
+			st.LastExit = err.Error()
+			st.NextRestartAt = &next
+		})
+		slog.Warn("Embedding runtime exited", "error", err, "restart_in", backoff)
+		select {
+		case <-ctx.Done():
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, embeddingRuntimeMaxBackoff)
+	}
+	s.update(func(st *embeddingRuntimeState) {
+		st.State = embeddingRuntimeStopped
+		st.Detail = ""
+		st.PID = 0
+		st.NextRestartAt = nil
+	})
+}
+
+// ensureModel downloads the configured model before a start when it is
+// missing and autoDownload allows it. Without a download command the runtime
+// fetches the model itself into the cache dir.
+func (s *embeddingSupervisor) ensureModel(ctx context.Context) error {
+	if !s.autoDownload || s.download == "" || embeddingModelCached(s.cacheDir, s.model) {
+		return nil
+	}
+	return s.downloadModel(ctx, s.model)
+}
+
+func (s *embeddingSupervisor) downloadModel(ctx context.Context, model string) error {
+	s.mu.Lock()
+	if s.downloading[model] {
+		s.mu.Unlock()
+		return fmt.Errorf("download of %s already running", model)
+	}
+	s.downloading[model] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.downloading, model)
+		s.mu.Unlock()
+	}()
+	if model == s.model {
+		s.update(func(st *embeddingRuntimeState) {
+			st.State = embeddingRuntimeDownloading
+			st.Detail = "downloading " + model + " to " + s.cacheDir
+		})
+	}
+
+	if s.cacheDir != "" {
+		if err := os.MkdirAll(s.cacheDir, 0o755); err != nil {
+			return fmt.Errorf("create embedding cache dir: %w", err)
+		}
+	}
+	vars := make(map[string]string, len(s.vars))
+	for k, v := range s.vars {
+		vars[k] = v
+	}
+	vars["model"] = model
+	args := expandEmbeddingCommand(s.download, vars)
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = append(os.Environ(), s.env...)
+	out := &lastLineWriter{}
+	cmd.Stdout, cmd.Stderr = out, out
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("download %s: %w%s", model, err, out.suffix())
+	}
+	if model == s.model {
+		s.update(func(st *embeddingRuntimeState) {
+			st.ModelCached = embeddingModelCached(s.cacheDir, s.model)
+		})
+	}
+	return nil
+}
+
+// runOnce starts the runtime and blocks until it exits. A runtime that is not
+// healthy within the startup timeout is killed.
+func (s *embeddingSupervisor) runOnce(ctx context.Context) error {
+	cmd := exec.CommandContext(ctx, s.command[0], s.command[1:]...)
+	cmd.Env = append(os.Environ(), s.env...)
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = embeddingRuntimeStopGrace
+	out := &lastLineWriter{}
+	cmd.Stdout, cmd.Stderr = out, out
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start %s: %w", s.command[0], err)
+	}
+	startedAt := time.Now().UTC()
+	s.update(func(st *embeddingRuntimeState) {
+		st.State = embeddingRuntimeStarting
+		st.Detail = "waiting for " + s.endpoint + "/healthz"
+		st.PID = cmd.Process.Pid
+		st.StartedAt = &startedAt
+		st.NextRestartAt = nil
+		st.ModelCached = embeddingModelCached(s.cacheDir, s.model)
+	})
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	poll := time.NewTicker(embeddingRuntimePollInterval)
+	defer poll.Stop()
+	deadline := time.NewTimer(s.startupTimeout)
+	defer deadline.Stop()
+	pollC, deadlineC := poll.C, deadline.C
+	for {
+		select {
+		case err := <-exited:
+			if err == nil {
+				err = errors.New("exited")
+			}
+			return fmt.Errorf("runtime %w%s", err, out.suffix())
+		case <-pollC:
+			if !s.probe().Ready {
+				continue
+			}
+			pollC, deadlineC = nil, nil
+			s.update(func(st *embeddingRuntimeState) {
+				st.State = embeddingRuntimeRunning
+				st.Detail = "local embedding runtime healthy"
+				st.ModelCached = embeddingModelCached(s.cacheDir, s.model)
+			})
+			slog.Info("Embedding runtime ready", "pid", cmd.Process.Pid, "endpoint", s.endpoint)
+		case <-deadlineC:
+			_ = cmd.Process.Kill()
+			<-exited
+			return fmt.Errorf("runtime not healthy within %s%s", s.startupTimeout, out.suffix())
+		}
+	}
+}
+
+func (s *embeddingSupervisor) update(fn func(*embeddingRuntimeState)) {
+	s.mu.Lock()
+	fn(&s.state)
+	st := s.state
+	s.mu.Unlock()
+	if s.onChange != nil {
+		s.onChange(st)
+	}
+}
+
+// lastLineWriter keeps the last non-empty output line of a child process for
+// error details.
+type lastLineWriter struct {
+	mu   sync.Mutex
+	line string
+}
+
+func (w *lastLineWriter) Write(p []byte) (int, error) {
+	lines := strings.Split(string(p), "\n")
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i := len(lines) - 1; i >= 0; i-- {
+		if l := strings.TrimSpace(lines[i]); l != "" {
+			if len(l) > 200 {
+				l = l[:200]
+			}
+			w.line = l
+			break
+		}
+	}
+	return len(p), nil
+}
+
+// suffix formats the last line for appending to an error.
+func (w *lastLineWriter) suffix() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.line == "" {
+		return ""
+	}
+	return ": " + w.line
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+func writeEmbeddingScript(t *testing.T, dir, name, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+		t.Fatalf("write script: %v", err)
+	}
+	return path
+}
+
+func waitEmbeddingState(t *testing.T, s *embeddingSupervisor, ok func(embeddingRuntimeState) bool) embeddingRuntimeState {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		st := s.Status()
+		if ok(st) {
+			return st
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("supervisor state not reached, last %+v", st)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestNewEmbeddingSupervisor(t *testing.T) {
+	cfg := config.DefaultConfig()
+	if s := newEmbeddingSupervisor(cfg); s != nil {
+		t.Fatal("expected no supervisor without memory.embedding.command")
+	}
+	if st := (*embeddingSupervisor)(nil).Status(); st.Supervised || st.State != embeddingRuntimeExternal {
+		t.Fatalf("nil supervisor status: %+v", st)
+	}
+
+	cfg.Memory.Embedding.Command = "embed-server --model {model} --host {host} --port {port} --cache {cacheDir}"
+	cfg.Memory.Embedding.CacheDir = "/var/cache/models"
+	cfg.Memory.Embedding.AutoDownload = false
+	s := newEmbeddingSupervisor(cfg)
+	if s == nil {
+		t.Fatal("expected supervisor for local-hf with command")
+	}
+	want := []string{"embed-server", "--model", "BAAI/bge-small-en-v1.5", "--host", "127.0.0.1", "--port", "8091", "--cache", "/var/cache/models"}
+	if !reflect.DeepEqual(s.command, want) {
+		t.Fatalf("command = %v, want %v", s.command, want)
+	}
+	if !strings.Contains(strings.Join(s.env, " "), "HF_HUB_OFFLINE=1") {
+		t.Fatalf("expected offline env without autoDownload: %v", s.env)
+	}
+
+	cfg.Memory.Embedding.Provider = "openai"
+	if s := newEmbeddingSupervisor(cfg); s != nil {
+		t.Fatal("expected no supervisor for non-local provider")
+	}
+}
+
+func TestEmbeddingSupervisorRestartsCrashedRuntime(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.DefaultConfig()
+	cfg.Memory.Embedding.CacheDir = dir
+	cfg.Memory.Embedding.Command = writeEmbeddingScript(t, dir, "runtime.sh", "echo 'model load failed'; exit 3")
+	s := newEmbeddingSupervisor(cfg)
+	s.probe = func() embeddingRuntimeHealth { return embeddingRuntimeHealth{} }
+	s.Start()
+
+	st := waitEmbeddingState(t, s, func(st embeddingRuntimeState) bool { return st.Restarts >= 2 })
+	if !strings.Contains(st.LastExit, "model load failed") {
+		t.Fatalf("expected runtime output in last exit, got %q", st.LastExit)
+	}
+	s.Stop()
+	if st := s.Status(); st.State != embeddingRuntimeStopped || st.PID != 0 {
+		t.Fatalf("expected stopped after Stop, got %+v", st)
+	}
+}
+
+func TestEmbeddingSupervisorDownloadsAndRuns(t *testing.T) {
+	dir := t.TempDir()
+	cache := filepath.Join(dir, "models")
+	cfg := config.DefaultConfig()
+	cfg.Memory.Embedding.CacheDir = cache
+	cfg.Memory.Embedding.AutoDownload = true
+	cfg.Memory.Embedding.Command = writeEmbeddingScript(t, dir, "runtime.sh", "exec sleep 30")
+	cfg.Memory.Embedding.DownloadCommand = writeEmbeddingScript(t, dir, "download.sh",
+		`mkdir -p "$2/models--$(echo "$1" | sed 's#/#--#g')/snapshots"`) + " {model} {cacheDir}"
+	s := newEmbeddingSupervisor(cfg)
+	if s.Status().ModelCached {
+		t.Fatal("model should not be cached yet")
+	}
+	s.probe = func() embeddingRuntimeHealth { return embeddingRuntimeHealth{Ready: true} }
+	s.Start()
+	defer s.Stop()
+
+	st := waitEmbeddingState(t, s, func(st embeddingRuntimeState) bool { return st.State == embeddingRuntimeRunning })
+	if !st.ModelCached || st.PID == 0 || st.Restarts != 0 {
+		t.Fatalf("expected running runtime with cached model, got %+v", st)
+	}
+
+	if err := s.RequestDownload("org/other-model"); err != nil {
+		t.Fatalf("request download: %v", err)
+	}
+	waitEmbeddingState(t, s, func(embeddingRuntimeState) bool {
+		return embeddingModelCached(cache, "org/other-model")
+	})
+}
@@ -6,8 +6,10 @@ import (
 	"crypto/tls"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/exec"
@@ -24,17 +26,22 @@ import (
 	"github.com/KafClaw/KafClaw/internal/agent"
 	"github.com/KafClaw/KafClaw/internal/bus"
 	"github.com/KafClaw/KafClaw/internal/channels"
+	"github.com/KafClaw/KafClaw/internal/cliconfig"
 	"github.com/KafClaw/KafClaw/internal/config"
 	"github.com/KafClaw/KafClaw/internal/group"
 	"github.com/KafClaw/KafClaw/internal/identity"
 	"github.com/KafClaw/KafClaw/internal/knowledge"
+	"github.com/KafClaw/KafClaw/internal/logging"
 	"github.com/KafClaw/KafClaw/internal/memory"
+	"github.com/KafClaw/KafClaw/internal/metrics"
+	"github.com/KafClaw/KafClaw/internal/msgtemplate"
 	"github.com/KafClaw/KafClaw/internal/orchestrator"
 	"github.com/KafClaw/KafClaw/internal/policy"
 	"github.com/KafClaw/KafClaw/internal/provider"
 	"github.com/KafClaw/KafClaw/internal/scheduler"
 	"github.com/KafClaw/KafClaw/internal/timeline"
 	"github.com/KafClaw/KafClaw/internal/tools"
+	"github.com/KafClaw/KafClaw/internal/tracing"
 	"github.com/spf13/cobra"
 )
 
@@ -46,6 +53,97 @@ func newTraceID() string {
 	return fmt.Sprintf("%d", time.Now().UnixNano())
 }
 
+// requestTraceID returns the trace ID of an API request: the W3C trace ID of
+// its traceparent header when present, a fresh one otherwise.
+func requestTraceID(r *http.Request) string {
+	if id := tracing.TraceID(r.Context()); id != "" {
+		return id
+	}
+	return newTraceID()
+}
+
+// requestParentSpanID returns the caller's span ID from the traceparent of an
+// API request, or "".
+func requestParentSpanID(r *http.Request) string {
+	p, _ := tracing.FromContext(r.Context())
+	return p.SpanID
+}
+
+// withTraceparent adds the traceparent of an API request to timeline metadata.
+func withTraceparent(r *http.Request, meta map[string]any) map[string]any {
+	if p, ok := tracing.FromContext(r.Context()); ok && p.SpanID != "" {
+		meta["traceparent"] = p.String()
+	}
+	return meta
+}
+
+// toolListPolicies turns the configured channel tool lists into policy
+// entries keyed by channel and by "channel:account".
+func toolListPolicies(cfg map[string]config.ToolChannelPolicyConfig) map[string]policy.ToolListPolicy {
+	if len(cfg) == 0 {
+		return nil
+	}
+	set := func(names []string) map[string]bool {
+		if len(names) == 0 {
+			return nil
+		}
+		out := make(map[string]bool, len(names))
+		for _, n := range names {
+			if n = strings.TrimSpace(n); n != "" {
+				out[n] = true
+			}
+		}
+		return out
+	}
+	out := make(map[string]policy.ToolListPolicy)
+	for channel, c := range cfg {
+		channel = strings.ToLower(strings.TrimSpace(channel))
+		out[channel] = policy.ToolListPolicy{Allow: set(c.Allow), Deny: set(c.Deny)}
+		for account, a := range c.Accounts {
+			out[channel+":"+strings.TrimSpace(account)] = policy.ToolListPolicy{Allow: set(a.Allow), Deny: set(a.Deny)}
+		}
+	}
+	return out
+}
+
+// startOTelExporter starts the OTLP span exporter when an endpoint is
+// configured, falling back to the standard OTEL_EXPORTER_OTLP_* variables.
+// The returned function flushes pending spans.
+func startOTelExporter(c config.GatewayOTelConfig) func() {
+	endpoint := strings.TrimSpace(c.Endpoint)
+	headers := c.Headers
+	if endpoint == "" {
+		endpoint = strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"))
+		headers = os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")
+	}
+	if endpoint == "" {
+		return func() {}
+	}
+	service := strings.TrimSpace(c.ServiceName)
+	if service == "" {
+		service = strings.TrimSpace(os.Getenv("OTEL_SERVICE_NAME"))
+	}
+	if service == "" {
+		service = "kafclaw-gateway"
+	}
+	hdrs, err := tracing.ParseHeaders(headers)
+	if err != nil {
+		slog.Warn("Invalid OTLP headers, exporting without them", "error", err)
+		hdrs = nil
+	}
+	stop, err := tracing.StartExporter(tracing.ExportOptions{Endpoint: endpoint, ServiceName: service, Headers: hdrs})
+	if err != nil {
+		slog.Warn("OpenTelemetry export disabled", "error", err)
+		return func() {}
+	}
+	slog.Info("Exporting OpenTelemetry spans", "endpoint", endpoint, "service", service)
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = stop(ctx)
+	}
+}
+
 var gatewayCmd = &cobra.Command{
 	Use:   "gateway",
 	Short: "Start the agent gateway (WhatsApp, etc)",
@@ -61,16 +159,21 @@ func runGateway(cmd *cobra.Command, args []string) {
 
 func runGatewayMain(cmd *cobra.Command, args []string) {
 	printHeader("🌐 KafClaw Gateway")
-	fmt.Println("Starting KafClaw Gateway...")
 
 	// 1. Load Config
 	cfg, err := config.Load()
 	if err != nil {
-		fmt.Printf("Config error: %v\n", err)
+		slog.Error("Config error", "error", err)
 		os.Exit(1)
 	}
+	if err := logging.Setup(logging.Options{Level: cfg.Gateway.Log.Level, Format: cfg.Gateway.Log.Format, Service: "gateway"}); err != nil {
+		slog.Warn("Invalid gateway log settings, using defaults", "error", err)
+	}
+	slog.Info("Starting KafClaw Gateway")
+	stopOTel := startOTelExporter(cfg.Gateway.OTel)
+	defer stopOTel()
 	if err := validateEmbeddingHardGate(cfg); err != nil {
-		fmt.Printf("Memory embedding gate failed: %v\n", err)
+		slog.Error("Memory embedding gate failed", "error", err)
 		os.Exit(1)
 	}
 	// 2. Setup Timeline (QMD)
@@ -78,9 +181,11 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 	timelinePath := fmt.Sprintf("%s/.kafclaw/timeline.db", home)
 	timeSvc, err := timeline.NewTimelineService(timelinePath)
 	if err != nil {
-		fmt.Printf("Failed to init timeline: %v\n", err)
+		slog.Error("Failed to init timeline", "path", timelinePath, "error", err)
 		os.Exit(1)
 	}
+	startup := newStartupReport()
+	startup.set("timeline", componentOK, "", true)
 
 	// Seed default settings if missing
 	seedSetting := func(key, value string) {
@@ -97,7 +202,10 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 	seedSetting("default_repo_search_path", home)
 	seedSetting("kafscale_lfs_proxy_url", "http://localhost:8080")
 	if err := reconcileDurableRuntimeState(timeSvc); err != nil {
-		fmt.Printf("⚠️ Runtime reconciliation failed: %v\n", err)
+		slog.Warn("Runtime reconciliation failed", "error", err)
+		startup.set("runtime_state", componentDegraded, err.Error(), false)
+	} else {
+		startup.set("runtime_state", componentOK, "", false)
 	}
 
 	// Resolve work repo path (settings override config)
@@ -106,9 +214,13 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 		workRepoPath = strings.TrimSpace(v)
 	}
 	if warn, err := config.EnsureWorkRepo(workRepoPath); err != nil {
-		fmt.Printf("Work repo error: %v\n", err)
+		slog.Warn("Work repo error", "path", workRepoPath, "error", err)
+		startup.set("work_repo", componentDegraded, err.Error(), false)
 	} else if warn != "" {
-		fmt.Printf("Work repo warning: %s\n", warn)
+		slog.Warn("Work repo warning", "path", workRepoPath, "warning", warn)
+		startup.set("work_repo", componentDegraded, warn, false)
+	} else {
+		startup.set("work_repo", componentOK, "", false)
 	}
 	var workRepoMu sync.RWMutex
 	getWorkRepo := func() string {
@@ -132,13 +244,31 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 
 	// 3. Setup Bus
 	msgBus := bus.NewMessageBus()
+	if cfg.Channels.Dedupe.Enabled && cfg.Channels.Dedupe.WindowSec > 0 {
+		// Channel retries and redeliveries are dropped before they reach the loop.
+		msgBus.EnableDedupe(time.Duration(cfg.Channels.Dedupe.WindowSec)*time.Second, func(msg *bus.InboundMessage, key string) {
+			meta, _ := json.Marshal(map[string]any{"channel": msg.Channel, "chat_id": msg.ChatID, "dedupe_key": key})
+			_ = timeSvc.AddEvent(&timeline.TimelineEvent{
+				EventID:        fmt.Sprintf("INBOUND_DUP_%d", time.Now().UnixNano()),
+				TraceID:        msg.TraceID,
+				Timestamp:      time.Now(),
+				SenderID:       msg.SenderID,
+				EventType:      "SYSTEM",
+				ContentText:    "duplicate inbound message dropped",
+				Classification: "INBOUND_DUPLICATE",
+				Authorized:     true,
+				Metadata:       string(meta),
+			})
+		})
+	}
 
 	// 4. Setup Providers
 	prov, provErr := provider.Resolve(cfg, "main")
 	if provErr != nil {
-		fmt.Printf("Provider error: %v\n", provErr)
+		slog.Error("Provider error", "error", provErr)
 		os.Exit(1)
 	}
+	startup.set("provider", componentOK, "", true)
 
 	if cfg.Providers.LocalWhisper.Enabled {
 		if oaProv, ok := prov.(*provider.OpenAIProvider); ok {
@@ -153,15 +283,68 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 	policyEngine.MaxAutoTier = 2
 	// External users (non-owner) are restricted to read-only tools (tier 0).
 	policyEngine.ExternalMaxTier = 0
+	// Optional plan-first rule: the agent posts its plan and waits for a go-ahead.
+	policyEngine.PlanFirstMinTier = cfg.Tools.PlanFirst.MinTier
+	if len(cfg.Tools.PlanFirst.Channels) > 0 {
+		policyEngine.PlanFirstChannels = make(map[string]bool, len(cfg.Tools.PlanFirst.Channels))
+		for _, ch := range cfg.Tools.PlanFirst.Channels {
+			policyEngine.PlanFirstChannels[strings.TrimSpace(ch)] = true
+		}
+	}
+	// Per-host limits of the ssh tool; unregistered hosts are denied.
+	if len(cfg.Tools.SSH.Hosts) > 0 {
+		policyEngine.RemoteHosts = make(map[string]policy.RemoteHostPolicy, len(cfg.Tools.SSH.Hosts))
+		for _, h := range cfg.Tools.SSH.Hosts {
+			deny, err := tools.CompileSSHDenyPatterns(h.DenyPatterns)
+			if err != nil {
+				slog.Warn("SSH host disabled", "host", h.Alias, "error", err)
+				continue
+			}
+			policyEngine.RemoteHosts[strings.TrimSpace(h.Alias)] = policy.RemoteHostPolicy{MaxTier: h.MaxTier, Deny: deny}
+		}
+	}
+
+	// Per-channel and per-account tool allow and deny lists.
+	policyEngine.ToolLists = toolListPolicies(cfg.Tools.ChannelPolicies)
 
 	// 4c. Setup Memory System (uses dedicated embedding resolver, independent from chat provider)
+	// With memory.embedding.command set, the gateway runs the local runtime itself.
+	embeddingSup := newEmbeddingSupervisor(cfg)
 	var memorySvc *memory.MemoryService
+	memoryIndexes := memory.NewIndexRouter(timeSvc.DB())
 	if embedder, source := resolveMemoryEmbedder(cfg, prov); embedder != nil {
-		vecStore := memory.NewSQLiteVecStore(timeSvc.DB(), 1536)
-		memorySvc = memory.NewMemoryService(vecStore, embedder)
-		fmt.Println("🧠 Memory system initialized:", source)
+		// One vector index per embedding model; searches only hit the index of
+		// the configured model.
+		index := memoryIndexName(cfg)
+		if _, err := memoryIndexes.Open(context.Background(), index, cfg.Memory.Embedding.Dimension); err != nil {
+			slog.Warn("Memory system disabled", "error", err)
+			startup.set("memory", componentFailed, err.Error(), true)
+		} else {
+			memorySvc = memory.NewMemoryService(memoryIndexes, embedder)
+			slog.Info("Memory system initialized", "source", source, "index", index)
+			if embeddingSup != nil {
+				embeddingSup.onChange = func(st embeddingRuntimeState) {
+					if st.State == embeddingRuntimeRunning {
+						startup.set("memory", componentOK, source, true)
+						return
+					}
+					startup.set("memory", componentDegraded, "embedding runtime "+st.State+": "+st.Detail, true)
+				}
+				startup.set("memory", componentDegraded, "embedding runtime starting (supervised)", true)
+			} else if health := probeEmbeddingRuntime(cfg); !health.Ready {
+				startup.set("memory", componentDegraded, "embedding runtime: "+health.Detail, true)
+			} else {
+				startup.set("memory", componentOK, source, true)
+			}
+		}
 	} else {
-		fmt.Println("ℹ️  Memory system disabled (no embedding provider available)")
+		slog.Info("Memory system disabled: no embedding provider available", "source", source)
+		status, critical := memoryUnavailableStatus(cfg)
+		startup.set("memory", status, source, critical)
+	}
+	if embeddingSup != nil {
+		slog.Info("Embedding runtime supervised", "command", strings.Join(embeddingSup.command, " "))
+		embeddingSup.Start()
 	}
 
 	// 4d. Setup Group Collaboration (conditional)
@@ -198,17 +381,25 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 		if cfg.Channels.MSTeams.Enabled {
 			identity.Channels = append(identity.Channels, "msteams")
 		}
+		identity.Taxonomy = append(identity.Taxonomy, skillCapabilities(cfg)...)
 		mgr := group.NewManager(grpCfg, timeSvc, identity)
 		// Bridge group memory items into local vector store for RAG
 		if memorySvc != nil {
 			mgr.SetMemoryIndexer(memorySvc)
 		}
+		// Overdue task notices go to the configured owner chat
+		if ch, chat := strings.TrimSpace(grpCfg.Escalation.Channel), strings.TrimSpace(grpCfg.Escalation.ChatID); ch != "" && chat != "" {
+			mgr.SetEscalationNotifier(func(text string) {
+				msgBus.PublishOutbound(&bus.OutboundMessage{Channel: ch, ChatID: chat, Content: text})
+			})
+		}
 		return mgr
 	}
 
 	// Helper: start Kafka consumer + router, returns cancel func
 	startGrpKafka := func(grpCfg config.GroupConfig, mgr *group.Manager, parentCtx context.Context, orchHandler group.OrchestratorHandler) context.CancelFunc {
 		if grpCfg.KafkaBrokers == "" {
+			startup.set("kafka", componentDisabled, "no brokers configured", false)
 			return func() {}
 		}
 		kafkaCtx, kafkaCancel := context.WithCancel(parentCtx)
@@ -226,8 +417,8 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 		}
 		dialer, err := group.BuildKafkaDialerFromGroupConfig(grpCfg)
 		if err != nil {
-			fmt.Println("⚠️ Kafka consumer config error: invalid or incomplete Kafka security settings.")
-			fmt.Println("⚠️ Group router not started until Kafka security settings are fixed.")
+			slog.Warn("Kafka consumer config error: invalid or incomplete Kafka security settings; group router not started", "error", err)
+			startup.set("kafka", componentFailed, "invalid Kafka security settings: "+err.Error(), true)
 			kafkaCancel()
 			return func() {}
 		}
@@ -244,21 +435,25 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 		}
 		if cfg.Knowledge.Enabled && len(knowledgeTopics) > 0 {
 			router.SetKnowledgeHandler(group.NewKnowledgeHandler(timeSvc, cfg.Node.ClawID, cfg.Knowledge.GovernanceEnabled), knowledgeTopics)
-			fmt.Printf("🧠 Knowledge router enabled (%d topic(s))\n", len(knowledgeTopics))
+			slog.Info("Knowledge router enabled", "topics", len(knowledgeTopics))
 		}
 		go func() {
 			if err := router.Run(kafkaCtx); err != nil {
-				fmt.Printf("⚠️ Group router stopped: %v\n", err)
+				slog.Warn("Group router stopped", "error", err)
+				if kafkaCtx.Err() == nil {
+					startup.set("kafka", componentDegraded, "group router stopped: "+err.Error(), true)
+				}
 			}
 		}()
-		fmt.Println("📡 Kafka consumer started for group topics")
+		slog.Info("Kafka consumer started for group topics", "brokers", grpCfg.KafkaBrokers)
+		startup.set("kafka", componentOK, grpCfg.KafkaBrokers, true)
 		return kafkaCancel
 	}
 
 	if cfg.Group.Enabled && cfg.Group.GroupName != "" {
 		mgr := buildGrpManager(cfg.Group)
 		grpState.SetManager(mgr, nil)
-		fmt.Println("🤝 Group collaboration enabled:", cfg.Group.GroupName)
+		slog.Info("Group collaboration enabled", "group", cfg.Group.GroupName)
 	} else if cfg.Orchestrator.Enabled || cfg.Gateway.Host == "0.0.0.0" {
 		// Non-standalone: allow auto-rejoin from DB
 		if active, err := timeSvc.GetSetting("group_active"); err == nil && active == "true" {
@@ -267,11 +462,11 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				cfg.Group.Enabled = true
 				mgr := buildGrpManager(cfg.Group)
 				grpState.SetManager(mgr, nil)
-				fmt.Println("🤝 Group collaboration restored from settings:", cfg.Group.GroupName)
+				slog.Info("Group collaboration restored from settings", "group", cfg.Group.GroupName)
 			}
 		}
 	} else {
-		fmt.Println("🖥️  Standalone Desktop mode: skipping group auto-rejoin")
+		slog.Info("Standalone Desktop mode: skipping group auto-rejoin")
 	}
 
 	// --- Shared mode variable (used by all handlers) ---
@@ -313,17 +508,13 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			Metadata:       `{"mode":"standalone","event":"ENTER_STANDALONE"}`,
 		})
 		_ = timeSvc.SetSetting("current_mode", "standalone")
-		fmt.Println("🖥️  Standalone Desktop mode active — group features disabled")
+		slog.Info("Standalone Desktop mode active, group features disabled")
 	}
 
 	// Helper: block mutating group endpoints in standalone mode
-	isStandaloneBlocked := func(w http.ResponseWriter) bool {
+	isStandaloneBlocked := func(w http.ResponseWriter, r *http.Request) bool {
 		if getMode() == "standalone" {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusForbidden)
-			json.NewEncoder(w).Encode(map[string]string{
-				"error": "group operations are disabled in standalone mode",
-			})
+			writeAPIError(w, r, http.StatusForbidden, codeForbidden, "group operations are disabled in standalone mode")
 			return true
 		}
 		return false
@@ -332,14 +523,14 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 	// Build group publisher for the loop (nil-safe)
 	var groupPublisher agent.GroupTracePublisher
 	if grpState.Manager() != nil {
-		groupPublisher = &groupTraceAdapter{mgr: grpState.Manager()}
+		groupPublisher = &groupTraceAdapter{mgr: grpState.Manager(), sampler: group.NewTraceSampler(cfg.Group.TraceSampling)}
 	}
 
 	// 4e. Setup Orchestrator (conditional)
 	var orch *orchestrator.Orchestrator
 	if cfg.Orchestrator.Enabled && grpState.Manager() != nil {
 		orch = orchestrator.New(cfg.Orchestrator, grpState.Manager(), timeSvc)
-		fmt.Println("🎯 Orchestrator enabled:", cfg.Orchestrator.Role)
+		slog.Info("Orchestrator enabled", "role", cfg.Orchestrator.Role)
 	}
 
 	gatewayStartTime := time.Now()
@@ -351,17 +542,25 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			MinLength:     100,
 			BatchSize:     5,
 			FlushInterval: 30 * time.Second,
+			Dedup: memory.DedupConfig{
+				Threshold: cfg.Memory.Dedup.Threshold,
+				Action:    strings.ToLower(strings.TrimSpace(cfg.Memory.Dedup.Action)),
+				Window:    time.Duration(cfg.Memory.Dedup.WindowHours) * time.Hour,
+			},
 		})
-		fmt.Println("📝 Auto-indexer initialized")
+		slog.Info("Auto-indexer initialized")
 	}
 
 	// 5a. Setup Expertise Tracker
 	expertiseTracker := memory.NewExpertiseTracker(timeSvc.DB())
-	fmt.Println("🎯 Expertise tracker initialized")
+	slog.Info("Expertise tracker initialized")
 
 	// 5a-ii. Setup Working Memory Store
-	workingMemoryStore := memory.NewWorkingMemoryStore(timeSvc.DB())
-	fmt.Println("📋 Working memory store initialized")
+	workingMemoryStore := memory.NewWorkingMemoryStoreWithConfig(timeSvc.DB(), memory.WorkingMemoryConfig{
+		DefaultTTL:       time.Duration(cfg.Memory.Working.TTLHours) * time.Hour,
+		MaxBytesPerScope: cfg.Memory.Working.MaxBytesPerScope,
+	})
+	slog.Info("Working memory store initialized")
 
 	// 5a-iii. Setup Observer (observational memory)
 	var observer *memory.Observer
@@ -373,7 +572,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			MaxObservations:  cfg.Observer.MaxObservations,
 		}, prov, timeSvc.DB())
 		if observer != nil {
-			fmt.Println("👁️  Observer initialized")
+			slog.Info("Observer initialized")
 		}
 	}
 
@@ -387,7 +586,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			SyncInterval: cfg.ER1.SyncInterval,
 		}, memorySvc)
 		if er1Client != nil {
-			fmt.Println("🔗 ER1 client initialized")
+			slog.Info("ER1 client initialized")
 		}
 	}
 
@@ -403,9 +602,10 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 		if !hasSoulFiles {
 			result, err := identity.ScaffoldWorkspace(cfg.Paths.Workspace, false)
 			if err != nil {
-				fmt.Printf("⚠️ Workspace scaffold error: %v\n", err)
+				slog.Warn("Workspace scaffold error", "error", err)
+				startup.set("workspace", componentDegraded, "scaffold: "+err.Error(), false)
 			} else if len(result.Created) > 0 {
-				fmt.Printf("📂 Auto-scaffolded workspace: created %v\n", result.Created)
+				slog.Info("Auto-scaffolded workspace", "created", result.Created)
 			}
 		}
 	}
@@ -439,6 +639,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 		SubagentMemoryShareMode: cfg.Tools.Subagents.MemoryShareMode,
 		SubagentToolsAllow:      cfg.Tools.Subagents.Tools.Allow,
 		SubagentToolsDeny:       cfg.Tools.Subagents.Tools.Deny,
+		SubagentProgressSec:     cfg.Tools.Subagents.ProgressIntervalSec,
 		Config:                  cfg,
 	})
 
@@ -447,16 +648,30 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 		go func() {
 			indexer := memory.NewSoulFileIndexer(memorySvc, cfg.Paths.Workspace)
 			if err := indexer.IndexAll(context.Background()); err != nil {
-				fmt.Printf("⚠️ Soul file indexing error: %v\n", err)
+				slog.Warn("Soul file indexing error", "error", err)
 			}
 		}()
 	}
 
 	// 6. Setup Channels
+	if cfg.Paths.Workspace != "" {
+		channels.SetMediaDir(filepath.Join(cfg.Paths.Workspace, "media"))
+	}
 	// WhatsApp
 	wa := channels.NewWhatsAppChannel(cfg.Channels.WhatsApp, msgBus, prov, timeSvc)
 	slack := channels.NewSlackChannel(cfg.Channels.Slack, msgBus, timeSvc)
 	msteams := channels.NewMSTeamsChannel(cfg.Channels.MSTeams, msgBus, timeSvc)
+	slack.SetTranscriber(prov)
+	msteams.SetTranscriber(prov)
+	discord := channels.NewDiscordChannel(cfg.Channels.Discord, msgBus, timeSvc)
+	telegram := channels.NewTelegramChannel(cfg.Channels.Telegram, msgBus, timeSvc)
+	// Replies naming a message template are rendered per channel.
+	templates := msgtemplate.NewRegistry(cfg.Paths.Workspace)
+	wa.Templates = templates
+	slack.Templates = templates
+	msteams.Templates = templates
+	discord.Templates = templates
+	telegram.Templates = templates
 
 	// 7. Start Everything
 	ctx, cancel := context.WithCancel(context.Background())
@@ -482,6 +697,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 	go func() {
 		// Run once at startup
 		lifecycleMgr.RunDaily()
+		_, _ = workingMemoryStore.PurgeExpired()
 		// Then daily
 		ticker := time.NewTicker(24 * time.Hour)
 		defer ticker.Stop()
@@ -491,32 +707,47 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				return
 			case <-ticker.C:
 				lifecycleMgr.RunDaily()
+				_, _ = workingMemoryStore.PurgeExpired()
 			}
 		}
 	}()
 
-	// Start Channels
-	if err := wa.Start(ctx); err != nil {
-		fmt.Printf("Failed to start WhatsApp: %v\n", err)
-	}
-	if err := slack.Start(ctx); err != nil {
-		fmt.Printf("Failed to start Slack: %v\n", err)
+	// Start the release check (opt-in)
+	var updates *updateChecker
+	if cfg.Gateway.UpdateCheck.Enabled {
+		updates = newUpdateChecker(cfg.Gateway.UpdateCheck, version)
+		go updates.run(ctx)
 	}
-	if err := msteams.Start(ctx); err != nil {
-		fmt.Printf("Failed to start MSTeams: %v\n", err)
+
+	// Start Channels
+	startChannel := func(name string, enabled bool, start func(context.Context) error) {
+		if !enabled {
+			startup.set(name, componentDisabled, "", false)
+			return
+		}
+		err := start(ctx)
+		if err != nil {
+			slog.Error("Failed to start channel", "channel", name, "error", err)
+		}
+		startup.setErr(name, err, true)
 	}
+	startChannel("whatsapp", cfg.Channels.WhatsApp.Enabled, wa.Start)
+	startChannel("slack", cfg.Channels.Slack.Enabled, slack.Start)
+	startChannel("msteams", cfg.Channels.MSTeams.Enabled, msteams.Start)
+	startChannel("discord", cfg.Channels.Discord.Enabled, discord.Start)
+	startChannel("telegram", cfg.Channels.Telegram.Enabled, telegram.Start)
 
 	// Route web UI outbound to WhatsApp and timeline
 	msgBus.Subscribe("webui", func(msg *bus.OutboundMessage) {
 		go func() {
 			webUserID, err := strconv.ParseInt(msg.ChatID, 10, 64)
 			if err != nil {
-				fmt.Printf("⚠️ webui outbound invalid web_user_id: %s\n", msg.ChatID)
+				slog.Warn("webui outbound invalid web_user_id", "chat_id", msg.ChatID, "trace_id", msg.TraceID)
 				return
 			}
 			jid, ok, err := timeSvc.GetWebLink(webUserID)
 			if err != nil {
-				fmt.Printf("⚠️ webui outbound link lookup error: %v\n", err)
+				slog.Warn("webui outbound link lookup error", "web_user_id", webUserID, "error", err)
 			}
 			status := "no_link"
 			jid = strings.TrimSpace(jid)
@@ -524,7 +755,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				jid = normalizeWhatsAppJID(jid)
 				status = "queued"
 			} else {
-				fmt.Printf("⚠️ webui outbound no WhatsApp link for web_user_id=%d\n", webUserID)
+				slog.Warn("webui outbound has no WhatsApp link", "web_user_id", webUserID)
 			}
 
 			// Check silent mode and optional override
@@ -533,7 +764,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				forceSend = user.ForceSend
 			}
 			if status != "no_link" && timeSvc.IsSilentMode() && !forceSend {
-				fmt.Printf("🔇 webui outbound suppressed (silent mode) to %s web_user_id=%d\n", jid, webUserID)
+				slog.Info("webui outbound suppressed (silent mode)", "to", jid, "web_user_id", webUserID)
 				status = "suppressed"
 			} else if status != "no_link" {
 				// Send via WhatsApp channel; bypass silent when forceSend is enabled
@@ -545,7 +776,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 						ChatID:  jid,
 						Content: msg.Content,
 					}); err != nil {
-						fmt.Printf("⚠️ webui outbound direct send error: %v\n", err)
+						slog.Warn("webui outbound direct send error", "to", jid, "error", err)
 						status = "error"
 					} else {
 						status = "sent"
@@ -571,7 +802,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				Classification: fmt.Sprintf("WEBUI_OUTBOUND->%s force=%v status=%s", jid, forceSend, status),
 				Authorized:     true,
 			})
-			fmt.Printf("📤 WebUI outbound status=%s to=%s\n", status, jid)
+			slog.Info("WebUI outbound", "status", status, "to", jid, "trace_id", msg.TraceID)
 		}()
 	})
 
@@ -579,6 +810,20 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 	deliveryWorker := agent.NewDeliveryWorker(timeSvc, msgBus)
 	go deliveryWorker.Run(ctx)
 
+	// Start Commitment Worker (conditional)
+	if cfg.Commitments.Enabled {
+		commitmentWorker := agent.NewCommitmentWorker(timeSvc, msgBus, time.Duration(cfg.Commitments.FollowUpIntervalSec)*time.Second)
+		go commitmentWorker.Run(ctx)
+		slog.Info("Commitment tracking enabled")
+	}
+
+	// Start Cost Anomaly Worker (conditional)
+	if cfg.FinOps.Anomaly.Enabled {
+		anomalyWorker := agent.NewCostAnomalyWorker(timeSvc, msgBus, cfg.FinOps.Anomaly)
+		go anomalyWorker.Run(ctx)
+		slog.Info("Cost anomaly detection enabled")
+	}
+
 	// Start Scheduler (conditional)
 	if cfg.Scheduler.Enabled {
 		schedCfg := scheduler.Config{
@@ -589,8 +834,16 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			MaxConcDefault: cfg.Scheduler.MaxConcDefault,
 		}
 		sched := scheduler.New(schedCfg, msgBus, timeSvc)
+		for _, cc := range cfg.Scheduler.Calendars {
+			src, err := scheduler.NewCalendarSource(cc, calendarToken(cc))
+			if err != nil {
+				slog.Warn("Calendar source disabled", "error", err)
+				continue
+			}
+			sched.AddCalendarSource(src)
+		}
 		go sched.Run(ctx)
-		fmt.Println("Scheduler started")
+		slog.Info("Scheduler started")
 	}
 
 	// Start Bus Dispatcher
@@ -601,95 +854,30 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 	go func() {
 		mux := http.NewServeMux()
 		mux.HandleFunc("/chat", func(w http.ResponseWriter, r *http.Request) {
-			if cfg.Gateway.AuthToken != "" {
-				token := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
-				if token != cfg.Gateway.AuthToken {
-					http.Error(w, "unauthorized", http.StatusUnauthorized)
-					return
-				}
-			}
-			if r.Method != http.MethodPost {
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-				return
-			}
-			msg := r.URL.Query().Get("message")
-			if msg == "" {
-				http.Error(w, "Missing message parameter", http.StatusBadRequest)
+			msg, session, ok := localChatRequest(w, r, cfg.Gateway.AuthToken)
+			if !ok {
 				return
 			}
-
-			session := r.URL.Query().Get("session")
-			if session == "" {
-				session = "local:default"
-			}
-
-			fmt.Printf("🌐 Local Network Request: %s\n", msg)
-			traceID := newTraceID()
-			inMeta, _ := json.Marshal(map[string]any{
-				"channel":      "local",
-				"sender":       session,
-				"message_type": "TEXT",
-				"content":      msg,
-			})
-			_ = timeSvc.AddEvent(&timeline.TimelineEvent{
-				EventID:        fmt.Sprintf("LOCAL_IN_%d", time.Now().UnixNano()),
-				TraceID:        traceID,
-				Timestamp:      time.Now(),
-				SenderID:       session,
-				SenderName:     "Local",
-				EventType:      "TEXT",
-				ContentText:    msg,
-				Classification: "LOCAL_INBOUND",
-				Authorized:     true,
-				Metadata:       string(inMeta),
-			})
+			traceID := requestTraceID(r)
+			logLocalChatInbound(timeSvc, r, msg, session, traceID)
 			resp, err := loop.ProcessDirectWithTrace(ctx, msg, session, traceID)
+			logLocalChatOutbound(timeSvc, traceID, session, resp, err)
 			if err != nil {
-				outErrMeta, _ := json.Marshal(map[string]any{
-					"response_text":   err.Error(),
-					"delivery_status": "error",
-				})
-				_ = timeSvc.AddEvent(&timeline.TimelineEvent{
-					EventID:        fmt.Sprintf("LOCAL_OUT_%d", time.Now().UnixNano()),
-					TraceID:        traceID,
-					Timestamp:      time.Now(),
-					SenderID:       "AGENT",
-					SenderName:     "Agent",
-					EventType:      "SYSTEM",
-					ContentText:    err.Error(),
-					Classification: "LOCAL_OUTBOUND status=error",
-					Authorized:     true,
-					Metadata:       string(outErrMeta),
-				})
-				fmt.Printf("📤 Local outbound status=error session=%s\n", session)
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
-			outMeta, _ := json.Marshal(map[string]any{
-				"response_text":   resp,
-				"delivery_status": "sent",
-			})
-			_ = timeSvc.AddEvent(&timeline.TimelineEvent{
-				EventID:        fmt.Sprintf("LOCAL_OUT_%d", time.Now().UnixNano()),
-				TraceID:        traceID,
-				Timestamp:      time.Now(),
-				SenderID:       "AGENT",
-				SenderName:     "Agent",
-				EventType:      "SYSTEM",
-				ContentText:    resp,
-				Classification: "LOCAL_OUTBOUND status=sent",
-				Authorized:     true,
-				Metadata:       string(outMeta),
-			})
-			fmt.Printf("📤 Local outbound status=sent session=%s\n", session)
 			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 			fmt.Fprint(w, resp)
 		})
+		mux.HandleFunc("/chat/stream", localChatStreamHandler(ctx, cfg.Gateway.AuthToken, timeSvc, loop.ProcessDirectWithTrace))
+		mux.HandleFunc("/v1/chat/completions", openAIChatCompletionsHandler(ctx, cfg, cfg.Gateway.AuthToken, timeSvc, loop.ProcessConversation))
+		mux.HandleFunc("/v1/models", openAIModelsHandler(cfg, cfg.Gateway.AuthToken))
 
 		addr := fmt.Sprintf("%s:%d", cfg.Gateway.Host, cfg.Gateway.Port)
-		fmt.Printf("📡 API Server listening on http://%s\n", addr)
-		if err := http.ListenAndServe(addr, mux); err != nil {
-			fmt.Printf("API Server Error: %v\n", err)
+		slog.Info("API server listening", "url", "http://"+addr)
+		if err := http.ListenAndServe(addr, traceContext(mux)); err != nil {
+			slog.Error("API server failed", "error", err)
+			startup.set("api_server", componentFailed, err.Error(), false)
 		}
 	}()
 
@@ -715,6 +903,11 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				orchEnabled = true
 			}
 
+			update := map[string]any{"enabled": false, "available": false}
+			if updates != nil {
+				update = updates.status()
+			}
+
 			json.NewEncoder(w).Encode(map[string]any{
 				"version":              version,
 				"mode":                 mode,
@@ -722,6 +915,30 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				"uptime_seconds":       int(time.Since(gatewayStartTime).Seconds()),
 				"group_enabled":        cfg.Group.Enabled,
 				"orchestrator_enabled": orchEnabled,
+				"update":               update,
+			})
+		})
+
+		// API: Component status (startup report, updated at runtime)
+		mux.HandleFunc("/api/v1/status/components", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == "OPTIONS" {
+				return
+			}
+			if r.Method != http.MethodGet {
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed")
+				return
+			}
+			breakers := provider.BreakerStates()
+			components := append(startup.snapshot(), providerBreakerComponents(breakers)...)
+			json.NewEncoder(w).Encode(map[string]any{
+				"status":            overallStatus(components),
+				"strict_startup":    cfg.Gateway.StrictStartup,
+				"components":        components,
+				"provider_breakers": breakers,
 			})
 		})
 
@@ -751,6 +968,25 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			ChannelID      string `json:"channel_id"`
 			HistoryLimit   int    `json:"history_limit"`
 			DMHistoryLimit int    `json:"dm_history_limit"`
+			// WorkspaceID and EnterpriseID identify the Slack workspace and
+			// Enterprise Grid org of the sender.
+			WorkspaceID  string `json:"workspace_id"`
+			EnterpriseID string `json:"enterprise_id"`
+			// EventType and Event carry non-message activity (Slack reactions,
+			// file shares, channel membership).
+			EventType string         `json:"event_type"`
+			Event     map[string]any `json:"event"`
+			// MediaURLs are attachments, as data: URLs when the bridge
+			// downloaded them, with their MIME types and file names.
+			MediaURLs  []string `json:"media_urls"`
+			MediaTypes []string `json:"media_types"`
+			MediaNames []string `json:"media_names"`
+			// CallbackURL, if set, receives the task outcome once the agent
+			// is done (see deliverInboundCallback).
+			CallbackURL string `json:"callback_url"`
+			// TraceID is the trace the bridge started for the message; it
+			// stands in for a traceparent header a proxy dropped.
+			TraceID string `json:"trace_id"`
 		}
 
 		verifyChannelToken := func(r *http.Request, expected string) bool {
@@ -797,6 +1033,23 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			return cfg.Channels.MSTeams.InboundToken
 		}
 
+		resolveDiscordInboundToken := func(string) string {
+			return cfg.Channels.Discord.InboundToken
+		}
+
+		resolveTelegramInboundToken := func(string) string {
+			return cfg.Channels.Telegram.InboundToken
+		}
+
+		// Inbound bridge endpoints answer 202 with the task as soon as the
+		// message is queued; the reply goes out through the outbound path.
+		acceptCallback := func(body channelInboundRequest, bt *channels.BridgeTask, token string) {
+			if bt == nil || bt.TaskID == "" || bt.Duplicate || strings.TrimSpace(body.CallbackURL) == "" {
+				return
+			}
+			go deliverInboundCallback(ctx, timeSvc, bt, strings.TrimSpace(body.CallbackURL), token)
+		}
+
 		// API: Slack inbound bridge (POST)
 		mux.HandleFunc("/api/v1/channels/slack/inbound", func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -807,23 +1060,49 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				return
 			}
 			if r.Method != "POST" {
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
 				return
 			}
 			var body channelInboundRequest
 			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-				http.Error(w, "invalid body", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
 				return
 			}
 			if !verifyChannelToken(r, resolveSlackInboundToken(body.AccountID)) {
-				http.Error(w, "invalid channel token", http.StatusUnauthorized)
+				writeAPIError(w, r, http.StatusUnauthorized, codeUnauthorized, "invalid channel token")
 				return
 			}
 			if strings.TrimSpace(body.SenderID) == "" || strings.TrimSpace(body.ChatID) == "" {
-				http.Error(w, "sender_id and chat_id required", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "sender_id and chat_id required")
+				return
+			}
+			if strings.TrimSpace(body.EventType) != "" {
+				if err := slack.HandleEventWithAccount(
+					body.AccountID,
+					body.SenderID,
+					body.ChatID,
+					body.ThreadID,
+					body.MessageID,
+					body.Text,
+					body.IsGroup,
+					body.WasMentioned,
+					strings.TrimSpace(body.EventType),
+					body.Event,
+					channels.SlackWorkspace{ID: body.WorkspaceID, EnterpriseID: body.EnterpriseID},
+					channels.BridgeMediaFromRequest(body.MediaURLs, body.MediaTypes, body.MediaNames),
+					channels.BridgeTraceparent(r.Header.Get(tracing.Header), body.TraceID),
+				); err != nil {
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]any{"ok": true})
+				return
+			}
+			if body.CallbackURL != "" && !validCallbackURL(body.CallbackURL) {
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, "callback_url must be an http(s) URL")
 				return
 			}
-			if err := slack.HandleInboundWithAccountAndHints(
+			bt, err := slack.AcceptInbound(
 				body.AccountID,
 				body.SenderID,
 				body.ChatID,
@@ -834,11 +1113,16 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				body.WasMentioned,
 				body.HistoryLimit,
 				body.DMHistoryLimit,
-			); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				channels.SlackWorkspace{ID: body.WorkspaceID, EnterpriseID: body.EnterpriseID},
+				channels.BridgeMediaFromRequest(body.MediaURLs, body.MediaTypes, body.MediaNames),
+				channels.BridgeTraceparent(r.Header.Get(tracing.Header), body.TraceID),
+			)
+			if err != nil {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
-			json.NewEncoder(w).Encode(map[string]any{"ok": true})
+			acceptCallback(body, bt, resolveSlackInboundToken(body.AccountID))
+			writeInboundAccepted(w, bt)
 		})
 
 		// API: MSTeams inbound bridge (POST)
@@ -851,23 +1135,27 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				return
 			}
 			if r.Method != "POST" {
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
 				return
 			}
 			var body channelInboundRequest
 			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-				http.Error(w, "invalid body", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
 				return
 			}
 			if !verifyChannelToken(r, resolveMSTeamsInboundToken(body.AccountID)) {
-				http.Error(w, "invalid channel token", http.StatusUnauthorized)
+				writeAPIError(w, r, http.StatusUnauthorized, codeUnauthorized, "invalid channel token")
 				return
 			}
 			if strings.TrimSpace(body.SenderID) == "" || strings.TrimSpace(body.ChatID) == "" {
-				http.Error(w, "sender_id and chat_id required", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "sender_id and chat_id required")
+				return
+			}
+			if body.CallbackURL != "" && !validCallbackURL(body.CallbackURL) {
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, "callback_url must be an http(s) URL")
 				return
 			}
-			if err := msteams.HandleInboundWithContextAndHints(
+			bt, err := msteams.AcceptInbound(
 				body.AccountID,
 				body.SenderID,
 				body.ChatID,
@@ -880,122 +1168,698 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				body.ChannelID,
 				body.HistoryLimit,
 				body.DMHistoryLimit,
-			); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				channels.BridgeMediaFromRequest(body.MediaURLs, body.MediaTypes, body.MediaNames),
+				channels.BridgeTraceparent(r.Header.Get(tracing.Header), body.TraceID),
+			)
+			if err != nil {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
-			json.NewEncoder(w).Encode(map[string]any{"ok": true})
+			acceptCallback(body, bt, resolveMSTeamsInboundToken(body.AccountID))
+			writeInboundAccepted(w, bt)
 		})
 
-		// Orchestrator API endpoints
-		mux.HandleFunc("/api/v1/orchestrator/status", func(w http.ResponseWriter, r *http.Request) {
+		// API: Discord inbound bridge (POST)
+		mux.HandleFunc("/api/v1/channels/discord/inbound", func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Channel-Token")
 			w.Header().Set("Content-Type", "application/json")
-			if orch == nil {
-				json.NewEncoder(w).Encode(map[string]any{"enabled": false})
+			if r.Method == "OPTIONS" {
 				return
 			}
-			json.NewEncoder(w).Encode(orch.Status())
-		})
-
-		mux.HandleFunc("/api/v1/orchestrator/hierarchy", func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Content-Type", "application/json")
-			if orch == nil {
-				json.NewEncoder(w).Encode([]any{})
+			if r.Method != "POST" {
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
 				return
 			}
-			json.NewEncoder(w).Encode(orch.GetHierarchy())
-		})
-
-		mux.HandleFunc("/api/v1/orchestrator/zones", func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Content-Type", "application/json")
-			if orch == nil {
-				json.NewEncoder(w).Encode([]any{})
+			var body channelInboundRequest
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
 				return
 			}
-			json.NewEncoder(w).Encode(orch.GetZones())
-		})
-
-		mux.HandleFunc("/api/v1/orchestrator/agents", func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Content-Type", "application/json")
-			if orch == nil {
-				json.NewEncoder(w).Encode([]any{})
+			if !verifyChannelToken(r, resolveDiscordInboundToken(body.AccountID)) {
+				writeAPIError(w, r, http.StatusUnauthorized, codeUnauthorized, "invalid channel token")
 				return
 			}
-			json.NewEncoder(w).Encode(orch.GetAgents())
+			if strings.TrimSpace(body.SenderID) == "" || strings.TrimSpace(body.ChatID) == "" {
+				writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "sender_id and chat_id required")
+				return
+			}
+			if body.CallbackURL != "" && !validCallbackURL(body.CallbackURL) {
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, "callback_url must be an http(s) URL")
+				return
+			}
+			bt, err := discord.AcceptInbound(
+				body.SenderID,
+				body.ChatID,
+				body.ThreadID,
+				body.MessageID,
+				body.Text,
+				body.IsGroup,
+				body.WasMentioned,
+				body.HistoryLimit,
+				body.DMHistoryLimit,
+				channels.BridgeTraceparent(r.Header.Get(tracing.Header), body.TraceID),
+			)
+			if err != nil {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+				return
+			}
+			acceptCallback(body, bt, resolveDiscordInboundToken(body.AccountID))
+			writeInboundAccepted(w, bt)
 		})
 
-		mux.HandleFunc("/api/v1/orchestrator/dispatch", func(w http.ResponseWriter, r *http.Request) {
+		// API: Telegram inbound bridge (POST)
+		mux.HandleFunc("/api/v1/channels/telegram/inbound", func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
 			w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Channel-Token")
 			w.Header().Set("Content-Type", "application/json")
 			if r.Method == "OPTIONS" {
 				return
 			}
 			if r.Method != "POST" {
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
 				return
 			}
-			if orch == nil {
-				http.Error(w, "orchestrator not enabled", http.StatusBadRequest)
+			var body channelInboundRequest
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
 				return
 			}
-			var body struct {
-				Description string `json:"description"`
-				TargetZone  string `json:"target_zone"`
-			}
-			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-				http.Error(w, "invalid body", http.StatusBadRequest)
+			if !verifyChannelToken(r, resolveTelegramInboundToken(body.AccountID)) {
+				writeAPIError(w, r, http.StatusUnauthorized, codeUnauthorized, "invalid channel token")
 				return
 			}
-			taskID := newTraceID()
-			if err := orch.DispatchTask(ctx, taskID, body.Description, body.TargetZone); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+			if strings.TrimSpace(body.SenderID) == "" || strings.TrimSpace(body.ChatID) == "" {
+				writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "sender_id and chat_id required")
 				return
 			}
-			json.NewEncoder(w).Encode(map[string]string{"status": "dispatched", "task_id": taskID})
-		})
-
-		// API: Timeline
-		mux.HandleFunc("/api/v1/timeline", func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Content-Type", "application/json")
-
-			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-			if limit == 0 {
-				limit = 100
+			if body.CallbackURL != "" && !validCallbackURL(body.CallbackURL) {
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, "callback_url must be an http(s) URL")
+				return
 			}
-			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
-			sender := r.URL.Query().Get("sender")
-			traceID := r.URL.Query().Get("trace_id")
-
-			events, err := timeSvc.GetEvents(timeline.FilterArgs{
-				Limit:    limit,
-				Offset:   offset,
-				SenderID: sender,
-				TraceID:  traceID,
-			})
+			bt, err := telegram.AcceptInbound(
+				body.SenderID,
+				body.ChatID,
+				body.ThreadID,
+				body.MessageID,
+				body.Text,
+				body.IsGroup,
+				body.WasMentioned,
+				body.HistoryLimit,
+				body.DMHistoryLimit,
+				channels.BridgeTraceparent(r.Header.Get(tracing.Header), body.TraceID),
+			)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
-
-			json.NewEncoder(w).Encode(events)
+			acceptCallback(body, bt, resolveTelegramInboundToken(body.AccountID))
+			writeInboundAccepted(w, bt)
 		})
 
-		// API: Trace (GET)
-		mux.HandleFunc("/api/v1/trace/", func(w http.ResponseWriter, r *http.Request) {
+		// API: Delivery receipts from the channel bridge (POST). The bridge
+		// reports the platform message IDs of each reply it sent so later
+		// edits and reactions can target them; a receipt with a trace_id
+		// also adds the delivery span to that trace. A receipt with status
+		// "failed" reports a queued reply the bridge dead-lettered and marks
+		// its task's delivery as failed.
+		deliveryReceiptHandler := func(channel string, resolveToken func(string) string) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if r.Method != "POST" {
+					writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+					return
+				}
+				var body struct {
+					timeline.DeliveryReceiptRecord
+					Status string `json:"status"`
+					Error  string `json:"error"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
+					return
+				}
+				rec := body.DeliveryReceiptRecord
+				if !verifyChannelToken(r, resolveToken(rec.AccountID)) {
+					writeAPIError(w, r, http.StatusUnauthorized, codeUnauthorized, "invalid channel token")
+					return
+				}
+				if body.Status == timeline.DeliveryFailed {
+					if strings.TrimSpace(rec.TaskID) == "" {
+						writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "task_id required")
+						return
+					}
+					if err := channels.MarkBridgeDeliveryFailed(timeSvc, channel, rec.TaskID, body.Error); err != nil {
+						writeAPIError(w, r, http.StatusNotFound, codeNotFound, err.Error())
+						return
+					}
+					json.NewEncoder(w).Encode(map[string]any{"ok": true})
+					return
+				}
+				if strings.TrimSpace(rec.ChatID) == "" || (strings.TrimSpace(rec.MessageID) == "" && len(rec.FileIDs) == 0) {
+					writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "chat_id and message_id required")
+					return
+				}
+				rec.Channel = channel
+				if err := timeSvc.LogDeliveryReceipt(&rec); err != nil {
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+					return
+				}
+				channels.LogDeliverySpan(timeSvc, &rec, r.Header.Get(tracing.Header))
+				json.NewEncoder(w).Encode(map[string]any{"ok": true})
+			}
+		}
+		mux.HandleFunc("/api/v1/channels/slack/delivery", deliveryReceiptHandler("slack", resolveSlackInboundToken))
+		mux.HandleFunc("/api/v1/channels/msteams/delivery", deliveryReceiptHandler("msteams", resolveMSTeamsInboundToken))
+		mux.HandleFunc("/api/v1/channels/discord/delivery", deliveryReceiptHandler("discord", resolveDiscordInboundToken))
+		mux.HandleFunc("/api/v1/channels/telegram/delivery", deliveryReceiptHandler("telegram", resolveTelegramInboundToken))
+
+		// API: Recorded delivery receipts (GET ?chat_id=&task_id=&limit=)
+		mux.HandleFunc("/api/v1/channels/delivery", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method != "GET" {
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+				return
+			}
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			receipts, err := timeSvc.ListDeliveryReceipts(r.URL.Query().Get("chat_id"), r.URL.Query().Get("task_id"), limit)
+			if err != nil {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+				return
+			}
+			if receipts == nil {
+				receipts = []timeline.DeliveryReceiptRecord{}
+			}
+			json.NewEncoder(w).Encode(receipts)
+		})
+
+		// API: Detected token spend anomalies, newest first (GET ?limit=)
+		mux.HandleFunc("/api/v1/finops/anomalies", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method != "GET" {
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+				return
+			}
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			anomalies, err := timeSvc.ListCostAnomalies(limit)
+			if err != nil {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+				return
+			}
+			if anomalies == nil {
+				anomalies = []timeline.CostAnomalyRecord{}
+			}
+			json.NewEncoder(w).Encode(anomalies)
+		})
+
+		// API: Aggregated channelbridge /status (health, metrics, last errors)
+		bridgeClient := &http.Client{Timeout: bridgeStatusTimeout}
+		mux.HandleFunc("/api/v1/bridges/status", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method != http.MethodGet {
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+				return
+			}
+			overall, bridges := aggregateBridgeStatus(r.Context(), bridgeClient, bridgeTargets(cfg))
+			json.NewEncoder(w).Encode(map[string]any{
+				"status":       overall,
+				"bridges":      bridges,
+				"collected_at": time.Now().UTC().Format(time.RFC3339),
+			})
+		})
+
+		// Public status page: unauthenticated, rate limited per IP and limited
+		// to states, counts and timestamps (opt-in).
+		publicStatusEnabled := cfg.Gateway.PublicStatus.Enabled
+		publicLimiter := newPublicStatusLimiter(cfg.Gateway.PublicStatus)
+		publicSnapshot := &publicStatusCache{collect: func(ctx context.Context) publicStatus {
+			components := append(startup.snapshot(), providerBreakerComponents(provider.BreakerStates())...)
+			_, bridges := aggregateBridgeStatus(ctx, bridgeClient, bridgeTargets(cfg))
+			return buildPublicStatus(components, bridges, startup.lastIncident(), msgBus.InboundSize(), msgBus.OutboundSize(), time.Since(gatewayStartTime), time.Now())
+		}}
+		mux.HandleFunc("/api/v1/public/status", publicLimiter.limitPublic(publicStatusEnabled, "/api/v1/public/status", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Cache-Control", "public, max-age=10")
+			if r.Method != http.MethodGet {
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+				return
+			}
+			// Detached: a client hanging up must not cache a half-probed snapshot.
+			json.NewEncoder(w).Encode(publicSnapshot.get(context.WithoutCancel(r.Context())))
+		}))
+		mux.HandleFunc("/status", publicLimiter.limitPublic(publicStatusEnabled, "/status", func(w http.ResponseWriter, r *http.Request) {
+			serveDashboardAsset(w, "status.html")
+		}))
+
+		// API: Broadcast one message to many chats (POST) and its delivery status (GET ?id=)
+		mux.HandleFunc("/api/v1/channels/broadcast", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == "OPTIONS" {
+				return
+			}
+			switch r.Method {
+			case http.MethodGet:
+				id := strings.TrimSpace(r.URL.Query().Get("id"))
+				if id == "" {
+					writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "id required")
+					return
+				}
+				status, err := broadcastStatus(timeSvc, id)
+				if err != nil {
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+					return
+				}
+				if status == nil {
+					writeAPIError(w, r, http.StatusNotFound, codeNotFound, "broadcast not found")
+					return
+				}
+				json.NewEncoder(w).Encode(status)
+			case http.MethodPost:
+				var body broadcastRequest
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
+					return
+				}
+				if strings.TrimSpace(body.Content) == "" {
+					writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "content required")
+					return
+				}
+				targets, err := resolveBroadcastTargets(timeSvc, body)
+				if err != nil {
+					writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, err.Error())
+					return
+				}
+				if len(targets) == 0 {
+					writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "targets or audiences required")
+					return
+				}
+				broadcastID := "broadcast-" + newTraceID()
+				taskIDs, err := queueBroadcast(timeSvc, broadcastID, body.Content, targets)
+				if err != nil {
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+					return
+				}
+				interval := defaultBroadcastInterval
+				if body.IntervalMs > 0 {
+					interval = time.Duration(body.IntervalMs) * time.Millisecond
+				}
+				go dispatchBroadcast(ctx, timeSvc, msgBus, broadcastID, body.Content, targets, taskIDs, interval, body.BatchSize)
+				w.WriteHeader(http.StatusAccepted)
+				json.NewEncoder(w).Encode(map[string]any{
+					"broadcast_id": broadcastID,
+					"targets":      len(targets),
+					"status":       "dispatching",
+				})
+			default:
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed")
+			}
+		})
+
+		// API: Saved broadcast audiences (GET list, POST upsert, DELETE ?name=)
+		mux.HandleFunc("/api/v1/channels/broadcast/audiences", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == "OPTIONS" {
+				return
+			}
+			switch r.Method {
+			case http.MethodGet:
+				audiences, err := timeSvc.ListBroadcastAudiences()
+				if err != nil {
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+					return
+				}
+				if audiences == nil {
+					audiences = []timeline.BroadcastAudienceRecord{}
+				}
+				json.NewEncoder(w).Encode(map[string]any{"audiences": audiences})
+			case http.MethodPost:
+				var body struct {
+					Name        string            `json:"name"`
+					Description string            `json:"description"`
+					Targets     []broadcastTarget `json:"targets"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
+					return
+				}
+				name := strings.TrimSpace(body.Name)
+				if name == "" {
+					writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "name required")
+					return
+				}
+				targets, err := resolveBroadcastTargets(timeSvc, broadcastRequest{Targets: body.Targets})
+				if err != nil {
+					writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, err.Error())
+					return
+				}
+				raw, _ := json.Marshal(targets)
+				if err := timeSvc.UpsertBroadcastAudience(&timeline.BroadcastAudienceRecord{
+					Name:        name,
+					Description: strings.TrimSpace(body.Description),
+					Targets:     string(raw),
+				}); err != nil {
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]any{"name": name, "targets": len(targets)})
+			case http.MethodDelete:
+				deleted, err := timeSvc.DeleteBroadcastAudience(strings.TrimSpace(r.URL.Query().Get("name")))
+				if err != nil {
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]any{"deleted": deleted})
+			default:
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed")
+			}
+		})
+
+		// API: Message templates (GET list or ?name=, POST save, DELETE ?name=)
+		mux.HandleFunc("/api/v1/channels/templates", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == "OPTIONS" {
+				return
+			}
+			writeTemplateError := func(err error) {
+				if errors.Is(err, msgtemplate.ErrNotFound) {
+					writeAPIError(w, r, http.StatusNotFound, codeNotFound, err.Error())
+					return
+				}
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, err.Error())
+			}
+			name := strings.TrimSpace(r.URL.Query().Get("name"))
+			switch r.Method {
+			case http.MethodGet:
+				if name != "" {
+					tpl, err := templates.Get(name)
+					if err != nil {
+						writeTemplateError(err)
+						return
+					}
+					json.NewEncoder(w).Encode(tpl)
+					return
+				}
+				list, err := templates.List()
+				if err != nil {
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+					return
+				}
+				if list == nil {
+					list = []*msgtemplate.Template{}
+				}
+				json.NewEncoder(w).Encode(map[string]any{"templates": list})
+			case http.MethodPost:
+				var tpl msgtemplate.Template
+				if err := json.NewDecoder(r.Body).Decode(&tpl); err != nil {
+					writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
+					return
+				}
+				if err := templates.Save(&tpl); err != nil {
+					writeTemplateError(err)
+					return
+				}
+				json.NewEncoder(w).Encode(tpl)
+			case http.MethodDelete:
+				if err := templates.Delete(name); err != nil {
+					writeTemplateError(err)
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]any{"deleted": true})
+			default:
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed")
+			}
+		})
+
+		// API: Missions (GET list, POST upsert, DELETE ?name=)
+		mux.HandleFunc("/api/v1/missions", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == "OPTIONS" {
+				return
+			}
+			switch r.Method {
+			case http.MethodGet:
+				missions, err := timeSvc.ListMissions()
+				if err != nil {
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+					return
+				}
+				if missions == nil {
+					missions = []timeline.MissionRecord{}
+				}
+				json.NewEncoder(w).Encode(map[string]any{"missions": missions})
+			case http.MethodPost:
+				var body struct {
+					Name        string `json:"name"`
+					Description string `json:"description"`
+					Status      string `json:"status"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
+					return
+				}
+				name := strings.TrimSpace(body.Name)
+				if name == "" || strings.ContainsAny(name, " \t\n") {
+					writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "name required (no whitespace)")
+					return
+				}
+				status := strings.ToLower(strings.TrimSpace(body.Status))
+				if status != "" && status != timeline.MissionStatusActive && status != timeline.MissionStatusArchived {
+					writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, "status must be active or archived")
+					return
+				}
+				rec := &timeline.MissionRecord{Name: name, Description: strings.TrimSpace(body.Description), Status: status}
+				if err := timeSvc.UpsertMission(rec); err != nil {
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]any{"name": name, "status": rec.Status})
+			case http.MethodDelete:
+				deleted, err := timeSvc.DeleteMission(strings.TrimSpace(r.URL.Query().Get("name")))
+				if err != nil {
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]any{"deleted": deleted})
+			default:
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed")
+			}
+		})
+
+		// API: Mission assignments (GET ?mission=&kind=, POST assign, DELETE ?kind=&ref=)
+		mux.HandleFunc("/api/v1/missions/assignments", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == "OPTIONS" {
+				return
+			}
+			switch r.Method {
+			case http.MethodGet:
+				q := r.URL.Query()
+				items, err := timeSvc.ListMissionAssignments(strings.TrimSpace(q.Get("mission")), strings.TrimSpace(q.Get("kind")))
+				if err != nil {
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+					return
+				}
+				if items == nil {
+					items = []timeline.MissionAssignmentRecord{}
+				}
+				json.NewEncoder(w).Encode(map[string]any{"assignments": items})
+			case http.MethodPost:
+				var body struct {
+					Mission string `json:"mission"`
+					Kind    string `json:"kind"`
+					Ref     string `json:"ref"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
+					return
+				}
+				kind := strings.ToLower(strings.TrimSpace(body.Kind))
+				ref := strings.TrimSpace(body.Ref)
+				if !timeline.IsMissionKind(kind) || ref == "" {
+					writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "kind (task|job|repo|memory) and ref required")
+					return
+				}
+				mission, err := timeSvc.GetMission(strings.TrimSpace(body.Mission))
+				if err != nil {
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+					return
+				}
+				if mission == nil {
+					writeAPIError(w, r, http.StatusNotFound, codeNotFound, "mission not found")
+					return
+				}
+				if err := timeSvc.AssignToMission(mission.Name, kind, ref); err != nil {
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]any{"mission": mission.Name, "kind": kind, "ref": ref})
+			case http.MethodDelete:
+				q := r.URL.Query()
+				removed, err := timeSvc.UnassignFromMission(strings.ToLower(strings.TrimSpace(q.Get("kind"))), strings.TrimSpace(q.Get("ref")))
+				if err != nil {
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]any{"removed": removed})
+			default:
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed")
+			}
+		})
+
+		// API: Mission dashboard (GET ?name=&limit=)
+		mux.HandleFunc("/api/v1/missions/dashboard", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method != http.MethodGet {
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed")
+				return
+			}
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			dash, err := timeSvc.GetMissionDashboard(strings.TrimSpace(r.URL.Query().Get("name")), limit)
+			if err != nil {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+				return
+			}
+			if dash == nil {
+				writeAPIError(w, r, http.StatusNotFound, codeNotFound, "mission not found")
+				return
+			}
+			json.NewEncoder(w).Encode(dash)
+		})
+
+		// Orchestrator API endpoints
+		mux.HandleFunc("/api/v1/orchestrator/status", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Content-Type", "application/json")
+			if orch == nil {
+				json.NewEncoder(w).Encode(map[string]any{"enabled": false})
+				return
+			}
+			json.NewEncoder(w).Encode(orch.Status())
+		})
+
+		mux.HandleFunc("/api/v1/orchestrator/hierarchy", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Content-Type", "application/json")
+			if orch == nil {
+				json.NewEncoder(w).Encode([]any{})
+				return
+			}
+			json.NewEncoder(w).Encode(orch.GetHierarchy())
+		})
+
+		mux.HandleFunc("/api/v1/orchestrator/zones", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Content-Type", "application/json")
+			if orch == nil {
+				json.NewEncoder(w).Encode([]any{})
+				return
+			}
+			json.NewEncoder(w).Encode(orch.GetZones())
+		})
+
+		mux.HandleFunc("/api/v1/orchestrator/agents", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Content-Type", "application/json")
+			if orch == nil {
+				json.NewEncoder(w).Encode([]any{})
+				return
+			}
+			json.NewEncoder(w).Encode(orch.GetAgents())
+		})
+
+		mux.HandleFunc("/api/v1/orchestrator/dispatch", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == "OPTIONS" {
+				return
+			}
+			if r.Method != "POST" {
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+				return
+			}
+			if orch == nil {
+				writeAPIError(w, r, http.StatusConflict, codeNotConfigured, "orchestrator not enabled")
+				return
+			}
+			var body struct {
+				Description string `json:"description"`
+				TargetZone  string `json:"target_zone"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
+				return
+			}
+			taskID := newTraceID()
+			if err := orch.DispatchTask(ctx, taskID, body.Description, body.TargetZone); err != nil {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"status": "dispatched", "task_id": taskID})
+		})
+
+		// API: Timeline
+		mux.HandleFunc("/api/v1/timeline", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Content-Type", "application/json")
+
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			if limit == 0 {
+				limit = 100
+			}
+			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+			sender := r.URL.Query().Get("sender")
+			traceID := r.URL.Query().Get("trace_id")
+
+			events, err := timeSvc.GetEvents(timeline.FilterArgs{
+				Limit:    limit,
+				Offset:   offset,
+				SenderID: sender,
+				TraceID:  traceID,
+			})
+			if err != nil {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+				return
+			}
+
+			json.NewEncoder(w).Encode(events)
+		})
+
+		// API: Timeline redaction (POST)
+		mux.HandleFunc("/api/v1/timeline/redact", timelineRedactHandler(cfg.Gateway.AuthToken, timeSvc))
+
+		// API: Trace (GET)
+		mux.HandleFunc("/api/v1/trace/", func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
 			w.Header().Set("Content-Type", "application/json")
 
 			traceID := strings.TrimPrefix(r.URL.Path, "/api/v1/trace/")
 			traceID = strings.TrimSpace(traceID)
 			if traceID == "" {
-				http.Error(w, "trace_id required", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "trace_id required")
 				return
 			}
 
@@ -1004,7 +1868,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				TraceID: traceID,
 			})
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 
@@ -1132,6 +1996,35 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			})
 		})
 
+		// API: Traces listing (GET ?channel=&sender=&status=&has_errors=&min_duration_ms=&limit=&offset=)
+		mux.HandleFunc("/api/v1/traces", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method != http.MethodGet {
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+				return
+			}
+			filter, err := parseTraceListFilter(r.URL.Query())
+			if err != nil {
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, err.Error())
+				return
+			}
+			traces, total, err := timeSvc.ListTraces(filter)
+			if err != nil {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+				return
+			}
+			if traces == nil {
+				traces = []timeline.TraceSummary{}
+			}
+			json.NewEncoder(w).Encode(map[string]any{
+				"traces": traces,
+				"total":  total,
+				"limit":  filter.Limit,
+				"offset": filter.Offset,
+			})
+		})
+
 		// API: Policy Decisions (GET)
 		mux.HandleFunc("/api/v1/policy-decisions", func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -1139,18 +2032,35 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 
 			traceID := r.URL.Query().Get("trace_id")
 			if traceID == "" {
-				http.Error(w, "trace_id required", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "trace_id required")
 				return
 			}
 
 			decisions, err := timeSvc.ListPolicyDecisions(traceID)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 			json.NewEncoder(w).Encode(decisions)
 		})
 
+		// API: Registered tools with JSON schemas and tiers (GET ?format=markdown)
+		mux.HandleFunc("/api/v1/tools", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			if r.Method != http.MethodGet {
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+				return
+			}
+			schemas := loop.ToolSchemas()
+			if r.URL.Query().Get("format") == "markdown" {
+				w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+				_, _ = w.Write([]byte(tools.RenderSchemasMarkdown(schemas)))
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(schemas)
+		})
+
 		// API: Trace Graph (GET)
 		mux.HandleFunc("/api/v1/trace-graph/", func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -1159,13 +2069,13 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			traceID := strings.TrimPrefix(r.URL.Path, "/api/v1/trace-graph/")
 			traceID = strings.TrimSpace(traceID)
 			if traceID == "" {
-				http.Error(w, "trace_id required", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "trace_id required")
 				return
 			}
 
 			graph, err := timeSvc.GetTraceGraph(traceID)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 			json.NewEncoder(w).Encode(graph)
@@ -1203,6 +2113,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				for _, m := range liveMembers {
 					caps, _ := json.Marshal(m.Capabilities)
 					chs, _ := json.Marshal(m.Channels)
+					tax, _ := json.Marshal(m.Taxonomy)
 					out = append(out, map[string]any{
 						"agent_id":     m.AgentID,
 						"agent_name":   m.AgentName,
@@ -1213,6 +2124,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 						"role":         m.Role,
 						"status":       m.Status,
 						"last_seen":    m.LastSeen,
+						"taxonomy":     string(tax),
 					})
 				}
 				json.NewEncoder(w).Encode(out)
@@ -1222,7 +2134,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			// Fallback: DB roster
 			members, err := timeSvc.ListGroupMembers()
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 			if members == nil {
@@ -1231,6 +2143,48 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			json.NewEncoder(w).Encode(members)
 		})
 
+		// API: Group Member Search (GET)
+		// ?capability=kubernetes matches capability names and tags; a
+		// "kind:" prefix or ?kind= restricts to channel, tool, skill or model.
+		mux.HandleFunc("/api/v1/group/members/search", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == "OPTIONS" {
+				return
+			}
+			if r.Method != http.MethodGet {
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+				return
+			}
+			query := strings.TrimSpace(r.URL.Query().Get("capability"))
+			kind := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("kind")))
+			if query == "" && kind == "" {
+				writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "capability or kind is required")
+				return
+			}
+			if kind != "" && !group.IsCapabilityKind(kind) {
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, "kind must be channel, tool, skill or model")
+				return
+			}
+
+			var live []group.GroupMember
+			if mgr := grpState.Manager(); mgr != nil && mgr.Active() {
+				live = mgr.Members()
+			}
+			records, err := timeSvc.ListGroupMembers()
+			if err != nil {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{
+				"capability": query,
+				"kind":       kind,
+				"members":    searchGroupMembers(live, records, query, kind),
+			})
+		})
+
 		// API: Group Join (POST)
 		mux.HandleFunc("/api/v1/group/join", func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -1240,11 +2194,15 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			if r.Method == "OPTIONS" {
 				return
 			}
-			if isStandaloneBlocked(w) {
+			if isStandaloneBlocked(w, r) {
 				return
 			}
 			if r.Method != "POST" {
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+				return
+			}
+			by, ok := mutationActor(w, r, cfg.Gateway.AuthToken)
+			if !ok {
 				return
 			}
 
@@ -1255,12 +2213,12 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				AgentID      string `json:"agent_id"`
 			}
 			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-				http.Error(w, "invalid body", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
 				return
 			}
 			groupName := strings.TrimSpace(body.GroupName)
 			if groupName == "" {
-				http.Error(w, "group_name required", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "group_name required")
 				return
 			}
 
@@ -1291,7 +2249,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			joinCtx, joinCancel := context.WithTimeout(ctx, 15*time.Second)
 			defer joinCancel()
 			if err := mgr.Join(joinCtx); err != nil {
-				http.Error(w, fmt.Sprintf("join failed: %v", err), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, fmt.Sprintf("join failed: %v", err))
 				return
 			}
 
@@ -1300,10 +2258,10 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			grpState.SetManager(mgr, kafkaCancel)
 
 			// Persist settings
-			_ = timeSvc.SetSetting("group_name", groupName)
-			_ = timeSvc.SetSetting("group_active", "true")
+			_ = timeSvc.SetSettingAudited("group_name", groupName, by)
+			_ = timeSvc.SetSettingAudited("group_active", "true", by)
 			if body.LFSProxyURL != "" {
-				_ = timeSvc.SetSetting("kafscale_lfs_proxy_url", body.LFSProxyURL)
+				_ = timeSvc.SetSettingAudited("kafscale_lfs_proxy_url", body.LFSProxyURL, by)
 			}
 
 			cfg.Group.Enabled = true
@@ -1320,29 +2278,33 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			if r.Method == "OPTIONS" {
 				return
 			}
-			if isStandaloneBlocked(w) {
+			if isStandaloneBlocked(w, r) {
 				return
 			}
 			if r.Method != "POST" {
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
 				return
 			}
 
+			by, ok := mutationActor(w, r, cfg.Gateway.AuthToken)
+			if !ok {
+				return
+			}
 			mgr := grpState.Manager()
 			if mgr == nil {
-				http.Error(w, "not in a group", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusConflict, codeNotConfigured, "not in a group")
 				return
 			}
 
 			leaveCtx, leaveCancel := context.WithTimeout(ctx, 10*time.Second)
 			defer leaveCancel()
 			if err := mgr.Leave(leaveCtx); err != nil {
-				http.Error(w, fmt.Sprintf("leave failed: %v", err), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, fmt.Sprintf("leave failed: %v", err))
 				return
 			}
 
 			grpState.Clear()
-			_ = timeSvc.SetSetting("group_active", "false")
+			_ = timeSvc.SetSettingAudited("group_active", "false", by)
 			cfg.Group.Enabled = false
 			recalcMode()
 
@@ -1358,7 +2320,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			if r.Method == "OPTIONS" {
 				return
 			}
-			if r.Method == "POST" && isStandaloneBlocked(w) {
+			if r.Method == "POST" && isStandaloneBlocked(w, r) {
 				return
 			}
 
@@ -1392,31 +2354,35 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			}
 
 			if r.Method == "POST" {
+				by, ok := mutationActor(w, r, cfg.Gateway.AuthToken)
+				if !ok {
+					return
+				}
 				var body map[string]string
 				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-					http.Error(w, "invalid body", http.StatusBadRequest)
+					writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
 					return
 				}
 				requiresRejoin := false
 				for key, val := range body {
 					switch key {
 					case "lfs_proxy_url":
-						_ = timeSvc.SetSetting("kafscale_lfs_proxy_url", val)
+						_ = timeSvc.SetSettingAudited("kafscale_lfs_proxy_url", val, by)
 						requiresRejoin = true
 					case "api_key":
-						_ = timeSvc.SetSetting("kafscale_lfs_proxy_api_key", val)
+						_ = timeSvc.SetSettingAudited("kafscale_lfs_proxy_api_key", val, by)
 						requiresRejoin = true
 					case "kafka_brokers":
-						_ = timeSvc.SetSetting("kafka_brokers", val)
+						_ = timeSvc.SetSettingAudited("kafka_brokers", val, by)
 						requiresRejoin = true
 					case "consumer_group":
-						_ = timeSvc.SetSetting("kafka_consumer_group", val)
+						_ = timeSvc.SetSettingAudited("kafka_consumer_group", val, by)
 						requiresRejoin = true
 					case "agent_id":
-						_ = timeSvc.SetSetting("group_agent_id", val)
+						_ = timeSvc.SetSettingAudited("group_agent_id", val, by)
 						requiresRejoin = true
 					case "poll_interval_ms":
-						_ = timeSvc.SetSetting("group_poll_interval_ms", val)
+						_ = timeSvc.SetSettingAudited("group_poll_interval_ms", val, by)
 					}
 				}
 				json.NewEncoder(w).Encode(map[string]any{
@@ -1426,7 +2392,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				return
 			}
 
-			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
 		})
 
 		// API: Group Tasks Submit (POST)
@@ -1438,52 +2404,82 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			if r.Method == "OPTIONS" {
 				return
 			}
-			if isStandaloneBlocked(w) {
+			if isStandaloneBlocked(w, r) {
 				return
 			}
 			if r.Method != "POST" {
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
 				return
 			}
 
 			mgr := grpState.Manager()
 			if mgr == nil || !mgr.Active() {
-				http.Error(w, "not in a group", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusConflict, codeNotConfigured, "not in a group")
 				return
 			}
 
 			var body struct {
-				Description string `json:"description"`
-				Content     string `json:"content"`
+				Description   string `json:"description"`
+				Content       string `json:"content"`
+				Priority      string `json:"priority"`
+				DeadlineAt    string `json:"deadline_at"` // RFC3339
+				TargetAgentID string `json:"target_agent_id"`
 			}
 			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-				http.Error(w, "invalid body", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
 				return
 			}
 			if strings.TrimSpace(body.Description) == "" {
-				http.Error(w, "description required", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "description required")
 				return
 			}
+			priority, err := group.NormalizeTaskPriority(body.Priority)
+			if err != nil {
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, err.Error())
+				return
+			}
+			var deadline *time.Time
+			if raw := strings.TrimSpace(body.DeadlineAt); raw != "" {
+				d, err := time.Parse(time.RFC3339, raw)
+				if err != nil {
+					writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, "deadline_at must be an RFC3339 time")
+					return
+				}
+				if !d.After(time.Now()) {
+					writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, "deadline_at must be in the future")
+					return
+				}
+				deadline = &d
+			}
 
 			taskID := newTraceID()
 			submitCtx, submitCancel := context.WithTimeout(ctx, 10*time.Second)
 			defer submitCancel()
-			if err := mgr.SubmitTask(submitCtx, taskID, body.Description, body.Content); err != nil {
-				http.Error(w, fmt.Sprintf("submit failed: %v", err), http.StatusInternalServerError)
+			target := strings.TrimSpace(body.TargetAgentID)
+			opts := group.TaskOptions{Priority: priority, Deadline: deadline, TargetAgentID: target}
+			if err := mgr.SubmitTaskWithOptions(submitCtx, taskID, body.Description, body.Content, opts); err != nil {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, fmt.Sprintf("submit failed: %v", err))
 				return
 			}
 
 			// Persist to local DB
 			_ = timeSvc.InsertGroupTask(&timeline.GroupTaskRecord{
-				TaskID:      taskID,
-				Description: body.Description,
-				Content:     body.Content,
-				Direction:   "outgoing",
-				RequesterID: mgr.AgentID(),
-				Status:      "pending",
+				TaskID:        taskID,
+				Description:   body.Description,
+				Content:       body.Content,
+				Direction:     "outgoing",
+				RequesterID:   mgr.AgentID(),
+				Status:        "pending",
+				Priority:      priority,
+				DeadlineAt:    deadline,
+				TargetAgentID: target,
 			})
 
-			json.NewEncoder(w).Encode(map[string]string{"status": "submitted", "task_id": taskID})
+			resp := map[string]string{"status": "submitted", "task_id": taskID, "priority": priority}
+			if target != "" {
+				resp["target_agent_id"] = target
+			}
+			json.NewEncoder(w).Encode(resp)
 		})
 
 		// API: Group Tasks List (GET)
@@ -1491,6 +2487,20 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
 			w.Header().Set("Content-Type", "application/json")
 
+			if taskID := strings.TrimSpace(r.URL.Query().Get("task_id")); taskID != "" {
+				task, err := timeSvc.GetGroupTask(taskID)
+				if err != nil {
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+					return
+				}
+				if task == nil {
+					writeAPIError(w, r, http.StatusNotFound, codeNotFound, "task not found")
+					return
+				}
+				json.NewEncoder(w).Encode(task)
+				return
+			}
+
 			direction := r.URL.Query().Get("direction")
 			status := r.URL.Query().Get("status")
 			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
@@ -1498,10 +2508,14 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				limit = 50
 			}
 			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+			sortBy := ""
+			if r.URL.Query().Get("sort") == timeline.GroupTaskSortUrgency {
+				sortBy = timeline.GroupTaskSortUrgency
+			}
 
-			tasks, err := timeSvc.ListGroupTasks(direction, status, limit, offset)
+			tasks, err := timeSvc.ListGroupTasksSorted(direction, status, sortBy, limit, offset)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 			if tasks == nil {
@@ -1524,7 +2538,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 
 			traces, err := timeSvc.ListAllGroupTraces(limit, offset, agentID)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 			if traces == nil {
@@ -1542,14 +2556,14 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			if r.Method == "OPTIONS" {
 				return
 			}
-			if r.Method == "POST" && isStandaloneBlocked(w) {
+			if r.Method == "POST" && isStandaloneBlocked(w, r) {
 				return
 			}
 
 			if r.Method == "POST" {
 				mgr := grpState.Manager()
 				if mgr == nil || !mgr.Active() {
-					http.Error(w, "not in a group", http.StatusBadRequest)
+					writeAPIError(w, r, http.StatusConflict, codeNotConfigured, "not in a group")
 					return
 				}
 				var body struct {
@@ -1559,14 +2573,14 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 					Tags        []string `json:"tags"`
 				}
 				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-					http.Error(w, "invalid body", http.StatusBadRequest)
+					writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
 					return
 				}
 				if body.ContentType == "" {
 					body.ContentType = "text/plain"
 				}
 				if err := mgr.ShareMemory(ctx, body.Title, body.ContentType, []byte(body.Content), body.Tags); err != nil {
-					http.Error(w, err.Error(), http.StatusInternalServerError)
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 					return
 				}
 				json.NewEncoder(w).Encode(map[string]string{"status": "shared"})
@@ -1583,7 +2597,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 
 			items, err := timeSvc.ListGroupMemoryItems(authorID, limit, offset)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 			if items == nil {
@@ -1601,29 +2615,29 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			if r.Method == "OPTIONS" {
 				return
 			}
-			if r.Method == "POST" && isStandaloneBlocked(w) {
+			if r.Method == "POST" && isStandaloneBlocked(w, r) {
 				return
 			}
 
 			if r.Method == "POST" {
 				mgr := grpState.Manager()
 				if mgr == nil || !mgr.Active() {
-					http.Error(w, "not in a group", http.StatusBadRequest)
+					writeAPIError(w, r, http.StatusConflict, codeNotConfigured, "not in a group")
 					return
 				}
 				var body struct {
 					SkillName string `json:"skill_name"`
 				}
 				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-					http.Error(w, "invalid body", http.StatusBadRequest)
+					writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
 					return
 				}
 				if body.SkillName == "" {
-					http.Error(w, "skill_name required", http.StatusBadRequest)
+					writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "skill_name required")
 					return
 				}
 				if err := mgr.RegisterSkill(ctx, body.SkillName, grpState.Consumer()); err != nil {
-					http.Error(w, err.Error(), http.StatusInternalServerError)
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 					return
 				}
 				json.NewEncoder(w).Encode(map[string]string{"status": "registered", "skill": body.SkillName})
@@ -1639,7 +2653,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			}
 			channels, err := timeSvc.ListGroupSkillChannels(groupName)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 			if channels == nil {
@@ -1657,17 +2671,17 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			if r.Method == "OPTIONS" {
 				return
 			}
-			if isStandaloneBlocked(w) {
+			if isStandaloneBlocked(w, r) {
 				return
 			}
 			if r.Method != "POST" {
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
 				return
 			}
 
 			mgr := grpState.Manager()
 			if mgr == nil || !mgr.Active() {
-				http.Error(w, "not in a group", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusConflict, codeNotConfigured, "not in a group")
 				return
 			}
 
@@ -1677,74 +2691,200 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				Content     string `json:"content"`
 			}
 			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-				http.Error(w, "invalid body", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
 				return
 			}
 			if body.SkillName == "" {
-				http.Error(w, "skill_name required", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "skill_name required")
+				return
+			}
+
+			taskID := newTraceID()
+			if err := mgr.SubmitSkillTask(ctx, taskID, body.SkillName, body.Description, body.Content); err != nil {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"status": "submitted", "task_id": taskID, "skill": body.SkillName})
+		})
+
+		// API: Group Onboard (POST)
+		mux.HandleFunc("/api/v1/group/onboard", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == "OPTIONS" {
+				return
+			}
+			if isStandaloneBlocked(w, r) {
+				return
+			}
+			if r.Method != "POST" {
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+				return
+			}
+
+			mgr := grpState.Manager()
+			if mgr == nil {
+				writeAPIError(w, r, http.StatusConflict, codeNotConfigured, "group not configured")
+				return
+			}
+
+			if err := mgr.Onboard(ctx); err != nil {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"status": "onboard_request_sent"})
+		})
+
+		// API: Group Membership History (GET)
+		mux.HandleFunc("/api/v1/group/membership/history", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Content-Type", "application/json")
+
+			agentID := r.URL.Query().Get("agent_id")
+			groupName := r.URL.Query().Get("group_name")
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			if limit == 0 {
+				limit = 50
+			}
+			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+			history, err := timeSvc.GetMembershipHistory(agentID, groupName, limit, offset)
+			if err != nil {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+				return
+			}
+			if history == nil {
+				history = []timeline.GroupMembershipHistoryRecord{}
+			}
+			json.NewEncoder(w).Encode(history)
+		})
+
+		// API: Group Roster Reconcile (POST)
+		mux.HandleFunc("/api/v1/group/roster/reconcile", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == "OPTIONS" {
+				return
+			}
+			if r.Method != "POST" {
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+				return
+			}
+
+			mgr := grpState.Manager()
+			if mgr == nil || !mgr.Active() {
+				writeAPIError(w, r, http.StatusConflict, codeNotConfigured, "not in a group")
 				return
 			}
 
-			taskID := newTraceID()
-			if err := mgr.SubmitSkillTask(ctx, taskID, body.SkillName, body.Description, body.Content); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+			reconcileCtx, reconcileCancel := context.WithTimeout(ctx, 30*time.Second)
+			defer reconcileCancel()
+			res, err := mgr.ReconcileRoster(reconcileCtx)
+			if err != nil {
+				writeAPIError(w, r, http.StatusBadGateway, codeUpstream, fmt.Sprintf("reconcile failed: %v", err))
 				return
 			}
-			json.NewEncoder(w).Encode(map[string]string{"status": "submitted", "task_id": taskID, "skill": body.SkillName})
+			json.NewEncoder(w).Encode(res)
 		})
 
-		// API: Group Onboard (POST)
-		mux.HandleFunc("/api/v1/group/onboard", func(w http.ResponseWriter, r *http.Request) {
+		// API: Group Administration — state and recent actions (GET), signed admin action (POST)
+		mux.HandleFunc("/api/v1/group/admin", func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
 			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 			w.Header().Set("Content-Type", "application/json")
 			if r.Method == "OPTIONS" {
 				return
 			}
-			if isStandaloneBlocked(w) {
-				return
-			}
-			if r.Method != "POST" {
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-				return
-			}
 
 			mgr := grpState.Manager()
 			if mgr == nil {
-				http.Error(w, "group not configured", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusConflict, codeNotConfigured, "no group manager")
 				return
 			}
 
-			if err := mgr.Onboard(ctx); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
+			switch r.Method {
+			case "GET":
+				actions, err := timeSvc.ListGroupAdminActions(mgr.GroupName(), 50)
+				if err != nil {
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+					return
+				}
+				if actions == nil {
+					actions = []timeline.GroupAdminActionRecord{}
+				}
+				json.NewEncoder(w).Encode(map[string]any{
+					"role":     mgr.AdminRole(),
+					"observer": mgr.Observer(),
+					"state":    mgr.AdminState(),
+					"actions":  actions,
+				})
+			case "POST":
+				if _, ok := mutationActor(w, r, cfg.Gateway.AuthToken); !ok {
+					return
+				}
+				var req struct {
+					Action   string `json:"action"`
+					TargetID string `json:"target_id"`
+					Value    string `json:"value"`
+					Reason   string `json:"reason"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid request body")
+					return
+				}
+				payload, err := mgr.Administer(ctx, group.AdminAction(strings.TrimSpace(req.Action)),
+					strings.TrimSpace(req.TargetID), strings.TrimSpace(req.Value), strings.TrimSpace(req.Reason))
+				if err != nil {
+					writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, err.Error())
+					return
+				}
+				json.NewEncoder(w).Encode(payload)
+			default:
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
 			}
-			json.NewEncoder(w).Encode(map[string]string{"status": "onboard_request_sent"})
 		})
 
-		// API: Group Membership History (GET)
-		mux.HandleFunc("/api/v1/group/membership/history", func(w http.ResponseWriter, r *http.Request) {
+		// API: Pinned member identity keys (GET), forget a pin (DELETE ?agent_id=)
+		mux.HandleFunc("/api/v1/group/identities", func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 			w.Header().Set("Content-Type", "application/json")
-
-			agentID := r.URL.Query().Get("agent_id")
-			groupName := r.URL.Query().Get("group_name")
-			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-			if limit == 0 {
-				limit = 50
+			if r.Method == "OPTIONS" {
+				return
 			}
-			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
 
-			history, err := timeSvc.GetMembershipHistory(agentID, groupName, limit, offset)
-			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+			mgr := grpState.Manager()
+			if mgr == nil {
+				writeAPIError(w, r, http.StatusConflict, codeNotConfigured, "no group manager")
 				return
 			}
-			if history == nil {
-				history = []timeline.GroupMembershipHistoryRecord{}
+
+			switch r.Method {
+			case "GET":
+				json.NewEncoder(w).Encode(mgr.IdentityPins())
+			case "DELETE":
+				if _, ok := mutationActor(w, r, cfg.Gateway.AuthToken); !ok {
+					return
+				}
+				agentID := strings.TrimSpace(r.URL.Query().Get("agent_id"))
+				if agentID == "" {
+					writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "agent_id required")
+					return
+				}
+				if !mgr.ForgetIdentity(agentID) {
+					writeAPIError(w, r, http.StatusNotFound, codeNotFound, "no pinned identity for "+agentID)
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]any{"ok": true, "agent_id": agentID})
+			default:
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
 			}
-			json.NewEncoder(w).Encode(history)
 		})
 
 		// API: Previous Group Members (GET)
@@ -1754,7 +2894,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 
 			members, err := timeSvc.ListPreviousGroupMembers()
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 			if members == nil {
@@ -1772,11 +2912,11 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			if r.Method == "OPTIONS" {
 				return
 			}
-			if isStandaloneBlocked(w) {
+			if isStandaloneBlocked(w, r) {
 				return
 			}
 			if r.Method != "POST" {
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
 				return
 			}
 
@@ -1785,11 +2925,11 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				GroupName string `json:"group_name"`
 			}
 			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-				http.Error(w, "invalid body", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
 				return
 			}
 			if body.AgentID == "" {
-				http.Error(w, "agent_id required", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "agent_id required")
 				return
 			}
 
@@ -1803,13 +2943,13 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 
 			prevConfig, err := timeSvc.GetLatestMembershipConfig(body.AgentID, groupName)
 			if err != nil {
-				http.Error(w, "no previous config found for this agent", http.StatusNotFound)
+				writeAPIError(w, r, http.StatusNotFound, codeNotFound, "no previous config found for this agent")
 				return
 			}
 
 			// Reactivate the member in the roster
 			if err := timeSvc.ReactivateGroupMember(body.AgentID); err != nil {
-				http.Error(w, fmt.Sprintf("reactivate failed: %v", err), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, fmt.Sprintf("reactivate failed: %v", err))
 				return
 			}
 
@@ -1827,7 +2967,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 
 			stats, err := timeSvc.GetGroupStats()
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 			json.NewEncoder(w).Encode(stats)
@@ -1854,7 +2994,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 
 			entries, err := timeSvc.ListUnifiedAudit(filter)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 			if entries == nil {
@@ -1870,12 +3010,12 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 
 			mgr := grpState.Manager()
 			if mgr == nil {
-				json.NewEncoder(w).Encode(map[string]any{"error": "no group manager"})
+				writeAPIError(w, r, http.StatusConflict, codeNotConfigured, "no group manager")
 				return
 			}
 			tm := mgr.TopicManager()
 			if tm == nil {
-				json.NewEncoder(w).Encode(map[string]any{"error": "no topic manager"})
+				writeAPIError(w, r, http.StatusConflict, codeNotConfigured, "no topic manager")
 				return
 			}
 			json.NewEncoder(w).Encode(tm.Manifest())
@@ -1895,7 +3035,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				}
 				msgs, err := timeSvc.GetTopicMessages(browseTopic, limit)
 				if err != nil {
-					json.NewEncoder(w).Encode(map[string]any{"error": err.Error()})
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 					return
 				}
 				if msgs == nil {
@@ -2015,7 +3155,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 
 			flow, err := timeSvc.GetTopicFlowData()
 			if err != nil {
-				json.NewEncoder(w).Encode(map[string]any{"error": err.Error()})
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 			if flow == nil {
@@ -2031,7 +3171,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 
 			health, err := timeSvc.GetTopicHealth()
 			if err != nil {
-				json.NewEncoder(w).Encode(map[string]any{"error": err.Error()})
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 			if health == nil {
@@ -2050,17 +3190,17 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			if r.Method == "OPTIONS" {
 				return
 			}
-			if isStandaloneBlocked(w) {
+			if isStandaloneBlocked(w, r) {
 				return
 			}
 			if r.Method != "POST" {
-				http.Error(w, "POST only", http.StatusMethodNotAllowed)
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "POST only")
 				return
 			}
 
 			mgr := grpState.Manager()
 			if mgr == nil {
-				json.NewEncoder(w).Encode(map[string]any{"error": "no group manager"})
+				writeAPIError(w, r, http.StatusConflict, codeNotConfigured, "no group manager")
 				return
 			}
 
@@ -2068,12 +3208,12 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				TopicName string `json:"topic_name"`
 			}
 			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.TopicName == "" {
-				http.Error(w, "topic_name required", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "topic_name required")
 				return
 			}
 
 			if err := mgr.EnsureTopic(r.Context(), body.TopicName); err != nil {
-				json.NewEncoder(w).Encode(map[string]any{"error": err.Error()})
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 			// Log the ensure event locally so stats are immediately visible
@@ -2092,105 +3232,453 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 		mux.HandleFunc("/api/v1/group/topics/xp", func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
 			w.Header().Set("Content-Type", "application/json")
-
-			xp, err := timeSvc.GetAgentXP()
+
+			xp, err := timeSvc.GetAgentXP()
+			if err != nil {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+				return
+			}
+			if xp == nil {
+				xp = []timeline.AgentXP{}
+			}
+			json.NewEncoder(w).Encode(map[string]any{"leaderboard": xp})
+		})
+
+		// API: Group Topic Density (GET) — hourly buckets + envelope types for sparkline popup
+		mux.HandleFunc("/api/v1/group/topics/density", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Content-Type", "application/json")
+
+			topicName := r.URL.Query().Get("topic")
+			if topicName == "" {
+				writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "topic parameter required")
+				return
+			}
+			hours := 48
+			if h, err := strconv.Atoi(r.URL.Query().Get("hours")); err == nil && h > 0 {
+				hours = h
+			}
+
+			buckets, err := timeSvc.GetTopicMessageDensity(topicName, hours)
+			if err != nil {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+				return
+			}
+			if buckets == nil {
+				buckets = []timeline.TopicDensityBucket{}
+			}
+
+			envTypes, err := timeSvc.GetTopicEnvelopeTypeCounts(topicName)
+			if err != nil {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+				return
+			}
+			if envTypes == nil {
+				envTypes = map[string]int{}
+			}
+
+			json.NewEncoder(w).Encode(map[string]any{
+				"topic":          topicName,
+				"hours":          hours,
+				"buckets":        buckets,
+				"envelope_types": envTypes,
+			})
+		})
+
+		// API: Settings (GET/POST)
+		mux.HandleFunc("/api/v1/settings", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Content-Type", "application/json")
+
+			if r.Method == "OPTIONS" {
+				return
+			}
+
+			if r.Method == "POST" {
+				by, ok := mutationActor(w, r, cfg.Gateway.AuthToken)
+				if !ok {
+					return
+				}
+				var body struct {
+					Key   string `json:"key"`
+					Value string `json:"value"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
+					return
+				}
+				if strings.HasPrefix(body.Key, channels.QuietHoursSettingPrefix) {
+					if _, err := channels.ParseQuietHours(body.Value); err != nil {
+						writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, err.Error())
+						return
+					}
+				}
+				if err := timeSvc.SetSettingAudited(body.Key, body.Value, by); err != nil {
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+					return
+				}
+				slog.InfoContext(r.Context(), "Setting changed", "actor", by.Actor, "key", body.Key, "value", timeline.MaskSettingValue(body.Value))
+				// Auto-reload WhatsApp auth when allowlist/denylist changes
+				if body.Key == "whatsapp_allowlist" || body.Key == "whatsapp_denylist" || body.Key == "whatsapp_pair_token" {
+					wa.ReloadAuth()
+				}
+				json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+				return
+			}
+
+			// GET: return all requested settings
+			key := r.URL.Query().Get("key")
+			if key != "" {
+				val, err := timeSvc.GetSetting(key)
+				if err != nil {
+					json.NewEncoder(w).Encode(map[string]string{"key": key, "value": ""})
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]string{"key": key, "value": val})
+				return
+			}
+			// Return silent_mode by default
+			json.NewEncoder(w).Encode(map[string]bool{"silent_mode": timeSvc.IsSilentMode()})
+		})
+
+		// API: Settings export (GET) — declarative bundle of settings and config sections
+		mux.HandleFunc("/api/v1/settings/export", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == "OPTIONS" {
+				return
+			}
+			if r.Method != http.MethodGet {
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+				return
+			}
+			bundle, err := cliconfig.ExportBundle(timeSvc)
+			if err != nil {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+				return
+			}
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			_ = enc.Encode(bundle)
+		})
+
+		// API: Settings import (POST) — validate, preview (?dry_run=true) and apply a bundle
+		mux.HandleFunc("/api/v1/settings/import", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == "OPTIONS" {
+				return
+			}
+			if r.Method != http.MethodPost {
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+				return
+			}
+			var bundle cliconfig.Bundle
+			if err := json.NewDecoder(r.Body).Decode(&bundle); err != nil {
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
+				return
+			}
+			dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+			by := timeline.SettingChangeRecord{}
+			if !dryRun {
+				var ok bool
+				if by, ok = mutationActor(w, r, cfg.Gateway.AuthToken); !ok {
+					return
+				}
+			}
+			res, err := cliconfig.ImportBundle(timeSvc, &bundle, dryRun)
+			if err != nil {
+				var verr *cliconfig.BundleValidationError
+				if errors.As(err, &verr) {
+					writeAPIErrorDetails(w, r, http.StatusBadRequest, codeInvalidRequest, "bundle validation failed", map[string]any{"problems": verr.Problems})
+					return
+				}
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+				return
+			}
+			if !dryRun {
+				slog.InfoContext(r.Context(), "Settings bundle imported", "actor", by.Actor, "changes", len(res.Changes), "restart_required", res.RestartRequired)
+				auditBundleChanges(timeSvc, by, res.Changes)
+				for _, ch := range res.Changes {
+					if ch.Path == "settings.whatsapp_allowlist" || ch.Path == "settings.whatsapp_denylist" {
+						wa.ReloadAuth()
+						break
+					}
+				}
+			}
+			json.NewEncoder(w).Encode(res)
+		})
+
+		// API: Settings audit (GET) — who changed which setting or config key
+		mux.HandleFunc("/api/v1/audit/settings", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == "OPTIONS" {
+				return
+			}
+			if r.Method != http.MethodGet {
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+				return
+			}
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			if limit <= 0 || limit > 500 {
+				limit = 100
+			}
+			changes, err := timeSvc.ListSettingChanges(strings.TrimSpace(r.URL.Query().Get("key")), limit)
+			if err != nil {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+				return
+			}
+			if changes == nil {
+				changes = []timeline.SettingChangeRecord{}
+			}
+			json.NewEncoder(w).Encode(changes)
+		})
+
+		// API: WhatsApp session export (POST) — encrypted snapshot of the whatsmeow store
+		mux.HandleFunc("/api/v1/whatsapp/session/export", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			if r.Method == "OPTIONS" {
+				return
+			}
+			if r.Method != http.MethodPost {
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+				return
+			}
+			var body struct {
+				Passphrase string `json:"passphrase"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
+				return
+			}
+			if len(body.Passphrase) < 8 {
+				writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "passphrase of at least 8 characters required")
+				return
+			}
+			dbPath, err := channels.WhatsAppSessionDBPath()
+			if err != nil {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+				return
+			}
+			data, err := channels.ExportWhatsAppSession(r.Context(), dbPath, body.Passphrase)
+			if err != nil {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=whatsapp-session-%s.json", time.Now().UTC().Format("20060102-150405Z")))
+			_, _ = w.Write(data)
+		})
+
+		// API: WhatsApp session import (POST) — staged, applied on next channel start
+		mux.HandleFunc("/api/v1/whatsapp/session/import", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == "OPTIONS" {
+				return
+			}
+			if r.Method != http.MethodPost {
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+				return
+			}
+			var body struct {
+				Passphrase string          `json:"passphrase"`
+				Backup     json.RawMessage `json:"backup"`
+			}
+			if err := json.NewDecoder(io.LimitReader(r.Body, 64<<20)).Decode(&body); err != nil || len(body.Backup) == 0 {
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
+				return
+			}
+			dbPath, err := channels.WhatsAppSessionDBPath()
 			if err != nil {
-				json.NewEncoder(w).Encode(map[string]any{"error": err.Error()})
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
-			if xp == nil {
-				xp = []timeline.AgentXP{}
+			if err := channels.ImportWhatsAppSession(body.Backup, dbPath, body.Passphrase); err != nil {
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, err.Error())
+				return
 			}
-			json.NewEncoder(w).Encode(map[string]any{"leaderboard": xp})
+			slog.InfoContext(r.Context(), "WhatsApp session imported; applied on next restart")
+			json.NewEncoder(w).Encode(map[string]any{
+				"status":           "staged",
+				"restart_required": true,
+			})
 		})
 
-		// API: Group Topic Density (GET) — hourly buckets + envelope types for sparkline popup
-		mux.HandleFunc("/api/v1/group/topics/density", func(w http.ResponseWriter, r *http.Request) {
+		// API: WhatsApp periodic session backups (GET list)
+		mux.HandleFunc("/api/v1/whatsapp/session/backups", func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 			w.Header().Set("Content-Type", "application/json")
-
-			topicName := r.URL.Query().Get("topic")
-			if topicName == "" {
-				http.Error(w, "topic parameter required", http.StatusBadRequest)
+			if r.Method == "OPTIONS" {
 				return
 			}
-			hours := 48
-			if h, err := strconv.Atoi(r.URL.Query().Get("hours")); err == nil && h > 0 {
-				hours = h
+			if r.Method != http.MethodGet {
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+				return
 			}
-
-			buckets, err := timeSvc.GetTopicMessageDensity(topicName, hours)
+			dir, err := channels.WhatsAppSessionBackupDir()
 			if err != nil {
-				json.NewEncoder(w).Encode(map[string]any{"error": err.Error()})
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
-			if buckets == nil {
-				buckets = []timeline.TopicDensityBucket{}
-			}
-
-			envTypes, err := timeSvc.GetTopicEnvelopeTypeCounts(topicName)
+			paths, err := channels.ListWhatsAppSessionBackups(dir)
 			if err != nil {
-				json.NewEncoder(w).Encode(map[string]any{"error": err.Error()})
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
-			if envTypes == nil {
-				envTypes = map[string]int{}
+			type backupInfo struct {
+				Path      string    `json:"path"`
+				Size      int64     `json:"size"`
+				CreatedAt time.Time `json:"created_at"`
+			}
+			out := make([]backupInfo, 0, len(paths))
+			for _, p := range paths {
+				if st, err := os.Stat(p); err == nil {
+					out = append(out, backupInfo{Path: p, Size: st.Size(), CreatedAt: st.ModTime().UTC()})
+				}
 			}
-
 			json.NewEncoder(w).Encode(map[string]any{
-				"topic":          topicName,
-				"hours":          hours,
-				"buckets":        buckets,
-				"envelope_types": envTypes,
+				"dir":          dir,
+				"interval_hrs": cfg.Channels.WhatsApp.SessionBackupHours,
+				"backups":      out,
 			})
 		})
 
-		// API: Settings (GET/POST)
-		mux.HandleFunc("/api/v1/settings", func(w http.ResponseWriter, r *http.Request) {
+		// API: Prompt templates (GET list or versions, POST new version, DELETE)
+		mux.HandleFunc("/api/v1/prompts", func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
 			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 			w.Header().Set("Content-Type", "application/json")
-
 			if r.Method == "OPTIONS" {
 				return
 			}
 
-			if r.Method == "POST" {
+			switch r.Method {
+			case http.MethodGet:
+				var (
+					list []timeline.PromptTemplateRecord
+					err  error
+				)
+				if name := r.URL.Query().Get("name"); name != "" {
+					list, err = timeSvc.ListPromptTemplateVersions(name)
+				} else {
+					list, err = timeSvc.ListPromptTemplates()
+				}
+				if err != nil {
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+					return
+				}
+				if list == nil {
+					list = []timeline.PromptTemplateRecord{}
+				}
+				json.NewEncoder(w).Encode(list)
+
+			case http.MethodPost:
 				var body struct {
-					Key   string `json:"key"`
-					Value string `json:"value"`
+					Name        string            `json:"name"`
+					Content     string            `json:"content"`
+					Description string            `json:"description"`
+					Variables   map[string]string `json:"variables"`
 				}
 				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-					http.Error(w, "invalid body", http.StatusBadRequest)
+					writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
 					return
 				}
-				if err := timeSvc.SetSetting(body.Key, body.Value); err != nil {
-					http.Error(w, err.Error(), http.StatusInternalServerError)
+				if !agent.ValidPromptTemplateName(body.Name) {
+					writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, "name must match [A-Za-z0-9_.-]+")
 					return
 				}
-				fmt.Printf("⚙️ Setting changed: %s = %s\n", body.Key, body.Value)
-				// Auto-reload WhatsApp auth when allowlist/denylist changes
-				if body.Key == "whatsapp_allowlist" || body.Key == "whatsapp_denylist" || body.Key == "whatsapp_pair_token" {
-					wa.ReloadAuth()
+				if strings.TrimSpace(body.Content) == "" {
+					writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "content is required")
+					return
 				}
-				json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
-				return
-			}
+				vars, _ := json.Marshal(body.Variables)
+				if body.Variables == nil {
+					vars = []byte("{}")
+				}
+				rec := &timeline.PromptTemplateRecord{
+					Name:        body.Name,
+					Content:     body.Content,
+					Description: body.Description,
+					Variables:   string(vars),
+				}
+				if err := timeSvc.SavePromptTemplate(rec); err != nil {
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+					return
+				}
+				slog.InfoContext(r.Context(), "Prompt template saved", "name", rec.Name, "version", rec.Version)
+				json.NewEncoder(w).Encode(rec)
 
-			// GET: return all requested settings
-			key := r.URL.Query().Get("key")
-			if key != "" {
-				val, err := timeSvc.GetSetting(key)
+			case http.MethodDelete:
+				name := r.URL.Query().Get("name")
+				if name == "" {
+					writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "name is required")
+					return
+				}
+				n, err := timeSvc.DeletePromptTemplate(name)
 				if err != nil {
-					json.NewEncoder(w).Encode(map[string]string{"key": key, "value": ""})
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 					return
 				}
-				json.NewEncoder(w).Encode(map[string]string{"key": key, "value": val})
+				json.NewEncoder(w).Encode(map[string]any{"status": "ok", "deleted_versions": n})
+
+			default:
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+			}
+		})
+
+		// API: Prompt template preview (POST) — render a template with variables
+		mux.HandleFunc("/api/v1/prompts/preview", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == "OPTIONS" {
 				return
 			}
-			// Return silent_mode by default
-			json.NewEncoder(w).Encode(map[string]bool{"silent_mode": timeSvc.IsSilentMode()})
+			if r.Method != http.MethodPost {
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+				return
+			}
+			var body struct {
+				Name      string            `json:"name"`
+				Version   int               `json:"version"`
+				Variables map[string]string `json:"variables"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
+				return
+			}
+			rec, err := timeSvc.GetPromptTemplate(body.Name, body.Version)
+			if err != nil {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+				return
+			}
+			if rec == nil {
+				writeAPIError(w, r, http.StatusNotFound, codeNotFound, "template not found")
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{
+				"name":     rec.Name,
+				"version":  rec.Version,
+				"rendered": agent.RenderPromptTemplate(rec.Content, agent.TemplateVariables(rec, body.Variables)),
+			})
 		})
 
 		// API: Memory Status (GET)
@@ -2238,6 +3726,14 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 					}
 				}
 			}
+			wmStats := workingMemoryStore.Stats()
+			wmInfo := map[string]any{
+				"entries":         wmEntries,
+				"preview":         wmPreview,
+				"bytes":           wmStats.Bytes,
+				"evicted_expired": wmStats.EvictedExpired,
+				"evicted_budget":  wmStats.EvictedBudget,
+			}
 
 			// Observer status
 			observerStatus := map[string]any{
@@ -2261,6 +3757,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				ID       string `json:"id"`
 				Content  string `json:"content"`
 				Priority string `json:"priority"`
+				Privacy  string `json:"privacy"`
 				Date     string `json:"date"`
 			}
 			var recentObs []obsJSON
@@ -2271,6 +3768,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 							ID:       o.ID,
 							Content:  o.Content,
 							Priority: o.Priority,
+							Privacy:  o.Privacy,
 							Date:     o.ObservedAt.Format(time.RFC3339),
 						})
 					}
@@ -2284,108 +3782,420 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				"synced_count": 0,
 				"url":          "",
 			}
-			if er1Client != nil {
-				es := er1Client.Status()
-				er1Status["connected"] = es.Connected
-				er1Status["url"] = es.URL
-				er1Status["synced_count"] = stats.BySource["er1"]
-				if !es.LastSync.IsZero() {
-					er1Status["last_sync"] = es.LastSync
-				}
+			if er1Client != nil {
+				es := er1Client.Status()
+				er1Status["connected"] = es.Connected
+				er1Status["url"] = es.URL
+				er1Status["synced_count"] = stats.BySource["er1"]
+				if !es.LastSync.IsZero() {
+					er1Status["last_sync"] = es.LastSync
+				}
+			}
+
+			// Expertise
+			type expertiseJSON struct {
+				Skill string  `json:"skill"`
+				Score float64 `json:"score"`
+				Trend string  `json:"trend"`
+				Uses  int     `json:"uses"`
+			}
+			var expertise []expertiseJSON
+			if expertiseTracker != nil {
+				if skills, err := expertiseTracker.ListExpertise(); err == nil {
+					for _, s := range skills {
+						expertise = append(expertise, expertiseJSON{
+							Skill: s.SkillName,
+							Score: s.Score,
+							Trend: s.Trend,
+							Uses:  s.SuccessCount + s.FailureCount,
+						})
+					}
+				}
+			}
+
+			// Totals
+			totals := map[string]any{
+				"total_chunks": stats.TotalChunks,
+				"max_chunks":   50000,
+			}
+			if stats.OldestChunk != nil {
+				totals["oldest"] = stats.OldestChunk
+			}
+			if stats.NewestChunk != nil {
+				totals["newest"] = stats.NewestChunk
+			}
+
+			// Config
+			observerEnabled := observer != nil
+			observerThreshold := 50
+			observerMaxObs := 200
+			if observer != nil {
+				observerThreshold = cfg.Observer.MessageThreshold
+				observerMaxObs = cfg.Observer.MaxObservations
+			}
+			er1URL := ""
+			er1SyncIntervalSec := 300
+			if er1Client != nil {
+				es := er1Client.Status()
+				er1URL = es.URL
+				er1SyncIntervalSec = int(cfg.ER1.SyncInterval.Seconds())
+				if er1SyncIntervalSec <= 0 {
+					er1SyncIntervalSec = 300
+				}
+			}
+
+			memConfig := map[string]any{
+				"observer_enabled":      observerEnabled,
+				"observer_threshold":    observerThreshold,
+				"observer_max_obs":      observerMaxObs,
+				"er1_url":               er1URL,
+				"er1_sync_interval_sec": er1SyncIntervalSec,
+				"max_chunks":            50000,
+			}
+
+			json.NewEncoder(w).Encode(map[string]any{
+				"layers":         layers,
+				"working_memory": wmInfo,
+				"observer":       observerStatus,
+				"observations":   recentObs,
+				"er1":            er1Status,
+				"expertise":      expertise,
+				"totals":         totals,
+				"config":         memConfig,
+			})
+		})
+
+		// API: Memory + Knowledge Metrics (GET)
+		mux.HandleFunc("/api/v1/memory/metrics", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == "OPTIONS" {
+				return
+			}
+			if r.Method != http.MethodGet {
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed")
+				return
+			}
+			payload, err := collectMemoryKnowledgeMetrics(timeSvc, autoIndexer)
+			if err != nil {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+				return
+			}
+			json.NewEncoder(w).Encode(payload)
+		})
+
+		// API: Shared knowledge facts with confidence scores (GET)
+		mux.HandleFunc("/api/v1/knowledge/facts", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == "OPTIONS" {
+				return
+			}
+			if r.Method != http.MethodGet {
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed")
+				return
+			}
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			group := strings.TrimSpace(r.URL.Query().Get("group"))
+			facts, err := timeSvc.ListKnowledgeFacts(group, limit, 0)
+			if err != nil {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+				return
+			}
+			scoreKnowledgeFacts(facts)
+			json.NewEncoder(w).Encode(map[string]any{
+				"group": group,
+				"count": len(facts),
+				"facts": facts,
+			})
+		})
+
+		// API: Commitments (GET)
+		mux.HandleFunc("/api/v1/commitments", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == "OPTIONS" {
+				return
+			}
+			if r.Method != http.MethodGet {
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed")
+				return
+			}
+			q := r.URL.Query()
+			limit, _ := strconv.Atoi(q.Get("limit"))
+			status := strings.TrimSpace(q.Get("status"))
+			if status == "" {
+				status = timeline.CommitmentOpen
+			} else if status == "all" {
+				status = ""
+			}
+			recs, err := timeSvc.ListCommitments(status, strings.TrimSpace(q.Get("channel")), strings.TrimSpace(q.Get("chat_id")), limit)
+			if err != nil {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+				return
+			}
+			if recs == nil {
+				recs = []timeline.CommitmentRecord{}
+			}
+			json.NewEncoder(w).Encode(map[string]any{
+				"count":       len(recs),
+				"commitments": recs,
+			})
+		})
+
+		// API: Close a commitment (POST)
+		mux.HandleFunc("/api/v1/commitments/close", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == "OPTIONS" {
+				return
+			}
+			if r.Method != http.MethodPost {
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed")
+				return
+			}
+			var body struct {
+				ID     int64  `json:"id"`
+				Status string `json:"status"`
 			}
-
-			// Expertise
-			type expertiseJSON struct {
-				Skill string  `json:"skill"`
-				Score float64 `json:"score"`
-				Trend string  `json:"trend"`
-				Uses  int     `json:"uses"`
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.ID <= 0 {
+				writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "id required")
+				return
 			}
-			var expertise []expertiseJSON
-			if expertiseTracker != nil {
-				if skills, err := expertiseTracker.ListExpertise(); err == nil {
-					for _, s := range skills {
-						expertise = append(expertise, expertiseJSON{
-							Skill: s.SkillName,
-							Score: s.Score,
-							Trend: s.Trend,
-							Uses:  s.SuccessCount + s.FailureCount,
-						})
-					}
-				}
+			if body.Status == "" {
+				body.Status = timeline.CommitmentDone
 			}
-
-			// Totals
-			totals := map[string]any{
-				"total_chunks": stats.TotalChunks,
-				"max_chunks":   50000,
+			if body.Status != timeline.CommitmentDone && body.Status != timeline.CommitmentCancelled {
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, "status must be done or cancelled")
+				return
 			}
-			if stats.OldestChunk != nil {
-				totals["oldest"] = stats.OldestChunk
+			closed, err := timeSvc.CloseCommitment(body.ID, body.Status)
+			if err != nil {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+				return
 			}
-			if stats.NewestChunk != nil {
-				totals["newest"] = stats.NewestChunk
+			if !closed {
+				writeAPIError(w, r, http.StatusNotFound, codeNotFound, "no open commitment with that id")
+				return
 			}
+			json.NewEncoder(w).Encode(map[string]any{"id": body.ID, "status": body.Status})
+		})
 
-			// Config
-			observerEnabled := observer != nil
-			observerThreshold := 50
-			observerMaxObs := 200
-			if observer != nil {
-				observerThreshold = cfg.Observer.MessageThreshold
-				observerMaxObs = cfg.Observer.MaxObservations
+		// API: Task boards (GET)
+		mux.HandleFunc("/api/v1/boards", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == "OPTIONS" {
+				return
 			}
-			er1URL := ""
-			er1SyncIntervalSec := 300
-			if er1Client != nil {
-				es := er1Client.Status()
-				er1URL = es.URL
-				er1SyncIntervalSec = int(cfg.ER1.SyncInterval.Seconds())
-				if er1SyncIntervalSec <= 0 {
-					er1SyncIntervalSec = 300
-				}
+			if r.Method != http.MethodGet {
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed")
+				return
 			}
-
-			memConfig := map[string]any{
-				"observer_enabled":      observerEnabled,
-				"observer_threshold":    observerThreshold,
-				"observer_max_obs":      observerMaxObs,
-				"er1_url":               er1URL,
-				"er1_sync_interval_sec": er1SyncIntervalSec,
-				"max_chunks":            50000,
+			boards, err := timeSvc.ListBoards(time.Now())
+			if err != nil {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+				return
+			}
+			if boards == nil {
+				boards = []timeline.BoardSummary{}
 			}
-
 			json.NewEncoder(w).Encode(map[string]any{
-				"layers":         layers,
-				"working_memory": map[string]any{"entries": wmEntries, "preview": wmPreview},
-				"observer":       observerStatus,
-				"observations":   recentObs,
-				"er1":            er1Status,
-				"expertise":      expertise,
-				"totals":         totals,
-				"config":         memConfig,
+				"count":  len(boards),
+				"boards": boards,
 			})
 		})
 
-		// API: Memory + Knowledge Metrics (GET)
-		mux.HandleFunc("/api/v1/memory/metrics", func(w http.ResponseWriter, r *http.Request) {
+		// API: Task board items (GET list, POST create)
+		mux.HandleFunc("/api/v1/boards/items", func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Content-Type", "application/json")
+			switch r.Method {
+			case "OPTIONS":
+				return
+			case http.MethodGet:
+				q := r.URL.Query()
+				limit, _ := strconv.Atoi(q.Get("limit"))
+				filter := timeline.BoardItemFilter{
+					Channel:  strings.TrimSpace(q.Get("channel")),
+					ChatID:   strings.TrimSpace(q.Get("chat_id")),
+					Status:   strings.TrimSpace(q.Get("status")),
+					Assignee: strings.TrimSpace(q.Get("assignee")),
+					Limit:    limit,
+				}
+				if filter.Status == "all" {
+					filter.Status = ""
+					filter.IncludeClosed = true
+				}
+				items, err := timeSvc.ListBoardItems(filter)
+				if err != nil {
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+					return
+				}
+				if items == nil {
+					items = []timeline.BoardItemRecord{}
+				}
+				json.NewEncoder(w).Encode(map[string]any{
+					"count": len(items),
+					"items": items,
+				})
+			case http.MethodPost:
+				var body boardItemRequest
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid JSON")
+					return
+				}
+				rec, err := newBoardItem(body)
+				if err != nil {
+					writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, err.Error())
+					return
+				}
+				if err := timeSvc.CreateBoardItem(&rec); err != nil {
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+					return
+				}
+				created, err := timeSvc.GetBoardItem(rec.ID)
+				if err != nil || created == nil {
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, "board item lookup failed")
+					return
+				}
+				w.WriteHeader(http.StatusCreated)
+				json.NewEncoder(w).Encode(created)
+			default:
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed")
+			}
+		})
+
+		// API: Update a task board item (POST)
+		mux.HandleFunc("/api/v1/boards/items/update", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
 			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 			w.Header().Set("Content-Type", "application/json")
 			if r.Method == "OPTIONS" {
 				return
 			}
-			if r.Method != http.MethodGet {
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			if r.Method != http.MethodPost {
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed")
+				return
+			}
+			var body boardItemRequest
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.ID <= 0 {
+				writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "id required")
 				return
 			}
-			payload, err := collectMemoryKnowledgeMetrics(timeSvc)
+			rec, err := timeSvc.GetBoardItem(body.ID)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
-			json.NewEncoder(w).Encode(payload)
+			if rec == nil {
+				writeAPIError(w, r, http.StatusNotFound, codeNotFound, "no board item with that id")
+				return
+			}
+			if err := applyBoardItemRequest(rec, body); err != nil {
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, err.Error())
+				return
+			}
+			if err := timeSvc.UpdateBoardItem(rec); err != nil {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+				return
+			}
+			updated, err := timeSvc.GetBoardItem(rec.ID)
+			if err != nil || updated == nil {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, "board item lookup failed")
+				return
+			}
+			json.NewEncoder(w).Encode(updated)
+		})
+
+		// API: Pinned notes of a chat (GET list, POST pin, DELETE unpin)
+		mux.HandleFunc("/api/v1/pins", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Content-Type", "application/json")
+			switch r.Method {
+			case "OPTIONS":
+				return
+			case http.MethodGet:
+				channel := strings.TrimSpace(r.URL.Query().Get("channel"))
+				chatID := strings.TrimSpace(r.URL.Query().Get("chat_id"))
+				if channel == "" || chatID == "" {
+					writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "channel and chat_id required")
+					return
+				}
+				pins, err := timeSvc.ListChatPins(channel, chatID)
+				if err != nil {
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+					return
+				}
+				if pins == nil {
+					pins = []timeline.ChatPinRecord{}
+				}
+				json.NewEncoder(w).Encode(map[string]any{
+					"count":        len(pins),
+					"budget_chars": timeline.ChatPinBudgetChars,
+					"pins":         pins,
+				})
+			case http.MethodPost:
+				var body struct {
+					Channel string `json:"channel"`
+					ChatID  string `json:"chat_id"`
+					Content string `json:"content"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid JSON")
+					return
+				}
+				rec := timeline.ChatPinRecord{
+					Channel:   strings.TrimSpace(body.Channel),
+					ChatID:    strings.TrimSpace(body.ChatID),
+					Content:   body.Content,
+					CreatedBy: "api",
+				}
+				if rec.Channel == "" || rec.ChatID == "" {
+					writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "channel and chat_id required")
+					return
+				}
+				if err := timeSvc.CreateChatPin(&rec); err != nil {
+					writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, err.Error())
+					return
+				}
+				w.WriteHeader(http.StatusCreated)
+				json.NewEncoder(w).Encode(rec)
+			case http.MethodDelete:
+				q := r.URL.Query()
+				id, _ := strconv.ParseInt(q.Get("id"), 10, 64)
+				if id <= 0 {
+					writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "id required")
+					return
+				}
+				ok, err := timeSvc.DeleteChatPin(strings.TrimSpace(q.Get("channel")), strings.TrimSpace(q.Get("chat_id")), id)
+				if err != nil {
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+					return
+				}
+				if !ok {
+					writeAPIError(w, r, http.StatusNotFound, codeNotFound, "no pin with that id in this chat")
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]any{"status": "removed", "id": id})
+			default:
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed")
+			}
 		})
 
 		// API: Memory Reset (POST)
@@ -2398,7 +4208,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				return
 			}
 			if r.Method != http.MethodPost {
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed")
 				return
 			}
 
@@ -2406,7 +4216,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				Layer string `json:"layer"` // "soul", "conversation", "tool", "group", "er1", "observation", "working_memory", "all"
 			}
 			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-				http.Error(w, "invalid body", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
 				return
 			}
 
@@ -2426,16 +4236,16 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			case "soul", "conversation", "tool", "group", "er1", "observation":
 				deleted, resetErr = lifecycleMgr.DeleteBySource(body.Layer + ":")
 			default:
-				http.Error(w, "invalid layer", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, "invalid layer")
 				return
 			}
 
 			if resetErr != nil {
-				http.Error(w, resetErr.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, resetErr.Error())
 				return
 			}
 
-			fmt.Printf("🧹 Memory reset: layer=%s deleted=%d\n", body.Layer, deleted)
+			slog.InfoContext(r.Context(), "Memory reset", "layer", body.Layer, "deleted", deleted)
 			json.NewEncoder(w).Encode(map[string]any{"status": "ok", "deleted": deleted})
 		})
 
@@ -2449,24 +4259,28 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				return
 			}
 			if r.Method != http.MethodPost {
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed")
 				return
 			}
 
+			by, ok := mutationActor(w, r, cfg.Gateway.AuthToken)
+			if !ok {
+				return
+			}
 			var body map[string]any
 			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-				http.Error(w, "invalid body", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
 				return
 			}
 
 			// Save each config key as a setting
 			for key, value := range body {
 				strVal := fmt.Sprintf("%v", value)
-				if err := timeSvc.SetSetting("memory_"+key, strVal); err != nil {
-					http.Error(w, err.Error(), http.StatusInternalServerError)
+				if err := timeSvc.SetSettingAudited("memory_"+key, strVal, by); err != nil {
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 					return
 				}
-				fmt.Printf("⚙️ Memory config changed: %s = %s\n", key, strVal)
+				slog.InfoContext(r.Context(), "Memory config changed", "key", key, "value", strVal)
 			}
 
 			json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
@@ -2482,20 +4296,76 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				return
 			}
 			if r.Method != http.MethodPost {
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed")
 				return
 			}
 
 			deleted, err := lifecycleMgr.Prune()
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 
-			fmt.Printf("🧹 Memory prune triggered: deleted=%d\n", deleted)
+			slog.InfoContext(r.Context(), "Memory prune triggered", "deleted", deleted)
 			json.NewEncoder(w).Encode(map[string]any{"status": "ok", "deleted": deleted})
 		})
 
+		// API: Memory Search (GET) — chunks with their provenance, so the
+		// dashboard can open the conversation a memory came from.
+		mux.HandleFunc("/api/v1/memory/search", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == "OPTIONS" {
+				return
+			}
+			if r.Method != http.MethodGet {
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed")
+				return
+			}
+			query := strings.TrimSpace(r.URL.Query().Get("q"))
+			if query == "" {
+				writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "q is required")
+				return
+			}
+			limit := 10
+			if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 && v <= 50 {
+				limit = v
+			}
+			if memorySvc == nil {
+				json.NewEncoder(w).Encode(map[string]any{"results": []any{}})
+				return
+			}
+			chunks, err := memorySvc.Search(r.Context(), query, limit)
+			if err != nil && len(chunks) == 0 {
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+				return
+			}
+			type chunkJSON struct {
+				ID      string  `json:"id"`
+				Content string  `json:"content"`
+				Source  string  `json:"source"`
+				Tags    string  `json:"tags"`
+				Privacy string  `json:"privacy"`
+				Score   float32 `json:"score"`
+				memory.Provenance
+			}
+			results := make([]chunkJSON, 0, len(chunks))
+			for _, c := range chunks {
+				results = append(results, chunkJSON{
+					ID:         c.ID,
+					Content:    c.Content,
+					Source:     c.Source,
+					Tags:       c.Tags,
+					Privacy:    c.Privacy,
+					Score:      c.Score,
+					Provenance: c.Provenance,
+				})
+			}
+			json.NewEncoder(w).Encode(map[string]any{"results": results})
+		})
+
 		// API: Embedding Runtime Status (GET)
 		mux.HandleFunc("/api/v1/memory/embedding/status", func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -2506,12 +4376,13 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				return
 			}
 			if r.Method != http.MethodGet {
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed")
 				return
 			}
 
 			health := probeEmbeddingRuntime(cfg)
 			embeddedCount, _ := countEmbeddedMemoryChunks()
+			indexes, _ := memoryIndexes.Indexes(r.Context())
 			pendingInstallAt, _ := timeSvc.GetSetting("memory_embedding_install_requested_at")
 			pendingInstallModel, _ := timeSvc.GetSetting("memory_embedding_install_model")
 
@@ -2535,9 +4406,12 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 					"detail":     health.Detail,
 					"checkedAt":  health.CheckedAt,
 					"httpStatus": health.HTTPStatus,
+					"lifecycle":  embeddingSup.Status(),
 				},
 				"index": map[string]any{
 					"embeddedChunks": embeddedCount,
+					"active":         memoryIndexes.Active(),
+					"indexes":        indexes,
 				},
 				"install": map[string]any{
 					"pending":            strings.TrimSpace(pendingInstallAt) != "",
@@ -2558,7 +4432,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				return
 			}
 			if r.Method != http.MethodGet {
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed")
 				return
 			}
 			health := probeEmbeddingRuntime(cfg)
@@ -2586,7 +4460,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				return
 			}
 			if r.Method != http.MethodPost {
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed")
 				return
 			}
 			var body struct {
@@ -2598,7 +4472,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				model = strings.TrimSpace(cfg.Memory.Embedding.Model)
 			}
 			if model == "" {
-				http.Error(w, "embedding model is required", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "embedding model is required")
 				return
 			}
 			_ = timeSvc.SetSetting("memory_embedding_install_requested_at", time.Now().UTC().Format(time.RFC3339))
@@ -2615,11 +4489,21 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				_ = os.MkdirAll(expanded, 0o755)
 			}
 
-			json.NewEncoder(w).Encode(map[string]any{
+			// A supervised runtime downloads the model now; otherwise the
+			// request stays pending for the externally managed runtime.
+			resp := map[string]any{
 				"status": "ok",
 				"action": "install-requested",
 				"model":  model,
-			})
+			}
+			if embeddingSup != nil {
+				if err := embeddingSup.RequestDownload(model); err != nil {
+					resp["downloadError"] = err.Error()
+				} else {
+					resp["action"] = "download-started"
+				}
+			}
+			json.NewEncoder(w).Encode(resp)
 		})
 
 		// API: Embedding Runtime Reindex (POST)
@@ -2632,7 +4516,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				return
 			}
 			if r.Method != http.MethodPost {
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed")
 				return
 			}
 			var body struct {
@@ -2640,16 +4524,16 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				Reason      string `json:"reason"`
 			}
 			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-				http.Error(w, "invalid body", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
 				return
 			}
 			if !body.ConfirmWipe {
-				http.Error(w, "confirmWipe must be true", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, "confirmWipe must be true")
 				return
 			}
 			wiped, err := wipeAllMemoryChunks()
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 			reason := strings.TrimSpace(body.Reason)
@@ -2691,16 +4575,20 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				workRepoMu.RUnlock()
 				json.NewEncoder(w).Encode(map[string]string{"path": current})
 			case "POST":
+				by, ok := mutationActor(w, r, cfg.Gateway.AuthToken)
+				if !ok {
+					return
+				}
 				var body struct {
 					Path string `json:"path"`
 				}
 				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-					http.Error(w, "invalid body", http.StatusBadRequest)
+					writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
 					return
 				}
 				newPath := strings.TrimSpace(body.Path)
 				if newPath == "" {
-					http.Error(w, "path required", http.StatusBadRequest)
+					writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "path required")
 					return
 				}
 				// If multiple absolute paths got concatenated, keep the last one.
@@ -2720,13 +4608,13 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 					}
 				}
 				if warn, err := config.EnsureWorkRepo(newPath); err != nil {
-					http.Error(w, err.Error(), http.StatusInternalServerError)
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 					return
 				} else if warn != "" {
-					fmt.Printf("Work repo warning: %s\n", warn)
+					slog.WarnContext(r.Context(), "Work repo warning", "warning", warn)
 				}
-				if err := timeSvc.SetSetting("work_repo_path", newPath); err != nil {
-					http.Error(w, err.Error(), http.StatusInternalServerError)
+				if err := timeSvc.SetSettingAudited("work_repo_path", newPath, by); err != nil {
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 					return
 				}
 				workRepoMu.Lock()
@@ -2734,7 +4622,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				workRepoMu.Unlock()
 				json.NewEncoder(w).Encode(map[string]string{"status": "ok", "path": newPath})
 			default:
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
 			}
 		})
 
@@ -2751,7 +4639,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			}
 			items, err := listRepoTree(repoPath, base)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 			json.NewEncoder(w).Encode(items)
@@ -2765,17 +4653,17 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			repo := resolveRepo(r)
 			rel := filepath.Clean(strings.TrimSpace(r.URL.Query().Get("path")))
 			if rel == "" || rel == "." || strings.Contains(rel, "..") {
-				http.Error(w, "path required", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "path required")
 				return
 			}
 			full := filepath.Join(repo, rel)
 			if verified, err := filepath.Rel(repo, full); err != nil || strings.HasPrefix(verified, "..") {
-				http.Error(w, "path outside repo", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, "path outside repo")
 				return
 			}
 			data, err := os.ReadFile(full)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 			if !utf8.Valid(data) {
@@ -2860,7 +4748,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			w.Header().Set("Content-Type", "application/json")
 			out, err := runGit(resolveRepo(r), "branch", "--format=%(refname:short)")
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 			lines := []string{}
@@ -2886,17 +4774,17 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				Branch string `json:"branch"`
 			}
 			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-				http.Error(w, "invalid body", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
 				return
 			}
 			branch := strings.TrimSpace(body.Branch)
 			if branch == "" || strings.HasPrefix(branch, "-") {
-				http.Error(w, "invalid branch name", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, "invalid branch name")
 				return
 			}
 			out, err := runGit(resolveRepo(r), "checkout", branch)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 			json.NewEncoder(w).Encode(map[string]string{"result": out})
@@ -2911,12 +4799,12 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				limit = "20"
 			}
 			if n, err := strconv.Atoi(limit); err != nil || n < 1 || n > 500 {
-				http.Error(w, "limit must be a number between 1 and 500", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, "limit must be a number between 1 and 500")
 				return
 			}
 			out, err := runGit(resolveRepo(r), "log", "--oneline", "-n", limit)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 			lines := []string{}
@@ -2935,12 +4823,12 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			w.Header().Set("Content-Type", "application/json")
 			rel := filepath.Clean(strings.TrimSpace(r.URL.Query().Get("path")))
 			if rel == "" || rel == "." || strings.HasPrefix(rel, "-") {
-				http.Error(w, "invalid path", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, "invalid path")
 				return
 			}
 			out, err := runGit(resolveRepo(r), "diff", "--", rel)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 			json.NewEncoder(w).Encode(map[string]string{"diff": out})
@@ -2957,7 +4845,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			}
 			out, err := runGit(resolveRepo(r), args...)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 			json.NewEncoder(w).Encode(map[string]string{"diff": out})
@@ -2976,22 +4864,22 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				Message string `json:"message"`
 			}
 			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-				http.Error(w, "invalid body", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
 				return
 			}
 			msg := strings.TrimSpace(body.Message)
 			if msg == "" {
-				http.Error(w, "message required", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "message required")
 				return
 			}
 			rp := resolveRepo(r)
 			if _, err := runGit(rp, "add", "-A"); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 			out, err := runGit(rp, "commit", "-m", msg)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 			json.NewEncoder(w).Encode(map[string]string{"result": out})
@@ -3008,7 +4896,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			}
 			out, err := runGit(resolveRepo(r), "pull", "--ff-only")
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 			json.NewEncoder(w).Encode(map[string]string{"result": out})
@@ -3025,7 +4913,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			}
 			out, err := runGit(resolveRepo(r), "push")
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 			json.NewEncoder(w).Encode(map[string]string{"result": out})
@@ -3044,21 +4932,21 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				RemoteURL string `json:"remote_url"`
 			}
 			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-				http.Error(w, "invalid body", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
 				return
 			}
 			repo := resolveRepo(r)
 			if warn, err := config.EnsureWorkRepo(repo); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			} else if warn != "" {
-				fmt.Printf("Work repo warning: %s\n", warn)
+				slog.WarnContext(r.Context(), "Work repo warning", "warning", warn)
 			}
 			remoteURL := strings.TrimSpace(body.RemoteURL)
 			if remoteURL != "" && !strings.HasPrefix(remoteURL, "-") {
 				_, _ = runGit(repo, "remote", "remove", "origin")
 				if _, err := runGit(repo, "remote", "add", "origin", remoteURL); err != nil {
-					http.Error(w, err.Error(), http.StatusInternalServerError)
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 					return
 				}
 			}
@@ -3082,11 +4970,11 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				Draft bool   `json:"draft"`
 			}
 			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-				http.Error(w, "invalid body", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
 				return
 			}
 			if strings.TrimSpace(body.Title) == "" {
-				http.Error(w, "title required", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "title required")
 				return
 			}
 			args := []string{"pr", "create", "--title", body.Title, "--body", body.Body}
@@ -3101,7 +4989,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			}
 			out, err := runGh(resolveRepo(r), args...)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 			json.NewEncoder(w).Encode(map[string]string{"result": out})
@@ -3122,14 +5010,14 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			case "GET":
 				users, err := timeSvc.ListWebUsers()
 				if err != nil {
-					http.Error(w, err.Error(), http.StatusInternalServerError)
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 					return
 				}
 				if users == nil {
 					users = []timeline.WebUser{}
 				}
 				if err := json.NewEncoder(w).Encode(users); err != nil {
-					http.Error(w, err.Error(), http.StatusInternalServerError)
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 					return
 				}
 			case "POST":
@@ -3137,17 +5025,17 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 					Name string `json:"name"`
 				}
 				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-					http.Error(w, "invalid body", http.StatusBadRequest)
+					writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
 					return
 				}
 				user, err := timeSvc.CreateWebUser(strings.TrimSpace(body.Name))
 				if err != nil {
-					http.Error(w, err.Error(), http.StatusBadRequest)
+					writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, err.Error())
 					return
 				}
 				json.NewEncoder(w).Encode(user)
 			default:
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
 			}
 		})
 
@@ -3162,7 +5050,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				return
 			}
 			if r.Method != "POST" {
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
 				return
 			}
 
@@ -3171,15 +5059,15 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				ForceSend bool  `json:"force_send"`
 			}
 			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-				http.Error(w, "invalid body", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
 				return
 			}
 			if body.WebUserID == 0 {
-				http.Error(w, "web_user_id required", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "web_user_id required")
 				return
 			}
 			if err := timeSvc.SetWebUserForceSend(body.WebUserID, body.ForceSend); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 			json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
@@ -3201,12 +5089,12 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				idStr := r.URL.Query().Get("web_user_id")
 				webUserID, err := strconv.ParseInt(idStr, 10, 64)
 				if err != nil {
-					http.Error(w, "invalid web_user_id", http.StatusBadRequest)
+					writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, "invalid web_user_id")
 					return
 				}
 				jid, ok, err := timeSvc.GetWebLink(webUserID)
 				if err != nil {
-					http.Error(w, err.Error(), http.StatusInternalServerError)
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 					return
 				}
 				if !ok {
@@ -3222,28 +5110,28 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 					WhatsAppJID string `json:"whatsapp_jid"`
 				}
 				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-					http.Error(w, "invalid body", http.StatusBadRequest)
+					writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
 					return
 				}
 				if body.WebUserID == 0 {
-					http.Error(w, "web_user_id required", http.StatusBadRequest)
+					writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "web_user_id required")
 					return
 				}
 				if strings.TrimSpace(body.WhatsAppJID) == "" {
 					if err := timeSvc.UnlinkWebUser(body.WebUserID); err != nil {
-						http.Error(w, err.Error(), http.StatusInternalServerError)
+						writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 						return
 					}
 				} else {
 					jid := normalizeWhatsAppJID(strings.TrimSpace(body.WhatsAppJID))
 					if err := timeSvc.LinkWebUser(body.WebUserID, jid); err != nil {
-						http.Error(w, err.Error(), http.StatusInternalServerError)
+						writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 						return
 					}
 				}
 				json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
 			default:
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
 			}
 		})
 
@@ -3258,7 +5146,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				return
 			}
 			if r.Method != "POST" {
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
 				return
 			}
 
@@ -3267,25 +5155,25 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				Message   string `json:"message"`
 			}
 			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-				http.Error(w, "invalid body", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
 				return
 			}
 			if body.WebUserID == 0 || strings.TrimSpace(body.Message) == "" {
-				http.Error(w, "web_user_id and message required", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "web_user_id and message required")
 				return
 			}
 
 			user, err := timeSvc.GetWebUser(body.WebUserID)
 			if err != nil {
-				http.Error(w, "web user not found", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusNotFound, codeNotFound, "web user not found")
 				return
 			}
-			traceID := newTraceID()
+			traceID := requestTraceID(r)
 
 			// Resolve link (optional) and maybe forward the input itself to WhatsApp
 			jid, ok, err := timeSvc.GetWebLink(body.WebUserID)
 			if err != nil {
-				http.Error(w, "link lookup failed", http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, "link lookup failed")
 				return
 			}
 			if ok && jid != "" {
@@ -3294,7 +5182,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				status := "queued"
 
 				if timeSvc.IsSilentMode() && !forceSend {
-					fmt.Printf("🔇 webui input suppressed (silent mode) to %s web_user_id=%d\n", jid, body.WebUserID)
+					slog.InfoContext(r.Context(), "webui input suppressed (silent mode)", "to", jid, "web_user_id", body.WebUserID)
 					status = "suppressed"
 				} else if timeSvc.IsSilentMode() && forceSend {
 					sendCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -3305,7 +5193,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 						TraceID: traceID,
 						Content: body.Message,
 					}); err != nil {
-						fmt.Printf("⚠️ webui input direct send error: %v\n", err)
+						slog.WarnContext(r.Context(), "webui input direct send error", "to", jid, "error", err)
 						status = "error"
 					} else {
 						status = "sent"
@@ -3334,15 +5222,16 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			}
 
 			// Log inbound from Web UI
-			webuiInMeta, _ := json.Marshal(map[string]any{
+			webuiInMeta, _ := json.Marshal(withTraceparent(r, map[string]any{
 				"channel":      "webui",
 				"sender":       user.Name,
 				"message_type": "TEXT",
 				"content":      body.Message,
-			})
+			}))
 			_ = timeSvc.AddEvent(&timeline.TimelineEvent{
 				EventID:        fmt.Sprintf("WEBUI_IN_%d", time.Now().UnixNano()),
 				TraceID:        traceID,
+				ParentSpanID:   requestParentSpanID(r),
 				Timestamp:      time.Now(),
 				SenderID:       fmt.Sprintf("webui:%s", user.Name),
 				SenderName:     user.Name,
@@ -3385,7 +5274,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 
 			tasks, err := timeSvc.ListTasks(status, channel, limit, offset)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 			if tasks == nil {
@@ -3401,16 +5290,31 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 
 			taskID := strings.TrimPrefix(r.URL.Path, "/api/v1/tasks/")
 			taskID = strings.TrimSpace(taskID)
+			if id, ok := strings.CutSuffix(taskID, "/cancel"); ok {
+				cancelTask(w, r, timeSvc, strings.TrimSpace(id), loop.CancelTask)
+				return
+			}
 			if taskID == "" {
-				http.Error(w, "task_id required", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "task_id required")
 				return
 			}
 
 			task, err := timeSvc.GetTask(taskID)
 			if err != nil {
-				http.Error(w, "task not found", http.StatusNotFound)
+				writeAPIError(w, r, http.StatusNotFound, codeNotFound, "task not found")
 				return
 			}
+			// Long-poll: ?wait=<seconds> blocks until the task finishes.
+			if wait, _ := strconv.Atoi(r.URL.Query().Get("wait")); wait > 0 && !taskFinished(task.Status) {
+				timeout := time.Duration(wait) * time.Second
+				if timeout > maxTaskWait {
+					timeout = maxTaskWait
+				}
+				if task, err = waitForTask(r.Context(), timeSvc, taskID, timeout); err != nil {
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+					return
+				}
+			}
 			json.NewEncoder(w).Encode(task)
 		})
 
@@ -3421,7 +5325,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 
 			approvals, err := timeSvc.GetPendingApprovals()
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
 				return
 			}
 			if approvals == nil {
@@ -3436,20 +5340,36 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			w.Header().Set("Content-Type", "application/json")
 
 			if r.Method == "OPTIONS" {
-				w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
 				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 				w.WriteHeader(http.StatusOK)
 				return
 			}
-			if r.Method != "POST" {
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			if r.Method != "GET" && r.Method != "POST" {
+				writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
 				return
 			}
 
 			approvalID := strings.TrimPrefix(r.URL.Path, "/api/v1/approvals/")
 			approvalID = strings.TrimSpace(approvalID)
 			if approvalID == "" || approvalID == "pending" {
-				http.Error(w, "approval_id required", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "approval_id required")
+				return
+			}
+
+			// GET returns the approval record with its expiry, reminders,
+			// delegation and escalation.
+			if r.Method == "GET" {
+				rec, err := timeSvc.GetApproval(approvalID)
+				if err != nil {
+					writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+					return
+				}
+				if rec == nil {
+					writeAPIError(w, r, http.StatusNotFound, codeNotFound, "approval not found")
+					return
+				}
+				json.NewEncoder(w).Encode(rec)
 				return
 			}
 
@@ -3457,7 +5377,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				Approved bool `json:"approved"`
 			}
 			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-				http.Error(w, "invalid JSON body", http.StatusBadRequest)
+				writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid JSON body")
 				return
 			}
 
@@ -3470,7 +5390,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				Channel:   "webui",
 				SenderID:  "webui:admin",
 				ChatID:    "approval",
-				TraceID:   newTraceID(),
+				TraceID:   requestTraceID(r),
 				Content:   fmt.Sprintf("%s:%s", action, approvalID),
 				Timestamp: time.Now(),
 				Metadata: map[string]any{
@@ -3505,12 +5425,31 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			serveDashboardAsset(w, "approvals.html")
 		})
 
+		// SPA: Channel bridges
+		mux.HandleFunc("/bridges", func(w http.ResponseWriter, r *http.Request) {
+			serveDashboardAsset(w, "bridges.html")
+		})
+
 		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 			if r.URL.Path == "/" {
 				serveDashboardAsset(w, "index.html")
 			}
 		})
 
+		// Request metrics and rate limiting for the dashboard API.
+		apiMetrics := newRequestMetrics()
+		apiLimiter := newAPIRateLimiter(cfg.Gateway.RateLimit, cfg.Gateway.AuthToken)
+		endpointOf := muxEndpoint(mux)
+
+		// Prometheus metrics (text exposition format)
+		registerGatewayMetrics(metrics.Default, msgBus, lifecycleMgr, cfg)
+		mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+			metrics.Default.WritePrometheus(w)
+			apiMetrics.WritePrometheus(w)
+			apiLimiter.WritePrometheus(w)
+		})
+
 		if cfg.Gateway.DashboardPort == 0 {
 			cfg.Gateway.DashboardPort = 18791
 		}
@@ -3521,27 +5460,33 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 		if cfg.Gateway.AuthToken != "" {
 			authToken := cfg.Gateway.AuthToken
 			handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				// Skip auth for status endpoint (health check) and CORS preflight
-				if r.URL.Path == "/api/v1/status" || r.Method == "OPTIONS" {
+				// Skip auth for status endpoint (health check), the public status
+				// page and CORS preflight
+				if r.URL.Path == "/api/v1/status" || (publicStatusEnabled && isPublicStatusPath(r.URL.Path)) || r.Method == "OPTIONS" {
 					mux.ServeHTTP(w, r)
 					return
 				}
 				token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
 				if token != authToken {
-					http.Error(w, "unauthorized", http.StatusUnauthorized)
+					writeAPIError(w, r, http.StatusUnauthorized, codeUnauthorized, "unauthorized")
 					return
 				}
 				mux.ServeHTTP(w, r)
 			})
-			fmt.Println("🔒 Auth token required for dashboard API")
+			slog.Info("Auth token required for dashboard API")
 		}
+		handler = apiLimiter.Wrap(handler, endpointOf)
+		handler = apiErrors(handler)
+		handler = apiMetrics.Wrap(handler, endpointOf)
+		handler = traceContext(handler)
 
 		// TLS support
 		if cfg.Gateway.TLSCert != "" && cfg.Gateway.TLSKey != "" {
-			fmt.Printf("🖥️  Dashboard listening on https://%s\n", addr)
+			slog.Info("Dashboard listening", "url", "https://"+addr)
 			cert, err := tls.LoadX509KeyPair(cfg.Gateway.TLSCert, cfg.Gateway.TLSKey)
 			if err != nil {
-				fmt.Printf("❌ TLS cert load failed: %v\n", err)
+				slog.Error("TLS cert load failed", "cert", cfg.Gateway.TLSCert, "error", err)
+				startup.set("dashboard", componentFailed, "TLS cert: "+err.Error(), true)
 				cancel()
 				return
 			}
@@ -3553,13 +5498,15 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 				},
 			}
 			if err := server.ListenAndServeTLS("", ""); err != nil {
-				fmt.Printf("❌ Dashboard Server FAILED to start: %v\n", err)
+				slog.Error("Dashboard server failed to start", "error", err)
+				startup.set("dashboard", componentFailed, err.Error(), true)
 				cancel()
 			}
 		} else {
-			fmt.Printf("🖥️  Dashboard listening on http://%s\n", addr)
+			slog.Info("Dashboard listening", "url", "http://"+addr)
 			if err := http.ListenAndServe(addr, handler); err != nil {
-				fmt.Printf("❌ Dashboard Server FAILED to start: %v\n", err)
+				slog.Error("Dashboard server failed to start", "error", err)
+				startup.set("dashboard", componentFailed, err.Error(), true)
 				cancel()
 			}
 		}
@@ -3568,7 +5515,7 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 	// Start Agent Loop in background
 	go func() {
 		if err := loop.Run(ctx); err != nil {
-			fmt.Printf("Agent loop crashed: %v\n", err)
+			slog.Error("Agent loop crashed", "error", err)
 			cancel()
 		}
 	}()
@@ -3577,9 +5524,10 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 	if orch != nil {
 		go func() {
 			if err := orch.Start(ctx); err != nil {
-				fmt.Printf("⚠️ Orchestrator start failed: %v\n", err)
+				slog.Warn("Orchestrator start failed", "error", err)
 			}
 		}()
+		orch.StartHealthReports(ctx, msgBus)
 	}
 
 	// Start Group Collaboration (if configured)
@@ -3592,28 +5540,43 @@ func runGatewayMain(cmd *cobra.Command, args []string) {
 			joinCtx, joinCancel := context.WithTimeout(ctx, 15*time.Second)
 			defer joinCancel()
 			if err := mgr.Join(joinCtx); err != nil {
-				fmt.Printf("⚠️ Group join failed: %v\n", err)
+				slog.Warn("Group join failed", "error", err)
+				startup.set("group", componentDegraded, "join failed: "+err.Error(), false)
 			} else {
-				fmt.Printf("🤝 Joined group: %s\n", mgr.GroupName())
+				slog.Info("Joined group", "group", mgr.GroupName())
+				startup.set("group", componentOK, mgr.GroupName(), false)
 			}
 		}()
 
 		// Start Kafka consumer if brokers are configured
 		kafkaCancel := startGrpKafka(cfg.Group, mgr, ctx, orchDiscoveryHandler(orch))
 		grpState.SetManager(mgr, kafkaCancel)
+	} else {
+		startup.set("kafka", componentDisabled, "group collaboration not active", false)
 	}
 	startKnowledgeAnnouncements(ctx, cfg, timeSvc)
 
-	fmt.Println("Gateway running. Press Ctrl+C to stop.")
+	startup.print(os.Stdout)
+	if failures := startup.criticalFailures(); cfg.Gateway.StrictStartup && len(failures) > 0 {
+		for _, c := range failures {
+			slog.Error("Strict startup: critical component failed", "component", c.Name, "reason", c.Reason)
+		}
+		slog.Error("Refusing to start (gateway.strictStartup=true)")
+		cancel()
+		os.Exit(1)
+	}
+
+	slog.Info("Gateway running. Press Ctrl+C to stop.")
 	<-sigChan
 
-	fmt.Println("Shutting down...")
+	slog.Info("Shutting down")
 	// Stop orchestrator
 	if orch != nil {
 		stopCtx, stopCancel := context.WithTimeout(context.Background(), 5*time.Second)
 		_ = orch.Stop(stopCtx)
 		stopCancel()
 	}
+	embeddingSup.Stop()
 	// Leave group cleanly
 	if mgr := grpState.Manager(); mgr != nil && mgr.Active() {
 		leaveCtx, leaveCancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -3693,16 +5656,10 @@ func probeEmbeddingRuntime(cfg *config.Config) embeddingRuntimeHealth {
 }
 
 func embeddingCachePresent(cacheDir string) bool {
-	p := strings.TrimSpace(cacheDir)
+	p := expandEmbeddingCacheDir(cacheDir)
 	if p == "" {
 		return false
 	}
-	if strings.HasPrefix(p, "~") {
-		home, err := os.UserHomeDir()
-		if err == nil {
-			p = filepath.Join(home, strings.TrimPrefix(p, "~"))
-		}
-	}
 	if fi, err := os.Stat(p); err == nil && fi.IsDir() {
 		return true
 	}
@@ -3819,6 +5776,12 @@ func inferNodeCapabilities(cfg *config.Config) []string {
 	if cfg.Channels.MSTeams.Enabled {
 		out = append(out, "channel.msteams")
 	}
+	if cfg.Channels.Discord.Enabled {
+		out = append(out, "channel.discord")
+	}
+	if cfg.Channels.Telegram.Enabled {
+		out = append(out, "channel.telegram")
+	}
 	if cfg.Channels.WhatsApp.Enabled {
 		out = append(out, "channel.whatsapp")
 	}
@@ -3838,7 +5801,7 @@ func inferNodeCapabilities(cfg *config.Config) []string {
 	return filtered
 }
 
-func collectMemoryKnowledgeMetrics(timeSvc *timeline.TimelineService) (map[string]any, error) {
+func collectMemoryKnowledgeMetrics(timeSvc *timeline.TimelineService, autoIndexer *memory.AutoIndexer) (map[string]any, error) {
 	if timeSvc == nil {
 		return map[string]any{
 			"status": "ok",
@@ -3849,6 +5812,8 @@ func collectMemoryKnowledgeMetrics(timeSvc *timeline.TimelineService) (map[strin
 	_ = timeSvc.DB().QueryRow(`SELECT COUNT(*) FROM memory_chunks`).Scan(&totalChunks)
 	embeddedChunks, _ := countEmbeddedMemoryChunks()
 	overflowTotal := parseSettingInt(timeSvc, "memory_overflow_events_total")
+	indexStats := autoIndexer.Stats()
+	deduped := indexStats.DedupSkipped + indexStats.DedupMerged
 
 	factAccepted := countTimelineClassifications(timeSvc, "KNOWLEDGE_FACT_ACCEPTED")
 	factStale := countTimelineClassifications(timeSvc, "KNOWLEDGE_FACT_STALE")
@@ -3871,6 +5836,12 @@ func collectMemoryKnowledgeMetrics(timeSvc *timeline.TimelineService) (map[strin
 			"chunksEmbedded":  embeddedChunks,
 			"overflowEvents":  overflowTotal,
 			"overflowPer1000": safeRatio(float64(overflowTotal*1000), float64(maxInt64(1, totalChunks))),
+			"dedup": map[string]any{
+				"indexed": indexStats.Indexed,
+				"skipped": indexStats.DedupSkipped,
+				"merged":  indexStats.DedupMerged,
+				"ratio":   safeRatio(float64(deduped), float64(indexStats.Indexed+deduped)),
+			},
 		},
 		"knowledge": map[string]any{
 			"factsAccepted": factAccepted,
@@ -4111,6 +6082,8 @@ func (gs *groupState) Clear() {
 // groupTraceAdapter adapts group.Manager to the agent.GroupTracePublisher interface.
 type groupTraceAdapter struct {
 	mgr *group.Manager
+	// sampler, if set, drops spans per the group trace sampling config.
+	sampler *group.TraceSampler
 }
 
 func (a *groupTraceAdapter) Active() bool {
@@ -4126,20 +6099,46 @@ func maskSecret(s string) string {
 
 func (a *groupTraceAdapter) PublishTrace(ctx context.Context, payload interface{}) error {
 	// Accept either TracePayload or map[string]string
+	var tp group.TracePayload
 	switch p := payload.(type) {
 	case group.TracePayload:
-		return a.mgr.PublishTrace(ctx, p)
+		tp = p
 	case map[string]string:
-		tp := group.TracePayload{
-			TraceID:  p["trace_id"],
-			SpanType: p["span_type"],
-			Title:    p["title"],
-			Content:  p["content"],
+		durationMs, _ := strconv.Atoi(p["duration_ms"])
+		tp = group.TracePayload{
+			TraceID:    p["trace_id"],
+			SpanType:   p["span_type"],
+			Title:      p["title"],
+			Content:    p["content"],
+			StartedAt:  p["started_at"],
+			EndedAt:    p["ended_at"],
+			DurationMs: durationMs,
+			Error:      p["error"] == "true",
 		}
-		return a.mgr.PublishTrace(ctx, tp)
 	default:
 		return fmt.Errorf("unsupported trace payload type: %T", payload)
 	}
+	if a.sampler == nil {
+		return a.mgr.PublishTrace(ctx, tp)
+	}
+	now := time.Now()
+	var err error
+	if a.sampler.Sample(&tp, now) == group.SpanPublished {
+		err = a.mgr.PublishTrace(ctx, tp)
+	}
+	a.reportSampling(ctx, now)
+	return err
+}
+
+// reportSampling publishes the sampler's dropped-span counts to the group
+// audit topic so dashboards know the traces topic holds partial data.
+func (a *groupTraceAdapter) reportSampling(ctx context.Context, now time.Time) {
+	report, ok := a.sampler.Report(now)
+	if !ok {
+		return
+	}
+	detail, _ := json.Marshal(report)
+	_ = a.mgr.PublishAudit(ctx, "trace_sampling", "", string(detail))
 }
 
 func (a *groupTraceAdapter) PublishAudit(ctx context.Context, eventType, traceID, detail string) error {
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// boardItemRequest is the body of POST /api/v1/boards/items and
+// /api/v1/boards/items/update. On update, fields left out stay unchanged and
+// an empty due_at clears the due date.
+type boardItemRequest struct {
+	ID       int64   `json:"id"`
+	Channel  string  `json:"channel"`
+	ChatID   string  `json:"chat_id"`
+	Title    *string `json:"title"`
+	Notes    *string `json:"notes"`
+	Status   *string `json:"status"`
+	Assignee *string `json:"assignee"`
+	DueAt    *string `json:"due_at"`
+}
+
+// newBoardItem builds the item created by a POST /api/v1/boards/items body.
+func newBoardItem(req boardItemRequest) (timeline.BoardItemRecord, error) {
+	rec := timeline.BoardItemRecord{
+		Channel:   strings.TrimSpace(req.Channel),
+		ChatID:    strings.TrimSpace(req.ChatID),
+		Status:    timeline.BoardItemOpen,
+		CreatedBy: "api",
+	}
+	if rec.Channel == "" || rec.ChatID == "" {
+		return rec, fmt.Errorf("channel and chat_id required")
+	}
+	if req.Title == nil {
+		return rec, fmt.Errorf("title required")
+	}
+	return rec, applyBoardItemRequest(&rec, req)
+}
+
+// applyBoardItemRequest copies the fields set in req onto rec.
+func applyBoardItemRequest(rec *timeline.BoardItemRecord, req boardItemRequest) error {
+	if req.Title != nil {
+		rec.Title = strings.TrimSpace(*req.Title)
+		if rec.Title == "" {
+			return fmt.Errorf("title must not be empty")
+		}
+	}
+	if req.Notes != nil {
+		rec.Notes = strings.TrimSpace(*req.Notes)
+	}
+	if req.Status != nil {
+		status := strings.TrimSpace(*req.Status)
+		if !timeline.ValidBoardItemStatus(status) {
+			return fmt.Errorf("status must be open, in_progress, done or cancelled")
+		}
+		rec.Status = status
+	}
+	if req.Assignee != nil {
+		rec.Assignee = strings.TrimSpace(*req.Assignee)
+	}
+	if req.DueAt != nil {
+		rec.DueAt = nil
+		if raw := strings.TrimSpace(*req.DueAt); raw != "" {
+			due, err := parseBoardDue(raw)
+			if err != nil {
+				return err
+			}
+			rec.DueAt = &due
+		}
+	}
+	return nil
+}
+
+// parseBoardDue accepts an RFC3339 timestamp or a YYYY-MM-DD date (UTC midnight).
+func parseBoardDue(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	if d, err := time.Parse("2006-01-02", raw); err == nil {
+		return d, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid due_at %q: use RFC3339 or YYYY-MM-DD", raw)
+}
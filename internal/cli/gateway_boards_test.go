@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestBoardItemRequests(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	rec, err := newBoardItem(boardItemRequest{Channel: "slack", ChatID: "C1", Title: str(" rotate keys "), Assignee: str("alice"), DueAt: str("2026-03-06")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Title != "rotate keys" || rec.Status != timeline.BoardItemOpen || rec.CreatedBy != "api" ||
+		rec.DueAt == nil || !rec.DueAt.Equal(time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected item: %+v", rec)
+	}
+
+	if err := applyBoardItemRequest(&rec, boardItemRequest{Status: str("done"), DueAt: str("")}); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Status != timeline.BoardItemDone || rec.DueAt != nil || rec.Title != "rotate keys" || rec.Assignee != "alice" {
+		t.Fatalf("unexpected updated item: %+v", rec)
+	}
+
+	for name, req := range map[string]boardItemRequest{
+		"missing chat":  {Channel: "slack", Title: str("x")},
+		"missing title": {Channel: "slack", ChatID: "C1"},
+		"empty title":   {Channel: "slack", ChatID: "C1", Title: str(" ")},
+		"bad status":    {Channel: "slack", ChatID: "C1", Title: str("x"), Status: str("blocked")},
+		"bad due":       {Channel: "slack", ChatID: "C1", Title: str("x"), DueAt: str("friday")},
+	} {
+		if _, err := newBoardItem(req); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
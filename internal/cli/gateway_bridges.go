@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+// bridgeStatusTimeout bounds one bridge /status probe so a dead bridge never
+// stalls the aggregated response.
+const bridgeStatusTimeout = 3 * time.Second
+
+// bridgeStatus is one channelbridge instance as seen from the gateway.
+type bridgeStatus struct {
+	Name      string         `json:"name"`
+	URL       string         `json:"url"`
+	Health    string         `json:"health"` // ok, degraded, down
+	LatencyMS int64          `json:"latency_ms"`
+	Error     string         `json:"error,omitempty"`
+	Metrics   map[string]any `json:"metrics,omitempty"`
+	Teams     map[string]any `json:"teams,omitempty"`
+	Dedupe    int            `json:"inbound_dedupe_cache"`
+	LastError string         `json:"last_error,omitempty"`
+	LastErrAt string         `json:"last_error_at,omitempty"`
+}
+
+// bridgeTargets lists the channelbridge instances to poll. Explicit
+// channels.bridges entries win; otherwise the bridge base URLs are derived
+// from the Slack, Teams, Discord and Telegram outbound URLs (and the Slack
+// and Teams accounts), deduplicated.
+func bridgeTargets(cfg *config.Config) []config.ChannelBridgeConfig {
+	if len(cfg.Channels.Bridges) > 0 {
+		out := make([]config.ChannelBridgeConfig, 0, len(cfg.Channels.Bridges))
+		for i, b := range cfg.Channels.Bridges {
+			u := strings.TrimRight(strings.TrimSpace(b.URL), "/")
+			if u == "" {
+				continue
+			}
+			name := strings.TrimSpace(b.Name)
+			if name == "" {
+				name = fmt.Sprintf("bridge-%d", i+1)
+			}
+			out = append(out, config.ChannelBridgeConfig{Name: name, URL: u})
+		}
+		return out
+	}
+
+	outbound := []string{cfg.Channels.Slack.OutboundURL}
+	for _, acc := range cfg.Channels.Slack.Accounts {
+		outbound = append(outbound, acc.OutboundURL)
+	}
+	outbound = append(outbound, cfg.Channels.MSTeams.OutboundURL)
+	for _, acc := range cfg.Channels.MSTeams.Accounts {
+		outbound = append(outbound, acc.OutboundURL)
+	}
+	outbound = append(outbound, cfg.Channels.Discord.OutboundURL, cfg.Channels.Telegram.OutboundURL)
+
+	seen := map[string]bool{}
+	var out []config.ChannelBridgeConfig
+	for _, raw := range outbound {
+		base := bridgeBaseURL(raw)
+		if base == "" || seen[base] {
+			continue
+		}
+		seen[base] = true
+		out = append(out, config.ChannelBridgeConfig{Name: fmt.Sprintf("bridge-%d", len(out)+1), URL: base})
+	}
+	return out
+}
+
+// bridgeBaseURL reduces an outbound URL such as
+// http://127.0.0.1:18888/slack/outbound to its scheme and host.
+func bridgeBaseURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// fetchBridgeStatus polls one bridge's /status. A bridge that answers but has
+// recorded forward or outbound errors is degraded; one that does not answer
+// is down.
+func fetchBridgeStatus(ctx context.Context, client *http.Client, target config.ChannelBridgeConfig) bridgeStatus {
+	st := bridgeStatus{Name: target.Name, URL: target.URL, Health: "down"}
+	ctx, cancel := context.WithTimeout(ctx, bridgeStatusTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.URL+"/status", nil)
+	if err != nil {
+		st.Error = err.Error()
+		return st
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	st.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		st.Error = err.Error()
+		return st
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		st.Error = fmt.Sprintf("status %d", resp.StatusCode)
+		return st
+	}
+
+	var body struct {
+		OK      bool           `json:"ok"`
+		Metrics map[string]any `json:"metrics"`
+		Teams   map[string]any `json:"teams"`
+		Dedupe  int            `json:"inbound_dedupe_cache"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		st.Error = "invalid status payload: " + err.Error()
+		return st
+	}
+	st.Metrics = body.Metrics
+	st.Teams = body.Teams
+	st.Dedupe = body.Dedupe
+	st.LastError, _ = body.Metrics["last_error"].(string)
+	st.LastErrAt, _ = body.Metrics["last_error_at"].(string)
+
+	errCount := 0.0
+	for _, key := range []string{"inbound_forward_errors", "outbound_errors"} {
+		if n, ok := body.Metrics[key].(float64); ok {
+			errCount += n
+		}
+	}
+	switch {
+	case !body.OK:
+		st.Health = "degraded"
+		st.Error = "bridge reported not ok"
+	case errCount > 0:
+		st.Health = "degraded"
+	default:
+		st.Health = "ok"
+	}
+	return st
+}
+
+// aggregateBridgeStatus polls all targets concurrently and returns them in
+// target order with the worst health as the overall status.
+func aggregateBridgeStatus(ctx context.Context, client *http.Client, targets []config.ChannelBridgeConfig) (string, []bridgeStatus) {
+	out := make([]bridgeStatus, len(targets))
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		wg.Add(1)
+		go func(i int, t config.ChannelBridgeConfig) {
+			defer wg.Done()
+			out[i] = fetchBridgeStatus(ctx, client, t)
+		}(i, t)
+	}
+	wg.Wait()
+
+	overall := "ok"
+	if len(out) == 0 {
+		overall = "unconfigured"
+	}
+	for _, st := range out {
+		switch st.Health {
+		case "down":
+			overall = "down"
+		case "degraded":
+			if overall != "down" {
+				overall = "degraded"
+			}
+		}
+	}
+	return overall, out
+}
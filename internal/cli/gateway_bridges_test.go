@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+func TestBridgeTargetsDerivedFromOutboundURLs(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Channels.Slack.OutboundURL = "http://127.0.0.1:18888/slack/outbound"
+	cfg.Channels.MSTeams.OutboundURL = "http://127.0.0.1:18888/teams/outbound"
+	cfg.Channels.MSTeams.Accounts = []config.MSTeamsAccountConfig{{OutboundURL: "http://10.0.0.2:18888/teams/outbound"}}
+
+	got := bridgeTargets(cfg)
+	if len(got) != 2 || got[0].URL != "http://127.0.0.1:18888" || got[1].URL != "http://10.0.0.2:18888" {
+		t.Fatalf("unexpected derived targets: %+v", got)
+	}
+
+	cfg.Channels.Bridges = []config.ChannelBridgeConfig{{Name: "edge", URL: "http://bridge.internal:18888/"}, {URL: " "}}
+	got = bridgeTargets(cfg)
+	if len(got) != 1 || got[0].Name != "edge" || got[0].URL != "http://bridge.internal:18888" {
+		t.Fatalf("explicit bridges must win: %+v", got)
+	}
+}
+
+func TestAggregateBridgeStatus(t *testing.T) {
+	serve := func(metrics map[string]any) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/status" {
+				http.NotFound(w, r)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "metrics": metrics, "inbound_dedupe_cache": 3})
+		}))
+	}
+	healthy := serve(map[string]any{"slack_outbound_sent": 4})
+	defer healthy.Close()
+	failing := serve(map[string]any{"outbound_errors": 2, "last_error": "teams 502", "last_error_at": "2026-01-01T00:00:00Z"})
+	defer failing.Close()
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+
+	targets := []config.ChannelBridgeConfig{{Name: "a", URL: healthy.URL}, {Name: "b", URL: failing.URL}}
+	overall, got := aggregateBridgeStatus(context.Background(), http.DefaultClient, targets)
+	if overall != "degraded" || got[0].Health != "ok" || got[0].Dedupe != 3 {
+		t.Fatalf("unexpected aggregate: %s %+v", overall, got)
+	}
+	if got[1].Health != "degraded" || got[1].LastError != "teams 502" || got[1].LastErrAt == "" {
+		t.Fatalf("failing bridge must surface its last error: %+v", got[1])
+	}
+
+	targets = append(targets, config.ChannelBridgeConfig{Name: "c", URL: dead.URL})
+	overall, got = aggregateBridgeStatus(context.Background(), http.DefaultClient, targets)
+	if overall != "down" || got[2].Health != "down" || got[2].Error == "" {
+		t.Fatalf("unreachable bridge must be down: %s %+v", overall, got[2])
+	}
+
+	if overall, _ := aggregateBridgeStatus(context.Background(), http.DefaultClient, nil); overall != "unconfigured" {
+		t.Fatalf("expected unconfigured, got %s", overall)
+	}
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/scheduler"
+	"github.com/KafClaw/KafClaw/internal/skills"
+)
+
+// calendarToken returns the OAuth token source of a google or m365 calendar
+// source, using the profile enrolled via `kafclaw skills auth`.
+func calendarToken(cc config.CalendarSourceConfig) scheduler.TokenFunc {
+	provider := skills.ProviderGoogleWorkspace
+	if strings.EqualFold(strings.TrimSpace(cc.Kind), "m365") {
+		provider = skills.ProviderM365
+	}
+	profile := strings.TrimSpace(cc.Profile)
+	if profile == "" {
+		profile = "default"
+	}
+	return func() (string, error) {
+		tok, err := skills.GetOAuthAccessToken(provider, profile)
+		if err != nil {
+			return "", fmt.Errorf("%s profile %s: %w", provider, profile, err)
+		}
+		return tok.AccessToken, nil
+	}
+}
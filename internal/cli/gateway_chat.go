@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/agent"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// localChatStreamPing is how often /chat/stream sends an SSE comment so
+// proxies keep an idle stream open during long tool calls.
+const localChatStreamPing = 15 * time.Second
+
+// localChatFunc runs one local chat message, as agent.Loop.ProcessDirectWithTrace.
+type localChatFunc func(ctx context.Context, msg, session, traceID string) (string, error)
+
+// localChatRequest checks auth and method of a local /chat request and
+// returns its message and session. It answers the request itself and
+// returns ok=false when the request is rejected.
+func localChatRequest(w http.ResponseWriter, r *http.Request, authToken string) (msg, session string, ok bool) {
+	if !localChatAuthorized(r, authToken) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return "", "", false
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return "", "", false
+	}
+	msg = r.URL.Query().Get("message")
+	if msg == "" {
+		http.Error(w, "Missing message parameter", http.StatusBadRequest)
+		return "", "", false
+	}
+	session = r.URL.Query().Get("session")
+	if session == "" {
+		session = "local:default"
+	}
+	return msg, session, true
+}
+
+// localChatAuthorized reports whether r carries the gateway bearer token,
+// if one is configured.
+func localChatAuthorized(r *http.Request, authToken string) bool {
+	if authToken == "" {
+		return true
+	}
+	return strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")) == authToken
+}
+
+// logLocalChatInbound records a local chat message on the timeline.
+func logLocalChatInbound(timeSvc *timeline.TimelineService, r *http.Request, msg, session, traceID string) {
+	slog.InfoContext(r.Context(), "Local network request", "session", session, "trace_id", traceID, "message", msg)
+	inMeta, _ := json.Marshal(withTraceparent(r, map[string]any{
+		"channel":      "local",
+		"sender":       session,
+		"message_type": "TEXT",
+		"content":      msg,
+	}))
+	_ = timeSvc.AddEvent(&timeline.TimelineEvent{
+		EventID:        fmt.Sprintf("LOCAL_IN_%d", time.Now().UnixNano()),
+		TraceID:        traceID,
+		ParentSpanID:   requestParentSpanID(r),
+		Timestamp:      time.Now(),
+		SenderID:       session,
+		SenderName:     "Local",
+		EventType:      "TEXT",
+		ContentText:    msg,
+		Classification: "LOCAL_INBOUND",
+		Authorized:     true,
+		Metadata:       string(inMeta),
+	})
+}
+
+// logLocalChatOutbound records the response to a local chat message, or
+// the error that replaced it, on the timeline.
+func logLocalChatOutbound(timeSvc *timeline.TimelineService, traceID, session, resp string, runErr error) {
+	status, text := "sent", resp
+	if runErr != nil {
+		status, text = "error", runErr.Error()
+	}
+	outMeta, _ := json.Marshal(map[string]any{
+		"response_text":   text,
+		"delivery_status": status,
+	})
+	_ = timeSvc.AddEvent(&timeline.TimelineEvent{
+		EventID:        fmt.Sprintf("LOCAL_OUT_%d", time.Now().UnixNano()),
+		TraceID:        traceID,
+		Timestamp:      time.Now(),
+		SenderID:       "AGENT",
+		SenderName:     "Agent",
+		EventType:      "SYSTEM",
+		ContentText:    text,
+		Classification: "LOCAL_OUTBOUND status=" + status,
+		Authorized:     true,
+		Metadata:       string(outMeta),
+	})
+	slog.Info("Local outbound", "status", status, "session", session, "trace_id", traceID)
+}
+
+// localChatStreamHandler serves /chat/stream: /chat as Server-Sent Events.
+// It sends "start", then the agent's "llm", "tool_call" and "tool_result"
+// steps as they happen, and ends with "done" (the response) or "error".
+// A client that disconnects does not cancel the run.
+func localChatStreamHandler(ctx context.Context, authToken string, timeSvc *timeline.TimelineService, run localChatFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		msg, session, ok := localChatRequest(w, r, authToken)
+		if !ok {
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+		traceID := requestTraceID(r)
+		logLocalChatInbound(timeSvc, r, msg, session, traceID)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		writeSSE(w, "start", map[string]any{"trace_id": traceID, "session": session})
+		flusher.Flush()
+
+		type result struct {
+			resp string
+			err  error
+		}
+		events := make(chan agent.StreamEvent, 64)
+		done := make(chan result, 1)
+		runCtx := agent.WithStreamSink(ctx, func(ev agent.StreamEvent) {
+			select {
+			case events <- ev:
+			case <-r.Context().Done():
+			}
+		})
+		go func() {
+			resp, err := run(runCtx, msg, session, traceID)
+			done <- result{resp, err}
+		}()
+
+		ping := time.NewTicker(localChatStreamPing)
+		defer ping.Stop()
+		for {
+			select {
+			case ev := <-events:
+				writeSSE(w, ev.Type, ev)
+				flusher.Flush()
+			case <-ping.C:
+				fmt.Fprint(w, ": ping\n\n")
+				flusher.Flush()
+			case res := <-done:
+				// The sink runs before the run returns, so every step is
+				// already buffered.
+				for drained := false; !drained; {
+					select {
+					case ev := <-events:
+						writeSSE(w, ev.Type, ev)
+					default:
+						drained = true
+					}
+				}
+				logLocalChatOutbound(timeSvc, traceID, session, res.resp, res.err)
+				if res.err != nil {
+					writeSSE(w, "error", map[string]any{"error": res.err.Error()})
+				} else {
+					writeSSE(w, "done", map[string]any{"content": res.resp})
+				}
+				flusher.Flush()
+				return
+			case <-r.Context().Done():
+				go func() {
+					res := <-done
+					logLocalChatOutbound(timeSvc, traceID, session, res.resp, res.err)
+				}()
+				return
+			}
+		}
+	}
+}
+
+// writeSSE writes one Server-Sent Event with a JSON payload.
+func writeSSE(w http.ResponseWriter, event string, payload any) {
+	data, _ := json.Marshal(payload)
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+}
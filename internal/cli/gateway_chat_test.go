@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/agent"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+type sseEvent struct {
+	name string
+	data map[string]any
+}
+
+func readSSE(t *testing.T, body string) []sseEvent {
+	t.Helper()
+	var (
+		out []sseEvent
+		cur sseEvent
+	)
+	sc := bufio.NewScanner(strings.NewReader(body))
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			cur.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &cur.data); err != nil {
+				t.Fatalf("invalid data line %q: %v", line, err)
+			}
+		case line == "" && cur.name != "":
+			out = append(out, cur)
+			cur = sseEvent{}
+		}
+	}
+	return out
+}
+
+func TestLocalChatStreamSendsStepsAndResponse(t *testing.T) {
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tl.Close()
+
+	var gotSession string
+	run := func(ctx context.Context, msg, session, traceID string) (string, error) {
+		gotSession = session
+		agent.EmitStreamEvent(ctx, agent.StreamEvent{Type: agent.StreamEventToolCall, Iteration: 1, Tool: "read_file"})
+		agent.EmitStreamEvent(ctx, agent.StreamEvent{Type: agent.StreamEventToolResult, Iteration: 1, Tool: "read_file", Result: "ok"})
+		if msg == "fail" {
+			return "", errors.New("provider down")
+		}
+		return "all done", nil
+	}
+	h := localChatStreamHandler(context.Background(), "secret", tl, run)
+
+	w := httptest.NewRecorder()
+	h(w, httptest.NewRequest(http.MethodPost, "/chat/stream?message=hi", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/chat/stream?message=hi&session=local:ui", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	h(w, req)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected response %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	events := readSSE(t, w.Body.String())
+	var names []string
+	for _, ev := range events {
+		names = append(names, ev.name)
+	}
+	if strings.Join(names, ",") != "start,tool_call,tool_result,done" {
+		t.Fatalf("unexpected event sequence %v", names)
+	}
+	if events[0].data["session"] != "local:ui" || gotSession != "local:ui" {
+		t.Fatalf("unexpected session: start=%v run=%q", events[0].data, gotSession)
+	}
+	if events[2].data["tool"] != "read_file" || events[2].data["result"] != "ok" {
+		t.Fatalf("unexpected tool_result: %v", events[2].data)
+	}
+	if events[3].data["content"] != "all done" {
+		t.Fatalf("unexpected done: %v", events[3].data)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/chat/stream?message=fail", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	h(w, req)
+	events = readSSE(t, w.Body.String())
+	if last := events[len(events)-1]; last.name != "error" || last.data["error"] != "provider down" {
+		t.Fatalf("expected error event, got %+v", last)
+	}
+}
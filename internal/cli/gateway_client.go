@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+// gatewayClient calls the dashboard API of the local gateway with the
+// configured auth token, so CLI commands work on headless servers.
+type gatewayClient struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// newGatewayClient targets override when set, else the configured gateway
+// host and dashboard port. Wildcard hosts are reached via loopback.
+func newGatewayClient(cfg *config.Config, override string) *gatewayClient {
+	base := strings.TrimRight(strings.TrimSpace(override), "/")
+	if base == "" {
+		host := strings.TrimSpace(cfg.Gateway.Host)
+		if host == "" || host == "0.0.0.0" || host == "::" {
+			host = "127.0.0.1"
+		}
+		port := cfg.Gateway.DashboardPort
+		if port == 0 {
+			port = config.DefaultConfig().Gateway.DashboardPort
+		}
+		scheme := "http"
+		if cfg.Gateway.TLSCert != "" && cfg.Gateway.TLSKey != "" {
+			scheme = "https"
+		}
+		base = fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(host, fmt.Sprint(port)))
+	}
+	return &gatewayClient{
+		baseURL: base,
+		token:   strings.TrimSpace(cfg.Gateway.AuthToken),
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// do sends body as JSON (if non-nil) and decodes the response into out (if
+// non-nil). Non-2xx responses become errors carrying the response text.
+func (c *gatewayClient) do(method, path string, query url.Values, body, out any) error {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, target, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg := strings.TrimSpace(string(data))
+		var envelope apiError
+		if json.Unmarshal(data, &envelope) == nil && envelope.Code != "" {
+			msg = envelope.Code + ": " + envelope.Message
+		}
+		if msg == "" {
+			msg = resp.Status
+		}
+		return fmt.Errorf("gateway %s %s: %s", method, path, msg)
+	}
+	if out == nil || len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// isGatewayUnreachable reports whether err means no gateway is listening.
+func isGatewayUnreachable(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
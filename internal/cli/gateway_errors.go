@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// API error codes. Every /api/ error response carries one of them, so clients
+// can branch on the code instead of parsing the message.
+const (
+	codeInvalidRequest   = "invalid_request"    // 400: a parameter or field has an invalid value
+	codeInvalidJSON      = "invalid_json"       // 400: the request body is not valid JSON
+	codeMissingParameter = "missing_parameter"  // 400: a required parameter or field is missing
+	codeUnauthorized     = "unauthorized"       // 401: missing or wrong auth token
+	codeForbidden        = "forbidden"          // 403: not allowed in the current mode
+	codeNotFound         = "not_found"          // 404: the resource or route does not exist
+	codeMethodNotAllowed = "method_not_allowed" // 405: the route does not support the method
+	codeConflict         = "conflict"           // 409: the request conflicts with the current state
+	codeNotConfigured    = "not_configured"     // 409: the feature is disabled or not set up
+	codeTooLarge         = "payload_too_large"  // 413: the request body is too large
+	codeRateLimited      = "rate_limited"       // 429: the client is over its request budget
+	codeInternal         = "internal_error"     // 500: the gateway failed to handle the request
+	codeUpstream         = "upstream_error"     // 502: a backend the gateway called failed
+	codeUnavailable      = "unavailable"        // 503: the gateway is not ready
+)
+
+// apiError is the error envelope of the dashboard API.
+type apiError struct {
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Details map[string]any `json:"details,omitempty"`
+	TraceID string         `json:"trace_id"`
+}
+
+// defaultErrorCode returns the catalogue code of a status without a more
+// specific code.
+func defaultErrorCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return codeInvalidRequest
+	case http.StatusUnauthorized:
+		return codeUnauthorized
+	case http.StatusForbidden:
+		return codeForbidden
+	case http.StatusNotFound:
+		return codeNotFound
+	case http.StatusMethodNotAllowed:
+		return codeMethodNotAllowed
+	case http.StatusConflict:
+		return codeConflict
+	case http.StatusRequestEntityTooLarge:
+		return codeTooLarge
+	case http.StatusTooManyRequests:
+		return codeRateLimited
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return codeUpstream
+	case http.StatusServiceUnavailable:
+		return codeUnavailable
+	}
+	if status >= 500 {
+		return codeInternal
+	}
+	return codeInvalidRequest
+}
+
+// writeAPIError writes the error envelope with status and code.
+func writeAPIError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	writeAPIErrorDetails(w, r, status, code, message, nil)
+}
+
+// writeAPIErrorDetails is writeAPIError with structured details, e.g. the
+// offending field or the validation problems.
+func writeAPIErrorDetails(w http.ResponseWriter, r *http.Request, status int, code, message string, details map[string]any) {
+	if code == "" {
+		code = defaultErrorCode(status)
+	}
+	body := apiError{Code: code, Message: message, Details: details, TraceID: requestTraceID(r)}
+	if status >= 500 {
+		slog.Warn("Dashboard API error", "path", r.URL.Path, "status", status, "code", code, "message", message, "trace_id", body.TraceID)
+	}
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Type", "application/json")
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// apiErrors turns plain-text error responses on /api/ routes into the error
+// envelope: those of net/http itself (unknown routes) and any handler still
+// using http.Error.
+func apiErrors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ew := &errorEnvelopeWriter{ResponseWriter: w}
+		next.ServeHTTP(ew, r)
+		if ew.capturing {
+			writeAPIError(w, r, ew.status, "", strings.TrimSpace(ew.body.String()))
+		}
+	})
+}
+
+// errorEnvelopeWriter captures plain-text error responses.
+type errorEnvelopeWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+	capturing   bool
+	status      int
+	body        bytes.Buffer
+}
+
+func (e *errorEnvelopeWriter) WriteHeader(code int) {
+	if e.wroteHeader {
+		return
+	}
+	e.wroteHeader = true
+	if code >= 400 && strings.HasPrefix(e.Header().Get("Content-Type"), "text/plain") {
+		e.capturing, e.status = true, code
+		return
+	}
+	e.ResponseWriter.WriteHeader(code)
+}
+
+func (e *errorEnvelopeWriter) Write(b []byte) (int, error) {
+	if !e.wroteHeader {
+		e.WriteHeader(http.StatusOK)
+	}
+	if e.capturing {
+		return e.body.Write(b)
+	}
+	return e.ResponseWriter.Write(b)
+}
+
+// Flush keeps streaming handlers working behind the writer.
+func (e *errorEnvelopeWriter) Flush() {
+	if e.capturing {
+		return
+	}
+	if f, ok := e.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/tracing"
+)
+
+func decodeAPIError(t *testing.T, rec *httptest.ResponseRecorder) apiError {
+	t.Helper()
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("expected JSON error, got content type %q body %q", ct, rec.Body.String())
+	}
+	var body apiError
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode envelope: %v (%q)", err, rec.Body.String())
+	}
+	return body
+}
+
+func TestWriteAPIError(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/settings/bundle", nil)
+	req = req.WithContext(tracing.WithTraceID(req.Context(), "trace-abc"))
+	rec := httptest.NewRecorder()
+	writeAPIErrorDetails(rec, req, http.StatusBadRequest, codeInvalidRequest, "bundle validation failed", map[string]any{"problems": []string{"bad version"}})
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+	body := decodeAPIError(t, rec)
+	if body.Code != codeInvalidRequest || body.Message != "bundle validation failed" || body.TraceID != "trace-abc" {
+		t.Fatalf("unexpected envelope: %+v", body)
+	}
+	if problems, _ := body.Details["problems"].([]any); len(problems) != 1 {
+		t.Fatalf("expected details to carry the problems, got %+v", body.Details)
+	}
+
+	// Without a code the status picks one; every error has a trace ID.
+	rec = httptest.NewRecorder()
+	writeAPIError(rec, httptest.NewRequest(http.MethodGet, "/api/v1/x", nil), http.StatusBadGateway, "", "provider down")
+	if body := decodeAPIError(t, rec); body.Code != codeUpstream || body.TraceID == "" {
+		t.Fatalf("unexpected envelope: %+v", body)
+	}
+}
+
+func TestDefaultErrorCode(t *testing.T) {
+	cases := map[int]string{
+		http.StatusBadRequest:          codeInvalidRequest,
+		http.StatusUnauthorized:        codeUnauthorized,
+		http.StatusNotFound:            codeNotFound,
+		http.StatusMethodNotAllowed:    codeMethodNotAllowed,
+		http.StatusTooManyRequests:     codeRateLimited,
+		http.StatusInternalServerError: codeInternal,
+		http.StatusNotImplemented:      codeInternal,
+		http.StatusServiceUnavailable:  codeUnavailable,
+		http.StatusTeapot:              codeInvalidRequest,
+	}
+	for status, want := range cases {
+		if got := defaultErrorCode(status); got != want {
+			t.Errorf("status %d: expected %s, got %s", status, want, got)
+		}
+	}
+}
+
+func TestAPIErrorsMiddleware(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/legacy", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "something broke", http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/api/v1/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	})
+	mux.HandleFunc("/api/v1/conflict", func(w http.ResponseWriter, r *http.Request) {
+		writeAPIError(w, r, http.StatusConflict, codeNotConfigured, "orchestrator not enabled")
+	})
+	mux.HandleFunc("/chat", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad form", http.StatusBadRequest)
+	})
+	handler := apiErrors(mux)
+	do := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := do("/api/v1/legacy")
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rec.Code)
+	}
+	if body := decodeAPIError(t, rec); body.Code != codeInternal || body.Message != "something broke" {
+		t.Fatalf("unexpected envelope: %+v", body)
+	}
+
+	rec = do("/api/v1/missing")
+	if body := decodeAPIError(t, rec); rec.Code != http.StatusNotFound || body.Code != codeNotFound {
+		t.Fatalf("expected not_found envelope, got %d %+v", rec.Code, body)
+	}
+
+	if rec = do("/api/v1/ok"); rec.Code != http.StatusOK || rec.Body.String() != `{"ok":true}` {
+		t.Fatalf("expected success untouched, got %d %q", rec.Code, rec.Body.String())
+	}
+	if body := decodeAPIError(t, do("/api/v1/conflict")); body.Code != codeNotConfigured {
+		t.Fatalf("expected handler code kept, got %+v", body)
+	}
+	if rec = do("/chat"); rec.Header().Get("Content-Type") == "application/json" {
+		t.Fatalf("expected non-API routes untouched, got %q", rec.Body.String())
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -15,6 +16,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/KafClaw/KafClaw/internal/bus"
 	"github.com/KafClaw/KafClaw/internal/config"
 	"github.com/KafClaw/KafClaw/internal/group"
 	"github.com/KafClaw/KafClaw/internal/orchestrator"
@@ -222,6 +224,17 @@ func TestPublishKnowledgePresenceAndCapabilitiesAnnouncement(t *testing.T) {
 	if !strings.Contains(strings.Join(types, ","), "capabilities") || !strings.Contains(strings.Join(types, ","), "presence") {
 		t.Fatalf("expected capabilities and presence types, got %v", types)
 	}
+	mu.Unlock()
+
+	// Observer members publish nothing to the group, knowledge included.
+	cfg.Group.Role = group.GroupRoleObserver
+	if err := publishKnowledgePresenceAnnouncement(cfg, tl, "active"); !errors.Is(err, group.ErrObserverPublish) {
+		t.Fatalf("expected observer publish to be refused, got %v", err)
+	}
+	mu.Lock()
+	if len(topics) != 2 {
+		t.Fatalf("observer published a knowledge envelope: %v", topics)
+	}
 }
 
 func TestInferNodeCapabilities(t *testing.T) {
@@ -287,7 +300,7 @@ func TestCollectMemoryKnowledgeMetrics(t *testing.T) {
 		Tags:      "[]",
 	})
 
-	got, err := collectMemoryKnowledgeMetrics(tl)
+	got, err := collectMemoryKnowledgeMetrics(tl, nil)
 	if err != nil {
 		t.Fatalf("collect metrics: %v", err)
 	}
@@ -499,6 +512,15 @@ func TestGroupTraceAdapterPublishers(t *testing.T) {
 		t.Fatalf("publish trace with map payload: %v", err)
 	}
 
+	adapter.sampler = group.NewTraceSampler(config.GroupTraceSamplingConfig{SpanTypeRates: map[string]float64{"TOOL": 0}})
+	if err := adapter.PublishTrace(context.Background(), map[string]string{
+		"trace_id":  "t-3",
+		"span_type": "TOOL",
+		"error":     "true",
+	}); err != nil {
+		t.Fatalf("publish sampled trace: %v", err)
+	}
+
 	if err := adapter.PublishTrace(context.Background(), 123); err == nil {
 		t.Fatal("expected unsupported payload type error")
 	}
@@ -550,6 +572,66 @@ func newActiveGroupManagerForGatewayTest(t *testing.T) *group.Manager {
 	return mgr
 }
 
+func TestGroupBusSubscriptionAnswersGroupTask(t *testing.T) {
+	var mu sync.Mutex
+	var responses []group.GroupEnvelope
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var env group.GroupEnvelope
+		_ = json.NewDecoder(r.Body).Decode(&env)
+		if r.Header.Get("X-Kafka-Topic") == "group.bus-test.responses" {
+			mu.Lock()
+			responses = append(responses, env)
+			mu.Unlock()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(group.LFSEnvelope{KfsLFS: 1})
+	}))
+	defer srv.Close()
+
+	mgr := group.NewManager(config.GroupConfig{
+		Enabled:        true,
+		GroupName:      "bus-test",
+		LFSProxyURL:    srv.URL,
+		PollIntervalMs: 10,
+	}, nil, group.AgentIdentity{AgentID: "bus-agent", AgentName: "BusAgent", Status: "active"})
+	if err := mgr.Join(context.Background()); err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	defer mgr.Leave(context.Background())
+
+	msgBus := bus.NewMessageBus()
+	setupGroupBusSubscription(mgr, msgBus)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go msgBus.DispatchOutbound(ctx)
+
+	msgBus.PublishOutbound(&bus.OutboundMessage{
+		Channel: "group",
+		ChatID:  "group-task-1",
+		TaskID:  "local-agent-task-7",
+		Content: "done",
+	})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		n := len(responses)
+		mu.Unlock()
+		if n > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(responses) != 1 {
+		t.Fatalf("expected one task response, got %d", len(responses))
+	}
+	if responses[0].CorrelationID != "group-task-1" {
+		t.Fatalf("expected response for the group task, got %q", responses[0].CorrelationID)
+	}
+}
+
 func TestListRepoTreeErrorPath(t *testing.T) {
 	// Non-existent root should return an error from WalkDir.
 	if _, err := listRepoTree(filepath.Join(t.TempDir(), "missing"), t.TempDir()); err == nil {
@@ -570,3 +652,21 @@ func TestNewTraceIDFallback(t *testing.T) {
 type errReader struct{}
 
 func (errReader) Read(_ []byte) (int, error) { return 0, io.ErrUnexpectedEOF }
+
+func TestToolListPolicies(t *testing.T) {
+	if toolListPolicies(nil) != nil {
+		t.Fatal("expected no lists without config")
+	}
+	lists := toolListPolicies(map[string]config.ToolChannelPolicyConfig{
+		" Slack ": {
+			Deny:     []string{"exec", " "},
+			Accounts: map[string]config.ToolListConfig{"ops": {Allow: []string{"exec", "read_file"}}},
+		},
+	})
+	if len(lists) != 2 || !lists["slack"].Deny["exec"] || len(lists["slack"].Deny) != 1 || lists["slack"].Allow != nil {
+		t.Fatalf("unexpected channel list %+v", lists)
+	}
+	if ops := lists["slack:ops"]; !ops.Allow["exec"] || !ops.Allow["read_file"] || ops.Deny != nil {
+		t.Fatalf("unexpected account list %+v", ops)
+	}
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/channels"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+const (
+	// inboundCallbackTimeout bounds how long a callback waits for its task.
+	inboundCallbackTimeout = 30 * time.Minute
+	// maxTaskWait caps the long-poll of GET /api/v1/tasks/<id>?wait=<s>.
+	maxTaskWait = 60 * time.Second
+)
+
+var taskPollInterval = 500 * time.Millisecond
+
+func taskFinished(status string) bool {
+	return status == timeline.TaskStatusCompleted || status == timeline.TaskStatusFailed || status == timeline.TaskStatusCancelled
+}
+
+// waitForTask polls a task until it finishes, ctx is done, or
+// timeout elapses, and returns its latest state.
+func waitForTask(ctx context.Context, tl *timeline.TimelineService, taskID string, timeout time.Duration) (*timeline.AgentTask, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		task, err := tl.GetTask(taskID)
+		if err != nil {
+			return nil, err
+		}
+		if taskFinished(task.Status) || !time.Now().Before(deadline) {
+			return task, nil
+		}
+		select {
+		case <-ctx.Done():
+			return task, nil
+		case <-time.After(taskPollInterval):
+		}
+	}
+}
+
+// validCallbackURL accepts absolute http(s) URLs only.
+func validCallbackURL(raw string) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// inboundCallbackPayload is POSTed to the bridge callback URL once the task
+// of an asynchronous inbound message finishes.
+type inboundCallbackPayload struct {
+	TaskID  string `json:"task_id"`
+	TraceID string `json:"trace_id"`
+	Channel string `json:"channel"`
+	ChatID  string `json:"chat_id"`
+	Status  string `json:"status"`
+	Content string `json:"content,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// deliverInboundCallback waits for the task and POSTs its outcome to
+// callbackURL, retrying transient failures. The channel inbound token, if
+// any, is sent as X-Channel-Token so the bridge can authenticate the call.
+func deliverInboundCallback(ctx context.Context, tl *timeline.TimelineService, bt *channels.BridgeTask, callbackURL, token string) {
+	task, err := waitForTask(ctx, tl, bt.TaskID, inboundCallbackTimeout)
+	if err != nil {
+		slog.Warn("inbound callback: task lookup failed", "task_id", bt.TaskID, "error", err)
+		return
+	}
+	payload := inboundCallbackPayload{
+		TaskID:  task.TaskID,
+		TraceID: task.TraceID,
+		Channel: task.Channel,
+		ChatID:  task.ChatID,
+		Status:  task.Status,
+		Content: task.ContentOut,
+		Error:   task.ErrorText,
+	}
+	if !taskFinished(task.Status) {
+		payload.Error = "timed out waiting for the agent"
+	}
+	body, _ := json.Marshal(payload)
+	client := &http.Client{Timeout: 15 * time.Second}
+	for attempt := 1; attempt <= 3; attempt++ {
+		err = postInboundCallback(ctx, client, callbackURL, token, body)
+		if err == nil {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(attempt) * 2 * time.Second):
+		}
+	}
+	slog.Warn("inbound callback failed", "task_id", task.TaskID, "url", callbackURL, "error", err)
+}
+
+func postInboundCallback(ctx context.Context, client *http.Client, callbackURL, token string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if tok := strings.TrimSpace(token); tok != "" {
+		req.Header.Set("X-Channel-Token", tok)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("callback status: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// writeInboundAccepted answers an asynchronous inbound request: 202 with the
+// task when the message was queued, 200 when policy dropped it.
+func writeInboundAccepted(w http.ResponseWriter, bt *channels.BridgeTask) {
+	if bt == nil {
+		json.NewEncoder(w).Encode(map[string]any{"ok": true, "queued": false})
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]any{
+		"ok":        true,
+		"queued":    true,
+		"task_id":   bt.TaskID,
+		"trace_id":  bt.TraceID,
+		"status":    bt.Status,
+		"duplicate": bt.Duplicate,
+	})
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/channels"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestInboundCallbackAndLongPoll(t *testing.T) {
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tl.Close()
+	prev := taskPollInterval
+	taskPollInterval = 10 * time.Millisecond
+	defer func() { taskPollInterval = prev }()
+
+	task, err := tl.CreateTask(&timeline.AgentTask{TraceID: "trace-cb", Channel: "slack", ChatID: "D1", ContentIn: "slow"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(chan inboundCallbackPayload, 1)
+	tokens := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p inboundCallbackPayload
+		_ = json.NewDecoder(r.Body).Decode(&p)
+		tokens <- r.Header.Get("X-Channel-Token")
+		got <- p
+	}))
+	defer server.Close()
+
+	go deliverInboundCallback(t.Context(), tl, &channels.BridgeTask{TaskID: task.TaskID}, server.URL, "tok")
+	if waited, err := waitForTask(t.Context(), tl, task.TaskID, 30*time.Millisecond); err != nil || waited.Status != timeline.TaskStatusPending {
+		t.Fatalf("long-poll must return the pending task on timeout: %+v %v", waited, err)
+	}
+	if err := tl.UpdateTaskStatus(task.TaskID, timeline.TaskStatusCompleted, "done: friday", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case p := <-got:
+		if p.TaskID != task.TaskID || p.Status != timeline.TaskStatusCompleted || p.Content != "done: friday" || p.TraceID != "trace-cb" {
+			t.Fatalf("unexpected callback payload: %+v", p)
+		}
+		if tok := <-tokens; tok != "tok" {
+			t.Fatalf("expected channel token on callback, got %q", tok)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("callback not delivered")
+	}
+
+	if validCallbackURL("ftp://bridge/cb") || validCallbackURL("/relative") || !validCallbackURL("https://bridge.local/cb") {
+		t.Fatal("unexpected callback URL validation")
+	}
+}
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"sync"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/memory"
+	"github.com/KafClaw/KafClaw/internal/metrics"
+)
+
+// embeddingProbeInterval bounds how often a scrape re-probes the embedding runtime.
+const embeddingProbeInterval = time.Minute
+
+// registerGatewayMetrics adds scrape-time gauges for state owned by the
+// gateway: bus queue depths, memory index size and embedding runtime health.
+func registerGatewayMetrics(reg *metrics.Registry, msgBus *bus.MessageBus, lifecycleMgr *memory.LifecycleManager, cfg *config.Config) {
+	queueDepth := reg.Gauge("kafclaw_bus_queue_depth", "Messages waiting in the bus queues.", "queue")
+	chunks := reg.Gauge("kafclaw_memory_chunks", "Indexed memory chunks, by layer.", "layer")
+	chunksTotal := reg.Gauge("kafclaw_memory_chunks_total", "Total indexed memory chunks.")
+	embeddingReady := reg.Gauge("kafclaw_embedding_runtime_ready", "1 when the embedding runtime answered its last health probe.")
+
+	var (
+		probeMu   sync.Mutex
+		probing   bool
+		lastProbe time.Time
+	)
+	reg.OnCollect(func() {
+		queueDepth.Set(float64(msgBus.InboundSize()), "inbound")
+		queueDepth.Set(float64(msgBus.OutboundSize()), "outbound")
+
+		if stats, err := lifecycleMgr.Stats(); err == nil {
+			chunksTotal.Set(float64(stats.TotalChunks))
+			for layer, n := range stats.BySource {
+				chunks.Set(float64(n), layer)
+			}
+		}
+
+		// Probe in the background so a slow runtime never stalls a scrape.
+		probeMu.Lock()
+		defer probeMu.Unlock()
+		if probing || time.Since(lastProbe) < embeddingProbeInterval {
+			return
+		}
+		probing = true
+		go func() {
+			health := probeEmbeddingRuntime(cfg)
+			ready := 0.0
+			if health.Ready {
+				ready = 1
+			}
+			embeddingReady.Set(ready)
+			probeMu.Lock()
+			probing = false
+			lastProbe = time.Now()
+			probeMu.Unlock()
+		}()
+	})
+}
@@ -0,0 +1,300 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/tracing"
+)
+
+// apiRateLimiter enforces per-client request budgets on the dashboard API.
+// Clients presenting the configured auth token share a per-token budget;
+// everyone else is limited per remote IP. Endpoint overrides add a second,
+// tighter budget for matching path prefixes.
+type apiRateLimiter struct {
+	cfg       config.GatewayRateLimitConfig
+	authToken string
+	now       func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	limited map[string]int64 // endpoint|scope -> rejected requests
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newAPIRateLimiter(cfg config.GatewayRateLimitConfig, authToken string) *apiRateLimiter {
+	return &apiRateLimiter{
+		cfg:       cfg,
+		authToken: authToken,
+		now:       time.Now,
+		buckets:   map[string]*tokenBucket{},
+		limited:   map[string]int64{},
+	}
+}
+
+// clientKey returns the bucket scope ("token" or "ip") and key for a request.
+// Only the valid auth token earns the token budget, so rotating bogus tokens
+// cannot bypass the per-IP limit.
+func (l *apiRateLimiter) clientKey(r *http.Request) (scope, key string) {
+	if l.authToken != "" && strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ") == l.authToken {
+		return "token", "token"
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip", "ip:" + host
+}
+
+// endpointLimit returns the longest configured path prefix matching path.
+func (l *apiRateLimiter) endpointLimit(path string) (prefix string, perMinute int) {
+	for p, n := range l.cfg.Endpoints {
+		if strings.HasPrefix(path, p) && len(p) > len(prefix) {
+			prefix, perMinute = p, n
+		}
+	}
+	return prefix, perMinute
+}
+
+// take consumes one token from the named bucket. It returns how long the
+// caller has to wait when the bucket is empty.
+func (l *apiRateLimiter) take(key string, perMinute int, now time.Time) (bool, time.Duration) {
+	if perMinute <= 0 {
+		return true, 0
+	}
+	burst := float64(l.cfg.Burst)
+	if burst <= 0 {
+		burst = math.Max(1, float64(perMinute)/4)
+	}
+	rate := float64(perMinute) / 60 // tokens per second
+
+	b := l.buckets[key]
+	if b == nil {
+		b = &tokenBucket{tokens: burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+// allow checks the client and endpoint budgets for r.
+func (l *apiRateLimiter) allow(r *http.Request, endpoint string) (bool, time.Duration) {
+	scope, key := l.clientKey(r)
+	limit := l.cfg.PerIPPerMinute
+	if scope == "token" {
+		limit = l.cfg.PerTokenPerMinute
+	}
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.buckets) > 10000 {
+		l.pruneLocked(now)
+	}
+	ok, wait := l.take(key, limit, now)
+	if ok {
+		if prefix, n := l.endpointLimit(r.URL.Path); prefix != "" {
+			ok, wait = l.take(key+"|"+prefix, n, now)
+		}
+	}
+	if !ok {
+		l.limited[endpoint+"|"+scope]++
+	}
+	return ok, wait
+}
+
+// pruneLocked drops buckets that have been idle long enough to be full again.
+func (l *apiRateLimiter) pruneLocked(now time.Time) {
+	for k, b := range l.buckets {
+		if now.Sub(b.last) > 10*time.Minute {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+// Wrap applies rate limiting to /api/ requests. The status endpoint and CORS
+// preflight requests are exempt.
+func (l *apiRateLimiter) Wrap(next http.Handler, endpointOf func(*http.Request) string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") || r.URL.Path == "/api/v1/status" || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if ok, wait := l.allow(r, endpointOf(r)); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			writeAPIError(w, r, http.StatusTooManyRequests, codeRateLimited, "rate limit exceeded")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// WritePrometheus writes rejected request counters in Prometheus text format.
+func (l *apiRateLimiter) WritePrometheus(w io.Writer) {
+	l.mu.Lock()
+	keys := make([]string, 0, len(l.limited))
+	for k := range l.limited {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	counts := make([]int64, len(keys))
+	for i, k := range keys {
+		counts[i] = l.limited[k]
+	}
+	l.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP kafclaw_http_rate_limited_total Dashboard API requests rejected by the rate limiter.")
+	fmt.Fprintln(w, "# TYPE kafclaw_http_rate_limited_total counter")
+	for i, k := range keys {
+		endpoint, scope, _ := strings.Cut(k, "|")
+		fmt.Fprintf(w, "kafclaw_http_rate_limited_total{endpoint=%q,scope=%q} %d\n", endpoint, scope, counts[i])
+	}
+}
+
+// latencyBuckets are the upper bounds (seconds) of the request latency histogram.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// requestMetrics records per-endpoint request counts and latencies for the
+// dashboard API.
+type requestMetrics struct {
+	mu        sync.Mutex
+	requests  map[string]int64 // endpoint|code -> count
+	latencies map[string]*latencyHistogram
+}
+
+type latencyHistogram struct {
+	counts []int64 // cumulative per latencyBuckets
+	sum    float64
+	count  int64
+}
+
+func newRequestMetrics() *requestMetrics {
+	return &requestMetrics{
+		requests:  map[string]int64{},
+		latencies: map[string]*latencyHistogram{},
+	}
+}
+
+func (m *requestMetrics) observe(endpoint string, code int, d time.Duration) {
+	secs := d.Seconds()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[endpoint+"|"+strconv.Itoa(code)]++
+	h := m.latencies[endpoint]
+	if h == nil {
+		h = &latencyHistogram{counts: make([]int64, len(latencyBuckets))}
+		m.latencies[endpoint] = h
+	}
+	for i, le := range latencyBuckets {
+		if secs <= le {
+			h.counts[i]++
+		}
+	}
+	h.sum += secs
+	h.count++
+}
+
+// statusRecorder captures the response status code.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+// Flush keeps streaming handlers working behind the recorder.
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Wrap records the status and latency of every request, including those
+// rejected by the rate limiter or auth.
+func (m *requestMetrics) Wrap(next http.Handler, endpointOf func(*http.Request) string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		m.observe(endpointOf(r), rec.status, time.Since(start))
+	})
+}
+
+// WritePrometheus writes request counters and latency histograms in
+// Prometheus text format.
+func (m *requestMetrics) WritePrometheus(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	reqKeys := make([]string, 0, len(m.requests))
+	for k := range m.requests {
+		reqKeys = append(reqKeys, k)
+	}
+	sort.Strings(reqKeys)
+	fmt.Fprintln(w, "# HELP kafclaw_http_requests_total Dashboard API requests by endpoint and status code.")
+	fmt.Fprintln(w, "# TYPE kafclaw_http_requests_total counter")
+	for _, k := range reqKeys {
+		endpoint, code, _ := strings.Cut(k, "|")
+		fmt.Fprintf(w, "kafclaw_http_requests_total{endpoint=%q,code=%q} %d\n", endpoint, code, m.requests[k])
+	}
+
+	endpoints := make([]string, 0, len(m.latencies))
+	for k := range m.latencies {
+		endpoints = append(endpoints, k)
+	}
+	sort.Strings(endpoints)
+	fmt.Fprintln(w, "# HELP kafclaw_http_request_duration_seconds Dashboard API request latency.")
+	fmt.Fprintln(w, "# TYPE kafclaw_http_request_duration_seconds histogram")
+	for _, endpoint := range endpoints {
+		h := m.latencies[endpoint]
+		for i, le := range latencyBuckets {
+			fmt.Fprintf(w, "kafclaw_http_request_duration_seconds_bucket{endpoint=%q,le=%q} %d\n", endpoint, strconv.FormatFloat(le, 'g', -1, 64), h.counts[i])
+		}
+		fmt.Fprintf(w, "kafclaw_http_request_duration_seconds_bucket{endpoint=%q,le=\"+Inf\"} %d\n", endpoint, h.count)
+		fmt.Fprintf(w, "kafclaw_http_request_duration_seconds_sum{endpoint=%q} %g\n", endpoint, h.sum)
+		fmt.Fprintf(w, "kafclaw_http_request_duration_seconds_count{endpoint=%q} %d\n", endpoint, h.count)
+	}
+}
+
+// muxEndpoint labels a request with the mux pattern that serves it, keeping
+// metric cardinality bounded for paths with IDs.
+func muxEndpoint(mux *http.ServeMux) func(*http.Request) string {
+	return func(r *http.Request) string {
+		if _, pattern := mux.Handler(r); pattern != "" {
+			return pattern
+		}
+		return "unmatched"
+	}
+}
+
+// traceContext carries a valid W3C traceparent header of a request in its
+// context, so the work it triggers joins the caller's trace.
+func traceContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p, ok := tracing.Parse(r.Header.Get(tracing.Header)); ok {
+			r = r.WithContext(tracing.WithParent(r.Context(), p))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+func TestAPIRateLimiterAndMetrics(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/timeline", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/api/v1/tasks/", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/api/v1/status", func(w http.ResponseWriter, r *http.Request) {})
+
+	now := time.Unix(1_700_000_000, 0)
+	limiter := newAPIRateLimiter(config.GatewayRateLimitConfig{
+		PerIPPerMinute:    600,
+		PerTokenPerMinute: 1200,
+		Endpoints:         map[string]int{"/api/v1/timeline": 12},
+	}, "secret")
+	limiter.now = func() time.Time { return now }
+	metrics := newRequestMetrics()
+	endpointOf := muxEndpoint(mux)
+	handler := metrics.Wrap(limiter.Wrap(mux, endpointOf), endpointOf)
+
+	do := func(path, remote, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remote
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := 0; i < 3; i++ {
+		if rec := do("/api/v1/timeline", "10.0.0.1:1234", ""); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i, rec.Code)
+		}
+	}
+	rec := do("/api/v1/timeline", "10.0.0.1:1234", "")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rec.Code)
+	}
+	// Endpoint limit is 12/min with a burst of 3, so the next token arrives in 5s.
+	if got := rec.Header().Get("Retry-After"); got != "5" {
+		t.Fatalf("expected Retry-After 5, got %q", got)
+	}
+
+	// Other clients have their own budgets; bogus tokens do not.
+	if rec := do("/api/v1/timeline", "10.0.0.2:1234", ""); rec.Code != http.StatusOK {
+		t.Fatalf("other ip should not be limited, got %d", rec.Code)
+	}
+	if rec := do("/api/v1/timeline", "10.0.0.1:1234", "bogus"); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("bogus token must fall back to the ip budget, got %d", rec.Code)
+	}
+	if rec := do("/api/v1/timeline", "10.0.0.1:1234", "secret"); rec.Code != http.StatusOK {
+		t.Fatalf("valid token has its own budget, got %d", rec.Code)
+	}
+	if rec := do("/api/v1/status", "10.0.0.1:1234", ""); rec.Code != http.StatusOK {
+		t.Fatalf("status endpoint must be exempt, got %d", rec.Code)
+	}
+
+	now = now.Add(5 * time.Second)
+	if rec := do("/api/v1/timeline", "10.0.0.1:1234", ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected refill after Retry-After, got %d", rec.Code)
+	}
+	do("/api/v1/tasks/abc123", "10.0.0.3:1234", "")
+
+	var sb strings.Builder
+	metrics.WritePrometheus(&sb)
+	limiter.WritePrometheus(&sb)
+	out := sb.String()
+	for _, want := range []string{
+		`kafclaw_http_requests_total{endpoint="/api/v1/timeline",code="200"} 6`,
+		`kafclaw_http_requests_total{endpoint="/api/v1/timeline",code="429"} 2`,
+		`kafclaw_http_requests_total{endpoint="/api/v1/tasks/",code="200"} 1`,
+		`kafclaw_http_request_duration_seconds_count{endpoint="/api/v1/timeline"} 8`,
+		`kafclaw_http_rate_limited_total{endpoint="/api/v1/timeline",scope="ip"} 2`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in metrics:\n%s", want, out)
+		}
+	}
+}
+
+func TestTraceContext(t *testing.T) {
+	var traceID, parent string
+	handler := traceContext(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceID = requestTraceID(r)
+		parent = requestParentSpanID(r)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/webui/chat", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if traceID != "4bf92f3577b34da6a3ce929d0e0e4736" || parent != "00f067aa0ba902b7" {
+		t.Fatalf("expected the caller trace, got %q parent %q", traceID, parent)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/webui/chat", nil)
+	req.Header.Set("traceparent", "garbage")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if traceID == "" || traceID == "4bf92f3577b34da6a3ce929d0e0e4736" || parent != "" {
+		t.Fatalf("expected a fresh trace for an invalid header, got %q parent %q", traceID, parent)
+	}
+}
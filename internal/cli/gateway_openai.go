@@ -0,0 +1,260 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/session"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// openAIDefaultModel is the model name of the gateway's own agent on the
+// OpenAI-compatible API.
+const openAIDefaultModel = "kafclaw"
+
+// openAIConversationFunc answers the last user turn of a conversation, as
+// agent.Loop.ProcessConversation.
+type openAIConversationFunc func(ctx context.Context, history []session.Message, content, session, traceID, agentID string) (string, error)
+
+// openAIChatRequest is the subset of an OpenAI chat completions request the
+// gateway uses. Sampling parameters are accepted and ignored.
+type openAIChatRequest struct {
+	Model    string              `json:"model"`
+	Messages []openAIChatMessage `json:"messages"`
+	User     string              `json:"user"`
+	Stream   bool                `json:"stream"`
+}
+
+type openAIChatMessage struct {
+	Role string `json:"role"`
+	// Content is a string or a list of content parts.
+	Content json.RawMessage `json:"content"`
+}
+
+// text returns the message content; of content parts only text parts count.
+func (m openAIChatMessage) text() string {
+	var s string
+	if err := json.Unmarshal(m.Content, &s); err == nil {
+		return s
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	_ = json.Unmarshal(m.Content, &parts)
+	var texts []string
+	for _, p := range parts {
+		if p.Type == "text" && p.Text != "" {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// openAIModels lists the model names the API accepts: the gateway's own
+// agent and every agents.list entry.
+func openAIModels(cfg *config.Config) []string {
+	models := []string{openAIDefaultModel}
+	if cfg != nil && cfg.Agents != nil {
+		for _, a := range cfg.Agents.List {
+			if id := strings.TrimSpace(a.ID); id != "" {
+				models = append(models, id)
+			}
+		}
+	}
+	return models
+}
+
+// openAIAgentForModel maps a model name to an agent ID. "kafclaw", "main"
+// and an empty name are the gateway's own agent (""); agents.list entries
+// match by ID or name, ignoring case.
+func openAIAgentForModel(cfg *config.Config, model string) (string, bool) {
+	model = strings.TrimSpace(model)
+	if model == "" || strings.EqualFold(model, openAIDefaultModel) || strings.EqualFold(model, "main") {
+		return "", true
+	}
+	if cfg == nil || cfg.Agents == nil {
+		return "", false
+	}
+	for _, a := range cfg.Agents.List {
+		id := strings.TrimSpace(a.ID)
+		if id != "" && (strings.EqualFold(model, id) || strings.EqualFold(model, strings.TrimSpace(a.Name))) {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// writeOpenAIError writes an error in the OpenAI error envelope.
+func writeOpenAIError(w http.ResponseWriter, status int, errType, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	errBody := map[string]any{"message": message, "type": errType, "code": nil}
+	if code != "" {
+		errBody["code"] = code
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"error": errBody})
+}
+
+// openAIModelsHandler serves GET /v1/models.
+func openAIModelsHandler(cfg *config.Config, authToken string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !localChatAuthorized(r, authToken) {
+			writeOpenAIError(w, http.StatusUnauthorized, "invalid_request_error", "invalid_api_key", "unauthorized")
+			return
+		}
+		if r.Method != http.MethodGet {
+			writeOpenAIError(w, http.StatusMethodNotAllowed, "invalid_request_error", "", "method not allowed")
+			return
+		}
+		var data []map[string]any
+		for _, id := range openAIModels(cfg) {
+			data = append(data, map[string]any{"id": id, "object": "model", "created": 0, "owned_by": "kafclaw"})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"object": "list", "data": data})
+	}
+}
+
+// openAIChatCompletionsHandler serves POST /v1/chat/completions. The last
+// message must be a user message and is the turn the agent answers; the
+// earlier user and assistant messages replace the history of the session
+// "openai:<user>". Requests without a user run on a throwaway session of
+// their own. System messages are ignored, the agent keeps its own
+// system prompt. With "stream": true the answer is sent as one
+// chat.completion.chunk stream ending in "data: [DONE]".
+func openAIChatCompletionsHandler(ctx context.Context, cfg *config.Config, authToken string, timeSvc *timeline.TimelineService, run openAIConversationFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !localChatAuthorized(r, authToken) {
+			writeOpenAIError(w, http.StatusUnauthorized, "invalid_request_error", "invalid_api_key", "unauthorized")
+			return
+		}
+		if r.Method != http.MethodPost {
+			writeOpenAIError(w, http.StatusMethodNotAllowed, "invalid_request_error", "", "method not allowed")
+			return
+		}
+		var req openAIChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "", "invalid json: "+err.Error())
+			return
+		}
+		agentID, ok := openAIAgentForModel(cfg, req.Model)
+		if !ok {
+			writeOpenAIError(w, http.StatusNotFound, "invalid_request_error", "model_not_found",
+				fmt.Sprintf("The model %q does not exist", req.Model))
+			return
+		}
+		n := len(req.Messages)
+		if n == 0 || req.Messages[n-1].Role != "user" || strings.TrimSpace(req.Messages[n-1].text()) == "" {
+			writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "", "messages must end with a non-empty user message")
+			return
+		}
+		content := req.Messages[n-1].text()
+		history := make([]session.Message, 0, n-1)
+		for _, m := range req.Messages[:n-1] {
+			history = append(history, session.Message{Role: m.Role, Content: m.text()})
+		}
+		model := strings.TrimSpace(req.Model)
+		if model == "" {
+			model = openAIDefaultModel
+		}
+
+		traceID := requestTraceID(r)
+		// An empty key asks for a throwaway session; the log names it
+		// after the request.
+		sessionKey, logKey := "", "openai:"+traceID
+		if user := strings.TrimSpace(req.User); user != "" {
+			sessionKey = "openai:" + user
+			logKey = sessionKey
+		}
+		logLocalChatInbound(timeSvc, r, content, logKey, traceID)
+		id := "chatcmpl-" + traceID
+		created := time.Now().Unix()
+		if !req.Stream {
+			resp, err := run(ctx, history, content, sessionKey, traceID, agentID)
+			logLocalChatOutbound(timeSvc, traceID, logKey, resp, err)
+			if err != nil {
+				writeOpenAIError(w, http.StatusInternalServerError, "server_error", "", err.Error())
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"id":      id,
+				"object":  "chat.completion",
+				"created": created,
+				"model":   model,
+				"choices": []map[string]any{{
+					"index":         0,
+					"message":       map[string]any{"role": "assistant", "content": resp},
+					"finish_reason": "stop",
+				}},
+				"usage": map[string]any{"prompt_tokens": 0, "completion_tokens": 0, "total_tokens": 0},
+			})
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			writeOpenAIError(w, http.StatusInternalServerError, "server_error", "", "streaming unsupported")
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		chunk := func(delta map[string]any, finish any) {
+			data, _ := json.Marshal(map[string]any{
+				"id":      id,
+				"object":  "chat.completion.chunk",
+				"created": created,
+				"model":   model,
+				"choices": []map[string]any{{"index": 0, "delta": delta, "finish_reason": finish}},
+			})
+			fmt.Fprintf(w, "data: %s\n\n", data)
+		}
+		chunk(map[string]any{"role": "assistant", "content": ""}, nil)
+		flusher.Flush()
+
+		type result struct {
+			resp string
+			err  error
+		}
+		done := make(chan result, 1)
+		go func() {
+			resp, err := run(ctx, history, content, sessionKey, traceID, agentID)
+			done <- result{resp, err}
+		}()
+		ping := time.NewTicker(localChatStreamPing)
+		defer ping.Stop()
+		for {
+			select {
+			case <-ping.C:
+				fmt.Fprint(w, ": ping\n\n")
+				flusher.Flush()
+			case res := <-done:
+				logLocalChatOutbound(timeSvc, traceID, logKey, res.resp, res.err)
+				if res.err != nil {
+					data, _ := json.Marshal(map[string]any{"error": map[string]any{"message": res.err.Error(), "type": "server_error"}})
+					fmt.Fprintf(w, "data: %s\n\n", data)
+				} else {
+					chunk(map[string]any{"content": res.resp}, nil)
+					chunk(map[string]any{}, "stop")
+				}
+				fmt.Fprint(w, "data: [DONE]\n\n")
+				flusher.Flush()
+				return
+			case <-r.Context().Done():
+				go func() {
+					res := <-done
+					logLocalChatOutbound(timeSvc, traceID, logKey, res.resp, res.err)
+				}()
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/session"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestOpenAIChatCompletionsMapsModelAndHistory(t *testing.T) {
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tl.Close()
+	cfg := &config.Config{Agents: &config.AgentsConfig{List: []config.AgentListEntry{{ID: "reviewer", Name: "Code Reviewer"}}}}
+
+	var (
+		gotHistory []session.Message
+		gotContent string
+		gotSession string
+		gotAgent   string
+	)
+	run := func(ctx context.Context, history []session.Message, content, session, traceID, agentID string) (string, error) {
+		gotHistory, gotContent, gotSession, gotAgent = history, content, session, agentID
+		return "Looks good.", nil
+	}
+	h := openAIChatCompletionsHandler(context.Background(), cfg, "secret", tl, run)
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		h(w, req)
+		return w
+	}
+
+	w := post(`{"model":"code reviewer","user":"ide","messages":[
+		{"role":"system","content":"be brief"},
+		{"role":"user","content":"review this"},
+		{"role":"assistant","content":"which file?"},
+		{"role":"user","content":[{"type":"text","text":"main.go"},{"type":"image_url","image_url":{"url":"x"}}]}]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	var out struct {
+		Object  string `json:"object"`
+		Model   string `json:"model"`
+		Choices []struct {
+			Message      struct{ Role, Content string } `json:"message"`
+			FinishReason string                         `json:"finish_reason"`
+		} `json:"choices"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &out)
+	if out.Object != "chat.completion" || out.Model != "code reviewer" || len(out.Choices) != 1 ||
+		out.Choices[0].Message.Content != "Looks good." || out.Choices[0].FinishReason != "stop" {
+		t.Fatalf("unexpected completion: %s", w.Body.String())
+	}
+	if gotAgent != "reviewer" || gotSession != "openai:ide" || gotContent != "main.go" || len(gotHistory) != 3 || gotHistory[2].Content != "which file?" {
+		t.Fatalf("unexpected run args agent=%q session=%q content=%q history=%+v", gotAgent, gotSession, gotContent, gotHistory)
+	}
+
+	w = post(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`)
+	if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), "model_not_found") {
+		t.Fatalf("expected model_not_found, got %d %s", w.Code, w.Body.String())
+	}
+	if w = post(`{"model":"kafclaw","messages":[{"role":"assistant","content":"hi"}]}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a trailing user message, got %d", w.Code)
+	}
+
+	w = post(`{"model":"kafclaw","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	body := w.Body.String()
+	if gotAgent != "" || gotSession != "" {
+		t.Fatalf("expected default agent and a throwaway session, got %q %q", gotAgent, gotSession)
+	}
+	if w.Header().Get("Content-Type") != "text/event-stream" || !strings.Contains(body, `"content":"Looks good."`) ||
+		!strings.Contains(body, `"finish_reason":"stop"`) || !strings.HasSuffix(body, "data: [DONE]\n\n") {
+		t.Fatalf("unexpected stream: %s", body)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{}`))
+	w = httptest.NewRecorder()
+	h(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %d", w.Code)
+	}
+}
+
+func TestOpenAIModelsListsAgents(t *testing.T) {
+	cfg := &config.Config{Agents: &config.AgentsConfig{List: []config.AgentListEntry{{ID: "reviewer"}}}}
+	w := httptest.NewRecorder()
+	openAIModelsHandler(cfg, "")(w, httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+	var out struct {
+		Data []struct{ ID string } `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &out)
+	if len(out.Data) != 2 || out.Data[0].ID != "kafclaw" || out.Data[1].ID != "reviewer" {
+		t.Fatalf("unexpected models: %s", w.Body.String())
+	}
+}
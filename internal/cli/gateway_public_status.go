@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+// publicStatusTTL is how long one public status snapshot is reused, so
+// unauthenticated polling never fans out into bridge probes.
+const publicStatusTTL = 10 * time.Second
+
+// publicStatus is the payload of the public status page. It carries states,
+// counts and timestamps only: no failure reasons, URLs, tokens, chat or
+// member details.
+type publicStatus struct {
+	Status        string             `json:"status"`
+	Version       string             `json:"version"`
+	UptimeSeconds int                `json:"uptime_seconds"`
+	Components    []publicHealth     `json:"components"`
+	Channels      []publicHealth     `json:"channels"`
+	Queue         publicQueueDepth   `json:"queue"`
+	LastIncident  *componentIncident `json:"last_incident"`
+	GeneratedAt   time.Time          `json:"generated_at"`
+}
+
+// publicHealth is the name and state of one component or channel bridge.
+type publicHealth struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+type publicQueueDepth struct {
+	Inbound  int `json:"inbound"`
+	Outbound int `json:"outbound"`
+}
+
+// buildPublicStatus reduces the component report and bridge states to the
+// public payload. The last incident is the newer of the report incident and
+// the last error recorded by a bridge.
+func buildPublicStatus(components []componentStatus, bridges []bridgeStatus, incident *componentIncident, inbound, outbound int, uptime time.Duration, now time.Time) publicStatus {
+	out := publicStatus{
+		Status:        overallStatus(components),
+		Version:       version,
+		UptimeSeconds: int(uptime.Seconds()),
+		Components:    make([]publicHealth, 0, len(components)),
+		Channels:      make([]publicHealth, 0, len(bridges)),
+		Queue:         publicQueueDepth{Inbound: inbound, Outbound: outbound},
+		LastIncident:  incident,
+		GeneratedAt:   now.UTC(),
+	}
+	for _, c := range components {
+		out.Components = append(out.Components, publicHealth{Name: c.Name, Status: c.Status})
+	}
+	for _, b := range bridges {
+		out.Channels = append(out.Channels, publicHealth{Name: b.Name, Status: b.Health})
+		at, err := time.Parse(time.RFC3339, b.LastErrAt)
+		if err != nil {
+			continue
+		}
+		if out.LastIncident == nil || at.After(out.LastIncident.At) {
+			out.LastIncident = &componentIncident{Component: "bridge:" + b.Name, Status: componentDegraded, At: at.UTC()}
+		}
+	}
+	if out.Status == componentOK {
+		for _, b := range bridges {
+			if b.Health != "ok" {
+				out.Status = componentDegraded
+				break
+			}
+		}
+	}
+	return out
+}
+
+// publicStatusCache holds the last public status snapshot for publicStatusTTL.
+type publicStatusCache struct {
+	mu      sync.Mutex
+	at      time.Time
+	value   publicStatus
+	collect func(ctx context.Context) publicStatus
+}
+
+func (c *publicStatusCache) get(ctx context.Context) publicStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.at.IsZero() || time.Since(c.at) > publicStatusTTL {
+		c.value = c.collect(ctx)
+		c.at = time.Now()
+	}
+	return c.value
+}
+
+// newPublicStatusLimiter returns a per-IP limiter for the public status
+// endpoints. It never grants the token budget.
+func newPublicStatusLimiter(cfg config.GatewayPublicStatusConfig) *apiRateLimiter {
+	return newAPIRateLimiter(config.GatewayRateLimitConfig{PerIPPerMinute: cfg.PerIPPerMinute}, "")
+}
+
+// isPublicStatusPath reports whether path is served without auth when the
+// public status page is enabled.
+func isPublicStatusPath(path string) bool {
+	return path == "/status" || path == "/api/v1/public/status"
+}
+
+// limitPublic rejects requests over the per-IP budget with 429. Requests
+// while the page is disabled get 404.
+func (l *apiRateLimiter) limitPublic(enabled bool, endpoint string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !enabled {
+			http.NotFound(w, r)
+			return
+		}
+		if ok, wait := l.allow(r, endpoint); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+func TestBuildPublicStatusOmitsSensitiveFields(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	components := []componentStatus{
+		{Name: "timeline", Status: componentOK, Critical: true},
+		{Name: "kafka", Status: componentDegraded, Reason: "sasl auth failed for user bot-secret"},
+	}
+	bridges := []bridgeStatus{{
+		Name: "bridge-1", URL: "http://10.0.0.5:18888", Health: "degraded",
+		Error: "token xoxb-123 rejected", LastError: "member U123 not found", LastErrAt: "2026-03-01T11:30:00Z",
+	}}
+	incident := &componentIncident{Component: "kafka", Status: componentDegraded, At: now.Add(-2 * time.Hour)}
+
+	st := buildPublicStatus(components, bridges, incident, 3, 1, 90*time.Minute, now)
+	if st.Status != componentDegraded || st.UptimeSeconds != 5400 || st.Queue.Inbound != 3 || st.Queue.Outbound != 1 {
+		t.Fatalf("unexpected summary: %+v", st)
+	}
+	if len(st.Components) != 2 || len(st.Channels) != 1 || st.Channels[0].Status != "degraded" {
+		t.Fatalf("unexpected health lists: %+v / %+v", st.Components, st.Channels)
+	}
+	if st.LastIncident == nil || st.LastIncident.Component != "bridge:bridge-1" {
+		t.Fatalf("expected the newer bridge error as last incident, got %+v", st.LastIncident)
+	}
+
+	raw, _ := json.Marshal(st)
+	for _, secret := range []string{"sasl", "bot-secret", "10.0.0.5", "xoxb", "U123"} {
+		if strings.Contains(string(raw), secret) {
+			t.Fatalf("public status leaks %q: %s", secret, raw)
+		}
+	}
+}
+
+func TestPublicStatusLimiter(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	limiter := newPublicStatusLimiter(config.GatewayPublicStatusConfig{Enabled: true, PerIPPerMinute: 4})
+	limiter.now = func() time.Time { return now }
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+
+	do := func(h http.HandlerFunc, remote, token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/public/status", nil)
+		req.RemoteAddr = remote
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		h(rec, req)
+		return rec.Code
+	}
+
+	if code := do(limiter.limitPublic(false, "/api/v1/public/status", ok), "1.2.3.4:1", ""); code != http.StatusNotFound {
+		t.Fatalf("disabled page: expected 404, got %d", code)
+	}
+
+	h := limiter.limitPublic(true, "/api/v1/public/status", ok)
+	// Burst defaults to a quarter of the per-minute limit: one request.
+	if code := do(h, "1.2.3.4:1", ""); code != http.StatusOK {
+		t.Fatalf("first request: expected 200, got %d", code)
+	}
+	if code := do(h, "1.2.3.4:2", "any-token"); code != http.StatusTooManyRequests {
+		t.Fatalf("second request: expected 429 regardless of token, got %d", code)
+	}
+	if code := do(h, "5.6.7.8:1", ""); code != http.StatusOK {
+		t.Fatalf("other client: expected 200, got %d", code)
+	}
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// timelineRedactHandler serves POST /api/v1/timeline/redact. Malformed
+// requests answer 400 and storage failures 500.
+func timelineRedactHandler(authToken string, timeSvc *timeline.TimelineService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+actorHeader)
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodOptions {
+			return
+		}
+		if r.Method != http.MethodPost {
+			writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+			return
+		}
+		// The audit records the authenticated caller, never a name
+		// from the body.
+		by, ok := mutationActor(w, r, authToken)
+		if !ok {
+			return
+		}
+		var body struct {
+			EventID string `json:"event_id"`
+			Pattern string `json:"pattern"`
+			Since   string `json:"since"`
+			Until   string `json:"until"`
+			Reason  string `json:"reason"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeAPIError(w, r, http.StatusBadRequest, codeInvalidJSON, "invalid body")
+			return
+		}
+		req, err := buildRedactionRequest(body.EventID, body.Pattern, body.Since, body.Until, body.Reason)
+		if err != nil {
+			writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, err.Error())
+			return
+		}
+		req.Actor = by.Actor
+		res, err := timeSvc.RedactEvents(req)
+		if errors.Is(err, timeline.ErrInvalidRedaction) {
+			writeAPIError(w, r, http.StatusBadRequest, codeInvalidRequest, err.Error())
+			return
+		}
+		if err != nil {
+			writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+			return
+		}
+		slog.InfoContext(r.Context(), "Timeline redaction", "events", len(res.Events), "actor", req.Actor)
+		json.NewEncoder(w).Encode(res)
+	}
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestTimelineRedactHandler(t *testing.T) {
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tl.AddEvent(&timeline.TimelineEvent{EventID: "e1", Timestamp: time.Now(), SenderID: "u", EventType: "TEXT", ContentText: "key sk-abc"}); err != nil {
+		t.Fatal(err)
+	}
+	h := timelineRedactHandler("secret", tl)
+	post := func(body, auth, actor string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/timeline/redact", strings.NewReader(body))
+		r.RemoteAddr = "10.0.0.5:4000"
+		if auth != "" {
+			r.Header.Set("Authorization", "Bearer "+auth)
+		}
+		if actor != "" {
+			r.Header.Set(actorHeader, actor)
+		}
+		w := httptest.NewRecorder()
+		h(w, r)
+		return w
+	}
+
+	if w := post(`{"event_id":"e1"}`, "", ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without the token, got %d", w.Code)
+	}
+	if w := post(`{"pattern":"("}`, "secret", ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid pattern, got %d %s", w.Code, w.Body.String())
+	}
+	if w := post(`{"pattern":"sk-[a-z]+","actor":"mallory"}`, "secret", "alice"); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d %s", w.Code, w.Body.String())
+	}
+	log, err := tl.ListRedactions("e1", 0)
+	if err != nil || len(log) != 1 || log[0].Actor != "alice" {
+		t.Fatalf("expected the authenticated actor in the log, got %+v err=%v", log, err)
+	}
+
+	tl.Close()
+	if w := post(`{"event_id":"e1"}`, "secret", ""); w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 for a storage failure, got %d %s", w.Code, w.Body.String())
+	}
+}
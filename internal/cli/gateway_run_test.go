@@ -170,8 +170,15 @@ func TestRunGatewayServesDashboardEndpoints(t *testing.T) {
 	if got := getStatus("/approvals"); got != http.StatusOK {
 		t.Fatalf("expected /approvals status 200, got %d", got)
 	}
+	if got := getStatus("/bridges"); got != http.StatusOK {
+		t.Fatalf("expected /bridges status 200, got %d", got)
+	}
 
 	call(http.MethodGet, "/api/v1/status", "")
+	call(http.MethodGet, "/api/v1/status/components", "")
+	call(http.MethodGet, "/api/v1/bridges/status", "")
+	call(http.MethodGet, "/api/v1/tools", "")
+	call(http.MethodGet, "/api/v1/tools?format=markdown", "")
 	call(http.MethodPost, "/api/v1/auth/verify", "{}")
 	call(http.MethodGet, "/api/v1/orchestrator/status", "")
 	call(http.MethodGet, "/api/v1/orchestrator/hierarchy", "")
@@ -184,11 +191,13 @@ func TestRunGatewayServesDashboardEndpoints(t *testing.T) {
 	call(http.MethodGet, "/api/v1/trace-graph/trace-x", "")
 	call(http.MethodGet, "/api/v1/group/status", "")
 	call(http.MethodGet, "/api/v1/group/members", "")
+	call(http.MethodGet, "/api/v1/group/members/search?capability=kubernetes", "")
 	call(http.MethodPost, "/api/v1/group/join", `{"group_name":"g1","kafka_brokers":"127.0.0.1:9092","consumer_group":"cg","agent_id":"a1","lfs_proxy_url":"http://127.0.0.1:8080"}`)
 	call(http.MethodPost, "/api/v1/group/leave", "{}")
 	call(http.MethodPost, "/api/v1/group/config", `{"group_name":"g2"}`)
 	call(http.MethodPost, "/api/v1/group/tasks/submit", `{"description":"do x","target_agent":"a2"}`)
 	call(http.MethodGet, "/api/v1/group/tasks", "")
+	call(http.MethodGet, "/api/v1/group/tasks?task_id=missing", "")
 	call(http.MethodGet, "/api/v1/group/traces", "")
 	call(http.MethodGet, "/api/v1/group/memory", "")
 	call(http.MethodPost, "/api/v1/group/memory", `{"item_id":"m1","author_id":"a1","title":"n","content_type":"text/plain","tags":"[]","metadata":"{}"}`)
@@ -198,6 +207,35 @@ func TestRunGatewayServesDashboardEndpoints(t *testing.T) {
 	call(http.MethodPost, "/api/v1/group/onboard", `{"agent_id":"a2","group_name":"g1","action":"invite"}`)
 	call(http.MethodGet, "/api/v1/group/membership/history", "")
 	call(http.MethodGet, "/api/v1/group/members/previous", "")
+	call(http.MethodGet, "/api/v1/group/identities", "")
+	call(http.MethodGet, "/api/v1/knowledge/facts?group=g1&limit=5", "")
+	call(http.MethodPost, "/api/v1/channels/broadcast/audiences", `{"name":"ops","targets":[{"channel":"slack","chat_id":"C1"}]}`)
+	call(http.MethodGet, "/api/v1/channels/broadcast/audiences", "")
+	call(http.MethodPost, "/api/v1/channels/broadcast", `{"content":"hi","audiences":["ops"],"interval_ms":1}`)
+	call(http.MethodGet, "/api/v1/channels/broadcast?id=broadcast-none", "")
+	call(http.MethodDelete, "/api/v1/channels/broadcast/audiences?name=ops", "")
+	call(http.MethodPost, "/api/v1/channels/slack/delivery", `{"chat_id":"C1","thread_id":"1.0","message_id":"1.1","task_id":"t1"}`)
+	call(http.MethodGet, "/api/v1/channels/delivery?chat_id=C1", "")
+	call(http.MethodGet, "/api/v1/finops/anomalies?limit=5", "")
+	if got := getStatus("/api/v1/public/status"); got != http.StatusNotFound {
+		t.Fatalf("expected disabled public status 404, got %d", got)
+	}
+	call(http.MethodPost, "/api/v1/missions", `{"name":"launch","description":"v2 launch"}`)
+	call(http.MethodGet, "/api/v1/missions", "")
+	call(http.MethodPost, "/api/v1/missions/assignments", `{"mission":"launch","kind":"repo","ref":"/src/app"}`)
+	call(http.MethodGet, "/api/v1/missions/assignments?mission=launch", "")
+	call(http.MethodGet, "/api/v1/missions/dashboard?name=launch", "")
+	call(http.MethodDelete, "/api/v1/missions/assignments?kind=repo&ref=/src/app", "")
+	call(http.MethodDelete, "/api/v1/missions?name=launch", "")
+	call(http.MethodGet, "/api/v1/commitments?status=all", "")
+	call(http.MethodPost, "/api/v1/commitments/close", `{"id":1,"status":"done"}`)
+	call(http.MethodPost, "/api/v1/boards/items", `{"channel":"slack","chat_id":"C1","title":"rotate keys","assignee":"alice"}`)
+	call(http.MethodGet, "/api/v1/boards/items?channel=slack&chat_id=C1&status=all", "")
+	call(http.MethodPost, "/api/v1/boards/items/update", `{"id":1,"status":"done"}`)
+	call(http.MethodGet, "/api/v1/boards", "")
+	call(http.MethodPost, "/api/v1/pins", `{"channel":"slack","chat_id":"C1","content":"prod cluster is eu-central-1"}`)
+	call(http.MethodGet, "/api/v1/pins?channel=slack&chat_id=C1", "")
+	call(http.MethodDelete, "/api/v1/pins?channel=slack&chat_id=C1&id=1", "")
 	call(http.MethodPost, "/api/v1/group/rejoin", "{}")
 	call(http.MethodGet, "/api/v1/group/stats", "")
 	call(http.MethodGet, "/api/v1/group/audit", "")
@@ -210,6 +248,7 @@ func TestRunGatewayServesDashboardEndpoints(t *testing.T) {
 	call(http.MethodGet, "/api/v1/group/topics/density?topic=team.tasks&hours=6", "")
 	call(http.MethodGet, "/api/v1/settings", "")
 	call(http.MethodPost, "/api/v1/settings", `{"key":"silent_mode","value":"false"}`)
+	call(http.MethodGet, "/api/v1/audit/settings?key=silent_mode", "")
 	call(http.MethodGet, "/api/v1/memory/status", "")
 	call(http.MethodGet, "/api/v1/memory/metrics", "")
 	call(http.MethodGet, "/api/v1/memory/embedding/status", "")
@@ -248,6 +287,7 @@ func TestRunGatewayServesDashboardEndpoints(t *testing.T) {
 	call(http.MethodGet, "/api/v1/tasks/nope", "")
 	call(http.MethodGet, "/api/v1/approvals/pending", "")
 	call(http.MethodPost, "/api/v1/approvals/nope", `{"status":"approved"}`)
+	call(http.MethodGet, "/api/v1/approvals/nope", "")
 	call(http.MethodGet, "/timeline", "")
 	call(http.MethodGet, "/group", "")
 	call(http.MethodGet, "/approvals", "")
@@ -356,12 +396,14 @@ func TestRunGatewayChatAuthEnforcedWhenTokenConfigured(t *testing.T) {
 	origPort := os.Getenv("KAFCLAW_GATEWAY_PORT")
 	origDash := os.Getenv("KAFCLAW_GATEWAY_DASHBOARD_PORT")
 	origAuth := os.Getenv("KAFCLAW_GATEWAY_AUTH_TOKEN")
+	origPublic := os.Getenv("KAFCLAW_GATEWAY_PUBLIC_STATUS_ENABLED")
 	t.Cleanup(func() {
 		_ = os.Setenv("HOME", origHome)
 		_ = os.Setenv("KAFCLAW_GATEWAY_HOST", origHost)
 		_ = os.Setenv("KAFCLAW_GATEWAY_PORT", origPort)
 		_ = os.Setenv("KAFCLAW_GATEWAY_DASHBOARD_PORT", origDash)
 		_ = os.Setenv("KAFCLAW_GATEWAY_AUTH_TOKEN", origAuth)
+		_ = os.Setenv("KAFCLAW_GATEWAY_PUBLIC_STATUS_ENABLED", origPublic)
 	})
 
 	_ = os.Setenv("HOME", tmpHome)
@@ -369,6 +411,7 @@ func TestRunGatewayChatAuthEnforcedWhenTokenConfigured(t *testing.T) {
 	_ = os.Setenv("KAFCLAW_GATEWAY_PORT", freePort(t))
 	_ = os.Setenv("KAFCLAW_GATEWAY_DASHBOARD_PORT", freePort(t))
 	_ = os.Setenv("KAFCLAW_GATEWAY_AUTH_TOKEN", "token123")
+	_ = os.Setenv("KAFCLAW_GATEWAY_PUBLIC_STATUS_ENABLED", "true")
 
 	if err := os.MkdirAll(filepath.Join(tmpHome, ".kafclaw"), 0755); err != nil {
 		t.Fatalf("mkdir home .kafclaw: %v", err)
@@ -413,6 +456,21 @@ func TestRunGatewayChatAuthEnforcedWhenTokenConfigured(t *testing.T) {
 		t.Fatalf("expected non-401 with correct token, got %d", code)
 	}
 
+	for path, want := range map[string]int{
+		"/status":                   http.StatusOK,
+		"/api/v1/public/status":     http.StatusOK,
+		"/api/v1/status/components": http.StatusUnauthorized,
+	} {
+		resp, err := client.Get(dashBase + path)
+		if err != nil {
+			t.Fatalf("get %s: %v", path, err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Fatalf("GET %s without token: expected %d, got %d", path, want, resp.StatusCode)
+		}
+	}
+
 	sendGatewaySignal(t, syscall.SIGTERM)
 	select {
 	case <-done:
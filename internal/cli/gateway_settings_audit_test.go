@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/cliconfig"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestMutationActor(t *testing.T) {
+	req := func(remote, auth, actor string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/settings", nil)
+		r.RemoteAddr = remote
+		if auth != "" {
+			r.Header.Set("Authorization", "Bearer "+auth)
+		}
+		if actor != "" {
+			r.Header.Set(actorHeader, actor)
+		}
+		return r
+	}
+
+	w := httptest.NewRecorder()
+	if by, ok := mutationActor(w, req("127.0.0.1:5000", "", ""), ""); !ok || by.Actor != "local" || by.SourceIP != "127.0.0.1" || by.Endpoint != "/api/v1/settings" {
+		t.Fatalf("loopback caller without token: %+v %v", by, ok)
+	}
+	w = httptest.NewRecorder()
+	if _, ok := mutationActor(w, req("10.0.0.5:5000", "", "mallory"), ""); ok || w.Code != http.StatusUnauthorized {
+		t.Fatalf("remote caller without auth token must be rejected, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	if _, ok := mutationActor(w, req("10.0.0.5:5000", "wrong", ""), "secret"); ok || w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong token must be rejected, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	if by, ok := mutationActor(w, req("10.0.0.5:5000", "secret", "alice"), "secret"); !ok || by.Actor != "alice" || by.SourceIP != "10.0.0.5" {
+		t.Fatalf("token caller: %+v %v", by, ok)
+	}
+}
+
+func TestAuditBundleChanges(t *testing.T) {
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tl.Close()
+
+	by := timeline.SettingChangeRecord{Actor: "alice", Endpoint: "/api/v1/settings/import"}
+	auditBundleChanges(tl, by, []cliconfig.BundleChange{
+		{Path: "settings.whatsapp_allowlist", From: "+491701234567", To: "+491709876543"},
+		{Path: "tools.exec.timeout", To: float64(60)},
+	})
+	changes, err := tl.ListSettingChanges("", 10)
+	if err != nil || len(changes) != 2 {
+		t.Fatalf("expected two audited changes: %+v %v", changes, err)
+	}
+	keys := map[string]timeline.SettingChangeRecord{}
+	for _, c := range changes {
+		keys[c.Key] = c
+	}
+	if c := keys["whatsapp_allowlist"]; c.OldValue != "+4****67" || c.NewValue != "+4****43" || c.Actor != "alice" {
+		t.Fatalf("unexpected setting change: %+v", c)
+	}
+	if c := keys["config.tools.exec.timeout"]; c.NewValue != "**" {
+		t.Fatalf("unexpected config change: %+v", c)
+	}
+}
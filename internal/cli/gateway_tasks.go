@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/KafClaw/KafClaw/internal/agent"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// cancelTask serves POST /api/v1/tasks/<id>/cancel. A running task is
+// cancelled through cancel (agent.Loop.CancelTask) and answers 202; the
+// loop marks it cancelled once its current step stops. A pending task is
+// marked cancelled right away so the loop skips it. Finished tasks, and
+// tasks processing elsewhere, answer 409.
+func cancelTask(w http.ResponseWriter, r *http.Request, timeSvc *timeline.TimelineService, taskID string, cancel func(string) bool) {
+	if r.Method != http.MethodPost {
+		writeAPIError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+		return
+	}
+	if taskID == "" {
+		writeAPIError(w, r, http.StatusBadRequest, codeMissingParameter, "task_id required")
+		return
+	}
+	task, err := timeSvc.GetTask(taskID)
+	if err != nil {
+		writeAPIError(w, r, http.StatusNotFound, codeNotFound, "task not found")
+		return
+	}
+	if cancel(taskID) {
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(map[string]string{"task_id": taskID, "status": "cancelling"})
+		return
+	}
+	switch task.Status {
+	case timeline.TaskStatusPending:
+		if err := timeSvc.UpdateTaskStatus(taskID, timeline.TaskStatusCancelled, "", agent.ErrTaskCancelled.Error()); err != nil {
+			writeAPIError(w, r, http.StatusInternalServerError, codeInternal, err.Error())
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"task_id": taskID, "status": timeline.TaskStatusCancelled})
+	case timeline.TaskStatusProcessing:
+		writeAPIError(w, r, http.StatusConflict, codeConflict, "task is not running on this gateway")
+	default:
+		writeAPIError(w, r, http.StatusConflict, codeConflict, "task already "+task.Status)
+	}
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestCancelTaskByStatus(t *testing.T) {
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tl.Close()
+	newTask := func(key, status string) string {
+		task, err := tl.CreateTask(&timeline.AgentTask{IdempotencyKey: key, Channel: "local", ChatID: "c1", ContentIn: "hi"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status != timeline.TaskStatusPending {
+			_ = tl.UpdateTaskStatus(task.TaskID, status, "", "")
+		}
+		return task.TaskID
+	}
+	running := newTask("k-running", timeline.TaskStatusProcessing)
+	pending := newTask("k-pending", timeline.TaskStatusPending)
+	done := newTask("k-done", timeline.TaskStatusCompleted)
+	stale := newTask("k-stale", timeline.TaskStatusProcessing)
+	cancel := func(id string) bool { return id == running }
+
+	call := func(method, id string) int {
+		w := httptest.NewRecorder()
+		cancelTask(w, httptest.NewRequest(method, "/api/v1/tasks/"+id+"/cancel", nil), tl, id, cancel)
+		return w.Code
+	}
+	if code := call(http.MethodGet, running); code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405 for GET, got %d", code)
+	}
+	if code := call(http.MethodPost, running); code != http.StatusAccepted {
+		t.Fatalf("expected 202 for a running task, got %d", code)
+	}
+	if code := call(http.MethodPost, pending); code != http.StatusOK {
+		t.Fatalf("expected 200 for a pending task, got %d", code)
+	}
+	if task, _ := tl.GetTask(pending); task.Status != timeline.TaskStatusCancelled {
+		t.Fatalf("expected pending task to be cancelled, got %s", task.Status)
+	}
+	for _, id := range []string{done, stale, pending} {
+		if code := call(http.MethodPost, id); code != http.StatusConflict {
+			t.Fatalf("expected 409 for %s, got %d", id, code)
+		}
+	}
+	if code := call(http.MethodPost, "nope"); code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown task, got %d", code)
+	}
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+const maxTraceListLimit = 200
+
+// parseTraceListFilter reads the GET /api/v1/traces query: channel, sender,
+// status, has_errors, min_duration_ms, limit (default 50, max 200) and
+// offset.
+func parseTraceListFilter(q url.Values) (timeline.TraceListFilter, error) {
+	f := timeline.TraceListFilter{
+		Channel:  strings.TrimSpace(q.Get("channel")),
+		SenderID: strings.TrimSpace(q.Get("sender")),
+		Status:   strings.TrimSpace(q.Get("status")),
+		Limit:    50,
+	}
+	if v := strings.TrimSpace(q.Get("has_errors")); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, fmt.Errorf("invalid has_errors %q", v)
+		}
+		f.HasErrors = &b
+	}
+	if v := strings.TrimSpace(q.Get("min_duration_ms")); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			return f, fmt.Errorf("invalid min_duration_ms %q", v)
+		}
+		f.MinDurationMS = n
+	}
+	if v := strings.TrimSpace(q.Get("limit")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return f, fmt.Errorf("invalid limit %q", v)
+		}
+		f.Limit = min(n, maxTraceListLimit)
+	}
+	if v := strings.TrimSpace(q.Get("offset")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return f, fmt.Errorf("invalid offset %q", v)
+		}
+		f.Offset = n
+	}
+	return f, nil
+}
@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestParseTraceListFilter(t *testing.T) {
+	f, err := parseTraceListFilter(url.Values{
+		"channel": {"slack"}, "sender": {"U1"}, "status": {"failed"},
+		"has_errors": {"true"}, "min_duration_ms": {"1500"}, "limit": {"500"}, "offset": {"20"},
+	})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if f.Channel != "slack" || f.SenderID != "U1" || f.Status != "failed" || f.HasErrors == nil || !*f.HasErrors ||
+		f.MinDurationMS != 1500 || f.Limit != maxTraceListLimit || f.Offset != 20 {
+		t.Fatalf("unexpected filter: %+v", f)
+	}
+
+	f, err = parseTraceListFilter(url.Values{})
+	if err != nil || f.Limit != 50 || f.HasErrors != nil {
+		t.Fatalf("unexpected defaults: %+v %v", f, err)
+	}
+
+	for _, bad := range []url.Values{
+		{"has_errors": {"maybe"}},
+		{"min_duration_ms": {"-1"}},
+		{"limit": {"0"}},
+		{"offset": {"x"}},
+	} {
+		if _, err := parseTraceListFilter(bad); err == nil {
+			t.Fatalf("expected error for %v", bad)
+		}
+	}
+}
@@ -19,10 +19,46 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	groupGatewayURL string
+	groupJSON       bool
+
+	groupJoinLFSProxyURL  string
+	groupJoinKafkaBrokers string
+	groupJoinAgentID      string
+
+	groupTaskDescription string
+	groupTaskContent     string
+	groupTaskSkill       string
+	groupTaskPriority    string
+	groupTaskDeadline    time.Duration
+	groupTaskTarget      string
+	groupTaskDirection   string
+	groupTaskStatus      string
+	groupTaskSort        string
+	groupTaskID          string
+
+	groupMemoryTitle       string
+	groupMemoryContent     string
+	groupMemoryFile        string
+	groupMemoryContentType string
+	groupMemoryTags        string
+	groupMemoryAuthor      string
+
+	groupAdminValue  string
+	groupAdminReason string
+
+	groupLimit int
+)
+
 var groupCmd = &cobra.Command{
 	Use:   "group",
 	Short: "Manage group collaboration",
-	Long:  "Join, leave, and inspect multi-agent collaboration groups via Kafka.",
+	Long: `Join, leave, and inspect multi-agent collaboration groups via Kafka.
+
+Commands talk to the local gateway API (gateway.host/dashboardPort, with
+gateway.authToken). join, leave, status and members fall back to working on
+the local database when no gateway is running.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		cmd.Help()
 	},
@@ -32,32 +68,122 @@ var groupJoinCmd = &cobra.Command{
 	Use:   "join <group-name>",
 	Short: "Join a collaboration group",
 	Args:  cobra.ExactArgs(1),
-	Run:   runGroupJoin,
+	RunE:  runGroupJoin,
 }
 
 var groupLeaveCmd = &cobra.Command{
 	Use:   "leave",
 	Short: "Leave the current collaboration group",
-	Run:   runGroupLeave,
+	RunE:  runGroupLeave,
 }
 
 var groupStatusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show group status",
-	Run:   runGroupStatus,
+	RunE:  runGroupStatus,
 }
 
 var groupMembersCmd = &cobra.Command{
 	Use:   "members",
 	Short: "List current group members",
-	Run:   runGroupMembers,
+	RunE:  runGroupMembers,
+}
+
+var groupTasksCmd = &cobra.Command{
+	Use:   "tasks",
+	Short: "Submit and list group tasks",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
+	},
+}
+
+var groupTasksSubmitCmd = &cobra.Command{
+	Use:   "submit [content]",
+	Short: "Submit a task to the group (or to a skill with --skill)",
+	RunE:  runGroupTasksSubmit,
+}
+
+var groupTasksListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List group tasks",
+	RunE:  runGroupTasksList,
+}
+
+var groupMemoryCmd = &cobra.Command{
+	Use:   "memory",
+	Short: "Share and list group memory items",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
+	},
+}
+
+var groupMemoryShareCmd = &cobra.Command{
+	Use:   "share [content]",
+	Short: "Share a memory item with the group",
+	RunE:  runGroupMemoryShare,
+}
+
+var groupMemoryListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List shared group memory items",
+	RunE:  runGroupMemoryList,
+}
+
+var groupAdminCmd = &cobra.Command{
+	Use:   "admin [action] [agent-id]",
+	Short: "Show group administration or issue a signed admin action",
+	Long: `Without arguments, show the founder, admins, muted, kicked and observer
+members and recent admin actions. With an action, sign and publish it to the
+group:
+
+  kick|reinstate|mute|unmute <agent-id>    (--reason)
+  set_observer|clear_observer <agent-id>   consume-only member (--reason)
+  rename --value "<display name>"
+  rotate_credentials                       replace this agent's admin key
+  grant_admin|revoke_admin <agent-id>      founder only`,
+	Args: cobra.MaximumNArgs(2),
+	RunE: runGroupAdmin,
 }
 
 func init() {
+	groupCmd.PersistentFlags().StringVar(&groupGatewayURL, "gateway", "", "Gateway API base URL (default from gateway.host/dashboardPort)")
+	groupCmd.PersistentFlags().BoolVar(&groupJSON, "json", false, "Output machine-readable JSON")
+
+	groupJoinCmd.Flags().StringVar(&groupJoinLFSProxyURL, "lfs-proxy-url", "", "LFS proxy URL (default from config)")
+	groupJoinCmd.Flags().StringVar(&groupJoinKafkaBrokers, "kafka-brokers", "", "Kafka brokers (default from config)")
+	groupJoinCmd.Flags().StringVar(&groupJoinAgentID, "agent-id", "", "Agent ID (default from config)")
+
+	groupTasksSubmitCmd.Flags().StringVar(&groupTaskDescription, "description", "", "Task description")
+	groupTasksSubmitCmd.Flags().StringVar(&groupTaskContent, "content", "", "Task content (or pass it as arguments)")
+	groupTasksSubmitCmd.Flags().StringVar(&groupTaskSkill, "skill", "", "Submit to a registered group skill")
+	groupTasksSubmitCmd.Flags().StringVar(&groupTaskPriority, "priority", "", "Task priority (low|normal|high|urgent)")
+	groupTasksSubmitCmd.Flags().DurationVar(&groupTaskDeadline, "deadline", 0, "Escalate the task if not done within this duration (e.g. 30m)")
+	groupTasksSubmitCmd.Flags().StringVar(&groupTaskTarget, "to", "", "Direct the task at one agent ID (kept for it while it is offline)")
+	groupTasksListCmd.Flags().StringVar(&groupTaskDirection, "direction", "", "Direction filter (incoming|outgoing)")
+	groupTasksListCmd.Flags().StringVar(&groupTaskStatus, "status", "", "Status filter")
+	groupTasksListCmd.Flags().StringVar(&groupTaskSort, "sort", "", "Sort order (urgency; default newest first)")
+	groupTasksListCmd.Flags().StringVar(&groupTaskID, "task-id", "", "Show a single task with its result")
+	groupTasksListCmd.Flags().IntVar(&groupLimit, "limit", 50, "Maximum rows to return")
+
+	groupMemoryShareCmd.Flags().StringVar(&groupMemoryTitle, "title", "", "Item title")
+	groupMemoryShareCmd.Flags().StringVar(&groupMemoryContent, "content", "", "Item content (or pass it as arguments)")
+	groupMemoryShareCmd.Flags().StringVar(&groupMemoryFile, "file", "", "Read item content from a file")
+	groupMemoryShareCmd.Flags().StringVar(&groupMemoryContentType, "content-type", "text/plain", "Item content type")
+	groupMemoryShareCmd.Flags().StringVar(&groupMemoryTags, "tags", "", "Comma-separated tags")
+	groupMemoryListCmd.Flags().StringVar(&groupMemoryAuthor, "author", "", "Author agent ID filter")
+	groupMemoryListCmd.Flags().IntVar(&groupLimit, "limit", 50, "Maximum rows to return")
+	groupAdminCmd.Flags().StringVar(&groupAdminValue, "value", "", "Action value (new display name for rename)")
+	groupAdminCmd.Flags().StringVar(&groupAdminReason, "reason", "", "Reason recorded in the audit log")
+
+	groupTasksCmd.AddCommand(groupTasksSubmitCmd, groupTasksListCmd)
+	groupMemoryCmd.AddCommand(groupMemoryShareCmd, groupMemoryListCmd)
 	groupCmd.AddCommand(groupJoinCmd)
 	groupCmd.AddCommand(groupLeaveCmd)
 	groupCmd.AddCommand(groupStatusCmd)
 	groupCmd.AddCommand(groupMembersCmd)
+	groupCmd.AddCommand(groupTasksCmd)
+	groupCmd.AddCommand(groupMemoryCmd)
+	groupCmd.AddCommand(groupAdminCmd)
 }
 
 func loadGroupTimeline() (*timeline.TimelineService, error) {
@@ -85,7 +211,7 @@ func buildGroupManager(cfg *config.Config, timeSvc *timeline.TimelineService) *g
 	return group.NewManager(cfg.Group, timeSvc, identity)
 }
 
-func runGroupJoin(cmd *cobra.Command, args []string) {
+func runGroupJoinLocal(cmd *cobra.Command, args []string) {
 	printHeader("🤝 KafClaw Group Join")
 
 	cfg, err := config.Load()
@@ -136,7 +262,7 @@ func runGroupJoin(cmd *cobra.Command, args []string) {
 	fmt.Printf("LFS Proxy: %s (healthy: %v)\n", cfg.Group.LFSProxyURL, mgr.LFSHealthy())
 }
 
-func runGroupLeave(cmd *cobra.Command, args []string) {
+func runGroupLeaveLocal(cmd *cobra.Command, args []string) {
 	printHeader("👋 KafClaw Group Leave")
 
 	cfg, err := config.Load()
@@ -184,7 +310,7 @@ func runGroupLeave(cmd *cobra.Command, args []string) {
 	fmt.Printf("Left group: %s\n", groupName)
 }
 
-func runGroupStatus(cmd *cobra.Command, args []string) {
+func runGroupStatusLocal(cmd *cobra.Command, args []string) {
 	printHeader("📊 KafClaw Group Status")
 
 	cfg, err := config.Load()
@@ -235,7 +361,7 @@ func runGroupStatus(cmd *cobra.Command, args []string) {
 	fmt.Printf("  Traces:    %s\n", topics.Traces)
 }
 
-func runGroupMembers(cmd *cobra.Command, args []string) {
+func runGroupMembersLocal(cmd *cobra.Command, args []string) {
 	printHeader("👥 KafClaw Group Members")
 
 	timeSvc, err := loadGroupTimeline()
@@ -287,7 +413,16 @@ func setupGroupBusSubscription(mgr *group.Manager, msgBus *bus.MessageBus) {
 		go func() {
 			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 			defer cancel()
-			if err := mgr.RespondTask(ctx, msg.TaskID, msg.Content, "completed"); err != nil {
+			// The group channel's chat is the group task ID; msg.TaskID is
+			// the local timeline task. Skill tasks are answered with a
+			// structured result envelope.
+			if ok, err := mgr.RespondSkillReply(ctx, msg.ChatID, msg.Content); ok {
+				if err != nil {
+					fmt.Printf("Group skill outbound error: %v\n", err)
+				}
+				return
+			}
+			if err := mgr.RespondTask(ctx, msg.ChatID, msg.Content, "completed"); err != nil {
 				fmt.Printf("Group outbound error: %v\n", err)
 			}
 		}()
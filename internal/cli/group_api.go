@@ -0,0 +1,458 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+	"github.com/spf13/cobra"
+)
+
+// groupGateway returns a client for the local gateway API.
+func groupGateway() (*gatewayClient, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	return newGatewayClient(cfg, groupGatewayURL), nil
+}
+
+func printGroupJSON(w io.Writer, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, string(b))
+	return nil
+}
+
+// groupCell flattens s to one line of at most n runes for table output.
+func groupCell(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-3]) + "..."
+	}
+	return s
+}
+
+func runGroupJoin(cmd *cobra.Command, args []string) error {
+	client, err := groupGateway()
+	if err != nil {
+		return err
+	}
+	groupName := strings.TrimSpace(args[0])
+	if groupName == "" {
+		return fmt.Errorf("group name required")
+	}
+	var status map[string]any
+	err = client.do("POST", "/api/v1/group/join", nil, map[string]string{
+		"group_name":    groupName,
+		"lfs_proxy_url": strings.TrimSpace(groupJoinLFSProxyURL),
+		"kafka_brokers": strings.TrimSpace(groupJoinKafkaBrokers),
+		"agent_id":      strings.TrimSpace(groupJoinAgentID),
+	}, &status)
+	if isGatewayUnreachable(err) {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Gateway not reachable at %s; joining from this process.\n", client.baseURL)
+		runGroupJoinLocal(cmd, args)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	if groupJSON {
+		return printGroupJSON(w, status)
+	}
+	fmt.Fprintf(w, "Joined group: %v\n", status["group_name"])
+	fmt.Fprintf(w, "Agent ID: %v\n", status["agent_id"])
+	fmt.Fprintf(w, "LFS Proxy: %v (healthy: %v)\n", status["lfs_proxy_url"], status["lfs_healthy"])
+	return nil
+}
+
+func runGroupLeave(cmd *cobra.Command, args []string) error {
+	client, err := groupGateway()
+	if err != nil {
+		return err
+	}
+	var out map[string]any
+	err = client.do("POST", "/api/v1/group/leave", nil, map[string]string{}, &out)
+	if isGatewayUnreachable(err) {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Gateway not reachable at %s; leaving from this process.\n", client.baseURL)
+		runGroupLeaveLocal(cmd, args)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if groupJSON {
+		return printGroupJSON(cmd.OutOrStdout(), out)
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), "Left group.")
+	return nil
+}
+
+func runGroupStatus(cmd *cobra.Command, args []string) error {
+	client, err := groupGateway()
+	if err != nil {
+		return err
+	}
+	var status map[string]any
+	err = client.do("GET", "/api/v1/group/status", nil, nil, &status)
+	if isGatewayUnreachable(err) {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Gateway not reachable at %s; showing local state.\n", client.baseURL)
+		runGroupStatusLocal(cmd, args)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	if groupJSON {
+		return printGroupJSON(w, status)
+	}
+	if name, _ := status["group_name"].(string); name == "" {
+		fmt.Fprintln(w, "Not in a group. Use 'kafclaw group join <name>' first.")
+		return nil
+	}
+	fmt.Fprintf(w, "Group:       %v\n", status["group_name"])
+	fmt.Fprintf(w, "Active:      %v\n", status["active"])
+	fmt.Fprintf(w, "Agent ID:    %v\n", status["agent_id"])
+	fmt.Fprintf(w, "Members:     %v\n", status["member_count"])
+	fmt.Fprintf(w, "LFS Proxy:   %v\n", status["lfs_proxy_url"])
+	fmt.Fprintf(w, "LFS Healthy: %v\n", status["lfs_healthy"])
+	return nil
+}
+
+func runGroupMembers(cmd *cobra.Command, args []string) error {
+	client, err := groupGateway()
+	if err != nil {
+		return err
+	}
+	var members []timeline.GroupMemberRecord
+	err = client.do("GET", "/api/v1/group/members", nil, nil, &members)
+	if isGatewayUnreachable(err) {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Gateway not reachable at %s; showing the local roster.\n", client.baseURL)
+		runGroupMembersLocal(cmd, args)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	if groupJSON {
+		return printGroupJSON(w, members)
+	}
+	if len(members) == 0 {
+		fmt.Fprintln(w, "No members in group.")
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "AGENT ID\tNAME\tMODEL\tSTATUS\tCAPABILITIES\tLAST SEEN")
+	for _, m := range members {
+		var caps []string
+		_ = json.Unmarshal([]byte(m.Capabilities), &caps)
+		capStr := strings.Join(caps, ", ")
+		if len(capStr) > 40 {
+			capStr = capStr[:37] + "..."
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			m.AgentID, m.AgentName, m.Model, m.Status, capStr, m.LastSeen.Format("2006-01-02 15:04:05"))
+	}
+	return tw.Flush()
+}
+
+func runGroupTasksSubmit(cmd *cobra.Command, args []string) error {
+	content := strings.TrimSpace(groupTaskContent)
+	if content == "" {
+		content = strings.TrimSpace(strings.Join(args, " "))
+	}
+	description := strings.TrimSpace(groupTaskDescription)
+	if description == "" {
+		description = content
+	}
+	if description == "" {
+		return fmt.Errorf("task description or content is required")
+	}
+	client, err := groupGateway()
+	if err != nil {
+		return err
+	}
+
+	path := "/api/v1/group/tasks/submit"
+	body := map[string]string{"description": description, "content": content}
+	if skill := strings.TrimSpace(groupTaskSkill); skill != "" {
+		if groupTaskPriority != "" || groupTaskDeadline != 0 || groupTaskTarget != "" {
+			return fmt.Errorf("--priority, --deadline and --to do not apply to skill tasks")
+		}
+		path = "/api/v1/group/skills/task"
+		body["skill_name"] = skill
+	}
+	if p := strings.TrimSpace(groupTaskPriority); p != "" {
+		body["priority"] = p
+	}
+	if to := strings.TrimSpace(groupTaskTarget); to != "" {
+		body["target_agent_id"] = to
+	}
+	if groupTaskDeadline < 0 {
+		return fmt.Errorf("--deadline must be positive")
+	}
+	if groupTaskDeadline > 0 {
+		body["deadline_at"] = time.Now().Add(groupTaskDeadline).UTC().Format(time.RFC3339)
+	}
+	var out map[string]any
+	if err := client.do("POST", path, nil, body, &out); err != nil {
+		return err
+	}
+	if groupJSON {
+		return printGroupJSON(cmd.OutOrStdout(), out)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Task submitted: %v\n", out["task_id"])
+	return nil
+}
+
+func runGroupTasksList(cmd *cobra.Command, args []string) error {
+	client, err := groupGateway()
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	if id := strings.TrimSpace(groupTaskID); id != "" {
+		var task timeline.GroupTaskRecord
+		if err := client.do("GET", "/api/v1/group/tasks", url.Values{"task_id": {id}}, nil, &task); err != nil {
+			return err
+		}
+		if groupJSON {
+			return printGroupJSON(w, task)
+		}
+		fmt.Fprintf(w, "Task:      %s\n", task.TaskID)
+		fmt.Fprintf(w, "Direction: %s\n", task.Direction)
+		fmt.Fprintf(w, "Status:    %s\n", task.Status)
+		if task.SkillName != "" {
+			fmt.Fprintf(w, "Skill:     %s (attempts: %d)\n", task.SkillName, task.Attempts)
+		}
+		if task.Priority != "" {
+			fmt.Fprintf(w, "Priority:  %s\n", task.Priority)
+		}
+		if task.DeadlineAt != nil {
+			fmt.Fprintf(w, "Deadline:  %s\n", task.DeadlineAt.Format("2006-01-02 15:04"))
+		}
+		if task.Escalations > 0 {
+			fmt.Fprintf(w, "Escalated: %d time(s)\n", task.Escalations)
+		}
+		fmt.Fprintf(w, "Request:   %s\n", task.Description)
+		if task.ResponseContent != "" {
+			fmt.Fprintf(w, "Response:  %s\n", task.ResponseContent)
+		}
+		if len(task.Result) > 0 {
+			fmt.Fprintf(w, "Result:    %s\n", task.Result)
+		}
+		return nil
+	}
+
+	query := url.Values{"limit": {strconv.Itoa(groupLimit)}}
+	if groupTaskDirection != "" {
+		query.Set("direction", groupTaskDirection)
+	}
+	if groupTaskStatus != "" {
+		query.Set("status", groupTaskStatus)
+	}
+	if groupTaskSort != "" {
+		query.Set("sort", groupTaskSort)
+	}
+	var tasks []timeline.GroupTaskRecord
+	if err := client.do("GET", "/api/v1/group/tasks", query, nil, &tasks); err != nil {
+		return err
+	}
+	if groupJSON {
+		return printGroupJSON(w, tasks)
+	}
+	if len(tasks) == 0 {
+		fmt.Fprintln(w, "No group tasks.")
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TASK ID\tDIRECTION\tSTATUS\tPRIORITY\tDEADLINE\tPEER\tDESCRIPTION\tCREATED")
+	for _, t := range tasks {
+		peer := t.ResponderID
+		if t.Direction == "incoming" {
+			peer = t.RequesterID
+		}
+		priority := t.Priority
+		if priority == "" {
+			priority = "normal"
+		}
+		deadline := "-"
+		if t.DeadlineAt != nil {
+			deadline = t.DeadlineAt.Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			t.TaskID, t.Direction, t.Status, priority, deadline, peer, groupCell(t.Description, 40), t.CreatedAt.Format("2006-01-02 15:04"))
+	}
+	return tw.Flush()
+}
+
+func runGroupMemoryShare(cmd *cobra.Command, args []string) error {
+	content := groupMemoryContent
+	if path := strings.TrimSpace(groupMemoryFile); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		content = string(data)
+	}
+	if strings.TrimSpace(content) == "" {
+		content = strings.Join(args, " ")
+	}
+	if strings.TrimSpace(content) == "" {
+		return fmt.Errorf("memory content is required (--content, --file or arguments)")
+	}
+	title := strings.TrimSpace(groupMemoryTitle)
+	if title == "" {
+		return fmt.Errorf("--title is required")
+	}
+	var tags []string
+	for _, tag := range strings.Split(groupMemoryTags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	client, err := groupGateway()
+	if err != nil {
+		return err
+	}
+	var out map[string]any
+	if err := client.do("POST", "/api/v1/group/memory", nil, map[string]any{
+		"title":        title,
+		"content_type": groupMemoryContentType,
+		"content":      content,
+		"tags":         tags,
+	}, &out); err != nil {
+		return err
+	}
+	if groupJSON {
+		return printGroupJSON(cmd.OutOrStdout(), out)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Shared memory item: %s\n", title)
+	return nil
+}
+
+func runGroupMemoryList(cmd *cobra.Command, args []string) error {
+	client, err := groupGateway()
+	if err != nil {
+		return err
+	}
+	query := url.Values{"limit": {strconv.Itoa(groupLimit)}}
+	if author := strings.TrimSpace(groupMemoryAuthor); author != "" {
+		query.Set("author_id", author)
+	}
+	var items []timeline.GroupMemoryItemRecord
+	if err := client.do("GET", "/api/v1/group/memory", query, nil, &items); err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	if groupJSON {
+		return printGroupJSON(w, items)
+	}
+	if len(items) == 0 {
+		fmt.Fprintln(w, "No shared memory items.")
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ITEM ID\tAUTHOR\tTITLE\tTYPE\tCREATED")
+	for _, it := range items {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			it.ItemID, it.AuthorID, groupCell(it.Title, 40), it.ContentType, it.CreatedAt.Format("2006-01-02 15:04"))
+	}
+	return tw.Flush()
+}
+
+func runGroupAdmin(cmd *cobra.Command, args []string) error {
+	client, err := groupGateway()
+	if err != nil {
+		return err
+	}
+	w := cmd.OutOrStdout()
+	if len(args) > 0 {
+		body := map[string]string{"action": args[0], "value": groupAdminValue, "reason": groupAdminReason}
+		if len(args) > 1 {
+			body["target_id"] = args[1]
+		}
+		var out map[string]any
+		if err := client.do("POST", "/api/v1/group/admin", nil, body, &out); err != nil {
+			return err
+		}
+		if groupJSON {
+			return printGroupJSON(w, out)
+		}
+		fmt.Fprintf(w, "Admin action %s published", args[0])
+		if len(args) > 1 {
+			fmt.Fprintf(w, " for %s", args[1])
+		}
+		fmt.Fprintln(w)
+		return nil
+	}
+
+	var res struct {
+		Role     string `json:"role"`
+		Observer bool   `json:"observer"`
+		State    struct {
+			Founder     string            `json:"founder"`
+			DisplayName string            `json:"display_name"`
+			Admins      map[string]string `json:"admins"`
+			Muted       map[string]string `json:"muted"`
+			Kicked      map[string]string `json:"kicked"`
+			Observers   map[string]string `json:"observers"`
+		} `json:"state"`
+		Actions []timeline.GroupAdminActionRecord `json:"actions"`
+	}
+	if err := client.do("GET", "/api/v1/group/admin", nil, nil, &res); err != nil {
+		return err
+	}
+	if groupJSON {
+		return printGroupJSON(w, res)
+	}
+	keys := func(m map[string]string) string {
+		ids := make([]string, 0, len(m))
+		for id := range m {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		if len(ids) == 0 {
+			return "-"
+		}
+		return strings.Join(ids, ", ")
+	}
+	fmt.Fprintf(w, "Founder: %s\n", res.State.Founder)
+	if res.State.DisplayName != "" {
+		fmt.Fprintf(w, "Display name: %s\n", res.State.DisplayName)
+	}
+	if res.Observer {
+		fmt.Fprintf(w, "Your role: %s (observer)\n", res.Role)
+	} else {
+		fmt.Fprintf(w, "Your role: %s\n", res.Role)
+	}
+	fmt.Fprintf(w, "Admins: %s\n", keys(res.State.Admins))
+	fmt.Fprintf(w, "Muted: %s\n", keys(res.State.Muted))
+	fmt.Fprintf(w, "Kicked: %s\n", keys(res.State.Kicked))
+	fmt.Fprintf(w, "Observers: %s\n", keys(res.State.Observers))
+	if len(res.Actions) == 0 {
+		return nil
+	}
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ACTION\tSTATUS\tISSUER\tTARGET\tDETAIL\tCREATED")
+	for _, a := range res.Actions {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			a.Action, a.Status, a.IssuerID, a.TargetID, groupCell(a.Detail, 40), a.CreatedAt.Format("2006-01-02 15:04"))
+	}
+	return tw.Flush()
+}
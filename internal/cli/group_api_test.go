@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestGroupCLIUsesGatewayAPI(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfgDir := filepath.Join(tmpDir, ".kafclaw")
+	if err := os.MkdirAll(cfgDir, 0o755); err != nil {
+		t.Fatalf("mkdir config dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(cfgDir, "config.json"), []byte(`{"gateway":{"authToken":"secret"}}`), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	origHome := os.Getenv("HOME")
+	defer os.Setenv("HOME", origHome)
+	_ = os.Setenv("HOME", tmpDir)
+
+	var mu sync.Mutex
+	bodies := map[string]map[string]any{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodPost {
+			var body map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			mu.Lock()
+			bodies[r.URL.Path] = body
+			mu.Unlock()
+		}
+		switch r.URL.Path {
+		case "/api/v1/group/join":
+			json.NewEncoder(w).Encode(map[string]any{"group_name": "g1", "agent_id": "a1", "active": true})
+		case "/api/v1/group/skills/task", "/api/v1/group/tasks/submit":
+			json.NewEncoder(w).Encode(map[string]string{"status": "submitted", "task_id": "t-42"})
+		case "/api/v1/group/tasks":
+			if r.URL.Query().Get("direction") != "outgoing" {
+				http.Error(w, "missing direction", http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode([]timeline.GroupTaskRecord{{
+				TaskID: "t-42", Direction: "outgoing", Status: "completed", ResponderID: "a2",
+				Description: "count rows", Priority: "high", CreatedAt: time.Now(),
+			}})
+		case "/api/v1/group/memory":
+			json.NewEncoder(w).Encode(map[string]string{"status": "shared"})
+		case "/api/v1/group/admin":
+			if r.Method == http.MethodPost {
+				json.NewEncoder(w).Encode(map[string]string{"action": "mute", "issuer_id": "a1"})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{
+				"role":  "founder",
+				"state": map[string]any{"founder": "a1", "admins": map[string]string{"a1": "k"}, "muted": map[string]string{"a3": "spam"}, "observers": map[string]string{"a4": "monitoring"}},
+				"actions": []timeline.GroupAdminActionRecord{{
+					Action: "mute", Status: "applied", IssuerID: "a1", TargetID: "a3", Detail: "spam", CreatedAt: time.Now(),
+				}},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	out, err := runRootCommand(t, "group", "join", "g1", "--gateway", server.URL)
+	if err != nil || !strings.Contains(out, "Joined group: g1") {
+		t.Fatalf("group join: %q (err=%v)", out, err)
+	}
+
+	out, err = runRootCommand(t, "group", "tasks", "submit", "--gateway", server.URL, "--skill", "sql", "count", "rows")
+	if err != nil || !strings.Contains(out, "Task submitted: t-42") {
+		t.Fatalf("group tasks submit: %q (err=%v)", out, err)
+	}
+	mu.Lock()
+	submit := bodies["/api/v1/group/skills/task"]
+	mu.Unlock()
+	if submit["skill_name"] != "sql" || submit["content"] != "count rows" {
+		t.Fatalf("unexpected submit body: %v", submit)
+	}
+
+	out, err = runRootCommand(t, "group", "tasks", "submit", "--gateway", server.URL, "--skill", "", "--priority", "urgent", "--deadline", "30m", "--to", "a2", "fix", "build")
+	if err != nil || !strings.Contains(out, "Task submitted: t-42") {
+		t.Fatalf("group tasks submit with priority: %q (err=%v)", out, err)
+	}
+	mu.Lock()
+	submit = bodies["/api/v1/group/tasks/submit"]
+	mu.Unlock()
+	if submit["priority"] != "urgent" || submit["target_agent_id"] != "a2" {
+		t.Fatalf("expected priority and target in submit body, got %v", submit)
+	}
+	if d, err := time.Parse(time.RFC3339, fmt.Sprint(submit["deadline_at"])); err != nil || d.Before(time.Now().Add(25*time.Minute)) {
+		t.Fatalf("expected deadline about 30m out, got %v", submit["deadline_at"])
+	}
+	groupTaskPriority, groupTaskDeadline, groupTaskTarget = "", 0, ""
+
+	out, err = runRootCommand(t, "group", "tasks", "list", "--gateway", server.URL, "--direction", "outgoing")
+	if err != nil || !strings.Contains(out, "t-42") || !strings.Contains(out, "completed") || !strings.Contains(out, "high") {
+		t.Fatalf("group tasks list: %q (err=%v)", out, err)
+	}
+
+	out, err = runRootCommand(t, "group", "memory", "share", "--gateway", server.URL, "--title", "notes", "--tags", "a, b", "hello")
+	if err != nil || !strings.Contains(out, "Shared memory item: notes") {
+		t.Fatalf("group memory share: %q (err=%v)", out, err)
+	}
+	mu.Lock()
+	share := bodies["/api/v1/group/memory"]
+	mu.Unlock()
+	if share["content"] != "hello" || len(share["tags"].([]any)) != 2 {
+		t.Fatalf("unexpected share body: %v", share)
+	}
+
+	out, err = runRootCommand(t, "group", "admin", "mute", "a3", "--gateway", server.URL, "--reason", "spam")
+	if err != nil || !strings.Contains(out, "Admin action mute published for a3") {
+		t.Fatalf("group admin mute: %q (err=%v)", out, err)
+	}
+	mu.Lock()
+	admin := bodies["/api/v1/group/admin"]
+	mu.Unlock()
+	if admin["action"] != "mute" || admin["target_id"] != "a3" || admin["reason"] != "spam" {
+		t.Fatalf("unexpected admin body: %v", admin)
+	}
+	groupAdminReason = ""
+
+	out, err = runRootCommand(t, "group", "admin", "--gateway", server.URL)
+	if err != nil || !strings.Contains(out, "Founder: a1") || !strings.Contains(out, "Muted: a3") || !strings.Contains(out, "Observers: a4") || !strings.Contains(out, "applied") {
+		t.Fatalf("group admin: %q (err=%v)", out, err)
+	}
+
+	// Gateway errors are surfaced.
+	if _, err := runRootCommand(t, "group", "memory", "list", "--gateway", server.URL+"/nope"); err == nil {
+		t.Fatal("expected error for unknown endpoint")
+	}
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/group"
+	"github.com/KafClaw/KafClaw/internal/skills"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// skillCapabilities returns the enabled skills as taxonomy entries, tagged
+// with the capabilities declared in their config entries.
+func skillCapabilities(cfg *config.Config) []group.Capability {
+	var out []group.Capability
+	for _, s := range skills.ListEnabledSkills(cfg) {
+		out = append(out, group.Capability{
+			Kind:    group.CapabilitySkill,
+			Name:    s.Name,
+			Version: s.Version,
+			Tags:    s.Tags,
+		})
+	}
+	return out
+}
+
+// memberCapabilityMatch is one result of /api/v1/group/members/search.
+type memberCapabilityMatch struct {
+	AgentID   string             `json:"agent_id"`
+	AgentName string             `json:"agent_name"`
+	Model     string             `json:"model"`
+	Role      string             `json:"role,omitempty"`
+	Status    string             `json:"status"`
+	LastSeen  time.Time          `json:"last_seen"`
+	Matches   []group.Capability `json:"matches"`
+}
+
+// searchGroupMembers returns the members with at least one capability
+// matching query (see group.MatchCapabilities). The live roster is used when
+// available; persisted records cover members known from earlier runs.
+// Records without a stored taxonomy are classified from their free-form
+// fields.
+func searchGroupMembers(live []group.GroupMember, records []timeline.GroupMemberRecord, query, kind string) []memberCapabilityMatch {
+	out := []memberCapabilityMatch{}
+	seen := map[string]bool{}
+	for _, m := range live {
+		seen[m.AgentID] = true
+		taxonomy := m.Taxonomy
+		if len(taxonomy) == 0 {
+			taxonomy = group.BuildCapabilityTaxonomy(group.AgentIdentity{Capabilities: m.Capabilities, Channels: m.Channels, Model: m.Model})
+		}
+		if matches := group.MatchCapabilities(taxonomy, query, kind); len(matches) > 0 {
+			out = append(out, memberCapabilityMatch{
+				AgentID: m.AgentID, AgentName: m.AgentName, Model: m.Model,
+				Role: m.Role, Status: m.Status, LastSeen: m.LastSeen, Matches: matches,
+			})
+		}
+	}
+	for _, rec := range records {
+		if seen[rec.AgentID] {
+			continue
+		}
+		id := group.AgentIdentity{Model: rec.Model}
+		_ = json.Unmarshal([]byte(rec.Taxonomy), &id.Taxonomy)
+		_ = json.Unmarshal([]byte(rec.Capabilities), &id.Capabilities)
+		_ = json.Unmarshal([]byte(rec.Channels), &id.Channels)
+		if matches := group.MatchCapabilities(group.BuildCapabilityTaxonomy(id), query, kind); len(matches) > 0 {
+			out = append(out, memberCapabilityMatch{
+				AgentID: rec.AgentID, AgentName: rec.AgentName, Model: rec.Model,
+				Status: rec.Status, LastSeen: rec.LastSeen, Matches: matches,
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].AgentID < out[j].AgentID })
+	return out
+}
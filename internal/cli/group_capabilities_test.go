@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/group"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestSearchGroupMembers(t *testing.T) {
+	live := []group.GroupMember{{
+		AgentID:  "agent-a",
+		Model:    "gpt-4o",
+		Status:   "active",
+		LastSeen: time.Now(),
+		Taxonomy: []group.Capability{{Kind: group.CapabilitySkill, Name: "k8s-ops", Tags: []string{"kubernetes"}}},
+	}}
+	records := []timeline.GroupMemberRecord{
+		{AgentID: "agent-a", Capabilities: `["exec"]`, Channels: "[]", Taxonomy: "[]"},
+		// Persisted before taxonomies existed.
+		{AgentID: "agent-b", Capabilities: `["kubectl"]`, Channels: `["slack"]`, Taxonomy: "[]"},
+		{AgentID: "agent-c", Capabilities: `["exec"]`, Channels: "[]", Taxonomy: `[{"kind":"skill","name":"helm","tags":["Kubernetes"]}]`},
+	}
+
+	got := searchGroupMembers(live, records, "kube", "")
+	if len(got) != 3 || got[0].AgentID != "agent-a" || got[1].Matches[0].Name != "kubectl" || got[2].Matches[0].Name != "helm" {
+		t.Fatalf("unexpected matches: %+v", got)
+	}
+	if got := searchGroupMembers(live, records, "kube", group.CapabilitySkill); len(got) != 2 {
+		t.Fatalf("expected kind filter to drop the tool match, got %+v", got)
+	}
+	if got := searchGroupMembers(nil, records, "channel:slack", ""); len(got) != 1 || got[0].AgentID != "agent-b" {
+		t.Fatalf("unexpected channel matches: %+v", got)
+	}
+	if got := searchGroupMembers(nil, nil, "nothing", ""); got == nil || len(got) != 0 {
+		t.Fatalf("expected empty non-nil result, got %#v", got)
+	}
+}
@@ -11,7 +11,6 @@ import (
 	"time"
 
 	"github.com/KafClaw/KafClaw/internal/config"
-	"github.com/KafClaw/KafClaw/internal/group"
 	"github.com/KafClaw/KafClaw/internal/knowledge"
 	"github.com/KafClaw/KafClaw/internal/timeline"
 	"github.com/spf13/cobra"
@@ -411,6 +410,7 @@ func runKnowledgeFacts(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	scoreKnowledgeFacts(list)
 	return printKnowledgeOutput(cmd.OutOrStdout(), map[string]any{
 		"status": "ok",
 		"action": "facts",
@@ -420,6 +420,21 @@ func runKnowledgeFacts(cmd *cobra.Command, args []string) error {
 	})
 }
 
+// scoreKnowledgeFacts fills in the confidence score of each fact.
+func scoreKnowledgeFacts(facts []timeline.KnowledgeFactRecord) {
+	for i := range facts {
+		f := &facts[i]
+		f.Confidence = knowledge.FactConfidence(knowledge.ConfidenceSignals{
+			YesVotes:         f.YesVotes,
+			NoVotes:          f.NoVotes,
+			ProposerApproved: f.ProposerApproved,
+			ProposerRejected: f.ProposerRejected,
+			Injections:       f.InjectionCount,
+			Contradictions:   f.ContradictionCount,
+		})
+	}
+}
+
 func estimateKnowledgePoolSize(timeSvc *timeline.TimelineService, cfg *config.Config) int {
 	if timeSvc != nil {
 		if members, err := timeSvc.ListGroupMembers(); err == nil {
@@ -456,11 +471,13 @@ func publishKnowledgeEnvelope(cfg *config.Config, timeSvc *timeline.TimelineServ
 	if err != nil {
 		return err
 	}
-	client := group.NewLFSClient(baseURL, cfg.Group.LFSProxyAPIKey)
+	// The group manager refuses publishes of observer members.
+	grpCfg := *cfg
+	grpCfg.Group.LFSProxyURL = baseURL
+	mgr := buildGroupManager(&grpCfg, timeSvc)
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 	defer cancel()
-	_, err = client.Produce(ctx, topic, env.TraceID, payload)
-	return err
+	return mgr.PublishKnowledge(ctx, topic, env.Type, env.TraceID, payload)
 }
 
 func printKnowledgeOutput(w io.Writer, v map[string]any) error {
@@ -49,6 +49,33 @@ func resolveMemoryEmbedder(cfg *config.Config, main provider.LLMProvider) (provi
 	}
 }
 
+// memoryUnavailableStatus is the startup state of memory when
+// resolveMemoryEmbedder found no embedder. Memory switched off by config is
+// disabled and auto-detection without a usable embedder only degrades; a
+// provider that is explicitly configured but unusable is a critical failure.
+func memoryUnavailableStatus(cfg *config.Config) (string, bool) {
+	if cfg == nil {
+		return componentFailed, true
+	}
+	embCfg := cfg.Memory.Embedding
+	providerID := strings.ToLower(strings.TrimSpace(embCfg.Provider))
+	switch {
+	case !embCfg.Enabled || providerID == "disabled":
+		return componentDisabled, false
+	case providerID == "" || providerID == "auto":
+		return componentDegraded, false
+	}
+	return componentFailed, true
+}
+
+// memoryIndexName names the vector index for the configured embedding model.
+func memoryIndexName(cfg *config.Config) string {
+	if model := strings.ToLower(strings.TrimSpace(cfg.Memory.Embedding.Model)); model != "" {
+		return model
+	}
+	return "default"
+}
+
 func withDefaultEmbeddingModel(inner provider.Embedder, model string) provider.Embedder {
 	model = strings.TrimSpace(model)
 	if inner == nil || model == "" {
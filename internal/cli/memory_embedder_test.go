@@ -47,6 +47,31 @@ func TestResolveMemoryEmbedder_Disabled(t *testing.T) {
 	}
 }
 
+func TestMemoryUnavailableStatus(t *testing.T) {
+	cases := []struct {
+		enabled  bool
+		provider string
+		status   string
+		critical bool
+	}{
+		{false, "local-hf", componentDisabled, false},
+		{true, "disabled", componentDisabled, false},
+		{true, "auto", componentDegraded, false},
+		{true, "", componentDegraded, false},
+		{true, "local-hf", componentFailed, true},
+		{true, "custom", componentFailed, true},
+	}
+	for _, tc := range cases {
+		cfg := config.DefaultConfig()
+		cfg.Memory.Embedding.Enabled = tc.enabled
+		cfg.Memory.Embedding.Provider = tc.provider
+		status, critical := memoryUnavailableStatus(cfg)
+		if status != tc.status || critical != tc.critical {
+			t.Fatalf("enabled=%v provider=%q: got %s/%v, want %s/%v", tc.enabled, tc.provider, status, critical, tc.status, tc.critical)
+		}
+	}
+}
+
 func TestResolveMemoryEmbedder_OpenAI(t *testing.T) {
 	cfg := config.DefaultConfig()
 	cfg.Memory.Embedding.Enabled = true
@@ -0,0 +1,626 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+const scaffoldModulePath = "github.com/KafClaw/KafClaw"
+
+var scaffoldNamePattern = regexp.MustCompile(`^[a-z][a-z0-9]*([_-][a-z0-9]+)*$`)
+
+var (
+	scaffoldRoot  string
+	scaffoldForce bool
+)
+
+var scaffoldCmd = &cobra.Command{
+	Use:   "scaffold",
+	Short: "Generate skeletons for new channels, tools and providers",
+	Long: "Generate the implementation skeleton and a table-driven test for a new module in a KafClaw source tree, " +
+		"and print the config stanza and registration wiring to add by hand.",
+}
+
+var scaffoldChannelCmd = &cobra.Command{
+	Use:   "channel <name>",
+	Short: "Generate a channel skeleton (internal/channels)",
+	Args:  cobra.ExactArgs(1),
+	RunE:  func(cmd *cobra.Command, args []string) error { return runScaffold(cmd, "channel", args[0]) },
+}
+
+var scaffoldToolCmd = &cobra.Command{
+	Use:   "tool <name>",
+	Short: "Generate an agent tool skeleton (internal/tools)",
+	Args:  cobra.ExactArgs(1),
+	RunE:  func(cmd *cobra.Command, args []string) error { return runScaffold(cmd, "tool", args[0]) },
+}
+
+var scaffoldProviderCmd = &cobra.Command{
+	Use:   "provider <name>",
+	Short: "Generate an LLM provider skeleton (internal/provider)",
+	Args:  cobra.ExactArgs(1),
+	RunE:  func(cmd *cobra.Command, args []string) error { return runScaffold(cmd, "provider", args[0]) },
+}
+
+func init() {
+	for _, c := range []*cobra.Command{scaffoldChannelCmd, scaffoldToolCmd, scaffoldProviderCmd} {
+		c.Flags().StringVar(&scaffoldRoot, "root", ".", "Root of the KafClaw source tree")
+		c.Flags().BoolVar(&scaffoldForce, "force", false, "Overwrite existing files")
+		scaffoldCmd.AddCommand(c)
+	}
+	rootCmd.AddCommand(scaffoldCmd)
+}
+
+// scaffoldNames holds the spellings of a module name used by the templates.
+type scaffoldNames struct {
+	Name  string // as registered: channel name, tool name or provider ID
+	Type  string // exported Go identifier, e.g. "MyChat"
+	JSON  string // config key, e.g. "myChat"
+	Env   string // env var suffix, e.g. "MY_CHAT"
+	File  string // file base name, e.g. "my_chat"
+	Title string // human-readable, e.g. "My Chat"
+}
+
+// newScaffoldNames validates name and derives its spellings. Names are
+// lowercase words separated by "-" or "_"; tools always use "_".
+func newScaffoldNames(kind, name string) (scaffoldNames, error) {
+	name = strings.TrimSpace(name)
+	if !scaffoldNamePattern.MatchString(name) {
+		return scaffoldNames{}, fmt.Errorf("invalid %s name %q: use lowercase letters and digits, words separated by - or _", kind, name)
+	}
+	words := strings.FieldsFunc(name, func(r rune) bool { return r == '-' || r == '_' })
+	n := scaffoldNames{Name: name, File: strings.Join(words, "_")}
+	if kind == "tool" {
+		n.Name = n.File
+	}
+	var title []string
+	for i, w := range words {
+		upper := strings.ToUpper(w[:1]) + w[1:]
+		n.Type += upper
+		if i == 0 {
+			n.JSON = w
+		} else {
+			n.JSON += upper
+		}
+		title = append(title, upper)
+	}
+	n.Env = strings.ToUpper(n.File)
+	n.Title = strings.Join(title, " ")
+	return n, nil
+}
+
+// scaffoldFile is one generated file, relative to the source root.
+type scaffoldFile struct {
+	Path    string
+	Content []byte
+}
+
+// scaffoldConfigPath is the file config types are appended to.
+const scaffoldConfigPath = "internal/config/config.go"
+
+// scaffoldPlan is the output of one scaffold run.
+type scaffoldPlan struct {
+	Files []scaffoldFile
+	// ConfigType is the config struct appended to scaffoldConfigPath, so the
+	// generated code builds right away. ConfigTypeName is its name.
+	ConfigType     string
+	ConfigTypeName string
+	Config         string // stanza for internal/config/config.go, added by hand
+	Wiring         string // registration to add by hand
+}
+
+// buildScaffold renders the files and snippets for kind and n.
+func buildScaffold(kind string, n scaffoldNames) (scaffoldPlan, error) {
+	var src, test, cfgType, cfg, wiring, dir string
+	switch kind {
+	case "channel":
+		dir, src, test, cfg, wiring = "internal/channels", scaffoldChannelTmpl, scaffoldChannelTestTmpl, scaffoldChannelConfigTmpl, scaffoldChannelWiringTmpl
+		cfgType = scaffoldChannelConfigTypeTmpl
+	case "tool":
+		dir, src, test, cfg, wiring = "internal/tools", scaffoldToolTmpl, scaffoldToolTestTmpl, scaffoldToolConfigTmpl, scaffoldToolWiringTmpl
+	case "provider":
+		dir, src, test, cfg, wiring = "internal/provider", scaffoldProviderTmpl, scaffoldProviderTestTmpl, scaffoldProviderConfigTmpl, scaffoldProviderWiringTmpl
+	default:
+		return scaffoldPlan{}, fmt.Errorf("unknown scaffold kind %q", kind)
+	}
+
+	var plan scaffoldPlan
+	for _, f := range []struct{ path, tmpl string }{
+		{filepath.Join(dir, n.File+".go"), src},
+		{filepath.Join(dir, n.File+"_test.go"), test},
+	} {
+		out, err := renderScaffold(f.tmpl, n)
+		if err != nil {
+			return scaffoldPlan{}, err
+		}
+		formatted, err := format.Source([]byte(out))
+		if err != nil {
+			return scaffoldPlan{}, fmt.Errorf("format %s: %w", f.path, err)
+		}
+		plan.Files = append(plan.Files, scaffoldFile{Path: f.path, Content: formatted})
+	}
+	var err error
+	if cfgType != "" {
+		if plan.ConfigType, err = renderScaffold(cfgType, n); err != nil {
+			return scaffoldPlan{}, err
+		}
+		plan.ConfigTypeName = n.Type + "Config"
+	}
+	if plan.Config, err = renderScaffold(cfg, n); err != nil {
+		return scaffoldPlan{}, err
+	}
+	if plan.Wiring, err = renderScaffold(wiring, n); err != nil {
+		return scaffoldPlan{}, err
+	}
+	return plan, nil
+}
+
+func renderScaffold(tmpl string, n scaffoldNames) (string, error) {
+	t, err := template.New("scaffold").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, n); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// writeScaffold writes the plan below root. root must hold the KafClaw
+// go.mod; existing files are only replaced with force.
+func writeScaffold(root string, plan scaffoldPlan, force bool) error {
+	mod, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return fmt.Errorf("%s is not a KafClaw source tree: %w", root, err)
+	}
+	if !strings.HasPrefix(strings.TrimSpace(string(mod)), "module "+scaffoldModulePath) {
+		return fmt.Errorf("%s is not a KafClaw source tree: go.mod is not module %s", root, scaffoldModulePath)
+	}
+	if !force {
+		for _, f := range plan.Files {
+			if _, err := os.Stat(filepath.Join(root, f.Path)); err == nil {
+				return fmt.Errorf("%s already exists (use --force to overwrite)", f.Path)
+			}
+		}
+	}
+	for _, f := range plan.Files {
+		if err := os.WriteFile(filepath.Join(root, f.Path), f.Content, 0o644); err != nil {
+			return err
+		}
+	}
+	if plan.ConfigType == "" {
+		return nil
+	}
+	cfgPath := filepath.Join(root, scaffoldConfigPath)
+	cfg, err := os.ReadFile(cfgPath)
+	if err != nil {
+		return err
+	}
+	if bytes.Contains(cfg, []byte("type "+plan.ConfigTypeName+" struct")) {
+		return nil
+	}
+	updated, err := format.Source(append(append(cfg, '\n'), plan.ConfigType...))
+	if err != nil {
+		return fmt.Errorf("format %s: %w", scaffoldConfigPath, err)
+	}
+	return os.WriteFile(cfgPath, updated, 0o644)
+}
+
+func runScaffold(cmd *cobra.Command, kind, name string) error {
+	n, err := newScaffoldNames(kind, name)
+	if err != nil {
+		return err
+	}
+	plan, err := buildScaffold(kind, n)
+	if err != nil {
+		return err
+	}
+	if err := writeScaffold(scaffoldRoot, plan, scaffoldForce); err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Generated %s %q:\n", kind, n.Name)
+	for _, f := range plan.Files {
+		fmt.Fprintf(out, "  %s\n", f.Path)
+	}
+	if plan.ConfigType != "" {
+		fmt.Fprintf(out, "  %s (config type appended)\n", scaffoldConfigPath)
+	}
+	fmt.Fprintf(out, "\nAdd to %s:\n\n%s\n", scaffoldConfigPath, plan.Config)
+	fmt.Fprintf(out, "Wire it up:\n\n%s\n", plan.Wiring)
+	fmt.Fprintln(out, "Then run: go build ./... && go test ./...")
+	return nil
+}
+
+const scaffoldChannelTmpl = `package channels
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// {{.Type}}Channel connects the agent to {{.Title}}.
+type {{.Type}}Channel struct {
+	BaseChannel
+	config   config.{{.Type}}Config
+	timeline *timeline.TimelineService
+}
+
+// New{{.Type}}Channel creates a {{.Title}} channel.
+func New{{.Type}}Channel(cfg config.{{.Type}}Config, messageBus *bus.MessageBus, tl *timeline.TimelineService) *{{.Type}}Channel {
+	return &{{.Type}}Channel{
+		BaseChannel: BaseChannel{Bus: messageBus},
+		config:      cfg,
+		timeline:    tl,
+	}
+}
+
+func (c *{{.Type}}Channel) Name() string { return "{{.Name}}" }
+
+// Start subscribes to outbound messages for this channel. Connect to the
+// platform (webhook, socket, polling loop) here as well.
+func (c *{{.Type}}Channel) Start(ctx context.Context) error {
+	if !c.config.Enabled {
+		return nil
+	}
+	c.Bus.Subscribe(c.Name(), func(msg *bus.OutboundMessage) {
+		if deferForQuietHours(c.timeline, c.Name(), msg, time.Now()) {
+			return
+		}
+		err := c.Send(ctx, msg)
+		recordSend(c.Name(), err)
+		if err != nil {
+			slog.Warn("{{.Title}} send failed", "chat_id", msg.ChatID, "error", err)
+		}
+	})
+	return nil
+}
+
+func (c *{{.Type}}Channel) Stop() error { return nil }
+
+// Send delivers msg to the platform.
+func (c *{{.Type}}Channel) Send(ctx context.Context, msg *bus.OutboundMessage) error {
+	if strings.TrimSpace(msg.ChatID) == "" {
+		return fmt.Errorf("{{.Name}}: missing chat id")
+	}
+	// TODO: call the {{.Title}} API with msg.Content (and msg.ThreadID, msg.MediaURLs).
+	return fmt.Errorf("{{.Name}}: send not implemented")
+}
+
+// HandleInbound publishes a platform message to the agent. Apply the
+// channel's access policy (allowlists, pairing) before calling it.
+func (c *{{.Type}}Channel) HandleInbound(senderID, chatID, messageID, text string) error {
+	if strings.TrimSpace(senderID) == "" || strings.TrimSpace(chatID) == "" {
+		return fmt.Errorf("{{.Name}}: missing sender or chat id")
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	c.Bus.PublishInbound(&bus.InboundMessage{
+		Channel:   c.Name(),
+		SenderID:  senderID,
+		ChatID:    chatID,
+		MessageID: messageID,
+		TraceID:   fmt.Sprintf("{{.Name}}-%d", time.Now().UnixNano()),
+		Content:   text,
+		Timestamp: time.Now(),
+	})
+	return nil
+}
+`
+
+const scaffoldChannelTestTmpl = `package channels
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+func Test{{.Type}}ChannelHandleInbound(t *testing.T) {
+	tests := []struct {
+		name      string
+		senderID  string
+		chatID    string
+		text      string
+		wantErr   bool
+		published bool
+	}{
+		{name: "message", senderID: "u1", chatID: "c1", text: "hello", published: true},
+		{name: "empty text is ignored", senderID: "u1", chatID: "c1", text: "  "},
+		{name: "missing chat", senderID: "u1", text: "hello", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := bus.NewMessageBus()
+			ch := New{{.Type}}Channel(config.{{.Type}}Config{Enabled: true}, b, nil)
+			err := ch.HandleInbound(tt.senderID, tt.chatID, "m1", tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("HandleInbound() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			msg, err := b.ConsumeInbound(ctx)
+			if !tt.published {
+				if err == nil {
+					t.Fatalf("expected nothing published, got %+v", msg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected a published message: %v", err)
+			}
+			if msg.Channel != "{{.Name}}" || msg.ChatID != tt.chatID || msg.Content != tt.text {
+				t.Fatalf("unexpected inbound message: %+v", msg)
+			}
+		})
+	}
+}
+
+func Test{{.Type}}ChannelSend(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     *bus.OutboundMessage
+		wantErr bool
+	}{
+		{name: "missing chat", msg: &bus.OutboundMessage{Content: "hi"}, wantErr: true},
+		// TODO: flip once Send talks to the platform (use an httptest server).
+		{name: "message", msg: &bus.OutboundMessage{ChatID: "c1", Content: "hi"}, wantErr: true},
+	}
+	ch := New{{.Type}}Channel(config.{{.Type}}Config{Enabled: true}, bus.NewMessageBus(), nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ch.Send(context.Background(), tt.msg); (err != nil) != tt.wantErr {
+				t.Fatalf("Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+`
+
+const scaffoldChannelConfigTmpl = `// In ChannelsConfig:
+	{{.Type}} {{.Type}}Config ` + "`" + `json:"{{.JSON}}"` + "`" + `
+`
+
+const scaffoldChannelConfigTypeTmpl = `
+// {{.Type}}Config contains {{.Title}} channel settings.
+type {{.Type}}Config struct {
+	Enabled   bool     ` + "`" + `json:"enabled" envconfig:"{{.Env}}_ENABLED"` + "`" + `
+	AllowFrom []string ` + "`" + `json:"allowFrom"` + "`" + `
+}
+`
+
+const scaffoldChannelWiringTmpl = `internal/cli/gateway.go, "6. Setup Channels":
+	{{.JSON}} := channels.New{{.Type}}Channel(cfg.Channels.{{.Type}}, msgBus, timeSvc)
+
+internal/cli/gateway.go, next to the other startChannel calls:
+	startChannel("{{.Name}}", cfg.Channels.{{.Type}}.Enabled, {{.JSON}}.Start)
+`
+
+const scaffoldToolTmpl = `package tools
+
+import (
+	"context"
+	"strings"
+)
+
+// {{.Type}}Tool TODO: describe what the tool does.
+type {{.Type}}Tool struct{}
+
+// New{{.Type}}Tool creates the {{.Name}} tool.
+func New{{.Type}}Tool() *{{.Type}}Tool {
+	return &{{.Type}}Tool{}
+}
+
+func (t *{{.Type}}Tool) Name() string { return "{{.Name}}" }
+
+// Tier is read-only by default; use TierWrite or TierHighRisk for tools
+// with side effects.
+func (t *{{.Type}}Tool) Tier() int { return TierReadOnly }
+
+func (t *{{.Type}}Tool) Description() string {
+	return "TODO: tell the model when to call {{.Name}} and what it returns."
+}
+
+func (t *{{.Type}}Tool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"input": map[string]any{
+				"type":        "string",
+				"description": "TODO: describe the input",
+			},
+		},
+		"required": []string{"input"},
+	}
+}
+
+func (t *{{.Type}}Tool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	input := strings.TrimSpace(GetString(params, "input", ""))
+	if input == "" {
+		return "Error: input is required", nil
+	}
+	// TODO: implement the tool.
+	return input, nil
+}
+`
+
+const scaffoldToolTestTmpl = `package tools
+
+import (
+	"context"
+	"testing"
+)
+
+func Test{{.Type}}Tool(t *testing.T) {
+	tool := New{{.Type}}Tool()
+	if tool.Name() != "{{.Name}}" {
+		t.Fatalf("unexpected name %q", tool.Name())
+	}
+	tests := []struct {
+		name   string
+		params map[string]any
+		want   string
+	}{
+		{name: "input", params: map[string]any{"input": "hello"}, want: "hello"},
+		{name: "missing input", params: map[string]any{}, want: "Error: input is required"},
+		{name: "blank input", params: map[string]any{"input": "  "}, want: "Error: input is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tool.Execute(context.Background(), tt.params)
+			if err != nil {
+				t.Fatalf("Execute() error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("Execute() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+`
+
+const scaffoldToolConfigTmpl = `// Only needed when the tool has settings. In ToolsConfig:
+	{{.Type}} {{.Type}}ToolConfig ` + "`" + `json:"{{.JSON}}"` + "`" + `
+
+// {{.Type}}ToolConfig contains {{.Name}} tool settings.
+type {{.Type}}ToolConfig struct {
+	Enabled bool ` + "`" + `json:"enabled" envconfig:"{{.Env}}_ENABLED"` + "`" + `
+}
+`
+
+const scaffoldToolWiringTmpl = `internal/agent/loop.go, registerDefaultTools:
+	l.registry.Register(tools.New{{.Type}}Tool())
+`
+
+const scaffoldProviderTmpl = `package provider
+
+import (
+	"context"
+)
+
+// {{.Type}}Provider wraps OpenAIProvider for the {{.Title}} API. Replace the
+// inner client if the API is not OpenAI-compatible.
+type {{.Type}}Provider struct {
+	inner *OpenAIProvider
+}
+
+// New{{.Type}}Provider creates a provider targeting the {{.Title}} API.
+func New{{.Type}}Provider(apiKey, apiBase, defaultModel string) *{{.Type}}Provider {
+	return &{{.Type}}Provider{
+		inner: NewOpenAIProvider(apiKey, apiBase, defaultModel),
+	}
+}
+
+func (p *{{.Type}}Provider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	return p.inner.Chat(ctx, req)
+}
+
+func (p *{{.Type}}Provider) Transcribe(ctx context.Context, req *AudioRequest) (*AudioResponse, error) {
+	return p.inner.Transcribe(ctx, req)
+}
+
+func (p *{{.Type}}Provider) Speak(ctx context.Context, req *TTSRequest) (*TTSResponse, error) {
+	return p.inner.Speak(ctx, req)
+}
+
+func (p *{{.Type}}Provider) DefaultModel() string {
+	return p.inner.DefaultModel()
+}
+
+// SetResilience applies the provider's timeout, retry and circuit breaker
+// settings.
+func (p *{{.Type}}Provider) SetResilience(providerID string, r Resilience) {
+	p.inner.SetResilience(providerID, r)
+}
+`
+
+const scaffoldProviderTestTmpl = `package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test{{.Type}}ProviderChat(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{name: "reply", status: http.StatusOK, body: ` + "`" + `{"choices":[{"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}` + "`" + `, want: "hi"},
+		{name: "bad request", status: http.StatusBadRequest, body: ` + "`" + `{"error":{"message":"bad"}}` + "`" + `, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/chat/completions" {
+					http.NotFound(w, r)
+					return
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			p := New{{.Type}}Provider("test-key", server.URL, "test-model")
+			if p.DefaultModel() != "test-model" {
+				t.Fatalf("unexpected default model %q", p.DefaultModel())
+			}
+			resp, err := p.Chat(context.Background(), &ChatRequest{Messages: []Message{{"{{"}}Role: "user", Content: "hello"{{"}}"}}})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Chat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && resp.Content != tt.want {
+				t.Fatalf("Chat() = %q, want %q", resp.Content, tt.want)
+			}
+		})
+	}
+}
+`
+
+const scaffoldProviderConfigTmpl = `// In ProvidersConfig:
+	{{.Type}} ProviderConfig ` + "`" + `json:"{{.JSON}}"` + "`" + `
+`
+
+const scaffoldProviderWiringTmpl = `internal/provider/resolver.go, providerConfigFor:
+	case "{{.Name}}":
+		return cfg.Providers.{{.Type}}
+
+internal/provider/resolver.go, newProvider:
+	case "{{.Name}}":
+		key := cfg.Providers.{{.Type}}.APIKey
+		base := cfg.Providers.{{.Type}}.APIBase
+		if key == "" {
+			return nil, &ProviderError{Provider: "{{.Name}}", Hint: "set providers.{{.JSON}}.apiKey in config"}
+		}
+		if base == "" {
+			return nil, &ProviderError{Provider: "{{.Name}}", Hint: "set providers.{{.JSON}}.apiBase in config"}
+		}
+		return New{{.Type}}Provider(key, base, model), nil
+
+Models are then selected as "{{.Name}}/<model>".
+`
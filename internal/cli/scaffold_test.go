@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScaffoldNames(t *testing.T) {
+	tests := []struct {
+		kind, name string
+		want       scaffoldNames
+		wantErr    bool
+	}{
+		{kind: "channel", name: "acme-chat", want: scaffoldNames{Name: "acme-chat", Type: "AcmeChat", JSON: "acmeChat", Env: "ACME_CHAT", File: "acme_chat", Title: "Acme Chat"}},
+		{kind: "tool", name: "word-count", want: scaffoldNames{Name: "word_count", Type: "WordCount", JSON: "wordCount", Env: "WORD_COUNT", File: "word_count", Title: "Word Count"}},
+		{kind: "provider", name: "mistral", want: scaffoldNames{Name: "mistral", Type: "Mistral", JSON: "mistral", Env: "MISTRAL", File: "mistral", Title: "Mistral"}},
+		{kind: "tool", name: "Bad", wantErr: true},
+		{kind: "tool", name: "1tool", wantErr: true},
+		{kind: "tool", name: "a--b", wantErr: true},
+		{kind: "tool", name: "../x", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := newScaffoldNames(tt.kind, tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%s %q: error = %v, wantErr %v", tt.kind, tt.name, err, tt.wantErr)
+		}
+		if err == nil && got != tt.want {
+			t.Fatalf("%s %q: got %+v, want %+v", tt.kind, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBuildScaffoldProducesValidGo(t *testing.T) {
+	for _, kind := range []string{"channel", "tool", "provider"} {
+		t.Run(kind, func(t *testing.T) {
+			n, err := newScaffoldNames(kind, "acme-thing")
+			if err != nil {
+				t.Fatal(err)
+			}
+			plan, err := buildScaffold(kind, n)
+			if err != nil {
+				t.Fatalf("build: %v", err)
+			}
+			if len(plan.Files) != 2 || !strings.HasSuffix(plan.Files[1].Path, "acme_thing_test.go") {
+				t.Fatalf("unexpected files: %+v", plan.Files)
+			}
+			for _, f := range plan.Files {
+				if _, err := parser.ParseFile(token.NewFileSet(), f.Path, f.Content, 0); err != nil {
+					t.Fatalf("%s does not parse: %v", f.Path, err)
+				}
+			}
+			if !strings.Contains(string(plan.Files[1].Content), "tests := []struct") {
+				t.Fatalf("expected a table-driven test, got:\n%s", plan.Files[1].Content)
+			}
+			if plan.Config == "" || !strings.Contains(plan.Wiring, "AcmeThing") {
+				t.Fatalf("expected config and wiring snippets, got %q / %q", plan.Config, plan.Wiring)
+			}
+		})
+	}
+}
+
+func TestScaffoldCommandWritesFiles(t *testing.T) {
+	root := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(root, "internal", "channels"), 0o755)
+	_ = os.MkdirAll(filepath.Join(root, "internal", "config"), 0o755)
+	_ = os.WriteFile(filepath.Join(root, "internal", "config", "config.go"), []byte("package config\n"), 0o644)
+	defer func() { scaffoldRoot, scaffoldForce = ".", false }()
+
+	if _, err := runRootCommand(t, "scaffold", "channel", "acme-chat", "--root", root); err == nil || !strings.Contains(err.Error(), "not a KafClaw source tree") {
+		t.Fatalf("expected a refusal outside the source tree, got %v", err)
+	}
+	_ = os.WriteFile(filepath.Join(root, "go.mod"), []byte("module "+scaffoldModulePath+"\n\ngo 1.24\n"), 0o644)
+
+	out, err := runRootCommand(t, "scaffold", "channel", "acme-chat", "--root", root)
+	if err != nil {
+		t.Fatalf("scaffold channel: %v", err)
+	}
+	for _, want := range []string{"internal/channels/acme_chat.go", "internal/channels/acme_chat_test.go", "AcmeChat AcmeChatConfig", `startChannel("acme-chat"`} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%s", want, out)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "internal", "channels", "acme_chat.go")); err != nil {
+		t.Fatalf("channel file not written: %v", err)
+	}
+	cfg, _ := os.ReadFile(filepath.Join(root, "internal", "config", "config.go"))
+	if strings.Count(string(cfg), "type AcmeChatConfig struct") != 1 {
+		t.Fatalf("expected the config type appended once:\n%s", cfg)
+	}
+
+	if _, err := runRootCommand(t, "scaffold", "channel", "acme-chat", "--root", root); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected a refusal to overwrite, got %v", err)
+	}
+	if _, err := runRootCommand(t, "scaffold", "channel", "acme-chat", "--root", root, "--force"); err != nil {
+		t.Fatalf("scaffold --force: %v", err)
+	}
+	cfg, _ = os.ReadFile(filepath.Join(root, "internal", "config", "config.go"))
+	if strings.Count(string(cfg), "type AcmeChatConfig struct") != 1 {
+		t.Fatalf("config type must not be appended twice:\n%s", cfg)
+	}
+}
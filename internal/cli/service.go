@@ -0,0 +1,306 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/onboarding"
+	"github.com/spf13/cobra"
+)
+
+var serviceCmd = &cobra.Command{
+	Use:   "service",
+	Short: "Manage gateway and channelbridge as system services (systemd/launchd)",
+}
+
+var serviceInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Generate service units/plists, env files and log rotation config",
+	RunE:  runServiceInstall,
+}
+
+var serviceUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Stop and remove service units/plists (env files and logs are kept)",
+	RunE:  runServiceUninstall,
+}
+
+var serviceStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show service state for gateway and channelbridge",
+	RunE:  runServiceStatus,
+}
+
+var serviceTarget string
+var serviceInit string
+var serviceUser string
+var serviceBinary string
+var serviceBridgeBinary string
+var servicePort int
+var serviceLogDir string
+var serviceLogMaxSizeMB int
+var serviceLogRotate int
+var serviceEnv []string
+var serviceActivate bool
+var serviceInstallRoot string
+var serviceHome string
+var serviceJSON bool
+
+var serviceExecFn = func(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).CombinedOutput()
+}
+
+func init() {
+	for _, c := range []*cobra.Command{serviceInstallCmd, serviceUninstallCmd, serviceStatusCmd} {
+		c.Flags().StringVar(&serviceTarget, "target", "all", "Service target: gateway|channelbridge|all")
+		c.Flags().StringVar(&serviceInit, "init", "auto", "Init system: auto|systemd|launchd")
+		c.Flags().StringVar(&serviceUser, "service-user", "kafclaw", "Service user (systemd)")
+		c.Flags().StringVar(&serviceInstallRoot, "service-install-root", "/", "Root path for system files (testing/packaging)")
+		c.Flags().StringVar(&serviceHome, "service-home", "", "Home directory of the service user (defaults to user lookup)")
+		c.Flags().BoolVar(&serviceJSON, "json", false, "Output machine-readable JSON")
+		_ = c.Flags().MarkHidden("service-install-root")
+	}
+	serviceInstallCmd.Flags().StringVar(&serviceBinary, "binary", "/usr/local/bin/kafclaw", "kafclaw binary path")
+	serviceInstallCmd.Flags().StringVar(&serviceBridgeBinary, "bridge-binary", "/usr/local/bin/channelbridge", "channelbridge binary path")
+	serviceInstallCmd.Flags().IntVar(&servicePort, "port", 0, "Gateway port (defaults to config gateway.port)")
+	serviceInstallCmd.Flags().StringVar(&serviceLogDir, "log-dir", "", "Log directory (default /var/log/kafclaw or ~/Library/Logs/kafclaw)")
+	serviceInstallCmd.Flags().IntVar(&serviceLogMaxSizeMB, "log-max-size", 50, "Rotate logs when they exceed this size in MB")
+	serviceInstallCmd.Flags().IntVar(&serviceLogRotate, "log-rotate", 7, "Number of rotated log files to keep")
+	serviceInstallCmd.Flags().StringArrayVar(&serviceEnv, "env", nil, "Set KEY=VALUE in the service env file (repeatable)")
+	serviceInstallCmd.Flags().BoolVar(&serviceActivate, "activate", true, "Enable and start services after install")
+
+	serviceCmd.AddCommand(serviceInstallCmd, serviceUninstallCmd, serviceStatusCmd)
+	rootCmd.AddCommand(serviceCmd)
+}
+
+func resolveServiceInit() (string, error) {
+	switch strings.ToLower(strings.TrimSpace(serviceInit)) {
+	case "", "auto":
+		switch daemonOS {
+		case "linux":
+			return onboarding.InitSystemd, nil
+		case "darwin":
+			return onboarding.InitLaunchd, nil
+		}
+		return "", fmt.Errorf("no supported init system on %s (use --init systemd|launchd)", daemonOS)
+	case onboarding.InitSystemd:
+		return onboarding.InitSystemd, nil
+	case onboarding.InitLaunchd:
+		return onboarding.InitLaunchd, nil
+	default:
+		return "", fmt.Errorf("unknown init system %q", serviceInit)
+	}
+}
+
+func resolveServiceTargets(port int) ([]onboarding.ServiceTarget, error) {
+	all := onboarding.DefaultServiceTargets(serviceBinary, serviceBridgeBinary, port)
+	switch strings.ToLower(strings.TrimSpace(serviceTarget)) {
+	case "", "all":
+		return all, nil
+	case onboarding.ServiceGateway:
+		return all[:1], nil
+	case onboarding.ServiceChannelBridge, "bridge":
+		return all[1:], nil
+	default:
+		return nil, fmt.Errorf("unknown service target %q (use gateway|channelbridge|all)", serviceTarget)
+	}
+}
+
+func parseServiceEnv(pairs []string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, p := range pairs {
+		k, v, ok := strings.Cut(p, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid --env %q (expected KEY=VALUE)", p)
+		}
+		out[k] = v
+	}
+	return out, nil
+}
+
+func serviceOptions(initSystem string, targets []onboarding.ServiceTarget) onboarding.ServiceInstallOptions {
+	user := serviceUser
+	if initSystem == onboarding.InitLaunchd {
+		user = ""
+	}
+	return onboarding.ServiceInstallOptions{
+		InitSystem:     initSystem,
+		Targets:        targets,
+		ServiceUser:    user,
+		ServiceHome:    serviceHome,
+		InstallRoot:    serviceInstallRoot,
+		LogDir:         serviceLogDir,
+		LogMaxSizeMB:   serviceLogMaxSizeMB,
+		LogRotateCount: serviceLogRotate,
+		Version:        version,
+	}
+}
+
+func runServiceInstall(cmd *cobra.Command, args []string) error {
+	initSystem, err := resolveServiceInit()
+	if err != nil {
+		return serviceResult(cmd, "error", "install", nil, err.Error())
+	}
+	port := servicePort
+	if port <= 0 {
+		port = 18790
+		if cfg, err := config.Load(); err == nil && cfg.Gateway.Port > 0 {
+			port = cfg.Gateway.Port
+		}
+	}
+	targets, err := resolveServiceTargets(port)
+	if err != nil {
+		return serviceResult(cmd, "error", "install", nil, err.Error())
+	}
+	env, err := parseServiceEnv(serviceEnv)
+	if err != nil {
+		return serviceResult(cmd, "error", "install", nil, err.Error())
+	}
+	opts := serviceOptions(initSystem, targets)
+	opts.Env = env
+
+	res, err := onboarding.InstallServices(opts)
+	if err != nil {
+		return serviceResult(cmd, "error", "install", nil, err.Error())
+	}
+	result := map[string]any{
+		"initSystem":    res.InitSystem,
+		"units":         res.Units,
+		"logRotatePath": res.LogRotatePath,
+		"userCreated":   res.UserCreated,
+		"activated":     serviceActivate,
+	}
+	if serviceActivate {
+		if initSystem == onboarding.InitSystemd && daemonCurrentEUID() != 0 {
+			return serviceResult(cmd, "error", "install", result, "activation requires root privileges")
+		}
+		if err := activateServices(initSystem, res.Units); err != nil {
+			return serviceResult(cmd, "error", "install", result, err.Error())
+		}
+	}
+	return serviceResult(cmd, "ok", "install", result, "")
+}
+
+func activateServices(initSystem string, units []onboarding.ServiceUnitResult) error {
+	if initSystem == onboarding.InitLaunchd {
+		for _, u := range units {
+			_, _ = serviceExecFn("launchctl", "unload", u.UnitPath)
+			if out, err := serviceExecFn("launchctl", "load", "-w", u.UnitPath); err != nil {
+				return fmt.Errorf("launchctl load %s failed: %w (%s)", u.UnitPath, err, strings.TrimSpace(string(out)))
+			}
+		}
+		return nil
+	}
+	if _, err := serviceExecFn("systemctl", "daemon-reload"); err != nil {
+		return fmt.Errorf("systemctl daemon-reload failed: %w", err)
+	}
+	for _, u := range units {
+		if out, err := serviceExecFn("systemctl", "enable", "--now", u.Unit); err != nil {
+			return fmt.Errorf("systemctl enable --now %s failed: %w (%s)", u.Unit, err, strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
+
+func runServiceUninstall(cmd *cobra.Command, args []string) error {
+	initSystem, err := resolveServiceInit()
+	if err != nil {
+		return serviceResult(cmd, "error", "uninstall", nil, err.Error())
+	}
+	targets, err := resolveServiceTargets(0)
+	if err != nil {
+		return serviceResult(cmd, "error", "uninstall", nil, err.Error())
+	}
+	if initSystem == onboarding.InitSystemd && daemonCurrentEUID() != 0 {
+		return serviceResult(cmd, "error", "uninstall", nil, "uninstall requires root privileges")
+	}
+	opts := serviceOptions(initSystem, targets)
+	for _, t := range targets {
+		u := onboarding.ServiceUnitPaths(opts, t.Name)
+		if initSystem == onboarding.InitLaunchd {
+			_, _ = serviceExecFn("launchctl", "unload", "-w", u.UnitPath)
+		} else {
+			_, _ = serviceExecFn("systemctl", "disable", "--now", u.Unit)
+		}
+	}
+	removed, err := onboarding.UninstallServices(opts)
+	if err != nil {
+		return serviceResult(cmd, "error", "uninstall", map[string]any{"removed": removed}, err.Error())
+	}
+	if initSystem == onboarding.InitSystemd {
+		_, _ = serviceExecFn("systemctl", "daemon-reload")
+	}
+	return serviceResult(cmd, "ok", "uninstall", map[string]any{"removed": removed}, "")
+}
+
+func runServiceStatus(cmd *cobra.Command, args []string) error {
+	initSystem, err := resolveServiceInit()
+	if err != nil {
+		return serviceResult(cmd, "error", "status", nil, err.Error())
+	}
+	targets, err := resolveServiceTargets(0)
+	if err != nil {
+		return serviceResult(cmd, "error", "status", nil, err.Error())
+	}
+	opts := serviceOptions(initSystem, targets)
+	services := map[string]any{}
+	healthy := true
+	for _, t := range targets {
+		u := onboarding.ServiceUnitPaths(opts, t.Name)
+		entry := map[string]any{"unit": u.Unit, "unitPath": u.UnitPath, "logPath": u.LogPath}
+		if initSystem == onboarding.InitLaunchd {
+			out, err := serviceExecFn("launchctl", "list", u.Unit)
+			entry["loaded"] = err == nil
+			if err != nil {
+				healthy = false
+			} else {
+				entry["output"] = strings.TrimSpace(string(out))
+			}
+		} else {
+			enabledOut, enabledErr := serviceExecFn("systemctl", "is-enabled", u.Unit)
+			activeOut, activeErr := serviceExecFn("systemctl", "is-active", u.Unit)
+			entry["enabled"] = strings.TrimSpace(string(enabledOut))
+			entry["active"] = strings.TrimSpace(string(activeOut))
+			if enabledErr != nil || activeErr != nil {
+				healthy = false
+			}
+		}
+		services[t.Name] = entry
+	}
+	result := map[string]any{"initSystem": initSystem, "services": services}
+	if !healthy {
+		return serviceResult(cmd, "error", "status", result, "one or more services not enabled/active")
+	}
+	return serviceResult(cmd, "ok", "status", result, "")
+}
+
+func serviceResult(cmd *cobra.Command, status, action string, result map[string]any, errMsg string) error {
+	if serviceJSON {
+		payload := map[string]any{
+			"status":  strings.TrimSpace(status),
+			"command": "service",
+			"action":  strings.TrimSpace(action),
+		}
+		if len(result) > 0 {
+			payload["result"] = result
+		}
+		if strings.TrimSpace(errMsg) != "" {
+			payload["error"] = strings.TrimSpace(errMsg)
+		}
+		b, _ := json.MarshalIndent(payload, "", "  ")
+		fmt.Fprintln(cmd.OutOrStdout(), string(b))
+		if strings.EqualFold(status, "error") {
+			return fmt.Errorf("%s", errMsg)
+		}
+		return nil
+	}
+	if strings.EqualFold(status, "error") {
+		return fmt.Errorf("%s", errMsg)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "service %s: ok\n", action)
+	return nil
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestRunServiceInstallSystemdWithoutActivation(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+
+	origInit, origRoot, origHome := serviceInit, serviceInstallRoot, serviceHome
+	origActivate, origJSON, origTarget, origEnv := serviceActivate, serviceJSON, serviceTarget, serviceEnv
+	defer func() {
+		serviceInit, serviceInstallRoot, serviceHome = origInit, origRoot, origHome
+		serviceActivate, serviceJSON, serviceTarget, serviceEnv = origActivate, origJSON, origTarget, origEnv
+	}()
+
+	serviceInit = "systemd"
+	serviceInstallRoot = filepath.Join(tmp, "root")
+	serviceHome = filepath.Join(tmp, "home")
+	serviceActivate = false
+	serviceJSON = true
+	serviceTarget = "all"
+	serviceEnv = []string{"FOO=bar"}
+
+	out := &bytes.Buffer{}
+	cmd := &cobra.Command{}
+	cmd.SetOut(out)
+	if err := runServiceInstall(cmd, nil); err != nil {
+		t.Fatalf("service install failed: %v (%s)", err, out.String())
+	}
+	for _, want := range []string{`"status": "ok"`, "kafclaw-gateway.service", "kafclaw-channelbridge.service"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("expected %q in output: %s", want, out.String())
+		}
+	}
+}
+
+func TestRunServiceInstallRejectsBadEnvAndTarget(t *testing.T) {
+	origInit, origEnv, origTarget, origJSON := serviceInit, serviceEnv, serviceTarget, serviceJSON
+	defer func() {
+		serviceInit, serviceEnv, serviceTarget, serviceJSON = origInit, origEnv, origTarget, origJSON
+	}()
+	serviceInit = "systemd"
+	serviceJSON = false
+	cmd := &cobra.Command{}
+	cmd.SetOut(&bytes.Buffer{})
+
+	serviceTarget = "nope"
+	serviceEnv = nil
+	if err := runServiceInstall(cmd, nil); err == nil {
+		t.Fatal("expected unknown target error")
+	}
+	serviceTarget = "gateway"
+	serviceEnv = []string{"novalue"}
+	if err := runServiceInstall(cmd, nil); err == nil {
+		t.Fatal("expected invalid env error")
+	}
+}
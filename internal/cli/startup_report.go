@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/provider"
+)
+
+// Component states reported at startup and updated while the gateway runs.
+const (
+	componentOK       = "ok"
+	componentDegraded = "degraded"
+	componentFailed   = "failed"
+	componentDisabled = "disabled"
+)
+
+// componentStatus is the state of one gateway dependency.
+type componentStatus struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	Reason    string    `json:"reason,omitempty"`
+	Critical  bool      `json:"critical"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// startupReport collects component states during the start sequence instead
+// of leaving them scattered across log lines. Components may be updated later
+// (e.g. a Kafka router that stops).
+type startupReport struct {
+	mu         sync.RWMutex
+	components map[string]componentStatus
+	order      []string
+	incident   *componentIncident
+}
+
+// componentIncident is the latest transition of a component into a degraded
+// or failed state. It outlives the recovery of the component.
+type componentIncident struct {
+	Component string    `json:"component"`
+	Status    string    `json:"status"`
+	At        time.Time `json:"at"`
+}
+
+func newStartupReport() *startupReport {
+	return &startupReport{components: map[string]componentStatus{}}
+}
+
+// set records a component state. Critical components fail strict startup.
+func (r *startupReport) set(name, status, reason string, critical bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	prev, ok := r.components[name]
+	if !ok {
+		r.order = append(r.order, name)
+	}
+	now := time.Now().UTC()
+	r.components[name] = componentStatus{
+		Name:      name,
+		Status:    status,
+		Reason:    reason,
+		Critical:  critical,
+		UpdatedAt: now,
+	}
+	if (status == componentDegraded || status == componentFailed) && prev.Status != status {
+		r.incident = &componentIncident{Component: name, Status: status, At: now}
+	}
+}
+
+// lastIncident returns the latest component incident, or nil.
+func (r *startupReport) lastIncident() *componentIncident {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.incident == nil {
+		return nil
+	}
+	inc := *r.incident
+	return &inc
+}
+
+// setErr records ok for a nil error and failed with the error text otherwise.
+func (r *startupReport) setErr(name string, err error, critical bool) {
+	if err != nil {
+		r.set(name, componentFailed, err.Error(), critical)
+		return
+	}
+	r.set(name, componentOK, "", critical)
+}
+
+// snapshot returns all components in registration order.
+func (r *startupReport) snapshot() []componentStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]componentStatus, 0, len(r.order))
+	for _, name := range r.order {
+		out = append(out, r.components[name])
+	}
+	return out
+}
+
+// overall summarizes the report: failed if a critical component failed,
+// degraded if any component is degraded or failed, ok otherwise.
+func (r *startupReport) overall() string {
+	return overallStatus(r.snapshot())
+}
+
+func overallStatus(components []componentStatus) string {
+	status := componentOK
+	for _, c := range components {
+		switch {
+		case c.Status == componentFailed && c.Critical:
+			return componentFailed
+		case c.Status == componentFailed || c.Status == componentDegraded:
+			status = componentDegraded
+		}
+	}
+	return status
+}
+
+// criticalFailures lists failed critical components, sorted by name.
+func (r *startupReport) criticalFailures() []componentStatus {
+	var out []componentStatus
+	for _, c := range r.snapshot() {
+		if c.Critical && c.Status == componentFailed {
+			out = append(out, c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// print writes a one-line-per-component summary.
+func (r *startupReport) print(w io.Writer) {
+	icons := map[string]string{
+		componentOK:       "✅",
+		componentDegraded: "⚠️ ",
+		componentFailed:   "❌",
+		componentDisabled: "➖",
+	}
+	fmt.Fprintf(w, "Startup report (%s):\n", r.overall())
+	for _, c := range r.snapshot() {
+		line := fmt.Sprintf("  %s %-14s %s", icons[c.Status], c.Name, c.Status)
+		if c.Critical {
+			line += " [critical]"
+		}
+		if c.Reason != "" {
+			line += " — " + c.Reason
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// providerBreakerComponents reports each provider circuit breaker as a
+// runtime component: closed is ok, half-open degraded and open failed.
+func providerBreakerComponents(states []provider.BreakerState) []componentStatus {
+	out := make([]componentStatus, 0, len(states))
+	for _, s := range states {
+		c := componentStatus{Name: "provider:" + s.Provider, Status: componentOK, UpdatedAt: time.Now().UTC()}
+		switch s.State {
+		case "open":
+			c.Status = componentFailed
+			c.Reason = fmt.Sprintf("circuit open after %d consecutive failures: %s", s.ConsecutiveFailures, s.LastError)
+			if s.RetryAt != nil {
+				c.Reason += fmt.Sprintf(" (retry at %s)", s.RetryAt.UTC().Format(time.RFC3339))
+			}
+		case "half_open":
+			c.Status = componentDegraded
+			c.Reason = "circuit half-open, probing provider"
+		}
+		out = append(out, c)
+	}
+	return out
+}
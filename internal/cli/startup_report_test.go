@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/provider"
+)
+
+func TestStartupReport(t *testing.T) {
+	r := newStartupReport()
+	r.set("timeline", componentOK, "", true)
+	r.set("slack", componentDisabled, "", false)
+	if r.overall() != componentOK {
+		t.Fatalf("expected ok, got %s", r.overall())
+	}
+
+	r.set("work_repo", componentDegraded, "not a git repo", false)
+	r.setErr("whatsapp", errors.New("session expired"), false)
+	if r.overall() != componentDegraded || len(r.criticalFailures()) != 0 {
+		t.Fatalf("non-critical failures must only degrade, got %s", r.overall())
+	}
+
+	r.setErr("memory", errors.New("embedding dimension mismatch"), true)
+	if r.overall() != componentFailed {
+		t.Fatalf("expected failed, got %s", r.overall())
+	}
+	if f := r.criticalFailures(); len(f) != 1 || f[0].Name != "memory" {
+		t.Fatalf("unexpected critical failures: %+v", f)
+	}
+
+	// Updates keep the original order.
+	r.setErr("memory", nil, true)
+	snap := r.snapshot()
+	if len(snap) != 5 || snap[4].Name != "memory" || snap[4].Status != componentOK {
+		t.Fatalf("unexpected snapshot: %+v", snap)
+	}
+
+	var buf bytes.Buffer
+	r.print(&buf)
+	if !strings.Contains(buf.String(), "Startup report (degraded)") || !strings.Contains(buf.String(), "session expired") {
+		t.Fatalf("unexpected report:\n%s", buf.String())
+	}
+}
+
+func TestStartupReportLastIncident(t *testing.T) {
+	r := newStartupReport()
+	r.set("kafka", componentOK, "", true)
+	if r.lastIncident() != nil {
+		t.Fatal("expected no incident while all components are ok")
+	}
+	r.set("kafka", componentDegraded, "router stopped", true)
+	first := r.lastIncident()
+	if first == nil || first.Component != "kafka" || first.Status != componentDegraded {
+		t.Fatalf("unexpected incident: %+v", first)
+	}
+	r.set("kafka", componentDegraded, "router stopped again", true)
+	r.set("kafka", componentOK, "", true)
+	if got := r.lastIncident(); got == nil || !got.At.Equal(first.At) {
+		t.Fatalf("incident must survive recovery and ignore repeated states, got %+v", got)
+	}
+}
+
+func TestProviderBreakerComponents(t *testing.T) {
+	retry := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	comps := providerBreakerComponents([]provider.BreakerState{
+		{Provider: "claude", State: "closed"},
+		{Provider: "openai", State: "open", ConsecutiveFailures: 5, LastError: "status 503", RetryAt: &retry},
+	})
+	if len(comps) != 2 || comps[0].Name != "provider:claude" || comps[0].Status != componentOK {
+		t.Fatalf("unexpected components: %+v", comps)
+	}
+	if comps[1].Status != componentFailed || comps[1].Critical || !strings.Contains(comps[1].Reason, "status 503") || !strings.Contains(comps[1].Reason, "2026-01-02T03:04:05Z") {
+		t.Fatalf("unexpected open breaker component: %+v", comps[1])
+	}
+	if overallStatus(comps) != componentDegraded {
+		t.Fatalf("an open breaker must only degrade, got %s", overallStatus(comps))
+	}
+}
@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+	"github.com/spf13/cobra"
+)
+
+var (
+	timelineJSON bool
+
+	redactEventID string
+	redactPattern string
+	redactSince   string
+	redactUntil   string
+	redactReason  string
+)
+
+var timelineCmd = &cobra.Command{
+	Use:   "timeline",
+	Short: "Maintain the local timeline database",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
+	},
+}
+
+var timelineRedactCmd = &cobra.Command{
+	Use:   "redact",
+	Short: "Redact sensitive content from recorded timeline events",
+	Long: `Replace sensitive content in timeline events with a redaction marker.
+
+Use --event-id to redact a whole event, or --pattern (regex) with an optional
+--since/--until range to replace matching substrings. Each redaction is logged
+with a hash of the original content and recorded as a REDACTION audit event.`,
+	RunE: runTimelineRedact,
+}
+
+func init() {
+	timelineCmd.PersistentFlags().BoolVar(&timelineJSON, "json", false, "Output machine-readable JSON")
+
+	timelineRedactCmd.Flags().StringVar(&redactEventID, "event-id", "", "Event ID to redact")
+	timelineRedactCmd.Flags().StringVar(&redactPattern, "pattern", "", "Regex of content to redact")
+	timelineRedactCmd.Flags().StringVar(&redactSince, "since", "", "Only events at or after this time (RFC3339 or duration like 24h)")
+	timelineRedactCmd.Flags().StringVar(&redactUntil, "until", "", "Only events at or before this time (RFC3339)")
+	timelineRedactCmd.Flags().StringVar(&redactReason, "reason", "", "Reason recorded in the redaction audit note")
+
+	timelineCmd.AddCommand(timelineRedactCmd)
+	rootCmd.AddCommand(timelineCmd)
+}
+
+// buildRedactionRequest validates redaction input shared by the CLI and the
+// /api/v1/timeline/redact endpoint.
+func buildRedactionRequest(eventID, pattern, since, until, reason string) (timeline.RedactionRequest, error) {
+	req := timeline.RedactionRequest{
+		EventID: strings.TrimSpace(eventID),
+		Pattern: pattern,
+		Reason:  strings.TrimSpace(reason),
+	}
+	if req.EventID == "" && strings.TrimSpace(req.Pattern) == "" {
+		return req, fmt.Errorf("event_id or pattern required")
+	}
+	var err error
+	if req.Since, err = parseRedactionTime(since); err != nil {
+		return req, fmt.Errorf("invalid since: %w", err)
+	}
+	if req.Until, err = parseRedactionTime(until); err != nil {
+		return req, fmt.Errorf("invalid until: %w", err)
+	}
+	if req.Since != nil && req.Until != nil && req.Until.Before(*req.Since) {
+		return req, fmt.Errorf("until must not be before since")
+	}
+	return req, nil
+}
+
+// parseRedactionTime accepts RFC3339 timestamps or a Go duration meaning "that long ago".
+func parseRedactionTime(v string) (*time.Time, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return nil, nil
+	}
+	if ts, err := time.Parse(time.RFC3339, v); err == nil {
+		return &ts, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return nil, fmt.Errorf("expected RFC3339 or duration, got %q", v)
+	}
+	ts := time.Now().Add(-d)
+	return &ts, nil
+}
+
+func runTimelineRedact(cmd *cobra.Command, args []string) error {
+	req, err := buildRedactionRequest(redactEventID, redactPattern, redactSince, redactUntil, redactReason)
+	if err != nil {
+		return err
+	}
+	req.Actor = "cli"
+	timeSvc, err := loadGroupTimeline()
+	if err != nil {
+		return err
+	}
+	defer timeSvc.Close()
+
+	res, err := timeSvc.RedactEvents(req)
+	if err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	if timelineJSON {
+		b, _ := json.MarshalIndent(res, "", "  ")
+		fmt.Fprintln(out, string(b))
+		return nil
+	}
+	fmt.Fprintf(out, "redacted %d event(s)\n", len(res.Events))
+	for _, e := range res.Events {
+		fmt.Fprintf(out, "  %s matches=%d sha256=%s\n", e.EventID, e.Matches, e.OriginalSHA256)
+	}
+	if res.AuditEventID != "" {
+		fmt.Fprintf(out, "audit event: %s\n", res.AuditEventID)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestTimelineRedactCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, ".kafclaw"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	origHome := os.Getenv("HOME")
+	defer os.Setenv("HOME", origHome)
+	_ = os.Setenv("HOME", tmpDir)
+	defer func() {
+		redactEventID, redactPattern, redactSince, redactUntil, redactReason = "", "", "", "", ""
+		timelineJSON = false
+	}()
+
+	svc, err := timeline.NewTimelineService(filepath.Join(tmpDir, ".kafclaw", "timeline.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = svc.AddEvent(&timeline.TimelineEvent{EventID: "evt-1", Timestamp: time.Now(), SenderID: "u", EventType: "TEXT", ContentText: "password=hunter2"})
+	svc.Close()
+
+	out, err := runRootCommand(t, "timeline", "redact", "--pattern=hunter2", "--since=1h", "--reason=leak", "--json")
+	if err != nil {
+		t.Fatalf("timeline redact: %v", err)
+	}
+	if !strings.Contains(out, `"event_id": "evt-1"`) || !strings.Contains(out, `"audit_event_id"`) {
+		t.Fatalf("unexpected output: %s", out)
+	}
+
+	svc, err = timeline.NewTimelineService(filepath.Join(tmpDir, ".kafclaw", "timeline.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer svc.Close()
+	events, _ := svc.GetEvents(timeline.FilterArgs{})
+	for _, e := range events {
+		if e.EventID == "evt-1" && e.ContentText != "password="+timeline.RedactionMarker {
+			t.Fatalf("event not redacted: %q", e.ContentText)
+		}
+	}
+}
+
+func TestBuildRedactionRequestValidation(t *testing.T) {
+	if _, err := buildRedactionRequest("", " ", "", "", ""); err == nil {
+		t.Fatal("expected error without event id or pattern")
+	}
+	if _, err := buildRedactionRequest("", "x", "yesterday", "", ""); err == nil {
+		t.Fatal("expected error for invalid since")
+	}
+	if _, err := buildRedactionRequest("", "x", "2026-01-02T00:00:00Z", "2026-01-01T00:00:00Z", ""); err == nil {
+		t.Fatal("expected error when until is before since")
+	}
+	req, err := buildRedactionRequest("evt-1", "", "", "", " why ")
+	if err != nil || req.EventID != "evt-1" || req.Reason != "why" {
+		t.Fatalf("unexpected request: %+v err=%v", req, err)
+	}
+}
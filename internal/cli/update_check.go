@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+// releaseAsset is a downloadable file of a release.
+type releaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// releaseInfo is the subset of a GitHub release document used by the update
+// check and `kafclaw upgrade`.
+type releaseInfo struct {
+	TagName     string         `json:"tag_name"`
+	HTMLURL     string         `json:"html_url"`
+	PublishedAt string         `json:"published_at"`
+	Assets      []releaseAsset `json:"assets"`
+}
+
+func (r releaseInfo) asset(name string) (releaseAsset, bool) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return releaseAsset{}, false
+}
+
+// releaseBinaryName is the release asset of the running platform.
+func releaseBinaryName() string {
+	name := fmt.Sprintf("kafclaw-%s-%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// releaseEndpoint returns the configured release endpoint, or the default.
+func releaseEndpoint(cfg *config.Config) string {
+	if cfg != nil {
+		if ep := strings.TrimSpace(cfg.Gateway.UpdateCheck.Endpoint); ep != "" {
+			return ep
+		}
+	}
+	return config.DefaultConfig().Gateway.UpdateCheck.Endpoint
+}
+
+// fetchRelease loads the latest release from endpoint, or the release of tag
+// when set (the ".../releases/latest" suffix is swapped for ".../tags/<tag>").
+func fetchRelease(ctx context.Context, client *http.Client, endpoint, tag string) (releaseInfo, error) {
+	target := endpoint
+	if tag != "" {
+		target = strings.TrimSuffix(strings.TrimRight(endpoint, "/"), "/latest") + "/tags/" + tag
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return releaseInfo{}, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "kafclaw/"+version)
+	resp, err := client.Do(req)
+	if err != nil {
+		return releaseInfo{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return releaseInfo{}, fmt.Errorf("release endpoint %s: %s", target, resp.Status)
+	}
+	var rel releaseInfo
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 4<<20)).Decode(&rel); err != nil {
+		return releaseInfo{}, fmt.Errorf("decode release: %w", err)
+	}
+	if strings.TrimSpace(rel.TagName) == "" {
+		return releaseInfo{}, fmt.Errorf("release endpoint %s returned no tag_name", target)
+	}
+	return rel, nil
+}
+
+// isNewerVersion reports whether latest is a higher semver than current.
+// Unparseable versions never count as newer.
+func isNewerVersion(current, latest string) bool {
+	cur, err := parseSemver(current)
+	if err != nil {
+		return false
+	}
+	lat, err := parseSemver(latest)
+	if err != nil {
+		return false
+	}
+	return versionLess(cur, lat)
+}
+
+// updateChecker periodically polls the release endpoint and remembers the
+// newest release for /api/v1/status and the dashboard.
+type updateChecker struct {
+	endpoint string
+	interval time.Duration
+	current  string
+	client   *http.Client
+
+	mu        sync.RWMutex
+	latest    releaseInfo
+	checkedAt time.Time
+	lastErr   string
+}
+
+func newUpdateChecker(cfg config.GatewayUpdateCheckConfig, current string) *updateChecker {
+	interval := time.Duration(cfg.IntervalHours) * time.Hour
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	endpoint := strings.TrimSpace(cfg.Endpoint)
+	if endpoint == "" {
+		endpoint = config.DefaultConfig().Gateway.UpdateCheck.Endpoint
+	}
+	return &updateChecker{
+		endpoint: endpoint,
+		interval: interval,
+		current:  current,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// run checks once at startup and then every interval until ctx is done.
+func (c *updateChecker) run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		c.check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *updateChecker) check(ctx context.Context) {
+	rel, err := fetchRelease(ctx, c.client, c.endpoint, "")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checkedAt = time.Now().UTC()
+	if err != nil {
+		c.lastErr = err.Error()
+		return
+	}
+	c.lastErr = ""
+	announced := c.latest.TagName
+	c.latest = rel
+	if rel.TagName != announced && isNewerVersion(c.current, rel.TagName) {
+		slog.Info("KafClaw update available; run `kafclaw upgrade`", "version", normalizeVersion(rel.TagName), "running", c.current)
+	}
+}
+
+// status is the "update" object of /api/v1/status.
+func (c *updateChecker) status() map[string]any {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := map[string]any{
+		"enabled":   true,
+		"available": isNewerVersion(c.current, c.latest.TagName),
+	}
+	if c.latest.TagName != "" {
+		out["latest_version"] = normalizeVersion(c.latest.TagName)
+		out["release_url"] = c.latest.HTMLURL
+	}
+	if !c.checkedAt.IsZero() {
+		out["checked_at"] = c.checkedAt.Format(time.RFC3339)
+	}
+	if c.lastErr != "" {
+		out["error"] = c.lastErr
+	}
+	return out
+}
@@ -0,0 +1,323 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var upgradeVersion string
+var upgradeCheckOnly bool
+var upgradeDryRun bool
+var upgradeSkipBackup bool
+var upgradeBackupDir string
+var upgradeAllowDowngrade bool
+var upgradeNoSignatureVerify bool
+var upgradeJSON bool
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Download, verify and install the latest KafClaw release",
+	Long: `Download the release binary for this platform, verify its SHA256 checksum
+(and cosign signature), back up config, env and timeline, and replace the
+running executable in place. Config and timeline database are left untouched.`,
+	Args: cobra.NoArgs,
+	RunE: runUpgrade,
+}
+
+var (
+	executablePathFn         = os.Executable
+	verifyReleaseSignatureFn = verifyReleaseSignature
+)
+
+func init() {
+	upgradeCmd.Flags().StringVar(&upgradeVersion, "version", "", "Install a pinned version (default: latest)")
+	upgradeCmd.Flags().BoolVar(&upgradeCheckOnly, "check", false, "Only report whether a newer release exists")
+	upgradeCmd.Flags().BoolVar(&upgradeDryRun, "dry-run", false, "Download and verify without replacing the binary")
+	upgradeCmd.Flags().BoolVar(&upgradeSkipBackup, "skip-backup", false, "Skip creating pre-update backup snapshot")
+	upgradeCmd.Flags().StringVar(&upgradeBackupDir, "backup-dir", "", "Backup root directory (default: ~/.kafclaw/backups)")
+	upgradeCmd.Flags().BoolVar(&upgradeAllowDowngrade, "allow-downgrade", false, "Allow pinned target version lower than current")
+	upgradeCmd.Flags().BoolVar(&upgradeNoSignatureVerify, "no-signature-verify", false, "Skip cosign signature verification (checksum is still verified)")
+	upgradeCmd.Flags().BoolVar(&upgradeJSON, "json", false, "Output machine-readable JSON")
+	rootCmd.AddCommand(upgradeCmd)
+}
+
+func runUpgrade(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("config compatibility check failed: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(cmd.Context(), 10*time.Minute)
+	defer cancel()
+	client := &http.Client{Timeout: 5 * time.Minute}
+
+	pinned := normalizeVersion(upgradeVersion)
+	rel, err := fetchRelease(ctx, client, releaseEndpoint(cfg), pinned)
+	if err != nil {
+		return fmt.Errorf("release lookup failed: %w", err)
+	}
+	current := strings.TrimSpace(version)
+	target := normalizeVersion(rel.TagName)
+	newer := isNewerVersion(current, target)
+
+	if upgradeCheckOnly || (pinned == "" && !newer) {
+		if upgradeJSON {
+			return printUpdateJSON(cmd, "ok", "upgrade-check", map[string]any{
+				"currentVersion":  current,
+				"latestVersion":   target,
+				"updateAvailable": newer,
+				"releaseUrl":      rel.HTMLURL,
+			}, "")
+		}
+		if newer {
+			fmt.Fprintf(cmd.OutOrStdout(), "Update available: %s (running %s)\n", target, current)
+			if rel.HTMLURL != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "Release notes: %s\n", rel.HTMLURL)
+			}
+		} else {
+			fmt.Fprintf(cmd.OutOrStdout(), "KafClaw %s is up to date.\n", current)
+		}
+		return nil
+	}
+
+	_ = emitLifecycleEvent("update", "upgrade-start", "info", "upgrade started", map[string]any{"target": target})
+	if err := preflightUpdateCompatibility(current, target, upgradeAllowDowngrade); err != nil {
+		_ = emitLifecycleEvent("update", "preflight-compat", "error", err.Error(), nil)
+		return err
+	}
+	if err := preflightUpdateRuntime(); err != nil {
+		_ = emitLifecycleEvent("update", "preflight-runtime", "error", err.Error(), nil)
+		return err
+	}
+
+	exe, err := executablePathFn()
+	if err != nil {
+		return fmt.Errorf("resolve executable: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+
+	staged, err := downloadVerifiedRelease(ctx, client, rel, filepath.Dir(exe), !upgradeNoSignatureVerify)
+	if err != nil {
+		_ = emitLifecycleEvent("update", "download", "error", err.Error(), map[string]any{"target": target})
+		return err
+	}
+	defer os.Remove(staged)
+	_ = emitLifecycleEvent("update", "download", "ok", "release binary verified", map[string]any{"target": target})
+
+	var backupPath string
+	if !upgradeSkipBackup {
+		backupRoot, err := resolveUpdateBackupRoot(upgradeBackupDir)
+		if err != nil {
+			return err
+		}
+		p, _, err := createUpdateBackup(backupRoot)
+		if err != nil {
+			_ = emitLifecycleEvent("update", "backup", "error", err.Error(), nil)
+			return err
+		}
+		backupPath = p
+		_ = emitLifecycleEvent("update", "backup", "ok", "backup created", map[string]any{"path": backupPath})
+	}
+
+	if upgradeDryRun {
+		if upgradeJSON {
+			return printUpdateJSON(cmd, "ok", "upgrade", map[string]any{
+				"dryRun":        true,
+				"targetVersion": target,
+				"backupPath":    backupPath,
+			}, "")
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Dry-run mode: %s downloaded and verified, binary not replaced.\n", target)
+		return nil
+	}
+
+	if err := os.Rename(staged, exe); err != nil {
+		_ = emitLifecycleEvent("update", "apply", "error", err.Error(), map[string]any{"path": exe})
+		return fmt.Errorf("replace %s: %w", exe, err)
+	}
+	_ = emitLifecycleEvent("update", "complete", "ok", "upgrade completed", map[string]any{"target": target})
+
+	if upgradeJSON {
+		return printUpdateJSON(cmd, "ok", "upgrade", map[string]any{
+			"previousVersion": current,
+			"targetVersion":   target,
+			"binary":          exe,
+			"backupPath":      backupPath,
+		}, "")
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Upgraded %s -> %s (%s)\n", current, target, exe)
+	if backupPath != "" {
+		fmt.Fprintf(cmd.OutOrStdout(), "Pre-update backup: %s (restore with `kafclaw update rollback --restore-binary`)\n", backupPath)
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), "Restart the gateway to run the new version.")
+	return nil
+}
+
+// downloadVerifiedRelease downloads the platform binary of rel into dir,
+// checks it against the release SHA256SUMS and, when verifySig is set, its
+// cosign signature. It returns the path of the executable staged file.
+func downloadVerifiedRelease(ctx context.Context, client *http.Client, rel releaseInfo, dir string, verifySig bool) (string, error) {
+	name := releaseBinaryName()
+	bin, ok := rel.asset(name)
+	if !ok {
+		return "", fmt.Errorf("release %s has no asset %s", rel.TagName, name)
+	}
+	sums, ok := rel.asset("SHA256SUMS")
+	if !ok {
+		return "", fmt.Errorf("release %s has no SHA256SUMS", rel.TagName)
+	}
+
+	sumsFile, err := os.CreateTemp("", "kafclaw-sums-*")
+	if err != nil {
+		return "", err
+	}
+	sumsFile.Close()
+	defer os.Remove(sumsFile.Name())
+	if err := downloadReleaseAsset(ctx, client, sums.URL, sumsFile.Name()); err != nil {
+		return "", err
+	}
+	expected, err := checksumFor(sumsFile.Name(), name)
+	if err != nil {
+		return "", err
+	}
+
+	// Stage next to the executable so the final rename stays on one filesystem.
+	staged, err := os.CreateTemp(dir, ".kafclaw-upgrade-*")
+	if err != nil {
+		return "", err
+	}
+	staged.Close()
+	verified := false
+	defer func() {
+		if !verified {
+			os.Remove(staged.Name())
+		}
+	}()
+	if err := downloadReleaseAsset(ctx, client, bin.URL, staged.Name()); err != nil {
+		return "", err
+	}
+	actual, err := fileSHA256(staged.Name())
+	if err != nil {
+		return "", err
+	}
+	if !strings.EqualFold(actual, expected) {
+		return "", fmt.Errorf("checksum mismatch for %s: expected %s, got %s", name, expected, actual)
+	}
+
+	if verifySig {
+		sig, hasSig := rel.asset(name + ".sig")
+		pem, hasPem := rel.asset(name + ".pem")
+		if !hasSig || !hasPem {
+			return "", fmt.Errorf("release %s has no signature for %s; pass --no-signature-verify if policy allows", rel.TagName, name)
+		}
+		sigPath, pemPath := staged.Name()+".sig", staged.Name()+".pem"
+		defer os.Remove(sigPath)
+		defer os.Remove(pemPath)
+		if err := downloadReleaseAsset(ctx, client, sig.URL, sigPath); err != nil {
+			return "", err
+		}
+		if err := downloadReleaseAsset(ctx, client, pem.URL, pemPath); err != nil {
+			return "", err
+		}
+		if err := verifyReleaseSignatureFn(staged.Name(), sigPath, pemPath); err != nil {
+			return "", err
+		}
+	}
+
+	if err := os.Chmod(staged.Name(), 0o755); err != nil {
+		return "", err
+	}
+	verified = true
+	return staged.Name(), nil
+}
+
+func downloadReleaseAsset(ctx context.Context, client *http.Client, url, dst string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "kafclaw/"+version)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download %s: %s", url, resp.Status)
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		out.Close()
+		return fmt.Errorf("download %s: %w", url, err)
+	}
+	return out.Close()
+}
+
+// checksumFor returns the digest of name from a sha256sum-style file.
+func checksumFor(sumsPath, name string) (string, error) {
+	f, err := os.Open(sumsPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return fields[0], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("checksum for %s not found in SHA256SUMS", name)
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// verifyReleaseSignature checks the keyless cosign signature of a release
+// binary against the KafClaw release workflow identity.
+func verifyReleaseSignature(binPath, sigPath, pemPath string) error {
+	if _, err := exec.LookPath("cosign"); err != nil {
+		return fmt.Errorf("cosign not found; install cosign or pass --no-signature-verify if policy allows")
+	}
+	out, err := exec.Command("cosign", "verify-blob",
+		"--certificate", pemPath,
+		"--signature", sigPath,
+		"--certificate-oidc-issuer", "https://token.actions.githubusercontent.com",
+		"--certificate-identity-regexp", `^https://github\.com/[Kk]af[Cc]law/[Kk]af[Cc]law/\.github/workflows/release\.yml@refs/tags/.*$`,
+		binPath,
+	).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("cosign signature verification failed: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+// newReleaseServer serves a release document for tag plus its binary and
+// SHA256SUMS assets; sum overrides the published checksum when non-empty.
+func newReleaseServer(t *testing.T, tag, binary, sum string) *httptest.Server {
+	t.Helper()
+	if sum == "" {
+		digest := sha256.Sum256([]byte(binary))
+		sum = hex.EncodeToString(digest[:])
+	}
+	name := releaseBinaryName()
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+	release := func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(releaseInfo{
+			TagName: tag,
+			HTMLURL: "https://example.test/releases/" + tag,
+			Assets: []releaseAsset{
+				{Name: name, URL: srv.URL + "/download/" + name},
+				{Name: "SHA256SUMS", URL: srv.URL + "/download/SHA256SUMS"},
+			},
+		})
+	}
+	mux.HandleFunc("/releases/latest", release)
+	mux.HandleFunc("/releases/tags/"+tag, release)
+	mux.HandleFunc("/download/"+name, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(binary))
+	})
+	mux.HandleFunc("/download/SHA256SUMS", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(sum + "  other-file\n" + sum + "  " + name + "\n"))
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestUpdateCheckerReportsNewerRelease(t *testing.T) {
+	srv := newReleaseServer(t, "v2.8.1", "bin", "")
+	checker := newUpdateChecker(config.GatewayUpdateCheckConfig{Endpoint: srv.URL + "/releases/latest"}, "2.7.0")
+	checker.check(context.Background())
+
+	status := checker.status()
+	if status["available"] != true || status["latest_version"] != "v2.8.1" {
+		t.Fatalf("expected v2.8.1 to be available, got %#v", status)
+	}
+	if _, ok := status["checked_at"]; !ok {
+		t.Fatalf("expected checked_at, got %#v", status)
+	}
+
+	current := newUpdateChecker(config.GatewayUpdateCheckConfig{Endpoint: srv.URL + "/releases/latest"}, "2.8.1")
+	current.check(context.Background())
+	if current.status()["available"] != false {
+		t.Fatalf("expected no update for current version, got %#v", current.status())
+	}
+
+	broken := newUpdateChecker(config.GatewayUpdateCheckConfig{Endpoint: srv.URL + "/missing"}, "2.7.0")
+	broken.check(context.Background())
+	if broken.status()["available"] != false || broken.status()["error"] == nil {
+		t.Fatalf("expected error status, got %#v", broken.status())
+	}
+}
+
+func TestUpgradeCommandVerifiesAndReplacesBinary(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("KAFCLAW_HOME", tmpDir)
+
+	exe := filepath.Join(tmpDir, "bin", "kafclaw")
+	if err := os.MkdirAll(filepath.Dir(exe), 0o755); err != nil {
+		t.Fatalf("mkdir bin: %v", err)
+	}
+	if err := os.WriteFile(exe, []byte("old-binary"), 0o755); err != nil {
+		t.Fatalf("write exe: %v", err)
+	}
+	origExe := executablePathFn
+	executablePathFn = func() (string, error) { return exe, nil }
+	defer func() {
+		executablePathFn = origExe
+		upgradeCheckOnly, upgradeSkipBackup, upgradeNoSignatureVerify, upgradeJSON = false, false, false, false
+	}()
+
+	writeConfig := func(endpoint string) []byte {
+		cfgPath, err := config.ConfigPath()
+		if err != nil {
+			t.Fatalf("config path: %v", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(cfgPath), 0o700); err != nil {
+			t.Fatalf("mkdir config dir: %v", err)
+		}
+		data := []byte(`{"gateway":{"updateCheck":{"endpoint":"` + endpoint + `"}}}`)
+		if err := os.WriteFile(cfgPath, data, 0o600); err != nil {
+			t.Fatalf("write config: %v", err)
+		}
+		return data
+	}
+
+	// A checksum mismatch leaves the installed binary alone.
+	bad := newReleaseServer(t, "v2.8.0", "new-binary", strings.Repeat("0", 64))
+	writeConfig(bad.URL + "/releases/latest")
+	if _, err := runRootCommand(t, "upgrade", "--skip-backup", "--no-signature-verify"); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected checksum mismatch, got %v", err)
+	}
+	if data, _ := os.ReadFile(exe); string(data) != "old-binary" {
+		t.Fatalf("binary replaced despite mismatch: %q", data)
+	}
+
+	// Without --no-signature-verify an unsigned release is refused.
+	srv := newReleaseServer(t, "v2.8.0", "new-binary", "")
+	cfgData := writeConfig(srv.URL + "/releases/latest")
+	upgradeNoSignatureVerify = false
+	if _, err := runRootCommand(t, "upgrade", "--skip-backup"); err == nil || !strings.Contains(err.Error(), "no signature") {
+		t.Fatalf("expected missing signature error, got %v", err)
+	}
+
+	out, err := runRootCommand(t, "upgrade", "--check", "--json")
+	if err != nil {
+		t.Fatalf("upgrade --check: %v", err)
+	}
+	if !strings.Contains(out, `"updateAvailable": true`) || !strings.Contains(out, `"latestVersion": "v2.8.0"`) {
+		t.Fatalf("unexpected check output: %s", out)
+	}
+	upgradeCheckOnly, upgradeJSON = false, false
+
+	out, err = runRootCommand(t, "upgrade", "--skip-backup", "--no-signature-verify")
+	if err != nil {
+		t.Fatalf("upgrade: %v (%s)", err, out)
+	}
+	if !strings.Contains(out, "-> v2.8.0") {
+		t.Fatalf("unexpected upgrade output: %s", out)
+	}
+	if data, _ := os.ReadFile(exe); string(data) != "new-binary" {
+		t.Fatalf("binary not replaced: %q", data)
+	}
+	if info, err := os.Stat(exe); err != nil || info.Mode().Perm()&0o100 == 0 {
+		t.Fatalf("expected executable binary, got %v %v", info, err)
+	}
+	cfgPath, _ := config.ConfigPath()
+	if data, _ := os.ReadFile(cfgPath); string(data) != string(cfgData) {
+		t.Fatalf("config changed by upgrade: %s", data)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(exe), ".kafclaw-upgrade-*")); len(leftovers) != 0 {
+		t.Fatalf("staged files left behind: %v", leftovers)
+	}
+}
@@ -0,0 +1,472 @@
+package cliconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/channels"
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// BundleVersion is the current declarative settings document version.
+const BundleVersion = 1
+
+// BundleRedacted replaces secret values on export. On import it means "keep
+// the value currently in the config file".
+const BundleRedacted = "<redacted>"
+
+// bundleSections are the config.json sections carried by a bundle: channel
+// accounts and access policies, repo paths, tool policies and the scheduler.
+var bundleSections = []string{"channels", "paths", "tools", "scheduler"}
+
+// Runtime state kept in the settings table that must not travel between
+// installs (counters, heartbeats, pending pairings, secrets). The group
+// signing keys and the trust state built on them (admin roles, pinned
+// identities) are neither exported nor imported.
+var (
+	bundleSkipSettings = map[string]bool{
+		"current_mode":               true,
+		"group_active":               true,
+		"group_admin_key":            true,
+		"group_identity_key":         true,
+		"kafscale_lfs_proxy_api_key": true,
+		"pairing_pending_v1":         true,
+		"whatsapp_pair_token":        true,
+		"whatsapp_pending":           true,
+	}
+	bundleSkipSettingPrefixes = []string{
+		"group_admin_state:",
+		"group_heartbeat_",
+		"group_identity_pins:",
+		"knowledge_capabilities_",
+		"knowledge_presence_",
+		"memory_embedding_install_",
+		"memory_overflow_",
+		"runtime_reconcile_",
+	}
+)
+
+// Bundle is a declarative snapshot of runtime settings and the channel, repo,
+// policy and scheduler sections of config.json.
+type Bundle struct {
+	Version    int                       `json:"version"`
+	ExportedAt string                    `json:"exportedAt,omitempty"`
+	Settings   map[string]string         `json:"settings,omitempty"`
+	Config     map[string]map[string]any `json:"config,omitempty"`
+}
+
+// BundleChange is one entry of an import diff. Secret values are masked.
+type BundleChange struct {
+	Path string `json:"path"`
+	From any    `json:"from,omitempty"`
+	To   any    `json:"to,omitempty"`
+}
+
+// BundleImportResult describes what an import changed (or would change).
+type BundleImportResult struct {
+	DryRun          bool           `json:"dryRun"`
+	Changes         []BundleChange `json:"changes"`
+	RestartRequired bool           `json:"restartRequired"`
+}
+
+// BundleValidationError lists every problem found in a bundle.
+type BundleValidationError struct {
+	Problems []string
+}
+
+func (e *BundleValidationError) Error() string {
+	return "invalid settings bundle: " + strings.Join(e.Problems, "; ")
+}
+
+func bundleSkipsSetting(key string) bool {
+	if bundleSkipSettings[key] {
+		return true
+	}
+	for _, p := range bundleSkipSettingPrefixes {
+		if strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSecretConfigKey(key string) bool {
+	k := strings.ToLower(key)
+	for _, suffix := range []string{"token", "password", "secret", "apikey", "encryptkey"} {
+		if strings.HasSuffix(k, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExportBundle builds a bundle from the effective config and the settings table.
+func ExportBundle(tl *timeline.TimelineService) (*Bundle, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	effective, err := configToMap(cfg)
+	if err != nil {
+		return nil, err
+	}
+	b := &Bundle{
+		Version:    BundleVersion,
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Settings:   map[string]string{},
+		Config:     map[string]map[string]any{},
+	}
+	for _, section := range bundleSections {
+		if m, ok := effective[section].(map[string]any); ok {
+			b.Config[section] = redactSecrets(m).(map[string]any)
+		}
+	}
+	if tl != nil {
+		all, err := tl.ListSettings()
+		if err != nil {
+			return nil, fmt.Errorf("list settings: %w", err)
+		}
+		for k, v := range all {
+			if !bundleSkipsSetting(k) {
+				b.Settings[k] = v
+			}
+		}
+	}
+	return b, nil
+}
+
+// ImportBundle validates b and applies it: listed settings are upserted and
+// each config section in b is merged into config.json (objects merge key by
+// key, arrays such as accounts are replaced). Anything not mentioned is left
+// alone. With dryRun nothing is written and the result only previews changes.
+func ImportBundle(tl *timeline.TimelineService, b *Bundle, dryRun bool) (*BundleImportResult, error) {
+	if b == nil {
+		return nil, &BundleValidationError{Problems: []string{"empty bundle"}}
+	}
+	fileMap, cfgPath, err := loadFileConfigMap()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	effective, err := configToMap(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	if b.Version != BundleVersion {
+		problems = append(problems, fmt.Sprintf("unsupported version %d (want %d)", b.Version, BundleVersion))
+	}
+	allowed := map[string]bool{}
+	for _, s := range bundleSections {
+		allowed[s] = true
+	}
+	for section := range b.Config {
+		if !allowed[section] {
+			problems = append(problems, fmt.Sprintf("unsupported config section %q", section))
+		}
+	}
+	for k, v := range b.Settings {
+		switch {
+		case strings.TrimSpace(k) == "":
+			problems = append(problems, "setting with empty key")
+		case bundleSkipsSetting(k):
+			problems = append(problems, fmt.Sprintf("setting %q is runtime state and cannot be imported", k))
+		case strings.HasPrefix(k, channels.QuietHoursSettingPrefix):
+			if _, err := channels.ParseQuietHours(v); err != nil {
+				problems = append(problems, fmt.Sprintf("setting %q: %v", k, err))
+			}
+		}
+	}
+
+	res := &BundleImportResult{DryRun: dryRun, Changes: []BundleChange{}}
+	newFile := cloneMap(fileMap)
+	for _, section := range sortedKeys(b.Config) {
+		if !allowed[section] {
+			continue
+		}
+		existing, _ := fileMap[section].(map[string]any)
+		resolved := resolveRedacted(b.Config[section], existing)
+		newFile[section] = mergeConfigMaps(cloneMap(existing), resolved)
+		before := flattenConfig(section, effective[section])
+		after := flattenConfig(section, resolved)
+		for _, path := range unionKeys(before, after) {
+			from, hadFrom := before[path]
+			to, hasTo := after[path]
+			if !hasTo || (hadFrom && reflect.DeepEqual(from, to)) {
+				continue
+			}
+			if isSecretConfigKey(path[strings.LastIndexAny(path, ".]")+1:]) {
+				from, to = maskBundleValue(from), maskBundleValue(to)
+			}
+			res.Changes = append(res.Changes, BundleChange{Path: path, From: from, To: to})
+			res.RestartRequired = true
+		}
+	}
+	if len(b.Config) > 0 {
+		problems = append(problems, validateBundleConfig(newFile)...)
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, &BundleValidationError{Problems: problems}
+	}
+
+	current := map[string]string{}
+	if tl != nil {
+		if current, err = tl.ListSettings(); err != nil {
+			return nil, fmt.Errorf("list settings: %w", err)
+		}
+	}
+	for _, k := range sortedKeys(b.Settings) {
+		v := b.Settings[k]
+		old, ok := current[k]
+		if ok && old == v {
+			continue
+		}
+		ch := BundleChange{Path: "settings." + k, To: v}
+		if ok {
+			ch.From = old
+		}
+		res.Changes = append(res.Changes, ch)
+	}
+
+	if dryRun {
+		return res, nil
+	}
+	if len(b.Settings) > 0 && tl == nil {
+		return nil, fmt.Errorf("timeline unavailable: cannot import settings")
+	}
+	for _, ch := range res.Changes {
+		if k, ok := strings.CutPrefix(ch.Path, "settings."); ok {
+			if err := tl.SetSetting(k, b.Settings[k]); err != nil {
+				return nil, fmt.Errorf("set %s: %w", k, err)
+			}
+		}
+	}
+	if res.RestartRequired {
+		if err := saveFileConfigMap(cfgPath, newFile); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// validateBundleConfig checks that the merged config still decodes and that
+// channel access policies use known values.
+func validateBundleConfig(fileMap map[string]any) []string {
+	data, err := json.Marshal(fileMap)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	var cfg config.Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return []string{fmt.Sprintf("config does not decode: %v", err)}
+	}
+	var problems []string
+	checkPolicy := func(where string, dm config.DmPolicy, group config.GroupPolicy) {
+		switch dm {
+		case "", config.DmPolicyPairing, config.DmPolicyAllowlist, config.DmPolicyOpen, config.DmPolicyDisabled:
+		default:
+			problems = append(problems, fmt.Sprintf("%s.dmPolicy: unknown value %q", where, dm))
+		}
+		switch group {
+		case "", config.GroupPolicyAllowlist, config.GroupPolicyOpen, config.GroupPolicyDisabled:
+		default:
+			problems = append(problems, fmt.Sprintf("%s.groupPolicy: unknown value %q", where, group))
+		}
+	}
+	checkIDs := func(where string, ids []string) {
+		seen := map[string]bool{}
+		for _, id := range ids {
+			id = strings.TrimSpace(id)
+			if id == "" {
+				problems = append(problems, fmt.Sprintf("%s: account without id", where))
+				continue
+			}
+			if seen[id] {
+				problems = append(problems, fmt.Sprintf("%s: duplicate account id %q", where, id))
+			}
+			seen[id] = true
+		}
+	}
+	slack := cfg.Channels.Slack
+	checkPolicy("channels.slack", slack.DmPolicy, slack.GroupPolicy)
+	var ids []string
+	for _, a := range slack.Accounts {
+		checkPolicy("channels.slack.accounts["+a.ID+"]", a.DmPolicy, a.GroupPolicy)
+		ids = append(ids, a.ID)
+	}
+	checkIDs("channels.slack.accounts", ids)
+	teams := cfg.Channels.MSTeams
+	checkPolicy("channels.msteams", teams.DmPolicy, teams.GroupPolicy)
+	ids = nil
+	for _, a := range teams.Accounts {
+		checkPolicy("channels.msteams.accounts["+a.ID+"]", a.DmPolicy, a.GroupPolicy)
+		ids = append(ids, a.ID)
+	}
+	checkIDs("channels.msteams.accounts", ids)
+	if cfg.Tools.PlanFirst.MinTier < 0 || cfg.Tools.PlanFirst.MinTier > 3 {
+		problems = append(problems, fmt.Sprintf("tools.planFirst.minTier: must be 0-3, got %d", cfg.Tools.PlanFirst.MinTier))
+	}
+	return problems
+}
+
+func configToMap(cfg *config.Config) (map[string]any, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// redactSecrets returns a copy of v with non-empty secret values masked.
+func redactSecrets(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(t))
+		for k, val := range t {
+			if s, ok := val.(string); ok && s != "" && isSecretConfigKey(k) {
+				out[k] = BundleRedacted
+				continue
+			}
+			out[k] = redactSecrets(val)
+		}
+		return out
+	case []any:
+		out := make([]any, len(t))
+		for i, val := range t {
+			out[i] = redactSecrets(val)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func maskBundleValue(v any) any {
+	if s, ok := v.(string); ok && s != "" {
+		return BundleRedacted
+	}
+	return v
+}
+
+// resolveRedacted replaces BundleRedacted placeholders with the value at the
+// same position in existing; placeholders without an existing value are
+// dropped so env vars and defaults keep applying.
+func resolveRedacted(v any, existing any) map[string]any {
+	out, _ := resolveRedactedNode(v, existing).(map[string]any)
+	if out == nil {
+		out = map[string]any{}
+	}
+	return out
+}
+
+func resolveRedactedNode(v any, existing any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		prev, _ := existing.(map[string]any)
+		out := make(map[string]any, len(t))
+		for k, val := range t {
+			if s, ok := val.(string); ok && s == BundleRedacted {
+				if old, ok := prev[k]; ok {
+					out[k] = old
+				}
+				continue
+			}
+			out[k] = resolveRedactedNode(val, prev[k])
+		}
+		return out
+	case []any:
+		prev, _ := existing.([]any)
+		out := make([]any, len(t))
+		for i, val := range t {
+			var old any
+			if i < len(prev) {
+				old = prev[i]
+			}
+			out[i] = resolveRedactedNode(val, old)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// flattenConfig maps dotted paths (config.<section>.a.b[0].c) to leaf values.
+func flattenConfig(section string, v any) map[string]any {
+	out := map[string]any{}
+	var walk func(prefix string, node any)
+	walk = func(prefix string, node any) {
+		switch t := node.(type) {
+		case map[string]any:
+			for k, val := range t {
+				walk(prefix+"."+k, val)
+			}
+		case []any:
+			if len(t) == 0 {
+				out[prefix] = t
+			}
+			for i, val := range t {
+				walk(fmt.Sprintf("%s[%d]", prefix, i), val)
+			}
+		default:
+			out[prefix] = node
+		}
+	}
+	walk("config."+section, v)
+	return out
+}
+
+// mergeConfigMaps merges src into dst recursively; non-object values replace.
+func mergeConfigMaps(dst, src map[string]any) map[string]any {
+	for k, v := range src {
+		srcMap, srcOK := v.(map[string]any)
+		dstMap, dstOK := dst[k].(map[string]any)
+		if srcOK && dstOK {
+			dst[k] = mergeConfigMaps(cloneMap(dstMap), srcMap)
+			continue
+		}
+		dst[k] = v
+	}
+	return dst
+}
+
+func cloneMap(m map[string]any) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func unionKeys(a, b map[string]any) []string {
+	seen := map[string]bool{}
+	for k := range a {
+		seen[k] = true
+	}
+	for k := range b {
+		seen[k] = true
+	}
+	return sortedKeys(seen)
+}
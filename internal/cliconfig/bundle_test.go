@@ -0,0 +1,123 @@
+package cliconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestBundleExportImportRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".kafclaw")
+	if err := os.MkdirAll(configDir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	cfgContent := `{"channels":{"slack":{"enabled":true,"botToken":"xoxb-secret","dmPolicy":"pairing"}},"gateway":{"port":18790}}`
+	cfgPath := filepath.Join(configDir, "config.json")
+	if err := os.WriteFile(cfgPath, []byte(cfgContent), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	origHome := os.Getenv("HOME")
+	defer os.Setenv("HOME", origHome)
+	_ = os.Setenv("HOME", tmpDir)
+
+	tl, err := timeline.NewTimelineService(filepath.Join(configDir, "timeline.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tl.Close()
+	_ = tl.SetSetting("daily_token_limit", "5000")
+	_ = tl.SetSetting("whatsapp_pair_token", "pair-secret")
+	_ = tl.SetSetting("runtime_reconcile_last_at", "2026-01-01T00:00:00Z")
+
+	exported, err := ExportBundle(tl)
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	raw, _ := json.Marshal(exported)
+	if strings.Contains(string(raw), "xoxb-secret") || strings.Contains(string(raw), "pair-secret") {
+		t.Fatalf("export leaks secrets: %s", raw)
+	}
+	if exported.Settings["daily_token_limit"] != "5000" {
+		t.Fatalf("missing setting in export: %+v", exported.Settings)
+	}
+	if _, ok := exported.Settings["runtime_reconcile_last_at"]; ok {
+		t.Fatal("runtime state must not be exported")
+	}
+
+	// Re-importing an unchanged export is a no-op.
+	res, err := ImportBundle(tl, exported, true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if len(res.Changes) != 0 {
+		t.Fatalf("expected no changes, got %+v", res.Changes)
+	}
+
+	slack := exported.Config["channels"]["slack"].(map[string]any)
+	slack["dmPolicy"] = "allowlist"
+	exported.Settings["daily_token_limit"] = "8000"
+
+	res, err = ImportBundle(tl, exported, true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if len(res.Changes) != 2 || !res.RestartRequired || !res.DryRun {
+		t.Fatalf("unexpected preview: %+v", res)
+	}
+	if v, _ := tl.GetSetting("daily_token_limit"); v != "5000" {
+		t.Fatalf("dry run must not write settings, got %s", v)
+	}
+
+	if _, err := ImportBundle(tl, exported, false); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if v, _ := tl.GetSetting("daily_token_limit"); v != "8000" {
+		t.Fatalf("setting not applied: %s", v)
+	}
+	data, _ := os.ReadFile(cfgPath)
+	var file map[string]any
+	_ = json.Unmarshal(data, &file)
+	fileSlack := file["channels"].(map[string]any)["slack"].(map[string]any)
+	if fileSlack["dmPolicy"] != "allowlist" || fileSlack["botToken"] != "xoxb-secret" {
+		t.Fatalf("unexpected slack config after import: %+v", fileSlack)
+	}
+	if file["gateway"] == nil {
+		t.Fatal("sections outside the bundle must be preserved")
+	}
+}
+
+func TestBundleImportValidation(t *testing.T) {
+	tmpDir := t.TempDir()
+	origHome := os.Getenv("HOME")
+	defer os.Setenv("HOME", origHome)
+	_ = os.Setenv("HOME", tmpDir)
+
+	b := &Bundle{
+		Version:  2,
+		Settings: map[string]string{"whatsapp_pending": "x", "quiet_hours:slack": "{bad"},
+		Config: map[string]map[string]any{
+			"providers": {},
+			"channels": {"msteams": map[string]any{
+				"dmPolicy": "sometimes",
+				"accounts": []any{map[string]any{"id": "a"}, map[string]any{"id": "a"}},
+			}},
+		},
+	}
+	_, err := ImportBundle(nil, b, true)
+	var verr *BundleValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	joined := strings.Join(verr.Problems, "\n")
+	for _, want := range []string{"unsupported version", `"providers"`, "whatsapp_pending", "quiet_hours:slack", "dmPolicy", "duplicate account id"} {
+		if !strings.Contains(joined, want) {
+			t.Fatalf("missing problem %q in:\n%s", want, joined)
+		}
+	}
+}
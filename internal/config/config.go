@@ -22,10 +22,12 @@ type Config struct {
 	Scheduler             SchedulerConfig             `json:"scheduler"`
 	ER1                   ER1IntegrationConfig        `json:"er1"`
 	Observer              ObserverMemoryConfig        `json:"observer"`
+	Commitments           CommitmentsConfig           `json:"commitments"`
 	ContentClassification ContentClassificationConfig `json:"contentClassification"`
 	PromptGuard           PromptGuardConfig           `json:"promptGuard"`
 	OutputSanitization    OutputSanitizationConfig    `json:"outputSanitization"`
 	FinOps                FinOpsConfig                `json:"finops"`
+	OutputHooks           OutputHooksConfig           `json:"outputHooks"`
 }
 
 // ---------------------------------------------------------------------------
@@ -64,21 +66,86 @@ type ChannelsConfig struct {
 	Feishu   FeishuConfig   `json:"feishu"`
 	Slack    SlackConfig    `json:"slack"`
 	MSTeams  MSTeamsConfig  `json:"msteams"`
+	Dedupe   DedupeConfig   `json:"dedupe"`
+	Presence PresenceConfig `json:"presence"`
+	// ThreadContext pulls platform thread history when the agent is
+	// addressed inside a Slack or Teams thread.
+	ThreadContext ThreadContextConfig `json:"threadContext"`
+	// ReplyEvents lists activity event types (e.g. "view_submission") the
+	// agent answers. Other activity, such as reactions and channel joins, is
+	// recorded without a reply.
+	ReplyEvents []string `json:"replyEvents,omitempty"`
+	// Bridges lists channelbridge instances whose /status the gateway
+	// aggregates. Empty derives them from the Slack/Teams/Discord/Telegram
+	// outbound URLs.
+	Bridges []ChannelBridgeConfig `json:"bridges,omitempty"`
 }
 
-// TelegramConfig configures the Telegram channel.
+// ChannelBridgeConfig names one channelbridge instance.
+type ChannelBridgeConfig struct {
+	Name string `json:"name"`
+	// URL is the bridge base URL, e.g. http://127.0.0.1:18888.
+	URL string `json:"url"`
+}
+
+// DedupeConfig controls inbound duplicate suppression on the message bus.
+// Messages are keyed by channel + chat + platform message ID, falling back
+// to the idempotency key.
+type DedupeConfig struct {
+	Enabled   bool `json:"enabled" envconfig:"ENABLED"`
+	WindowSec int  `json:"windowSec" envconfig:"WINDOW_SEC"`
+}
+
+// PresenceConfig controls typing indicators and read receipts. Channels
+// without platform support ignore them.
+type PresenceConfig struct {
+	Typing           bool `json:"typing" envconfig:"TYPING"`
+	ReadReceipts     bool `json:"readReceipts" envconfig:"READ_RECEIPTS"`
+	TypingRefreshSec int  `json:"typingRefreshSec" envconfig:"TYPING_REFRESH_SEC"`
+	// TypingAfterMs delays the typing indicator until a tool call has run
+	// this long; 0 shows it as soon as a task starts.
+	TypingAfterMs int `json:"typingAfterMs" envconfig:"TYPING_AFTER_MS"`
+}
+
+// ThreadContextConfig controls on-demand thread history. The first message
+// the agent handles in a thread fetches the newest Limit thread messages via
+// the channelbridge; they are cached per session and thread.
+type ThreadContextConfig struct {
+	Enabled bool `json:"enabled" envconfig:"ENABLED"`
+	Limit   int  `json:"limit" envconfig:"LIMIT"`
+}
+
+// TelegramConfig configures the Telegram channel. Like Slack and Teams it is
+// served by the channelbridge: inbound messages arrive on the inbound API,
+// replies go to OutboundURL.
 type TelegramConfig struct {
-	Enabled   bool     `json:"enabled" envconfig:"TELEGRAM_ENABLED"`
-	Token     string   `json:"token" envconfig:"TELEGRAM_TOKEN"`
-	AllowFrom []string `json:"allowFrom"`
-	Proxy     string   `json:"proxy,omitempty" envconfig:"TELEGRAM_PROXY"`
+	Enabled         bool        `json:"enabled" envconfig:"TELEGRAM_ENABLED"`
+	Token           string      `json:"token" envconfig:"TELEGRAM_TOKEN"`
+	InboundToken    string      `json:"inboundToken" envconfig:"TELEGRAM_INBOUND_TOKEN"`
+	OutboundURL     string      `json:"outboundUrl" envconfig:"TELEGRAM_OUTBOUND_URL"`
+	SessionScope    string      `json:"sessionScope" envconfig:"TELEGRAM_SESSION_SCOPE"`
+	AllowFrom       []string    `json:"allowFrom"`
+	DmPolicy        DmPolicy    `json:"dmPolicy"`
+	GroupPolicy     GroupPolicy `json:"groupPolicy"`
+	RequireMention  bool        `json:"requireMention" envconfig:"TELEGRAM_REQUIRE_MENTION"`
+	MaxMessageChars int         `json:"maxMessageChars,omitempty" envconfig:"TELEGRAM_MAX_MESSAGE_CHARS"` // split longer replies; 0 = default, <0 = off
+	Proxy           string      `json:"proxy,omitempty" envconfig:"TELEGRAM_PROXY"`
 }
 
-// DiscordConfig configures the Discord channel.
+// DiscordConfig configures the Discord channel. Like Slack and Teams it is
+// served by the channelbridge: inbound messages arrive on the inbound API,
+// replies go to OutboundURL.
 type DiscordConfig struct {
-	Enabled   bool     `json:"enabled" envconfig:"DISCORD_ENABLED"`
-	Token     string   `json:"token" envconfig:"DISCORD_TOKEN"`
-	AllowFrom []string `json:"allowFrom"`
+	Enabled         bool        `json:"enabled" envconfig:"DISCORD_ENABLED"`
+	Token           string      `json:"token" envconfig:"DISCORD_TOKEN"`
+	InboundToken    string      `json:"inboundToken" envconfig:"DISCORD_INBOUND_TOKEN"`
+	OutboundURL     string      `json:"outboundUrl" envconfig:"DISCORD_OUTBOUND_URL"`
+	SessionScope    string      `json:"sessionScope" envconfig:"DISCORD_SESSION_SCOPE"`
+	AllowFrom       []string    `json:"allowFrom"`
+	DmPolicy        DmPolicy    `json:"dmPolicy"`
+	GroupPolicy     GroupPolicy `json:"groupPolicy"`
+	RequireMention  bool        `json:"requireMention" envconfig:"DISCORD_REQUIRE_MENTION"`
+	MaxMessageChars int         `json:"maxMessageChars,omitempty" envconfig:"DISCORD_MAX_MESSAGE_CHARS"` // split longer replies; 0 = default, <0 = off
 }
 
 // WhatsAppConfig configures the WhatsApp channel.
@@ -89,6 +156,12 @@ type WhatsAppConfig struct {
 	DropUnauthorized bool     `json:"dropUnauthorized" envconfig:"WHATSAPP_DROP_UNAUTHORIZED"`
 	IgnoreReactions  bool     `json:"ignoreReactions" envconfig:"WHATSAPP_IGNORE_REACTIONS"`
 	SessionScope     string   `json:"sessionScope" envconfig:"WHATSAPP_SESSION_SCOPE"`
+	MaxMessageChars  int      `json:"maxMessageChars,omitempty" envconfig:"WHATSAPP_MAX_MESSAGE_CHARS"` // split longer replies; 0 = default, <0 = off
+
+	// Periodic encrypted session backups into ~/.kafclaw/backups/whatsapp.
+	// 0 hours disables them.
+	SessionBackupHours int `json:"sessionBackupHours" envconfig:"WHATSAPP_SESSION_BACKUP_HOURS"`
+	SessionBackupKeep  int `json:"sessionBackupKeep" envconfig:"WHATSAPP_SESSION_BACKUP_KEEP"`
 }
 
 // FeishuConfig configures the Feishu channel.
@@ -136,6 +209,7 @@ type SlackConfig struct {
 	DmPolicy         DmPolicy             `json:"dmPolicy"`
 	GroupPolicy      GroupPolicy          `json:"groupPolicy"`
 	RequireMention   bool                 `json:"requireMention" envconfig:"SLACK_REQUIRE_MENTION"`
+	MaxMessageChars  int                  `json:"maxMessageChars,omitempty" envconfig:"SLACK_MAX_MESSAGE_CHARS"` // split longer replies; 0 = default, <0 = off
 }
 
 // SlackAccountConfig configures one named Slack account.
@@ -158,19 +232,20 @@ type SlackAccountConfig struct {
 
 // MSTeamsConfig configures the Microsoft Teams channel.
 type MSTeamsConfig struct {
-	Enabled        bool                   `json:"enabled" envconfig:"MSTEAMS_ENABLED"`
-	AppID          string                 `json:"appId" envconfig:"MSTEAMS_APP_ID"`
-	AppPassword    string                 `json:"appPassword" envconfig:"MSTEAMS_APP_PASSWORD"`
-	TenantID       string                 `json:"tenantId" envconfig:"MSTEAMS_TENANT_ID"`
-	InboundToken   string                 `json:"inboundToken" envconfig:"MSTEAMS_INBOUND_TOKEN"`
-	OutboundURL    string                 `json:"outboundUrl" envconfig:"MSTEAMS_OUTBOUND_URL"`
-	SessionScope   string                 `json:"sessionScope" envconfig:"MSTEAMS_SESSION_SCOPE"`
-	Accounts       []MSTeamsAccountConfig `json:"accounts,omitempty"`
-	AllowFrom      []string               `json:"allowFrom"`
-	GroupAllowFrom []string               `json:"groupAllowFrom"`
-	DmPolicy       DmPolicy               `json:"dmPolicy"`
-	GroupPolicy    GroupPolicy            `json:"groupPolicy"`
-	RequireMention bool                   `json:"requireMention" envconfig:"MSTEAMS_REQUIRE_MENTION"`
+	Enabled         bool                   `json:"enabled" envconfig:"MSTEAMS_ENABLED"`
+	AppID           string                 `json:"appId" envconfig:"MSTEAMS_APP_ID"`
+	AppPassword     string                 `json:"appPassword" envconfig:"MSTEAMS_APP_PASSWORD"`
+	TenantID        string                 `json:"tenantId" envconfig:"MSTEAMS_TENANT_ID"`
+	InboundToken    string                 `json:"inboundToken" envconfig:"MSTEAMS_INBOUND_TOKEN"`
+	OutboundURL     string                 `json:"outboundUrl" envconfig:"MSTEAMS_OUTBOUND_URL"`
+	SessionScope    string                 `json:"sessionScope" envconfig:"MSTEAMS_SESSION_SCOPE"`
+	Accounts        []MSTeamsAccountConfig `json:"accounts,omitempty"`
+	AllowFrom       []string               `json:"allowFrom"`
+	GroupAllowFrom  []string               `json:"groupAllowFrom"`
+	DmPolicy        DmPolicy               `json:"dmPolicy"`
+	GroupPolicy     GroupPolicy            `json:"groupPolicy"`
+	RequireMention  bool                   `json:"requireMention" envconfig:"MSTEAMS_REQUIRE_MENTION"`
+	MaxMessageChars int                    `json:"maxMessageChars,omitempty" envconfig:"MSTEAMS_MAX_MESSAGE_CHARS"` // split longer replies; 0 = default, <0 = off
 }
 
 // MSTeamsAccountConfig configures one named Teams account.
@@ -212,6 +287,13 @@ type ProvidersConfig struct {
 type ProviderConfig struct {
 	APIKey  string `json:"apiKey" envconfig:"API_KEY"`
 	APIBase string `json:"apiBase,omitempty" envconfig:"API_BASE"`
+	// Resilience: per-attempt timeout, retries on 429/5xx/network errors and
+	// a circuit breaker. Zero uses the defaults (120s, 2, 5, 30s); negative
+	// maxRetries or breakerThreshold disable retries or the breaker.
+	TimeoutSec         int `json:"timeoutSec,omitempty" envconfig:"TIMEOUT_SEC"`
+	MaxRetries         int `json:"maxRetries,omitempty" envconfig:"MAX_RETRIES"`
+	BreakerThreshold   int `json:"breakerThreshold,omitempty" envconfig:"BREAKER_THRESHOLD"`
+	BreakerCooldownSec int `json:"breakerCooldownSec,omitempty" envconfig:"BREAKER_COOLDOWN_SEC"`
 }
 
 // LocalWhisperConfig contains settings for local Whisper transcription.
@@ -234,6 +316,67 @@ type GatewayConfig struct {
 	TLSCert       string `json:"tlsCert" envconfig:"TLS_CERT"`
 	TLSKey        string `json:"tlsKey" envconfig:"TLS_KEY"`
 	DaemonRuntime string `json:"daemonRuntime" envconfig:"DAEMON_RUNTIME"`
+	// RateLimit bounds dashboard API request rates per client.
+	RateLimit GatewayRateLimitConfig `json:"rateLimit" envconfig:"RATE_LIMIT"`
+	// StrictStartup refuses to start when a critical component fails.
+	StrictStartup bool `json:"strictStartup" envconfig:"STRICT_STARTUP"`
+	// UpdateCheck polls the release endpoint for newer versions (opt-in).
+	UpdateCheck GatewayUpdateCheckConfig `json:"updateCheck" envconfig:"UPDATE_CHECK"`
+	// PublicStatus serves an unauthenticated, read-only status page (opt-in).
+	PublicStatus GatewayPublicStatusConfig `json:"publicStatus" envconfig:"PUBLIC_STATUS"`
+	// Log sets the level and format of the gateway logs.
+	Log GatewayLogConfig `json:"log" envconfig:"LOG"`
+	// OTel exports OpenTelemetry spans to an OTLP collector (opt-in).
+	OTel GatewayOTelConfig `json:"otel" envconfig:"OTEL"`
+}
+
+// GatewayLogConfig configures the gateway logger. Empty values default to
+// info and text.
+type GatewayLogConfig struct {
+	// Level is debug, info, warn or error.
+	Level string `json:"level" envconfig:"LEVEL"`
+	// Format is text or json (one JSON object per line).
+	Format string `json:"format" envconfig:"FORMAT"`
+}
+
+// GatewayOTelConfig configures OpenTelemetry span export. Spans are off
+// unless an endpoint is set here or in OTEL_EXPORTER_OTLP_ENDPOINT.
+type GatewayOTelConfig struct {
+	// Endpoint is the OTLP/HTTP base URL, e.g. http://localhost:4318.
+	Endpoint string `json:"endpoint" envconfig:"ENDPOINT"`
+	// ServiceName defaults to kafclaw-gateway.
+	ServiceName string `json:"serviceName" envconfig:"SERVICE_NAME"`
+	// Headers are key=value pairs, comma separated, sent with every export.
+	Headers string `json:"headers" envconfig:"HEADERS"`
+}
+
+// GatewayPublicStatusConfig configures the public status page at /status and
+// its JSON feed /api/v1/public/status. Both skip auth, so they only expose
+// states, counts and timestamps.
+type GatewayPublicStatusConfig struct {
+	Enabled bool `json:"enabled" envconfig:"ENABLED"`
+	// PerIPPerMinute limits requests per client IP (0 disables the limit).
+	PerIPPerMinute int `json:"perIpPerMinute" envconfig:"PER_IP"`
+}
+
+// GatewayUpdateCheckConfig configures the release check of the gateway. The
+// endpoint returns a GitHub-style release document; `kafclaw upgrade` uses
+// it as well, whether or not the periodic check is enabled.
+type GatewayUpdateCheckConfig struct {
+	Enabled       bool   `json:"enabled" envconfig:"ENABLED"`
+	Endpoint      string `json:"endpoint" envconfig:"ENDPOINT"`
+	IntervalHours int    `json:"intervalHours" envconfig:"INTERVAL_HOURS"`
+}
+
+// GatewayRateLimitConfig configures dashboard API rate limiting. Limits are
+// requests per minute; 0 disables the corresponding limit.
+type GatewayRateLimitConfig struct {
+	PerTokenPerMinute int `json:"perTokenPerMinute" envconfig:"PER_TOKEN"`
+	PerIPPerMinute    int `json:"perIpPerMinute" envconfig:"PER_IP"`
+	// Burst is the number of requests allowed at once (default: a quarter of the per-minute limit).
+	Burst int `json:"burst" envconfig:"BURST"`
+	// Endpoints sets tighter per-client limits for path prefixes, e.g. {"/api/v1/timeline": 60}.
+	Endpoints map[string]int `json:"endpoints,omitempty" envconfig:"ENDPOINTS"`
 }
 
 // ---------------------------------------------------------------------------
@@ -255,6 +398,30 @@ type NodeConfig struct {
 type MemoryConfig struct {
 	Embedding MemoryEmbeddingConfig `json:"embedding"`
 	Search    MemorySearchConfig    `json:"search"`
+	Working   MemoryWorkingConfig   `json:"working"`
+	Privacy   MemoryPrivacyConfig   `json:"privacy"`
+	Grounded  MemoryGroundedConfig  `json:"grounded"`
+	Dedup     MemoryDedupConfig     `json:"dedup"`
+}
+
+// MemoryDedupConfig configures near-duplicate detection of auto-indexed
+// chunks: a chunk at least Threshold cosine-similar to a chunk of the same
+// source and chat indexed within WindowHours is skipped or merged into it.
+// It is off by default.
+type MemoryDedupConfig struct {
+	Threshold   float64 `json:"threshold" envconfig:"THRESHOLD"`      // 0 = off (default)
+	Action      string  `json:"action" envconfig:"ACTION"`            // skip (default) | merge
+	WindowHours int     `json:"windowHours" envconfig:"WINDOW_HOURS"` // 0 = any age
+}
+
+// MemoryGroundedConfig configures grounded answer mode. Grounded chats are
+// answered only from retrieved memory and knowledge, with citations; the
+// agent refuses when the best match scores below MinScore. Chats opt in via
+// Channels or the grounded_mode / grounded:<channel>[:<chat_id>] settings.
+type MemoryGroundedConfig struct {
+	Channels []string `json:"channels,omitempty" envconfig:"CHANNELS"` // channels that are always grounded
+	MinScore float64  `json:"minScore" envconfig:"MIN_SCORE"`          // retrieval confidence required to answer
+	Refusal  string   `json:"refusal,omitempty" envconfig:"REFUSAL"`   // reply when nothing qualifies
 }
 
 // MemoryEmbeddingConfig configures embedding backend/runtime settings.
@@ -268,6 +435,26 @@ type MemoryEmbeddingConfig struct {
 	AutoDownload      bool   `json:"autoDownload" envconfig:"AUTO_DOWNLOAD"`
 	Endpoint          string `json:"endpoint" envconfig:"ENDPOINT"`
 	StartupTimeoutSec int    `json:"startupTimeoutSec" envconfig:"STARTUP_TIMEOUT_SEC"`
+	// Command launches the local-hf runtime under gateway supervision; empty
+	// means the runtime is managed externally. DownloadCommand fetches a
+	// missing model into CacheDir. Both accept {model}, {cacheDir}, {host},
+	// {port} and {endpoint} placeholders.
+	Command         string `json:"command,omitempty" envconfig:"COMMAND"`
+	DownloadCommand string `json:"downloadCommand,omitempty" envconfig:"DOWNLOAD_COMMAND"`
+}
+
+// MemoryWorkingConfig bounds scoped working memory.
+type MemoryWorkingConfig struct {
+	TTLHours         int `json:"ttlHours" envconfig:"TTL_HOURS"`                   // 0 = entries never expire
+	MaxBytesPerScope int `json:"maxBytesPerScope" envconfig:"MAX_BYTES_PER_SCOPE"` // 0 = unlimited
+}
+
+// MemoryPrivacyConfig bounds which observation and chunk privacy levels
+// (owner, trusted, public) are injected. Owner messages may see every level,
+// external senders up to External; a channel entry caps both.
+type MemoryPrivacyConfig struct {
+	External string            `json:"external" envconfig:"EXTERNAL"` // level allowed for external senders (default trusted)
+	Channels map[string]string `json:"channels,omitempty"`            // channel -> maximum level, e.g. "msteams": "public"
 }
 
 // MemorySearchConfig configures recall behavior.
@@ -329,6 +516,18 @@ type OrchestratorConfig struct {
 	ZoneID   string `json:"zoneId" envconfig:"ZONE_ID"`
 	ParentID string `json:"parentId" envconfig:"PARENT_ID"`
 	Endpoint string `json:"endpoint" envconfig:"ENDPOINT"` // This agent's remote API URL
+
+	HealthReport ZoneHealthReportConfig `json:"healthReport"`
+}
+
+// ZoneHealthReportConfig schedules the periodic zone health digest. The
+// report is off unless Channel, ChatID and IntervalMinutes are set.
+type ZoneHealthReportConfig struct {
+	Channel         string `json:"channel"`
+	ChatID          string `json:"chatId"`
+	IntervalMinutes int    `json:"intervalMinutes"`
+	Verbosity       string `json:"verbosity"`      // summary (default) | detailed
+	LaggardMinutes  int    `json:"laggardMinutes"` // no heartbeat for this long marks an agent as laggard (default 3x interval)
 }
 
 // ---------------------------------------------------------------------------
@@ -340,6 +539,28 @@ type ToolsConfig struct {
 	Exec      ExecToolConfig      `json:"exec"`
 	Web       WebToolConfig       `json:"web"`
 	Subagents SubagentsToolConfig `json:"subagents"`
+	PlanFirst PlanFirstConfig     `json:"planFirst"`
+	Approvals ApprovalsConfig     `json:"approvals"`
+	ImageGen  ImageGenToolConfig  `json:"imageGen"`
+	Output    ToolOutputConfig    `json:"output"`
+	SSH       SSHToolConfig       `json:"ssh"`
+	// ChannelPolicies are tool allow and deny lists keyed by channel name.
+	ChannelPolicies map[string]ToolChannelPolicyConfig `json:"channelPolicies,omitempty"`
+}
+
+// ToolChannelPolicyConfig limits the tools messages of one channel may
+// trigger. An empty Allow allows every tool not in Deny.
+type ToolChannelPolicyConfig struct {
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+	// Accounts override the lists for single channel accounts.
+	Accounts map[string]ToolListConfig `json:"accounts,omitempty"`
+}
+
+// ToolListConfig is a tool allow and deny list.
+type ToolListConfig struct {
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
 }
 
 // SkillsConfig contains skill-system settings.
@@ -424,6 +645,81 @@ type GroupConfig struct {
 	PollIntervalMs     int    `json:"pollIntervalMs" envconfig:"POLL_INTERVAL_MS"`
 	OnboardMode        string `json:"onboardMode" envconfig:"ONBOARD_MODE"` // "open" (default) or "gated"
 	MaxDelegationDepth int    `json:"maxDelegationDepth" envconfig:"MAX_DELEGATION_DEPTH"`
+	// RosterReconcileSec is how often the roster is reconciled against the
+	// LFS proxy's authoritative roster. 0 disables reconciliation.
+	RosterReconcileSec int `json:"rosterReconcileSec" envconfig:"ROSTER_RECONCILE_SEC"`
+	// SkillPolicies maps skill task failures to retry/escalate actions, by
+	// skill name; "*" applies to skills without their own entry.
+	SkillPolicies map[string]GroupSkillPolicy `json:"skillPolicies,omitempty"`
+	// TraceSampling limits the spans this agent publishes to the group
+	// traces topic.
+	TraceSampling GroupTraceSamplingConfig `json:"traceSampling"`
+	// RequireSignedAnnounce rejects announces that are not signed with an
+	// identity key. Off by default so agents that predate identity keys can
+	// still join; announces for an agent ID with a pinned key must always be
+	// signed.
+	RequireSignedAnnounce bool `json:"requireSignedAnnounce" envconfig:"REQUIRE_SIGNED_ANNOUNCE"`
+	// RequireEncryptedDirectTasks refuses to send a task addressed to one
+	// agent that advertises no encryption key, instead of sending it
+	// readable to the whole group.
+	RequireEncryptedDirectTasks bool `json:"requireEncryptedDirectTasks" envconfig:"REQUIRE_ENCRYPTED_DIRECT_TASKS"`
+	// Role is the membership role declared at join. "observer" makes the
+	// agent consume-only: it never claims tasks and publishes nothing but
+	// its announces. Empty means a regular member.
+	Role string `json:"role,omitempty" envconfig:"ROLE"`
+	// Escalation handles this agent's submitted tasks that pass their
+	// deadline unclaimed or unfinished.
+	Escalation GroupEscalationConfig `json:"escalation"`
+}
+
+// GroupEscalationConfig controls deadline escalation of submitted group
+// tasks. A task still unclaimed at its deadline is re-broadcast with a raised
+// priority and a renewed deadline; a claimed but unfinished task, a task
+// directed at one agent or skill, and one out of re-broadcasts is reported to
+// the owner channel instead. The zero value checks every minute and
+// re-broadcasts twice.
+type GroupEscalationConfig struct {
+	// IntervalSec is how often deadlines are checked (default 60); negative
+	// disables escalation.
+	IntervalSec int `json:"intervalSec"`
+	// MaxRebroadcasts caps re-broadcasts per task (default 2); negative
+	// reports every overdue task right away.
+	MaxRebroadcasts int `json:"maxRebroadcasts"`
+	// Channel and ChatID receive escalation notices; without them notices
+	// are only logged and recorded as delegation events.
+	Channel string `json:"channel,omitempty"`
+	ChatID  string `json:"chatId,omitempty"`
+}
+
+// GroupTraceSamplingConfig controls which spans are published to the group
+// traces topic. Sampling is decided per trace, so the spans of a trace are
+// kept or dropped together. The zero value publishes every span.
+type GroupTraceSamplingConfig struct {
+	// Rate is the fraction of traces published (0 < Rate <= 1). 0 means 1.
+	Rate float64 `json:"rate"`
+	// SpanTypeRates overrides Rate per span type (TASK, LLM, TOOL); 0 drops
+	// the type.
+	SpanTypeRates map[string]float64 `json:"spanTypeRates,omitempty"`
+	// AlwaysSampleErrors publishes failed spans regardless of sampling and
+	// MaxSpansPerMinute. Default true.
+	AlwaysSampleErrors *bool `json:"alwaysSampleErrors,omitempty"`
+	// MaxSpansPerMinute caps published spans; 0 = no cap.
+	MaxSpansPerMinute int `json:"maxSpansPerMinute"`
+	// MaxContentChars truncates span content; 0 = no limit.
+	MaxContentChars int `json:"maxContentChars"`
+	// ReportIntervalSec is how often sampled-out counts are published to the
+	// group audit topic (default 300).
+	ReportIntervalSec int `json:"reportIntervalSec"`
+}
+
+// GroupSkillPolicy decides what happens when a skill task fails. Failures
+// whose error class is in RetryOn are resubmitted up to MaxRetries times; other
+// failures, and exhausted retries, escalate: the task is marked escalated and,
+// if EscalateSkill is set, resubmitted to that skill.
+type GroupSkillPolicy struct {
+	MaxRetries    int      `json:"maxRetries"`
+	RetryOn       []string `json:"retryOn,omitempty"`
+	EscalateSkill string   `json:"escalateSkill,omitempty"`
 }
 
 // ---------------------------------------------------------------------------
@@ -437,6 +733,24 @@ type SchedulerConfig struct {
 	MaxConcLLM     int           `json:"maxConcLLM" envconfig:"MAX_CONC_LLM"`
 	MaxConcShell   int           `json:"maxConcShell" envconfig:"MAX_CONC_SHELL"`
 	MaxConcDefault int           `json:"maxConcDefault" envconfig:"MAX_CONC_DEFAULT"`
+	// Calendars turn upcoming calendar events into one-shot scheduled prompts.
+	Calendars []CalendarSourceConfig `json:"calendars,omitempty"`
+}
+
+// CalendarSourceConfig polls a calendar and schedules Prompt LeadMinutes
+// before every upcoming event whose title matches TitleMatch.
+type CalendarSourceConfig struct {
+	Name           string `json:"name"`
+	Kind           string `json:"kind"`                 // ics | google | m365
+	URL            string `json:"url,omitempty"`        // ics: feed URL or local file path
+	CalendarID     string `json:"calendarId,omitempty"` // google: default primary
+	Profile        string `json:"profile,omitempty"`    // OAuth profile for google/m365 (default: default)
+	TitleMatch     string `json:"titleMatch,omitempty"` // case-insensitive regex; empty matches every event
+	LeadMinutes    int    `json:"leadMinutes"`
+	Prompt         string `json:"prompt"`                   // text/template over the event: {{.Title}}, {{.Start}}, {{.Location}}, ...
+	Category       string `json:"category,omitempty"`       // llm (default) | shell | default
+	PollMinutes    int    `json:"pollMinutes,omitempty"`    // default 15
+	LookaheadHours int    `json:"lookaheadHours,omitempty"` // default 24
 }
 
 // ExecToolConfig contains shell execution tool settings.
@@ -445,6 +759,78 @@ type ExecToolConfig struct {
 	RestrictToWorkspace bool          `json:"restrictToWorkspace" envconfig:"EXEC_RESTRICT_WORKSPACE"`
 }
 
+// PlanFirstConfig requires the agent to post its plan and get a go-ahead
+// before running tools at or above MinTier. MinTier 0 disables the rule.
+type PlanFirstConfig struct {
+	MinTier  int      `json:"minTier" envconfig:"PLAN_FIRST_MIN_TIER"`
+	Channels []string `json:"channels" envconfig:"PLAN_FIRST_CHANNELS"` // empty = all channels
+}
+
+// ApprovalsConfig controls the lifecycle of pending approvals. Zero values
+// keep the plain behavior: a single prompt, denied after the
+// approval_timeout_seconds setting (default 60s).
+type ApprovalsConfig struct {
+	// ExpirySec is how long an approval stays open (0 = the
+	// approval_timeout_seconds setting).
+	ExpirySec int `json:"expirySec" envconfig:"APPROVAL_EXPIRY_SEC"`
+	// ExpiryAction is "deny" (default) or "escalate": an escalated approval
+	// is announced to the escalation target, opened to the delegates and
+	// denied only after a second expiry window.
+	ExpiryAction string `json:"expiryAction" envconfig:"APPROVAL_EXPIRY_ACTION"`
+	// ReminderIntervalSec repeats the prompt over the originating channel
+	// while the approval is pending (0 = no reminders).
+	ReminderIntervalSec int `json:"reminderIntervalSec" envconfig:"APPROVAL_REMINDER_INTERVAL_SEC"`
+	// DelegateAfterSec lets the delegates decide once the approval has been
+	// pending this long (0 = only on escalation).
+	DelegateAfterSec int `json:"delegateAfterSec" envconfig:"APPROVAL_DELEGATE_AFTER_SEC"`
+	// Delegates are sender IDs of second approvers, e.g. other trusted
+	// senders.
+	Delegates []string `json:"delegates" envconfig:"APPROVAL_DELEGATES"`
+	// EscalationChannel and EscalationChatID receive escalation notices;
+	// without them notices go to the originating chat.
+	EscalationChannel string `json:"escalationChannel,omitempty"`
+	EscalationChatID  string `json:"escalationChatId,omitempty"`
+}
+
+// ImageGenToolConfig configures the generate_image tool. An empty Backend
+// disables the tool.
+type ImageGenToolConfig struct {
+	Backend  string `json:"backend" envconfig:"IMAGE_GEN_BACKEND"`   // openai|sd
+	Endpoint string `json:"endpoint" envconfig:"IMAGE_GEN_ENDPOINT"` // API base (openai) or WebUI URL (sd)
+	APIKey   string `json:"apiKey" envconfig:"IMAGE_GEN_API_KEY"`    // default: providers.openai.apiKey
+	Model    string `json:"model" envconfig:"IMAGE_GEN_MODEL"`
+	Size     string `json:"size" envconfig:"IMAGE_GEN_SIZE"`
+}
+
+// ToolOutputConfig bounds how much tool output enters the conversation.
+// Larger results are saved as workspace artifacts readable via read_artifact.
+type ToolOutputConfig struct {
+	MaxChars int `json:"maxChars" envconfig:"OUTPUT_MAX_CHARS"` // 0 = built-in default
+}
+
+// SSHToolConfig configures the ssh tool. The tool is registered only when at
+// least one host is configured.
+type SSHToolConfig struct {
+	Timeout        time.Duration   `json:"timeout"`
+	KnownHostsFile string          `json:"knownHostsFile"` // default: ~/.ssh/known_hosts
+	Hosts          []SSHHostConfig `json:"hosts,omitempty"`
+}
+
+// SSHHostConfig registers a remote host under an alias. Credentials are
+// secret references (env:NAME, tomb:NAME, file:PATH), never inline secrets.
+type SSHHostConfig struct {
+	Alias         string   `json:"alias"`
+	Address       string   `json:"address"` // host or host:port
+	User          string   `json:"user"`
+	KeyRef        string   `json:"keyRef,omitempty"`
+	PassphraseRef string   `json:"passphraseRef,omitempty"`
+	PasswordRef   string   `json:"passwordRef,omitempty"`
+	HostKey       string   `json:"hostKey,omitempty"`  // pinned key in authorized_keys format; overrides known_hosts
+	JumpHost      string   `json:"jumpHost,omitempty"` // alias of the host to tunnel through
+	MaxTier       int      `json:"maxTier"`            // highest command tier allowed (0 = read-only commands)
+	DenyPatterns  []string `json:"denyPatterns,omitempty"`
+}
+
 // WebToolConfig contains web tool settings.
 type WebToolConfig struct {
 	Search SearchConfig `json:"search"`
@@ -467,6 +853,9 @@ type SubagentsToolConfig struct {
 	Model               string             `json:"model" envconfig:"MODEL"`
 	Thinking            string             `json:"thinking" envconfig:"THINKING"`
 	Tools               SubagentToolPolicy `json:"tools"`
+	// ProgressIntervalSec throttles progress updates posted to the parent
+	// chat while a subagent runs. 0 disables progress updates.
+	ProgressIntervalSec int `json:"progressIntervalSec" envconfig:"PROGRESS_INTERVAL_SEC"`
 }
 
 type SubagentToolPolicy struct {
@@ -498,6 +887,19 @@ type ObserverMemoryConfig struct {
 	MaxObservations  int    `json:"maxObservations" envconfig:"OBSERVER_MAX_OBS"`
 }
 
+// ---------------------------------------------------------------------------
+// Commitments – extraction and follow-up of promises made in conversations
+// ---------------------------------------------------------------------------
+
+// CommitmentsConfig configures commitment tracking.
+type CommitmentsConfig struct {
+	Enabled bool `json:"enabled" envconfig:"COMMITMENTS_ENABLED"`
+	// Model overrides the model used for extraction (empty = agent model).
+	Model string `json:"model" envconfig:"COMMITMENTS_MODEL"`
+	// FollowUpIntervalSec is how often due commitments are checked.
+	FollowUpIntervalSec int `json:"followUpIntervalSec" envconfig:"COMMITMENTS_FOLLOW_UP_INTERVAL_SEC"`
+}
+
 // ---------------------------------------------------------------------------
 // Middleware – content classification, prompt guard, output sanitization, FinOps
 // ---------------------------------------------------------------------------
@@ -568,6 +970,51 @@ type FinOpsConfig struct {
 	Pricing       map[string]ProviderPricing `json:"pricing,omitempty"`       // providerID → pricing
 	DailyBudget   float64                    `json:"dailyBudget,omitempty"`   // max USD per day (0 = unlimited)
 	MonthlyBudget float64                    `json:"monthlyBudget,omitempty"` // max USD per month (0 = unlimited)
+	Anomaly       CostAnomalyConfig          `json:"anomaly"`
+}
+
+// CostAnomalyConfig configures the token spend anomaly detector. It learns
+// the hourly and daily token spend per channel and model over BaselineDays
+// and alerts when a window reaches Factor times its baseline. Alerts go to
+// Channel/ChatID when both are set; they are always recorded.
+type CostAnomalyConfig struct {
+	Enabled      bool    `json:"enabled"`
+	IntervalSec  int     `json:"intervalSec"`
+	BaselineDays int     `json:"baselineDays"`
+	Factor       float64 `json:"factor"`
+	MinTokens    int     `json:"minTokens"` // windows below this never alert
+	Channel      string  `json:"channel,omitempty"`
+	ChatID       string  `json:"chatId,omitempty"`
+}
+
+// OutputHooksConfig configures the post-processing hooks run on agent replies
+// after the provider middleware and before channel formatting.
+type OutputHooksConfig struct {
+	// Channels maps a channel name to its hook chain, run in order; "*"
+	// applies to channels without their own chain.
+	Channels map[string][]OutputHookConfig `json:"channels,omitempty"`
+}
+
+// OutputHookConfig is one hook of a chain.
+type OutputHookConfig struct {
+	// Name identifies the hook in logs and metrics (default: its type).
+	Name string `json:"name,omitempty"`
+	// Type is "redact", "block", "footer" or "webhook".
+	Type string `json:"type"`
+	// Pattern is the regular expression for redact and block.
+	Pattern string `json:"pattern,omitempty"`
+	// Replacement replaces redact matches (default "[redacted]").
+	Replacement string `json:"replacement,omitempty"`
+	// Text is the footer, or the reply sent instead of a blocked one.
+	Text string `json:"text,omitempty"`
+	// URL, TokenRef and TimeoutMs configure a webhook. TokenRef is a secret
+	// reference (env:, tomb:, file:) sent as a bearer token.
+	URL       string `json:"url,omitempty"`
+	TokenRef  string `json:"tokenRef,omitempty"`
+	TimeoutMs int    `json:"timeoutMs,omitempty"`
+	// FailOpen delivers the reply unchanged when the webhook fails; by
+	// default the reply is blocked.
+	FailOpen bool `json:"failOpen,omitempty"`
 }
 
 // DefaultConfig returns a Config with sensible defaults.
@@ -596,6 +1043,17 @@ func DefaultConfig() *Config {
 			Port:          18790,
 			DashboardPort: 18791,
 			DaemonRuntime: "native",
+			RateLimit: GatewayRateLimitConfig{
+				PerTokenPerMinute: 1200,
+				PerIPPerMinute:    600,
+			},
+			UpdateCheck: GatewayUpdateCheckConfig{
+				Endpoint:      "https://api.github.com/repos/kafclaw/kafclaw/releases/latest",
+				IntervalHours: 24,
+			},
+			PublicStatus: GatewayPublicStatusConfig{
+				PerIPPerMinute: 30,
+			},
 		},
 		Node: NodeConfig{
 			ClawID:      "claw-local",
@@ -619,6 +1077,17 @@ func DefaultConfig() *Config {
 				MaxResults: 8,
 				MinScore:   0.22,
 			},
+			Grounded: MemoryGroundedConfig{
+				MinScore: 0.5,
+				Refusal:  "I don't know. Nothing in my indexed memory or knowledge answers this.",
+			},
+			Privacy: MemoryPrivacyConfig{
+				External: "trusted",
+			},
+			Dedup: MemoryDedupConfig{
+				Action:      "skip",
+				WindowHours: 7 * 24,
+			},
 		},
 		Knowledge: KnowledgeConfig{
 			Enabled:           false,
@@ -662,6 +1131,10 @@ func DefaultConfig() *Config {
 				MaxChildrenPerAgent: 5,
 				ArchiveAfterMinutes: 60,
 				MemoryShareMode:     "handoff",
+				ProgressIntervalSec: 30,
+			},
+			Output: ToolOutputConfig{
+				MaxChars: 16000,
 			},
 		},
 		Skills: SkillsConfig{
@@ -684,6 +1157,7 @@ func DefaultConfig() *Config {
 			LFSProxyURL:        "http://localhost:8080",
 			PollIntervalMs:     2000,
 			MaxDelegationDepth: 3,
+			RosterReconcileSec: 300,
 		},
 		Orchestrator: OrchestratorConfig{
 			Enabled: false,
@@ -704,6 +1178,18 @@ func DefaultConfig() *Config {
 			MessageThreshold: 50,
 			MaxObservations:  200,
 		},
+		Commitments: CommitmentsConfig{
+			Enabled:             false,
+			FollowUpIntervalSec: 60,
+		},
+		FinOps: FinOpsConfig{
+			Anomaly: CostAnomalyConfig{
+				IntervalSec:  300,
+				BaselineDays: 7,
+				Factor:       5,
+				MinTokens:    50000,
+			},
+		},
 		Channels: ChannelsConfig{
 			Slack: SlackConfig{
 				DmPolicy:         DmPolicyPairing,
@@ -720,8 +1206,35 @@ func DefaultConfig() *Config {
 				RequireMention: true,
 				SessionScope:   "room",
 			},
+			Telegram: TelegramConfig{
+				DmPolicy:       DmPolicyPairing,
+				GroupPolicy:    GroupPolicyAllowlist,
+				RequireMention: true,
+				SessionScope:   "room",
+			},
+			Discord: DiscordConfig{
+				DmPolicy:       DmPolicyPairing,
+				GroupPolicy:    GroupPolicyAllowlist,
+				RequireMention: true,
+				SessionScope:   "room",
+			},
 			WhatsApp: WhatsAppConfig{
-				SessionScope: "room",
+				SessionScope:       "room",
+				SessionBackupHours: 24,
+				SessionBackupKeep:  7,
+			},
+			Dedupe: DedupeConfig{
+				Enabled:   true,
+				WindowSec: 600,
+			},
+			Presence: PresenceConfig{
+				Typing:           true,
+				ReadReceipts:     true,
+				TypingRefreshSec: 8,
+			},
+			ThreadContext: ThreadContextConfig{
+				Enabled: true,
+				Limit:   20,
 			},
 		},
 	}
@@ -190,16 +190,24 @@ func Load() (*Config, error) {
 	envconfig.Process("MIKROBOT_CHANNELS_FEISHU", &cfg.Channels.Feishu)
 	envconfig.Process("MIKROBOT_CHANNELS_SLACK", &cfg.Channels.Slack)
 	envconfig.Process("MIKROBOT_CHANNELS_MSTEAMS", &cfg.Channels.MSTeams)
+	envconfig.Process("MIKROBOT_CHANNELS_DEDUPE", &cfg.Channels.Dedupe)
+	envconfig.Process("MIKROBOT_CHANNELS_PRESENCE", &cfg.Channels.Presence)
+	envconfig.Process("MIKROBOT_CHANNELS_THREAD_CONTEXT", &cfg.Channels.ThreadContext)
 	envconfig.Process("MIKROBOT_GATEWAY", &cfg.Gateway)
 	envconfig.Process("MIKROBOT_NODE", &cfg.Node)
 	envconfig.Process("MIKROBOT_MEMORY_EMBEDDING", &cfg.Memory.Embedding)
 	envconfig.Process("MIKROBOT_MEMORY_SEARCH", &cfg.Memory.Search)
+	envconfig.Process("MIKROBOT_MEMORY_GROUNDED", &cfg.Memory.Grounded)
+	envconfig.Process("MIKROBOT_MEMORY_PRIVACY", &cfg.Memory.Privacy)
+	envconfig.Process("MIKROBOT_MEMORY_DEDUP", &cfg.Memory.Dedup)
 	envconfig.Process("MIKROBOT_KNOWLEDGE", &cfg.Knowledge)
 	envconfig.Process("MIKROBOT_KNOWLEDGE_TOPICS", &cfg.Knowledge.Topics)
 	envconfig.Process("MIKROBOT_KNOWLEDGE_VOTING", &cfg.Knowledge.Voting)
 	envconfig.Process("MIKROBOT_TOOLS_EXEC", &cfg.Tools.Exec)
 	envconfig.Process("MIKROBOT_TOOLS_WEB_SEARCH", &cfg.Tools.Web.Search)
 	envconfig.Process("MIKROBOT_TOOLS_SUBAGENTS", &cfg.Tools.Subagents)
+	envconfig.Process("MIKROBOT_TOOLS", &cfg.Tools.ImageGen)
+	envconfig.Process("MIKROBOT_TOOLS", &cfg.Tools.Output)
 	envconfig.Process("MIKROBOT_SKILLS", &cfg.Skills)
 	legacyAgentDefaults := SubagentsToolConfig{}
 	if cfg.Agents != nil {
@@ -217,6 +225,7 @@ func Load() (*Config, error) {
 	envconfig.Process("MIKROBOT_SCHEDULER", &cfg.Scheduler)
 	envconfig.Process("MIKROBOT", &cfg.ER1)
 	envconfig.Process("MIKROBOT", &cfg.Observer)
+	envconfig.Process("MIKROBOT", &cfg.Commitments)
 	envconfig.Process("KAFCLAW_PATHS", &cfg.Paths)
 	envconfig.Process("KAFCLAW_MODEL", &cfg.Model)
 	envconfig.Process("KAFCLAW_OPENAI", &cfg.Providers.OpenAI)
@@ -226,16 +235,24 @@ func Load() (*Config, error) {
 	envconfig.Process("KAFCLAW_CHANNELS_FEISHU", &cfg.Channels.Feishu)
 	envconfig.Process("KAFCLAW_CHANNELS_SLACK", &cfg.Channels.Slack)
 	envconfig.Process("KAFCLAW_CHANNELS_MSTEAMS", &cfg.Channels.MSTeams)
+	envconfig.Process("KAFCLAW_CHANNELS_DEDUPE", &cfg.Channels.Dedupe)
+	envconfig.Process("KAFCLAW_CHANNELS_PRESENCE", &cfg.Channels.Presence)
+	envconfig.Process("KAFCLAW_CHANNELS_THREAD_CONTEXT", &cfg.Channels.ThreadContext)
 	envconfig.Process("KAFCLAW_GATEWAY", &cfg.Gateway)
 	envconfig.Process("KAFCLAW_NODE", &cfg.Node)
 	envconfig.Process("KAFCLAW_MEMORY_EMBEDDING", &cfg.Memory.Embedding)
 	envconfig.Process("KAFCLAW_MEMORY_SEARCH", &cfg.Memory.Search)
+	envconfig.Process("KAFCLAW_MEMORY_GROUNDED", &cfg.Memory.Grounded)
+	envconfig.Process("KAFCLAW_MEMORY_PRIVACY", &cfg.Memory.Privacy)
+	envconfig.Process("KAFCLAW_MEMORY_DEDUP", &cfg.Memory.Dedup)
 	envconfig.Process("KAFCLAW_KNOWLEDGE", &cfg.Knowledge)
 	envconfig.Process("KAFCLAW_KNOWLEDGE_TOPICS", &cfg.Knowledge.Topics)
 	envconfig.Process("KAFCLAW_KNOWLEDGE_VOTING", &cfg.Knowledge.Voting)
 	envconfig.Process("KAFCLAW_TOOLS_EXEC", &cfg.Tools.Exec)
 	envconfig.Process("KAFCLAW_TOOLS_WEB_SEARCH", &cfg.Tools.Web.Search)
 	envconfig.Process("KAFCLAW_TOOLS_SUBAGENTS", &cfg.Tools.Subagents)
+	envconfig.Process("KAFCLAW_TOOLS", &cfg.Tools.ImageGen)
+	envconfig.Process("KAFCLAW_TOOLS", &cfg.Tools.Output)
 	envconfig.Process("KAFCLAW_SKILLS", &cfg.Skills)
 	agentDefaults := SubagentsToolConfig{}
 	if cfg.Agents != nil {
@@ -253,6 +270,7 @@ func Load() (*Config, error) {
 	envconfig.Process("KAFCLAW_SCHEDULER", &cfg.Scheduler)
 	envconfig.Process("KAFCLAW", &cfg.ER1)
 	envconfig.Process("KAFCLAW", &cfg.Observer)
+	envconfig.Process("KAFCLAW", &cfg.Commitments)
 
 	// Legacy env var compatibility
 	envconfig.Process("MIKROBOT_AGENTS", &cfg.Paths)
@@ -350,6 +368,18 @@ func Load() (*Config, error) {
 	if cfg.Channels.MSTeams.GroupPolicy == "" {
 		cfg.Channels.MSTeams.GroupPolicy = GroupPolicyAllowlist
 	}
+	if cfg.Channels.Discord.DmPolicy == "" {
+		cfg.Channels.Discord.DmPolicy = DmPolicyPairing
+	}
+	if cfg.Channels.Discord.GroupPolicy == "" {
+		cfg.Channels.Discord.GroupPolicy = GroupPolicyAllowlist
+	}
+	if cfg.Channels.Telegram.DmPolicy == "" {
+		cfg.Channels.Telegram.DmPolicy = DmPolicyPairing
+	}
+	if cfg.Channels.Telegram.GroupPolicy == "" {
+		cfg.Channels.Telegram.GroupPolicy = GroupPolicyAllowlist
+	}
 
 	normalizeMemoryKnowledgeConfig(cfg)
 
@@ -0,0 +1,542 @@
+package group
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// AdminAction is the action of an administrative envelope.
+type AdminAction string
+
+const (
+	AdminActionKick      AdminAction = "kick"               // remove a member; its envelopes are dropped
+	AdminActionReinstate AdminAction = "reinstate"          // lift a kick
+	AdminActionMute      AdminAction = "mute"               // drop everything but announces from a member
+	AdminActionUnmute    AdminAction = "unmute"             // lift a mute
+	AdminActionRename    AdminAction = "rename"             // set the group display name
+	AdminActionRotate    AdminAction = "rotate_credentials" // replace the issuer's admin signing key
+	AdminActionGrant     AdminAction = "grant_admin"        // founder only: promote a member
+	AdminActionRevoke    AdminAction = "revoke_admin"       // founder only: demote an admin
+	AdminActionObserve   AdminAction = "set_observer"       // make a member consume-only
+	AdminActionUnobserve AdminAction = "clear_observer"     // lift set_observer
+)
+
+// Administrative roles of a group member.
+const (
+	AdminRoleFounder = "founder"
+	AdminRoleAdmin   = "admin"
+	AdminRoleMember  = "member"
+)
+
+// adminMaxAge bounds how old (or how far in the future) an administrative
+// envelope may be; older envelopes are rejected as replays.
+const adminMaxAge = 15 * time.Minute
+
+// foundingRace is how long after founding a group locally this agent still
+// yields to a concurrent founder. FoundedAt is self-asserted, so later claims
+// never replace a recorded founder.
+const foundingRace = 30 * time.Second
+
+// AdminPayload is the wire format for administrative envelopes on the roster
+// control topic. Signature is the issuer's ed25519 signature (base64) over
+// the JSON encoding of the payload with an empty Signature.
+type AdminPayload struct {
+	Action    AdminAction `json:"action"`
+	GroupName string      `json:"group_name"`
+	IssuerID  string      `json:"issuer_id"`
+	TargetID  string      `json:"target_id,omitempty"`
+	Value     string      `json:"value,omitempty"` // display name (rename) or public key (grant_admin, rotate_credentials)
+	Reason    string      `json:"reason,omitempty"`
+	Nonce     string      `json:"nonce"`
+	IssuedAt  time.Time   `json:"issued_at"`
+	Signature string      `json:"signature,omitempty"`
+}
+
+// AdminState is the administrative state of the group as seen by this agent.
+type AdminState struct {
+	Founder     string            `json:"founder,omitempty"`
+	FoundedAt   string            `json:"founded_at,omitempty"`
+	DisplayName string            `json:"display_name,omitempty"`
+	Admins      map[string]string `json:"admins"` // agent ID -> admin public key, founder included
+	Muted       map[string]string `json:"muted"`  // agent ID -> reason
+	Kicked      map[string]string `json:"kicked"` // agent ID -> reason
+	// Observers are members made consume-only by an admin (agent ID ->
+	// reason). Members that declare the observer role at join are not listed.
+	Observers map[string]string `json:"observers"`
+}
+
+func newAdminState() AdminState {
+	return AdminState{Admins: map[string]string{}, Muted: map[string]string{}, Kicked: map[string]string{}, Observers: map[string]string{}}
+}
+
+func (s AdminState) clone() AdminState {
+	out := s
+	out.Admins, out.Muted, out.Kicked, out.Observers = map[string]string{}, map[string]string{}, map[string]string{}, map[string]string{}
+	for k, v := range s.Admins {
+		out.Admins[k] = v
+	}
+	for k, v := range s.Muted {
+		out.Muted[k] = v
+	}
+	for k, v := range s.Kicked {
+		out.Kicked[k] = v
+	}
+	for k, v := range s.Observers {
+		out.Observers[k] = v
+	}
+	return out
+}
+
+// RoleOf returns the administrative role of agentID.
+func (s AdminState) RoleOf(agentID string) string {
+	if agentID != "" && agentID == s.Founder {
+		return AdminRoleFounder
+	}
+	if _, ok := s.Admins[agentID]; ok {
+		return AdminRoleAdmin
+	}
+	return AdminRoleMember
+}
+
+func (p AdminPayload) signingBytes() []byte {
+	p.Signature = ""
+	data, _ := json.Marshal(p)
+	return data
+}
+
+func (m *Manager) adminStateKey() string {
+	return "group_admin_state:" + m.cfg.GroupName
+}
+
+// loadAdmin restores the signing key and admin state from the timeline,
+// creating a signing key on first use.
+func (m *Manager) loadAdmin() {
+	m.admin = newAdminState()
+	if m.timeline != nil {
+		if raw, err := m.timeline.GetSetting("group_admin_key"); err == nil {
+			if seed, err := base64.StdEncoding.DecodeString(raw); err == nil && len(seed) == ed25519.SeedSize {
+				m.adminKey = ed25519.NewKeyFromSeed(seed)
+			}
+		}
+		if raw, err := m.timeline.GetSetting(m.adminStateKey()); err == nil && raw != "" {
+			state := newAdminState()
+			if err := json.Unmarshal([]byte(raw), &state); err == nil {
+				m.admin = state.clone()
+			}
+		}
+	}
+	if m.adminKey == nil {
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			slog.Warn("Group admin key generation failed", "error", err)
+			return
+		}
+		m.adminKey = priv
+		m.saveAdminKey()
+	}
+	m.identity.AdminKey = m.adminPublicKey()
+	if m.admin.Founder == m.identity.AgentID {
+		m.identity.FoundedAt = m.admin.FoundedAt
+	}
+}
+
+func (m *Manager) adminPublicKey() string {
+	if m.adminKey == nil {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(m.adminKey.Public().(ed25519.PublicKey))
+}
+
+func (m *Manager) saveAdminKey() {
+	if m.timeline == nil || m.adminKey == nil {
+		return
+	}
+	_ = m.timeline.SetSetting("group_admin_key", base64.StdEncoding.EncodeToString(m.adminKey.Seed()))
+}
+
+// saveAdminState persists the admin state. Callers hold adminMu.
+func (m *Manager) saveAdminState() {
+	if m.timeline == nil {
+		return
+	}
+	data, err := json.Marshal(m.admin)
+	if err != nil {
+		return
+	}
+	_ = m.timeline.SetSetting(m.adminStateKey(), string(data))
+}
+
+// AdminState returns a copy of the group's administrative state.
+func (m *Manager) AdminState() AdminState {
+	m.adminMu.RLock()
+	defer m.adminMu.RUnlock()
+	return m.admin.clone()
+}
+
+// AdminRole returns this agent's administrative role.
+func (m *Manager) AdminRole() string {
+	m.adminMu.RLock()
+	defer m.adminMu.RUnlock()
+	return m.admin.RoleOf(m.identity.AgentID)
+}
+
+// foundGroup makes this agent the founder when it creates the group.
+func (m *Manager) foundGroup(foundedAt string) {
+	m.adminMu.Lock()
+	defer m.adminMu.Unlock()
+	m.admin.Founder = m.identity.AgentID
+	m.admin.FoundedAt = foundedAt
+	m.admin.Admins[m.identity.AgentID] = m.identity.AdminKey
+	m.foundedLocal = time.Now()
+	m.saveAdminState()
+	m.logAdminAction(&AdminPayload{Action: "found", IssuerID: m.identity.AgentID}, "applied", "group created", "")
+}
+
+// observeFounder learns the founder from announce identities. The first
+// founder seen is pinned. A concurrent founding only replaces this agent's own
+// claim while that is still fresh (foundingRace on the local clock) and the
+// other claim is older but within the same window (ties go to the lower agent
+// ID); afterwards the recorded founder stays.
+func (m *Manager) observeFounder(id AgentIdentity) {
+	if id.FoundedAt == "" || id.AdminKey == "" || id.AgentID == "" {
+		return
+	}
+	self := m.identity.AgentID
+	m.adminMu.Lock()
+	defer m.adminMu.Unlock()
+	switch m.admin.Founder {
+	case id.AgentID:
+		return
+	case "":
+	case self:
+		if m.foundedLocal.IsZero() || time.Since(m.foundedLocal) > foundingRace {
+			slog.Warn("Ignoring founder claim against the recorded founder", "agent_id", id.AgentID)
+			return
+		}
+		theirs, err := time.Parse(time.RFC3339Nano, id.FoundedAt)
+		if err != nil {
+			return
+		}
+		ours, _ := time.Parse(time.RFC3339Nano, m.admin.FoundedAt)
+		if theirs.After(ours) || (theirs.Equal(ours) && id.AgentID > self) || ours.Sub(theirs) > foundingRace {
+			return
+		}
+		delete(m.admin.Admins, self)
+		m.identity.FoundedAt = ""
+	default:
+		slog.Debug("Ignoring founder claim", "agent_id", id.AgentID, "founder", m.admin.Founder)
+		return
+	}
+	m.admin.Founder = id.AgentID
+	m.admin.FoundedAt = id.FoundedAt
+	m.admin.Admins[id.AgentID] = id.AdminKey
+	m.saveAdminState()
+	m.logAdminAction(&AdminPayload{Action: "found", IssuerID: id.AgentID}, "applied", "founder recognized", "")
+	slog.Info("Group founder recognized", "founder", id.AgentID)
+}
+
+// allowsSender enforces kicks and mutes on incoming envelopes: kicked members
+// are ignored entirely; muted members may only announce, so they stay in the
+// roster, and send administrative envelopes (checked by signature).
+func (m *Manager) allowsSender(senderID, envType string) bool {
+	m.adminMu.RLock()
+	defer m.adminMu.RUnlock()
+	if _, kicked := m.admin.Kicked[senderID]; kicked {
+		return false
+	}
+	if _, muted := m.admin.Muted[senderID]; muted {
+		return envType == EnvelopeAnnounce || envType == EnvelopeAdmin
+	}
+	return true
+}
+
+func (m *Manager) isKicked(agentID string) bool {
+	m.adminMu.RLock()
+	defer m.adminMu.RUnlock()
+	_, kicked := m.admin.Kicked[agentID]
+	return kicked
+}
+
+// Administer signs an administrative action, publishes it to the group and
+// applies it locally. Every action, applied or rejected, is audited.
+func (m *Manager) Administer(ctx context.Context, action AdminAction, targetID, value, reason string) (*AdminPayload, error) {
+	if !m.Active() {
+		return nil, fmt.Errorf("not in a group")
+	}
+	if m.adminKey == nil {
+		return nil, fmt.Errorf("no admin signing key")
+	}
+	nonce := make([]byte, 12)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	p := &AdminPayload{
+		Action:    action,
+		GroupName: m.cfg.GroupName,
+		IssuerID:  m.identity.AgentID,
+		TargetID:  targetID,
+		Value:     value,
+		Reason:    reason,
+		Nonce:     hex.EncodeToString(nonce),
+		IssuedAt:  time.Now().UTC(),
+	}
+
+	var rotated ed25519.PrivateKey
+	switch action {
+	case AdminActionGrant:
+		if p.Value == "" {
+			m.rosterMu.RLock()
+			if member, ok := m.roster[targetID]; ok {
+				p.Value = member.AdminKey
+			}
+			m.rosterMu.RUnlock()
+		}
+		if p.Value == "" {
+			return nil, fmt.Errorf("no admin key known for %s; wait for its heartbeat", targetID)
+		}
+	case AdminActionRotate:
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		rotated = priv
+		p.TargetID = m.identity.AgentID
+		p.Value = base64.StdEncoding.EncodeToString(priv.Public().(ed25519.PublicKey))
+	}
+	// Signed with the current key; a rotation is vouched for by the old key.
+	p.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(m.adminKey, p.signingBytes()))
+
+	correlationID := "admin-" + p.Nonce
+	if err := m.checkAdmin(p); err != nil {
+		m.logAdminAction(p, "rejected", err.Error(), correlationID)
+		return nil, err
+	}
+	env := &GroupEnvelope{
+		Type:          EnvelopeAdmin,
+		CorrelationID: correlationID,
+		SenderID:      m.identity.AgentID,
+		Timestamp:     time.Now(),
+		Payload:       p,
+	}
+	if err := m.publish(ctx, m.extTopics.ControlRoster, env); err != nil {
+		return nil, fmt.Errorf("publish admin action: %w", err)
+	}
+	m.applyAdmin(p, correlationID)
+	if rotated != nil {
+		m.adminKey = rotated
+		m.identity.AdminKey = p.Value
+		m.saveAdminKey()
+	}
+	_ = m.PublishAudit(ctx, "admin_"+string(action), correlationID, adminDetail(p))
+	return p, nil
+}
+
+// HandleAdmin verifies and applies an administrative envelope from another member.
+func (m *Manager) HandleAdmin(env *GroupEnvelope) {
+	data, err := json.Marshal(env.Payload)
+	if err != nil {
+		return
+	}
+	var p AdminPayload
+	if err := json.Unmarshal(data, &p); err != nil {
+		slog.Warn("HandleAdmin: unmarshal payload", "error", err)
+		return
+	}
+	if p.IssuerID != env.SenderID {
+		m.logAdminAction(&p, "rejected", "issuer does not match sender "+env.SenderID, env.CorrelationID)
+		return
+	}
+	if err := m.checkAdmin(&p); err != nil {
+		slog.Warn("Group admin action rejected", "action", p.Action, "issuer", p.IssuerID, "target", p.TargetID, "error", err)
+		m.logAdminAction(&p, "rejected", err.Error(), env.CorrelationID)
+		return
+	}
+	m.applyAdmin(&p, env.CorrelationID)
+}
+
+// checkAdmin verifies the signature, freshness and authority of p against the
+// current admin state.
+func (m *Manager) checkAdmin(p *AdminPayload) error {
+	if p.GroupName != m.cfg.GroupName {
+		return fmt.Errorf("admin action for group %q", p.GroupName)
+	}
+	if age := time.Since(p.IssuedAt); age > adminMaxAge || age < -adminMaxAge {
+		return fmt.Errorf("admin action issued at %s is outside the accepted window", p.IssuedAt.Format(time.RFC3339))
+	}
+
+	m.adminMu.Lock()
+	defer m.adminMu.Unlock()
+	for nonce, seen := range m.adminSeen {
+		if time.Since(seen) > 2*adminMaxAge {
+			delete(m.adminSeen, nonce)
+		}
+	}
+	if p.Nonce == "" {
+		return fmt.Errorf("admin action without nonce")
+	}
+	if _, dup := m.adminSeen[p.Nonce]; dup {
+		return fmt.Errorf("replayed admin action %s", p.Nonce)
+	}
+
+	key, ok := m.admin.Admins[p.IssuerID]
+	if !ok {
+		return fmt.Errorf("%s is not an admin of group %s", p.IssuerID, m.cfg.GroupName)
+	}
+	pub, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid admin key for %s", p.IssuerID)
+	}
+	sig, err := base64.StdEncoding.DecodeString(p.Signature)
+	if err != nil || !ed25519.Verify(ed25519.PublicKey(pub), p.signingBytes(), sig) {
+		return fmt.Errorf("invalid signature from %s", p.IssuerID)
+	}
+
+	founder := p.IssuerID == m.admin.Founder
+	_, targetIsAdmin := m.admin.Admins[p.TargetID]
+	switch p.Action {
+	case AdminActionKick, AdminActionMute, AdminActionObserve:
+		if p.TargetID == "" || p.TargetID == p.IssuerID {
+			return fmt.Errorf("%s needs another member as target", p.Action)
+		}
+		if p.TargetID == m.admin.Founder {
+			return fmt.Errorf("the founder cannot be kicked, muted or made an observer")
+		}
+		if targetIsAdmin && !founder {
+			return fmt.Errorf("only the founder can %s an admin", p.Action)
+		}
+	case AdminActionReinstate, AdminActionUnmute, AdminActionUnobserve:
+		if p.TargetID == "" {
+			return fmt.Errorf("%s needs a target", p.Action)
+		}
+	case AdminActionRename:
+		if p.Value == "" || len([]rune(p.Value)) > 64 {
+			return fmt.Errorf("rename needs a name of 1-64 characters")
+		}
+	case AdminActionRotate:
+		if p.TargetID != p.IssuerID {
+			return fmt.Errorf("admins can only rotate their own credentials")
+		}
+		if !validAdminKey(p.Value) {
+			return fmt.Errorf("rotate_credentials needs a valid public key")
+		}
+	case AdminActionGrant, AdminActionRevoke:
+		if !founder {
+			return fmt.Errorf("only the founder can %s", p.Action)
+		}
+		if p.TargetID == "" || p.TargetID == m.admin.Founder {
+			return fmt.Errorf("%s needs a member other than the founder", p.Action)
+		}
+		if p.Action == AdminActionGrant && !validAdminKey(p.Value) {
+			return fmt.Errorf("grant_admin needs the member's admin public key")
+		}
+	default:
+		return fmt.Errorf("unknown admin action %q", p.Action)
+	}
+	m.adminSeen[p.Nonce] = time.Now()
+	return nil
+}
+
+func validAdminKey(s string) bool {
+	pub, err := base64.StdEncoding.DecodeString(s)
+	return err == nil && len(pub) == ed25519.PublicKeySize
+}
+
+// applyAdmin applies a verified action to the admin state and roster.
+func (m *Manager) applyAdmin(p *AdminPayload, correlationID string) {
+	self := m.identity.AgentID
+	m.adminMu.Lock()
+	switch p.Action {
+	case AdminActionKick:
+		m.admin.Kicked[p.TargetID] = p.Reason
+		delete(m.admin.Muted, p.TargetID)
+		delete(m.admin.Observers, p.TargetID)
+		delete(m.admin.Admins, p.TargetID)
+	case AdminActionReinstate:
+		delete(m.admin.Kicked, p.TargetID)
+	case AdminActionMute:
+		m.admin.Muted[p.TargetID] = p.Reason
+	case AdminActionUnmute:
+		delete(m.admin.Muted, p.TargetID)
+	case AdminActionObserve:
+		m.admin.Observers[p.TargetID] = p.Reason
+	case AdminActionUnobserve:
+		delete(m.admin.Observers, p.TargetID)
+	case AdminActionRename:
+		m.admin.DisplayName = p.Value
+	case AdminActionRotate, AdminActionGrant:
+		m.admin.Admins[p.TargetID] = p.Value
+	case AdminActionRevoke:
+		delete(m.admin.Admins, p.TargetID)
+	}
+	m.saveAdminState()
+	m.adminMu.Unlock()
+	m.logAdminAction(p, "applied", adminDetail(p), correlationID)
+	slog.Info("Group admin action applied", "action", p.Action, "issuer", p.IssuerID, "target", p.TargetID)
+
+	if p.Action != AdminActionKick {
+		return
+	}
+	if p.TargetID == self {
+		if err := m.Leave(context.Background()); err != nil {
+			slog.Warn("Leave after kick failed", "error", err)
+		}
+		return
+	}
+	m.rosterMu.Lock()
+	member := m.roster[p.TargetID]
+	delete(m.roster, p.TargetID)
+	m.rosterMu.Unlock()
+	if m.timeline != nil {
+		_ = m.timeline.SoftDeleteGroupMember(p.TargetID)
+		var name, role string
+		if member != nil {
+			name, role = member.AgentName, member.Role
+		}
+		_ = m.timeline.LogMembershipHistory(&timeline.GroupMembershipHistoryRecord{
+			AgentID:       p.TargetID,
+			GroupName:     m.cfg.GroupName,
+			Role:          role,
+			Action:        "kicked",
+			LFSProxyURL:   m.cfg.LFSProxyURL,
+			KafkaBrokers:  m.cfg.KafkaBrokers,
+			ConsumerGroup: m.cfg.ConsumerGroup,
+			AgentName:     name,
+			Capabilities:  "[]",
+			Channels:      "[]",
+		})
+	}
+}
+
+func adminDetail(p *AdminPayload) string {
+	switch p.Action {
+	case AdminActionRename:
+		return "renamed to " + p.Value
+	case AdminActionRotate, AdminActionGrant:
+		return "key " + p.Value
+	}
+	return p.Reason
+}
+
+func (m *Manager) logAdminAction(p *AdminPayload, status, detail, correlationID string) {
+	if m.timeline == nil {
+		return
+	}
+	_ = m.timeline.LogGroupAdminAction(&timeline.GroupAdminActionRecord{
+		GroupName:     m.cfg.GroupName,
+		Action:        string(p.Action),
+		IssuerID:      p.IssuerID,
+		TargetID:      p.TargetID,
+		Detail:        detail,
+		Status:        status,
+		CorrelationID: correlationID,
+	})
+}
@@ -0,0 +1,258 @@
+package group
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func lastAdminEnvelope(t *testing.T, produced *producedStore) GroupEnvelope {
+	t.Helper()
+	items := produced.snapshot()
+	for i := len(items) - 1; i >= 0; i-- {
+		if items[i].Type == EnvelopeAdmin {
+			return items[i]
+		}
+	}
+	t.Fatal("no admin envelope produced")
+	return GroupEnvelope{}
+}
+
+func heartbeatOf(m *Manager) GroupEnvelope {
+	return GroupEnvelope{
+		Type:      EnvelopeAnnounce,
+		SenderID:  m.identity.AgentID,
+		Timestamp: time.Now(),
+		Payload:   AnnouncePayload{Action: "heartbeat", Identity: m.identity},
+	}
+}
+
+func TestAdmin_FounderMutesKicksAndDelegates(t *testing.T) {
+	var produced producedStore
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var env GroupEnvelope
+		_ = json.NewDecoder(r.Body).Decode(&env)
+		produced.append(env)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(LFSEnvelope{KfsLFS: 1})
+	}))
+	defer server.Close()
+
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tl.Close()
+	ctx := context.Background()
+	ext := ExtendedTopics("test-group")
+
+	founder := newTestManagerForOnboard(server.URL, "founder", "open")
+	if err := founder.Join(ctx); err != nil {
+		t.Fatalf("founder join: %v", err)
+	}
+	if founder.AdminRole() != AdminRoleFounder {
+		t.Fatalf("expected founder role, got %q", founder.AdminRole())
+	}
+
+	// The member joins later and also finds an empty roster; the older
+	// founding wins once it sees the founder's heartbeat.
+	member := newTestManagerForOnboard(server.URL, "member", "open")
+	member.timeline = tl
+	if err := member.Join(ctx); err != nil {
+		t.Fatalf("member join: %v", err)
+	}
+	router := NewGroupRouter(member, bus.NewMessageBus(), NewChannelConsumer())
+	router.handleMessage(inboxMessage(t, ext.ControlAnnounce, heartbeatOf(founder)))
+	if state := member.AdminState(); state.Founder != "founder" || member.AdminRole() != AdminRoleMember {
+		t.Fatalf("expected founder to be recognized, got %+v (role %s)", state, member.AdminRole())
+	}
+
+	// A mute from the founder is verified and enforced.
+	if _, err := founder.Administer(ctx, AdminActionMute, "spammer", "", "flooding requests"); err != nil {
+		t.Fatalf("mute: %v", err)
+	}
+	muteEnv := lastAdminEnvelope(t, &produced)
+	router.handleMessage(inboxMessage(t, ext.ControlRoster, muteEnv))
+	if _, ok := member.AdminState().Muted["spammer"]; !ok {
+		t.Fatal("expected spammer to be muted")
+	}
+	if member.allowsSender("spammer", EnvelopeRequest) || !member.allowsSender("spammer", EnvelopeAnnounce) {
+		t.Fatal("muted member may only announce")
+	}
+
+	// Replays, tampering and envelopes from non-admins are rejected.
+	router.handleMessage(inboxMessage(t, ext.ControlRoster, muteEnv))
+	tampered := muteEnv
+	payload := muteEnv.Payload.(map[string]any)
+	forged := map[string]any{}
+	for k, v := range payload {
+		forged[k] = v
+	}
+	forged["action"], forged["target_id"], forged["nonce"] = "kick", "member2", "other-nonce"
+	tampered.Payload = forged
+	router.handleMessage(inboxMessage(t, ext.ControlRoster, tampered))
+
+	_, rogueKey, _ := ed25519.GenerateKey(rand.Reader)
+	rogue := AdminPayload{Action: AdminActionUnmute, GroupName: "test-group", IssuerID: "spammer", TargetID: "spammer", Nonce: "n1", IssuedAt: time.Now().UTC()}
+	rogue.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(rogueKey, rogue.signingBytes()))
+	router.handleMessage(inboxMessage(t, ext.ControlRoster, GroupEnvelope{Type: EnvelopeAdmin, SenderID: "spammer", Timestamp: time.Now(), Payload: rogue}))
+	if _, ok := member.AdminState().Muted["spammer"]; !ok {
+		t.Fatal("a non-admin must not unmute itself")
+	}
+	if _, ok := member.AdminState().Kicked["member2"]; ok {
+		t.Fatal("tampered envelope must not be applied")
+	}
+
+	// Kicked members leave the roster and are ignored afterwards.
+	router.handleMessage(inboxMessage(t, ext.ControlAnnounce, GroupEnvelope{
+		Type: EnvelopeAnnounce, SenderID: "spammer", Timestamp: time.Now(),
+		Payload: AnnouncePayload{Action: "join", Identity: AgentIdentity{AgentID: "spammer", Status: "active"}},
+	}))
+	if _, err := founder.Administer(ctx, AdminActionKick, "spammer", "", "ignored mute"); err != nil {
+		t.Fatalf("kick: %v", err)
+	}
+	router.handleMessage(inboxMessage(t, ext.ControlRoster, lastAdminEnvelope(t, &produced)))
+	for _, m := range member.Members() {
+		if m.AgentID == "spammer" {
+			t.Fatal("kicked member still in roster")
+		}
+	}
+	if member.allowsSender("spammer", EnvelopeAnnounce) {
+		t.Fatal("kicked member must be ignored")
+	}
+
+	// Only the founder grants admin; the new admin can then rename the group.
+	if _, err := member.Administer(ctx, AdminActionRename, "", "Ops Crew", ""); err == nil {
+		t.Fatal("members must not rename the group")
+	}
+	founder.HandleAnnounce(&GroupEnvelope{Type: EnvelopeAnnounce, SenderID: "member", Payload: AnnouncePayload{Action: "heartbeat", Identity: member.identity}})
+	if _, err := founder.Administer(ctx, AdminActionGrant, "member", "", ""); err != nil {
+		t.Fatalf("grant: %v", err)
+	}
+	router.handleMessage(inboxMessage(t, ext.ControlRoster, lastAdminEnvelope(t, &produced)))
+	if member.AdminRole() != AdminRoleAdmin {
+		t.Fatalf("expected member to be admin, got %q", member.AdminRole())
+	}
+	if _, err := member.Administer(ctx, AdminActionRename, "", "Ops Crew", ""); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	founder.HandleAdmin(func() *GroupEnvelope { env := lastAdminEnvelope(t, &produced); return &env }())
+	if founder.AdminState().DisplayName != "Ops Crew" {
+		t.Fatalf("expected rename on founder, got %+v", founder.AdminState())
+	}
+	if _, err := member.Administer(ctx, AdminActionKick, "founder", "", ""); err == nil {
+		t.Fatal("the founder must not be kickable")
+	}
+
+	// Every action, applied or rejected, is in the unified audit log.
+	entries, err := tl.ListUnifiedAudit(timeline.AuditFilter{Source: "group_admin", Limit: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	for _, e := range entries {
+		counts[e.EventType]++
+	}
+	for _, want := range []string{"found", "mute", "mute_rejected", "kick", "kick_rejected", "unmute_rejected", "grant_admin", "rename", "rename_rejected"} {
+		if counts[want] == 0 {
+			t.Errorf("missing %s audit entry, got %v", want, counts)
+		}
+	}
+}
+
+func TestAdmin_FounderClaimTakeoverRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(LFSEnvelope{KfsLFS: 1})
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	founder := newTestManagerForOnboard(server.URL, "founder", "open")
+	if err := founder.Join(ctx); err != nil {
+		t.Fatalf("founder join: %v", err)
+	}
+	attacker := newTestManagerForOnboard(server.URL, "attacker", "open")
+	if err := attacker.Join(ctx); err != nil {
+		t.Fatalf("attacker join: %v", err)
+	}
+
+	// A claim that predates the founding by more than the race window is
+	// never a concurrent founding.
+	attacker.identity.FoundedAt = time.Now().Add(-time.Hour).UTC().Format(time.RFC3339Nano)
+	founder.HandleAnnounce(&GroupEnvelope{Type: EnvelopeAnnounce, SenderID: "attacker", Payload: AnnouncePayload{Action: "heartbeat", Identity: attacker.identity}})
+	if state := founder.AdminState(); state.Founder != "founder" || founder.AdminRole() != AdminRoleFounder {
+		t.Fatalf("an old founder claim must not demote the founder, got %+v", state)
+	}
+
+	// Once the founding is settled, even a slightly older claim is ignored.
+	founder.adminMu.Lock()
+	founder.foundedLocal = time.Now().Add(-2 * foundingRace)
+	ours, _ := time.Parse(time.RFC3339Nano, founder.admin.FoundedAt)
+	founder.adminMu.Unlock()
+	attacker.identity.FoundedAt = ours.Add(-time.Second).Format(time.RFC3339Nano)
+	founder.HandleAnnounce(&GroupEnvelope{Type: EnvelopeAnnounce, SenderID: "attacker", Payload: AnnouncePayload{Action: "heartbeat", Identity: attacker.identity}})
+	if state := founder.AdminState(); state.Founder != "founder" {
+		t.Fatalf("a settled founder must not be replaced, got %+v", state)
+	}
+	if _, isAdmin := founder.AdminState().Admins["attacker"]; isAdmin {
+		t.Fatal("the claimant must not become an admin")
+	}
+}
+
+func TestAdmin_RotateCredentialsAndRejoinAfterKick(t *testing.T) {
+	var produced producedStore
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var env GroupEnvelope
+		_ = json.NewDecoder(r.Body).Decode(&env)
+		produced.append(env)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(LFSEnvelope{KfsLFS: 1})
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	founder := newTestManagerForOnboard(server.URL, "founder", "open")
+	if err := founder.Join(ctx); err != nil {
+		t.Fatalf("founder join: %v", err)
+	}
+	member := newTestManagerForOnboard(server.URL, "member", "open")
+	member.HandleAnnounce(func() *GroupEnvelope { env := heartbeatOf(founder); return &env }())
+
+	oldKey := founder.identity.AdminKey
+	if _, err := founder.Administer(ctx, AdminActionRotate, "", "", ""); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	rotate := lastAdminEnvelope(t, &produced)
+	member.HandleAdmin(&rotate)
+	newKey := member.AdminState().Admins["founder"]
+	if newKey == oldKey || newKey != founder.identity.AdminKey {
+		t.Fatalf("expected rotated key %s, got %s", founder.identity.AdminKey, newKey)
+	}
+
+	// Actions signed with the new key verify; the member is kicked and leaves.
+	if err := member.Join(ctx); err != nil {
+		t.Fatalf("member join: %v", err)
+	}
+	if _, err := founder.Administer(ctx, AdminActionKick, "member", "", "rotation test"); err != nil {
+		t.Fatalf("kick: %v", err)
+	}
+	kick := lastAdminEnvelope(t, &produced)
+	member.HandleAdmin(&kick)
+	if member.Active() {
+		t.Fatal("kicked member must leave the group")
+	}
+	if err := member.Join(ctx); err == nil {
+		t.Fatal("kicked member must not rejoin")
+	}
+}
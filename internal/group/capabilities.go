@@ -0,0 +1,133 @@
+package group
+
+import (
+	"sort"
+	"strings"
+)
+
+// Capability kinds of the member capability taxonomy.
+const (
+	CapabilityChannel = "channel"
+	CapabilityTool    = "tool"
+	CapabilitySkill   = "skill"
+	CapabilityModel   = "model"
+)
+
+// Capability is one typed entry of a member's capability taxonomy. Tags are
+// free-form topics (e.g. "kubernetes") that make a capability discoverable
+// beyond its name.
+type Capability struct {
+	Kind    string   `json:"kind"`
+	Name    string   `json:"name"`
+	Version string   `json:"version,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+// String returns the canonical "kind:name@version" form.
+func (c Capability) String() string {
+	s := c.Kind + ":" + c.Name
+	if c.Version != "" {
+		s += "@" + c.Version
+	}
+	return s
+}
+
+// IsCapabilityKind reports whether kind is part of the taxonomy.
+func IsCapabilityKind(kind string) bool {
+	switch kind {
+	case CapabilityChannel, CapabilityTool, CapabilitySkill, CapabilityModel:
+		return true
+	}
+	return false
+}
+
+// ParseCapability parses "kind:name@version". The kind and version are
+// optional; a bare name is a tool, as in the free-form capability list.
+func ParseCapability(s string) (Capability, bool) {
+	s = strings.TrimSpace(s)
+	c := Capability{Kind: CapabilityTool}
+	if kind, rest, ok := strings.Cut(s, ":"); ok && IsCapabilityKind(strings.ToLower(kind)) {
+		c.Kind = strings.ToLower(kind)
+		s = rest
+	}
+	if name, version, ok := strings.Cut(s, "@"); ok {
+		s = name
+		c.Version = strings.TrimSpace(version)
+	}
+	c.Name = strings.TrimSpace(s)
+	return c, c.Name != ""
+}
+
+// BuildCapabilityTaxonomy derives the taxonomy of an identity from its
+// free-form capabilities, channels and model, merged with any typed entries
+// it already declares. Entries are unique per kind and name; declared entries
+// win. The result is sorted by kind and name.
+func BuildCapabilityTaxonomy(id AgentIdentity) []Capability {
+	byKey := map[string]Capability{}
+	add := func(c Capability, override bool) {
+		c.Kind = strings.ToLower(strings.TrimSpace(c.Kind))
+		c.Name = strings.TrimSpace(c.Name)
+		if c.Name == "" || !IsCapabilityKind(c.Kind) {
+			return
+		}
+		key := c.Kind + ":" + strings.ToLower(c.Name)
+		if _, ok := byKey[key]; ok && !override {
+			return
+		}
+		byKey[key] = c
+	}
+	for _, c := range id.Taxonomy {
+		add(c, true)
+	}
+	for _, s := range id.Capabilities {
+		if c, ok := ParseCapability(s); ok {
+			add(c, false)
+		}
+	}
+	for _, ch := range id.Channels {
+		add(Capability{Kind: CapabilityChannel, Name: ch}, false)
+	}
+	if id.Model != "" {
+		add(Capability{Kind: CapabilityModel, Name: id.Model}, false)
+	}
+
+	out := make([]Capability, 0, len(byKey))
+	for _, c := range byKey {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind < out[j].Kind
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// MatchCapabilities returns the capabilities matching query, a
+// case-insensitive substring of the name or one of the tags. A query in
+// "kind:term" form, or a non-empty kind, restricts matches to that kind.
+func MatchCapabilities(caps []Capability, query, kind string) []Capability {
+	query = strings.ToLower(strings.TrimSpace(query))
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	if k, rest, ok := strings.Cut(query, ":"); ok && IsCapabilityKind(k) {
+		kind, query = k, strings.TrimSpace(rest)
+	}
+	var out []Capability
+	for _, c := range caps {
+		if kind != "" && c.Kind != kind {
+			continue
+		}
+		if query == "" || strings.Contains(strings.ToLower(c.Name), query) {
+			out = append(out, c)
+			continue
+		}
+		for _, tag := range c.Tags {
+			if strings.Contains(strings.ToLower(tag), query) {
+				out = append(out, c)
+				break
+			}
+		}
+	}
+	return out
+}
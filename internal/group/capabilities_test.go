@@ -0,0 +1,56 @@
+package group
+
+import "testing"
+
+func TestParseCapability(t *testing.T) {
+	cases := map[string]Capability{
+		"exec":                 {Kind: CapabilityTool, Name: "exec"},
+		"skill:k8s-ops@1.2.0":  {Kind: CapabilitySkill, Name: "k8s-ops", Version: "1.2.0"},
+		"Channel:slack":        {Kind: CapabilityChannel, Name: "slack"},
+		"mcp:github":           {Kind: CapabilityTool, Name: "mcp:github"},
+		"model:gpt-4o@2024-08": {Kind: CapabilityModel, Name: "gpt-4o", Version: "2024-08"},
+	}
+	for in, want := range cases {
+		got, ok := ParseCapability(in)
+		if !ok || got.Kind != want.Kind || got.Name != want.Name || got.Version != want.Version {
+			t.Errorf("ParseCapability(%q) = %+v, want %+v", in, got, want)
+		}
+	}
+	if _, ok := ParseCapability("  "); ok {
+		t.Fatal("expected empty capability to be rejected")
+	}
+	if got := (Capability{Kind: CapabilitySkill, Name: "k8s", Version: "1.0"}).String(); got != "skill:k8s@1.0" {
+		t.Fatalf("unexpected String: %s", got)
+	}
+}
+
+func TestBuildCapabilityTaxonomyAndMatch(t *testing.T) {
+	tax := BuildCapabilityTaxonomy(AgentIdentity{
+		Capabilities: []string{"exec", "read_file", "exec"},
+		Channels:     []string{"cli", "slack"},
+		Model:        "claude-sonnet",
+		Taxonomy: []Capability{
+			{Kind: CapabilitySkill, Name: "k8s-ops", Version: "1.2.0", Tags: []string{"Kubernetes", "helm"}},
+			{Kind: "bogus", Name: "ignored"},
+		},
+	})
+	if len(tax) != 6 {
+		t.Fatalf("expected 6 entries, got %+v", tax)
+	}
+	if tax[0].Kind != CapabilityChannel || tax[0].Name != "cli" {
+		t.Fatalf("expected sorted by kind and name, got %+v", tax)
+	}
+
+	if m := MatchCapabilities(tax, "kubernetes", ""); len(m) != 1 || m[0].Name != "k8s-ops" {
+		t.Fatalf("expected tag match, got %+v", m)
+	}
+	if m := MatchCapabilities(tax, "skill:k8s", ""); len(m) != 1 {
+		t.Fatalf("expected kind-qualified match, got %+v", m)
+	}
+	if m := MatchCapabilities(tax, "k8s", CapabilityTool); len(m) != 0 {
+		t.Fatalf("expected kind filter to exclude skills, got %+v", m)
+	}
+	if m := MatchCapabilities(tax, "", CapabilityChannel); len(m) != 2 {
+		t.Fatalf("expected all channels, got %+v", m)
+	}
+}
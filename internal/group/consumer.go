@@ -9,9 +9,12 @@ import (
 	"time"
 
 	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/metrics"
 	"github.com/KafClaw/KafClaw/internal/timeline"
 )
 
+var envelopesTotal = metrics.NewCounter("kafclaw_group_envelopes_total", "Group envelopes, by type and direction (sent, received, dropped, blocked).", "type", "direction")
+
 // Consumer reads messages from Kafka topics.
 type Consumer interface {
 	// Start begins consuming from the configured topics.
@@ -45,8 +48,16 @@ type GroupRouter struct {
 	orchHandler OrchestratorHandler
 	knowledge   KnowledgeEnvelopeHandler
 	knTopics    map[string]struct{}
+
+	// batching defers routing the task requests of a batch to deferred,
+	// so they are claimed in sortTaskRequests order (see handleBatch).
+	batching bool
+	deferred []TaskRequestPayload
 }
 
+// maxRouteBatch bounds the messages the router takes in at once.
+const maxRouteBatch = 100
+
 // NewGroupRouter creates a router that bridges Kafka messages into the bus.
 func NewGroupRouter(manager *Manager, msgBus *bus.MessageBus, consumer Consumer) *GroupRouter {
 	return &GroupRouter{
@@ -84,17 +95,56 @@ func (r *GroupRouter) Run(ctx context.Context) error {
 	}
 	defer r.consumer.Close()
 
+	msgs := r.consumer.Messages()
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
-		case msg, ok := <-r.consumer.Messages():
+		case msg, ok := <-msgs:
 			if !ok {
 				return nil
 			}
-			r.handleMessage(msg)
+			batch, open := drainMessages(msg, msgs)
+			r.handleBatch(batch)
+			if !open {
+				return nil
+			}
+		}
+	}
+}
+
+// drainMessages collects first and the messages already waiting behind it.
+// It reports false once msgs is closed.
+func drainMessages(first ConsumerMessage, msgs <-chan ConsumerMessage) ([]ConsumerMessage, bool) {
+	batch := []ConsumerMessage{first}
+	for len(batch) < maxRouteBatch {
+		select {
+		case msg, ok := <-msgs:
+			if !ok {
+				return batch, false
+			}
+			batch = append(batch, msg)
+		default:
+			return batch, true
 		}
 	}
+	return batch, true
+}
+
+// handleBatch handles messages that arrived together. Their task requests
+// are claimed most urgent first rather than in arrival order.
+func (r *GroupRouter) handleBatch(msgs []ConsumerMessage) {
+	r.batching = true
+	for _, msg := range msgs {
+		r.handleMessage(msg)
+	}
+	r.batching = false
+	tasks := r.deferred
+	r.deferred = nil
+	sortTaskRequests(tasks)
+	for _, t := range tasks {
+		r.routeTaskRequest(t.TraceID, t)
+	}
 }
 
 func (r *GroupRouter) handleMessage(msg ConsumerMessage) {
@@ -112,6 +162,7 @@ func (r *GroupRouter) handleMessage(msg ConsumerMessage) {
 		slog.Warn("GroupRouter: unmarshal envelope", "error", err, "topic", msg.Topic)
 		return
 	}
+	envelopesTotal.Inc(env.Type, "received")
 
 	// Log topic message for analytics (before filtering own messages)
 	if r.manager.timeline != nil {
@@ -128,6 +179,18 @@ func (r *GroupRouter) handleMessage(msg ConsumerMessage) {
 	if env.SenderID == r.manager.identity.AgentID {
 		return
 	}
+	// Kicked and muted members are enforced before any handler runs.
+	if !r.manager.allowsSender(env.SenderID, env.Type) {
+		envelopesTotal.Inc(env.Type, "dropped")
+		slog.Debug("GroupRouter: dropped envelope from restricted member", "from", env.SenderID, "type", env.Type)
+		return
+	}
+	// Observers are consume-only; anything but their announces is dropped.
+	if r.manager.dropsObserverEnvelope(msg.Topic, &env) {
+		envelopesTotal.Inc(env.Type, "dropped")
+		slog.Debug("GroupRouter: dropped envelope from observer", "from", env.SenderID, "type", env.Type)
+		return
+	}
 
 	switch msg.Topic {
 	case r.topics.Announce:
@@ -143,9 +206,17 @@ func (r *GroupRouter) handleMessage(msg ConsumerMessage) {
 		r.handleTrace(&env)
 
 	case r.extTopics.ControlOnboarding:
+		if env.Type == EnvelopeInbox {
+			r.handleInbox(&env)
+			return
+		}
 		r.manager.HandleOnboard(&env)
 
 	case r.extTopics.ControlRoster:
+		if env.Type == EnvelopeAdmin {
+			r.manager.HandleAdmin(&env)
+			return
+		}
 		r.handleRoster(&env)
 
 	case r.extTopics.TaskStatus:
@@ -173,6 +244,10 @@ func (r *GroupRouter) handleMessage(msg ConsumerMessage) {
 }
 
 func (r *GroupRouter) handleTaskRequest(env *GroupEnvelope) {
+	// Observers never claim tasks.
+	if r.manager.Observer() {
+		return
+	}
 	data, err := json.Marshal(env.Payload)
 	if err != nil {
 		return
@@ -182,24 +257,80 @@ func (r *GroupRouter) handleTaskRequest(env *GroupEnvelope) {
 		return
 	}
 
+	// Directed tasks are only handled by their target; the inbox keeper holds
+	// a copy until the target acknowledges it.
+	if target := payload.TargetAgentID; target != "" {
+		if target != r.manager.identity.AgentID {
+			payload.TraceID = env.CorrelationID
+			if err := r.manager.StoreInboxTask(payload); err != nil {
+				slog.Warn("GroupRouter: inbox store failed", "task_id", payload.TaskID, "target", target, "error", err)
+			}
+			return
+		}
+		r.routeDirectedTask(env.CorrelationID, payload)
+		return
+	}
+	r.routeTaskRequest(env.CorrelationID, payload)
+}
+
+// routeDirectedTask routes a task directed at this agent. The inbox copy is
+// acknowledged when the task is answered (see RespondTask).
+func (r *GroupRouter) routeDirectedTask(traceID string, payload TaskRequestPayload) {
+	if traceID == "" {
+		// Inbox copies stored before trace IDs were kept.
+		traceID = payload.TaskID
+	}
+	r.manager.markInboxPending(payload.TaskID)
+	r.routeTaskRequest(traceID, payload)
+}
+
+// routeTaskRequest publishes a task request into the agent's inbound bus.
+// Sealed requests are decrypted first; one that cannot be opened is reported
+// back to the requester as failed.
+func (r *GroupRouter) routeTaskRequest(traceID string, payload TaskRequestPayload) {
+	if r.batching {
+		payload.TraceID = traceID
+		r.deferred = append(r.deferred, payload)
+		return
+	}
+	if err := r.manager.openTask(&payload); err != nil {
+		slog.Warn("GroupRouter: sealed task request not opened", "task_id", payload.TaskID, "from", payload.RequesterID, "error", err)
+		if err := r.manager.ReportTaskStatus(context.Background(), payload.TaskID, "failed", "sealed task could not be decrypted: "+err.Error()); err != nil {
+			slog.Warn("GroupRouter: task status report failed", "task_id", payload.TaskID, "error", err)
+		}
+		return
+	}
+	// Re-broadcasts of an overdue task reuse the task ID; the escalation
+	// keeps them distinct.
+	key := fmt.Sprintf("group:%s", payload.TaskID)
+	if payload.Escalation > 0 {
+		key = fmt.Sprintf("%s:e%d", key, payload.Escalation)
+	}
+	metadata := map[string]any{
+		"group_task_id":   payload.TaskID,
+		"group_requester": payload.RequesterID,
+		"description":     payload.Description,
+	}
+	if payload.Priority != "" {
+		metadata["group_priority"] = payload.Priority
+	}
+	if payload.DeadlineAt != "" {
+		metadata["group_deadline_at"] = payload.DeadlineAt
+	}
 	// Route into the agent's inbound bus as a "group" channel message
 	r.msgBus.PublishInbound(&bus.InboundMessage{
 		Channel:        "group",
 		SenderID:       payload.RequesterID,
 		ChatID:         payload.TaskID,
-		TraceID:        env.CorrelationID,
-		IdempotencyKey: fmt.Sprintf("group:%s", payload.TaskID),
+		TraceID:        traceID,
+		IdempotencyKey: key,
 		Content:        payload.Content,
 		Timestamp:      time.Now(),
-		Metadata: map[string]any{
-			"group_task_id":   payload.TaskID,
-			"group_requester": payload.RequesterID,
-			"description":     payload.Description,
-		},
+		Metadata:       metadata,
 	})
 
 	slog.Info("GroupRouter: task request routed to bus",
-		"task_id", payload.TaskID, "from", payload.RequesterID)
+		"task_id", payload.TaskID, "from", payload.RequesterID, "priority", payload.Priority)
 }
 
 func (r *GroupRouter) handleTaskResponse(env *GroupEnvelope) {
@@ -336,6 +467,9 @@ func (r *GroupRouter) handleSkillMessage(topic string, env *GroupEnvelope) {
 
 	switch direction {
 	case "requests":
+		if r.manager.Observer() {
+			return
+		}
 		data, err := json.Marshal(env.Payload)
 		if err != nil {
 			return
@@ -344,12 +478,18 @@ func (r *GroupRouter) handleSkillMessage(topic string, env *GroupEnvelope) {
 		if err := json.Unmarshal(data, &payload); err != nil {
 			return
 		}
+		// Resubmissions reuse the task ID; the attempt keeps them distinct.
+		key := fmt.Sprintf("skill:%s:%s", skillName, payload.TaskID)
+		if payload.Attempt > 0 {
+			key = fmt.Sprintf("%s:a%d", key, payload.Attempt)
+		}
+		r.manager.trackSkillRequest(payload.TaskID, skillName)
 		r.msgBus.PublishInbound(&bus.InboundMessage{
 			Channel:        "group",
 			SenderID:       payload.RequesterID,
 			ChatID:         payload.TaskID,
 			TraceID:        env.CorrelationID,
-			IdempotencyKey: fmt.Sprintf("skill:%s:%s", skillName, payload.TaskID),
+			IdempotencyKey: key,
 			Content:        payload.Content,
 			Timestamp:      time.Now(),
 			Metadata: map[string]any{
@@ -370,16 +510,29 @@ func (r *GroupRouter) handleSkillMessage(topic string, env *GroupEnvelope) {
 		if err := json.Unmarshal(data, &payload); err != nil {
 			return
 		}
+		out := r.manager.HandleSkillResponse(context.Background(), skillName, payload)
+		slog.Info("GroupRouter: skill task response", "skill", skillName, "task_id", payload.TaskID,
+			"status", out.Result.Status, "action", out.Action, "attempts", out.Attempts)
+		if out.Action == SkillActionRetry {
+			// The task was resubmitted; only the final outcome reaches the agent.
+			return
+		}
+		content := fmt.Sprintf("[Skill %s Response from %s] Status: %s\n%s", skillName, payload.ResponderID, out.Result.Status, out.Result.Summary())
+		if out.Action == SkillActionEscalate {
+			content += fmt.Sprintf("\nEscalated after %d attempt(s)", out.Attempts+1)
+			if out.NextSkill != "" {
+				content += " to skill " + out.NextSkill
+			}
+		}
 		r.msgBus.PublishInbound(&bus.InboundMessage{
 			Channel:        "group",
 			SenderID:       payload.ResponderID,
 			ChatID:         payload.TaskID,
 			TraceID:        env.CorrelationID,
 			IdempotencyKey: fmt.Sprintf("skill-resp:%s:%s:%s", skillName, payload.TaskID, payload.ResponderID),
-			Content:        fmt.Sprintf("[Skill %s Response from %s] Status: %s\n%s", skillName, payload.ResponderID, payload.Status, payload.Content),
+			Content:        content,
 			Timestamp:      time.Now(),
 		})
-		slog.Info("GroupRouter: skill task response", "skill", skillName, "task_id", payload.TaskID)
 
 	default:
 		slog.Warn("GroupRouter: unknown skill direction", "topic", topic, "direction", direction)
@@ -0,0 +1,269 @@
+package group
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/metrics"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+var taskEscalationsTotal = metrics.NewCounter("kafclaw_group_task_escalations_total", "Overdue group tasks escalated, by action (rebroadcast, notify).", "action")
+
+// Group task priorities, least urgent first. Tasks without a priority are
+// normal.
+const (
+	TaskPriorityLow    = "low"
+	TaskPriorityNormal = "normal"
+	TaskPriorityHigh   = "high"
+	TaskPriorityUrgent = "urgent"
+)
+
+var taskPriorities = []string{TaskPriorityLow, TaskPriorityNormal, TaskPriorityHigh, TaskPriorityUrgent}
+
+// Escalation defaults, used when the config leaves a value at zero.
+const (
+	defaultEscalationInterval = time.Minute
+	defaultMaxRebroadcasts    = 2
+	minEscalationWindow       = time.Minute
+)
+
+// NormalizeTaskPriority validates a task priority; empty means normal.
+func NormalizeTaskPriority(p string) (string, error) {
+	p = strings.ToLower(strings.TrimSpace(p))
+	if p == "" {
+		return TaskPriorityNormal, nil
+	}
+	for _, known := range taskPriorities {
+		if p == known {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("unknown priority %q (want low, normal, high or urgent)", p)
+}
+
+// taskPriorityRank orders priorities; higher is more urgent.
+func taskPriorityRank(p string) int {
+	switch strings.ToLower(strings.TrimSpace(p)) {
+	case TaskPriorityLow:
+		return 0
+	case TaskPriorityHigh:
+		return 2
+	case TaskPriorityUrgent:
+		return 3
+	default:
+		return 1
+	}
+}
+
+// raiseTaskPriority returns the next more urgent priority.
+func raiseTaskPriority(p string) string {
+	rank := taskPriorityRank(p) + 1
+	if rank >= len(taskPriorities) {
+		rank = len(taskPriorities) - 1
+	}
+	return taskPriorities[rank]
+}
+
+// sortTaskRequests orders tasks for claiming: most urgent priority first,
+// then earliest deadline; tasks without a deadline go last.
+func sortTaskRequests(tasks []TaskRequestPayload) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		ri, rj := taskPriorityRank(tasks[i].Priority), taskPriorityRank(tasks[j].Priority)
+		if ri != rj {
+			return ri > rj
+		}
+		di, dj := parseDeadline(tasks[i].DeadlineAt), parseDeadline(tasks[j].DeadlineAt)
+		switch {
+		case di.IsZero():
+			return false
+		case dj.IsZero():
+			return true
+		default:
+			return di.Before(dj)
+		}
+	})
+}
+
+func parseDeadline(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}
+
+// TaskOptions carries the urgency and the target of a submitted task.
+type TaskOptions struct {
+	Priority string
+	Deadline *time.Time
+	// TargetAgentID directs the task at one member; the inbox keeper holds
+	// it until that member has answered.
+	TargetAgentID string
+}
+
+// SubmitTaskWithOptions sends a task request with a priority and deadline to
+// the group.
+func (m *Manager) SubmitTaskWithOptions(ctx context.Context, taskID, description, content string, opts TaskOptions) error {
+	if !m.Active() {
+		return fmt.Errorf("not in a group")
+	}
+	if opts.TargetAgentID != "" && m.isObserver(opts.TargetAgentID) {
+		return fmt.Errorf("submit task: agent %s is an observer", opts.TargetAgentID)
+	}
+	payload := TaskRequestPayload{
+		TaskID:        taskID,
+		Description:   description,
+		Content:       content,
+		RequesterID:   m.identity.AgentID,
+		TargetAgentID: opts.TargetAgentID,
+	}
+	if opts.Priority != TaskPriorityNormal {
+		payload.Priority = opts.Priority
+	}
+	if opts.Deadline != nil {
+		payload.DeadlineAt = opts.Deadline.UTC().Format(time.RFC3339)
+	}
+	// Tasks for one agent are readable by that agent only.
+	if err := m.sealForTarget(&payload); err != nil {
+		return fmt.Errorf("submit task: %w", err)
+	}
+	env := &GroupEnvelope{
+		Type:          EnvelopeRequest,
+		CorrelationID: taskID,
+		SenderID:      m.identity.AgentID,
+		Timestamp:     time.Now(),
+		Payload:       payload,
+	}
+	if err := m.publish(ctx, m.topics.Requests, env); err != nil {
+		return err
+	}
+	// Our own envelopes are skipped by the router, so the keeper stores its
+	// directed tasks here.
+	if opts.TargetAgentID != "" {
+		payload.TraceID = env.CorrelationID
+		if err := m.StoreInboxTask(payload); err != nil {
+			slog.Warn("Inbox store failed", "task_id", taskID, "error", err)
+		}
+	}
+	return nil
+}
+
+// SetEscalationNotifier sets the function that delivers escalation notices
+// to the owner channel.
+func (m *Manager) SetEscalationNotifier(fn func(text string)) {
+	m.escalationNotify = fn
+}
+
+// escalationInterval returns how often deadlines are checked, 0 if disabled.
+func (m *Manager) escalationInterval() time.Duration {
+	switch sec := m.cfg.Escalation.IntervalSec; {
+	case sec < 0:
+		return 0
+	case sec == 0:
+		return defaultEscalationInterval
+	default:
+		return time.Duration(sec) * time.Second
+	}
+}
+
+// EscalateOverdueTasks escalates this agent's open group tasks whose deadline
+// passed before now. An unclaimed task is re-broadcast with a raised priority
+// and its deadline renewed by the original window; claimed, directed and
+// skill tasks, and tasks out of re-broadcasts, are reported to the owner.
+// It returns the number of tasks escalated.
+func (m *Manager) EscalateOverdueTasks(ctx context.Context, now time.Time) (int, error) {
+	if m.timeline == nil || !m.Active() || m.Observer() {
+		return 0, nil
+	}
+	tasks, err := m.timeline.ListOverdueGroupTasks(now)
+	if err != nil {
+		return 0, fmt.Errorf("list overdue group tasks: %w", err)
+	}
+	maxRebroadcasts := m.cfg.Escalation.MaxRebroadcasts
+	if maxRebroadcasts == 0 {
+		maxRebroadcasts = defaultMaxRebroadcasts
+	}
+	n := 0
+	for _, t := range tasks {
+		rebroadcast := t.Status == "pending" && t.TargetAgentID == "" && t.SkillName == "" && t.Escalations < maxRebroadcasts
+		if rebroadcast {
+			err = m.rebroadcastTask(ctx, t, now)
+		} else {
+			err = m.notifyOverdueTask(t, now)
+		}
+		if err != nil {
+			slog.Warn("Group task escalation failed", "task_id", t.TaskID, "error", err)
+			continue
+		}
+		n++
+	}
+	return n, nil
+}
+
+// rebroadcastTask publishes an unclaimed task again with a raised priority.
+func (m *Manager) rebroadcastTask(ctx context.Context, t timeline.GroupTaskRecord, now time.Time) error {
+	window := minEscalationWindow
+	if t.DeadlineAt != nil && t.DeadlineAt.Sub(t.CreatedAt) > window {
+		window = t.DeadlineAt.Sub(t.CreatedAt)
+	}
+	deadline := now.Add(window)
+	priority := raiseTaskPriority(t.Priority)
+	env := &GroupEnvelope{
+		Type:          EnvelopeRequest,
+		CorrelationID: t.TaskID,
+		SenderID:      m.identity.AgentID,
+		Timestamp:     now,
+		Payload: TaskRequestPayload{
+			TaskID:              t.TaskID,
+			Description:         t.Description,
+			Content:             t.Content,
+			RequesterID:         m.identity.AgentID,
+			ParentTaskID:        t.ParentTaskID,
+			DelegationDepth:     t.DelegationDepth,
+			OriginalRequesterID: t.OriginalRequesterID,
+			DeadlineAt:          deadline.UTC().Format(time.RFC3339),
+			Priority:            priority,
+			Escalation:          t.Escalations + 1,
+		},
+	}
+	if err := m.publish(ctx, m.topics.Requests, env); err != nil {
+		return err
+	}
+	if err := m.timeline.EscalateGroupTask(t.TaskID, priority, &deadline, now); err != nil {
+		return err
+	}
+	taskEscalationsTotal.Inc("rebroadcast")
+	_ = m.timeline.LogDelegationEvent(t.TaskID, "escalated", m.identity.AgentID, "",
+		fmt.Sprintf("unclaimed past deadline; re-broadcast with priority %s", priority), t.DelegationDepth)
+	slog.Info("Group task re-broadcast", "task_id", t.TaskID, "priority", priority, "deadline", deadline.Format(time.RFC3339))
+	return nil
+}
+
+// notifyOverdueTask reports an overdue task to the owner channel.
+func (m *Manager) notifyOverdueTask(t timeline.GroupTaskRecord, now time.Time) error {
+	var state string
+	switch {
+	case t.Status == "accepted" && t.ResponderID != "":
+		state = "claimed by " + t.ResponderID + " but not finished"
+	case t.TargetAgentID != "":
+		state = "not picked up by " + t.TargetAgentID
+	case t.Escalations > 0:
+		state = fmt.Sprintf("still unclaimed after %d re-broadcast(s)", t.Escalations)
+	default:
+		state = "still unclaimed"
+	}
+	text := fmt.Sprintf("⏰ Group task %s passed its deadline (%s UTC): %s. %s",
+		t.TaskID, t.DeadlineAt.UTC().Format("2006-01-02 15:04"), state, truncateUTF8(strings.TrimSpace(t.Description), 200))
+	if err := m.timeline.EscalateGroupTask(t.TaskID, raiseTaskPriority(t.Priority), nil, now); err != nil {
+		return err
+	}
+	taskEscalationsTotal.Inc("notify")
+	_ = m.timeline.LogDelegationEvent(t.TaskID, "escalated", m.identity.AgentID, t.ResponderID, state, t.DelegationDepth)
+	slog.Warn("Group task overdue", "task_id", t.TaskID, "state", state)
+	if m.escalationNotify != nil {
+		m.escalationNotify(text)
+	}
+	return nil
+}
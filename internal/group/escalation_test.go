@@ -0,0 +1,163 @@
+package group
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestNormalizeTaskPriority(t *testing.T) {
+	if p, err := NormalizeTaskPriority(""); err != nil || p != TaskPriorityNormal {
+		t.Fatalf("expected empty to be normal, got %q (err=%v)", p, err)
+	}
+	if p, err := NormalizeTaskPriority(" Urgent "); err != nil || p != TaskPriorityUrgent {
+		t.Fatalf("expected urgent, got %q (err=%v)", p, err)
+	}
+	if _, err := NormalizeTaskPriority("asap"); err == nil {
+		t.Fatal("expected unknown priority to be rejected")
+	}
+	if got := raiseTaskPriority(TaskPriorityUrgent); got != TaskPriorityUrgent {
+		t.Fatalf("expected urgent to stay urgent, got %q", got)
+	}
+}
+
+func TestSortTaskRequests(t *testing.T) {
+	soon := time.Now().Add(time.Minute).UTC().Format(time.RFC3339)
+	later := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	tasks := []TaskRequestPayload{
+		{TaskID: "plain"},
+		{TaskID: "low", Priority: TaskPriorityLow, DeadlineAt: soon},
+		{TaskID: "normal-later", DeadlineAt: later},
+		{TaskID: "urgent", Priority: TaskPriorityUrgent},
+		{TaskID: "normal-soon", DeadlineAt: soon},
+	}
+	sortTaskRequests(tasks)
+	var got []string
+	for _, task := range tasks {
+		got = append(got, task.TaskID)
+	}
+	if strings.Join(got, ",") != "urgent,normal-soon,normal-later,plain,low" {
+		t.Fatalf("unexpected claim order: %v", got)
+	}
+}
+
+func TestRouterClaimsWaitingTasksByPriority(t *testing.T) {
+	mgr := newTestManagerForOnboard("http://127.0.0.1:0", "worker", "open")
+	consumer := NewChannelConsumer()
+	msgBus := bus.NewMessageBus()
+	router := NewGroupRouter(mgr, msgBus, consumer)
+	for _, p := range []string{TaskPriorityLow, "", TaskPriorityUrgent} {
+		id := "task-" + p
+		consumer.Send(inboxMessage(t, mgr.topics.Requests, GroupEnvelope{
+			Type: EnvelopeRequest, SenderID: "requester", CorrelationID: id, Timestamp: time.Now(),
+			Payload: TaskRequestPayload{TaskID: id, Content: "do it", RequesterID: "requester", Priority: p},
+		}))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go func() { _ = router.Run(ctx) }()
+
+	var got []string
+	for range 3 {
+		msg, err := msgBus.ConsumeInbound(ctx)
+		if err != nil {
+			t.Fatalf("consume: %v", err)
+		}
+		got = append(got, msg.ChatID)
+	}
+	if strings.Join(got, ",") != "task-urgent,task-,task-low" {
+		t.Fatalf("waiting tasks must be claimed most urgent first, got %v", got)
+	}
+}
+
+func TestEscalateOverdueTasks(t *testing.T) {
+	var produced producedStore
+	server := newObserverTestServer(t, &produced)
+	tl := newObserverTestTimeline(t)
+	mgr := newTestManagerWithTimeline(server.URL, tl)
+	if err := mgr.Join(context.Background()); err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	var notices []string
+	mgr.SetEscalationNotifier(func(text string) { notices = append(notices, text) })
+
+	now := time.Now().UTC()
+	deadline := now.Add(-time.Minute)
+	for _, rec := range []*timeline.GroupTaskRecord{
+		{TaskID: "open", Description: "open task", Direction: "outgoing", RequesterID: "test-agent", Status: "pending", DeadlineAt: &deadline},
+		{TaskID: "claimed", Description: "claimed task", Direction: "outgoing", RequesterID: "test-agent", Status: "pending", DeadlineAt: &deadline},
+		{TaskID: "directed", Description: "directed task", Direction: "outgoing", RequesterID: "test-agent", Status: "pending", DeadlineAt: &deadline, TargetAgentID: "peer"},
+	} {
+		if err := tl.InsertGroupTask(rec); err != nil {
+			t.Fatalf("insert %s: %v", rec.TaskID, err)
+		}
+	}
+	if err := tl.AcceptGroupTask("claimed", "worker"); err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	produced.reset()
+
+	n, err := mgr.EscalateOverdueTasks(context.Background(), now)
+	if err != nil || n != 3 {
+		t.Fatalf("expected 3 escalations, got %d (err=%v)", n, err)
+	}
+
+	// Only the unclaimed broadcast task is published again.
+	var requests []GroupEnvelope
+	for _, env := range produced.snapshot() {
+		if env.Type == EnvelopeRequest {
+			requests = append(requests, env)
+		}
+	}
+	if len(requests) != 1 || requests[0].CorrelationID != "open" {
+		t.Fatalf("expected one re-broadcast of the open task, got %+v", requests)
+	}
+	payload, _ := requests[0].Payload.(map[string]any)
+	if payload["priority"] != TaskPriorityHigh || payload["escalation"] != float64(1) {
+		t.Fatalf("expected raised priority and escalation 1, got %v", payload)
+	}
+	open, err := tl.GetGroupTask("open")
+	if err != nil || open == nil || open.Escalations != 1 || open.DeadlineAt == nil || !open.DeadlineAt.After(now) {
+		t.Fatalf("expected renewed deadline on the open task, got %+v (err=%v)", open, err)
+	}
+
+	if len(notices) != 2 {
+		t.Fatalf("expected 2 owner notices, got %q", notices)
+	}
+	joined := strings.Join(notices, "\n")
+	if !strings.Contains(joined, "claimed by worker") || !strings.Contains(joined, "not picked up by peer") {
+		t.Fatalf("unexpected notices: %q", notices)
+	}
+
+	// Escalated tasks are not reported again until their deadline moves.
+	if n, _ := mgr.EscalateOverdueTasks(context.Background(), now); n != 0 {
+		t.Fatalf("expected no repeat escalation, got %d", n)
+	}
+}
+
+func TestRouteTaskRequestEscalation(t *testing.T) {
+	msgBus := bus.NewMessageBus()
+	router := NewGroupRouter(newTestManager("http://unused"), msgBus, NewChannelConsumer())
+	router.routeTaskRequest("trace-1", TaskRequestPayload{
+		TaskID:      "t1",
+		RequesterID: "peer",
+		Content:     "do it",
+		Priority:    TaskPriorityHigh,
+		DeadlineAt:  "2026-01-01T00:00:00Z",
+		Escalation:  2,
+	})
+	msg, err := msgBus.ConsumeInbound(context.Background())
+	if err != nil {
+		t.Fatalf("consume: %v", err)
+	}
+	if msg.IdempotencyKey != "group:t1:e2" {
+		t.Fatalf("expected escalation in idempotency key, got %q", msg.IdempotencyKey)
+	}
+	if msg.Metadata["group_priority"] != TaskPriorityHigh || msg.Metadata["group_deadline_at"] != "2026-01-01T00:00:00Z" {
+		t.Fatalf("unexpected metadata: %v", msg.Metadata)
+	}
+}
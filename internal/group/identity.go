@@ -0,0 +1,223 @@
+package group
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Membership states of a pinned agent identity.
+const (
+	IdentityStateJoined = "joined"
+	IdentityStateLeft   = "left"
+)
+
+// identityMaxAge bounds how old (or how far in the future) a signed announce
+// may be; older announces are rejected as replays.
+const identityMaxAge = 15 * time.Minute
+
+// IdentityPin is what this agent remembers about another member's identity:
+// the identity key first seen for its agent ID (trust on first use) and its
+// membership state. Pins survive leave, so a rejoin must present the same key.
+type IdentityPin struct {
+	Key       string    `json:"key"`
+	State     string    `json:"state"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"` // IssuedAt of the last accepted announce
+	Rejoins   int       `json:"rejoins,omitempty"`
+}
+
+// signingBytes is the JSON encoding of the payload with an empty Signature.
+func (p AnnouncePayload) signingBytes() []byte {
+	p.Signature = ""
+	data, _ := json.Marshal(p)
+	return data
+}
+
+func (m *Manager) identityPinsKey() string {
+	return "group_identity_pins:" + m.cfg.GroupName
+}
+
+// loadIdentity restores this agent's identity key and the pinned member
+// identities from the timeline. The key itself is created on first join.
+func (m *Manager) loadIdentity() {
+	m.identityPins = map[string]*IdentityPin{}
+	if m.timeline == nil {
+		return
+	}
+	if raw, err := m.timeline.GetSetting("group_identity_key"); err == nil {
+		if seed, err := base64.StdEncoding.DecodeString(raw); err == nil && len(seed) == ed25519.SeedSize {
+			m.identityKey = ed25519.NewKeyFromSeed(seed)
+			m.identity.IdentityKey = base64.StdEncoding.EncodeToString(m.identityKey.Public().(ed25519.PublicKey))
+			m.setEncryptionKey(seed)
+		}
+	}
+	if raw, err := m.timeline.GetSetting(m.identityPinsKey()); err == nil && raw != "" {
+		pins := map[string]*IdentityPin{}
+		if err := json.Unmarshal([]byte(raw), &pins); err == nil {
+			m.identityPins = pins
+		}
+	}
+}
+
+// ensureIdentityKey creates and persists this agent's identity key the first
+// time it joins a group. The key is kept across leave and rejoin.
+func (m *Manager) ensureIdentityKey() error {
+	if m.identityKey != nil {
+		return nil
+	}
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return fmt.Errorf("generate identity key: %w", err)
+	}
+	m.identityKey = priv
+	m.identity.IdentityKey = base64.StdEncoding.EncodeToString(priv.Public().(ed25519.PublicKey))
+	m.setEncryptionKey(priv.Seed())
+	if m.timeline != nil {
+		_ = m.timeline.SetSetting("group_identity_key", base64.StdEncoding.EncodeToString(priv.Seed()))
+	}
+	return nil
+}
+
+// signedAnnounce builds an announce payload for action signed with this
+// agent's identity key.
+func (m *Manager) signedAnnounce(action string) AnnouncePayload {
+	p := AnnouncePayload{
+		Action:    action,
+		Identity:  m.identity,
+		GroupName: m.cfg.GroupName,
+		IssuedAt:  time.Now().UTC(),
+	}
+	if m.identityKey != nil {
+		p.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(m.identityKey, p.signingBytes()))
+	}
+	return p
+}
+
+// IdentityPins returns a copy of the pinned member identities.
+func (m *Manager) IdentityPins() map[string]IdentityPin {
+	m.identityMu.Lock()
+	defer m.identityMu.Unlock()
+	out := make(map[string]IdentityPin, len(m.identityPins))
+	for id, pin := range m.identityPins {
+		out[id] = *pin
+	}
+	return out
+}
+
+// ForgetIdentity drops the pinned key of agentID so its next signed announce
+// pins a new key, e.g. after the agent lost its state. The operator action
+// is audited.
+func (m *Manager) ForgetIdentity(agentID string) bool {
+	m.identityMu.Lock()
+	pin, ok := m.identityPins[agentID]
+	if ok {
+		delete(m.identityPins, agentID)
+		m.savePins()
+	}
+	m.identityMu.Unlock()
+	if ok {
+		m.logAdminAction(&AdminPayload{Action: "identity_forget", IssuerID: m.identity.AgentID, TargetID: agentID}, "applied", "forgot pinned key "+pin.Key, "")
+	}
+	return ok
+}
+
+// savePins persists the pinned identities. Callers hold identityMu.
+func (m *Manager) savePins() {
+	if m.timeline == nil {
+		return
+	}
+	data, err := json.Marshal(m.identityPins)
+	if err != nil {
+		return
+	}
+	_ = m.timeline.SetSetting(m.identityPinsKey(), string(data))
+}
+
+// admitAnnounce verifies an announce from senderID and advances the sender's
+// membership state. Unsigned announces are accepted from agents without a
+// pinned key unless group.requireSignedAnnounce is set; once a key is pinned,
+// every announce for that agent ID must be signed with it. Rejections are
+// audited.
+func (m *Manager) admitAnnounce(senderID string, p *AnnouncePayload) bool {
+	rejoined, err := m.checkAnnounce(senderID, p)
+	if err != nil {
+		slog.Warn("Group announce rejected", "action", p.Action, "sender", senderID, "error", err)
+		m.logAdminAction(&AdminPayload{Action: "identity", IssuerID: senderID, TargetID: p.Identity.AgentID}, "rejected", p.Action+": "+err.Error(), "")
+		return false
+	}
+	if rejoined {
+		m.logAdminAction(&AdminPayload{Action: "identity_rejoin", IssuerID: senderID}, "applied", "rejoined with pinned key "+p.Identity.IdentityKey, "")
+	}
+	return true
+}
+
+func (m *Manager) checkAnnounce(senderID string, p *AnnouncePayload) (rejoined bool, err error) {
+	id := p.Identity
+	m.identityMu.Lock()
+	defer m.identityMu.Unlock()
+	pin := m.identityPins[id.AgentID]
+
+	if p.Signature == "" {
+		switch {
+		case pin != nil:
+			return false, fmt.Errorf("unsigned announce for an agent with a pinned identity key")
+		case m.cfg.RequireSignedAnnounce:
+			return false, fmt.Errorf("unsigned announce")
+		}
+		return false, nil
+	}
+
+	if id.AgentID != senderID {
+		return false, fmt.Errorf("identity %q does not match sender", id.AgentID)
+	}
+	if p.GroupName != m.cfg.GroupName {
+		return false, fmt.Errorf("announce for group %q", p.GroupName)
+	}
+	if age := time.Since(p.IssuedAt); age > identityMaxAge || age < -identityMaxAge {
+		return false, fmt.Errorf("announce issued at %s is outside the accepted window", p.IssuedAt.Format(time.RFC3339))
+	}
+	if pin != nil && id.IdentityKey != pin.Key {
+		return false, fmt.Errorf("identity key differs from the key pinned on %s", pin.FirstSeen.Format(time.RFC3339))
+	}
+	pub, err := base64.StdEncoding.DecodeString(id.IdentityKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return false, fmt.Errorf("invalid identity key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(p.Signature)
+	if err != nil || !ed25519.Verify(ed25519.PublicKey(pub), p.signingBytes(), sig) {
+		return false, fmt.Errorf("invalid signature")
+	}
+
+	if pin == nil {
+		pin = &IdentityPin{Key: id.IdentityKey, FirstSeen: p.IssuedAt}
+		m.identityPins[id.AgentID] = pin
+	} else if !p.IssuedAt.After(pin.LastSeen) {
+		return false, fmt.Errorf("replayed announce issued at %s", p.IssuedAt.Format(time.RFC3339Nano))
+	}
+
+	// joined --leave--> left --join--> joined (a rejoin). A heartbeat after a
+	// leave is stale; the agent announces a join when it comes back.
+	switch p.Action {
+	case "join":
+		rejoined = pin.State == IdentityStateLeft
+		if rejoined {
+			pin.Rejoins++
+		}
+		pin.State = IdentityStateJoined
+	case "heartbeat":
+		if pin.State == IdentityStateLeft {
+			return false, fmt.Errorf("heartbeat after leave")
+		}
+		pin.State = IdentityStateJoined
+	case "leave":
+		pin.State = IdentityStateLeft
+	}
+	pin.LastSeen = p.IssuedAt
+	m.savePins()
+	return rejoined, nil
+}
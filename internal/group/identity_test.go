@@ -0,0 +1,223 @@
+package group
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/cliconfig"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func lastAnnounce(t *testing.T, produced *producedStore, action string) *GroupEnvelope {
+	t.Helper()
+	items := produced.snapshot()
+	for i := len(items) - 1; i >= 0; i-- {
+		if items[i].Type != EnvelopeAnnounce {
+			continue
+		}
+		if p, _ := items[i].Payload.(map[string]any); p["action"] == action {
+			env := items[i]
+			return &env
+		}
+	}
+	t.Fatalf("no %s announce produced", action)
+	return nil
+}
+
+func TestIdentity_SignedAnnouncesPinnedAcrossRejoin(t *testing.T) {
+	var produced producedStore
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var env GroupEnvelope
+		_ = json.NewDecoder(r.Body).Decode(&env)
+		produced.append(env)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(LFSEnvelope{KfsLFS: 1})
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	tl, err := timeline.NewTimelineService(filepath.Join(dir, "observer.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tl.Close()
+	agentTL, err := timeline.NewTimelineService(filepath.Join(dir, "agent.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer agentTL.Close()
+	ctx := context.Background()
+
+	observer := newTestManagerForOnboard(server.URL, "observer", "open")
+	observer.timeline = tl
+	observer.loadIdentity()
+	agent := newTestManagerForOnboard(server.URL, "agent", "open")
+	agent.timeline = agentTL
+	agent.loadIdentity()
+	if agent.identity.IdentityKey != "" {
+		t.Fatal("identity key must be created on first join, not before")
+	}
+
+	// First join creates the key; the observer pins it.
+	if err := agent.Join(ctx); err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	key := agent.identity.IdentityKey
+	if key == "" {
+		t.Fatal("expected an identity key after join")
+	}
+	observer.HandleAnnounce(lastAnnounce(t, &produced, "join"))
+	if pin := observer.IdentityPins()["agent"]; pin.Key != key || pin.State != IdentityStateJoined {
+		t.Fatalf("expected pinned joined identity, got %+v", pin)
+	}
+
+	// Replays and tampered announces are rejected.
+	observer.HandleAnnounce(lastAnnounce(t, &produced, "join"))
+	tampered := lastAnnounce(t, &produced, "join")
+	forged := map[string]any{}
+	for k, v := range tampered.Payload.(map[string]any) {
+		forged[k] = v
+	}
+	forged["action"] = "leave"
+	tampered.Payload = forged
+	observer.HandleAnnounce(tampered)
+	if pin := observer.IdentityPins()["agent"]; pin.State != IdentityStateJoined {
+		t.Fatalf("tampered leave must not apply, got %+v", pin)
+	}
+
+	// An impostor claiming the agent ID with its own key is rejected, signed
+	// or not.
+	_, impostorKey, _ := ed25519.GenerateKey(rand.Reader)
+	impostor := AnnouncePayload{
+		Action:    "join",
+		Identity:  AgentIdentity{AgentID: "agent", AgentName: "Impostor", IdentityKey: base64.StdEncoding.EncodeToString(impostorKey.Public().(ed25519.PublicKey))},
+		GroupName: "test-group",
+		IssuedAt:  time.Now().UTC(),
+	}
+	impostor.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(impostorKey, impostor.signingBytes()))
+	observer.HandleAnnounce(&GroupEnvelope{Type: EnvelopeAnnounce, SenderID: "agent", Payload: impostor})
+	observer.HandleAnnounce(&GroupEnvelope{Type: EnvelopeAnnounce, SenderID: "agent", Payload: AnnouncePayload{Action: "heartbeat", Identity: AgentIdentity{AgentID: "agent", AgentName: "Impostor"}}})
+	for _, m := range observer.Members() {
+		if m.AgentName == "Impostor" {
+			t.Fatal("impostor announce must not reach the roster")
+		}
+	}
+
+	// Leave, then rejoin with the same persisted key.
+	if err := agent.Leave(ctx); err != nil {
+		t.Fatalf("leave: %v", err)
+	}
+	observer.HandleAnnounce(lastAnnounce(t, &produced, "leave"))
+	if pin := observer.IdentityPins()["agent"]; pin.State != IdentityStateLeft {
+		t.Fatalf("expected left state, got %+v", pin)
+	}
+
+	restarted := newTestManagerForOnboard(server.URL, "agent", "open")
+	restarted.timeline = agentTL
+	restarted.loadIdentity()
+	if restarted.identity.IdentityKey != key {
+		t.Fatal("identity key must survive a restart")
+	}
+	if err := restarted.Join(ctx); err != nil {
+		t.Fatalf("rejoin: %v", err)
+	}
+	observer.HandleAnnounce(lastAnnounce(t, &produced, "join"))
+	if pin := observer.IdentityPins()["agent"]; pin.State != IdentityStateJoined || pin.Rejoins != 1 {
+		t.Fatalf("expected rejoin on the pinned key, got %+v", pin)
+	}
+
+	// Pins are persisted, and every rejection is audited.
+	reloaded := newTestManagerForOnboard(server.URL, "observer", "open")
+	reloaded.timeline = tl
+	reloaded.loadIdentity()
+	if reloaded.IdentityPins()["agent"].Key != key {
+		t.Fatal("expected pins to be persisted")
+	}
+	entries, err := tl.ListUnifiedAudit(timeline.AuditFilter{Source: "group_admin", Limit: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	for _, e := range entries {
+		counts[e.EventType]++
+	}
+	if counts["identity_rejected"] != 4 || counts["identity_rejoin"] != 1 {
+		t.Fatalf("unexpected identity audit entries: %v", counts)
+	}
+
+	// An operator can forget a pin, after which a new key is accepted.
+	if !observer.ForgetIdentity("agent") || observer.ForgetIdentity("agent") {
+		t.Fatal("expected the pin to be forgotten exactly once")
+	}
+	observer.HandleAnnounce(&GroupEnvelope{Type: EnvelopeAnnounce, SenderID: "agent", Payload: impostor})
+	if observer.IdentityPins()["agent"].Key != impostor.Identity.IdentityKey {
+		t.Fatal("expected the new key to be pinned after forgetting the old one")
+	}
+}
+
+func TestIdentity_RequireSignedAnnounce(t *testing.T) {
+	m := newTestManagerForOnboard("http://127.0.0.1:1", "observer", "open")
+	unsigned := &GroupEnvelope{Type: EnvelopeAnnounce, SenderID: "legacy", Payload: AnnouncePayload{Action: "join", Identity: AgentIdentity{AgentID: "legacy"}}}
+	m.HandleAnnounce(unsigned)
+	if m.MemberCount() != 1 {
+		t.Fatal("unsigned announces from legacy agents are accepted by default")
+	}
+
+	m = newTestManagerForOnboard("http://127.0.0.1:1", "observer", "open")
+	m.cfg.RequireSignedAnnounce = true
+	m.HandleAnnounce(unsigned)
+	if m.MemberCount() != 0 {
+		t.Fatal("unsigned announce must be rejected when signatures are required")
+	}
+}
+
+func TestIdentity_KeysStayOutOfSettingsBundle(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	tl, err := timeline.NewTimelineService(filepath.Join(home, "timeline.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tl.Close()
+
+	m := newTestManagerForOnboard("http://127.0.0.1:0", "agent", "open")
+	m.timeline = tl
+	if err := m.ensureIdentityKey(); err != nil {
+		t.Fatalf("ensure identity key: %v", err)
+	}
+	seed, err := tl.GetSetting("group_identity_key")
+	if err != nil || seed == "" {
+		t.Fatalf("expected a persisted identity seed, got %q (%v)", seed, err)
+	}
+	_ = tl.SetSetting("group_admin_key", "admin-seed")
+	_ = tl.SetSetting(m.identityPinsKey(), `{"peer":{}}`)
+	_ = tl.SetSetting(m.adminStateKey(), `{}`)
+
+	b, err := cliconfig.ExportBundle(tl)
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	raw, _ := json.Marshal(b)
+	for _, leak := range []string{seed, "admin-seed", m.identityPinsKey(), m.adminStateKey()} {
+		if strings.Contains(string(raw), leak) {
+			t.Fatalf("export contains %q: %s", leak, raw)
+		}
+	}
+
+	b.Settings = map[string]string{"group_identity_key": "forged", m.identityPinsKey(): "{}"}
+	if _, err := cliconfig.ImportBundle(tl, b, false); err == nil {
+		t.Fatal("expected import of group keys and pins to be refused")
+	}
+	if got, _ := tl.GetSetting("group_identity_key"); got != seed {
+		t.Fatal("import must not replace the identity seed")
+	}
+}
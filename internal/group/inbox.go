@@ -0,0 +1,212 @@
+package group
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// InboxAction is the action type for store-and-forward inbox messages.
+type InboxAction string
+
+const (
+	InboxActionFetch   InboxAction = "inbox_fetch"   // member asks for its backlog
+	InboxActionDeliver InboxAction = "inbox_deliver" // keeper returns the backlog
+	InboxActionAck     InboxAction = "inbox_ack"     // member confirms receipt
+)
+
+// InboxPayload is the wire format for inbox messages on the onboarding control topic.
+type InboxPayload struct {
+	Action   InboxAction          `json:"action"`
+	AgentID  string               `json:"agent_id"` // inbox owner
+	KeeperID string               `json:"keeper_id,omitempty"`
+	TaskIDs  []string             `json:"task_ids,omitempty"`
+	Tasks    []TaskRequestPayload `json:"tasks,omitempty"`
+}
+
+// IsInboxKeeper reports whether this agent persists directed tasks for
+// offline members. The group founder (coordinator) keeps the inbox.
+func (m *Manager) IsInboxKeeper() bool {
+	return m.identity.Role == "coordinator" && m.timeline != nil
+}
+
+// isInboxKeeperID reports whether the roster lists agentID as the inbox
+// keeper.
+func (m *Manager) isInboxKeeperID(agentID string) bool {
+	m.rosterMu.RLock()
+	defer m.rosterMu.RUnlock()
+	member, ok := m.roster[agentID]
+	return ok && member.Role == "coordinator"
+}
+
+// StoreInboxTask persists a directed task for its target. Tasks stay in the
+// inbox until the target acknowledges them, whether it was online or not.
+// Targets acknowledge a task once they have answered it, so a task is not
+// lost when the target stops before running it.
+func (m *Manager) StoreInboxTask(payload TaskRequestPayload) error {
+	if !m.IsInboxKeeper() {
+		return nil
+	}
+	if payload.TargetAgentID == "" || payload.TargetAgentID == m.identity.AgentID {
+		return nil
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return m.timeline.EnqueueGroupInboxTask(&timeline.GroupInboxRecord{
+		TaskID:        payload.TaskID,
+		TargetAgentID: payload.TargetAgentID,
+		RequesterID:   payload.RequesterID,
+		Payload:       string(raw),
+	})
+}
+
+// FetchInbox asks the inbox keeper for tasks directed at this agent while it was away.
+func (m *Manager) FetchInbox(ctx context.Context) error {
+	return m.publishInbox(ctx, InboxPayload{Action: InboxActionFetch, AgentID: m.identity.AgentID})
+}
+
+func (m *Manager) fetchInbox(ctx context.Context) {
+	if err := m.FetchInbox(ctx); err != nil {
+		slog.Debug("Inbox fetch failed", "error", err)
+	}
+}
+
+// AckInbox confirms directed tasks as handled so the keeper can drop them.
+func (m *Manager) AckInbox(ctx context.Context, taskIDs []string) error {
+	if len(taskIDs) == 0 {
+		return nil
+	}
+	return m.publishInbox(ctx, InboxPayload{Action: InboxActionAck, AgentID: m.identity.AgentID, TaskIDs: taskIDs})
+}
+
+// markInboxPending records a directed task to acknowledge once answered.
+func (m *Manager) markInboxPending(taskID string) {
+	m.inboxMu.Lock()
+	defer m.inboxMu.Unlock()
+	if m.inboxPending == nil {
+		m.inboxPending = map[string]bool{}
+	}
+	m.inboxPending[taskID] = true
+}
+
+// takeInboxPending reports whether taskID was directed at this agent and
+// forgets it.
+func (m *Manager) takeInboxPending(taskID string) bool {
+	m.inboxMu.Lock()
+	defer m.inboxMu.Unlock()
+	if !m.inboxPending[taskID] {
+		return false
+	}
+	delete(m.inboxPending, taskID)
+	return true
+}
+
+// deliverInbox sends the stored backlog for agentID. Returns the number of tasks sent.
+func (m *Manager) deliverInbox(ctx context.Context, agentID string) (int, error) {
+	if !m.IsInboxKeeper() {
+		return 0, nil
+	}
+	recs, err := m.timeline.ListGroupInboxTasks(agentID, 0)
+	if err != nil {
+		return 0, fmt.Errorf("list inbox: %w", err)
+	}
+	if len(recs) == 0 {
+		return 0, nil
+	}
+	tasks := make([]TaskRequestPayload, 0, len(recs))
+	for _, rec := range recs {
+		var t TaskRequestPayload
+		if err := json.Unmarshal([]byte(rec.Payload), &t); err != nil {
+			slog.Warn("Inbox: skipping malformed task", "task_id", rec.TaskID, "error", err)
+			continue
+		}
+		tasks = append(tasks, t)
+	}
+	err = m.publishInbox(ctx, InboxPayload{
+		Action:   InboxActionDeliver,
+		AgentID:  agentID,
+		KeeperID: m.identity.AgentID,
+		Tasks:    tasks,
+	})
+	return len(tasks), err
+}
+
+func (m *Manager) publishInbox(ctx context.Context, payload InboxPayload) error {
+	env := &GroupEnvelope{
+		Type:          EnvelopeInbox,
+		CorrelationID: fmt.Sprintf("inbox-%d", time.Now().UnixNano()),
+		SenderID:      m.identity.AgentID,
+		Timestamp:     time.Now(),
+		Payload:       payload,
+	}
+	return m.publish(ctx, m.extTopics.ControlOnboarding, env)
+}
+
+// handleInbox processes inbox fetch/deliver/ack messages. Members may only
+// fetch and acknowledge their own inbox, and only the keeper delivers.
+func (r *GroupRouter) handleInbox(env *GroupEnvelope) {
+	data, err := json.Marshal(env.Payload)
+	if err != nil {
+		return
+	}
+	var payload InboxPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		slog.Warn("GroupRouter: unmarshal inbox payload", "error", err)
+		return
+	}
+	ctx := context.Background()
+	self := r.manager.identity.AgentID
+
+	switch payload.Action {
+	case InboxActionFetch:
+		if env.SenderID != payload.AgentID {
+			slog.Warn("Inbox: dropped fetch for another agent", "from", env.SenderID, "agent_id", payload.AgentID)
+			return
+		}
+		n, err := r.manager.deliverInbox(ctx, payload.AgentID)
+		if err != nil {
+			slog.Warn("Inbox deliver failed", "agent_id", payload.AgentID, "error", err)
+			return
+		}
+		if n > 0 {
+			slog.Info("Inbox backlog delivered", "agent_id", payload.AgentID, "tasks", n)
+		}
+
+	case InboxActionDeliver:
+		if payload.AgentID != self {
+			return
+		}
+		if !r.manager.isInboxKeeperID(env.SenderID) {
+			slog.Warn("Inbox: dropped delivery from non-keeper", "from", env.SenderID)
+			return
+		}
+		// The backlog is claimed most urgent first. Each task is acknowledged
+		// when it is answered; a task delivered again meanwhile is deduped by
+		// the agent loop.
+		sortTaskRequests(payload.Tasks)
+		for _, t := range payload.Tasks {
+			r.routeDirectedTask(t.TraceID, t)
+		}
+
+	case InboxActionAck:
+		if !r.manager.IsInboxKeeper() {
+			return
+		}
+		if env.SenderID != payload.AgentID {
+			slog.Warn("Inbox: dropped ack for another agent", "from", env.SenderID, "agent_id", payload.AgentID)
+			return
+		}
+		if _, err := r.manager.timeline.AckGroupInboxTasks(payload.AgentID, payload.TaskIDs); err != nil {
+			slog.Warn("Inbox ack cleanup failed", "agent_id", payload.AgentID, "error", err)
+		}
+
+	default:
+		slog.Warn("GroupRouter: unknown inbox action", "action", payload.Action)
+	}
+}
@@ -0,0 +1,245 @@
+package group
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func inboxMessage(t *testing.T, topic string, env GroupEnvelope) ConsumerMessage {
+	t.Helper()
+	raw, err := json.Marshal(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ConsumerMessage{Topic: topic, Value: raw}
+}
+
+func lastInboxPayload(t *testing.T, produced *producedStore, action InboxAction) InboxPayload {
+	t.Helper()
+	items := produced.snapshot()
+	for i := len(items) - 1; i >= 0; i-- {
+		if items[i].Type != EnvelopeInbox {
+			continue
+		}
+		data, _ := json.Marshal(items[i].Payload)
+		var p InboxPayload
+		_ = json.Unmarshal(data, &p)
+		if p.Action == action {
+			return p
+		}
+	}
+	t.Fatalf("no %s envelope produced", action)
+	return InboxPayload{}
+}
+
+func TestInbox_StoreAndForwardToRejoiningMember(t *testing.T) {
+	var produced producedStore
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var env GroupEnvelope
+		_ = json.NewDecoder(r.Body).Decode(&env)
+		produced.append(env)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(LFSEnvelope{KfsLFS: 1})
+	}))
+	defer server.Close()
+
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tl.Close()
+
+	founder := newTestManagerForOnboard(server.URL, "founder", "open")
+	founder.timeline = tl
+	if err := founder.Join(context.Background()); err != nil {
+		t.Fatalf("founder join: %v", err)
+	}
+	if !founder.IsInboxKeeper() {
+		t.Fatal("expected founder to keep the inbox")
+	}
+	founderRouter := NewGroupRouter(founder, bus.NewMessageBus(), NewChannelConsumer())
+	ext := ExtendedTopics("test-group")
+
+	// A directed task for an offline member is stored, not routed locally.
+	founderRouter.handleMessage(inboxMessage(t, ext.TaskRequests, GroupEnvelope{
+		Type:          EnvelopeRequest,
+		CorrelationID: "trace-1",
+		SenderID:      "requester",
+		Timestamp:     time.Now(),
+		Payload: TaskRequestPayload{
+			TaskID:        "task-1",
+			Content:       "review the PR",
+			RequesterID:   "requester",
+			TargetAgentID: "worker",
+		},
+	}))
+	recs, err := tl.ListGroupInboxTasks("worker", 0)
+	if err != nil || len(recs) != 1 {
+		t.Fatalf("expected 1 inbox task, got %d (err=%v)", len(recs), err)
+	}
+
+	// The worker rejoins and fetches its backlog.
+	founderRouter.handleMessage(inboxMessage(t, ext.ControlOnboarding, GroupEnvelope{
+		Type:      EnvelopeInbox,
+		SenderID:  "worker",
+		Timestamp: time.Now(),
+		Payload:   InboxPayload{Action: InboxActionFetch, AgentID: "worker"},
+	}))
+	deliver := lastInboxPayload(t, &produced, InboxActionDeliver)
+	if deliver.AgentID != "worker" || len(deliver.Tasks) != 1 || deliver.Tasks[0].TaskID != "task-1" {
+		t.Fatalf("unexpected deliver payload: %+v", deliver)
+	}
+
+	// The worker fetches its inbox when it joins.
+	worker := newTestManagerForOnboard(server.URL, "worker", "open")
+	worker.roster["founder"] = &GroupMember{AgentID: "founder", Role: "coordinator", Status: "active"}
+	if err := worker.Join(context.Background()); err != nil {
+		t.Fatalf("worker join: %v", err)
+	}
+	defer worker.Leave(context.Background())
+	if worker.IsInboxKeeper() {
+		t.Fatal("expected the worker not to keep the inbox")
+	}
+	fetched := func() bool {
+		for _, env := range produced.snapshot() {
+			if env.Type == EnvelopeInbox && env.SenderID == "worker" {
+				return true
+			}
+		}
+		return false
+	}
+	// The first fetch follows one heartbeat interval (1.5s here) after join.
+	for deadline := time.Now().Add(4 * time.Second); !fetched() && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if fetch := lastInboxPayload(t, &produced, InboxActionFetch); fetch.AgentID != "worker" {
+		t.Fatalf("unexpected fetch payload: %+v", fetch)
+	}
+
+	// The worker routes the delivered task into its bus under the original
+	// trace, and acknowledges it once answered.
+	workerBus := bus.NewMessageBus()
+	workerRouter := NewGroupRouter(worker, workerBus, NewChannelConsumer())
+	workerRouter.handleMessage(inboxMessage(t, ext.ControlOnboarding, GroupEnvelope{
+		Type:      EnvelopeInbox,
+		SenderID:  "founder",
+		Timestamp: time.Now(),
+		Payload:   deliver,
+	}))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	in, err := workerBus.ConsumeInbound(ctx)
+	if err != nil {
+		t.Fatalf("expected delivered task on bus: %v", err)
+	}
+	if in.ChatID != "task-1" || in.Content != "review the PR" || in.TraceID != "trace-1" {
+		t.Fatalf("unexpected inbound: %+v", in)
+	}
+	for _, env := range produced.snapshot() {
+		if data, _ := json.Marshal(env.Payload); env.Type == EnvelopeInbox && strings.Contains(string(data), string(InboxActionAck)) {
+			t.Fatal("expected no ack before the task is answered")
+		}
+	}
+	if err := worker.RespondTask(context.Background(), "task-1", "done", "completed"); err != nil {
+		t.Fatalf("respond: %v", err)
+	}
+	ack := lastInboxPayload(t, &produced, InboxActionAck)
+	if len(ack.TaskIDs) != 1 || ack.TaskIDs[0] != "task-1" {
+		t.Fatalf("unexpected ack payload: %+v", ack)
+	}
+
+	// The acknowledgement cleans the founder's inbox.
+	founderRouter.handleMessage(inboxMessage(t, ext.ControlOnboarding, GroupEnvelope{
+		Type:      EnvelopeInbox,
+		SenderID:  "worker",
+		Timestamp: time.Now(),
+		Payload:   ack,
+	}))
+	recs, _ = tl.ListGroupInboxTasks("worker", 0)
+	if len(recs) != 0 {
+		t.Fatalf("expected inbox cleaned after ack, got %d", len(recs))
+	}
+
+	// Tasks the keeper directs itself are stored too.
+	if err := founder.SubmitTaskWithOptions(context.Background(), "task-2", "deploy", "", TaskOptions{TargetAgentID: "worker"}); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	recs, _ = tl.ListGroupInboxTasks("worker", 0)
+	if len(recs) != 1 || recs[0].TaskID != "task-2" || !strings.Contains(recs[0].Payload, `"trace_id":"task-2"`) {
+		t.Fatalf("expected the submitted task in the inbox, got %+v", recs)
+	}
+}
+
+func TestInbox_RejectsSpoofedSenders(t *testing.T) {
+	var produced producedStore
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var env GroupEnvelope
+		_ = json.NewDecoder(r.Body).Decode(&env)
+		produced.append(env)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(LFSEnvelope{KfsLFS: 1})
+	}))
+	defer server.Close()
+
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tl.Close()
+
+	founder := newTestManagerForOnboard(server.URL, "founder", "open")
+	founder.timeline = tl
+	if err := founder.Join(context.Background()); err != nil {
+		t.Fatalf("founder join: %v", err)
+	}
+	if err := founder.StoreInboxTask(TaskRequestPayload{TaskID: "task-1", Content: "review", TargetAgentID: "worker"}); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	founderRouter := NewGroupRouter(founder, bus.NewMessageBus(), NewChannelConsumer())
+	ext := ExtendedTopics("test-group")
+	inboxFrom := func(sender string, p InboxPayload) ConsumerMessage {
+		return inboxMessage(t, ext.ControlOnboarding, GroupEnvelope{Type: EnvelopeInbox, SenderID: sender, Timestamp: time.Now(), Payload: p})
+	}
+
+	// Another member cannot pull the worker's backlog.
+	founderRouter.handleMessage(inboxFrom("mallory", InboxPayload{Action: InboxActionFetch, AgentID: "worker"}))
+	for _, env := range produced.snapshot() {
+		if env.Type == EnvelopeInbox {
+			t.Fatalf("expected no delivery for a spoofed fetch, got %+v", env)
+		}
+	}
+
+	// Nor acknowledge it away.
+	founderRouter.handleMessage(inboxFrom("mallory", InboxPayload{Action: InboxActionAck, AgentID: "worker", TaskIDs: []string{"task-1"}}))
+	if recs, _ := tl.ListGroupInboxTasks("worker", 0); len(recs) != 1 {
+		t.Fatalf("expected the task kept after a spoofed ack, got %d", len(recs))
+	}
+
+	// Only the keeper delivers into the worker's inbox.
+	worker := newTestManagerForOnboard(server.URL, "worker", "open")
+	worker.roster["founder"] = &GroupMember{AgentID: "founder", Role: "coordinator", Status: "active"}
+	worker.roster["mallory"] = &GroupMember{AgentID: "mallory", Role: "worker", Status: "active"}
+	workerBus := bus.NewMessageBus()
+	workerRouter := NewGroupRouter(worker, workerBus, NewChannelConsumer())
+	forged := InboxPayload{
+		Action:   InboxActionDeliver,
+		AgentID:  "worker",
+		KeeperID: "founder",
+		Tasks:    []TaskRequestPayload{{TaskID: "task-x", Content: "rm -rf", TargetAgentID: "worker"}},
+	}
+	workerRouter.handleMessage(inboxFrom("mallory", forged))
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if in, err := workerBus.ConsumeInbound(ctx); err == nil {
+		t.Fatalf("expected no task from a non-keeper delivery, got %+v", in)
+	}
+}
@@ -220,6 +220,13 @@ func (h *defaultKnowledgeHandler) applyFactPayload(env knowledge.Envelope) (stat
 			return "", "", err
 		}
 	}
+	// A conflicting assertion about a known fact lowers its confidence.
+	if result.Status == knowledge.FactApplyConflict && current != nil &&
+		(current.Subject != p.Subject || current.Predicate != p.Predicate || current.Object != p.Object) {
+		if err := h.timeline.RecordKnowledgeFactContradiction(p.FactID); err != nil {
+			slog.Warn("Knowledge fact contradiction not recorded", "fact_id", p.FactID, "error", err)
+		}
+	}
 	return result.Status, result.Reason, nil
 }
 
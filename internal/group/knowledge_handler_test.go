@@ -129,6 +129,9 @@ func TestKnowledgeHandlerProcess_FactVersionPolicy(t *testing.T) {
 	if cur == nil || cur.Version != 1 || cur.Object != "v1" {
 		t.Fatalf("expected latest unchanged on gap conflict, got %+v", cur)
 	}
+	if cur.ContradictionCount != 1 {
+		t.Fatalf("expected conflicting content to count as contradiction, got %d", cur.ContradictionCount)
+	}
 
 	if err := h.Process("group.g1.knowledge.facts", makeEnv("idem-f3", 2, "v2")); err != nil {
 		t.Fatalf("process fact v2: %v", err)
@@ -140,6 +143,9 @@ func TestKnowledgeHandlerProcess_FactVersionPolicy(t *testing.T) {
 	if cur == nil || cur.Version != 2 || cur.Object != "v2" {
 		t.Fatalf("unexpected fact latest after v2: %+v", cur)
 	}
+	if cur.ContradictionCount != 0 {
+		t.Fatalf("expected usage signals reset on content change, got %d", cur.ContradictionCount)
+	}
 }
 
 func TestKnowledgeHandlerProcess_ProposalDecisionFactEndToEnd(t *testing.T) {
@@ -50,6 +50,19 @@ type LFSEnvelope struct {
 	ProxyID     string `json:"proxy_id"`
 }
 
+// RosterEntry is one member of the authoritative group roster kept by the LFS proxy.
+type RosterEntry struct {
+	AgentID      string    `json:"agent_id"`
+	AgentName    string    `json:"agent_name"`
+	SoulSummary  string    `json:"soul_summary,omitempty"`
+	Capabilities []string  `json:"capabilities,omitempty"`
+	Channels     []string  `json:"channels,omitempty"`
+	Model        string    `json:"model,omitempty"`
+	Role         string    `json:"role,omitempty"`
+	Status       string    `json:"status"` // "active" or "inactive"
+	LastSeen     time.Time `json:"last_seen"`
+}
+
 // endpointURL returns a copy of the pre-validated parsedBase with the given path appended.
 func (c *LFSClient) endpointURL(path string) *url.URL {
 	u := *c.parsedBase // shallow copy
@@ -117,8 +130,42 @@ func (c *LFSClient) ProduceEnvelope(ctx context.Context, topic string, env *Grou
 	if err != nil {
 		return fmt.Errorf("lfs produce envelope: marshal: %w", err)
 	}
-	_, err = c.Produce(ctx, topic, env.CorrelationID, data)
-	return err
+	if _, err = c.Produce(ctx, topic, env.CorrelationID, data); err != nil {
+		return err
+	}
+	envelopesTotal.Inc(env.Type, "sent")
+	return nil
+}
+
+// FetchRoster returns the authoritative roster for a group. The proxy builds it
+// from the compacted announce topic, so it survives missed announce messages.
+func (c *LFSClient) FetchRoster(ctx context.Context, groupName string) ([]RosterEntry, error) {
+	req := c.newRequest(ctx, http.MethodGet, "/lfs/groups/"+groupName+"/roster", nil)
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("lfs roster: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("lfs roster: read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("lfs roster: status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var out struct {
+		Members []RosterEntry `json:"members"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return nil, fmt.Errorf("lfs roster: decode response: %w", err)
+	}
+	return out.Members, nil
 }
 
 // Healthy checks if the LFS proxy is reachable.
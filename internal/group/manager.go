@@ -2,7 +2,10 @@ package group
 
 import (
 	"context"
+	"crypto/ecdh"
+	"crypto/ed25519"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strconv"
@@ -29,6 +32,27 @@ type Manager struct {
 	active    bool
 	activeMu  sync.RWMutex
 	cancelHB  context.CancelFunc
+	skillMu   sync.Mutex
+	skillReqs map[string]skillRequest
+	adminMu   sync.RWMutex
+	admin     AdminState
+	adminKey  ed25519.PrivateKey
+	adminSeen map[string]time.Time // nonces of accepted admin envelopes
+	// foundedLocal is when this agent founded the group, on the local clock;
+	// zero when it did not found it in this process. Guarded by adminMu.
+	foundedLocal time.Time
+
+	identityMu    sync.Mutex
+	identityKey   ed25519.PrivateKey
+	identityPins  map[string]*IdentityPin // agent ID -> pinned identity
+	encryptionKey *ecdh.PrivateKey        // opens tasks sealed to this agent
+
+	observerAudited map[string]time.Time // last audit of a blocked observer publish; guarded by adminMu
+
+	escalationNotify func(text string) // delivers overdue task notices to the owner
+
+	inboxMu      sync.Mutex
+	inboxPending map[string]bool // directed tasks acknowledged once answered
 }
 
 // NewManager creates a new group manager.
@@ -37,8 +61,12 @@ func NewManager(cfg config.GroupConfig, timeSvc *timeline.TimelineService, ident
 	topics := Topics(cfg.GroupName)
 	extTopics := ExtendedTopics(cfg.GroupName)
 	topicMgr := NewTopicManager(cfg.GroupName)
+	identity.Taxonomy = BuildCapabilityTaxonomy(identity)
+	if declaresObserver(cfg.Role) {
+		identity.Role = GroupRoleObserver
+	}
 
-	return &Manager{
+	m := &Manager{
 		cfg:       cfg,
 		lfs:       lfs,
 		timeline:  timeSvc,
@@ -47,7 +75,11 @@ func NewManager(cfg config.GroupConfig, timeSvc *timeline.TimelineService, ident
 		extTopics: extTopics,
 		topicMgr:  topicMgr,
 		roster:    make(map[string]*GroupMember),
+		adminSeen: make(map[string]time.Time),
 	}
+	m.loadAdmin()
+	m.loadIdentity()
+	return m
 }
 
 // SetMemoryIndexer sets an optional local memory indexer for group items.
@@ -63,6 +95,23 @@ func (m *Manager) Join(ctx context.Context) error {
 	if m.active {
 		return fmt.Errorf("already joined group %s", m.cfg.GroupName)
 	}
+	if m.isKicked(m.identity.AgentID) {
+		return fmt.Errorf("agent %s was kicked from group %s", m.identity.AgentID, m.cfg.GroupName)
+	}
+	if err := m.ensureIdentityKey(); err != nil {
+		return err
+	}
+
+	// The agent creating the group (no founder known, empty roster) founds it.
+	m.adminMu.RLock()
+	founding := m.admin.Founder == ""
+	m.adminMu.RUnlock()
+	m.rosterMu.RLock()
+	founding = founding && len(m.roster) == 0
+	m.rosterMu.RUnlock()
+	if founding {
+		m.identity.FoundedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	}
 
 	// Announce join
 	env := &GroupEnvelope{
@@ -70,34 +119,44 @@ func (m *Manager) Join(ctx context.Context) error {
 		CorrelationID: fmt.Sprintf("join-%d", time.Now().UnixNano()),
 		SenderID:      m.identity.AgentID,
 		Timestamp:     time.Now(),
-		Payload: AnnouncePayload{
-			Action:   "join",
-			Identity: m.identity,
-		},
+		Payload:       m.signedAnnounce("join"),
 	}
 
-	if err := m.lfs.ProduceEnvelope(ctx, m.topics.Announce, env); err != nil {
+	if err := m.publish(ctx, m.topics.Announce, env); err != nil {
+		if founding {
+			m.identity.FoundedAt = ""
+		}
 		return fmt.Errorf("join announce failed: %w", err)
 	}
+	if founding {
+		m.foundGroup(m.identity.FoundedAt)
+	}
 
 	// Add self to in-memory roster.
-	// First agent in the group becomes coordinator.
+	// First agent in the group becomes coordinator; observers never do.
+	m.adminMu.RLock()
+	_, imposedObserver := m.admin.Observers[m.identity.AgentID]
+	m.adminMu.RUnlock()
 	m.rosterMu.Lock()
 	role := m.identity.Role
-	if role == "" && len(m.roster) == 0 {
+	if role == "" && len(m.roster) == 0 && !imposedObserver {
 		role = "coordinator"
 		m.identity.Role = role
 	}
 	m.roster[m.identity.AgentID] = &GroupMember{
-		AgentID:      m.identity.AgentID,
-		AgentName:    m.identity.AgentName,
-		SoulSummary:  m.identity.SoulSummary,
-		Capabilities: m.identity.Capabilities,
-		Channels:     m.identity.Channels,
-		Model:        m.identity.Model,
-		Role:         role,
-		Status:       "active",
-		LastSeen:     time.Now(),
+		AgentID:       m.identity.AgentID,
+		AgentName:     m.identity.AgentName,
+		SoulSummary:   m.identity.SoulSummary,
+		Capabilities:  m.identity.Capabilities,
+		Channels:      m.identity.Channels,
+		Model:         m.identity.Model,
+		Role:          role,
+		Status:        "active",
+		LastSeen:      time.Now(),
+		Taxonomy:      m.identity.Taxonomy,
+		AdminKey:      m.identity.AdminKey,
+		IdentityKey:   m.identity.IdentityKey,
+		EncryptionKey: m.identity.EncryptionKey,
 	}
 	m.rosterMu.Unlock()
 
@@ -105,6 +164,7 @@ func (m *Manager) Join(ctx context.Context) error {
 	if m.timeline != nil {
 		caps, _ := json.Marshal(m.identity.Capabilities)
 		chs, _ := json.Marshal(m.identity.Channels)
+		tax, _ := json.Marshal(m.identity.Taxonomy)
 		_ = m.timeline.UpsertGroupMember(&timeline.GroupMemberRecord{
 			AgentID:      m.identity.AgentID,
 			AgentName:    m.identity.AgentName,
@@ -113,6 +173,7 @@ func (m *Manager) Join(ctx context.Context) error {
 			Channels:     string(chs),
 			Model:        m.identity.Model,
 			Status:       "active",
+			Taxonomy:     string(tax),
 		})
 		// Log membership history
 		_ = m.timeline.LogMembershipHistory(&timeline.GroupMembershipHistoryRecord{
@@ -162,13 +223,10 @@ func (m *Manager) Leave(ctx context.Context) error {
 		CorrelationID: fmt.Sprintf("leave-%d", time.Now().UnixNano()),
 		SenderID:      m.identity.AgentID,
 		Timestamp:     time.Now(),
-		Payload: AnnouncePayload{
-			Action:   "leave",
-			Identity: m.identity,
-		},
+		Payload:       m.signedAnnounce("leave"),
 	}
 
-	if err := m.lfs.ProduceEnvelope(ctx, m.topics.Announce, env); err != nil {
+	if err := m.publish(ctx, m.topics.Announce, env); err != nil {
 		slog.Warn("Leave announce failed", "error", err)
 	}
 
@@ -247,6 +305,7 @@ func (m *Manager) Status() map[string]any {
 		"member_count":  m.MemberCount(),
 		"lfs_proxy_url": m.cfg.LFSProxyURL,
 		"lfs_healthy":   healthy,
+		"admin_role":    m.AdminRole(),
 	}
 }
 
@@ -262,29 +321,44 @@ func (m *Manager) HandleAnnounce(env *GroupEnvelope) {
 		slog.Warn("HandleAnnounce: unmarshal payload", "error", err)
 		return
 	}
+	if !m.admitAnnounce(env.SenderID, &payload) {
+		return
+	}
 
 	id := payload.Identity
+	if payload.Signature == "" {
+		// Only a signed announce vouches for the key tasks get sealed to.
+		id.EncryptionKey = ""
+	}
 	switch payload.Action {
 	case "join", "heartbeat":
+		// Agents that predate the taxonomy only send free-form capabilities.
+		taxonomy := BuildCapabilityTaxonomy(id)
 		member := &GroupMember{
-			AgentID:      id.AgentID,
-			AgentName:    id.AgentName,
-			SoulSummary:  id.SoulSummary,
-			Capabilities: id.Capabilities,
-			Channels:     id.Channels,
-			Model:        id.Model,
-			Role:         id.Role,
-			Status:       id.Status,
-			LastSeen:     time.Now(),
+			AgentID:       id.AgentID,
+			AgentName:     id.AgentName,
+			SoulSummary:   id.SoulSummary,
+			Capabilities:  id.Capabilities,
+			Channels:      id.Channels,
+			Model:         id.Model,
+			Role:          id.Role,
+			Status:        id.Status,
+			LastSeen:      time.Now(),
+			Taxonomy:      taxonomy,
+			AdminKey:      id.AdminKey,
+			IdentityKey:   id.IdentityKey,
+			EncryptionKey: id.EncryptionKey,
 		}
 		m.rosterMu.Lock()
 		m.roster[id.AgentID] = member
 		m.rosterMu.Unlock()
+		m.observeFounder(id)
 
 		// Persist to DB
 		if m.timeline != nil {
 			caps, _ := json.Marshal(id.Capabilities)
 			chs, _ := json.Marshal(id.Channels)
+			tax, _ := json.Marshal(taxonomy)
 			_ = m.timeline.UpsertGroupMember(&timeline.GroupMemberRecord{
 				AgentID:      id.AgentID,
 				AgentName:    id.AgentName,
@@ -293,6 +367,7 @@ func (m *Manager) HandleAnnounce(env *GroupEnvelope) {
 				Channels:     string(chs),
 				Model:        id.Model,
 				Status:       "active",
+				Taxonomy:     string(tax),
 			})
 		}
 
@@ -326,7 +401,10 @@ func (m *Manager) PublishTrace(ctx context.Context, tracePayload TracePayload) e
 		Timestamp:     time.Now(),
 		Payload:       tracePayload,
 	}
-	err := m.lfs.ProduceEnvelope(ctx, m.topics.Traces, env)
+	err := m.publish(ctx, m.topics.Traces, env)
+	if errors.Is(err, ErrObserverPublish) {
+		return err
+	}
 	// Also log to topic_message_log so the browse view shows trace data
 	if m.timeline != nil {
 		_ = m.timeline.LogTopicMessage(&timeline.TopicMessageLogRecord{
@@ -355,7 +433,10 @@ func (m *Manager) PublishAudit(ctx context.Context, eventType, traceID, detail s
 			"detail":     detail,
 		},
 	}
-	err := m.lfs.ProduceEnvelope(ctx, m.extTopics.ObserveAudit, env)
+	err := m.publish(ctx, m.extTopics.ObserveAudit, env)
+	if errors.Is(err, ErrObserverPublish) {
+		return err
+	}
 	// Also log to topic_message_log so the browse view shows audit data
 	if m.timeline != nil {
 		_ = m.timeline.LogTopicMessage(&timeline.TopicMessageLogRecord{
@@ -371,22 +452,7 @@ func (m *Manager) PublishAudit(ctx context.Context, eventType, traceID, detail s
 
 // SubmitTask sends a task request to the group.
 func (m *Manager) SubmitTask(ctx context.Context, taskID, description, content string) error {
-	if !m.Active() {
-		return fmt.Errorf("not in a group")
-	}
-	env := &GroupEnvelope{
-		Type:          EnvelopeRequest,
-		CorrelationID: taskID,
-		SenderID:      m.identity.AgentID,
-		Timestamp:     time.Now(),
-		Payload: TaskRequestPayload{
-			TaskID:      taskID,
-			Description: description,
-			Content:     content,
-			RequesterID: m.identity.AgentID,
-		},
-	}
-	return m.lfs.ProduceEnvelope(ctx, m.topics.Requests, env)
+	return m.SubmitTaskWithOptions(ctx, taskID, description, content, TaskOptions{})
 }
 
 // RespondTask sends a task response to the group.
@@ -406,9 +472,15 @@ func (m *Manager) RespondTask(ctx context.Context, taskID, content, status strin
 			Status:      status,
 		},
 	}
-	if err := m.lfs.ProduceEnvelope(ctx, m.topics.Responses, env); err != nil {
+	if err := m.publish(ctx, m.topics.Responses, env); err != nil {
 		return err
 	}
+	// The keeper drops a directed task only once it has been answered.
+	if m.takeInboxPending(taskID) {
+		if err := m.AckInbox(ctx, []string{taskID}); err != nil {
+			slog.Warn("Inbox ack failed", "task_id", taskID, "error", err)
+		}
+	}
 
 	// Log delegation events for completed/failed responses.
 	if m.timeline != nil && (status == "completed" || status == "failed") {
@@ -435,6 +507,10 @@ func (m *Manager) SubmitDelegatedTask(ctx context.Context, req DelegatedTaskRequ
 		return fmt.Errorf("delegation depth %d exceeds max %d", req.DelegationDepth, maxDepth)
 	}
 
+	if req.TargetAgentID != "" && m.isObserver(req.TargetAgentID) {
+		return fmt.Errorf("submit delegated task: agent %s is an observer", req.TargetAgentID)
+	}
+
 	originalRequester := req.OriginalRequesterID
 	if originalRequester == "" {
 		originalRequester = m.identity.AgentID
@@ -445,26 +521,41 @@ func (m *Manager) SubmitDelegatedTask(ctx context.Context, req DelegatedTaskRequ
 		deadlineStr = req.DeadlineAt.Format("2006-01-02T15:04:05Z07:00")
 	}
 
+	payload := TaskRequestPayload{
+		TaskID:              req.TaskID,
+		Description:         req.Description,
+		Content:             req.Content,
+		RequesterID:         m.identity.AgentID,
+		ParentTaskID:        req.ParentTaskID,
+		DelegationDepth:     req.DelegationDepth + 1,
+		OriginalRequesterID: originalRequester,
+		DeadlineAt:          deadlineStr,
+		TargetAgentID:       req.TargetAgentID,
+		Priority:            req.Priority,
+	}
+	// Tasks for one agent are readable by that agent only.
+	if err := m.sealForTarget(&payload); err != nil {
+		return fmt.Errorf("submit delegated task: %w", err)
+	}
 	env := &GroupEnvelope{
 		Type:          EnvelopeRequest,
 		CorrelationID: req.TaskID,
 		SenderID:      m.identity.AgentID,
 		Timestamp:     time.Now(),
-		Payload: TaskRequestPayload{
-			TaskID:              req.TaskID,
-			Description:         req.Description,
-			Content:             req.Content,
-			RequesterID:         m.identity.AgentID,
-			ParentTaskID:        req.ParentTaskID,
-			DelegationDepth:     req.DelegationDepth + 1,
-			OriginalRequesterID: originalRequester,
-			DeadlineAt:          deadlineStr,
-		},
+		Payload:       payload,
 	}
 
-	if err := m.lfs.ProduceEnvelope(ctx, m.topics.Requests, env); err != nil {
+	if err := m.publish(ctx, m.topics.Requests, env); err != nil {
 		return fmt.Errorf("submit delegated task: %w", err)
 	}
+	// Our own envelopes are skipped by the router, so the keeper stores its
+	// directed tasks here.
+	if req.TargetAgentID != "" {
+		payload.TraceID = env.CorrelationID
+		if err := m.StoreInboxTask(payload); err != nil {
+			slog.Warn("Inbox store failed", "task_id", req.TaskID, "error", err)
+		}
+	}
 
 	// Persist to DB with delegation info.
 	if m.timeline != nil {
@@ -479,12 +570,14 @@ func (m *Manager) SubmitDelegatedTask(ctx context.Context, req DelegatedTaskRequ
 			DelegationDepth:     req.DelegationDepth + 1,
 			OriginalRequesterID: originalRequester,
 			DeadlineAt:          req.DeadlineAt,
+			Priority:            req.Priority,
+			TargetAgentID:       req.TargetAgentID,
 		})
 
 		// Log delegation event.
 		_ = m.timeline.LogDelegationEvent(
 			req.TaskID, "submitted",
-			m.identity.AgentID, "", // receiver unknown yet
+			m.identity.AgentID, req.TargetAgentID, // empty when not directed
 			req.Description, req.DelegationDepth+1,
 		)
 	}
@@ -515,7 +608,7 @@ func (m *Manager) ReportTaskStatus(ctx context.Context, taskID, status, summary
 		},
 	}
 
-	if err := m.lfs.ProduceEnvelope(ctx, m.topics.Responses, env); err != nil {
+	if err := m.publish(ctx, m.topics.Responses, env); err != nil {
 		return fmt.Errorf("report task status: %w", err)
 	}
 
@@ -555,7 +648,7 @@ func (m *Manager) Topics() TopicNames {
 
 // PublishEnvelope publishes a pre-built envelope to a specific Kafka topic.
 func (m *Manager) PublishEnvelope(ctx context.Context, topic string, env *GroupEnvelope) error {
-	return m.lfs.ProduceEnvelope(ctx, topic, env)
+	return m.publish(ctx, topic, env)
 }
 
 // EnsureTopic sends a lightweight heartbeat to a topic to auto-create it in Kafka.
@@ -579,7 +672,7 @@ func (m *Manager) EnsureTopic(ctx context.Context, topicName string) error {
 			"topic":  topicName,
 		},
 	}
-	if err := m.lfs.ProduceEnvelope(ctx, topicName, env); err != nil {
+	if err := m.publish(ctx, topicName, env); err != nil {
 		return fmt.Errorf("ensure topic %s: %w", topicName, err)
 	}
 
@@ -601,6 +694,34 @@ func (m *Manager) startHeartbeat(ctx context.Context) {
 	staleTicker := time.NewTicker(interval * 3)
 	defer staleTicker.Stop()
 
+	// Members pick up tasks directed at them while they were away, one
+	// heartbeat after joining and then periodically in case a live request
+	// was missed. Observers are never targets.
+	var inboxC <-chan time.Time
+	var inboxTimer *time.Timer
+	if !m.IsInboxKeeper() && !m.Observer() {
+		inboxTimer = time.NewTimer(interval)
+		defer inboxTimer.Stop()
+		inboxC = inboxTimer.C
+	}
+
+	// Anti-entropy: reconcile against the proxy's roster to repair drift
+	// from missed announce messages.
+	var reconcileC <-chan time.Time
+	if m.cfg.RosterReconcileSec > 0 {
+		reconcileTicker := time.NewTicker(time.Duration(m.cfg.RosterReconcileSec) * time.Second)
+		defer reconcileTicker.Stop()
+		reconcileC = reconcileTicker.C
+	}
+
+	// Deadline escalation of the tasks this agent submitted.
+	var escalateC <-chan time.Time
+	if d := m.escalationInterval(); d > 0 {
+		escalateTicker := time.NewTicker(d)
+		defer escalateTicker.Stop()
+		escalateC = escalateTicker.C
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -614,6 +735,21 @@ func (m *Manager) startHeartbeat(ctx context.Context) {
 					slog.Info("Marked stale members", "count", n)
 				}
 			}
+		case <-reconcileC:
+			if res, err := m.ReconcileRoster(ctx); err != nil {
+				slog.Debug("Roster reconciliation failed", "error", err)
+			} else if len(res.Activated)+len(res.Deactivated) > 0 {
+				slog.Info("Roster reconciled", "activated", len(res.Activated), "deactivated", len(res.Deactivated))
+			}
+		case <-inboxC:
+			m.fetchInbox(ctx)
+			inboxTimer.Reset(interval * 3)
+		case <-escalateC:
+			if n, err := m.EscalateOverdueTasks(ctx, time.Now()); err != nil {
+				slog.Debug("Group task escalation failed", "error", err)
+			} else if n > 0 {
+				slog.Info("Overdue group tasks escalated", "count", n)
+			}
 		}
 	}
 }
@@ -627,12 +763,9 @@ func (m *Manager) sendHeartbeat(ctx context.Context) {
 		CorrelationID: fmt.Sprintf("hb-%d", time.Now().UnixNano()),
 		SenderID:      m.identity.AgentID,
 		Timestamp:     time.Now(),
-		Payload: AnnouncePayload{
-			Action:   "heartbeat",
-			Identity: m.identity,
-		},
+		Payload:       m.signedAnnounce("heartbeat"),
 	}
-	if err := m.lfs.ProduceEnvelope(ctx, m.topics.Announce, env); err != nil {
+	if err := m.publish(ctx, m.topics.Announce, env); err != nil {
 		if m.timeline != nil {
 			_ = m.timeline.SetSetting("group_heartbeat_last_attempt_at", time.Now().UTC().Format(time.RFC3339))
 		}
@@ -199,3 +199,82 @@ func TestManager_HeartbeatMetadataPersists(t *testing.T) {
 		t.Fatalf("expected heartbeat seq > 0, got %q", seq)
 	}
 }
+
+func TestManager_ReconcileRoster(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/lfs/groups/test-group/roster" {
+			json.NewEncoder(w).Encode(map[string]any{"members": []RosterEntry{
+				{AgentID: "test-agent", Status: "active"},
+				{AgentID: "missed-join", AgentName: "Missed", Role: "worker", Status: "active"},
+				{AgentID: "known", AgentName: "Known", Status: "active"},
+				{AgentID: "gone", Status: "inactive"},
+			}})
+			return
+		}
+		json.NewEncoder(w).Encode(LFSEnvelope{KfsLFS: 1})
+	}))
+	defer server.Close()
+
+	timeSvc, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatalf("open timeline: %v", err)
+	}
+	defer timeSvc.Close()
+
+	m := newTestManagerWithTimeline(server.URL, timeSvc)
+	if err := m.Join(context.Background()); err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	for _, id := range []string{"known", "gone", "missed-leave"} {
+		m.HandleAnnounce(&GroupEnvelope{Type: EnvelopeAnnounce, Payload: AnnouncePayload{
+			Action:   "heartbeat",
+			Identity: AgentIdentity{AgentID: id, AgentName: id, Status: "active"},
+		}})
+	}
+
+	res, err := m.ReconcileRoster(context.Background())
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if len(res.Activated) != 1 || res.Activated[0] != "missed-join" {
+		t.Fatalf("unexpected activated: %v", res.Activated)
+	}
+	if len(res.Deactivated) != 2 || res.Deactivated[0] != "gone" || res.Deactivated[1] != "missed-leave" {
+		t.Fatalf("unexpected deactivated: %v", res.Deactivated)
+	}
+
+	ids := map[string]bool{}
+	for _, mem := range m.Members() {
+		ids[mem.AgentID] = true
+	}
+	if !ids["test-agent"] || !ids["known"] || !ids["missed-join"] || ids["gone"] || ids["missed-leave"] {
+		t.Fatalf("unexpected in-memory roster: %v", ids)
+	}
+
+	members, _ := timeSvc.ListGroupMembers()
+	status := map[string]string{}
+	for _, rec := range members {
+		status[rec.AgentID] = rec.Status
+	}
+	if status["missed-join"] != "active" || status["gone"] != "inactive" || status["missed-leave"] != "inactive" {
+		t.Fatalf("unexpected DB roster: %v", status)
+	}
+
+	history, _ := timeSvc.GetMembershipHistory("", "test-group", 50, 0)
+	actions := map[string]string{}
+	for _, h := range history {
+		actions[h.AgentID] = h.Action
+	}
+	if actions["missed-join"] != ActionReconciledActive || actions["gone"] != ActionReconciledInactive || actions["missed-leave"] != ActionReconciledInactive {
+		t.Fatalf("unexpected history: %v", actions)
+	}
+
+	// A second run is a no-op.
+	res, err = m.ReconcileRoster(context.Background())
+	if err != nil {
+		t.Fatalf("reconcile again: %v", err)
+	}
+	if len(res.Activated)+len(res.Deactivated) != 0 {
+		t.Fatalf("expected no changes, got %+v", res)
+	}
+}
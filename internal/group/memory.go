@@ -37,6 +37,9 @@ func (m *Manager) ShareMemory(ctx context.Context, title, contentType string, co
 	}
 
 	itemID := fmt.Sprintf("mem-%d", time.Now().UnixNano())
+	if err := m.checkPublish(m.extTopics.MemoryShared, &GroupEnvelope{Type: EnvelopeMemory, CorrelationID: itemID}); err != nil {
+		return fmt.Errorf("share memory: %w", err)
+	}
 
 	// Produce content to LFS to get S3 pointer
 	lfsEnv, err := m.lfs.Produce(ctx, m.extTopics.MemoryShared, itemID, content)
@@ -62,7 +65,7 @@ func (m *Manager) ShareMemory(ctx context.Context, title, contentType string, co
 		Payload:       item,
 	}
 
-	if err := m.lfs.ProduceEnvelope(ctx, m.extTopics.MemoryShared, env); err != nil {
+	if err := m.publish(ctx, m.extTopics.MemoryShared, env); err != nil {
 		return fmt.Errorf("share memory: publish failed: %w", err)
 	}
 
@@ -112,7 +115,7 @@ func (m *Manager) ShareContext(ctx context.Context, title string, content []byte
 		Payload:       item,
 	}
 
-	if err := m.lfs.ProduceEnvelope(ctx, m.extTopics.MemoryContext, env); err != nil {
+	if err := m.publish(ctx, m.extTopics.MemoryContext, env); err != nil {
 		return fmt.Errorf("share context: publish failed: %w", err)
 	}
 
@@ -0,0 +1,117 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// GroupRoleObserver is the membership role of a consume-only member, e.g. a
+// monitoring agent. It is declared at join (group.role) or imposed by an
+// admin (set_observer).
+const GroupRoleObserver = "observer"
+
+// observerAuditInterval throttles the audit record of repeated blocked
+// publishes of the same envelope type to the same topic.
+const observerAuditInterval = time.Minute
+
+// ErrObserverPublish is returned for any publish attempt of an observer
+// other than its announces.
+var ErrObserverPublish = errors.New("observer members may not publish to the group")
+
+// declaresObserver reports whether a configured role is the observer role.
+func declaresObserver(role string) bool {
+	return strings.EqualFold(strings.TrimSpace(role), GroupRoleObserver)
+}
+
+// isObserver reports whether agentID is an observer, either by the role it
+// announced or by an admin's set_observer.
+func (m *Manager) isObserver(agentID string) bool {
+	if agentID == m.identity.AgentID && declaresObserver(m.identity.Role) {
+		return true
+	}
+	m.adminMu.RLock()
+	_, imposed := m.admin.Observers[agentID]
+	m.adminMu.RUnlock()
+	if imposed {
+		return true
+	}
+	m.rosterMu.RLock()
+	defer m.rosterMu.RUnlock()
+	member, ok := m.roster[agentID]
+	return ok && declaresObserver(member.Role)
+}
+
+// Observer reports whether this agent is a consume-only observer.
+func (m *Manager) Observer() bool {
+	return m.isObserver(m.identity.AgentID)
+}
+
+// publish produces env to topic unless this agent is an observer. Observers
+// keep announcing (join, heartbeat, leave) so they stay in the roster; every
+// other envelope is blocked and audited.
+func (m *Manager) publish(ctx context.Context, topic string, env *GroupEnvelope) error {
+	if err := m.checkPublish(topic, env); err != nil {
+		return err
+	}
+	return m.lfs.ProduceEnvelope(ctx, topic, env)
+}
+
+// PublishKnowledge produces a knowledge protocol envelope of type envType to
+// topic. Like every other publish, it is blocked for observers.
+func (m *Manager) PublishKnowledge(ctx context.Context, topic, envType, traceID string, payload []byte) error {
+	env := &GroupEnvelope{Type: envType, CorrelationID: traceID, SenderID: m.identity.AgentID}
+	if err := m.checkPublish(topic, env); err != nil {
+		return err
+	}
+	_, err := m.lfs.Produce(ctx, topic, traceID, payload)
+	return err
+}
+
+// checkPublish blocks and audits a publish of env to topic by an observer.
+// Callers that upload content before publishing check first.
+func (m *Manager) checkPublish(topic string, env *GroupEnvelope) error {
+	if env.Type == EnvelopeAnnounce || !m.Observer() {
+		return nil
+	}
+	envelopesTotal.Inc(env.Type, "blocked")
+	m.auditObserverPublish(m.identity.AgentID, topic, env)
+	slog.Warn("Group publish blocked: observer role", "type", env.Type, "topic", topic)
+	return fmt.Errorf("%s to %s: %w", env.Type, topic, ErrObserverPublish)
+}
+
+// dropsObserverEnvelope reports whether an incoming envelope is a publish by
+// an observer, which members drop. Announces are accepted so observers stay
+// in the roster.
+func (m *Manager) dropsObserverEnvelope(topic string, env *GroupEnvelope) bool {
+	if env.Type == EnvelopeAnnounce || !m.isObserver(env.SenderID) {
+		return false
+	}
+	m.auditObserverPublish(env.SenderID, topic, env)
+	return true
+}
+
+// auditObserverPublish records a blocked observer publish as a rejected
+// observer_publish admin action, at most once per observerAuditInterval for
+// the same sender, envelope type and topic.
+func (m *Manager) auditObserverPublish(senderID, topic string, env *GroupEnvelope) {
+	key := senderID + "|" + env.Type + "|" + topic
+	now := time.Now()
+	m.adminMu.Lock()
+	if m.observerAudited == nil {
+		m.observerAudited = make(map[string]time.Time)
+	}
+	last, seen := m.observerAudited[key]
+	if seen && now.Sub(last) < observerAuditInterval {
+		m.adminMu.Unlock()
+		return
+	}
+	m.observerAudited[key] = now
+	m.adminMu.Unlock()
+
+	m.logAdminAction(&AdminPayload{Action: "observer_publish", IssuerID: senderID},
+		"rejected", fmt.Sprintf("%s envelope to %s", env.Type, topic), env.CorrelationID)
+}
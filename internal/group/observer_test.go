@@ -0,0 +1,175 @@
+package group
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func newObserverTestServer(t *testing.T, produced *producedStore) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var env GroupEnvelope
+		_ = json.NewDecoder(r.Body).Decode(&env)
+		produced.append(env)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(LFSEnvelope{KfsLFS: 1})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newObserverTestTimeline(t *testing.T) *timeline.TimelineService {
+	t.Helper()
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { tl.Close() })
+	return tl
+}
+
+func observerAudits(t *testing.T, tl *timeline.TimelineService, issuer string) int {
+	t.Helper()
+	actions, err := tl.ListGroupAdminActions("test-group", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, a := range actions {
+		if a.Action == "observer_publish" && a.Status == "rejected" && a.IssuerID == issuer {
+			n++
+		}
+	}
+	return n
+}
+
+func TestObserver_DeclaredRoleIsConsumeOnly(t *testing.T) {
+	var produced producedStore
+	server := newObserverTestServer(t, &produced)
+	tl := newObserverTestTimeline(t)
+	ctx := context.Background()
+
+	base := newTestManagerForOnboard(server.URL, "watcher", "open")
+	cfg := base.cfg
+	cfg.Role = "Observer"
+	observer := NewManager(cfg, tl, base.identity)
+	if err := observer.Join(ctx); err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	defer observer.Leave(ctx)
+	if !observer.Observer() || observer.Members()[0].Role != GroupRoleObserver {
+		t.Fatalf("expected observer role instead of coordinator, got %+v", observer.Members())
+	}
+
+	// Announces go out; every other publish is blocked and audited once.
+	if err := observer.SubmitTask(ctx, "t1", "count rows", "SELECT 1"); !errors.Is(err, ErrObserverPublish) {
+		t.Fatalf("expected blocked task submission, got %v", err)
+	}
+	if err := observer.SubmitTask(ctx, "t2", "count rows", "SELECT 1"); !errors.Is(err, ErrObserverPublish) {
+		t.Fatalf("expected blocked task submission, got %v", err)
+	}
+	if err := observer.ShareMemory(ctx, "notes", "text/plain", []byte("x"), nil); !errors.Is(err, ErrObserverPublish) {
+		t.Fatalf("expected blocked memory publish, got %v", err)
+	}
+	if err := observer.PublishTrace(ctx, TracePayload{TraceID: "tr1", SpanType: "TASK"}); !errors.Is(err, ErrObserverPublish) {
+		t.Fatalf("expected blocked trace publish, got %v", err)
+	}
+	if err := observer.PublishKnowledge(ctx, "group.test-group.knowledge.votes", "vote", "tr2", []byte(`{}`)); !errors.Is(err, ErrObserverPublish) {
+		t.Fatalf("expected blocked knowledge publish, got %v", err)
+	}
+	for _, env := range produced.snapshot() {
+		if env.Type != EnvelopeAnnounce {
+			t.Fatalf("observer published %s envelope", env.Type)
+		}
+	}
+	if n := observerAudits(t, tl, "watcher"); n != 4 {
+		t.Fatalf("expected one audit per envelope type and topic, got %d", n)
+	}
+
+	// Task requests from other members are never claimed.
+	msgBus := bus.NewMessageBus()
+	router := NewGroupRouter(observer, msgBus, NewChannelConsumer())
+	router.handleMessage(inboxMessage(t, observer.topics.Requests, GroupEnvelope{
+		Type: EnvelopeRequest, SenderID: "worker", CorrelationID: "t3", Timestamp: time.Now(),
+		Payload: TaskRequestPayload{TaskID: "t3", Content: "do it", RequesterID: "worker"},
+	}))
+	if msgBus.InboundSize() != 0 {
+		t.Fatal("observer must not route task requests to its agent")
+	}
+}
+
+func TestObserver_MembersDropObserverPublishesAndAdminsImposeRole(t *testing.T) {
+	var produced producedStore
+	server := newObserverTestServer(t, &produced)
+	tl := newObserverTestTimeline(t)
+	ctx := context.Background()
+	ext := ExtendedTopics("test-group")
+
+	founder := newTestManagerForOnboard(server.URL, "founder", "open")
+	if err := founder.Join(ctx); err != nil {
+		t.Fatalf("founder join: %v", err)
+	}
+	member := newTestManagerForOnboard(server.URL, "member", "open")
+	member.timeline = tl
+	if err := member.Join(ctx); err != nil {
+		t.Fatalf("member join: %v", err)
+	}
+	msgBus := bus.NewMessageBus()
+	router := NewGroupRouter(member, msgBus, NewChannelConsumer())
+	router.handleMessage(inboxMessage(t, ext.ControlAnnounce, heartbeatOf(founder)))
+
+	// A member announcing the observer role stays in the roster, but its
+	// task requests are dropped and audited.
+	router.handleMessage(inboxMessage(t, ext.ControlAnnounce, GroupEnvelope{
+		Type: EnvelopeAnnounce, SenderID: "watcher", Timestamp: time.Now(),
+		Payload: AnnouncePayload{Action: "heartbeat", Identity: AgentIdentity{AgentID: "watcher", Role: GroupRoleObserver, Status: "active"}},
+	}))
+	router.handleMessage(inboxMessage(t, member.topics.Requests, GroupEnvelope{
+		Type: EnvelopeRequest, SenderID: "watcher", CorrelationID: "t1", Timestamp: time.Now(),
+		Payload: TaskRequestPayload{TaskID: "t1", Content: "do it", RequesterID: "watcher"},
+	}))
+	if msgBus.InboundSize() != 0 {
+		t.Fatal("task request from an observer must be dropped")
+	}
+	if n := observerAudits(t, tl, "watcher"); n != 1 {
+		t.Fatalf("expected dropped observer publish to be audited, got %d", n)
+	}
+	if err := member.SubmitDelegatedTask(ctx, DelegatedTaskRequest{TaskID: "t2", Content: "x", TargetAgentID: "watcher"}); err == nil {
+		t.Fatal("expected tasks addressed to an observer to be refused")
+	}
+
+	// An admin makes a regular member an observer, and lifts it again.
+	if _, err := founder.Administer(ctx, AdminActionObserve, "member", "", "monitoring only"); err != nil {
+		t.Fatalf("set_observer: %v", err)
+	}
+	router.handleMessage(inboxMessage(t, ext.ControlRoster, lastAdminEnvelope(t, &produced)))
+	if _, ok := member.AdminState().Observers["member"]; !ok || !member.Observer() {
+		t.Fatal("expected member to be an observer")
+	}
+	if err := member.SubmitTask(ctx, "t3", "x", "y"); !errors.Is(err, ErrObserverPublish) {
+		t.Fatalf("expected blocked publish, got %v", err)
+	}
+	if _, err := founder.Administer(ctx, AdminActionObserve, "founder", "", ""); err == nil {
+		t.Fatal("the founder cannot be made an observer")
+	}
+
+	if _, err := founder.Administer(ctx, AdminActionUnobserve, "member", "", ""); err != nil {
+		t.Fatalf("clear_observer: %v", err)
+	}
+	router.handleMessage(inboxMessage(t, ext.ControlRoster, lastAdminEnvelope(t, &produced)))
+	if member.Observer() {
+		t.Fatal("expected observer role lifted")
+	}
+	if err := member.SubmitTask(ctx, "t4", "x", "y"); err != nil {
+		t.Fatalf("expected publish after clear_observer, got %v", err)
+	}
+}
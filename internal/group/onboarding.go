@@ -61,7 +61,7 @@ func (m *Manager) Onboard(ctx context.Context) error {
 		},
 	}
 
-	if err := m.lfs.ProduceEnvelope(ctx, ext.ControlOnboarding, env); err != nil {
+	if err := m.publish(ctx, ext.ControlOnboarding, env); err != nil {
 		return fmt.Errorf("onboard request failed: %w", err)
 	}
 
@@ -129,7 +129,7 @@ func (m *Manager) handleOnboardRequest(env *GroupEnvelope, payload *OnboardPaylo
 				Challenge:   challenge,
 			},
 		}
-		if err := m.lfs.ProduceEnvelope(ctx, ext.ControlOnboarding, resp); err != nil {
+		if err := m.publish(ctx, ext.ControlOnboarding, resp); err != nil {
 			slog.Warn("Onboard challenge send failed", "error", err)
 		}
 		return
@@ -168,7 +168,7 @@ func (m *Manager) handleOnboardChallenge(env *GroupEnvelope, payload *OnboardPay
 			Response:    response,
 		},
 	}
-	if err := m.lfs.ProduceEnvelope(ctx, ext.ControlOnboarding, resp); err != nil {
+	if err := m.publish(ctx, ext.ControlOnboarding, resp); err != nil {
 		slog.Warn("Onboard response send failed", "error", err)
 	}
 }
@@ -215,6 +215,8 @@ func (m *Manager) handleOnboardComplete(env *GroupEnvelope, payload *OnboardPayl
 
 	// Now proceed with standard join
 	ctx := context.Background()
+	// Joining also fetches the tasks directed at us while we were offline,
+	// one heartbeat later.
 	if err := m.Join(ctx); err != nil {
 		slog.Warn("Post-onboard join failed", "error", err)
 	}
@@ -249,7 +251,7 @@ func (m *Manager) sendOnboardComplete(ctx context.Context, correlationID, reques
 			Manifest:    manifest,
 		},
 	}
-	if err := m.lfs.ProduceEnvelope(ctx, ext.ControlOnboarding, resp); err != nil {
+	if err := m.publish(ctx, ext.ControlOnboarding, resp); err != nil {
 		slog.Warn("Onboard complete send failed", "error", err)
 	}
 	slog.Info("Onboard complete sent", "to", requesterID)
@@ -270,7 +272,7 @@ func (m *Manager) sendOnboardReject(ctx context.Context, correlationID, requeste
 			Reason:      reason,
 		},
 	}
-	if err := m.lfs.ProduceEnvelope(ctx, ext.ControlOnboarding, resp); err != nil {
+	if err := m.publish(ctx, ext.ControlOnboarding, resp); err != nil {
 		slog.Warn("Onboard reject send failed", "error", err)
 	}
 }
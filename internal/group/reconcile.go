@@ -0,0 +1,161 @@
+package group
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// Membership history actions written by roster reconciliation.
+const (
+	ActionReconciledActive   = "reconciled_active"
+	ActionReconciledInactive = "reconciled_inactive"
+)
+
+// RosterReconcileResult lists the members whose state was changed by a
+// reconciliation run.
+type RosterReconcileResult struct {
+	Activated   []string `json:"activated"`
+	Deactivated []string `json:"deactivated"`
+}
+
+// ReconcileRoster compares the in-memory and DB rosters with the authoritative
+// roster on the LFS proxy. Members the proxy reports as active are added or
+// reactivated; members it reports as inactive or does not know are marked
+// inactive. Every change is recorded in the membership history. An empty
+// authoritative roster is treated as "no data" and deactivates nobody.
+func (m *Manager) ReconcileRoster(ctx context.Context) (*RosterReconcileResult, error) {
+	entries, err := m.lfs.FetchRoster(ctx, m.cfg.GroupName)
+	if err != nil {
+		return nil, err
+	}
+	res := &RosterReconcileResult{}
+	if len(entries) == 0 {
+		return res, nil
+	}
+
+	self := m.identity.AgentID
+	authoritative := make(map[string]RosterEntry, len(entries))
+	for _, e := range entries {
+		if e.AgentID == "" || e.AgentID == self {
+			continue
+		}
+		// Kicked members stay out even while the proxy still lists them.
+		if (e.Status == "" || e.Status == "active") && !m.isKicked(e.AgentID) {
+			authoritative[e.AgentID] = e
+		}
+	}
+
+	dbStatus := map[string]string{}
+	if m.timeline != nil {
+		if members, err := m.timeline.ListGroupMembers(); err == nil {
+			for _, rec := range members {
+				dbStatus[rec.AgentID] = rec.Status
+			}
+		}
+	}
+
+	m.rosterMu.Lock()
+	for id, e := range authoritative {
+		_, inMemory := m.roster[id]
+		if inMemory && (m.timeline == nil || dbStatus[id] == "active") {
+			continue
+		}
+		lastSeen := e.LastSeen
+		if lastSeen.IsZero() {
+			lastSeen = time.Now()
+		}
+		m.roster[id] = &GroupMember{
+			AgentID:      id,
+			AgentName:    e.AgentName,
+			SoulSummary:  e.SoulSummary,
+			Capabilities: e.Capabilities,
+			Channels:     e.Channels,
+			Model:        e.Model,
+			Role:         e.Role,
+			Status:       "active",
+			LastSeen:     lastSeen,
+		}
+		res.Activated = append(res.Activated, id)
+	}
+
+	stale := map[string]*GroupMember{}
+	for id, member := range m.roster {
+		if id != self {
+			if _, ok := authoritative[id]; !ok {
+				stale[id] = member
+				delete(m.roster, id)
+			}
+		}
+	}
+	m.rosterMu.Unlock()
+
+	for id, status := range dbStatus {
+		if id == self || status == "inactive" {
+			continue
+		}
+		if _, ok := authoritative[id]; !ok {
+			if _, ok := stale[id]; !ok {
+				stale[id] = nil
+			}
+		}
+	}
+	for id := range stale {
+		res.Deactivated = append(res.Deactivated, id)
+	}
+	sort.Strings(res.Activated)
+	sort.Strings(res.Deactivated)
+
+	if m.timeline == nil {
+		return res, nil
+	}
+	for _, id := range res.Activated {
+		e := authoritative[id]
+		caps, _ := json.Marshal(e.Capabilities)
+		chs, _ := json.Marshal(e.Channels)
+		_ = m.timeline.UpsertGroupMember(&timeline.GroupMemberRecord{
+			AgentID:      id,
+			AgentName:    e.AgentName,
+			SoulSummary:  e.SoulSummary,
+			Capabilities: string(caps),
+			Channels:     string(chs),
+			Model:        e.Model,
+			Status:       "active",
+		})
+		// Clears left_at for members that were soft-deleted after a missed rejoin.
+		_ = m.timeline.ReactivateGroupMember(id)
+		m.logReconcileHistory(ActionReconciledActive, id, e.AgentName, e.Role, string(caps), string(chs), e.Model)
+	}
+	for _, id := range res.Deactivated {
+		_ = m.timeline.SetGroupMemberStatus(id, "inactive")
+		var name, role, model string
+		caps, chs := "[]", "[]"
+		if member := stale[id]; member != nil {
+			name, role, model = member.AgentName, member.Role, member.Model
+			c, _ := json.Marshal(member.Capabilities)
+			ch, _ := json.Marshal(member.Channels)
+			caps, chs = string(c), string(ch)
+		}
+		m.logReconcileHistory(ActionReconciledInactive, id, name, role, caps, chs, model)
+	}
+	return res, nil
+}
+
+func (m *Manager) logReconcileHistory(action, agentID, agentName, role, caps, chs, model string) {
+	_ = m.timeline.LogMembershipHistory(&timeline.GroupMembershipHistoryRecord{
+		AgentID:       agentID,
+		GroupName:     m.cfg.GroupName,
+		Role:          role,
+		Action:        action,
+		LFSProxyURL:   m.cfg.LFSProxyURL,
+		KafkaBrokers:  m.cfg.KafkaBrokers,
+		ConsumerGroup: m.cfg.ConsumerGroup,
+		AgentName:     agentName,
+		Capabilities:  caps,
+		Channels:      chs,
+		Model:         model,
+	})
+}
@@ -0,0 +1,171 @@
+package group
+
+import (
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/metrics"
+)
+
+var traceSpansTotal = metrics.NewCounter("kafclaw_group_trace_spans_total", "Trace spans offered to the group traces topic, by span type and decision (published, sampled_out, rate_limited).", "span_type", "decision")
+
+// Sampling decisions.
+const (
+	SpanPublished   = "published"
+	SpanSampledOut  = "sampled_out"
+	SpanRateLimited = "rate_limited"
+)
+
+// TraceSampler decides which spans are published to the group traces topic.
+// Traces are sampled by a hash of the trace id, so every agent in a group
+// keeps or drops the same traces.
+type TraceSampler struct {
+	rate         float64
+	spanRates    map[string]float64
+	keepErrors   bool
+	maxPerMinute int
+	maxContent   int
+	reportEvery  time.Duration
+
+	mu          sync.Mutex
+	windowStart time.Time
+	windowCount int
+	counts      map[string]map[string]int64 // span type -> decision -> count
+	since       time.Time
+}
+
+// TraceSamplingReport summarizes the sampling decisions of a period.
+type TraceSamplingReport struct {
+	Since  time.Time                   `json:"since"`
+	Until  time.Time                   `json:"until"`
+	Rate   float64                     `json:"rate"`
+	Counts map[string]map[string]int64 `json:"counts"`
+}
+
+// NewTraceSampler creates a sampler from the group sampling config.
+func NewTraceSampler(cfg config.GroupTraceSamplingConfig) *TraceSampler {
+	s := &TraceSampler{
+		rate:         cfg.Rate,
+		spanRates:    make(map[string]float64, len(cfg.SpanTypeRates)),
+		keepErrors:   cfg.AlwaysSampleErrors == nil || *cfg.AlwaysSampleErrors,
+		maxPerMinute: cfg.MaxSpansPerMinute,
+		maxContent:   cfg.MaxContentChars,
+		reportEvery:  time.Duration(cfg.ReportIntervalSec) * time.Second,
+		counts:       map[string]map[string]int64{},
+	}
+	if s.rate <= 0 || s.rate > 1 {
+		s.rate = 1
+	}
+	for k, v := range cfg.SpanTypeRates {
+		s.spanRates[strings.ToUpper(strings.TrimSpace(k))] = clampRate(v)
+	}
+	if s.reportEvery <= 0 {
+		s.reportEvery = 5 * time.Minute
+	}
+	return s
+}
+
+func clampRate(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+// Sample decides whether p is published and returns the decision. Published
+// spans get their SampleRate set and content truncated to the configured
+// limit.
+func (s *TraceSampler) Sample(p *TracePayload, now time.Time) string {
+	spanType := strings.ToUpper(p.SpanType)
+	rate := s.rate
+	if r, ok := s.spanRates[spanType]; ok {
+		rate = r
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.since.IsZero() {
+		s.since = now
+	}
+	decision := SpanPublished
+	forced := p.Error && s.keepErrors
+	switch {
+	case forced:
+	case !traceSampled(p.TraceID, rate):
+		decision = SpanSampledOut
+	case s.maxPerMinute > 0:
+		if now.Sub(s.windowStart) >= time.Minute {
+			s.windowStart, s.windowCount = now, 0
+		}
+		if s.windowCount >= s.maxPerMinute {
+			decision = SpanRateLimited
+		}
+	}
+	if decision == SpanPublished {
+		s.windowCount++
+		p.SampleRate = rate
+		if forced {
+			p.SampleRate = 1
+		}
+		if s.maxContent > 0 && len(p.Content) > s.maxContent {
+			p.Content = truncateUTF8(p.Content, s.maxContent) + "…[truncated]"
+		}
+	}
+	if s.counts[spanType] == nil {
+		s.counts[spanType] = map[string]int64{}
+	}
+	s.counts[spanType][decision]++
+	traceSpansTotal.Inc(spanType, decision)
+	return decision
+}
+
+// Report returns the decisions since the last report once the report
+// interval has passed and some span was dropped, and starts a new period.
+// It returns false when there is nothing to report yet.
+func (s *TraceSampler) Report(now time.Time) (TraceSamplingReport, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.since.IsZero() || now.Sub(s.since) < s.reportEvery {
+		return TraceSamplingReport{}, false
+	}
+	dropped := false
+	for _, byDecision := range s.counts {
+		if byDecision[SpanSampledOut] > 0 || byDecision[SpanRateLimited] > 0 {
+			dropped = true
+		}
+	}
+	r := TraceSamplingReport{Since: s.since, Until: now, Rate: s.rate, Counts: s.counts}
+	s.counts = map[string]map[string]int64{}
+	s.since = now
+	return r, dropped
+}
+
+// traceSampled maps the trace id onto [0,1) and keeps it below rate.
+func traceSampled(traceID string, rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	if rate <= 0 {
+		return false
+	}
+	h := fnv.New32a()
+	h.Write([]byte(traceID))
+	return float64(h.Sum32())/float64(1<<32) < rate
+}
+
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,91 @@
+package group
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+func TestTraceSamplerDecidesPerTrace(t *testing.T) {
+	s := NewTraceSampler(config.GroupTraceSamplingConfig{Rate: 0.5})
+	now := time.Now()
+	kept := 0
+	for i := 0; i < 200; i++ {
+		traceID := fmt.Sprintf("trace-%d", i)
+		task := s.Sample(&TracePayload{TraceID: traceID, SpanType: "TASK"}, now)
+		tool := s.Sample(&TracePayload{TraceID: traceID, SpanType: "TOOL"}, now)
+		if task != tool {
+			t.Fatalf("spans of %s got different decisions: %s vs %s", traceID, task, tool)
+		}
+		if task == SpanPublished {
+			kept++
+		}
+	}
+	if kept < 60 || kept > 140 {
+		t.Fatalf("expected about half the traces kept, got %d/200", kept)
+	}
+
+	p := &TracePayload{TraceID: "x", SpanType: "TASK"}
+	for i := 0; i < 200 && s.Sample(p, now) == SpanPublished; i++ {
+		p = &TracePayload{TraceID: fmt.Sprintf("y-%d", i), SpanType: "TASK"}
+	}
+	p.Error = true
+	if d := s.Sample(p, now); d != SpanPublished || p.SampleRate != 1 {
+		t.Fatalf("failed span must be published with rate 1, got %s rate=%v", d, p.SampleRate)
+	}
+}
+
+func TestTraceSamplerSpanTypesCapAndTruncation(t *testing.T) {
+	keepErrors := false
+	s := NewTraceSampler(config.GroupTraceSamplingConfig{
+		SpanTypeRates:      map[string]float64{"llm": 0},
+		AlwaysSampleErrors: &keepErrors,
+		MaxSpansPerMinute:  2,
+		MaxContentChars:    4,
+	})
+	now := time.Now()
+	if d := s.Sample(&TracePayload{TraceID: "a", SpanType: "LLM", Error: true}, now); d != SpanSampledOut {
+		t.Fatalf("LLM spans must be dropped, got %s", d)
+	}
+	p := &TracePayload{TraceID: "a", SpanType: "TOOL", Content: "héllo world"}
+	if d := s.Sample(p, now); d != SpanPublished || p.Content != "hél…[truncated]" || p.SampleRate != 1 {
+		t.Fatalf("unexpected published span: %s %+v", d, p)
+	}
+	_ = s.Sample(&TracePayload{TraceID: "b", SpanType: "TOOL"}, now)
+	if d := s.Sample(&TracePayload{TraceID: "c", SpanType: "TOOL"}, now.Add(time.Second)); d != SpanRateLimited {
+		t.Fatalf("third span in a minute must be rate limited, got %s", d)
+	}
+	if d := s.Sample(&TracePayload{TraceID: "d", SpanType: "TOOL"}, now.Add(time.Minute)); d != SpanPublished {
+		t.Fatalf("cap must reset after a minute, got %s", d)
+	}
+}
+
+func TestTraceSamplerReport(t *testing.T) {
+	s := NewTraceSampler(config.GroupTraceSamplingConfig{
+		SpanTypeRates:     map[string]float64{"TOOL": 0},
+		ReportIntervalSec: 60,
+	})
+	now := time.Now()
+	s.Sample(&TracePayload{TraceID: "a", SpanType: "TASK"}, now)
+	if _, ok := s.Report(now.Add(2 * time.Minute)); ok {
+		t.Fatal("nothing dropped, nothing to report")
+	}
+
+	s.Sample(&TracePayload{TraceID: "a", SpanType: "TOOL"}, now.Add(3*time.Minute))
+	s.Sample(&TracePayload{TraceID: "a", SpanType: "TASK"}, now.Add(3*time.Minute))
+	if _, ok := s.Report(now.Add(150 * time.Second)); ok {
+		t.Fatal("report must wait for the interval")
+	}
+	r, ok := s.Report(now.Add(4 * time.Minute))
+	if !ok || r.Counts["TOOL"][SpanSampledOut] != 1 || r.Counts["TASK"][SpanPublished] != 1 {
+		t.Fatalf("unexpected report: %v %+v", ok, r)
+	}
+	if !r.Since.Equal(now.Add(2 * time.Minute)) {
+		t.Fatalf("report period must start at the previous report, got %v", r.Since)
+	}
+	if r, ok := s.Report(now.Add(10 * time.Minute)); ok || len(r.Counts) != 0 {
+		t.Fatal("counts must reset after a report")
+	}
+}
@@ -0,0 +1,218 @@
+package group
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// SealedTaskAlg names the addressed task encryption: an ephemeral X25519 key
+// agreement with the recipient's encryption key, HKDF-SHA256 and AES-256-GCM.
+const SealedTaskAlg = "x25519-hkdf-sha256-aes256gcm"
+
+// encryptionKeyInfo separates the encryption key derived from the identity
+// seed from any other use of the seed.
+const encryptionKeyInfo = "kafclaw group encryption key v1"
+
+// SealedPayload is the encrypted part of a task addressed to one agent.
+// Everything needed for routing stays in the clear on the request.
+type SealedPayload struct {
+	Alg string `json:"alg"`
+	// RecipientKey is the encryption key the payload was sealed to, so a
+	// recipient with a rotated key can tell why it cannot open it.
+	RecipientKey string `json:"recipient_key"`
+	EphemeralKey string `json:"ephemeral_key"`
+	Nonce        string `json:"nonce"`
+	Ciphertext   string `json:"ciphertext"`
+}
+
+// sealedTaskContent is the plaintext of a sealed task request.
+type sealedTaskContent struct {
+	Description string `json:"description"`
+	Content     string `json:"content"`
+}
+
+var errNoEncryptionKey = errors.New("no encryption key")
+
+// deriveEncryptionKey derives this agent's X25519 encryption key from its
+// identity seed, so it needs no storage of its own and lives as long as the
+// identity key.
+func deriveEncryptionKey(seed []byte) (*ecdh.PrivateKey, error) {
+	raw, err := hkdf.Key(sha256.New, seed, nil, encryptionKeyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	return ecdh.X25519().NewPrivateKey(raw)
+}
+
+// setEncryptionKey derives and advertises the encryption key for seed.
+func (m *Manager) setEncryptionKey(seed []byte) {
+	key, err := deriveEncryptionKey(seed)
+	if err != nil {
+		slog.Warn("Group encryption key derivation failed", "error", err)
+		return
+	}
+	m.encryptionKey = key
+	m.identity.EncryptionKey = base64.StdEncoding.EncodeToString(key.PublicKey().Bytes())
+}
+
+// sealedTaskAAD binds the ciphertext to the routing metadata, so a sealed
+// payload cannot be replayed under another task, requester or target.
+func sealedTaskAAD(p *TaskRequestPayload) []byte {
+	return []byte(p.TaskID + "\n" + p.RequesterID + "\n" + p.TargetAgentID)
+}
+
+// sealTaskRequest encrypts the description and content of p to
+// recipientKey (base64 X25519) and clears them from the payload.
+func sealTaskRequest(p *TaskRequestPayload, recipientKey string) error {
+	pubBytes, err := base64.StdEncoding.DecodeString(recipientKey)
+	if err != nil {
+		return fmt.Errorf("decode recipient key: %w", err)
+	}
+	recipient, err := ecdh.X25519().NewPublicKey(pubBytes)
+	if err != nil {
+		return fmt.Errorf("recipient key: %w", err)
+	}
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	shared, err := ephemeral.ECDH(recipient)
+	if err != nil {
+		return err
+	}
+	aead, err := sealedTaskAEAD(shared, ephemeral.PublicKey().Bytes(), pubBytes)
+	if err != nil {
+		return err
+	}
+	plain, err := json.Marshal(sealedTaskContent{Description: p.Description, Content: p.Content})
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	p.Sealed = &SealedPayload{
+		Alg:          SealedTaskAlg,
+		RecipientKey: recipientKey,
+		EphemeralKey: base64.StdEncoding.EncodeToString(ephemeral.PublicKey().Bytes()),
+		Nonce:        base64.StdEncoding.EncodeToString(nonce),
+		Ciphertext:   base64.StdEncoding.EncodeToString(aead.Seal(nil, nonce, plain, sealedTaskAAD(p))),
+	}
+	p.Description = ""
+	p.Content = ""
+	return nil
+}
+
+// openTaskRequest decrypts a sealed task request with key and restores its
+// description and content.
+func openTaskRequest(p *TaskRequestPayload, key *ecdh.PrivateKey) error {
+	s := p.Sealed
+	if s == nil {
+		return nil
+	}
+	if key == nil {
+		return errNoEncryptionKey
+	}
+	if s.Alg != SealedTaskAlg {
+		return fmt.Errorf("unsupported algorithm %q", s.Alg)
+	}
+	own := key.PublicKey().Bytes()
+	if s.RecipientKey != base64.StdEncoding.EncodeToString(own) {
+		return fmt.Errorf("sealed to another encryption key")
+	}
+	ephBytes, err1 := base64.StdEncoding.DecodeString(s.EphemeralKey)
+	nonce, err2 := base64.StdEncoding.DecodeString(s.Nonce)
+	ciphertext, err3 := base64.StdEncoding.DecodeString(s.Ciphertext)
+	if err := errors.Join(err1, err2, err3); err != nil {
+		return fmt.Errorf("decode sealed payload: %w", err)
+	}
+	ephemeral, err := ecdh.X25519().NewPublicKey(ephBytes)
+	if err != nil {
+		return fmt.Errorf("ephemeral key: %w", err)
+	}
+	shared, err := key.ECDH(ephemeral)
+	if err != nil {
+		return err
+	}
+	aead, err := sealedTaskAEAD(shared, ephBytes, own)
+	if err != nil {
+		return err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return fmt.Errorf("invalid nonce")
+	}
+	plain, err := aead.Open(nil, nonce, ciphertext, sealedTaskAAD(p))
+	if err != nil {
+		return fmt.Errorf("decrypt: %w", err)
+	}
+	var content sealedTaskContent
+	if err := json.Unmarshal(plain, &content); err != nil {
+		return fmt.Errorf("decode sealed content: %w", err)
+	}
+	p.Description = content.Description
+	p.Content = content.Content
+	p.Sealed = nil
+	return nil
+}
+
+// sealedTaskAEAD derives the AES-256-GCM key from the X25519 shared secret,
+// bound to both public keys.
+func sealedTaskAEAD(shared, ephemeralPub, recipientPub []byte) (cipher.AEAD, error) {
+	salt := append(append([]byte{}, ephemeralPub...), recipientPub...)
+	key, err := hkdf.Key(sha256.New, shared, salt, SealedTaskAlg, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// openTask decrypts a task request sealed to this agent. Requests that are
+// not sealed are left unchanged.
+func (m *Manager) openTask(p *TaskRequestPayload) error {
+	return openTaskRequest(p, m.encryptionKey)
+}
+
+// memberEncryptionKey returns the advertised encryption key of agentID.
+func (m *Manager) memberEncryptionKey(agentID string) string {
+	m.rosterMu.RLock()
+	defer m.rosterMu.RUnlock()
+	if member, ok := m.roster[agentID]; ok {
+		return member.EncryptionKey
+	}
+	return ""
+}
+
+// sealForTarget encrypts a directed task for its target when the target
+// advertises an encryption key. Without one the task is sent readable,
+// unless group.requireEncryptedDirectTasks is set.
+func (m *Manager) sealForTarget(p *TaskRequestPayload) error {
+	if p.TargetAgentID == "" {
+		return nil
+	}
+	key := m.memberEncryptionKey(p.TargetAgentID)
+	if key == "" {
+		if m.cfg.RequireEncryptedDirectTasks {
+			return fmt.Errorf("agent %s advertises no encryption key", p.TargetAgentID)
+		}
+		slog.Warn("Directed task sent unencrypted: target advertises no encryption key", "task_id", p.TaskID, "target", p.TargetAgentID)
+		return nil
+	}
+	if err := sealTaskRequest(p, key); err != nil {
+		return fmt.Errorf("seal task for %s: %w", p.TargetAgentID, err)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package group
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+)
+
+func lastEnvelope(t *testing.T, produced *producedStore, typ string) GroupEnvelope {
+	t.Helper()
+	items := produced.snapshot()
+	for i := len(items) - 1; i >= 0; i-- {
+		if items[i].Type == typ {
+			return items[i]
+		}
+	}
+	t.Fatalf("no %s envelope produced", typ)
+	return GroupEnvelope{}
+}
+
+func TestSealed_DirectTaskReadableByTargetOnly(t *testing.T) {
+	var produced producedStore
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var env GroupEnvelope
+		_ = json.NewDecoder(r.Body).Decode(&env)
+		produced.append(env)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(LFSEnvelope{KfsLFS: 1})
+	}))
+	defer server.Close()
+	ctx := context.Background()
+	ext := ExtendedTopics("test-group")
+
+	requester := newTestManagerForOnboard(server.URL, "requester", "open")
+	worker := newTestManagerForOnboard(server.URL, "worker", "open")
+	bystander := newTestManagerForOnboard(server.URL, "bystander", "open")
+	for _, m := range []*Manager{requester, worker, bystander} {
+		if err := m.Join(ctx); err != nil {
+			t.Fatalf("join %s: %v", m.identity.AgentID, err)
+		}
+	}
+	if worker.identity.EncryptionKey == "" {
+		t.Fatal("expected an encryption key after join")
+	}
+	requester.HandleAnnounce(func() *GroupEnvelope {
+		env := lastAnnounceFrom(t, &produced, "worker")
+		return &env
+	}())
+
+	err := requester.SubmitTaskWithOptions(ctx, "task-sealed", "quarterly numbers", "the secret plan", TaskOptions{TargetAgentID: "worker"})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	env := lastEnvelope(t, &produced, EnvelopeRequest)
+	raw, _ := json.Marshal(env)
+	if strings.Contains(string(raw), "secret plan") || strings.Contains(string(raw), "quarterly") {
+		t.Fatalf("directed task payload travels readable: %s", raw)
+	}
+	if !strings.Contains(string(raw), `"target_agent_id":"worker"`) || !strings.Contains(string(raw), `"task_id":"task-sealed"`) {
+		t.Fatalf("routing metadata must stay readable: %s", raw)
+	}
+
+	// Another member cannot route it; the target reads the plaintext.
+	bystanderBus := bus.NewMessageBus()
+	NewGroupRouter(bystander, bystanderBus, NewChannelConsumer()).handleMessage(inboxMessage(t, ext.TaskRequests, env))
+	if bystanderBus.InboundSize() != 0 {
+		t.Fatal("bystander must not route a task addressed to another agent")
+	}
+	workerBus := bus.NewMessageBus()
+	NewGroupRouter(worker, workerBus, NewChannelConsumer()).handleMessage(inboxMessage(t, ext.TaskRequests, env))
+	cctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	in, err := workerBus.ConsumeInbound(cctx)
+	if err != nil {
+		t.Fatalf("expected task on the worker bus: %v", err)
+	}
+	if in.Content != "the secret plan" || in.Metadata["description"] != "quarterly numbers" {
+		t.Fatalf("unexpected inbound: %+v", in)
+	}
+
+	// A sealed payload moved to another task ID does not open; the requester
+	// learns the task failed.
+	var moved TaskRequestPayload
+	data, _ := json.Marshal(env.Payload)
+	_ = json.Unmarshal(data, &moved)
+	moved.TaskID = "task-moved"
+	NewGroupRouter(worker, workerBus, NewChannelConsumer()).handleMessage(inboxMessage(t, ext.TaskRequests, GroupEnvelope{
+		Type: EnvelopeRequest, CorrelationID: "task-moved", SenderID: "requester", Timestamp: time.Now(), Payload: moved,
+	}))
+	if workerBus.InboundSize() != 0 {
+		t.Fatal("tampered sealed task must not be routed")
+	}
+	status := lastEnvelope(t, &produced, EnvelopeTaskStatus)
+	if p, _ := status.Payload.(map[string]any); p["task_id"] != "task-moved" || p["status"] != "failed" {
+		t.Fatalf("expected failed status for the tampered task, got %+v", status.Payload)
+	}
+}
+
+func lastAnnounceFrom(t *testing.T, produced *producedStore, agentID string) GroupEnvelope {
+	t.Helper()
+	items := produced.snapshot()
+	for i := len(items) - 1; i >= 0; i-- {
+		if items[i].Type == EnvelopeAnnounce && items[i].SenderID == agentID {
+			return items[i]
+		}
+	}
+	t.Fatalf("no announce from %s", agentID)
+	return GroupEnvelope{}
+}
+
+func TestSealed_KeyOnlyFromSignedAnnounceAndRequiredMode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(LFSEnvelope{KfsLFS: 1})
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	requester := newTestManagerForOnboard(server.URL, "requester", "open")
+	if err := requester.Join(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// An unsigned announce cannot plant a key tasks would be sealed to.
+	requester.HandleAnnounce(&GroupEnvelope{
+		Type: EnvelopeAnnounce, SenderID: "legacy", Timestamp: time.Now(),
+		Payload: AnnouncePayload{Action: "join", Identity: AgentIdentity{AgentID: "legacy", EncryptionKey: requester.identity.EncryptionKey}},
+	})
+	if key := requester.memberEncryptionKey("legacy"); key != "" {
+		t.Fatalf("expected no encryption key from an unsigned announce, got %q", key)
+	}
+
+	req := DelegatedTaskRequest{TaskID: "task-legacy", Content: "hello", TargetAgentID: "legacy"}
+	if err := requester.SubmitDelegatedTask(ctx, req); err != nil {
+		t.Fatalf("readable fallback expected by default: %v", err)
+	}
+	requester.cfg.RequireEncryptedDirectTasks = true
+	req.TaskID = "task-legacy-2"
+	if err := requester.SubmitDelegatedTask(ctx, req); err == nil || !strings.Contains(err.Error(), "no encryption key") {
+		t.Fatalf("expected refusal without a target key, got %v", err)
+	}
+}
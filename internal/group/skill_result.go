@@ -0,0 +1,166 @@
+package group
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+// Skill result statuses.
+const (
+	SkillStatusSucceeded = "succeeded"
+	SkillStatusFailed    = "failed"
+	SkillStatusRejected  = "rejected"
+)
+
+// Skill error classes. SkillErrorInvalidResult is assigned by the requester
+// when a response carries no valid envelope; executors never return it.
+const (
+	SkillErrorTransient     = "transient"
+	SkillErrorTimeout       = "timeout"
+	SkillErrorInvalidInput  = "invalid_input"
+	SkillErrorPermission    = "permission"
+	SkillErrorInternal      = "internal"
+	SkillErrorInvalidResult = "invalid_result"
+)
+
+// Actions the requester takes on a skill result.
+const (
+	SkillActionAccept   = "accept"
+	SkillActionRetry    = "retry"
+	SkillActionEscalate = "escalate"
+)
+
+// Defaults for skills without a configured failure policy.
+const defaultSkillMaxRetries = 2
+
+// maxSkillAttempts bounds resubmissions across escalation chains.
+const maxSkillAttempts = 10
+
+var defaultSkillRetryOn = []string{SkillErrorTransient, SkillErrorTimeout}
+
+// SkillArtifact is a file or object produced by a skill task.
+type SkillArtifact struct {
+	Name        string `json:"name"`
+	URI         string `json:"uri"`
+	ContentType string `json:"content_type,omitempty"`
+	SizeBytes   int64  `json:"size_bytes,omitempty"`
+}
+
+// SkillError classifies a failed or rejected skill task.
+type SkillError struct {
+	Class   string `json:"class"`
+	Message string `json:"message"`
+}
+
+// SkillResult is the envelope every skill executor returns. Outputs holds
+// named values; "text" is used as the human-readable answer.
+type SkillResult struct {
+	Status     string          `json:"status"`
+	Outputs    map[string]any  `json:"outputs,omitempty"`
+	Artifacts  []SkillArtifact `json:"artifacts,omitempty"`
+	Error      *SkillError     `json:"error,omitempty"`
+	DurationMs int64           `json:"duration_ms"`
+	CostUSD    float64         `json:"cost_usd,omitempty"`
+}
+
+func isSkillErrorClass(class string) bool {
+	switch class {
+	case SkillErrorTransient, SkillErrorTimeout, SkillErrorInvalidInput,
+		SkillErrorPermission, SkillErrorInternal, SkillErrorInvalidResult:
+		return true
+	}
+	return false
+}
+
+// Validate checks the envelope contract: a known status, an error with a
+// known class exactly when the task did not succeed, well-formed artifacts
+// and non-negative duration and cost.
+func (r *SkillResult) Validate() error {
+	switch r.Status {
+	case SkillStatusSucceeded:
+		if r.Error != nil {
+			return fmt.Errorf("succeeded result must not carry an error")
+		}
+	case SkillStatusFailed, SkillStatusRejected:
+		if r.Error == nil {
+			return fmt.Errorf("%s result requires an error", r.Status)
+		}
+		if !isSkillErrorClass(r.Error.Class) {
+			return fmt.Errorf("unknown error class %q", r.Error.Class)
+		}
+	default:
+		return fmt.Errorf("unknown status %q", r.Status)
+	}
+	for i, a := range r.Artifacts {
+		if strings.TrimSpace(a.Name) == "" || strings.TrimSpace(a.URI) == "" {
+			return fmt.Errorf("artifact %d requires name and uri", i)
+		}
+	}
+	if r.DurationMs < 0 || r.CostUSD < 0 {
+		return fmt.Errorf("duration and cost must not be negative")
+	}
+	return nil
+}
+
+// Summary returns the human-readable form of a result: the "text" output,
+// else the outputs as JSON, or the classified error.
+func (r *SkillResult) Summary() string {
+	if r.Error != nil {
+		return fmt.Sprintf("%s: %s", r.Error.Class, r.Error.Message)
+	}
+	if text, ok := r.Outputs["text"].(string); ok {
+		return text
+	}
+	if len(r.Outputs) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(r.Outputs)
+	return string(data)
+}
+
+// invalidSkillResult replaces a response that breaks the envelope contract.
+func invalidSkillResult(reason string) *SkillResult {
+	return &SkillResult{
+		Status: SkillStatusFailed,
+		Error:  &SkillError{Class: SkillErrorInvalidResult, Message: reason},
+	}
+}
+
+// skillPolicyFor returns the failure policy of a skill: its own entry, else
+// the "*" entry, else the defaults. An entry without RetryOn retries the
+// default classes (transient, timeout).
+func skillPolicyFor(cfg config.GroupConfig, skill string) config.GroupSkillPolicy {
+	policy, ok := cfg.SkillPolicies[skill]
+	if !ok {
+		policy, ok = cfg.SkillPolicies["*"]
+	}
+	if !ok {
+		policy = config.GroupSkillPolicy{MaxRetries: defaultSkillMaxRetries}
+	}
+	if len(policy.RetryOn) == 0 {
+		policy.RetryOn = defaultSkillRetryOn
+	}
+	if policy.MaxRetries < 0 {
+		policy.MaxRetries = 0
+	}
+	return policy
+}
+
+// decideSkillAction maps a result to an action given the resubmissions made
+// so far.
+func decideSkillAction(policy config.GroupSkillPolicy, result *SkillResult, attempts int) string {
+	if result.Status == SkillStatusSucceeded {
+		return SkillActionAccept
+	}
+	if attempts < policy.MaxRetries {
+		for _, class := range policy.RetryOn {
+			if strings.EqualFold(class, result.Error.Class) {
+				return SkillActionRetry
+			}
+		}
+	}
+	return SkillActionEscalate
+}
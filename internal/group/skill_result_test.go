@@ -0,0 +1,191 @@
+package group
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestSkillResultValidate(t *testing.T) {
+	cases := []struct {
+		name   string
+		result SkillResult
+		ok     bool
+	}{
+		{"succeeded", SkillResult{Status: SkillStatusSucceeded, Outputs: map[string]any{"text": "done"}, DurationMs: 12}, true},
+		{"failed", SkillResult{Status: SkillStatusFailed, Error: &SkillError{Class: SkillErrorTimeout, Message: "slow"}}, true},
+		{"unknown status", SkillResult{Status: "done"}, false},
+		{"succeeded with error", SkillResult{Status: SkillStatusSucceeded, Error: &SkillError{Class: SkillErrorInternal}}, false},
+		{"failed without error", SkillResult{Status: SkillStatusFailed}, false},
+		{"unknown class", SkillResult{Status: SkillStatusRejected, Error: &SkillError{Class: "oops"}}, false},
+		{"artifact without uri", SkillResult{Status: SkillStatusSucceeded, Artifacts: []SkillArtifact{{Name: "a"}}}, false},
+		{"negative cost", SkillResult{Status: SkillStatusSucceeded, CostUSD: -1}, false},
+	}
+	for _, tc := range cases {
+		if err := tc.result.Validate(); (err == nil) != tc.ok {
+			t.Errorf("%s: Validate() = %v, want ok=%v", tc.name, err, tc.ok)
+		}
+	}
+}
+
+func TestDecideSkillAction(t *testing.T) {
+	cfg := config.GroupConfig{SkillPolicies: map[string]config.GroupSkillPolicy{
+		"sql": {MaxRetries: 1, RetryOn: []string{SkillErrorInvalidResult}, EscalateSkill: "dba"},
+	}}
+	failed := func(class string) *SkillResult {
+		return &SkillResult{Status: SkillStatusFailed, Error: &SkillError{Class: class}}
+	}
+
+	def := skillPolicyFor(cfg, "other")
+	if def.MaxRetries != defaultSkillMaxRetries || len(def.RetryOn) != 2 {
+		t.Fatalf("unexpected default policy: %+v", def)
+	}
+	if got := decideSkillAction(def, failed(SkillErrorTransient), 1); got != SkillActionRetry {
+		t.Fatalf("transient failure: got %s", got)
+	}
+	if got := decideSkillAction(def, failed(SkillErrorTransient), 2); got != SkillActionEscalate {
+		t.Fatalf("retries exhausted: got %s", got)
+	}
+	if got := decideSkillAction(def, failed(SkillErrorInvalidInput), 0); got != SkillActionEscalate {
+		t.Fatalf("invalid input: got %s", got)
+	}
+
+	sql := skillPolicyFor(cfg, "sql")
+	if got := decideSkillAction(sql, failed(SkillErrorInvalidResult), 0); got != SkillActionRetry {
+		t.Fatalf("sql invalid result: got %s", got)
+	}
+	if got := decideSkillAction(sql, failed(SkillErrorTimeout), 0); got != SkillActionEscalate {
+		t.Fatalf("sql timeout: got %s", got)
+	}
+	if got := decideSkillAction(sql, &SkillResult{Status: SkillStatusSucceeded}, 5); got != SkillActionAccept {
+		t.Fatalf("success: got %s", got)
+	}
+}
+
+func TestGroupRouter_SkillResponseRetryAndEscalate(t *testing.T) {
+	var produced producedStore
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var env GroupEnvelope
+		_ = json.NewDecoder(r.Body).Decode(&env)
+		produced.append(env)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(LFSEnvelope{KfsLFS: 1})
+	}))
+	defer server.Close()
+
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tl.Close()
+
+	mgr := newTestManagerForOnboard(server.URL, "requester", "open")
+	mgr.timeline = tl
+	mgr.cfg.SkillPolicies = map[string]config.GroupSkillPolicy{
+		"sql": {MaxRetries: 1, EscalateSkill: "dba"},
+	}
+	if err := mgr.Join(context.Background()); err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	msgBus := bus.NewMessageBus()
+	router := NewGroupRouter(mgr, msgBus, NewChannelConsumer())
+
+	if err := mgr.SubmitSkillTask(context.Background(), "task-1", "sql", "report", "count rows"); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	respond := func(skill string, payload TaskResponsePayload) {
+		_, respTopic := SkillTopics("test-group", skill)
+		router.handleMessage(inboxMessage(t, respTopic, GroupEnvelope{
+			Type:          EnvelopeSkillResponse,
+			CorrelationID: "task-1",
+			SenderID:      "worker",
+			Timestamp:     time.Now(),
+			Payload:       payload,
+		}))
+	}
+
+	// A response without a result envelope is invalid; invalid results are
+	// not in the default retry classes, so the task escalates right away.
+	respond("sql", TaskResponsePayload{TaskID: "task-1", ResponderID: "worker", Content: "42", Status: "completed"})
+	rec, err := tl.GetGroupTask("task-1")
+	if err != nil || rec == nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if rec.SkillName != "dba" || rec.Attempts != 1 || rec.Status != "pending" {
+		t.Fatalf("expected escalation to dba, got %+v", rec)
+	}
+	msg, err := msgBus.ConsumeInbound(context.Background())
+	if err != nil || !strings.Contains(msg.Content, "invalid_result") || !strings.Contains(msg.Content, "to skill dba") {
+		t.Fatalf("unexpected escalation message: %+v (err=%v)", msg, err)
+	}
+
+	// A transient failure of the escalation target is retried silently.
+	respond("dba", TaskResponsePayload{TaskID: "task-1", ResponderID: "worker", Status: SkillStatusFailed,
+		Result: &SkillResult{Status: SkillStatusFailed, Error: &SkillError{Class: SkillErrorTransient, Message: "busy"}}})
+	rec, _ = tl.GetGroupTask("task-1")
+	if rec.Attempts != 2 || rec.Status != "pending" {
+		t.Fatalf("expected retry, got %+v", rec)
+	}
+	var lastReq TaskRequestPayload
+	for _, env := range produced.snapshot() {
+		if env.Type == EnvelopeSkillRequest {
+			data, _ := json.Marshal(env.Payload)
+			_ = json.Unmarshal(data, &lastReq)
+		}
+	}
+	if lastReq.Attempt != 2 || lastReq.Content != "count rows" {
+		t.Fatalf("unexpected resubmission: %+v", lastReq)
+	}
+
+	respond("dba", TaskResponsePayload{TaskID: "task-1", ResponderID: "worker", Status: SkillStatusSucceeded,
+		Result: &SkillResult{Status: SkillStatusSucceeded, Outputs: map[string]any{"text": "42"}, DurationMs: 30}})
+	rec, _ = tl.GetGroupTask("task-1")
+	if rec.Status != "completed" || rec.ResponseContent != "42" || !strings.Contains(string(rec.Result), `"duration_ms":30`) {
+		t.Fatalf("expected completed task with result, got %+v", rec)
+	}
+}
+
+func TestRespondSkillReply(t *testing.T) {
+	var produced producedStore
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var env GroupEnvelope
+		_ = json.NewDecoder(r.Body).Decode(&env)
+		produced.append(env)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(LFSEnvelope{KfsLFS: 1})
+	}))
+	defer server.Close()
+
+	mgr := newTestManagerForOnboard(server.URL, "worker", "open")
+	if err := mgr.Join(context.Background()); err != nil {
+		t.Fatalf("join: %v", err)
+	}
+	if ok, _ := mgr.RespondSkillReply(context.Background(), "plain-task", "hi"); ok {
+		t.Fatal("expected non-skill task to be left to RespondTask")
+	}
+
+	mgr.trackSkillRequest("task-1", "sql")
+	ok, err := mgr.RespondSkillReply(context.Background(), "task-1", "Error: provider down")
+	if !ok || err != nil {
+		t.Fatalf("RespondSkillReply = %v, %v", ok, err)
+	}
+	var resp TaskResponsePayload
+	for _, env := range produced.snapshot() {
+		if env.Type == EnvelopeSkillResponse {
+			data, _ := json.Marshal(env.Payload)
+			_ = json.Unmarshal(data, &resp)
+		}
+	}
+	if resp.Result == nil || resp.Status != SkillStatusFailed || resp.Result.Error.Class != SkillErrorInternal {
+		t.Fatalf("unexpected skill response: %+v", resp)
+	}
+}
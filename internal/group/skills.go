@@ -2,13 +2,21 @@ package group
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/KafClaw/KafClaw/internal/timeline"
 )
 
+// skillRequest is an inbound skill task awaiting the local agent's reply.
+type skillRequest struct {
+	skill      string
+	receivedAt time.Time
+}
+
 // RegisterSkill creates a skill channel pair and updates the topic manifest.
 // It also dynamically subscribes the consumer to the new topics if provided.
 func (m *Manager) RegisterSkill(ctx context.Context, skillName string, consumer Consumer) error {
@@ -59,12 +67,28 @@ func (m *Manager) RegisterSkill(ctx context.Context, skillName string, consumer
 	return nil
 }
 
-// SubmitSkillTask sends a task request to a specific skill channel.
+// SubmitSkillTask sends a task request to a specific skill channel and
+// records it as an outgoing group task, so the structured response can be
+// validated and retried or escalated per the skill's failure policy.
 func (m *Manager) SubmitSkillTask(ctx context.Context, taskID, skillName, description, content string) error {
 	if !m.Active() {
 		return fmt.Errorf("not in a group")
 	}
+	if m.timeline != nil {
+		_ = m.timeline.InsertGroupTask(&timeline.GroupTaskRecord{
+			TaskID:      taskID,
+			Description: description,
+			Content:     content,
+			Direction:   "outgoing",
+			RequesterID: m.identity.AgentID,
+			Status:      "pending",
+			SkillName:   skillName,
+		})
+	}
+	return m.produceSkillRequest(ctx, taskID, skillName, description, content, 0)
+}
 
+func (m *Manager) produceSkillRequest(ctx context.Context, taskID, skillName, description, content string, attempt int) error {
 	reqTopic, _ := SkillTopics(m.cfg.GroupName, skillName)
 
 	env := &GroupEnvelope{
@@ -77,16 +101,24 @@ func (m *Manager) SubmitSkillTask(ctx context.Context, taskID, skillName, descri
 			Description: description,
 			Content:     content,
 			RequesterID: m.identity.AgentID,
+			Attempt:     attempt,
 		},
 	}
-	return m.lfs.ProduceEnvelope(ctx, reqTopic, env)
+	return m.publish(ctx, reqTopic, env)
 }
 
-// RespondSkillTask sends a task response to a specific skill channel.
-func (m *Manager) RespondSkillTask(ctx context.Context, taskID, skillName, content, status string) error {
+// RespondSkillTask sends the result envelope of a skill task to the skill's
+// response channel. Results that break the envelope contract are rejected.
+func (m *Manager) RespondSkillTask(ctx context.Context, taskID, skillName string, result *SkillResult) error {
 	if !m.Active() {
 		return fmt.Errorf("not in a group")
 	}
+	if result == nil {
+		return fmt.Errorf("skill result required")
+	}
+	if err := result.Validate(); err != nil {
+		return fmt.Errorf("invalid skill result: %w", err)
+	}
 
 	_, respTopic := SkillTopics(m.cfg.GroupName, skillName)
 
@@ -98,11 +130,107 @@ func (m *Manager) RespondSkillTask(ctx context.Context, taskID, skillName, conte
 		Payload: TaskResponsePayload{
 			TaskID:      taskID,
 			ResponderID: m.identity.AgentID,
-			Content:     content,
-			Status:      status,
+			Content:     result.Summary(),
+			Status:      result.Status,
+			Result:      result,
 		},
 	}
-	return m.lfs.ProduceEnvelope(ctx, respTopic, env)
+	return m.publish(ctx, respTopic, env)
+}
+
+// trackSkillRequest remembers an inbound skill task until the local agent
+// answers it (see RespondSkillReply).
+func (m *Manager) trackSkillRequest(taskID, skillName string) {
+	m.skillMu.Lock()
+	defer m.skillMu.Unlock()
+	if m.skillReqs == nil {
+		m.skillReqs = map[string]skillRequest{}
+	}
+	m.skillReqs[taskID] = skillRequest{skill: skillName, receivedAt: time.Now()}
+}
+
+// RespondSkillReply answers a pending inbound skill task with the local
+// agent's reply, wrapped in a result envelope. The agent loop reports
+// processing failures as "Error: ..." replies; those become internal errors.
+// It reports false when taskID is not a pending skill task.
+func (m *Manager) RespondSkillReply(ctx context.Context, taskID, reply string) (bool, error) {
+	m.skillMu.Lock()
+	req, ok := m.skillReqs[taskID]
+	delete(m.skillReqs, taskID)
+	m.skillMu.Unlock()
+	if !ok {
+		return false, nil
+	}
+	result := &SkillResult{
+		Status:     SkillStatusSucceeded,
+		Outputs:    map[string]any{"text": reply},
+		DurationMs: time.Since(req.receivedAt).Milliseconds(),
+	}
+	if msg, failed := strings.CutPrefix(reply, "Error: "); failed {
+		result.Status = SkillStatusFailed
+		result.Outputs = nil
+		result.Error = &SkillError{Class: SkillErrorInternal, Message: msg}
+	}
+	return true, m.RespondSkillTask(ctx, taskID, req.skill, result)
+}
+
+// SkillOutcome is how the requester handled a skill task response.
+type SkillOutcome struct {
+	Result    *SkillResult
+	Action    string // accept, retry or escalate
+	Attempts  int    // resubmissions so far
+	NextSkill string // skill the task was resubmitted to, if any
+}
+
+// HandleSkillResponse validates a skill task response and applies the
+// skill's failure policy to tasks this agent requested: failures are
+// resubmitted or escalated. Responses to other agents' tasks are only
+// validated.
+func (m *Manager) HandleSkillResponse(ctx context.Context, skillName string, payload TaskResponsePayload) SkillOutcome {
+	result := payload.Result
+	if result == nil {
+		result = invalidSkillResult("response carries no result envelope")
+	} else if err := result.Validate(); err != nil {
+		result = invalidSkillResult(err.Error())
+	}
+	out := SkillOutcome{Result: result, Action: SkillActionAccept}
+
+	if m.timeline == nil {
+		return out
+	}
+	rec, err := m.timeline.GetGroupTask(payload.TaskID)
+	if err != nil || rec == nil || rec.Direction != "outgoing" || rec.RequesterID != m.identity.AgentID {
+		return out
+	}
+	policy := skillPolicyFor(m.cfg, skillName)
+	out.Attempts = rec.Attempts
+	out.Action = decideSkillAction(policy, result, rec.Attempts)
+
+	status := "completed"
+	switch out.Action {
+	case SkillActionRetry:
+		status = "retrying"
+		out.NextSkill = skillName
+	case SkillActionEscalate:
+		status = "escalated"
+		if next := strings.TrimSpace(policy.EscalateSkill); next != "" && next != skillName && rec.Attempts < maxSkillAttempts {
+			out.NextSkill = next
+		}
+	}
+	data, _ := json.Marshal(result)
+	_ = m.timeline.UpdateGroupTaskResult(payload.TaskID, payload.ResponderID, result.Summary(), status, string(data))
+
+	if out.NextSkill != "" {
+		attempts, err := m.timeline.RetryGroupTask(payload.TaskID, out.NextSkill)
+		if err == nil {
+			out.Attempts = attempts
+			err = m.produceSkillRequest(ctx, payload.TaskID, out.NextSkill, rec.Description, rec.Content, attempts)
+		}
+		if err != nil {
+			slog.Warn("Skill task resubmission failed", "task_id", payload.TaskID, "skill", out.NextSkill, "error", err)
+		}
+	}
+	return out
 }
 
 // publishManifest publishes the current topic manifest to the roster topic.
@@ -119,7 +247,7 @@ func (m *Manager) publishManifest(ctx context.Context) error {
 		Timestamp:     time.Now(),
 		Payload:       manifest,
 	}
-	return m.lfs.ProduceEnvelope(ctx, m.extTopics.ControlRoster, env)
+	return m.publish(ctx, m.extTopics.ControlRoster, env)
 }
 
 // publishAudit sends an audit event to the observe.audit topic.
@@ -137,7 +265,7 @@ func (m *Manager) publishAudit(ctx context.Context, eventType string, details ma
 		Timestamp:     time.Now(),
 		Payload:       details,
 	}
-	if err := m.lfs.ProduceEnvelope(ctx, m.extTopics.ObserveAudit, env); err != nil {
+	if err := m.publish(ctx, m.extTopics.ObserveAudit, env); err != nil {
 		slog.Debug("Audit publish failed", "error", err)
 	}
 }
@@ -20,6 +20,21 @@ type AgentIdentity struct {
 	ZoneID       string   `json:"zone_id,omitempty"`
 	Endpoint     string   `json:"endpoint,omitempty"`
 	Role         string   `json:"role,omitempty"` // "orchestrator", "worker", "observer"
+	// Taxonomy is the typed, versioned form of Capabilities, Channels and
+	// Model (see BuildCapabilityTaxonomy). Older agents omit it.
+	Taxonomy []Capability `json:"taxonomy,omitempty"`
+	// AdminKey is the agent's ed25519 public key (base64) used to verify the
+	// administrative envelopes it signs once it is founder or admin.
+	AdminKey string `json:"admin_key,omitempty"`
+	// FoundedAt is set by the group founder only (RFC3339).
+	FoundedAt string `json:"founded_at,omitempty"`
+	// IdentityKey is the agent's ed25519 public key (base64), created on its
+	// first join and kept across rejoins. Members pin it to the agent ID and
+	// verify the agent's signed announces with it.
+	IdentityKey string `json:"identity_key,omitempty"`
+	// EncryptionKey is the agent's X25519 public key (base64), derived from
+	// its identity key. Tasks addressed to the agent are sealed to it.
+	EncryptionKey string `json:"encryption_key,omitempty"`
 }
 
 // GroupEnvelope is the wire format for all Kafka group messages.
@@ -47,12 +62,20 @@ const (
 	EnvelopeAudit         = "audit"
 	EnvelopeTaskStatus    = "task_status"
 	EnvelopeRoster        = "roster"
+	EnvelopeInbox         = "inbox"
+	EnvelopeAdmin         = "admin"
 )
 
-// AnnouncePayload is sent on join/leave/heartbeat.
+// AnnouncePayload is sent on join/leave/heartbeat. Signature is the
+// sender's identity-key signature (base64) over the JSON encoding of the
+// payload with an empty Signature; agents that predate identity keys omit
+// GroupName, IssuedAt and Signature.
 type AnnouncePayload struct {
-	Action   string        `json:"action"` // "join", "leave", "heartbeat"
-	Identity AgentIdentity `json:"identity"`
+	Action    string        `json:"action"` // "join", "leave", "heartbeat"
+	Identity  AgentIdentity `json:"identity"`
+	GroupName string        `json:"group_name,omitempty"`
+	IssuedAt  time.Time     `json:"issued_at,omitempty"`
+	Signature string        `json:"signature,omitempty"`
 }
 
 // TaskRequestPayload is a task request from one agent to the group.
@@ -65,6 +88,14 @@ type TaskRequestPayload struct {
 	DelegationDepth     int    `json:"delegation_depth,omitempty"`
 	OriginalRequesterID string `json:"original_requester_id,omitempty"`
 	DeadlineAt          string `json:"deadline_at,omitempty"` // RFC3339
+	TargetAgentID       string `json:"target_agent_id,omitempty"`
+	Attempt             int    `json:"attempt,omitempty"`    // skill task resubmissions so far
+	Priority            string `json:"priority,omitempty"`   // low, normal (default), high, urgent
+	Escalation          int    `json:"escalation,omitempty"` // deadline re-broadcasts so far
+	TraceID             string `json:"trace_id,omitempty"`   // trace of the request envelope, kept with inbox copies
+	// Sealed holds Description and Content encrypted to the target agent;
+	// both are empty on the wire when it is set.
+	Sealed *SealedPayload `json:"sealed,omitempty"`
 }
 
 // TaskResponsePayload is a task response from an agent.
@@ -73,6 +104,8 @@ type TaskResponsePayload struct {
 	ResponderID string `json:"responder_id"`
 	Content     string `json:"content"`
 	Status      string `json:"status"` // "completed", "failed", "rejected"
+	// Result is the structured envelope of a skill task response.
+	Result *SkillResult `json:"result,omitempty"`
 }
 
 // TaskStatusPayload reports task status changes (accepted, progress, etc.).
@@ -93,6 +126,8 @@ type DelegatedTaskRequest struct {
 	DelegationDepth     int        `json:"delegation_depth"`
 	OriginalRequesterID string     `json:"original_requester_id"`
 	DeadlineAt          *time.Time `json:"deadline_at,omitempty"`
+	TargetAgentID       string     `json:"target_agent_id,omitempty"`
+	Priority            string     `json:"priority,omitempty"`
 }
 
 // TracePayload carries shared trace data between agents.
@@ -106,6 +141,11 @@ type TracePayload struct {
 	StartedAt    string `json:"started_at"`
 	EndedAt      string `json:"ended_at"`
 	DurationMs   int    `json:"duration_ms"`
+	// Error marks a failed span. SampleRate is the probability with which
+	// spans like this one are published (1 = all), so consumers can tell
+	// partial data apart.
+	Error      bool    `json:"error,omitempty"`
+	SampleRate float64 `json:"sample_rate,omitempty"`
 }
 
 // GroupMember represents a known member in the local roster.
@@ -119,6 +159,16 @@ type GroupMember struct {
 	Role         string    `json:"role"`
 	Status       string    `json:"status"`
 	LastSeen     time.Time `json:"last_seen"`
+	// Taxonomy is the typed capability list (see BuildCapabilityTaxonomy).
+	Taxonomy []Capability `json:"taxonomy,omitempty"`
+	// AdminKey is the member's advertised admin public key.
+	AdminKey string `json:"admin_key,omitempty"`
+	// IdentityKey is the member's identity public key, when it signs its
+	// announces.
+	IdentityKey string `json:"identity_key,omitempty"`
+	// EncryptionKey is the member's encryption public key, taken from
+	// signed announces only.
+	EncryptionKey string `json:"encryption_key,omitempty"`
 }
 
 // TopicNames returns the Kafka topic names for a group.
@@ -0,0 +1,51 @@
+package knowledge
+
+// ConfidenceSignals are the inputs to a shared fact's confidence score.
+type ConfidenceSignals struct {
+	YesVotes         int // votes on the proposal that produced the fact
+	NoVotes          int
+	ProposerApproved int // proposals by the same proposer that were approved
+	ProposerRejected int
+	Injections       int // times the fact was injected into a prompt
+	Contradictions   int // times a peer asserted conflicting content
+}
+
+// Confidence component weights; they sum to 1.
+const (
+	confidenceVoteWeight     = 0.5
+	confidenceProposerWeight = 0.2
+	confidenceUsageWeight    = 0.3
+
+	// contradictionPenalty is how many uncontradicted injections one
+	// contradiction outweighs.
+	contradictionPenalty = 3
+)
+
+// FactConfidence scores a fact in [0,1]. Each component is a Laplace-smoothed
+// ratio, so a fact without any signal scores 0.5:
+// - vote margin: (yes+1)/(yes+no+2)
+// - proposer track record: (approved+1)/(approved+rejected+2)
+// - usage: (injections+1)/(injections+3*contradictions+2)
+func FactConfidence(s ConfidenceSignals) float64 {
+	vote := smoothedRatio(s.YesVotes, s.NoVotes)
+	proposer := smoothedRatio(s.ProposerApproved, s.ProposerRejected)
+	usage := smoothedRatio(s.Injections, s.Contradictions*contradictionPenalty)
+	score := confidenceVoteWeight*vote + confidenceProposerWeight*proposer + confidenceUsageWeight*usage
+	if score < 0 {
+		return 0
+	}
+	if score > 1 {
+		return 1
+	}
+	return score
+}
+
+func smoothedRatio(pos, neg int) float64 {
+	if pos < 0 {
+		pos = 0
+	}
+	if neg < 0 {
+		neg = 0
+	}
+	return float64(pos+1) / float64(pos+neg+2)
+}
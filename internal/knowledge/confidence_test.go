@@ -0,0 +1,35 @@
+package knowledge
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFactConfidence_NoSignalsIsNeutral(t *testing.T) {
+	if got := FactConfidence(ConfidenceSignals{}); math.Abs(got-0.5) > 1e-9 {
+		t.Fatalf("expected neutral 0.5, got %f", got)
+	}
+}
+
+func TestFactConfidence_SignalsMoveScore(t *testing.T) {
+	base := FactConfidence(ConfidenceSignals{YesVotes: 2, NoVotes: 1})
+	strongVote := FactConfidence(ConfidenceSignals{YesVotes: 5})
+	if strongVote <= base {
+		t.Fatalf("expected wider vote margin to raise confidence: %f <= %f", strongVote, base)
+	}
+	goodProposer := FactConfidence(ConfidenceSignals{YesVotes: 2, NoVotes: 1, ProposerApproved: 8})
+	badProposer := FactConfidence(ConfidenceSignals{YesVotes: 2, NoVotes: 1, ProposerRejected: 8})
+	if goodProposer <= base || badProposer >= base {
+		t.Fatalf("expected proposer track record to move confidence: good=%f base=%f bad=%f", goodProposer, base, badProposer)
+	}
+	used := FactConfidence(ConfidenceSignals{YesVotes: 2, NoVotes: 1, Injections: 10})
+	contradicted := FactConfidence(ConfidenceSignals{YesVotes: 2, NoVotes: 1, Injections: 10, Contradictions: 4})
+	if used <= base || contradicted >= base {
+		t.Fatalf("expected usage to raise and contradictions to lower confidence: used=%f base=%f contradicted=%f", used, base, contradicted)
+	}
+	for _, v := range []float64{base, strongVote, goodProposer, badProposer, used, contradicted} {
+		if v < 0 || v > 1 {
+			t.Fatalf("confidence out of range: %f", v)
+		}
+	}
+}
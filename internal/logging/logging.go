@@ -0,0 +1,111 @@
+// Package logging sets up the process-wide slog logger of the gateway and the
+// channel bridge: a level, text or JSON output, and a trace_id attribute
+// taken from the context of each record, so log lines correlate with the
+// timeline and APM traces.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/KafClaw/KafClaw/internal/tracing"
+)
+
+// Output formats.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Options configures a logger. Empty fields take the defaults: level info,
+// text output.
+type Options struct {
+	// Level is debug, info, warn or error.
+	Level string
+	// Format is text or json.
+	Format string
+	// Service, when set, is added to every record as "service".
+	Service string
+}
+
+// ParseLevel parses a level name; "" is info and "warning" is warn.
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "debug":
+		return slog.LevelDebug, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("unknown log level %q (want debug, info, warn or error)", s)
+}
+
+// New returns a logger writing to w.
+func New(w io.Writer, opts Options) (*slog.Logger, error) {
+	level, err := ParseLevel(opts.Level)
+	if err != nil {
+		return nil, err
+	}
+	hopts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler
+	switch strings.ToLower(strings.TrimSpace(opts.Format)) {
+	case "", FormatText:
+		h = slog.NewTextHandler(w, hopts)
+	case FormatJSON:
+		h = slog.NewJSONHandler(w, hopts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q (want text or json)", opts.Format)
+	}
+	logger := slog.New(traceHandler{h})
+	if s := strings.TrimSpace(opts.Service); s != "" {
+		logger = logger.With("service", s)
+	}
+	return logger, nil
+}
+
+// Setup makes a logger writing to stderr the default of slog and of the
+// standard log package. Invalid options fall back to the defaults and are
+// reported in the returned error.
+func Setup(opts Options) error {
+	logger, err := New(os.Stderr, opts)
+	if err != nil {
+		logger, _ = New(os.Stderr, Options{Service: opts.Service})
+	}
+	slog.SetDefault(logger)
+	return err
+}
+
+// traceHandler adds the trace ID of the record's context as trace_id,
+// unless the record carries one already.
+type traceHandler struct {
+	slog.Handler
+}
+
+func (h traceHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := tracing.TraceID(ctx); id != "" {
+		has := false
+		r.Attrs(func(a slog.Attr) bool {
+			has = a.Key == "trace_id"
+			return !has
+		})
+		if !has {
+			r.AddAttrs(slog.String("trace_id", id))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return traceHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h traceHandler) WithGroup(name string) slog.Handler {
+	return traceHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,50 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/tracing"
+)
+
+func TestJSONLoggerAddsTraceIDAndFiltersLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Options{Level: "warn", Format: "json", Service: "gateway"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := tracing.WithTraceID(context.Background(), "trace-42")
+	logger.InfoContext(ctx, "dropped")
+	logger.WarnContext(ctx, "provider slow", "provider", "openai")
+	logger.WarnContext(ctx, "explicit", "trace_id", "trace-7")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 records at warn level, got %q", buf.String())
+	}
+	var rec map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec["msg"] != "provider slow" || rec["trace_id"] != "trace-42" || rec["service"] != "gateway" || rec["provider"] != "openai" {
+		t.Fatalf("unexpected record: %v", rec)
+	}
+	if strings.Count(lines[1], "trace_id") != 1 || !strings.Contains(lines[1], `"trace_id":"trace-7"`) {
+		t.Fatalf("expected the explicit trace_id to be kept: %s", lines[1])
+	}
+}
+
+func TestOptionsValidation(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, Options{Level: "loud"}); err == nil {
+		t.Fatal("expected unknown level to fail")
+	}
+	if _, err := New(&bytes.Buffer{}, Options{Format: "xml"}); err == nil {
+		t.Fatal("expected unknown format to fail")
+	}
+	if lvl, err := ParseLevel("WARNING"); err != nil || lvl.String() != "WARN" {
+		t.Fatalf("expected warning alias, got %v %v", lvl, err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -14,6 +15,8 @@ type IndexItem struct {
 	Content string
 	Source  string // e.g. "conversation:whatsapp", "tool:read_file"
 	Tags    string
+	Privacy string // empty = source default (see SourcePrivacy)
+	Provenance
 }
 
 // AutoIndexerConfig holds configuration for the AutoIndexer.
@@ -22,6 +25,15 @@ type AutoIndexerConfig struct {
 	BatchSize     int           // flush after N items (default: 5)
 	FlushInterval time.Duration // flush on timer (default: 30s)
 	QueueSize     int           // channel buffer size (default: 100)
+	Dedup         DedupConfig   // near-duplicate check (zero = off)
+}
+
+// AutoIndexerStats counts what the auto-indexer did with indexed items since
+// it started.
+type AutoIndexerStats struct {
+	Indexed      int64 `json:"indexed"`
+	DedupSkipped int64 `json:"dedupSkipped"`
+	DedupMerged  int64 `json:"dedupMerged"`
 }
 
 // AutoIndexer runs a background goroutine that batches and indexes content
@@ -32,6 +44,8 @@ type AutoIndexer struct {
 	queue    chan IndexItem
 	stopOnce sync.Once
 	done     chan struct{}
+
+	indexed, skipped, merged atomic.Int64
 }
 
 // NewAutoIndexer creates a new AutoIndexer. If service is nil, Enqueue is a no-op.
@@ -128,12 +142,34 @@ func (a *AutoIndexer) indexBatch(ctx context.Context, items []IndexItem) {
 		if ctx.Err() != nil {
 			return
 		}
-		id, err := a.service.Store(ctx, item.Content, item.Source, item.Tags)
+		id, dedup, err := a.service.StoreItemDeduped(ctx, item, a.config.Dedup)
 		if err != nil {
 			slog.Warn("AutoIndexer store failed", "source", item.Source, "error", err)
 			continue
 		}
-		slog.Debug("AutoIndexer indexed", "id", id, "source", item.Source, "len", len(item.Content))
+		switch dedup {
+		case DedupSkip:
+			a.skipped.Add(1)
+			slog.Debug("AutoIndexer skipped near-duplicate", "id", id, "source", item.Source)
+		case DedupMerge:
+			a.merged.Add(1)
+			slog.Debug("AutoIndexer merged near-duplicate", "id", id, "source", item.Source)
+		default:
+			a.indexed.Add(1)
+			slog.Debug("AutoIndexer indexed", "id", id, "source", item.Source, "len", len(item.Content))
+		}
+	}
+}
+
+// Stats returns the indexing and dedup counters.
+func (a *AutoIndexer) Stats() AutoIndexerStats {
+	if a == nil {
+		return AutoIndexerStats{}
+	}
+	return AutoIndexerStats{
+		Indexed:      a.indexed.Load(),
+		DedupSkipped: a.skipped.Load(),
+		DedupMerged:  a.merged.Load(),
 	}
 }
 
@@ -168,6 +204,10 @@ func FormatConversationPair(userMsg, agentResponse, channel, chatID string) Inde
 		Content: fmt.Sprintf("Q: %s\nA: %s", userMsg, agentResponse),
 		Source:  "conversation:" + channel,
 		Tags:    chatID,
+		Provenance: Provenance{
+			Channel: channel,
+			ChatID:  chatID,
+		},
 	}
 }
 
@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/metrics"
+	"github.com/KafClaw/KafClaw/internal/provider"
+)
+
+var dedupTotal = metrics.NewCounter("kafclaw_memory_dedup_total", "Auto-indexed chunks deduplicated at index time, by action (skip, merge).", "action")
+
+// Dedup actions for near-duplicate chunks.
+const (
+	DedupSkip  = "skip"  // keep the existing chunk, drop the new one
+	DedupMerge = "merge" // replace the existing chunk with the newer content
+)
+
+// dedupCandidates is how many nearest chunks are checked for a duplicate.
+const dedupCandidates = 5
+
+// DedupConfig configures near-duplicate detection at index time. A chunk is
+// a near-duplicate when its embedding is at least Threshold cosine-similar
+// to a chunk of the same source, privacy, channel and chat created within
+// Window.
+type DedupConfig struct {
+	Threshold float64       // 0 = off
+	Action    string        // DedupSkip (default) or DedupMerge
+	Window    time.Duration // 0 = any age
+}
+
+// StoreItemDeduped stores an item unless it nearly duplicates a recent chunk.
+// A duplicate is skipped, or merged into the existing chunk: its ID is kept
+// and its content, embedding and provenance are replaced by the newer ones.
+// It returns the stored or matched chunk ID and the dedup action taken, ""
+// when the item was stored as a new chunk.
+func (m *MemoryService) StoreItemDeduped(ctx context.Context, item IndexItem, cfg DedupConfig) (string, string, error) {
+	if m.embedder == nil || cfg.Threshold <= 0 {
+		id, err := m.StoreItem(ctx, item)
+		return id, "", err
+	}
+	id, payload := itemPayload(item)
+	resp, err := m.embedder.Embed(ctx, &provider.EmbeddingRequest{Input: item.Content})
+	if err != nil {
+		return "", "", fmt.Errorf("embed content: %w", err)
+	}
+
+	if dup, ok := m.findDuplicate(ctx, id, resp.Vector, payload, cfg); ok {
+		action := DedupSkip
+		if cfg.Action == DedupMerge {
+			action = DedupMerge
+			if created, ok := dup.Payload["created_at"].(string); ok && created != "" {
+				payload["created_at"] = created
+			}
+			if err := m.store.Upsert(ctx, dup.ID, resp.Vector, payload); err != nil {
+				return "", "", fmt.Errorf("merge chunk: %w", err)
+			}
+		}
+		dedupTotal.Inc(action)
+		return dup.ID, action, nil
+	}
+
+	if err := m.store.Upsert(ctx, id, resp.Vector, payload); err != nil {
+		return "", "", fmt.Errorf("upsert chunk: %w", err)
+	}
+	return id, "", nil
+}
+
+// findDuplicate returns the most similar recent chunk of the same source,
+// privacy, channel and chat that reaches the threshold, so one chat never
+// drops or overwrites the memory of another. An identical chunk (same ID) is not a
+// duplicate; storing it again only updates it.
+func (m *MemoryService) findDuplicate(ctx context.Context, id string, vector []float32, payload map[string]interface{}, cfg DedupConfig) (Result, bool) {
+	results, err := m.store.Search(ctx, vector, dedupCandidates)
+	if err != nil {
+		return Result{}, false
+	}
+	for _, r := range results {
+		if r.ID == id || float64(r.Score) < cfg.Threshold {
+			continue
+		}
+		if r.Payload["source"] != payload["source"] || r.Payload["privacy"] != payload["privacy"] {
+			continue
+		}
+		if theirs, ours := provenanceFromPayload(r.Payload), provenanceFromPayload(payload); theirs.Channel != ours.Channel || theirs.ChatID != ours.ChatID {
+			continue
+		}
+		if cfg.Window > 0 {
+			created := createdAtFromPayload(r.Payload)
+			if created.IsZero() || time.Since(created) > cfg.Window {
+				continue
+			}
+		}
+		return r, true
+	}
+	return Result{}, false
+}
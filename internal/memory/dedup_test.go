@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/provider"
+)
+
+// mapEmbedder returns the vector registered for the input text.
+type mapEmbedder map[string][]float32
+
+func (m mapEmbedder) Embed(ctx context.Context, req *provider.EmbeddingRequest) (*provider.EmbeddingResponse, error) {
+	return &provider.EmbeddingResponse{Vector: m[req.Input]}, nil
+}
+
+func TestStoreItemDedupedSkipsNearDuplicates(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	emb := mapEmbedder{
+		"Q: status?\nA: all green":    {1, 0, 0},
+		"Q: status?\nA: all green!":   {0.99, 0.05, 0},
+		"Q: deploy plan?\nA: Friday":  {0, 1, 0},
+		"Q: status?\nA: all green :)": {0.99, 0.04, 0},
+	}
+	svc := NewMemoryService(NewSQLiteVecStore(db, 3), emb)
+	ctx := context.Background()
+	cfg := DedupConfig{Threshold: 0.95, Window: time.Hour}
+
+	first, action, err := svc.StoreItemDeduped(ctx, IndexItem{Content: "Q: status?\nA: all green", Source: "conversation:slack"}, cfg)
+	if err != nil || action != "" {
+		t.Fatalf("expected first item stored, got action=%q err=%v", action, err)
+	}
+	id, action, err := svc.StoreItemDeduped(ctx, IndexItem{Content: "Q: status?\nA: all green!", Source: "conversation:slack"}, cfg)
+	if err != nil || action != DedupSkip || id != first {
+		t.Fatalf("expected near-duplicate skipped, got id=%q action=%q err=%v", id, action, err)
+	}
+	if _, action, _ := svc.StoreItemDeduped(ctx, IndexItem{Content: "Q: deploy plan?\nA: Friday", Source: "conversation:slack"}, cfg); action != "" {
+		t.Fatalf("expected distinct item stored, got %q", action)
+	}
+	// Another source is never a duplicate.
+	if _, action, _ := svc.StoreItemDeduped(ctx, IndexItem{Content: "Q: status?\nA: all green!", Source: "conversation:msteams"}, cfg); action != "" {
+		t.Fatalf("expected other source stored, got %q", action)
+	}
+	// Neither is another chat of the same source.
+	other := IndexItem{Content: "Q: status?\nA: all green!", Source: "conversation:slack", Provenance: Provenance{Channel: "slack", ChatID: "C2"}}
+	if _, action, _ := svc.StoreItemDeduped(ctx, other, cfg); action != "" {
+		t.Fatalf("expected other chat stored, got %q", action)
+	}
+	if n := countChunks(db); n != 4 {
+		t.Fatalf("expected 4 chunks, got %d", n)
+	}
+
+	// Merge keeps the existing chunk ID with the newer content.
+	cfg.Action = DedupMerge
+	id, action, err = svc.StoreItemDeduped(ctx, IndexItem{Content: "Q: status?\nA: all green :)", Source: "conversation:slack"}, cfg)
+	if err != nil || action != DedupMerge || id != first {
+		t.Fatalf("expected merge into first chunk, got id=%q action=%q err=%v", id, action, err)
+	}
+	var content string
+	var version int
+	if err := db.QueryRow(`SELECT content, version FROM memory_chunks WHERE id = ?`, first).Scan(&content, &version); err != nil {
+		t.Fatal(err)
+	}
+	if content != "Q: status?\nA: all green :)" || version != 2 {
+		t.Fatalf("expected merged content and bumped version, got %q v%d", content, version)
+	}
+	if n := countChunks(db); n != 4 {
+		t.Fatalf("expected merge to add no chunk, got %d", n)
+	}
+
+	// Without a threshold every item is stored.
+	if _, action, _ := svc.StoreItemDeduped(ctx, IndexItem{Content: "Q: status?\nA: all green!", Source: "conversation:slack"}, DedupConfig{}); action != "" {
+		t.Fatalf("expected dedup off, got %q", action)
+	}
+}
+
+func TestAutoIndexerDedupStats(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	emb := mapEmbedder{
+		"Q: status?\nA: all green":  {1, 0, 0},
+		"Q: status?\nA: all green!": {0.99, 0.05, 0},
+	}
+	svc := NewMemoryService(NewSQLiteVecStore(db, 3), emb)
+	ai := NewAutoIndexer(svc, AutoIndexerConfig{MinLength: 1, Dedup: DedupConfig{Threshold: 0.95}})
+
+	ai.indexBatch(context.Background(), []IndexItem{
+		{Content: "Q: status?\nA: all green", Source: "conversation:slack"},
+		{Content: "Q: status?\nA: all green!", Source: "conversation:slack"},
+	})
+	if got := ai.Stats(); got.Indexed != 1 || got.DedupSkipped != 1 || got.DedupMerged != 0 {
+		t.Fatalf("unexpected stats: %+v", got)
+	}
+}
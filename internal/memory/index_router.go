@@ -0,0 +1,157 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// IndexInfo describes one per-model embedding index.
+type IndexInfo struct {
+	Name      string `json:"name"`
+	Dimension int    `json:"dimension"`
+	Chunks    int    `json:"chunks"`
+	Active    bool   `json:"active"`
+}
+
+// IndexRouter holds one SQLiteVecStore per embedding model and routes
+// VectorStore calls to the index of the active model, so vectors from
+// different models are never compared with each other.
+type IndexRouter struct {
+	db *sql.DB
+
+	mu      sync.RWMutex
+	indexes map[string]*SQLiteVecStore
+	active  string
+}
+
+// NewIndexRouter creates an empty router over the timeline DB.
+func NewIndexRouter(db *sql.DB) *IndexRouter {
+	return &IndexRouter{db: db, indexes: map[string]*SQLiteVecStore{}}
+}
+
+// Open registers the index for model (see OpenSQLiteVecIndex). The first
+// opened index becomes active.
+func (r *IndexRouter) Open(ctx context.Context, model string, dimension int) (*SQLiteVecStore, error) {
+	model = strings.TrimSpace(model)
+	store, err := OpenSQLiteVecIndex(ctx, r.db, model, dimension)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.indexes[model] = store
+	if r.active == "" {
+		r.active = model
+	}
+	return store, nil
+}
+
+// SetActive switches Upsert/Search to the index of an opened model.
+func (r *IndexRouter) SetActive(model string) error {
+	model = strings.TrimSpace(model)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.indexes[model]; !ok {
+		return fmt.Errorf("embedding index %q is not open", model)
+	}
+	r.active = model
+	return nil
+}
+
+// Active returns the name of the active index.
+func (r *IndexRouter) Active() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.active
+}
+
+// Index returns the opened index for model, or nil.
+func (r *IndexRouter) Index(model string) *SQLiteVecStore {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.indexes[strings.TrimSpace(model)]
+}
+
+func (r *IndexRouter) activeStore() (*SQLiteVecStore, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	store := r.indexes[r.active]
+	if store == nil {
+		return nil, fmt.Errorf("no active embedding index")
+	}
+	return store, nil
+}
+
+// EnsureCollection is a no-op — the tables are created by the schema migration.
+func (r *IndexRouter) EnsureCollection(ctx context.Context) error {
+	return nil
+}
+
+// Upsert stores the chunk in the active index.
+func (r *IndexRouter) Upsert(ctx context.Context, id string, vector []float32, payload map[string]interface{}) error {
+	store, err := r.activeStore()
+	if err != nil {
+		return err
+	}
+	return store.Upsert(ctx, id, vector, payload)
+}
+
+// Search queries the active index.
+func (r *IndexRouter) Search(ctx context.Context, vector []float32, limit int) ([]Result, error) {
+	store, err := r.activeStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.Search(ctx, vector, limit)
+}
+
+// UpsertText stores chunk content without a vector. Content is shared by all
+// indexes.
+func (r *IndexRouter) UpsertText(ctx context.Context, id string, payload map[string]interface{}) error {
+	store, err := r.activeStore()
+	if err != nil {
+		return err
+	}
+	return store.UpsertText(ctx, id, payload)
+}
+
+// SearchText is the lexical fallback; it is index-independent.
+func (r *IndexRouter) SearchText(ctx context.Context, query string, limit int) ([]Result, error) {
+	store, err := r.activeStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.SearchText(ctx, query, limit)
+}
+
+// Indexes lists all recorded indexes with their chunk counts, including ones
+// not opened by this process.
+func (r *IndexRouter) Indexes(ctx context.Context) ([]IndexInfo, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT i.name, i.dimension, COUNT(c.id)
+		FROM memory_embedding_indexes i
+		LEFT JOIN memory_embeddings e ON e.index_name = i.name
+		LEFT JOIN memory_chunks c ON c.id = e.chunk_id
+		GROUP BY i.name, i.dimension
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	active := r.Active()
+	var out []IndexInfo
+	for rows.Next() {
+		var info IndexInfo
+		if err := rows.Scan(&info.Name, &info.Dimension, &info.Chunks); err != nil {
+			return nil, err
+		}
+		info.Active = info.Name == active
+		out = append(out, info)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, rows.Err()
+}
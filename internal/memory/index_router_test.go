@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestIndexRouter_RoutesByActiveModel(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	// A legacy embedding of matching dimension is adopted by the new index.
+	legacy := NewSQLiteVecStore(db, 3)
+	_ = legacy.Upsert(ctx, "old", []float32{0, 0, 1}, map[string]interface{}{"content": "legacy"})
+
+	router := NewIndexRouter(db)
+	if _, err := router.Open(ctx, "small-3d", 3); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := router.Open(ctx, "large-4d", 0); err != nil {
+		t.Fatal(err)
+	}
+	if router.Active() != "small-3d" {
+		t.Fatalf("expected first index active, got %q", router.Active())
+	}
+
+	if err := router.Upsert(ctx, "a", []float32{1, 0, 0}, map[string]interface{}{"content": "shared chunk"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := router.Upsert(ctx, "b", []float32{1, 0, 0, 0}, map[string]interface{}{"content": "wrong dim"}); !errors.Is(err, ErrDimensionMismatch) {
+		t.Fatalf("expected dimension mismatch, got %v", err)
+	}
+
+	if err := router.SetActive("large-4d"); err != nil {
+		t.Fatal(err)
+	}
+	// The 4d index negotiates its dimension from the first vector.
+	if err := router.Upsert(ctx, "a", []float32{0, 1, 0, 0}, map[string]interface{}{"content": "shared chunk"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := router.Index("large-4d").Dimension(); got != 4 {
+		t.Fatalf("expected negotiated dimension 4, got %d", got)
+	}
+	results, err := router.Search(ctx, []float32{0, 1, 0, 0}, 5)
+	if err != nil || len(results) != 1 || results[0].ID != "a" {
+		t.Fatalf("unexpected 4d results: %+v err=%v", results, err)
+	}
+	if _, err := router.Search(ctx, []float32{1, 0, 0}, 5); !errors.Is(err, ErrDimensionMismatch) {
+		t.Fatalf("expected mismatch for 3d query on 4d index, got %v", err)
+	}
+
+	_ = router.SetActive("small-3d")
+	results, _ = router.Search(ctx, []float32{1, 0, 0}, 5)
+	if len(results) != 2 || results[0].ID != "a" || results[1].ID != "old" {
+		t.Fatalf("unexpected 3d results: %+v", results)
+	}
+
+	// Reopening with a different configured dimension is a clear error.
+	if _, err := NewIndexRouter(db).Open(ctx, "small-3d", 1536); !errors.Is(err, ErrDimensionMismatch) {
+		t.Fatalf("expected dimension mismatch on reopen, got %v", err)
+	}
+	if err := router.SetActive("unknown"); err == nil {
+		t.Fatal("expected error for unopened index")
+	}
+
+	// Deleting a chunk drops its vectors from every index.
+	_, _ = db.Exec(`DELETE FROM memory_chunks WHERE id = 'a'`)
+	infos, err := router.Indexes(ctx)
+	if err != nil || len(infos) != 2 {
+		t.Fatalf("unexpected indexes: %+v err=%v", infos, err)
+	}
+	for _, info := range infos {
+		if info.Name == "large-4d" && (info.Chunks != 0 || info.Dimension != 4) {
+			t.Fatalf("unexpected 4d index info: %+v", info)
+		}
+		if info.Name == "small-3d" && (info.Chunks != 1 || !info.Active) {
+			t.Fatalf("unexpected 3d index info: %+v", info)
+		}
+	}
+}
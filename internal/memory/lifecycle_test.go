@@ -23,6 +23,11 @@ CREATE TABLE memory_chunks (
 	embedding BLOB,
 	source TEXT NOT NULL DEFAULT 'user',
 	tags TEXT DEFAULT '',
+	privacy TEXT DEFAULT '',
+	trace_id TEXT DEFAULT '',
+	event_id TEXT DEFAULT '',
+	channel TEXT DEFAULT '',
+	chat_id TEXT DEFAULT '',
 	version INTEGER NOT NULL DEFAULT 1,
 	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 	updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
@@ -25,6 +25,7 @@ type Observation struct {
 	SessionID    string
 	Content      string    // compressed observation text
 	Priority     string    // "high", "medium", "low"
+	Privacy      string    // most restricted level of the source messages
 	ObservedAt   time.Time // when observation was created
 	ReferencedAt time.Time // earliest event referenced
 }
@@ -72,17 +73,27 @@ func (o *Observer) ShouldObserve(sessionID string) bool {
 	return count >= o.config.MessageThreshold
 }
 
-// EnqueueMessage records a message for potential future observation.
+// EnqueueMessage records an owner-only message for potential future
+// observation.
 func (o *Observer) EnqueueMessage(sessionID, role, content string) {
+	o.EnqueueMessageWithPrivacy(sessionID, role, content, PrivacyOwner)
+}
+
+// EnqueueMessageWithPrivacy records a message with the privacy level its
+// observations inherit. Unknown levels are stored as owner.
+func (o *Observer) EnqueueMessageWithPrivacy(sessionID, role, content, privacy string) {
 	if o == nil || o.db == nil {
 		return
 	}
 	if len(strings.TrimSpace(content)) < 20 {
 		return
 	}
+	if privacy = NormalizePrivacy(privacy); privacy == "" {
+		privacy = PrivacyOwner
+	}
 	_, err := o.db.Exec(
-		`INSERT INTO observations_queue (session_id, role, content) VALUES (?, ?, ?)`,
-		sessionID, role, content,
+		`INSERT INTO observations_queue (session_id, role, content, privacy) VALUES (?, ?, ?, ?)`,
+		sessionID, role, content, privacy,
 	)
 	if err != nil {
 		slog.Debug("Observer enqueue failed", "error", err)
@@ -98,7 +109,7 @@ func (o *Observer) Observe(ctx context.Context, sessionID string) error {
 
 	// Fetch unobserved messages
 	rows, err := o.db.Query(
-		`SELECT id, role, content, created_at FROM observations_queue
+		`SELECT id, role, content, privacy, created_at FROM observations_queue
 		 WHERE session_id = ? AND observed = 0
 		 ORDER BY created_at ASC`,
 		sessionID,
@@ -114,6 +125,9 @@ func (o *Observer) Observe(ctx context.Context, sessionID string) error {
 		Content   string
 		CreatedAt time.Time
 	}
+	// Observations may mix facts from all compressed messages, so they take
+	// the most restricted level among them.
+	privacy := PrivacyPublic
 	for rows.Next() {
 		var m struct {
 			ID        int64
@@ -121,9 +135,11 @@ func (o *Observer) Observe(ctx context.Context, sessionID string) error {
 			Content   string
 			CreatedAt time.Time
 		}
-		if err := rows.Scan(&m.ID, &m.Role, &m.Content, &m.CreatedAt); err != nil {
+		var level string
+		if err := rows.Scan(&m.ID, &m.Role, &m.Content, &level, &m.CreatedAt); err != nil {
 			continue
 		}
+		privacy = MorePrivate(privacy, level)
 		messages = append(messages, m)
 	}
 
@@ -157,6 +173,9 @@ func (o *Observer) Observe(ctx context.Context, sessionID string) error {
 
 	// Parse and store observations
 	observations := parseObservations(resp.Content, sessionID)
+	for i := range observations {
+		observations[i].Privacy = privacy
+	}
 
 	tx, err := o.db.Begin()
 	if err != nil {
@@ -166,9 +185,9 @@ func (o *Observer) Observe(ctx context.Context, sessionID string) error {
 
 	for _, obs := range observations {
 		_, err := tx.Exec(
-			`INSERT INTO observations (session_id, content, priority, observed_at, referenced_at)
-			 VALUES (?, ?, ?, ?, ?)`,
-			obs.SessionID, obs.Content, obs.Priority, obs.ObservedAt, obs.ReferencedAt,
+			`INSERT INTO observations (session_id, content, priority, privacy, observed_at, referenced_at)
+			 VALUES (?, ?, ?, ?, ?, ?)`,
+			obs.SessionID, obs.Content, obs.Priority, obs.Privacy, obs.ObservedAt, obs.ReferencedAt,
 		)
 		if err != nil {
 			slog.Warn("Observer insert failed", "error", err)
@@ -203,7 +222,7 @@ func (o *Observer) LoadObservations(sessionID string) ([]Observation, error) {
 	}
 
 	rows, err := o.db.Query(
-		`SELECT id, session_id, content, priority, observed_at, referenced_at
+		`SELECT id, session_id, content, priority, privacy, observed_at, referenced_at
 		 FROM observations WHERE session_id = ?
 		 ORDER BY referenced_at ASC, observed_at ASC`,
 		sessionID,
@@ -217,7 +236,7 @@ func (o *Observer) LoadObservations(sessionID string) ([]Observation, error) {
 	for rows.Next() {
 		var obs Observation
 		var id int64
-		if err := rows.Scan(&id, &obs.SessionID, &obs.Content, &obs.Priority, &obs.ObservedAt, &obs.ReferencedAt); err != nil {
+		if err := rows.Scan(&id, &obs.SessionID, &obs.Content, &obs.Priority, &obs.Privacy, &obs.ObservedAt, &obs.ReferencedAt); err != nil {
 			continue
 		}
 		obs.ID = fmt.Sprintf("obs-%d", id)
@@ -254,7 +273,8 @@ func FormatObservations(observations []Observation) string {
 }
 
 // Reflect consolidates observations when they exceed MaxObservations.
-// Uses the LLM to merge, deduplicate, and prune old observations.
+// Uses the LLM to merge, deduplicate, and prune old observations. Each
+// privacy level is consolidated separately so merged notes keep their level.
 func (o *Observer) Reflect(ctx context.Context, sessionID string) error {
 	if o == nil || o.db == nil {
 		return nil
@@ -268,27 +288,37 @@ func (o *Observer) Reflect(ctx context.Context, sessionID string) error {
 		return nil
 	}
 
-	// Build observation text for the LLM
-	formatted := FormatObservations(observations)
+	byPrivacy := map[string][]Observation{}
+	for _, obs := range observations {
+		byPrivacy[obs.Privacy] = append(byPrivacy[obs.Privacy], obs)
+	}
 
 	model := o.config.Model
 	if model == "" {
 		model = o.provider.DefaultModel()
 	}
 
-	resp, err := o.provider.Chat(ctx, &provider.ChatRequest{
-		Model: model,
-		Messages: []provider.Message{
-			{Role: "system", Content: reflectorPrompt},
-			{Role: "user", Content: formatted},
-		},
-		MaxTokens: 3000,
-	})
-	if err != nil {
-		return fmt.Errorf("reflector LLM call: %w", err)
-	}
+	var consolidated []Observation
+	for privacy, group := range byPrivacy {
+		// Build observation text for the LLM
+		formatted := FormatObservations(group)
 
-	consolidated := parseObservations(resp.Content, sessionID)
+		resp, err := o.provider.Chat(ctx, &provider.ChatRequest{
+			Model: model,
+			Messages: []provider.Message{
+				{Role: "system", Content: reflectorPrompt},
+				{Role: "user", Content: formatted},
+			},
+			MaxTokens: 3000,
+		})
+		if err != nil {
+			return fmt.Errorf("reflector LLM call: %w", err)
+		}
+		for _, obs := range parseObservations(resp.Content, sessionID) {
+			obs.Privacy = privacy
+			consolidated = append(consolidated, obs)
+		}
+	}
 
 	tx, err := o.db.Begin()
 	if err != nil {
@@ -302,9 +332,9 @@ func (o *Observer) Reflect(ctx context.Context, sessionID string) error {
 	// Insert consolidated
 	for _, obs := range consolidated {
 		_, _ = tx.Exec(
-			`INSERT INTO observations (session_id, content, priority, observed_at, referenced_at)
-			 VALUES (?, ?, ?, ?, ?)`,
-			obs.SessionID, obs.Content, obs.Priority, obs.ObservedAt, obs.ReferencedAt,
+			`INSERT INTO observations (session_id, content, priority, privacy, observed_at, referenced_at)
+			 VALUES (?, ?, ?, ?, ?, ?)`,
+			obs.SessionID, obs.Content, obs.Priority, obs.Privacy, obs.ObservedAt, obs.ReferencedAt,
 		)
 	}
 
@@ -364,7 +394,7 @@ func (o *Observer) AllObservations(limit int) ([]Observation, error) {
 	}
 
 	rows, err := o.db.Query(
-		`SELECT id, session_id, content, priority, observed_at, referenced_at
+		`SELECT id, session_id, content, priority, privacy, observed_at, referenced_at
 		 FROM observations ORDER BY observed_at DESC LIMIT ?`, limit,
 	)
 	if err != nil {
@@ -376,7 +406,7 @@ func (o *Observer) AllObservations(limit int) ([]Observation, error) {
 	for rows.Next() {
 		var obs Observation
 		var id int64
-		if err := rows.Scan(&id, &obs.SessionID, &obs.Content, &obs.Priority, &obs.ObservedAt, &obs.ReferencedAt); err != nil {
+		if err := rows.Scan(&id, &obs.SessionID, &obs.Content, &obs.Priority, &obs.Privacy, &obs.ObservedAt, &obs.ReferencedAt); err != nil {
 			continue
 		}
 		obs.ID = fmt.Sprintf("obs-%d", id)
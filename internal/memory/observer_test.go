@@ -21,6 +21,7 @@ func setupObserverDB(t *testing.T) *sql.DB {
 			session_id TEXT NOT NULL,
 			role TEXT NOT NULL,
 			content TEXT NOT NULL,
+			privacy TEXT NOT NULL DEFAULT 'owner',
 			observed INTEGER NOT NULL DEFAULT 0,
 			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 		);
@@ -29,6 +30,7 @@ func setupObserverDB(t *testing.T) *sql.DB {
 			session_id TEXT NOT NULL,
 			content TEXT NOT NULL,
 			priority TEXT NOT NULL DEFAULT 'medium',
+			privacy TEXT NOT NULL DEFAULT 'owner',
 			observed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 			referenced_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 		);
@@ -0,0 +1,68 @@
+package memory
+
+import "strings"
+
+// Privacy levels of observations and memory chunks, assigned at creation.
+// Owner items are only injected for the owner, trusted items also for
+// external senders of trusted channels, public items everywhere.
+const (
+	PrivacyOwner   = "owner"
+	PrivacyTrusted = "trusted"
+	PrivacyPublic  = "public"
+)
+
+var privacyRank = map[string]int{
+	PrivacyPublic:  0,
+	PrivacyTrusted: 1,
+	PrivacyOwner:   2,
+}
+
+// NormalizePrivacy returns the canonical form of a privacy level ("private"
+// is accepted for owner), or "" when the level is unknown.
+func NormalizePrivacy(level string) string {
+	level = strings.ToLower(strings.TrimSpace(level))
+	if level == "private" {
+		return PrivacyOwner
+	}
+	if _, ok := privacyRank[level]; ok {
+		return level
+	}
+	return ""
+}
+
+// PrivacyAllows reports whether an item of the given level may be injected
+// where allowed is the most restricted level permitted. Unknown item levels
+// count as owner; an unknown allowed level permits public items only.
+func PrivacyAllows(allowed, level string) bool {
+	item, ok := privacyRank[NormalizePrivacy(level)]
+	if !ok {
+		item = privacyRank[PrivacyOwner]
+	}
+	return item <= privacyRank[NormalizePrivacy(allowed)]
+}
+
+// MorePrivate returns the more restricted of two levels.
+func MorePrivate(a, b string) string {
+	a, b = NormalizePrivacy(a), NormalizePrivacy(b)
+	if a == "" || b == "" {
+		return PrivacyOwner
+	}
+	if privacyRank[a] >= privacyRank[b] {
+		return a
+	}
+	return b
+}
+
+// SourcePrivacy is the level of chunks stored without an explicit one: soul
+// files and group or knowledge items are public, everything else (user
+// memories, conversations, tool results, images) is owner-only.
+func SourcePrivacy(source string) string {
+	switch {
+	case strings.HasPrefix(source, "soul:"),
+		strings.HasPrefix(source, "group:"),
+		source == "knowledge":
+		return PrivacyPublic
+	default:
+		return PrivacyOwner
+	}
+}
@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/provider"
+)
+
+type cannedProvider struct{ content string }
+
+func (p *cannedProvider) Chat(_ context.Context, _ *provider.ChatRequest) (*provider.ChatResponse, error) {
+	return &provider.ChatResponse{Content: p.content}, nil
+}
+func (p *cannedProvider) Transcribe(_ context.Context, _ *provider.AudioRequest) (*provider.AudioResponse, error) {
+	return &provider.AudioResponse{}, nil
+}
+func (p *cannedProvider) Speak(_ context.Context, _ *provider.TTSRequest) (*provider.TTSResponse, error) {
+	return &provider.TTSResponse{}, nil
+}
+func (p *cannedProvider) DefaultModel() string { return "canned" }
+
+func TestPrivacyAllows(t *testing.T) {
+	cases := []struct {
+		allowed, level string
+		want           bool
+	}{
+		{PrivacyOwner, PrivacyOwner, true},
+		{PrivacyOwner, PrivacyPublic, true},
+		{PrivacyTrusted, PrivacyOwner, false},
+		{PrivacyTrusted, PrivacyTrusted, true},
+		{PrivacyPublic, PrivacyTrusted, false},
+		{PrivacyPublic, PrivacyPublic, true},
+		{"private", PrivacyOwner, true},
+		{PrivacyOwner, "", true},
+		{PrivacyTrusted, "", false}, // unknown levels are owner-only
+		{"bogus", PrivacyTrusted, false},
+	}
+	for _, tc := range cases {
+		if got := PrivacyAllows(tc.allowed, tc.level); got != tc.want {
+			t.Errorf("PrivacyAllows(%q, %q) = %v, want %v", tc.allowed, tc.level, got, tc.want)
+		}
+	}
+}
+
+func TestSourcePrivacy(t *testing.T) {
+	for source, want := range map[string]string{
+		"soul:SOUL.md":          PrivacyPublic,
+		"group:a1:item":         PrivacyPublic,
+		"knowledge":             PrivacyPublic,
+		"user":                  PrivacyOwner,
+		"conversation:whatsapp": PrivacyOwner,
+		"tool:read_file":        PrivacyOwner,
+	} {
+		if got := SourcePrivacy(source); got != want {
+			t.Errorf("SourcePrivacy(%q) = %q, want %q", source, got, want)
+		}
+	}
+	if got := MorePrivate(PrivacyPublic, PrivacyTrusted); got != PrivacyTrusted {
+		t.Errorf("MorePrivate(public, trusted) = %q", got)
+	}
+}
+
+func TestObserveInheritsMostRestrictedPrivacy(t *testing.T) {
+	db := setupObserverDB(t)
+	defer db.Close()
+
+	o := NewObserver(ObserverConfig{Enabled: true, MessageThreshold: 2},
+		&cannedProvider{content: "- [HIGH] User shared a phone number"}, db)
+	o.EnqueueMessageWithPrivacy("s1", "user", "a message from an external sender", PrivacyTrusted)
+	o.EnqueueMessageWithPrivacy("s1", "user", "a message from the owner with personal details", PrivacyOwner)
+	if err := o.Observe(context.Background(), "s1"); err != nil {
+		t.Fatalf("observe: %v", err)
+	}
+	obs, err := o.LoadObservations("s1")
+	if err != nil || len(obs) != 1 {
+		t.Fatalf("expected 1 observation, got %d (err=%v)", len(obs), err)
+	}
+	if obs[0].Privacy != PrivacyOwner {
+		t.Fatalf("expected owner privacy, got %q", obs[0].Privacy)
+	}
+}
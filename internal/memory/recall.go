@@ -0,0 +1,143 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// RecallOptions narrows a memory search.
+type RecallOptions struct {
+	// Since and Until bound the chunk creation time; zero means open.
+	Since time.Time
+	Until time.Time
+	// SourcePrefix keeps chunks whose source starts with it, for example
+	// "conversation:slack".
+	SourcePrefix string
+	// Namespace keeps chunks whose source namespace (the part before the
+	// first ":", such as "conversation", "tool", "soul", "er1") matches.
+	Namespace string
+	// Privacy is the most restricted privacy level returned (see
+	// PrivacyAllows); empty returns every level.
+	Privacy string
+	TopK    int
+}
+
+// RecallResult is a chunk with the reasons it matched.
+type RecallResult struct {
+	MemoryChunk
+	Explanation string
+}
+
+// Recall searches memory with opts and explains each match. Filters are
+// applied after an over-fetched search, like SearchBySource.
+func (m *MemoryService) Recall(ctx context.Context, query string, opts RecallOptions) ([]RecallResult, error) {
+	if opts.TopK <= 0 {
+		opts.TopK = 5
+	}
+	fetch := opts.TopK
+	if opts.filtered() {
+		fetch = max(opts.TopK*5, 50)
+	}
+	chunks, err := m.Search(ctx, query, fetch)
+	if err != nil && len(chunks) == 0 {
+		return nil, err
+	}
+	var out []RecallResult
+	for _, c := range chunks {
+		if !opts.keep(c) {
+			continue
+		}
+		out = append(out, RecallResult{MemoryChunk: c, Explanation: explainRecall(query, c, opts)})
+		if len(out) >= opts.TopK {
+			break
+		}
+	}
+	return out, err
+}
+
+func (o RecallOptions) filtered() bool {
+	return !o.Since.IsZero() || !o.Until.IsZero() || o.SourcePrefix != "" || o.Namespace != "" || o.Privacy != ""
+}
+
+func (o RecallOptions) keep(c MemoryChunk) bool {
+	if o.SourcePrefix != "" && !strings.HasPrefix(c.Source, o.SourcePrefix) {
+		return false
+	}
+	if o.Namespace != "" && !strings.EqualFold(SourceNamespace(c.Source), o.Namespace) {
+		return false
+	}
+	if o.Privacy != "" && !PrivacyAllows(o.Privacy, c.Privacy) {
+		return false
+	}
+	if !o.Since.IsZero() || !o.Until.IsZero() {
+		// A chunk without a creation time cannot be placed in a range.
+		if c.CreatedAt.IsZero() {
+			return false
+		}
+		if !o.Since.IsZero() && c.CreatedAt.Before(o.Since) {
+			return false
+		}
+		if !o.Until.IsZero() && c.CreatedAt.After(o.Until) {
+			return false
+		}
+	}
+	return true
+}
+
+// SourceNamespace returns the namespace of a chunk source: the part before
+// the first ":", or the whole source ("user", "knowledge").
+func SourceNamespace(source string) string {
+	ns, _, _ := strings.Cut(source, ":")
+	return ns
+}
+
+// explainRecall says why c matched query: the match kind and score, the
+// query terms it contains, and the filters it passed.
+func explainRecall(query string, c MemoryChunk, opts RecallOptions) string {
+	var parts []string
+	if c.Match == MatchLexical {
+		parts = append(parts, "lexical match on the query text")
+	} else {
+		parts = append(parts, fmt.Sprintf("semantic similarity %.2f", c.Score))
+	}
+	if terms := sharedTerms(query, c.Content); len(terms) > 0 {
+		parts = append(parts, "shares terms "+strings.Join(terms, ", "))
+	}
+	if opts.SourcePrefix != "" {
+		parts = append(parts, fmt.Sprintf("source starts with %q", opts.SourcePrefix))
+	}
+	if opts.Namespace != "" {
+		parts = append(parts, fmt.Sprintf("in namespace %q", SourceNamespace(c.Source)))
+	}
+	if !opts.Since.IsZero() || !opts.Until.IsZero() {
+		parts = append(parts, "created "+c.CreatedAt.UTC().Format(time.RFC3339)+" within the time range")
+	}
+	return strings.Join(parts, "; ")
+}
+
+// sharedTerms lists the query words of three or more letters found in
+// content, in query order.
+func sharedTerms(query, content string) []string {
+	words := func(s string) []string {
+		return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+	}
+	have := map[string]bool{}
+	for _, w := range words(content) {
+		have[w] = true
+	}
+	var out []string
+	seen := map[string]bool{}
+	for _, w := range words(query) {
+		if len([]rune(w)) < 3 || seen[w] || !have[w] {
+			continue
+		}
+		seen[w] = true
+		out = append(out, w)
+	}
+	return out
+}
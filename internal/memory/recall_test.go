@@ -0,0 +1,48 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMemoryService_Recall(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	svc := NewMemoryService(NewSQLiteVecStore(db, 3), &fakeEmbedder{vector: []float32{1, 0, 0}})
+	ctx := context.Background()
+
+	old, _ := svc.Store(ctx, "Deploy runbook: restart the api pods", "conversation:slack", "")
+	recent, _ := svc.Store(ctx, "Deploy freeze starts Friday", "conversation:msteams", "")
+	tool, _ := svc.Store(ctx, "deploy.sh exited 0", "tool:exec", "")
+	if _, err := db.Exec(`UPDATE memory_chunks SET created_at = ? WHERE id = ?`, time.Now().Add(-30*24*time.Hour).UTC(), old); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := svc.Recall(ctx, "deploy runbook", RecallOptions{Namespace: "conversation", TopK: 5})
+	if err != nil || len(res) != 2 {
+		t.Fatalf("expected the two conversation memories, got %+v %v", res, err)
+	}
+	for _, r := range res {
+		if r.ID == tool || r.Match != MatchSemantic || r.CreatedAt.IsZero() {
+			t.Fatalf("unexpected result: %+v", r)
+		}
+		if r.ID == old && !strings.Contains(r.Explanation, "shares terms deploy, runbook") {
+			t.Fatalf("explanation must name the shared terms: %q", r.Explanation)
+		}
+	}
+
+	res, _ = svc.Recall(ctx, "deploy", RecallOptions{Since: time.Now().Add(-7 * 24 * time.Hour), SourcePrefix: "conversation:"})
+	if len(res) != 1 || res[0].ID != recent || !strings.Contains(res[0].Explanation, "within the time range") {
+		t.Fatalf("expected only the recent conversation memory, got %+v", res)
+	}
+
+	res, _ = svc.Recall(ctx, "deploy", RecallOptions{TopK: 1})
+	if len(res) != 1 {
+		t.Fatalf("top_k must cap results, got %d", len(res))
+	}
+	if SourceNamespace("user") != "user" || SourceNamespace("er1:42") != "er1" {
+		t.Fatal("unexpected source namespace")
+	}
+}
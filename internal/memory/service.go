@@ -3,8 +3,10 @@ package memory
 import (
 	"context"
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/KafClaw/KafClaw/internal/provider"
 )
@@ -15,7 +17,43 @@ type MemoryChunk struct {
 	Content string
 	Source  string
 	Tags    string
+	Privacy string // owner, trusted or public
 	Score   float32
+	Provenance
+	CreatedAt time.Time // zero when the store does not record it
+	Match     string    // MatchSemantic or MatchLexical
+}
+
+// How a chunk matched a search.
+const (
+	MatchSemantic = "semantic"
+	MatchLexical  = "lexical"
+)
+
+// Provenance links a chunk back to the timeline trace it was indexed from.
+// All fields are optional; manually stored chunks have none.
+type Provenance struct {
+	TraceID string `json:"trace_id,omitempty"`
+	EventID string `json:"event_id,omitempty"`
+	Channel string `json:"channel,omitempty"`
+	ChatID  string `json:"chat_id,omitempty"`
+}
+
+// payload adds the provenance fields to a vector store payload.
+func (p Provenance) payload(m map[string]interface{}) {
+	m["trace_id"] = p.TraceID
+	m["event_id"] = p.EventID
+	m["channel"] = p.Channel
+	m["chat_id"] = p.ChatID
+}
+
+func provenanceFromPayload(m map[string]interface{}) Provenance {
+	var p Provenance
+	p.TraceID, _ = m["trace_id"].(string)
+	p.EventID, _ = m["event_id"].(string)
+	p.Channel, _ = m["channel"].(string)
+	p.ChatID, _ = m["chat_id"].(string)
+	return p
 }
 
 // MemoryService provides high-level Store/Search operations for the memory system.
@@ -35,18 +73,26 @@ func NewMemoryService(store VectorStore, embedder provider.Embedder) *MemoryServ
 	return &MemoryService{store: store, embedder: embedder}
 }
 
-// Store embeds content and upserts it into the vector store.
+// Store embeds content and upserts it into the vector store with the default
+// privacy level of its source (see SourcePrivacy).
 // Returns the chunk ID. Gracefully degrades if embedder is nil.
 func (m *MemoryService) Store(ctx context.Context, content, source, tags string) (string, error) {
-	id := chunkID(source, content)
+	return m.StoreWithPrivacy(ctx, content, source, tags, "")
+}
+
+// StoreWithPrivacy is Store with an explicit privacy level. An empty or
+// unknown level falls back to the source default.
+func (m *MemoryService) StoreWithPrivacy(ctx context.Context, content, source, tags, privacy string) (string, error) {
+	return m.StoreItem(ctx, IndexItem{Content: content, Source: source, Tags: tags, Privacy: privacy})
+}
+
+// StoreItem stores an indexed item together with its provenance.
+func (m *MemoryService) StoreItem(ctx context.Context, item IndexItem) (string, error) {
+	id, payload := itemPayload(item)
 
 	if m.embedder == nil {
 		if ts, ok := m.store.(textCapableStore); ok {
-			err := ts.UpsertText(ctx, id, map[string]interface{}{
-				"content": content,
-				"source":  source,
-				"tags":    tags,
-			})
+			err := ts.UpsertText(ctx, id, payload)
 			if err != nil {
 				return "", fmt.Errorf("upsert text-only chunk: %w", err)
 			}
@@ -55,16 +101,12 @@ func (m *MemoryService) Store(ctx context.Context, content, source, tags string)
 		return "", nil
 	}
 
-	resp, err := m.embedder.Embed(ctx, &provider.EmbeddingRequest{Input: content})
+	resp, err := m.embedder.Embed(ctx, &provider.EmbeddingRequest{Input: item.Content})
 	if err != nil {
 		return "", fmt.Errorf("embed content: %w", err)
 	}
 
-	err = m.store.Upsert(ctx, id, resp.Vector, map[string]interface{}{
-		"content": content,
-		"source":  source,
-		"tags":    tags,
-	})
+	err = m.store.Upsert(ctx, id, resp.Vector, payload)
 	if err != nil {
 		return "", fmt.Errorf("upsert chunk: %w", err)
 	}
@@ -72,6 +114,23 @@ func (m *MemoryService) Store(ctx context.Context, content, source, tags string)
 	return id, nil
 }
 
+// itemPayload returns the chunk ID and vector store payload of an item.
+func itemPayload(item IndexItem) (string, map[string]interface{}) {
+	privacy := NormalizePrivacy(item.Privacy)
+	if privacy == "" {
+		privacy = SourcePrivacy(item.Source)
+	}
+	payload := map[string]interface{}{
+		"content":    item.Content,
+		"source":     item.Source,
+		"tags":       item.Tags,
+		"privacy":    privacy,
+		"created_at": time.Now().UTC().Format(time.RFC3339),
+	}
+	item.Provenance.payload(payload)
+	return chunkID(item.Source, item.Content), payload
+}
+
 // Search finds the most relevant memory chunks for the given query.
 // Gracefully degrades if embedder is nil (returns nil).
 func (m *MemoryService) Search(ctx context.Context, query string, limit int) ([]MemoryChunk, error) {
@@ -90,11 +149,16 @@ func (m *MemoryService) Search(ctx context.Context, query string, limit int) ([]
 	}
 
 	results, err := m.store.Search(ctx, resp.Vector, limit)
+	if errors.Is(err, ErrDimensionMismatch) {
+		// Still answer lexically, but surface the misconfiguration.
+		chunks, _ := m.searchTextFallback(ctx, query, limit)
+		return chunks, err
+	}
 	if err != nil {
 		return m.searchTextFallback(ctx, query, limit)
 	}
 
-	return chunksFromResults(results), nil
+	return chunksFromResults(results, MatchSemantic), nil
 }
 
 func (m *MemoryService) searchTextFallback(ctx context.Context, query string, limit int) ([]MemoryChunk, error) {
@@ -106,26 +170,44 @@ func (m *MemoryService) searchTextFallback(ctx context.Context, query string, li
 	if err != nil {
 		return nil, fmt.Errorf("text fallback search: %w", err)
 	}
-	return chunksFromResults(results), nil
+	return chunksFromResults(results, MatchLexical), nil
 }
 
-func chunksFromResults(results []Result) []MemoryChunk {
+func chunksFromResults(results []Result, match string) []MemoryChunk {
 	chunks := make([]MemoryChunk, len(results))
 	for i, r := range results {
 		content, _ := r.Payload["content"].(string)
 		source, _ := r.Payload["source"].(string)
 		tags, _ := r.Payload["tags"].(string)
+		privacy, _ := r.Payload["privacy"].(string)
+		if privacy = NormalizePrivacy(privacy); privacy == "" {
+			privacy = SourcePrivacy(source)
+		}
 		chunks[i] = MemoryChunk{
-			ID:      r.ID,
-			Content: content,
-			Source:  source,
-			Tags:    tags,
-			Score:   r.Score,
+			ID:         r.ID,
+			Content:    content,
+			Source:     source,
+			Tags:       tags,
+			Privacy:    privacy,
+			Score:      r.Score,
+			Provenance: provenanceFromPayload(r.Payload),
+			CreatedAt:  createdAtFromPayload(r.Payload),
+			Match:      match,
 		}
 	}
 	return chunks
 }
 
+// createdAtFromPayload reads the RFC 3339 created_at of a payload.
+func createdAtFromPayload(m map[string]interface{}) time.Time {
+	s, _ := m["created_at"].(string)
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
 // SearchBySource searches memory filtered by source prefix.
 // Results are post-filtered to only include chunks matching sourcePrefix.
 func (m *MemoryService) SearchBySource(ctx context.Context, query string, sourcePrefix string, limit int) ([]MemoryChunk, error) {
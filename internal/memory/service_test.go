@@ -119,6 +119,35 @@ func TestMemoryService_NilEmbedder(t *testing.T) {
 	}
 }
 
+func TestMemoryService_StoreItemKeepsProvenance(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	ctx := context.Background()
+	prov := Provenance{TraceID: "trace-1", EventID: "TOOL_trace-1_read_file_1", Channel: "slack", ChatID: "C1"}
+
+	// Vector path first, then the text-only path over the same row.
+	for _, emb := range []provider.Embedder{&fakeEmbedder{vector: []float32{1, 0, 0}}, nil} {
+		svc := NewMemoryService(NewSQLiteVecStore(db, 3), emb)
+		item := FormatToolResult("read_file", map[string]any{"path": "deploy.md"}, "deploy window is friday")
+		item.Provenance = prov
+		if _, err := svc.StoreItem(ctx, item); err != nil {
+			t.Fatal(err)
+		}
+		chunks, err := svc.Search(ctx, "deploy window", 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(chunks) != 1 || chunks[0].Provenance != prov {
+			t.Fatalf("expected provenance %+v (embedder=%v), got %+v", prov, emb != nil, chunks)
+		}
+	}
+
+	pair := FormatConversationPair("when do we deploy?", "friday", "whatsapp", "49123")
+	if pair.Channel != "whatsapp" || pair.ChatID != "49123" {
+		t.Fatalf("conversation pair must record its chat, got %+v", pair.Provenance)
+	}
+}
+
 func TestMemoryService_EmbedErrorFallsBackToTextSearch(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
@@ -4,17 +4,32 @@ import (
 	"context"
 	"database/sql"
 	"encoding/binary"
+	"errors"
+	"fmt"
 	"math"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 )
 
+// ErrDimensionMismatch is returned when a vector does not have the dimension
+// of the index it is written to or searched in.
+var ErrDimensionMismatch = errors.New("embedding dimension mismatch")
+
 // SQLiteVecStore implements VectorStore using the shared timeline SQLite DB.
 // Embeddings are stored as BLOBs (little-endian float32 arrays) in the
 // memory_chunks table. Cosine similarity is computed in Go — at <10K chunks
 // this is sub-millisecond.
+//
+// A named index (see OpenSQLiteVecIndex) keeps its vectors in
+// memory_embeddings instead, so several embedding models can index the same
+// chunks side by side.
 type SQLiteVecStore struct {
-	db        *sql.DB
+	db    *sql.DB
+	index string
+
+	mu        sync.RWMutex
 	dimension int
 }
 
@@ -24,6 +39,97 @@ func NewSQLiteVecStore(db *sql.DB, dimension int) *SQLiteVecStore {
 	return &SQLiteVecStore{db: db, dimension: dimension}
 }
 
+// OpenSQLiteVecIndex opens the named per-model index. The dimension recorded
+// for an existing index wins; a configured dimension that disagrees with it is
+// an error rather than a source of silently mismatched results. A new index
+// with dimension <= 0 adopts the dimension of its first vector. A new index
+// also adopts legacy memory_chunks embeddings of the same dimension.
+func OpenSQLiteVecIndex(ctx context.Context, db *sql.DB, name string, dimension int) (*SQLiteVecStore, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("embedding index name is required")
+	}
+	var stored int
+	err := db.QueryRowContext(ctx, `SELECT dimension FROM memory_embedding_indexes WHERE name = ?`, name).Scan(&stored)
+	switch {
+	case err == nil:
+		if dimension > 0 && dimension != stored {
+			return nil, fmt.Errorf("%w: index %q was built with %d dimensions but %d are configured; re-index or wipe memory before changing the dimension",
+				ErrDimensionMismatch, name, stored, dimension)
+		}
+		dimension = stored
+	case errors.Is(err, sql.ErrNoRows):
+		if dimension > 0 {
+			if err := createVecIndex(ctx, db, name, dimension); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("load embedding index %q: %w", name, err)
+	}
+	return &SQLiteVecStore{db: db, index: name, dimension: dimension}, nil
+}
+
+// createVecIndex records a new index and copies legacy embeddings of the same
+// dimension into it.
+func createVecIndex(ctx context.Context, db *sql.DB, name string, dimension int) error {
+	res, err := db.ExecContext(ctx, `INSERT OR IGNORE INTO memory_embedding_indexes (name, dimension) VALUES (?, ?)`, name, dimension)
+	if err != nil {
+		return fmt.Errorf("create embedding index %q: %w", name, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil
+	}
+	_, err = db.ExecContext(ctx, `
+		INSERT OR IGNORE INTO memory_embeddings (chunk_id, index_name, embedding, updated_at)
+		SELECT id, ?, embedding, updated_at
+		FROM memory_chunks
+		WHERE embedding IS NOT NULL AND length(embedding) = ?
+	`, name, dimension*4)
+	if err != nil {
+		return fmt.Errorf("adopt legacy embeddings into %q: %w", name, err)
+	}
+	return nil
+}
+
+// Index returns the index name, or "" for the legacy memory_chunks column.
+func (s *SQLiteVecStore) Index() string {
+	return s.index
+}
+
+// Dimension returns the index dimension (0 while not yet negotiated).
+func (s *SQLiteVecStore) Dimension() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.dimension
+}
+
+// checkDimension validates a vector against the index dimension. A named
+// index without a dimension adopts the vector's length when negotiate is set.
+func (s *SQLiteVecStore) checkDimension(ctx context.Context, vector []float32, negotiate bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dimension <= 0 {
+		if !negotiate || s.index == "" || len(vector) == 0 {
+			return nil
+		}
+		if err := createVecIndex(ctx, s.db, s.index, len(vector)); err != nil {
+			return err
+		}
+		if err := s.db.QueryRowContext(ctx, `SELECT dimension FROM memory_embedding_indexes WHERE name = ?`, s.index).Scan(&s.dimension); err != nil {
+			return fmt.Errorf("load embedding index %q: %w", s.index, err)
+		}
+	}
+	if len(vector) != s.dimension {
+		name := s.index
+		if name == "" {
+			name = "default"
+		}
+		return fmt.Errorf("%w: index %q expects %d dimensions, got %d", ErrDimensionMismatch, name, s.dimension, len(vector))
+	}
+	return nil
+}
+
 // EnsureCollection is a no-op — the table is created by the schema migration.
 func (s *SQLiteVecStore) EnsureCollection(ctx context.Context) error {
 	return nil
@@ -34,26 +140,57 @@ func (s *SQLiteVecStore) Upsert(ctx context.Context, id string, vector []float32
 	content, _ := payload["content"].(string)
 	source, _ := payload["source"].(string)
 	tags, _ := payload["tags"].(string)
+	privacy, _ := payload["privacy"].(string)
+	prov := provenanceFromPayload(payload)
 	if source == "" {
 		source = "user"
 	}
 
 	var blob []byte
 	if len(vector) > 0 {
+		if err := s.checkDimension(ctx, vector, true); err != nil {
+			return err
+		}
 		blob = encodeFloat32s(vector)
 	}
+	if s.index != "" {
+		return s.upsertIndexed(ctx, id, blob, payload)
+	}
 
 	_, err := s.db.ExecContext(ctx, `
-		INSERT INTO memory_chunks (id, content, embedding, source, tags)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO memory_chunks (id, content, embedding, source, tags, privacy, trace_id, event_id, channel, chat_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
 			content = excluded.content,
 			embedding = excluded.embedding,
 			source = excluded.source,
 			tags = excluded.tags,
+			privacy = excluded.privacy,
+			trace_id = excluded.trace_id,
+			event_id = excluded.event_id,
+			channel = excluded.channel,
+			chat_id = excluded.chat_id,
 			version = memory_chunks.version + 1,
 			updated_at = CURRENT_TIMESTAMP
-	`, id, content, blob, source, tags)
+	`, id, content, blob, source, tags, privacy, prov.TraceID, prov.EventID, prov.Channel, prov.ChatID)
+	return err
+}
+
+// upsertIndexed stores the chunk content and its vector in the named index.
+func (s *SQLiteVecStore) upsertIndexed(ctx context.Context, id string, blob []byte, payload map[string]interface{}) error {
+	if err := s.UpsertText(ctx, id, payload); err != nil {
+		return err
+	}
+	if blob == nil {
+		return nil
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO memory_embeddings (chunk_id, index_name, embedding)
+		VALUES (?, ?, ?)
+		ON CONFLICT(chunk_id, index_name) DO UPDATE SET
+			embedding = excluded.embedding,
+			updated_at = CURRENT_TIMESTAMP
+	`, id, s.index, blob)
 	return err
 }
 
@@ -62,20 +199,27 @@ func (s *SQLiteVecStore) UpsertText(ctx context.Context, id string, payload map[
 	content, _ := payload["content"].(string)
 	source, _ := payload["source"].(string)
 	tags, _ := payload["tags"].(string)
+	privacy, _ := payload["privacy"].(string)
+	prov := provenanceFromPayload(payload)
 	if source == "" {
 		source = "user"
 	}
 
 	_, err := s.db.ExecContext(ctx, `
-		INSERT INTO memory_chunks (id, content, embedding, source, tags)
-		VALUES (?, ?, NULL, ?, ?)
+		INSERT INTO memory_chunks (id, content, embedding, source, tags, privacy, trace_id, event_id, channel, chat_id)
+		VALUES (?, ?, NULL, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
 			content = excluded.content,
 			source = excluded.source,
 			tags = excluded.tags,
+			privacy = excluded.privacy,
+			trace_id = excluded.trace_id,
+			event_id = excluded.event_id,
+			channel = excluded.channel,
+			chat_id = excluded.chat_id,
 			version = memory_chunks.version + 1,
 			updated_at = CURRENT_TIMESTAMP
-	`, id, content, source, tags)
+	`, id, content, source, tags, privacy, prov.TraceID, prov.EventID, prov.Channel, prov.ChatID)
 	return err
 }
 
@@ -91,7 +235,8 @@ func (s *SQLiteVecStore) SearchText(ctx context.Context, query string, limit int
 
 	pattern := "%" + strings.ToLower(query) + "%"
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, content, source, tags
+		SELECT id, content, source, tags, COALESCE(privacy, ''),
+			COALESCE(trace_id, ''), COALESCE(event_id, ''), COALESCE(channel, ''), COALESCE(chat_id, ''), created_at
 		FROM memory_chunks
 		WHERE LOWER(content) LIKE ?
 		ORDER BY updated_at DESC
@@ -104,30 +249,53 @@ func (s *SQLiteVecStore) SearchText(ctx context.Context, query string, limit int
 
 	var out []Result
 	for rows.Next() {
-		var id, content, source, tags string
-		if err := rows.Scan(&id, &content, &source, &tags); err != nil {
+		var id, content, source, tags, privacy string
+		var prov Provenance
+		var createdAt time.Time
+		if err := rows.Scan(&id, &content, &source, &tags, &privacy, &prov.TraceID, &prov.EventID, &prov.Channel, &prov.ChatID, &createdAt); err != nil {
 			continue
 		}
+		payload := map[string]interface{}{
+			"content":    content,
+			"source":     source,
+			"tags":       tags,
+			"privacy":    privacy,
+			"created_at": createdAt.UTC().Format(time.RFC3339),
+		}
+		prov.payload(payload)
 		out = append(out, Result{
-			ID:    id,
-			Score: 1, // lexical fallback; deterministic non-zero score
-			Payload: map[string]interface{}{
-				"content": content,
-				"source":  source,
-				"tags":    tags,
-			},
+			ID:      id,
+			Score:   1, // lexical fallback; deterministic non-zero score
+			Payload: payload,
 		})
 	}
 	return out, nil
 }
 
-// Search finds the top-k most similar chunks by cosine similarity.
+// Search finds the top-k most similar chunks by cosine similarity. A query
+// vector whose dimension differs from the index returns ErrDimensionMismatch.
 func (s *SQLiteVecStore) Search(ctx context.Context, vector []float32, limit int) ([]Result, error) {
-	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, content, embedding, source, tags
-		FROM memory_chunks
-		WHERE embedding IS NOT NULL
-	`)
+	if err := s.checkDimension(ctx, vector, false); err != nil {
+		return nil, err
+	}
+	var rows *sql.Rows
+	var err error
+	if s.index != "" {
+		rows, err = s.db.QueryContext(ctx, `
+			SELECT c.id, c.content, e.embedding, c.source, c.tags, COALESCE(c.privacy, ''),
+				COALESCE(c.trace_id, ''), COALESCE(c.event_id, ''), COALESCE(c.channel, ''), COALESCE(c.chat_id, ''), c.created_at
+			FROM memory_embeddings e
+			JOIN memory_chunks c ON c.id = e.chunk_id
+			WHERE e.index_name = ?
+		`, s.index)
+	} else {
+		rows, err = s.db.QueryContext(ctx, `
+			SELECT id, content, embedding, source, tags, COALESCE(privacy, ''),
+				COALESCE(trace_id, ''), COALESCE(event_id, ''), COALESCE(channel, ''), COALESCE(chat_id, ''), created_at
+			FROM memory_chunks
+			WHERE embedding IS NOT NULL
+		`)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -141,10 +309,12 @@ func (s *SQLiteVecStore) Search(ctx context.Context, vector []float32, limit int
 	var candidates []scored
 
 	for rows.Next() {
-		var id, content, source, tags string
+		var id, content, source, tags, privacy string
+		var prov Provenance
 		var blob []byte
+		var createdAt time.Time
 
-		if err := rows.Scan(&id, &content, &blob, &source, &tags); err != nil {
+		if err := rows.Scan(&id, &content, &blob, &source, &tags, &privacy, &prov.TraceID, &prov.EventID, &prov.Channel, &prov.ChatID, &createdAt); err != nil {
 			continue
 		}
 
@@ -154,16 +324,20 @@ func (s *SQLiteVecStore) Search(ctx context.Context, vector []float32, limit int
 		}
 
 		sim := cosineSimilarity(vector, stored)
+		payload := map[string]interface{}{
+			"content":    content,
+			"source":     source,
+			"tags":       tags,
+			"privacy":    privacy,
+			"created_at": createdAt.UTC().Format(time.RFC3339),
+		}
+		prov.payload(payload)
 
 		candidates = append(candidates, scored{
 			result: Result{
-				ID:    id,
-				Score: sim,
-				Payload: map[string]interface{}{
-					"content": content,
-					"source":  source,
-					"tags":    tags,
-				},
+				ID:      id,
+				Score:   sim,
+				Payload: payload,
 			},
 			score: sim,
 		})
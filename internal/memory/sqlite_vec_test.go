@@ -3,6 +3,7 @@ package memory
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"math"
 	"testing"
 
@@ -22,10 +23,32 @@ func setupTestDB(t *testing.T) *sql.DB {
 			embedding BLOB,
 			source TEXT NOT NULL DEFAULT 'user',
 			tags TEXT DEFAULT '',
+			privacy TEXT DEFAULT '',
+			trace_id TEXT DEFAULT '',
+			event_id TEXT DEFAULT '',
+			channel TEXT DEFAULT '',
+			chat_id TEXT DEFAULT '',
 			version INTEGER NOT NULL DEFAULT 1,
 			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
-		)
+		);
+		CREATE TABLE IF NOT EXISTS memory_embedding_indexes (
+			name TEXT PRIMARY KEY,
+			dimension INTEGER NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE TABLE IF NOT EXISTS memory_embeddings (
+			chunk_id TEXT NOT NULL,
+			index_name TEXT NOT NULL,
+			embedding BLOB NOT NULL,
+			updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (chunk_id, index_name)
+		);
+		CREATE TRIGGER IF NOT EXISTS trg_memory_chunks_delete_embeddings
+			AFTER DELETE ON memory_chunks
+			BEGIN
+				DELETE FROM memory_embeddings WHERE chunk_id = OLD.id;
+			END;
 	`)
 	if err != nil {
 		t.Fatal(err)
@@ -198,6 +221,20 @@ func TestSQLiteVecStore_DimensionMismatchSkipped(t *testing.T) {
 	}
 }
 
+func TestSQLiteVecStore_QueryDimensionMismatchErrors(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	store := NewSQLiteVecStore(db, 3)
+	ctx := context.Background()
+
+	if err := store.Upsert(ctx, "a", []float32{1, 0}, map[string]interface{}{"content": "short"}); !errors.Is(err, ErrDimensionMismatch) {
+		t.Fatalf("expected dimension mismatch on upsert, got %v", err)
+	}
+	if _, err := store.Search(ctx, []float32{1, 0, 0, 0}, 5); !errors.Is(err, ErrDimensionMismatch) {
+		t.Fatalf("expected dimension mismatch on search, got %v", err)
+	}
+}
+
 func TestCosineSimilarity(t *testing.T) {
 	// Same vector → 1.0
 	sim := cosineSimilarity([]float32{1, 0, 0}, []float32{1, 0, 0})
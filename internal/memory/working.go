@@ -2,13 +2,34 @@ package memory
 
 import (
 	"database/sql"
+	"log/slog"
+	"sync/atomic"
 	"time"
 )
 
+// WorkingMemoryConfig bounds working memory so it stays short-lived context
+// rather than a second long-term store.
+type WorkingMemoryConfig struct {
+	DefaultTTL       time.Duration // expiry for entries saved without an explicit TTL (0 = never)
+	MaxBytesPerScope int           // content budget per resource across all its threads (0 = unlimited)
+}
+
+// WorkingMemoryStats reports working-memory size and eviction counters since start.
+type WorkingMemoryStats struct {
+	Entries        int   `json:"entries"`
+	Bytes          int   `json:"bytes"`
+	EvictedExpired int64 `json:"evicted_expired"`
+	EvictedBudget  int64 `json:"evicted_budget"`
+}
+
 // WorkingMemoryStore provides scoped persistent working memory keyed by
 // (resourceID, threadID). Resource-scoped entries use threadID = "".
 type WorkingMemoryStore struct {
-	db *sql.DB
+	db     *sql.DB
+	config WorkingMemoryConfig
+
+	evictedExpired atomic.Int64
+	evictedBudget  atomic.Int64
 }
 
 // WorkingMemoryEntry represents a single working-memory record.
@@ -22,10 +43,32 @@ type WorkingMemoryEntry struct {
 // NewWorkingMemoryStore creates a new store backed by the given database.
 // Returns nil if db is nil (callers must handle nil gracefully).
 func NewWorkingMemoryStore(db *sql.DB) *WorkingMemoryStore {
+	return NewWorkingMemoryStoreWithConfig(db, WorkingMemoryConfig{})
+}
+
+// NewWorkingMemoryStoreWithConfig creates a store with TTL and size limits.
+func NewWorkingMemoryStoreWithConfig(db *sql.DB, cfg WorkingMemoryConfig) *WorkingMemoryStore {
 	if db == nil {
 		return nil
 	}
-	return &WorkingMemoryStore{db: db}
+	if cfg.DefaultTTL < 0 {
+		cfg.DefaultTTL = 0
+	}
+	if cfg.MaxBytesPerScope < 0 {
+		cfg.MaxBytesPerScope = 0
+	}
+	return &WorkingMemoryStore{db: db, config: cfg}
+}
+
+// liveFilter excludes expired entries; it takes the current UTC time as argument.
+const liveFilter = `(expires_at IS NULL OR expires_at > ?)`
+
+func nowUTC() time.Time { return time.Now().UTC() }
+
+// touch records a read so budget eviction drops the least recently used entries first.
+func (w *WorkingMemoryStore) touch(resourceID, threadID string) {
+	_, _ = w.db.Exec(`UPDATE working_memory SET last_accessed_at = ? WHERE resource_id = ? AND thread_id = ?`,
+		nowUTC(), resourceID, threadID)
 }
 
 // Load returns the working memory content for a resource and optional thread.
@@ -40,10 +83,11 @@ func (w *WorkingMemoryStore) Load(resourceID, threadID string) (string, error) {
 	if threadID != "" {
 		var content string
 		err := w.db.QueryRow(
-			`SELECT content FROM working_memory WHERE resource_id = ? AND thread_id = ?`,
-			resourceID, threadID,
+			`SELECT content FROM working_memory WHERE resource_id = ? AND thread_id = ? AND `+liveFilter,
+			resourceID, threadID, nowUTC(),
 		).Scan(&content)
 		if err == nil {
+			w.touch(resourceID, threadID)
 			return content, nil
 		}
 		if err != sql.ErrNoRows {
@@ -55,30 +99,129 @@ func (w *WorkingMemoryStore) Load(resourceID, threadID string) (string, error) {
 	// Resource-level (thread_id = '')
 	var content string
 	err := w.db.QueryRow(
-		`SELECT content FROM working_memory WHERE resource_id = ? AND thread_id = ''`,
-		resourceID,
+		`SELECT content FROM working_memory WHERE resource_id = ? AND thread_id = '' AND `+liveFilter,
+		resourceID, nowUTC(),
 	).Scan(&content)
 	if err == sql.ErrNoRows {
 		return "", nil
 	}
+	if err == nil {
+		w.touch(resourceID, "")
+	}
 	return content, err
 }
 
-// Save persists working memory content. Uses upsert (INSERT OR REPLACE).
+// Save persists working memory content with the configured default TTL.
+// Uses upsert (INSERT OR REPLACE).
 func (w *WorkingMemoryStore) Save(resourceID, threadID, content string) error {
+	if w == nil {
+		return nil
+	}
+	return w.SaveWithTTL(resourceID, threadID, content, w.config.DefaultTTL)
+}
+
+// SaveWithTTL persists working memory content that expires after ttl
+// (0 = never) and then enforces the per-resource size budget.
+func (w *WorkingMemoryStore) SaveWithTTL(resourceID, threadID, content string, ttl time.Duration) error {
 	if w == nil || w.db == nil {
 		return nil
 	}
-	if threadID == "" {
-		threadID = "" // explicit for clarity
+	now := nowUTC()
+	var expiresAt any
+	if ttl > 0 {
+		expiresAt = now.Add(ttl)
 	}
 	_, err := w.db.Exec(
-		`INSERT INTO working_memory (resource_id, thread_id, content, updated_at)
-		 VALUES (?, ?, ?, ?)
-		 ON CONFLICT(resource_id, thread_id) DO UPDATE SET content = excluded.content, updated_at = excluded.updated_at`,
-		resourceID, threadID, content, time.Now(),
+		`INSERT INTO working_memory (resource_id, thread_id, content, updated_at, expires_at, last_accessed_at)
+		 VALUES (?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(resource_id, thread_id) DO UPDATE SET content = excluded.content, updated_at = excluded.updated_at,
+		 expires_at = excluded.expires_at, last_accessed_at = excluded.last_accessed_at`,
+		resourceID, threadID, content, time.Now(), expiresAt, now,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	if _, err := w.PurgeExpired(); err != nil {
+		slog.Warn("Working memory purge failed", "error", err)
+	}
+	return w.enforceBudget(resourceID, threadID)
+}
+
+// enforceBudget evicts the least recently used entries of a resource until its
+// total content fits MaxBytesPerScope. The entry just written is never evicted.
+func (w *WorkingMemoryStore) enforceBudget(resourceID, keepThreadID string) error {
+	if w.config.MaxBytesPerScope <= 0 {
+		return nil
+	}
+	rows, err := w.db.Query(
+		`SELECT thread_id, length(CAST(content AS BLOB)) FROM working_memory WHERE resource_id = ?
+		 ORDER BY COALESCE(last_accessed_at, updated_at) ASC`,
+		resourceID,
+	)
+	if err != nil {
+		return err
+	}
+	type entry struct {
+		threadID string
+		size     int
+	}
+	var entries []entry
+	total := 0
+	for rows.Next() {
+		var e entry
+		if err := rows.Scan(&e.threadID, &e.size); err != nil {
+			rows.Close()
+			return err
+		}
+		entries = append(entries, e)
+		total += e.size
+	}
+	rows.Close()
+
+	for _, e := range entries {
+		if total <= w.config.MaxBytesPerScope {
+			break
+		}
+		if e.threadID == keepThreadID {
+			continue
+		}
+		if _, err := w.db.Exec(`DELETE FROM working_memory WHERE resource_id = ? AND thread_id = ?`, resourceID, e.threadID); err != nil {
+			return err
+		}
+		total -= e.size
+		w.evictedBudget.Add(1)
+		slog.Info("Working memory evicted over budget", "resource_id", resourceID, "thread_id", e.threadID, "bytes", e.size)
+	}
+	return nil
+}
+
+// PurgeExpired deletes entries past their TTL and returns how many were removed.
+func (w *WorkingMemoryStore) PurgeExpired() (int, error) {
+	if w == nil || w.db == nil {
+		return 0, nil
+	}
+	res, err := w.db.Exec(`DELETE FROM working_memory WHERE expires_at IS NOT NULL AND expires_at <= ?`, nowUTC())
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	w.evictedExpired.Add(n)
+	return int(n), nil
+}
+
+// Stats returns current size and eviction counters.
+func (w *WorkingMemoryStore) Stats() WorkingMemoryStats {
+	if w == nil || w.db == nil {
+		return WorkingMemoryStats{}
+	}
+	var st WorkingMemoryStats
+	_ = w.db.QueryRow(
+		`SELECT COUNT(*), COALESCE(SUM(length(CAST(content AS BLOB))), 0) FROM working_memory WHERE `+liveFilter,
+		nowUTC(),
+	).Scan(&st.Entries, &st.Bytes)
+	st.EvictedExpired = w.evictedExpired.Load()
+	st.EvictedBudget = w.evictedBudget.Load()
+	return st
 }
 
 // LoadBoth returns both thread-specific and resource-level working memory.
@@ -88,18 +231,23 @@ func (w *WorkingMemoryStore) LoadBoth(resourceID, threadID string) (resourceCont
 		return "", "", nil
 	}
 
+	now := nowUTC()
 	// Resource-level
-	_ = w.db.QueryRow(
-		`SELECT content FROM working_memory WHERE resource_id = ? AND thread_id = ''`,
-		resourceID,
-	).Scan(&resourceContent)
+	if w.db.QueryRow(
+		`SELECT content FROM working_memory WHERE resource_id = ? AND thread_id = '' AND `+liveFilter,
+		resourceID, now,
+	).Scan(&resourceContent) == nil {
+		w.touch(resourceID, "")
+	}
 
 	// Thread-specific
 	if threadID != "" {
-		_ = w.db.QueryRow(
-			`SELECT content FROM working_memory WHERE resource_id = ? AND thread_id = ?`,
-			resourceID, threadID,
-		).Scan(&threadContent)
+		if w.db.QueryRow(
+			`SELECT content FROM working_memory WHERE resource_id = ? AND thread_id = ? AND `+liveFilter,
+			resourceID, threadID, now,
+		).Scan(&threadContent) == nil {
+			w.touch(resourceID, threadID)
+		}
 	}
 
 	return resourceContent, threadContent, nil
@@ -111,7 +259,7 @@ func (w *WorkingMemoryStore) ListAll() ([]WorkingMemoryEntry, error) {
 		return nil, nil
 	}
 
-	rows, err := w.db.Query(`SELECT resource_id, thread_id, content, updated_at FROM working_memory ORDER BY updated_at DESC`)
+	rows, err := w.db.Query(`SELECT resource_id, thread_id, content, updated_at FROM working_memory WHERE `+liveFilter+` ORDER BY updated_at DESC`, nowUTC())
 	if err != nil {
 		return nil, err
 	}
@@ -2,7 +2,9 @@ package memory
 
 import (
 	"database/sql"
+	"strings"
 	"testing"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -18,6 +20,8 @@ func setupWorkingDB(t *testing.T) *sql.DB {
 		thread_id   TEXT NOT NULL DEFAULT '',
 		content     TEXT NOT NULL DEFAULT '',
 		updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+		expires_at  DATETIME,
+		last_accessed_at DATETIME,
 		PRIMARY KEY (resource_id, thread_id)
 	)`)
 	if err != nil {
@@ -160,3 +164,66 @@ func TestWorkingDelete(t *testing.T) {
 		t.Fatalf("expected deleted, got %q", got)
 	}
 }
+
+func TestWorkingTTLExpiry(t *testing.T) {
+	db := setupWorkingDB(t)
+	defer db.Close()
+	w := NewWorkingMemoryStoreWithConfig(db, WorkingMemoryConfig{DefaultTTL: time.Hour})
+
+	if err := w.SaveWithTTL("user-1", "thread-1", "short-lived", time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Save("user-1", "", "default ttl"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	res, thr, _ := w.LoadBoth("user-1", "thread-1")
+	if thr != "" {
+		t.Fatalf("expired thread entry should be hidden, got %q", thr)
+	}
+	if res != "default ttl" {
+		t.Fatalf("resource entry should still be live, got %q", res)
+	}
+	n, err := w.PurgeExpired()
+	if err != nil || n != 1 {
+		t.Fatalf("expected 1 purged entry, got %d err=%v", n, err)
+	}
+	if st := w.Stats(); st.Entries != 1 || st.EvictedExpired != 1 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+}
+
+func TestWorkingBudgetEvictsLeastRecentlyUsed(t *testing.T) {
+	db := setupWorkingDB(t)
+	defer db.Close()
+	w := NewWorkingMemoryStoreWithConfig(db, WorkingMemoryConfig{MaxBytesPerScope: 250})
+
+	chunk := strings.Repeat("x", 100)
+	w.Save("user-1", "t1", chunk)
+	time.Sleep(2 * time.Millisecond)
+	w.Save("user-1", "t2", chunk)
+	time.Sleep(2 * time.Millisecond)
+	w.Save("other", "t1", chunk) // separate scope, never evicted by user-1
+	// Reading t1 makes t2 the least recently used entry.
+	if got, _ := w.Load("user-1", "t1"); got != chunk {
+		t.Fatal("expected t1 content")
+	}
+	time.Sleep(2 * time.Millisecond)
+	w.Save("user-1", "t3", chunk)
+
+	if got, _ := w.Load("user-1", "t2"); got != "" {
+		t.Fatal("t2 should have been evicted")
+	}
+	for _, th := range []string{"t1", "t3"} {
+		if got, _ := w.Load("user-1", th); got != chunk {
+			t.Fatalf("%s should be kept", th)
+		}
+	}
+	if got, _ := w.Load("other", "t1"); got != chunk {
+		t.Fatal("other scope must not be evicted")
+	}
+	if st := w.Stats(); st.EvictedBudget != 1 || st.Entries != 3 || st.Bytes != 300 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+}
@@ -0,0 +1,237 @@
+// Package metrics is a small, dependency-free metrics facade. Packages
+// declare counters, gauges and histograms against the default registry and
+// the gateway exposes them in Prometheus text format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefBuckets are the default histogram buckets (seconds).
+var DefBuckets = []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+
+// Default is the process-wide registry.
+var Default = NewRegistry()
+
+// Registry holds metric families and renders them.
+type Registry struct {
+	mu        sync.Mutex
+	families  map[string]*family
+	collect   []func()
+	collectMu sync.Mutex
+}
+
+type family struct {
+	name    string
+	help    string
+	kind    string // counter, gauge, histogram
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	counts      []uint64 // histogram: per bucket, non-cumulative
+	sum         float64
+	count       uint64
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{families: map[string]*family{}}
+}
+
+func (r *Registry) register(name, help, kind string, buckets []float64, labels []string) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f, ok := r.families[name]; ok {
+		return f
+	}
+	f := &family{name: name, help: help, kind: kind, labels: labels, buckets: buckets, series: map[string]*series{}}
+	r.families[name] = f
+	return f
+}
+
+// OnCollect registers fn to run before every scrape, e.g. to refresh gauges
+// that mirror state owned elsewhere.
+func (r *Registry) OnCollect(fn func()) {
+	r.collectMu.Lock()
+	r.collect = append(r.collect, fn)
+	r.collectMu.Unlock()
+}
+
+func (f *family) get(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	s := f.series[key]
+	if s == nil {
+		s = &series{labelValues: append([]string(nil), values...)}
+		if f.kind == "histogram" {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// Counter is a monotonically increasing value.
+type Counter struct{ f *family }
+
+// Inc adds 1 to the series identified by labelValues.
+func (c *Counter) Inc(labelValues ...string) { c.Add(1, labelValues...) }
+
+// Add adds v (must be >= 0) to the series identified by labelValues.
+func (c *Counter) Add(v float64, labelValues ...string) {
+	c.f.mu.Lock()
+	c.f.get(labelValues).value += v
+	c.f.mu.Unlock()
+}
+
+// Gauge is a value that can go up and down.
+type Gauge struct{ f *family }
+
+// Set sets the series identified by labelValues.
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.f.mu.Lock()
+	g.f.get(labelValues).value = v
+	g.f.mu.Unlock()
+}
+
+// Add adds v (may be negative) to the series identified by labelValues.
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	g.f.mu.Lock()
+	g.f.get(labelValues).value += v
+	g.f.mu.Unlock()
+}
+
+// Histogram tracks the distribution of observed values.
+type Histogram struct{ f *family }
+
+// Observe records v in the series identified by labelValues.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	h.f.mu.Lock()
+	s := h.f.get(labelValues)
+	for i, le := range h.f.buckets {
+		if v <= le {
+			s.counts[i]++
+			break
+		}
+	}
+	s.sum += v
+	s.count++
+	h.f.mu.Unlock()
+}
+
+// Counter returns the counter registered under name, creating it if needed.
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	return &Counter{r.register(name, help, "counter", nil, labels)}
+}
+
+// Gauge returns the gauge registered under name, creating it if needed.
+func (r *Registry) Gauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{r.register(name, help, "gauge", nil, labels)}
+}
+
+// Histogram returns the histogram registered under name, creating it if
+// needed. A nil buckets slice uses DefBuckets.
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	return &Histogram{r.register(name, help, "histogram", buckets, labels)}
+}
+
+// NewCounter registers a counter on the default registry.
+func NewCounter(name, help string, labels ...string) *Counter {
+	return Default.Counter(name, help, labels...)
+}
+
+// NewGauge registers a gauge on the default registry.
+func NewGauge(name, help string, labels ...string) *Gauge {
+	return Default.Gauge(name, help, labels...)
+}
+
+// NewHistogram registers a histogram on the default registry.
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return Default.Histogram(name, help, buckets, labels...)
+}
+
+// WritePrometheus runs the collect hooks and writes all families in
+// Prometheus text exposition format, sorted by name.
+func (r *Registry) WritePrometheus(w io.Writer) {
+	r.collectMu.Lock()
+	hooks := append([]func(){}, r.collect...)
+	r.collectMu.Unlock()
+	for _, fn := range hooks {
+		fn()
+	}
+
+	r.mu.Lock()
+	fams := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		fams = append(fams, f)
+	}
+	r.mu.Unlock()
+	sort.Slice(fams, func(i, j int) bool { return fams[i].name < fams[j].name })
+
+	for _, f := range fams {
+		f.write(w)
+	}
+}
+
+func (f *family) write(w io.Writer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, f.help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+	keys := make([]string, 0, len(f.series))
+	for k := range f.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s := f.series[k]
+		if f.kind != "histogram" {
+			fmt.Fprintf(w, "%s%s %s\n", f.name, labelString(f.labels, s.labelValues, "", ""), formatFloat(s.value))
+			continue
+		}
+		var cumulative uint64
+		for i, le := range f.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, labelString(f.labels, s.labelValues, "le", formatFloat(le)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, labelString(f.labels, s.labelValues, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", f.name, labelString(f.labels, s.labelValues, "", ""), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", f.name, labelString(f.labels, s.labelValues, "", ""), s.count)
+	}
+}
+
+func labelString(names, values []string, extraName, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+	parts := make([]string, 0, len(names)+1)
+	for i, n := range names {
+		parts = append(parts, n+"="+strconv.Quote(values[i]))
+	}
+	if extraName != "" {
+		parts = append(parts, extraName+"="+strconv.Quote(extraValue))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistryWritePrometheus(t *testing.T) {
+	reg := NewRegistry()
+	c := reg.Counter("test_events_total", "Events.", "kind")
+	c.Inc("a")
+	c.Add(2, "a")
+	c.Inc("b")
+	if again := reg.Counter("test_events_total", "Events.", "kind"); again.f != c.f {
+		t.Fatal("re-registering a name must return the same family")
+	}
+
+	depth := reg.Gauge("test_depth", "Depth.")
+	reg.OnCollect(func() { depth.Set(7) })
+
+	h := reg.Histogram("test_latency_seconds", "Latency.", []float64{0.1, 1}, "op")
+	h.Observe(0.05, "x")
+	h.Observe(0.5, "x")
+	h.Observe(3, "x")
+
+	var sb strings.Builder
+	reg.WritePrometheus(&sb)
+	out := sb.String()
+	for _, want := range []string{
+		"# TYPE test_events_total counter",
+		`test_events_total{kind="a"} 3`,
+		`test_events_total{kind="b"} 1`,
+		"test_depth 7",
+		`test_latency_seconds_bucket{op="x",le="0.1"} 1`,
+		`test_latency_seconds_bucket{op="x",le="1"} 2`,
+		`test_latency_seconds_bucket{op="x",le="+Inf"} 3`,
+		`test_latency_seconds_sum{op="x"} 3.55`,
+		`test_latency_seconds_count{op="x"} 3`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "test_depth") > strings.Index(out, "test_events_total") {
+		t.Fatal("families must be sorted by name")
+	}
+}
@@ -0,0 +1,340 @@
+// Package msgtemplate keeps named outbound message templates under the
+// workspace and renders them natively per platform: Block Kit for Slack, an
+// Adaptive Card for Teams and plain text elsewhere.
+package msgtemplate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Dir is the folder of the templates inside the workspace.
+const Dir = "templates"
+
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// Template is a platform-neutral message layout. Every string may use
+// text/template actions on the variables, e.g. "Deploy of {{.service}}".
+// Variables used as {{.name}} must be given; {{index . "name"}} is
+// optional.
+type Template struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Title       string   `json:"title,omitempty"`
+	Text        string   `json:"text,omitempty"`
+	Fields      []Field  `json:"fields,omitempty"`
+	ImageURL    string   `json:"image_url,omitempty"`
+	Actions     []Action `json:"actions,omitempty"`
+}
+
+// Field is a label/value pair, shown as a Slack field or a Teams fact.
+type Field struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+// Action is a link button.
+type Action struct {
+	Label string `json:"label"`
+	URL   string `json:"url"`
+}
+
+// Registry stores templates as <name>.json files in one folder.
+type Registry struct {
+	dir string
+}
+
+// NewRegistry returns the registry of the workspace's templates folder.
+func NewRegistry(workspace string) *Registry {
+	return &Registry{dir: filepath.Join(workspace, Dir)}
+}
+
+// ErrNotFound is returned for an unknown template name.
+var ErrNotFound = errors.New("template not found")
+
+func (r *Registry) path(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !validName.MatchString(name) {
+		return "", fmt.Errorf("invalid template name %q: use a-z, 0-9, - and _", name)
+	}
+	return filepath.Join(r.dir, name+".json"), nil
+}
+
+// Get loads a template by name.
+func (r *Registry) Get(name string) (*Template, error) {
+	path, err := r.path(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var t Template
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("template %s: %w", name, err)
+	}
+	t.Name = strings.TrimSuffix(filepath.Base(path), ".json")
+	return &t, nil
+}
+
+// List returns all templates, sorted by name. Unreadable files are skipped.
+func (r *Registry) List() ([]*Template, error) {
+	entries, err := os.ReadDir(r.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var out []*Template
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		if t, err := r.Get(strings.TrimSuffix(e.Name(), ".json")); err == nil {
+			out = append(out, t)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// Save validates and writes a template, replacing one of the same name.
+func (r *Registry) Save(t *Template) error {
+	path, err := r.path(t.Name)
+	if err != nil {
+		return err
+	}
+	t.Name = strings.TrimSuffix(filepath.Base(path), ".json")
+	if strings.TrimSpace(t.Title) == "" && strings.TrimSpace(t.Text) == "" && len(t.Fields) == 0 {
+		return errors.New("template needs a title, text or fields")
+	}
+	if _, err := t.Render(nil, true); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Delete removes a template.
+func (r *Registry) Delete(name string) error {
+	path, err := r.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Render fills a template with variables. Fields with an empty value and
+// actions without a URL are left out. With parseOnly the strings are only
+// parsed, which checks the template syntax.
+func (t *Template) Render(vars map[string]any, parseOnly bool) (*Template, error) {
+	if vars == nil {
+		vars = map[string]any{}
+	}
+	out := &Template{Name: t.Name, Description: t.Description}
+	var firstErr error
+	render := func(what, s string) string {
+		if firstErr != nil || strings.TrimSpace(s) == "" {
+			return ""
+		}
+		tpl, err := template.New(what).Option("missingkey=error").Parse(s)
+		if err != nil {
+			firstErr = fmt.Errorf("template %s: %s: %w", t.Name, what, err)
+			return ""
+		}
+		if parseOnly {
+			return s
+		}
+		var sb strings.Builder
+		if err := tpl.Execute(&sb, vars); err != nil {
+			firstErr = fmt.Errorf("template %s: %s: %w", t.Name, what, err)
+			return ""
+		}
+		// Optional variables are read with {{index . "name"}}, which prints
+		// "<no value>" when they are not given.
+		return strings.TrimSpace(strings.ReplaceAll(sb.String(), "<no value>", ""))
+	}
+	out.Title = render("title", t.Title)
+	out.Text = render("text", t.Text)
+	out.ImageURL = render("image_url", t.ImageURL)
+	for i, f := range t.Fields {
+		label, value := render(fmt.Sprintf("fields[%d].label", i), f.Label), render(fmt.Sprintf("fields[%d].value", i), f.Value)
+		if value != "" {
+			out.Fields = append(out.Fields, Field{Label: label, Value: value})
+		}
+	}
+	for i, a := range t.Actions {
+		label, url := render(fmt.Sprintf("actions[%d].label", i), a.Label), render(fmt.Sprintf("actions[%d].url", i), a.URL)
+		if url != "" {
+			out.Actions = append(out.Actions, Action{Label: firstNonEmpty(label, url), URL: url})
+		}
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return out, nil
+}
+
+// SlackBlocks renders a filled template as a Block Kit card for the
+// channel bridge: {"blocks": [...], "text": fallback}. A non-empty intro,
+// the agent's own reply text, comes first.
+func (t *Template) SlackBlocks(intro string) map[string]any {
+	var blocks []map[string]any
+	mrkdwn := func(s string) map[string]any {
+		return map[string]any{"type": "section", "text": map[string]any{"type": "mrkdwn", "text": s}}
+	}
+	if title := strings.TrimSpace(t.Title); title != "" {
+		blocks = append(blocks, map[string]any{"type": "header", "text": map[string]any{"type": "plain_text", "text": truncate(title, 150), "emoji": true}})
+	}
+	if intro = strings.TrimSpace(intro); intro != "" {
+		blocks = append(blocks, mrkdwn(intro))
+	}
+	if t.Text != "" {
+		blocks = append(blocks, mrkdwn(t.Text))
+	}
+	// Slack allows ten fields per section.
+	for i := 0; i < len(t.Fields); i += 10 {
+		end := min(i+10, len(t.Fields))
+		fields := make([]map[string]any, 0, end-i)
+		for _, f := range t.Fields[i:end] {
+			text := f.Value
+			if f.Label != "" {
+				text = "*" + f.Label + "*\n" + f.Value
+			}
+			fields = append(fields, map[string]any{"type": "mrkdwn", "text": text})
+		}
+		blocks = append(blocks, map[string]any{"type": "section", "fields": fields})
+	}
+	if t.ImageURL != "" {
+		blocks = append(blocks, map[string]any{"type": "image", "image_url": t.ImageURL, "alt_text": firstNonEmpty(t.Title, "image")})
+	}
+	if len(t.Actions) > 0 {
+		buttons := make([]map[string]any, 0, len(t.Actions))
+		for _, a := range t.Actions {
+			buttons = append(buttons, map[string]any{"type": "button", "text": map[string]any{"type": "plain_text", "text": truncate(a.Label, 75)}, "url": a.URL})
+		}
+		blocks = append(blocks, map[string]any{"type": "actions", "elements": buttons})
+	}
+	return map[string]any{"blocks": blocks, "text": firstNonEmpty(intro, t.Title, t.Text)}
+}
+
+// AdaptiveCard renders a filled template as a Teams Adaptive Card, with a
+// non-empty intro first.
+func (t *Template) AdaptiveCard(intro string) map[string]any {
+	var body []map[string]any
+	if t.Title != "" {
+		body = append(body, map[string]any{"type": "TextBlock", "text": t.Title, "weight": "Bolder", "size": "Medium", "wrap": true})
+	}
+	if intro = strings.TrimSpace(intro); intro != "" {
+		body = append(body, map[string]any{"type": "TextBlock", "text": intro, "wrap": true})
+	}
+	if t.Text != "" {
+		body = append(body, map[string]any{"type": "TextBlock", "text": t.Text, "wrap": true})
+	}
+	if len(t.Fields) > 0 {
+		facts := make([]map[string]any, 0, len(t.Fields))
+		for _, f := range t.Fields {
+			facts = append(facts, map[string]any{"title": f.Label, "value": f.Value})
+		}
+		body = append(body, map[string]any{"type": "FactSet", "facts": facts})
+	}
+	if t.ImageURL != "" {
+		body = append(body, map[string]any{"type": "Image", "url": t.ImageURL, "altText": firstNonEmpty(t.Title, "image")})
+	}
+	card := map[string]any{
+		"type":    "AdaptiveCard",
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"version": "1.4",
+		"body":    body,
+		"msteams": map[string]any{"width": "Full"},
+	}
+	if len(t.Actions) > 0 {
+		actions := make([]map[string]any, 0, len(t.Actions))
+		for _, a := range t.Actions {
+			actions = append(actions, map[string]any{"type": "Action.OpenUrl", "title": a.Label, "url": a.URL})
+		}
+		card["actions"] = actions
+	}
+	return card
+}
+
+// PlainText renders a filled template as text for channels without cards,
+// after a non-empty intro.
+func (t *Template) PlainText(intro string) string {
+	var parts []string
+	if intro = strings.TrimSpace(intro); intro != "" {
+		parts = append(parts, intro)
+	}
+	if t.Title != "" {
+		parts = append(parts, "*"+t.Title+"*")
+	}
+	if t.Text != "" {
+		parts = append(parts, t.Text)
+	}
+	if len(t.Fields) > 0 {
+		lines := make([]string, 0, len(t.Fields))
+		for _, f := range t.Fields {
+			if f.Label == "" {
+				lines = append(lines, f.Value)
+				continue
+			}
+			lines = append(lines, f.Label+": "+f.Value)
+		}
+		parts = append(parts, strings.Join(lines, "\n"))
+	}
+	if t.ImageURL != "" {
+		parts = append(parts, t.ImageURL)
+	}
+	if len(t.Actions) > 0 {
+		lines := make([]string, 0, len(t.Actions))
+		for _, a := range t.Actions {
+			if a.Label == a.URL {
+				lines = append(lines, a.URL)
+				continue
+			}
+			lines = append(lines, a.Label+": "+a.URL)
+		}
+		parts = append(parts, strings.Join(lines, "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func truncate(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max-1]) + "…"
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
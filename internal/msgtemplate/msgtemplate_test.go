@@ -0,0 +1,86 @@
+package msgtemplate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func deployTemplate() *Template {
+	return &Template{
+		Name:  "Deploy",
+		Title: "Deploy of {{.service}}",
+		Text:  "Version {{.version}} is live.",
+		Fields: []Field{
+			{Label: "Env", Value: "{{.env}}"},
+			{Label: "Owner", Value: "{{index . \"owner\"}}"},
+		},
+		Actions: []Action{{Label: "Open", URL: "{{.url}}"}},
+	}
+}
+
+func TestRegistrySaveGetListDelete(t *testing.T) {
+	reg := NewRegistry(t.TempDir())
+	if list, err := reg.List(); err != nil || len(list) != 0 {
+		t.Fatalf("expected no templates, got %v %v", list, err)
+	}
+	if err := reg.Save(deployTemplate()); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	tpl, err := reg.Get("deploy")
+	if err != nil || tpl.Name != "deploy" || tpl.Title != "Deploy of {{.service}}" {
+		t.Fatalf("get: %+v %v", tpl, err)
+	}
+	if list, _ := reg.List(); len(list) != 1 || list[0].Name != "deploy" {
+		t.Fatalf("expected one template, got %+v", list)
+	}
+	if err := reg.Save(&Template{Name: "../escape", Title: "x"}); err == nil {
+		t.Fatal("expected an invalid name rejected")
+	}
+	if err := reg.Save(&Template{Name: "broken", Title: "{{.x"}); err == nil {
+		t.Fatal("expected a template syntax error rejected")
+	}
+	if err := reg.Delete("deploy"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := reg.Get("deploy"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestRenderPerPlatform(t *testing.T) {
+	if _, err := deployTemplate().Render(map[string]any{"service": "api"}, false); err == nil {
+		t.Fatal("expected an error for a missing variable")
+	}
+	filled, err := deployTemplate().Render(map[string]any{"service": "api", "version": "1.2", "env": "prod", "url": "https://ci.example/1"}, false)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if len(filled.Fields) != 1 || filled.Fields[0].Value != "prod" {
+		t.Fatalf("expected the empty field left out, got %+v", filled.Fields)
+	}
+
+	slack := filled.SlackBlocks("Done!")
+	blocks, _ := slack["blocks"].([]map[string]any)
+	types := make([]string, 0, len(blocks))
+	for _, b := range blocks {
+		types = append(types, b["type"].(string))
+	}
+	if strings.Join(types, ",") != "header,section,section,section,actions" || slack["text"] != "Done!" {
+		t.Fatalf("unexpected blocks %v text=%v", types, slack["text"])
+	}
+
+	card := filled.AdaptiveCard("Done!")
+	body, _ := card["body"].([]map[string]any)
+	if card["type"] != "AdaptiveCard" || len(body) != 4 || body[3]["type"] != "FactSet" {
+		t.Fatalf("unexpected card %#v", card)
+	}
+	if actions, _ := card["actions"].([]map[string]any); len(actions) != 1 || actions[0]["url"] != "https://ci.example/1" {
+		t.Fatalf("unexpected card actions %#v", card["actions"])
+	}
+
+	want := "Done!\n\n*Deploy of api*\n\nVersion 1.2 is live.\n\nEnv: prod\n\nOpen: https://ci.example/1"
+	if got := filled.PlainText("Done!"); got != want {
+		t.Fatalf("plain text:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,479 @@
+package onboarding
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Supported init systems for `kafclaw service`.
+const (
+	InitSystemd = "systemd"
+	InitLaunchd = "launchd"
+)
+
+// Managed service names.
+const (
+	ServiceGateway       = "gateway"
+	ServiceChannelBridge = "channelbridge"
+)
+
+// ServiceTarget describes one managed binary.
+type ServiceTarget struct {
+	Name       string
+	BinaryPath string
+	Args       []string
+	// Port is the gateway port, used by the systemd gateway unit.
+	Port int
+}
+
+// ServiceInstallOptions configures unit/plist generation for one or more targets.
+type ServiceInstallOptions struct {
+	InitSystem     string
+	Targets        []ServiceTarget
+	ServiceUser    string
+	ServiceHome    string
+	InstallRoot    string
+	LogDir         string
+	LogMaxSizeMB   int
+	LogRotateCount int
+	// Version is shown in the systemd gateway unit description.
+	Version string
+	// Env is merged into each target's environment file (KEY → value).
+	Env map[string]string
+}
+
+// ServiceUnitResult reports the files written for one target.
+type ServiceUnitResult struct {
+	Name     string `json:"name"`
+	Unit     string `json:"unit"`
+	UnitPath string `json:"unitPath"`
+	EnvPath  string `json:"envPath"`
+	LogPath  string `json:"logPath,omitempty"`
+}
+
+// ServiceInstallResult reports the outcome of InstallServices.
+type ServiceInstallResult struct {
+	InitSystem    string              `json:"initSystem"`
+	UserCreated   bool                `json:"userCreated"`
+	Units         []ServiceUnitResult `json:"units"`
+	LogRotatePath string              `json:"logRotatePath"`
+}
+
+// DefaultServiceTargets returns the gateway and channelbridge targets.
+func DefaultServiceTargets(kafclawBinary, bridgeBinary string, port int) []ServiceTarget {
+	return []ServiceTarget{
+		{Name: ServiceGateway, BinaryPath: kafclawBinary, Args: []string{"gateway", "--port", fmt.Sprintf("%d", port)}, Port: port},
+		{Name: ServiceChannelBridge, BinaryPath: bridgeBinary},
+	}
+}
+
+// ServiceUnitName returns the systemd unit name or launchd label for a target.
+func ServiceUnitName(initSystem, name string) string {
+	if initSystem == InitLaunchd {
+		return "ai.kafclaw." + name
+	}
+	return "kafclaw-" + name + ".service"
+}
+
+// InstallServices writes unit files (systemd) or plists (launchd), per-target
+// environment files and a log rotation config covering all target logs. The
+// systemd gateway unit is written by SetupSystemdGateway, like `kafclaw daemon
+// install`, and logs to the journal.
+func InstallServices(opts ServiceInstallOptions) (*ServiceInstallResult, error) {
+	opts, created, err := normalizeServiceOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	res := &ServiceInstallResult{InitSystem: opts.InitSystem, UserCreated: created}
+	if err := os.MkdirAll(opts.LogDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	var logPaths []string
+	for _, t := range opts.Targets {
+		if strings.TrimSpace(t.BinaryPath) == "" {
+			return nil, fmt.Errorf("binary path is required for %s", t.Name)
+		}
+		if opts.InitSystem == InitSystemd && t.Name == ServiceGateway {
+			u, err := installSystemdGatewayTarget(t, opts)
+			if err != nil {
+				return nil, err
+			}
+			res.Units = append(res.Units, u)
+			continue
+		}
+		u := serviceUnitPaths(opts, t.Name)
+		env, err := MergeEnvFile(u.EnvPath, opts.Env)
+		if err != nil {
+			return nil, fmt.Errorf("env file %s: %w", u.EnvPath, err)
+		}
+		var body string
+		switch opts.InitSystem {
+		case InitLaunchd:
+			body = renderLaunchdPlist(t, opts, env, u.LogPath)
+		default:
+			body = renderServiceUnit(t, opts, u.EnvPath, u.LogPath)
+		}
+		if err := os.MkdirAll(filepath.Dir(u.UnitPath), 0o755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(u.UnitPath, []byte(body), 0o644); err != nil {
+			return nil, err
+		}
+		logPaths = append(logPaths, u.LogPath)
+		res.Units = append(res.Units, u)
+	}
+
+	if len(logPaths) == 0 {
+		return res, nil
+	}
+	res.LogRotatePath = logRotatePath(opts)
+	var rotate string
+	if opts.InitSystem == InitLaunchd {
+		rotate = renderNewsyslogConfig(opts, logPaths)
+	} else {
+		rotate = renderLogrotateConfig(opts, logPaths)
+	}
+	if err := os.MkdirAll(filepath.Dir(res.LogRotatePath), 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(res.LogRotatePath, []byte(rotate), 0o644); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// installSystemdGatewayTarget writes the gateway unit, its override and env
+// file through SetupSystemdGateway and merges opts.Env into the env file.
+func installSystemdGatewayTarget(t ServiceTarget, opts ServiceInstallOptions) (ServiceUnitResult, error) {
+	setup, err := SetupSystemdGateway(SetupOptions{
+		ServiceUser: opts.ServiceUser,
+		ServiceHome: opts.ServiceHome,
+		BinaryPath:  t.BinaryPath,
+		Port:        t.Port,
+		Version:     opts.Version,
+		InstallRoot: opts.InstallRoot,
+	})
+	if err != nil {
+		return ServiceUnitResult{}, err
+	}
+	if len(opts.Env) > 0 {
+		if _, err := MergeEnvFile(setup.EnvPath, opts.Env); err != nil {
+			return ServiceUnitResult{}, fmt.Errorf("env file %s: %w", setup.EnvPath, err)
+		}
+	}
+	u := serviceUnitPaths(opts, t.Name)
+	u.UnitPath = setup.ServicePath
+	return u, nil
+}
+
+// UninstallServices removes unit files/plists for the given targets.
+// Environment files and logs are kept; the rotation config is removed only
+// when no managed unit remains installed.
+func UninstallServices(opts ServiceInstallOptions) ([]string, error) {
+	if opts.ServiceHome == "" {
+		opts.ServiceHome = resolveServiceHome(opts.ServiceUser)
+	}
+	opts, _, err := normalizeServiceOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, t := range opts.Targets {
+		u := serviceUnitPaths(opts, t.Name)
+		if err := os.Remove(u.UnitPath); err == nil {
+			removed = append(removed, u.UnitPath)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return removed, err
+		}
+	}
+	for _, name := range []string{ServiceGateway, ServiceChannelBridge} {
+		if _, err := os.Stat(serviceUnitPaths(opts, name).UnitPath); err == nil {
+			return removed, nil
+		}
+	}
+	if err := os.Remove(logRotatePath(opts)); err == nil {
+		removed = append(removed, logRotatePath(opts))
+	}
+	return removed, nil
+}
+
+// ServiceUnitPaths returns where files for a target live under opts.
+func ServiceUnitPaths(opts ServiceInstallOptions, name string) ServiceUnitResult {
+	if opts.ServiceHome == "" {
+		opts.ServiceHome = resolveServiceHome(opts.ServiceUser)
+	}
+	opts, _, _ = normalizeServiceOptions(opts)
+	return serviceUnitPaths(opts, name)
+}
+
+func normalizeServiceOptions(opts ServiceInstallOptions) (ServiceInstallOptions, bool, error) {
+	switch opts.InitSystem {
+	case InitSystemd, InitLaunchd:
+	default:
+		return opts, false, fmt.Errorf("unsupported init system %q (use systemd or launchd)", opts.InitSystem)
+	}
+	if len(opts.Targets) == 0 {
+		return opts, false, fmt.Errorf("at least one service target is required")
+	}
+	if opts.InstallRoot == "" {
+		opts.InstallRoot = "/"
+	}
+	if opts.LogMaxSizeMB <= 0 {
+		opts.LogMaxSizeMB = 50
+	}
+	if opts.LogRotateCount <= 0 {
+		opts.LogRotateCount = 7
+	}
+	created := false
+	if opts.ServiceHome == "" {
+		if opts.InitSystem == InitSystemd {
+			if opts.ServiceUser == "" {
+				return opts, false, fmt.Errorf("service user is required")
+			}
+			var err error
+			created, opts.ServiceHome, err = ensureUserFn(opts.ServiceUser)
+			if err != nil {
+				return opts, false, err
+			}
+		} else {
+			opts.ServiceHome = resolveServiceHome("")
+		}
+	}
+	if opts.LogDir == "" {
+		if opts.InitSystem == InitLaunchd {
+			opts.LogDir = filepath.Join(opts.ServiceHome, "Library", "Logs", "kafclaw")
+		} else {
+			opts.LogDir = filepath.Join(opts.InstallRoot, "var", "log", "kafclaw")
+		}
+	}
+	return opts, created, nil
+}
+
+func resolveServiceHome(serviceUser string) string {
+	if serviceUser != "" {
+		if u, err := lookupUserFn(serviceUser); err == nil {
+			return u.HomeDir
+		}
+	}
+	if u, err := currentUserFn(); err == nil {
+		return u.HomeDir
+	}
+	home, _ := os.UserHomeDir()
+	return home
+}
+
+func serviceUnitPaths(opts ServiceInstallOptions, name string) ServiceUnitResult {
+	unit := ServiceUnitName(opts.InitSystem, name)
+	u := ServiceUnitResult{
+		Name:    name,
+		Unit:    unit,
+		EnvPath: filepath.Join(opts.ServiceHome, ".config", "kafclaw", name+".env"),
+		LogPath: filepath.Join(opts.LogDir, name+".log"),
+	}
+	if opts.InitSystem == InitLaunchd {
+		u.UnitPath = filepath.Join(opts.ServiceHome, "Library", "LaunchAgents", unit+".plist")
+		return u
+	}
+	u.UnitPath = filepath.Join(opts.InstallRoot, "etc", "systemd", "system", unit)
+	if name == ServiceGateway {
+		// The gateway unit of SetupSystemdGateway shares the daemon env file
+		// and logs to the journal.
+		u.EnvPath = filepath.Join(opts.ServiceHome, ".config", "kafclaw", "env")
+		u.LogPath = ""
+	}
+	return u
+}
+
+func logRotatePath(opts ServiceInstallOptions) string {
+	if opts.InitSystem == InitLaunchd {
+		return filepath.Join(opts.InstallRoot, "etc", "newsyslog.d", "kafclaw.conf")
+	}
+	return filepath.Join(opts.InstallRoot, "etc", "logrotate.d", "kafclaw")
+}
+
+func serviceExecLine(t ServiceTarget) string {
+	parts := []string{shellEscape(filepath.Clean(t.BinaryPath))}
+	for _, a := range t.Args {
+		parts = append(parts, shellEscape(a))
+	}
+	return strings.Join(parts, " ")
+}
+
+func renderServiceUnit(t ServiceTarget, opts ServiceInstallOptions, envPath, logPath string) string {
+	lines := []string{
+		"[Unit]",
+		fmt.Sprintf("Description=KafClaw %s", t.Name),
+		"After=network-online.target",
+		"Wants=network-online.target",
+	}
+	if t.Name == ServiceChannelBridge {
+		lines = append(lines, "After="+ServiceUnitName(InitSystemd, ServiceGateway))
+	}
+	lines = append(lines,
+		"",
+		"[Service]",
+	)
+	if opts.ServiceUser != "" {
+		lines = append(lines, "User="+opts.ServiceUser, "Group="+opts.ServiceUser)
+	}
+	lines = append(lines,
+		"ExecStart="+serviceExecLine(t),
+		"Restart=always",
+		"RestartSec=5",
+		"EnvironmentFile=-"+envPath,
+		"Environment=HOME="+opts.ServiceHome,
+		"WorkingDirectory="+opts.ServiceHome,
+		"StandardOutput=append:"+logPath,
+		"StandardError=append:"+logPath,
+		"",
+		"[Install]",
+		"WantedBy=multi-user.target",
+		"",
+	)
+	return strings.Join(lines, "\n")
+}
+
+func renderLaunchdPlist(t ServiceTarget, opts ServiceInstallOptions, env map[string]string, logPath string) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	b.WriteString(`<plist version="1.0">` + "\n<dict>\n")
+	fmt.Fprintf(&b, "  <key>Label</key>\n  <string>%s</string>\n", xmlEscape(ServiceUnitName(InitLaunchd, t.Name)))
+	b.WriteString("  <key>ProgramArguments</key>\n  <array>\n")
+	fmt.Fprintf(&b, "    <string>%s</string>\n", xmlEscape(filepath.Clean(t.BinaryPath)))
+	for _, a := range t.Args {
+		fmt.Fprintf(&b, "    <string>%s</string>\n", xmlEscape(a))
+	}
+	b.WriteString("  </array>\n")
+	if len(env) > 0 {
+		b.WriteString("  <key>EnvironmentVariables</key>\n  <dict>\n")
+		for _, k := range sortedKeys(env) {
+			fmt.Fprintf(&b, "    <key>%s</key>\n    <string>%s</string>\n", xmlEscape(k), xmlEscape(env[k]))
+		}
+		b.WriteString("  </dict>\n")
+	}
+	fmt.Fprintf(&b, "  <key>WorkingDirectory</key>\n  <string>%s</string>\n", xmlEscape(opts.ServiceHome))
+	b.WriteString("  <key>RunAtLoad</key>\n  <true/>\n")
+	b.WriteString("  <key>KeepAlive</key>\n  <true/>\n")
+	fmt.Fprintf(&b, "  <key>StandardOutPath</key>\n  <string>%s</string>\n", xmlEscape(logPath))
+	fmt.Fprintf(&b, "  <key>StandardErrorPath</key>\n  <string>%s</string>\n", xmlEscape(logPath))
+	b.WriteString("</dict>\n</plist>\n")
+	return b.String()
+}
+
+func renderLogrotateConfig(opts ServiceInstallOptions, logPaths []string) string {
+	lines := []string{"# KafClaw service logs (managed by `kafclaw service install`)"}
+	lines = append(lines, strings.Join(logPaths, " ")+" {")
+	lines = append(lines,
+		fmt.Sprintf("    size %dM", opts.LogMaxSizeMB),
+		fmt.Sprintf("    rotate %d", opts.LogRotateCount),
+		"    compress",
+		"    delaycompress",
+		"    missingok",
+		"    notifempty",
+		"    copytruncate",
+		"}",
+		"",
+	)
+	return strings.Join(lines, "\n")
+}
+
+func renderNewsyslogConfig(opts ServiceInstallOptions, logPaths []string) string {
+	lines := []string{
+		"# KafClaw service logs (managed by `kafclaw service install`)",
+		"# logfilename [owner:group] mode count size(KB) when flags",
+	}
+	owner := ""
+	if opts.ServiceUser != "" {
+		owner = opts.ServiceUser + ": "
+	}
+	for _, p := range logPaths {
+		lines = append(lines, fmt.Sprintf("%s %s644 %d %d * JN", p, owner, opts.LogRotateCount, opts.LogMaxSizeMB*1024))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// MergeEnvFile applies updates to a KEY=VALUE environment file, creating it
+// (mode 0600) when missing. Comments and unrelated keys are preserved. Returns
+// the resulting key/value set.
+func MergeEnvFile(path string, updates map[string]string) (map[string]string, error) {
+	env := map[string]string{}
+	var lines []string
+	if f, err := os.Open(path); err == nil {
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			lines = append(lines, sc.Text())
+		}
+		f.Close()
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	} else {
+		lines = []string{"# KafClaw service environment (managed by `kafclaw service install`)"}
+	}
+
+	seen := map[string]bool{}
+	for i, line := range lines {
+		k, v, ok := parseEnvLine(line)
+		if !ok {
+			continue
+		}
+		if nv, upd := updates[k]; upd {
+			v = nv
+			lines[i] = k + "=" + v
+		}
+		env[k] = v
+		seen[k] = true
+	}
+	for _, k := range sortedKeys(updates) {
+		if seen[k] {
+			continue
+		}
+		lines = append(lines, k+"="+updates[k])
+		env[k] = updates[k]
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+func parseEnvLine(line string) (string, string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false
+	}
+	line = strings.TrimPrefix(line, "export ")
+	k, v, ok := strings.Cut(line, "=")
+	k = strings.TrimSpace(k)
+	if !ok || k == "" {
+		return "", "", false
+	}
+	return k, strings.TrimSpace(v), true
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func xmlEscape(s string) string {
+	r := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;")
+	return r.Replace(s)
+}
@@ -0,0 +1,138 @@
+package onboarding
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInstallServicesSystemdWritesUnitsEnvAndLogrotate(t *testing.T) {
+	tmp := t.TempDir()
+	home := filepath.Join(tmp, "home")
+	root := filepath.Join(tmp, "root")
+
+	res, err := InstallServices(ServiceInstallOptions{
+		InitSystem:  InitSystemd,
+		Targets:     DefaultServiceTargets("/usr/local/bin/kafclaw", "/usr/local/bin/channelbridge", 18790),
+		ServiceUser: "kafclaw",
+		ServiceHome: home,
+		InstallRoot: root,
+		Env:         map[string]string{"SLACK_BOT_TOKEN": "xoxb-1"},
+	})
+	if err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	if len(res.Units) != 2 {
+		t.Fatalf("expected 2 units, got %d", len(res.Units))
+	}
+
+	gw, err := os.ReadFile(filepath.Join(root, "etc", "systemd", "system", "kafclaw-gateway.service"))
+	if err != nil {
+		t.Fatalf("read gateway unit: %v", err)
+	}
+	if !strings.Contains(string(gw), "ExecStart=/usr/local/bin/kafclaw gateway --port 18790") {
+		t.Fatalf("unexpected gateway unit: %s", gw)
+	}
+	if !strings.Contains(string(gw), "EnvironmentFile=-"+filepath.Join(home, ".config", "kafclaw", "env")) {
+		t.Fatalf("gateway unit should use the daemon env file: %s", gw)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".config", "systemd", "user", "kafclaw-gateway.service.d", "override.conf")); err != nil {
+		t.Fatalf("missing gateway override: %v", err)
+	}
+	gwEnv, err := os.ReadFile(filepath.Join(home, ".config", "kafclaw", "env"))
+	if err != nil {
+		t.Fatalf("read gateway env: %v", err)
+	}
+	if !strings.Contains(string(gwEnv), "KAFCLAW_GATEWAY_AUTH_TOKEN=") || !strings.Contains(string(gwEnv), "KAFCLAW_HOME="+home) || !strings.Contains(string(gwEnv), "SLACK_BOT_TOKEN=xoxb-1") {
+		t.Fatalf("unexpected gateway env: %s", gwEnv)
+	}
+	br, err := os.ReadFile(filepath.Join(root, "etc", "systemd", "system", "kafclaw-channelbridge.service"))
+	if err != nil {
+		t.Fatalf("read bridge unit: %v", err)
+	}
+	if !strings.Contains(string(br), "After=kafclaw-gateway.service") {
+		t.Fatalf("bridge should start after gateway: %s", br)
+	}
+	if !strings.Contains(string(br), "StandardOutput=append:"+filepath.Join(root, "var", "log", "kafclaw", "channelbridge.log")) {
+		t.Fatalf("missing log redirection: %s", br)
+	}
+
+	env, err := os.ReadFile(filepath.Join(home, ".config", "kafclaw", "channelbridge.env"))
+	if err != nil {
+		t.Fatalf("read env: %v", err)
+	}
+	if !strings.Contains(string(env), "SLACK_BOT_TOKEN=xoxb-1") {
+		t.Fatalf("env not merged: %s", env)
+	}
+
+	rotate, err := os.ReadFile(res.LogRotatePath)
+	if err != nil {
+		t.Fatalf("read logrotate: %v", err)
+	}
+	if !strings.Contains(string(rotate), "rotate 7") || !strings.Contains(string(rotate), "size 50M") {
+		t.Fatalf("unexpected logrotate config: %s", rotate)
+	}
+
+	removed, err := UninstallServices(ServiceInstallOptions{
+		InitSystem:  InitSystemd,
+		Targets:     DefaultServiceTargets("", "", 0),
+		ServiceHome: home,
+		InstallRoot: root,
+	})
+	if err != nil {
+		t.Fatalf("uninstall: %v", err)
+	}
+	if len(removed) != 3 {
+		t.Fatalf("expected units and logrotate removed, got %v", removed)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".config", "kafclaw", "channelbridge.env")); err != nil {
+		t.Fatalf("env file should be kept: %v", err)
+	}
+}
+
+func TestInstallServicesLaunchdRendersPlistWithEnv(t *testing.T) {
+	tmp := t.TempDir()
+	home := filepath.Join(tmp, "home")
+
+	res, err := InstallServices(ServiceInstallOptions{
+		InitSystem:  InitLaunchd,
+		Targets:     DefaultServiceTargets("/opt/kafclaw", "/opt/channelbridge", 18790)[:1],
+		ServiceHome: home,
+		InstallRoot: filepath.Join(tmp, "root"),
+		Env:         map[string]string{"KAFCLAW_GATEWAY_AUTH_TOKEN": "a&b"},
+	})
+	if err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	plist, err := os.ReadFile(res.Units[0].UnitPath)
+	if err != nil {
+		t.Fatalf("read plist: %v", err)
+	}
+	for _, want := range []string{"<string>ai.kafclaw.gateway</string>", "<string>a&amp;b</string>", "<key>StandardOutPath</key>"} {
+		if !strings.Contains(string(plist), want) {
+			t.Fatalf("plist missing %q: %s", want, plist)
+		}
+	}
+	if !strings.HasSuffix(res.LogRotatePath, filepath.Join("newsyslog.d", "kafclaw.conf")) {
+		t.Fatalf("unexpected rotation path: %s", res.LogRotatePath)
+	}
+}
+
+func TestMergeEnvFilePreservesCommentsAndUpdates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "svc.env")
+	if err := os.WriteFile(path, []byte("# keep\nA=1\nB=2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	env, err := MergeEnvFile(path, map[string]string{"B": "3", "C": "4"})
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if env["A"] != "1" || env["B"] != "3" || env["C"] != "4" {
+		t.Fatalf("unexpected env: %v", env)
+	}
+	raw, _ := os.ReadFile(path)
+	if string(raw) != "# keep\nA=1\nB=3\nC=4\n" {
+		t.Fatalf("unexpected file: %q", raw)
+	}
+}
@@ -0,0 +1,206 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+// ZoneHealth summarizes one zone for the health report.
+type ZoneHealth struct {
+	ZoneID   string   `json:"zone_id"`
+	Name     string   `json:"name"`
+	Agents   []string `json:"agents"`
+	Inactive []string `json:"inactive,omitempty"`
+}
+
+// Laggard is an agent that needs attention.
+type Laggard struct {
+	AgentID string `json:"agent_id"`
+	Reason  string `json:"reason"`
+}
+
+// HealthReport is the periodic zone health digest.
+type HealthReport struct {
+	GeneratedAt time.Time                      `json:"generated_at"`
+	Since       time.Time                      `json:"since"`
+	Zones       []ZoneHealth                   `json:"zones"`
+	AgentCount  int                            `json:"agent_count"`
+	ActiveCount int                            `json:"active_count"`
+	Completed   int                            `json:"completed"`
+	Failed      int                            `json:"failed"`
+	Open        int                            `json:"open"`
+	Overdue     int                            `json:"overdue"`
+	PerAgent    []timeline.GroupTaskAgentStats `json:"per_agent,omitempty"`
+	Laggards    []Laggard                      `json:"laggards,omitempty"`
+}
+
+// BuildHealthReport compiles zone membership, group task throughput since the
+// given time and laggard agents. Agents without a heartbeat for laggardAfter
+// are reported as laggards.
+func (o *Orchestrator) BuildHealthReport(since, now time.Time, laggardAfter time.Duration) HealthReport {
+	rep := HealthReport{GeneratedAt: now, Since: since}
+
+	nodes := o.hierarchy.AllNodes()
+	status := make(map[string]string, len(nodes))
+	zoneAgents := map[string]map[string]bool{}
+	addToZone := func(zoneID, agentID string) {
+		if zoneID == "" {
+			zoneID = "public"
+		}
+		if zoneAgents[zoneID] == nil {
+			zoneAgents[zoneID] = map[string]bool{}
+		}
+		zoneAgents[zoneID][agentID] = true
+	}
+	for _, n := range nodes {
+		status[n.AgentID] = n.Status
+		addToZone(n.ZoneID, n.AgentID)
+		if n.Status == "" || n.Status == "active" {
+			rep.ActiveCount++
+		}
+	}
+	rep.AgentCount = len(nodes)
+
+	zones := o.zones.AllZones()
+	names := map[string]string{}
+	for _, z := range zones {
+		names[z.ZoneID] = z.Name
+		for _, id := range o.zones.Members(z.ZoneID) {
+			addToZone(z.ZoneID, id)
+		}
+	}
+	for zoneID, members := range zoneAgents {
+		zh := ZoneHealth{ZoneID: zoneID, Name: names[zoneID]}
+		for id := range members {
+			zh.Agents = append(zh.Agents, id)
+			if st := status[id]; st != "" && st != "active" {
+				zh.Inactive = append(zh.Inactive, id)
+			}
+		}
+		sort.Strings(zh.Agents)
+		sort.Strings(zh.Inactive)
+		rep.Zones = append(rep.Zones, zh)
+	}
+	sort.Slice(rep.Zones, func(i, j int) bool { return rep.Zones[i].ZoneID < rep.Zones[j].ZoneID })
+
+	laggards := map[string][]string{}
+	for _, n := range nodes {
+		if n.Status != "" && n.Status != "active" {
+			laggards[n.AgentID] = append(laggards[n.AgentID], "status "+n.Status)
+		}
+	}
+	if o.timeline != nil {
+		if stats, err := o.timeline.GroupTaskStatsSince(since); err == nil {
+			for _, st := range stats {
+				rep.Completed += st.Completed
+				rep.Failed += st.Failed
+				rep.Open += st.Open
+				rep.Overdue += st.Overdue
+				if st.AgentID == "" {
+					continue
+				}
+				rep.PerAgent = append(rep.PerAgent, st)
+				if st.Overdue > 0 {
+					laggards[st.AgentID] = append(laggards[st.AgentID], fmt.Sprintf("%d overdue task(s)", st.Overdue))
+				}
+			}
+		}
+		if members, err := o.timeline.ListGroupMembers(); err == nil && laggardAfter > 0 {
+			for _, m := range members {
+				if m.AgentID == o.selfNode.AgentID || m.LastSeen.IsZero() {
+					continue
+				}
+				if idle := now.Sub(m.LastSeen); idle > laggardAfter {
+					laggards[m.AgentID] = append(laggards[m.AgentID], "no heartbeat for "+idle.Round(time.Minute).String())
+				}
+			}
+		}
+	}
+	for id, reasons := range laggards {
+		rep.Laggards = append(rep.Laggards, Laggard{AgentID: id, Reason: strings.Join(reasons, ", ")})
+	}
+	sort.Slice(rep.Laggards, func(i, j int) bool { return rep.Laggards[i].AgentID < rep.Laggards[j].AgentID })
+	return rep
+}
+
+// FormatHealthReport renders a report as chat text. "detailed" verbosity adds
+// per-zone membership and per-agent task counts.
+func FormatHealthReport(rep HealthReport, verbosity string) string {
+	var sb strings.Builder
+	window := rep.GeneratedAt.Sub(rep.Since).Round(time.Minute)
+	fmt.Fprintf(&sb, "📊 Zone health report (last %s)\n", window)
+	fmt.Fprintf(&sb, "Zones: %d · Agents: %d (%d active)\n", len(rep.Zones), rep.AgentCount, rep.ActiveCount)
+	fmt.Fprintf(&sb, "Tasks: %d completed · %d failed · %d open", rep.Completed, rep.Failed, rep.Open)
+	if rep.Overdue > 0 {
+		fmt.Fprintf(&sb, " (%d overdue)", rep.Overdue)
+	}
+	sb.WriteString("\n")
+
+	if strings.EqualFold(strings.TrimSpace(verbosity), "detailed") {
+		for _, z := range rep.Zones {
+			label := z.ZoneID
+			if z.Name != "" && z.Name != z.ZoneID {
+				label = fmt.Sprintf("%s (%s)", z.Name, z.ZoneID)
+			}
+			fmt.Fprintf(&sb, "• %s: %d agent(s)", label, len(z.Agents))
+			if len(z.Inactive) > 0 {
+				fmt.Fprintf(&sb, ", inactive: %s", strings.Join(z.Inactive, ", "))
+			}
+			sb.WriteString("\n")
+		}
+		for _, st := range rep.PerAgent {
+			fmt.Fprintf(&sb, "• %s: %d completed, %d failed, %d open\n", st.AgentID, st.Completed, st.Failed, st.Open)
+		}
+	}
+
+	if len(rep.Laggards) == 0 {
+		sb.WriteString("Laggards: none")
+	} else {
+		parts := make([]string, len(rep.Laggards))
+		for i, l := range rep.Laggards {
+			parts[i] = fmt.Sprintf("%s (%s)", l.AgentID, l.Reason)
+		}
+		sb.WriteString("Laggards: " + strings.Join(parts, "; "))
+	}
+	return sb.String()
+}
+
+// StartHealthReports posts the zone health digest to the configured chat on
+// every interval until ctx is cancelled. It is a no-op when reporting is not
+// configured.
+func (o *Orchestrator) StartHealthReports(ctx context.Context, msgBus *bus.MessageBus) {
+	rc := o.cfg.HealthReport
+	if msgBus == nil || rc.IntervalMinutes <= 0 || strings.TrimSpace(rc.Channel) == "" || strings.TrimSpace(rc.ChatID) == "" {
+		return
+	}
+	interval := time.Duration(rc.IntervalMinutes) * time.Minute
+	laggardAfter := time.Duration(rc.LaggardMinutes) * time.Minute
+	if laggardAfter <= 0 {
+		laggardAfter = 3 * interval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				rep := o.BuildHealthReport(now.Add(-interval), now, laggardAfter)
+				msgBus.PublishOutbound(&bus.OutboundMessage{
+					Channel: strings.TrimSpace(rc.Channel),
+					ChatID:  strings.TrimSpace(rc.ChatID),
+					TraceID: fmt.Sprintf("zone-report-%d", now.UnixNano()),
+					Content: FormatHealthReport(rep, rc.Verbosity),
+				})
+			}
+		}
+	}()
+	fmt.Printf("📊 Zone health reports every %s to %s/%s\n", interval, rc.Channel, rc.ChatID)
+}
@@ -0,0 +1,69 @@
+package orchestrator
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/timeline"
+)
+
+func TestBuildAndFormatHealthReport(t *testing.T) {
+	tl, err := timeline.NewTimelineService(filepath.Join(t.TempDir(), "timeline.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tl.Close()
+
+	now := time.Now()
+	for _, m := range []timeline.GroupMemberRecord{
+		{AgentID: "self", Status: "active"},
+		{AgentID: "worker-a", Status: "active"},
+		{AgentID: "worker-b", Status: "active"},
+	} {
+		if err := tl.UpsertGroupMember(&m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, _ = tl.DB().Exec(`UPDATE group_members SET last_seen = datetime('now', '-2 hours') WHERE agent_id = 'worker-b'`)
+	for i, st := range []string{"completed", "completed", "failed", "pending"} {
+		if err := tl.InsertGroupTask(&timeline.GroupTaskRecord{
+			TaskID: fmt.Sprintf("t%d", i), Direction: "outgoing", RequesterID: "self", ResponderID: "worker-a", Status: st,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, _ = tl.DB().Exec(`UPDATE group_tasks SET deadline_at = datetime('now', '-5 minutes') WHERE status = 'pending'`)
+
+	h := NewHierarchy()
+	h.AddNode(AgentNode{AgentID: "self", Role: "orchestrator", Status: "active"})
+	h.AddNode(AgentNode{AgentID: "worker-a", Role: "worker", ZoneID: "ops", Status: "active"})
+	h.AddNode(AgentNode{AgentID: "worker-b", Role: "worker", ZoneID: "ops", Status: "stale"})
+	zm := NewZoneManager()
+	_ = zm.AddMember("public", "self")
+	_ = zm.CreateZone(Zone{ZoneID: "ops", Name: "Operations", Visibility: "shared"})
+	o := &Orchestrator{hierarchy: h, zones: zm, timeline: tl, selfNode: AgentNode{AgentID: "self"}}
+
+	rep := o.BuildHealthReport(now.Add(-time.Hour), now, 30*time.Minute)
+	if rep.AgentCount != 3 || rep.ActiveCount != 2 || len(rep.Zones) != 2 {
+		t.Fatalf("unexpected agent/zone counts: %+v", rep)
+	}
+	if rep.Completed != 2 || rep.Failed != 1 || rep.Open != 1 || rep.Overdue != 1 {
+		t.Fatalf("unexpected task counts: %+v", rep)
+	}
+	if len(rep.Laggards) != 2 || !strings.Contains(rep.Laggards[0].Reason, "overdue") ||
+		!strings.Contains(rep.Laggards[1].Reason, "status stale") || !strings.Contains(rep.Laggards[1].Reason, "no heartbeat") {
+		t.Fatalf("unexpected laggards: %+v", rep.Laggards)
+	}
+
+	summary := FormatHealthReport(rep, "summary")
+	if !strings.Contains(summary, "2 completed · 1 failed · 1 open (1 overdue)") || strings.Contains(summary, "Operations") {
+		t.Fatalf("unexpected summary:\n%s", summary)
+	}
+	detailed := FormatHealthReport(rep, "detailed")
+	if !strings.Contains(detailed, "Operations (ops): 2 agent(s), inactive: worker-b") || !strings.Contains(detailed, "worker-a: 2 completed") {
+		t.Fatalf("unexpected detailed report:\n%s", detailed)
+	}
+}
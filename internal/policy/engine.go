@@ -3,6 +3,8 @@ package policy
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/KafClaw/KafClaw/internal/tools"
@@ -12,6 +14,7 @@ import (
 type Context struct {
 	Sender      string
 	Channel     string
+	Account     string // channel account, e.g. a Slack workspace account
 	Tool        string
 	Tier        int
 	Arguments   map[string]any
@@ -23,6 +26,7 @@ type Context struct {
 type Decision struct {
 	Allow            bool
 	RequiresApproval bool // true when tier exceeds auto-approve but interactive approval is possible
+	RequiresPlan     bool // true when a plan-first rule applies to this tool call
 	Reason           string
 	Tier             int
 	Ts               time.Time
@@ -46,6 +50,39 @@ type DefaultEngine struct {
 	// AllowedSenders is the set of senders permitted to trigger tools.
 	// If empty, all senders are allowed.
 	AllowedSenders map[string]bool
+	// PlanFirstMinTier makes tools at or above this tier require a posted
+	// plan and go-ahead before running. 0 disables the rule.
+	PlanFirstMinTier int
+	// PlanFirstChannels limits the plan-first rule to these channels.
+	// If empty, the rule applies to all channels.
+	PlanFirstChannels map[string]bool
+	// RemoteHosts limits the ssh tool per host alias. When set, calls to
+	// hosts not in the map are denied.
+	RemoteHosts map[string]RemoteHostPolicy
+	// ToolLists limits tools per channel ("slack") and per channel account
+	// ("slack:ops"). The account entry, when present, replaces the channel
+	// entry.
+	ToolLists map[string]ToolListPolicy
+}
+
+// ToolListPolicy is the tool allow and deny list of a channel or channel
+// account. Denials are final: a listed tool cannot be approved
+// interactively.
+type ToolListPolicy struct {
+	// Allow, when set, holds the only tools that may run.
+	Allow map[string]bool
+	// Deny holds tools that never run.
+	Deny map[string]bool
+}
+
+// RemoteHostPolicy limits the ssh tool on one host. Denials are final: a
+// command above MaxTier cannot be approved interactively.
+type RemoteHostPolicy struct {
+	// MaxTier is the highest command tier allowed on the host.
+	MaxTier int
+	// Deny are command patterns refused on the host, in addition to the
+	// tool's global deny list.
+	Deny []*regexp.Regexp
 }
 
 // NewDefaultEngine creates a policy engine with sensible defaults.
@@ -58,9 +95,22 @@ func NewDefaultEngine() *DefaultEngine {
 // Evaluate checks tool tier and sender authorization.
 func (e *DefaultEngine) Evaluate(ctx Context) Decision {
 	d := Decision{
-		Tier:    ctx.Tier,
-		Ts:      time.Now(),
-		TraceID: ctx.TraceID,
+		Tier:         ctx.Tier,
+		Ts:           time.Now(),
+		TraceID:      ctx.TraceID,
+		RequiresPlan: e.planFirstApplies(ctx),
+	}
+
+	if reason := e.ToolListDenial(ctx); reason != "" {
+		d.Allow = false
+		d.Reason = reason
+		return d
+	}
+
+	if reason := e.remoteHostDenial(ctx); reason != "" {
+		d.Allow = false
+		d.Reason = reason
+		return d
 	}
 
 	// Tier 0 tools are always allowed
@@ -101,3 +151,58 @@ func (e *DefaultEngine) Evaluate(ctx Context) Decision {
 	d.Reason = fmt.Sprintf("tier_%d_auto_approved", ctx.Tier)
 	return d
 }
+
+// ToolListDenial returns why the tool list of the message's channel account
+// or channel refuses the tool, or "".
+func (e *DefaultEngine) ToolListDenial(ctx Context) string {
+	if len(e.ToolLists) == 0 || ctx.Channel == "" {
+		return ""
+	}
+	scope := ctx.Channel
+	list, ok := e.ToolLists[scope]
+	if ctx.Account != "" {
+		if al, found := e.ToolLists[ctx.Channel+":"+ctx.Account]; found {
+			scope, list, ok = ctx.Channel+":"+ctx.Account, al, true
+		}
+	}
+	if !ok {
+		return ""
+	}
+	if list.Deny[ctx.Tool] {
+		return fmt.Sprintf("tool_denied_for_channel: %s", scope)
+	}
+	if len(list.Allow) > 0 && !list.Allow[ctx.Tool] {
+		return fmt.Sprintf("tool_not_allowed_for_channel: %s", scope)
+	}
+	return ""
+}
+
+// remoteHostDenial returns why an ssh call breaks its host policy, or "".
+func (e *DefaultEngine) remoteHostDenial(ctx Context) string {
+	if ctx.Tool != tools.SSHToolName || e.RemoteHosts == nil {
+		return ""
+	}
+	host := strings.TrimSpace(tools.GetString(ctx.Arguments, "host", ""))
+	hp, ok := e.RemoteHosts[host]
+	if !ok {
+		return fmt.Sprintf("remote_host_not_registered: %s", host)
+	}
+	command := tools.GetString(ctx.Arguments, "command", "")
+	for _, re := range hp.Deny {
+		if re.MatchString(command) {
+			return fmt.Sprintf("remote_command_denied: %s", host)
+		}
+	}
+	if ctx.Tier > hp.MaxTier {
+		return fmt.Sprintf("tier_%d_above_host_max_%d: %s", ctx.Tier, hp.MaxTier, host)
+	}
+	return ""
+}
+
+// planFirstApplies reports whether the plan-first rule covers this tool call.
+func (e *DefaultEngine) planFirstApplies(ctx Context) bool {
+	if e.PlanFirstMinTier <= 0 || ctx.Tier < e.PlanFirstMinTier {
+		return false
+	}
+	return len(e.PlanFirstChannels) == 0 || e.PlanFirstChannels[ctx.Channel]
+}
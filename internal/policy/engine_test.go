@@ -1,6 +1,7 @@
 package policy
 
 import (
+	"regexp"
 	"testing"
 
 	"github.com/KafClaw/KafClaw/internal/tools"
@@ -145,3 +146,73 @@ func TestInternalMessageAllowsFullAccess(t *testing.T) {
 		t.Fatalf("empty message type should use MaxAutoTier, got: %s", d.Reason)
 	}
 }
+
+func TestPlanFirstRule(t *testing.T) {
+	engine := NewDefaultEngine()
+	engine.PlanFirstMinTier = 2
+	engine.PlanFirstChannels = map[string]bool{"slack": true}
+	if d := engine.Evaluate(Context{Tool: "exec", Tier: 2, Channel: "slack"}); !d.RequiresPlan {
+		t.Fatal("expected plan rule for slack")
+	}
+	if d := engine.Evaluate(Context{Tool: "exec", Tier: 2, Channel: "whatsapp"}); d.RequiresPlan {
+		t.Fatal("plan rule should not apply to whatsapp")
+	}
+	if d := engine.Evaluate(Context{Tool: "write_file", Tier: 1, Channel: "slack"}); d.RequiresPlan {
+		t.Fatal("plan rule should not apply below min tier")
+	}
+}
+
+func TestRemoteHostPolicy(t *testing.T) {
+	engine := NewDefaultEngine()
+	engine.MaxAutoTier = 2
+	engine.RemoteHosts = map[string]RemoteHostPolicy{
+		"db":  {MaxTier: tools.TierReadOnly},
+		"web": {MaxTier: tools.TierHighRisk, Deny: []*regexp.Regexp{regexp.MustCompile(`(?i)\bdrop\b`)}},
+	}
+	call := func(host, command string, tier int) Decision {
+		return engine.Evaluate(Context{Tool: tools.SSHToolName, Tier: tier, Arguments: map[string]any{"host": host, "command": command}})
+	}
+	if d := call("db", "df -h", tools.TierReadOnly); !d.Allow {
+		t.Fatalf("read-only command on db should pass: %s", d.Reason)
+	}
+	if d := call("db", "systemctl restart pg", tools.TierHighRisk); d.Allow || d.RequiresApproval {
+		t.Fatalf("tier above host max must be a final denial, got %+v", d)
+	}
+	if d := call("web", "psql -c 'DROP TABLE x'", tools.TierHighRisk); d.Allow || d.Reason != "remote_command_denied: web" {
+		t.Fatalf("expected host deny pattern, got %+v", d)
+	}
+	if d := call("mail", "uptime", tools.TierReadOnly); d.Allow {
+		t.Fatal("unregistered hosts must be denied even for read-only commands")
+	}
+	if d := call("web", "apt-get upgrade -y", tools.TierHighRisk); !d.Allow {
+		t.Fatalf("tier 2 within host max should follow the normal tier rules: %s", d.Reason)
+	}
+}
+
+func TestToolListPolicy(t *testing.T) {
+	engine := NewDefaultEngine()
+	engine.MaxAutoTier = 2
+	engine.ToolLists = map[string]ToolListPolicy{
+		"slack":     {Deny: map[string]bool{"exec": true}},
+		"slack:ops": {},
+		"msteams":   {Allow: map[string]bool{"read_file": true}},
+	}
+	call := func(channel, account, tool string, tier int) Decision {
+		return engine.Evaluate(Context{Channel: channel, Account: account, Tool: tool, Tier: tier})
+	}
+	if d := call("slack", "default", "exec", tools.TierHighRisk); d.Allow || d.RequiresApproval || d.Reason != "tool_denied_for_channel: slack" {
+		t.Fatalf("expected a final channel denial, got %+v", d)
+	}
+	if d := call("slack", "ops", "exec", tools.TierHighRisk); !d.Allow {
+		t.Fatalf("the account entry should replace the channel entry: %s", d.Reason)
+	}
+	if d := call("msteams", "", "list_dir", tools.TierReadOnly); d.Allow || d.Reason != "tool_not_allowed_for_channel: msteams" {
+		t.Fatalf("expected the allowlist to apply to read-only tools too, got %+v", d)
+	}
+	if d := call("msteams", "", "read_file", tools.TierReadOnly); !d.Allow {
+		t.Fatalf("allowlisted tool should pass: %s", d.Reason)
+	}
+	if d := call("cli", "", "exec", tools.TierHighRisk); !d.Allow {
+		t.Fatalf("channels without lists follow the tier rules: %s", d.Reason)
+	}
+}
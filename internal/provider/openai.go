@@ -3,6 +3,7 @@ package provider
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -421,3 +422,145 @@ func (p *OpenAIProvider) Speak(ctx context.Context, req *TTSRequest) (*TTSRespon
 		Format:    "opus",
 	}, nil
 }
+
+// maxVisionImageBytes caps images sent inline as data URLs.
+const maxVisionImageBytes = 20 << 20
+
+// DescribeImage sends an image to a vision-capable chat model and returns
+// its description. The image is inlined as a base64 data URL.
+func (p *OpenAIProvider) DescribeImage(ctx context.Context, req *VisionRequest) (*VisionResponse, error) {
+	data, err := os.ReadFile(req.ImagePath)
+	if err != nil {
+		return nil, fmt.Errorf("read image: %w", err)
+	}
+	if len(data) > maxVisionImageBytes {
+		return nil, fmt.Errorf("image too large (%d bytes, max %d)", len(data), maxVisionImageBytes)
+	}
+	mimeType := req.MimeType
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	if !strings.HasPrefix(mimeType, "image/") {
+		return nil, fmt.Errorf("unsupported image type %q", mimeType)
+	}
+	model := req.Model
+	if model == "" {
+		model = p.defaultModel
+	}
+	prompt := strings.TrimSpace(req.Prompt)
+	if prompt == "" {
+		prompt = "Describe this image. Transcribe any visible text verbatim."
+	}
+
+	body := map[string]any{
+		"model": model,
+		"messages": []map[string]any{{
+			"role": "user",
+			"content": []map[string]any{
+				{"type": "text", "text": prompt},
+				{"type": "image_url", "image_url": map[string]any{
+					"url": "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data),
+				}},
+			},
+		}},
+	}
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("marshal vision request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.apiBase+"/chat/completions", bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("create vision request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("execute vision request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read vision response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vision API error (status %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	var apiResp openAIResponse
+	if err := json.Unmarshal(respBody, &apiResp); err != nil {
+		return nil, fmt.Errorf("parse vision response: %w", err)
+	}
+	chatResp, err := p.parseResponse(&apiResp)
+	if err != nil {
+		return nil, err
+	}
+	return &VisionResponse{Text: chatResp.Content, Usage: chatResp.Usage}, nil
+}
+
+// GenerateImage creates an image with the OpenAI images API.
+func (p *OpenAIProvider) GenerateImage(ctx context.Context, req *ImageRequest) (*ImageResponse, error) {
+	model := req.Model
+	if model == "" {
+		model = "gpt-image-1"
+	}
+	size := req.Size
+	if size == "" {
+		size = "1024x1024"
+	}
+	body := map[string]any{
+		"model":  model,
+		"prompt": req.Prompt,
+		"size":   size,
+		"n":      1,
+	}
+	if strings.HasPrefix(model, "dall-e") {
+		body["response_format"] = "b64_json"
+	}
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("marshal image request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.apiBase+"/images/generations", bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("create image request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("execute image request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read image response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("image API error (status %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	var imgResp struct {
+		Data []struct {
+			B64JSON       string `json:"b64_json"`
+			RevisedPrompt string `json:"revised_prompt"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &imgResp); err != nil {
+		return nil, fmt.Errorf("parse image response: %w", err)
+	}
+	if len(imgResp.Data) == 0 || imgResp.Data[0].B64JSON == "" {
+		return nil, fmt.Errorf("no image data in response")
+	}
+	data, err := base64.StdEncoding.DecodeString(imgResp.Data[0].B64JSON)
+	if err != nil {
+		return nil, fmt.Errorf("decode image data: %w", err)
+	}
+	return &ImageResponse{Data: data, Format: "png", RevisedPrompt: imgResp.Data[0].RevisedPrompt}, nil
+}
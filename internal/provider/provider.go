@@ -118,3 +118,43 @@ type EmbeddingResponse struct {
 	Vector []float32
 	Usage  Usage
 }
+
+// VisionProvider is an optional interface for providers that can describe
+// images. Callers should use type assertion like Embedder.
+type VisionProvider interface {
+	DescribeImage(ctx context.Context, req *VisionRequest) (*VisionResponse, error)
+}
+
+// VisionRequest contains parameters for an image understanding request.
+type VisionRequest struct {
+	ImagePath string
+	MimeType  string // detected from the file when empty
+	Prompt    string
+	Model     string // default: the provider's default model
+}
+
+// VisionResponse contains the model's description of the image.
+type VisionResponse struct {
+	Text  string
+	Usage Usage
+}
+
+// ImageGenerator is an optional interface for backends that can create
+// images from a text prompt.
+type ImageGenerator interface {
+	GenerateImage(ctx context.Context, req *ImageRequest) (*ImageResponse, error)
+}
+
+// ImageRequest contains parameters for an image generation request.
+type ImageRequest struct {
+	Prompt string
+	Size   string // e.g. "1024x1024"; backend default when empty
+	Model  string
+}
+
+// ImageResponse contains the generated image.
+type ImageResponse struct {
+	Data          []byte
+	Format        string // file extension, e.g. "png"
+	RevisedPrompt string
+}
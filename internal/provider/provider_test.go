@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -246,3 +249,59 @@ func TestOpenAIProvider_APIError(t *testing.T) {
 		t.Error("expected error for unauthorized request")
 	}
 }
+
+func TestOpenAIProvider_DescribeImage(t *testing.T) {
+	img := filepath.Join(t.TempDir(), "shot.png")
+	if err := os.WriteFile(img, []byte("\x89PNG\r\n\x1a\n0000"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var gotURL string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []struct {
+				Content []map[string]any `json:"content"`
+			} `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if len(body.Messages) == 1 && len(body.Messages[0].Content) == 2 {
+			if iu, ok := body.Messages[0].Content[1]["image_url"].(map[string]any); ok {
+				gotURL, _ = iu["url"].(string)
+			}
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"A chart"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	p := NewOpenAIProvider("key", server.URL, "vision-model")
+	resp, err := p.DescribeImage(context.Background(), &VisionRequest{ImagePath: img})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text != "A chart" || !strings.HasPrefix(gotURL, "data:image/png;base64,") {
+		t.Fatalf("unexpected result %q, url %q", resp.Text, gotURL)
+	}
+}
+
+func TestStableDiffusionProvider_GenerateImage(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sdapi/v1/txt2img" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"images":["cG5nLWJ5dGVz"]}`))
+	}))
+	defer server.Close()
+
+	p := NewStableDiffusionProvider(server.URL)
+	resp, err := p.GenerateImage(context.Background(), &ImageRequest{Prompt: "a diagram", Size: "640x480"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Data) != "png-bytes" || resp.Format != "png" {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+	if body["prompt"] != "a diagram" || body["width"] != float64(640) || body["height"] != float64(480) {
+		t.Fatalf("unexpected request body %+v", body)
+	}
+}
@@ -0,0 +1,342 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/tracing"
+)
+
+// Resilience defaults, used when a provider config leaves a value at zero.
+const (
+	defaultProviderTimeout  = 120 * time.Second
+	defaultProviderRetries  = 2
+	defaultProviderBackoff  = 500 * time.Millisecond
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+	maxProviderRetryAfter   = 30 * time.Second
+)
+
+// Circuit breaker states.
+const (
+	breakerStateClosed   = "closed"
+	breakerStateOpen     = "open"
+	breakerStateHalfOpen = "half_open"
+)
+
+// ErrCircuitOpen is returned without calling the provider while its circuit
+// breaker is open.
+var ErrCircuitOpen = errors.New("provider circuit breaker open")
+
+// Resilience configures the HTTP behaviour of a provider: a per-attempt
+// timeout, retries with exponential backoff on retryable statuses and network
+// errors, and a circuit breaker that short-circuits calls after consecutive
+// failures until a cool-down has passed.
+type Resilience struct {
+	Timeout          time.Duration
+	MaxRetries       int
+	Backoff          time.Duration
+	BreakerThreshold int // 0 disables the breaker
+	BreakerCooldown  time.Duration
+}
+
+// ResilienceFromConfig applies defaults to a provider config. Negative
+// maxRetries or breakerThreshold disable retries or the breaker.
+func ResilienceFromConfig(pc config.ProviderConfig) Resilience {
+	r := Resilience{
+		Timeout:          defaultProviderTimeout,
+		MaxRetries:       defaultProviderRetries,
+		Backoff:          defaultProviderBackoff,
+		BreakerThreshold: defaultBreakerThreshold,
+		BreakerCooldown:  defaultBreakerCooldown,
+	}
+	if pc.TimeoutSec > 0 {
+		r.Timeout = time.Duration(pc.TimeoutSec) * time.Second
+	}
+	switch {
+	case pc.MaxRetries > 0:
+		r.MaxRetries = pc.MaxRetries
+	case pc.MaxRetries < 0:
+		r.MaxRetries = 0
+	}
+	switch {
+	case pc.BreakerThreshold > 0:
+		r.BreakerThreshold = pc.BreakerThreshold
+	case pc.BreakerThreshold < 0:
+		r.BreakerThreshold = 0
+	}
+	if pc.BreakerCooldownSec > 0 {
+		r.BreakerCooldown = time.Duration(pc.BreakerCooldownSec) * time.Second
+	}
+	return r
+}
+
+// httpClient returns a client applying r, with a breaker shared by all
+// clients of the same provider ID.
+func (r Resilience) httpClient(providerID string) *http.Client {
+	t := &resilientTransport{
+		base:    http.DefaultTransport,
+		timeout: r.Timeout,
+		retries: r.MaxRetries,
+		backoff: r.Backoff,
+	}
+	if r.BreakerThreshold > 0 {
+		t.breaker = breakerFor(providerID, r.BreakerThreshold, r.BreakerCooldown)
+	}
+	// The per-attempt timeout lives in the transport so retries get a fresh one.
+	return &http.Client{Transport: t}
+}
+
+// resilienceSetter is implemented by HTTP-based providers.
+type resilienceSetter interface {
+	SetResilience(providerID string, r Resilience)
+}
+
+// SetResilience replaces the HTTP client with one applying r.
+func (p *OpenAIProvider) SetResilience(providerID string, r Resilience) {
+	p.httpClient = r.httpClient(providerID)
+}
+
+// SetResilience replaces the HTTP client with one applying r.
+func (p *GeminiProvider) SetResilience(providerID string, r Resilience) {
+	p.httpClient = r.httpClient(providerID)
+}
+
+// SetResilience replaces the HTTP client with one applying r.
+func (p *XAIProvider) SetResilience(providerID string, r Resilience) {
+	p.inner.SetResilience(providerID, r)
+}
+
+// resilientTransport retries idempotent-safe replays of a request (the body
+// must be replayable via GetBody) and feeds the circuit breaker. Every attempt
+// carries a traceparent for the trace of the request context.
+type resilientTransport struct {
+	base    http.RoundTripper
+	timeout time.Duration
+	retries int
+	backoff time.Duration
+	breaker *CircuitBreaker
+}
+
+func (t *resilientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if t.breaker != nil {
+			if err := t.breaker.allow(); err != nil {
+				return nil, err
+			}
+		}
+		resp, err := t.attempt(req, attempt)
+		failed := err != nil || resp.StatusCode >= http.StatusInternalServerError
+		if t.breaker != nil {
+			t.breaker.record(failed, describeAttempt(resp, err))
+		}
+		retryable := failed || resp.StatusCode == http.StatusTooManyRequests
+		if !retryable || attempt >= t.retries || req.Context().Err() != nil || (req.Body != nil && req.GetBody == nil) {
+			return resp, err
+		}
+		wait := t.backoff << attempt
+		if resp != nil {
+			if d := retryAfter(resp.Header.Get("Retry-After")); d > 0 {
+				wait = d
+			}
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (t *resilientTransport) attempt(req *http.Request, attempt int) (*http.Response, error) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if t.timeout > 0 {
+		ctx, cancel = context.WithTimeout(req.Context(), t.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(req.Context())
+	}
+	r := req.Clone(ctx)
+	if attempt > 0 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		r.Body = body
+	}
+	tracing.Inject(r, tracing.TraceID(ctx))
+	resp, err := t.base.RoundTrip(r)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// The attempt timeout also bounds reading the body; release it on Close.
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+func describeAttempt(resp *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("status %d", resp.StatusCode)
+}
+
+func retryAfter(v string) time.Duration {
+	secs, err := strconv.Atoi(v)
+	if err != nil || secs <= 0 {
+		return 0
+	}
+	d := time.Duration(secs) * time.Second
+	if d > maxProviderRetryAfter {
+		d = maxProviderRetryAfter
+	}
+	return d
+}
+
+// ---------------------------------------------------------------------------
+// Circuit breakers
+// ---------------------------------------------------------------------------
+
+// CircuitBreaker opens after threshold consecutive failures. Once the
+// cool-down has passed it lets one probe call through (half-open); a success
+// closes it, a failure opens it again.
+type CircuitBreaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	state     string
+	failures  int
+	openedAt  time.Time
+	probing   bool
+	lastError string
+	now       func() time.Time
+}
+
+// BreakerState is a snapshot of a provider circuit breaker.
+type BreakerState struct {
+	Provider            string     `json:"provider"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Threshold           int        `json:"threshold"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+	RetryAt             *time.Time `json:"retry_at,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+}
+
+var (
+	breakersMu sync.Mutex
+	breakers   = map[string]*CircuitBreaker{}
+)
+
+// breakerFor returns the shared breaker of a provider ID. The latest
+// threshold and cool-down win.
+func breakerFor(providerID string, threshold int, cooldown time.Duration) *CircuitBreaker {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	b, ok := breakers[providerID]
+	if !ok {
+		b = &CircuitBreaker{name: providerID, state: breakerStateClosed, now: time.Now}
+		breakers[providerID] = b
+	}
+	b.mu.Lock()
+	b.threshold, b.cooldown = threshold, cooldown
+	b.mu.Unlock()
+	return b
+}
+
+// BreakerStates returns all provider breakers, sorted by provider ID.
+func BreakerStates() []BreakerState {
+	breakersMu.Lock()
+	list := make([]*CircuitBreaker, 0, len(breakers))
+	for _, b := range breakers {
+		list = append(list, b)
+	}
+	breakersMu.Unlock()
+	out := make([]BreakerState, 0, len(list))
+	for _, b := range list {
+		out = append(out, b.Snapshot())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Provider < out[j].Provider })
+	return out
+}
+
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case breakerStateOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return fmt.Errorf("%w for %s (last error: %s)", ErrCircuitOpen, b.name, b.lastError)
+		}
+		b.state = breakerStateHalfOpen
+		b.probing = true
+		return nil
+	case breakerStateHalfOpen:
+		if b.probing {
+			return fmt.Errorf("%w for %s (probe in progress)", ErrCircuitOpen, b.name)
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+func (b *CircuitBreaker) record(failed bool, detail string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if !failed {
+		b.state = breakerStateClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	b.lastError = detail
+	if b.state == breakerStateHalfOpen || b.failures >= b.threshold {
+		b.state = breakerStateOpen
+		b.openedAt = b.now()
+	}
+}
+
+// Snapshot returns the current breaker state.
+func (b *CircuitBreaker) Snapshot() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := BreakerState{
+		Provider:            b.name,
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		Threshold:           b.threshold,
+		LastError:           b.lastError,
+	}
+	if b.state != breakerStateClosed {
+		opened := b.openedAt
+		retry := opened.Add(b.cooldown)
+		s.OpenedAt, s.RetryAt = &opened, &retry
+	}
+	return s
+}
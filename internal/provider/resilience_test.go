@@ -0,0 +1,127 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+func TestResilienceFromConfig(t *testing.T) {
+	r := ResilienceFromConfig(config.ProviderConfig{})
+	if r.Timeout != defaultProviderTimeout || r.MaxRetries != defaultProviderRetries || r.BreakerThreshold != defaultBreakerThreshold {
+		t.Fatalf("unexpected defaults: %+v", r)
+	}
+	r = ResilienceFromConfig(config.ProviderConfig{TimeoutSec: 5, MaxRetries: -1, BreakerThreshold: -1, BreakerCooldownSec: 7})
+	if r.Timeout != 5*time.Second || r.MaxRetries != 0 || r.BreakerThreshold != 0 || r.BreakerCooldown != 7*time.Second {
+		t.Fatalf("unexpected overrides: %+v", r)
+	}
+}
+
+func TestOpenAIProvider_RetriesRetryableStatus(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	p := NewOpenAIProvider("k", server.URL, "m")
+	p.SetResilience("test-retry", Resilience{Timeout: time.Second, MaxRetries: 2, Backoff: time.Millisecond, BreakerThreshold: 10, BreakerCooldown: time.Minute})
+	resp, err := p.Chat(context.Background(), &ChatRequest{Messages: []Message{{Role: "user", Content: "hi"}}})
+	if err != nil || resp.Content != "ok" {
+		t.Fatalf("expected success after retries, got %v %v", resp, err)
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", calls)
+	}
+
+	// Client errors are not retried.
+	atomic.StoreInt32(&calls, 0)
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer bad.Close()
+	p = NewOpenAIProvider("k", bad.URL, "m")
+	p.SetResilience("test-retry-4xx", Resilience{MaxRetries: 2, Backoff: time.Millisecond})
+	if _, err := p.Chat(context.Background(), &ChatRequest{}); err == nil || calls != 1 {
+		t.Fatalf("expected one attempt and an error, calls=%d err=%v", calls, err)
+	}
+}
+
+func TestOpenAIProvider_AttemptTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	p := NewOpenAIProvider("k", server.URL, "m")
+	p.SetResilience("test-timeout", Resilience{Timeout: 50 * time.Millisecond})
+	start := time.Now()
+	if _, err := p.Chat(context.Background(), &ChatRequest{}); err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("timeout not applied")
+	}
+}
+
+func TestCircuitBreaker_OpensAndRecovers(t *testing.T) {
+	var calls int32
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+	}))
+	defer server.Close()
+
+	p := NewOpenAIProvider("k", server.URL, "m")
+	p.SetResilience("test-breaker", Resilience{Timeout: time.Second, BreakerThreshold: 2, BreakerCooldown: time.Hour})
+	now := time.Now()
+	b := breakerFor("test-breaker", 2, time.Hour)
+	b.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		_, _ = p.Chat(context.Background(), &ChatRequest{})
+	}
+	_, err := p.Chat(context.Background(), &ChatRequest{})
+	if !errors.Is(err, ErrCircuitOpen) || calls != 2 {
+		t.Fatalf("expected short-circuit after 2 failures, calls=%d err=%v", calls, err)
+	}
+	var state BreakerState
+	for _, s := range BreakerStates() {
+		if s.Provider == "test-breaker" {
+			state = s
+		}
+	}
+	if state.State != breakerStateOpen || state.RetryAt == nil || !strings.Contains(state.LastError, "502") {
+		t.Fatalf("unexpected breaker state: %+v", state)
+	}
+
+	// After the cool-down one probe goes through and closes the breaker.
+	healthy.Store(true)
+	now = now.Add(2 * time.Hour)
+	if _, err := p.Chat(context.Background(), &ChatRequest{}); err != nil {
+		t.Fatalf("expected probe to succeed: %v", err)
+	}
+	if s := b.Snapshot(); s.State != breakerStateClosed || s.ConsecutiveFailures != 0 {
+		t.Fatalf("expected closed breaker, got %+v", s)
+	}
+}
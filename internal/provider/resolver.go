@@ -56,12 +56,12 @@ func Resolve(cfg *config.Config, agentID string) (LLMProvider, error) {
 	modelStr := resolveModelString(cfg, agentID)
 	if modelStr == "" {
 		// Legacy fallback: use global OpenAI provider.
-		return NewOpenAIProvider(cfg.Providers.OpenAI.APIKey, cfg.Providers.OpenAI.APIBase, cfg.Model.Name), nil
+		return legacyOpenAIProvider(cfg, cfg.Model.Name), nil
 	}
 	provID, model := ParseModelString(modelStr)
 	if provID == "" {
 		// Bare model name — use legacy OpenAI path.
-		return NewOpenAIProvider(cfg.Providers.OpenAI.APIKey, cfg.Providers.OpenAI.APIBase, model), nil
+		return legacyOpenAIProvider(cfg, model), nil
 	}
 	provID = NormalizeProviderID(provID, cfg)
 	prov, err := buildProvider(cfg, provID, model)
@@ -69,7 +69,7 @@ func Resolve(cfg *config.Config, agentID string) (LLMProvider, error) {
 		// If the model string came from the global model.name (not per-agent config),
 		// fall back to legacy OpenAI provider for backward compatibility.
 		if !hasPerAgentModel(cfg, agentID) {
-			return NewOpenAIProvider(cfg.Providers.OpenAI.APIKey, cfg.Providers.OpenAI.APIBase, modelStr), nil
+			return legacyOpenAIProvider(cfg, modelStr), nil
 		}
 		return nil, err
 	}
@@ -125,7 +125,7 @@ func ResolveSubagent(cfg *config.Config, agentID string) (LLMProvider, error) {
 			if entry.ID == agentID && entry.Subagents != nil && entry.Subagents.Model != "" {
 				provID, model := ParseModelString(entry.Subagents.Model)
 				if provID == "" {
-					return NewOpenAIProvider(cfg.Providers.OpenAI.APIKey, cfg.Providers.OpenAI.APIBase, model), nil
+					return legacyOpenAIProvider(cfg, model), nil
 				}
 				provID = NormalizeProviderID(provID, cfg)
 				return buildProvider(cfg, provID, model)
@@ -135,7 +135,7 @@ func ResolveSubagent(cfg *config.Config, agentID string) (LLMProvider, error) {
 	if cfg.Tools.Subagents.Model != "" {
 		provID, model := ParseModelString(cfg.Tools.Subagents.Model)
 		if provID == "" {
-			return NewOpenAIProvider(cfg.Providers.OpenAI.APIKey, cfg.Providers.OpenAI.APIBase, model), nil
+			return legacyOpenAIProvider(cfg, model), nil
 		}
 		provID = NormalizeProviderID(provID, cfg)
 		return buildProvider(cfg, provID, model)
@@ -185,8 +185,53 @@ func ResolveFallbacks(cfg *config.Config, agentID string) []LLMProvider {
 	return nil
 }
 
-// buildProvider constructs a provider from its canonical ID and model name.
+// legacyOpenAIProvider builds the providers.openai client used for bare model
+// names.
+func legacyOpenAIProvider(cfg *config.Config, model string) LLMProvider {
+	p := NewOpenAIProvider(cfg.Providers.OpenAI.APIKey, cfg.Providers.OpenAI.APIBase, model)
+	p.SetResilience("openai", ResilienceFromConfig(cfg.Providers.OpenAI))
+	return p
+}
+
+// providerConfigFor returns the config section of a canonical provider ID.
+func providerConfigFor(cfg *config.Config, providerID string) config.ProviderConfig {
+	switch providerID {
+	case "claude":
+		return cfg.Providers.Anthropic
+	case "openai", "openai-codex":
+		return cfg.Providers.OpenAI
+	case "gemini", "gemini-cli":
+		return cfg.Providers.Gemini
+	case "xai":
+		return cfg.Providers.XAI
+	case "scalytics-copilot":
+		return cfg.Providers.ScalyticsCopilot
+	case "openrouter":
+		return cfg.Providers.OpenRouter
+	case "deepseek":
+		return cfg.Providers.DeepSeek
+	case "groq":
+		return cfg.Providers.Groq
+	case "vllm":
+		return cfg.Providers.VLLM
+	}
+	return config.ProviderConfig{}
+}
+
+// buildProvider constructs a provider from its canonical ID and model name
+// and applies the provider's timeout, retry and circuit breaker settings.
 func buildProvider(cfg *config.Config, providerID, model string) (LLMProvider, error) {
+	prov, err := newProvider(cfg, providerID, model)
+	if err != nil {
+		return nil, err
+	}
+	if rs, ok := prov.(resilienceSetter); ok {
+		rs.SetResilience(providerID, ResilienceFromConfig(providerConfigFor(cfg, providerID)))
+	}
+	return prov, nil
+}
+
+func newProvider(cfg *config.Config, providerID, model string) (LLMProvider, error) {
 	switch providerID {
 	case "claude":
 		key := cfg.Providers.Anthropic.APIKey
@@ -294,6 +339,36 @@ func (e *ProviderError) Error() string {
 	return fmt.Sprintf("provider %q: %s", e.Provider, e.Hint)
 }
 
+// ResolveImageGenerator builds the image generation backend configured under
+// tools.imageGen. It returns nil when no backend is configured.
+func ResolveImageGenerator(cfg *config.Config) (ImageGenerator, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	ic := cfg.Tools.ImageGen
+	switch strings.ToLower(strings.TrimSpace(ic.Backend)) {
+	case "":
+		return nil, nil
+	case "openai":
+		key := ic.APIKey
+		if key == "" {
+			key = cfg.Providers.OpenAI.APIKey
+		}
+		if key == "" {
+			return nil, &ProviderError{Provider: "openai", Hint: "set tools.imageGen.apiKey or providers.openai.apiKey"}
+		}
+		base := ic.Endpoint
+		if base == "" {
+			base = cfg.Providers.OpenAI.APIBase
+		}
+		return NewOpenAIProvider(key, base, ""), nil
+	case "sd", "stable-diffusion":
+		return NewStableDiffusionProvider(ic.Endpoint), nil
+	default:
+		return nil, fmt.Errorf("unknown image generation backend %q (want openai or sd)", ic.Backend)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // In-memory rate limit cache
 // ---------------------------------------------------------------------------
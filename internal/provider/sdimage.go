@@ -0,0 +1,101 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StableDiffusionProvider generates images through a local Stable Diffusion
+// WebUI-compatible endpoint (POST /sdapi/v1/txt2img).
+type StableDiffusionProvider struct {
+	endpoint   string
+	httpClient *http.Client
+}
+
+// NewStableDiffusionProvider creates an image generator for a local SD endpoint.
+func NewStableDiffusionProvider(endpoint string) *StableDiffusionProvider {
+	if endpoint == "" {
+		endpoint = "http://127.0.0.1:7860"
+	}
+	return &StableDiffusionProvider{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		httpClient: &http.Client{
+			Timeout: 300 * time.Second,
+		},
+	}
+}
+
+// GenerateImage renders the prompt with txt2img and returns the first image.
+func (p *StableDiffusionProvider) GenerateImage(ctx context.Context, req *ImageRequest) (*ImageResponse, error) {
+	body := map[string]any{
+		"prompt": req.Prompt,
+		"steps":  25,
+	}
+	if w, h, ok := parseImageSize(req.Size); ok {
+		body["width"], body["height"] = w, h
+	}
+	if req.Model != "" {
+		body["override_settings"] = map[string]any{"sd_model_checkpoint": req.Model}
+	}
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("marshal txt2img request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.endpoint+"/sdapi/v1/txt2img", bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("create txt2img request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("execute txt2img request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read txt2img response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("txt2img error (status %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	var sdResp struct {
+		Images []string `json:"images"`
+	}
+	if err := json.Unmarshal(respBody, &sdResp); err != nil {
+		return nil, fmt.Errorf("parse txt2img response: %w", err)
+	}
+	if len(sdResp.Images) == 0 {
+		return nil, fmt.Errorf("no image data in txt2img response")
+	}
+	data, err := base64.StdEncoding.DecodeString(sdResp.Images[0])
+	if err != nil {
+		return nil, fmt.Errorf("decode image data: %w", err)
+	}
+	return &ImageResponse{Data: data, Format: "png"}, nil
+}
+
+// parseImageSize parses "WIDTHxHEIGHT".
+func parseImageSize(size string) (int, int, bool) {
+	w, h, ok := strings.Cut(strings.ToLower(strings.TrimSpace(size)), "x")
+	if !ok {
+		return 0, 0, false
+	}
+	width, err1 := strconv.Atoi(w)
+	height, err2 := strconv.Atoi(h)
+	if err1 != nil || err2 != nil || width <= 0 || height <= 0 {
+		return 0, 0, false
+	}
+	return width, height, true
+}
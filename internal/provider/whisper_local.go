@@ -33,6 +33,10 @@ func (p *LocalWhisperProvider) Speak(ctx context.Context, req *TTSRequest) (*TTS
 	return p.openai.Speak(ctx, req)
 }
 
+func (p *LocalWhisperProvider) DescribeImage(ctx context.Context, req *VisionRequest) (*VisionResponse, error) {
+	return p.openai.DescribeImage(ctx, req)
+}
+
 func (p *LocalWhisperProvider) DefaultModel() string {
 	return p.openai.DefaultModel()
 }
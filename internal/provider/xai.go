@@ -33,6 +33,10 @@ func (p *XAIProvider) Speak(ctx context.Context, req *TTSRequest) (*TTSResponse,
 	return p.inner.Speak(ctx, req)
 }
 
+func (p *XAIProvider) DescribeImage(ctx context.Context, req *VisionRequest) (*VisionResponse, error) {
+	return p.inner.DescribeImage(ctx, req)
+}
+
 func (p *XAIProvider) DefaultModel() string {
 	return p.inner.DefaultModel()
 }
@@ -0,0 +1,492 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+// defaultCalendarPrompt is used when a calendar source has no prompt.
+const defaultCalendarPrompt = `The calendar event "{{.Title}}" starts in {{.LeadMinutes}} minutes. Prepare a short briefing for it.`
+
+// firedRetention bounds how long dispatched one-shot job names are kept.
+const firedRetention = 7 * 24 * time.Hour
+
+// Calendar API endpoints; variables so tests can point them at a fake server.
+var (
+	googleCalendarBaseURL = "https://www.googleapis.com/calendar/v3"
+	graphBaseURL          = "https://graph.microsoft.com/v1.0"
+)
+
+// CalendarEvent is one occurrence of a calendar event.
+type CalendarEvent struct {
+	UID         string
+	Title       string
+	Description string
+	Location    string
+	Organizer   string
+	Attendees   []string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+}
+
+// CalendarFetcher lists the event occurrences that start in [from, to).
+// Recurring events are expanded into their occurrences.
+type CalendarFetcher interface {
+	Events(ctx context.Context, from, to time.Time) ([]CalendarEvent, error)
+}
+
+// TokenFunc returns an OAuth access token for a calendar API.
+type TokenFunc func() (string, error)
+
+// CalendarSource materializes upcoming calendar events into one-shot jobs
+// that fire Lead before each matching event starts.
+type CalendarSource struct {
+	Name      string
+	Category  JobCategory
+	Fetcher   CalendarFetcher
+	Match     *regexp.Regexp // nil matches every event
+	Lead      time.Duration
+	Poll      time.Duration
+	Lookahead time.Duration
+	Prompt    *template.Template
+}
+
+// NewCalendarSource builds a calendar source from config. token supplies
+// the OAuth access token of google and m365 sources and is unused for ics.
+func NewCalendarSource(cfg config.CalendarSourceConfig, token TokenFunc) (*CalendarSource, error) {
+	name := strings.TrimSpace(cfg.Name)
+	if name == "" {
+		return nil, fmt.Errorf("calendar source: name is required")
+	}
+	src := &CalendarSource{
+		Name:      name,
+		Category:  JobCategory(strings.ToLower(strings.TrimSpace(cfg.Category))),
+		Lead:      time.Duration(cfg.LeadMinutes) * time.Minute,
+		Poll:      time.Duration(cfg.PollMinutes) * time.Minute,
+		Lookahead: time.Duration(cfg.LookaheadHours) * time.Hour,
+	}
+	if src.Category == "" {
+		src.Category = CategoryLLM
+	}
+	if src.Poll <= 0 {
+		src.Poll = 15 * time.Minute
+	}
+	if src.Lookahead <= 0 {
+		src.Lookahead = 24 * time.Hour
+	}
+	if m := strings.TrimSpace(cfg.TitleMatch); m != "" {
+		re, err := regexp.Compile("(?i)" + m)
+		if err != nil {
+			return nil, fmt.Errorf("calendar source %s: titleMatch: %w", name, err)
+		}
+		src.Match = re
+	}
+	prompt := cfg.Prompt
+	if strings.TrimSpace(prompt) == "" {
+		prompt = defaultCalendarPrompt
+	}
+	tmpl, err := template.New(name).Option("missingkey=zero").Parse(prompt)
+	if err != nil {
+		return nil, fmt.Errorf("calendar source %s: prompt: %w", name, err)
+	}
+	src.Prompt = tmpl
+
+	switch strings.ToLower(strings.TrimSpace(cfg.Kind)) {
+	case "ics":
+		if strings.TrimSpace(cfg.URL) == "" {
+			return nil, fmt.Errorf("calendar source %s: url is required for ics", name)
+		}
+		src.Fetcher = &ICSFetcher{Location: strings.TrimSpace(cfg.URL)}
+	case "google":
+		calendarID := strings.TrimSpace(cfg.CalendarID)
+		if calendarID == "" {
+			calendarID = "primary"
+		}
+		src.Fetcher = &GoogleCalendarFetcher{CalendarID: calendarID, Token: token}
+	case "m365":
+		src.Fetcher = &GraphCalendarFetcher{Token: token}
+	default:
+		return nil, fmt.Errorf("calendar source %s: unknown kind %q (want ics, google or m365)", name, cfg.Kind)
+	}
+	return src, nil
+}
+
+// AddCalendarSource registers a calendar source. Sources are polled while
+// Run is active.
+func (s *Scheduler) AddCalendarSource(src *CalendarSource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calendars = append(s.calendars, src)
+	slog.Info("Scheduler calendar source registered", "name", src.Name, "lead", src.Lead)
+}
+
+// pollCalendar materializes the events of src every Poll until ctx is done.
+func (s *Scheduler) pollCalendar(ctx context.Context, src *CalendarSource) {
+	ticker := time.NewTicker(src.Poll)
+	defer ticker.Stop()
+	for {
+		if err := s.syncCalendar(ctx, src, time.Now()); err != nil {
+			slog.Warn("Calendar poll failed", "source", src.Name, "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// syncCalendar fetches the upcoming events of src and replaces its one-shot
+// jobs with one per matching occurrence. Occurrences whose fire time already
+// passed are not scheduled late.
+func (s *Scheduler) syncCalendar(ctx context.Context, src *CalendarSource, now time.Time) error {
+	events, err := src.Fetcher.Events(ctx, now, now.Add(src.Lookahead+src.Lead))
+	if err != nil {
+		return err
+	}
+	prefix := calendarJobPrefix(src.Name)
+	want := make(map[string]*Job)
+	for _, ev := range events {
+		if src.Match != nil && !src.Match.MatchString(ev.Title) {
+			continue
+		}
+		at := ev.Start.Add(-src.Lead)
+		if at.Before(now.Add(-s.cfg.TickInterval)) {
+			continue
+		}
+		name := prefix + ev.UID + "@" + ev.Start.UTC().Format(time.RFC3339)
+		content, err := src.render(ev)
+		if err != nil {
+			slog.Warn("Calendar prompt render failed", "source", src.Name, "event", ev.Title, "error", err)
+			continue
+		}
+		want[name] = &Job{
+			Name:     name,
+			Category: src.Category,
+			Content:  content,
+			At:       at,
+			ChatID:   "scheduler:calendar:" + src.Name,
+			// Titles, descriptions and attendees come from whoever sent the
+			// invite, so the job runs with the external sender policy.
+			Metadata: map[string]any{
+				bus.MetaKeyMessageType: bus.MessageTypeExternal,
+				"calendar_source":      src.Name,
+				"calendar_event":       ev.metadata(),
+			},
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, t := range s.fired {
+		if now.Sub(t) > firedRetention {
+			delete(s.fired, name)
+		}
+	}
+	for name := range s.jobs {
+		if strings.HasPrefix(name, prefix) && want[name] == nil {
+			delete(s.jobs, name)
+		}
+	}
+	for name, job := range want {
+		if _, done := s.fired[name]; done {
+			continue
+		}
+		s.jobs[name] = job
+	}
+	return nil
+}
+
+func calendarJobPrefix(source string) string {
+	return "calendar:" + source + ":"
+}
+
+// render builds the job content: the prompt template, followed by the event
+// details so the agent always has them.
+func (src *CalendarSource) render(ev CalendarEvent) (string, error) {
+	var b strings.Builder
+	data := struct {
+		CalendarEvent
+		LeadMinutes int
+	}{ev, int(src.Lead / time.Minute)}
+	if err := src.Prompt.Execute(&b, data); err != nil {
+		return "", err
+	}
+	b.WriteString("\n\n[Calendar event]\n")
+	fmt.Fprintf(&b, "Title: %s\n", ev.Title)
+	fmt.Fprintf(&b, "Start: %s\n", ev.Start.Format(time.RFC3339))
+	if !ev.End.IsZero() {
+		fmt.Fprintf(&b, "End: %s\n", ev.End.Format(time.RFC3339))
+	}
+	if ev.Location != "" {
+		fmt.Fprintf(&b, "Location: %s\n", ev.Location)
+	}
+	if ev.Organizer != "" {
+		fmt.Fprintf(&b, "Organizer: %s\n", ev.Organizer)
+	}
+	if len(ev.Attendees) > 0 {
+		fmt.Fprintf(&b, "Attendees: %s\n", strings.Join(ev.Attendees, ", "))
+	}
+	if ev.Description != "" {
+		fmt.Fprintf(&b, "Description: %s\n", ev.Description)
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+func (ev CalendarEvent) metadata() map[string]any {
+	m := map[string]any{
+		"uid":     ev.UID,
+		"title":   ev.Title,
+		"start":   ev.Start.Format(time.RFC3339),
+		"all_day": ev.AllDay,
+	}
+	if !ev.End.IsZero() {
+		m["end"] = ev.End.Format(time.RFC3339)
+	}
+	if ev.Location != "" {
+		m["location"] = ev.Location
+	}
+	if ev.Organizer != "" {
+		m["organizer"] = ev.Organizer
+	}
+	if len(ev.Attendees) > 0 {
+		m["attendees"] = ev.Attendees
+	}
+	return m
+}
+
+// ICSFetcher reads an iCalendar feed from an http(s)/webcal URL or a local
+// file.
+type ICSFetcher struct {
+	Location string
+	Client   *http.Client
+}
+
+func (f *ICSFetcher) Events(ctx context.Context, from, to time.Time) ([]CalendarEvent, error) {
+	data, err := f.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ParseICS(data, from, to)
+}
+
+func (f *ICSFetcher) read(ctx context.Context) ([]byte, error) {
+	loc := f.Location
+	if strings.HasPrefix(loc, "webcal://") {
+		loc = "https://" + strings.TrimPrefix(loc, "webcal://")
+	}
+	if !strings.HasPrefix(loc, "http://") && !strings.HasPrefix(loc, "https://") {
+		return os.ReadFile(strings.TrimPrefix(loc, "file://"))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, loc, nil)
+	if err != nil {
+		return nil, err
+	}
+	return doCalendarRequest(f.Client, req)
+}
+
+// GoogleCalendarFetcher lists events of a Google calendar.
+type GoogleCalendarFetcher struct {
+	CalendarID string
+	Token      TokenFunc
+	Client     *http.Client
+}
+
+func (f *GoogleCalendarFetcher) Events(ctx context.Context, from, to time.Time) ([]CalendarEvent, error) {
+	token, err := f.Token()
+	if err != nil {
+		return nil, fmt.Errorf("google calendar token: %w", err)
+	}
+	q := url.Values{}
+	q.Set("singleEvents", "true")
+	q.Set("orderBy", "startTime")
+	q.Set("maxResults", "250")
+	q.Set("timeMin", from.UTC().Format(time.RFC3339))
+	q.Set("timeMax", to.UTC().Format(time.RFC3339))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		googleCalendarBaseURL+"/calendars/"+url.PathEscape(f.CalendarID)+"/events?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	body, err := doCalendarRequest(f.Client, req)
+	if err != nil {
+		return nil, err
+	}
+
+	type googleTime struct {
+		DateTime string `json:"dateTime"`
+		Date     string `json:"date"`
+	}
+	var resp struct {
+		Items []struct {
+			ID          string     `json:"id"`
+			Status      string     `json:"status"`
+			Summary     string     `json:"summary"`
+			Description string     `json:"description"`
+			Location    string     `json:"location"`
+			Start       googleTime `json:"start"`
+			End         googleTime `json:"end"`
+			Organizer   struct {
+				Email string `json:"email"`
+			} `json:"organizer"`
+			Attendees []struct {
+				Email string `json:"email"`
+			} `json:"attendees"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("google calendar: %w", err)
+	}
+	parse := func(t googleTime) (time.Time, bool) {
+		if t.DateTime != "" {
+			v, err := time.Parse(time.RFC3339, t.DateTime)
+			return v, err == nil
+		}
+		v, err := time.ParseInLocation("2006-01-02", t.Date, time.Local)
+		return v, err == nil
+	}
+	var out []CalendarEvent
+	for _, it := range resp.Items {
+		if it.Status == "cancelled" {
+			continue
+		}
+		start, ok := parse(it.Start)
+		if !ok {
+			continue
+		}
+		end, _ := parse(it.End)
+		ev := CalendarEvent{
+			UID:         it.ID,
+			Title:       it.Summary,
+			Description: it.Description,
+			Location:    it.Location,
+			Organizer:   it.Organizer.Email,
+			Start:       start,
+			End:         end,
+			AllDay:      it.Start.DateTime == "",
+		}
+		for _, a := range it.Attendees {
+			ev.Attendees = append(ev.Attendees, a.Email)
+		}
+		out = append(out, ev)
+	}
+	return out, nil
+}
+
+// GraphCalendarFetcher lists events of the signed-in user's Microsoft 365
+// calendar through the Graph calendarView, which expands recurrences.
+type GraphCalendarFetcher struct {
+	Token  TokenFunc
+	Client *http.Client
+}
+
+func (f *GraphCalendarFetcher) Events(ctx context.Context, from, to time.Time) ([]CalendarEvent, error) {
+	token, err := f.Token()
+	if err != nil {
+		return nil, fmt.Errorf("m365 calendar token: %w", err)
+	}
+	q := url.Values{}
+	q.Set("startDateTime", from.UTC().Format(time.RFC3339))
+	q.Set("endDateTime", to.UTC().Format(time.RFC3339))
+	q.Set("$top", "250")
+	q.Set("$orderby", "start/dateTime")
+	q.Set("$select", "id,subject,bodyPreview,location,start,end,organizer,attendees,isCancelled,isAllDay")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, graphBaseURL+"/me/calendarView?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Prefer", `outlook.timezone="UTC"`)
+	body, err := doCalendarRequest(f.Client, req)
+	if err != nil {
+		return nil, err
+	}
+
+	type graphTime struct {
+		DateTime string `json:"dateTime"`
+	}
+	type graphAddress struct {
+		EmailAddress struct {
+			Address string `json:"address"`
+		} `json:"emailAddress"`
+	}
+	var resp struct {
+		Value []struct {
+			ID          string `json:"id"`
+			Subject     string `json:"subject"`
+			BodyPreview string `json:"bodyPreview"`
+			Location    struct {
+				DisplayName string `json:"displayName"`
+			} `json:"location"`
+			Start       graphTime      `json:"start"`
+			End         graphTime      `json:"end"`
+			Organizer   graphAddress   `json:"organizer"`
+			Attendees   []graphAddress `json:"attendees"`
+			IsCancelled bool           `json:"isCancelled"`
+			IsAllDay    bool           `json:"isAllDay"`
+		} `json:"value"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("m365 calendar: %w", err)
+	}
+	var out []CalendarEvent
+	for _, it := range resp.Value {
+		if it.IsCancelled {
+			continue
+		}
+		start, err := time.ParseInLocation("2006-01-02T15:04:05.9999999", it.Start.DateTime, time.UTC)
+		if err != nil {
+			continue
+		}
+		end, _ := time.ParseInLocation("2006-01-02T15:04:05.9999999", it.End.DateTime, time.UTC)
+		ev := CalendarEvent{
+			UID:         it.ID,
+			Title:       it.Subject,
+			Description: it.BodyPreview,
+			Location:    it.Location.DisplayName,
+			Organizer:   it.Organizer.EmailAddress.Address,
+			Start:       start,
+			End:         end,
+			AllDay:      it.IsAllDay,
+		}
+		for _, a := range it.Attendees {
+			ev.Attendees = append(ev.Attendees, a.EmailAddress.Address)
+		}
+		out = append(out, ev)
+	}
+	return out, nil
+}
+
+func doCalendarRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("calendar fetch: status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
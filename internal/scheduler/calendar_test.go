@@ -0,0 +1,195 @@
+package scheduler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/config"
+)
+
+const testICS = "BEGIN:VCALENDAR\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup-1\r\n" +
+	"SUMMARY:Daily Standup\r\n" +
+	"DTSTART:20261012T090000Z\r\n" +
+	"DTEND:20261012T091500Z\r\n" +
+	"RRULE:FREQ=WEEKLY;BYDAY=MO,WE,FR;COUNT=10\r\n" +
+	"EXDATE:20261016T090000Z\r\n" +
+	"LOCATION:Room 4\\, 2nd floor\r\n" +
+	"ATTENDEE;CN=Ann:mailto:ann@example.com\r\n" +
+	"DESCRIPTION:Yesterday\\, today\\, \r\n" +
+	" blockers\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup-1\r\n" +
+	"RECURRENCE-ID:20261019T090000Z\r\n" +
+	"SUMMARY:Daily Standup (moved)\r\n" +
+	"DTSTART:20261019T100000Z\r\n" +
+	"DTEND:20261019T101500Z\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup-1\r\n" +
+	"RECURRENCE-ID:20261021T090000Z\r\n" +
+	"STATUS:CANCELLED\r\n" +
+	"DTSTART:20261021T090000Z\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:review-1\r\n" +
+	"SUMMARY:Quarterly Review\r\n" +
+	"DTSTART;VALUE=DATE:20261020\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseICSExpandsRecurrences(t *testing.T) {
+	from := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 10, 24, 0, 0, 0, 0, time.UTC)
+	events, err := ParseICS([]byte(testICS), from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, ev := range events {
+		if ev.UID == "standup-1" {
+			got = append(got, ev.Start.UTC().Format("Mon 02 15:04")+" "+ev.Title)
+		}
+	}
+	// Fri 16 is an EXDATE, Mon 19 moved to 10:00, Wed 21 cancelled.
+	want := []string{"Wed 14 09:00 Daily Standup", "Mon 19 10:00 Daily Standup (moved)", "Fri 23 09:00 Daily Standup"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("occurrences = %v, want %v", got, want)
+	}
+	first := events[0]
+	if first.Location != "Room 4, 2nd floor" || first.Description != "Yesterday, today, blockers" ||
+		len(first.Attendees) != 1 || first.Attendees[0] != "ann@example.com" || first.End.Sub(first.Start) != 15*time.Minute {
+		t.Fatalf("unexpected event fields: %+v", first)
+	}
+	if _, err := ParseICS([]byte("<html>login</html>"), from, to); err == nil {
+		t.Fatal("expected error for non-iCalendar data")
+	}
+}
+
+type fakeFetcher struct{ events []CalendarEvent }
+
+func (f *fakeFetcher) Events(context.Context, time.Time, time.Time) ([]CalendarEvent, error) {
+	return f.events, nil
+}
+
+func TestCalendarSourceMaterializesOneShotJobs(t *testing.T) {
+	b := bus.NewMessageBus()
+	s := New(Config{TickInterval: time.Minute, LockPath: t.TempDir() + "/cal.lock"}, b, nil)
+	src, err := NewCalendarSource(config.CalendarSourceConfig{
+		Name:        "work",
+		Kind:        "ics",
+		URL:         "/unused.ics",
+		TitleMatch:  "^standup$",
+		LeadMinutes: 30,
+		Prompt:      "Prepare a summary for {{.Title}} in {{.Location}} ({{.LeadMinutes}}m).",
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	fetcher := &fakeFetcher{events: []CalendarEvent{
+		{UID: "a", Title: "Standup", Location: "Room 4", Start: now.Add(time.Hour)},
+		{UID: "b", Title: "Lunch", Start: now.Add(2 * time.Hour)},
+		{UID: "c", Title: "standup", Start: now.Add(10 * time.Minute)}, // fire time already passed
+	}}
+	src.Fetcher = fetcher
+
+	ctx := context.Background()
+	if err := s.syncCalendar(ctx, src, now); err != nil {
+		t.Fatal(err)
+	}
+	if jobs := s.Jobs(); len(jobs) != 1 || !jobs[0].At.Equal(now.Add(30*time.Minute)) {
+		t.Fatalf("expected one job 30m before the standup, got %+v", jobs)
+	}
+
+	s.tick(ctx, now.Add(29*time.Minute))
+	if len(s.Jobs()) != 1 {
+		t.Fatal("job fired early")
+	}
+	s.tick(ctx, now.Add(30*time.Minute))
+	msg, err := b.ConsumeInbound(withTimeout(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(msg.Content, "Prepare a summary for Standup in Room 4 (30m).") || !strings.Contains(msg.Content, "[Calendar event]") {
+		t.Fatalf("unexpected content: %q", msg.Content)
+	}
+	ev, _ := msg.Metadata["calendar_event"].(map[string]any)
+	if msg.ChatID != "scheduler:calendar:work" || msg.Metadata["calendar_source"] != "work" || ev["uid"] != "a" {
+		t.Fatalf("unexpected message routing/metadata: %s %+v", msg.ChatID, msg.Metadata)
+	}
+	if msg.MessageType() != bus.MessageTypeExternal {
+		t.Fatalf("calendar jobs carry invite text and must be external, got %q", msg.MessageType())
+	}
+	if len(s.Jobs()) != 0 {
+		t.Fatal("one-shot job must be removed after firing")
+	}
+
+	// A later poll neither re-schedules the fired occurrence nor keeps jobs
+	// of events that left the calendar.
+	fetcher.events = append(fetcher.events, CalendarEvent{UID: "d", Title: "Standup", Start: now.Add(5 * time.Hour)})
+	if err := s.syncCalendar(ctx, src, now.Add(31*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if jobs := s.Jobs(); len(jobs) != 1 || !strings.Contains(jobs[0].Name, ":d@") {
+		t.Fatalf("expected only the new occurrence, got %+v", jobs)
+	}
+	fetcher.events = nil
+	_ = s.syncCalendar(ctx, src, now.Add(32*time.Minute))
+	if len(s.Jobs()) != 0 {
+		t.Fatal("jobs of removed events must be dropped")
+	}
+}
+
+func TestCalendarAPIFetchers(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/google/calendars/primary/events"):
+			if r.URL.Query().Get("singleEvents") != "true" {
+				t.Errorf("google query must expand recurrences: %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`{"items":[
+				{"id":"g1","summary":"Standup","start":{"dateTime":"2026-10-16T09:00:00+02:00"},"end":{"dateTime":"2026-10-16T09:15:00+02:00"},"organizer":{"email":"boss@example.com"},"attendees":[{"email":"ann@example.com"}]},
+				{"id":"g2","status":"cancelled","summary":"Gone","start":{"dateTime":"2026-10-16T10:00:00Z"}}]}`))
+		case r.URL.Path == "/graph/me/calendarView":
+			if r.Header.Get("Prefer") != `outlook.timezone="UTC"` {
+				t.Errorf("graph request must ask for UTC times")
+			}
+			w.Write([]byte(`{"value":[{"id":"m1","subject":"Standup","start":{"dateTime":"2026-10-16T07:00:00.0000000","timeZone":"UTC"},"end":{"dateTime":"2026-10-16T07:15:00.0000000","timeZone":"UTC"},"location":{"displayName":"Teams"}}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	prevGoogle, prevGraph := googleCalendarBaseURL, graphBaseURL
+	googleCalendarBaseURL, graphBaseURL = srv.URL+"/google", srv.URL+"/graph"
+	defer func() { googleCalendarBaseURL, graphBaseURL = prevGoogle, prevGraph }()
+
+	token := func() (string, error) { return "tok", nil }
+	want := time.Date(2026, 10, 16, 7, 0, 0, 0, time.UTC)
+	g, err := (&GoogleCalendarFetcher{CalendarID: "primary", Token: token}).Events(context.Background(), want, want.Add(time.Hour))
+	if err != nil || len(g) != 1 || !g[0].Start.Equal(want) || g[0].Organizer != "boss@example.com" || g[0].Attendees[0] != "ann@example.com" {
+		t.Fatalf("google events: %+v %v", g, err)
+	}
+	m, err := (&GraphCalendarFetcher{Token: token}).Events(context.Background(), want, want.Add(time.Hour))
+	if err != nil || len(m) != 1 || !m[0].Start.Equal(want) || m[0].Location != "Teams" {
+		t.Fatalf("graph events: %+v %v", m, err)
+	}
+}
+
+func withTimeout(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
@@ -0,0 +1,381 @@
+package scheduler
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRecurrenceSteps bounds RRULE expansion of a single event.
+const maxRecurrenceSteps = 10000
+
+// icsProp is one content line of an iCalendar component.
+type icsProp struct {
+	params map[string]string
+	value  string
+}
+
+// ParseICS returns the occurrences of the VEVENTs in data that start in
+// [from, to). Recurring events are expanded (FREQ DAILY/WEEKLY/MONTHLY/
+// YEARLY with INTERVAL, COUNT, UNTIL and weekly BYDAY), EXDATEs are skipped
+// and RECURRENCE-ID overrides replace the occurrence they modify. Cancelled
+// events, and events that cannot be parsed, are dropped.
+func ParseICS(data []byte, from, to time.Time) ([]CalendarEvent, error) {
+	if !strings.Contains(strings.ToUpper(string(data)), "BEGIN:VCALENDAR") {
+		return nil, fmt.Errorf("ics: not an iCalendar feed")
+	}
+	var masters []map[string][]icsProp
+	overrides := map[string]map[string][]icsProp{} // uid@original start -> props
+	var cur map[string][]icsProp
+	for _, line := range unfoldICS(string(data)) {
+		switch {
+		case strings.EqualFold(line, "BEGIN:VEVENT"):
+			cur = map[string][]icsProp{}
+		case strings.EqualFold(line, "END:VEVENT"):
+			if cur == nil {
+				continue
+			}
+			if rid, ok := cur["RECURRENCE-ID"]; ok {
+				if t, _, err := parseICSTime(rid[0]); err == nil {
+					overrides[icsValue(cur, "UID")+"@"+t.UTC().Format(time.RFC3339)] = cur
+				}
+			} else {
+				masters = append(masters, cur)
+			}
+			cur = nil
+		case cur != nil:
+			name, prop, ok := parseICSLine(line)
+			if ok {
+				cur[name] = append(cur[name], prop)
+			}
+		}
+	}
+
+	var out []CalendarEvent
+	for _, props := range masters {
+		base, err := icsEvent(props)
+		if err != nil {
+			slog.Debug("Skipping calendar event", "error", err)
+			continue
+		}
+		starts := []time.Time{base.Start}
+		if rrule := icsValue(props, "RRULE"); rrule != "" {
+			if starts, err = expandRRule(rrule, base.Start, to); err != nil {
+				slog.Debug("Skipping recurring calendar event", "title", base.Title, "error", err)
+				continue
+			}
+		}
+		excluded := map[int64]bool{}
+		for _, ex := range props["EXDATE"] {
+			for _, v := range strings.Split(ex.value, ",") {
+				if t, _, err := parseICSTime(icsProp{params: ex.params, value: v}); err == nil {
+					excluded[t.Unix()] = true
+				}
+			}
+		}
+		duration := base.End.Sub(base.Start)
+		for _, start := range starts {
+			if excluded[start.Unix()] {
+				continue
+			}
+			ev := base
+			ev.Start = start
+			if !base.End.IsZero() {
+				ev.End = start.Add(duration)
+			}
+			status := icsValue(props, "STATUS")
+			if ov, ok := overrides[base.UID+"@"+start.UTC().Format(time.RFC3339)]; ok {
+				if ev, err = icsEvent(ov); err != nil {
+					continue
+				}
+				status = icsValue(ov, "STATUS")
+			}
+			if strings.EqualFold(status, "CANCELLED") {
+				continue
+			}
+			if ev.Start.Before(from) || !ev.Start.Before(to) {
+				continue
+			}
+			out = append(out, ev)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out, nil
+}
+
+func icsEvent(props map[string][]icsProp) (CalendarEvent, error) {
+	ev := CalendarEvent{
+		UID:         icsValue(props, "UID"),
+		Title:       icsText(icsValue(props, "SUMMARY")),
+		Description: icsText(icsValue(props, "DESCRIPTION")),
+		Location:    icsText(icsValue(props, "LOCATION")),
+		Organizer:   icsAddress(icsValue(props, "ORGANIZER")),
+	}
+	for _, a := range props["ATTENDEE"] {
+		ev.Attendees = append(ev.Attendees, icsAddress(a.value))
+	}
+	dtstart, ok := props["DTSTART"]
+	if !ok {
+		return ev, fmt.Errorf("ics: event %q has no DTSTART", ev.Title)
+	}
+	start, allDay, err := parseICSTime(dtstart[0])
+	if err != nil {
+		return ev, fmt.Errorf("ics: event %q: %w", ev.Title, err)
+	}
+	ev.Start, ev.AllDay = start, allDay
+	if dtend, ok := props["DTEND"]; ok {
+		if end, _, err := parseICSTime(dtend[0]); err == nil {
+			ev.End = end
+		}
+	} else if d := icsValue(props, "DURATION"); d != "" {
+		if dur, err := parseICSDuration(d); err == nil {
+			ev.End = start.Add(dur)
+		}
+	}
+	if ev.UID == "" {
+		ev.UID = fmt.Sprintf("%s-%d", ev.Title, start.Unix())
+	}
+	return ev, nil
+}
+
+// unfoldICS joins folded content lines (RFC 5545 3.1).
+func unfoldICS(s string) []string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	var lines []string
+	for _, l := range strings.Split(s, "\n") {
+		if (strings.HasPrefix(l, " ") || strings.HasPrefix(l, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += l[1:]
+			continue
+		}
+		if l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+// parseICSLine splits "NAME;PARAM=V;...:value".
+func parseICSLine(line string) (string, icsProp, bool) {
+	colon := -1
+	inQuote := false
+	for i, r := range line {
+		if r == '"' {
+			inQuote = !inQuote
+		}
+		if r == ':' && !inQuote {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return "", icsProp{}, false
+	}
+	parts := strings.Split(line[:colon], ";")
+	prop := icsProp{params: map[string]string{}, value: line[colon+1:]}
+	for _, p := range parts[1:] {
+		if k, v, ok := strings.Cut(p, "="); ok {
+			prop.params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), prop, true
+}
+
+func icsValue(props map[string][]icsProp, name string) string {
+	if p, ok := props[name]; ok && len(p) > 0 {
+		return strings.TrimSpace(p[0].value)
+	}
+	return ""
+}
+
+func icsText(s string) string {
+	r := strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return r.Replace(s)
+}
+
+func icsAddress(s string) string {
+	if len(s) >= 7 && strings.EqualFold(s[:7], "mailto:") {
+		return s[7:]
+	}
+	return s
+}
+
+// parseICSTime parses DATE and DATE-TIME values: UTC ("Z"), TZID-qualified
+// or floating (local time).
+func parseICSTime(p icsProp) (time.Time, bool, error) {
+	v := strings.TrimSpace(p.value)
+	if p.params["VALUE"] == "DATE" || len(v) == 8 {
+		t, err := time.ParseInLocation("20060102", v, time.Local)
+		return t, true, err
+	}
+	if strings.HasSuffix(v, "Z") {
+		t, err := time.Parse("20060102T150405Z", v)
+		return t, false, err
+	}
+	loc := time.Local
+	if tz := p.params["TZID"]; tz != "" {
+		if l, err := time.LoadLocation(tz); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", v, loc)
+	return t, false, err
+}
+
+// parseICSDuration parses durations like PT30M, PT1H30M, P1D or P1W.
+func parseICSDuration(s string) (time.Duration, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimLeft(s, "+-")
+	if !strings.HasPrefix(s, "P") {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	var d time.Duration
+	inTime := false
+	num := ""
+	for _, r := range s[1:] {
+		switch {
+		case r == 'T':
+			inTime = true
+		case r >= '0' && r <= '9':
+			num += string(r)
+		default:
+			n, err := strconv.Atoi(num)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			num = ""
+			switch {
+			case r == 'W':
+				d += time.Duration(n) * 7 * 24 * time.Hour
+			case r == 'D':
+				d += time.Duration(n) * 24 * time.Hour
+			case r == 'H' && inTime:
+				d += time.Duration(n) * time.Hour
+			case r == 'M' && inTime:
+				d += time.Duration(n) * time.Minute
+			case r == 'S' && inTime:
+				d += time.Duration(n) * time.Second
+			default:
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+		}
+	}
+	if neg {
+		d = -d
+	}
+	return d, nil
+}
+
+var icsWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// expandRRule returns the occurrence starts of a recurrence rule from start
+// up to (excluding) until.
+func expandRRule(rule string, start, until time.Time) ([]time.Time, error) {
+	opts := map[string]string{}
+	for _, part := range strings.Split(rule, ";") {
+		if k, v, ok := strings.Cut(part, "="); ok {
+			opts[strings.ToUpper(k)] = strings.ToUpper(v)
+		}
+	}
+	interval := 1
+	if v := opts["INTERVAL"]; v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid RRULE INTERVAL %q", v)
+		}
+		interval = n
+	}
+	count := 0
+	if v := opts["COUNT"]; v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid RRULE COUNT %q", v)
+		}
+		count = n
+	}
+	if v := opts["UNTIL"]; v != "" {
+		u, _, err := parseICSTime(icsProp{value: v})
+		if err != nil {
+			return nil, fmt.Errorf("invalid RRULE UNTIL %q", v)
+		}
+		// UNTIL is inclusive.
+		if u = u.Add(time.Second); u.Before(until) {
+			until = u
+		}
+	}
+
+	var byDay []time.Weekday
+	if v := opts["BYDAY"]; v != "" {
+		for _, d := range strings.Split(v, ",") {
+			wd, ok := icsWeekdays[d]
+			if !ok {
+				return nil, fmt.Errorf("unsupported RRULE BYDAY %q", d)
+			}
+			byDay = append(byDay, wd)
+		}
+	}
+
+	var out []time.Time
+	emit := func(t time.Time) bool {
+		if !t.Before(until) || (count > 0 && len(out) >= count) {
+			return false
+		}
+		out = append(out, t)
+		return true
+	}
+	step := func(i int) time.Time {
+		switch opts["FREQ"] {
+		case "DAILY":
+			return start.AddDate(0, 0, i*interval)
+		case "WEEKLY":
+			return start.AddDate(0, 0, 7*i*interval)
+		case "MONTHLY":
+			return start.AddDate(0, i*interval, 0)
+		default: // YEARLY
+			return start.AddDate(i*interval, 0, 0)
+		}
+	}
+	switch opts["FREQ"] {
+	case "DAILY", "MONTHLY", "YEARLY":
+		for i := 0; i < maxRecurrenceSteps; i++ {
+			if !emit(step(i)) {
+				break
+			}
+		}
+	case "WEEKLY":
+		if len(byDay) == 0 {
+			byDay = []time.Weekday{start.Weekday()}
+		}
+		// Weeks start on Monday (the RFC 5545 default WKST).
+		offset := (int(start.Weekday()) + 6) % 7
+		weekStart := start.AddDate(0, 0, -offset)
+		for i := 0; i < maxRecurrenceSteps; i++ {
+			week := weekStart.AddDate(0, 0, 7*i*interval)
+			if !week.Before(until) {
+				break
+			}
+			days := make([]time.Time, 0, len(byDay))
+			for _, wd := range byDay {
+				days = append(days, week.AddDate(0, 0, (int(wd)+6)%7))
+			}
+			sort.Slice(days, func(a, b int) bool { return days[a].Before(days[b]) })
+			for _, d := range days {
+				if d.Before(start) {
+					continue
+				}
+				if !emit(d) {
+					return out, nil
+				}
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported RRULE FREQ %q", opts["FREQ"])
+	}
+	return out, nil
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/KafClaw/KafClaw/internal/bus"
+	"github.com/KafClaw/KafClaw/internal/metrics"
 	"github.com/KafClaw/KafClaw/internal/timeline"
 )
 
@@ -28,6 +29,14 @@ type Job struct {
 	Cron     *CronExpr   // Parsed cron expression.
 	Category JobCategory // For semaphore selection.
 	Content  string      // Message content dispatched to the agent loop.
+
+	// At makes the job one-shot: it fires on the first tick at or after At
+	// and is then removed. Cron is ignored when At is set.
+	At time.Time
+	// Metadata is added to the metadata of the dispatched message.
+	Metadata map[string]any
+	// ChatID overrides the default "scheduler:<Name>" session of the job.
+	ChatID string
 }
 
 // Config holds scheduler settings.
@@ -62,6 +71,10 @@ type Scheduler struct {
 	mu         sync.RWMutex
 	semaphores map[JobCategory]*Semaphore
 	lock       *FileLock
+	calendars  []*CalendarSource
+	// fired remembers dispatched one-shot jobs so a calendar poll does not
+	// materialize them again.
+	fired map[string]time.Time
 }
 
 // New creates a Scheduler.
@@ -92,7 +105,8 @@ func New(cfg Config, b *bus.MessageBus, tl *timeline.TimelineService) *Scheduler
 			CategoryShell:   NewSemaphore(cfg.MaxConcShell),
 			CategoryDefault: NewSemaphore(cfg.MaxConcDefault),
 		},
-		lock: NewFileLock(cfg.LockPath),
+		lock:  NewFileLock(cfg.LockPath),
+		fired: make(map[string]time.Time),
 	}
 }
 
@@ -124,7 +138,10 @@ func (s *Scheduler) Jobs() []*Job {
 
 // Run starts the scheduler tick loop. Blocks until context is cancelled.
 func (s *Scheduler) Run(ctx context.Context) error {
-	slog.Info("Scheduler started", "tick", s.cfg.TickInterval, "jobs", len(s.jobs))
+	slog.Info("Scheduler started", "tick", s.cfg.TickInterval, "jobs", len(s.jobs), "calendars", len(s.calendars))
+	for _, src := range s.calendars {
+		go s.pollCalendar(ctx, src)
+	}
 	ticker := time.NewTicker(s.cfg.TickInterval)
 	defer ticker.Stop()
 
@@ -153,19 +170,40 @@ func (s *Scheduler) tick(ctx context.Context, now time.Time) {
 	}
 	defer s.lock.Unlock()
 
+	var fired []string
 	s.mu.RLock()
-	defer s.mu.RUnlock()
-
 	for _, job := range s.jobs {
-		if !job.Cron.Matches(now) {
+		if !job.At.IsZero() {
+			if now.Before(job.At) {
+				continue
+			}
+			if s.dispatch(ctx, job, now) {
+				fired = append(fired, job.Name)
+			}
+			continue
+		}
+		if job.Cron == nil || !job.Cron.Matches(now) {
 			continue
 		}
 		s.dispatch(ctx, job, now)
 	}
+	s.mu.RUnlock()
+
+	if len(fired) == 0 {
+		return
+	}
+	s.mu.Lock()
+	for _, name := range fired {
+		delete(s.jobs, name)
+		s.fired[name] = now
+	}
+	s.mu.Unlock()
 }
 
-// dispatch sends a job as a bus.InboundMessage if a semaphore slot is available.
-func (s *Scheduler) dispatch(ctx context.Context, job *Job, now time.Time) {
+// dispatch sends a job as a bus.InboundMessage if a semaphore slot is
+// available, and reports whether it did. A skipped one-shot job stays
+// registered and is retried on the next tick.
+func (s *Scheduler) dispatch(ctx context.Context, job *Job, now time.Time) bool {
 	sem := s.semaphores[job.Category]
 	if sem == nil {
 		sem = s.semaphores[CategoryDefault]
@@ -174,34 +212,46 @@ func (s *Scheduler) dispatch(ctx context.Context, job *Job, now time.Time) {
 	if !sem.TryAcquire() {
 		slog.Warn("Scheduler job skipped: concurrency limit", "job", job.Name, "category", job.Category)
 		s.logJobRun(job.Name, "skipped_concurrency", now)
-		return
+		return false
 	}
 
 	slog.Info("Scheduler dispatching job", "job", job.Name)
 
 	// Dispatch asynchronously; release semaphore when the bus consume completes.
+	metadata := map[string]any{
+		"message_type":   "internal",
+		"scheduler_job":  job.Name,
+		"scheduler_tick": now.Format(time.RFC3339),
+	}
+	for k, v := range job.Metadata {
+		metadata[k] = v
+	}
+	chatID := job.ChatID
+	if chatID == "" {
+		chatID = fmt.Sprintf("scheduler:%s", job.Name)
+	}
 	go func() {
 		defer sem.Release()
 
 		s.bus.PublishInbound(&bus.InboundMessage{
-			Channel:  "scheduler",
-			SenderID: "scheduler",
-			ChatID:   fmt.Sprintf("scheduler:%s", job.Name),
-			Content:  job.Content,
-			Metadata: map[string]any{
-				"message_type":   "internal",
-				"scheduler_job":  job.Name,
-				"scheduler_tick": now.Format(time.RFC3339),
-			},
+			Channel:   "scheduler",
+			SenderID:  "scheduler",
+			ChatID:    chatID,
+			Content:   job.Content,
+			Metadata:  metadata,
 			Timestamp: now,
 		})
 
 		s.logJobRun(job.Name, "dispatched", now)
 	}()
+	return true
 }
 
+var jobRunsTotal = metrics.NewCounter("kafclaw_scheduler_runs_total", "Scheduler job runs, by job and status.", "job", "status")
+
 // logJobRun persists the run status to the scheduled_jobs table (best-effort).
 func (s *Scheduler) logJobRun(name, status string, tick time.Time) {
+	jobRunsTotal.Inc(name, status)
 	if s.timeline == nil {
 		return
 	}
@@ -0,0 +1,69 @@
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Resolve returns the secret a config reference points at. Supported forms:
+//
+//	env:NAME    environment variable NAME
+//	tomb:NAME   env secret sealed in the local tomb
+//	file:PATH   contents of PATH (a leading ~ expands to the home directory)
+//
+// References are kept in config instead of the secret itself, so config
+// files and exports never carry credentials.
+func Resolve(ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	scheme, name, ok := strings.Cut(ref, ":")
+	if !ok || strings.TrimSpace(name) == "" {
+		return "", fmt.Errorf("invalid secret reference %q (want env:, tomb: or file:)", ref)
+	}
+	name = strings.TrimSpace(name)
+	switch strings.ToLower(scheme) {
+	case "env":
+		v, found := os.LookupEnv(name)
+		if !found {
+			return "", fmt.Errorf("secret env var %s not set", name)
+		}
+		return v, nil
+	case "tomb":
+		tombPath, err := ResolveLocalTombPath()
+		if err != nil {
+			return "", err
+		}
+		data, err := os.ReadFile(tombPath)
+		if err != nil {
+			return "", fmt.Errorf("read tomb: %w", err)
+		}
+		doc, err := DecodeLocalTomb(data)
+		if err != nil {
+			return "", err
+		}
+		kv, err := LoadEnvSecretsFromTombDoc(doc)
+		if err != nil {
+			return "", err
+		}
+		v, found := kv[name]
+		if !found {
+			return "", fmt.Errorf("secret %s not found in tomb", name)
+		}
+		return v, nil
+	case "file":
+		if strings.HasPrefix(name, "~") {
+			home, err := resolveHomeDir()
+			if err != nil {
+				return "", err
+			}
+			name = expandTildePath(name, home)
+		}
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return "", fmt.Errorf("read secret file: %w", err)
+		}
+		return string(data), nil
+	default:
+		return "", fmt.Errorf("unsupported secret reference scheme %q", scheme)
+	}
+}
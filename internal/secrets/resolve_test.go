@@ -0,0 +1,26 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	t.Setenv("KAFCLAW_TEST_SECRET", "s3cret")
+	if v, err := Resolve("env:KAFCLAW_TEST_SECRET"); err != nil || v != "s3cret" {
+		t.Fatalf("env ref: %q %v", v, err)
+	}
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, []byte("PRIVATE"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := Resolve("file:" + path); err != nil || v != "PRIVATE" {
+		t.Fatalf("file ref: %q %v", v, err)
+	}
+	for _, ref := range []string{"plain-secret", "env:", "vault:x", "env:KAFCLAW_TEST_UNSET_SECRET"} {
+		if _, err := Resolve(ref); err == nil {
+			t.Errorf("expected error for %q", ref)
+		}
+	}
+}
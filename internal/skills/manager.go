@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -139,3 +140,53 @@ func EffectiveSkillEnabled(cfg *config.Config, skillName string) bool {
 	}
 	return false
 }
+
+// EnabledSkill is an enabled skill with its installed version (if known) and
+// the capability tags declared in its config entry.
+type EnabledSkill struct {
+	Name    string
+	Version string
+	Tags    []string
+}
+
+// ListEnabledSkills returns the enabled bundled, configured and installed
+// skills, sorted by name.
+func ListEnabledSkills(cfg *config.Config) []EnabledSkill {
+	if cfg == nil || !cfg.Skills.Enabled {
+		return nil
+	}
+	names := map[string]bool{}
+	for _, bundled := range BundledCatalog {
+		names[bundled.Name] = true
+	}
+	for name := range cfg.Skills.Entries {
+		names[name] = true
+	}
+	installedDir := ""
+	if state, err := ResolveStateDirs(); err == nil {
+		installedDir = state.Installed
+		if entries, err := os.ReadDir(installedDir); err == nil {
+			for _, e := range entries {
+				if e.IsDir() {
+					names[e.Name()] = true
+				}
+			}
+		}
+	}
+
+	var out []EnabledSkill
+	for name := range names {
+		if strings.TrimSpace(name) == "" || !EffectiveSkillEnabled(cfg, name) {
+			continue
+		}
+		skill := EnabledSkill{Name: name, Tags: cfg.Skills.Entries[name].Capabilities}
+		if installedDir != "" {
+			if meta, err := readMetadata(filepath.Join(installedDir, name, metadataFileName)); err == nil {
+				skill.Version = meta.ClawhubVersion
+			}
+		}
+		out = append(out, skill)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
@@ -1,6 +1,7 @@
 package timeline
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -71,11 +72,13 @@ const (
 	TaskStatusProcessing = "processing"
 	TaskStatusCompleted  = "completed"
 	TaskStatusFailed     = "failed"
+	TaskStatusCancelled  = "cancelled"
 
-	DeliveryPending = "pending"
-	DeliverySent    = "sent"
-	DeliveryFailed  = "failed"
-	DeliverySkipped = "skipped"
+	DeliveryPending  = "pending"
+	DeliverySent     = "sent"
+	DeliveryFailed   = "failed"
+	DeliverySkipped  = "skipped"
+	DeliveryDeferred = "deferred"
 )
 
 // TraceNode represents a node in the trace graph.
@@ -134,6 +137,9 @@ type GroupMemberRecord struct {
 	Status       string     `json:"status"`
 	LastSeen     time.Time  `json:"last_seen"`
 	LeftAt       *time.Time `json:"left_at,omitempty"`
+	// Taxonomy is the typed capability list as a JSON array. An empty value
+	// on upsert keeps the stored taxonomy.
+	Taxonomy string `json:"taxonomy"`
 }
 
 // GroupMembershipHistoryRecord represents a single join/leave event with config snapshot.
@@ -142,7 +148,7 @@ type GroupMembershipHistoryRecord struct {
 	AgentID       string    `json:"agent_id"`
 	GroupName     string    `json:"group_name"`
 	Role          string    `json:"role"`
-	Action        string    `json:"action"` // "joined" or "left"
+	Action        string    `json:"action"` // "joined", "left", "reconciled_active" or "reconciled_inactive"
 	LFSProxyURL   string    `json:"lfs_proxy_url"`
 	KafkaBrokers  string    `json:"kafka_brokers"`
 	ConsumerGroup string    `json:"consumer_group"`
@@ -185,7 +191,7 @@ type AgentTraceStat struct {
 // UnifiedAuditEntry is a merged row from delegation_events, policy_decisions, and approval_requests.
 type UnifiedAuditEntry struct {
 	ID        int64     `json:"id"`
-	Source    string    `json:"source"`     // "delegation", "policy", "approval"
+	Source    string    `json:"source"`     // "delegation", "policy", "approval", "group_admin", "remote_exec"
 	EventType string    `json:"event_type"` // submitted, accepted, allowed, denied, pending, approved, etc.
 	Tier      int       `json:"tier"`
 	AgentID   string    `json:"agent_id"`
@@ -223,6 +229,380 @@ type GroupTaskRecord struct {
 	AcceptedAt          *time.Time `json:"accepted_at,omitempty"`
 	CreatedAt           time.Time  `json:"created_at"`
 	RespondedAt         *time.Time `json:"responded_at,omitempty"`
+
+	// Skill tasks: the skill channel, how often the task was resubmitted, and
+	// the structured result envelope of the last response.
+	SkillName string          `json:"skill_name,omitempty"`
+	Attempts  int             `json:"attempts,omitempty"`
+	Result    json.RawMessage `json:"result,omitempty"`
+	// Urgency: the priority (low, normal, high, urgent; empty is normal),
+	// the agent a directed task was sent to, and how often and when the
+	// task was last escalated after missing its deadline.
+	Priority      string     `json:"priority,omitempty"`
+	TargetAgentID string     `json:"target_agent_id,omitempty"`
+	Escalations   int        `json:"escalations,omitempty"`
+	EscalatedAt   *time.Time `json:"escalated_at,omitempty"`
+}
+
+// GroupTaskAgentStats aggregates group task outcomes for one responder.
+type GroupTaskAgentStats struct {
+	AgentID   string `json:"agent_id"`
+	Completed int    `json:"completed"`
+	Failed    int    `json:"failed"` // failed or rejected
+	Open      int    `json:"open"`   // pending or accepted
+	Overdue   int    `json:"overdue"`
+}
+
+// TimelineRedactionRecord logs one redaction applied to a timeline event.
+type TimelineRedactionRecord struct {
+	ID             int64     `json:"id"`
+	EventID        string    `json:"event_id"`
+	OriginalSHA256 string    `json:"original_sha256"`
+	PatternSHA256  string    `json:"pattern_sha256,omitempty"`
+	Matches        int       `json:"matches"`
+	Actor          string    `json:"actor"`
+	Reason         string    `json:"reason"`
+	RedactedAt     time.Time `json:"redacted_at"`
+}
+
+// GroupInboxRecord is a directed task held for an agent that may be offline.
+type GroupInboxRecord struct {
+	ID            int64     `json:"id"`
+	TaskID        string    `json:"task_id"`
+	TargetAgentID string    `json:"target_agent_id"`
+	RequesterID   string    `json:"requester_id"`
+	Payload       string    `json:"payload"` // JSON-encoded task request
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// GroupAdminActionRecord is the audit record of an administrative group
+// envelope (kick, mute, rename, ...), whether it was applied or rejected.
+type GroupAdminActionRecord struct {
+	ID            int64     `json:"id"`
+	GroupName     string    `json:"group_name"`
+	Action        string    `json:"action"`
+	IssuerID      string    `json:"issuer_id"`
+	TargetID      string    `json:"target_id"`
+	Detail        string    `json:"detail"`
+	Status        string    `json:"status"` // "applied" or "rejected"
+	CorrelationID string    `json:"correlation_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// RemoteExecRecord audits one command the ssh tool ran or refused on a
+// remote host.
+type RemoteExecRecord struct {
+	ID          int64     `json:"id"`
+	TraceID     string    `json:"trace_id"`
+	TaskID      string    `json:"task_id"`
+	Host        string    `json:"host"`
+	Address     string    `json:"address"`
+	User        string    `json:"user"`
+	Command     string    `json:"command"`
+	Tier        int       `json:"tier"`
+	ExitCode    int       `json:"exit_code"` // -1 when the command did not complete
+	DurationMs  int64     `json:"duration_ms"`
+	OutputBytes int       `json:"output_bytes"`
+	Error       string    `json:"error"` // "denied", "timeout", a connection error, or empty
+	Sender      string    `json:"sender"`
+	Channel     string    `json:"channel"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// SettingChangeRecord audits one change of a runtime setting or config key
+// made through the gateway API. Values are stored masked.
+type SettingChangeRecord struct {
+	ID        int64     `json:"id"`
+	Key       string    `json:"key"`
+	OldValue  string    `json:"old_value"`
+	NewValue  string    `json:"new_value"`
+	Actor     string    `json:"actor"`
+	SourceIP  string    `json:"source_ip"`
+	Endpoint  string    `json:"endpoint"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DeliveryReceiptRecord links an outbound reply to the platform messages the
+// channel bridge created for it (Slack ts, Teams activity ID).
+type DeliveryReceiptRecord struct {
+	ID         int64     `json:"id"`
+	Channel    string    `json:"channel"`
+	AccountID  string    `json:"account_id"`
+	ChatID     string    `json:"chat_id"`
+	ThreadID   string    `json:"thread_id"`
+	MessageID  string    `json:"message_id"`
+	MessageIDs []string  `json:"message_ids"`
+	FileIDs    []string  `json:"file_ids,omitempty"`
+	TaskID     string    `json:"task_id"`
+	TraceID    string    `json:"trace_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// UsageBucket is the token usage of one channel and model in one hour or
+// day.
+type UsageBucket struct {
+	Bucket  string  `json:"bucket"`
+	Channel string  `json:"channel"`
+	Model   string  `json:"model"`
+	Tokens  int     `json:"tokens"`
+	CostUSD float64 `json:"cost_usd"`
+	Tasks   int     `json:"tasks"`
+}
+
+// TraceTokens is the token usage of one trace.
+type TraceTokens struct {
+	TraceID string `json:"trace_id"`
+	Tokens  int    `json:"tokens"`
+}
+
+// TraceSummary aggregates the timeline events and tasks of one trace.
+// Channel, SenderID and Status come from the trace's latest task and are
+// empty for traces without one.
+type TraceSummary struct {
+	TraceID          string    `json:"trace_id"`
+	Channel          string    `json:"channel,omitempty"`
+	SenderID         string    `json:"sender_id,omitempty"`
+	Status           string    `json:"status,omitempty"`
+	SpanCount        int       `json:"span_count"`
+	ErrorCount       int       `json:"error_count"`
+	HasErrors        bool      `json:"has_errors"`
+	StartedAt        time.Time `json:"started_at"`
+	EndedAt          time.Time `json:"ended_at"`
+	DurationMS       int64     `json:"duration_ms"`
+	Tasks            int       `json:"tasks"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+}
+
+// TraceListFilter selects traces for ListTraces. Zero values do not filter;
+// HasErrors nil matches both.
+type TraceListFilter struct {
+	Channel       string
+	SenderID      string
+	Status        string
+	HasErrors     *bool
+	MinDurationMS int64
+	Limit         int
+	Offset        int
+}
+
+// CostAnomalyRecord is a detected token spend anomaly: Tokens used by a
+// channel and model in a Window ("hour" or "day") against the learned
+// Baseline for that window.
+type CostAnomalyRecord struct {
+	ID        int64         `json:"id"`
+	Window    string        `json:"window"`
+	Bucket    string        `json:"bucket"`
+	Channel   string        `json:"channel"`
+	Model     string        `json:"model"`
+	Tokens    int           `json:"tokens"`
+	Baseline  float64       `json:"baseline"`
+	CostUSD   float64       `json:"cost_usd"`
+	Traces    []TraceTokens `json:"traces"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// DeferredOutboundRecord is an outbound message held back by quiet hours.
+type DeferredOutboundRecord struct {
+	ID        int64     `json:"id"`
+	Channel   string    `json:"channel"`
+	ChatID    string    `json:"chat_id"`
+	TraceID   string    `json:"trace_id,omitempty"`
+	Payload   string    `json:"payload"` // JSON-encoded outbound message
+	Reason    string    `json:"reason,omitempty"`
+	DeliverAt time.Time `json:"deliver_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PromptTemplateRecord is one version of a named prompt template.
+type PromptTemplateRecord struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Version     int       `json:"version"`
+	Content     string    `json:"content"`
+	Description string    `json:"description,omitempty"`
+	Variables   string    `json:"variables"` // JSON object of default variable values
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Commitment owners and statuses.
+const (
+	CommitmentOwnerAgent = "agent"
+	CommitmentOwnerUser  = "user"
+
+	CommitmentOpen      = "open"
+	CommitmentDone      = "done"
+	CommitmentCancelled = "cancelled"
+)
+
+// CommitmentRecord is a promise made in a conversation, either by the agent
+// ("I'll check tomorrow") or by the user ("remind me to send the report").
+type CommitmentRecord struct {
+	ID           int64      `json:"id"`
+	Owner        string     `json:"owner"` // agent|user
+	Description  string     `json:"description"`
+	DueAt        *time.Time `json:"due_at,omitempty"`
+	Status       string     `json:"status"` // open|done|cancelled
+	Channel      string     `json:"channel"`
+	ChatID       string     `json:"chat_id"`
+	ThreadID     string     `json:"thread_id,omitempty"`
+	SessionKey   string     `json:"session_key,omitempty"`
+	TraceID      string     `json:"trace_id,omitempty"`
+	SourceText   string     `json:"source_text,omitempty"`
+	FollowedUpAt *time.Time `json:"followed_up_at,omitempty"`
+	ClosedAt     *time.Time `json:"closed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// Task board item statuses. Open and in-progress items are active; done and
+// cancelled items are closed.
+const (
+	BoardItemOpen       = "open"
+	BoardItemInProgress = "in_progress"
+	BoardItemDone       = "done"
+	BoardItemCancelled  = "cancelled"
+)
+
+// ValidBoardItemStatus reports whether status is a known task board status.
+func ValidBoardItemStatus(status string) bool {
+	switch status {
+	case BoardItemOpen, BoardItemInProgress, BoardItemDone, BoardItemCancelled:
+		return true
+	}
+	return false
+}
+
+// BoardItemRecord is one item on the task board of a chat.
+type BoardItemRecord struct {
+	ID        int64      `json:"id"`
+	Channel   string     `json:"channel"`
+	ChatID    string     `json:"chat_id"`
+	Title     string     `json:"title"`
+	Notes     string     `json:"notes,omitempty"`
+	Status    string     `json:"status"` // open|in_progress|done|cancelled
+	Assignee  string     `json:"assignee,omitempty"`
+	DueAt     *time.Time `json:"due_at,omitempty"`
+	CreatedBy string     `json:"created_by,omitempty"`
+	TraceID   string     `json:"trace_id,omitempty"`
+	ClosedAt  *time.Time `json:"closed_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// BoardItemFilter selects task board items. Empty fields match everything;
+// without a Status only active items are returned unless IncludeClosed is set.
+type BoardItemFilter struct {
+	Channel       string
+	ChatID        string
+	Status        string
+	Assignee      string
+	IncludeClosed bool
+	Limit         int
+}
+
+// BoardSummary is the task board of one chat with its item counts.
+type BoardSummary struct {
+	Channel    string    `json:"channel"`
+	ChatID     string    `json:"chat_id"`
+	Open       int       `json:"open"`
+	InProgress int       `json:"in_progress"`
+	Done       int       `json:"done"`
+	Cancelled  int       `json:"cancelled"`
+	Overdue    int       `json:"overdue"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// ChatPinBudgetChars bounds the total size of the pinned notes of one chat,
+// all of which are injected into every context built for that chat.
+const ChatPinBudgetChars = 2000
+
+// ChatPinRecord is a note pinned to a chat.
+type ChatPinRecord struct {
+	ID        int64     `json:"id"`
+	Channel   string    `json:"channel"`
+	ChatID    string    `json:"chat_id"`
+	Content   string    `json:"content"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BroadcastAudienceRecord is a named, reusable list of broadcast targets.
+type BroadcastAudienceRecord struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Targets     string    `json:"targets"` // JSON array of {"channel","chat_id","thread_id"}
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Mission statuses.
+const (
+	MissionStatusActive   = "active"
+	MissionStatusArchived = "archived"
+)
+
+// Kinds of resources that can be assigned to a mission.
+const (
+	MissionKindTask   = "task"
+	MissionKindJob    = "job"
+	MissionKindRepo   = "repo"
+	MissionKindMemory = "memory"
+)
+
+// IsMissionKind reports whether kind can be assigned to a mission.
+func IsMissionKind(kind string) bool {
+	switch kind {
+	case MissionKindTask, MissionKindJob, MissionKindRepo, MissionKindMemory:
+		return true
+	}
+	return false
+}
+
+// MissionRecord is a named workspace grouping tasks, scheduled jobs, repos
+// and memory namespaces.
+type MissionRecord struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Status      string    `json:"status"` // active|archived
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// MissionAssignmentRecord assigns one resource to a mission. Ref is a task ID,
+// scheduled job name, repo path or memory source namespace, depending on Kind.
+type MissionAssignmentRecord struct {
+	Mission   string    `json:"mission"`
+	Kind      string    `json:"kind"`
+	Ref       string    `json:"ref"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MissionTaskSummary is a task row of a mission dashboard.
+type MissionTaskSummary struct {
+	TaskID      string    `json:"task_id"`
+	TraceID     string    `json:"trace_id,omitempty"`
+	Channel     string    `json:"channel"`
+	ChatID      string    `json:"chat_id"`
+	Status      string    `json:"status"`
+	ContentIn   string    `json:"content_in,omitempty"`
+	TotalTokens int       `json:"total_tokens"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// MissionDashboard aggregates everything assigned to a mission.
+type MissionDashboard struct {
+	Mission          MissionRecord        `json:"mission"`
+	TotalTasks       int                  `json:"total_tasks"`
+	TaskCounts       map[string]int       `json:"task_counts"`
+	TotalTokens      int                  `json:"total_tokens"`
+	CostUSD          float64              `json:"cost_usd"`
+	RecentTasks      []MissionTaskSummary `json:"recent_tasks"`
+	Jobs             []ScheduledJobRecord `json:"jobs"`
+	Repos            []string             `json:"repos"`
+	MemoryNamespaces []string             `json:"memory_namespaces"`
+	MemoryChunks     int                  `json:"memory_chunks"`
 }
 
 // DelegationEventRecord represents a delegation audit event.
@@ -297,6 +677,17 @@ type KnowledgeFactRecord struct {
 	DecisionID string    `json:"decision_id,omitempty"`
 	Tags       string    `json:"tags"` // JSON array
 	UpdatedAt  time.Time `json:"updated_at"`
+
+	// Confidence signals. Usage counters reset when the fact content changes;
+	// vote and proposer counts come from the originating proposal.
+	InjectionCount     int `json:"injection_count"`
+	ContradictionCount int `json:"contradiction_count"`
+	YesVotes           int `json:"yes_votes"`
+	NoVotes            int `json:"no_votes"`
+	ProposerApproved   int `json:"proposer_approved"`
+	ProposerRejected   int `json:"proposer_rejected"`
+	// Confidence is computed from the signals by knowledge.FactConfidence.
+	Confidence float64 `json:"confidence"`
 }
 
 // KnowledgeProposalRecord is a persisted shared-knowledge proposal.
@@ -405,6 +796,15 @@ type ApprovalRecord struct {
 	Status      string     `json:"status"`
 	CreatedAt   time.Time  `json:"created_at"`
 	RespondedAt *time.Time `json:"responded_at,omitempty"`
+	// Lifecycle of the pending approval: expiry, reminders sent over the
+	// originating channel, delegation to second approvers and escalation.
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	Reminders      int        `json:"reminders"`
+	LastReminderAt *time.Time `json:"last_reminder_at,omitempty"`
+	DelegatedAt    *time.Time `json:"delegated_at,omitempty"`
+	Delegates      []string   `json:"delegates,omitempty"`
+	EscalatedAt    *time.Time `json:"escalated_at,omitempty"`
+	DecidedBy      string     `json:"decided_by,omitempty"`
 }
 
 const Schema = `
@@ -499,12 +899,32 @@ CREATE TABLE IF NOT EXISTS memory_chunks (
 	embedding BLOB,
 	source TEXT NOT NULL DEFAULT 'user',
 	tags TEXT DEFAULT '',
+	privacy TEXT DEFAULT '',
+	trace_id TEXT DEFAULT '',
+	event_id TEXT DEFAULT '',
+	channel TEXT DEFAULT '',
+	chat_id TEXT DEFAULT '',
 	version INTEGER NOT NULL DEFAULT 1,
 	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 	updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
 CREATE INDEX IF NOT EXISTS idx_memory_chunks_source ON memory_chunks(source);
 
+CREATE TABLE IF NOT EXISTS memory_embedding_indexes (
+	name TEXT PRIMARY KEY,
+	dimension INTEGER NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS memory_embeddings (
+	chunk_id TEXT NOT NULL,
+	index_name TEXT NOT NULL,
+	embedding BLOB NOT NULL,
+	updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (chunk_id, index_name)
+);
+CREATE INDEX IF NOT EXISTS idx_memory_embeddings_index ON memory_embeddings(index_name);
+
 CREATE TABLE IF NOT EXISTS group_traces (
 	id INTEGER PRIMARY KEY AUTOINCREMENT,
 	trace_id TEXT NOT NULL,
@@ -660,6 +1080,112 @@ CREATE TABLE IF NOT EXISTS group_membership_history (
 CREATE INDEX IF NOT EXISTS idx_membership_history_agent ON group_membership_history(agent_id);
 CREATE INDEX IF NOT EXISTS idx_membership_history_group ON group_membership_history(group_name);
 
+CREATE TABLE IF NOT EXISTS timeline_redactions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	event_id TEXT NOT NULL,
+	original_sha256 TEXT NOT NULL,
+	pattern_sha256 TEXT DEFAULT '',
+	matches INTEGER NOT NULL DEFAULT 0,
+	actor TEXT DEFAULT '',
+	reason TEXT DEFAULT '',
+	redacted_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_timeline_redactions_event ON timeline_redactions(event_id);
+
+CREATE TABLE IF NOT EXISTS deferred_outbound (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	channel TEXT NOT NULL,
+	chat_id TEXT NOT NULL,
+	trace_id TEXT NOT NULL DEFAULT '',
+	payload TEXT NOT NULL,
+	reason TEXT NOT NULL DEFAULT '',
+	deliver_at DATETIME NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_deferred_outbound_deliver_at ON deferred_outbound(deliver_at);
+
+CREATE TABLE IF NOT EXISTS prompt_templates (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	version INTEGER NOT NULL,
+	content TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	variables TEXT NOT NULL DEFAULT '{}',
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE(name, version)
+);
+
+CREATE TABLE IF NOT EXISTS group_task_inbox (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	task_id TEXT NOT NULL,
+	target_agent_id TEXT NOT NULL,
+	requester_id TEXT NOT NULL DEFAULT '',
+	payload TEXT NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE(task_id, target_agent_id)
+);
+CREATE INDEX IF NOT EXISTS idx_group_task_inbox_target ON group_task_inbox(target_agent_id);
+
+CREATE TABLE IF NOT EXISTS group_admin_actions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	group_name TEXT NOT NULL DEFAULT '',
+	action TEXT NOT NULL,
+	issuer_id TEXT NOT NULL,
+	target_id TEXT NOT NULL DEFAULT '',
+	detail TEXT NOT NULL DEFAULT '',
+	status TEXT NOT NULL,
+	correlation_id TEXT NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_group_admin_actions_created ON group_admin_actions(created_at);
+
+CREATE TABLE IF NOT EXISTS remote_exec_audit (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	trace_id TEXT NOT NULL DEFAULT '',
+	task_id TEXT NOT NULL DEFAULT '',
+	host TEXT NOT NULL,
+	address TEXT NOT NULL DEFAULT '',
+	user TEXT NOT NULL DEFAULT '',
+	command TEXT NOT NULL,
+	tier INTEGER NOT NULL DEFAULT 0,
+	exit_code INTEGER NOT NULL DEFAULT -1,
+	duration_ms INTEGER NOT NULL DEFAULT 0,
+	output_bytes INTEGER NOT NULL DEFAULT 0,
+	error TEXT NOT NULL DEFAULT '',
+	sender TEXT NOT NULL DEFAULT '',
+	channel TEXT NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_remote_exec_audit_host ON remote_exec_audit(host, created_at);
+
+CREATE TABLE IF NOT EXISTS settings_audit (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	key TEXT NOT NULL,
+	old_value TEXT NOT NULL DEFAULT '',
+	new_value TEXT NOT NULL DEFAULT '',
+	actor TEXT NOT NULL,
+	source_ip TEXT NOT NULL DEFAULT '',
+	endpoint TEXT NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_settings_audit_key ON settings_audit(key, created_at);
+
+CREATE TABLE IF NOT EXISTS delivery_receipts (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	channel TEXT NOT NULL,
+	account_id TEXT NOT NULL DEFAULT '',
+	chat_id TEXT NOT NULL,
+	thread_id TEXT NOT NULL DEFAULT '',
+	message_id TEXT NOT NULL DEFAULT '',
+	message_ids TEXT NOT NULL DEFAULT '[]',
+	file_ids TEXT NOT NULL DEFAULT '[]',
+	task_id TEXT NOT NULL DEFAULT '',
+	trace_id TEXT NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_delivery_receipts_chat ON delivery_receipts(channel, chat_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_delivery_receipts_task ON delivery_receipts(task_id);
+
 CREATE TABLE IF NOT EXISTS topic_message_log (
 	id INTEGER PRIMARY KEY AUTOINCREMENT,
 	topic_name TEXT NOT NULL,
@@ -701,6 +1227,8 @@ CREATE TABLE IF NOT EXISTS working_memory (
 	thread_id   TEXT NOT NULL DEFAULT '',
 	content     TEXT NOT NULL DEFAULT '',
 	updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+	expires_at  DATETIME,
+	last_accessed_at DATETIME,
 	PRIMARY KEY (resource_id, thread_id)
 );
 
@@ -709,6 +1237,7 @@ CREATE TABLE IF NOT EXISTS observations_queue (
 	session_id TEXT NOT NULL,
 	role TEXT NOT NULL,
 	content TEXT NOT NULL,
+	privacy TEXT NOT NULL DEFAULT 'owner',
 	observed INTEGER NOT NULL DEFAULT 0,
 	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
@@ -719,6 +1248,7 @@ CREATE TABLE IF NOT EXISTS observations (
 	session_id TEXT NOT NULL,
 	content TEXT NOT NULL,
 	priority TEXT NOT NULL DEFAULT 'medium',
+	privacy TEXT NOT NULL DEFAULT 'owner',
 	observed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 	referenced_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
@@ -2,11 +2,16 @@ package timeline
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	_ "modernc.org/sqlite"
 )
@@ -103,11 +108,40 @@ func NewTimelineService(dbPath string) (*TimelineService, error) {
 		embedding BLOB,
 		source TEXT NOT NULL DEFAULT 'user',
 		tags TEXT DEFAULT '',
+		privacy TEXT DEFAULT '',
 		version INTEGER NOT NULL DEFAULT 1,
 		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 	)`)
 	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_memory_chunks_source ON memory_chunks(source)`)
+	// Best-effort migration: privacy levels on chunks and observations.
+	_, _ = db.Exec(`ALTER TABLE memory_chunks ADD COLUMN privacy TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE observations_queue ADD COLUMN privacy TEXT NOT NULL DEFAULT 'owner'`)
+	_, _ = db.Exec(`ALTER TABLE observations ADD COLUMN privacy TEXT NOT NULL DEFAULT 'owner'`)
+	// Best-effort migration: chunk provenance (trace, event, channel, chat).
+	for _, col := range []string{"trace_id", "event_id", "channel", "chat_id"} {
+		_, _ = db.Exec(`ALTER TABLE memory_chunks ADD COLUMN ` + col + ` TEXT DEFAULT ''`)
+	}
+	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_memory_chunks_trace ON memory_chunks(trace_id)`)
+	// Best-effort migration: per-model embedding indexes.
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS memory_embedding_indexes (
+		name TEXT PRIMARY KEY,
+		dimension INTEGER NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS memory_embeddings (
+		chunk_id TEXT NOT NULL,
+		index_name TEXT NOT NULL,
+		embedding BLOB NOT NULL,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (chunk_id, index_name)
+	)`)
+	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_memory_embeddings_index ON memory_embeddings(index_name)`)
+	_, _ = db.Exec(`CREATE TRIGGER IF NOT EXISTS trg_memory_chunks_delete_embeddings
+		AFTER DELETE ON memory_chunks
+		BEGIN
+			DELETE FROM memory_embeddings WHERE chunk_id = OLD.id;
+		END`)
 	// Best-effort migration: span timing columns on timeline.
 	_, _ = db.Exec(`ALTER TABLE timeline ADD COLUMN span_started_at DATETIME`)
 	_, _ = db.Exec(`ALTER TABLE timeline ADD COLUMN span_ended_at DATETIME`)
@@ -222,6 +256,8 @@ func NewTimelineService(dbPath string) (*TimelineService, error) {
 		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 	)`)
 	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_knowledge_facts_group ON knowledge_facts(group_name)`)
+	_, _ = db.Exec(`ALTER TABLE knowledge_facts ADD COLUMN injection_count INTEGER NOT NULL DEFAULT 0`)
+	_, _ = db.Exec(`ALTER TABLE knowledge_facts ADD COLUMN contradiction_count INTEGER NOT NULL DEFAULT 0`)
 	// Best-effort migration: knowledge proposals/votes tables.
 	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS knowledge_proposals (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -281,6 +317,13 @@ func NewTimelineService(dbPath string) (*TimelineService, error) {
 	)`)
 	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_approval_status ON approval_requests(status)`)
 	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_approval_id ON approval_requests(approval_id)`)
+	_, _ = db.Exec(`ALTER TABLE approval_requests ADD COLUMN expires_at DATETIME`)
+	_, _ = db.Exec(`ALTER TABLE approval_requests ADD COLUMN reminders INTEGER DEFAULT 0`)
+	_, _ = db.Exec(`ALTER TABLE approval_requests ADD COLUMN last_reminder_at DATETIME`)
+	_, _ = db.Exec(`ALTER TABLE approval_requests ADD COLUMN delegated_at DATETIME`)
+	_, _ = db.Exec(`ALTER TABLE approval_requests ADD COLUMN delegates TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE approval_requests ADD COLUMN escalated_at DATETIME`)
+	_, _ = db.Exec(`ALTER TABLE approval_requests ADD COLUMN decided_by TEXT DEFAULT ''`)
 	// Best-effort migration: scheduled_jobs table.
 	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS scheduled_jobs (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -322,6 +365,198 @@ func NewTimelineService(dbPath string) (*TimelineService, error) {
 	)`)
 	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_membership_history_agent ON group_membership_history(agent_id)`)
 	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_membership_history_group ON group_membership_history(group_name)`)
+	// Best-effort migration: timeline_redactions table.
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS timeline_redactions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id TEXT NOT NULL,
+		original_sha256 TEXT NOT NULL,
+		pattern_sha256 TEXT DEFAULT '',
+		matches INTEGER NOT NULL DEFAULT 0,
+		actor TEXT DEFAULT '',
+		reason TEXT DEFAULT '',
+		redacted_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_timeline_redactions_event ON timeline_redactions(event_id)`)
+	// Best-effort migration: deferred_outbound table (quiet hours queue).
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS deferred_outbound (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		channel TEXT NOT NULL,
+		chat_id TEXT NOT NULL,
+		trace_id TEXT NOT NULL DEFAULT '',
+		payload TEXT NOT NULL,
+		reason TEXT NOT NULL DEFAULT '',
+		deliver_at DATETIME NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_deferred_outbound_deliver_at ON deferred_outbound(deliver_at)`)
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS prompt_templates (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		content TEXT NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		variables TEXT NOT NULL DEFAULT '{}',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(name, version)
+	)`)
+	// Best-effort migration: commitments table (tracked promises and reminders).
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS commitments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		owner TEXT NOT NULL DEFAULT 'agent',
+		description TEXT NOT NULL,
+		due_at DATETIME,
+		status TEXT NOT NULL DEFAULT 'open',
+		channel TEXT NOT NULL DEFAULT '',
+		chat_id TEXT NOT NULL DEFAULT '',
+		thread_id TEXT NOT NULL DEFAULT '',
+		session_key TEXT NOT NULL DEFAULT '',
+		trace_id TEXT NOT NULL DEFAULT '',
+		source_text TEXT NOT NULL DEFAULT '',
+		followed_up_at DATETIME,
+		closed_at DATETIME,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_commitments_status_due ON commitments(status, due_at)`)
+	// Best-effort migration: board_items table (per-chat task boards).
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS board_items (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		channel TEXT NOT NULL DEFAULT '',
+		chat_id TEXT NOT NULL DEFAULT '',
+		title TEXT NOT NULL,
+		notes TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL DEFAULT 'open',
+		assignee TEXT NOT NULL DEFAULT '',
+		due_at DATETIME,
+		created_by TEXT NOT NULL DEFAULT '',
+		trace_id TEXT NOT NULL DEFAULT '',
+		closed_at DATETIME,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_board_items_chat_status ON board_items(channel, chat_id, status)`)
+	// Best-effort migration: chat_pins table (notes pinned to a chat's context).
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS chat_pins (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		channel TEXT NOT NULL DEFAULT '',
+		chat_id TEXT NOT NULL DEFAULT '',
+		content TEXT NOT NULL,
+		created_by TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_chat_pins_chat ON chat_pins(channel, chat_id)`)
+	// Best-effort migration: broadcast_audiences table (saved broadcast target lists).
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS broadcast_audiences (
+		name TEXT PRIMARY KEY,
+		description TEXT NOT NULL DEFAULT '',
+		targets TEXT NOT NULL DEFAULT '[]',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	// Best-effort migration: missions (named workspaces) and their assignments.
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS missions (
+		name TEXT PRIMARY KEY,
+		description TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL DEFAULT 'active',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS mission_assignments (
+		mission TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		ref TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (kind, ref)
+	)`)
+	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_mission_assignments_mission ON mission_assignments(mission, kind)`)
+	// Best-effort migration: working memory TTL and LRU columns.
+	_, _ = db.Exec(`ALTER TABLE working_memory ADD COLUMN expires_at DATETIME`)
+	_, _ = db.Exec(`ALTER TABLE working_memory ADD COLUMN last_accessed_at DATETIME`)
+	// Best-effort migration: group_task_inbox table (store-and-forward for offline members).
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS group_task_inbox (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id TEXT NOT NULL,
+		target_agent_id TEXT NOT NULL,
+		requester_id TEXT NOT NULL DEFAULT '',
+		payload TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(task_id, target_agent_id)
+	)`)
+	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_group_task_inbox_target ON group_task_inbox(target_agent_id)`)
+	// Best-effort migration: group_admin_actions table (audit of admin envelopes).
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS group_admin_actions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		group_name TEXT NOT NULL DEFAULT '',
+		action TEXT NOT NULL,
+		issuer_id TEXT NOT NULL,
+		target_id TEXT NOT NULL DEFAULT '',
+		detail TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL,
+		correlation_id TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_group_admin_actions_created ON group_admin_actions(created_at)`)
+	// Best-effort migration: remote_exec_audit table (ssh tool commands).
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS remote_exec_audit (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		trace_id TEXT NOT NULL DEFAULT '',
+		task_id TEXT NOT NULL DEFAULT '',
+		host TEXT NOT NULL,
+		address TEXT NOT NULL DEFAULT '',
+		user TEXT NOT NULL DEFAULT '',
+		command TEXT NOT NULL,
+		tier INTEGER NOT NULL DEFAULT 0,
+		exit_code INTEGER NOT NULL DEFAULT -1,
+		duration_ms INTEGER NOT NULL DEFAULT 0,
+		output_bytes INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		sender TEXT NOT NULL DEFAULT '',
+		channel TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_remote_exec_audit_host ON remote_exec_audit(host, created_at)`)
+	// Best-effort migration: settings_audit table (who changed which setting).
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS settings_audit (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		key TEXT NOT NULL,
+		old_value TEXT NOT NULL DEFAULT '',
+		new_value TEXT NOT NULL DEFAULT '',
+		actor TEXT NOT NULL,
+		source_ip TEXT NOT NULL DEFAULT '',
+		endpoint TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_settings_audit_key ON settings_audit(key, created_at)`)
+	// Best-effort migration: delivery_receipts table (platform message IDs of replies).
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS delivery_receipts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		channel TEXT NOT NULL,
+		account_id TEXT NOT NULL DEFAULT '',
+		chat_id TEXT NOT NULL,
+		thread_id TEXT NOT NULL DEFAULT '',
+		message_id TEXT NOT NULL DEFAULT '',
+		message_ids TEXT NOT NULL DEFAULT '[]',
+		file_ids TEXT NOT NULL DEFAULT '[]',
+		task_id TEXT NOT NULL DEFAULT '',
+		trace_id TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_delivery_receipts_chat ON delivery_receipts(channel, chat_id, created_at)`)
+	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_delivery_receipts_task ON delivery_receipts(task_id)`)
+	// Best-effort migration: cost_anomalies table (finops anomaly alerts).
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS cost_anomalies (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		period TEXT NOT NULL,
+		bucket TEXT NOT NULL,
+		channel TEXT NOT NULL,
+		model TEXT NOT NULL,
+		tokens INTEGER NOT NULL,
+		baseline REAL NOT NULL,
+		cost_usd REAL NOT NULL DEFAULT 0,
+		trace_ids TEXT NOT NULL DEFAULT '[]',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(period, bucket, channel, model)
+	)`)
 	// Best-effort migration: topic_message_log table.
 	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS topic_message_log (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -337,12 +572,22 @@ func NewTimelineService(dbPath string) (*TimelineService, error) {
 	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_topic_log_created ON topic_message_log(created_at)`)
 	// Best-effort migration: left_at column on group_members.
 	_, _ = db.Exec(`ALTER TABLE group_members ADD COLUMN left_at DATETIME`)
+	_, _ = db.Exec(`ALTER TABLE group_members ADD COLUMN taxonomy TEXT DEFAULT '[]'`)
 	// Best-effort migration: delegation columns on group_tasks.
 	_, _ = db.Exec(`ALTER TABLE group_tasks ADD COLUMN parent_task_id TEXT DEFAULT ''`)
 	_, _ = db.Exec(`ALTER TABLE group_tasks ADD COLUMN delegation_depth INTEGER DEFAULT 0`)
 	_, _ = db.Exec(`ALTER TABLE group_tasks ADD COLUMN original_requester_id TEXT DEFAULT ''`)
 	_, _ = db.Exec(`ALTER TABLE group_tasks ADD COLUMN deadline_at DATETIME`)
 	_, _ = db.Exec(`ALTER TABLE group_tasks ADD COLUMN accepted_at DATETIME`)
+	// Best-effort migration: skill task result envelope columns on group_tasks.
+	_, _ = db.Exec(`ALTER TABLE group_tasks ADD COLUMN skill_name TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE group_tasks ADD COLUMN attempts INTEGER DEFAULT 0`)
+	_, _ = db.Exec(`ALTER TABLE group_tasks ADD COLUMN result TEXT DEFAULT ''`)
+	// Best-effort migration: priority and escalation columns on group_tasks.
+	_, _ = db.Exec(`ALTER TABLE group_tasks ADD COLUMN priority TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE group_tasks ADD COLUMN target_agent_id TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE group_tasks ADD COLUMN escalations INTEGER DEFAULT 0`)
+	_, _ = db.Exec(`ALTER TABLE group_tasks ADD COLUMN escalated_at DATETIME`)
 
 	return &TimelineService{db: db}, nil
 }
@@ -468,6 +713,24 @@ func (s *TimelineService) GetSetting(key string) (string, error) {
 	return val, nil
 }
 
+// ListSettings returns all stored settings keyed by name.
+func (s *TimelineService) ListSettings() (map[string]string, error) {
+	rows, err := s.db.Query("SELECT key, value FROM settings ORDER BY key")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]string{}
+	for rows.Next() {
+		var k, v string
+		if err := rows.Scan(&k, &v); err != nil {
+			return nil, err
+		}
+		out[k] = v
+	}
+	return out, rows.Err()
+}
+
 // SetSetting persists a setting value.
 func (s *TimelineService) SetSetting(key, value string) error {
 	_, err := s.db.Exec(`
@@ -497,13 +760,19 @@ func (s *TimelineService) RecordKnowledgeIdempotency(idempotencyKey, clawID, ins
 	return rows > 0, nil
 }
 
-// GetKnowledgeFactLatest returns the current accepted state for a fact ID.
-func (s *TimelineService) GetKnowledgeFactLatest(factID string) (*KnowledgeFactRecord, error) {
-	row := s.db.QueryRow(`SELECT fact_id, group_name, subject, predicate, object, version, source,
-		COALESCE(proposal_id,''), COALESCE(decision_id,''), COALESCE(tags,'[]'), updated_at
-		FROM knowledge_facts WHERE fact_id = ?`, factID)
+// knowledgeFactSelect joins each fact with the vote tally of its proposal and
+// the proposer's approved/rejected track record.
+const knowledgeFactSelect = `SELECT f.fact_id, f.group_name, f.subject, f.predicate, f.object, f.version, f.source,
+		COALESCE(f.proposal_id,''), COALESCE(f.decision_id,''), COALESCE(f.tags,'[]'), f.updated_at,
+		COALESCE(f.injection_count,0), COALESCE(f.contradiction_count,0),
+		COALESCE(p.yes_votes,0), COALESCE(p.no_votes,0),
+		(SELECT COUNT(*) FROM knowledge_proposals pp WHERE pp.proposer_claw_id = p.proposer_claw_id AND pp.status = 'approved'),
+		(SELECT COUNT(*) FROM knowledge_proposals pp WHERE pp.proposer_claw_id = p.proposer_claw_id AND pp.status = 'rejected')
+		FROM knowledge_facts f LEFT JOIN knowledge_proposals p ON p.proposal_id = f.proposal_id AND f.proposal_id != ''`
+
+func scanKnowledgeFact(scan func(dest ...any) error) (KnowledgeFactRecord, error) {
 	var rec KnowledgeFactRecord
-	err := row.Scan(
+	err := scan(
 		&rec.FactID,
 		&rec.GroupName,
 		&rec.Subject,
@@ -515,7 +784,19 @@ func (s *TimelineService) GetKnowledgeFactLatest(factID string) (*KnowledgeFactR
 		&rec.DecisionID,
 		&rec.Tags,
 		&rec.UpdatedAt,
+		&rec.InjectionCount,
+		&rec.ContradictionCount,
+		&rec.YesVotes,
+		&rec.NoVotes,
+		&rec.ProposerApproved,
+		&rec.ProposerRejected,
 	)
+	return rec, err
+}
+
+// GetKnowledgeFactLatest returns the current accepted state for a fact ID.
+func (s *TimelineService) GetKnowledgeFactLatest(factID string) (*KnowledgeFactRecord, error) {
+	rec, err := scanKnowledgeFact(s.db.QueryRow(knowledgeFactSelect+` WHERE f.fact_id = ?`, factID).Scan)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -534,6 +815,10 @@ func (s *TimelineService) UpsertKnowledgeFactLatest(rec *KnowledgeFactRecord) er
 		(fact_id, group_name, subject, predicate, object, version, source, proposal_id, decision_id, tags, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, datetime('now'))
 		ON CONFLICT(fact_id) DO UPDATE SET
+			injection_count = CASE WHEN knowledge_facts.subject = excluded.subject AND knowledge_facts.predicate = excluded.predicate
+				AND knowledge_facts.object = excluded.object THEN knowledge_facts.injection_count ELSE 0 END,
+			contradiction_count = CASE WHEN knowledge_facts.subject = excluded.subject AND knowledge_facts.predicate = excluded.predicate
+				AND knowledge_facts.object = excluded.object THEN knowledge_facts.contradiction_count ELSE 0 END,
 			group_name = excluded.group_name,
 			subject = excluded.subject,
 			predicate = excluded.predicate,
@@ -566,15 +851,13 @@ func (s *TimelineService) ListKnowledgeFacts(groupName string, limit, offset int
 	if limit <= 0 {
 		limit = 50
 	}
-	query := `SELECT fact_id, group_name, subject, predicate, object, version, source,
-		COALESCE(proposal_id,''), COALESCE(decision_id,''), COALESCE(tags,'[]'), updated_at
-		FROM knowledge_facts WHERE 1=1`
+	query := knowledgeFactSelect + ` WHERE 1=1`
 	args := []interface{}{}
 	if strings.TrimSpace(groupName) != "" {
-		query += ` AND group_name = ?`
+		query += ` AND f.group_name = ?`
 		args = append(args, strings.TrimSpace(groupName))
 	}
-	query += ` ORDER BY updated_at DESC LIMIT ? OFFSET ?`
+	query += ` ORDER BY f.updated_at DESC LIMIT ? OFFSET ?`
 	args = append(args, limit, offset)
 
 	rows, err := s.db.Query(query, args...)
@@ -584,20 +867,8 @@ func (s *TimelineService) ListKnowledgeFacts(groupName string, limit, offset int
 	defer rows.Close()
 	out := make([]KnowledgeFactRecord, 0, limit)
 	for rows.Next() {
-		var rec KnowledgeFactRecord
-		if err := rows.Scan(
-			&rec.FactID,
-			&rec.GroupName,
-			&rec.Subject,
-			&rec.Predicate,
-			&rec.Object,
-			&rec.Version,
-			&rec.Source,
-			&rec.ProposalID,
-			&rec.DecisionID,
-			&rec.Tags,
-			&rec.UpdatedAt,
-		); err != nil {
+		rec, err := scanKnowledgeFact(rows.Scan)
+		if err != nil {
 			return nil, err
 		}
 		out = append(out, rec)
@@ -605,6 +876,26 @@ func (s *TimelineService) ListKnowledgeFacts(groupName string, limit, offset int
 	return out, rows.Err()
 }
 
+// RecordKnowledgeFactInjections increments the usage counter of facts that
+// were injected into an agent prompt.
+func (s *TimelineService) RecordKnowledgeFactInjections(factIDs []string) error {
+	for _, id := range factIDs {
+		if _, err := s.db.Exec(`UPDATE knowledge_facts SET injection_count = injection_count + 1 WHERE fact_id = ?`, id); err != nil {
+			return fmt.Errorf("record knowledge fact injection: %w", err)
+		}
+	}
+	return nil
+}
+
+// RecordKnowledgeFactContradiction increments the contradiction counter of a
+// fact, e.g. when a peer asserts conflicting content for it.
+func (s *TimelineService) RecordKnowledgeFactContradiction(factID string) error {
+	if _, err := s.db.Exec(`UPDATE knowledge_facts SET contradiction_count = contradiction_count + 1 WHERE fact_id = ?`, factID); err != nil {
+		return fmt.Errorf("record knowledge fact contradiction: %w", err)
+	}
+	return nil
+}
+
 func (s *TimelineService) CountKnowledgeFacts(groupName string) (int, error) {
 	query := `SELECT COUNT(*) FROM knowledge_facts`
 	args := []interface{}{}
@@ -999,7 +1290,7 @@ func (s *TimelineService) GetTaskByIdempotencyKey(key string) (*AgentTask, error
 // UpdateTaskStatus updates a task's status, content_out, and error_text.
 func (s *TimelineService) UpdateTaskStatus(taskID, status, contentOut, errorText string) error {
 	query := `UPDATE tasks SET status = ?, content_out = ?, error_text = ?, updated_at = datetime('now')`
-	if status == TaskStatusCompleted || status == TaskStatusFailed {
+	if status == TaskStatusCompleted || status == TaskStatusFailed || status == TaskStatusCancelled {
 		query += `, completed_at = datetime('now')`
 	}
 	query += ` WHERE task_id = ?`
@@ -1028,6 +1319,15 @@ func (s *TimelineService) UpdateTaskDeliveryWithReason(taskID, deliveryStatus st
 	return err
 }
 
+// DeferTaskDelivery marks a task's reply as held back until the given time
+// without counting it as a delivery attempt. The reply itself is queued in
+// deferred_outbound, so the delivery worker does not retry deferred tasks.
+func (s *TimelineService) DeferTaskDelivery(taskID string, until time.Time, reason string) error {
+	_, err := s.db.Exec(`UPDATE tasks SET delivery_status = ?, delivery_next_at = ?, error_text = ?, updated_at = datetime('now') WHERE task_id = ?`,
+		DeliveryDeferred, until.UTC().Format("2006-01-02 15:04:05"), strings.TrimSpace(reason), taskID)
+	return err
+}
+
 // ListPendingDeliveries returns completed tasks that still need delivery.
 func (s *TimelineService) ListPendingDeliveries(limit int) ([]AgentTask, error) {
 	if limit <= 0 {
@@ -1053,6 +1353,26 @@ func (s *TimelineService) ListPendingDeliveries(limit int) ([]AgentTask, error)
 	return scanTasks(rows)
 }
 
+// ListTasksByTrace returns all tasks that share a trace ID, oldest first.
+func (s *TimelineService) ListTasksByTrace(traceID string) ([]AgentTask, error) {
+	query := `SELECT id, task_id, COALESCE(idempotency_key,''), COALESCE(trace_id,''),
+		channel, chat_id, COALESCE(sender_id,''), COALESCE(message_type,''), status,
+		COALESCE(content_in,''), COALESCE(content_out,''), COALESCE(error_text,''),
+		prompt_tokens, completion_tokens, total_tokens,
+		delivery_status, delivery_attempts, delivery_next_at,
+		created_at, updated_at, completed_at
+	FROM tasks
+	WHERE trace_id = ?
+	ORDER BY id ASC`
+
+	rows, err := s.db.Query(query, traceID)
+	if err != nil {
+		return nil, fmt.Errorf("list tasks by trace: %w", err)
+	}
+	defer rows.Close()
+	return scanTasks(rows)
+}
+
 // CountPendingDeliveries returns the number of completed tasks waiting for delivery.
 func (s *TimelineService) CountPendingDeliveries() (int, error) {
 	var count int
@@ -1253,6 +1573,195 @@ func (s *TimelineService) GetTokenUsageSummary(days int) ([]ProviderDayStat, err
 	return out, rows.Err()
 }
 
+// GetTokenUsageBuckets returns token usage and cost per channel and model
+// for tasks created since since, bucketed by hour ("2006-01-02 15:00") or by
+// day ("2006-01-02"), oldest first.
+func (s *TimelineService) GetTokenUsageBuckets(since time.Time, hourly bool) ([]UsageBucket, error) {
+	format := "%Y-%m-%d"
+	if hourly {
+		format = "%Y-%m-%d %H:00"
+	}
+	rows, err := s.db.Query(`SELECT strftime(?, created_at), channel, COALESCE(model_name,''),
+		COALESCE(SUM(total_tokens),0), COALESCE(SUM(cost_usd),0), COUNT(*)
+		FROM tasks WHERE created_at >= ? AND total_tokens > 0
+		GROUP BY 1, 2, 3 ORDER BY 1, 2, 3`, format, since.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []UsageBucket
+	for rows.Next() {
+		var b UsageBucket
+		if err := rows.Scan(&b.Bucket, &b.Channel, &b.Model, &b.Tokens, &b.CostUSD, &b.Tasks); err != nil {
+			return nil, err
+		}
+		if b.Model == "" {
+			b.Model = "unknown"
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+// TraceListScanLimit bounds the newest traces ListTraces aggregates; older
+// traces are not listed.
+const TraceListScanLimit = 5000
+
+// traceErrorCondition matches timeline events that record a failure: error
+// statuses in the classification and tool or LLM spans with an error in
+// their metadata.
+const traceErrorCondition = `(classification LIKE '%status=error%' OR classification LIKE '%ERROR%' OR COALESCE(metadata,'') LIKE '%"error":%')`
+
+// ListTraces lists traces newest first with their span, error, duration and
+// token aggregates, and returns the number of traces matching filter. Traces
+// are the trace IDs of timeline events; a trace has errors when one of its
+// events records an error or one of its tasks failed. Duration is the time
+// between the first and the last event.
+func (s *TimelineService) ListTraces(filter TraceListFilter) ([]TraceSummary, int, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = 50
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	query := `SELECT g.trace_id, g.spans, g.errors, f.timestamp, l.timestamp,
+		COALESCE(t.channel,''), COALESCE(NULLIF(t.sender_id,''), f.sender_id, ''), COALESCE(t.status,''),
+		COALESCE(tt.tasks,0), COALESCE(tt.failed,0), COALESCE(tt.prompt,0), COALESCE(tt.completion,0), COALESCE(tt.total,0)
+		FROM (SELECT trace_id, COUNT(*) AS spans, MIN(id) AS first_id, MAX(id) AS last_id,
+				SUM(CASE WHEN ` + traceErrorCondition + ` THEN 1 ELSE 0 END) AS errors
+			FROM timeline WHERE COALESCE(trace_id,'') != '' GROUP BY trace_id) g
+		JOIN timeline f ON f.id = g.first_id
+		JOIN timeline l ON l.id = g.last_id
+		LEFT JOIN (SELECT trace_id, MAX(id) AS last_task, COUNT(*) AS tasks,
+				SUM(CASE WHEN status = 'failed' THEN 1 ELSE 0 END) AS failed,
+				SUM(prompt_tokens) AS prompt, SUM(completion_tokens) AS completion, SUM(total_tokens) AS total
+			FROM tasks WHERE COALESCE(trace_id,'') != '' GROUP BY trace_id) tt ON tt.trace_id = g.trace_id
+		LEFT JOIN tasks t ON t.id = tt.last_task
+		WHERE 1=1`
+	var args []any
+	if filter.Channel != "" {
+		query += " AND t.channel = ?"
+		args = append(args, filter.Channel)
+	}
+	if filter.SenderID != "" {
+		query += " AND COALESCE(NULLIF(t.sender_id,''), f.sender_id) = ?"
+		args = append(args, filter.SenderID)
+	}
+	if filter.Status != "" {
+		query += " AND t.status = ?"
+		args = append(args, filter.Status)
+	}
+	if filter.HasErrors != nil {
+		if *filter.HasErrors {
+			query += " AND (g.errors > 0 OR COALESCE(tt.failed,0) > 0)"
+		} else {
+			query += " AND g.errors = 0 AND COALESCE(tt.failed,0) = 0"
+		}
+	}
+	query += " ORDER BY g.last_id DESC LIMIT ?"
+	args = append(args, TraceListScanLimit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	var out []TraceSummary
+	total := 0
+	for rows.Next() {
+		var ts TraceSummary
+		var failed int
+		if err := rows.Scan(&ts.TraceID, &ts.SpanCount, &ts.ErrorCount, &ts.StartedAt, &ts.EndedAt,
+			&ts.Channel, &ts.SenderID, &ts.Status,
+			&ts.Tasks, &failed, &ts.PromptTokens, &ts.CompletionTokens, &ts.TotalTokens); err != nil {
+			return nil, 0, err
+		}
+		if ts.EndedAt.Before(ts.StartedAt) {
+			ts.StartedAt, ts.EndedAt = ts.EndedAt, ts.StartedAt
+		}
+		ts.DurationMS = ts.EndedAt.Sub(ts.StartedAt).Milliseconds()
+		if ts.DurationMS < filter.MinDurationMS {
+			continue
+		}
+		ts.HasErrors = ts.ErrorCount > 0 || failed > 0
+		if total >= filter.Offset && len(out) < filter.Limit {
+			out = append(out, ts)
+		}
+		total++
+	}
+	return out, total, rows.Err()
+}
+
+// ListTopTokenTraces returns the trace IDs of the tasks of channel and model
+// created in [from, to) that used the most tokens. An empty or "unknown"
+// model matches tasks without a model.
+func (s *TimelineService) ListTopTokenTraces(channel, model string, from, to time.Time, limit int) ([]TraceTokens, error) {
+	if limit <= 0 {
+		limit = 5
+	}
+	if model == "unknown" {
+		model = ""
+	}
+	const layout = "2006-01-02 15:04:05"
+	rows, err := s.db.Query(`SELECT COALESCE(trace_id,''), SUM(total_tokens) AS tokens
+		FROM tasks WHERE channel = ? AND COALESCE(model_name,'') = ? AND created_at >= ? AND created_at < ?
+		AND COALESCE(trace_id,'') != ''
+		GROUP BY trace_id ORDER BY tokens DESC LIMIT ?`,
+		channel, model, from.UTC().Format(layout), to.UTC().Format(layout), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []TraceTokens
+	for rows.Next() {
+		var t TraceTokens
+		if err := rows.Scan(&t.TraceID, &t.Tokens); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// RecordCostAnomaly stores a detected anomaly. It reports false when the same
+// window, bucket, channel and model was already recorded.
+func (s *TimelineService) RecordCostAnomaly(rec *CostAnomalyRecord) (bool, error) {
+	traces, _ := json.Marshal(rec.Traces)
+	res, err := s.db.Exec(`INSERT OR IGNORE INTO cost_anomalies
+		(period, bucket, channel, model, tokens, baseline, cost_usd, trace_ids)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		rec.Window, rec.Bucket, rec.Channel, rec.Model, rec.Tokens, rec.Baseline, rec.CostUSD, string(traces))
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ListCostAnomalies returns the most recent cost anomalies, newest first.
+func (s *TimelineService) ListCostAnomalies(limit int) ([]CostAnomalyRecord, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := s.db.Query(`SELECT id, period, bucket, channel, model, tokens, baseline, cost_usd, trace_ids, created_at
+		FROM cost_anomalies ORDER BY created_at DESC, id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []CostAnomalyRecord
+	for rows.Next() {
+		var r CostAnomalyRecord
+		var traces string
+		if err := rows.Scan(&r.ID, &r.Window, &r.Bucket, &r.Channel, &r.Model, &r.Tokens, &r.Baseline, &r.CostUSD, &traces, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		_ = json.Unmarshal([]byte(traces), &r.Traces)
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
 // LogPolicyDecision records a policy evaluation result.
 func (s *TimelineService) LogPolicyDecision(rec *PolicyDecisionRecord) error {
 	_, err := s.db.Exec(`INSERT INTO policy_decisions (trace_id, task_id, tool, tier, sender, channel, allowed, reason)
@@ -1538,8 +2047,8 @@ func (s *TimelineService) GetGroupTraces(traceID string) ([]GroupTrace, error) {
 // UpsertGroupMember inserts or updates a group member in the local roster.
 func (s *TimelineService) UpsertGroupMember(m *GroupMemberRecord) error {
 	_, err := s.db.Exec(`INSERT INTO group_members
-		(agent_id, agent_name, soul_summary, capabilities, channels, model, status, last_seen)
-		VALUES (?, ?, ?, ?, ?, ?, ?, datetime('now'))
+		(agent_id, agent_name, soul_summary, capabilities, channels, model, status, taxonomy, last_seen)
+		VALUES (?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), datetime('now'))
 		ON CONFLICT(agent_id) DO UPDATE SET
 			agent_name = excluded.agent_name,
 			soul_summary = excluded.soul_summary,
@@ -1547,8 +2056,9 @@ func (s *TimelineService) UpsertGroupMember(m *GroupMemberRecord) error {
 			channels = excluded.channels,
 			model = excluded.model,
 			status = excluded.status,
+			taxonomy = COALESCE(excluded.taxonomy, group_members.taxonomy),
 			last_seen = datetime('now')`,
-		m.AgentID, m.AgentName, m.SoulSummary, m.Capabilities, m.Channels, m.Model, m.Status)
+		m.AgentID, m.AgentName, m.SoulSummary, m.Capabilities, m.Channels, m.Model, m.Status, m.Taxonomy)
 	return err
 }
 
@@ -1556,7 +2066,7 @@ func (s *TimelineService) UpsertGroupMember(m *GroupMemberRecord) error {
 func (s *TimelineService) ListGroupMembers() ([]GroupMemberRecord, error) {
 	rows, err := s.db.Query(`SELECT agent_id, COALESCE(agent_name,''), COALESCE(soul_summary,''),
 		COALESCE(capabilities,'[]'), COALESCE(channels,'[]'), COALESCE(model,''),
-		COALESCE(status,'active'), last_seen
+		COALESCE(status,'active'), COALESCE(taxonomy,'[]'), last_seen
 		FROM group_members WHERE left_at IS NULL ORDER BY last_seen DESC`)
 	if err != nil {
 		return nil, err
@@ -1567,7 +2077,7 @@ func (s *TimelineService) ListGroupMembers() ([]GroupMemberRecord, error) {
 	for rows.Next() {
 		var m GroupMemberRecord
 		if err := rows.Scan(&m.AgentID, &m.AgentName, &m.SoulSummary,
-			&m.Capabilities, &m.Channels, &m.Model, &m.Status, &m.LastSeen); err != nil {
+			&m.Capabilities, &m.Channels, &m.Model, &m.Status, &m.Taxonomy, &m.LastSeen); err != nil {
 			return nil, err
 		}
 		out = append(out, m)
@@ -1593,11 +2103,18 @@ func (s *TimelineService) ReactivateGroupMember(agentID string) error {
 	return err
 }
 
+// SetGroupMemberStatus updates the status of a current member without
+// touching last_seen or left_at.
+func (s *TimelineService) SetGroupMemberStatus(agentID, status string) error {
+	_, err := s.db.Exec(`UPDATE group_members SET status = ? WHERE agent_id = ?`, status, agentID)
+	return err
+}
+
 // ListPreviousGroupMembers returns soft-deleted members (left_at IS NOT NULL).
 func (s *TimelineService) ListPreviousGroupMembers() ([]GroupMemberRecord, error) {
 	rows, err := s.db.Query(`SELECT agent_id, COALESCE(agent_name,''), COALESCE(soul_summary,''),
 		COALESCE(capabilities,'[]'), COALESCE(channels,'[]'), COALESCE(model,''),
-		COALESCE(status,'left'), last_seen, left_at
+		COALESCE(status,'left'), COALESCE(taxonomy,'[]'), last_seen, left_at
 		FROM group_members WHERE left_at IS NOT NULL ORDER BY left_at DESC`)
 	if err != nil {
 		return nil, err
@@ -1609,7 +2126,7 @@ func (s *TimelineService) ListPreviousGroupMembers() ([]GroupMemberRecord, error
 		var m GroupMemberRecord
 		var leftAt sql.NullTime
 		if err := rows.Scan(&m.AgentID, &m.AgentName, &m.SoulSummary,
-			&m.Capabilities, &m.Channels, &m.Model, &m.Status, &m.LastSeen, &leftAt); err != nil {
+			&m.Capabilities, &m.Channels, &m.Model, &m.Status, &m.Taxonomy, &m.LastSeen, &leftAt); err != nil {
 			return nil, err
 		}
 		if leftAt.Valid {
@@ -1808,6 +2325,33 @@ func (s *TimelineService) ListUnifiedAudit(filter AuditFilter) ([]UnifiedAuditEn
 		content_text as details, timestamp as created_at
 		FROM timeline WHERE classification = 'MODE_CHANGE'`
 
+	query += ` UNION ALL `
+
+	// group administration (rejected actions carry a "_rejected" suffix)
+	query += `SELECT id, 'group_admin' as source,
+		CASE WHEN status = 'applied' THEN action ELSE action || '_rejected' END as event_type,
+		0 as tier, issuer_id as agent_id, target_id, detail as details, created_at
+		FROM group_admin_actions`
+
+	query += ` UNION ALL `
+
+	// remote commands of the ssh tool (event type: ok, failed, denied, timeout)
+	query += `SELECT id, 'remote_exec' as source,
+		CASE WHEN error = '' AND exit_code = 0 THEN 'ok'
+			WHEN error IN ('denied', 'timeout') THEN error
+			WHEN error LIKE 'tier %' THEN 'denied'
+			ELSE 'failed' END as event_type,
+		tier, sender as agent_id, host as target_id, command as details, created_at
+		FROM remote_exec_audit`
+
+	query += ` UNION ALL `
+
+	// settings and config changes made through the gateway API
+	query += `SELECT id, 'settings' as source, 'changed' as event_type,
+		0 as tier, actor as agent_id, key as target_id,
+		old_value || ' -> ' || new_value as details, created_at
+		FROM settings_audit`
+
 	query += `) AS unified WHERE 1=1`
 	args := []any{}
 
@@ -1867,13 +2411,108 @@ func (s *TimelineService) MarkStaleMembers(cutoff time.Time) (int64, error) {
 // InsertGroupTask inserts a new group collaboration task.
 func (s *TimelineService) InsertGroupTask(task *GroupTaskRecord) error {
 	_, err := s.db.Exec(`INSERT INTO group_tasks
-		(task_id, description, content, direction, requester_id, responder_id, response_content, status)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		(task_id, description, content, direction, requester_id, responder_id, response_content, status, skill_name,
+		 priority, deadline_at, target_agent_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		task.TaskID, task.Description, task.Content, task.Direction,
-		task.RequesterID, task.ResponderID, task.ResponseContent, task.Status)
+		task.RequesterID, task.ResponderID, task.ResponseContent, task.Status, task.SkillName,
+		task.Priority, utcTime(task.DeadlineAt), task.TargetAgentID)
+	return err
+}
+
+// groupTaskColumns are the group_tasks columns read by scanGroupTask.
+const groupTaskColumns = `id, task_id, COALESCE(description,''), COALESCE(content,''),
+		direction, requester_id, COALESCE(responder_id,''),
+		COALESCE(response_content,''), status, created_at, responded_at,
+		COALESCE(skill_name,''), COALESCE(attempts,0), COALESCE(result,''),
+		COALESCE(parent_task_id,''), COALESCE(delegation_depth,0), COALESCE(original_requester_id,''),
+		COALESCE(priority,''), deadline_at, accepted_at, COALESCE(target_agent_id,''),
+		COALESCE(escalations,0), escalated_at`
+
+func scanGroupTask(row interface{ Scan(...any) error }) (GroupTaskRecord, error) {
+	var t GroupTaskRecord
+	var respondedAt, deadlineAt, acceptedAt, escalatedAt sql.NullTime
+	var result string
+	if err := row.Scan(&t.ID, &t.TaskID, &t.Description, &t.Content,
+		&t.Direction, &t.RequesterID, &t.ResponderID,
+		&t.ResponseContent, &t.Status, &t.CreatedAt, &respondedAt,
+		&t.SkillName, &t.Attempts, &result,
+		&t.ParentTaskID, &t.DelegationDepth, &t.OriginalRequesterID,
+		&t.Priority, &deadlineAt, &acceptedAt, &t.TargetAgentID,
+		&t.Escalations, &escalatedAt); err != nil {
+		return t, err
+	}
+	if respondedAt.Valid {
+		t.RespondedAt = &respondedAt.Time
+	}
+	if deadlineAt.Valid {
+		t.DeadlineAt = &deadlineAt.Time
+	}
+	if acceptedAt.Valid {
+		t.AcceptedAt = &acceptedAt.Time
+	}
+	if escalatedAt.Valid {
+		t.EscalatedAt = &escalatedAt.Time
+	}
+	if result != "" {
+		t.Result = json.RawMessage(result)
+	}
+	return t, nil
+}
+
+// utcTime normalizes a timestamp before storing it, so stored times compare
+// and sort consistently.
+func utcTime(t *time.Time) any {
+	if t == nil || t.IsZero() {
+		return nil
+	}
+	return t.UTC()
+}
+
+// nullTimePtr returns the time of a nullable column, or nil.
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// GetGroupTask returns a group task by task_id, or nil when it does not exist.
+func (s *TimelineService) GetGroupTask(taskID string) (*GroupTaskRecord, error) {
+	t, err := scanGroupTask(s.db.QueryRow(`SELECT `+groupTaskColumns+`
+		FROM group_tasks WHERE task_id = ?`, taskID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// UpdateGroupTaskResult records a skill task response with its structured
+// result envelope (JSON).
+func (s *TimelineService) UpdateGroupTaskResult(taskID, responderID, content, status, result string) error {
+	_, err := s.db.Exec(`UPDATE group_tasks SET
+		responder_id = ?, response_content = ?, status = ?, result = ?, responded_at = datetime('now')
+		WHERE task_id = ?`,
+		responderID, content, status, result, taskID)
 	return err
 }
 
+// RetryGroupTask counts a resubmission of a skill task, possibly to another
+// skill, and sets it back to pending. It returns the new attempt count.
+func (s *TimelineService) RetryGroupTask(taskID, skillName string) (int, error) {
+	if _, err := s.db.Exec(`UPDATE group_tasks SET
+		attempts = COALESCE(attempts,0) + 1, skill_name = ?, status = 'pending'
+		WHERE task_id = ?`, skillName, taskID); err != nil {
+		return 0, err
+	}
+	var attempts int
+	err := s.db.QueryRow(`SELECT COALESCE(attempts,0) FROM group_tasks WHERE task_id = ?`, taskID).Scan(&attempts)
+	return attempts, err
+}
+
 // UpdateGroupTaskResponse updates a group task with response data.
 func (s *TimelineService) UpdateGroupTaskResponse(taskID, responderID, content, status string) error {
 	_, err := s.db.Exec(`UPDATE group_tasks SET
@@ -1885,12 +2524,23 @@ func (s *TimelineService) UpdateGroupTaskResponse(taskID, responderID, content,
 
 // ListGroupTasks returns group tasks filtered by direction and status.
 func (s *TimelineService) ListGroupTasks(direction, status string, limit, offset int) ([]GroupTaskRecord, error) {
+	return s.ListGroupTasksSorted(direction, status, "", limit, offset)
+}
+
+// GroupTaskSortUrgency orders group tasks by priority, then earliest
+// deadline, then newest.
+const GroupTaskSortUrgency = "urgency"
+
+// groupTaskPriorityRank is the SQL rank of a priority; higher is more urgent.
+const groupTaskPriorityRank = `CASE priority WHEN 'urgent' THEN 3 WHEN 'high' THEN 2 WHEN 'low' THEN 0 ELSE 1 END`
+
+// ListGroupTasksSorted returns group tasks filtered by direction and status,
+// newest first or, with GroupTaskSortUrgency, most urgent first.
+func (s *TimelineService) ListGroupTasksSorted(direction, status, sortBy string, limit, offset int) ([]GroupTaskRecord, error) {
 	if limit <= 0 {
 		limit = 50
 	}
-	query := `SELECT id, task_id, COALESCE(description,''), COALESCE(content,''),
-		direction, requester_id, COALESCE(responder_id,''),
-		COALESCE(response_content,''), status, created_at, responded_at
+	query := `SELECT ` + groupTaskColumns + `
 		FROM group_tasks WHERE 1=1`
 	args := []interface{}{}
 
@@ -1902,7 +2552,12 @@ func (s *TimelineService) ListGroupTasks(direction, status string, limit, offset
 		query += " AND status = ?"
 		args = append(args, status)
 	}
-	query += " ORDER BY created_at DESC LIMIT ? OFFSET ?"
+	if sortBy == GroupTaskSortUrgency {
+		query += " ORDER BY " + groupTaskPriorityRank + " DESC, deadline_at IS NULL, deadline_at ASC, created_at DESC"
+	} else {
+		query += " ORDER BY created_at DESC"
+	}
+	query += " LIMIT ? OFFSET ?"
 	args = append(args, limit, offset)
 
 	rows, err := s.db.Query(query, args...)
@@ -1913,33 +2568,94 @@ func (s *TimelineService) ListGroupTasks(direction, status string, limit, offset
 
 	var out []GroupTaskRecord
 	for rows.Next() {
-		var t GroupTaskRecord
-		var respondedAt sql.NullTime
-		if err := rows.Scan(&t.ID, &t.TaskID, &t.Description, &t.Content,
-			&t.Direction, &t.RequesterID, &t.ResponderID,
-			&t.ResponseContent, &t.Status, &t.CreatedAt, &respondedAt); err != nil {
+		t, err := scanGroupTask(rows)
+		if err != nil {
 			return nil, err
 		}
-		if respondedAt.Valid {
-			t.RespondedAt = &respondedAt.Time
-		}
 		out = append(out, t)
 	}
 	return out, rows.Err()
 }
 
-// CountOpenGroupTasks returns number of group tasks still awaiting completion.
-func (s *TimelineService) CountOpenGroupTasks() (int, error) {
-	var count int
-	err := s.db.QueryRow(`SELECT COUNT(*) FROM group_tasks WHERE status IN ('pending','accepted')`).Scan(&count)
+// ListOverdueGroupTasks returns the open outgoing group tasks whose deadline
+// passed before now and that were not escalated since, oldest deadline first.
+func (s *TimelineService) ListOverdueGroupTasks(now time.Time) ([]GroupTaskRecord, error) {
+	rows, err := s.db.Query(`SELECT ` + groupTaskColumns + `
+		FROM group_tasks
+		WHERE direction = 'outgoing' AND status IN ('pending', 'accepted') AND deadline_at IS NOT NULL
+		ORDER BY deadline_at ASC`)
 	if err != nil {
-		return 0, fmt.Errorf("count open group tasks: %w", err)
+		return nil, err
 	}
-	return count, nil
-}
+	defer rows.Close()
 
-// ListAllGroupTraces returns paginated group traces with optional agent filter.
-func (s *TimelineService) ListAllGroupTraces(limit, offset int, agentFilter string) ([]GroupTrace, error) {
+	var out []GroupTaskRecord
+	for rows.Next() {
+		t, err := scanGroupTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		if !t.DeadlineAt.Before(now) {
+			continue
+		}
+		if t.EscalatedAt != nil && !t.EscalatedAt.Before(*t.DeadlineAt) {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// EscalateGroupTask records an escalation of a group task at the given time:
+// it sets the task's priority and, when deadline is not nil, a new deadline.
+func (s *TimelineService) EscalateGroupTask(taskID, priority string, deadline *time.Time, at time.Time) error {
+	_, err := s.db.Exec(`UPDATE group_tasks SET
+		priority = ?, deadline_at = COALESCE(?, deadline_at),
+		escalations = COALESCE(escalations,0) + 1, escalated_at = ?
+		WHERE task_id = ?`, priority, utcTime(deadline), at.UTC(), taskID)
+	return err
+}
+
+// CountOpenGroupTasks returns number of group tasks still awaiting completion.
+func (s *TimelineService) CountOpenGroupTasks() (int, error) {
+	var count int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM group_tasks WHERE status IN ('pending','accepted')`).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count open group tasks: %w", err)
+	}
+	return count, nil
+}
+
+// GroupTaskStatsSince aggregates group tasks created since the given time per
+// responder. Tasks without a responder are reported under an empty AgentID.
+func (s *TimelineService) GroupTaskStatsSince(since time.Time) ([]GroupTaskAgentStats, error) {
+	rows, err := s.db.Query(`SELECT COALESCE(responder_id,''),
+		SUM(CASE WHEN status = 'completed' THEN 1 ELSE 0 END),
+		SUM(CASE WHEN status IN ('failed','rejected') THEN 1 ELSE 0 END),
+		SUM(CASE WHEN status IN ('pending','accepted') THEN 1 ELSE 0 END),
+		SUM(CASE WHEN status IN ('pending','accepted') AND deadline_at IS NOT NULL AND deadline_at < datetime('now') THEN 1 ELSE 0 END)
+		FROM group_tasks
+		WHERE created_at >= ?
+		GROUP BY COALESCE(responder_id,'')
+		ORDER BY 1`, since.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return nil, fmt.Errorf("group task stats: %w", err)
+	}
+	defer rows.Close()
+
+	var out []GroupTaskAgentStats
+	for rows.Next() {
+		var st GroupTaskAgentStats
+		if err := rows.Scan(&st.AgentID, &st.Completed, &st.Failed, &st.Open, &st.Overdue); err != nil {
+			return nil, err
+		}
+		out = append(out, st)
+	}
+	return out, rows.Err()
+}
+
+// ListAllGroupTraces returns paginated group traces with optional agent filter.
+func (s *TimelineService) ListAllGroupTraces(limit, offset int, agentFilter string) ([]GroupTrace, error) {
 	if limit <= 0 {
 		limit = 50
 	}
@@ -2118,56 +2834,103 @@ func (s *TimelineService) UpdateApprovalStatus(approvalID, status string) error
 	return err
 }
 
-// GetPendingApprovals returns all approval requests with status 'pending'.
-func (s *TimelineService) GetPendingApprovals() ([]ApprovalRecord, error) {
-	rows, err := s.db.Query(`SELECT id, approval_id, COALESCE(trace_id,''), COALESCE(task_id,''),
+// ResolveApproval records the final status of an approval and who decided
+// it; decidedBy is empty when nobody did (timeout, expiry).
+func (s *TimelineService) ResolveApproval(approvalID, status, decidedBy string) error {
+	_, err := s.db.Exec(`UPDATE approval_requests SET status = ?, decided_by = ?, responded_at = datetime('now') WHERE approval_id = ?`,
+		status, decidedBy, approvalID)
+	return err
+}
+
+// SetApprovalExpiry sets when a pending approval expires.
+func (s *TimelineService) SetApprovalExpiry(approvalID string, expiresAt time.Time) error {
+	_, err := s.db.Exec(`UPDATE approval_requests SET expires_at = ? WHERE approval_id = ?`, expiresAt.UTC(), approvalID)
+	return err
+}
+
+// RecordApprovalReminder counts a reminder sent for a pending approval.
+func (s *TimelineService) RecordApprovalReminder(approvalID string, at time.Time) error {
+	_, err := s.db.Exec(`UPDATE approval_requests SET reminders = COALESCE(reminders,0) + 1, last_reminder_at = ? WHERE approval_id = ?`,
+		at.UTC(), approvalID)
+	return err
+}
+
+// RecordApprovalDelegation records that the delegates may decide a pending
+// approval from at on.
+func (s *TimelineService) RecordApprovalDelegation(approvalID string, delegates []string, at time.Time) error {
+	_, err := s.db.Exec(`UPDATE approval_requests SET delegated_at = ?, delegates = ? WHERE approval_id = ?`,
+		at.UTC(), strings.Join(delegates, ","), approvalID)
+	return err
+}
+
+// RecordApprovalEscalation records that a pending approval was escalated at
+// its expiry and extended to expiresAt.
+func (s *TimelineService) RecordApprovalEscalation(approvalID string, at, expiresAt time.Time) error {
+	_, err := s.db.Exec(`UPDATE approval_requests SET escalated_at = ?, expires_at = ? WHERE approval_id = ?`,
+		at.UTC(), expiresAt.UTC(), approvalID)
+	return err
+}
+
+const approvalColumns = `id, approval_id, COALESCE(trace_id,''), COALESCE(task_id,''),
 		tool, tier, COALESCE(arguments,''), COALESCE(sender,''), COALESCE(channel,''),
-		status, created_at, responded_at
-		FROM approval_requests WHERE status = 'pending' ORDER BY created_at ASC`)
+		status, created_at, responded_at, expires_at, COALESCE(reminders,0), last_reminder_at,
+		delegated_at, COALESCE(delegates,''), escalated_at, COALESCE(decided_by,'')`
+
+// GetApproval returns an approval request by ID, or nil when it does not
+// exist.
+func (s *TimelineService) GetApproval(approvalID string) (*ApprovalRecord, error) {
+	rows, err := s.db.Query(`SELECT `+approvalColumns+` FROM approval_requests WHERE approval_id = ?`, approvalID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	out, err := scanApprovals(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return &out[0], nil
+}
 
-	var out []ApprovalRecord
-	for rows.Next() {
-		var r ApprovalRecord
-		var respondedAt sql.NullTime
-		if err := rows.Scan(&r.ID, &r.ApprovalID, &r.TraceID, &r.TaskID,
-			&r.Tool, &r.Tier, &r.Arguments, &r.Sender, &r.Channel,
-			&r.Status, &r.CreatedAt, &respondedAt); err != nil {
-			return nil, err
-		}
-		if respondedAt.Valid {
-			r.RespondedAt = &respondedAt.Time
-		}
-		out = append(out, r)
+// GetPendingApprovals returns all approval requests with status 'pending'.
+func (s *TimelineService) GetPendingApprovals() ([]ApprovalRecord, error) {
+	rows, err := s.db.Query(`SELECT ` + approvalColumns + ` FROM approval_requests WHERE status = 'pending' ORDER BY created_at ASC`)
+	if err != nil {
+		return nil, err
 	}
-	return out, rows.Err()
+	return scanApprovals(rows)
 }
 
 // GetApprovalsByTraceID returns approval records for a given trace ID.
 func (s *TimelineService) GetApprovalsByTraceID(traceID string) ([]ApprovalRecord, error) {
-	rows, err := s.db.Query(`SELECT id, approval_id, COALESCE(trace_id,''), COALESCE(task_id,''),
-		tool, tier, COALESCE(arguments,''), COALESCE(sender,''), COALESCE(channel,''),
-		status, created_at, responded_at
-		FROM approval_requests WHERE trace_id = ? ORDER BY created_at ASC`, traceID)
+	rows, err := s.db.Query(`SELECT `+approvalColumns+` FROM approval_requests WHERE trace_id = ? ORDER BY created_at ASC`, traceID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	return scanApprovals(rows)
+}
 
+func scanApprovals(rows *sql.Rows) ([]ApprovalRecord, error) {
+	defer rows.Close()
 	var out []ApprovalRecord
 	for rows.Next() {
 		var r ApprovalRecord
-		var respondedAt sql.NullTime
+		var respondedAt, expiresAt, lastReminderAt, delegatedAt, escalatedAt sql.NullTime
+		var delegates string
 		if err := rows.Scan(&r.ID, &r.ApprovalID, &r.TraceID, &r.TaskID,
 			&r.Tool, &r.Tier, &r.Arguments, &r.Sender, &r.Channel,
-			&r.Status, &r.CreatedAt, &respondedAt); err != nil {
+			&r.Status, &r.CreatedAt, &respondedAt, &expiresAt, &r.Reminders, &lastReminderAt,
+			&delegatedAt, &delegates, &escalatedAt, &r.DecidedBy); err != nil {
 			return nil, err
 		}
-		if respondedAt.Valid {
-			r.RespondedAt = &respondedAt.Time
+		r.RespondedAt = nullTimePtr(respondedAt)
+		r.ExpiresAt = nullTimePtr(expiresAt)
+		r.LastReminderAt = nullTimePtr(lastReminderAt)
+		r.DelegatedAt = nullTimePtr(delegatedAt)
+		r.EscalatedAt = nullTimePtr(escalatedAt)
+		if delegates != "" {
+			r.Delegates = strings.Split(delegates, ",")
 		}
 		out = append(out, r)
 	}
@@ -2266,6 +3029,248 @@ func (s *TimelineService) ListDelegationEvents(taskID string) ([]DelegationEvent
 	return out, rows.Err()
 }
 
+// --- Group Task Inbox ---
+
+// EnqueueGroupInboxTask stores a directed task for later delivery.
+// Re-enqueueing the same task for the same agent is a no-op.
+func (s *TimelineService) EnqueueGroupInboxTask(rec *GroupInboxRecord) error {
+	_, err := s.db.Exec(`INSERT OR IGNORE INTO group_task_inbox
+		(task_id, target_agent_id, requester_id, payload)
+		VALUES (?, ?, ?, ?)`,
+		rec.TaskID, rec.TargetAgentID, rec.RequesterID, rec.Payload)
+	return err
+}
+
+// ListGroupInboxTasks returns the unacknowledged backlog for an agent, oldest first.
+func (s *TimelineService) ListGroupInboxTasks(targetAgentID string, limit int) ([]GroupInboxRecord, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := s.db.Query(`SELECT id, task_id, target_agent_id, requester_id, payload, created_at
+		FROM group_task_inbox WHERE target_agent_id = ? ORDER BY created_at ASC, id ASC LIMIT ?`,
+		targetAgentID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []GroupInboxRecord
+	for rows.Next() {
+		var r GroupInboxRecord
+		if err := rows.Scan(&r.ID, &r.TaskID, &r.TargetAgentID, &r.RequesterID, &r.Payload, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// AckGroupInboxTasks removes acknowledged tasks from an agent's inbox.
+func (s *TimelineService) AckGroupInboxTasks(targetAgentID string, taskIDs []string) (int64, error) {
+	var total int64
+	for _, id := range taskIDs {
+		res, err := s.db.Exec(`DELETE FROM group_task_inbox WHERE target_agent_id = ? AND task_id = ?`, targetAgentID, id)
+		if err != nil {
+			return total, err
+		}
+		n, _ := res.RowsAffected()
+		total += n
+	}
+	return total, nil
+}
+
+// --- Group Administration ---
+
+// LogGroupAdminAction records an applied or rejected administrative action.
+func (s *TimelineService) LogGroupAdminAction(rec *GroupAdminActionRecord) error {
+	_, err := s.db.Exec(`INSERT INTO group_admin_actions
+		(group_name, action, issuer_id, target_id, detail, status, correlation_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		rec.GroupName, rec.Action, rec.IssuerID, rec.TargetID, rec.Detail, rec.Status, rec.CorrelationID)
+	return err
+}
+
+// ListGroupAdminActions returns the most recent administrative actions of a
+// group, newest first. An empty groupName lists all groups.
+func (s *TimelineService) ListGroupAdminActions(groupName string, limit int) ([]GroupAdminActionRecord, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := s.db.Query(`SELECT id, group_name, action, issuer_id, target_id, detail, status, correlation_id, created_at
+		FROM group_admin_actions WHERE (? = '' OR group_name = ?) ORDER BY created_at DESC, id DESC LIMIT ?`,
+		groupName, groupName, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []GroupAdminActionRecord
+	for rows.Next() {
+		var r GroupAdminActionRecord
+		if err := rows.Scan(&r.ID, &r.GroupName, &r.Action, &r.IssuerID, &r.TargetID,
+			&r.Detail, &r.Status, &r.CorrelationID, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// LogRemoteExec records a command the ssh tool ran or refused.
+func (s *TimelineService) LogRemoteExec(rec *RemoteExecRecord) error {
+	_, err := s.db.Exec(`INSERT INTO remote_exec_audit
+		(trace_id, task_id, host, address, user, command, tier, exit_code, duration_ms, output_bytes, error, sender, channel)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rec.TraceID, rec.TaskID, rec.Host, rec.Address, rec.User, rec.Command, rec.Tier, rec.ExitCode,
+		rec.DurationMs, rec.OutputBytes, rec.Error, rec.Sender, rec.Channel)
+	return err
+}
+
+// ListRemoteExec returns the most recent remote commands, newest first. An
+// empty host lists all hosts.
+func (s *TimelineService) ListRemoteExec(host string, limit int) ([]RemoteExecRecord, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := s.db.Query(`SELECT id, trace_id, task_id, host, address, user, command, tier, exit_code,
+		duration_ms, output_bytes, error, sender, channel, created_at
+		FROM remote_exec_audit WHERE (? = '' OR host = ?) ORDER BY created_at DESC, id DESC LIMIT ?`,
+		host, host, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []RemoteExecRecord
+	for rows.Next() {
+		var r RemoteExecRecord
+		if err := rows.Scan(&r.ID, &r.TraceID, &r.TaskID, &r.Host, &r.Address, &r.User, &r.Command, &r.Tier,
+			&r.ExitCode, &r.DurationMs, &r.OutputBytes, &r.Error, &r.Sender, &r.Channel, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// SetSettingAudited stores a setting like SetSetting and, if the value
+// changed, records the change with the actor, source IP and endpoint of rec.
+// The old and new values are masked before they are stored.
+func (s *TimelineService) SetSettingAudited(key, value string, rec SettingChangeRecord) error {
+	old, err := s.GetSetting(key)
+	existed := err == nil
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if err := s.SetSetting(key, value); err != nil {
+		return err
+	}
+	if existed && old == value {
+		return nil
+	}
+	rec.Key = key
+	rec.OldValue = MaskSettingValue(old)
+	rec.NewValue = MaskSettingValue(value)
+	return s.LogSettingChange(&rec)
+}
+
+// LogSettingChange records a setting or config change. Callers mask the
+// values; see MaskSettingValue.
+func (s *TimelineService) LogSettingChange(rec *SettingChangeRecord) error {
+	_, err := s.db.Exec(`INSERT INTO settings_audit (key, old_value, new_value, actor, source_ip, endpoint)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		rec.Key, rec.OldValue, rec.NewValue, rec.Actor, rec.SourceIP, rec.Endpoint)
+	return err
+}
+
+// ListSettingChanges returns the most recent setting changes, newest first.
+// An empty key lists all keys.
+func (s *TimelineService) ListSettingChanges(key string, limit int) ([]SettingChangeRecord, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := s.db.Query(`SELECT id, key, old_value, new_value, actor, source_ip, endpoint, created_at
+		FROM settings_audit WHERE (? = '' OR key = ?) ORDER BY created_at DESC, id DESC LIMIT ?`,
+		key, key, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []SettingChangeRecord
+	for rows.Next() {
+		var r SettingChangeRecord
+		if err := rows.Scan(&r.ID, &r.Key, &r.OldValue, &r.NewValue, &r.Actor, &r.SourceIP, &r.Endpoint, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// LogDeliveryReceipt records the platform messages created for a reply.
+func (s *TimelineService) LogDeliveryReceipt(rec *DeliveryReceiptRecord) error {
+	msgIDs, _ := json.Marshal(nonNilStrings(rec.MessageIDs))
+	fileIDs, _ := json.Marshal(nonNilStrings(rec.FileIDs))
+	_, err := s.db.Exec(`INSERT INTO delivery_receipts
+		(channel, account_id, chat_id, thread_id, message_id, message_ids, file_ids, task_id, trace_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rec.Channel, rec.AccountID, rec.ChatID, rec.ThreadID, rec.MessageID, string(msgIDs), string(fileIDs), rec.TaskID, rec.TraceID)
+	return err
+}
+
+// ListDeliveryReceipts returns the most recent delivery receipts, newest
+// first. Empty chatID or taskID match all.
+func (s *TimelineService) ListDeliveryReceipts(chatID, taskID string, limit int) ([]DeliveryReceiptRecord, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := s.db.Query(`SELECT id, channel, account_id, chat_id, thread_id, message_id, message_ids, file_ids,
+		task_id, trace_id, created_at
+		FROM delivery_receipts WHERE (? = '' OR chat_id = ?) AND (? = '' OR task_id = ?)
+		ORDER BY created_at DESC, id DESC LIMIT ?`,
+		chatID, chatID, taskID, taskID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []DeliveryReceiptRecord
+	for rows.Next() {
+		var r DeliveryReceiptRecord
+		var msgIDs, fileIDs string
+		if err := rows.Scan(&r.ID, &r.Channel, &r.AccountID, &r.ChatID, &r.ThreadID, &r.MessageID, &msgIDs, &fileIDs,
+			&r.TaskID, &r.TraceID, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		_ = json.Unmarshal([]byte(msgIDs), &r.MessageIDs)
+		_ = json.Unmarshal([]byte(fileIDs), &r.FileIDs)
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+func nonNilStrings(v []string) []string {
+	if v == nil {
+		return []string{}
+	}
+	return v
+}
+
+// MaskSettingValue hides a setting value in the audit log, keeping at most
+// two characters at each end so an operator can tell values apart.
+func MaskSettingValue(v string) string {
+	r := []rune(v)
+	switch {
+	case len(r) == 0:
+		return ""
+	case len(r) <= 8:
+		return strings.Repeat("*", len(r))
+	default:
+		return string(r[:2]) + "****" + string(r[len(r)-2:])
+	}
+}
+
 // --- Delegation-aware Group Task methods ---
 
 // AcceptGroupTask marks a group task as accepted.
@@ -2369,12 +3374,12 @@ func (s *TimelineService) InsertDelegatedGroupTask(task *GroupTaskRecord) error
 	_, err := s.db.Exec(`INSERT INTO group_tasks
 		(task_id, description, content, direction, requester_id, responder_id,
 		 response_content, status, parent_task_id, delegation_depth,
-		 original_requester_id, deadline_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		 original_requester_id, deadline_at, priority, target_agent_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		task.TaskID, task.Description, task.Content, task.Direction,
 		task.RequesterID, task.ResponderID, task.ResponseContent, task.Status,
 		task.ParentTaskID, task.DelegationDepth,
-		task.OriginalRequesterID, task.DeadlineAt)
+		task.OriginalRequesterID, utcTime(task.DeadlineAt), task.Priority, task.TargetAgentID)
 	return err
 }
 
@@ -2647,3 +3652,993 @@ func (s *TimelineService) GetTopicEnvelopeTypeCounts(topicName string) (map[stri
 	}
 	return out, rows.Err()
 }
+
+// --- Redaction ---
+
+// RedactionMarker replaces redacted timeline content.
+const RedactionMarker = "[REDACTED]"
+
+// ErrInvalidRedaction wraps the errors of a malformed RedactionRequest.
+var ErrInvalidRedaction = errors.New("invalid redaction request")
+
+// RedactionRequest selects timeline content to redact. Either EventID or
+// Pattern must be set. With only EventID the whole event body is redacted;
+// with Pattern only the matching substrings are replaced. Since/Until narrow
+// the scan for pattern-based redaction.
+type RedactionRequest struct {
+	EventID string
+	Pattern string
+	Since   *time.Time
+	Until   *time.Time
+	Actor   string
+	Reason  string
+}
+
+// RedactedEvent describes one event changed by a redaction. OriginalSHA256
+// fingerprints the pre-redaction content so integrity can still be checked
+// against external copies without keeping the secret.
+type RedactedEvent struct {
+	EventID        string `json:"event_id"`
+	OriginalSHA256 string `json:"original_sha256"`
+	Matches        int    `json:"matches"`
+}
+
+// RedactionResult summarises a redaction run.
+type RedactionResult struct {
+	AuditEventID string          `json:"audit_event_id,omitempty"`
+	Events       []RedactedEvent `json:"events"`
+}
+
+// RedactEvents replaces sensitive content in recorded events with
+// RedactionMarker. Every affected event gets a row in timeline_redactions and
+// the run is recorded as a REDACTION audit event. The pattern itself is only
+// stored as a hash since it is often the secret.
+func (s *TimelineService) RedactEvents(req RedactionRequest) (*RedactionResult, error) {
+	eventID := strings.TrimSpace(req.EventID)
+	if eventID == "" && req.Pattern == "" {
+		return nil, fmt.Errorf("%w: event_id or pattern required", ErrInvalidRedaction)
+	}
+	var re *regexp.Regexp
+	if req.Pattern != "" {
+		var err error
+		if re, err = regexp.Compile(req.Pattern); err != nil {
+			return nil, fmt.Errorf("%w: invalid pattern: %v", ErrInvalidRedaction, err)
+		}
+	}
+
+	query := `SELECT event_id, COALESCE(content_text,''), COALESCE(metadata,'') FROM timeline WHERE classification != 'REDACTION'`
+	args := []interface{}{}
+	if eventID != "" {
+		query += " AND event_id = ?"
+		args = append(args, eventID)
+	}
+	if req.Since != nil {
+		query += " AND timestamp >= ?"
+		args = append(args, *req.Since)
+	}
+	if req.Until != nil {
+		query += " AND timestamp <= ?"
+		args = append(args, *req.Until)
+	}
+
+	type pending struct {
+		id, content, metadata string
+		sum                   string
+		matches               int
+	}
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	var updates []pending
+	for rows.Next() {
+		var p pending
+		var content, metadata string
+		if err := rows.Scan(&p.id, &content, &metadata); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if re == nil {
+			if content == RedactionMarker && metadata == "" {
+				continue
+			}
+			p.content, p.metadata, p.matches = RedactionMarker, "", 1
+		} else {
+			var metaMatches int
+			p.metadata, metaMatches = redactMetadata(re, metadata)
+			p.matches = len(re.FindAllStringIndex(content, -1)) + metaMatches
+			if p.matches == 0 {
+				continue
+			}
+			p.content = re.ReplaceAllLiteralString(content, RedactionMarker)
+		}
+		h := sha256.Sum256([]byte(content + "\x00" + metadata))
+		p.sum = hex.EncodeToString(h[:])
+		updates = append(updates, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := &RedactionResult{Events: []RedactedEvent{}}
+	if len(updates) == 0 {
+		return result, nil
+	}
+
+	patternSum := ""
+	if req.Pattern != "" {
+		h := sha256.Sum256([]byte(req.Pattern))
+		patternSum = hex.EncodeToString(h[:])
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	for _, p := range updates {
+		if _, err := tx.Exec(`UPDATE timeline SET content_text = ?, metadata = ? WHERE event_id = ?`, p.content, p.metadata, p.id); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(`INSERT INTO timeline_redactions (event_id, original_sha256, pattern_sha256, matches, actor, reason)
+			VALUES (?, ?, ?, ?, ?, ?)`, p.id, p.sum, patternSum, p.matches, req.Actor, req.Reason); err != nil {
+			return nil, err
+		}
+		result.Events = append(result.Events, RedactedEvent{EventID: p.id, OriginalSHA256: p.sum, Matches: p.matches})
+	}
+
+	meta, _ := json.Marshal(map[string]any{
+		"actor":          req.Actor,
+		"reason":         req.Reason,
+		"pattern_sha256": patternSum,
+		"events":         result.Events,
+	})
+	result.AuditEventID = fmt.Sprintf("REDACT_%d", time.Now().UnixNano())
+	if _, err := tx.Exec(`INSERT INTO timeline (event_id, trace_id, span_id, parent_span_id, timestamp, sender_id, sender_name, event_type, content_text, media_path, vector_id, classification, authorized, metadata)
+		VALUES (?, '', '', '', ?, ?, ?, ?, ?, '', '', ?, ?, ?)`,
+		result.AuditEventID, time.Now(), "SYSTEM", "Redaction", "SYSTEM",
+		fmt.Sprintf("redacted %d event(s)", len(result.Events)), "REDACTION", true, string(meta)); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// redactMetadata replaces the matches of re in event metadata and returns
+// the result and the number of matches. In JSON metadata only string values
+// are redacted, so the result stays valid JSON; when matches remain outside
+// them (in keys or across the JSON syntax) the whole metadata is dropped.
+// Metadata that is not JSON is redacted as text.
+func redactMetadata(re *regexp.Regexp, metadata string) (string, int) {
+	matches := len(re.FindAllStringIndex(metadata, -1))
+	if matches == 0 {
+		return metadata, 0
+	}
+	dec := json.NewDecoder(strings.NewReader(metadata))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return re.ReplaceAllLiteralString(metadata, RedactionMarker), matches
+	}
+	out, err := json.Marshal(redactJSONStrings(re, v))
+	if err != nil || re.Match(out) {
+		return "", matches
+	}
+	return string(out), matches
+}
+
+// redactJSONStrings replaces the matches of re in the string values of a
+// decoded JSON value.
+func redactJSONStrings(re *regexp.Regexp, v any) any {
+	switch t := v.(type) {
+	case string:
+		return re.ReplaceAllLiteralString(t, RedactionMarker)
+	case map[string]any:
+		for k, x := range t {
+			t[k] = redactJSONStrings(re, x)
+		}
+	case []any:
+		for i, x := range t {
+			t[i] = redactJSONStrings(re, x)
+		}
+	}
+	return v
+}
+
+// ListRedactions returns the redaction log for an event, or all entries when eventID is empty.
+func (s *TimelineService) ListRedactions(eventID string, limit int) ([]TimelineRedactionRecord, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	query := `SELECT id, event_id, original_sha256, COALESCE(pattern_sha256,''), matches,
+		COALESCE(actor,''), COALESCE(reason,''), redacted_at FROM timeline_redactions`
+	args := []interface{}{}
+	if eventID != "" {
+		query += " WHERE event_id = ?"
+		args = append(args, eventID)
+	}
+	query += " ORDER BY redacted_at DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []TimelineRedactionRecord
+	for rows.Next() {
+		var r TimelineRedactionRecord
+		if err := rows.Scan(&r.ID, &r.EventID, &r.OriginalSHA256, &r.PatternSHA256, &r.Matches,
+			&r.Actor, &r.Reason, &r.RedactedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// --- Deferred Outbound ---
+
+// DeferOutbound stores an outbound message to be re-published at rec.DeliverAt.
+func (s *TimelineService) DeferOutbound(rec *DeferredOutboundRecord) error {
+	_, err := s.db.Exec(`INSERT INTO deferred_outbound (channel, chat_id, trace_id, payload, reason, deliver_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		rec.Channel, rec.ChatID, rec.TraceID, rec.Payload, rec.Reason,
+		rec.DeliverAt.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return fmt.Errorf("defer outbound: %w", err)
+	}
+	return nil
+}
+
+// ListDueDeferredOutbound returns deferred messages whose delivery time has passed, oldest first.
+func (s *TimelineService) ListDueDeferredOutbound(limit int) ([]DeferredOutboundRecord, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := s.db.Query(`SELECT id, channel, chat_id, trace_id, payload, reason, deliver_at, created_at
+		FROM deferred_outbound WHERE deliver_at <= datetime('now') ORDER BY deliver_at ASC, id ASC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list deferred outbound: %w", err)
+	}
+	defer rows.Close()
+
+	var out []DeferredOutboundRecord
+	for rows.Next() {
+		var r DeferredOutboundRecord
+		if err := rows.Scan(&r.ID, &r.Channel, &r.ChatID, &r.TraceID, &r.Payload, &r.Reason,
+			&r.DeliverAt, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// CountDeferredOutbound returns the number of messages held back for later delivery.
+func (s *TimelineService) CountDeferredOutbound() (int, error) {
+	var count int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM deferred_outbound`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count deferred outbound: %w", err)
+	}
+	return count, nil
+}
+
+// DeleteDeferredOutbound removes a deferred message once it has been re-published.
+func (s *TimelineService) DeleteDeferredOutbound(id int64) error {
+	_, err := s.db.Exec(`DELETE FROM deferred_outbound WHERE id = ?`, id)
+	return err
+}
+
+// --- Prompt Templates ---
+
+// SavePromptTemplate stores a new version of a prompt template. The version
+// number is assigned automatically and written back into rec.
+func (s *TimelineService) SavePromptTemplate(rec *PromptTemplateRecord) error {
+	if rec.Variables == "" {
+		rec.Variables = "{}"
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("save prompt template: %w", err)
+	}
+	defer tx.Rollback()
+
+	var version int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(version), 0) + 1 FROM prompt_templates WHERE name = ?`, rec.Name).Scan(&version); err != nil {
+		return fmt.Errorf("save prompt template: %w", err)
+	}
+	res, err := tx.Exec(`INSERT INTO prompt_templates (name, version, content, description, variables)
+		VALUES (?, ?, ?, ?, ?)`, rec.Name, version, rec.Content, rec.Description, rec.Variables)
+	if err != nil {
+		return fmt.Errorf("save prompt template: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("save prompt template: %w", err)
+	}
+	rec.ID, _ = res.LastInsertId()
+	rec.Version = version
+	return nil
+}
+
+// GetPromptTemplate returns one version of a template, or the latest when
+// version is 0. It returns nil when the template does not exist.
+func (s *TimelineService) GetPromptTemplate(name string, version int) (*PromptTemplateRecord, error) {
+	query := `SELECT id, name, version, content, description, variables, created_at
+		FROM prompt_templates WHERE name = ?`
+	args := []any{name}
+	if version > 0 {
+		query += " AND version = ?"
+		args = append(args, version)
+	}
+	query += " ORDER BY version DESC LIMIT 1"
+
+	var rec PromptTemplateRecord
+	err := s.db.QueryRow(query, args...).Scan(&rec.ID, &rec.Name, &rec.Version,
+		&rec.Content, &rec.Description, &rec.Variables, &rec.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// ListPromptTemplates returns the latest version of every template.
+func (s *TimelineService) ListPromptTemplates() ([]PromptTemplateRecord, error) {
+	return s.queryPromptTemplates(`SELECT t.id, t.name, t.version, t.content, t.description, t.variables, t.created_at
+		FROM prompt_templates t
+		WHERE t.version = (SELECT MAX(version) FROM prompt_templates WHERE name = t.name)
+		ORDER BY t.name`)
+}
+
+// ListPromptTemplateVersions returns all versions of a template, newest first.
+func (s *TimelineService) ListPromptTemplateVersions(name string) ([]PromptTemplateRecord, error) {
+	return s.queryPromptTemplates(`SELECT id, name, version, content, description, variables, created_at
+		FROM prompt_templates WHERE name = ? ORDER BY version DESC`, name)
+}
+
+// DeletePromptTemplate removes all versions of a template.
+func (s *TimelineService) DeletePromptTemplate(name string) (int64, error) {
+	res, err := s.db.Exec(`DELETE FROM prompt_templates WHERE name = ?`, name)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (s *TimelineService) queryPromptTemplates(query string, args ...any) ([]PromptTemplateRecord, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []PromptTemplateRecord
+	for rows.Next() {
+		var rec PromptTemplateRecord
+		if err := rows.Scan(&rec.ID, &rec.Name, &rec.Version, &rec.Content,
+			&rec.Description, &rec.Variables, &rec.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
+// --- Commitments ---
+
+// CreateCommitment stores a new open commitment and writes its ID back into rec.
+func (s *TimelineService) CreateCommitment(rec *CommitmentRecord) error {
+	if rec.Owner == "" {
+		rec.Owner = CommitmentOwnerAgent
+	}
+	rec.Status = CommitmentOpen
+	var due any
+	if rec.DueAt != nil {
+		due = rec.DueAt.UTC().Format("2006-01-02 15:04:05")
+	}
+	res, err := s.db.Exec(`INSERT INTO commitments (owner, description, due_at, status, channel, chat_id, thread_id, session_key, trace_id, source_text)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rec.Owner, rec.Description, due, rec.Status, rec.Channel, rec.ChatID,
+		rec.ThreadID, rec.SessionKey, rec.TraceID, rec.SourceText)
+	if err != nil {
+		return fmt.Errorf("create commitment: %w", err)
+	}
+	rec.ID, _ = res.LastInsertId()
+	return nil
+}
+
+// GetCommitment returns a commitment by ID, or nil when it does not exist.
+func (s *TimelineService) GetCommitment(id int64) (*CommitmentRecord, error) {
+	recs, err := s.queryCommitments(commitmentSelect+` WHERE id = ?`, id)
+	if err != nil || len(recs) == 0 {
+		return nil, err
+	}
+	return &recs[0], nil
+}
+
+// ListCommitments returns commitments filtered by status, channel and chat.
+// Empty filters match everything. Open commitments with the nearest due date
+// come first.
+func (s *TimelineService) ListCommitments(status, channel, chatID string, limit int) ([]CommitmentRecord, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	query := commitmentSelect + ` WHERE 1=1`
+	var args []any
+	if status != "" {
+		query += ` AND status = ?`
+		args = append(args, status)
+	}
+	if channel != "" {
+		query += ` AND channel = ?`
+		args = append(args, channel)
+	}
+	if chatID != "" {
+		query += ` AND chat_id = ?`
+		args = append(args, chatID)
+	}
+	query += ` ORDER BY due_at IS NULL, due_at ASC, id ASC LIMIT ?`
+	args = append(args, limit)
+	return s.queryCommitments(query, args...)
+}
+
+// ListDueCommitments returns open commitments that are due at now and have
+// not been followed up yet.
+func (s *TimelineService) ListDueCommitments(now time.Time, limit int) ([]CommitmentRecord, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	return s.queryCommitments(commitmentSelect+` WHERE status = ? AND due_at IS NOT NULL AND due_at <= ?
+		AND followed_up_at IS NULL ORDER BY due_at ASC, id ASC LIMIT ?`,
+		CommitmentOpen, now.UTC().Format("2006-01-02 15:04:05"), limit)
+}
+
+// MarkCommitmentFollowedUp records that the follow-up for a due commitment was sent.
+func (s *TimelineService) MarkCommitmentFollowedUp(id int64) error {
+	_, err := s.db.Exec(`UPDATE commitments SET followed_up_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`, id)
+	return err
+}
+
+// CloseCommitment sets an open commitment to done or cancelled. It returns
+// false when no open commitment with that ID exists.
+func (s *TimelineService) CloseCommitment(id int64, status string) (bool, error) {
+	if status != CommitmentDone && status != CommitmentCancelled {
+		return false, fmt.Errorf("invalid commitment status %q", status)
+	}
+	res, err := s.db.Exec(`UPDATE commitments SET status = ?, closed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = ?`, status, id, CommitmentOpen)
+	if err != nil {
+		return false, fmt.Errorf("close commitment: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+const commitmentSelect = `SELECT id, owner, description, due_at, status, channel, chat_id, thread_id,
+	session_key, trace_id, source_text, followed_up_at, closed_at, created_at, updated_at FROM commitments`
+
+func (s *TimelineService) queryCommitments(query string, args ...any) ([]CommitmentRecord, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list commitments: %w", err)
+	}
+	defer rows.Close()
+
+	var out []CommitmentRecord
+	for rows.Next() {
+		var (
+			r                     CommitmentRecord
+			due, followed, closed sql.NullTime
+		)
+		if err := rows.Scan(&r.ID, &r.Owner, &r.Description, &due, &r.Status, &r.Channel, &r.ChatID,
+			&r.ThreadID, &r.SessionKey, &r.TraceID, &r.SourceText, &followed, &closed,
+			&r.CreatedAt, &r.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if due.Valid {
+			r.DueAt = &due.Time
+		}
+		if followed.Valid {
+			r.FollowedUpAt = &followed.Time
+		}
+		if closed.Valid {
+			r.ClosedAt = &closed.Time
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// --- Task Boards ---
+
+// CreateBoardItem stores a new task board item and writes its ID back into rec.
+func (s *TimelineService) CreateBoardItem(rec *BoardItemRecord) error {
+	if rec.Status == "" {
+		rec.Status = BoardItemOpen
+	}
+	if !ValidBoardItemStatus(rec.Status) {
+		return fmt.Errorf("invalid board item status %q", rec.Status)
+	}
+	res, err := s.db.Exec(`INSERT INTO board_items (channel, chat_id, title, notes, status, assignee, due_at, created_by, trace_id, closed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, `+boardClosedAtExpr+`)`,
+		rec.Channel, rec.ChatID, rec.Title, rec.Notes, rec.Status, rec.Assignee,
+		boardDue(rec.DueAt), rec.CreatedBy, rec.TraceID, rec.Status)
+	if err != nil {
+		return fmt.Errorf("create board item: %w", err)
+	}
+	rec.ID, _ = res.LastInsertId()
+	return nil
+}
+
+// GetBoardItem returns a task board item by ID, or nil when it does not exist.
+func (s *TimelineService) GetBoardItem(id int64) (*BoardItemRecord, error) {
+	recs, err := s.queryBoardItems(boardItemSelect+` WHERE id = ?`, id)
+	if err != nil || len(recs) == 0 {
+		return nil, err
+	}
+	return &recs[0], nil
+}
+
+// UpdateBoardItem writes the title, notes, status, assignee and due date of
+// rec. Moving an item to done or cancelled sets its closed time; reopening
+// clears it.
+func (s *TimelineService) UpdateBoardItem(rec *BoardItemRecord) error {
+	if !ValidBoardItemStatus(rec.Status) {
+		return fmt.Errorf("invalid board item status %q", rec.Status)
+	}
+	res, err := s.db.Exec(`UPDATE board_items SET title = ?, notes = ?, status = ?, assignee = ?, due_at = ?,
+		closed_at = CASE WHEN ? IN ('done', 'cancelled') THEN COALESCE(closed_at, CURRENT_TIMESTAMP) ELSE NULL END,
+		updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+		rec.Title, rec.Notes, rec.Status, rec.Assignee, boardDue(rec.DueAt), rec.Status, rec.ID)
+	if err != nil {
+		return fmt.Errorf("update board item: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("board item %d not found", rec.ID)
+	}
+	return nil
+}
+
+// ListBoardItems returns task board items matching filter. Active items come
+// first, then by nearest due date.
+func (s *TimelineService) ListBoardItems(filter BoardItemFilter) ([]BoardItemRecord, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	query := boardItemSelect + ` WHERE 1=1`
+	var args []any
+	if filter.Channel != "" {
+		query += ` AND channel = ?`
+		args = append(args, filter.Channel)
+	}
+	if filter.ChatID != "" {
+		query += ` AND chat_id = ?`
+		args = append(args, filter.ChatID)
+	}
+	if filter.Assignee != "" {
+		query += ` AND assignee = ?`
+		args = append(args, filter.Assignee)
+	}
+	switch {
+	case filter.Status != "":
+		query += ` AND status = ?`
+		args = append(args, filter.Status)
+	case !filter.IncludeClosed:
+		query += ` AND status IN (?, ?)`
+		args = append(args, BoardItemOpen, BoardItemInProgress)
+	}
+	query += ` ORDER BY status IN ('done', 'cancelled'), due_at IS NULL, due_at ASC, id ASC LIMIT ?`
+	args = append(args, limit)
+	return s.queryBoardItems(query, args...)
+}
+
+// ListBoards returns one summary per chat that has task board items, most
+// recently updated first.
+func (s *TimelineService) ListBoards(now time.Time) ([]BoardSummary, error) {
+	rows, err := s.db.Query(`SELECT channel, chat_id,
+			SUM(status = 'open'), SUM(status = 'in_progress'), SUM(status = 'done'), SUM(status = 'cancelled'),
+			SUM(status IN ('open', 'in_progress') AND due_at IS NOT NULL AND due_at < ?)
+		FROM board_items GROUP BY channel, chat_id ORDER BY MAX(updated_at) DESC, channel, chat_id`,
+		now.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return nil, fmt.Errorf("list boards: %w", err)
+	}
+	var out []BoardSummary
+	for rows.Next() {
+		var b BoardSummary
+		if err := rows.Scan(&b.Channel, &b.ChatID, &b.Open, &b.InProgress, &b.Done, &b.Cancelled, &b.Overdue); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// MAX(updated_at) comes back as text; read the newest timestamp per chat
+	// as a DATETIME column instead.
+	for i := range out {
+		_ = s.db.QueryRow(`SELECT updated_at FROM board_items WHERE channel = ? AND chat_id = ?
+			ORDER BY updated_at DESC, id DESC LIMIT 1`, out[i].Channel, out[i].ChatID).Scan(&out[i].UpdatedAt)
+	}
+	return out, nil
+}
+
+// boardClosedAtExpr sets closed_at on insert when the item starts closed.
+const boardClosedAtExpr = `CASE WHEN ? IN ('done', 'cancelled') THEN CURRENT_TIMESTAMP ELSE NULL END`
+
+func boardDue(due *time.Time) any {
+	if due == nil {
+		return nil
+	}
+	return due.UTC().Format("2006-01-02 15:04:05")
+}
+
+const boardItemSelect = `SELECT id, channel, chat_id, title, notes, status, assignee, due_at, created_by,
+	trace_id, closed_at, created_at, updated_at FROM board_items`
+
+func (s *TimelineService) queryBoardItems(query string, args ...any) ([]BoardItemRecord, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list board items: %w", err)
+	}
+	defer rows.Close()
+
+	var out []BoardItemRecord
+	for rows.Next() {
+		var (
+			r           BoardItemRecord
+			due, closed sql.NullTime
+		)
+		if err := rows.Scan(&r.ID, &r.Channel, &r.ChatID, &r.Title, &r.Notes, &r.Status, &r.Assignee, &due,
+			&r.CreatedBy, &r.TraceID, &closed, &r.CreatedAt, &r.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if due.Valid {
+			r.DueAt = &due.Time
+		}
+		if closed.Valid {
+			r.ClosedAt = &closed.Time
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// --- Chat Pins ---
+
+// CreateChatPin pins a note to a chat and writes its ID back into rec. It
+// fails when the chat's pins would exceed ChatPinBudgetChars.
+func (s *TimelineService) CreateChatPin(rec *ChatPinRecord) error {
+	rec.Content = strings.TrimSpace(rec.Content)
+	if rec.Content == "" {
+		return fmt.Errorf("pin content must not be empty")
+	}
+	var used int
+	if err := s.db.QueryRow(`SELECT COALESCE(SUM(LENGTH(content)),0) FROM chat_pins WHERE channel = ? AND chat_id = ?`,
+		rec.Channel, rec.ChatID).Scan(&used); err != nil {
+		return fmt.Errorf("create chat pin: %w", err)
+	}
+	if n := utf8.RuneCountInString(rec.Content); used+n > ChatPinBudgetChars {
+		return fmt.Errorf("pinned notes of this chat would exceed %d characters (%d used); remove a pin first", ChatPinBudgetChars, used)
+	}
+	res, err := s.db.Exec(`INSERT INTO chat_pins (channel, chat_id, content, created_by) VALUES (?, ?, ?, ?)`,
+		rec.Channel, rec.ChatID, rec.Content, rec.CreatedBy)
+	if err != nil {
+		return fmt.Errorf("create chat pin: %w", err)
+	}
+	rec.ID, _ = res.LastInsertId()
+	return nil
+}
+
+// ListChatPins returns the pinned notes of a chat, oldest first.
+func (s *TimelineService) ListChatPins(channel, chatID string) ([]ChatPinRecord, error) {
+	rows, err := s.db.Query(`SELECT id, channel, chat_id, content, created_by, created_at
+		FROM chat_pins WHERE channel = ? AND chat_id = ? ORDER BY id ASC`, channel, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("list chat pins: %w", err)
+	}
+	defer rows.Close()
+
+	var out []ChatPinRecord
+	for rows.Next() {
+		var r ChatPinRecord
+		if err := rows.Scan(&r.ID, &r.Channel, &r.ChatID, &r.Content, &r.CreatedBy, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// DeleteChatPin removes a pin of a chat. It reports false when the chat has
+// no pin with that ID.
+func (s *TimelineService) DeleteChatPin(channel, chatID string, id int64) (bool, error) {
+	res, err := s.db.Exec(`DELETE FROM chat_pins WHERE id = ? AND channel = ? AND chat_id = ?`, id, channel, chatID)
+	if err != nil {
+		return false, fmt.Errorf("delete chat pin: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// --- Broadcast Audiences ---
+
+// UpsertBroadcastAudience creates or replaces a saved broadcast audience.
+func (s *TimelineService) UpsertBroadcastAudience(rec *BroadcastAudienceRecord) error {
+	if rec.Targets == "" {
+		rec.Targets = "[]"
+	}
+	_, err := s.db.Exec(`INSERT INTO broadcast_audiences (name, description, targets)
+		VALUES (?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET description = excluded.description, targets = excluded.targets,
+			updated_at = CURRENT_TIMESTAMP`,
+		rec.Name, rec.Description, rec.Targets)
+	if err != nil {
+		return fmt.Errorf("upsert broadcast audience: %w", err)
+	}
+	return nil
+}
+
+// GetBroadcastAudience returns a saved audience, or nil when it does not exist.
+func (s *TimelineService) GetBroadcastAudience(name string) (*BroadcastAudienceRecord, error) {
+	var rec BroadcastAudienceRecord
+	err := s.db.QueryRow(`SELECT name, description, targets, created_at, updated_at
+		FROM broadcast_audiences WHERE name = ?`, name).Scan(&rec.Name, &rec.Description, &rec.Targets,
+		&rec.CreatedAt, &rec.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// ListBroadcastAudiences returns all saved audiences ordered by name.
+func (s *TimelineService) ListBroadcastAudiences() ([]BroadcastAudienceRecord, error) {
+	rows, err := s.db.Query(`SELECT name, description, targets, created_at, updated_at
+		FROM broadcast_audiences ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("list broadcast audiences: %w", err)
+	}
+	defer rows.Close()
+
+	var out []BroadcastAudienceRecord
+	for rows.Next() {
+		var rec BroadcastAudienceRecord
+		if err := rows.Scan(&rec.Name, &rec.Description, &rec.Targets, &rec.CreatedAt, &rec.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
+// DeleteBroadcastAudience removes a saved audience.
+func (s *TimelineService) DeleteBroadcastAudience(name string) (bool, error) {
+	res, err := s.db.Exec(`DELETE FROM broadcast_audiences WHERE name = ?`, name)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// --- Missions ---
+
+// UpsertMission creates or updates a mission. Status defaults to active.
+func (s *TimelineService) UpsertMission(rec *MissionRecord) error {
+	if rec.Status == "" {
+		rec.Status = MissionStatusActive
+	}
+	_, err := s.db.Exec(`INSERT INTO missions (name, description, status)
+		VALUES (?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET description = excluded.description, status = excluded.status,
+			updated_at = CURRENT_TIMESTAMP`,
+		rec.Name, rec.Description, rec.Status)
+	if err != nil {
+		return fmt.Errorf("upsert mission: %w", err)
+	}
+	return nil
+}
+
+// GetMission returns a mission, or nil when it does not exist.
+func (s *TimelineService) GetMission(name string) (*MissionRecord, error) {
+	var rec MissionRecord
+	err := s.db.QueryRow(`SELECT name, description, status, created_at, updated_at
+		FROM missions WHERE name = ?`, name).Scan(&rec.Name, &rec.Description, &rec.Status,
+		&rec.CreatedAt, &rec.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// ListMissions returns all missions ordered by name.
+func (s *TimelineService) ListMissions() ([]MissionRecord, error) {
+	rows, err := s.db.Query(`SELECT name, description, status, created_at, updated_at
+		FROM missions ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("list missions: %w", err)
+	}
+	defer rows.Close()
+
+	var out []MissionRecord
+	for rows.Next() {
+		var rec MissionRecord
+		if err := rows.Scan(&rec.Name, &rec.Description, &rec.Status, &rec.CreatedAt, &rec.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
+// DeleteMission removes a mission and its assignments. Tasks, jobs, repos and
+// memory stay untouched.
+func (s *TimelineService) DeleteMission(name string) (bool, error) {
+	res, err := s.db.Exec(`DELETE FROM missions WHERE name = ?`, name)
+	if err != nil {
+		return false, err
+	}
+	if _, err := s.db.Exec(`DELETE FROM mission_assignments WHERE mission = ?`, name); err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// AssignToMission assigns a task, scheduled job, repo or memory namespace to
+// a mission. A resource belongs to at most one mission; reassigning moves it.
+func (s *TimelineService) AssignToMission(mission, kind, ref string) error {
+	if !IsMissionKind(kind) {
+		return fmt.Errorf("unknown mission assignment kind %q", kind)
+	}
+	_, err := s.db.Exec(`INSERT INTO mission_assignments (mission, kind, ref)
+		VALUES (?, ?, ?)
+		ON CONFLICT(kind, ref) DO UPDATE SET mission = excluded.mission, created_at = CURRENT_TIMESTAMP`,
+		mission, kind, ref)
+	if err != nil {
+		return fmt.Errorf("assign to mission: %w", err)
+	}
+	return nil
+}
+
+// UnassignFromMission removes a resource from whatever mission it belongs to.
+func (s *TimelineService) UnassignFromMission(kind, ref string) (bool, error) {
+	res, err := s.db.Exec(`DELETE FROM mission_assignments WHERE kind = ? AND ref = ?`, kind, ref)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ListMissionAssignments returns the assignments of a mission, optionally
+// restricted to one kind, newest first.
+func (s *TimelineService) ListMissionAssignments(mission, kind string) ([]MissionAssignmentRecord, error) {
+	query := `SELECT mission, kind, ref, created_at FROM mission_assignments WHERE mission = ?`
+	args := []any{mission}
+	if kind != "" {
+		query += ` AND kind = ?`
+		args = append(args, kind)
+	}
+	query += ` ORDER BY created_at DESC, ref`
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list mission assignments: %w", err)
+	}
+	defer rows.Close()
+
+	var out []MissionAssignmentRecord
+	for rows.Next() {
+		var rec MissionAssignmentRecord
+		if err := rows.Scan(&rec.Mission, &rec.Kind, &rec.Ref, &rec.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
+// GetMissionDashboard aggregates a mission's tasks, traces, scheduled jobs,
+// repos and memory namespaces. recentLimit bounds the recent task list.
+// Returns nil when the mission does not exist.
+func (s *TimelineService) GetMissionDashboard(name string, recentLimit int) (*MissionDashboard, error) {
+	mission, err := s.GetMission(name)
+	if err != nil || mission == nil {
+		return nil, err
+	}
+	if recentLimit <= 0 {
+		recentLimit = 20
+	}
+	d := &MissionDashboard{
+		Mission:          *mission,
+		TaskCounts:       map[string]int{},
+		RecentTasks:      []MissionTaskSummary{},
+		Jobs:             []ScheduledJobRecord{},
+		Repos:            []string{},
+		MemoryNamespaces: []string{},
+	}
+
+	rows, err := s.db.Query(`SELECT t.status, COUNT(*), COALESCE(SUM(t.total_tokens),0), COALESCE(SUM(t.cost_usd),0)
+		FROM tasks t JOIN mission_assignments a ON a.kind = 'task' AND a.ref = t.task_id
+		WHERE a.mission = ? GROUP BY t.status`, name)
+	if err != nil {
+		return nil, fmt.Errorf("mission task counts: %w", err)
+	}
+	for rows.Next() {
+		var status string
+		var count, tokens int
+		var cost float64
+		if err := rows.Scan(&status, &count, &tokens, &cost); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		d.TaskCounts[status] = count
+		d.TotalTasks += count
+		d.TotalTokens += tokens
+		d.CostUSD += cost
+	}
+	rows.Close()
+
+	rows, err = s.db.Query(`SELECT t.task_id, COALESCE(t.trace_id,''), t.channel, t.chat_id, t.status,
+		COALESCE(t.content_in,''), t.total_tokens, t.created_at
+		FROM tasks t JOIN mission_assignments a ON a.kind = 'task' AND a.ref = t.task_id
+		WHERE a.mission = ? ORDER BY t.created_at DESC, t.id DESC LIMIT ?`, name, recentLimit)
+	if err != nil {
+		return nil, fmt.Errorf("mission recent tasks: %w", err)
+	}
+	for rows.Next() {
+		var t MissionTaskSummary
+		if err := rows.Scan(&t.TaskID, &t.TraceID, &t.Channel, &t.ChatID, &t.Status,
+			&t.ContentIn, &t.TotalTokens, &t.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		d.RecentTasks = append(d.RecentTasks, t)
+	}
+	rows.Close()
+
+	assignments, err := s.ListMissionAssignments(name, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range assignments {
+		switch a.Kind {
+		case MissionKindJob:
+			job, err := s.GetScheduledJob(a.Ref)
+			if err == sql.ErrNoRows {
+				// Assigned before its first run.
+				job, err = &ScheduledJobRecord{JobName: a.Ref}, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			d.Jobs = append(d.Jobs, *job)
+		case MissionKindRepo:
+			d.Repos = append(d.Repos, a.Ref)
+		case MissionKindMemory:
+			d.MemoryNamespaces = append(d.MemoryNamespaces, a.Ref)
+			var n int
+			if err := s.db.QueryRow(`SELECT COUNT(*) FROM memory_chunks WHERE source = ? OR source LIKE ? || ':%'`,
+				a.Ref, a.Ref).Scan(&n); err != nil {
+				return nil, err
+			}
+			d.MemoryChunks += n
+		}
+	}
+	return d, nil
+}
@@ -108,13 +108,21 @@ func TestTimelineGroupAndAuditCoverage(t *testing.T) {
 		Channels:     "[\"whatsapp\"]",
 		Model:        "gpt-4o",
 		Status:       "active",
+		Taxonomy:     `[{"kind":"skill","name":"k8s"}]`,
 	}); err != nil {
 		t.Fatalf("upsert member: %v", err)
 	}
+	// An upsert without a taxonomy keeps the stored one.
+	if err := svc.UpsertGroupMember(&GroupMemberRecord{AgentID: "a1", AgentName: "Alpha", Capabilities: "[]", Channels: "[]", Status: "active"}); err != nil {
+		t.Fatalf("re-upsert member: %v", err)
+	}
 	members, err := svc.ListGroupMembers()
 	if err != nil || len(members) != 1 {
 		t.Fatalf("list members failed: len=%d err=%v", len(members), err)
 	}
+	if members[0].Taxonomy != `[{"kind":"skill","name":"k8s"}]` {
+		t.Fatalf("expected taxonomy to be kept, got %q", members[0].Taxonomy)
+	}
 
 	if err := svc.SoftDeleteGroupMember("a1"); err != nil {
 		t.Fatalf("soft delete member: %v", err)
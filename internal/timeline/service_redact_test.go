@@ -0,0 +1,143 @@
+package timeline
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedactEventsByPatternAndID(t *testing.T) {
+	svc := newTestTimeline(t)
+	now := time.Now()
+	events := []*TimelineEvent{
+		{EventID: "e1", Timestamp: now.Add(-2 * time.Hour), SenderID: "u", EventType: "TEXT",
+			ContentText: "my token is sk-abc123 please", Metadata: `{"args":"sk-abc123"}`},
+		{EventID: "e2", Timestamp: now.Add(-10 * time.Minute), SenderID: "u", EventType: "TEXT",
+			ContentText: "db postgres://admin:hunter2@db:5432/app"},
+		{EventID: "e3", Timestamp: now.Add(-5 * time.Minute), SenderID: "u", EventType: "TEXT",
+			ContentText: "nothing secret here"},
+	}
+	for _, e := range events {
+		if err := svc.AddEvent(e); err != nil {
+			t.Fatalf("add event: %v", err)
+		}
+	}
+
+	since := now.Add(-3 * time.Hour)
+	res, err := svc.RedactEvents(RedactionRequest{Pattern: `sk-[a-z0-9]+`, Since: &since, Actor: "test", Reason: "leaked key"})
+	if err != nil {
+		t.Fatalf("redact pattern: %v", err)
+	}
+	if len(res.Events) != 1 || res.Events[0].EventID != "e1" || res.Events[0].Matches != 2 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if res.AuditEventID == "" || len(res.Events[0].OriginalSHA256) != 64 {
+		t.Fatalf("expected audit event and hash, got %+v", res)
+	}
+
+	res, err = svc.RedactEvents(RedactionRequest{EventID: "e2", Actor: "test"})
+	if err != nil || len(res.Events) != 1 {
+		t.Fatalf("redact by id: %+v err=%v", res, err)
+	}
+
+	got, err := svc.GetEvents(FilterArgs{})
+	if err != nil {
+		t.Fatalf("get events: %v", err)
+	}
+	byID := map[string]TimelineEvent{}
+	var audits int
+	for _, e := range got {
+		byID[e.EventID] = e
+		if e.Classification == "REDACTION" {
+			audits++
+			if strings.Contains(e.Metadata, "sk-abc123") || strings.Contains(e.Metadata, `sk-[a-z0-9]+`) {
+				t.Fatalf("audit event leaks secret or pattern: %s", e.Metadata)
+			}
+		}
+	}
+	if c := byID["e1"].ContentText; c != "my token is [REDACTED] please" {
+		t.Fatalf("unexpected e1 content: %q", c)
+	}
+	if strings.Contains(byID["e1"].Metadata, "sk-abc123") {
+		t.Fatalf("metadata not redacted: %s", byID["e1"].Metadata)
+	}
+	if byID["e2"].ContentText != RedactionMarker {
+		t.Fatalf("unexpected e2 content: %q", byID["e2"].ContentText)
+	}
+	if byID["e3"].ContentText != "nothing secret here" {
+		t.Fatalf("e3 should be untouched: %q", byID["e3"].ContentText)
+	}
+	if audits != 2 {
+		t.Fatalf("expected 2 audit events, got %d", audits)
+	}
+
+	log, err := svc.ListRedactions("e1", 0)
+	if err != nil || len(log) != 1 || log[0].Reason != "leaked key" || log[0].PatternSHA256 == "" {
+		t.Fatalf("unexpected redaction log: %+v err=%v", log, err)
+	}
+
+	if _, err := svc.RedactEvents(RedactionRequest{}); err == nil {
+		t.Fatal("expected error without event_id or pattern")
+	}
+	if _, err := svc.RedactEvents(RedactionRequest{Pattern: "("}); err == nil {
+		t.Fatal("expected error for invalid pattern")
+	}
+}
+
+func TestRedactEventsKeepsMetadataJSON(t *testing.T) {
+	svc := newTestTimeline(t)
+	for _, e := range []*TimelineEvent{
+		{EventID: "e1", Timestamp: time.Now(), SenderID: "u", EventType: "TEXT", ContentText: "call",
+			Metadata: `{"args":{"token":"sk-abc123"},"list":["sk-def456",2]}`},
+		{EventID: "e2", Timestamp: time.Now(), SenderID: "u", EventType: "TEXT", ContentText: "call",
+			Metadata: `{"password":"hunter2","n":1}`},
+	} {
+		if err := svc.AddEvent(e); err != nil {
+			t.Fatalf("add event: %v", err)
+		}
+	}
+
+	if _, err := svc.RedactEvents(RedactionRequest{Pattern: `sk-[a-z0-9]+`, Actor: "test"}); err != nil {
+		t.Fatalf("redact values: %v", err)
+	}
+	// The pattern spans quotes and the colon, so it cannot be confined to a value.
+	res, err := svc.RedactEvents(RedactionRequest{Pattern: `"password":"[^"]*"`, Actor: "test"})
+	if err != nil || len(res.Events) != 1 || res.Events[0].Matches != 1 {
+		t.Fatalf("redact across syntax: %+v err=%v", res, err)
+	}
+
+	got, err := svc.GetEvents(FilterArgs{})
+	if err != nil {
+		t.Fatalf("get events: %v", err)
+	}
+	byID := map[string]TimelineEvent{}
+	for _, e := range got {
+		byID[e.EventID] = e
+	}
+	var meta struct {
+		Args struct {
+			Token string `json:"token"`
+		} `json:"args"`
+		List []any `json:"list"`
+	}
+	if err := json.Unmarshal([]byte(byID["e1"].Metadata), &meta); err != nil {
+		t.Fatalf("expected valid JSON metadata, got %q: %v", byID["e1"].Metadata, err)
+	}
+	if meta.Args.Token != RedactionMarker || meta.List[0] != RedactionMarker || meta.List[1] != float64(2) {
+		t.Fatalf("unexpected redacted metadata %+v", meta)
+	}
+	if m := byID["e2"].Metadata; m != "" && !json.Valid([]byte(m)) || strings.Contains(m, "hunter2") {
+		t.Fatalf("expected the metadata dropped, got %q", m)
+	}
+}
+
+func TestRedactEventsValidationErrors(t *testing.T) {
+	svc := newTestTimeline(t)
+	for _, req := range []RedactionRequest{{}, {Pattern: "("}} {
+		if _, err := svc.RedactEvents(req); !errors.Is(err, ErrInvalidRedaction) {
+			t.Fatalf("expected ErrInvalidRedaction for %+v, got %v", req, err)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package timeline
 
 import (
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestCreateAndGetTask(t *testing.T) {
@@ -293,6 +295,22 @@ func TestKnowledgeFactLatestCRUD(t *testing.T) {
 	if got == nil || got.Version != 1 || got.Object != "v1" {
 		t.Fatalf("unexpected fact v1: %+v", got)
 	}
+	if err := svc.RecordKnowledgeFactInjections([]string{"fact-1", "fact-1"}); err != nil {
+		t.Fatalf("record injections: %v", err)
+	}
+	if err := svc.RecordKnowledgeFactContradiction("fact-1"); err != nil {
+		t.Fatalf("record contradiction: %v", err)
+	}
+	if err := svc.UpsertKnowledgeFactLatest(rec); err != nil {
+		t.Fatalf("re-upsert fact v1: %v", err)
+	}
+	got, err = svc.GetKnowledgeFactLatest("fact-1")
+	if err != nil {
+		t.Fatalf("get fact v1 usage: %v", err)
+	}
+	if got.InjectionCount != 2 || got.ContradictionCount != 1 {
+		t.Fatalf("expected usage kept for unchanged content, got %+v", got)
+	}
 
 	rec.Object = "v2"
 	rec.Version = 2
@@ -306,6 +324,9 @@ func TestKnowledgeFactLatestCRUD(t *testing.T) {
 	if got == nil || got.Version != 2 || got.Object != "v2" {
 		t.Fatalf("unexpected fact v2: %+v", got)
 	}
+	if got.InjectionCount != 0 || got.ContradictionCount != 0 {
+		t.Fatalf("expected usage reset for changed content, got %+v", got)
+	}
 }
 
 func TestKnowledgeProposalVoteCRUD(t *testing.T) {
@@ -581,3 +602,219 @@ func TestGetTokenUsageSummary(t *testing.T) {
 		t.Errorf("expected total cost >= 0.03, got %f", totalCost)
 	}
 }
+
+func TestKnowledgeFactProposalSignals(t *testing.T) {
+	svc := newTestTimeline(t)
+	for _, p := range []struct{ id, status string }{{"p1", "approved"}, {"p2", "approved"}, {"p3", "rejected"}} {
+		if err := svc.CreateKnowledgeProposal(&KnowledgeProposalRecord{
+			ProposalID:     p.id,
+			GroupName:      "g",
+			Statement:      "s",
+			ProposerClawID: "claw-a",
+		}); err != nil {
+			t.Fatalf("create proposal %s: %v", p.id, err)
+		}
+		if err := svc.UpdateKnowledgeProposalDecision(p.id, p.status, 3, 1, ""); err != nil {
+			t.Fatalf("decide proposal %s: %v", p.id, err)
+		}
+	}
+	if err := svc.UpsertKnowledgeFactLatest(&KnowledgeFactRecord{
+		FactID: "fact-p1", GroupName: "g", Subject: "svc", Predicate: "owner", Object: "team-a",
+		Version: 1, Source: "decision:p1", ProposalID: "p1", Tags: "[]",
+	}); err != nil {
+		t.Fatalf("upsert fact: %v", err)
+	}
+	if err := svc.UpsertKnowledgeFactLatest(&KnowledgeFactRecord{
+		FactID: "fact-direct", GroupName: "g", Subject: "svc", Predicate: "tier", Object: "gold",
+		Version: 1, Source: "direct", Tags: "[]",
+	}); err != nil {
+		t.Fatalf("upsert direct fact: %v", err)
+	}
+
+	facts, err := svc.ListKnowledgeFacts("g", 10, 0)
+	if err != nil {
+		t.Fatalf("list facts: %v", err)
+	}
+	byID := map[string]KnowledgeFactRecord{}
+	for _, f := range facts {
+		byID[f.FactID] = f
+	}
+	got := byID["fact-p1"]
+	if got.YesVotes != 3 || got.NoVotes != 1 || got.ProposerApproved != 2 || got.ProposerRejected != 1 {
+		t.Fatalf("unexpected proposal signals: %+v", got)
+	}
+	direct := byID["fact-direct"]
+	if direct.YesVotes != 0 || direct.ProposerApproved != 0 || direct.ProposerRejected != 0 {
+		t.Fatalf("expected no proposal signals for direct fact: %+v", direct)
+	}
+}
+
+func TestGroupTaskSkillResult(t *testing.T) {
+	svc := newTestTimeline(t)
+	if task, err := svc.GetGroupTask("missing"); err != nil || task != nil {
+		t.Fatalf("expected nil for missing task, got %+v (err=%v)", task, err)
+	}
+	if err := svc.InsertGroupTask(&GroupTaskRecord{
+		TaskID:      "s1",
+		Content:     "count rows",
+		Direction:   "outgoing",
+		RequesterID: "me",
+		Status:      "pending",
+		SkillName:   "sql",
+	}); err != nil {
+		t.Fatalf("insert group task: %v", err)
+	}
+	attempts, err := svc.RetryGroupTask("s1", "dba")
+	if err != nil || attempts != 1 {
+		t.Fatalf("retry: attempts=%d err=%v", attempts, err)
+	}
+	if err := svc.UpdateGroupTaskResult("s1", "worker", "42", "completed", `{"status":"succeeded"}`); err != nil {
+		t.Fatalf("update result: %v", err)
+	}
+	task, err := svc.GetGroupTask("s1")
+	if err != nil || task == nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if task.SkillName != "dba" || task.Attempts != 1 || task.Status != "completed" ||
+		task.ResponderID != "worker" || string(task.Result) != `{"status":"succeeded"}` {
+		t.Fatalf("unexpected task: %+v", task)
+	}
+}
+
+func TestListTraces(t *testing.T) {
+	svc := newTestTimeline(t)
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	addEvent := func(id, trace string, at time.Duration, classification, meta string) {
+		t.Helper()
+		if err := svc.AddEvent(&TimelineEvent{
+			EventID: id, TraceID: trace, Timestamp: base.Add(at), SenderID: "user1", SenderName: "User",
+			EventType: "TEXT", ContentText: id, Classification: classification, Authorized: true, Metadata: meta,
+		}); err != nil {
+			t.Fatalf("add event: %v", err)
+		}
+	}
+	addTask := func(trace, channel, sender, status string, tokens int) {
+		t.Helper()
+		task, err := svc.CreateTask(&AgentTask{Channel: channel, ChatID: "c1", SenderID: sender, TraceID: trace})
+		if err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+		if err := svc.UpdateTaskTokens(task.TaskID, tokens/2, tokens-tokens/2, tokens); err != nil {
+			t.Fatal(err)
+		}
+		if err := svc.UpdateTaskStatus(task.TaskID, status, "", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// trace-a: slack, 3 spans over 4s, a failing tool.
+	addEvent("a1", "trace-a", 0, "INBOUND", "")
+	addEvent("a2", "trace-a", 2*time.Second, "TOOL", `{"tool_name":"exec","error":"exit 1"}`)
+	addEvent("a3", "trace-a", 4*time.Second, "OUTBOUND", "")
+	addTask("trace-a", "slack", "alice", TaskStatusCompleted, 100)
+	// trace-b: whatsapp, 2 spans over 500ms, failed task.
+	addEvent("b1", "trace-b", time.Minute, "INBOUND", "")
+	addEvent("b2", "trace-b", time.Minute+500*time.Millisecond, "LLM", `{"duration_ms":400}`)
+	addTask("trace-b", "whatsapp", "bob", TaskStatusFailed, 40)
+	// trace-c: no task, clean.
+	addEvent("c1", "trace-c", 2*time.Minute, "ROUTING", "")
+
+	all, total, err := svc.ListTraces(TraceListFilter{})
+	if err != nil {
+		t.Fatalf("list traces: %v", err)
+	}
+	if total != 3 || len(all) != 3 || all[0].TraceID != "trace-c" || all[2].TraceID != "trace-a" {
+		t.Fatalf("expected 3 traces newest first, got %d %+v", total, all)
+	}
+	a := all[2]
+	if a.SpanCount != 3 || a.ErrorCount != 1 || !a.HasErrors || a.DurationMS != 4000 ||
+		a.Channel != "slack" || a.SenderID != "alice" || a.Status != TaskStatusCompleted || a.TotalTokens != 100 || a.Tasks != 1 {
+		t.Fatalf("unexpected trace-a summary: %+v", a)
+	}
+	if c := all[0]; c.SenderID != "user1" || c.Status != "" || c.HasErrors || c.DurationMS != 0 {
+		t.Fatalf("unexpected trace-c summary: %+v", c)
+	}
+
+	yes, no := true, false
+	tests := []struct {
+		name   string
+		filter TraceListFilter
+		want   []string
+		total  int
+	}{
+		{name: "channel", filter: TraceListFilter{Channel: "whatsapp"}, want: []string{"trace-b"}, total: 1},
+		{name: "sender", filter: TraceListFilter{SenderID: "alice"}, want: []string{"trace-a"}, total: 1},
+		{name: "status", filter: TraceListFilter{Status: TaskStatusFailed}, want: []string{"trace-b"}, total: 1},
+		{name: "has errors", filter: TraceListFilter{HasErrors: &yes}, want: []string{"trace-b", "trace-a"}, total: 2},
+		{name: "no errors", filter: TraceListFilter{HasErrors: &no}, want: []string{"trace-c"}, total: 1},
+		{name: "min duration", filter: TraceListFilter{MinDurationMS: 1000}, want: []string{"trace-a"}, total: 1},
+		{name: "page", filter: TraceListFilter{Limit: 1, Offset: 1}, want: []string{"trace-b"}, total: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, total, err := svc.ListTraces(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, s := range got {
+				ids = append(ids, s.TraceID)
+			}
+			if total != tt.total || strings.Join(ids, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("got %v (total %d), want %v (total %d)", ids, total, tt.want, tt.total)
+			}
+		})
+	}
+}
+
+func TestGroupTaskDeadlinesAndEscalation(t *testing.T) {
+	svc := newTestTimeline(t)
+	now := time.Now().UTC()
+	past, later := now.Add(-time.Minute), now.Add(time.Hour)
+	for _, rec := range []*GroupTaskRecord{
+		{TaskID: "late", Description: "late", Direction: "outgoing", RequesterID: "me", Status: "pending", DeadlineAt: &past},
+		{TaskID: "urgent", Description: "urgent", Direction: "outgoing", RequesterID: "me", Status: "pending", Priority: "urgent", DeadlineAt: &later},
+		{TaskID: "plain", Description: "plain", Direction: "outgoing", RequesterID: "me", Status: "pending"},
+		{TaskID: "incoming", Description: "incoming", Direction: "incoming", RequesterID: "a", Status: "pending", DeadlineAt: &past},
+	} {
+		if err := svc.InsertGroupTask(rec); err != nil {
+			t.Fatalf("insert %s: %v", rec.TaskID, err)
+		}
+	}
+
+	overdue, err := svc.ListOverdueGroupTasks(now)
+	if err != nil || len(overdue) != 1 || overdue[0].TaskID != "late" {
+		t.Fatalf("expected only the late outgoing task overdue, got %+v (err=%v)", overdue, err)
+	}
+
+	sorted, err := svc.ListGroupTasksSorted("outgoing", "", GroupTaskSortUrgency, 10, 0)
+	if err != nil || len(sorted) != 3 {
+		t.Fatalf("sorted list: %+v (err=%v)", sorted, err)
+	}
+	if sorted[0].TaskID != "urgent" || sorted[1].TaskID != "late" || sorted[2].TaskID != "plain" {
+		t.Fatalf("expected urgent, late, plain; got %s, %s, %s", sorted[0].TaskID, sorted[1].TaskID, sorted[2].TaskID)
+	}
+
+	// Escalating without a new deadline stops the task from being reported
+	// again until its deadline moves.
+	if err := svc.EscalateGroupTask("late", "high", nil, now); err != nil {
+		t.Fatalf("escalate: %v", err)
+	}
+	if overdue, _ := svc.ListOverdueGroupTasks(now.Add(time.Minute)); len(overdue) != 0 {
+		t.Fatalf("expected escalated task not overdue again, got %+v", overdue)
+	}
+	renewed := now.Add(2 * time.Minute)
+	if err := svc.EscalateGroupTask("late", "urgent", &renewed, now); err != nil {
+		t.Fatalf("escalate with deadline: %v", err)
+	}
+	task, err := svc.GetGroupTask("late")
+	if err != nil || task == nil {
+		t.Fatalf("get task: %v", err)
+	}
+	if task.Priority != "urgent" || task.Escalations != 2 || task.DeadlineAt == nil || task.DeadlineAt.Sub(renewed).Abs() > time.Second {
+		t.Fatalf("unexpected escalated task: %+v", task)
+	}
+	if overdue, _ := svc.ListOverdueGroupTasks(now.Add(3 * time.Minute)); len(overdue) != 1 {
+		t.Fatalf("expected task overdue again past its renewed deadline, got %+v", overdue)
+	}
+}
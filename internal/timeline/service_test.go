@@ -3,7 +3,9 @@ package timeline
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func newTestTimeline(t *testing.T) *TimelineService {
@@ -56,3 +58,362 @@ func TestWebUserLinkLifecycle(t *testing.T) {
 		t.Fatalf("expected no link after unlink")
 	}
 }
+
+func TestPromptTemplateVersions(t *testing.T) {
+	svc := newTestTimeline(t)
+
+	for _, content := range []string{"v1", "v2"} {
+		rec := &PromptTemplateRecord{Name: "greeting", Content: content}
+		if err := svc.SavePromptTemplate(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = svc.SavePromptTemplate(&PromptTemplateRecord{Name: "other", Content: "o"})
+
+	latest, err := svc.GetPromptTemplate("greeting", 0)
+	if err != nil || latest == nil || latest.Version != 2 || latest.Content != "v2" || latest.Variables != "{}" {
+		t.Fatalf("unexpected latest: %+v err=%v", latest, err)
+	}
+	first, _ := svc.GetPromptTemplate("greeting", 1)
+	if first == nil || first.Content != "v1" {
+		t.Fatalf("unexpected v1: %+v", first)
+	}
+	if missing, err := svc.GetPromptTemplate("nope", 0); err != nil || missing != nil {
+		t.Fatalf("expected nil for unknown template, got %+v err=%v", missing, err)
+	}
+
+	list, _ := svc.ListPromptTemplates()
+	if len(list) != 2 || list[0].Name != "greeting" || list[0].Version != 2 {
+		t.Fatalf("unexpected list: %+v", list)
+	}
+	versions, _ := svc.ListPromptTemplateVersions("greeting")
+	if len(versions) != 2 || versions[0].Version != 2 {
+		t.Fatalf("unexpected versions: %+v", versions)
+	}
+	if n, err := svc.DeletePromptTemplate("greeting"); err != nil || n != 2 {
+		t.Fatalf("delete: n=%d err=%v", n, err)
+	}
+}
+
+func TestCommitmentLifecycle(t *testing.T) {
+	svc := newTestTimeline(t)
+
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(24 * time.Hour)
+	due := &CommitmentRecord{Owner: CommitmentOwnerUser, Description: "send the report", DueAt: &past, Channel: "slack", ChatID: "C1"}
+	later := &CommitmentRecord{Description: "check the build", DueAt: &future, Channel: "slack", ChatID: "C1"}
+	undated := &CommitmentRecord{Description: "look into flaky test", Channel: "slack", ChatID: "C2"}
+	for _, rec := range []*CommitmentRecord{later, due, undated} {
+		if err := svc.CreateCommitment(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if later.Owner != CommitmentOwnerAgent || later.Status != CommitmentOpen {
+		t.Fatalf("unexpected defaults: %+v", later)
+	}
+
+	open, err := svc.ListCommitments(CommitmentOpen, "slack", "C1", 0)
+	if err != nil || len(open) != 2 || open[0].ID != due.ID {
+		t.Fatalf("unexpected open list: %+v err=%v", open, err)
+	}
+
+	dueList, err := svc.ListDueCommitments(time.Now(), 0)
+	if err != nil || len(dueList) != 1 || dueList[0].ID != due.ID || dueList[0].DueAt == nil {
+		t.Fatalf("unexpected due list: %+v err=%v", dueList, err)
+	}
+	if err := svc.MarkCommitmentFollowedUp(due.ID); err != nil {
+		t.Fatal(err)
+	}
+	if dueList, _ = svc.ListDueCommitments(time.Now(), 0); len(dueList) != 0 {
+		t.Fatalf("followed-up commitment should not be due again: %+v", dueList)
+	}
+
+	if ok, err := svc.CloseCommitment(due.ID, CommitmentDone); err != nil || !ok {
+		t.Fatalf("close: ok=%v err=%v", ok, err)
+	}
+	if ok, _ := svc.CloseCommitment(due.ID, CommitmentCancelled); ok {
+		t.Fatal("closing a closed commitment should report false")
+	}
+	if _, err := svc.CloseCommitment(later.ID, "bogus"); err == nil {
+		t.Fatal("expected error for invalid status")
+	}
+	got, err := svc.GetCommitment(due.ID)
+	if err != nil || got == nil || got.Status != CommitmentDone || got.ClosedAt == nil || got.FollowedUpAt == nil {
+		t.Fatalf("unexpected closed commitment: %+v err=%v", got, err)
+	}
+	if missing, err := svc.GetCommitment(9999); err != nil || missing != nil {
+		t.Fatalf("expected nil for unknown commitment, got %+v err=%v", missing, err)
+	}
+}
+
+func TestBoardItemLifecycle(t *testing.T) {
+	svc := newTestTimeline(t)
+
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(24 * time.Hour)
+	overdue := &BoardItemRecord{Channel: "slack", ChatID: "C1", Title: "rotate keys", Assignee: "alice", DueAt: &past}
+	later := &BoardItemRecord{Channel: "slack", ChatID: "C1", Title: "write release notes", DueAt: &future}
+	undated := &BoardItemRecord{Channel: "slack", ChatID: "C1", Title: "tidy dashboards"}
+	other := &BoardItemRecord{Channel: "msteams", ChatID: "T1", Title: "other chat"}
+	for _, rec := range []*BoardItemRecord{undated, later, overdue, other} {
+		if err := svc.CreateBoardItem(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if undated.Status != BoardItemOpen || undated.ID == 0 {
+		t.Fatalf("unexpected defaults: %+v", undated)
+	}
+	if err := svc.CreateBoardItem(&BoardItemRecord{Title: "x", Status: "bogus"}); err == nil {
+		t.Fatal("expected error for invalid status")
+	}
+
+	items, err := svc.ListBoardItems(BoardItemFilter{Channel: "slack", ChatID: "C1"})
+	if err != nil || len(items) != 3 || items[0].ID != overdue.ID || items[2].ID != undated.ID {
+		t.Fatalf("unexpected board: %+v err=%v", items, err)
+	}
+
+	later.Status = BoardItemDone
+	later.Assignee = "bob"
+	if err := svc.UpdateBoardItem(later); err != nil {
+		t.Fatal(err)
+	}
+	got, err := svc.GetBoardItem(later.ID)
+	if err != nil || got == nil || got.Status != BoardItemDone || got.Assignee != "bob" || got.ClosedAt == nil {
+		t.Fatalf("unexpected updated item: %+v err=%v", got, err)
+	}
+	if items, _ = svc.ListBoardItems(BoardItemFilter{Channel: "slack", ChatID: "C1"}); len(items) != 2 {
+		t.Fatalf("closed item should be hidden by default: %+v", items)
+	}
+	if items, _ = svc.ListBoardItems(BoardItemFilter{Channel: "slack", ChatID: "C1", IncludeClosed: true}); len(items) != 3 || items[2].ID != later.ID {
+		t.Fatalf("expected closed item last: %+v", items)
+	}
+	if items, _ = svc.ListBoardItems(BoardItemFilter{Assignee: "alice"}); len(items) != 1 || items[0].ID != overdue.ID {
+		t.Fatalf("unexpected assignee filter: %+v", items)
+	}
+
+	got.Status = BoardItemOpen
+	if err := svc.UpdateBoardItem(got); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = svc.GetBoardItem(later.ID); got.ClosedAt != nil {
+		t.Fatalf("reopened item should have no closed time: %+v", got)
+	}
+	if err := svc.UpdateBoardItem(&BoardItemRecord{ID: 9999, Title: "x", Status: BoardItemOpen}); err == nil {
+		t.Fatal("expected error for unknown item")
+	}
+
+	boards, err := svc.ListBoards(time.Now())
+	if err != nil || len(boards) != 2 {
+		t.Fatalf("unexpected boards: %+v err=%v", boards, err)
+	}
+	for _, b := range boards {
+		if b.ChatID == "C1" && (b.Open != 3 || b.Overdue != 1 || b.UpdatedAt.IsZero()) {
+			t.Fatalf("unexpected summary: %+v", b)
+		}
+	}
+}
+
+func TestChatPins(t *testing.T) {
+	svc := newTestTimeline(t)
+
+	first := &ChatPinRecord{Channel: "slack", ChatID: "C1", Content: "  prod cluster is eu-central-1 ", CreatedBy: "U1"}
+	second := &ChatPinRecord{Channel: "slack", ChatID: "C1", Content: "deploys need a change ticket"}
+	for _, rec := range []*ChatPinRecord{first, second, {Channel: "slack", ChatID: "C2", Content: "other chat"}} {
+		if err := svc.CreateChatPin(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := svc.CreateChatPin(&ChatPinRecord{Channel: "slack", ChatID: "C1", Content: "  "}); err == nil {
+		t.Fatal("expected error for empty pin")
+	}
+	pins, err := svc.ListChatPins("slack", "C1")
+	if err != nil || len(pins) != 2 || pins[0].ID != first.ID || pins[0].Content != "prod cluster is eu-central-1" {
+		t.Fatalf("unexpected pins: %+v err=%v", pins, err)
+	}
+
+	big := &ChatPinRecord{Channel: "slack", ChatID: "C1", Content: strings.Repeat("x", ChatPinBudgetChars)}
+	if err := svc.CreateChatPin(big); err == nil {
+		t.Fatal("expected error when the pin budget is exceeded")
+	}
+
+	if ok, err := svc.DeleteChatPin("slack", "C2", first.ID); err != nil || ok {
+		t.Fatalf("expected pin of another chat not to be deleted: ok=%v err=%v", ok, err)
+	}
+	if ok, err := svc.DeleteChatPin("slack", "C1", first.ID); err != nil || !ok {
+		t.Fatalf("delete pin: ok=%v err=%v", ok, err)
+	}
+	if pins, _ = svc.ListChatPins("slack", "C1"); len(pins) != 1 || pins[0].ID != second.ID {
+		t.Fatalf("unexpected pins after delete: %+v", pins)
+	}
+}
+
+func TestBroadcastAudiences(t *testing.T) {
+	svc := newTestTimeline(t)
+
+	if err := svc.UpsertBroadcastAudience(&BroadcastAudienceRecord{Name: "ops"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.UpsertBroadcastAudience(&BroadcastAudienceRecord{Name: "ops", Description: "on-call", Targets: `[{"channel":"slack","chat_id":"C1"}]`}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := svc.GetBroadcastAudience("ops")
+	if err != nil || got == nil || got.Description != "on-call" || got.Targets == "[]" {
+		t.Fatalf("unexpected audience: %+v err=%v", got, err)
+	}
+	list, _ := svc.ListBroadcastAudiences()
+	if len(list) != 1 {
+		t.Fatalf("expected one audience, got %+v", list)
+	}
+	if ok, err := svc.DeleteBroadcastAudience("ops"); err != nil || !ok {
+		t.Fatalf("delete: ok=%v err=%v", ok, err)
+	}
+	if missing, _ := svc.GetBroadcastAudience("ops"); missing != nil {
+		t.Fatalf("expected audience removed, got %+v", missing)
+	}
+}
+
+func TestMissionDashboard(t *testing.T) {
+	svc := newTestTimeline(t)
+
+	if err := svc.UpsertMission(&MissionRecord{Name: "launch", Description: "v2 launch"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := svc.GetMission("launch"); got == nil || got.Status != MissionStatusActive {
+		t.Fatalf("unexpected mission: %+v", got)
+	}
+	task, err := svc.CreateTask(&AgentTask{TraceID: "tr-1", Channel: "slack", ChatID: "C1", ContentIn: "ship it"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = svc.UpdateTaskStatus(task.TaskID, TaskStatusCompleted, "done", "")
+	if _, err := svc.CreateTask(&AgentTask{Channel: "slack", ChatID: "C1", ContentIn: "unrelated"}); err != nil {
+		t.Fatal(err)
+	}
+	_ = svc.UpsertScheduledJob("nightly-report", "ok", time.Now())
+	if _, err := svc.DB().Exec(`INSERT INTO memory_chunks (id, content, source) VALUES ('m1','a','launch'), ('m2','b','launch:notes'), ('m3','c','launchpad')`); err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range [][2]string{{MissionKindTask, task.TaskID}, {MissionKindJob, "nightly-report"}, {MissionKindJob, "weekly"}, {MissionKindRepo, "/src/app"}, {MissionKindMemory, "launch"}} {
+		if err := svc.AssignToMission("launch", a[0], a[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := svc.AssignToMission("launch", "bogus", "x"); err == nil {
+		t.Fatal("expected unknown kind to be rejected")
+	}
+
+	d, err := svc.GetMissionDashboard("launch", 10)
+	if err != nil || d == nil {
+		t.Fatalf("dashboard: %+v err=%v", d, err)
+	}
+	if d.TotalTasks != 1 || d.TaskCounts[TaskStatusCompleted] != 1 || len(d.RecentTasks) != 1 || d.RecentTasks[0].TraceID != "tr-1" {
+		t.Fatalf("unexpected task aggregation: %+v", d)
+	}
+	if len(d.Jobs) != 2 || len(d.Repos) != 1 || d.MemoryChunks != 2 {
+		t.Fatalf("unexpected dashboard: jobs=%+v repos=%v chunks=%d", d.Jobs, d.Repos, d.MemoryChunks)
+	}
+
+	// Reassigning moves the resource; deleting the mission drops assignments.
+	_ = svc.UpsertMission(&MissionRecord{Name: "ops"})
+	_ = svc.AssignToMission("ops", MissionKindRepo, "/src/app")
+	if repos, _ := svc.ListMissionAssignments("launch", MissionKindRepo); len(repos) != 0 {
+		t.Fatalf("expected repo moved to ops, got %+v", repos)
+	}
+	if ok, err := svc.DeleteMission("launch"); err != nil || !ok {
+		t.Fatalf("delete: ok=%v err=%v", ok, err)
+	}
+	if left, _ := svc.ListMissionAssignments("launch", ""); len(left) != 0 {
+		t.Fatalf("expected assignments removed, got %+v", left)
+	}
+	if d, _ := svc.GetMissionDashboard("launch", 0); d != nil {
+		t.Fatalf("expected nil dashboard for missing mission, got %+v", d)
+	}
+}
+
+func TestRemoteExecAudit(t *testing.T) {
+	svc := newTestTimeline(t)
+	for _, rec := range []*RemoteExecRecord{
+		{TraceID: "tr1", Host: "web", User: "ops", Command: "uptime", ExitCode: 0, OutputBytes: 10, Sender: "alice"},
+		{TraceID: "tr1", Host: "web", User: "ops", Command: "rm -rf /", ExitCode: -1, Error: "denied", Sender: "alice"},
+		{TraceID: "tr2", Host: "db", User: "ops", Command: "false", ExitCode: 1, Sender: "bob"},
+	} {
+		if err := svc.LogRemoteExec(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	web, err := svc.ListRemoteExec("web", 10)
+	if err != nil || len(web) != 2 || web[0].Command != "rm -rf /" || web[0].Error != "denied" {
+		t.Fatalf("unexpected web history: %+v %v", web, err)
+	}
+
+	entries, err := svc.ListUnifiedAudit(AuditFilter{Source: "remote_exec", Limit: 10})
+	if err != nil || len(entries) != 3 {
+		t.Fatalf("expected remote commands in unified audit: %+v %v", entries, err)
+	}
+	got := map[string]string{}
+	for _, e := range entries {
+		got[e.Details] = e.EventType
+	}
+	if got["uptime"] != "ok" || got["rm -rf /"] != "denied" || got["false"] != "failed" {
+		t.Fatalf("unexpected audit event types: %v", got)
+	}
+}
+
+func TestSettingsAudit(t *testing.T) {
+	svc := newTestTimeline(t)
+	by := SettingChangeRecord{Actor: "alice", SourceIP: "127.0.0.1", Endpoint: "/api/v1/settings"}
+	if err := svc.SetSettingAudited("kafka_brokers", "broker-1.internal:9092", by); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.SetSettingAudited("kafka_brokers", "broker-1.internal:9092", by); err != nil {
+		t.Fatal(err)
+	}
+	by.Actor = "bob"
+	if err := svc.SetSettingAudited("kafka_brokers", "broker-2.internal:9092", by); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := svc.GetSetting("kafka_brokers"); v != "broker-2.internal:9092" {
+		t.Fatalf("setting not stored: %q", v)
+	}
+
+	changes, err := svc.ListSettingChanges("kafka_brokers", 10)
+	if err != nil || len(changes) != 2 {
+		t.Fatalf("expected two changes (unchanged writes are not audited): %+v %v", changes, err)
+	}
+	latest := changes[0]
+	if latest.Actor != "bob" || latest.OldValue != "br****92" || latest.NewValue != "br****92" || latest.SourceIP != "127.0.0.1" {
+		t.Fatalf("unexpected latest change: %+v", latest)
+	}
+	if changes[1].OldValue != "" || changes[1].Actor != "alice" {
+		t.Fatalf("first change must have no old value: %+v", changes[1])
+	}
+	if MaskSettingValue("true") != "****" {
+		t.Fatal("short values must be masked completely")
+	}
+
+	entries, err := svc.ListUnifiedAudit(AuditFilter{Source: "settings", Limit: 10})
+	if err != nil || len(entries) != 2 || entries[0].TargetID != "kafka_brokers" || entries[0].EventType != "changed" {
+		t.Fatalf("expected settings changes in unified audit: %+v %v", entries, err)
+	}
+}
+
+func TestDeliveryReceipts(t *testing.T) {
+	svc := newTestTimeline(t)
+	for _, rec := range []*DeliveryReceiptRecord{
+		{Channel: "slack", ChatID: "C1", ThreadID: "1.0", MessageID: "1.1", MessageIDs: []string{"1.1", "1.2"}, TaskID: "task-a"},
+		{Channel: "slack", ChatID: "C1", MessageID: "2.1", TaskID: "task-b"},
+		{Channel: "msteams", ChatID: "conv-1", MessageID: "act-1", TaskID: "task-c"},
+	} {
+		if err := svc.LogDeliveryReceipt(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	chat, err := svc.ListDeliveryReceipts("C1", "", 10)
+	if err != nil || len(chat) != 2 || chat[0].MessageID != "2.1" || len(chat[0].MessageIDs) != 0 {
+		t.Fatalf("unexpected chat receipts: %+v %v", chat, err)
+	}
+	task, err := svc.ListDeliveryReceipts("", "task-a", 10)
+	if err != nil || len(task) != 1 || task[0].ThreadID != "1.0" || len(task[0].MessageIDs) != 2 || task[0].MessageIDs[1] != "1.2" {
+		t.Fatalf("unexpected task receipt: %+v %v", task, err)
+	}
+}
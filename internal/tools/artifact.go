@@ -0,0 +1,255 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// artifactTTL is how long tool output artifacts are kept on disk.
+const artifactTTL = 7 * 24 * time.Hour
+
+// readArtifactMaxChars caps the size of one read_artifact page.
+const readArtifactMaxChars = 16000
+
+var (
+	artifactIDRe   = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+	artifactNameRe = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+)
+
+// ArtifactStore keeps oversized tool outputs as files in the workspace so the
+// conversation only carries a preview and a reference.
+type ArtifactStore struct {
+	dir string
+}
+
+// NewArtifactStore creates a store writing to dir.
+func NewArtifactStore(dir string) *ArtifactStore {
+	return &ArtifactStore{dir: expandPath(dir)}
+}
+
+// Dir returns the artifact directory.
+func (s *ArtifactStore) Dir() string {
+	return s.dir
+}
+
+// Save writes content as a new artifact and returns its ID. Artifacts older
+// than artifactTTL are removed on the way.
+func (s *ArtifactStore) Save(toolName, content string) (string, error) {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return "", err
+	}
+	s.prune()
+	name := strings.Trim(artifactNameRe.ReplaceAllString(toolName, "_"), "_")
+	if name == "" {
+		name = "tool"
+	}
+	id := fmt.Sprintf("%s-%d.txt", name, time.Now().UnixNano())
+	if err := os.WriteFile(filepath.Join(s.dir, id), []byte(content), 0o644); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+func (s *ArtifactStore) prune() {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+	cutoff := time.Now().Add(-artifactTTL)
+	for _, e := range entries {
+		if info, err := e.Info(); err == nil && !e.IsDir() && info.ModTime().Before(cutoff) {
+			_ = os.Remove(filepath.Join(s.dir, e.Name()))
+		}
+	}
+}
+
+// Read returns up to limit lines starting at the 1-based line offset, and the
+// total number of lines in the artifact.
+func (s *ArtifactStore) Read(id string, offset, limit int) ([]string, int, error) {
+	id = strings.TrimSpace(id)
+	if !artifactIDRe.MatchString(id) || strings.Contains(id, "..") {
+		return nil, 0, fmt.Errorf("invalid artifact id %q", id)
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, 0, fmt.Errorf("artifact %q not found (artifacts expire after %s)", id, artifactTTL)
+		}
+		return nil, 0, err
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if offset < 1 {
+		offset = 1
+	}
+	if offset > len(lines) {
+		return nil, len(lines), nil
+	}
+	end := offset - 1 + limit
+	if limit <= 0 || end > len(lines) {
+		end = len(lines)
+	}
+	return lines[offset-1 : end], len(lines), nil
+}
+
+// Compact returns result unchanged when it fits maxChars. Larger output is
+// saved as an artifact and replaced by a summary, head and tail excerpt and a
+// read_artifact reference. If saving fails, the output is only truncated.
+// Must be called on a non-nil store.
+func (s *ArtifactStore) Compact(toolName, result string, maxChars int) string {
+	if maxChars <= 0 || len(result) <= maxChars {
+		return result
+	}
+	lines := strings.Count(strings.TrimRight(result, "\n"), "\n") + 1
+	head := cutPrefix(result, maxChars/2)
+	tail := cutSuffix(result, maxChars/4)
+
+	var b strings.Builder
+	b.WriteString(head)
+	fmt.Fprintf(&b, "\n\n[... %d of %d chars omitted ...]\n\n", len(result)-len(head)-len(tail), len(result))
+	b.WriteString(strings.TrimRight(tail, "\n"))
+
+	id, err := s.Save(toolName, result)
+	if err != nil {
+		fmt.Fprintf(&b, "\n\n[output truncated: %d chars, %d lines; full output could not be saved]", len(result), lines)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "\n\n[output truncated: %d chars, %d lines", len(result), lines)
+	if n, first := countProblemLines(result); n > 0 {
+		fmt.Fprintf(&b, ", %d line(s) mention error/fail (first at line %d)", n, first)
+	}
+	fmt.Fprintf(&b, ". Full output saved as artifact %q; call read_artifact with id=%q and offset/limit (lines) to page through it.]", id, id)
+	return b.String()
+}
+
+// cutPrefix returns at most n bytes from the start of s, preferring to end on
+// a line break and never splitting a UTF-8 sequence.
+func cutPrefix(s string, n int) string {
+	if n >= len(s) {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	out := s[:n]
+	if i := strings.LastIndexByte(out, '\n'); i > n/2 {
+		out = out[:i]
+	}
+	return out
+}
+
+// cutSuffix returns at most n bytes from the end of s, preferring to start
+// after a line break and never splitting a UTF-8 sequence.
+func cutSuffix(s string, n int) string {
+	if n >= len(s) {
+		return s
+	}
+	start := len(s) - n
+	for start < len(s) && !utf8.RuneStart(s[start]) {
+		start++
+	}
+	out := s[start:]
+	if i := strings.IndexByte(out, '\n'); i >= 0 && i < len(out)/2 {
+		out = out[i+1:]
+	}
+	return out
+}
+
+// countProblemLines counts lines mentioning errors or failures and returns
+// the 1-based number of the first one.
+func countProblemLines(s string) (int, int) {
+	count, first := 0, 0
+	for i, line := range strings.Split(s, "\n") {
+		l := strings.ToLower(line)
+		if strings.Contains(l, "error") || strings.Contains(l, "fail") {
+			count++
+			if first == 0 {
+				first = i + 1
+			}
+		}
+	}
+	return count, first
+}
+
+// ReadArtifactTool pages through tool outputs that were too large for the
+// conversation.
+type ReadArtifactTool struct {
+	store *ArtifactStore
+}
+
+// NewReadArtifactTool creates the read_artifact tool.
+func NewReadArtifactTool(store *ArtifactStore) *ReadArtifactTool {
+	return &ReadArtifactTool{store: store}
+}
+
+func (t *ReadArtifactTool) Name() string { return "read_artifact" }
+func (t *ReadArtifactTool) Description() string {
+	return "Read part of a large tool output that was saved as an artifact. Use the artifact id from the truncation note and page with offset/limit (line numbers)."
+}
+func (t *ReadArtifactTool) Tier() int { return TierReadOnly }
+
+func (t *ReadArtifactTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"id": map[string]any{
+				"type":        "string",
+				"description": "Artifact id from the truncation note",
+			},
+			"offset": map[string]any{
+				"type":        "integer",
+				"description": "1-based line to start at (default 1)",
+			},
+			"limit": map[string]any{
+				"type":        "integer",
+				"description": "Number of lines to return (default 200, max 1000)",
+			},
+		},
+		"required": []string{"id"},
+	}
+}
+
+func (t *ReadArtifactTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	id := GetString(params, "id", "")
+	offset := GetInt(params, "offset", 1)
+	limit := GetInt(params, "limit", 200)
+	if limit <= 0 || limit > 1000 {
+		limit = 1000
+	}
+	lines, total, err := t.store.Read(id, offset, limit)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	if offset < 1 {
+		offset = 1
+	}
+	if len(lines) == 0 {
+		return fmt.Sprintf("Artifact %s has %d lines; offset %d is past the end.", id, total, offset), nil
+	}
+
+	var b strings.Builder
+	last := offset - 1
+	for i, line := range lines {
+		entry := fmt.Sprintf("%6d| %s\n", offset+i, line)
+		if b.Len()+len(entry) > readArtifactMaxChars {
+			if i == 0 {
+				b.WriteString(cutPrefix(entry, readArtifactMaxChars))
+				b.WriteString(" [line cut]\n")
+				last = offset
+			}
+			break
+		}
+		b.WriteString(entry)
+		last = offset + i
+	}
+	header := fmt.Sprintf("Artifact %s, lines %d-%d of %d", id, offset, last, total)
+	if last < total {
+		header += fmt.Sprintf(" (next: offset=%d)", last+1)
+	}
+	return header + "\n" + b.String(), nil
+}
@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestArtifactStoreCompactAndRead(t *testing.T) {
+	store := NewArtifactStore(t.TempDir())
+	if got := store.Compact("exec", "short", 100); got != "short" {
+		t.Fatalf("expected small output unchanged, got %q", got)
+	}
+
+	var b strings.Builder
+	for i := 1; i <= 500; i++ {
+		if i == 250 {
+			b.WriteString("FAIL: TestSomething\n")
+			continue
+		}
+		fmt.Fprintf(&b, "log line %d\n", i)
+	}
+	full := b.String()
+	out := store.Compact("exec", full, 1000)
+	if len(out) > 1500 || !strings.HasPrefix(out, "log line 1\n") || !strings.Contains(out, "log line 500") {
+		t.Fatalf("unexpected preview (%d chars): %q", len(out), out)
+	}
+	if !strings.Contains(out, "500 lines") || !strings.Contains(out, "first at line 250") {
+		t.Fatalf("expected summary in preview: %q", out)
+	}
+	id := regexp.MustCompile(`artifact "([^"]+)"`).FindStringSubmatch(out)
+	if id == nil {
+		t.Fatalf("expected artifact reference: %q", out)
+	}
+	saved, err := os.ReadFile(store.Dir() + "/" + id[1])
+	if err != nil || string(saved) != full {
+		t.Fatalf("expected full output saved, err=%v", err)
+	}
+
+	tool := NewReadArtifactTool(store)
+	page, _ := tool.Execute(context.Background(), map[string]any{"id": id[1], "offset": float64(249), "limit": float64(2)})
+	if !strings.Contains(page, "lines 249-250 of 500") || !strings.Contains(page, "FAIL: TestSomething") || !strings.Contains(page, "next: offset=251") {
+		t.Fatalf("unexpected page: %q", page)
+	}
+	if res, _ := tool.Execute(context.Background(), map[string]any{"id": "../etc/passwd"}); !strings.Contains(res, "invalid artifact id") {
+		t.Fatalf("expected path traversal rejected, got %q", res)
+	}
+}
@@ -0,0 +1,188 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// TaskBoardToolName is the name of the per-chat task board tool.
+const TaskBoardToolName = "task_board"
+
+// BoardItemView is a task board item as seen by the model. Due is RFC3339 or
+// empty.
+type BoardItemView struct {
+	ID       int64  `json:"id"`
+	Title    string `json:"title"`
+	Notes    string `json:"notes,omitempty"`
+	Status   string `json:"status"`
+	Assignee string `json:"assignee,omitempty"`
+	Due      string `json:"due,omitempty"`
+}
+
+// BoardItemChange holds the fields of an update; nil fields stay unchanged.
+// An empty Due clears the due date.
+type BoardItemChange struct {
+	Title    *string
+	Notes    *string
+	Status   *string
+	Assignee *string
+	Due      *string
+}
+
+// TaskBoardTool lets the agent read and maintain the task board of the chat
+// it is answering in. The callbacks are scoped to that chat by the caller.
+type TaskBoardTool struct {
+	list   func(includeClosed bool) ([]BoardItemView, error)
+	create func(item BoardItemView) (BoardItemView, error)
+	update func(id int64, change BoardItemChange) (BoardItemView, error)
+}
+
+func NewTaskBoardTool(
+	listFn func(includeClosed bool) ([]BoardItemView, error),
+	createFn func(item BoardItemView) (BoardItemView, error),
+	updateFn func(id int64, change BoardItemChange) (BoardItemView, error),
+) *TaskBoardTool {
+	return &TaskBoardTool{list: listFn, create: createFn, update: updateFn}
+}
+
+func (t *TaskBoardTool) Name() string { return TaskBoardToolName }
+func (t *TaskBoardTool) Tier() int    { return TierWrite }
+func (t *TaskBoardTool) Description() string {
+	return "Read and maintain the task board of the current chat: list items, create items, or update their status, assignee, due date, title or notes. Use it when the conversation agrees on a task, hands one to someone, or finishes one."
+}
+
+// TierFor reports listing as read-only.
+func (t *TaskBoardTool) TierFor(params map[string]any) int {
+	if strings.TrimSpace(GetString(params, "action", "list")) == "list" {
+		return TierReadOnly
+	}
+	return TierWrite
+}
+
+func (t *TaskBoardTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"action": map[string]any{
+				"type":        "string",
+				"description": "Action: list, create, or update.",
+				"enum":        []string{"list", "create", "update"},
+			},
+			"id": map[string]any{
+				"type":        "integer",
+				"description": "Item ID when action=update.",
+			},
+			"title": map[string]any{
+				"type":        "string",
+				"description": "Short imperative title. Required when action=create.",
+			},
+			"notes": map[string]any{
+				"type":        "string",
+				"description": "Optional details.",
+			},
+			"status": map[string]any{
+				"type":        "string",
+				"description": "Item status.",
+				"enum":        []string{"open", "in_progress", "done", "cancelled"},
+			},
+			"assignee": map[string]any{
+				"type":        "string",
+				"description": "Who owns the item (a name or handle; empty to unassign).",
+			},
+			"due": map[string]any{
+				"type":        "string",
+				"description": "Due date as RFC3339 timestamp or YYYY-MM-DD; empty to clear on update.",
+			},
+			"include_closed": map[string]any{
+				"type":        "boolean",
+				"description": "When action=list, include done and cancelled items (default: false).",
+			},
+		},
+		"required": []string{"action"},
+	}
+}
+
+func (t *TaskBoardTool) Execute(_ context.Context, params map[string]any) (string, error) {
+	action := strings.TrimSpace(GetString(params, "action", "list"))
+	switch action {
+	case "", "list":
+		if t.list == nil {
+			return "", fmt.Errorf("task board list unavailable")
+		}
+		items, err := t.list(GetBool(params, "include_closed", false))
+		if err != nil {
+			return "", err
+		}
+		if items == nil {
+			items = []BoardItemView{}
+		}
+		return taskBoardResult("list", map[string]any{"items": items})
+	case "create":
+		if t.create == nil {
+			return "", fmt.Errorf("task board create unavailable")
+		}
+		item := BoardItemView{
+			Title:    strings.TrimSpace(GetString(params, "title", "")),
+			Notes:    strings.TrimSpace(GetString(params, "notes", "")),
+			Status:   strings.TrimSpace(GetString(params, "status", "")),
+			Assignee: strings.TrimSpace(GetString(params, "assignee", "")),
+			Due:      strings.TrimSpace(GetString(params, "due", "")),
+		}
+		if item.Title == "" {
+			return "", fmt.Errorf("title is required for create")
+		}
+		created, err := t.create(item)
+		if err != nil {
+			return "", err
+		}
+		return taskBoardResult("create", map[string]any{"item": created})
+	case "update":
+		if t.update == nil {
+			return "", fmt.Errorf("task board update unavailable")
+		}
+		id := int64(GetInt(params, "id", 0))
+		if id <= 0 {
+			return "", fmt.Errorf("id is required for update")
+		}
+		change := BoardItemChange{
+			Title:    optionalString(params, "title"),
+			Notes:    optionalString(params, "notes"),
+			Status:   optionalString(params, "status"),
+			Assignee: optionalString(params, "assignee"),
+			Due:      optionalString(params, "due"),
+		}
+		if change == (BoardItemChange{}) {
+			return "", fmt.Errorf("nothing to update")
+		}
+		updated, err := t.update(id, change)
+		if err != nil {
+			return "", err
+		}
+		return taskBoardResult("update", map[string]any{"item": updated})
+	default:
+		return "", fmt.Errorf("unknown action %q", action)
+	}
+}
+
+// optionalString returns the trimmed string parameter key, or nil when the
+// call does not set it.
+func optionalString(params map[string]any, key string) *string {
+	v, ok := params[key].(string)
+	if !ok {
+		return nil
+	}
+	v = strings.TrimSpace(v)
+	return &v
+}
+
+func taskBoardResult(action string, body map[string]any) (string, error) {
+	body["status"] = "ok"
+	body["action"] = action
+	out, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTaskBoardTool_Execute(t *testing.T) {
+	var (
+		created BoardItemView
+		changed BoardItemChange
+	)
+	tool := NewTaskBoardTool(
+		func(includeClosed bool) ([]BoardItemView, error) {
+			if !includeClosed {
+				return nil, nil
+			}
+			return []BoardItemView{{ID: 1, Title: "ship it", Status: "done"}}, nil
+		},
+		func(item BoardItemView) (BoardItemView, error) {
+			created = item
+			item.ID = 7
+			item.Status = "open"
+			return item, nil
+		},
+		func(id int64, change BoardItemChange) (BoardItemView, error) {
+			changed = change
+			return BoardItemView{ID: id, Title: "ship it", Status: *change.Status}, nil
+		},
+	)
+
+	out, err := tool.Execute(context.Background(), map[string]any{"action": "list"})
+	if err != nil || !strings.Contains(out, `"items":[]`) {
+		t.Fatalf("unexpected empty list: %s err=%v", out, err)
+	}
+	out, err = tool.Execute(context.Background(), map[string]any{"action": "list", "include_closed": true})
+	if err != nil || !strings.Contains(out, `"ship it"`) {
+		t.Fatalf("unexpected list: %s err=%v", out, err)
+	}
+
+	out, err = tool.Execute(context.Background(), map[string]any{
+		"action": "create", "title": " ship it ", "assignee": "alice", "due": "2026-03-06",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body struct {
+		Status string        `json:"status"`
+		Item   BoardItemView `json:"item"`
+	}
+	if err := json.Unmarshal([]byte(out), &body); err != nil || body.Status != "ok" || body.Item.ID != 7 {
+		t.Fatalf("unexpected create result: %s err=%v", out, err)
+	}
+	if created.Title != "ship it" || created.Assignee != "alice" || created.Due != "2026-03-06" {
+		t.Fatalf("unexpected create input: %+v", created)
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]any{"action": "update", "id": float64(7), "status": "done", "due": ""}); err != nil {
+		t.Fatal(err)
+	}
+	if changed.Status == nil || *changed.Status != "done" || changed.Due == nil || *changed.Due != "" || changed.Title != nil {
+		t.Fatalf("unexpected change: %+v", changed)
+	}
+
+	for name, params := range map[string]map[string]any{
+		"create without title": {"action": "create"},
+		"update without id":    {"action": "update", "status": "done"},
+		"update without field": {"action": "update", "id": float64(7)},
+		"unknown action":       {"action": "delete"},
+	} {
+		if _, err := tool.Execute(context.Background(), params); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+
+	if tool.TierFor(map[string]any{"action": "list"}) != TierReadOnly || tool.TierFor(map[string]any{"action": "create"}) != TierWrite {
+		t.Fatal("unexpected tiers")
+	}
+}
@@ -178,7 +178,7 @@ func TestMemoryToolMetadataAndValidation(t *testing.T) {
 		t.Fatalf("expected content validation, got %s", out)
 	}
 
-	recall := NewRecallTool(nil)
+	recall := NewRecallTool(nil, nil)
 	if recall.Name() != "recall" || recall.Description() == "" || recall.Tier() != TierReadOnly {
 		t.Fatalf("unexpected recall metadata")
 	}
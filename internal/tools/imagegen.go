@@ -0,0 +1,94 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/provider"
+)
+
+// GenerateImageTool creates an image from a prompt, saves it into the media
+// directory and attaches it to the reply.
+type GenerateImageTool struct {
+	generator provider.ImageGenerator
+	outDir    string
+	size      string
+	model     string
+	attach    func(path string)
+}
+
+// NewGenerateImageTool creates the image generation tool. Images are written
+// to outDir; attach is called with the saved path so the caller can add it to
+// the outbound message.
+func NewGenerateImageTool(generator provider.ImageGenerator, outDir, size, model string, attach func(path string)) *GenerateImageTool {
+	if outDir == "" {
+		outDir = "~/.kafclaw/workspace/media/generated"
+	}
+	return &GenerateImageTool{
+		generator: generator,
+		outDir:    expandPath(outDir),
+		size:      size,
+		model:     model,
+		attach:    attach,
+	}
+}
+
+func (t *GenerateImageTool) Name() string { return "generate_image" }
+func (t *GenerateImageTool) Description() string {
+	return "Generate an image (diagram, illustration, sketch) from a text description. The image is attached to your reply automatically."
+}
+func (t *GenerateImageTool) Tier() int { return TierWrite }
+
+func (t *GenerateImageTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"prompt": map[string]any{
+				"type":        "string",
+				"description": "Detailed description of the image to generate",
+			},
+			"size": map[string]any{
+				"type":        "string",
+				"description": "Optional size as WIDTHxHEIGHT (e.g. 1024x1024)",
+			},
+		},
+		"required": []string{"prompt"},
+	}
+}
+
+func (t *GenerateImageTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	prompt := strings.TrimSpace(GetString(params, "prompt", ""))
+	size := GetString(params, "size", t.size)
+	if prompt == "" {
+		return "Error: prompt is required", nil
+	}
+
+	resp, err := t.generator.GenerateImage(ctx, &provider.ImageRequest{Prompt: prompt, Size: size, Model: t.model})
+	if err != nil {
+		return fmt.Sprintf("Error generating image: %v", err), nil
+	}
+	ext := resp.Format
+	if ext == "" {
+		ext = "png"
+	}
+	if err := os.MkdirAll(t.outDir, 0o755); err != nil {
+		return fmt.Sprintf("Error creating media directory: %v", err), nil
+	}
+	path := filepath.Join(t.outDir, fmt.Sprintf("img-%d.%s", time.Now().UnixNano(), ext))
+	if err := os.WriteFile(path, resp.Data, 0o644); err != nil {
+		return fmt.Sprintf("Error saving image: %v", err), nil
+	}
+	if t.attach != nil {
+		t.attach(path)
+	}
+
+	result := fmt.Sprintf("Image generated and attached to the reply (%s, %d bytes).", path, len(resp.Data))
+	if resp.RevisedPrompt != "" {
+		result += "\nRevised prompt: " + resp.RevisedPrompt
+	}
+	return result, nil
+}
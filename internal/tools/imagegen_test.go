@@ -0,0 +1,40 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/provider"
+)
+
+type fakeImageGenerator struct {
+	req *provider.ImageRequest
+}
+
+func (f *fakeImageGenerator) GenerateImage(ctx context.Context, req *provider.ImageRequest) (*provider.ImageResponse, error) {
+	f.req = req
+	return &provider.ImageResponse{Data: []byte("png"), Format: "png"}, nil
+}
+
+func TestGenerateImageToolSavesAndAttaches(t *testing.T) {
+	outDir := t.TempDir()
+	gen := &fakeImageGenerator{}
+	var attached []string
+	tool := NewGenerateImageTool(gen, outDir, "512x512", "", func(p string) { attached = append(attached, p) })
+
+	out, _ := tool.Execute(context.Background(), map[string]any{"prompt": "architecture diagram"})
+	if len(attached) != 1 || !strings.HasPrefix(attached[0], outDir) || !strings.Contains(out, attached[0]) {
+		t.Fatalf("unexpected result %q, attached %v", out, attached)
+	}
+	if data, err := os.ReadFile(attached[0]); err != nil || string(data) != "png" {
+		t.Fatalf("image not saved: %v", err)
+	}
+	if gen.req.Size != "512x512" || gen.req.Prompt != "architecture diagram" {
+		t.Fatalf("unexpected request %+v", gen.req)
+	}
+	if out, _ := tool.Execute(context.Background(), map[string]any{}); !strings.Contains(out, "prompt is required") {
+		t.Fatalf("expected missing prompt error, got %q", out)
+	}
+}
@@ -2,8 +2,11 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/KafClaw/KafClaw/internal/memory"
 )
@@ -63,15 +66,20 @@ func (t *RememberTool) Execute(ctx context.Context, params map[string]any) (stri
 // RecallTool searches semantic memory for relevant information.
 type RecallTool struct {
 	service *memory.MemoryService
+	// privacy returns the most restricted level the active message may
+	// see, the same limit the context builder applies to injected memory.
+	privacy func() string
 }
 
-func NewRecallTool(service *memory.MemoryService) *RecallTool {
-	return &RecallTool{service: service}
+// NewRecallTool creates the recall tool. A nil privacy getter returns
+// memories of every privacy level.
+func NewRecallTool(service *memory.MemoryService, privacy func() string) *RecallTool {
+	return &RecallTool{service: service, privacy: privacy}
 }
 
 func (t *RecallTool) Name() string { return "recall" }
 func (t *RecallTool) Description() string {
-	return "Search long-term memory for information relevant to a query. Returns the most relevant stored memories."
+	return "Search long-term memory for information relevant to a query. Filter by time range (since/until), source prefix or namespace. Returns JSON results with content, source, created_at, score and an explanation of why each matched; cite the source when you use a memory."
 }
 func (t *RecallTool) Tier() int { return TierReadOnly }
 
@@ -83,40 +91,124 @@ func (t *RecallTool) Parameters() map[string]any {
 				"type":        "string",
 				"description": "The search query to find relevant memories",
 			},
-			"limit": map[string]any{
+			"top_k": map[string]any{
 				"type":        "integer",
 				"description": "Maximum number of results (default: 5)",
 			},
+			"limit": map[string]any{
+				"type":        "integer",
+				"description": "Deprecated alias of top_k",
+			},
+			"since": map[string]any{
+				"type":        "string",
+				"description": "Only memories created at or after this time: RFC 3339, YYYY-MM-DD, or an age like 24h or 7d",
+			},
+			"until": map[string]any{
+				"type":        "string",
+				"description": "Only memories created at or before this time (same formats as since)",
+			},
+			"source": map[string]any{
+				"type":        "string",
+				"description": "Only memories whose source starts with this prefix, e.g. conversation:slack",
+			},
+			"namespace": map[string]any{
+				"type":        "string",
+				"description": "Only memories of this source namespace: user, conversation, tool, soul, group, er1, knowledge",
+			},
 		},
 		"required": []string{"query"},
 	}
 }
 
+// recallResult is one entry of the recall tool output.
+type recallResult struct {
+	ID          string  `json:"id"`
+	Content     string  `json:"content"`
+	Source      string  `json:"source"`
+	CreatedAt   string  `json:"created_at,omitempty"`
+	Score       float32 `json:"score"`
+	Match       string  `json:"match"`
+	TraceID     string  `json:"trace_id,omitempty"`
+	Explanation string  `json:"explanation"`
+}
+
 func (t *RecallTool) Execute(ctx context.Context, params map[string]any) (string, error) {
 	query := GetString(params, "query", "")
-	limit := GetInt(params, "limit", 5)
-
 	if query == "" {
 		return "Error: query is required", nil
 	}
+	opts := memory.RecallOptions{
+		TopK:         GetInt(params, "top_k", GetInt(params, "limit", 5)),
+		SourcePrefix: strings.TrimSpace(GetString(params, "source", "")),
+		Namespace:    strings.TrimSpace(GetString(params, "namespace", "")),
+	}
+	if t.privacy != nil {
+		if opts.Privacy = t.privacy(); opts.Privacy == "" {
+			opts.Privacy = memory.PrivacyPublic
+		}
+	}
+	now := time.Now()
+	var err error
+	if opts.Since, err = parseRecallTime(GetString(params, "since", ""), now); err != nil {
+		return fmt.Sprintf("Error: invalid since: %v", err), nil
+	}
+	if opts.Until, err = parseRecallTime(GetString(params, "until", ""), now); err != nil {
+		return fmt.Sprintf("Error: invalid until: %v", err), nil
+	}
 
-	chunks, err := t.service.Search(ctx, query, limit)
-	if err != nil {
+	results, err := t.service.Recall(ctx, query, opts)
+	if err != nil && len(results) == 0 {
 		return fmt.Sprintf("Error searching memory: %v", err), nil
 	}
-
-	if len(chunks) == 0 {
+	if len(results) == 0 {
 		return "No relevant memories found.", nil
 	}
 
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("Found %d relevant memories:\n\n", len(chunks)))
-	for i, chunk := range chunks {
-		sb.WriteString(fmt.Sprintf("%d. [score=%.2f, source=%s] %s\n",
-			i+1, chunk.Score, chunk.Source, chunk.Content))
+	out := make([]recallResult, 0, len(results))
+	for _, r := range results {
+		rr := recallResult{
+			ID:          r.ID,
+			Content:     r.Content,
+			Source:      r.Source,
+			Score:       r.Score,
+			Match:       r.Match,
+			TraceID:     r.TraceID,
+			Explanation: r.Explanation,
+		}
+		if !r.CreatedAt.IsZero() {
+			rr.CreatedAt = r.CreatedAt.UTC().Format(time.RFC3339)
+		}
+		out = append(out, rr)
+	}
+	data, err := json.MarshalIndent(map[string]any{"query": query, "count": len(out), "results": out}, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("Error encoding results: %v", err), nil
 	}
+	return string(data), nil
+}
 
-	return sb.String(), nil
+// parseRecallTime accepts RFC 3339, a YYYY-MM-DD date (UTC), or an age such
+// as 90m, 24h or 7d counted back from now. Empty means no bound.
+func parseRecallTime(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not RFC 3339, YYYY-MM-DD or an age like 24h/7d", s)
 }
 
 func truncate(s string, max int) string {
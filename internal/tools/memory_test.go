@@ -3,8 +3,10 @@ package tools
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/KafClaw/KafClaw/internal/memory"
 	"github.com/KafClaw/KafClaw/internal/provider"
@@ -32,6 +34,11 @@ func setupMemoryService(t *testing.T) *memory.MemoryService {
 			embedding BLOB,
 			source TEXT NOT NULL DEFAULT 'user',
 			tags TEXT DEFAULT '',
+			privacy TEXT DEFAULT '',
+			trace_id TEXT DEFAULT '',
+			event_id TEXT DEFAULT '',
+			channel TEXT DEFAULT '',
+			chat_id TEXT DEFAULT '',
 			version INTEGER NOT NULL DEFAULT 1,
 			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
@@ -90,7 +97,7 @@ func TestRecallTool(t *testing.T) {
 	_, _ = remTool.Execute(ctx, map[string]any{"content": "I prefer short answers"})
 
 	// Then recall
-	tool := NewRecallTool(svc)
+	tool := NewRecallTool(svc, nil)
 	if tool.Name() != "recall" {
 		t.Errorf("expected name 'recall', got %q", tool.Name())
 	}
@@ -109,7 +116,7 @@ func TestRecallTool(t *testing.T) {
 
 func TestRecallTool_NoResults(t *testing.T) {
 	svc := setupMemoryService(t)
-	tool := NewRecallTool(svc)
+	tool := NewRecallTool(svc, nil)
 
 	result, err := tool.Execute(context.Background(), map[string]any{"query": "anything"})
 	if err != nil {
@@ -122,7 +129,7 @@ func TestRecallTool_NoResults(t *testing.T) {
 
 func TestRecallTool_EmptyQuery(t *testing.T) {
 	svc := setupMemoryService(t)
-	tool := NewRecallTool(svc)
+	tool := NewRecallTool(svc, nil)
 
 	result, err := tool.Execute(context.Background(), map[string]any{})
 	if err != nil {
@@ -132,3 +139,64 @@ func TestRecallTool_EmptyQuery(t *testing.T) {
 		t.Errorf("expected error for empty query, got: %q", result)
 	}
 }
+
+func TestRecallTool_Filters(t *testing.T) {
+	svc := setupMemoryService(t)
+	ctx := context.Background()
+	_, _ = svc.Store(ctx, "Standup moved to 10:00", "conversation:slack", "")
+	_, _ = svc.Store(ctx, "standup.sh printed the agenda", "tool:exec", "")
+
+	tool := NewRecallTool(svc, nil)
+	result, err := tool.Execute(ctx, map[string]any{"query": "standup", "namespace": "tool", "since": "24h", "top_k": 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Count   int `json:"count"`
+		Results []struct {
+			Source      string `json:"source"`
+			CreatedAt   string `json:"created_at"`
+			Explanation string `json:"explanation"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(result), &out); err != nil {
+		t.Fatalf("expected JSON results, got %q: %v", result, err)
+	}
+	if out.Count != 1 || out.Results[0].Source != "tool:exec" || out.Results[0].CreatedAt == "" || out.Results[0].Explanation == "" {
+		t.Fatalf("unexpected results: %s", result)
+	}
+
+	if result, _ := tool.Execute(ctx, map[string]any{"query": "standup", "until": "yesterday"}); !strings.Contains(result, "Error: invalid until") {
+		t.Fatalf("expected invalid time error, got %q", result)
+	}
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	if got, _ := parseRecallTime("7d", now); !got.Equal(now.AddDate(0, 0, -7)) {
+		t.Fatalf("7d parsed to %v", got)
+	}
+	if got, _ := parseRecallTime("2026-10-01", now); !got.Equal(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("date parsed to %v", got)
+	}
+}
+
+func TestRecallTool_PrivacyLimit(t *testing.T) {
+	svc := setupMemoryService(t)
+	ctx := context.Background()
+	_, _ = svc.StoreWithPrivacy(ctx, "Salary review notes for Bob", "user", "", memory.PrivacyOwner)
+	_, _ = svc.StoreWithPrivacy(ctx, "Salary bands are published on the wiki", "conversation:slack", "", memory.PrivacyPublic)
+
+	allowed := memory.PrivacyPublic
+	tool := NewRecallTool(svc, func() string { return allowed })
+	result, err := tool.Execute(ctx, map[string]any{"query": "salary", "top_k": 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(result, "Bob") || !strings.Contains(result, "wiki") {
+		t.Fatalf("external sender must only see public memories, got %q", result)
+	}
+
+	allowed = memory.PrivacyOwner
+	result, _ = tool.Execute(ctx, map[string]any{"query": "salary", "top_k": 5})
+	if !strings.Contains(result, "Bob") {
+		t.Fatalf("owner must see owner memories, got %q", result)
+	}
+}
@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/KafClaw/KafClaw/internal/msgtemplate"
+)
+
+// MessageTemplateToolName is the name of the message template tool.
+const MessageTemplateToolName = "message_template"
+
+// MessageTemplateTool lets the agent reply with a named template of the
+// workspace, which each channel renders natively, and maintain the
+// templates.
+type MessageTemplateTool struct {
+	registry *msgtemplate.Registry
+	use      func(name string, vars map[string]any)
+}
+
+// NewMessageTemplateTool creates the template tool. use is called with the
+// template and its variables so the caller can add them to the reply.
+func NewMessageTemplateTool(registry *msgtemplate.Registry, use func(name string, vars map[string]any)) *MessageTemplateTool {
+	return &MessageTemplateTool{registry: registry, use: use}
+}
+
+func (t *MessageTemplateTool) Name() string { return MessageTemplateToolName }
+func (t *MessageTemplateTool) Tier() int    { return TierWrite }
+func (t *MessageTemplateTool) Description() string {
+	return "Reply with a message template: a card on Slack and Teams, formatted text elsewhere. Use action=list to see the templates and their variables, action=use to render one as your reply (your text comes first), and action=save or delete to maintain them."
+}
+
+// TierFor reports list and use as read-only.
+func (t *MessageTemplateTool) TierFor(params map[string]any) int {
+	switch strings.TrimSpace(GetString(params, "action", "list")) {
+	case "save", "delete":
+		return TierWrite
+	}
+	return TierReadOnly
+}
+
+func (t *MessageTemplateTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"action": map[string]any{
+				"type":        "string",
+				"description": "Action: list, use, save, or delete.",
+				"enum":        []string{"list", "use", "save", "delete"},
+			},
+			"name": map[string]any{
+				"type":        "string",
+				"description": "Template name (a-z, 0-9, - and _). Required for use, save and delete.",
+			},
+			"vars": map[string]any{
+				"type":        "object",
+				"description": "When action=use, the variables of the template, e.g. {\"service\":\"api\"}.",
+			},
+			"template": map[string]any{
+				"type":        "object",
+				"description": "When action=save, the template: title, text, fields [{label, value}], image_url, actions [{label, url}] and description. Strings may use {{.var}}.",
+			},
+		},
+		"required": []string{"action"},
+	}
+}
+
+func (t *MessageTemplateTool) Execute(_ context.Context, params map[string]any) (string, error) {
+	if t.registry == nil {
+		return "", fmt.Errorf("message templates unavailable")
+	}
+	name := strings.TrimSpace(GetString(params, "name", ""))
+	action := strings.TrimSpace(GetString(params, "action", "list"))
+	if action != "" && action != "list" && name == "" {
+		return "", fmt.Errorf("name is required for %s", action)
+	}
+	switch action {
+	case "", "list":
+		list, err := t.registry.List()
+		if err != nil {
+			return "", err
+		}
+		if list == nil {
+			list = []*msgtemplate.Template{}
+		}
+		return messageTemplateResult("list", map[string]any{"templates": list})
+	case "use":
+		tpl, err := t.registry.Get(name)
+		if err != nil {
+			return "", err
+		}
+		vars, _ := params["vars"].(map[string]any)
+		filled, err := tpl.Render(vars, false)
+		if err != nil {
+			return "", err
+		}
+		if t.use != nil {
+			t.use(tpl.Name, vars)
+		}
+		return messageTemplateResult("use", map[string]any{"name": tpl.Name, "preview": filled.PlainText("")})
+	case "save":
+		raw, ok := params["template"].(map[string]any)
+		if !ok {
+			return "", fmt.Errorf("template is required for save")
+		}
+		blob, _ := json.Marshal(raw)
+		var tpl msgtemplate.Template
+		if err := json.Unmarshal(blob, &tpl); err != nil {
+			return "", fmt.Errorf("invalid template: %w", err)
+		}
+		tpl.Name = name
+		if err := t.registry.Save(&tpl); err != nil {
+			return "", err
+		}
+		return messageTemplateResult("save", map[string]any{"template": tpl})
+	case "delete":
+		if err := t.registry.Delete(name); err != nil {
+			return "", err
+		}
+		return messageTemplateResult("delete", map[string]any{"name": name})
+	default:
+		return "", fmt.Errorf("unknown action %q", action)
+	}
+}
+
+func messageTemplateResult(action string, body map[string]any) (string, error) {
+	body["status"] = "ok"
+	body["action"] = action
+	out, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
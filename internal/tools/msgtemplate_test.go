@@ -0,0 +1,39 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/msgtemplate"
+)
+
+func TestMessageTemplateToolSaveAndUse(t *testing.T) {
+	var usedName string
+	var usedVars map[string]any
+	tool := NewMessageTemplateTool(msgtemplate.NewRegistry(t.TempDir()), func(name string, vars map[string]any) {
+		usedName, usedVars = name, vars
+	})
+
+	if _, err := tool.Execute(context.Background(), map[string]any{
+		"action":   "save",
+		"name":     "incident",
+		"template": map[string]any{"title": "Incident {{.id}}", "text": "{{.summary}}"},
+	}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	out, err := tool.Execute(context.Background(), map[string]any{"action": "list"})
+	if err != nil || !strings.Contains(out, `"name":"incident"`) {
+		t.Fatalf("list: %s %v", out, err)
+	}
+	if _, err := tool.Execute(context.Background(), map[string]any{"action": "use", "name": "incident", "vars": map[string]any{"id": "42"}}); err == nil || usedName != "" {
+		t.Fatalf("expected a missing variable to fail without using the template, got %v", err)
+	}
+	out, err = tool.Execute(context.Background(), map[string]any{"action": "use", "name": "incident", "vars": map[string]any{"id": "42", "summary": "DB down"}})
+	if err != nil || usedName != "incident" || usedVars["id"] != "42" || !strings.Contains(out, "*Incident 42*") {
+		t.Fatalf("use: %s %v (used %q %v)", out, err, usedName, usedVars)
+	}
+	if tool.TierFor(map[string]any{"action": "use"}) != TierReadOnly || tool.TierFor(map[string]any{"action": "save"}) != TierWrite {
+		t.Fatal("expected list/use read-only and save a write")
+	}
+}
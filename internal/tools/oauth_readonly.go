@@ -13,6 +13,7 @@ import (
 
 	"github.com/KafClaw/KafClaw/internal/config"
 	"github.com/KafClaw/KafClaw/internal/skills"
+	"github.com/KafClaw/KafClaw/internal/tracing"
 )
 
 // GoogleWorkspaceReadTool provides read-only Gmail/Calendar access.
@@ -268,6 +269,7 @@ func oauthGETJSON(ctx context.Context, client *http.Client, rawURL string, acces
 		return "", err
 	}
 	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(accessToken))
+	tracing.Inject(req, tracing.TraceID(ctx))
 	resp, err := client.Do(req)
 	if err != nil {
 		return "", err
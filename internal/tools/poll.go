@@ -0,0 +1,126 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// PollToolName is the name of the poll tool.
+const PollToolName = "poll"
+
+// PollView is a poll of the current chat as seen by the model.
+type PollView struct {
+	ID        string           `json:"id"`
+	Question  string           `json:"question"`
+	Options   []PollOptionView `json:"options"`
+	Voters    int              `json:"voters"`
+	Closed    bool             `json:"closed"`
+	CreatedAt string           `json:"created_at,omitempty"`
+	ClosedAt  string           `json:"closed_at,omitempty"`
+}
+
+// PollOptionView is one answer of a poll with its votes. Percent is the
+// share of voters that picked it.
+type PollOptionView struct {
+	Label   string `json:"label"`
+	Votes   int    `json:"votes"`
+	Percent int    `json:"percent"`
+}
+
+// PollTool lets the agent read the results of the polls it sent to the
+// current chat and close them. The callbacks are scoped to that chat by the
+// caller.
+type PollTool struct {
+	list    func(ctx context.Context) ([]PollView, error)
+	results func(ctx context.Context, id string) (PollView, error)
+	close   func(ctx context.Context, id string) (PollView, error)
+}
+
+func NewPollTool(
+	listFn func(ctx context.Context) ([]PollView, error),
+	resultsFn func(ctx context.Context, id string) (PollView, error),
+	closeFn func(ctx context.Context, id string) (PollView, error),
+) *PollTool {
+	return &PollTool{list: listFn, results: resultsFn, close: closeFn}
+}
+
+func (t *PollTool) Name() string { return PollToolName }
+func (t *PollTool) Tier() int    { return TierWrite }
+func (t *PollTool) Description() string {
+	return "Read the polls sent to the current Slack or Teams chat: list them, get the votes per option, or close one, which posts the results to the chat and stops counting votes. Use it to summarize a poll's outcome."
+}
+
+// TierFor reports list and results as read-only.
+func (t *PollTool) TierFor(params map[string]any) int {
+	if strings.TrimSpace(GetString(params, "action", "list")) == "close" {
+		return TierWrite
+	}
+	return TierReadOnly
+}
+
+func (t *PollTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"action": map[string]any{
+				"type":        "string",
+				"description": "Action: list, results, or close.",
+				"enum":        []string{"list", "results", "close"},
+			},
+			"id": map[string]any{
+				"type":        "string",
+				"description": "Poll ID from action=list. Required for results and close.",
+			},
+		},
+		"required": []string{"action"},
+	}
+}
+
+func (t *PollTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	action := strings.TrimSpace(GetString(params, "action", "list"))
+	id := strings.TrimSpace(GetString(params, "id", ""))
+	switch action {
+	case "", "list":
+		if t.list == nil {
+			return "", fmt.Errorf("poll list unavailable")
+		}
+		polls, err := t.list(ctx)
+		if err != nil {
+			return "", err
+		}
+		if polls == nil {
+			polls = []PollView{}
+		}
+		return pollResult("list", map[string]any{"polls": polls})
+	case "results", "close":
+		if id == "" {
+			return "", fmt.Errorf("id is required for %s", action)
+		}
+		fn := t.results
+		if action == "close" {
+			fn = t.close
+		}
+		if fn == nil {
+			return "", fmt.Errorf("poll %s unavailable", action)
+		}
+		poll, err := fn(ctx, id)
+		if err != nil {
+			return "", err
+		}
+		return pollResult(action, map[string]any{"poll": poll})
+	default:
+		return "", fmt.Errorf("unknown action %q", action)
+	}
+}
+
+func pollResult(action string, body map[string]any) (string, error) {
+	body["status"] = "ok"
+	body["action"] = action
+	out, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
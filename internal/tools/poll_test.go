@@ -0,0 +1,41 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPollTool_Execute(t *testing.T) {
+	var closedID string
+	lunch := PollView{ID: "slack:1", Question: "Lunch?", Voters: 3, Options: []PollOptionView{{Label: "Sushi", Votes: 2, Percent: 66}}}
+	tool := NewPollTool(
+		func(ctx context.Context) ([]PollView, error) { return nil, nil },
+		func(ctx context.Context, id string) (PollView, error) { return lunch, nil },
+		func(ctx context.Context, id string) (PollView, error) {
+			closedID = id
+			p := lunch
+			p.Closed = true
+			return p, nil
+		},
+	)
+
+	out, err := tool.Execute(context.Background(), map[string]any{"action": "list"})
+	if err != nil || !strings.Contains(out, `"polls":[]`) {
+		t.Fatalf("unexpected empty list: %s err=%v", out, err)
+	}
+	out, err = tool.Execute(context.Background(), map[string]any{"action": "results", "id": "slack:1"})
+	if err != nil || !strings.Contains(out, `"label":"Sushi","votes":2,"percent":66`) {
+		t.Fatalf("unexpected results: %s err=%v", out, err)
+	}
+	if _, err := tool.Execute(context.Background(), map[string]any{"action": "close"}); err == nil {
+		t.Fatal("expected close without id to fail")
+	}
+	out, err = tool.Execute(context.Background(), map[string]any{"action": "close", "id": "slack:1"})
+	if err != nil || closedID != "slack:1" || !strings.Contains(out, `"closed":true`) {
+		t.Fatalf("unexpected close: %s err=%v id=%q", out, err, closedID)
+	}
+	if tool.TierFor(map[string]any{"action": "results"}) != TierReadOnly || tool.TierFor(map[string]any{"action": "close"}) != TierWrite {
+		t.Fatal("expected results read-only and close write")
+	}
+}
@@ -0,0 +1,160 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ScheduledMessageToolName is the name of the scheduled message tool.
+const ScheduledMessageToolName = "scheduled_message"
+
+// scheduleMaxAhead is how far ahead a message may be scheduled; Slack's
+// chat.scheduleMessage allows 120 days.
+const scheduleMaxAhead = 120 * 24 * time.Hour
+
+// ScheduledMessageView is a pending scheduled message of the current chat as
+// seen by the model.
+type ScheduledMessageView struct {
+	ID       string `json:"id"`
+	ThreadID string `json:"thread_id,omitempty"`
+	SendAt   string `json:"send_at"`
+	Preview  string `json:"preview,omitempty"`
+}
+
+// ScheduledMessageTool lets the agent schedule a message to the current
+// Slack or Teams chat for a later time, list the pending ones and cancel
+// them. The callbacks are scoped to that chat by the caller.
+type ScheduledMessageTool struct {
+	schedule func(ctx context.Context, content string, sendAt time.Time) error
+	list     func(ctx context.Context) ([]ScheduledMessageView, error)
+	cancel   func(ctx context.Context, id string) error
+}
+
+func NewScheduledMessageTool(
+	scheduleFn func(ctx context.Context, content string, sendAt time.Time) error,
+	listFn func(ctx context.Context) ([]ScheduledMessageView, error),
+	cancelFn func(ctx context.Context, id string) error,
+) *ScheduledMessageTool {
+	return &ScheduledMessageTool{schedule: scheduleFn, list: listFn, cancel: cancelFn}
+}
+
+func (t *ScheduledMessageTool) Name() string { return ScheduledMessageToolName }
+func (t *ScheduledMessageTool) Tier() int    { return TierWrite }
+func (t *ScheduledMessageTool) Description() string {
+	return "Schedule a message to the current Slack or Teams chat for a later time, list the messages still waiting to be sent, or cancel one. Use it for reminders and announcements that must go out at a given time."
+}
+
+// TierFor reports list as read-only.
+func (t *ScheduledMessageTool) TierFor(params map[string]any) int {
+	if strings.TrimSpace(GetString(params, "action", "list")) == "list" {
+		return TierReadOnly
+	}
+	return TierWrite
+}
+
+func (t *ScheduledMessageTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"action": map[string]any{
+				"type":        "string",
+				"description": "Action: schedule, list, or cancel.",
+				"enum":        []string{"schedule", "list", "cancel"},
+			},
+			"content": map[string]any{
+				"type":        "string",
+				"description": "When action=schedule, the message text.",
+			},
+			"send_at": map[string]any{
+				"type":        "string",
+				"description": "When action=schedule, the time to send as RFC 3339, e.g. 2026-03-06T09:00:00+01:00. At most 120 days ahead.",
+			},
+			"id": map[string]any{
+				"type":        "string",
+				"description": "Scheduled message ID from action=list. Required for cancel.",
+			},
+		},
+		"required": []string{"action"},
+	}
+}
+
+func (t *ScheduledMessageTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	action := strings.TrimSpace(GetString(params, "action", "list"))
+	switch action {
+	case "schedule":
+		content := strings.TrimSpace(GetString(params, "content", ""))
+		if content == "" {
+			return "", fmt.Errorf("content is required for schedule")
+		}
+		sendAt, err := parseScheduleTime(GetString(params, "send_at", ""), time.Now())
+		if err != nil {
+			return "", err
+		}
+		if t.schedule == nil {
+			return "", fmt.Errorf("scheduling unavailable")
+		}
+		if err := t.schedule(ctx, content, sendAt); err != nil {
+			return "", err
+		}
+		return scheduledResult("schedule", map[string]any{"send_at": sendAt.Format(time.RFC3339)})
+	case "", "list":
+		if t.list == nil {
+			return "", fmt.Errorf("scheduled message list unavailable")
+		}
+		items, err := t.list(ctx)
+		if err != nil {
+			return "", err
+		}
+		if items == nil {
+			items = []ScheduledMessageView{}
+		}
+		return scheduledResult("list", map[string]any{"scheduled": items})
+	case "cancel":
+		id := strings.TrimSpace(GetString(params, "id", ""))
+		if id == "" {
+			return "", fmt.Errorf("id is required for cancel")
+		}
+		if t.cancel == nil {
+			return "", fmt.Errorf("scheduled message cancel unavailable")
+		}
+		if err := t.cancel(ctx, id); err != nil {
+			return "", err
+		}
+		return scheduledResult("cancel", map[string]any{"id": id})
+	default:
+		return "", fmt.Errorf("unknown action %q", action)
+	}
+}
+
+// parseScheduleTime reads an RFC 3339 send time that lies in the future and
+// within scheduleMaxAhead.
+func parseScheduleTime(raw string, now time.Time) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, fmt.Errorf("send_at is required for schedule")
+	}
+	at, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("send_at must be an RFC 3339 time")
+	}
+	if !at.After(now) {
+		return time.Time{}, fmt.Errorf("send_at must be in the future")
+	}
+	if at.Sub(now) > scheduleMaxAhead {
+		return time.Time{}, fmt.Errorf("send_at must be within 120 days")
+	}
+	return at, nil
+}
+
+func scheduledResult(action string, body map[string]any) (string, error) {
+	body["status"] = "ok"
+	body["action"] = action
+	out, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
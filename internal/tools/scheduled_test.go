@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScheduledMessageTool_Execute(t *testing.T) {
+	var scheduled time.Time
+	var cancelled string
+	tool := NewScheduledMessageTool(
+		func(ctx context.Context, content string, sendAt time.Time) error {
+			scheduled = sendAt
+			return nil
+		},
+		func(ctx context.Context) ([]ScheduledMessageView, error) {
+			return []ScheduledMessageView{{ID: "s1", SendAt: "2030-01-01T09:00:00Z", Preview: "standup"}}, nil
+		},
+		func(ctx context.Context, id string) error {
+			cancelled = id
+			return nil
+		},
+	)
+
+	at := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	out, err := tool.Execute(context.Background(), map[string]any{"action": "schedule", "content": "standup", "send_at": at.Format(time.RFC3339)})
+	if err != nil || !scheduled.Equal(at) || !strings.Contains(out, `"action":"schedule"`) {
+		t.Fatalf("unexpected schedule: %s err=%v at=%v", out, err, scheduled)
+	}
+	for _, bad := range []map[string]any{
+		{"action": "schedule", "send_at": at.Format(time.RFC3339)},
+		{"action": "schedule", "content": "x", "send_at": "tomorrow"},
+		{"action": "schedule", "content": "x", "send_at": time.Now().Add(-time.Hour).Format(time.RFC3339)},
+		{"action": "schedule", "content": "x", "send_at": time.Now().Add(200 * 24 * time.Hour).Format(time.RFC3339)},
+		{"action": "cancel"},
+	} {
+		if _, err := tool.Execute(context.Background(), bad); err == nil {
+			t.Fatalf("expected %v to fail", bad)
+		}
+	}
+	out, err = tool.Execute(context.Background(), map[string]any{"action": "list"})
+	if err != nil || !strings.Contains(out, `"id":"s1"`) {
+		t.Fatalf("unexpected list: %s err=%v", out, err)
+	}
+	if _, err := tool.Execute(context.Background(), map[string]any{"action": "cancel", "id": "s1"}); err != nil || cancelled != "s1" {
+		t.Fatalf("unexpected cancel: err=%v id=%q", err, cancelled)
+	}
+	if tool.TierFor(map[string]any{"action": "list"}) != TierReadOnly || tool.TierFor(map[string]any{"action": "schedule"}) != TierWrite {
+		t.Fatal("expected list read-only and schedule write")
+	}
+}
@@ -0,0 +1,132 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Schema describes one registered tool for clients outside the agent loop:
+// the dashboard, external integrators and generated docs. TierByArgs is set
+// when the tier depends on the call arguments; Tier is then the worst case.
+type Schema struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Tier        int            `json:"tier"`
+	TierByArgs  bool           `json:"tier_by_args,omitempty"`
+	Parameters  map[string]any `json:"parameters"`
+}
+
+// Schemas returns the schema of every registered tool, sorted by name.
+func (r *Registry) Schemas() []Schema {
+	out := make([]Schema, 0, len(r.tools))
+	for _, tool := range r.tools {
+		_, byArgs := tool.(ArgTieredTool)
+		out = append(out, Schema{
+			Name:        tool.Name(),
+			Description: tool.Description(),
+			Tier:        ToolTier(tool),
+			TierByArgs:  byArgs,
+			Parameters:  tool.Parameters(),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// tierLabel names a risk tier for documentation.
+func tierLabel(tier int) string {
+	switch tier {
+	case TierReadOnly:
+		return "read-only"
+	case TierWrite:
+		return "write"
+	case TierHighRisk:
+		return "high-risk (approval)"
+	default:
+		return fmt.Sprintf("tier %d", tier)
+	}
+}
+
+// RenderSchemasMarkdown renders tool schemas as a markdown reference: one
+// section per tool with its tier and a parameter table.
+func RenderSchemasMarkdown(schemas []Schema) string {
+	var b strings.Builder
+	b.WriteString("# Agent Tools\n")
+	for _, s := range schemas {
+		fmt.Fprintf(&b, "\n## `%s`\n\n", s.Name)
+		tier := fmt.Sprintf("%d (%s)", s.Tier, tierLabel(s.Tier))
+		if s.TierByArgs {
+			tier += ", lower for read-only arguments"
+		}
+		fmt.Fprintf(&b, "Tier: %s\n\n", tier)
+		if desc := strings.TrimSpace(s.Description); desc != "" {
+			b.WriteString(desc + "\n\n")
+		}
+
+		props, _ := s.Parameters["properties"].(map[string]any)
+		if len(props) == 0 {
+			b.WriteString("No parameters.\n")
+			continue
+		}
+		required := map[string]bool{}
+		switch req := s.Parameters["required"].(type) {
+		case []string:
+			for _, n := range req {
+				required[n] = true
+			}
+		case []any:
+			for _, n := range req {
+				if name, ok := n.(string); ok {
+					required[name] = true
+				}
+			}
+		}
+		names := make([]string, 0, len(props))
+		for n := range props {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+
+		b.WriteString("| Parameter | Type | Required | Description |\n")
+		b.WriteString("|-----------|------|----------|-------------|\n")
+		for _, n := range names {
+			prop, _ := props[n].(map[string]any)
+			typ := schemaType(prop)
+			desc, _ := prop["description"].(string)
+			if enum, ok := prop["enum"]; ok {
+				raw, _ := json.Marshal(enum)
+				desc = strings.TrimSpace(desc + " One of " + string(raw) + ".")
+			}
+			req := ""
+			if required[n] {
+				req = "yes"
+			}
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", n, typ, req, markdownCell(desc))
+		}
+	}
+	return b.String()
+}
+
+// schemaType renders a JSON Schema property type, including array item types.
+func schemaType(prop map[string]any) string {
+	typ, _ := prop["type"].(string)
+	if typ == "" {
+		return "any"
+	}
+	if typ == "array" {
+		if items, ok := prop["items"].(map[string]any); ok {
+			if it, _ := items["type"].(string); it != "" {
+				return it + "[]"
+			}
+		}
+	}
+	return typ
+}
+
+// markdownCell keeps a description on one table row.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	return strings.ReplaceAll(s, "|", "\\|")
+}
@@ -0,0 +1,30 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistrySchemas(t *testing.T) {
+	r := NewRegistry()
+	r.Register(NewWriteFileTool(nil))
+	r.Register(NewReadFileTool())
+
+	schemas := r.Schemas()
+	if len(schemas) != 2 || schemas[0].Name != "read_file" || schemas[1].Name != "write_file" {
+		t.Fatalf("expected schemas sorted by name, got %+v", schemas)
+	}
+	if schemas[0].Tier != TierReadOnly || schemas[1].Tier != ToolTier(NewWriteFileTool(nil)) {
+		t.Fatalf("unexpected tiers: %+v", schemas)
+	}
+	if schemas[0].Parameters["type"] != "object" {
+		t.Fatalf("expected JSON schema parameters, got %+v", schemas[0].Parameters)
+	}
+
+	md := RenderSchemasMarkdown(schemas)
+	for _, want := range []string{"## `read_file`", "Tier: 0 (read-only)", "| `path` | string | yes |", "| `content` | string | yes |"} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("markdown missing %q:\n%s", want, md)
+		}
+	}
+}
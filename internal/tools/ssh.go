@@ -0,0 +1,542 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"github.com/KafClaw/KafClaw/internal/secrets"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SSHToolName is the registry name of the ssh tool. The policy engine keys
+// its per-host rules on it.
+const SSHToolName = "ssh"
+
+// maxSSHOutput caps each captured stream of a remote command.
+const maxSSHOutput = 1 << 20
+
+// sshReadOnlyCommand is a remote command that only inspects the host. Its
+// flags must be listed; operands (paths, units, resources) are allowed
+// unless noOperands is set.
+type sshReadOnlyCommand struct {
+	short      string   // single-letter flags, combinable as in "-lah"
+	long       []string // long flags, also accepted as --flag=value
+	noOperands bool
+	anyFlags   bool // every flag is read-only (ps)
+}
+
+// sshReadOnlyCommands is the tier 0 allowlist, keyed by the command words.
+// Flags that write files, run helpers or follow output (git --output and
+// --ext-diff, journalctl --vacuum-*, tail -f) are deliberately missing.
+var sshReadOnlyCommands = map[string]sshReadOnlyCommand{
+	"ls":     {short: "lahtrRdS1ifF"},
+	"cat":    {short: "nAbsvET"},
+	"head":   {short: "ncqv"},
+	"tail":   {short: "ncqv"},
+	"grep":   {short: "inrcvlLEFwHhoABCemsIqxz"},
+	"wc":     {short: "lcwmL"},
+	"stat":   {short: "cfLt"},
+	"df":     {short: "hTiaPklx", long: []string{"--human-readable", "--output"}},
+	"du":     {short: "shcdaxkm", long: []string{"--max-depth", "--summarize", "--human-readable"}},
+	"free":   {short: "hmgbktw", noOperands: true},
+	"uptime": {short: "ps", noOperands: true},
+	"uname":  {short: "armsnovpi", noOperands: true},
+	"whoami": {noOperands: true},
+	"id":     {short: "ugnGr"},
+	"pwd":    {noOperands: true},
+	"ps":     {anyFlags: true},
+
+	"systemctl status":     {short: "lanq", long: []string{"--no-pager", "--full", "--all", "--lines"}},
+	"systemctl is-active":  {short: "q", long: []string{"--quiet"}},
+	"systemctl is-enabled": {short: "q", long: []string{"--quiet"}},
+	"systemctl list-units": {short: "alt", long: []string{"--no-pager", "--all", "--type", "--state", "--failed", "--plain", "--no-legend", "--full"}},
+	"journalctl": {short: "unbkpoerx", long: []string{"--unit", "--lines", "--since", "--until", "--priority", "--output",
+		"--no-pager", "--boot", "--dmesg", "--reverse", "--utc", "--identifier", "--grep", "--case-sensitive"}},
+
+	"docker ps":      {short: "aqnls", long: []string{"--all", "--quiet", "--no-trunc", "--last", "--latest", "--size", "--filter"}},
+	"docker logs":    {short: "nt", long: []string{"--tail", "--since", "--until", "--timestamps", "--details"}},
+	"docker inspect": {short: "s", long: []string{"--type", "--size"}},
+	"docker images":  {short: "aq", long: []string{"--all", "--quiet", "--no-trunc", "--digests", "--filter"}},
+	"docker stats":   {short: "a", long: []string{"--no-stream", "--no-trunc", "--all"}},
+
+	"kubectl get": {short: "nAolL", long: []string{"--namespace", "--all-namespaces", "--output", "--selector", "--show-labels",
+		"--no-headers", "--field-selector", "--context", "--label-columns", "--sort-by", "--chunk-size"}},
+	"kubectl describe": {short: "nAl", long: []string{"--namespace", "--all-namespaces", "--selector", "--context", "--show-events"}},
+	"kubectl logs": {short: "nlcp", long: []string{"--namespace", "--selector", "--container", "--previous", "--tail", "--since",
+		"--since-time", "--timestamps", "--all-containers", "--prefix", "--context", "--limit-bytes"}},
+	"kubectl top": {short: "nAl", long: []string{"--namespace", "--all-namespaces", "--selector", "--containers", "--context",
+		"--sort-by", "--no-headers"}},
+
+	"git status": {short: "sbuz", long: []string{"--short", "--branch", "--porcelain", "--untracked-files", "--ignored"}},
+	"git log": {short: "npz", long: []string{"--oneline", "--max-count", "--stat", "--name-only", "--name-status", "--patch",
+		"--since", "--until", "--author", "--graph", "--decorate", "--pretty", "--format", "--no-color", "--all", "--reverse",
+		"--first-parent", "--merges", "--no-merges", "--abbrev-commit", "--date", "--grep"}},
+	"git diff": {short: "pUwz", long: []string{"--stat", "--name-only", "--name-status", "--cached", "--staged", "--no-color",
+		"--shortstat", "--numstat", "--patch", "--unified", "--word-diff", "--ignore-all-space"}},
+	"git show": {short: "psz", long: []string{"--stat", "--name-only", "--name-status", "--no-color", "--oneline", "--pretty",
+		"--format", "--no-patch", "--abbrev-commit", "--patch"}},
+}
+
+// sshCommandChars are the only characters a tier 0 command may contain:
+// no quoting, expansion, globbing, redirection or chaining.
+var sshCommandChars = regexp.MustCompile(`^[A-Za-z0-9._/:@=,+% -]*$`)
+
+// SSHHost is a registered remote host.
+type SSHHost struct {
+	config.SSHHostConfig
+	deny []*regexp.Regexp
+}
+
+// SSHAuditRecord describes one command the ssh tool ran or refused.
+type SSHAuditRecord struct {
+	Host        string
+	Address     string
+	User        string
+	Command     string
+	Tier        int
+	ExitCode    int // -1 when the command did not run to completion
+	Duration    time.Duration
+	OutputBytes int
+	Error       string
+}
+
+// SSHTool runs commands on registered remote hosts over SSH.
+type SSHTool struct {
+	timeout        time.Duration
+	knownHostsFile string
+	hosts          map[string]*SSHHost
+	globalDeny     []*regexp.Regexp
+	audit          func(SSHAuditRecord)
+	// resolve turns credential references into secrets.
+	resolve func(ref string) (string, error)
+}
+
+// NewSSHTool creates the ssh tool from the host registry in cfg. audit, if
+// set, is called for every command the tool runs or refuses.
+func NewSSHTool(cfg config.SSHToolConfig, audit func(SSHAuditRecord)) (*SSHTool, error) {
+	t := &SSHTool{
+		timeout:        cfg.Timeout,
+		knownHostsFile: cfg.KnownHostsFile,
+		hosts:          make(map[string]*SSHHost, len(cfg.Hosts)),
+		audit:          audit,
+		resolve:        secrets.Resolve,
+	}
+	if t.timeout <= 0 {
+		t.timeout = 60 * time.Second
+	}
+	if t.knownHostsFile == "" {
+		t.knownHostsFile = "~/.ssh/known_hosts"
+	}
+	t.globalDeny = append(t.globalDeny, destructiveRMRootRegex)
+	for _, pattern := range DenyPatterns {
+		if re, err := regexp.Compile(pattern); err == nil {
+			t.globalDeny = append(t.globalDeny, re)
+		}
+	}
+
+	for _, hc := range cfg.Hosts {
+		alias := strings.TrimSpace(hc.Alias)
+		if alias == "" || strings.TrimSpace(hc.Address) == "" || strings.TrimSpace(hc.User) == "" {
+			return nil, fmt.Errorf("ssh host %q: alias, address and user are required", hc.Alias)
+		}
+		if _, dup := t.hosts[alias]; dup {
+			return nil, fmt.Errorf("ssh host %q registered twice", alias)
+		}
+		if hc.MaxTier < TierReadOnly || hc.MaxTier > TierHighRisk {
+			return nil, fmt.Errorf("ssh host %q: maxTier must be 0-2", alias)
+		}
+		deny, err := CompileSSHDenyPatterns(hc.DenyPatterns)
+		if err != nil {
+			return nil, fmt.Errorf("ssh host %q: %w", alias, err)
+		}
+		hc.Alias = alias
+		t.hosts[alias] = &SSHHost{SSHHostConfig: hc, deny: deny}
+	}
+	for alias, h := range t.hosts {
+		seen := map[string]bool{alias: true}
+		for jump := h.JumpHost; jump != ""; jump = t.hosts[jump].JumpHost {
+			if _, ok := t.hosts[jump]; !ok {
+				return nil, fmt.Errorf("ssh host %q: unknown jump host %q", alias, jump)
+			}
+			if seen[jump] {
+				return nil, fmt.Errorf("ssh host %q: jump host loop via %q", alias, jump)
+			}
+			seen[jump] = true
+		}
+	}
+	return t, nil
+}
+
+// CompileSSHDenyPatterns compiles per-host deny patterns. Matching is
+// case-insensitive.
+func CompileSSHDenyPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	out := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid deny pattern %q: %w", pattern, err)
+		}
+		out = append(out, re)
+	}
+	return out, nil
+}
+
+func (t *SSHTool) Name() string { return SSHToolName }
+func (t *SSHTool) Tier() int    { return TierHighRisk }
+
+// TierFor classifies a call by its command: read-only inspection is tier 0,
+// anything else tier 2.
+func (t *SSHTool) TierFor(params map[string]any) int {
+	return t.commandTier(GetString(params, "command", ""))
+}
+
+func (t *SSHTool) Description() string {
+	return "Run a command on a registered remote host over SSH and return its output. Hosts: " + strings.Join(t.Aliases(), ", ")
+}
+
+func (t *SSHTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"host": map[string]any{
+				"type":        "string",
+				"description": "Alias of the registered host",
+				"enum":        t.Aliases(),
+			},
+			"command": map[string]any{
+				"type":        "string",
+				"description": "The command to run on the host",
+			},
+		},
+		"required": []string{"host", "command"},
+	}
+}
+
+// Aliases returns the registered host aliases in sorted order.
+func (t *SSHTool) Aliases() []string {
+	out := make([]string, 0, len(t.hosts))
+	for alias := range t.hosts {
+		out = append(out, alias)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (t *SSHTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	alias := strings.TrimSpace(GetString(params, "host", ""))
+	command := strings.TrimSpace(GetString(params, "command", ""))
+	if alias == "" || command == "" {
+		return "Error: host and command are required", nil
+	}
+	h, ok := t.hosts[alias]
+	if !ok {
+		return fmt.Sprintf("Error: unknown host %q (registered: %s)", alias, strings.Join(t.Aliases(), ", ")), nil
+	}
+	rec := SSHAuditRecord{
+		Host:     alias,
+		Address:  h.Address,
+		User:     h.User,
+		Command:  command,
+		Tier:     t.commandTier(command),
+		ExitCode: -1,
+	}
+	if t.denied(h, command) {
+		rec.Error = "denied"
+		t.record(rec)
+		return fmt.Sprintf("Error: command denied for host %s", alias), nil
+	}
+	if rec.Tier > h.MaxTier {
+		rec.Error = fmt.Sprintf("tier %d above host max %d", rec.Tier, h.MaxTier)
+		t.record(rec)
+		return fmt.Sprintf("Error: tier %d command not allowed on host %s (max tier %d)", rec.Tier, alias, h.MaxTier), nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	start := time.Now()
+	stdout, stderr, exitCode, err := t.run(ctx, h, command)
+	rec.Duration = time.Since(start)
+	rec.OutputBytes = stdout.total + stderr.total
+	rec.ExitCode = exitCode
+
+	var result strings.Builder
+	result.WriteString(stdout.String())
+	if stderr.Len() > 0 {
+		if result.Len() > 0 {
+			result.WriteString("\n")
+		}
+		result.WriteString("STDERR:\n")
+		result.WriteString(stderr.String())
+	}
+	if stdout.total+stderr.total > stdout.Len()+stderr.Len() {
+		result.WriteString(fmt.Sprintf("\n(output truncated, %d bytes total)", stdout.total+stderr.total))
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		rec.Error = "timeout"
+		t.record(rec)
+		return fmt.Sprintf("Error: command timed out after %v\n%s", t.timeout, result.String()), nil
+	}
+	if err != nil {
+		rec.Error = err.Error()
+		t.record(rec)
+		return fmt.Sprintf("Error executing command on %s: %v", alias, err), nil
+	}
+	t.record(rec)
+	if exitCode != 0 {
+		result.WriteString(fmt.Sprintf("\nExit code: %d", exitCode))
+	}
+	if result.Len() == 0 {
+		return "(no output)", nil
+	}
+	return result.String(), nil
+}
+
+func (t *SSHTool) record(rec SSHAuditRecord) {
+	if t.audit != nil {
+		t.audit(rec)
+	}
+}
+
+func (t *SSHTool) commandTier(command string) int {
+	if sshReadOnly(command) {
+		return TierReadOnly
+	}
+	return TierHighRisk
+}
+
+// sshReadOnly reports whether command is on the read-only allowlist with
+// only allowed flags and plain operands.
+func sshReadOnly(command string) bool {
+	if !sshCommandChars.MatchString(command) {
+		return false
+	}
+	words := strings.Fields(command)
+	if len(words) == 0 {
+		return false
+	}
+	spec, ok := sshReadOnlyCommand{}, false
+	args := words[1:]
+	if len(words) > 1 {
+		spec, ok = sshReadOnlyCommands[words[0]+" "+words[1]]
+		args = words[2:]
+	}
+	if !ok {
+		if spec, ok = sshReadOnlyCommands[words[0]]; !ok {
+			return false
+		}
+		args = words[1:]
+	}
+	for _, arg := range args {
+		switch {
+		case !strings.HasPrefix(arg, "-"):
+			if spec.noOperands {
+				return false
+			}
+		case spec.anyFlags:
+		case strings.HasPrefix(arg, "--"):
+			name, _, _ := strings.Cut(arg, "=")
+			if !slices.Contains(spec.long, name) {
+				return false
+			}
+		default:
+			// Short flags may be combined and carry a numeric value
+			// ("-lah", "-n20", "-20").
+			for _, c := range arg[1:] {
+				if (c < '0' || c > '9') && !strings.ContainsRune(spec.short, c) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+func (t *SSHTool) denied(h *SSHHost, command string) bool {
+	normalized := strings.ToLower(command)
+	for _, re := range t.globalDeny {
+		if re.MatchString(normalized) {
+			return true
+		}
+	}
+	for _, re := range h.deny {
+		if re.MatchString(command) {
+			return true
+		}
+	}
+	return false
+}
+
+// run executes command on h and returns its captured output and exit code.
+// A command that exits non-zero is not an error.
+func (t *SSHTool) run(ctx context.Context, h *SSHHost, command string) (*cappedBuffer, *cappedBuffer, int, error) {
+	stdout, stderr := &cappedBuffer{max: maxSSHOutput}, &cappedBuffer{max: maxSSHOutput}
+	client, closeAll, err := t.dial(ctx, h)
+	if err != nil {
+		return stdout, stderr, -1, err
+	}
+	defer closeAll()
+	session, err := client.NewSession()
+	if err != nil {
+		return stdout, stderr, -1, fmt.Errorf("open session: %w", err)
+	}
+	defer session.Close()
+	session.Stdout = stdout
+	session.Stderr = stderr
+
+	done := make(chan error, 1)
+	go func() { done <- session.Run(command) }()
+	select {
+	case <-ctx.Done():
+		_ = session.Signal(ssh.SIGKILL)
+		closeAll()
+		return stdout, stderr, -1, ctx.Err()
+	case err = <-done:
+	}
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return stdout, stderr, exitErr.ExitStatus(), nil
+	}
+	if err != nil {
+		return stdout, stderr, -1, err
+	}
+	return stdout, stderr, 0, nil
+}
+
+// dial connects to h, tunnelling through its jump host chain. The returned
+// func closes every client of the chain.
+func (t *SSHTool) dial(ctx context.Context, h *SSHHost) (*ssh.Client, func(), error) {
+	cfg, err := t.clientConfig(h)
+	if err != nil {
+		return nil, nil, err
+	}
+	addr := sshAddress(h.Address)
+
+	var conn net.Conn
+	closeJump := func() {}
+	if h.JumpHost == "" {
+		d := net.Dialer{Timeout: t.timeout}
+		conn, err = d.DialContext(ctx, "tcp", addr)
+	} else {
+		var jump *ssh.Client
+		jump, closeJump, err = t.dial(ctx, t.hosts[h.JumpHost])
+		if err != nil {
+			return nil, nil, fmt.Errorf("jump host %s: %w", h.JumpHost, err)
+		}
+		conn, err = jump.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		closeJump()
+		return nil, nil, fmt.Errorf("connect %s: %w", addr, err)
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, cfg)
+	if err != nil {
+		conn.Close()
+		closeJump()
+		return nil, nil, fmt.Errorf("handshake %s: %w", addr, err)
+	}
+	client := ssh.NewClient(c, chans, reqs)
+	return client, func() {
+		client.Close()
+		closeJump()
+	}, nil
+}
+
+// clientConfig resolves the credentials and host key check of h.
+func (t *SSHTool) clientConfig(h *SSHHost) (*ssh.ClientConfig, error) {
+	var auth []ssh.AuthMethod
+	if h.KeyRef != "" {
+		key, err := t.resolve(h.KeyRef)
+		if err != nil {
+			return nil, fmt.Errorf("ssh key of %s: %w", h.Alias, err)
+		}
+		var signer ssh.Signer
+		if h.PassphraseRef != "" {
+			passphrase, perr := t.resolve(h.PassphraseRef)
+			if perr != nil {
+				return nil, fmt.Errorf("ssh key passphrase of %s: %w", h.Alias, perr)
+			}
+			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(key), []byte(passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey([]byte(key))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ssh key of %s: %w", h.Alias, err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if h.PasswordRef != "" {
+		password, err := t.resolve(h.PasswordRef)
+		if err != nil {
+			return nil, fmt.Errorf("ssh password of %s: %w", h.Alias, err)
+		}
+		auth = append(auth, ssh.Password(password))
+	}
+	if len(auth) == 0 {
+		return nil, fmt.Errorf("ssh host %s has no credentials (keyRef or passwordRef)", h.Alias)
+	}
+
+	var hostKeyCallback ssh.HostKeyCallback
+	if h.HostKey != "" {
+		pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(h.HostKey))
+		if err != nil {
+			return nil, fmt.Errorf("host key of %s: %w", h.Alias, err)
+		}
+		hostKeyCallback = ssh.FixedHostKey(pub)
+	} else {
+		cb, err := knownhosts.New(expandPath(t.knownHostsFile))
+		if err != nil {
+			return nil, fmt.Errorf("known_hosts: %w", err)
+		}
+		hostKeyCallback = cb
+	}
+	return &ssh.ClientConfig{
+		User:            h.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         t.timeout,
+	}, nil
+}
+
+// sshAddress adds the default port to addresses without one.
+func sshAddress(address string) string {
+	address = strings.TrimSpace(address)
+	if _, _, err := net.SplitHostPort(address); err == nil {
+		return address
+	}
+	return net.JoinHostPort(address, "22")
+}
+
+// cappedBuffer keeps the first max bytes written to it and counts the rest.
+// It wraps rather than embeds bytes.Buffer so io.Copy cannot bypass Write
+// through the promoted ReadFrom.
+type cappedBuffer struct {
+	buf   bytes.Buffer
+	max   int
+	total int
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	b.total += n
+	if room := b.max - b.buf.Len(); room > 0 {
+		if n > room {
+			p = p[:room]
+		}
+		b.buf.Write(p)
+	}
+	return n, nil
+}
+
+func (b *cappedBuffer) Len() int       { return b.buf.Len() }
+func (b *cappedBuffer) String() string { return b.buf.String() }
@@ -0,0 +1,243 @@
+package tools
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/config"
+	"golang.org/x/crypto/ssh"
+)
+
+// startTestSSHServer serves exec and direct-tcpip requests for clientKey.
+// "uptime" prints a line, "false" exits 1; every command is recorded.
+func startTestSSHServer(t *testing.T, clientKey ssh.PublicKey) (string, ssh.PublicKey, *[]string) {
+	t.Helper()
+	_, hostPriv, _ := ed25519.GenerateKey(rand.Reader)
+	hostSigner, err := ssh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) == string(clientKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, io.EOF
+		},
+	}
+	cfg.AddHostKey(hostSigner)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	var mu sync.Mutex
+	commands := &[]string{}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(conn, cfg)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for nc := range chans {
+					switch nc.ChannelType() {
+					case "direct-tcpip":
+						var target struct {
+							Host       string
+							Port       uint32
+							OriginHost string
+							OriginPort uint32
+						}
+						_ = ssh.Unmarshal(nc.ExtraData(), &target)
+						up, err := net.Dial("tcp", net.JoinHostPort(target.Host, strconv.FormatUint(uint64(target.Port), 10)))
+						if err != nil {
+							nc.Reject(ssh.ConnectionFailed, err.Error())
+							continue
+						}
+						ch, creqs, _ := nc.Accept()
+						go ssh.DiscardRequests(creqs)
+						go func() { io.Copy(ch, up); ch.Close() }()
+						go func() { io.Copy(up, ch); up.Close() }()
+					case "session":
+						ch, creqs, _ := nc.Accept()
+						go func() {
+							for req := range creqs {
+								if req.Type != "exec" {
+									req.Reply(false, nil)
+									continue
+								}
+								var payload struct{ Command string }
+								_ = ssh.Unmarshal(req.Payload, &payload)
+								req.Reply(true, nil)
+								mu.Lock()
+								*commands = append(*commands, payload.Command)
+								mu.Unlock()
+								status := uint32(0)
+								switch payload.Command {
+								case "uptime":
+									io.WriteString(ch, "up 3 days\n")
+								case "false":
+									status = 1
+								}
+								b := make([]byte, 4)
+								binary.BigEndian.PutUint32(b, status)
+								ch.SendRequest("exit-status", false, b)
+								ch.Close()
+								return
+							}
+						}()
+					default:
+						nc.Reject(ssh.UnknownChannelType, "unsupported")
+					}
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String(), hostSigner.PublicKey(), commands
+}
+
+func TestSSHToolRunsCommandsThroughJumpHost(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	clientPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	addr, hostKey, commands := startTestSSHServer(t, clientPub)
+	pinned := string(ssh.MarshalAuthorizedKey(hostKey))
+
+	var audits []SSHAuditRecord
+	tool, err := NewSSHTool(config.SSHToolConfig{Hosts: []config.SSHHostConfig{
+		{Alias: "bastion", Address: addr, User: "ops", KeyRef: "file:" + keyFile, HostKey: pinned},
+		{Alias: "web", Address: addr, User: "ops", KeyRef: "file:" + keyFile, HostKey: pinned, JumpHost: "bastion", MaxTier: TierHighRisk, DenyPatterns: []string{`\bdrop\b`}},
+	}}, func(r SSHAuditRecord) { audits = append(audits, r) })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, _ := tool.Execute(context.Background(), map[string]any{"host": "web", "command": "uptime"})
+	if out != "up 3 days\n" {
+		t.Fatalf("unexpected output: %q", out)
+	}
+	out, _ = tool.Execute(context.Background(), map[string]any{"host": "web", "command": "false"})
+	if !strings.Contains(out, "Exit code: 1") {
+		t.Fatalf("expected exit code in output, got %q", out)
+	}
+	out, _ = tool.Execute(context.Background(), map[string]any{"host": "web", "command": "psql -c 'DROP TABLE users'"})
+	if !strings.Contains(out, "denied") {
+		t.Fatalf("expected host deny pattern to refuse the command, got %q", out)
+	}
+	out, _ = tool.Execute(context.Background(), map[string]any{"host": "bastion", "command": "touch /tmp/x"})
+	if !strings.Contains(out, "max tier 0") {
+		t.Fatalf("expected tier limit on read-only host, got %q", out)
+	}
+
+	if len(*commands) != 2 {
+		t.Fatalf("only allowed commands may reach the server, got %v", *commands)
+	}
+	if len(audits) != 4 || audits[0].ExitCode != 0 || audits[0].OutputBytes != 10 || audits[1].ExitCode != 1 ||
+		audits[2].Error != "denied" || !strings.HasPrefix(audits[3].Error, "tier 2") {
+		t.Fatalf("unexpected audit records: %+v", audits)
+	}
+}
+
+func TestSSHToolTiersAndValidation(t *testing.T) {
+	tool, err := NewSSHTool(config.SSHToolConfig{Hosts: []config.SSHHostConfig{{Alias: "db", Address: "db.internal", User: "ops"}}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for cmd, want := range map[string]int{
+		"df -h":                                     TierReadOnly,
+		"systemctl status nginx":                    TierReadOnly,
+		"ps aux | grep nginx":                       TierHighRisk,
+		"cat /etc/passwd; rm -f x":                  TierHighRisk,
+		"echo $(id)":                                TierHighRisk,
+		"apt-get upgrade -y":                        TierHighRisk,
+		"ls -lah /var/log":                          TierReadOnly,
+		"tail -n 50 /var/log/syslog":                TierReadOnly,
+		"kubectl get pods -n kube-system -o wide":   TierReadOnly,
+		"git log --oneline -20":                     TierReadOnly,
+		"journalctl -u nginx --since=1h --no-pager": TierReadOnly,
+	} {
+		if got := ToolTierFor(tool, map[string]any{"host": "db", "command": cmd}); got != want {
+			t.Errorf("tier of %q = %d, want %d", cmd, got, want)
+		}
+	}
+	// Allowlisted commands with mutating arguments, chaining or shell syntax.
+	for _, cmd := range []string{
+		"env rm -rf /tmp/x",
+		"printenv",
+		"date -s 2020-01-01",
+		"hostname evil",
+		"git diff --output=/etc/cron.d/x",
+		"git log --output /root/.bashrc",
+		"git diff --ext-diff",
+		"journalctl --vacuum-time=1s",
+		"journalctl --rotate",
+		"tail -f /var/log/syslog",
+		"docker run alpine",
+		"kubectl get pods --watch",
+		"kubectl delete pod x",
+		"systemctl restart nginx",
+		"cat /etc/passwd && rm -f x",
+		"cat /etc/passwd || rm -f x",
+		"ls; reboot",
+		"ls | sh",
+		"cat `id`",
+		"cat $(id)",
+		"cat $HOME/.ssh/id_rsa > /tmp/k",
+		"ls\nreboot",
+		"ls\rreboot",
+		"cat 'a b'",
+		"cat \\x",
+		"ls *",
+		"uptime now",
+		"whoami --help",
+		"ls --",
+	} {
+		if got := ToolTierFor(tool, map[string]any{"host": "db", "command": cmd}); got != TierHighRisk {
+			t.Errorf("tier of %q = %d, want %d", cmd, got, TierHighRisk)
+		}
+	}
+
+	if sshAddress("db.internal") != "db.internal:22" || sshAddress("10.0.0.1:2222") != "10.0.0.1:2222" {
+		t.Fatal("unexpected default port handling")
+	}
+
+	for name, hosts := range map[string][]config.SSHHostConfig{
+		"unknown jump": {{Alias: "a", Address: "a", User: "u", JumpHost: "b"}},
+		"jump loop":    {{Alias: "a", Address: "a", User: "u", JumpHost: "b"}, {Alias: "b", Address: "b", User: "u", JumpHost: "a"}},
+		"duplicate":    {{Alias: "a", Address: "a", User: "u"}, {Alias: "a", Address: "b", User: "u"}},
+		"bad pattern":  {{Alias: "a", Address: "a", User: "u", DenyPatterns: []string{"("}}},
+		"missing user": {{Alias: "a", Address: "a"}},
+	} {
+		if _, err := NewSSHTool(config.SSHToolConfig{Hosts: hosts}, nil); err == nil {
+			t.Errorf("%s: expected registry error", name)
+		}
+	}
+}
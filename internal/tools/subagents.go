@@ -45,6 +45,9 @@ type SubagentRunView struct {
 	StartedAt       *time.Time `json:"startedAt,omitempty"`
 	EndedAt         *time.Time `json:"endedAt,omitempty"`
 	Error           string     `json:"error,omitempty"`
+	CurrentStep     string     `json:"currentStep,omitempty"`
+	Iterations      int        `json:"iterations,omitempty"`
+	TokensUsed      int        `json:"tokensUsed,omitempty"`
 }
 
 type AgentDiscovery struct {
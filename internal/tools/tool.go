@@ -28,6 +28,14 @@ type TieredTool interface {
 	Tier() int
 }
 
+// ArgTieredTool is an optional interface for tools whose risk tier depends
+// on the call arguments (e.g. a read-only versus a mutating remote command).
+// Tier() still reports the worst case.
+type ArgTieredTool interface {
+	TieredTool
+	TierFor(params map[string]any) int
+}
+
 // Risk tier constants.
 const (
 	TierReadOnly = 0 // Read-only internal tools
@@ -45,6 +53,15 @@ func ToolTier(t Tool) int {
 	return TierReadOnly
 }
 
+// ToolTierFor returns the risk tier of one call of a tool. Tools implementing
+// ArgTieredTool are asked for the tier of params; others fall back to ToolTier.
+func ToolTierFor(t Tool, params map[string]any) int {
+	if at, ok := t.(ArgTieredTool); ok {
+		return at.TierFor(params)
+	}
+	return ToolTier(t)
+}
+
 // DefaultToolNames returns the names of tools that are registered by default
 // in the agent loop. Used for identity announcements when a full registry is
 // not available (e.g. group manager startup).
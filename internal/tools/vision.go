@@ -0,0 +1,105 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/KafClaw/KafClaw/internal/memory"
+	"github.com/KafClaw/KafClaw/internal/provider"
+)
+
+// AnalyzeImageTool describes an inbound image with a vision-capable provider
+// and indexes the result into semantic memory.
+type AnalyzeImageTool struct {
+	vision     provider.VisionProvider
+	memory     *memory.MemoryService
+	mediaRoots []string
+}
+
+// NewAnalyzeImageTool creates the image understanding tool. Only files inside
+// one of mediaRoots can be analyzed; memory may be nil to skip indexing.
+func NewAnalyzeImageTool(vision provider.VisionProvider, mem *memory.MemoryService, mediaRoots ...string) *AnalyzeImageTool {
+	roots := make([]string, 0, len(mediaRoots))
+	for _, r := range mediaRoots {
+		if strings.TrimSpace(r) != "" {
+			roots = append(roots, expandPath(r))
+		}
+	}
+	return &AnalyzeImageTool{vision: vision, memory: mem, mediaRoots: roots}
+}
+
+// DefaultMediaDirs returns the directories channels store inbound media in.
+func DefaultMediaDirs(workspace string) []string {
+	dirs := []string{"~/.kafclaw/workspace/media"}
+	if strings.TrimSpace(workspace) != "" {
+		dirs = append(dirs, filepath.Join(workspace, "media"))
+	}
+	return dirs
+}
+
+func (t *AnalyzeImageTool) Name() string { return "analyze_image" }
+func (t *AnalyzeImageTool) Description() string {
+	return "Describe an image the user sent (screenshot, photo, scan) and extract any visible text. Pass the attachment path from the message."
+}
+func (t *AnalyzeImageTool) Tier() int { return TierReadOnly }
+
+func (t *AnalyzeImageTool) Parameters() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"description": "Path of the received image file",
+			},
+			"question": map[string]any{
+				"type":        "string",
+				"description": "Optional question or focus for the analysis (default: describe and transcribe text)",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+func (t *AnalyzeImageTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	path := strings.TrimSpace(GetString(params, "path", ""))
+	question := GetString(params, "question", "")
+	if path == "" {
+		return "Error: path is required", nil
+	}
+	path = expandPath(path)
+	if !t.allowed(path) {
+		return fmt.Sprintf("Error: %s is not a received media file", path), nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Sprintf("Error: image not found: %s", path), nil
+	}
+
+	resp, err := t.vision.DescribeImage(ctx, &provider.VisionRequest{ImagePath: path, Prompt: question})
+	if err != nil {
+		return fmt.Sprintf("Error analyzing image: %v", err), nil
+	}
+	text := strings.TrimSpace(resp.Text)
+	if text == "" {
+		return "The vision model returned no description.", nil
+	}
+
+	if t.memory != nil {
+		content := fmt.Sprintf("Image %s: %s", filepath.Base(path), text)
+		if _, err := t.memory.Store(ctx, content, "image:"+path, "image,vision"); err != nil {
+			return fmt.Sprintf("%s\n\n(Not indexed into memory: %v)", text, err), nil
+		}
+	}
+	return text, nil
+}
+
+func (t *AnalyzeImageTool) allowed(path string) bool {
+	for _, root := range t.mediaRoots {
+		if isWithin(root, path) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KafClaw/KafClaw/internal/provider"
+)
+
+type fakeVision struct {
+	lastPath string
+}
+
+func (f *fakeVision) DescribeImage(ctx context.Context, req *provider.VisionRequest) (*provider.VisionResponse, error) {
+	f.lastPath = req.ImagePath
+	return &provider.VisionResponse{Text: "A login error dialog reading: Invalid token"}, nil
+}
+
+func TestAnalyzeImageToolIndexesIntoMemory(t *testing.T) {
+	mediaDir := t.TempDir()
+	img := filepath.Join(mediaDir, "images", "ABC.png")
+	_ = os.MkdirAll(filepath.Dir(img), 0o755)
+	if err := os.WriteFile(img, []byte("\x89PNG\r\n\x1a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	svc := setupMemoryService(t)
+	vision := &fakeVision{}
+	tool := NewAnalyzeImageTool(vision, svc, mediaDir)
+
+	out, _ := tool.Execute(context.Background(), map[string]any{"path": img})
+	if !strings.Contains(out, "Invalid token") || vision.lastPath != img {
+		t.Fatalf("unexpected result %q (path %q)", out, vision.lastPath)
+	}
+	chunks, err := svc.SearchBySource(context.Background(), "login error", "image:", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 1 || chunks[0].Source != "image:"+img {
+		t.Fatalf("expected indexed image chunk, got %+v", chunks)
+	}
+
+	outside := filepath.Join(t.TempDir(), "secret.png")
+	_ = os.WriteFile(outside, []byte("x"), 0o644)
+	out, _ = tool.Execute(context.Background(), map[string]any{"path": outside})
+	if !strings.Contains(out, "not a received media file") {
+		t.Fatalf("expected path outside media dirs to be rejected, got %q", out)
+	}
+}
@@ -0,0 +1,367 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SpanKind is the OTLP kind of a span.
+type SpanKind int
+
+// Span kinds used by KafClaw.
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+)
+
+// KafClawTraceIDKey is the span attribute carrying the KafClaw trace ID, the
+// trace_id of the timeline, so an APM trace links back to /api/v1/trace.
+const KafClawTraceIDKey = "kafclaw.trace_id"
+
+const (
+	exportBatchSize = 256
+	exportMaxQueue  = 4096
+)
+
+// ExportOptions configures the OTLP span exporter.
+type ExportOptions struct {
+	// Endpoint is the OTLP/HTTP base URL of a collector, e.g.
+	// http://localhost:4318. Spans are POSTed as JSON to <Endpoint>/v1/traces.
+	Endpoint string
+	// ServiceName is the service.name resource attribute.
+	ServiceName string
+	// Headers are sent with every export, e.g. an API key of a hosted backend.
+	Headers map[string]string
+	// FlushInterval defaults to 2s.
+	FlushInterval time.Duration
+	// Client defaults to a client with a 10s timeout.
+	Client *http.Client
+}
+
+// ParseHeaders parses "key=value,key2=value2", the format of
+// OTEL_EXPORTER_OTLP_HEADERS.
+func ParseHeaders(s string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("invalid header %q (want key=value)", strings.TrimSpace(pair))
+		}
+		out[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return out, nil
+}
+
+type exporter struct {
+	url     string
+	service string
+	headers map[string]string
+	client  *http.Client
+
+	mu      sync.Mutex
+	pending []*Span
+	dropped int
+	failing bool
+
+	wake chan struct{}
+	quit chan struct{}
+	done chan struct{}
+}
+
+var activeExporter atomic.Pointer[exporter]
+
+// StartExporter makes Start record spans and exports them to an OTLP
+// collector in the background. The returned stop function flushes pending
+// spans and turns recording off again.
+func StartExporter(opts ExportOptions) (stop func(context.Context) error, err error) {
+	endpoint := strings.TrimRight(strings.TrimSpace(opts.Endpoint), "/")
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		return nil, fmt.Errorf("otlp endpoint %q must be an http(s) URL", opts.Endpoint)
+	}
+	e := &exporter{
+		url:     endpoint + "/v1/traces",
+		service: strings.TrimSpace(opts.ServiceName),
+		headers: opts.Headers,
+		client:  opts.Client,
+		wake:    make(chan struct{}, 1),
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	if e.service == "" {
+		e.service = "kafclaw"
+	}
+	if e.client == nil {
+		e.client = &http.Client{Timeout: 10 * time.Second}
+	}
+	interval := opts.FlushInterval
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	if !activeExporter.CompareAndSwap(nil, e) {
+		return nil, errors.New("an otlp exporter is already running")
+	}
+	go e.run(interval)
+	return func(ctx context.Context) error {
+		activeExporter.CompareAndSwap(e, nil)
+		close(e.quit)
+		select {
+		case <-e.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		return e.flush(ctx)
+	}, nil
+}
+
+func (e *exporter) run(interval time.Duration) {
+	defer close(e.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.quit:
+			return
+		case <-ticker.C:
+		case <-e.wake:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		_ = e.flush(ctx)
+		cancel()
+	}
+}
+
+func (e *exporter) add(s *Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.pending) >= exportMaxQueue {
+		e.dropped++
+		return
+	}
+	e.pending = append(e.pending, s)
+	if len(e.pending) >= exportBatchSize {
+		select {
+		case e.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// flush exports the pending spans. A failing collector is logged once until
+// an export succeeds again; its spans are dropped.
+func (e *exporter) flush(ctx context.Context) error {
+	e.mu.Lock()
+	batch, dropped := e.pending, e.dropped
+	e.pending, e.dropped = nil, 0
+	e.mu.Unlock()
+	if dropped > 0 {
+		slog.Warn("OTLP span queue full, spans dropped", "dropped", dropped)
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+	err := e.post(ctx, batch)
+	e.mu.Lock()
+	wasFailing := e.failing
+	e.failing = err != nil
+	e.mu.Unlock()
+	if err != nil && !wasFailing {
+		slog.Warn("OTLP span export failed", "url", e.url, "spans", len(batch), "error", err)
+	} else if err == nil && wasFailing {
+		slog.Info("OTLP span export recovered", "url", e.url)
+	}
+	return err
+}
+
+func (e *exporter) post(ctx context.Context, batch []*Span) error {
+	spans := make([]map[string]any, 0, len(batch))
+	for _, s := range batch {
+		spans = append(spans, s.otlp())
+	}
+	body, err := json.Marshal(map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{
+				"attributes": []any{otlpAttr("service.name", e.service)},
+			},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]any{"name": "github.com/KafClaw/KafClaw"},
+				"spans": spans,
+			}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("collector answered %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// Span is an OpenTelemetry span. Without a running exporter Start returns a
+// nil *Span, on which every method is a no-op.
+type Span struct {
+	exp       *exporter
+	name      string
+	kind      SpanKind
+	traceID   string
+	spanID    string
+	parentID  string
+	kafclawID string
+	start     time.Time
+
+	mu    sync.Mutex
+	attrs []attr
+	end   time.Time
+	err   error
+	ended bool
+}
+
+type attr struct {
+	key   string
+	value any
+}
+
+type spanKey struct{}
+
+// Start starts a span as a child of the span in ctx. Without one it joins
+// the trace of ctx: the caller's span of a traceparent, or the W3C ID of the
+// KafClaw trace, which is also set as the kafclaw.trace_id attribute.
+func Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	e := activeExporter.Load()
+	if e == nil {
+		return ctx, nil
+	}
+	s := &Span{exp: e, name: name, kind: kind, spanID: NewSpanID(), start: time.Now()}
+	p, traced := FromContext(ctx)
+	parent, _ := ctx.Value(spanKey{}).(*Span)
+	switch {
+	case parent != nil && (!traced || TraceIDFor(p.TraceID) == parent.traceID):
+		s.traceID, s.parentID, s.kafclawID = parent.traceID, parent.spanID, parent.kafclawID
+	case traced:
+		s.traceID, s.parentID, s.kafclawID = TraceIDFor(p.TraceID), p.SpanID, p.TraceID
+	default:
+		var b [16]byte
+		_, _ = rand.Read(b[:])
+		b[15] |= 1
+		s.traceID = hex.EncodeToString(b[:])
+	}
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// Set sets an attribute of the span.
+func (s *Span) Set(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.attrs {
+		if s.attrs[i].key == key {
+			s.attrs[i].value = value
+			return
+		}
+	}
+	s.attrs = append(s.attrs, attr{key, value})
+}
+
+// End ends the span, with an error status when err is set, and queues it
+// for export. Only the first call counts.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended, s.end, s.err = true, time.Now(), err
+	s.mu.Unlock()
+	s.exp.add(s)
+}
+
+// Traceparent returns the traceparent of the span, for calls made within it.
+func (s *Span) Traceparent() string {
+	if s == nil {
+		return ""
+	}
+	return Parent{TraceID: s.traceID, SpanID: s.spanID, Flags: "01"}.String()
+}
+
+func (s *Span) otlp() map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attrs := make([]any, 0, len(s.attrs)+1)
+	if s.kafclawID != "" {
+		attrs = append(attrs, otlpAttr(KafClawTraceIDKey, s.kafclawID))
+	}
+	for _, a := range s.attrs {
+		attrs = append(attrs, otlpAttr(a.key, a.value))
+	}
+	out := map[string]any{
+		"traceId":           s.traceID,
+		"spanId":            s.spanID,
+		"name":              s.name,
+		"kind":              int(s.kind),
+		"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+		"endTimeUnixNano":   strconv.FormatInt(s.end.UnixNano(), 10),
+		"attributes":        attrs,
+	}
+	if s.parentID != "" {
+		out["parentSpanId"] = s.parentID
+	}
+	if s.err != nil {
+		out["status"] = map[string]any{"code": 2, "message": s.err.Error()}
+	}
+	return out
+}
+
+func otlpAttr(key string, value any) map[string]any {
+	var v map[string]any
+	switch x := value.(type) {
+	case string:
+		v = map[string]any{"stringValue": x}
+	case bool:
+		v = map[string]any{"boolValue": x}
+	case int:
+		v = map[string]any{"intValue": strconv.Itoa(x)}
+	case int64:
+		v = map[string]any{"intValue": strconv.FormatInt(x, 10)}
+	case float64:
+		v = map[string]any{"doubleValue": x}
+	default:
+		v = map[string]any{"stringValue": fmt.Sprint(x)}
+	}
+	return map[string]any{"key": key, "value": v}
+}
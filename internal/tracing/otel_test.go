@@ -0,0 +1,134 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStartWithoutExporterIsNoop(t *testing.T) {
+	ctx, span := Start(context.Background(), "noop", KindInternal)
+	if span != nil || ctx.Value(spanKey{}) != nil {
+		t.Fatalf("expected no span without an exporter, got %+v", span)
+	}
+	span.Set("k", "v")
+	span.End(nil)
+}
+
+func TestExporterSendsOTLPSpans(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		spans  []map[string]any
+		header string
+	)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			http.NotFound(w, r)
+			return
+		}
+		var body struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []map[string]any `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		header = r.Header.Get("X-Api-Key")
+		for _, rs := range body.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+		mu.Unlock()
+	}))
+	defer collector.Close()
+
+	stop, err := StartExporter(ExportOptions{Endpoint: collector.URL + "/", Headers: map[string]string{"X-Api-Key": "k"}, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := StartExporter(ExportOptions{Endpoint: collector.URL}); err == nil {
+		t.Fatal("expected a second exporter to be refused")
+	}
+
+	ctx, root := Start(WithTraceID(context.Background(), "trace-1"), "agent.process_message", KindServer)
+	_, child := Start(ctx, "execute_tool exec", KindInternal)
+	child.Set("gen_ai.tool.name", "exec")
+	child.End(errors.New("boom"))
+	root.End(nil)
+	root.End(errors.New("ignored"))
+	if err := stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(spans) != 2 || header != "k" {
+		t.Fatalf("expected two spans with the header, got %d (%q)", len(spans), header)
+	}
+	tool, proc := spans[0], spans[1]
+	if proc["traceId"] != TraceIDFor("trace-1") || tool["traceId"] != proc["traceId"] || tool["parentSpanId"] != proc["spanId"] {
+		t.Fatalf("expected the tool span under the process span, got %v / %v", tool, proc)
+	}
+	if proc["status"] != nil || tool["status"].(map[string]any)["message"] != "boom" {
+		t.Fatalf("unexpected status: %v / %v", proc["status"], tool["status"])
+	}
+	attrs := map[string]string{}
+	for _, a := range tool["attributes"].([]any) {
+		kv := a.(map[string]any)
+		attrs[kv["key"].(string)] = kv["value"].(map[string]any)["stringValue"].(string)
+	}
+	if attrs[KafClawTraceIDKey] != "trace-1" || attrs["gen_ai.tool.name"] != "exec" {
+		t.Fatalf("unexpected attributes %v", attrs)
+	}
+
+	if _, span := Start(ctx, "after stop", KindInternal); span != nil {
+		t.Fatal("expected no spans after stop")
+	}
+}
+
+func TestStartJoinsTraceparentAndInject(t *testing.T) {
+	stop, err := StartExporter(ExportOptions{Endpoint: "http://127.0.0.1:1", FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = stop(ctx)
+	}()
+
+	p, _ := Parse("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx, span := Start(WithParent(context.Background(), p), "deliver slack", KindClient)
+	if span.traceID != p.TraceID || span.parentID != p.SpanID {
+		t.Fatalf("expected the traceparent as parent, got %+v", span)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/", nil).WithContext(ctx)
+	Inject(req, p.TraceID)
+	if req.Header.Get(Header) != span.Traceparent() {
+		t.Fatalf("expected the span as parent of the call, got %q", req.Header.Get(Header))
+	}
+
+	// A different trace in ctx starts below its own caller, not the span.
+	_, other := Start(WithTraceID(ctx, "trace-2"), "other", KindInternal)
+	if other.traceID != TraceIDFor("trace-2") || other.parentID != "" {
+		t.Fatalf("expected a new trace root, got %+v", other)
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	h, err := ParseHeaders(" a=1 , b = x=y ,")
+	if err != nil || len(h) != 2 || h["a"] != "1" || h["b"] != "x=y" {
+		t.Fatalf("unexpected headers %v %v", h, err)
+	}
+	if _, err := ParseHeaders("novalue"); err == nil {
+		t.Fatal("expected an error for a pair without =")
+	}
+}
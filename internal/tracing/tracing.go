@@ -0,0 +1,148 @@
+// Package tracing maps W3C Trace Context (the traceparent header) onto
+// KafClaw trace IDs and propagates it on outbound HTTP calls, so traces of
+// the gateway, the channel bridge and LLM providers line up in an APM. With an
+// OTLP endpoint it also exports spans of the agent loop (see StartExporter).
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// Header is the W3C trace context request header.
+const Header = "traceparent"
+
+// Parent is a parsed traceparent: a 32-hex trace ID, the 16-hex ID of the
+// caller's span and the trace flags.
+type Parent struct {
+	TraceID string
+	SpanID  string
+	Flags   string
+}
+
+// Parse parses a traceparent header value. Unknown future versions are
+// accepted as long as the version 00 fields parse.
+func Parse(header string) (Parent, bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 {
+		return Parent{}, false
+	}
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	if !isHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return Parent{}, false
+	}
+	if !isHex(traceID, 32) || isZero(traceID) || !isHex(spanID, 16) || isZero(spanID) || !isHex(flags, 2) {
+		return Parent{}, false
+	}
+	return Parent{TraceID: traceID, SpanID: spanID, Flags: flags}, true
+}
+
+// String formats p as a version 00 traceparent.
+func (p Parent) String() string {
+	flags := p.Flags
+	if flags == "" {
+		flags = "01"
+	}
+	return "00-" + p.TraceID + "-" + p.SpanID + "-" + flags
+}
+
+// TraceIDFor returns the W3C trace ID of a KafClaw trace ID. Trace IDs taken
+// from an incoming traceparent are already W3C IDs; others (e.g. "trace-…")
+// are hashed, so one trace always maps to the same W3C ID.
+func TraceIDFor(traceID string) string {
+	traceID = strings.TrimSpace(traceID)
+	if traceID == "" {
+		return ""
+	}
+	if id := strings.ToLower(traceID); isHex(id, 32) && !isZero(id) {
+		return id
+	}
+	sum := sha256.Sum256([]byte(traceID))
+	return hex.EncodeToString(sum[:16])
+}
+
+// NewSpanID returns a random 16-hex span ID.
+func NewSpanID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	if b == [8]byte{} {
+		b[7] = 1
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// Child returns the traceparent of an outbound call made for traceID, with a
+// fresh span ID. It is empty when traceID is empty.
+func Child(traceID string) string {
+	id := TraceIDFor(traceID)
+	if id == "" {
+		return ""
+	}
+	return Parent{TraceID: id, SpanID: NewSpanID(), Flags: "01"}.String()
+}
+
+// Inject sets the traceparent header of req for traceID, unless the request
+// already carries one. A span of that trace in the request context becomes
+// the parent of the call.
+func Inject(req *http.Request, traceID string) {
+	if req == nil || req.Header.Get(Header) != "" {
+		return
+	}
+	if s, _ := req.Context().Value(spanKey{}).(*Span); s != nil && s.traceID == TraceIDFor(traceID) {
+		req.Header.Set(Header, s.Traceparent())
+		return
+	}
+	if tp := Child(traceID); tp != "" {
+		req.Header.Set(Header, tp)
+	}
+}
+
+type contextKey struct{}
+
+// WithParent returns ctx carrying p, e.g. the traceparent of an API request.
+func WithParent(ctx context.Context, p Parent) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// WithTraceID returns ctx carrying the KafClaw trace ID of the work it runs.
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	if strings.TrimSpace(traceID) == "" {
+		return ctx
+	}
+	return WithParent(ctx, Parent{TraceID: traceID})
+}
+
+// FromContext returns the trace carried by ctx.
+func FromContext(ctx context.Context) (Parent, bool) {
+	if ctx == nil {
+		return Parent{}, false
+	}
+	p, ok := ctx.Value(contextKey{}).(Parent)
+	return p, ok && p.TraceID != ""
+}
+
+// TraceID returns the trace ID carried by ctx, or "".
+func TraceID(ctx context.Context) string {
+	p, _ := FromContext(ctx)
+	return p.TraceID
+}
+
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func isZero(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
@@ -0,0 +1,75 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	p, ok := Parse("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if !ok || p.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || p.SpanID != "00f067aa0ba902b7" || p.Flags != "01" {
+		t.Fatalf("unexpected parse result %+v ok=%v", p, ok)
+	}
+	if p.String() != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Fatalf("unexpected round trip %q", p.String())
+	}
+	if _, ok := Parse("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"); !ok {
+		t.Fatal("expected future version with extra fields to parse")
+	}
+	for _, bad := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+	} {
+		if _, ok := Parse(bad); ok {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestTraceIDFor(t *testing.T) {
+	w3c := "4bf92f3577b34da6a3ce929d0e0e4736"
+	if got := TraceIDFor(w3c); got != w3c {
+		t.Fatalf("expected W3C trace ID unchanged, got %q", got)
+	}
+	a, b := TraceIDFor("trace-123"), TraceIDFor("trace-123")
+	if a != b || len(a) != 32 {
+		t.Fatalf("expected stable 32-hex ID, got %q / %q", a, b)
+	}
+	if TraceIDFor("") != "" || Child("") != "" {
+		t.Fatal("expected no trace for an empty trace ID")
+	}
+}
+
+func TestInject(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "http://example.invalid", nil)
+	Inject(req, "4bf92f3577b34da6a3ce929d0e0e4736")
+	p, ok := Parse(req.Header.Get(Header))
+	if !ok || p.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || p.Flags != "01" {
+		t.Fatalf("unexpected injected header %q", req.Header.Get(Header))
+	}
+	Inject(req, "trace-other")
+	if !strings.Contains(req.Header.Get(Header), "4bf92f3577b34da6a3ce929d0e0e4736") {
+		t.Fatal("expected an existing traceparent to be kept")
+	}
+}
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	if TraceID(ctx) != "" {
+		t.Fatal("expected no trace in a bare context")
+	}
+	ctx = WithTraceID(ctx, "trace-1")
+	if TraceID(ctx) != "trace-1" {
+		t.Fatalf("expected trace-1, got %q", TraceID(ctx))
+	}
+	if TraceID(WithTraceID(ctx, " ")) != "trace-1" {
+		t.Fatal("expected an empty trace ID to keep the context")
+	}
+}
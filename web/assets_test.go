@@ -9,6 +9,8 @@ func TestEmbeddedWebAssetsIncludeDashboardPages(t *testing.T) {
 		"timeline.html",
 		"group.html",
 		"approvals.html",
+		"bridges.html",
+		"status.html",
 		"templates/kshark_report.html",
 	}
 	for _, name := range required {